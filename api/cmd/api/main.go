@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"regexp"
 
@@ -12,14 +13,35 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/mooncorn/gshub/api/config"
 	"github.com/mooncorn/gshub/api/internal/api"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
 	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/services/abuse"
+	"github.com/mooncorn/gshub/api/internal/services/backupreplica"
 	"github.com/mooncorn/gshub/api/internal/services/broadcast"
+	"github.com/mooncorn/gshub/api/internal/services/cardexpiry"
 	"github.com/mooncorn/gshub/api/internal/services/cleanup"
+	"github.com/mooncorn/gshub/api/internal/services/commandwake"
+	"github.com/mooncorn/gshub/api/internal/services/digest"
+	"github.com/mooncorn/gshub/api/internal/services/egress"
+	"github.com/mooncorn/gshub/api/internal/services/email"
 	"github.com/mooncorn/gshub/api/internal/services/k8s"
 	"github.com/mooncorn/gshub/api/internal/services/nodesync"
+	"github.com/mooncorn/gshub/api/internal/services/objectstore"
 	"github.com/mooncorn/gshub/api/internal/services/podmonitor"
 	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+	"github.com/mooncorn/gshub/api/internal/services/recommendation"
 	"github.com/mooncorn/gshub/api/internal/services/reconciler"
+	"github.com/mooncorn/gshub/api/internal/services/reminder"
+	"github.com/mooncorn/gshub/api/internal/services/scheduler"
+	"github.com/mooncorn/gshub/api/internal/services/serverclone"
+	"github.com/mooncorn/gshub/api/internal/services/serversession"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"github.com/mooncorn/gshub/api/internal/services/spending"
+	"github.com/mooncorn/gshub/api/internal/services/statusingest"
+	"github.com/mooncorn/gshub/api/internal/services/suspension"
+	"github.com/mooncorn/gshub/api/internal/services/volumebackup"
+	"github.com/mooncorn/gshub/api/internal/services/waitlist"
+	"github.com/mooncorn/gshub/api/internal/services/webhook"
 	"go.uber.org/zap"
 )
 
@@ -31,6 +53,7 @@ func main() {
 	// Register custom validators
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("dns", validateDNS)
+		v.RegisterTagNameFunc(apierror.JSONFieldName)
 	}
 
 	// Load config
@@ -59,6 +82,9 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to initialize K8s client:", err)
 	}
+	if cfg.K8sServerServiceAccount != "" {
+		k8sClient.ScopeServerNamespaces(cfg.K8sNamespace, cfg.K8sServerServiceAccount)
+	}
 
 	log.Println("Kubernetes client initialized successfully")
 
@@ -77,28 +103,75 @@ func main() {
 	defer logger.Sync()
 
 	// Initialize port allocation service
-	portAllocService := portalloc.NewService(database, logger)
+	var edgePorts portalloc.EdgePortRange
+	if cfg.EdgeProxyEnabled() {
+		edgePorts = portalloc.EdgePortRange{Min: cfg.EdgePortRangeMin, Max: cfg.EdgePortRangeMax}
+	}
+	portAllocService := portalloc.NewService(database, k8sClient, edgePorts, cfg.PortReuseCooldown, logger)
 	log.Println("Port allocation service initialized")
 
 	// Initialize broadcast hub for real-time SSE updates
-	hub := broadcast.NewHub(logger)
+	hub := broadcast.NewHub(logger, cfg.SSESlowClientTimeout, broadcast.Limits{
+		PerUser: cfg.SSEMaxStreamsPerUser,
+		Total:   cfg.SSEMaxStreams,
+	})
 	log.Println("Broadcast hub initialized")
 
-	// Initialize and start node sync service
+	// Status transitions go through the state machine; observed status changes
+	// (supervisor, pod monitor, heartbeats) are arbitrated by the ingestor first
+	stateMachine := serverstate.NewMachine(database, hub, portAllocService)
+	stateMachine.OnTransition(func(ctx context.Context, change serverstate.Change) {
+		logger.Info("server status changed",
+			zap.String("server_id", change.Server.ID.String()),
+			zap.String("from", string(change.From)),
+			zap.String("to", string(change.To)),
+			zap.String("reason", string(change.Reason)))
+	})
+	statusIngestor := statusingest.NewIngestor(database, stateMachine, logger)
+
+	// Initialize and start the webhook service, which notifies user webhooks of status
+	// changes, crashes and backups
+	webhookService := webhook.NewService(database, webhook.DefaultConfig(), logger)
+	stateMachine.OnTransition(webhookService.OnTransition)
+	webhookService.Start(ctx)
+	defer webhookService.Stop()
+
+	log.Println("Webhook service started")
+
+	// Crashes are counted for the weekly digest
+	stateMachine.OnTransition(digest.CrashCounter(database, logger))
+
+	// Online sessions are recorded for uptime and billing
+	stateMachine.OnTransition(serversession.Hook(database, logger))
+
+	// Expired servers free capacity for the waitlist
+	waitlistService := waitlist.NewService(database, k8sClient, portAllocService, email.NewService(cfg), cfg, waitlist.DefaultConfig(), logger)
+	stateMachine.OnTransition(waitlistService.CapacityFreed)
+
+	// Initialize and start node sync service, which moves servers off reclaimed spot nodes
+	// through the ingestor (after the webhook hook is registered, so moves are notified)
 	nodeSyncConfig := nodesync.Config{
-		PortRangeMin:  cfg.PortRangeMin,
-		PortRangeMax:  cfg.PortRangeMax,
-		SyncInterval:  nodesync.DefaultConfig().SyncInterval,
-		NodeRoleLabel: nodesync.DefaultConfig().NodeRoleLabel,
-		PublicIPLabel: nodesync.DefaultConfig().PublicIPLabel,
+		PortRangeMin:         cfg.PortRangeMin,
+		PortRangeMax:         cfg.PortRangeMax,
+		SyncInterval:         nodesync.DefaultConfig().SyncInterval,
+		NodeRoleLabel:        nodesync.DefaultConfig().NodeRoleLabel,
+		PublicIPLabel:        nodesync.DefaultConfig().PublicIPLabel,
+		DedicatedTaintKey:    nodesync.DefaultConfig().DedicatedTaintKey,
+		SpotLabels:           nodesync.DefaultConfig().SpotLabels,
+		ReclaimTaintKeys:     nodesync.DefaultConfig().ReclaimTaintKeys,
+		ReclaimCheckInterval: nodesync.DefaultConfig().ReclaimCheckInterval,
+		ZoneLabel:            nodesync.DefaultConfig().ZoneLabel,
+		RegionLabel:          nodesync.DefaultConfig().RegionLabel,
+		ProviderLabel:        nodesync.DefaultConfig().ProviderLabel,
+		DatacenterLabel:      nodesync.DefaultConfig().DatacenterLabel,
 	}
-	nodeSyncService := nodesync.NewService(database, k8sClient, nodeSyncConfig, logger)
+	nodeSyncService := nodesync.NewService(database, k8sClient, hub, statusIngestor, nodeSyncConfig, logger)
 	nodeSyncService.Start(ctx)
 	defer nodeSyncService.Stop()
 	log.Println("Node sync service started")
 
 	// Initialize and start the server reconciler
-	serverReconciler := reconciler.NewServerReconciler(database, k8sClient, portAllocService, logger, cfg.K8sNamespace, cfg.K8sGameCatalogName)
+	serverReconciler := reconciler.NewServerReconciler(database, k8sClient, portAllocService, stateMachine, statusIngestor, logger, cfg.K8sNamespace, cfg.ServerNamespaces, cfg.GameCatalogName, cfg.SupervisorGRPC)
 	serverReconciler.Start(ctx)
 	defer serverReconciler.Stop()
 
@@ -106,31 +179,144 @@ func main() {
 
 	// Initialize and start the cleanup service
 	cleanupConfig := cleanup.Config{
-		Interval:  cleanup.DefaultConfig().Interval,
-		Namespace: cfg.K8sNamespace,
+		Interval:             cleanup.DefaultConfig().Interval,
+		Namespace:            cfg.K8sNamespace,
+		DeletionWarningLead:  cleanup.DefaultConfig().DeletionWarningLead,
+		MetricsRetention:     cleanup.DefaultConfig().MetricsRetention,
+		PortHistoryRetention: cleanup.DefaultConfig().PortHistoryRetention,
 	}
-	cleanupService := cleanup.NewService(database, k8sClient, cleanupConfig, logger)
+	cleanupService := cleanup.NewService(database, k8sClient, stateMachine, email.NewService(cfg), cleanupConfig, logger)
 	cleanupService.Start(ctx)
 	defer cleanupService.Stop()
 
 	log.Println("Cleanup service started")
 
 	// Initialize and start the pod monitor service
-	podMonitorService := podmonitor.NewPodMonitor(database, k8sClient, hub, logger, cfg.K8sNamespace)
+	podMonitorService := podmonitor.NewPodMonitor(database, k8sClient, statusIngestor, logger, cfg.K8sNamespace)
 	podMonitorService.Start(ctx)
 	defer podMonitorService.Stop()
 
 	log.Println("Pod monitor service started")
 
-	handlers := api.NewHandlers(database, cfg, k8sClient, portAllocService, hub)
+	handlers := api.NewHandlers(database, cfg, k8sClient, portAllocService, stateMachine, hub, nodeSyncService)
 	r := gin.Default()
 	handlers.RegisterRoutes(r)
 
+	// The node agent registers new machines through the public API, and their nodes are
+	// synced as soon as it reports them healthy
+	api.NewNodeAgentHandler(database, nodeSyncService).RegisterRoutes(r)
+
+	// Initialize and start the stopped-server reminder service
+	if cfg.StoppedReminderAfter > 0 {
+		reminderConfig := reminder.Config{
+			Interval:   reminder.DefaultConfig().Interval,
+			StoppedFor: cfg.StoppedReminderAfter,
+		}
+		reminderService := reminder.NewService(database, cfg, handlers.StripeService, email.NewService(cfg), reminderConfig, logger)
+		reminderService.Start(ctx)
+		defer reminderService.Stop()
+
+		log.Println("Reminder service started")
+	}
+
+	// Initialize and start the spending service
+	spendingService := spending.NewService(database, handlers.StripeService, email.NewService(cfg), spending.DefaultConfig(), logger)
+	spendingService.Start(ctx)
+	defer spendingService.Stop()
+
+	log.Println("Spending service started")
+
+	// Initialize and start the card expiry service, which warns about cards expiring before a renewal
+	cardExpiryService := cardexpiry.NewService(database, handlers.StripeService, email.NewService(cfg), cardexpiry.DefaultConfig(), logger)
+	cardExpiryService.Start(ctx)
+	defer cardExpiryService.Stop()
+
+	log.Println("Card expiry service started")
+
+	// Initialize and start the egress service (usage is recorded from supervisor heartbeats)
+	egressService := egress.NewService(database, k8sClient, cfg, email.NewService(cfg), egress.DefaultConfig(), logger)
+	egressService.Start(ctx)
+	defer egressService.Stop()
+
+	log.Println("Egress service started")
+
+	// Initialize and start the recommendation service (usage is recorded from supervisor heartbeats)
+	recommendationService := recommendation.NewService(database, k8sClient, cfg, handlers.StripeService, email.NewService(cfg), recommendation.DefaultConfig(), logger)
+	recommendationService.Start(ctx)
+	defer recommendationService.Stop()
+
+	log.Println("Recommendation service started")
+
+	// Initialize and start the digest service, which emails owners a weekly summary of their servers
+	digestService := digest.NewService(database, handlers.StripeService, email.NewService(cfg), digest.DefaultConfig(), logger)
+	digestService.Start(ctx)
+	defer digestService.Stop()
+
+	log.Println("Digest service started")
+
+	// Start the scheduler service, which runs the restarts, console commands and backups
+	// users schedule on their servers
+	schedulerService := scheduler.NewService(database, stateMachine, handlers.ServerHandler.BackupCommandPayload, scheduler.DefaultConfig(), logger)
+	schedulerService.Start(ctx)
+	defer schedulerService.Stop()
+
+	log.Println("Scheduler service started")
+
+	// Start the waitlist service, which offers users capacity they waited for once it frees up
+	waitlistService.Start(ctx)
+	defer waitlistService.Stop()
+
+	log.Println("Waitlist service started")
+
+	// Start the clone service, which copies cloned servers' data in the supervisor image
+	if cfg.FileAccessEnabled() {
+		cloneService := serverclone.NewService(database, k8sClient, stateMachine, cfg, serverclone.DefaultConfig(), logger)
+		cloneService.Start(ctx)
+		defer cloneService.Stop()
+
+		log.Println("Clone service started")
+	}
+
+	// Initialize and start the backup replication and volume backup services, if an off-site
+	// bucket is configured
+	if cfg.BackupReplicationEnabled() {
+		replicaStore := objectstore.NewClient(objectstore.Config{
+			Endpoint:        cfg.BackupReplicaEndpoint,
+			Region:          cfg.BackupReplicaRegion,
+			Bucket:          cfg.BackupReplicaBucket,
+			AccessKeyID:     cfg.BackupReplicaAccessKeyID,
+			SecretAccessKey: cfg.BackupReplicaSecretAccessKey,
+		})
+		backupReplicaService := backupreplica.NewService(database, k8sClient, replicaStore, cfg, backupreplica.DefaultConfig(), logger)
+		backupReplicaService.Start(ctx)
+		defer backupReplicaService.Stop()
+
+		log.Println("Backup replication service started")
+
+		// Volume backups are stored in the same bucket
+		volumeBackupService := volumebackup.NewService(database, k8sClient, replicaStore, stateMachine, cfg, volumebackup.DefaultConfig(), logger)
+		volumeBackupService.Start(ctx)
+		defer volumeBackupService.Stop()
+
+		log.Println("Volume backup service started")
+	}
+
+	// Abuse detection runs on supervisor heartbeats and banned binary reports
+	suspensionService := suspension.NewService(database, k8sClient, portAllocService, cfg.K8sNamespace)
+	abuseService := abuse.NewService(database, suspensionService, handlers.AccountService, email.NewService(cfg), hub, cfg, logger)
+
+	// Held command polls are woken when a command is queued for their server
+	commandWakeService := commandwake.NewService(database, logger)
+	commandWakeService.Start(ctx)
+	defer commandWakeService.Stop()
+
 	// Start internal API server for supervisor communication
-	internalHandler := api.NewInternalHandler(database, hub, logger)
+	internalHandler := api.NewInternalHandler(database, hub, abuseService, statusIngestor, webhookService, commandWakeService, cfg, logger)
 	internalRouter := gin.New()
 	internalRouter.Use(gin.Recovery())
 	internalHandler.RegisterInternalRoutes(internalRouter)
+	handlers.DiscordHandler.RegisterInternalRoutes(internalRouter)
+	handlers.EdgeHandler.RegisterInternalRoutes(internalRouter)
 
 	go func() {
 		internalPort := "8081"
@@ -144,6 +330,20 @@ func main() {
 		}
 	}()
 
+	// The supervisor protocol over gRPC, beside the JSON endpoints supervisors fall back to
+	go func() {
+		grpcPort := "8082"
+		log.Printf("Starting supervisor gRPC server on :%s", grpcPort)
+		listener, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			log.Printf("Supervisor gRPC server error: %v", err)
+			return
+		}
+		if err := internalHandler.NewGRPCServer().Serve(listener); err != nil {
+			log.Printf("Supervisor gRPC server error: %v", err)
+		}
+	}()
+
 	// Start server
 	log.Printf("Starting server on :%s", cfg.Port)
 	if err := r.Run(":" + cfg.Port); err != nil {
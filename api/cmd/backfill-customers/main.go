@@ -0,0 +1,76 @@
+// Command backfill-customers records a Stripe customer for existing users that have none.
+// Checkouts used to create a new customer per session, so a user may have several with
+// their email; the oldest is adopted and the duplicates are reported. Users that never
+// paid are skipped and get a customer at their first checkout.
+//
+// Usage:
+//
+//	go run ./cmd/backfill-customers -dry-run # report what would be recorded
+//	go run ./cmd/backfill-customers          # record customers
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/services/stripe"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report matches without recording them")
+	flag.Parse()
+
+	_ = godotenv.Load()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	stripeService := stripe.NewService(db, cfg, nil, nil, nil, nil, cfg.K8sNamespace)
+
+	users, err := db.ListUsersWithoutStripeCustomer(ctx)
+	if err != nil {
+		log.Fatal("Failed to list users:", err)
+	}
+
+	var matched, skipped, failed int
+	for i := range users {
+		user := &users[i]
+
+		customerID, err := stripeService.FindCustomer(ctx, user)
+		if err != nil {
+			log.Printf("user_id=%s: %v", user.ID, err)
+			failed++
+			continue
+		}
+		if customerID == "" {
+			skipped++
+			continue
+		}
+
+		if !*dryRun {
+			if _, err := db.SetUserStripeCustomerID(ctx, user.ID, customerID); err != nil {
+				log.Printf("user_id=%s: %v", user.ID, err)
+				failed++
+				continue
+			}
+		}
+		log.Printf("user_id=%s email=%s customer_id=%s", user.ID, user.Email, customerID)
+		matched++
+	}
+
+	log.Printf("Backfill complete: users=%d matched=%d skipped=%d failed=%d dry_run=%t",
+		len(users), matched, skipped, failed, *dryRun)
+}
@@ -0,0 +1,79 @@
+// Command catalog-lint checks game catalogs for problems that would break servers: missing
+// images, invalid resource quantities, conflicting ports and unknown health check types.
+// It accepts the game-catalog ConfigMap manifest or a bare games.yaml, and exits non-zero
+// if any file has issues.
+//
+// Usage:
+//
+//	go run ./cmd/catalog-lint ../k8s/base/gshub/game-catalog.yaml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"gopkg.in/yaml.v3"
+)
+
+// configMap holds the part of a ConfigMap manifest the catalog lives in
+type configMap struct {
+	Kind string            `yaml:"kind"`
+	Data map[string]string `yaml:"data"`
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: catalog-lint FILE...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, file := range flag.Args() {
+		issues, err := lintFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed = true
+			continue
+		}
+		for _, issue := range issues {
+			fmt.Printf("%s: %s\n", file, issue)
+		}
+		if len(issues) > 0 {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// lintFile validates the catalog in file
+func lintFile(file string) ([]k8s.CatalogIssue, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest configMap
+	if err := yaml.Unmarshal(data, &manifest); err == nil && manifest.Kind == "ConfigMap" {
+		catalogYAML, ok := manifest.Data["games.yaml"]
+		if !ok {
+			return nil, fmt.Errorf("games.yaml not found in ConfigMap")
+		}
+		data = []byte(catalogYAML)
+	}
+
+	catalog, err := k8s.ParseGameCatalog(data)
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Validate(), nil
+}
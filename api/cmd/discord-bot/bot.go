@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// commandTimeout bounds a command's API calls and follow-up; Discord keeps the
+	// interaction token valid for 15 minutes
+	commandTimeout = 30 * time.Second
+
+	// maxInteractionBody caps the interaction payloads read
+	maxInteractionBody = 1 << 20
+
+	notLinkedReply = "Your Discord account isn't linked to GSHUB yet. Create a link code in your " +
+		"account settings on the dashboard, then run `/link code:<code>`."
+)
+
+// statusEmoji marks server statuses in replies
+var statusEmoji = map[string]string{
+	"running":  "🟢",
+	"starting": "🟡",
+	"pending":  "🟡",
+	"stopping": "🟠",
+	"stopped":  "⚫",
+	"failed":   "🔴",
+}
+
+type bot struct {
+	cfg     botConfig
+	gshub   *gshubClient
+	discord *http.Client
+	wg      sync.WaitGroup
+}
+
+func newBot(cfg botConfig) *bot {
+	return &bot{
+		cfg:     cfg,
+		gshub:   newGSHubClient(cfg),
+		discord: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// handleInteraction verifies an interaction and acknowledges it right away with a deferred,
+// ephemeral reply, since Discord requires an answer within 3 seconds. The command runs in
+// the background and edits the reply once done.
+func (b *bot) handleInteraction(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxInteractionBody))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if !verifyInteraction(b.cfg.PublicKey, r, body) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var in interaction
+	if err := json.Unmarshal(body, &in); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	switch in.Type {
+	case interactionPing:
+		writeJSON(w, interactionResponse{Type: responsePong})
+	case interactionApplicationCommand:
+		writeJSON(w, interactionResponse{
+			Type: responseDeferredChannelMessage,
+			Data: &interactionCallback{Flags: messageFlagEphemeral},
+		})
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			b.runCommand(in)
+		}()
+	default:
+		http.Error(w, "unsupported interaction type", http.StatusBadRequest)
+	}
+}
+
+// runCommand runs a slash command and posts its reply
+func (b *bot) runCommand(in interaction) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	user := in.invoker()
+	var reply string
+	switch in.Data.Name {
+	case "link":
+		reply = b.link(ctx, user, stringOption(in.Data.Options, "code"))
+	case "server":
+		reply = b.server(ctx, user, in.Data.Options)
+	default:
+		reply = "Unknown command."
+	}
+
+	if err := editOriginalResponse(ctx, b.discord, b.cfg.ApplicationID, in.Token, reply); err != nil {
+		log.Printf("failed to reply to /%s from %s: %v", in.Data.Name, user.ID, err)
+	}
+}
+
+// wait blocks until running commands finish or ctx is done
+func (b *bot) wait(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+func (b *bot) link(ctx context.Context, user discordUser, code string) string {
+	err := b.gshub.link(ctx, user, code)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Code == "DISCORD_LINK_CODE_INVALID" {
+		return "That link code is invalid or has expired. Create a new one on the dashboard."
+	}
+	if err != nil {
+		log.Printf("failed to link discord user %s: %v", user.ID, err)
+		return "Couldn't link your account, please try again later."
+	}
+	return "Your Discord account is now linked to GSHUB. Try `/server status`."
+}
+
+func (b *bot) server(ctx context.Context, user discordUser, options []interactionOption) string {
+	if len(options) != 1 || options[0].Type != optionSubcommand {
+		return "Unknown command."
+	}
+	subcommand := options[0].Name
+	query := stringOption(options[0].Options, "server")
+
+	token, err := b.gshub.userToken(ctx, user.ID)
+	if errors.Is(err, errNotLinked) {
+		return notLinkedReply
+	}
+	if err != nil {
+		log.Printf("failed to get token for discord user %s: %v", user.ID, err)
+		return "Couldn't reach GSHUB, please try again later."
+	}
+
+	servers, err := b.gshub.listServers(ctx, token)
+	if err != nil {
+		log.Printf("failed to list servers for discord user %s: %v", user.ID, err)
+		return "Couldn't reach GSHUB, please try again later."
+	}
+
+	if subcommand == "status" && query == "" {
+		if len(servers) == 0 {
+			return "You don't have any servers yet."
+		}
+		lines := make([]string, 0, len(servers))
+		for _, s := range servers {
+			lines = append(lines, formatServer(s))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	target := findServer(servers, query)
+	if target == nil {
+		return fmt.Sprintf("No server named `%s` found.", query)
+	}
+
+	switch subcommand {
+	case "status":
+		return formatServer(*target)
+	case "start", "stop":
+		if err := b.gshub.serverAction(ctx, token, target.ID, subcommand); err != nil {
+			var apiErr *apiError
+			if errors.As(err, &apiErr) {
+				return fmt.Sprintf("Couldn't %s **%s**: %s", subcommand, target.DisplayName, apiErr.Message)
+			}
+			log.Printf("failed to %s server %s: %v", subcommand, target.ID, err)
+			return "Couldn't reach GSHUB, please try again later."
+		}
+		if subcommand == "start" {
+			return fmt.Sprintf("Starting **%s**…", target.DisplayName)
+		}
+		return fmt.Sprintf("Stopping **%s**…", target.DisplayName)
+	default:
+		return "Unknown command."
+	}
+}
+
+// findServer matches a server by subdomain, display name or ID, ignoring case
+func findServer(servers []server, query string) *server {
+	query = strings.TrimSpace(query)
+	for i, s := range servers {
+		if strings.EqualFold(s.Subdomain, query) || strings.EqualFold(s.DisplayName, query) || s.ID == query {
+			return &servers[i]
+		}
+	}
+	return nil
+}
+
+func formatServer(s server) string {
+	emoji := statusEmoji[s.Status]
+	if emoji == "" {
+		emoji = "⚪"
+	}
+	return fmt.Sprintf("%s **%s** (`%s`, %s): %s", emoji, s.DisplayName, s.Subdomain, s.Game, s.Status)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write interaction response: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const discordAPIURL = "https://discord.com/api/v10"
+
+// Discord API constants used by the bot
+// (https://discord.com/developers/docs/interactions/receiving-and-responding)
+const (
+	interactionPing               = 1
+	interactionApplicationCommand = 2
+
+	responsePong                   = 1
+	responseDeferredChannelMessage = 5
+	messageFlagEphemeral           = 1 << 6
+
+	commandTypeChatInput = 1
+	optionSubcommand     = 1
+	optionString         = 3
+
+	// Commands work in servers and in DMs with the bot, installed to a guild or a user
+	contextGuild     = 0
+	contextBotDM     = 1
+	integrationGuild = 0
+	integrationUser  = 1
+)
+
+type discordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type interactionOption struct {
+	Name    string              `json:"name"`
+	Type    int                 `json:"type"`
+	Value   json.RawMessage     `json:"value,omitempty"`
+	Options []interactionOption `json:"options,omitempty"`
+}
+
+// stringOption returns the value of the named string option, or "" if it wasn't given
+func stringOption(options []interactionOption, name string) string {
+	for _, o := range options {
+		if o.Name == name && o.Type == optionString {
+			var value string
+			_ = json.Unmarshal(o.Value, &value)
+			return value
+		}
+	}
+	return ""
+}
+
+type interaction struct {
+	Type  int    `json:"type"`
+	Token string `json:"token"`
+	Data  struct {
+		Name    string              `json:"name"`
+		Options []interactionOption `json:"options"`
+	} `json:"data"`
+	Member *struct {
+		User discordUser `json:"user"`
+	} `json:"member"` // Set in guilds
+	User *discordUser `json:"user"` // Set in DMs
+}
+
+// invoker returns the Discord user who ran the command
+func (i *interaction) invoker() discordUser {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	if i.User != nil {
+		return *i.User
+	}
+	return discordUser{}
+}
+
+type interactionResponse struct {
+	Type int                  `json:"type"`
+	Data *interactionCallback `json:"data,omitempty"`
+}
+
+type interactionCallback struct {
+	Content string `json:"content,omitempty"`
+	Flags   int    `json:"flags,omitempty"`
+}
+
+// verifyInteraction checks Discord's Ed25519 signature over the timestamp and raw body.
+// Discord rejects endpoints that accept unsigned requests.
+func verifyInteraction(publicKey ed25519.PublicKey, r *http.Request, body []byte) bool {
+	signature, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+	message := append([]byte(r.Header.Get("X-Signature-Timestamp")), body...)
+	return ed25519.Verify(publicKey, message, signature)
+}
+
+// editOriginalResponse replaces the deferred "thinking" message with the command's reply
+func editOriginalResponse(ctx context.Context, client *http.Client, applicationID, token, content string) error {
+	url := fmt.Sprintf("%s/webhooks/%s/%s/messages/@original", discordAPIURL, applicationID, token)
+	return discordRequest(ctx, client, http.MethodPatch, url, "", interactionCallback{Content: content})
+}
+
+// commandDefinitions are the slash commands registered with Discord
+func commandDefinitions() []map[string]any {
+	serverOption := func(required bool) map[string]any {
+		return map[string]any{
+			"type":        optionString,
+			"name":        "server",
+			"description": "Server name or subdomain",
+			"required":    required,
+		}
+	}
+	subcommand := func(name, description string, required bool) map[string]any {
+		return map[string]any{
+			"type":        optionSubcommand,
+			"name":        name,
+			"description": description,
+			"options":     []map[string]any{serverOption(required)},
+		}
+	}
+
+	contexts := []int{contextGuild, contextBotDM}
+	integrations := []int{integrationGuild, integrationUser}
+	return []map[string]any{
+		{
+			"type":              commandTypeChatInput,
+			"name":              "link",
+			"description":       "Link your GSHUB account",
+			"contexts":          contexts,
+			"integration_types": integrations,
+			"options": []map[string]any{{
+				"type":        optionString,
+				"name":        "code",
+				"description": "Link code from the GSHUB dashboard",
+				"required":    true,
+			}},
+		},
+		{
+			"type":              commandTypeChatInput,
+			"name":              "server",
+			"description":       "Manage your GSHUB servers",
+			"contexts":          contexts,
+			"integration_types": integrations,
+			"options": []map[string]any{
+				subcommand("status", "Show your servers' status", false),
+				subcommand("start", "Start a server", true),
+				subcommand("stop", "Stop a server", true),
+			},
+		},
+	}
+}
+
+// registerCommands overwrites the application's global slash commands
+func registerCommands(ctx context.Context, cfg botConfig) error {
+	url := fmt.Sprintf("%s/applications/%s/commands", discordAPIURL, cfg.ApplicationID)
+	return discordRequest(ctx, http.DefaultClient, http.MethodPut, url, cfg.BotToken, commandDefinitions())
+}
+
+// discordRequest sends a JSON request to the Discord API, authenticated as the bot when
+// botToken is set
+func discordRequest(ctx context.Context, client *http.Client, method, url, botToken string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if botToken != "" {
+		req.Header.Set("Authorization", "Bot "+botToken)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("discord responded %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errNotLinked is returned when the Discord user has no linked GSHUB account
+var errNotLinked = errors.New("discord account not linked")
+
+// apiError is the API's standard error envelope
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// server holds the fields of a GSHUB server the bot shows
+type server struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	Subdomain   string `json:"subdomain"`
+	Game        string `json:"game"`
+	Status      string `json:"status"`
+}
+
+// gshubClient calls the internal API as the bot and the public API as a linked user
+type gshubClient struct {
+	apiURL      string
+	internalURL string
+	botSecret   string
+	http        *http.Client
+}
+
+func newGSHubClient(cfg botConfig) *gshubClient {
+	return &gshubClient{
+		apiURL:      strings.TrimRight(cfg.APIURL, "/"),
+		internalURL: strings.TrimRight(cfg.InternalURL, "/"),
+		botSecret:   cfg.BotSecret,
+		http:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// link redeems a link code for the Discord user
+func (c *gshubClient) link(ctx context.Context, user discordUser, code string) error {
+	body := map[string]string{
+		"code":             code,
+		"discord_user_id":  user.ID,
+		"discord_username": user.Username,
+	}
+	return c.do(ctx, http.MethodPost, c.internalURL+"/internal/discord/link", c.botSecret, body, nil)
+}
+
+// userToken returns a scoped token for the GSHUB account linked to the Discord user
+func (c *gshubClient) userToken(ctx context.Context, discordUserID string) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	err := c.do(ctx, http.MethodPost, c.internalURL+"/internal/discord/token", c.botSecret,
+		map[string]string{"discord_user_id": discordUserID}, &resp)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Code == "DISCORD_NOT_LINKED" {
+		return "", errNotLinked
+	}
+	return resp.Token, err
+}
+
+// listServers returns the user's servers
+func (c *gshubClient) listServers(ctx context.Context, token string) ([]server, error) {
+	var resp struct {
+		Servers []server `json:"servers"`
+	}
+	err := c.do(ctx, http.MethodGet, c.apiURL+"/servers", token, nil, &resp)
+	return resp.Servers, err
+}
+
+// serverAction starts or stops a server
+func (c *gshubClient) serverAction(ctx context.Context, token, serverID, action string) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("%s/servers/%s/%s", c.apiURL, serverID, action), token, nil, nil)
+}
+
+func (c *gshubClient) do(ctx context.Context, method, url, token string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var envelope struct {
+			Error apiError `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil || envelope.Error.Message == "" {
+			return fmt.Errorf("GSHUB API responded %d", resp.StatusCode)
+		}
+		return &envelope.Error
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
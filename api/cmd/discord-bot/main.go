@@ -0,0 +1,127 @@
+// Command discord-bot serves the GSHUB Discord application's slash commands. Discord
+// POSTs interactions to it over HTTP; it acts on a user's servers with a short-lived
+// token the API issues for the GSHUB account linked to their Discord account.
+//
+// Commands:
+//
+//	/link code:<code>                link a GSHUB account with a code from the dashboard
+//	/server status [server:<name>]   show one or all of your servers
+//	/server start server:<name>      start a server
+//	/server stop server:<name>       stop a server
+//
+// Usage:
+//
+//	go run ./cmd/discord-bot            # serve interactions
+//	go run ./cmd/discord-bot -register  # register the slash commands with Discord, then exit
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// botConfig is read from the environment
+type botConfig struct {
+	ApplicationID string            // DISCORD_APPLICATION_ID
+	PublicKey     ed25519.PublicKey // DISCORD_PUBLIC_KEY, verifies interaction signatures
+	BotToken      string            // DISCORD_BOT_TOKEN, only needed to register commands
+	BotSecret     string            // DISCORD_BOT_SECRET, shared with the API
+	APIURL        string            // GSHUB_API_URL, public API
+	InternalURL   string            // GSHUB_INTERNAL_URL, internal API
+	Port          string            // PORT
+}
+
+func loadConfig() botConfig {
+	cfg := botConfig{
+		ApplicationID: os.Getenv("DISCORD_APPLICATION_ID"),
+		BotToken:      os.Getenv("DISCORD_BOT_TOKEN"),
+		BotSecret:     os.Getenv("DISCORD_BOT_SECRET"),
+		APIURL:        envOr("GSHUB_API_URL", "http://api.gshub.svc:8080"),
+		InternalURL:   envOr("GSHUB_INTERNAL_URL", "http://api.gshub.svc:8081"),
+		Port:          envOr("PORT", "8082"),
+	}
+
+	if cfg.ApplicationID == "" {
+		log.Fatal("DISCORD_APPLICATION_ID is required")
+	}
+	publicKey, err := hex.DecodeString(os.Getenv("DISCORD_PUBLIC_KEY"))
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		log.Fatal("DISCORD_PUBLIC_KEY must be the application's hex-encoded public key")
+	}
+	cfg.PublicKey = publicKey
+
+	return cfg
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func main() {
+	register := flag.Bool("register", false, "register the slash commands with Discord and exit")
+	flag.Parse()
+
+	_ = godotenv.Load()
+	cfg := loadConfig()
+
+	if *register {
+		if cfg.BotToken == "" {
+			log.Fatal("DISCORD_BOT_TOKEN is required to register commands")
+		}
+		if err := registerCommands(context.Background(), cfg); err != nil {
+			log.Fatal("Failed to register commands:", err)
+		}
+		log.Println("Slash commands registered")
+		return
+	}
+
+	if cfg.BotSecret == "" {
+		log.Fatal("DISCORD_BOT_SECRET is required")
+	}
+
+	bot := newBot(cfg)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /interactions", bot.handleInteraction)
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Discord bot listening on :%s", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down Discord bot...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Discord bot forced to shutdown: %v", err)
+	}
+	// Give in-flight command follow-ups a moment to finish
+	bot.wait(ctx)
+}
@@ -0,0 +1,130 @@
+// Command edge-proxy forwards game traffic from stable ports on an anycast address to the
+// node ports servers currently run on, so players keep one address while servers move
+// between nodes. It polls its routing table from the API's internal edge routes, which are
+// built from port allocations and supervisor heartbeats, and only forwards to healthy
+// servers.
+//
+// Every edge port is listened on for its protocol: TCP connections are proxied as streams,
+// UDP packets through a per-client session that's closed after EDGE_UDP_IDLE_TIMEOUT. New
+// UDP clients are dropped while EDGE_UDP_MAX_SESSIONS_PER_PORT or EDGE_UDP_MAX_SESSIONS
+// sessions are open, so spoofed source addresses can't use up the proxy's sockets.
+//
+// Usage:
+//
+//	go run ./cmd/edge-proxy
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// proxyConfig is read from the environment
+type proxyConfig struct {
+	ProxySecret    string        // EDGE_PROXY_SECRET, shared with the API
+	InternalURL    string        // GSHUB_INTERNAL_URL, internal API
+	ListenIP       string        // EDGE_LISTEN_IP, address edge ports are bound on (empty for all)
+	PollInterval   time.Duration // EDGE_POLL_INTERVAL, how often routes are refreshed
+	UDPIdleTimeout time.Duration // EDGE_UDP_IDLE_TIMEOUT, closes quiet UDP sessions
+	Port           string        // PORT, health endpoint
+
+	UDPMaxSessionsPerPort int // EDGE_UDP_MAX_SESSIONS_PER_PORT, UDP clients per edge port
+	UDPMaxSessions        int // EDGE_UDP_MAX_SESSIONS, UDP clients across all edge ports
+}
+
+func loadConfig() proxyConfig {
+	cfg := proxyConfig{
+		ProxySecret:    os.Getenv("EDGE_PROXY_SECRET"),
+		InternalURL:    envOr("GSHUB_INTERNAL_URL", "http://api.gshub.svc:8081"),
+		ListenIP:       os.Getenv("EDGE_LISTEN_IP"),
+		PollInterval:   envDuration("EDGE_POLL_INTERVAL", 10*time.Second),
+		UDPIdleTimeout: envDuration("EDGE_UDP_IDLE_TIMEOUT", 2*time.Minute),
+		Port:           envOr("PORT", "8083"),
+
+		UDPMaxSessionsPerPort: envInt("EDGE_UDP_MAX_SESSIONS_PER_PORT", 256),
+		UDPMaxSessions:        envInt("EDGE_UDP_MAX_SESSIONS", 20000),
+	}
+
+	if cfg.ProxySecret == "" {
+		log.Fatal("EDGE_PROXY_SECRET is required")
+	}
+
+	return cfg
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if duration, err := time.ParseDuration(os.Getenv(key)); err == nil && duration > 0 {
+		return duration
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func main() {
+	_ = godotenv.Load()
+	cfg := loadConfig()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := newRoutesClient(cfg)
+	proxy := newProxy(cfg)
+	go proxy.run(ctx, client)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		// Unready until routes were loaded once, so traffic isn't announced to an empty proxy
+		if !proxy.ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Edge proxy health endpoint listening on :%s", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down edge proxy...")
+	cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Edge proxy forced to shutdown: %v", err)
+	}
+	proxy.close()
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// dialTimeout bounds connecting to a backend for a new TCP connection
+	dialTimeout = 5 * time.Second
+
+	// udpBufferSize fits any UDP datagram
+	udpBufferSize = 64 * 1024
+)
+
+// proxy listens on every edge port and forwards traffic to the route's current backend.
+// Routes are looked up per connection (TCP) or packet (UDP), so a server that moved nodes
+// is reached at its new backend without restarting listeners.
+type proxy struct {
+	cfg proxyConfig
+
+	mu        sync.RWMutex
+	routes    map[routeKey]route
+	listeners map[routeKey]io.Closer
+	loaded    bool
+
+	// udpSessions counts open UDP sessions across all edge ports
+	udpSessions atomic.Int64
+}
+
+func newProxy(cfg proxyConfig) *proxy {
+	return &proxy{
+		cfg:       cfg,
+		routes:    make(map[routeKey]route),
+		listeners: make(map[routeKey]io.Closer),
+	}
+}
+
+// run refreshes the routing table every PollInterval until ctx is done
+func (p *proxy) run(ctx context.Context, client *routesClient) {
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		routes, err := client.routes(ctx)
+		if err != nil {
+			// Keep forwarding with the last known routes
+			log.Printf("failed to fetch edge routes: %v", err)
+		} else {
+			p.sync(routes)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ready reports whether routes were loaded at least once
+func (p *proxy) ready() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.loaded
+}
+
+// sync replaces the routing table, opening listeners for new edge ports and closing those
+// of removed ones
+func (p *proxy) sync(routes []route) {
+	next := make(map[routeKey]route, len(routes))
+	for _, r := range routes {
+		next[r.key()] = r
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, listener := range p.listeners {
+		if _, ok := next[key]; !ok {
+			listener.Close()
+			delete(p.listeners, key)
+		}
+	}
+	for key := range next {
+		if _, ok := p.listeners[key]; ok {
+			continue
+		}
+		listener, err := p.listen(key)
+		if err != nil {
+			// Retried on the next sync
+			log.Printf("failed to listen on %s/%d: %v", key.protocol, key.port, err)
+			continue
+		}
+		p.listeners[key] = listener
+	}
+
+	p.routes = next
+	p.loaded = true
+}
+
+// close stops every listener
+func (p *proxy) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, listener := range p.listeners {
+		listener.Close()
+		delete(p.listeners, key)
+	}
+}
+
+// backend returns where an edge port's traffic goes, or "" if its server isn't healthy
+func (p *proxy) backend(key routeKey) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	r, ok := p.routes[key]
+	if !ok || !r.Healthy {
+		return ""
+	}
+	return r.Backend
+}
+
+func (p *proxy) listen(key routeKey) (io.Closer, error) {
+	addr := net.JoinHostPort(p.cfg.ListenIP, strconv.Itoa(key.port))
+	switch key.protocol {
+	case "TCP":
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		go p.serveTCP(key, listener)
+		return listener, nil
+	case "UDP":
+		udpAddr, err := net.ResolveUDPAddr("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := net.ListenUDP("udp", udpAddr)
+		if err != nil {
+			return nil, err
+		}
+		go p.serveUDP(key, conn)
+		return conn, nil
+	default:
+		return nil, errors.New("unsupported protocol")
+	}
+}
+
+// serveTCP proxies each accepted connection to the route's backend
+func (p *proxy) serveTCP(key routeKey, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("failed to accept on TCP/%d: %v", key.port, err)
+			}
+			return
+		}
+		go p.proxyTCP(key, conn)
+	}
+}
+
+func (p *proxy) proxyTCP(key routeKey, client net.Conn) {
+	defer client.Close()
+
+	backend := p.backend(key)
+	if backend == "" {
+		return
+	}
+	upstream, err := net.DialTimeout("tcp", backend, dialTimeout)
+	if err != nil {
+		log.Printf("failed to reach backend %s for TCP/%d: %v", backend, key.port, err)
+		return
+	}
+	defer upstream.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstream, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+	// Either side hanging up ends the connection
+	<-done
+}
+
+// udpSession forwards one client's packets to a backend and the replies back
+type udpSession struct {
+	backend  string
+	upstream *net.UDPConn
+}
+
+// serveUDP forwards packets through a session per client address. Packets from new clients
+// are dropped while the port or the proxy is at its session limit.
+func (p *proxy) serveUDP(key routeKey, conn *net.UDPConn) {
+	var mu sync.Mutex
+	sessions := make(map[string]*udpSession)
+
+	buf := make([]byte, udpBufferSize)
+	for {
+		n, clientAddr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("failed to read on UDP/%d: %v", key.port, err)
+			}
+			mu.Lock()
+			for _, session := range sessions {
+				session.upstream.Close()
+			}
+			mu.Unlock()
+			return
+		}
+
+		backend := p.backend(key)
+		if backend == "" {
+			continue
+		}
+
+		client := clientAddr.String()
+		mu.Lock()
+		session, ok := sessions[client]
+		if ok && session.backend != backend {
+			// The server moved; the old session's reader removes it once closed
+			session.upstream.Close()
+			ok = false
+		}
+		if !ok {
+			if len(sessions) >= p.cfg.UDPMaxSessionsPerPort || !p.reserveUDPSession() {
+				mu.Unlock()
+				continue
+			}
+			session, err = p.dialUDP(backend)
+			if err != nil {
+				p.udpSessions.Add(-1)
+				mu.Unlock()
+				log.Printf("failed to reach backend %s for UDP/%d: %v", backend, key.port, err)
+				continue
+			}
+			sessions[client] = session
+			go func() {
+				p.relayUDP(conn, clientAddr, session)
+				mu.Lock()
+				if sessions[client] == session {
+					delete(sessions, client)
+				}
+				mu.Unlock()
+				p.udpSessions.Add(-1)
+			}()
+		}
+		mu.Unlock()
+
+		session.upstream.Write(buf[:n])
+	}
+}
+
+// reserveUDPSession counts a new UDP session, unless the proxy is at its limit
+func (p *proxy) reserveUDPSession() bool {
+	if p.udpSessions.Add(1) > int64(p.cfg.UDPMaxSessions) {
+		p.udpSessions.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (p *proxy) dialUDP(backend string) (*udpSession, error) {
+	addr, err := net.ResolveUDPAddr("udp", backend)
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &udpSession{backend: backend, upstream: upstream}, nil
+}
+
+// relayUDP sends the backend's replies to the client until the session is idle for
+// UDPIdleTimeout or closed
+func (p *proxy) relayUDP(conn *net.UDPConn, clientAddr *net.UDPAddr, session *udpSession) {
+	defer session.upstream.Close()
+
+	buf := make([]byte, udpBufferSize)
+	for {
+		session.upstream.SetReadDeadline(time.Now().Add(p.cfg.UDPIdleTimeout))
+		n, err := session.upstream.Read(buf)
+		if err != nil {
+			return
+		}
+		if _, err := conn.WriteToUDP(buf[:n], clientAddr); err != nil {
+			return
+		}
+	}
+}
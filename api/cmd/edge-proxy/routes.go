@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// route is an edge port and the node port its traffic goes to
+type route struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	ServerID string `json:"server_id"`
+	Backend  string `json:"backend"` // node_ip:host_port, empty while the server isn't placed
+	Healthy  bool   `json:"healthy"`
+}
+
+// routeKey identifies an edge listener
+type routeKey struct {
+	port     int
+	protocol string
+}
+
+func (r route) key() routeKey {
+	return routeKey{port: r.Port, protocol: r.Protocol}
+}
+
+// routesClient fetches the routing table from the internal API
+type routesClient struct {
+	internalURL string
+	secret      string
+	http        *http.Client
+}
+
+func newRoutesClient(cfg proxyConfig) *routesClient {
+	return &routesClient{
+		internalURL: strings.TrimRight(cfg.InternalURL, "/"),
+		secret:      cfg.ProxySecret,
+		http:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// routes returns every edge route
+func (c *routesClient) routes(ctx context.Context) ([]route, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.internalURL+"/internal/edge/routes", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.secret)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GSHUB API responded %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Routes []route `json:"routes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Routes, nil
+}
@@ -0,0 +1,161 @@
+// Command genvalues renders the environment expected by config.Load as Kubernetes
+// manifests, so cluster configuration is generated from the Go code instead of
+// being maintained by hand.
+//
+// Usage:
+//
+//	go run ./cmd/genvalues -format values    # Helm-style values.yaml
+//	go run ./cmd/genvalues -format env       # Deployment container env list
+//	go run ./cmd/genvalues -format configmap # ConfigMap with non-secret defaults
+//	go run ./cmd/genvalues -format secret    # Secret template with secret keys
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mooncorn/gshub/api/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configMapName = "gshub-api-config"
+	secretName    = "gshub-secrets"
+)
+
+type secretKeyRef struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+}
+
+type envVarSource struct {
+	SecretKeyRef secretKeyRef `yaml:"secretKeyRef"`
+}
+
+type envVar struct {
+	Name      string        `yaml:"name"`
+	Value     *string       `yaml:"value,omitempty"`
+	ValueFrom *envVarSource `yaml:"valueFrom,omitempty"`
+}
+
+type metadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+type configMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   metadata          `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type secret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   metadata          `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	StringData map[string]string `yaml:"stringData"`
+}
+
+// values is the Helm-style layout: plain env with defaults, and secret env mapped to Secret keys
+type values struct {
+	API struct {
+		Env       map[string]string `yaml:"env"`
+		SecretEnv map[string]string `yaml:"secretEnv"`
+		Required  []string          `yaml:"required,omitempty"`
+	} `yaml:"api"`
+}
+
+func main() {
+	format := flag.String("format", "values", "output format: values, env, configmap or secret")
+	namespace := flag.String("namespace", "gshub", "namespace for generated ConfigMap/Secret")
+	flag.Parse()
+
+	var out any
+	switch *format {
+	case "values":
+		out = buildValues()
+	case "env":
+		out = buildEnv()
+	case "configmap":
+		out = buildConfigMap(*namespace)
+	case "secret":
+		out = buildSecret(*namespace)
+	default:
+		log.Fatalf("unknown format %q (want values, env, configmap or secret)", *format)
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	fmt.Println("# Code generated by cmd/genvalues from config.EnvVars. DO NOT EDIT.")
+	if err := enc.Encode(out); err != nil {
+		log.Fatalf("failed to encode %s: %v", *format, err)
+	}
+}
+
+func buildValues() values {
+	var v values
+	v.API.Env = make(map[string]string)
+	v.API.SecretEnv = make(map[string]string)
+	for _, ev := range config.EnvVars {
+		if ev.Secret {
+			v.API.SecretEnv[ev.Name] = ev.SecretKey()
+		} else {
+			v.API.Env[ev.Name] = ev.Default
+		}
+		if ev.Required {
+			v.API.Required = append(v.API.Required, ev.Name)
+		}
+	}
+	return v
+}
+
+func buildEnv() []envVar {
+	env := make([]envVar, 0, len(config.EnvVars))
+	for _, ev := range config.EnvVars {
+		if ev.Secret {
+			env = append(env, envVar{
+				Name:      ev.Name,
+				ValueFrom: &envVarSource{SecretKeyRef: secretKeyRef{Name: secretName, Key: ev.SecretKey()}},
+			})
+			continue
+		}
+		value := ev.Default
+		env = append(env, envVar{Name: ev.Name, Value: &value})
+	}
+	return env
+}
+
+func buildConfigMap(namespace string) configMap {
+	data := make(map[string]string)
+	for _, ev := range config.EnvVars {
+		if !ev.Secret {
+			data[ev.Name] = ev.Default
+		}
+	}
+	return configMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   metadata{Name: configMapName, Namespace: namespace},
+		Data:       data,
+	}
+}
+
+func buildSecret(namespace string) secret {
+	data := make(map[string]string)
+	for _, ev := range config.EnvVars {
+		if ev.Secret {
+			data[ev.SecretKey()] = ""
+		}
+	}
+	return secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   metadata{Name: secretName, Namespace: namespace},
+		Type:       "Opaque",
+		StringData: data,
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// nodeRegistration is the API's answer to registering a node
+type nodeRegistration struct {
+	NodeName     string `json:"node_name"`
+	PortRangeMin int    `json:"port_range_min"`
+	PortRangeMax int    `json:"port_range_max"`
+}
+
+// syncedNode is the node as node sync recorded it
+type syncedNode struct {
+	Name     string `json:"name"`
+	PublicIP string `json:"public_ip"`
+	IsActive bool   `json:"is_active"`
+}
+
+// permanentError is an error retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// apiClient calls the API's node agent routes with the registration token
+type apiClient struct {
+	cfg  agentConfig
+	http *http.Client
+}
+
+func newAPIClient(cfg agentConfig) *apiClient {
+	return &apiClient{
+		cfg: cfg,
+		// Readiness syncs every node, which takes a while on large clusters
+		http: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// register has the API label the node. Fails (retryably) until the node joined the cluster.
+func (c *apiClient) register(ctx context.Context) (nodeRegistration, error) {
+	var registration nodeRegistration
+	err := c.post(ctx, "/nodes/agent/register", map[string]string{
+		"node_name": c.cfg.NodeName,
+		"public_ip": c.cfg.PublicIP,
+	}, &registration)
+	return registration, err
+}
+
+// ready reports the node healthy, which syncs it and uses up the token
+func (c *apiClient) ready(ctx context.Context) (syncedNode, error) {
+	var body struct {
+		Node syncedNode `json:"node"`
+	}
+	err := c.post(ctx, "/nodes/agent/ready", nil, &body)
+	return body.Node, err
+}
+
+func (c *apiClient) post(ctx context.Context, path string, payload, result any) error {
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return &permanentError{err}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.APIURL+path, &body)
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		err := fmt.Errorf("GSHUB API responded %d: %s", resp.StatusCode, apiErr.Error.Message)
+		// The node may not have joined yet, and server errors may pass
+		if apiErr.Error.Code == "NODE_NOT_JOINED" || resp.StatusCode >= 500 {
+			return err
+		}
+		return &permanentError{err}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// openPorts allows inbound TCP and UDP traffic to the game port range: through ufw when it's
+// active, iptables otherwise, or Windows Firewall on Windows nodes. Rules that already exist
+// aren't added again, so the agent can be rerun.
+func openPorts(ctx context.Context, portMin, portMax int) error {
+	if runtime.GOOS == "windows" {
+		return openWindowsPorts(ctx, portMin, portMax)
+	}
+
+	if out, err := exec.CommandContext(ctx, "ufw", "status").Output(); err == nil && strings.Contains(string(out), "Status: active") {
+		for _, protocol := range []string{"tcp", "udp"} {
+			if err := run(ctx, "ufw", "allow", fmt.Sprintf("%d:%d/%s", portMin, portMax, protocol)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, protocol := range []string{"tcp", "udp"} {
+		rule := []string{"INPUT", "-p", protocol, "--dport", fmt.Sprintf("%d:%d", portMin, portMax), "-j", "ACCEPT"}
+		if exec.CommandContext(ctx, "iptables", append([]string{"-C"}, rule...)...).Run() == nil {
+			continue
+		}
+		if err := run(ctx, "iptables", append([]string{"-I"}, rule...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func openWindowsPorts(ctx context.Context, portMin, portMax int) error {
+	for _, protocol := range []string{"TCP", "UDP"} {
+		name := "GSHUB game ports " + protocol
+		if exec.CommandContext(ctx, "netsh", "advfirewall", "firewall", "show", "rule", "name="+name).Run() == nil {
+			continue
+		}
+		err := run(ctx, "netsh", "advfirewall", "firewall", "add", "rule", "name="+name,
+			"dir=in", "action=allow", "protocol="+protocol, fmt.Sprintf("localport=%d-%d", portMin, portMax))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run runs a command, returning its output with the error if it fails
+func run(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
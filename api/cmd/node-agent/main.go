@@ -0,0 +1,163 @@
+// Command node-agent joins a machine to the platform as a game server node, after it joined
+// the cluster as a K3s agent. With a registration token from POST /admin/nodes/register-token
+// it waits for kubelet to be healthy, has the API label the node (game server role, public
+// IP and the token's location), opens the game port range in the firewall and reports back,
+// so node sync picks the node up right away instead of on its next interval.
+//
+// Run it as root (or Administrator on Windows nodes); it exits once the node is registered.
+//
+// Usage:
+//
+//	GSHUB_API_URL=https://api.gshub.pro GSHUB_NODE_TOKEN=<token> NODE_PUBLIC_IP=45.x.x.13 \
+//	  go run ./cmd/node-agent
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// agentConfig is read from the environment
+type agentConfig struct {
+	APIURL         string        // GSHUB_API_URL, public API
+	Token          string        // GSHUB_NODE_TOKEN, node registration token
+	NodeName       string        // NODE_NAME, the Kubernetes node name (default: hostname)
+	PublicIP       string        // NODE_PUBLIC_IP, address players connect to
+	KubeletHealthz string        // KUBELET_HEALTHZ_URL, kubelet's health endpoint
+	Timeout        time.Duration // NODE_AGENT_TIMEOUT, how long to wait for kubelet and the node to join
+}
+
+func loadConfig() agentConfig {
+	cfg := agentConfig{
+		APIURL:         strings.TrimRight(os.Getenv("GSHUB_API_URL"), "/"),
+		Token:          os.Getenv("GSHUB_NODE_TOKEN"),
+		NodeName:       os.Getenv("NODE_NAME"),
+		PublicIP:       os.Getenv("NODE_PUBLIC_IP"),
+		KubeletHealthz: envOr("KUBELET_HEALTHZ_URL", "http://127.0.0.1:10248/healthz"),
+		Timeout:        envDuration("NODE_AGENT_TIMEOUT", 5*time.Minute),
+	}
+
+	if cfg.APIURL == "" {
+		log.Fatal("GSHUB_API_URL is required")
+	}
+	if cfg.Token == "" {
+		log.Fatal("GSHUB_NODE_TOKEN is required")
+	}
+	if cfg.PublicIP == "" {
+		log.Fatal("NODE_PUBLIC_IP is required")
+	}
+	if cfg.NodeName == "" {
+		// K3s names nodes after the lowercased hostname
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Fatal("NODE_NAME is required: ", err)
+		}
+		cfg.NodeName = strings.ToLower(hostname)
+	}
+
+	return cfg
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if duration, err := time.ParseDuration(os.Getenv(key)); err == nil && duration > 0 {
+		return duration
+	}
+	return fallback
+}
+
+// retryInterval is how often kubelet and the API are retried while the node joins
+const retryInterval = 5 * time.Second
+
+func main() {
+	_ = godotenv.Load()
+	cfg := loadConfig()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	client := newAPIClient(cfg)
+	joinCtx, joinCancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer joinCancel()
+
+	log.Printf("Waiting for kubelet to be healthy at %s", cfg.KubeletHealthz)
+	if err := retry(joinCtx, func() error { return kubeletHealthy(joinCtx, cfg.KubeletHealthz) }); err != nil {
+		log.Fatalf("Kubelet isn't healthy: %v", err)
+	}
+
+	log.Printf("Registering node %s with public IP %s", cfg.NodeName, cfg.PublicIP)
+	var registration nodeRegistration
+	err := retry(joinCtx, func() error {
+		var err error
+		registration, err = client.register(joinCtx)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to register node: %v", err)
+	}
+
+	log.Printf("Opening ports %d-%d", registration.PortRangeMin, registration.PortRangeMax)
+	if err := openPorts(ctx, registration.PortRangeMin, registration.PortRangeMax); err != nil {
+		log.Fatalf("Failed to open ports: %v", err)
+	}
+
+	node, err := client.ready(ctx)
+	if err != nil {
+		log.Fatalf("Failed to report node ready: %v", err)
+	}
+	if !node.IsActive {
+		log.Printf("Node %s is registered but not ready yet; node sync activates it once Kubernetes reports it Ready", node.Name)
+		return
+	}
+	log.Printf("Node %s is registered and taking game servers", node.Name)
+}
+
+// retry calls fn until it succeeds, fails permanently or ctx is done
+func retry(ctx context.Context, fn func() error) error {
+	for {
+		err := fn()
+		var permanent *permanentError
+		if err == nil || errors.As(err, &permanent) {
+			return err
+		}
+		log.Printf("Retrying: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// kubeletHealthy checks kubelet's health endpoint
+func kubeletHealthy(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &permanentError{err}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("kubelet responded " + resp.Status)
+	}
+	return nil
+}
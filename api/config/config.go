@@ -25,6 +25,9 @@ type Config struct {
 	JWTAccessExpiry  time.Duration
 	JWTRefreshExpiry time.Duration
 
+	// Name authenticator apps show for two-factor authentication codes
+	MFAIssuer string
+
 	// MailerSend
 	MailerSendAPIKey    string
 	MailerSendFromEmail string
@@ -37,29 +40,124 @@ type Config struct {
 	StripeProPriceID        string
 	StripeEnterprisePriceID string
 	StripePrices            map[string]map[string]string // game -> plan -> priceID
+	StripeMockMode          bool                         // Simulate Stripe locally (no real API calls)
 
 	FrontendURL string
 
 	// Kubernetes
-	K8sNamespace       string
-	K8sGameCatalogName string
+	K8sNamespace              string // Control-plane namespace (API, game catalog) and default for game servers
+	K8sGameCatalogName        string
+	K8sGameCatalogStagingName string            // Staging channel catalog, for testing game definitions on internal servers
+	K8sServerNamespaces       map[string]string // plan -> namespaces for new game servers (e.g. per tier), "|"-separated
+	K8sServerServiceAccount   string            // ServiceAccount impersonated in server namespaces, empty to use the API's own
 
 	// Port Allocation
 	PortRangeMin int
 	PortRangeMax int
 
+	// Released ports are handed to other servers only after PortReuseCooldown, unless a node
+	// has no other free port, so stale DNS and client caches don't reach someone else's server
+	PortReuseCooldown time.Duration
+
+	// Restart loop protection: at most RestartBudget user starts/restarts per server
+	// within RestartBudgetWindow (0 disables the limit)
+	RestartBudget       int
+	RestartBudgetWindow time.Duration
+
+	// Servers stopped continuously for StoppedReminderAfter get a billing reminder (0 disables)
+	StoppedReminderAfter time.Duration
+
+	// Admins receive dispute alerts and may use the /admin endpoints, like users with the admin role
+	AdminEmails []string
+
+	// Abuse detection: servers whose CPU (percent of one core) or outbound traffic stays
+	// above these thresholds for AbuseSustainedFor are suspended (0 disables a rule)
+	AbuseCPUPercent   int
+	AbuseNetTxMbps    int
+	AbuseSustainedFor time.Duration
+
+	// Supervisor requests to the internal API: each server may make InternalRateLimit
+	// requests a minute, in bursts of up to InternalRateBurst (0 disables the limit), and
+	// status reports or heartbeats identical to its previous one within InternalDedupWindow
+	// are dropped (0 disables)
+	InternalRateLimit   int
+	InternalRateBurst   int
+	InternalDedupWindow time.Duration
+
+	// Supervisors are given the API's gRPC endpoint, and fall back to the JSON endpoints only
+	// if it's unreachable; disabling keeps new supervisors on JSON
+	SupervisorGRPC bool
+
+	// Status stream (SSE) clients whose event buffer stays full this long are disconnected,
+	// so one stuck client can't back up broadcasts (0 only drops their events)
+	SSESlowClientTimeout time.Duration
+
+	// Concurrent status streams per user (a new one closes their oldest) and per API
+	// replica (new ones are refused with 429); 0 disables either limit
+	SSEMaxStreamsPerUser int
+	SSEMaxStreams        int
+
+	// Accounts are suspended automatically once they reach this many payment disputes
+	// or abuse suspensions (0 disables)
+	AccountSuspendDisputes int
+	AccountSuspendAbuse    int
+
+	// Registries (or registry paths) custom game images may be pulled from (empty disables
+	// the custom game type)
+	CustomGameRegistries []string
+
+	// Discord bot integration: the bot authenticates to the internal API with this secret
+	// (empty disables the integration)
+	DiscordBotSecret string
+
+	// Edge proxy: servers get stable ports in [EdgePortRangeMin, EdgePortRangeMax] on
+	// EdgeAddress, and the proxy authenticates to the internal API with EdgeProxySecret
+	// (empty secret or address disables the edge tier)
+	EdgeProxySecret  string
+	EdgeAddress      string
+	EdgePortRangeMin int
+	EdgePortRangeMax int
+
+	// World exports of data volumes larger than this are refused (0 means no limit)
+	ExportMaxGB int
+
+	// Largest world archive, in GiB, owners can import, compressed or extracted (0 means no limit)
+	ImportMaxGB int
+
+	// Owners of expired servers can browse and download their data through a temporary pod
+	// running this supervisor image in file access mode (empty disables file access)
+	FileAccessImage string
+
+	// Largest file, in MiB, owners can upload to a running server (0 means no limit)
+	FileUploadMaxMB int
+
+	// Mods are searched and downloaded through the Modrinth API at this URL
+	ModrinthAPIURL string
+
+	// Steam Web API publisher key that checks what linked Steam accounts own and creates game
+	// server login tokens (empty disables both)
+	SteamWebAPIKey string
+
+	// Off-site backup replicas are copied to this S3-compatible bucket, meant to be in another
+	// region than the cluster (empty endpoint or bucket disables replication)
+	BackupReplicaEndpoint        string
+	BackupReplicaRegion          string
+	BackupReplicaBucket          string
+	BackupReplicaAccessKeyID     string
+	BackupReplicaSecretAccessKey string
+
 	// Migrations
 	MigrationsDir string
 }
 
 func Load() (*Config, error) {
 	// Build DATABASE_URL from components
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "gshub")
-	dbPassword := getEnv("DB_PASSWORD", "")
-	dbName := getEnv("DB_NAME", "gshub")
-	dbSSLMode := getEnv("DB_SSLMODE", "disable")
+	dbHost := getEnv("DB_HOST")
+	dbPort := getEnv("DB_PORT")
+	dbUser := getEnv("DB_USER")
+	dbPassword := getEnv("DB_PASSWORD")
+	dbName := getEnv("DB_NAME")
+	dbSSLMode := getEnv("DB_SSLMODE")
 
 	databaseURL := fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
@@ -69,45 +167,108 @@ func Load() (*Config, error) {
 	// Initialize stripe prices map
 	stripePrices := make(map[string]map[string]string)
 	stripePrices["minecraft"] = map[string]string{
-		"small":  getEnv("STRIPE_PRICE_MINECRAFT_SMALL", ""),
-		"medium": getEnv("STRIPE_PRICE_MINECRAFT_MEDIUM", ""),
-		"large":  getEnv("STRIPE_PRICE_MINECRAFT_LARGE", ""),
+		"small":     getEnv("STRIPE_PRICE_MINECRAFT_SMALL"),
+		"medium":    getEnv("STRIPE_PRICE_MINECRAFT_MEDIUM"),
+		"large":     getEnv("STRIPE_PRICE_MINECRAFT_LARGE"),
+		"dedicated": getEnv("STRIPE_PRICE_MINECRAFT_DEDICATED"),
+		"budget":    getEnv("STRIPE_PRICE_MINECRAFT_BUDGET"),
 	}
 	stripePrices["valheim"] = map[string]string{
-		"small":  getEnv("STRIPE_PRICE_VALHEIM_SMALL", ""),
-		"medium": getEnv("STRIPE_PRICE_VALHEIM_MEDIUM", ""),
+		"small":  getEnv("STRIPE_PRICE_VALHEIM_SMALL"),
+		"medium": getEnv("STRIPE_PRICE_VALHEIM_MEDIUM"),
+	}
+	stripePrices["custom"] = map[string]string{
+		"small":  getEnv("STRIPE_PRICE_CUSTOM_SMALL"),
+		"medium": getEnv("STRIPE_PRICE_CUSTOM_MEDIUM"),
+		"large":  getEnv("STRIPE_PRICE_CUSTOM_LARGE"),
 	}
 
 	cfg := &Config{
-		Environment: getEnv("ENVIRONMENT", "development"),
+		Environment: getEnv("ENVIRONMENT"),
 
-		Port:           getEnv("PORT", "8080"),
-		GinMode:        getEnv("GIN_MODE", "debug"),
-		AllowedOrigins: getEnvSlice("ALLOWED_ORIGINS", []string{"http://localhost:5173", "http://127.0.0.1:5173", "http://localhost:3000", "http://127.0.0.1:3000"}),
+		Port:           getEnv("PORT"),
+		GinMode:        getEnv("GIN_MODE"),
+		AllowedOrigins: getEnvSlice("ALLOWED_ORIGINS"),
 
 		DatabaseURL: databaseURL,
 
-		JWTSecret:        getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
-		JWTAccessExpiry:  parseDuration(getEnv("JWT_ACCESS_EXPIRY", "15m"), 15*time.Minute),
-		JWTRefreshExpiry: parseDuration(getEnv("JWT_REFRESH_EXPIRY", "168h"), 168*time.Hour),
+		JWTSecret:        getEnv("JWT_SECRET"),
+		JWTAccessExpiry:  getEnvDuration("JWT_ACCESS_EXPIRY"),
+		JWTRefreshExpiry: getEnvDuration("JWT_REFRESH_EXPIRY"),
+
+		MFAIssuer: getEnv("MFA_ISSUER"),
 
-		MailerSendAPIKey:    getEnv("MAILERSEND_API_KEY", ""),
-		MailerSendFromEmail: getEnv("MAILERSEND_FROM_EMAIL", "noreply@gshub.pro"),
-		MailerSendFromName:  getEnv("MAILERSEND_FROM_NAME", "GSHUB.PRO"),
+		MailerSendAPIKey:    getEnv("MAILERSEND_API_KEY"),
+		MailerSendFromEmail: getEnv("MAILERSEND_FROM_EMAIL"),
+		MailerSendFromName:  getEnv("MAILERSEND_FROM_NAME"),
 
-		StripeSecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
-		StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		StripeSecretKey:     getEnv("STRIPE_SECRET_KEY"),
+		StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET"),
 		StripePrices:        stripePrices,
+		StripeMockMode:      getEnvBool("STRIPE_MOCK_MODE"),
+
+		FrontendURL: getEnv("FRONTEND_URL"),
+
+		K8sNamespace:              getEnv("K8S_NAMESPACE"),
+		K8sGameCatalogName:        getEnv("K8S_GAME_CATALOG_NAME"),
+		K8sGameCatalogStagingName: getEnv("K8S_GAME_CATALOG_STAGING_NAME"),
+		K8sServerNamespaces:       getEnvMap("K8S_SERVER_NAMESPACES"),
+		K8sServerServiceAccount:   getEnv("K8S_SERVER_SERVICE_ACCOUNT"),
+
+		PortRangeMin:      getEnvInt("PORT_RANGE_MIN"),
+		PortRangeMax:      getEnvInt("PORT_RANGE_MAX"),
+		PortReuseCooldown: getEnvDuration("PORT_REUSE_COOLDOWN"),
+
+		RestartBudget:       getEnvInt("RESTART_BUDGET"),
+		RestartBudgetWindow: getEnvDuration("RESTART_BUDGET_WINDOW"),
+
+		StoppedReminderAfter: getEnvDuration("STOPPED_REMINDER_AFTER"),
+
+		AdminEmails: getEnvSlice("ADMIN_EMAILS"),
+
+		AbuseCPUPercent:   getEnvInt("ABUSE_CPU_PERCENT"),
+		AbuseNetTxMbps:    getEnvInt("ABUSE_NET_TX_MBPS"),
+		AbuseSustainedFor: getEnvDuration("ABUSE_SUSTAINED_FOR"),
+
+		InternalRateLimit:   getEnvInt("INTERNAL_RATE_LIMIT"),
+		InternalRateBurst:   getEnvInt("INTERNAL_RATE_BURST"),
+		InternalDedupWindow: getEnvDuration("INTERNAL_DEDUP_WINDOW"),
+
+		SupervisorGRPC: getEnvBool("SUPERVISOR_GRPC"),
+
+		SSESlowClientTimeout: getEnvDuration("SSE_SLOW_CLIENT_TIMEOUT"),
+		SSEMaxStreamsPerUser: getEnvInt("SSE_MAX_STREAMS_PER_USER"),
+		SSEMaxStreams:        getEnvInt("SSE_MAX_STREAMS"),
+
+		AccountSuspendDisputes: getEnvInt("ACCOUNT_SUSPEND_DISPUTES"),
+		AccountSuspendAbuse:    getEnvInt("ACCOUNT_SUSPEND_ABUSE"),
 
-		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:5173"),
+		CustomGameRegistries: getEnvSlice("CUSTOM_GAME_REGISTRIES"),
 
-		K8sNamespace:       getEnv("K8S_NAMESPACE", "gshub"),
-		K8sGameCatalogName: getEnv("K8S_GAME_CATALOG_NAME", "game-catalog"),
+		DiscordBotSecret: getEnv("DISCORD_BOT_SECRET"),
 
-		PortRangeMin: getEnvInt("PORT_RANGE_MIN", 25501),
-		PortRangeMax: getEnvInt("PORT_RANGE_MAX", 25999),
+		EdgeProxySecret:  getEnv("EDGE_PROXY_SECRET"),
+		EdgeAddress:      getEnv("EDGE_ADDRESS"),
+		EdgePortRangeMin: getEnvInt("EDGE_PORT_RANGE_MIN"),
+		EdgePortRangeMax: getEnvInt("EDGE_PORT_RANGE_MAX"),
 
-		MigrationsDir: getEnv("MIGRATIONS_DIR", "migrations"),
+		ExportMaxGB: getEnvInt("EXPORT_MAX_GB"),
+		ImportMaxGB: getEnvInt("IMPORT_MAX_GB"),
+
+		FileAccessImage: getEnv("FILE_ACCESS_IMAGE"),
+		FileUploadMaxMB: getEnvInt("FILE_UPLOAD_MAX_MB"),
+
+		ModrinthAPIURL: getEnv("MODRINTH_API_URL"),
+
+		SteamWebAPIKey: getEnv("STEAM_WEB_API_KEY"),
+
+		BackupReplicaEndpoint:        getEnv("BACKUP_REPLICA_ENDPOINT"),
+		BackupReplicaRegion:          getEnv("BACKUP_REPLICA_REGION"),
+		BackupReplicaBucket:          getEnv("BACKUP_REPLICA_BUCKET"),
+		BackupReplicaAccessKeyID:     getEnv("BACKUP_REPLICA_ACCESS_KEY_ID"),
+		BackupReplicaSecretAccessKey: getEnv("BACKUP_REPLICA_SECRET_ACCESS_KEY"),
+
+		MigrationsDir: getEnv("MIGRATIONS_DIR"),
 	}
 
 	// Validate required fields
@@ -121,34 +282,50 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
-func getEnv(key, defaultValue string) string {
+func getEnv(key string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
-	return defaultValue
+	return envDefault(key)
 }
 
-func getEnvSlice(key string, defaultValue []string) []string {
-	if value := os.Getenv(key); value != "" {
-		return strings.Split(value, ",")
-	}
-	return defaultValue
+func getEnvSlice(key string) []string {
+	return strings.Split(getEnv(key), ",")
 }
 
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+// getEnvMap parses a comma-separated list of key=value pairs (e.g. "small=gshub-basic,large=gshub-premium")
+func getEnvMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(getEnv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && k != "" && v != "" {
+			result[k] = v
 		}
 	}
-	return defaultValue
+	return result
+}
+
+func getEnvInt(key string) int {
+	if intValue, err := strconv.Atoi(getEnv(key)); err == nil {
+		return intValue
+	}
+	intValue, _ := strconv.Atoi(envDefault(key))
+	return intValue
+}
+
+func getEnvBool(key string) bool {
+	if boolValue, err := strconv.ParseBool(getEnv(key)); err == nil {
+		return boolValue
+	}
+	boolValue, _ := strconv.ParseBool(envDefault(key))
+	return boolValue
 }
 
-func parseDuration(value string, defaultValue time.Duration) time.Duration {
-	duration, err := time.ParseDuration(value)
-	if err != nil {
-		return defaultValue
+func getEnvDuration(key string) time.Duration {
+	if duration, err := time.ParseDuration(getEnv(key)); err == nil {
+		return duration
 	}
+	duration, _ := time.ParseDuration(envDefault(key))
 	return duration
 }
 
@@ -160,9 +337,90 @@ func (c *Config) GetPriceID(game, plan string) (string, error) {
 	}
 
 	priceID, ok := gamePrices[plan]
+	if ok && priceID == "" && c.StripeMockMode {
+		// Mock mode doesn't need real prices; synthesize a stable placeholder
+		return fmt.Sprintf("price_mock_%s_%s", game, plan), nil
+	}
 	if !ok || priceID == "" {
 		return "", fmt.Errorf("price not configured for game %s, plan %s", game, plan)
 	}
 
 	return priceID, nil
 }
+
+// ServerNamespace returns the namespace new game servers on the given plan are created in
+func (c *Config) ServerNamespace(plan string) string {
+	return c.ServerNamespaces(plan)[0]
+}
+
+// ServerNamespaces returns the namespaces new game servers on the given plan may be placed
+// in, in order of preference: a server moves on to the next when the quotas of the ones
+// before it are used up on its first placement
+func (c *Config) ServerNamespaces(plan string) []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(c.K8sServerNamespaces[plan], "|") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	if len(namespaces) == 0 {
+		return []string{c.K8sNamespace}
+	}
+	return namespaces
+}
+
+// Game catalog channels. Servers use production unless an admin moves them to staging.
+const (
+	CatalogChannelProduction = "production"
+	CatalogChannelStaging    = "staging"
+)
+
+// HasCatalogChannel reports whether the catalog channel is configured
+func (c *Config) HasCatalogChannel(channel string) bool {
+	return channel == CatalogChannelProduction || (channel == CatalogChannelStaging && c.K8sGameCatalogStagingName != "")
+}
+
+// GameCatalogName returns the game catalog ConfigMap for a catalog channel. Channels that
+// aren't configured fall back to production, so servers keep working if staging is removed.
+func (c *Config) GameCatalogName(channel string) string {
+	if channel == CatalogChannelStaging && c.K8sGameCatalogStagingName != "" {
+		return c.K8sGameCatalogStagingName
+	}
+	return c.K8sGameCatalogName
+}
+
+// CustomGamesEnabled reports whether any registry is allowed for custom game images
+func (c *Config) CustomGamesEnabled() bool {
+	for _, registry := range c.CustomGameRegistries {
+		if strings.TrimSpace(registry) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// EdgeProxyEnabled reports whether servers get stable ports on the edge proxy
+func (c *Config) EdgeProxyEnabled() bool {
+	return c.EdgeProxySecret != "" && c.EdgeAddress != ""
+}
+
+// FileAccessEnabled reports whether owners of expired servers can access their data
+func (c *Config) FileAccessEnabled() bool {
+	return c.FileAccessImage != ""
+}
+
+// BackupReplicationEnabled reports whether backups can be replicated off-site
+func (c *Config) BackupReplicationEnabled() bool {
+	return c.BackupReplicaEndpoint != "" && c.BackupReplicaBucket != ""
+}
+
+// IsAdmin reports whether email belongs to a configured admin. Callers must check that the
+// user verified it.
+func (c *Config) IsAdmin(email string) bool {
+	for _, admin := range c.AdminEmails {
+		if admin = strings.TrimSpace(admin); admin != "" && strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
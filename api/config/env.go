@@ -0,0 +1,129 @@
+package config
+
+import "strings"
+
+// EnvVar describes an environment variable read by Load
+type EnvVar struct {
+	Name        string
+	Default     string
+	Secret      bool // Provided from the gshub-secrets Secret instead of a plain value
+	Required    bool
+	Description string
+}
+
+// SecretKey returns the key the variable is stored under in the gshub-secrets Secret
+func (v EnvVar) SecretKey() string {
+	return strings.ToLower(strings.ReplaceAll(v.Name, "_", "-"))
+}
+
+// EnvVars lists every environment variable read by Load, with its default.
+// cmd/genvalues renders cluster manifests from this list, and Load panics on
+// names missing from it, so code and manifests can't drift apart.
+var EnvVars = []EnvVar{
+	{Name: "ENVIRONMENT", Default: "development", Description: "Deployment environment name"},
+	{Name: "PORT", Default: "8080", Description: "Public HTTP port"},
+	{Name: "GIN_MODE", Default: "debug", Description: "Gin mode (debug or release)"},
+	{Name: "ALLOWED_ORIGINS", Default: "http://localhost:5173,http://127.0.0.1:5173,http://localhost:3000,http://127.0.0.1:3000", Description: "Comma-separated CORS origins"},
+
+	{Name: "DB_HOST", Default: "localhost", Description: "PostgreSQL host"},
+	{Name: "DB_PORT", Default: "5432", Description: "PostgreSQL port"},
+	{Name: "DB_USER", Default: "gshub", Description: "PostgreSQL user"},
+	{Name: "DB_PASSWORD", Secret: true, Required: true, Description: "PostgreSQL password"},
+	{Name: "DB_NAME", Default: "gshub", Description: "PostgreSQL database"},
+	{Name: "DB_SSLMODE", Default: "disable", Description: "PostgreSQL sslmode"},
+
+	{Name: "JWT_SECRET", Default: "your-super-secret-jwt-key", Secret: true, Description: "JWT signing key"},
+	{Name: "JWT_ACCESS_EXPIRY", Default: "15m", Description: "Access token lifetime"},
+	{Name: "JWT_REFRESH_EXPIRY", Default: "168h", Description: "Refresh token lifetime"},
+	{Name: "MFA_ISSUER", Default: "GSHUB.PRO", Description: "Name authenticator apps show for two-factor codes"},
+
+	{Name: "MAILERSEND_API_KEY", Secret: true, Description: "MailerSend API key"},
+	{Name: "MAILERSEND_FROM_EMAIL", Default: "noreply@gshub.pro", Description: "Sender address for emails"},
+	{Name: "MAILERSEND_FROM_NAME", Default: "GSHUB.PRO", Description: "Sender name for emails"},
+
+	{Name: "STRIPE_SECRET_KEY", Secret: true, Description: "Stripe API key"},
+	{Name: "STRIPE_WEBHOOK_SECRET", Secret: true, Description: "Stripe webhook signing secret"},
+	{Name: "STRIPE_MOCK_MODE", Default: "false", Description: "Simulate Stripe locally instead of calling the API"},
+	{Name: "STRIPE_PRICE_MINECRAFT_SMALL", Description: "Stripe price ID for minecraft/small"},
+	{Name: "STRIPE_PRICE_MINECRAFT_MEDIUM", Description: "Stripe price ID for minecraft/medium"},
+	{Name: "STRIPE_PRICE_MINECRAFT_LARGE", Description: "Stripe price ID for minecraft/large"},
+	{Name: "STRIPE_PRICE_MINECRAFT_DEDICATED", Description: "Stripe price ID for minecraft/dedicated"},
+	{Name: "STRIPE_PRICE_MINECRAFT_BUDGET", Description: "Stripe price ID for minecraft/budget"},
+	{Name: "STRIPE_PRICE_VALHEIM_SMALL", Description: "Stripe price ID for valheim/small"},
+	{Name: "STRIPE_PRICE_VALHEIM_MEDIUM", Description: "Stripe price ID for valheim/medium"},
+	{Name: "STRIPE_PRICE_CUSTOM_SMALL", Description: "Stripe price ID for custom/small"},
+	{Name: "STRIPE_PRICE_CUSTOM_MEDIUM", Description: "Stripe price ID for custom/medium"},
+	{Name: "STRIPE_PRICE_CUSTOM_LARGE", Description: "Stripe price ID for custom/large"},
+
+	{Name: "FRONTEND_URL", Default: "http://localhost:5173", Description: "Public web app URL"},
+
+	{Name: "K8S_NAMESPACE", Default: "gshub", Description: "Control-plane namespace and default for game servers"},
+	{Name: "K8S_GAME_CATALOG_NAME", Default: "game-catalog", Description: "Game catalog ConfigMap name"},
+	{Name: "K8S_GAME_CATALOG_STAGING_NAME", Description: "Staging game catalog ConfigMap name; unset disables the staging channel"},
+	{Name: "K8S_SERVER_NAMESPACES", Description: "Comma-separated plan=namespace pairs for new game servers; several namespaces separated by | are tried in order as their quotas fill up"},
+	{Name: "K8S_SERVER_SERVICE_ACCOUNT", Description: "ServiceAccount the API impersonates in each server namespace, so that namespace's RoleBinding scopes it; unset uses the API's own"},
+
+	{Name: "PORT_RANGE_MIN", Default: "25501", Description: "First host port for game servers"},
+	{Name: "PORT_RANGE_MAX", Default: "25999", Description: "Last host port for game servers"},
+	{Name: "PORT_REUSE_COOLDOWN", Default: "15m", Description: "How long released ports are kept from other servers while other ports are free (0 disables)"},
+
+	{Name: "RESTART_BUDGET", Default: "5", Description: "Max user starts/restarts per server per window (0 disables)"},
+	{Name: "RESTART_BUDGET_WINDOW", Default: "10m", Description: "Restart budget window"},
+
+	{Name: "STOPPED_REMINDER_AFTER", Default: "168h", Description: "Remind owners of servers stopped this long that they're still billed (0 disables)"},
+
+	{Name: "ADMIN_EMAILS", Description: "Comma-separated admin emails; they receive dispute alerts and can use /admin endpoints"},
+
+	{Name: "ABUSE_CPU_PERCENT", Default: "0", Description: "Suspend servers whose game process uses this much CPU (percent of one core) for ABUSE_SUSTAINED_FOR (0 disables)"},
+	{Name: "ABUSE_NET_TX_MBPS", Default: "200", Description: "Suspend servers whose game sends more than this many Mbit/s for ABUSE_SUSTAINED_FOR, not counting backups and downloads served by the supervisor (0 disables)"},
+	{Name: "ABUSE_SUSTAINED_FOR", Default: "10m", Description: "How long an abuse threshold must be exceeded before suspending"},
+
+	{Name: "INTERNAL_RATE_LIMIT", Default: "120", Description: "Requests per minute each server's supervisor may make to the internal API (0 disables)"},
+	{Name: "INTERNAL_RATE_BURST", Default: "30", Description: "Burst of internal API requests allowed per server above INTERNAL_RATE_LIMIT"},
+	{Name: "INTERNAL_DEDUP_WINDOW", Default: "10s", Description: "Drop supervisor status reports and heartbeats identical to the server's previous one within this window (0 disables)"},
+	{Name: "SUPERVISOR_GRPC", Default: "true", Description: "Have supervisors use the gRPC internal protocol on port 8082 (false keeps them on the JSON endpoints)"},
+
+	{Name: "SSE_SLOW_CLIENT_TIMEOUT", Default: "30s", Description: "Disconnect status stream clients whose event buffer stays full this long (0 only drops their events)"},
+	{Name: "SSE_MAX_STREAMS_PER_USER", Default: "5", Description: "Status streams a user may have open at once; a new one closes their oldest (0 for no limit)"},
+	{Name: "SSE_MAX_STREAMS", Default: "2000", Description: "Status streams an API replica serves at once; more are refused with 429 (0 for no limit)"},
+
+	{Name: "ACCOUNT_SUSPEND_DISPUTES", Default: "2", Description: "Suspend accounts with this many payment disputes (0 disables)"},
+	{Name: "ACCOUNT_SUSPEND_ABUSE", Default: "2", Description: "Suspend accounts whose servers were suspended for abuse this many times (0 disables)"},
+
+	{Name: "CUSTOM_GAME_REGISTRIES", Description: "Comma-separated registries or registry paths custom game images may come from (empty disables custom games)"},
+
+	{Name: "DISCORD_BOT_SECRET", Secret: true, Description: "Shared secret the Discord bot authenticates to the internal API with (empty disables the Discord integration)"},
+
+	{Name: "EDGE_PROXY_SECRET", Secret: true, Description: "Shared secret the edge proxy authenticates to the internal API with (empty disables the edge proxy)"},
+	{Name: "EDGE_ADDRESS", Description: "Anycast address of the edge proxy shown to users (empty disables the edge proxy)"},
+	{Name: "EDGE_PORT_RANGE_MIN", Default: "30000", Description: "First edge proxy port for game servers"},
+	{Name: "EDGE_PORT_RANGE_MAX", Default: "39999", Description: "Last edge proxy port for game servers"},
+
+	{Name: "EXPORT_MAX_GB", Default: "20", Description: "Largest data volume, in GiB, a world export archives (0 means no limit)"},
+	{Name: "IMPORT_MAX_GB", Default: "20", Description: "Largest world archive, in GiB, owners can import, compressed or extracted (0 means no limit)"},
+
+	{Name: "FILE_ACCESS_IMAGE", Description: "Supervisor image run to give owners of expired servers access to their data (empty disables file access)"},
+	{Name: "FILE_UPLOAD_MAX_MB", Default: "1024", Description: "Largest file, in MiB, owners can upload to a running server (0 means no limit)"},
+
+	{Name: "MODRINTH_API_URL", Default: "https://api.modrinth.com/v2", Description: "Modrinth API mods are searched and downloaded through"},
+
+	{Name: "STEAM_WEB_API_KEY", Secret: true, Description: "Steam Web API publisher key for checking game ownership of linked Steam accounts and creating game server login tokens (empty disables both)"},
+
+	{Name: "BACKUP_REPLICA_ENDPOINT", Description: "S3-compatible endpoint of the off-site backup bucket, e.g. https://s3.eu-west-1.amazonaws.com (empty disables replication)"},
+	{Name: "BACKUP_REPLICA_REGION", Default: "us-east-1", Description: "Region the off-site backup bucket's requests are signed for"},
+	{Name: "BACKUP_REPLICA_BUCKET", Description: "Off-site backup bucket (empty disables replication)"},
+	{Name: "BACKUP_REPLICA_ACCESS_KEY_ID", Description: "Access key ID for the off-site backup bucket"},
+	{Name: "BACKUP_REPLICA_SECRET_ACCESS_KEY", Secret: true, Description: "Secret access key for the off-site backup bucket"},
+
+	{Name: "MIGRATIONS_DIR", Default: "migrations", Description: "Directory with SQL migrations"},
+}
+
+// envDefault returns the registered default for key
+func envDefault(key string) string {
+	for _, v := range EnvVars {
+		if v.Name == key {
+			return v.Default
+		}
+	}
+	panic("config: environment variable " + key + " is not registered in EnvVars")
+}
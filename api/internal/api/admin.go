@@ -0,0 +1,414 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/account"
+	"github.com/mooncorn/gshub/api/internal/services/broadcast"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/nodesync"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	stripeservice "github.com/mooncorn/gshub/api/internal/services/stripe"
+	"github.com/mooncorn/gshub/api/internal/services/suspension"
+)
+
+// AdminHandler serves the /admin endpoints, restricted to admins (see IsAdmin)
+type AdminHandler struct {
+	db            *database.DB
+	k8sClient     *k8s.Client
+	config        *config.Config
+	suspension    *suspension.Service
+	account       *account.Service
+	stripeService *stripeservice.Service
+	hub           *broadcast.Hub
+	machine       *serverstate.Machine
+	nodeSync      *nodesync.Service
+}
+
+func NewAdminHandler(db *database.DB, k8sClient *k8s.Client, cfg *config.Config, suspensionService *suspension.Service, accountService *account.Service, stripeService *stripeservice.Service, hub *broadcast.Hub, machine *serverstate.Machine, nodeSyncService *nodesync.Service) *AdminHandler {
+	return &AdminHandler{
+		db:            db,
+		k8sClient:     k8sClient,
+		config:        cfg,
+		suspension:    suspensionService,
+		account:       accountService,
+		stripeService: stripeService,
+		hub:           hub,
+		machine:       machine,
+		nodeSync:      nodeSyncService,
+	}
+}
+
+// ListDisputes returns the disputes whose server suspension hasn't been lifted yet
+func (h *AdminHandler) ListDisputes(c *gin.Context) {
+	disputes, err := h.db.ListUnresolvedDisputes(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to list disputes: %v", err)
+		c.Error(apierror.Internal("failed to list disputes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"disputes": disputes})
+}
+
+// LiftSuspension returns a suspended server to stopped so its owner can start it again,
+// optionally clearing the owner's account flag as well
+func (h *AdminHandler) LiftSuspension(c *gin.Context) {
+	var req models.LiftSuspensionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	lifted, err := h.suspension.Lift(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to lift suspension of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to lift suspension"))
+		return
+	}
+	if !lifted {
+		c.Error(apierror.InvalidServerState("server is not suspended"))
+		return
+	}
+
+	if req.ClearUserFlag {
+		if err := h.db.ClearUserFlag(c.Request.Context(), server.UserID); err != nil {
+			log.Printf("failed to clear flag of user %s: %v", server.UserID, err)
+			c.Error(apierror.Internal("failed to clear user flag"))
+			return
+		}
+	}
+
+	h.hub.Publish(server.UserID, broadcast.StatusEvent{
+		ServerID:  serverID,
+		Status:    string(models.ServerStatusStopped),
+		Timestamp: time.Now().UTC(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"status": "stopped", "message": "suspension lifted"})
+}
+
+// ListAbuseReports returns the abuse reports whose server suspension hasn't been lifted yet
+func (h *AdminHandler) ListAbuseReports(c *gin.Context) {
+	reports, err := h.db.ListUnresolvedAbuseReports(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to list abuse reports: %v", err)
+		c.Error(apierror.Internal("failed to list abuse reports"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// SetAbuseExempt exempts a reviewed server from the CPU and network anomaly rules
+func (h *AdminHandler) SetAbuseExempt(c *gin.Context) {
+	var req models.SetAbuseExemptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	serverID := c.Param("id")
+	if _, err := h.db.GetServerByID(c.Request.Context(), serverID); err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if err := h.db.SetServerAbuseExempt(c.Request.Context(), serverID, *req.Exempt); err != nil {
+		log.Printf("failed to set abuse exemption of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to update server"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"abuse_exempt": *req.Exempt})
+}
+
+// ListBannedHashes returns the binaries supervisors refuse to run
+func (h *AdminHandler) ListBannedHashes(c *gin.Context) {
+	hashes, err := h.db.ListBannedHashes(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to list banned hashes: %v", err)
+		c.Error(apierror.Internal("failed to list banned hashes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hashes": hashes})
+}
+
+// AddBannedHash bans a binary by SHA-256; supervisors check the list before starting the game
+func (h *AdminHandler) AddBannedHash(c *gin.Context) {
+	var req models.AddBannedHashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	hash, err := h.db.AddBannedHash(c.Request.Context(), strings.ToLower(req.SHA256), req.Name)
+	if err != nil {
+		log.Printf("failed to add banned hash: %v", err)
+		c.Error(apierror.Internal("failed to add banned hash"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, hash)
+}
+
+// DeleteBannedHash unbans a binary
+func (h *AdminHandler) DeleteBannedHash(c *gin.Context) {
+	deleted, err := h.db.DeleteBannedHash(c.Request.Context(), strings.ToLower(c.Param("sha256")))
+	if err != nil {
+		log.Printf("failed to delete banned hash: %v", err)
+		c.Error(apierror.Internal("failed to delete banned hash"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.NotFound("banned hash not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// ListSuspendedUsers returns suspended accounts, those asking to be reinstated first
+func (h *AdminHandler) ListSuspendedUsers(c *gin.Context) {
+	users, err := h.db.ListSuspendedUsers(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to list suspended users: %v", err)
+		c.Error(apierror.Internal("failed to list suspended users"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// SuspendUser makes an account read-only until it is reinstated
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	var req models.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+	if _, err := h.db.GetUserByID(c.Request.Context(), userID); err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+
+	suspended, err := h.account.Suspend(c.Request.Context(), userID, req.Reason)
+	if err != nil {
+		log.Printf("failed to suspend user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to suspend account"))
+		return
+	}
+	if !suspended {
+		c.Error(apierror.BadRequest("account is already suspended"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account suspended"})
+}
+
+// ReinstateUser lifts an account suspension. Server suspensions are lifted separately.
+func (h *AdminHandler) ReinstateUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+
+	reinstated, err := h.account.Reinstate(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to reinstate user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to reinstate account"))
+		return
+	}
+	if !reinstated {
+		c.Error(apierror.BadRequest("account is not suspended"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account reinstated"})
+}
+
+// ServerStates returns the server status transition table and a Mermaid diagram of it.
+// With ?format=mermaid only the diagram is returned, as plain text.
+func (h *AdminHandler) ServerStates(c *gin.Context) {
+	if c.Query("format") == "mermaid" {
+		c.String(http.StatusOK, serverstate.Mermaid())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"states":      serverstate.States,
+		"transitions": serverstate.Edges(),
+		"mermaid":     serverstate.Mermaid(),
+	})
+}
+
+// StatusStreamStats returns the status stream subscribers per user, their queued and dropped
+// events, and the hub's counters since startup
+func (h *AdminHandler) StatusStreamStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.hub.Stats())
+}
+
+// SetCatalogChannel moves a server to a game catalog channel, so game definitions in the
+// staging catalog can be tried on internal servers. The server picks up the channel's
+// definition when its deployment is next recreated (restart).
+func (h *AdminHandler) SetCatalogChannel(c *gin.Context) {
+	var req models.SetCatalogChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+	if !h.config.HasCatalogChannel(req.Channel) {
+		c.Error(apierror.BadRequest("catalog channel is not configured"))
+		return
+	}
+
+	serverID := c.Param("id")
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	game := server.Game
+	if req.Game != "" {
+		game = models.GameType(req.Game)
+	}
+
+	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.GameCatalogName(req.Channel))
+	if err != nil {
+		log.Printf("failed to load %s game catalog: %v", req.Channel, err)
+		c.Error(apierror.Internal("failed to load game catalog"))
+		return
+	}
+	gameConfig, err := catalog.GetGameConfig(string(game))
+	if err == nil {
+		_, err = gameConfig.GetPlanConfig(string(server.Plan))
+	}
+	if err != nil {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidGameOrPlan, err.Error()))
+		return
+	}
+	if issues := catalog.PlanIssues(string(game), string(server.Plan)); len(issues) > 0 {
+		c.Error(apierror.BadRequest("catalog entry is invalid").WithDetails(issues))
+		return
+	}
+
+	if err := h.db.SetServerCatalogChannel(c.Request.Context(), serverID, req.Channel, game); err != nil {
+		log.Printf("failed to set catalog channel of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to update server"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"catalog_channel": req.Channel,
+		"game":            game,
+		"message":         "Catalog channel updated. Restart the server to apply it.",
+	})
+}
+
+// InspectServer returns a server's live Deployment, pods, PVC and recent events, with secret
+// env values redacted, so operators can debug it without kubectl access
+func (h *AdminHandler) InspectServer(c *gin.Context) {
+	serverID := c.Param("id")
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	inspection, err := h.k8sClient.InspectServer(c.Request.Context(), server.K8sNamespace(h.config.K8sNamespace), serverID)
+	if err != nil {
+		log.Printf("failed to inspect server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to inspect server"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"namespace":  server.K8sNamespace(h.config.K8sNamespace),
+		"status":     server.Status,
+		"inspection": inspection,
+	})
+}
+
+// maxCatalogSize caps the games.yaml accepted by ValidateCatalog
+const maxCatalogSize = 1 << 20
+
+// ValidateCatalog checks the game catalog for problems that would break servers. GET checks
+// the live ConfigMap of ?channel (default production); POST checks a candidate games.yaml
+// sent as the request body.
+func (h *AdminHandler) ValidateCatalog(c *gin.Context) {
+	var catalog *k8s.GameCatalog
+	var err error
+	if c.Request.Method == http.MethodPost {
+		data, readErr := io.ReadAll(io.LimitReader(c.Request.Body, maxCatalogSize))
+		if readErr != nil {
+			c.Error(apierror.BadRequest("failed to read request body"))
+			return
+		}
+		if catalog, err = k8s.ParseGameCatalog(data); err != nil {
+			c.Error(apierror.BadRequest(err.Error()))
+			return
+		}
+	} else {
+		channel := c.DefaultQuery("channel", config.CatalogChannelProduction)
+		if !h.config.HasCatalogChannel(channel) {
+			c.Error(apierror.BadRequest("catalog channel is not configured"))
+			return
+		}
+		catalog, err = h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.GameCatalogName(channel))
+		if err != nil {
+			log.Printf("failed to load game catalog: %v", err)
+			c.Error(apierror.Internal("failed to load game catalog"))
+			return
+		}
+	}
+
+	issues := catalog.Validate()
+	c.JSON(http.StatusOK, gin.H{"valid": len(issues) == 0, "issues": issues})
+}
+
+// checkAccountFlagged rejects checkouts by users flagged for a payment dispute
+func checkAccountFlagged(ctx context.Context, db *database.DB, userID uuid.UUID) error {
+	flagged, err := db.IsUserFlagged(ctx, userID)
+	if err != nil {
+		log.Printf("failed to check user flag: %v", err)
+		return apierror.Internal("failed to check account status")
+	}
+	if flagged {
+		return apierror.ErrAccountFlagged
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/broadcast"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+)
+
+// forceStopFrom are the statuses an operator can force stop a server from: those a user
+// can stop it from, and stops that hang
+var forceStopFrom = append([]models.ServerStatus{models.ServerStatusStopping}, serverstate.StopFrom...)
+
+// requeueFrom are the statuses an operator can hand a server back to the reconciler from
+var requeueFrom = []models.ServerStatus{models.ServerStatusStarting, models.ServerStatusFailed}
+
+// IsAdmin reports whether the authenticated user may use the /admin API: their role is
+// admin, or their verified email is in ADMIN_EMAILS. The email comes from the user record
+// rather than the token, since logins don't require a verified email.
+func (h *AdminHandler) IsAdmin(c *gin.Context) (bool, error) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		return false, nil
+	}
+	user, err := h.db.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		return false, err
+	}
+	if user.Role == models.UserRoleAdmin {
+		return true, nil
+	}
+	return user.EmailVerified && h.config.IsAdmin(user.Email), nil
+}
+
+// SetUserRole grants or revokes a user's admin role
+func (h *AdminHandler) SetUserRole(c *gin.Context) {
+	var req models.SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+
+	updated, err := h.db.SetUserRole(c.Request.Context(), userID, models.UserRole(req.Role))
+	if err != nil {
+		log.Printf("failed to set role of user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to update user"))
+		return
+	}
+	if !updated {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": req.Role})
+}
+
+// ListServers returns servers across all users, filtered by ?status, ?user_id, a ?q search
+// and ?stuck, most recently updated first
+func (h *AdminHandler) ListServers(c *gin.Context) {
+	var filter models.AdminServerFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	servers, err := h.db.ListAllServers(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("failed to list servers: %v", err)
+		c.Error(apierror.Internal("failed to list servers"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"servers": servers})
+}
+
+// ForceStopServer stops a server without waiting for the game to shut down: its deployment
+// is scaled to 0, its pods are killed and it's marked stopped right away. Also ends stops
+// that hang in stopping.
+func (h *AdminHandler) ForceStopServer(c *gin.Context) {
+	serverID := c.Param("id")
+	if _, err := h.db.GetServerByID(c.Request.Context(), serverID); err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	ctx := c.Request.Context()
+	errNotStoppable := errors.New("server is not stoppable")
+	err := h.db.WithServerLock(ctx, serverID, func() error {
+		current, err := h.db.GetServerByID(ctx, serverID)
+		if err != nil {
+			return err
+		}
+		if !serverstate.In(current.Status, forceStopFrom) {
+			return errNotStoppable
+		}
+		if current.Status != models.ServerStatusStopping {
+			if _, err := h.machine.Transition(ctx, current, serverstate.Request{
+				From:    serverstate.StopFrom,
+				To:      models.ServerStatusStopping,
+				Message: "Stopping server...",
+			}); err != nil {
+				return err
+			}
+		}
+
+		namespace := current.K8sNamespace(h.config.K8sNamespace)
+		if err := h.k8sClient.ScaleGameDeployment(ctx, namespace, "server-"+serverID, 0); err != nil {
+			return err
+		}
+		if err := h.k8sClient.KillServerPods(ctx, namespace, serverID); err != nil {
+			return err
+		}
+		_, err = h.machine.Transition(ctx, current, serverstate.Request{
+			From:    []models.ServerStatus{models.ServerStatusStopping},
+			To:      models.ServerStatusStopped,
+			Message: "Stopped by an administrator",
+		})
+		return err
+	})
+	if errors.Is(err, errNotStoppable) {
+		c.Error(apierror.InvalidServerState("server cannot be stopped from current state"))
+		return
+	}
+	if err != nil {
+		log.Printf("failed to force stop server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to stop server"))
+		return
+	}
+
+	log.Printf("server %s force stopped by admin %s", serverID, middleware.GetUserID(c))
+	c.JSON(http.StatusOK, gin.H{"status": "stopped", "message": "server stopped"})
+}
+
+// ForceDeleteServer deletes a server without the 7-day grace period or confirmation: it
+// expires from any status, suspended included, its subscription is cancelled, and the
+// cleanup service removes its data on its next run
+func (h *AdminHandler) ForceDeleteServer(c *gin.Context) {
+	serverID := c.Param("id")
+	if _, err := h.db.GetServerByID(c.Request.Context(), serverID); err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	ctx := c.Request.Context()
+	var deleted bool
+	err := h.db.WithServerLock(ctx, serverID, func() error {
+		current, err := h.db.GetServerByID(ctx, serverID)
+		if err != nil {
+			return err
+		}
+		deleted, err = h.stripeService.ForceDeleteServer(ctx, current)
+		return err
+	})
+	if err != nil {
+		log.Printf("failed to force delete server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to delete server"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.InvalidServerState("server is already being deleted"))
+		return
+	}
+
+	log.Printf("server %s force deleted by admin %s", serverID, middleware.GetUserID(c))
+	c.JSON(http.StatusOK, gin.H{"status": "expired", "message": "server will be deleted on the next cleanup"})
+}
+
+// RequeueServer hands a server stuck in starting, or failed, back to the reconciler, which
+// recreates or updates its deployment
+func (h *AdminHandler) RequeueServer(c *gin.Context) {
+	serverID := c.Param("id")
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	transitioned, err := h.machine.Transition(c.Request.Context(), server, serverstate.Request{
+		From:    requeueFrom,
+		To:      models.ServerStatusPending,
+		Message: "Requeued by an administrator",
+	})
+	if err != nil {
+		log.Printf("failed to requeue server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to requeue server"))
+		return
+	}
+	if !transitioned {
+		c.Error(apierror.InvalidServerState("only starting or failed servers can be requeued"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "pending", "message": "server requeued"})
+}
+
+// ListNodes returns each node's port pool and resources, how much of them is in use, and
+// what this replica's last node sync did with it
+func (h *AdminHandler) ListNodes(c *gin.Context) {
+	h.respondNodes(c, h.nodeSync.LastSync())
+}
+
+// SyncNodes syncs the nodes with Kubernetes right away instead of waiting for the next
+// periodic sync, then responds like ListNodes
+func (h *AdminHandler) SyncNodes(c *gin.Context) {
+	status, err := h.nodeSync.Sync(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to sync nodes: %v", err)
+		c.Error(apierror.Internal("failed to sync nodes").WithDetails(gin.H{"last_sync": status}))
+		return
+	}
+
+	log.Printf("node sync run by admin %s", middleware.GetUserID(c))
+	h.respondNodes(c, status)
+}
+
+func (h *AdminHandler) respondNodes(c *gin.Context, lastSync *models.NodeSyncStatus) {
+	nodes, err := h.db.ListNodeCapacity(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to list node capacity: %v", err)
+		c.Error(apierror.Internal("failed to list nodes"))
+		return
+	}
+
+	if lastSync != nil {
+		results := make(map[string]models.NodeSyncResult, len(lastSync.Nodes))
+		for _, result := range lastSync.Nodes {
+			results[result.Name] = result
+		}
+		for i := range nodes {
+			if result, ok := results[nodes[i].Name]; ok {
+				nodes[i].LastSync = &result
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"nodes": nodes, "last_sync": lastSync})
+}
+
+// StreamUserStatus streams a user's status events via SSE as they see them, so operators
+// can follow what a user reports
+func (h *AdminHandler) StreamUserStatus(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+	if _, err := h.db.GetUserByID(c.Request.Context(), userID); err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+
+	log.Printf("admin %s streaming status of user %s", middleware.GetUserID(c), userID)
+	streamStatus(c, h.db, h.hub, userID, broadcast.SubscribeOptions{Watcher: true})
+}
@@ -0,0 +1,262 @@
+// Package apierror defines the typed errors returned by API handlers.
+// Handlers attach an *Error to the gin context with c.Error and the
+// middleware.ErrorHandler renders it as the standard error envelope.
+package apierror
+
+import (
+	"net/http"
+)
+
+// Code is a stable, machine-readable error identifier the frontend can branch on
+type Code string
+
+const (
+	// Generic codes
+	CodeBadRequest   Code = "BAD_REQUEST"
+	CodeValidation   Code = "VALIDATION_FAILED"
+	CodeUnauthorized Code = "UNAUTHORIZED"
+	CodeForbidden    Code = "FORBIDDEN"
+	CodeNotFound     Code = "NOT_FOUND"
+	CodeConflict     Code = "CONFLICT"
+	CodeInternal     Code = "INTERNAL_ERROR"
+	CodeRateLimited  Code = "RATE_LIMITED"
+
+	// Auth codes
+	CodeInvalidCredentials   Code = "INVALID_CREDENTIALS"
+	CodeInvalidToken         Code = "INVALID_TOKEN"
+	CodeEmailTaken           Code = "EMAIL_TAKEN"
+	CodeEmailAlreadyVerified Code = "EMAIL_ALREADY_VERIFIED"
+	CodeInvalidMFACode       Code = "INVALID_MFA_CODE"
+
+	// Server codes
+	CodeServerNotFound        Code = "SERVER_NOT_FOUND"
+	CodeInvalidServerState    Code = "INVALID_SERVER_STATE"
+	CodeSubdomainTaken        Code = "SUBDOMAIN_TAKEN"
+	CodeCapacityUnavailable   Code = "CAPACITY_UNAVAILABLE"
+	CodeInvalidGameOrPlan     Code = "INVALID_GAME_OR_PLAN"
+	CodeLogsUnavailable       Code = "LOGS_UNAVAILABLE"
+	CodeNoUpgradeAvailable    Code = "NO_UPGRADE_AVAILABLE"
+	CodeRestartCooldown       Code = "RESTART_COOLDOWN"
+	CodeCommandNotFound       Code = "COMMAND_NOT_FOUND"
+	CodeLiveReloadUnsupported Code = "LIVE_RELOAD_UNSUPPORTED"
+	CodeConfirmationMismatch  Code = "CONFIRMATION_MISMATCH"
+	CodeServerSuspended       Code = "SERVER_SUSPENDED"
+	CodeWebhookNotFound       Code = "WEBHOOK_NOT_FOUND"
+	CodeWebhookLimit          Code = "WEBHOOK_LIMIT"
+	CodeTemplateNotFound      Code = "TEMPLATE_NOT_FOUND"
+	CodeServerGroupNotFound   Code = "SERVER_GROUP_NOT_FOUND"
+	CodeServerGroupLimit      Code = "SERVER_GROUP_LIMIT"
+	CodeEnvRevisionNotFound   Code = "ENV_REVISION_NOT_FOUND"
+	CodeCustomGamesDisabled   Code = "CUSTOM_GAMES_DISABLED"
+	CodeImageNotAllowed       Code = "IMAGE_NOT_ALLOWED"
+	CodeInvalidCustomGame     Code = "INVALID_CUSTOM_GAME"
+	CodeCustomDomainNotFound  Code = "CUSTOM_DOMAIN_NOT_FOUND"
+	CodeCustomDomainLimit     Code = "CUSTOM_DOMAIN_LIMIT"
+	CodeCustomDomainTaken     Code = "CUSTOM_DOMAIN_TAKEN"
+	CodeFileAccessNotReady    Code = "FILE_ACCESS_NOT_READY"
+	CodeImportUnsupported     Code = "IMPORT_UNSUPPORTED"
+	CodeImportNotReady        Code = "IMPORT_NOT_READY"
+	CodeImportTooLarge        Code = "IMPORT_TOO_LARGE"
+	CodeBackupLimit           Code = "BACKUP_LIMIT"
+	CodeConsoleCommandFailed  Code = "CONSOLE_COMMAND_FAILED"
+	CodeFileTooLarge          Code = "FILE_TOO_LARGE"
+	CodeWaitlistOfferInvalid  Code = "WAITLIST_OFFER_INVALID"
+	CodeNodeNotJoined         Code = "NODE_NOT_JOINED"
+	CodeScheduleNotFound      Code = "SCHEDULE_NOT_FOUND"
+	CodeScheduleLimit         Code = "SCHEDULE_LIMIT"
+	CodePortUnavailable       Code = "PORT_UNAVAILABLE"
+	CodeTooManyStreams        Code = "TOO_MANY_STREAMS"
+
+	// Integration codes
+	CodeDiscordLinkCodeInvalid Code = "DISCORD_LINK_CODE_INVALID"
+	CodeDiscordNotLinked       Code = "DISCORD_NOT_LINKED"
+	CodeAccountAlreadyLinked   Code = "ACCOUNT_ALREADY_LINKED"
+	CodeSteamLinkFailed        Code = "STEAM_LINK_FAILED"
+	CodeSteamNotLinked         Code = "STEAM_NOT_LINKED"
+	CodeSteamNotOwned          Code = "STEAM_NOT_OWNED"
+
+	// Billing codes
+	CodeNoSubscription     Code = "NO_SUBSCRIPTION"
+	CodeSpendLimitExceeded Code = "SPEND_LIMIT_EXCEEDED"
+	CodeAccountFlagged     Code = "ACCOUNT_FLAGGED"
+	CodeAccountSuspended   Code = "ACCOUNT_SUSPENDED"
+)
+
+// Error is an API error with an HTTP status, a stable code and a user-facing message
+type Error struct {
+	Status  int    // HTTP status code to return
+	Code    Code   // Machine-readable code
+	Message string // User-facing message
+	Details any    // Optional structured details (e.g. per-field validation errors)
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// New creates a new Error
+func New(status int, code Code, message string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// WithDetails returns a copy of the error carrying the given details
+func (e *Error) WithDetails(details any) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// Body is the JSON representation of an error
+type Body struct {
+	Code      Code   `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Response is the standard error envelope: {"error": {...}}
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Response builds the error envelope for this error
+func (e *Error) Response(requestID string) Response {
+	return Response{
+		Error: Body{
+			Code:      e.Code,
+			Message:   e.Message,
+			Details:   e.Details,
+			RequestID: requestID,
+		},
+	}
+}
+
+// BadRequest creates a 400 error with the generic BAD_REQUEST code
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Unauthorized creates a 401 error with the generic UNAUTHORIZED code
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// NotFound creates a 404 error with the generic NOT_FOUND code
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Internal creates a 500 error with the generic INTERNAL_ERROR code.
+// The message is shown to clients, so never include internal error details.
+func Internal(message string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, message)
+}
+
+// InvalidServerState creates a 400 error for actions not allowed in the server's current status
+func InvalidServerState(message string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidServerState, message)
+}
+
+// RateLimited creates a 429 error for clients making requests faster than they're allowed to
+func RateLimited(retryAfterSeconds int) *Error {
+	return New(http.StatusTooManyRequests, CodeRateLimited, "too many requests, please slow down").
+		WithDetails(map[string]int{"retry_after_seconds": retryAfterSeconds})
+}
+
+// RestartCooldown creates a 429 error for servers that exhausted their restart budget
+func RestartCooldown(retryAfterSeconds int) *Error {
+	return New(http.StatusTooManyRequests, CodeRestartCooldown,
+		"server was restarted too many times, please wait before trying again").
+		WithDetails(map[string]int{"retry_after_seconds": retryAfterSeconds})
+}
+
+// Common errors
+var (
+	ErrUnauthorized          = Unauthorized("unauthorized")
+	ErrInvalidUserID         = Unauthorized("invalid user ID")
+	ErrServerIDRequired      = BadRequest("server ID required")
+	ErrServerNotFound        = New(http.StatusNotFound, CodeServerNotFound, "server not found")
+	ErrSubdomainTaken        = New(http.StatusConflict, CodeSubdomainTaken, "subdomain already taken")
+	ErrInvalidCredentials    = New(http.StatusUnauthorized, CodeInvalidCredentials, "invalid credentials")
+	ErrEmailTaken            = New(http.StatusConflict, CodeEmailTaken, "user already exists")
+	ErrInvalidMFACode        = New(http.StatusBadRequest, CodeInvalidMFACode, "invalid verification code")
+	ErrIncorrectPassword     = New(http.StatusBadRequest, CodeInvalidCredentials, "incorrect password")
+	ErrNoSubscription        = New(http.StatusBadRequest, CodeNoSubscription, "server has no active subscription")
+	ErrNoUpgradeAvailable    = New(http.StatusBadRequest, CodeNoUpgradeAvailable, "no larger plan is available for this server")
+	ErrCommandNotFound       = New(http.StatusNotFound, CodeCommandNotFound, "command not found")
+	ErrWebhookNotFound       = New(http.StatusNotFound, CodeWebhookNotFound, "webhook not found")
+	ErrScheduleNotFound      = New(http.StatusNotFound, CodeScheduleNotFound, "schedule not found")
+	ErrTemplateNotFound      = New(http.StatusNotFound, CodeTemplateNotFound, "template not found")
+	ErrServerGroupNotFound   = New(http.StatusNotFound, CodeServerGroupNotFound, "server group not found")
+	ErrEnvRevisionNotFound   = New(http.StatusNotFound, CodeEnvRevisionNotFound, "environment revision not found")
+	ErrLiveReloadUnsupported = New(http.StatusBadRequest, CodeLiveReloadUnsupported,
+		"this game does not support applying changes without a restart")
+	ErrDeleteConfirmationMismatch = New(http.StatusBadRequest, CodeConfirmationMismatch,
+		"confirmation does not match the server's subdomain")
+	ErrCapacityUnavailable = New(http.StatusServiceUnavailable, CodeCapacityUnavailable,
+		"No server capacity available at this time. Please try again later.")
+	ErrWaitlistOfferInvalid = New(http.StatusConflict, CodeWaitlistOfferInvalid,
+		"this waitlist offer has expired or was already used")
+	ErrSpendLimitExceeded = New(http.StatusForbidden, CodeSpendLimitExceeded,
+		"this purchase would exceed your monthly spending limit")
+	ErrServerSuspended = New(http.StatusForbidden, CodeServerSuspended,
+		"server is suspended pending review")
+	ErrAccountFlagged = New(http.StatusForbidden, CodeAccountFlagged,
+		"your account is under review, please contact support")
+	ErrAccountSuspended = New(http.StatusForbidden, CodeAccountSuspended,
+		"your account is suspended and read-only until reinstated")
+	ErrAdminRequired  = New(http.StatusForbidden, CodeForbidden, "admin access required")
+	ErrTokenScope     = New(http.StatusForbidden, CodeForbidden, "token is not allowed to access this endpoint")
+	ErrTooManyStreams = New(http.StatusTooManyRequests, CodeTooManyStreams,
+		"too many live status connections, please try again shortly")
+	ErrTooManyUserStreams = New(http.StatusConflict, CodeTooManyStreams,
+		"too many status streams are open for this account, close another tab or window")
+	ErrDiscordLinkCodeInvalid = New(http.StatusBadRequest, CodeDiscordLinkCodeInvalid,
+		"link code is invalid or expired")
+	ErrDiscordNotLinked = New(http.StatusNotFound, CodeDiscordNotLinked,
+		"no GSHUB account is linked to this Discord account")
+	ErrDiscordDisabled      = NotFound("discord integration is not enabled")
+	ErrAccountAlreadyLinked = New(http.StatusConflict, CodeAccountAlreadyLinked,
+		"this account is already linked to another user")
+	ErrSteamLinkFailed = New(http.StatusBadRequest, CodeSteamLinkFailed,
+		"could not verify the Steam sign-in, please try again")
+	ErrSteamNotLinked = New(http.StatusForbidden, CodeSteamNotLinked,
+		"the server owner needs to link a Steam account first")
+	ErrSteamNotOwned = New(http.StatusForbidden, CodeSteamNotOwned,
+		"the server owner's Steam account doesn't own this game")
+	ErrSteamPrivateProfile = New(http.StatusForbidden, CodeSteamNotOwned,
+		"the server owner's Steam game details are private, make them public so ownership can be checked")
+	ErrSteamDisabled       = NotFound("steam integration is not enabled")
+	ErrCustomGamesDisabled = New(http.StatusBadRequest, CodeCustomGamesDisabled,
+		"custom games are not available")
+	ErrImageNotAllowed = New(http.StatusBadRequest, CodeImageNotAllowed,
+		"images must come from an allowed registry")
+	ErrCustomGameRequired = New(http.StatusBadRequest, CodeInvalidCustomGame,
+		"custom games need a game definition")
+	ErrNotCustomGame        = BadRequest("server is not a custom game")
+	ErrCustomDomainNotFound = New(http.StatusNotFound, CodeCustomDomainNotFound, "custom domain not found")
+	ErrCustomDomainExists   = New(http.StatusConflict, CodeCustomDomainTaken,
+		"domain is already added to this server")
+	ErrCustomDomainTaken = New(http.StatusConflict, CodeCustomDomainTaken,
+		"domain is already verified for another server")
+	ErrNoCommandArtifact   = NotFound("command has no archive to download")
+	ErrDownloadLinkInvalid = New(http.StatusForbidden, CodeForbidden, "download link is invalid or expired")
+	ErrReminderLinkInvalid = New(http.StatusForbidden, CodeForbidden, "cancel link is invalid or expired")
+	ErrFileAccessDisabled  = NotFound("file access is not enabled")
+	ErrFileAccessNotReady  = New(http.StatusConflict, CodeFileAccessNotReady,
+		"file access is not started or not ready yet")
+	ErrImportUnsupported = New(http.StatusBadRequest, CodeImportUnsupported,
+		"this game does not support importing worlds")
+	ErrImportNotReady = New(http.StatusConflict, CodeImportNotReady,
+		"server is still starting, retry the upload shortly")
+	ErrImportNotWaiting = New(http.StatusConflict, CodeConflict,
+		"import is not waiting for an upload")
+	ErrConsoleCommandFailed = New(http.StatusBadGateway, CodeConsoleCommandFailed,
+		"the game could not run the command")
+	ErrBackupReplicationUnavailable = New(http.StatusBadRequest, CodeBackupLimit,
+		"off-site backup replication is not available on this server's plan")
+)
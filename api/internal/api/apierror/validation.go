@@ -0,0 +1,74 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FromBindError converts an error returned by c.ShouldBindJSON into an API error.
+// Validation failures become VALIDATION_FAILED with per-field messages in details,
+// e.g. {"subdomain": "must be a valid DNS label"}. Any other binding error
+// (malformed JSON, wrong types) is reported as a generic invalid body.
+func FromBindError(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return BadRequest("invalid request body")
+	}
+
+	fields := make(map[string]string, len(validationErrs))
+	for _, fe := range validationErrs {
+		field := fe.Field()
+		if _, exists := fields[field]; exists {
+			continue // Keep the first failure per field
+		}
+		fields[field] = validationMessage(fe)
+	}
+
+	return New(http.StatusBadRequest, CodeValidation, "request validation failed").WithDetails(fields)
+}
+
+// validationMessage returns a user-facing message for a single field failure
+func validationMessage(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "dns":
+		return "must be a valid DNS label"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	default:
+		return "is invalid"
+	}
+}
+
+// JSONFieldName reports struct fields by their JSON name so validation details
+// match the request body. Register it with validator.RegisterTagNameFunc.
+func JSONFieldName(fld reflect.StructField) string {
+	name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return fld.Name
+	}
+	return name
+}
@@ -0,0 +1,41 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromBindError_ValidationErrors(t *testing.T) {
+	type request struct {
+		Email     string `json:"email" validate:"required,email"`
+		Subdomain string `json:"subdomain" validate:"required,min=3"`
+		Plan      string `json:"plan" validate:"oneof=small medium"`
+	}
+
+	v := validator.New()
+	v.RegisterTagNameFunc(JSONFieldName)
+
+	err := v.Struct(request{Email: "not-an-email", Subdomain: "ab", Plan: "huge"})
+	require.Error(t, err)
+
+	apiErr := FromBindError(err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, CodeValidation, apiErr.Code)
+	assert.Equal(t, map[string]string{
+		"email":     "must be a valid email address",
+		"subdomain": "must be at least 3 characters",
+		"plan":      "must be one of: small, medium",
+	}, apiErr.Details)
+}
+
+func TestFromBindError_NonValidationError(t *testing.T) {
+	apiErr := FromBindError(errors.New("unexpected EOF"))
+	assert.Equal(t, CodeBadRequest, apiErr.Code)
+	assert.Equal(t, "invalid request body", apiErr.Message)
+	assert.Nil(t, apiErr.Details)
+}
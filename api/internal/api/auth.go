@@ -4,23 +4,32 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
 	"github.com/mooncorn/gshub/api/internal/api/middleware"
 	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/account"
 	"github.com/mooncorn/gshub/api/internal/services/auth"
 	"github.com/mooncorn/gshub/api/internal/services/email"
 )
 
 type AuthHandler struct {
-	authService  *auth.Service
-	emailService *email.Service
+	authService    *auth.Service
+	emailService   *email.Service
+	accountService *account.Service
+
+	// mfaLimiter limits two-factor code attempts per user, so codes can't be guessed
+	mfaLimiter *middleware.RateLimiter
 }
 
-func NewAuthHandler(authService *auth.Service, emailService *email.Service) *AuthHandler {
+func NewAuthHandler(authService *auth.Service, emailService *email.Service, accountService *account.Service) *AuthHandler {
 	return &AuthHandler{
-		authService:  authService,
-		emailService: emailService,
+		authService:    authService,
+		emailService:   emailService,
+		accountService: accountService,
+		mfaLimiter:     middleware.NewRateLimiter(mfaAttemptsPerMinute, mfaAttemptsPerMinute),
 	}
 }
 
@@ -40,6 +49,14 @@ type AuthResponse struct {
 	User         *models.UserResponse `json:"user"`
 }
 
+// MFARequiredResponse is returned by Login instead of tokens for users with two-factor
+// authentication; the login continues at LoginMFA with the token
+type MFARequiredResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
 type VerifyEmailRequest struct {
 	Token string `json:"token" binding:"required"`
 }
@@ -61,28 +78,28 @@ type ResetPasswordRequest struct {
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
 	// Check if user already exists
 	existingUser, _ := h.authService.GetUserByEmail(c.Request.Context(), strings.ToLower(req.Email))
 	if existingUser != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "user already exists"})
+		c.Error(apierror.ErrEmailTaken)
 		return
 	}
 
 	// Create user
 	user, err := h.authService.CreateUser(c.Request.Context(), strings.ToLower(req.Email), req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		c.Error(apierror.Internal("failed to create user"))
 		return
 	}
 
 	// Generate verification token
 	verificationToken, err := h.authService.GenerateVerificationToken(c.Request.Context(), user.ID.String())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate verification token"})
+		c.Error(apierror.Internal("failed to generate verification token"))
 		return
 	}
 
@@ -107,40 +124,51 @@ func (h *AuthHandler) Register(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
 	// Get user by email
 	user, err := h.authService.GetUserByEmail(c.Request.Context(), strings.ToLower(req.Email))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		c.Error(apierror.ErrInvalidCredentials)
 		return
 	}
 
 	// Compare password
 	if err := h.authService.ComparePassword(user.PasswordHash, req.Password); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		c.Error(apierror.ErrInvalidCredentials)
 		return
 	}
 
-	// Generate access token
-	accessToken, err := h.authService.GenerateAccessToken(user)
+	// With two-factor authentication, tokens are issued by LoginMFA once the code is entered
+	mfa, err := h.authService.GetMFA(c.Request.Context(), user.ID.String())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		log.Printf("failed to get mfa of user %s: %v", user.ID, err)
+		c.Error(apierror.Internal("failed to log in"))
+		return
+	}
+	if mfa.Enabled() {
+		mfaToken, expiresAt, err := h.authService.GenerateMFAToken(user, middleware.ScopeMFALogin)
+		if err != nil {
+			c.Error(apierror.Internal("failed to generate token"))
+			return
+		}
+		c.JSON(http.StatusOK, MFARequiredResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresAt:   expiresAt,
+		})
 		return
 	}
 
-	// Generate refresh token
-	refreshToken, err := h.authService.GenerateRefreshToken()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
-		return
-	}
+	h.issueTokens(c, user)
+}
 
-	// Save refresh token
-	if err := h.authService.SaveRefreshToken(c.Request.Context(), user.ID.String(), refreshToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save refresh token"})
+// issueTokens responds with a new access token and refresh token for a logged-in user
+func (h *AuthHandler) issueTokens(c *gin.Context, user *models.User) {
+	accessToken, refreshToken, ok := h.newTokens(c, user)
+	if !ok {
 		return
 	}
 
@@ -151,6 +179,31 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// newTokens generates an access token and saves a new refresh token for the user.
+// Otherwise it sets the error and returns false.
+func (h *AuthHandler) newTokens(c *gin.Context, user *models.User) (string, string, bool) {
+	// Generate access token
+	accessToken, err := h.authService.GenerateAccessToken(user)
+	if err != nil {
+		c.Error(apierror.Internal("failed to generate token"))
+		return "", "", false
+	}
+
+	// Generate refresh token
+	refreshToken, err := h.authService.GenerateRefreshToken()
+	if err != nil {
+		c.Error(apierror.Internal("failed to generate refresh token"))
+		return "", "", false
+	}
+
+	// Save refresh token
+	if err := h.authService.SaveRefreshToken(c.Request.Context(), user.ID.String(), refreshToken); err != nil {
+		c.Error(apierror.Internal("failed to save refresh token"))
+		return "", "", false
+	}
+	return accessToken, refreshToken, true
+}
+
 // Logout invalidates the refresh token
 func (h *AuthHandler) Logout(c *gin.Context) {
 	type LogoutRequest struct {
@@ -159,13 +212,13 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 	var req LogoutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
 	// Delete refresh token
 	if err := h.authService.DeleteRefreshToken(c.Request.Context(), req.RefreshToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		c.Error(apierror.Internal("failed to logout"))
 		return
 	}
 
@@ -180,46 +233,46 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 
 	var req RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
 	// Validate refresh token
 	userID, err := h.authService.ValidateRefreshToken(c.Request.Context(), req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeInvalidToken, err.Error()))
 		return
 	}
 
 	// Get user
 	user, err := h.authService.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		c.Error(apierror.Unauthorized("user not found"))
 		return
 	}
 
 	// Generate new access token
 	accessToken, err := h.authService.GenerateAccessToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		c.Error(apierror.Internal("failed to generate token"))
 		return
 	}
 
 	// Generate new refresh token
 	newRefreshToken, err := h.authService.GenerateRefreshToken()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		c.Error(apierror.Internal("failed to generate refresh token"))
 		return
 	}
 
 	// Delete old refresh token and save new one
 	if err := h.authService.DeleteRefreshToken(c.Request.Context(), req.RefreshToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to invalidate old token"})
+		c.Error(apierror.Internal("failed to invalidate old token"))
 		return
 	}
 
 	if err := h.authService.SaveRefreshToken(c.Request.Context(), user.ID.String(), newRefreshToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save refresh token"})
+		c.Error(apierror.Internal("failed to save refresh token"))
 		return
 	}
 
@@ -233,20 +286,20 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 	var req VerifyEmailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
 	// Validate token
 	userID, err := h.authService.ValidateVerificationToken(c.Request.Context(), req.Token)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidToken, err.Error()))
 		return
 	}
 
 	// Mark email as verified
 	if err := h.authService.VerifyEmail(c.Request.Context(), userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		c.Error(apierror.Internal("failed to verify email"))
 		return
 	}
 
@@ -257,7 +310,7 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 func (h *AuthHandler) ResendVerification(c *gin.Context) {
 	var req ResendVerificationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
@@ -271,20 +324,20 @@ func (h *AuthHandler) ResendVerification(c *gin.Context) {
 
 	// Check if already verified
 	if user.EmailVerified {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "email already verified"})
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeEmailAlreadyVerified, "email already verified"))
 		return
 	}
 
 	// Generate new verification token
 	verificationToken, err := h.authService.GenerateVerificationToken(c.Request.Context(), user.ID.String())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate verification token"})
+		c.Error(apierror.Internal("failed to generate verification token"))
 		return
 	}
 
 	// Send verification email
 	if err := h.emailService.SendVerificationEmail(user.Email, verificationToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send verification email"})
+		c.Error(apierror.Internal("failed to send verification email"))
 		return
 	}
 
@@ -295,7 +348,7 @@ func (h *AuthHandler) ResendVerification(c *gin.Context) {
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var req ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
@@ -310,13 +363,13 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	// Generate reset token
 	resetToken, err := h.authService.GeneratePasswordResetToken(c.Request.Context(), user.ID.String())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate reset token"})
+		c.Error(apierror.Internal("failed to generate reset token"))
 		return
 	}
 
 	// Send reset email
 	if err := h.emailService.SendPasswordResetEmail(user.Email, resetToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send reset email"})
+		c.Error(apierror.Internal("failed to send reset email"))
 		return
 	}
 
@@ -327,20 +380,20 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
 	// Validate token
 	userID, err := h.authService.ValidatePasswordResetToken(c.Request.Context(), req.Token)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidToken, err.Error()))
 		return
 	}
 
 	// Update password
 	if err := h.authService.UpdatePassword(c.Request.Context(), userID, req.Password); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
+		c.Error(apierror.Internal("failed to update password"))
 		return
 	}
 
@@ -363,7 +416,7 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 	user, err := h.authService.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		c.Error(apierror.NotFound("user not found"))
 		return
 	}
 
@@ -380,7 +433,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 	var req UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
@@ -390,3 +443,33 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		"user_id": userID,
 	})
 }
+
+// RequestReinstatement lets a suspended user ask the admins to reinstate their account
+func (h *AuthHandler) RequestReinstatement(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.ReinstatementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	user, err := h.authService.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+
+	requested, err := h.accountService.RequestReinstatement(c.Request.Context(), user, req.Message)
+	if err != nil {
+		log.Printf("failed to request reinstatement for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to request reinstatement"))
+		return
+	}
+	if !requested {
+		c.Error(apierror.BadRequest("account is not suspended"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reinstatement requested"})
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// GetBackupPolicy returns how many backups the server keeps and whether they're replicated
+// off-site, along with the limits of its plan
+func (h *ServerHandler) GetBackupPolicy(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	limits, err := h.backupLimits(ctx, server)
+	if err != nil {
+		log.Printf("failed to get backup limits of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to get backup policy"))
+		return
+	}
+	policy, err := h.backupPolicy(ctx, server, limits)
+	if err != nil {
+		log.Printf("failed to get backup policy of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to get backup policy"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy, "limits": limits})
+}
+
+// UpdateBackupPolicy changes how many backups the server keeps and whether they're
+// replicated off-site, within its plan's limits. It applies from the next backup; turning
+// replication off removes the server's off-site copies.
+func (h *ServerHandler) UpdateBackupPolicy(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	var req models.UpdateBackupPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	serverID := server.ID.String()
+	limits, err := h.backupLimits(ctx, server)
+	if err != nil {
+		log.Printf("failed to get backup limits of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to update backup policy"))
+		return
+	}
+
+	if req.KeepDaily > limits.MaxDaily || req.KeepWeekly > limits.MaxWeekly {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeBackupLimit,
+			fmt.Sprintf("this server's plan keeps at most %d daily and %d weekly backups", limits.MaxDaily, limits.MaxWeekly)))
+		return
+	}
+	if req.Replicate && !limits.Replication {
+		c.Error(apierror.ErrBackupReplicationUnavailable)
+		return
+	}
+
+	policy, err := h.db.SetBackupPolicy(ctx, serverID, models.BackupPolicy{
+		KeepDaily:  req.KeepDaily,
+		KeepWeekly: req.KeepWeekly,
+		Replicate:  req.Replicate,
+	})
+	if err != nil {
+		log.Printf("failed to set backup policy of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to update backup policy"))
+		return
+	}
+
+	if !policy.Replicate {
+		if err := h.db.MarkServerBackupReplicasDeleting(ctx, serverID); err != nil {
+			log.Printf("failed to remove backup replicas of server %s: %v", serverID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy, "limits": limits})
+}
+
+// ListBackupReplicas returns the server's off-site backup copies, newest first
+func (h *ServerHandler) ListBackupReplicas(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	replicas, err := h.db.ListBackupReplicas(c.Request.Context(), server.ID.String())
+	if err != nil {
+		log.Printf("failed to list backup replicas of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to list backup replicas"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replicas": replicas})
+}
+
+// getBackupServer returns the server in the path if the user owns it. Otherwise it sets
+// the error and returns nil.
+func (h *ServerHandler) getBackupServer(c *gin.Context) *models.Server {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return nil
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return nil
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return nil
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return nil
+	}
+	return server
+}
+
+// backupLimits returns the backup retention and replication the server's plan allows.
+// Replication is only offered when an off-site bucket is configured.
+func (h *ServerHandler) backupLimits(ctx context.Context, server *models.Server) (models.BackupLimits, error) {
+	catalog, err := h.k8sClient.LoadGameCatalog(ctx, h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err != nil {
+		return models.BackupLimits{}, fmt.Errorf("failed to load game catalog: %w", err)
+	}
+	gameConfig, err := h.serverGameConfig(ctx, server, catalog)
+	if err != nil {
+		return models.BackupLimits{}, err
+	}
+	planConfig, err := gameConfig.GetPlanConfig(string(server.Plan))
+	if err != nil {
+		return models.BackupLimits{}, err
+	}
+
+	limits := planConfig.BackupLimits()
+	limits.Replication = limits.Replication && h.config.BackupReplicationEnabled()
+	return limits, nil
+}
+
+// backupPolicy returns the server's backup policy within limits, or the plan's default if
+// the owner hasn't set one
+func (h *ServerHandler) backupPolicy(ctx context.Context, server *models.Server, limits models.BackupLimits) (models.BackupPolicy, error) {
+	policy, err := h.db.GetBackupPolicy(ctx, server.ID.String())
+	if err != nil {
+		return models.BackupPolicy{}, err
+	}
+	if policy == nil {
+		return models.DefaultBackupPolicy(limits), nil
+	}
+	return policy.Within(limits), nil
+}
+
+// BackupCommandPayload returns the payload of a backup command for the server, carrying
+// the retention the supervisor prunes old archives by and whether the archive is replicated
+func (h *ServerHandler) BackupCommandPayload(ctx context.Context, server *models.Server) (string, error) {
+	limits, err := h.backupLimits(ctx, server)
+	if err != nil {
+		return "", err
+	}
+	policy, err := h.backupPolicy(ctx, server, limits)
+	if err != nil {
+		return "", err
+	}
+
+	retention := policy.Retention()
+	payload, err := json.Marshal(models.BackupCommandPayload{Retention: &retention, Replicate: policy.Replicate})
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"time"
@@ -8,9 +10,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
 	"github.com/mooncorn/gshub/api/internal/api/middleware"
 	"github.com/mooncorn/gshub/api/internal/database"
 	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/reminder"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
 	stripeservice "github.com/mooncorn/gshub/api/internal/services/stripe"
 )
 
@@ -28,17 +33,94 @@ func NewBillingHandler(db *database.DB, cfg *config.Config, stripeSvc *stripeser
 	}
 }
 
+// GetPaymentMethod returns the saved card the user can pay with instead of going through Checkout
+func (h *BillingHandler) GetPaymentMethod(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	user, err := h.db.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get user: %v", err)
+		c.Error(apierror.Internal("failed to get user"))
+		return
+	}
+
+	card, err := h.stripeService.SavedCard(c.Request.Context(), user)
+	if err != nil {
+		log.Printf("failed to get saved card: %v", err)
+		c.Error(apierror.Internal("failed to get payment method"))
+		return
+	}
+
+	resp := models.PaymentMethodResponse{}
+	if card != nil && card.Card != nil {
+		resp.Card = &models.SavedCard{
+			Brand:    string(card.Card.Brand),
+			Last4:    card.Card.Last4,
+			ExpMonth: card.Card.ExpMonth,
+			ExpYear:  card.Card.ExpYear,
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreatePortalSession returns a link to the Stripe customer portal, where the user can update
+// their saved card. The link is short-lived, so it's created when the user asks for it.
+func (h *BillingHandler) CreatePortalSession(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	user, err := h.db.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get user: %v", err)
+		c.Error(apierror.Internal("failed to get user"))
+		return
+	}
+
+	url, err := h.stripeService.CreatePortalSession(c.Request.Context(), user)
+	if errors.Is(err, stripeservice.ErrSavedCardUnavailable) {
+		c.Error(apierror.BadRequest("no payment method to manage yet"))
+		return
+	}
+	if err != nil {
+		log.Printf("failed to create portal session: %v", err)
+		c.Error(apierror.Internal("failed to open billing portal"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": url})
+}
+
 // GetBilling returns subscription information for all user servers
 func (h *BillingHandler) GetBilling(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
@@ -46,7 +128,14 @@ func (h *BillingHandler) GetBilling(c *gin.Context) {
 	servers, err := h.db.ListServersByUser(c.Request.Context(), userID)
 	if err != nil {
 		log.Printf("failed to list servers: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list servers"})
+		c.Error(apierror.Internal("failed to list servers"))
+		return
+	}
+
+	autoCancel, err := h.db.GetAutoCancelServerIDs(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get auto-cancel servers: %v", err)
+		c.Error(apierror.Internal("failed to list servers"))
 		return
 	}
 
@@ -61,6 +150,8 @@ func (h *BillingHandler) GetBilling(c *gin.Context) {
 			Status:      server.Status,
 			ExpiredAt:   server.ExpiredAt,
 			DeleteAfter: server.DeleteAfter,
+
+			AutoCancelWhenStopped: autoCancel[server.ID],
 		}
 
 		// Fetch Stripe subscription details if available
@@ -105,37 +196,37 @@ func (h *BillingHandler) GetBilling(c *gin.Context) {
 func (h *BillingHandler) CancelSubscription(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	serverID := c.Param("id")
 	if serverID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
+		c.Error(apierror.ErrServerIDRequired)
 		return
 	}
 
 	// Get server and verify ownership
 	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	if server.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	// Verify server has active subscription
 	if server.StripeSubscriptionID == nil || *server.StripeSubscriptionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server has no active subscription"})
+		c.Error(apierror.ErrNoSubscription)
 		return
 	}
 
@@ -143,7 +234,7 @@ func (h *BillingHandler) CancelSubscription(c *gin.Context) {
 	sub, err := h.stripeService.CancelSubscriptionAtPeriodEnd(c.Request.Context(), *server.StripeSubscriptionID)
 	if err != nil {
 		log.Printf("failed to cancel subscription: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel subscription"})
+		c.Error(apierror.Internal("failed to cancel subscription"))
 		return
 	}
 
@@ -165,37 +256,44 @@ func (h *BillingHandler) CancelSubscription(c *gin.Context) {
 func (h *BillingHandler) ResubscribeServer(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	serverID := c.Param("id")
 	if serverID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
+		c.Error(apierror.ErrServerIDRequired)
 		return
 	}
 
 	// Get server and verify ownership
 	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	if server.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	// Verify server is in expired state
-	if server.Status != models.ServerStatusExpired {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server is not expired"})
+	if !serverstate.In(server.Status, serverstate.ResubscribeFrom) {
+		c.Error(apierror.InvalidServerState("server is not expired"))
+		return
+	}
+
+	// The body is optional; without it the user is sent to Checkout
+	var req models.ResubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
@@ -203,7 +301,7 @@ func (h *BillingHandler) ResubscribeServer(c *gin.Context) {
 	user, err := h.db.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		log.Printf("failed to get user: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		c.Error(apierror.Internal("failed to get user"))
 		return
 	}
 
@@ -211,21 +309,48 @@ func (h *BillingHandler) ResubscribeServer(c *gin.Context) {
 	priceID, err := h.config.GetPriceID(string(server.Game), string(server.Plan))
 	if err != nil {
 		log.Printf("failed to get price ID: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get price"})
+		c.Error(apierror.Internal("failed to get price"))
+		return
+	}
+
+	if err := checkAccountFlagged(c.Request.Context(), h.db, userID); err != nil {
+		c.Error(err)
 		return
 	}
 
+	if err := checkSpendLimit(c.Request.Context(), h.db, h.stripeService, userID, priceID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Returning customers can pay with their saved card and skip the Checkout redirect
+	if req.UseSavedCard {
+		err := h.stripeService.ResubscribeWithSavedCard(c.Request.Context(), user, server, priceID)
+		if err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"status":  "reactivated",
+				"message": "Server reactivated",
+			})
+			return
+		}
+		if !errors.Is(err, stripeservice.ErrSavedCardUnavailable) {
+			log.Printf("failed to resubscribe with saved card: %v", err)
+			c.Error(apierror.Internal("failed to charge saved card"))
+			return
+		}
+		// Fall back to Checkout so the user can enter or confirm a card
+	}
+
 	// Create checkout session for resubscription
 	sessionID, checkoutURL, err := h.stripeService.CreateResubscribeCheckoutSession(
 		c.Request.Context(),
 		server.ID,
-		userID,
+		user,
 		priceID,
-		user.Email,
 	)
 	if err != nil {
 		log.Printf("failed to create resubscribe checkout session: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create checkout session"})
+		c.Error(apierror.Internal("failed to create checkout session"))
 		return
 	}
 
@@ -239,37 +364,37 @@ func (h *BillingHandler) ResubscribeServer(c *gin.Context) {
 func (h *BillingHandler) ResumeSubscription(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	serverID := c.Param("id")
 	if serverID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
+		c.Error(apierror.ErrServerIDRequired)
 		return
 	}
 
 	// Get server and verify ownership
 	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	if server.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	// Verify server has active subscription
 	if server.StripeSubscriptionID == nil || *server.StripeSubscriptionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server has no active subscription"})
+		c.Error(apierror.ErrNoSubscription)
 		return
 	}
 
@@ -277,7 +402,7 @@ func (h *BillingHandler) ResumeSubscription(c *gin.Context) {
 	_, err = h.stripeService.ResumeSubscription(c.Request.Context(), *server.StripeSubscriptionID)
 	if err != nil {
 		log.Printf("failed to resume subscription: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resume subscription"})
+		c.Error(apierror.Internal("failed to resume subscription"))
 		return
 	}
 
@@ -286,3 +411,110 @@ func (h *BillingHandler) ResumeSubscription(c *gin.Context) {
 		"message": "Subscription has been resumed",
 	})
 }
+
+// SetAutoCancel toggles whether a server's subscription is cancelled automatically
+// after it has been stopped for a long time, instead of reminding the owner
+func (h *BillingHandler) SetAutoCancel(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	var req models.SetAutoCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	// Get server and verify ownership
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.StripeSubscriptionID == nil || *server.StripeSubscriptionID == "" {
+		c.Error(apierror.ErrNoSubscription)
+		return
+	}
+
+	if err := h.db.SetServerAutoCancel(c.Request.Context(), serverID, *req.Enabled); err != nil {
+		log.Printf("failed to set auto-cancel: %v", err)
+		c.Error(apierror.Internal("failed to update auto-cancel"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"auto_cancel_when_stopped": *req.Enabled,
+	})
+}
+
+// CancelFromReminder cancels a server's subscription at period end from the one-click link
+// in a stopped-server reminder email. The link's signature authorizes it instead of a login.
+func (h *BillingHandler) CancelFromReminder(c *gin.Context) {
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	var req models.ReminderCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	if !reminder.VerifyCancel(h.config.JWTSecret, serverID, req.Expires, req.Signature, time.Now()) {
+		c.Error(apierror.ErrReminderLinkInvalid)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.StripeSubscriptionID == nil || *server.StripeSubscriptionID == "" {
+		c.Error(apierror.ErrNoSubscription)
+		return
+	}
+
+	sub, err := h.stripeService.CancelSubscriptionAtPeriodEnd(c.Request.Context(), *server.StripeSubscriptionID)
+	if err != nil {
+		log.Printf("failed to cancel subscription: %v", err)
+		c.Error(apierror.Internal("failed to cancel subscription"))
+		return
+	}
+
+	var currentPeriodEnd int64
+	if sub.Items != nil && len(sub.Items.Data) > 0 {
+		currentPeriodEnd = sub.Items.Data[0].CurrentPeriodEnd
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":               "cancelled",
+		"message":              "Subscription will be cancelled at the end of the billing period",
+		"display_name":         server.DisplayName,
+		"cancel_at_period_end": sub.CancelAtPeriodEnd,
+		"current_period_end":   time.Unix(currentPeriodEnd, 0),
+	})
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+)
+
+// ListBlockedPorts returns the blocked port ranges and how many of their ports servers still hold
+func (h *AdminHandler) ListBlockedPorts(c *gin.Context) {
+	blocked, err := h.db.ListBlockedPorts(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to list blocked ports: %v", err)
+		c.Error(apierror.Internal("failed to list blocked ports"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocked_ports": blocked})
+}
+
+// BlockPorts stops a port range from being allocated on any node. Servers holding ports in
+// it keep them until they're released or the servers are moved with ReleaseBlockedPorts.
+func (h *AdminHandler) BlockPorts(c *gin.Context) {
+	var req models.BlockPortsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+	if req.MaxPort == 0 {
+		req.MaxPort = req.MinPort
+	}
+	if req.MaxPort < req.MinPort {
+		c.Error(apierror.BadRequest("max_port must not be below min_port"))
+		return
+	}
+
+	blocked, err := h.db.BlockPorts(c.Request.Context(), req.MinPort, req.MaxPort, req.Protocol, req.Reason)
+	if err != nil {
+		log.Printf("failed to block ports %d-%d: %v", req.MinPort, req.MaxPort, err)
+		c.Error(apierror.Internal("failed to block ports"))
+		return
+	}
+
+	log.Printf("ports %d-%d %s blocked by admin %s", req.MinPort, req.MaxPort, req.Protocol, middleware.GetUserID(c))
+	c.JSON(http.StatusCreated, gin.H{"blocked_port": blocked})
+}
+
+// UnblockPorts removes a blocked range, giving its ports in the port range back to every node
+func (h *AdminHandler) UnblockPorts(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apierror.NotFound("blocked port range not found"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	portMin, portMax, err := h.nodeSync.PortRange(ctx)
+	if err != nil {
+		log.Printf("failed to get port range: %v", err)
+		c.Error(apierror.Internal("failed to unblock ports"))
+		return
+	}
+
+	removed, err := h.db.UnblockPorts(ctx, id, portMin, portMax)
+	if err != nil {
+		log.Printf("failed to unblock ports %s: %v", id, err)
+		c.Error(apierror.Internal("failed to unblock ports"))
+		return
+	}
+	if !removed {
+		c.Error(apierror.NotFound("blocked port range not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ports unblocked"})
+}
+
+// ReleaseBlockedPorts moves the starting and running servers holding blocked ports: they go
+// back to pending with their ports released, so the reconciler gives them new ones. Other
+// servers get new ports on their next start.
+func (h *AdminHandler) ReleaseBlockedPorts(c *gin.Context) {
+	ctx := c.Request.Context()
+	serverIDs, err := h.db.ListServersOnBlockedPorts(ctx)
+	if err != nil {
+		log.Printf("failed to list servers on blocked ports: %v", err)
+		c.Error(apierror.Internal("failed to release blocked ports"))
+		return
+	}
+
+	moved := []string{}
+	for _, serverID := range serverIDs {
+		server, err := h.db.GetServerByID(ctx, serverID)
+		if err != nil {
+			log.Printf("failed to get server %s on blocked ports: %v", serverID, err)
+			continue
+		}
+		transitioned, err := h.machine.Transition(ctx, server, serverstate.Request{
+			From:         []models.ServerStatus{models.ServerStatusStarting, models.ServerStatusRunning},
+			To:           models.ServerStatusPending,
+			Message:      "The server's port was reserved by the platform, so the server is moving to a new port. Players may have been briefly disconnected.",
+			Reason:       models.StatusReasonPortBlocked,
+			ReleasePorts: true,
+			ReleaseCause: models.PortCauseAdmin,
+		})
+		if err != nil {
+			log.Printf("failed to move server %s off blocked ports: %v", serverID, err)
+			continue
+		}
+		if transitioned {
+			moved = append(moved, serverID)
+		}
+	}
+
+	log.Printf("%d servers moved off blocked ports by admin %s", len(moved), middleware.GetUserID(c))
+	c.JSON(http.StatusOK, gin.H{"moved": moved, "waiting_for_start": len(serverIDs) - len(moved)})
+}
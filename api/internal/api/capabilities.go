@@ -0,0 +1,72 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+)
+
+// GetServerCapabilities returns which actions are currently valid for the server, from the
+// same status rules the action endpoints use. Suspended accounts can't take any; flagged
+// accounts can't resubscribe. The restart budget isn't checked, since it only delays starts.
+func (h *ServerHandler) GetServerCapabilities(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	ctx := c.Request.Context()
+	server, err := h.db.GetServerByID(ctx, serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	suspended, err := h.db.IsUserSuspended(ctx, userID)
+	if err != nil {
+		log.Printf("failed to check account suspension: %v", err)
+		c.Error(apierror.Internal("failed to check account status"))
+		return
+	}
+	if suspended {
+		c.JSON(http.StatusOK, gin.H{"capabilities": models.ServerCapabilities{}})
+		return
+	}
+
+	capabilities := models.ServerCapabilities{
+		CanStart:   serverstate.In(server.Status, serverstate.StartFrom),
+		CanStop:    serverstate.In(server.Status, serverstate.StopFrom),
+		CanRestart: serverstate.In(server.Status, serverstate.RestartFrom),
+		CanEditEnv: serverstate.CanEditEnv(server.Status),
+	}
+	if serverstate.In(server.Status, serverstate.ResubscribeFrom) {
+		flagged, err := h.db.IsUserFlagged(ctx, userID)
+		if err != nil {
+			log.Printf("failed to check user flag: %v", err)
+			c.Error(apierror.Internal("failed to check account status"))
+			return
+		}
+		capabilities.CanResubscribe = !flagged
+	}
+
+	c.JSON(http.StatusOK, gin.H{"capabilities": capabilities})
+}
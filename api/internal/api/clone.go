@@ -0,0 +1,83 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// CloneServer starts checkout for a new server with the game, plan and env overrides of the
+// server in the path, whose data is copied into it before its first start. The copy waits
+// for the source to be stopped. Works like CreateCheckoutSession otherwise.
+func (h *ServerHandler) CloneServer(c *gin.Context) {
+	var req models.CloneServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	source := h.getBackupServer(c)
+	if source == nil {
+		return
+	}
+
+	// Copies run in the supervisor image, like file access
+	if !h.config.FileAccessEnabled() {
+		c.Error(apierror.BadRequest("server cloning is not available"))
+		return
+	}
+	switch source.Status {
+	case models.ServerStatusExpired, models.ServerStatusDeleted:
+		c.Error(apierror.InvalidServerState("expired servers can't be cloned"))
+		return
+	}
+	// The copy pod mounts both volumes, which must be in one namespace
+	if source.K8sNamespace(h.config.K8sNamespace) != h.config.ServerNamespace(string(source.Plan)) {
+		c.Error(apierror.BadRequest("this server's data can't be copied to a new server"))
+		return
+	}
+
+	createReq := models.CreateServerRequest{
+		DisplayName:  req.DisplayName,
+		Subdomain:    req.Subdomain,
+		Game:         string(source.Game),
+		Plan:         string(source.Plan),
+		UseSavedCard: req.UseSavedCard,
+	}
+	if source.Game == models.GameCustom {
+		def, err := h.db.GetServerCustomGame(c.Request.Context(), source.ID.String())
+		if err != nil {
+			log.Printf("failed to get custom game of server %s: %v", source.ID, err)
+			c.Error(apierror.Internal("failed to clone server"))
+			return
+		}
+		createReq.CustomGame = def
+	}
+
+	h.startCheckout(c, source.UserID, createReq, source.EnvOverrides, &source.ID, nil)
+}
+
+// GetServerClone returns how far copying the data of the server in the path, a clone, has
+// got
+func (h *ServerHandler) GetServerClone(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	clone, err := h.db.GetServerClone(c.Request.Context(), server.ID.String())
+	if err != nil {
+		log.Printf("failed to get clone of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to get server clone"))
+		return
+	}
+	if clone == nil {
+		c.Error(apierror.NotFound("server is not a clone"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"clone": clone})
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// SendCommand queues a command (config reload, backup, console input, world export) for
+// the server's supervisor, which picks it up via GET /internal/servers/:id/commands
+func (h *ServerHandler) SendCommand(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	var req models.CreateServerCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	// Only a running supervisor polls for commands
+	if server.Status != models.ServerStatusRunning {
+		c.Error(apierror.InvalidServerState("server must be running to receive commands"))
+		return
+	}
+
+	var payload *string
+	if req.Payload != "" {
+		payload = &req.Payload
+	}
+	// Exports are limited by the API, not the user
+	if models.CommandType(req.Type) == models.CommandExport {
+		exportPayload := fmt.Sprintf(`{"max_bytes":%d}`, int64(h.config.ExportMaxGB)<<30)
+		payload = &exportPayload
+	}
+	// Backups are pruned by the server's backup policy
+	if models.CommandType(req.Type) == models.CommandBackup {
+		backupPayload, err := h.BackupCommandPayload(c.Request.Context(), server)
+		if err != nil {
+			log.Printf("failed to get backup policy of server %s: %v", serverID, err)
+			c.Error(apierror.Internal("failed to queue command"))
+			return
+		}
+		payload = &backupPayload
+	}
+
+	cmd, err := h.db.CreateServerCommand(c.Request.Context(), serverID, models.CommandType(req.Type), payload)
+	if err != nil {
+		log.Printf("failed to queue %s command for server %s: %v", req.Type, serverID, err)
+		c.Error(apierror.Internal("failed to queue command"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"command": cmd})
+}
+
+// GetCommand returns a queued command and, once the supervisor reports it, its result
+func (h *ServerHandler) GetCommand(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	commandID, err := uuid.Parse(c.Param("commandId"))
+	if err != nil {
+		c.Error(apierror.ErrCommandNotFound)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	cmd, err := h.db.GetServerCommand(c.Request.Context(), serverID, commandID.String())
+	if err != nil {
+		c.Error(apierror.ErrCommandNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"command": cmd})
+}
+
+// RestartProcess restarts the game process inside its running pod via the supervisor,
+// keeping downloaded assets and warm caches and skipping pod scheduling
+func (h *ServerHandler) RestartProcess(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	// Unlike RestartServer, a stopped server has no supervisor to restart the process
+	if server.Status != models.ServerStatusRunning {
+		c.Error(apierror.InvalidServerState("server must be running to receive commands"))
+		return
+	}
+
+	// Hold the server lock, so concurrent restarts can't both pass the restart budget check
+	var exhausted bool
+	var cmd *models.ServerCommand
+	err = h.db.WithServerLock(c.Request.Context(), serverID, func() error {
+		if exhausted = h.restartBudgetExhausted(c, serverID); exhausted {
+			return nil
+		}
+		cmd, err = h.db.CreateServerCommand(c.Request.Context(), serverID, models.CommandRestartProcess, nil)
+		if err == nil {
+			h.chargeRestartBudget(c.Request.Context(), serverID)
+		}
+		return err
+	})
+	if exhausted {
+		return
+	}
+	if err != nil {
+		log.Printf("failed to queue process restart for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to queue command"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Game process restart requested",
+		"command": cmd,
+	})
+}
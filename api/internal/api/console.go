@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// consoleCommandTimeout bounds relaying a console command; the supervisor gives the game
+// 10 seconds to answer
+const consoleCommandTimeout = 15 * time.Second
+
+// RunConsoleCommand relays a console command to the game through the server's supervisor,
+// which uses RCON if the game has it configured and stdin otherwise, and returns its output
+func (h *ServerHandler) RunConsoleCommand(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	var req models.ConsoleCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), consoleCommandTimeout)
+	defer cancel()
+
+	server, err := h.db.GetServerByID(ctx, serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+	if server.Status != models.ServerStatusRunning {
+		c.Error(apierror.InvalidServerState("server must be running to receive commands"))
+		return
+	}
+
+	podIP, err := h.runningPodIP(ctx, server)
+	if err != nil {
+		log.Printf("failed to list pods for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to run command"))
+		return
+	}
+	if podIP == "" {
+		c.Error(apierror.InvalidServerState("server must be running to receive commands"))
+		return
+	}
+
+	token, err := h.db.GetServerAuthToken(ctx, serverID)
+	if err != nil {
+		log.Printf("failed to get auth token of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to run command"))
+		return
+	}
+
+	body, _ := json.Marshal(gin.H{"command": req.Command})
+	target := fmt.Sprintf("http://%s:%d/console", podIP, k8s.SupervisorHTTPPort)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		c.Error(apierror.Internal("failed to run command"))
+		return
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := fileAccessClient.Do(httpReq)
+	if err != nil {
+		log.Printf("failed to relay console command to server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to run command"))
+		return
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var result struct {
+			Output string `json:"output"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			log.Printf("failed to decode console output of server %s: %v", serverID, err)
+			c.Error(apierror.Internal("failed to run command"))
+			return
+		}
+		// The command itself isn't logged, it may contain secrets
+		log.Printf("ran console command on server %s: user=%s", serverID, userID)
+		c.JSON(http.StatusOK, gin.H{"output": result.Output})
+	case http.StatusConflict:
+		c.Error(apierror.InvalidServerState("server must be running to receive commands"))
+	case http.StatusBadRequest:
+		c.Error(apierror.BadRequest("command must be a single line"))
+	case http.StatusNotFound:
+		// Supervisors started before console commands existed
+		c.Error(apierror.BadRequest("restart the server to enable console commands"))
+	case http.StatusBadGateway:
+		c.Error(apierror.ErrConsoleCommandFailed)
+	default:
+		log.Printf("supervisor rejected console command for server %s: status %d", serverID, resp.StatusCode)
+		c.Error(apierror.Internal("failed to run command"))
+	}
+}
+
+// runningPodIP returns the IP of the server's running pod, or "" if none is running yet
+func (h *ServerHandler) runningPodIP(ctx context.Context, server *models.Server) (string, error) {
+	pods, err := h.k8sClient.ListPodsByLabel(ctx, server.K8sNamespace(h.config.K8sNamespace), "server="+server.ID.String())
+	if err != nil {
+		return "", err
+	}
+	for i := range pods {
+		if pods[i].DeletionTimestamp == nil && pods[i].Status.PodIP != "" && pods[i].Status.Phase == corev1.PodRunning {
+			return pods[i].Status.PodIP, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// consoleInputMaxBytes bounds a line typed into the interactive console
+	consoleInputMaxBytes = 1024
+	// consoleInputTimeout bounds relaying one line of console input to the supervisor
+	consoleInputTimeout = 5 * time.Second
+)
+
+// consoleEvent is a message the interactive console sends the browser
+type consoleEvent struct {
+	Type    string `json:"type"`              // "output" or "error"
+	Line    string `json:"line,omitempty"`    // A line of game output
+	Message string `json:"message,omitempty"` // What went wrong
+}
+
+// errConsoleUnsupported is returned by supervisors started before the interactive console
+var errConsoleUnsupported = errors.New("restart the server to enable the console")
+
+// Console is the interactive console of a running or starting server over a WebSocket. The
+// game's output, as captured by its supervisor, is sent as "output" events, starting with
+// recent lines; each text message received is written to the game's stdin as a line.
+// Problems are sent as "error" events, and the socket closes once the game's output ends.
+func (h *ServerHandler) Console(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	ctx := c.Request.Context()
+	server, err := h.db.GetServerByID(ctx, serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+	if server.Status != models.ServerStatusRunning && server.Status != models.ServerStatusStarting {
+		c.Error(apierror.InvalidServerState("server must be running to open its console"))
+		return
+	}
+
+	// The console takes input, which suspended accounts can't send; the upgrade is a GET, so
+	// RequireActiveAccount lets it through
+	suspended, err := h.db.IsUserSuspended(ctx, userID)
+	if err != nil {
+		log.Printf("failed to check suspension of user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to check account status"))
+		return
+	}
+	if suspended {
+		c.Error(apierror.ErrAccountSuspended)
+		return
+	}
+
+	podIP, err := h.runningPodIP(ctx, server)
+	if err != nil {
+		log.Printf("failed to list pods for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to open console"))
+		return
+	}
+	if podIP == "" {
+		c.Error(apierror.InvalidServerState("server must be running to open its console"))
+		return
+	}
+
+	token, err := h.db.GetServerAuthToken(ctx, serverID)
+	if err != nil {
+		log.Printf("failed to get auth token of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to open console"))
+		return
+	}
+
+	socket := websocket.Server{
+		Handshake: h.checkConsoleOrigin,
+		Handler: func(ws *websocket.Conn) {
+			log.Printf("opened console of server %s: user=%s", serverID, userID)
+			relayConsole(ws, serverID, podIP, token)
+		},
+	}
+	socket.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkConsoleOrigin only lets browsers open consoles from the frontend, as the socket isn't
+// covered by CORS. Clients sending no Origin aren't browsers.
+func (h *ServerHandler) checkConsoleOrigin(_ *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" || slices.Contains(h.config.AllowedOrigins, origin) {
+		return nil
+	}
+	return fmt.Errorf("origin %s not allowed", origin)
+}
+
+// relayConsole connects a console socket to the supervisor: its output stream to the socket,
+// and the socket's messages to its console input
+func relayConsole(ws *websocket.Conn, serverID, podIP, token string) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = 4 * consoleInputMaxBytes
+
+	ctx, cancel := context.WithCancel(ws.Request().Context())
+	defer cancel()
+
+	output, err := openConsoleStream(ctx, podIP, token)
+	if err != nil {
+		if !errors.Is(err, errConsoleUnsupported) {
+			log.Printf("failed to open console stream of server %s: %v", serverID, err)
+			err = errors.New("failed to open console")
+		}
+		websocket.JSON.Send(ws, consoleEvent{Type: "error", Message: err.Error()})
+		return
+	}
+
+	go func() {
+		// Closing the socket also ends the loop receiving input
+		defer ws.Close()
+		defer output.Close()
+
+		br := bufio.NewReader(output)
+		for {
+			line, err := br.ReadString('\n')
+			if line != "" {
+				if websocket.JSON.Send(ws, consoleEvent{Type: "output", Line: strings.TrimRight(line, "\r\n")}) != nil {
+					return
+				}
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					websocket.JSON.Send(ws, consoleEvent{Type: "error", Message: "console closed, the server stopped or restarted"})
+				}
+				return
+			}
+		}
+	}()
+
+	for {
+		var line string
+		if err := websocket.Message.Receive(ws, &line); err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) > consoleInputMaxBytes || strings.ContainsAny(line, "\r\n") {
+			websocket.JSON.Send(ws, consoleEvent{Type: "error", Message: fmt.Sprintf("input must be a single line of at most %d bytes", consoleInputMaxBytes)})
+			continue
+		}
+		if err := sendConsoleInput(ctx, podIP, token, line); err != nil {
+			websocket.JSON.Send(ws, consoleEvent{Type: "error", Message: err.Error()})
+		}
+	}
+}
+
+// openConsoleStream opens the supervisor's stream of the game's output, one line each
+func openConsoleStream(ctx context.Context, podIP, token string) (io.ReadCloser, error) {
+	target := fmt.Sprintf("http://%s:%d/console", podIP, k8s.SupervisorHTTPPort)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := fileAccessClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		resp.Body.Close()
+		return nil, errConsoleUnsupported
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("supervisor returned status %d", resp.StatusCode)
+	}
+}
+
+// sendConsoleInput writes a line to the game's stdin through the supervisor. The error is
+// shown to the user.
+func sendConsoleInput(ctx context.Context, podIP, token, line string) error {
+	ctx, cancel := context.WithTimeout(ctx, consoleInputTimeout)
+	defer cancel()
+
+	body, _ := json.Marshal(gin.H{"line": line})
+	target := fmt.Sprintf("http://%s:%d/console/input", podIP, k8s.SupervisorHTTPPort)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("failed to send input")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := fileAccessClient.Do(req)
+	if err != nil {
+		log.Printf("failed to relay console input to %s: %v", podIP, err)
+		return errors.New("failed to send input")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusConflict:
+		return errors.New("the game isn't running, input can't be sent yet")
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return errConsoleUnsupported
+	default:
+		return errors.New("failed to send input")
+	}
+}
@@ -0,0 +1,439 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const (
+	// maxCustomDomainsPerServer caps how many custom domains a server can have
+	maxCustomDomainsPerServer = 5
+
+	// customDomainChallengeLabel is where the verification TXT record goes, under the domain
+	customDomainChallengeLabel = "_gshub-challenge"
+
+	// customDomainTokenPrefix prefixes the token in the verification TXT record
+	customDomainTokenPrefix = "gshub-verify="
+
+	// customDomainLookupTimeout bounds the TXT lookup when verifying a domain
+	customDomainLookupTimeout = 5 * time.Second
+)
+
+// customDomainPattern matches lowercase hostnames with at least two labels
+var customDomainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z][a-z0-9-]{0,61}[a-z0-9]$`)
+
+// domainTarget is where a server's custom domains should point: its game port on the edge
+// proxy if it has one, otherwise on the node it currently runs on
+type domainTarget struct {
+	address    string
+	port       int
+	protocol   string
+	srvService string
+}
+
+// ListCustomDomains returns a server's custom domains with the DNS records each needs
+func (h *ServerHandler) ListCustomDomains(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	server, err := h.db.GetServerByIDWithDetails(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	domains, err := h.db.ListCustomDomains(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to list custom domains for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to list custom domains"))
+		return
+	}
+
+	target := h.customDomainTarget(c.Request.Context(), server)
+	for i := range domains {
+		describeCustomDomain(&domains[i], target)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"domains": domains})
+}
+
+// CreateCustomDomain adds a domain to a server. It stays pending until the verification
+// TXT record is found by VerifyCustomDomain.
+func (h *ServerHandler) CreateCustomDomain(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	var req models.CreateCustomDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	domain := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(req.Domain), "."))
+	if !customDomainPattern.MatchString(domain) {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeValidation, "request validation failed").
+			WithDetails(map[string]string{"domain": "must be a valid domain name"}))
+		return
+	}
+
+	server, err := h.db.GetServerByIDWithDetails(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	existing, err := h.db.ListCustomDomains(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to list custom domains for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to add custom domain"))
+		return
+	}
+	for _, d := range existing {
+		if d.Domain == domain {
+			c.Error(apierror.ErrCustomDomainExists)
+			return
+		}
+	}
+	if len(existing) >= maxCustomDomainsPerServer {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeCustomDomainLimit,
+			"server already has the maximum number of custom domains").
+			WithDetails(gin.H{"limit": maxCustomDomainsPerServer}))
+		return
+	}
+
+	taken, err := h.db.CustomDomainVerifiedElsewhere(c.Request.Context(), serverID, domain)
+	if err != nil {
+		log.Printf("failed to check custom domain %s: %v", domain, err)
+		c.Error(apierror.Internal("failed to add custom domain"))
+		return
+	}
+	if taken {
+		c.Error(apierror.ErrCustomDomainTaken)
+		return
+	}
+
+	token, err := generateCustomDomainToken()
+	if err != nil {
+		log.Printf("failed to generate custom domain token: %v", err)
+		c.Error(apierror.Internal("failed to add custom domain"))
+		return
+	}
+
+	created, err := h.db.CreateCustomDomain(c.Request.Context(), serverID, domain, token)
+	if err != nil {
+		log.Printf("failed to create custom domain for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to add custom domain"))
+		return
+	}
+
+	describeCustomDomain(created, h.customDomainTarget(c.Request.Context(), server))
+	c.JSON(http.StatusCreated, gin.H{"domain": created})
+}
+
+// VerifyCustomDomain looks up a domain's verification TXT record and records the result.
+// Verified domains can be re-checked; one that no longer has the record loses its
+// verification.
+func (h *ServerHandler) VerifyCustomDomain(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	domainID, err := uuid.Parse(c.Param("domainId"))
+	if err != nil {
+		c.Error(apierror.ErrCustomDomainNotFound)
+		return
+	}
+
+	server, err := h.db.GetServerByIDWithDetails(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	domain, err := h.db.GetCustomDomain(c.Request.Context(), serverID, domainID.String())
+	if err != nil {
+		log.Printf("failed to get custom domain %s: %v", domainID, err)
+		c.Error(apierror.Internal("failed to verify custom domain"))
+		return
+	}
+	if domain == nil {
+		c.Error(apierror.ErrCustomDomainNotFound)
+		return
+	}
+
+	if reason := checkCustomDomainTXT(c.Request.Context(), domain); reason != "" {
+		if err := h.db.MarkCustomDomainFailed(c.Request.Context(), domainID.String(), reason); err != nil {
+			log.Printf("failed to mark custom domain %s failed: %v", domainID, err)
+			c.Error(apierror.Internal("failed to verify custom domain"))
+			return
+		}
+	} else {
+		verified, err := h.db.MarkCustomDomainVerified(c.Request.Context(), domainID.String())
+		if err != nil {
+			log.Printf("failed to mark custom domain %s verified: %v", domainID, err)
+			c.Error(apierror.Internal("failed to verify custom domain"))
+			return
+		}
+		if !verified {
+			c.Error(apierror.ErrCustomDomainTaken)
+			return
+		}
+	}
+
+	domain, err = h.db.GetCustomDomain(c.Request.Context(), serverID, domainID.String())
+	if err != nil || domain == nil {
+		log.Printf("failed to get custom domain %s: %v", domainID, err)
+		c.Error(apierror.Internal("failed to verify custom domain"))
+		return
+	}
+
+	describeCustomDomain(domain, h.customDomainTarget(c.Request.Context(), server))
+	c.JSON(http.StatusOK, gin.H{"domain": domain})
+}
+
+// DeleteCustomDomain removes a server's custom domain
+func (h *ServerHandler) DeleteCustomDomain(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	domainID, err := uuid.Parse(c.Param("domainId"))
+	if err != nil {
+		c.Error(apierror.ErrCustomDomainNotFound)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	deleted, err := h.db.DeleteCustomDomain(c.Request.Context(), serverID, domainID.String())
+	if err != nil {
+		log.Printf("failed to delete custom domain %s: %v", domainID, err)
+		c.Error(apierror.Internal("failed to delete custom domain"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.ErrCustomDomainNotFound)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// customDomainTarget returns where a server's custom domains should point, or nil while
+// the server has neither an edge port nor a node port for its game port
+func (h *ServerHandler) customDomainTarget(ctx context.Context, server *models.Server) *domainTarget {
+	var srvService string
+	catalog, err := h.k8sClient.LoadGameCatalog(ctx, h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err == nil {
+		if gameConfig, err := catalog.GetGameConfig(string(server.Game)); err == nil {
+			srvService = gameConfig.SRVService
+		}
+	}
+
+	// Edge ports don't change when the server moves, so they're preferred
+	if h.config.EdgeProxyEnabled() {
+		edgePorts, err := h.db.GetServerEdgePorts(ctx, server.ID.String())
+		if err != nil {
+			log.Printf("failed to get edge ports for server %s: %v", server.ID, err)
+		}
+		if port := gamePortIndex(len(edgePorts), func(i int) string { return edgePorts[i].Name }); port >= 0 {
+			return &domainTarget{
+				address:    h.config.EdgeAddress,
+				port:       edgePorts[port].Port,
+				protocol:   edgePorts[port].Protocol,
+				srvService: srvService,
+			}
+		}
+	}
+
+	port := gamePortIndex(len(server.Ports), func(i int) string { return server.Ports[i].Name })
+	if port < 0 || server.Ports[port].NodeIP == nil || server.Ports[port].HostPort == nil {
+		return nil
+	}
+	return &domainTarget{
+		address:    *server.Ports[port].NodeIP,
+		port:       *server.Ports[port].HostPort,
+		protocol:   server.Ports[port].Protocol,
+		srvService: srvService,
+	}
+}
+
+// gamePortIndex returns the index of the port named "game", falling back to the first port
+// (custom games name their own ports), or -1 if there are none
+func gamePortIndex(count int, name func(i int) string) int {
+	for i := 0; i < count; i++ {
+		if name(i) == "game" {
+			return i
+		}
+	}
+	if count > 0 {
+		return 0
+	}
+	return -1
+}
+
+// describeCustomDomain sets the DNS records a domain needs and what players connect with.
+// Without a target only the verification record is set.
+func describeCustomDomain(domain *models.CustomDomain, target *domainTarget) {
+	domain.Verification = models.DNSRecord{
+		Type:  "TXT",
+		Name:  customDomainChallengeLabel + "." + domain.Domain,
+		Value: customDomainTokenPrefix + domain.VerificationToken,
+	}
+	if target == nil {
+		return
+	}
+
+	addressType := "A"
+	if strings.Contains(target.address, ":") {
+		addressType = "AAAA"
+	}
+	domain.Records = []models.DNSRecord{{Type: addressType, Name: domain.Domain, Value: target.address}}
+
+	// With an SRV record players connect without a port
+	if target.srvService != "" {
+		domain.Records = append(domain.Records, models.DNSRecord{
+			Type:  "SRV",
+			Name:  fmt.Sprintf("_%s._%s.%s", target.srvService, strings.ToLower(target.protocol), domain.Domain),
+			Value: fmt.Sprintf("0 5 %d %s.", target.port, domain.Domain),
+		})
+		domain.Connect = domain.Domain
+		return
+	}
+	domain.Connect = net.JoinHostPort(domain.Domain, fmt.Sprint(target.port))
+}
+
+// checkCustomDomainTXT looks up a domain's verification record. Returns why verification
+// failed, or "" if the record is in place.
+func checkCustomDomainTXT(ctx context.Context, domain *models.CustomDomain) string {
+	ctx, cancel := context.WithTimeout(ctx, customDomainLookupTimeout)
+	defer cancel()
+
+	name := customDomainChallengeLabel + "." + domain.Domain
+	records, err := net.DefaultResolver.LookupTXT(ctx, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return fmt.Sprintf("no TXT record found at %s", name)
+		}
+		return fmt.Sprintf("DNS lookup for %s failed", name)
+	}
+
+	expected := customDomainTokenPrefix + domain.VerificationToken
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected {
+			return ""
+		}
+	}
+	return fmt.Sprintf("TXT record at %s doesn't contain the verification token", name)
+}
+
+// generateCustomDomainToken returns a random token for the verification TXT record
+func generateCustomDomainToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+)
+
+// applyCustomGame checks a custom game definition against the registry allowlist and the
+// catalog's custom entry, and returns the game config servers with it run. Errors are API
+// errors.
+func (h *ServerHandler) applyCustomGame(gameConfig *k8s.GameConfig, def *models.CustomGame, plan string) (*k8s.GameConfig, error) {
+	if !h.config.CustomGamesEnabled() || !gameConfig.Custom {
+		return nil, apierror.ErrCustomGamesDisabled
+	}
+	if def == nil {
+		return nil, apierror.ErrCustomGameRequired
+	}
+	if !k8s.ImageAllowed(def.Image, h.config.CustomGameRegistries) {
+		return nil, apierror.ErrImageNotAllowed.WithDetails(map[string][]string{"allowed_registries": h.config.CustomGameRegistries})
+	}
+
+	resolved := gameConfig.WithCustomGame(def)
+	if issues := resolved.PlanIssues(string(models.GameCustom), plan); len(issues) > 0 {
+		return nil, apierror.New(http.StatusBadRequest, apierror.CodeInvalidCustomGame, "custom game definition is invalid").WithDetails(issues)
+	}
+	return resolved, nil
+}
+
+// serverGameConfig returns the game config a server runs with: its catalog entry, with the
+// server's definition applied for custom games
+func (h *ServerHandler) serverGameConfig(ctx context.Context, server *models.Server, catalog *k8s.GameCatalog) (*k8s.GameConfig, error) {
+	gameConfig, err := catalog.GetGameConfig(string(server.Game))
+	if err != nil {
+		return nil, err
+	}
+	if server.Game != models.GameCustom {
+		return gameConfig, nil
+	}
+
+	def, err := h.db.GetServerCustomGame(ctx, server.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if def == nil {
+		return nil, fmt.Errorf("custom game server %s has no definition", server.ID)
+	}
+	return gameConfig.WithCustomGame(def), nil
+}
+
+// UpdateCustomGame replaces a custom game server's definition. Like env changes, the new
+// definition is applied the next time the server is restarted.
+func (h *ServerHandler) UpdateCustomGame(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	var req models.UpdateCustomGameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+	if server.Game != models.GameCustom {
+		c.Error(apierror.ErrNotCustomGame)
+		return
+	}
+
+	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err != nil {
+		log.Printf("failed to load game catalog: %v", err)
+		c.Error(apierror.Internal("failed to load game catalog"))
+		return
+	}
+	gameConfig, err := catalog.GetGameConfig(string(models.GameCustom))
+	if err != nil {
+		c.Error(apierror.ErrCustomGamesDisabled)
+		return
+	}
+	if _, err := h.applyCustomGame(gameConfig, &req.CustomGame, string(server.Plan)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Host ports are allocated per port name and protocol when the server is created, so
+	// only the container side of existing ports can change
+	current, err := h.db.GetServerCustomGame(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get custom game for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to update custom game"))
+		return
+	}
+	if current != nil && !samePortNames(current.Ports, req.CustomGame.Ports) {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidCustomGame,
+			"ports can't be added, removed or renamed after the server is created"))
+		return
+	}
+
+	if err := h.db.SetServerCustomGame(c.Request.Context(), serverID, &req.CustomGame); err != nil {
+		log.Printf("failed to update custom game for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to update custom game"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"custom_game": req.CustomGame})
+}
+
+// samePortNames reports whether both port lists have the same names with the same protocols
+func samePortNames(a, b []models.CustomGamePort) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	protocols := make(map[string]string, len(a))
+	for _, port := range a {
+		protocols[port.Name] = port.Protocol
+	}
+	for _, port := range b {
+		if protocol, ok := protocols[port.Name]; !ok || protocol != port.Protocol {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/broadcast"
+)
+
+// DeleteServer deletes a server at the user's request. The server expires and its
+// subscription is cancelled immediately: data is kept for 7 days, during which the user is
+// warned by email and can restore it by resubscribing, before cleanup removes it for good.
+// Deleting an expired server again retries cancelling a subscription that's still active.
+func (h *ServerHandler) DeleteServer(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	var req models.DeleteServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	// Typing the subdomain guards against deleting the wrong server by accident
+	if !strings.EqualFold(strings.TrimSpace(req.Confirmation), server.Subdomain) {
+		c.Error(apierror.ErrDeleteConfirmationMismatch)
+		return
+	}
+
+	if server.Status == models.ServerStatusDeleting ||
+		server.Status == models.ServerStatusDeleted {
+		c.Error(apierror.InvalidServerState("server is already scheduled for deletion"))
+		return
+	}
+
+	// Hold the server lock so a concurrent start/stop can't recreate resources mid-delete
+	var deleted bool
+	err = h.db.WithServerLock(c.Request.Context(), serverID, func() error {
+		current, err := h.db.GetServerByID(c.Request.Context(), serverID)
+		if err != nil {
+			return err
+		}
+		deleted, err = h.stripeService.DeleteServer(c.Request.Context(), current)
+		return err
+	})
+	if err != nil {
+		log.Printf("failed to delete server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to delete server"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.InvalidServerState("server is already scheduled for deletion"))
+		return
+	}
+
+	h.hub.Publish(server.UserID, broadcast.StatusEvent{
+		ServerID:  serverID,
+		Status:    string(models.ServerStatusExpired),
+		Timestamp: time.Now().UTC(),
+	})
+
+	response := gin.H{
+		"status":  string(models.ServerStatusExpired),
+		"message": "Server deleted. You can restore it by resubscribing until its data is removed.",
+	}
+	if updated, err := h.db.GetServerByID(c.Request.Context(), serverID); err == nil {
+		response["delete_after"] = updated.DeleteAfter
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/services/auth"
+)
+
+const (
+	// discordLinkCodeTTL is how long a link code can be redeemed with /link
+	discordLinkCodeTTL = 10 * time.Minute
+
+	// discordTokenTTL is the lifetime of the scoped tokens the bot calls the API with
+	discordTokenTTL = 5 * time.Minute
+
+	// discordLinkCodeAlphabet leaves out characters that are easy to mistype (0/O, 1/I)
+	discordLinkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	discordLinkCodeLength   = 8
+)
+
+// DiscordHandler links Discord accounts to users and issues the Discord bot scoped tokens
+// for linked users. The integration is disabled when no bot secret is configured.
+type DiscordHandler struct {
+	db          *database.DB
+	authService *auth.Service
+	botSecret   string
+}
+
+// NewDiscordHandler creates a new Discord handler
+func NewDiscordHandler(db *database.DB, authService *auth.Service, botSecret string) *DiscordHandler {
+	return &DiscordHandler{
+		db:          db,
+		authService: authService,
+		botSecret:   botSecret,
+	}
+}
+
+// Enabled reports whether the Discord integration is configured
+func (h *DiscordHandler) Enabled() bool {
+	return h.botSecret != ""
+}
+
+// GetLink returns the user's linked Discord account, or null if none is linked
+func (h *DiscordHandler) GetLink(c *gin.Context) {
+	if !h.Enabled() {
+		c.Error(apierror.ErrDiscordDisabled)
+		return
+	}
+
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	link, err := h.db.GetDiscordLinkByUser(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get discord link for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to get discord link"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"link": link})
+}
+
+// CreateLinkCode issues a one-time code the user links their Discord account with by
+// running /link in Discord. Issuing a new code invalidates the previous one.
+func (h *DiscordHandler) CreateLinkCode(c *gin.Context) {
+	if !h.Enabled() {
+		c.Error(apierror.ErrDiscordDisabled)
+		return
+	}
+
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	code, err := generateDiscordLinkCode()
+	if err != nil {
+		log.Printf("failed to generate discord link code: %v", err)
+		c.Error(apierror.Internal("failed to create link code"))
+		return
+	}
+
+	expiresAt := time.Now().Add(discordLinkCodeTTL)
+	if err := h.db.CreateDiscordLinkCode(c.Request.Context(), userID, code, expiresAt); err != nil {
+		log.Printf("failed to create discord link code for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to create link code"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": code, "expires_at": expiresAt})
+}
+
+// Unlink removes the user's linked Discord account
+func (h *DiscordHandler) Unlink(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	if _, err := h.db.DeleteDiscordLink(c.Request.Context(), userID); err != nil {
+		log.Printf("failed to delete discord link for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to unlink discord account"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegisterInternalRoutes registers the routes the Discord bot calls on the internal API.
+// They're only registered when the integration is enabled.
+func (h *DiscordHandler) RegisterInternalRoutes(r *gin.Engine) {
+	if !h.Enabled() {
+		return
+	}
+
+	discord := r.Group("/internal/discord")
+	discord.Use(h.botAuthMiddleware())
+	{
+		discord.POST("/link", h.RedeemLinkCode)
+		discord.POST("/token", h.IssueToken)
+	}
+}
+
+// botAuthMiddleware validates the shared Discord bot secret
+func (h *DiscordHandler) botAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.botSecret)) != 1 {
+			c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeInvalidToken, "invalid token"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RedeemLinkCodeRequest is sent by the bot when a Discord user runs /link
+type RedeemLinkCodeRequest struct {
+	Code            string `json:"code" binding:"required"`
+	DiscordUserID   string `json:"discord_user_id" binding:"required,max=32"`
+	DiscordUsername string `json:"discord_username" binding:"required,max=255"`
+}
+
+// RedeemLinkCode links the Discord account that ran /link to the user the code was issued to
+func (h *DiscordHandler) RedeemLinkCode(c *gin.Context) {
+	var req RedeemLinkCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	link, err := h.db.LinkDiscordAccount(c.Request.Context(), code, req.DiscordUserID, req.DiscordUsername)
+	if err != nil {
+		log.Printf("failed to link discord account %s: %v", req.DiscordUserID, err)
+		c.Error(apierror.Internal("failed to link discord account"))
+		return
+	}
+	if link == nil {
+		c.Error(apierror.ErrDiscordLinkCodeInvalid)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"link": link})
+}
+
+// IssueTokenRequest is sent by the bot before acting for a Discord user
+type IssueTokenRequest struct {
+	DiscordUserID string `json:"discord_user_id" binding:"required,max=32"`
+}
+
+// IssueToken returns a short-lived token for the user linked to a Discord account, scoped to
+// the server routes the bot's commands need
+func (h *DiscordHandler) IssueToken(c *gin.Context) {
+	var req IssueTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	link, err := h.db.GetDiscordLinkByDiscordUser(c.Request.Context(), req.DiscordUserID)
+	if err != nil {
+		log.Printf("failed to get discord link for %s: %v", req.DiscordUserID, err)
+		c.Error(apierror.Internal("failed to issue token"))
+		return
+	}
+	if link == nil {
+		c.Error(apierror.ErrDiscordNotLinked)
+		return
+	}
+
+	user, err := h.db.GetUserByID(c.Request.Context(), link.UserID)
+	if err != nil {
+		log.Printf("failed to get user %s: %v", link.UserID, err)
+		c.Error(apierror.Internal("failed to issue token"))
+		return
+	}
+
+	token, expiresAt, err := h.authService.GenerateScopedToken(user, middleware.ScopeDiscordBot, discordTokenTTL)
+	if err != nil {
+		log.Printf("failed to generate discord bot token for user %s: %v", user.ID, err)
+		c.Error(apierror.Internal("failed to issue token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_at": expiresAt})
+}
+
+// generateDiscordLinkCode returns a random code from discordLinkCodeAlphabet
+func generateDiscordLinkCode() (string, error) {
+	b := make([]byte, discordLinkCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = discordLinkCodeAlphabet[int(b[i])%len(discordLinkCodeAlphabet)]
+	}
+	return string(b), nil
+}
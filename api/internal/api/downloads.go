@@ -0,0 +1,149 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+)
+
+// commandDownloadURLLifetime is how long a signed download link works
+const commandDownloadURLLifetime = 15 * time.Minute
+
+// CreateCommandDownloadURL returns a signed link to download the archive a completed backup
+// or export produced. The link needs no login, so it can be handed to a browser or curl.
+func (h *ServerHandler) CreateCommandDownloadURL(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	cmd, err := h.db.GetServerCommand(c.Request.Context(), serverID, c.Param("commandId"))
+	if err != nil {
+		c.Error(apierror.ErrCommandNotFound)
+		return
+	}
+	if cmd.State != models.CommandStateSucceeded || cmd.Artifact == nil {
+		c.Error(apierror.ErrNoCommandArtifact)
+		return
+	}
+
+	// Archives live in the data volume, served by the running supervisor
+	if server.Status != models.ServerStatusRunning {
+		c.Error(apierror.InvalidServerState("server must be running to download its archives"))
+		return
+	}
+
+	expiresAt := time.Now().Add(commandDownloadURLLifetime).UTC()
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expiresAt.Unix(), 10)},
+		"signature": {h.signCommandDownload(cmd.ID.String(), expiresAt.Unix())},
+	}
+	downloadURL := fmt.Sprintf("%s/downloads/commands/%s?%s", requestBaseURL(c), cmd.ID, query.Encode())
+
+	c.JSON(http.StatusOK, gin.H{"download": models.CommandDownloadURL{URL: downloadURL, ExpiresAt: expiresAt}})
+}
+
+// DownloadCommandArtifact streams a command's archive from the server's supervisor. It's
+// authorized by the link's signature instead of a login.
+func (h *ServerHandler) DownloadCommandArtifact(c *gin.Context) {
+	commandID := c.Param("commandId")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires ||
+		!hmac.Equal([]byte(c.Query("signature")), []byte(h.signCommandDownload(commandID, expires))) {
+		c.Error(apierror.ErrDownloadLinkInvalid)
+		return
+	}
+
+	ctx := c.Request.Context()
+	cmd, err := h.db.GetServerCommandByID(ctx, commandID)
+	if err != nil || cmd.Artifact == nil {
+		c.Error(apierror.ErrNoCommandArtifact)
+		return
+	}
+
+	serverID := cmd.ServerID.String()
+	server, err := h.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+	if server.Status != models.ServerStatusRunning {
+		c.Error(apierror.InvalidServerState("server must be running to download its archives"))
+		return
+	}
+
+	pods, err := h.k8sClient.ListPodsByLabel(ctx, server.K8sNamespace(h.config.K8sNamespace), "server="+serverID)
+	if err != nil {
+		log.Printf("failed to list pods for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to download archive"))
+		return
+	}
+	podIP := ""
+	for i := range pods {
+		if pods[i].DeletionTimestamp == nil && k8s.PodReady(&pods[i]) {
+			podIP = pods[i].Status.PodIP
+			break
+		}
+	}
+	if podIP == "" {
+		c.Error(apierror.InvalidServerState("server must be running to download its archives"))
+		return
+	}
+
+	token, err := h.db.GetServerAuthToken(ctx, serverID)
+	if err != nil {
+		log.Printf("failed to get auth token of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to download archive"))
+		return
+	}
+
+	proxySupervisorDownload(c, serverID, podIP, token, cmd.Artifact.Path)
+}
+
+// signCommandDownload signs a download link for a command's archive, valid until expires
+func (h *ServerHandler) signCommandDownload(commandID string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(h.config.JWTSecret))
+	fmt.Fprintf(mac, "command-download:%s:%d", commandID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// requestBaseURL returns the scheme and host the client reached the API on
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
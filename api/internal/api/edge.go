@@ -0,0 +1,73 @@
+package api
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/database"
+)
+
+// edgeHeartbeatFreshness is how recent a heartbeat must be for the edge proxy to forward
+// traffic to a running server (four missed heartbeats)
+const edgeHeartbeatFreshness = 2 * time.Minute
+
+// EdgeHandler serves the edge proxy its routing table. The edge tier is disabled unless
+// both a proxy secret and an edge address are configured.
+type EdgeHandler struct {
+	db     *database.DB
+	config *config.Config
+}
+
+// NewEdgeHandler creates a new edge handler
+func NewEdgeHandler(db *database.DB, cfg *config.Config) *EdgeHandler {
+	return &EdgeHandler{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// RegisterInternalRoutes registers the routes the edge proxy calls on the internal API.
+// They're only registered when the edge tier is enabled.
+func (h *EdgeHandler) RegisterInternalRoutes(r *gin.Engine) {
+	if !h.config.EdgeProxyEnabled() {
+		return
+	}
+
+	edge := r.Group("/internal/edge")
+	edge.Use(h.proxyAuthMiddleware())
+	{
+		edge.GET("/routes", h.ListRoutes)
+	}
+}
+
+// proxyAuthMiddleware validates the shared edge proxy secret
+func (h *EdgeHandler) proxyAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.config.EdgeProxySecret)) != 1 {
+			c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeInvalidToken, "invalid token"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ListRoutes returns every edge port with the node port its traffic goes to and whether
+// the server behind it is healthy
+func (h *EdgeHandler) ListRoutes(c *gin.Context) {
+	routes, err := h.db.ListEdgeRoutes(c.Request.Context(), edgeHeartbeatFreshness)
+	if err != nil {
+		log.Printf("failed to list edge routes: %v", err)
+		c.Error(apierror.Internal("failed to list edge routes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"routes": routes})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// GetEgressUsage returns the server's outbound traffic this month and its plan's limits
+func (h *ServerHandler) GetEgressUsage(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	usage, err := h.db.GetEgressUsage(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get egress usage for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to get egress usage"))
+		return
+	}
+	if usage == nil {
+		now := time.Now().UTC()
+		usage = &models.EgressUsage{Period: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)}
+	}
+
+	// Limits are informational; usage is still returned if the catalog can't be read
+	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err == nil {
+		if gameConfig, err := catalog.GetGameConfig(string(server.Game)); err == nil {
+			if planConfig, err := gameConfig.GetPlanConfig(string(server.Plan)); err == nil {
+				usage.QuotaBytes = planConfig.EgressQuotaBytes()
+				usage.BandwidthLimit = planConfig.EgressBandwidth
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"usage": usage})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// EmailPreferencesHandler manages which optional emails users get
+type EmailPreferencesHandler struct {
+	db *database.DB
+}
+
+// NewEmailPreferencesHandler creates a new email preferences handler
+func NewEmailPreferencesHandler(db *database.DB) *EmailPreferencesHandler {
+	return &EmailPreferencesHandler{db: db}
+}
+
+// GetEmailPreferences returns the user's email preferences
+func (h *EmailPreferencesHandler) GetEmailPreferences(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	prefs, err := h.db.GetEmailPreferences(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get email preferences: %v", err)
+		c.Error(apierror.Internal("failed to get email preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateEmailPreferences opts the user in or out of optional emails
+func (h *EmailPreferencesHandler) UpdateEmailPreferences(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.UpdateEmailPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	prefs := models.EmailPreferences{WeeklyDigest: *req.WeeklyDigest}
+	if err := h.db.UpdateEmailPreferences(c.Request.Context(), userID, prefs); err != nil {
+		log.Printf("failed to update email preferences: %v", err)
+		c.Error(apierror.Internal("failed to update email preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
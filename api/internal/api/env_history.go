@@ -0,0 +1,117 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+)
+
+// envHistoryLimit caps how many env revisions are returned
+const envHistoryLimit = 100
+
+// GetEnvHistory returns a server's env override changes, newest first
+func (h *ServerHandler) GetEnvHistory(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	revisions, err := h.db.ListEnvRevisions(c.Request.Context(), serverID, envHistoryLimit)
+	if err != nil {
+		log.Printf("failed to list env revisions for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to get environment history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// RevertServerEnv restores the env overrides saved by an earlier revision. The revert is
+// recorded as a new revision, so it can be undone too.
+func (h *ServerHandler) RevertServerEnv(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	revisionNum, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.Error(apierror.ErrEnvRevisionNotFound)
+		return
+	}
+
+	target, err := h.db.GetEnvRevision(c.Request.Context(), serverID, revisionNum)
+	if err != nil {
+		log.Printf("failed to get env revision %d for server %s: %v", revisionNum, serverID, err)
+		c.Error(apierror.Internal("failed to revert environment variables"))
+		return
+	}
+	if target == nil {
+		c.Error(apierror.ErrEnvRevisionNotFound)
+		return
+	}
+
+	revision, err := h.db.UpdateServerEnvWithRevision(c.Request.Context(), serverID, target.EnvOverrides, userID, &revisionNum)
+	if err != nil {
+		log.Printf("failed to revert env overrides for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to revert environment variables"))
+		return
+	}
+
+	server.EnvOverrides = target.EnvOverrides
+	restartRequired, err := h.restartRequired(c.Request.Context(), server)
+	if err != nil {
+		log.Printf("failed to check pending changes for server %s: %v", serverID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":           "updated",
+		"revision":         revision, // null when the overrides already matched
+		"env_overrides":    target.EnvOverrides,
+		"message":          "Environment variables updated.",
+		"restart_required": restartRequired,
+	})
+}
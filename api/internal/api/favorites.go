@@ -0,0 +1,93 @@
+package api
+
+import (
+	"cmp"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// SetServerFavorite marks or unmarks a server as one of the user's favorites
+func (h *ServerHandler) SetServerFavorite(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	var req models.SetServerFavoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if err := h.db.SetServerFavorite(c.Request.Context(), userID, server.ID, *req.Favorite); err != nil {
+		log.Printf("failed to set favorite for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to update favorite"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"favorite": *req.Favorite,
+	})
+}
+
+// sortServers orders a server list as requested. Servers that compare equal keep their order.
+func sortServers(servers []models.Server, order models.ServerSort) {
+	recent := func(a, b models.Server) int {
+		// Never opened sorts last
+		return cmp.Compare(accessedAt(b), accessedAt(a))
+	}
+
+	switch order {
+	case models.ServerSortRecent:
+		slices.SortStableFunc(servers, recent)
+	case models.ServerSortFavorites:
+		slices.SortStableFunc(servers, func(a, b models.Server) int {
+			if a.Favorite != b.Favorite {
+				if a.Favorite {
+					return -1
+				}
+				return 1
+			}
+			return recent(a, b)
+		})
+	}
+}
+
+// accessedAt returns when a server was last opened, as Unix nanoseconds, 0 if never
+func accessedAt(server models.Server) int64 {
+	if server.LastAccessedAt == nil {
+		return 0
+	}
+	return server.LastAccessedAt.UnixNano()
+}
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// fileAccessLifetime is how long a file access pod runs before stopping itself
+	fileAccessLifetime = time.Hour
+
+	// fileListTimeout bounds listing a directory through the file access pod
+	fileListTimeout = 30 * time.Second
+)
+
+// fileAccessClient talks to supervisors' file servers. Downloads can be large, so requests
+// are bounded by their context rather than a client timeout.
+var fileAccessClient = &http.Client{}
+
+// GetFileAccess returns the state of an expired server's file access
+func (h *ServerHandler) GetFileAccess(c *gin.Context) {
+	server := h.getExpiredServer(c)
+	if server == nil {
+		return
+	}
+
+	pod, err := h.k8sClient.GetFileAccessPod(c.Request.Context(), server.K8sNamespace(h.config.K8sNamespace), server.ID.String())
+	if err != nil {
+		log.Printf("failed to get file access pod for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to get file access"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"file_access": fileAccessState(server, pod)})
+}
+
+// StartFileAccess starts a temporary pod serving an expired server's data read-only, so the
+// owner can download their files without resubscribing. Starting it while it runs is a no-op.
+func (h *ServerHandler) StartFileAccess(c *gin.Context) {
+	server := h.getExpiredServer(c)
+	if server == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	namespace := server.K8sNamespace(h.config.K8sNamespace)
+	serverID := server.ID.String()
+
+	pod, err := h.k8sClient.GetFileAccessPod(ctx, namespace, serverID)
+	if err != nil {
+		log.Printf("failed to get file access pod for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to start file access"))
+		return
+	}
+
+	if pod == nil {
+		// Remove pods that reached their lifetime, so they don't pile up
+		if err := h.k8sClient.DeleteFileAccessPods(ctx, namespace, serverID); err != nil {
+			log.Printf("failed to delete stopped file access pods for server %s: %v", serverID, err)
+		}
+
+		token, err := generateFileAccessToken()
+		if err != nil {
+			log.Printf("failed to generate file access token: %v", err)
+			c.Error(apierror.Internal("failed to start file access"))
+			return
+		}
+
+		pod, err = h.k8sClient.CreateFileAccessPod(ctx, k8s.FileAccessParams{
+			Namespace: namespace,
+			ServerID:  serverID,
+			Image:     h.config.FileAccessImage,
+			PVCName:   "server-" + serverID,
+			Token:     token,
+			Lifetime:  fileAccessLifetime,
+		})
+		if err != nil {
+			log.Printf("failed to create file access pod for server %s: %v", serverID, err)
+			c.Error(apierror.Internal("failed to start file access"))
+			return
+		}
+		log.Printf("started file access for server %s: pod=%s", serverID, pod.Name)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"file_access": fileAccessState(server, pod)})
+}
+
+// StopFileAccess stops an expired server's file access before its lifetime is up
+func (h *ServerHandler) StopFileAccess(c *gin.Context) {
+	server := h.getExpiredServer(c)
+	if server == nil {
+		return
+	}
+
+	if err := h.k8sClient.DeleteFileAccessPods(c.Request.Context(), server.K8sNamespace(h.config.K8sNamespace), server.ID.String()); err != nil {
+		log.Printf("failed to delete file access pods for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to stop file access"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"file_access": fileAccessState(server, nil)})
+}
+
+// ListFiles lists a directory (?path=, relative to the data root) of a running server, or of
+// an expired one through its file access pod, directories first
+func (h *ServerHandler) ListFiles(c *gin.Context) {
+	target := h.getFilesTarget(c, false)
+	if target == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), fileListTimeout)
+	defer cancel()
+
+	resp, err := supervisorFilesRequest(ctx, target.podIP, target.token, "/files", c.Query("path"), "")
+	if err != nil {
+		log.Printf("failed to list files of server %s: %v", target.server.ID, err)
+		c.Error(apierror.Internal("failed to list files"))
+		return
+	}
+	defer resp.Body.Close()
+
+	if !fileAccessResponseOK(c, resp) {
+		return
+	}
+	c.DataFromReader(http.StatusOK, resp.ContentLength, "application/json", resp.Body, nil)
+}
+
+// DownloadFile downloads a file (?path=) of a running or expired server, or a directory as
+// a zip archive. Single files support range requests, so interrupted downloads can resume.
+func (h *ServerHandler) DownloadFile(c *gin.Context) {
+	target := h.getFilesTarget(c, false)
+	if target == nil {
+		return
+	}
+
+	proxySupervisorDownload(c, target.server.ID.String(), target.podIP, target.token, c.Query("path"))
+}
+
+// getExpiredServer returns the server in the path if the user owns it and it's expired,
+// awaiting deletion. Otherwise it sets the error and returns nil.
+func (h *ServerHandler) getExpiredServer(c *gin.Context) *models.Server {
+	if !h.config.FileAccessEnabled() {
+		c.Error(apierror.ErrFileAccessDisabled)
+		return nil
+	}
+
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return nil
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return nil
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return nil
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return nil
+	}
+
+	// Running servers are reached through the game itself; deleted ones have no data left
+	if server.Status != models.ServerStatusExpired {
+		c.Error(apierror.InvalidServerState("file access is only available for expired servers"))
+		return nil
+	}
+
+	return server
+}
+
+// getReadyFileAccessPod returns the server's file access pod if it's ready to serve files.
+// Otherwise it sets the error and returns nil.
+func (h *ServerHandler) getReadyFileAccessPod(c *gin.Context, server *models.Server) *corev1.Pod {
+	pod, err := h.k8sClient.GetFileAccessPod(c.Request.Context(), server.K8sNamespace(h.config.K8sNamespace), server.ID.String())
+	if err != nil {
+		log.Printf("failed to get file access pod for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to get file access"))
+		return nil
+	}
+	if pod == nil || !k8s.PodReady(pod) {
+		c.Error(apierror.ErrFileAccessNotReady)
+		return nil
+	}
+	return pod
+}
+
+// fileAccessState describes the file access a pod provides; nil means none
+func fileAccessState(server *models.Server, pod *corev1.Pod) models.FileAccess {
+	access := models.FileAccess{Status: models.FileAccessStopped, DeleteAfter: server.DeleteAfter}
+	if pod == nil {
+		return access
+	}
+
+	access.Status = models.FileAccessStarting
+	if k8s.PodReady(pod) {
+		access.Status = models.FileAccessReady
+	}
+
+	// The lifetime counts from when the pod was scheduled
+	started := pod.CreationTimestamp.Time
+	if pod.Status.StartTime != nil {
+		started = pod.Status.StartTime.Time
+	}
+	if pod.Spec.ActiveDeadlineSeconds != nil {
+		expiresAt := started.Add(time.Duration(*pod.Spec.ActiveDeadlineSeconds) * time.Second).UTC()
+		access.ExpiresAt = &expiresAt
+	}
+	return access
+}
+
+// supervisorFilesRequest sends a request for path to the file endpoints of the supervisor
+// at podIP, authenticated with token
+func supervisorFilesRequest(ctx context.Context, podIP, token, endpoint, path, rangeHeader string) (*http.Response, error) {
+	target := fmt.Sprintf("http://%s:%d%s?path=%s", podIP, k8s.SupervisorHTTPPort, endpoint, url.QueryEscape(path))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	return fileAccessClient.Do(req)
+}
+
+// proxySupervisorDownload streams a file (or a directory as a zip) from the supervisor at
+// podIP to the client. Range requests are passed through, so downloads can resume.
+func proxySupervisorDownload(c *gin.Context, serverID, podIP, token, path string) {
+	resp, err := supervisorFilesRequest(c.Request.Context(), podIP, token, "/files/download", path, c.GetHeader("Range"))
+	if err != nil {
+		log.Printf("failed to download files of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to download files"))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent && !fileAccessResponseOK(c, resp) {
+		return
+	}
+
+	for _, header := range []string{"Content-Type", "Content-Length", "Content-Disposition", "Content-Range", "Accept-Ranges", "Last-Modified"} {
+		if value := resp.Header.Get(header); value != "" {
+			c.Header(header, value)
+		}
+	}
+	c.Status(resp.StatusCode)
+	if _, err := io.Copy(c.Writer, resp.Body); err != nil {
+		log.Printf("file download of server %s interrupted: %v", serverID, err)
+	}
+}
+
+// fileAccessResponseOK reports whether the supervisor succeeded, setting the error for the
+// path not existing or being unreadable otherwise
+func fileAccessResponseOK(c *gin.Context, resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true
+	case http.StatusNotFound:
+		c.Error(apierror.NotFound("path not found"))
+	case http.StatusForbidden, http.StatusBadRequest:
+		c.Error(apierror.BadRequest("path can't be read"))
+	default:
+		log.Printf("supervisor file server returned status %d", resp.StatusCode)
+		c.Error(apierror.Internal("failed to read files"))
+	}
+	return false
+}
+
+// generateFileAccessToken returns a random 32-byte hex token for a file access pod
+func generateFileAccessToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
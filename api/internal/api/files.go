@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+)
+
+// filesTarget is the supervisor serving a server's files, and the token it accepts
+type filesTarget struct {
+	server *models.Server
+	podIP  string
+	token  string
+}
+
+// UploadFile stores the request body as the file at ?path= on the running server's data
+// volume, creating missing directories and replacing an existing file
+func (h *ServerHandler) UploadFile(c *gin.Context) {
+	target := h.getFilesTarget(c, true)
+	if target == nil {
+		return
+	}
+
+	maxBytes := h.fileUploadMaxBytes()
+	if maxBytes > 0 && c.Request.ContentLength > maxBytes {
+		c.Error(h.fileTooLarge())
+		return
+	}
+	body := c.Request.Body
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, maxBytes)
+	}
+
+	resp, err := supervisorFilesWrite(c.Request.Context(), target, http.MethodPut, "/files/upload", c.Query("path"), body, c.Request.ContentLength)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Error(h.fileTooLarge())
+			return
+		}
+		log.Printf("failed to upload file to server %s: %v", target.server.ID, err)
+		c.Error(apierror.Internal("failed to upload file"))
+		return
+	}
+	defer resp.Body.Close()
+
+	if !filesWriteResponseOK(c, resp, http.StatusCreated) {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "File uploaded"})
+}
+
+// CreateDirectory creates the directory at ?path= on the running server's data volume
+func (h *ServerHandler) CreateDirectory(c *gin.Context) {
+	target := h.getFilesTarget(c, true)
+	if target == nil {
+		return
+	}
+
+	resp, err := supervisorFilesWrite(c.Request.Context(), target, http.MethodPost, "/files/mkdir", c.Query("path"), nil, 0)
+	if err != nil {
+		log.Printf("failed to create directory on server %s: %v", target.server.ID, err)
+		c.Error(apierror.Internal("failed to create directory"))
+		return
+	}
+	defer resp.Body.Close()
+
+	if !filesWriteResponseOK(c, resp, http.StatusCreated) {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Directory created"})
+}
+
+// DeleteFile deletes the file or directory (with its contents) at ?path= on the running
+// server's data volume
+func (h *ServerHandler) DeleteFile(c *gin.Context) {
+	target := h.getFilesTarget(c, true)
+	if target == nil {
+		return
+	}
+
+	resp, err := supervisorFilesWrite(c.Request.Context(), target, http.MethodDelete, "/files", c.Query("path"), nil, 0)
+	if err != nil {
+		log.Printf("failed to delete file on server %s: %v", target.server.ID, err)
+		c.Error(apierror.Internal("failed to delete file"))
+		return
+	}
+	defer resp.Body.Close()
+
+	if !filesWriteResponseOK(c, resp, http.StatusNoContent) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "File deleted"})
+}
+
+// getFilesTarget returns the supervisor serving the files of the server in the path, if the
+// user owns it: the game's own supervisor while it runs, or the file access pod once it
+// expired, which is read-only. Otherwise it sets the error and returns nil.
+func (h *ServerHandler) getFilesTarget(c *gin.Context, write bool) *filesTarget {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return nil
+	}
+	ctx := c.Request.Context()
+
+	switch server.Status {
+	case models.ServerStatusExpired:
+		if write {
+			c.Error(apierror.InvalidServerState("files of expired servers are read-only"))
+			return nil
+		}
+		if !h.config.FileAccessEnabled() {
+			c.Error(apierror.ErrFileAccessDisabled)
+			return nil
+		}
+		pod := h.getReadyFileAccessPod(c, server)
+		if pod == nil {
+			return nil
+		}
+		return &filesTarget{server: server, podIP: pod.Status.PodIP, token: k8s.FileAccessToken(pod)}
+
+	case models.ServerStatusStarting, models.ServerStatusRunning:
+		serverID := server.ID.String()
+		podIP, err := h.runningPodIP(ctx, server)
+		if err != nil {
+			log.Printf("failed to list pods for server %s: %v", serverID, err)
+			c.Error(apierror.Internal("failed to access files"))
+			return nil
+		}
+		if podIP == "" {
+			c.Error(apierror.InvalidServerState("server must be running to manage its files"))
+			return nil
+		}
+		token, err := h.db.GetServerAuthToken(ctx, serverID)
+		if err != nil {
+			log.Printf("failed to get auth token of server %s: %v", serverID, err)
+			c.Error(apierror.Internal("failed to access files"))
+			return nil
+		}
+		return &filesTarget{server: server, podIP: podIP, token: token}
+
+	default:
+		c.Error(apierror.InvalidServerState("server must be running to manage its files"))
+		return nil
+	}
+}
+
+// supervisorFilesWrite sends a request changing path to the file endpoints of the target's
+// supervisor
+func supervisorFilesWrite(ctx context.Context, target *filesTarget, method, endpoint, path string, body io.Reader, contentLength int64) (*http.Response, error) {
+	u := fmt.Sprintf("http://%s:%d%s?path=%s", target.podIP, k8s.SupervisorHTTPPort, endpoint, url.QueryEscape(path))
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = contentLength
+	req.Header.Set("Authorization", "Bearer "+target.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	return fileAccessClient.Do(req)
+}
+
+// filesWriteResponseOK reports whether the supervisor answered with want, setting the error
+// for what went wrong otherwise. Supervisors started before file management have no write
+// endpoints: uploads get 404, and deletes reach the listing instead.
+func filesWriteResponseOK(c *gin.Context, resp *http.Response, want int) bool {
+	switch {
+	case resp.StatusCode == want:
+		return true
+	case resp.StatusCode == http.StatusNotFound && want == http.StatusNoContent:
+		c.Error(apierror.NotFound("path not found"))
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusOK:
+		c.Error(apierror.BadRequest("restart the server to enable file management"))
+	case resp.StatusCode == http.StatusForbidden:
+		c.Error(apierror.BadRequest("path can't be changed"))
+	case resp.StatusCode == http.StatusConflict:
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict, "path is a directory"))
+	case resp.StatusCode == http.StatusInsufficientStorage:
+		c.Error(apierror.BadRequest("not enough free space on the server for the file"))
+	case resp.StatusCode == http.StatusBadRequest:
+		c.Error(apierror.BadRequest("path can't be written"))
+	default:
+		log.Printf("supervisor file server returned status %d", resp.StatusCode)
+		c.Error(apierror.Internal("failed to change files"))
+	}
+	return false
+}
+
+// fileUploadMaxBytes is the largest file owners can upload (0 means no limit)
+func (h *ServerHandler) fileUploadMaxBytes() int64 {
+	return int64(h.config.FileUploadMaxMB) << 20
+}
+
+func (h *ServerHandler) fileTooLarge() *apierror.Error {
+	return apierror.New(http.StatusRequestEntityTooLarge, apierror.CodeFileTooLarge,
+		fmt.Sprintf("file is over the %d MiB upload limit", h.config.FileUploadMaxMB))
+}
@@ -6,32 +6,69 @@ import (
 	"github.com/mooncorn/gshub/api/config"
 	"github.com/mooncorn/gshub/api/internal/api/middleware"
 	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/services/account"
 	"github.com/mooncorn/gshub/api/internal/services/auth"
 	"github.com/mooncorn/gshub/api/internal/services/broadcast"
 	"github.com/mooncorn/gshub/api/internal/services/email"
 	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/nodesync"
 	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+	"github.com/mooncorn/gshub/api/internal/services/querycache"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
 	"github.com/mooncorn/gshub/api/internal/services/stripe"
+	"github.com/mooncorn/gshub/api/internal/services/suspension"
 )
 
 type Handlers struct {
-	Config         *config.Config
-	AuthHandler    *AuthHandler
-	ServerHandler  *ServerHandler
-	BillingHandler *BillingHandler
+	Config                  *config.Config
+	AuthHandler             *AuthHandler
+	ServerHandler           *ServerHandler
+	BillingHandler          *BillingHandler
+	AdminHandler            *AdminHandler
+	StatusHandler           *StatusHandler
+	QueryHandler            *QueryHandler
+	DiscordHandler          *DiscordHandler
+	EdgeHandler             *EdgeHandler
+	LinkedAccountHandler    *LinkedAccountHandler
+	EmailPreferencesHandler *EmailPreferencesHandler
+
+	// StripeService is shared with background services so mock subscriptions stay consistent
+	StripeService *stripe.Service
+
+	// AccountService is shared with abuse detection, which suspends repeat offenders
+	AccountService *account.Service
+
+	// MockStripeHandler is only set when STRIPE_MOCK_MODE is enabled
+	MockStripeHandler *MockStripeHandler
 }
 
-func NewHandlers(db *database.DB, cfg *config.Config, k8sClient *k8s.Client, portAllocService *portalloc.Service, hub *broadcast.Hub) *Handlers {
+func NewHandlers(db *database.DB, cfg *config.Config, k8sClient *k8s.Client, portAllocService *portalloc.Service, machine *serverstate.Machine, hub *broadcast.Hub, nodeSyncService *nodesync.Service) *Handlers {
 	authService := auth.NewService(db, cfg)
 	emailService := email.NewService(cfg)
-	stripeService := stripe.NewService(db, cfg, k8sClient, portAllocService, cfg.K8sNamespace)
+	accountService := account.NewService(db, emailService, cfg)
+	stripeService := stripe.NewService(db, cfg, k8sClient, portAllocService, machine, accountService, cfg.K8sNamespace)
+
+	handlers := &Handlers{
+		Config:                  cfg,
+		AuthHandler:             NewAuthHandler(authService, emailService, accountService),
+		ServerHandler:           NewServerHandler(db, k8sClient, cfg, stripeService, portAllocService, machine, hub),
+		BillingHandler:          NewBillingHandler(db, cfg, stripeService),
+		AdminHandler:            NewAdminHandler(db, k8sClient, cfg, suspension.NewService(db, k8sClient, portAllocService, cfg.K8sNamespace), accountService, stripeService, hub, machine, nodeSyncService),
+		StatusHandler:           NewStatusHandler(db, k8sClient, stripeService),
+		QueryHandler:            NewQueryHandler(querycache.New(db, querycache.DefaultConfig())),
+		DiscordHandler:          NewDiscordHandler(db, authService, cfg.DiscordBotSecret),
+		EdgeHandler:             NewEdgeHandler(db, cfg),
+		LinkedAccountHandler:    NewLinkedAccountHandler(db, cfg),
+		EmailPreferencesHandler: NewEmailPreferencesHandler(db),
+		StripeService:           stripeService,
+		AccountService:          accountService,
+	}
 
-	return &Handlers{
-		Config:         cfg,
-		AuthHandler:    NewAuthHandler(authService, emailService),
-		ServerHandler:  NewServerHandler(db, k8sClient, cfg, stripeService, portAllocService, hub),
-		BillingHandler: NewBillingHandler(db, cfg, stripeService),
+	if stripeService.IsMockMode() {
+		handlers.MockStripeHandler = NewMockStripeHandler(db, stripeService)
 	}
+
+	return handlers
 }
 
 // RegisterRoutes registers all API routes
@@ -40,11 +77,14 @@ func (h *Handlers) RegisterRoutes(r *gin.Engine) {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     h.Config.AllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Accept-Language", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
 		AllowCredentials: true,
 	}))
 
+	// Render errors attached via c.Error as the standard error envelope
+	r.Use(middleware.RequestID(), middleware.Locale(), middleware.ErrorHandler())
+
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"status": "healthy",
@@ -56,6 +96,7 @@ func (h *Handlers) RegisterRoutes(r *gin.Engine) {
 	{
 		authRoutes.POST("/register", h.AuthHandler.Register)
 		authRoutes.POST("/login", h.AuthHandler.Login)
+		authRoutes.POST("/login/mfa", h.AuthHandler.LoginMFA)
 		authRoutes.POST("/logout", h.AuthHandler.Logout)
 		authRoutes.POST("/refresh", h.AuthHandler.RefreshToken)
 		authRoutes.POST("/verify-email", h.AuthHandler.VerifyEmail)
@@ -64,30 +105,197 @@ func (h *Handlers) RegisterRoutes(r *gin.Engine) {
 		authRoutes.POST("/reset-password", h.AuthHandler.ResetPassword)
 	}
 
+	// Platform status (public)
+	r.GET("/status/incidents", h.StatusHandler.ListIncidents)
+	r.GET("/status/platform", h.StatusHandler.GetPlatformStatus)
+	r.GET("/status/platform/badge", h.StatusHandler.GetPlatformBadge)
+
+	// Public server queries, answered from cached supervisor data
+	r.GET("/query/:subdomain", h.QueryHandler.GetServerQuery)
+	r.GET("/query/:subdomain/badge", h.QueryHandler.GetServerQueryBadge)
+
+	// Backup and export downloads, authorized by a signed link
+	r.GET("/downloads/commands/:commandId", h.ServerHandler.DownloadCommandArtifact)
+
+	// One-click cancel from a stopped-server reminder email, authorized by a signed link
+	r.POST("/servers/:id/reminder-action", h.BillingHandler.CancelFromReminder)
+
 	// Protected routes
 	protected := r.Group("")
-	protected.Use(middleware.AuthMiddleware(h.Config.JWTSecret))
+	protected.Use(
+		middleware.AuthMiddleware(h.Config.JWTSecret),
+		middleware.RestrictTokenScope(),
+		middleware.RequireActiveAccount(h.AccountService.IsSuspended),
+	)
 	{
 		// User profile
 		protected.GET("/me", h.AuthHandler.GetProfile)
 		protected.PATCH("/me", h.AuthHandler.UpdateProfile)
+		protected.POST("/me/reinstatement-request", h.AuthHandler.RequestReinstatement)
+		protected.GET("/me/mfa", h.AuthHandler.GetMFA)
+		protected.POST("/me/mfa/setup", h.AuthHandler.SetupMFA)
+		protected.POST("/me/mfa/enable", h.AuthHandler.EnableMFA)
+		protected.POST("/me/mfa/disable", h.AuthHandler.DisableMFA)
+		protected.POST("/me/mfa/backup-codes", h.AuthHandler.RegenerateMFABackupCodes)
+		protected.GET("/me/discord", h.DiscordHandler.GetLink)
+		protected.POST("/me/discord/link-code", h.DiscordHandler.CreateLinkCode)
+		protected.DELETE("/me/discord", h.DiscordHandler.Unlink)
+		protected.GET("/me/linked-accounts", h.LinkedAccountHandler.ListLinkedAccounts)
+		protected.POST("/me/linked-accounts/steam", h.LinkedAccountHandler.StartSteamLink)
+		protected.POST("/me/linked-accounts/steam/verify", h.LinkedAccountHandler.VerifySteamLink)
+		protected.DELETE("/me/linked-accounts/:provider", h.LinkedAccountHandler.UnlinkAccount)
+		protected.GET("/me/email-preferences", h.EmailPreferencesHandler.GetEmailPreferences)
+		protected.PUT("/me/email-preferences", h.EmailPreferencesHandler.UpdateEmailPreferences)
 
 		// Server management
 		protected.GET("/servers", h.ServerHandler.ListServers)
 		protected.GET("/servers/status", h.ServerHandler.StreamStatus) // SSE endpoint for real-time status updates
+		protected.GET("/servers/port-availability", h.ServerHandler.CheckPortAvailability)
 		protected.GET("/servers/:id", h.ServerHandler.GetServer)
+		protected.GET("/servers/:id/capabilities", h.ServerHandler.GetServerCapabilities)
+		protected.DELETE("/servers/:id", h.ServerHandler.DeleteServer)
+		protected.PUT("/servers/:id/favorite", h.ServerHandler.SetServerFavorite)
 		protected.GET("/servers/:id/logs", h.ServerHandler.StreamLogs)
+		protected.GET("/servers/:id/logs/download", h.ServerHandler.DownloadLogs)
 		protected.POST("/servers/:id/stop", h.ServerHandler.StopServer)
 		protected.POST("/servers/:id/start", h.ServerHandler.StartServer)
 		protected.POST("/servers/:id/restart", h.ServerHandler.RestartServer)
+		protected.POST("/servers/:id/process/restart", h.ServerHandler.RestartProcess)
 		protected.PUT("/servers/:id/env", h.ServerHandler.UpdateServerEnv)
+		protected.GET("/servers/:id/env/history", h.ServerHandler.GetEnvHistory)
+		protected.GET("/servers/:id/pending-changes", h.ServerHandler.GetPendingChanges)
+		protected.GET("/servers/:id/egress", h.ServerHandler.GetEgressUsage)
+		protected.GET("/servers/:id/recommendations", h.ServerHandler.GetRecommendations)
+		protected.GET("/servers/:id/metrics", h.ServerHandler.GetServerMetrics)
+		protected.GET("/servers/:id/sessions", h.ServerHandler.GetServerSessions)
+		protected.POST("/servers/:id/env/revert/:revision", h.ServerHandler.RevertServerEnv)
+		protected.PUT("/servers/:id/custom-game", h.ServerHandler.UpdateCustomGame)
+		protected.POST("/servers/:id/upgrade-from-oom", h.ServerHandler.UpgradeFromOOM)
+		protected.GET("/servers/:id/operations", h.ServerHandler.ListOperations)
+		protected.POST("/servers/:id/commands", h.ServerHandler.SendCommand)
+		protected.GET("/servers/:id/commands/:commandId", h.ServerHandler.GetCommand)
+		protected.POST("/servers/:id/commands/:commandId/download-url", h.ServerHandler.CreateCommandDownloadURL)
+		protected.POST("/servers/:id/command", h.ServerHandler.RunConsoleCommand)
+		protected.GET("/servers/:id/console", h.ServerHandler.Console)
+		protected.POST("/servers/:id/import", h.ServerHandler.StartImport)
+		protected.PUT("/servers/:id/import/:commandId", h.ServerHandler.UploadImport)
+		protected.GET("/servers/:id/backup-policy", h.ServerHandler.GetBackupPolicy)
+		protected.PUT("/servers/:id/backup-policy", h.ServerHandler.UpdateBackupPolicy)
+		protected.GET("/servers/:id/backup-replicas", h.ServerHandler.ListBackupReplicas)
+		protected.GET("/servers/:id/volume-backups", h.ServerHandler.ListVolumeBackups)
+		protected.POST("/servers/:id/volume-backups", h.ServerHandler.CreateVolumeBackup)
+		protected.DELETE("/servers/:id/volume-backups/:backupId", h.ServerHandler.DeleteVolumeBackup)
+		protected.POST("/servers/:id/volume-backups/:backupId/restore", h.ServerHandler.RestoreVolumeBackup)
+		protected.DELETE("/servers/:id/volume-restore", h.ServerHandler.CancelVolumeRestore)
+		protected.GET("/servers/:id/schedules", h.ServerHandler.ListSchedules)
+		protected.POST("/servers/:id/schedules", h.ServerHandler.CreateSchedule)
+		protected.PUT("/servers/:id/schedules/:scheduleId", h.ServerHandler.UpdateSchedule)
+		protected.DELETE("/servers/:id/schedules/:scheduleId", h.ServerHandler.DeleteSchedule)
+		protected.GET("/servers/:id/webhooks", h.ServerHandler.ListWebhooks)
+		protected.POST("/servers/:id/webhooks", h.ServerHandler.CreateWebhook)
+		protected.DELETE("/servers/:id/webhooks/:webhookId", h.ServerHandler.DeleteWebhook)
+		protected.GET("/servers/:id/webhooks/:webhookId/deliveries", h.ServerHandler.ListWebhookDeliveries)
+		protected.GET("/servers/:id/domains", h.ServerHandler.ListCustomDomains)
+		protected.POST("/servers/:id/domains", h.ServerHandler.CreateCustomDomain)
+		protected.POST("/servers/:id/domains/:domainId/verify", h.ServerHandler.VerifyCustomDomain)
+		protected.DELETE("/servers/:id/domains/:domainId", h.ServerHandler.DeleteCustomDomain)
+		protected.GET("/servers/:id/file-access", h.ServerHandler.GetFileAccess)
+		protected.POST("/servers/:id/file-access", h.ServerHandler.StartFileAccess)
+		protected.DELETE("/servers/:id/file-access", h.ServerHandler.StopFileAccess)
+		protected.GET("/servers/:id/files", h.ServerHandler.ListFiles)
+		protected.GET("/servers/:id/files/download", h.ServerHandler.DownloadFile)
+		protected.PUT("/servers/:id/files/upload", h.ServerHandler.UploadFile)
+		protected.POST("/servers/:id/files/mkdir", h.ServerHandler.CreateDirectory)
+		protected.DELETE("/servers/:id/files", h.ServerHandler.DeleteFile)
+		protected.GET("/servers/:id/mods", h.ServerHandler.ListMods)
+		protected.GET("/servers/:id/mods/search", h.ServerHandler.SearchMods)
+		protected.POST("/servers/:id/mods", h.ServerHandler.InstallMod)
+		protected.DELETE("/servers/:id/mods/:modId", h.ServerHandler.RemoveMod)
+		protected.POST("/servers/:id/mods/pending", h.ServerHandler.InstallPendingMods)
+		protected.DELETE("/servers/:id/mods/pending/:projectId", h.ServerHandler.DismissPendingMod)
+		protected.POST("/servers/:id/steam-token", h.ServerHandler.CreateSteamToken)
 		protected.POST("/servers/checkout", h.ServerHandler.CreateCheckoutSession)
+		protected.POST("/capacity-waitlist", h.ServerHandler.JoinCapacityWaitlist)
+		protected.GET("/capacity-waitlist", h.ServerHandler.ListCapacityWaitlist)
+		protected.DELETE("/capacity-waitlist/:id", h.ServerHandler.LeaveCapacityWaitlist)
+		protected.POST("/servers/from-template/:id", h.ServerHandler.CreateServerFromTemplate)
+		protected.POST("/servers/:id/clone", h.ServerHandler.CloneServer)
+		protected.GET("/servers/:id/clone", h.ServerHandler.GetServerClone)
+
+		// Server groups
+		protected.GET("/server-groups", h.ServerHandler.ListServerGroups)
+		protected.POST("/server-groups", h.ServerHandler.CreateServerGroup)
+		protected.PUT("/server-groups/order", h.ServerHandler.ReorderServerGroups)
+		protected.PATCH("/server-groups/:id", h.ServerHandler.UpdateServerGroup)
+		protected.DELETE("/server-groups/:id", h.ServerHandler.DeleteServerGroup)
+		protected.PUT("/server-groups/:id/servers", h.ServerHandler.SetGroupServers)
+
+		// Server templates
+		protected.GET("/templates", h.ServerHandler.ListTemplates)
+		protected.POST("/templates", h.ServerHandler.CreateTemplate)
+		protected.GET("/templates/:id", h.ServerHandler.GetTemplate)
+		protected.PATCH("/templates/:id", h.ServerHandler.UpdateTemplate)
+		protected.DELETE("/templates/:id", h.ServerHandler.DeleteTemplate)
 
 		// Billing
 		protected.GET("/billing", h.BillingHandler.GetBilling)
 		protected.POST("/billing/servers/:id/cancel", h.BillingHandler.CancelSubscription)
 		protected.POST("/billing/servers/:id/resume", h.BillingHandler.ResumeSubscription)
 		protected.POST("/billing/servers/:id/resubscribe", h.BillingHandler.ResubscribeServer)
+		protected.PUT("/billing/servers/:id/auto-cancel", h.BillingHandler.SetAutoCancel)
+		protected.GET("/billing/invoices", h.BillingHandler.ListInvoices)
+		protected.GET("/billing/payment-method", h.BillingHandler.GetPaymentMethod)
+		protected.POST("/billing/portal", h.BillingHandler.CreatePortalSession)
+		protected.GET("/billing/spend-limit", h.BillingHandler.GetSpendLimit)
+		protected.PUT("/billing/spend-limit", h.BillingHandler.UpdateSpendLimit)
+
+		// Admin (restricted to users with the admin role and ADMIN_EMAILS)
+		admin := protected.Group("/admin")
+		admin.Use(middleware.RequireAdmin(h.AdminHandler.IsAdmin))
+		{
+			admin.GET("/servers", h.AdminHandler.ListServers)
+			admin.POST("/servers/:id/force-stop", h.AdminHandler.ForceStopServer)
+			admin.DELETE("/servers/:id", h.AdminHandler.ForceDeleteServer)
+			admin.POST("/servers/:id/requeue", h.AdminHandler.RequeueServer)
+			admin.GET("/nodes", h.AdminHandler.ListNodes)
+			admin.POST("/nodes/sync", h.AdminHandler.SyncNodes)
+			admin.GET("/users/:id/status", h.AdminHandler.StreamUserStatus)
+			admin.GET("/status-streams", h.AdminHandler.StatusStreamStats)
+			admin.PUT("/users/:id/role", h.AdminHandler.SetUserRole)
+			admin.GET("/disputes", h.AdminHandler.ListDisputes)
+			admin.POST("/servers/:id/lift-suspension", h.AdminHandler.LiftSuspension)
+			admin.GET("/abuse-reports", h.AdminHandler.ListAbuseReports)
+			admin.PUT("/servers/:id/abuse-exempt", h.AdminHandler.SetAbuseExempt)
+			admin.PUT("/servers/:id/catalog-channel", h.AdminHandler.SetCatalogChannel)
+			admin.GET("/servers/:id/k8s", h.AdminHandler.InspectServer)
+			admin.GET("/banned-hashes", h.AdminHandler.ListBannedHashes)
+			admin.POST("/banned-hashes", h.AdminHandler.AddBannedHash)
+			admin.DELETE("/banned-hashes/:sha256", h.AdminHandler.DeleteBannedHash)
+			admin.GET("/users/suspended", h.AdminHandler.ListSuspendedUsers)
+			admin.POST("/users/:id/suspend", h.AdminHandler.SuspendUser)
+			admin.POST("/users/:id/reinstate", h.AdminHandler.ReinstateUser)
+			admin.GET("/server-states", h.AdminHandler.ServerStates)
+			admin.GET("/catalog/validate", h.AdminHandler.ValidateCatalog)
+			admin.POST("/catalog/validate", h.AdminHandler.ValidateCatalog)
+			admin.POST("/nodes/register-token", h.AdminHandler.CreateNodeRegistrationToken)
+			admin.PUT("/nodes/:name/cost", h.AdminHandler.SetNodeCost)
+			admin.GET("/nodes/port-range", h.AdminHandler.GetPortRange)
+			admin.PUT("/nodes/port-range", h.AdminHandler.SetPortRange)
+			admin.GET("/nodes/blocked-ports", h.AdminHandler.ListBlockedPorts)
+			admin.POST("/nodes/blocked-ports", h.AdminHandler.BlockPorts)
+			admin.DELETE("/nodes/blocked-ports/:id", h.AdminHandler.UnblockPorts)
+			admin.POST("/nodes/blocked-ports/release", h.AdminHandler.ReleaseBlockedPorts)
+			admin.GET("/nodes/port-history", h.AdminHandler.ListPortHistory)
+			admin.GET("/reports/margin", h.AdminHandler.GetMarginReport)
+		}
+
+		// Simulated Stripe flow (local development and E2E tests only)
+		if h.MockStripeHandler != nil {
+			protected.GET("/dev/stripe/checkout/:session_id", h.MockStripeHandler.GetCheckoutSession)
+			protected.POST("/dev/stripe/checkout/:session_id/complete", h.MockStripeHandler.CompleteCheckoutSession)
+			protected.POST("/dev/stripe/servers/:id/end-subscription", h.MockStripeHandler.EndSubscription)
+			protected.POST("/dev/stripe/servers/:id/dispute", h.MockStripeHandler.DisputeSubscription)
+		}
 	}
 
 	// Stripe webhook (public, signature verified)
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+)
+
+// StartImport queues an import command, which replaces the server's world with an archive
+// uploaded through UploadImport. Imports queued while a new server is being created run
+// before its first start; a running game is stopped for the import and started again.
+func (h *ServerHandler) StartImport(c *gin.Context) {
+	server := h.getImportServer(c)
+	if server == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	catalog, err := h.k8sClient.LoadGameCatalog(ctx, h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err != nil {
+		log.Printf("failed to load game catalog: %v", err)
+		c.Error(apierror.Internal("failed to load game catalog"))
+		return
+	}
+	gameConfig, err := h.serverGameConfig(ctx, server, catalog)
+	if err != nil {
+		log.Printf("failed to get game config for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to load game config"))
+		return
+	}
+	if !gameConfig.SupportsImport() {
+		c.Error(apierror.ErrImportUnsupported)
+		return
+	}
+
+	// Imports are limited by the API, not the user
+	payload := fmt.Sprintf(`{"max_bytes":%d}`, h.importMaxBytes())
+	cmd, err := h.db.CreateServerCommand(ctx, server.ID.String(), models.CommandImport, &payload)
+	if err != nil {
+		log.Printf("failed to queue import for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to queue command"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"command": cmd})
+}
+
+// UploadImport streams the request body, a .zip or .tar.gz world archive, to the server's
+// supervisor for a queued import. The supervisor's HTTP server comes up before the game, so
+// the upload works as soon as the pod runs.
+func (h *ServerHandler) UploadImport(c *gin.Context) {
+	server := h.getImportServer(c)
+	if server == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	serverID := server.ID.String()
+
+	cmd, err := h.db.GetServerCommand(ctx, serverID, c.Param("commandId"))
+	if err != nil || cmd.Type != models.CommandImport {
+		c.Error(apierror.ErrCommandNotFound)
+		return
+	}
+	if cmd.State != models.CommandStatePending && cmd.State != models.CommandStateDelivered {
+		c.Error(apierror.ErrImportNotWaiting)
+		return
+	}
+
+	maxBytes := h.importMaxBytes()
+	if maxBytes > 0 && c.Request.ContentLength > maxBytes {
+		c.Error(h.importTooLarge())
+		return
+	}
+
+	podIP, err := h.runningPodIP(ctx, server)
+	if err != nil {
+		log.Printf("failed to list pods for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to upload archive"))
+		return
+	}
+	if podIP == "" {
+		c.Error(apierror.ErrImportNotReady)
+		return
+	}
+
+	token, err := h.db.GetServerAuthToken(ctx, serverID)
+	if err != nil {
+		log.Printf("failed to get auth token of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to upload archive"))
+		return
+	}
+
+	body := c.Request.Body
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, maxBytes)
+	}
+	target := fmt.Sprintf("http://%s:%d/imports/%s", podIP, k8s.SupervisorHTTPPort, cmd.ID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, body)
+	if err != nil {
+		c.Error(apierror.Internal("failed to upload archive"))
+		return
+	}
+	req.ContentLength = c.Request.ContentLength
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := fileAccessClient.Do(req)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Error(h.importTooLarge())
+			return
+		}
+		log.Printf("failed to upload import archive for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to upload archive"))
+		return
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		log.Printf("uploaded import archive for server %s: command=%s bytes=%d", serverID, cmd.ID, c.Request.ContentLength)
+		c.JSON(http.StatusOK, gin.H{"command": cmd})
+	case http.StatusConflict:
+		c.Error(apierror.ErrImportNotWaiting)
+	case http.StatusInsufficientStorage:
+		c.Error(apierror.BadRequest("not enough free space on the server for the archive"))
+	default:
+		log.Printf("supervisor rejected import archive for server %s: status %d", serverID, resp.StatusCode)
+		c.Error(apierror.Internal("failed to upload archive"))
+	}
+}
+
+// getImportServer returns the server in the path if the user owns it and it's running or
+// about to start. Otherwise it sets the error and returns nil.
+func (h *ServerHandler) getImportServer(c *gin.Context) *models.Server {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return nil
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return nil
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return nil
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return nil
+	}
+
+	switch server.Status {
+	case models.ServerStatusPending, models.ServerStatusStarting, models.ServerStatusRunning:
+		return server
+	default:
+		c.Error(apierror.InvalidServerState("server must be running or starting to import a world"))
+		return nil
+	}
+}
+
+// importMaxBytes is the largest archive, compressed or extracted, owners can import (0 means
+// no limit)
+func (h *ServerHandler) importMaxBytes() int64 {
+	return int64(h.config.ImportMaxGB) << 30
+}
+
+func (h *ServerHandler) importTooLarge() *apierror.Error {
+	return apierror.New(http.StatusRequestEntityTooLarge, apierror.CodeImportTooLarge,
+		fmt.Sprintf("archive is over the %d GiB import limit", h.config.ImportMaxGB))
+}
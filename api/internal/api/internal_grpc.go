@@ -0,0 +1,288 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/supervisorpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// supervisorService serves the gRPC protocol (proto/supervisor/v1) supervisors use in place
+// of the JSON endpoints, sharing their handling with InternalHandler
+type supervisorService struct {
+	supervisorpb.UnimplementedSupervisorServer
+	h *InternalHandler
+}
+
+// serverIDKey carries an authenticated call's server ID in its context
+type serverIDKey struct{}
+
+// NewGRPCServer returns the gRPC server for the supervisor protocol, authenticated and rate
+// limited like the JSON endpoints
+func (h *InternalHandler) NewGRPCServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, err := h.authenticateCall(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := h.authenticateCall(stream.Context())
+			if err != nil {
+				return err
+			}
+			return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+		}),
+	)
+	supervisorpb.RegisterSupervisorServer(server, &supervisorService{h: h})
+	return server
+}
+
+// authenticateCall checks a call's "x-server-id" and "authorization" metadata and the
+// server's rate limit, and returns a context carrying the server ID
+func (h *InternalHandler) authenticateCall(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	serverID := firstValue(md, "x-server-id")
+	if serverID == "" {
+		return nil, grpcError(apierror.ErrServerIDRequired)
+	}
+	if apiErr := h.authenticate(ctx, serverID, firstValue(md, "authorization")); apiErr != nil {
+		return nil, grpcError(apiErr)
+	}
+	if ok, retryAfter := h.limiter.Allow(serverID); !ok {
+		return nil, grpcError(apierror.RateLimited(middleware.RetryAfterSeconds(retryAfter)))
+	}
+	return context.WithValue(ctx, serverIDKey{}, serverID), nil
+}
+
+// authenticatedStream is a server stream whose context carries the server ID
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func serverIDFrom(ctx context.Context) string {
+	serverID, _ := ctx.Value(serverIDKey{}).(string)
+	return serverID
+}
+
+// grpcError converts an API error to a gRPC status with the closest code
+func grpcError(apiErr *apierror.Error) error {
+	code := codes.Internal
+	switch apiErr.Status {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	}
+	return status.Error(code, apiErr.Message)
+}
+
+// validate applies a request's binding tags, as gin does for the JSON endpoints
+func validate(req any) error {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return grpcError(apierror.BadRequest("invalid request body"))
+	}
+	return nil
+}
+
+func (s *supervisorService) ReportStatus(ctx context.Context, req *supervisorpb.ReportStatusRequest) (*supervisorpb.ReportStatusResponse, error) {
+	update := StatusUpdateRequest{
+		Status:     req.GetStatus(),
+		Message:    req.GetMessage(),
+		Reason:     req.GetReason(),
+		Phase:      req.GetPhase(),
+		ProcessPID: int(req.GetProcessPid()),
+	}
+	if err := validate(&update); err != nil {
+		return nil, err
+	}
+
+	result, apiErr := s.h.updateStatus(ctx, serverIDFrom(ctx), update)
+	if apiErr != nil {
+		return nil, grpcError(apiErr)
+	}
+	return &supervisorpb.ReportStatusResponse{Result: result}, nil
+}
+
+func (s *supervisorService) ReportProgress(ctx context.Context, req *supervisorpb.ReportProgressRequest) (*supervisorpb.ReportProgressResponse, error) {
+	progress := StartupProgressRequest{Label: req.GetLabel(), Percent: int(req.GetPercent())}
+	if err := validate(&progress); err != nil {
+		return nil, err
+	}
+
+	result, apiErr := s.h.startupProgress(ctx, serverIDFrom(ctx), progress)
+	if apiErr != nil {
+		return nil, grpcError(apiErr)
+	}
+	return &supervisorpb.ReportProgressResponse{Result: result}, nil
+}
+
+func (s *supervisorService) Heartbeat(ctx context.Context, req *supervisorpb.HeartbeatRequest) (*supervisorpb.HeartbeatResponse, error) {
+	heartbeat := HeartbeatRequest{
+		ProcessPID: int(req.GetProcessPid()),
+		NetTxBytes: req.GetNetTxBytes(),
+		Samples:    make([]HeartbeatSample, len(req.GetSamples())),
+	}
+	if req.PlayersOnline != nil {
+		players := int(req.GetPlayersOnline())
+		heartbeat.PlayersOnline = &players
+	}
+	for i, sample := range req.GetSamples() {
+		heartbeat.Samples[i] = HeartbeatSample{
+			SampledAt:  sample.GetSampledAt().AsTime(),
+			MemoryMB:   sample.GetMemoryMb(),
+			CPUPercent: sample.GetCpuPercent(),
+		}
+	}
+	if n := len(heartbeat.Samples); n > 0 {
+		heartbeat.MemoryMB, heartbeat.CPUPercent = heartbeat.Samples[n-1].MemoryMB, heartbeat.Samples[n-1].CPUPercent
+	}
+	if err := validate(&heartbeat); err != nil {
+		return nil, err
+	}
+
+	result, apiErr := s.h.heartbeat(ctx, serverIDFrom(ctx), heartbeat)
+	if apiErr != nil {
+		return nil, grpcError(apiErr)
+	}
+	return &supervisorpb.HeartbeatResponse{Result: result}, nil
+}
+
+func (s *supervisorService) ClaimCommands(ctx context.Context, _ *supervisorpb.ClaimCommandsRequest) (*supervisorpb.ClaimCommandsResponse, error) {
+	commands, apiErr := s.h.claimCommands(ctx, serverIDFrom(ctx))
+	if apiErr != nil {
+		return nil, grpcError(apiErr)
+	}
+
+	resp := &supervisorpb.ClaimCommandsResponse{Commands: make([]*supervisorpb.Command, len(commands))}
+	for i := range commands {
+		resp.Commands[i] = commandMessage(&commands[i])
+	}
+	return resp, nil
+}
+
+// StreamCommands claims queued commands as they're queued, and every commandPollInterval,
+// and sends them until the supervisor disconnects
+func (s *supervisorService) StreamCommands(_ *supervisorpb.StreamCommandsRequest, stream grpc.ServerStreamingServer[supervisorpb.Command]) error {
+	ctx := stream.Context()
+	serverID := serverIDFrom(ctx)
+
+	ticker := time.NewTicker(commandPollInterval)
+	defer ticker.Stop()
+
+	for {
+		woken, stopWaiting := s.h.wakeup.Wait(serverID)
+		if err := s.sendQueuedCommands(ctx, serverID, stream); err != nil {
+			stopWaiting()
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			stopWaiting()
+			return nil
+		case <-woken:
+		case <-ticker.C:
+			stopWaiting()
+		}
+	}
+}
+
+// sendQueuedCommands claims the server's queued commands and sends them on stream
+func (s *supervisorService) sendQueuedCommands(ctx context.Context, serverID string, stream grpc.ServerStreamingServer[supervisorpb.Command]) error {
+	commands, apiErr := s.h.claimCommands(ctx, serverID)
+	if apiErr != nil {
+		if ctx.Err() != nil {
+			return nil // Supervisor went away
+		}
+		return grpcError(apiErr)
+	}
+	for i := range commands {
+		if err := stream.Send(commandMessage(&commands[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func commandMessage(cmd *models.ServerCommand) *supervisorpb.Command {
+	msg := &supervisorpb.Command{Id: cmd.ID.String(), Type: string(cmd.Type)}
+	if cmd.Payload != nil {
+		msg.Payload = *cmd.Payload
+	}
+	return msg
+}
+
+func (s *supervisorService) ReportCommandResult(ctx context.Context, req *supervisorpb.ReportCommandResultRequest) (*supervisorpb.ReportCommandResultResponse, error) {
+	result := CommandResultRequest{Success: req.GetSuccess(), Result: req.GetResult()}
+	if artifact := req.GetArtifact(); artifact != nil {
+		result.Artifact = &CommandArtifactRequest{Path: artifact.GetPath(), Size: artifact.GetSize()}
+	}
+	if err := validate(&result); err != nil {
+		return nil, err
+	}
+
+	if apiErr := s.h.commandResult(ctx, serverIDFrom(ctx), req.GetCommandId(), result); apiErr != nil {
+		return nil, grpcError(apiErr)
+	}
+	return &supervisorpb.ReportCommandResultResponse{}, nil
+}
+
+func (s *supervisorService) ReportCommandProgress(ctx context.Context, req *supervisorpb.ReportCommandProgressRequest) (*supervisorpb.ReportCommandProgressResponse, error) {
+	progress := CommandProgressRequest{DoneBytes: req.GetDoneBytes(), TotalBytes: req.GetTotalBytes()}
+	if err := validate(&progress); err != nil {
+		return nil, err
+	}
+
+	if apiErr := s.h.commandProgress(ctx, serverIDFrom(ctx), req.GetCommandId(), progress); apiErr != nil {
+		return nil, grpcError(apiErr)
+	}
+	return &supervisorpb.ReportCommandProgressResponse{}, nil
+}
+
+func (s *supervisorService) GetBannedHashes(ctx context.Context, _ *supervisorpb.GetBannedHashesRequest) (*supervisorpb.GetBannedHashesResponse, error) {
+	hashes, apiErr := s.h.bannedHashes(ctx)
+	if apiErr != nil {
+		return nil, grpcError(apiErr)
+	}
+	return &supervisorpb.GetBannedHashesResponse{Hashes: hashes}, nil
+}
+
+func (s *supervisorService) ReportBannedBinary(ctx context.Context, req *supervisorpb.ReportBannedBinaryRequest) (*supervisorpb.ReportBannedBinaryResponse, error) {
+	report := BannedBinaryRequest{Path: req.GetPath(), SHA256: req.GetSha256()}
+	if err := validate(&report); err != nil {
+		return nil, err
+	}
+
+	if apiErr := s.h.reportBannedBinary(ctx, serverIDFrom(ctx), report); apiErr != nil {
+		return nil, grpcError(apiErr)
+	}
+	return &supervisorpb.ReportBannedBinaryResponse{}, nil
+}
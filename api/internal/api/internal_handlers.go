@@ -1,51 +1,94 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
 	"github.com/mooncorn/gshub/api/internal/database"
 	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/abuse"
+	"github.com/mooncorn/gshub/api/internal/services/backupreplica"
 	"github.com/mooncorn/gshub/api/internal/services/broadcast"
+	"github.com/mooncorn/gshub/api/internal/services/commandwake"
+	"github.com/mooncorn/gshub/api/internal/services/serversession"
+	"github.com/mooncorn/gshub/api/internal/services/statusingest"
+	"github.com/mooncorn/gshub/api/internal/services/webhook"
 	"go.uber.org/zap"
 )
 
-// Helper to convert string pointer
-func stringPtr(s string) *string {
-	if s == "" {
-		return nil
-	}
-	return &s
-}
+const (
+	// commandPollMaxWait caps how long GET /internal/servers/:id/commands holds a request open
+	commandPollMaxWait = 30 * time.Second
+	// commandPollInterval is how often a held poll re-checks the queue without being woken,
+	// in case a notification of a queued command was missed
+	commandPollInterval = 15 * time.Second
+	// commandMaxAge expires commands the supervisor didn't pick up in time (e.g. it was down)
+	commandMaxAge = 5 * time.Minute
+	// importCommandMaxAge is commandMaxAge for imports, which wait for a new server's first start
+	importCommandMaxAge = time.Hour
+)
 
 // InternalHandler handles internal API requests from supervisors
 type InternalHandler struct {
-	db     *database.DB
-	hub    *broadcast.Hub
-	logger *zap.Logger
+	db       *database.DB
+	hub      *broadcast.Hub
+	abuse    *abuse.Service
+	ingestor *statusingest.Ingestor
+	webhooks *webhook.Service
+	wakeup   *commandwake.Service
+	limiter  *middleware.RateLimiter
+	dedup    *reportDedup
+	logger   *zap.Logger
 }
 
 // NewInternalHandler creates a new internal handler
-func NewInternalHandler(db *database.DB, hub *broadcast.Hub, logger *zap.Logger) *InternalHandler {
+func NewInternalHandler(db *database.DB, hub *broadcast.Hub, abuseService *abuse.Service, ingestor *statusingest.Ingestor, webhookService *webhook.Service, wakeupService *commandwake.Service, cfg *config.Config, logger *zap.Logger) *InternalHandler {
 	return &InternalHandler{
-		db:     db,
-		hub:    hub,
-		logger: logger,
+		db:       db,
+		hub:      hub,
+		abuse:    abuseService,
+		ingestor: ingestor,
+		webhooks: webhookService,
+		wakeup:   wakeupService,
+		limiter:  middleware.NewRateLimiter(cfg.InternalRateLimit, cfg.InternalRateBurst),
+		dedup:    newReportDedup(cfg.InternalDedupWindow),
+		logger:   logger,
 	}
 }
 
 // RegisterInternalRoutes registers internal API routes
 func (h *InternalHandler) RegisterInternalRoutes(r *gin.Engine) {
+	r.Use(middleware.RequestID(), middleware.ErrorHandler())
+
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
 	internal := r.Group("/internal")
-	internal.Use(h.authMiddleware())
+	// Limited per server after authentication, so a misbehaving supervisor can only use up
+	// its own server's budget
+	internal.Use(h.authMiddleware(), middleware.RateLimit(h.limiter, func(c *gin.Context) string {
+		return c.GetString("server_id")
+	}))
 	{
 		internal.POST("/servers/:id/status", h.UpdateStatus)
+		internal.POST("/servers/:id/progress", h.StartupProgress)
 		internal.POST("/servers/:id/heartbeat", h.Heartbeat)
+		internal.GET("/servers/:id/commands", h.PollCommands)
+		internal.POST("/servers/:id/commands/:commandId/result", h.CommandResult)
+		internal.POST("/servers/:id/commands/:commandId/progress", h.CommandProgress)
+		internal.GET("/servers/:id/banned-hashes", h.BannedHashes)
+		internal.POST("/servers/:id/banned-binary", h.ReportBannedBinary)
 	}
 }
 
@@ -54,28 +97,14 @@ func (h *InternalHandler) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		serverID := c.Param("id")
 		if serverID == "" {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
-			return
-		}
-
-		// Extract bearer token
-		authHeader := c.GetHeader("Authorization")
-		if len(authHeader) < 8 || authHeader[:7] != "Bearer " {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
-			return
-		}
-		token := authHeader[7:]
-
-		// Validate token
-		valid, err := h.db.ValidateServerAuthToken(c.Request.Context(), serverID, token)
-		if err != nil {
-			h.logger.Error("failed to validate auth token", zap.Error(err), zap.String("server_id", serverID))
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			c.Error(apierror.ErrServerIDRequired)
+			c.Abort()
 			return
 		}
 
-		if !valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		if apiErr := h.authenticate(c.Request.Context(), serverID, c.GetHeader("Authorization")); apiErr != nil {
+			c.Error(apiErr)
+			c.Abort()
 			return
 		}
 
@@ -84,23 +113,52 @@ func (h *InternalHandler) authMiddleware() gin.HandlerFunc {
 	}
 }
 
+// authenticate validates a supervisor's "Bearer <token>" authorization for a server
+func (h *InternalHandler) authenticate(ctx context.Context, serverID, authHeader string) *apierror.Error {
+	if len(authHeader) < 8 || authHeader[:7] != "Bearer " {
+		return apierror.Unauthorized("invalid authorization header")
+	}
+	token := authHeader[7:]
+
+	valid, err := h.db.ValidateServerAuthToken(ctx, serverID, token)
+	if err != nil {
+		h.logger.Error("failed to validate auth token", zap.Error(err), zap.String("server_id", serverID))
+		return apierror.Internal("internal error")
+	}
+	if !valid {
+		return apierror.New(http.StatusUnauthorized, apierror.CodeInvalidToken, "invalid token")
+	}
+	return nil
+}
+
 // StatusUpdateRequest represents a status update from the supervisor
 type StatusUpdateRequest struct {
 	Status     string `json:"status" binding:"required"`
 	Message    string `json:"message"`
+	Reason     string `json:"reason"` // Optional machine-readable reason, e.g. INVALID_CONFIG
+	Phase      string `json:"phase"`  // Optional startup phase of a starting server, e.g. installing
 	ProcessPID int    `json:"process_pid"`
 }
 
 // UpdateStatus handles status updates from supervisors
 func (h *InternalHandler) UpdateStatus(c *gin.Context) {
-	serverID := c.GetString("server_id")
-
 	var req StatusUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		c.Error(apierror.BadRequest("invalid request body"))
+		return
+	}
+
+	result, apiErr := h.updateStatus(c.Request.Context(), c.GetString("server_id"), req)
+	if apiErr != nil {
+		c.Error(apiErr)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"status": result})
+}
 
+// updateStatus ingests a supervisor's status report, and returns whether it was "updated",
+// "ignored" or a "duplicate"
+func (h *InternalHandler) updateStatus(ctx context.Context, serverID string, req StatusUpdateRequest) (string, *apierror.Error) {
 	// Map supervisor status to model status
 	var toStatus models.ServerStatus
 	switch req.Status {
@@ -115,41 +173,99 @@ func (h *InternalHandler) UpdateStatus(c *gin.Context) {
 	case "failed":
 		toStatus = models.ServerStatusFailed
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
-		return
+		return "", apierror.BadRequest("invalid status")
 	}
 
-	// Get current server to find user ID for broadcasting
-	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
-	if err != nil {
-		h.logger.Error("failed to get server", zap.Error(err), zap.String("server_id", serverID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
-		return
+	reason := models.StatusReason(req.Reason)
+	if !reason.IsValid() {
+		reason = ""
+	}
+	phase := models.StartupPhase(req.Phase)
+	if toStatus != models.ServerStatusStarting || !phase.IsValid() {
+		phase = ""
 	}
 
-	// Transition status (allow from any status for flexibility)
-	err = h.db.UpdateServerStatusAny(c.Request.Context(), serverID, toStatus, req.Message)
+	// A supervisor repeating itself (e.g. retrying a report that timed out but was handled)
+	// has nothing new to say
+	hash := reportHash(req)
+	if h.dedup.seen(serverID, "status", hash) {
+		return "duplicate", nil
+	}
+
+	// The ingestor arbitrates against the current status and broadcasts accepted changes
+	applied, err := h.ingestor.Ingest(ctx, statusingest.Report{
+		ServerID: serverID,
+		Source:   statusingest.SourceSupervisor,
+		Status:   toStatus,
+		Message:  req.Message,
+		Reason:   reason,
+		Phase:    phase,
+	})
 	if err != nil {
 		h.logger.Error("failed to update status", zap.Error(err), zap.String("server_id", serverID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update status"})
-		return
+		return "", apierror.Internal("failed to update status")
+	}
+	h.dedup.remember(serverID, "status", hash)
+	if !applied {
+		// Rejected reports are expected (e.g. after a stop or suspension); the supervisor
+		// has nothing to retry
+		return "ignored", nil
 	}
 
 	h.logger.Info("server status updated",
 		zap.String("server_id", serverID),
 		zap.String("status", req.Status),
 		zap.String("message", req.Message),
+		zap.String("reason", string(reason)),
+		zap.String("phase", string(phase)),
 		zap.Int("pid", req.ProcessPID))
 
-	// Broadcast status update to connected clients
-	h.hub.Publish(server.UserID, broadcast.StatusEvent{
-		ServerID:      serverID,
-		Status:        string(toStatus),
-		StatusMessage: stringPtr(req.Message),
-		Timestamp:     time.Now().UTC(),
+	return "updated", nil
+}
+
+// StartupProgressRequest is a startup milestone reported by the supervisor
+type StartupProgressRequest struct {
+	Label   string `json:"label" binding:"required,max=100"`
+	Percent int    `json:"percent" binding:"min=0,max=100"`
+}
+
+// StartupProgress passes how far a starting server has got with a milestone on to its
+// owner. Progress isn't stored; it's only meaningful while the server starts.
+func (h *InternalHandler) StartupProgress(c *gin.Context) {
+	var req StartupProgressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.BadRequest("invalid request body"))
+		return
+	}
+
+	result, apiErr := h.startupProgress(c.Request.Context(), c.GetString("server_id"), req)
+	if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": result})
+}
+
+// startupProgress publishes a startup milestone, and returns whether it was "recorded" or
+// "ignored"
+func (h *InternalHandler) startupProgress(ctx context.Context, serverID string, req StartupProgressRequest) (string, *apierror.Error) {
+	server, err := h.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		return "", apierror.ErrServerNotFound
+	}
+	if server.Status != models.ServerStatusStarting {
+		// Late reports after the server came up or was stopped; nothing to retry
+		return "ignored", nil
+	}
+
+	h.hub.Publish(server.UserID, broadcast.ProgressEvent{
+		ServerID:  serverID,
+		Label:     req.Label,
+		Percent:   req.Percent,
+		Timestamp: time.Now().UTC(),
 	})
 
-	c.JSON(http.StatusOK, gin.H{"status": "updated"})
+	return "recorded", nil
 }
 
 // HeartbeatRequest represents a heartbeat from the supervisor
@@ -157,24 +273,390 @@ type HeartbeatRequest struct {
 	ProcessPID int     `json:"process_pid"`
 	MemoryMB   int64   `json:"memory_mb"`
 	CPUPercent float64 `json:"cpu_percent"`
+	NetTxBytes int64   `json:"net_tx_bytes"` // Cumulative bytes sent by the game's network namespace
+
+	// PlayersOnline is omitted by supervisors of games whose output doesn't report players
+	PlayersOnline *int `json:"players_online" binding:"omitempty,min=0"`
+
+	// Samples are the usage samples taken since the last heartbeat, oldest first. Older
+	// supervisors send none, and MemoryMB and CPUPercent are used as a single sample.
+	Samples []HeartbeatSample `json:"samples" binding:"max=60,dive"`
+}
+
+// HeartbeatSample is one of the resource usage samples batched into a heartbeat
+type HeartbeatSample struct {
+	SampledAt  time.Time `json:"sampled_at" binding:"required"`
+	MemoryMB   int64     `json:"memory_mb" binding:"min=0"`
+	CPUPercent float64   `json:"cpu_percent" binding:"min=0"`
+}
+
+// maxHeartbeatSampleAge is how old a batched sample may be; older ones, and ones from the
+// future, are taken as sampled now, so a skewed supervisor clock can't backfill hours
+const maxHeartbeatSampleAge = 10 * time.Minute
+
+// resourceSamples returns the heartbeat's usage samples, timestamped at most
+// maxHeartbeatSampleAge before now
+func (r *HeartbeatRequest) resourceSamples(now time.Time) []database.ResourceSample {
+	if len(r.Samples) == 0 {
+		return []database.ResourceSample{{SampledAt: now, CPUPercent: r.CPUPercent, MemoryMB: r.MemoryMB, PlayersOnline: r.PlayersOnline}}
+	}
+
+	samples := make([]database.ResourceSample, len(r.Samples))
+	for i, sample := range r.Samples {
+		sampledAt := sample.SampledAt
+		if sampledAt.After(now) || now.Sub(sampledAt) > maxHeartbeatSampleAge {
+			sampledAt = now
+		}
+		// Players are only reported per heartbeat, so every sample in the batch gets the count
+		samples[i] = database.ResourceSample{
+			SampledAt:     sampledAt,
+			CPUPercent:    sample.CPUPercent,
+			MemoryMB:      sample.MemoryMB,
+			PlayersOnline: r.PlayersOnline,
+		}
+	}
+	return samples
 }
 
 // Heartbeat handles heartbeat requests from supervisors
 func (h *InternalHandler) Heartbeat(c *gin.Context) {
-	serverID := c.GetString("server_id")
-
 	var req HeartbeatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		c.Error(apierror.BadRequest("invalid request body"))
+		return
+	}
+
+	result, apiErr := h.heartbeat(c.Request.Context(), c.GetString("server_id"), req)
+	if apiErr != nil {
+		c.Error(apiErr)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"status": result})
+}
+
+// heartbeat records a heartbeat and its usage samples, and returns "ok" or "duplicate"
+func (h *InternalHandler) heartbeat(ctx context.Context, serverID string, req HeartbeatRequest) (string, *apierror.Error) {
+	// Batched samples are timestamped, so only a resent heartbeat is identical to the last
+	hash := reportHash(req)
+	if h.dedup.seen(serverID, "heartbeat", hash) {
+		return "duplicate", nil
+	}
 
 	// Update heartbeat timestamp
-	if err := h.db.UpdateServerHeartbeat(c.Request.Context(), serverID); err != nil {
+	if err := h.db.UpdateServerHeartbeat(ctx, serverID, req.PlayersOnline); err != nil {
 		h.logger.Error("failed to update heartbeat", zap.Error(err), zap.String("server_id", serverID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update heartbeat"})
+		return "", apierror.Internal("failed to update heartbeat")
+	}
+	h.dedup.remember(serverID, "heartbeat", hash)
+
+	// Neither usage tracking nor abuse checks fail the heartbeat; the next heartbeat's counter
+	// includes the traffic, and a missed sample only delays detection
+	if err := h.db.RecordEgressUsage(ctx, serverID, req.NetTxBytes); err != nil {
+		h.logger.Error("failed to record egress usage", zap.Error(err), zap.String("server_id", serverID))
+	}
+	if err := h.db.TouchServerSession(ctx, serverID, serversession.Gap); err != nil {
+		h.logger.Error("failed to touch server session", zap.Error(err), zap.String("server_id", serverID))
+	}
+
+	samples := req.resourceSamples(time.Now())
+
+	// Samples from heartbeats without a running game would skew recommendations and charts
+	if req.ProcessPID > 0 {
+		if err := h.db.RecordResourceUsage(ctx, serverID, samples); err != nil {
+			h.logger.Error("failed to record resource usage", zap.Error(err), zap.String("server_id", serverID))
+		}
+		if err := h.db.RecordServerMetrics(ctx, serverID, samples); err != nil {
+			h.logger.Error("failed to record server metrics", zap.Error(err), zap.String("server_id", serverID))
+		}
+	}
+
+	// The CPU rule is about sustained usage, so a batch only counts if every sample is high
+	minCPUPercent := samples[0].CPUPercent
+	for _, sample := range samples[1:] {
+		minCPUPercent = min(minCPUPercent, sample.CPUPercent)
+	}
+	if err := h.abuse.CheckHeartbeat(ctx, serverID, minCPUPercent, req.NetTxBytes); err != nil {
+		h.logger.Error("failed to check heartbeat for abuse", zap.Error(err), zap.String("server_id", serverID))
+	}
+
+	return "ok", nil
+}
+
+// PollCommands long-polls for commands queued for the supervisor. It returns as soon as
+// any are pending, woken when one is queued, or an empty list once ?wait= seconds (capped
+// at 30) have passed.
+func (h *InternalHandler) PollCommands(c *gin.Context) {
+	serverID := c.GetString("server_id")
+
+	wait, err := strconv.Atoi(c.DefaultQuery("wait", "0"))
+	if err != nil || wait < 0 {
+		c.Error(apierror.BadRequest("invalid wait"))
+		return
+	}
+	deadline := time.NewTimer(min(time.Duration(wait)*time.Second, commandPollMaxWait))
+	defer deadline.Stop()
+
+	ctx := c.Request.Context()
+	ticker := time.NewTicker(commandPollInterval)
+	defer ticker.Stop()
+
+	for {
+		woken, stopWaiting := h.wakeup.Wait(serverID)
+		commands, apiErr := h.claimCommands(ctx, serverID)
+		if apiErr != nil {
+			stopWaiting()
+			if ctx.Err() != nil {
+				return // Supervisor went away
+			}
+			c.Error(apiErr)
+			return
+		}
+
+		if len(commands) > 0 || wait == 0 {
+			stopWaiting()
+			c.JSON(http.StatusOK, gin.H{"commands": commands})
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			stopWaiting()
+			return
+		case <-deadline.C:
+			stopWaiting()
+			c.JSON(http.StatusOK, gin.H{"commands": commands})
+			return
+		case <-woken:
+		case <-ticker.C:
+			stopWaiting()
+		}
+	}
+}
+
+// claimCommands marks the server's queued commands as delivered and returns them
+func (h *InternalHandler) claimCommands(ctx context.Context, serverID string) ([]models.ServerCommand, *apierror.Error) {
+	commands, err := h.db.ClaimServerCommands(ctx, serverID, commandMaxAge, importCommandMaxAge)
+	if err != nil {
+		if ctx.Err() == nil {
+			h.logger.Error("failed to claim commands", zap.Error(err), zap.String("server_id", serverID))
+		}
+		return nil, apierror.Internal("failed to fetch commands")
+	}
+	return commands, nil
+}
+
+// CommandResultRequest is the outcome of a command reported by the supervisor
+type CommandResultRequest struct {
+	Success  bool                    `json:"success"`
+	Result   string                  `json:"result"`
+	Artifact *CommandArtifactRequest `json:"artifact"`
+}
+
+// CommandArtifactRequest is a file a command produced in the server's data volume
+type CommandArtifactRequest struct {
+	Path string `json:"path" binding:"required"`
+	Size int64  `json:"size" binding:"min=0"`
+}
+
+// CommandProgressRequest is the progress of a long-running command reported by the supervisor
+type CommandProgressRequest struct {
+	DoneBytes  int64 `json:"done_bytes" binding:"min=0"`
+	TotalBytes int64 `json:"total_bytes" binding:"min=0"`
+}
+
+// CommandResult records the outcome of a delivered command
+func (h *InternalHandler) CommandResult(c *gin.Context) {
+	var req CommandResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.BadRequest("invalid request body"))
+		return
+	}
+
+	if apiErr := h.commandResult(c.Request.Context(), c.GetString("server_id"), c.Param("commandId"), req); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "recorded"})
+}
+
+// commandResult completes a delivered command with its outcome
+func (h *InternalHandler) commandResult(ctx context.Context, serverID, id string, req CommandResultRequest) *apierror.Error {
+	commandID, err := uuid.Parse(id)
+	if err != nil {
+		return apierror.ErrCommandNotFound
+	}
+
+	state := models.CommandStateSucceeded
+	if !req.Success {
+		state = models.CommandStateFailed
+	}
+
+	// Artifacts are downloaded from the data volume, so their path must stay inside it
+	var artifact *models.CommandArtifact
+	if req.Artifact != nil && req.Success {
+		p := path.Clean(req.Artifact.Path)
+		if path.IsAbs(p) || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+			return apierror.BadRequest("invalid artifact path")
+		}
+		artifact = &models.CommandArtifact{Path: p, Size: req.Artifact.Size}
+	}
+
+	if err := h.db.CompleteServerCommand(ctx, serverID, commandID, state, req.Result, artifact); err != nil {
+		h.logger.Warn("failed to complete command", zap.Error(err),
+			zap.String("server_id", serverID), zap.String("command_id", commandID.String()))
+		return apierror.ErrCommandNotFound
+	}
+
+	h.logger.Info("command completed",
+		zap.String("server_id", serverID),
+		zap.String("command_id", commandID.String()),
+		zap.String("state", string(state)))
+
+	// Backups notify the user's webhooks
+	if cmd, err := h.db.GetServerCommand(ctx, serverID, commandID.String()); err != nil {
+		h.logger.Warn("failed to get completed command", zap.Error(err),
+			zap.String("server_id", serverID), zap.String("command_id", commandID.String()))
+	} else {
+		if cmd.Type == models.CommandBackup {
+			h.webhooks.BackupFinished(ctx, serverID, commandID, req.Success, req.Result)
+			h.queueBackupReplica(ctx, cmd)
+		}
+		h.publishCommandEvent(ctx, cmd)
+	}
+	return nil
+}
+
+// queueBackupReplica queues the archive of a finished backup to be copied off-site, if the
+// server's backup policy asked for it when the backup was queued
+func (h *InternalHandler) queueBackupReplica(ctx context.Context, cmd *models.ServerCommand) {
+	if cmd.State != models.CommandStateSucceeded || cmd.Artifact == nil || cmd.Payload == nil {
+		return
+	}
+	var payload models.BackupCommandPayload
+	if err := json.Unmarshal([]byte(*cmd.Payload), &payload); err != nil || !payload.Replicate {
+		return
+	}
+
+	serverID := cmd.ServerID.String()
+	objectKey := backupreplica.ObjectKey(serverID, path.Base(cmd.Artifact.Path))
+	if err := h.db.CreateBackupReplica(ctx, serverID, cmd.ID, cmd.Artifact.Path, objectKey, cmd.Artifact.Size); err != nil {
+		h.logger.Error("failed to queue backup replica", zap.Error(err),
+			zap.String("server_id", serverID), zap.String("command_id", cmd.ID.String()))
+	}
+}
+
+// CommandProgress records the progress of a long-running command, such as a world export,
+// and passes it on to the user
+func (h *InternalHandler) CommandProgress(c *gin.Context) {
+	var req CommandProgressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.BadRequest("invalid request body"))
+		return
+	}
+
+	if apiErr := h.commandProgress(c.Request.Context(), c.GetString("server_id"), c.Param("commandId"), req); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "recorded"})
+}
+
+// commandProgress records and publishes the progress of a delivered command
+func (h *InternalHandler) commandProgress(ctx context.Context, serverID, id string, req CommandProgressRequest) *apierror.Error {
+	commandID, err := uuid.Parse(id)
+	if err != nil {
+		return apierror.ErrCommandNotFound
+	}
+
+	if err := h.db.UpdateServerCommandProgress(ctx, serverID, commandID, req.DoneBytes, req.TotalBytes); err != nil {
+		h.logger.Debug("failed to update command progress", zap.Error(err),
+			zap.String("server_id", serverID), zap.String("command_id", commandID.String()))
+		return apierror.ErrCommandNotFound
+	}
+
+	if cmd, err := h.db.GetServerCommand(ctx, serverID, commandID.String()); err == nil {
+		h.publishCommandEvent(ctx, cmd)
+	}
+	return nil
+}
+
+// publishCommandEvent tells the server's owner about a command's progress or completion
+func (h *InternalHandler) publishCommandEvent(ctx context.Context, cmd *models.ServerCommand) {
+	server, err := h.db.GetServerByID(ctx, cmd.ServerID.String())
+	if err != nil {
+		h.logger.Warn("failed to get server for command event", zap.Error(err),
+			zap.String("server_id", cmd.ServerID.String()))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	event := broadcast.CommandEvent{
+		ServerID:  cmd.ServerID.String(),
+		CommandID: cmd.ID.String(),
+		Type:      string(cmd.Type),
+		State:     string(cmd.State),
+		Timestamp: time.Now().UTC(),
+	}
+	if cmd.Progress != nil {
+		event.DoneBytes, event.TotalBytes = cmd.Progress.DoneBytes, cmd.Progress.TotalBytes
+	}
+	h.hub.Publish(server.UserID, event)
+}
+
+// BannedHashes returns the SHA-256 hashes of binaries the supervisor must refuse to run
+func (h *InternalHandler) BannedHashes(c *gin.Context) {
+	hashes, apiErr := h.bannedHashes(c.Request.Context())
+	if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hashes": hashes})
+}
+
+// bannedHashes returns the SHA-256 hashes of banned binaries
+func (h *InternalHandler) bannedHashes(ctx context.Context) ([]string, *apierror.Error) {
+	bannedHashes, err := h.db.ListBannedHashes(ctx)
+	if err != nil {
+		h.logger.Error("failed to list banned hashes", zap.Error(err))
+		return nil, apierror.Internal("failed to list banned hashes")
+	}
+
+	hashes := make([]string, len(bannedHashes))
+	for i, banned := range bannedHashes {
+		hashes[i] = banned.SHA256
+	}
+	return hashes, nil
+}
+
+// BannedBinaryRequest reports a banned binary found by the supervisor
+type BannedBinaryRequest struct {
+	Path   string `json:"path" binding:"required"`
+	SHA256 string `json:"sha256" binding:"required"`
+}
+
+// ReportBannedBinary suspends a server whose supervisor found a banned binary
+func (h *InternalHandler) ReportBannedBinary(c *gin.Context) {
+	var req BannedBinaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.BadRequest("invalid request body"))
+		return
+	}
+
+	if apiErr := h.reportBannedBinary(c.Request.Context(), c.GetString("server_id"), req); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "suspended"})
+}
+
+// reportBannedBinary suspends the server if the reported binary is banned
+func (h *InternalHandler) reportBannedBinary(ctx context.Context, serverID string, req BannedBinaryRequest) *apierror.Error {
+	banned, err := h.abuse.ReportBannedBinary(ctx, serverID, req.Path, req.SHA256)
+	if err != nil {
+		h.logger.Error("failed to handle banned binary report", zap.Error(err), zap.String("server_id", serverID))
+		return apierror.Internal("failed to handle report")
+	}
+	if !banned {
+		return apierror.BadRequest("hash is not banned")
+	}
+	return nil
 }
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// ListInvoices returns the user's recent invoices, with the server each line charges for
+func (h *BillingHandler) ListInvoices(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	user, err := h.db.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get user: %v", err)
+		c.Error(apierror.Internal("failed to get user"))
+		return
+	}
+
+	invoices, err := h.stripeService.ListInvoices(c.Request.Context(), user)
+	if err != nil {
+		log.Printf("failed to list invoices: %v", err)
+		c.Error(apierror.Internal("failed to list invoices"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.InvoicesResponse{Invoices: invoices})
+}
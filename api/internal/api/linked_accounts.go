@@ -0,0 +1,185 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/steam"
+)
+
+const (
+	// steamReturnPath is the frontend page Steam redirects back to after sign-in
+	steamReturnPath = "/settings/integrations/steam"
+
+	// steamLinkStateTTL is how long a user has to finish signing in with Steam
+	steamLinkStateTTL = 15 * time.Minute
+)
+
+// LinkedAccountHandler links third-party accounts to users
+type LinkedAccountHandler struct {
+	db     *database.DB
+	config *config.Config
+	steam  *steam.OpenID
+}
+
+// NewLinkedAccountHandler creates a new linked account handler
+func NewLinkedAccountHandler(db *database.DB, cfg *config.Config) *LinkedAccountHandler {
+	return &LinkedAccountHandler{
+		db:     db,
+		config: cfg,
+		steam:  steam.NewOpenID(),
+	}
+}
+
+// ListLinkedAccounts returns the user's linked accounts
+func (h *LinkedAccountHandler) ListLinkedAccounts(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	accounts, err := h.db.ListLinkedAccounts(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to list linked accounts for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to list linked accounts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+// StartSteamLink returns the Steam sign-in URL. Steam redirects back to the frontend, which
+// posts the assertion to VerifySteamLink.
+func (h *LinkedAccountHandler) StartSteamLink(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	frontendURL := strings.TrimRight(h.config.FrontendURL, "/")
+	returnTo := frontendURL + steamReturnPath + "?" + url.Values{"state": {h.signLinkState(userID)}}.Encode()
+
+	c.JSON(http.StatusOK, gin.H{"redirect_url": h.steam.AuthURL(returnTo, frontendURL)})
+}
+
+// VerifySteamLinkRequest carries the query string Steam redirected back with
+type VerifySteamLinkRequest struct {
+	Assertion string `json:"assertion" binding:"required"`
+}
+
+// VerifySteamLink verifies a Steam sign-in and links the Steam account to the user
+func (h *LinkedAccountHandler) VerifySteamLink(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req VerifySteamLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	assertion, err := url.ParseQuery(strings.TrimPrefix(req.Assertion, "?"))
+	if err != nil {
+		c.Error(apierror.ErrSteamLinkFailed)
+		return
+	}
+
+	// The state in return_to ties the sign-in to the user who started it, so a user can't be
+	// tricked into linking someone else's Steam account
+	returnTo, err := url.Parse(assertion.Get("openid.return_to"))
+	if err != nil || !h.verifyLinkState(userIDStr, returnTo.Query().Get("state")) {
+		c.Error(apierror.ErrSteamLinkFailed)
+		return
+	}
+
+	steamID, err := h.steam.Verify(c.Request.Context(), assertion, strings.TrimRight(h.config.FrontendURL, "/")+steamReturnPath)
+	if err != nil {
+		log.Printf("failed to verify steam sign-in for user %s: %v", userID, err)
+		c.Error(apierror.ErrSteamLinkFailed)
+		return
+	}
+
+	existing, err := h.db.GetLinkedAccountByExternalID(c.Request.Context(), models.LinkedAccountSteam, steamID)
+	if err != nil {
+		log.Printf("failed to get linked steam account %s: %v", steamID, err)
+		c.Error(apierror.Internal("failed to link account"))
+		return
+	}
+	if existing != nil && existing.UserID != userID {
+		c.Error(apierror.ErrAccountAlreadyLinked)
+		return
+	}
+
+	account, err := h.db.UpsertLinkedAccount(c.Request.Context(), userID, models.LinkedAccountSteam, steamID, steamID)
+	if err != nil {
+		log.Printf("failed to link steam account for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to link account"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account": account})
+}
+
+// UnlinkAccount removes the user's linked account from a provider
+func (h *LinkedAccountHandler) UnlinkAccount(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	provider := models.LinkedAccountProvider(c.Param("provider"))
+	deleted, err := h.db.DeleteLinkedAccount(c.Request.Context(), userID, provider)
+	if err != nil {
+		log.Printf("failed to unlink %s account for user %s: %v", provider, userID, err)
+		c.Error(apierror.Internal("failed to unlink account"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.NotFound("linked account not found"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// signLinkState returns "<expiry>.<hmac>" binding a link flow to the user
+func (h *LinkedAccountHandler) signLinkState(userID string) string {
+	expires := strconv.FormatInt(time.Now().Add(steamLinkStateTTL).Unix(), 10)
+	return expires + "." + h.linkStateMAC(userID, expires)
+}
+
+// verifyLinkState checks that state was signed for userID and hasn't expired
+func (h *LinkedAccountHandler) verifyLinkState(userID, state string) bool {
+	expires, mac, ok := strings.Cut(state, ".")
+	if !ok {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(h.linkStateMAC(userID, expires)))
+}
+
+func (h *LinkedAccountHandler) linkStateMAC(userID, expires string) string {
+	mac := hmac.New(sha256.New, []byte(h.config.JWTSecret))
+	mac.Write([]byte("account-link:" + userID + ":" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+)
+
+// logSeverityTagPrefix starts the severity tag the supervisor writes before each game
+// output line, e.g. "[gshub:warn] "
+const logSeverityTagPrefix = "[gshub:"
+
+// logSeverities ranks the severities a level filter accepts
+var logSeverities = map[string]int{
+	"debug": 0,
+	"info":  1,
+	"warn":  2,
+	"error": 3,
+}
+
+// logQuery holds the StreamLogs query parameters. Filters are applied before lines are
+// sent, so clients don't receive output they would discard.
+type logQuery struct {
+	previousOnly bool           // previous=true: only the previous container instance
+	pattern      *regexp.Regexp // grep: lines must match
+	minSeverity  int            // level: lines must be at least this severe
+	since        time.Time      // since: only lines logged after this time
+}
+
+// filtered reports whether any filter is set, in which case more history is read so
+// the filtered tail isn't nearly empty
+func (q logQuery) filtered() bool {
+	return q.pattern != nil || q.minSeverity > 0 || !q.since.IsZero()
+}
+
+// parseLogQuery reads the StreamLogs query parameters
+func parseLogQuery(c *gin.Context) (logQuery, *apierror.Error) {
+	query := logQuery{previousOnly: c.Query("previous") == "true"}
+	fields := make(map[string]string)
+
+	if grep := c.Query("grep"); grep != "" {
+		pattern, err := regexp.Compile(grep)
+		if err != nil {
+			fields["grep"] = "is invalid"
+		}
+		query.pattern = pattern
+	}
+
+	if level := c.Query("level"); level != "" {
+		severity, ok := logSeverities[level]
+		if !ok {
+			fields["level"] = "must be one of: debug, info, warn, error"
+		}
+		query.minSeverity = severity
+	}
+
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			fields["since"] = "is invalid"
+		}
+		query.since = t
+	}
+
+	if len(fields) > 0 {
+		return logQuery{}, apierror.New(http.StatusBadRequest, apierror.CodeValidation, "request validation failed").WithDetails(fields)
+	}
+	return query, nil
+}
+
+// parseSeverityTag splits the supervisor's severity tag from a line. Untagged lines, such
+// as the supervisor's own logs, have no severity.
+func parseSeverityTag(line string) (severity, text string) {
+	if !strings.HasPrefix(line, logSeverityTagPrefix) {
+		return "", line
+	}
+	end := strings.Index(line, "] ")
+	if end < 0 {
+		return "", line
+	}
+	severity = line[len(logSeverityTagPrefix):end]
+	if _, ok := logSeverities[severity]; !ok {
+		return "", line
+	}
+	return severity, line[end+2:]
+}
+
+// match reports whether a line passes the filters. Untagged lines count as info.
+func (q logQuery) match(severity, text string) bool {
+	rank, ok := logSeverities[severity]
+	if !ok {
+		rank = logSeverities["info"]
+	}
+	if rank < q.minSeverity {
+		return false
+	}
+	return q.pattern == nil || q.pattern.MatchString(text)
+}
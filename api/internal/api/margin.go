@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// SetNodeCost sets what a node costs per month at its provider, for the margin report
+func (h *AdminHandler) SetNodeCost(c *gin.Context) {
+	var req models.SetNodeCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	name := c.Param("name")
+	updated, err := h.db.SetNodeMonthlyCost(c.Request.Context(), name, req.MonthlyCost)
+	if err != nil {
+		log.Printf("failed to set monthly cost of node %s: %v", name, err)
+		c.Error(apierror.Internal("failed to update node"))
+		return
+	}
+	if !updated {
+		c.Error(apierror.NotFound("node not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"node": name, "monthly_cost": req.MonthlyCost})
+}
+
+// GetMarginReport compares node costs with the revenue of the servers scheduled on them,
+// per node and per game, to guide capacity purchasing
+func (h *AdminHandler) GetMarginReport(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	nodes, err := h.db.ListNodeCosts(ctx)
+	if err != nil {
+		log.Printf("failed to list node costs: %v", err)
+		c.Error(apierror.Internal("failed to build margin report"))
+		return
+	}
+	servers, err := h.db.ListBilledServers(ctx)
+	if err != nil {
+		log.Printf("failed to list billed servers: %v", err)
+		c.Error(apierror.Internal("failed to build margin report"))
+		return
+	}
+
+	c.JSON(http.StatusOK, buildMarginReport(nodes, servers, h.planPrices(ctx, servers)))
+}
+
+// planPrice is the monthly price of a game's plan
+type planPrice struct {
+	amount   int64
+	currency string
+}
+
+// planPrices looks up the price of every game and plan the servers are on. Plans whose
+// price can't be retrieved are left out.
+func (h *AdminHandler) planPrices(ctx context.Context, servers []database.BilledServer) map[string]planPrice {
+	prices := make(map[string]planPrice)
+	tried := make(map[string]bool)
+	for _, server := range servers {
+		key := string(server.Game) + "/" + string(server.Plan)
+		if tried[key] {
+			continue
+		}
+		tried[key] = true
+
+		priceID, err := h.config.GetPriceID(string(server.Game), string(server.Plan))
+		if err != nil {
+			continue // Plan not purchasable, e.g. retired
+		}
+		price, err := h.stripeService.GetPrice(ctx, priceID)
+		if err != nil {
+			log.Printf("failed to get price %s: %v", priceID, err)
+			continue
+		}
+		prices[key] = planPrice{amount: price.UnitAmount, currency: string(price.Currency)}
+	}
+	return prices
+}
+
+// buildMarginReport attributes revenue to the nodes servers are scheduled on, and splits
+// each node's cost between its servers' games by reserved CPU
+func buildMarginReport(nodes []database.NodeCost, servers []database.BilledServer, prices map[string]planPrice) *models.MarginReport {
+	report := &models.MarginReport{
+		Nodes:       make([]models.NodeMargin, 0, len(nodes)),
+		Games:       []models.GameMargin{},
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	byNode := make(map[string][]database.BilledServer)
+	for _, server := range servers {
+		if server.NodeName == nil {
+			report.UnscheduledServers++
+			continue
+		}
+		byNode[*server.NodeName] = append(byNode[*server.NodeName], server)
+	}
+
+	games := make(map[models.GameType]*models.GameMargin)
+	game := func(g models.GameType) *models.GameMargin {
+		if games[g] == nil {
+			games[g] = &models.GameMargin{Game: g}
+		}
+		return games[g]
+	}
+
+	revenue := func(server database.BilledServer) int64 {
+		price, ok := prices[string(server.Game)+"/"+string(server.Plan)]
+		if !ok {
+			report.UnpricedServers++
+			return 0
+		}
+		if report.Currency == "" {
+			report.Currency = price.currency
+		}
+		game(server.Game).Revenue += price.amount
+		return price.amount
+	}
+
+	for _, server := range servers {
+		game(server.Game).Servers++
+		if server.NodeName == nil {
+			report.UnscheduledRevenue += revenue(server)
+		}
+	}
+
+	for _, node := range nodes {
+		margin := models.NodeMargin{
+			Node:                     node.Name,
+			Provider:                 node.Provider,
+			Region:                   node.Region,
+			Active:                   node.IsActive,
+			MonthlyCost:              node.MonthlyCost,
+			AllocatableCPUMillicores: node.AllocatableCPUMillicores,
+		}
+
+		scheduled := byNode[node.Name]
+		for _, server := range scheduled {
+			margin.Servers++
+			margin.Revenue += revenue(server)
+			margin.ReservedCPUMillicores += server.ReservedCPUMillicores
+		}
+
+		if node.MonthlyCost != nil {
+			cost := *node.MonthlyCost
+			report.TotalCost += cost
+			m := margin.Revenue - cost
+			margin.Margin = &m
+			margin.MarginPercent = marginPercent(m, margin.Revenue)
+
+			if len(scheduled) == 0 {
+				report.IdleCost += cost
+			}
+			splitNodeCost(cost, scheduled, margin.ReservedCPUMillicores, func(g models.GameType, share int64) {
+				game(g).Cost += share
+			})
+		}
+
+		report.Nodes = append(report.Nodes, margin)
+	}
+
+	for _, g := range games {
+		g.Margin = g.Revenue - g.Cost
+		g.MarginPercent = marginPercent(g.Margin, g.Revenue)
+		report.TotalRevenue += g.Revenue
+		report.Games = append(report.Games, *g)
+	}
+	sort.Slice(report.Games, func(i, j int) bool { return report.Games[i].Game < report.Games[j].Game })
+
+	report.TotalMargin = report.TotalRevenue - report.TotalCost
+	return report
+}
+
+// splitNodeCost divides a node's cost between its servers by reserved CPU, or evenly when
+// none of them reserve any. The last server takes the rounding remainder so the shares add
+// up to the cost.
+func splitNodeCost(cost int64, servers []database.BilledServer, reservedCPU int, add func(models.GameType, int64)) {
+	var assigned int64
+	for i, server := range servers {
+		var share int64
+		switch {
+		case i == len(servers)-1:
+			share = cost - assigned
+		case reservedCPU > 0:
+			share = cost * int64(server.ReservedCPUMillicores) / int64(reservedCPU)
+		default:
+			share = cost / int64(len(servers))
+		}
+		assigned += share
+		add(server.Game, share)
+	}
+}
+
+// marginPercent returns margin as a percentage of revenue, or nil without revenue
+func marginPercent(margin, revenue int64) *float64 {
+	if revenue == 0 {
+		return nil
+	}
+	percent := float64(margin) * 100 / float64(revenue)
+	return &percent
+}
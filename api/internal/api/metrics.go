@@ -0,0 +1,68 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// metricsRanges are the ranges GetServerMetrics charts, and the step each is averaged over
+var metricsRanges = map[string]struct{ window, step time.Duration }{
+	"1h":  {time.Hour, time.Minute},
+	"6h":  {6 * time.Hour, 5 * time.Minute},
+	"24h": {24 * time.Hour, 15 * time.Minute},
+	"7d":  {7 * 24 * time.Hour, 2 * time.Hour},
+}
+
+// GetServerMetrics returns the server's CPU, memory and player usage over ?range (1h, 6h,
+// 24h or 7d, default 24h), averaged per step, with its plan's limits to chart them against
+func (h *ServerHandler) GetServerMetrics(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	rangeName := c.DefaultQuery("range", "24h")
+	metricsRange, ok := metricsRanges[rangeName]
+	if !ok {
+		c.Error(apierror.BadRequest("range must be 1h, 6h, 24h or 7d"))
+		return
+	}
+
+	points, err := h.db.ListServerMetrics(c.Request.Context(), server.ID.String(), time.Now().Add(-metricsRange.window), metricsRange.step)
+	if err != nil {
+		log.Printf("failed to list metrics of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to get server metrics"))
+		return
+	}
+
+	metrics := models.ServerMetrics{
+		Range:       rangeName,
+		StepSeconds: int(metricsRange.step.Seconds()),
+		Points:      points,
+	}
+
+	// Limits are informational; metrics are still returned if the catalog can't be read
+	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err == nil {
+		if gameConfig, err := catalog.GetGameConfig(string(server.Game)); err == nil {
+			if planConfig, err := gameConfig.GetPlanConfig(string(server.Plan)); err == nil {
+				if cpu, err := resource.ParseQuantity(planConfig.CPU); err == nil {
+					percent := float64(cpu.MilliValue()) / 10
+					metrics.CPULimitPercent = &percent
+				}
+				if memory, err := resource.ParseQuantity(planConfig.Memory); err == nil {
+					megabytes := memory.Value() / (1024 * 1024)
+					metrics.MemoryLimitMB = &megabytes
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"metrics": metrics})
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/services/auth"
+)
+
+// mfaAttemptsPerMinute is how many two-factor codes a user may try a minute
+const mfaAttemptsPerMinute = 5
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"` // From the authenticator app, or a backup code
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+// LoginMFA completes a login of a user with two-factor authentication: it takes the token
+// Login returned and a code from the user's authenticator app, or a backup code, and
+// returns the user's tokens
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	claims, err := h.authService.ParseScopedToken(req.MFAToken, middleware.ScopeMFALogin)
+	if err != nil {
+		c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeInvalidToken, err.Error()))
+		return
+	}
+	if !h.verifyMFACode(c, claims.UserID, req.Code) {
+		return
+	}
+
+	user, err := h.authService.GetUserByID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.Error(apierror.Unauthorized("user not found"))
+		return
+	}
+	h.issueTokens(c, user)
+}
+
+// GetMFA returns whether the user has two-factor authentication enabled
+func (h *AuthHandler) GetMFA(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	mfa, err := h.authService.GetMFA(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get mfa of user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to get two-factor authentication"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mfa": mfa.Status()})
+}
+
+// SetupMFA starts enrolling the user in two-factor authentication. It returns a secret for
+// their authenticator app and its otpauth:// URI to show as a QR code; logins need codes
+// once EnableMFA confirms one. Starting again replaces an unconfirmed secret.
+func (h *AuthHandler) SetupMFA(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	user, err := h.authService.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+
+	secret, otpauthURL, err := h.authService.StartMFAEnrollment(c.Request.Context(), user)
+	if errors.Is(err, auth.ErrMFAAlreadyEnabled) {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict, "two-factor authentication is already enabled"))
+		return
+	}
+	if err != nil {
+		log.Printf("failed to start mfa enrollment for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to set up two-factor authentication"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_url": otpauthURL,
+	})
+}
+
+// EnableMFA confirms enrollment with a code from the user's authenticator app and returns
+// their backup codes, shown only this once. Sessions logged in without a code are logged
+// out, so it also returns new tokens for this one.
+func (h *AuthHandler) EnableMFA(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+	if !h.allowMFAAttempt(c, userID) {
+		return
+	}
+
+	user, err := h.authService.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return
+	}
+
+	backupCodes, err := h.authService.EnableMFA(c.Request.Context(), userID, req.Code)
+	switch {
+	case errors.Is(err, auth.ErrMFAInvalidCode):
+		c.Error(apierror.ErrInvalidMFACode)
+		return
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict, "two-factor authentication is already enabled"))
+		return
+	case errors.Is(err, auth.ErrMFANotEnrolled):
+		c.Error(apierror.BadRequest("two-factor authentication setup was not started"))
+		return
+	case err != nil:
+		log.Printf("failed to enable mfa for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to enable two-factor authentication"))
+		return
+	}
+
+	if err := h.authService.DeleteUserRefreshTokens(c.Request.Context(), userID); err != nil {
+		log.Printf("failed to revoke refresh tokens of user %s: %v", userID, err)
+	}
+	accessToken, refreshToken, ok := h.newTokens(c, user)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "two-factor authentication enabled",
+		"backup_codes":  backupCodes,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
+}
+
+// DisableMFA turns two-factor authentication off, after checking the user's password and a
+// code
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+	if !h.checkPassword(c, userID, req.Password) || !h.verifyMFACode(c, userID, req.Code) {
+		return
+	}
+
+	if err := h.authService.DisableMFA(c.Request.Context(), userID); err != nil {
+		log.Printf("failed to disable mfa for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to disable two-factor authentication"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// RegenerateMFABackupCodes replaces the user's backup codes after checking a code, and
+// returns the new ones
+func (h *AuthHandler) RegenerateMFABackupCodes(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+	if !h.verifyMFACode(c, userID, req.Code) {
+		return
+	}
+
+	backupCodes, err := h.authService.RegenerateBackupCodes(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to regenerate mfa backup codes for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to generate backup codes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"backup_codes": backupCodes})
+}
+
+// verifyMFACode checks and uses up a two-factor code of the user. Otherwise it sets the
+// error and returns false.
+func (h *AuthHandler) verifyMFACode(c *gin.Context, userID, code string) bool {
+	if !h.allowMFAAttempt(c, userID) {
+		return false
+	}
+
+	err := h.authService.VerifyMFA(c.Request.Context(), userID, code)
+	if errors.Is(err, auth.ErrMFAInvalidCode) {
+		c.Error(apierror.ErrInvalidMFACode)
+		return false
+	}
+	if err != nil {
+		log.Printf("failed to verify mfa code of user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to verify code"))
+		return false
+	}
+	return true
+}
+
+// allowMFAAttempt takes one of the user's code attempts. Otherwise it sets the error and
+// returns false.
+func (h *AuthHandler) allowMFAAttempt(c *gin.Context, userID string) bool {
+	if ok, retryAfter := h.mfaLimiter.Allow(userID); !ok {
+		seconds := middleware.RetryAfterSeconds(retryAfter)
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.Error(apierror.RateLimited(seconds))
+		return false
+	}
+	return true
+}
+
+// checkPassword checks the user's password before a sensitive change. Otherwise it sets
+// the error and returns false.
+func (h *AuthHandler) checkPassword(c *gin.Context, userID, password string) bool {
+	user, err := h.authService.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(apierror.NotFound("user not found"))
+		return false
+	}
+	if err := h.authService.ComparePassword(user.PasswordHash, password); err != nil {
+		c.Error(apierror.ErrIncorrectPassword)
+		return false
+	}
+	return true
+}
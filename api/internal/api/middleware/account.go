@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+)
+
+// suspendedAccountRoutes are the writes a suspended account may still make, keyed by
+// method and route pattern: winding down servers and appealing the suspension
+var suspendedAccountRoutes = map[string]bool{
+	"POST /servers/:id/stop":           true,
+	"DELETE /servers/:id":              true,
+	"POST /billing/servers/:id/cancel": true,
+	"POST /me/reinstatement-request":   true,
+}
+
+// RequireActiveAccount makes suspended accounts read-only: reads pass, writes are rejected
+// unless listed in suspendedAccountRoutes. Must run after AuthMiddleware.
+func RequireActiveAccount(isSuspended func(ctx context.Context, userID string) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if suspendedAccountRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		suspended, err := isSuspended(c.Request.Context(), GetUserID(c))
+		if err != nil {
+			log.Printf("failed to check account suspension: %v", err)
+			c.Error(apierror.Internal("failed to check account status"))
+			c.Abort()
+			return
+		}
+		if suspended {
+			c.Error(apierror.ErrAccountSuspended)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
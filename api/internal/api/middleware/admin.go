@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+)
+
+// RequireAdmin rejects requests whose authenticated user isAdmin doesn't accept, e.g.
+// because their role isn't admin. Must run after AuthMiddleware.
+func RequireAdmin(isAdmin func(c *gin.Context) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin, err := isAdmin(c)
+		if err != nil {
+			log.Printf("failed to check admin role of user %s: %v", GetUserID(c), err)
+			c.Error(apierror.Internal("failed to check permissions"))
+			c.Abort()
+			return
+		}
+		if !admin {
+			c.Error(apierror.ErrAdminRequired)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
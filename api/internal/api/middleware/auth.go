@@ -6,11 +6,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
 )
 
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	Scope  string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -33,7 +35,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		}
 
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization"})
+			c.Error(apierror.Unauthorized("missing authorization"))
 			c.Abort()
 			return
 		}
@@ -44,7 +46,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeInvalidToken, "invalid or expired token"))
 			c.Abort()
 			return
 		}
@@ -52,7 +54,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		// Extract claims
 		claims, ok := token.Claims.(*Claims)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeInvalidToken, "invalid token claims"))
 			c.Abort()
 			return
 		}
@@ -60,6 +62,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		// Set user context
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
+		c.Set("scope", claims.Scope)
 
 		c.Next()
 	}
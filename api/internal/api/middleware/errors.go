@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/i18n"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// RequestID assigns every request an ID (reusing a valid incoming X-Request-ID)
+// and echoes it in the response headers so errors can be correlated with logs
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = newRequestID()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(requestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// GetRequestID returns the current request ID, or "" if RequestID is not installed
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ErrorHandler renders the last error attached with c.Error as the standard
+// error envelope. Errors that are not *apierror.Error are logged and reported
+// as INTERNAL_ERROR so internal details never leak to clients.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+
+		var apiErr *apierror.Error
+		if !errors.As(err, &apiErr) {
+			log.Printf("unhandled error: request_id=%s path=%s error=%v", GetRequestID(c), c.FullPath(), err)
+			apiErr = apierror.Internal("internal error")
+		}
+
+		c.JSON(apiErr.Status, localizeResponse(GetLanguage(c), apiErr.Response(GetRequestID(c))))
+	}
+}
+
+// localizeResponse translates the message and any per-field detail messages
+func localizeResponse(lang string, resp apierror.Response) apierror.Response {
+	resp.Error.Message = i18n.T(lang, resp.Error.Message)
+
+	switch details := resp.Error.Details.(type) {
+	case map[string]string:
+		translated := make(map[string]string, len(details))
+		for field, msg := range details {
+			translated[field] = i18n.T(lang, msg)
+		}
+		resp.Error.Details = translated
+	case gin.H:
+		translated := make(gin.H, len(details))
+		for key, value := range details {
+			if msg, ok := value.(string); ok {
+				value = i18n.T(lang, msg)
+			}
+			translated[key] = value
+		}
+		resp.Error.Details = translated
+	}
+
+	return resp
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/i18n"
+)
+
+// Locale resolves the response language from the Accept-Language header
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("lang", i18n.MatchLanguage(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// GetLanguage returns the resolved response language, defaulting to English
+func GetLanguage(c *gin.Context) string {
+	if lang := c.GetString("lang"); lang != "" {
+		return lang
+	}
+	return i18n.DefaultLanguage
+}
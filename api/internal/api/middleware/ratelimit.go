@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"golang.org/x/time/rate"
+)
+
+// rateLimiterIdleTTL is how long a key's bucket is kept after its last request; an idle
+// bucket is full again by then, so dropping it changes nothing
+const rateLimiterIdleTTL = 10 * time.Minute
+
+// RateLimiter gives each key (e.g. a server ID) its own token bucket. Buckets live in
+// memory, so each API replica enforces the limit separately.
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+	sweptAt time.Time
+}
+
+type rateBucket struct {
+	limiter *rate.Limiter
+	usedAt  time.Time
+}
+
+// NewRateLimiter allows each key perMinute requests a minute on average, and bursts of up
+// to burst requests. A perMinute of 0 disables the limit.
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	return &RateLimiter{
+		limit:   rate.Limit(float64(perMinute) / 60),
+		burst:   max(burst, 1),
+		buckets: make(map[string]*rateBucket),
+	}
+}
+
+// Allow takes a token from key's bucket. If it's empty, it returns false and how long
+// until the next token.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.sweptAt) > time.Minute {
+		for k, bucket := range l.buckets {
+			if now.Sub(bucket.usedAt) > rateLimiterIdleTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.sweptAt = now
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &rateBucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = bucket
+	}
+	bucket.usedAt = now
+
+	if bucket.limiter.AllowN(now, 1) {
+		return true, 0
+	}
+	tokens := bucket.limiter.TokensAt(now)
+	return false, time.Duration((1 - tokens) / float64(l.limit) * float64(time.Second))
+}
+
+// RateLimit rejects requests with 429 once the key returned for them runs out of tokens.
+// Requests with an empty key aren't limited.
+func RateLimit(limiter *RateLimiter, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		if ok, retryAfter := limiter.Allow(k); !ok {
+			seconds := RetryAfterSeconds(retryAfter)
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.Error(apierror.RateLimited(seconds))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RetryAfterSeconds rounds a wait up to whole seconds, at least 1, for Retry-After
+func RetryAfterSeconds(retryAfter time.Duration) int {
+	return max(int(math.Ceil(retryAfter.Seconds())), 1)
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	tests := []struct {
+		name      string
+		perMinute int
+		burst     int
+		requests  int
+		allowed   int
+	}{
+		{"within burst", 60, 5, 5, 5},
+		{"over burst", 60, 5, 8, 5},
+		{"burst of at least one", 60, 0, 3, 1},
+		{"disabled", 0, 1, 100, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(tt.perMinute, tt.burst)
+			allowed := 0
+			for range tt.requests {
+				if ok, _ := limiter.Allow("srv-1"); ok {
+					allowed++
+				}
+			}
+			assert.Equal(t, tt.allowed, allowed)
+		})
+	}
+}
+
+func TestRateLimiter_RetryAfter(t *testing.T) {
+	limiter := NewRateLimiter(60, 1) // One token a second
+
+	ok, retryAfter := limiter.Allow("srv-1")
+	require.True(t, ok)
+	assert.Zero(t, retryAfter)
+
+	ok, retryAfter = limiter.Allow("srv-1")
+	require.False(t, ok)
+	assert.Greater(t, retryAfter, time.Duration(0))
+	assert.LessOrEqual(t, retryAfter, time.Second)
+}
+
+func TestRateLimiter_KeysAreIndependent(t *testing.T) {
+	limiter := NewRateLimiter(60, 1)
+
+	ok, _ := limiter.Allow("srv-1")
+	require.True(t, ok)
+	ok, _ = limiter.Allow("srv-1")
+	assert.False(t, ok, "srv-1 is out of tokens")
+
+	ok, _ = limiter.Allow("srv-2")
+	assert.True(t, ok, "srv-2 has its own bucket")
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		want       int
+	}{
+		{0, 1},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1001 * time.Millisecond, 2},
+		{2500 * time.Millisecond, 3},
+		{time.Minute, 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.retryAfter.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, RetryAfterSeconds(tt.retryAfter))
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewRateLimiter(60, 2)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/report", RateLimit(limiter, func(c *gin.Context) string {
+		return c.GetHeader("X-Server-ID")
+	}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(serverID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/report", nil)
+		if serverID != "" {
+			req.Header.Set("X-Server-ID", serverID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name       string
+		serverID   string
+		wantStatus int
+	}{
+		{"first request", "srv-1", http.StatusNoContent},
+		{"second request uses the burst", "srv-1", http.StatusNoContent},
+		{"third request is limited", "srv-1", http.StatusTooManyRequests},
+		{"other server is not limited", "srv-2", http.StatusNoContent},
+		{"no key", "", http.StatusNoContent},
+		{"no key, second request", "", http.StatusNoContent},
+		{"no key, past the burst", "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		w := request(tt.serverID)
+		require.Equal(t, tt.wantStatus, w.Code, tt.name)
+
+		if tt.wantStatus == http.StatusTooManyRequests {
+			assert.Equal(t, "1", w.Header().Get("Retry-After"), tt.name)
+
+			var resp apierror.Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, apierror.CodeRateLimited, resp.Error.Code, tt.name)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+)
+
+// ScopeDiscordBot is the scope of tokens the Discord bot acts on a linked user's behalf with
+const ScopeDiscordBot = "discord-bot"
+
+// ScopeMFALogin is the scope of the tokens a login continues with while it waits for the
+// user's two-factor code. It allows no routes; only POST /auth/login/mfa accepts them.
+const ScopeMFALogin = "mfa-login"
+
+// scopedTokenRoutes are the routes a scoped token may call, keyed by scope, then by method
+// and route pattern
+var scopedTokenRoutes = map[string]map[string]bool{
+	ScopeDiscordBot: {
+		"GET /servers":            true,
+		"GET /servers/:id":        true,
+		"POST /servers/:id/start": true,
+		"POST /servers/:id/stop":  true,
+	},
+}
+
+// RestrictTokenScope rejects requests made with a scoped token to routes outside its scope.
+// Unscoped tokens pass. Must run after AuthMiddleware.
+func RestrictTokenScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := c.GetString("scope")
+		if scope == "" || scopedTokenRoutes[scope][c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+		c.Error(apierror.ErrTokenScope)
+		c.Abort()
+	}
+}
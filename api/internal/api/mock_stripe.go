@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/database"
+	stripeservice "github.com/mooncorn/gshub/api/internal/services/stripe"
+)
+
+// MockStripeHandler exposes local endpoints that drive the simulated Stripe flow.
+// Routes are only registered when STRIPE_MOCK_MODE is enabled.
+type MockStripeHandler struct {
+	db            *database.DB
+	stripeService *stripeservice.Service
+}
+
+func NewMockStripeHandler(db *database.DB, stripeSvc *stripeservice.Service) *MockStripeHandler {
+	return &MockStripeHandler{
+		db:            db,
+		stripeService: stripeSvc,
+	}
+}
+
+// GetCheckoutSession returns a mock checkout session owned by the current user
+func (h *MockStripeHandler) GetCheckoutSession(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	sess, err := h.stripeService.RetrieveCheckoutSession(c.Request.Context(), c.Param("session_id"))
+	if err != nil || sess.Metadata["user_id"] != userID {
+		c.Error(apierror.NotFound("checkout session not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":     sess.ID,
+		"status":         sess.Status,
+		"payment_status": sess.PaymentStatus,
+		"success_url":    sess.SuccessURL,
+		"cancel_url":     sess.CancelURL,
+	})
+}
+
+// CompleteCheckoutSession simulates a successful payment for a mock checkout session
+func (h *MockStripeHandler) CompleteCheckoutSession(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	sessionID := c.Param("session_id")
+	sess, err := h.stripeService.RetrieveCheckoutSession(c.Request.Context(), sessionID)
+	if err != nil || sess.Metadata["user_id"] != userID {
+		c.Error(apierror.NotFound("checkout session not found"))
+		return
+	}
+
+	sess, err = h.stripeService.CompleteMockCheckout(c.Request.Context(), sessionID)
+	if err != nil {
+		log.Printf("failed to complete mock checkout session %s: %v", sessionID, err)
+		c.Error(apierror.Internal("failed to complete checkout session"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":      sess.ID,
+		"subscription_id": sess.Subscription.ID,
+		"success_url":     sess.SuccessURL,
+	})
+}
+
+// EndSubscription simulates the end of a server's billing period (customer.subscription.deleted)
+func (h *MockStripeHandler) EndSubscription(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.StripeSubscriptionID == nil || *server.StripeSubscriptionID == "" {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeNoSubscription, "server has no subscription"))
+		return
+	}
+
+	if err := h.stripeService.EndMockSubscription(c.Request.Context(), *server.StripeSubscriptionID); err != nil {
+		if errors.Is(err, stripeservice.ErrMockModeDisabled) {
+			c.Error(apierror.NotFound("not found"))
+			return
+		}
+		log.Printf("failed to end mock subscription for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to end subscription"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "expired"})
+}
+
+// DisputeSubscription simulates a chargeback on a server's subscription (charge.dispute.created)
+func (h *MockStripeHandler) DisputeSubscription(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.StripeSubscriptionID == nil || *server.StripeSubscriptionID == "" {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeNoSubscription, "server has no subscription"))
+		return
+	}
+
+	if err := h.stripeService.DisputeMockSubscription(c.Request.Context(), *server.StripeSubscriptionID); err != nil {
+		if errors.Is(err, stripeservice.ErrMockModeDisabled) {
+			c.Error(apierror.NotFound("not found"))
+			return
+		}
+		log.Printf("failed to dispute mock subscription for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to dispute subscription"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "suspended"})
+}
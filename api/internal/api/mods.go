@@ -0,0 +1,435 @@
+package api
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/modrinth"
+)
+
+// ListMods returns the mods installed on the server, the loader they're installed for, and
+// the mods from the server's template it hasn't installed yet
+func (h *ServerHandler) ListMods(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+	loader, gameVersion := h.getModLoader(c, server)
+	if loader == nil {
+		return
+	}
+
+	mods, err := h.db.ListServerMods(c.Request.Context(), server.ID.String())
+	if err != nil {
+		log.Printf("failed to list mods of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to list mods"))
+		return
+	}
+
+	pending, err := h.db.ListServerPendingMods(c.Request.Context(), server.ID.String())
+	if err != nil {
+		log.Printf("failed to list pending mods of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to list mods"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"loader":       loader.Name,
+		"game_version": gameVersion,
+		"dir":          loader.Dir,
+		"mods":         mods,
+		"pending":      pending,
+	})
+}
+
+// SearchMods searches Modrinth for mods supporting the server's loader and game version
+func (h *ServerHandler) SearchMods(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	var query models.ModSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+
+	loader, gameVersion := h.getModLoader(c, server)
+	if loader == nil {
+		return
+	}
+
+	projects, err := h.mods.Search(c.Request.Context(), query.Query, loader.SearchProjectType(), loader.Name, gameVersion, query.Limit)
+	if err != nil {
+		log.Printf("failed to search mods for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to search mods"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+// InstallMod downloads a mod from Modrinth into the running server's mod directory,
+// replacing the installed version of the same project. The game loads it on its next start.
+// Loaders tied to a Steam app need the owner's linked Steam account to own it.
+func (h *ServerHandler) InstallMod(c *gin.Context) {
+	var req models.InstallModRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	target := h.getFilesTarget(c, true)
+	if target == nil {
+		return
+	}
+	server := target.server
+	loader, gameVersion := h.getModLoader(c, server)
+	if loader == nil {
+		return
+	}
+	if loader.SteamAppID != 0 && h.checkSteamOwnership(c, server, loader.SteamAppID) == nil {
+		return
+	}
+
+	mod, version := h.installMod(c, target, loader, gameVersion, req)
+	if mod == nil {
+		return
+	}
+
+	missing, err := h.missingModDependencies(c.Request.Context(), server, version)
+	if err != nil {
+		log.Printf("failed to check dependencies of mod %s: %v", version.ProjectID, err)
+		missing = []string{}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"mod":                  mod,
+		"missing_dependencies": missing,
+		"message":              "Mod installed, restart the server to load it",
+	})
+}
+
+// InstallPendingMods installs the mods a server created from a template hasn't installed
+// yet, at the versions the template saved. Installed mods stop being pending, so after a
+// failure the rest can be retried.
+func (h *ServerHandler) InstallPendingMods(c *gin.Context) {
+	target := h.getFilesTarget(c, true)
+	if target == nil {
+		return
+	}
+	server := target.server
+	loader, gameVersion := h.getModLoader(c, server)
+	if loader == nil {
+		return
+	}
+	if loader.SteamAppID != 0 && h.checkSteamOwnership(c, server, loader.SteamAppID) == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	pending, err := h.db.ListServerPendingMods(ctx, server.ID.String())
+	if err != nil {
+		log.Printf("failed to list pending mods of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to install mods"))
+		return
+	}
+
+	installed := []models.ServerMod{}
+	for _, p := range pending {
+		mod, _ := h.installMod(c, target, loader, gameVersion, models.InstallModRequest{
+			ProjectID: p.ProjectID,
+			VersionID: p.VersionID,
+		})
+		if mod == nil {
+			return
+		}
+		installed = append(installed, *mod)
+
+		if _, err := h.db.DeleteServerPendingMod(ctx, server.ID.String(), p.ProjectID); err != nil {
+			log.Printf("failed to clear pending mod %s of server %s: %v", p.ProjectID, server.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"mods":    installed,
+		"message": "Mods installed, restart the server to load them",
+	})
+}
+
+// DismissPendingMod drops a mod from the ones a server created from a template still has to
+// install, e.g. one no longer available on Modrinth
+func (h *ServerHandler) DismissPendingMod(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	dismissed, err := h.db.DeleteServerPendingMod(c.Request.Context(), server.ID.String(), c.Param("projectId"))
+	if err != nil {
+		log.Printf("failed to dismiss pending mod of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to dismiss mod"))
+		return
+	}
+	if !dismissed {
+		c.Error(apierror.NotFound("mod not found"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// installMod downloads a mod version into the server's mod directory and records it,
+// replacing the installed version of the same project. Otherwise it sets the error and
+// returns nil.
+func (h *ServerHandler) installMod(c *gin.Context, target *filesTarget, loader *k8s.ModLoader, gameVersion string, req models.InstallModRequest) (*models.ServerMod, *modrinth.Version) {
+	server := target.server
+	ctx := c.Request.Context()
+
+	version, err := h.resolveModVersion(ctx, req, loader, gameVersion)
+	if errors.Is(err, modrinth.ErrNotFound) {
+		c.Error(apierror.NotFound("no version of the mod supports this server"))
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("failed to resolve mod %s for server %s: %v", req.ProjectID, server.ID, err)
+		c.Error(apierror.Internal("failed to install mod"))
+		return nil, nil
+	}
+	file := version.PrimaryFile()
+	if file == nil {
+		c.Error(apierror.NotFound("mod version has no files"))
+		return nil, nil
+	}
+	if maxBytes := h.fileUploadMaxBytes(); maxBytes > 0 && file.Size > maxBytes {
+		c.Error(h.fileTooLarge())
+		return nil, nil
+	}
+
+	installed, err := h.db.GetServerModByProject(ctx, server.ID.String(), version.ProjectID)
+	if err != nil {
+		log.Printf("failed to get mod %s of server %s: %v", version.ProjectID, server.ID, err)
+		c.Error(apierror.Internal("failed to install mod"))
+		return nil, nil
+	}
+
+	// Modrinth filenames come from uploaders, so only the base name is kept
+	filename := path.Base(file.Filename)
+	if filename == "." || filename == ".." || filename == "/" {
+		log.Printf("mod version %s has an invalid filename %q", version.ID, file.Filename)
+		c.Error(apierror.Internal("failed to install mod"))
+		return nil, nil
+	}
+	filePath := path.Join(loader.Dir, filename)
+
+	if !h.uploadModFile(c, target, file, filePath) {
+		return nil, nil
+	}
+	if installed != nil && installed.Path != filePath {
+		h.deleteModFile(ctx, target, installed.Path)
+	}
+
+	name, err := h.mods.GetProjectTitle(ctx, version.ProjectID)
+	if err != nil {
+		log.Printf("failed to get title of mod %s: %v", version.ProjectID, err)
+		name = version.Name
+	}
+
+	mod, err := h.db.SaveServerMod(ctx, &models.ServerMod{
+		ServerID:      server.ID,
+		ProjectID:     version.ProjectID,
+		VersionID:     version.ID,
+		Name:          name,
+		VersionNumber: version.VersionNumber,
+		Loader:        loader.Name,
+		Path:          filePath,
+		Size:          file.Size,
+	})
+	if err != nil {
+		log.Printf("failed to save mod %s of server %s: %v", version.ProjectID, server.ID, err)
+		c.Error(apierror.Internal("failed to install mod"))
+		return nil, nil
+	}
+	return mod, version
+}
+
+// RemoveMod deletes an installed mod's file from the running server and forgets it
+func (h *ServerHandler) RemoveMod(c *gin.Context) {
+	target := h.getFilesTarget(c, true)
+	if target == nil {
+		return
+	}
+	server := target.server
+
+	modID, err := uuid.Parse(c.Param("modId"))
+	if err != nil {
+		c.Error(apierror.NotFound("mod not found"))
+		return
+	}
+
+	mod, err := h.db.GetServerMod(c.Request.Context(), server.ID.String(), modID.String())
+	if err != nil {
+		log.Printf("failed to get mod %s: %v", modID, err)
+		c.Error(apierror.Internal("failed to remove mod"))
+		return
+	}
+	if mod == nil {
+		c.Error(apierror.NotFound("mod not found"))
+		return
+	}
+
+	resp, err := supervisorFilesWrite(c.Request.Context(), target, http.MethodDelete, "/files", mod.Path, nil, 0)
+	if err != nil {
+		log.Printf("failed to delete mod file on server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to remove mod"))
+		return
+	}
+	resp.Body.Close()
+	// Already deleted through the file manager is as good as removed
+	if resp.StatusCode != http.StatusNotFound && !filesWriteResponseOK(c, resp, http.StatusNoContent) {
+		return
+	}
+
+	if err := h.db.DeleteServerMod(c.Request.Context(), mod.ID.String()); err != nil {
+		log.Printf("failed to delete mod %s: %v", mod.ID, err)
+		c.Error(apierror.Internal("failed to remove mod"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mod removed, restart the server to unload it"})
+}
+
+// getModLoader returns the mod loader the server's env selects and the game version its mods
+// must support. Otherwise it sets the error and returns nil.
+func (h *ServerHandler) getModLoader(c *gin.Context, server *models.Server) (*k8s.ModLoader, string) {
+	ctx := c.Request.Context()
+	catalog, err := h.k8sClient.LoadGameCatalog(ctx, h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err != nil {
+		log.Printf("failed to load game catalog: %v", err)
+		c.Error(apierror.Internal("failed to load game configuration"))
+		return nil, ""
+	}
+	gameConfig, err := h.serverGameConfig(ctx, server, catalog)
+	if err != nil {
+		log.Printf("failed to get game config for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to load game configuration"))
+		return nil, ""
+	}
+	planConfig, err := gameConfig.GetPlanConfig(string(server.Plan))
+	if err != nil {
+		log.Printf("failed to get plan config for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to load game configuration"))
+		return nil, ""
+	}
+
+	env := k8s.MergeEnvVars(gameConfig.Env, planConfig.Env, server.EnvOverrides)
+	loader, gameVersion := gameConfig.ServerModLoader(env)
+	if loader == nil {
+		c.Error(apierror.BadRequest("mods aren't available for this server"))
+		return nil, ""
+	}
+	return loader, gameVersion
+}
+
+// resolveModVersion returns the requested version, or the newest one for the loader and
+// game version
+func (h *ServerHandler) resolveModVersion(ctx context.Context, req models.InstallModRequest, loader *k8s.ModLoader, gameVersion string) (*modrinth.Version, error) {
+	if req.VersionID == "" {
+		return h.mods.LatestVersion(ctx, req.ProjectID, loader.Name, gameVersion)
+	}
+
+	version, err := h.mods.GetVersion(ctx, req.VersionID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(version.Loaders, loader.Name) {
+		return nil, modrinth.ErrNotFound
+	}
+	return version, nil
+}
+
+// uploadModFile streams a mod file from Modrinth to filePath on the server, checking its
+// hash on the way. Otherwise it sets the error and returns false.
+func (h *ServerHandler) uploadModFile(c *gin.Context, target *filesTarget, file *modrinth.File, filePath string) bool {
+	ctx := c.Request.Context()
+	body, err := h.mods.Download(ctx, file)
+	if err != nil {
+		log.Printf("failed to download mod for server %s: %v", target.server.ID, err)
+		c.Error(apierror.Internal("failed to download mod"))
+		return false
+	}
+	defer body.Close()
+
+	hash := sha512.New()
+	resp, err := supervisorFilesWrite(ctx, target, http.MethodPut, "/files/upload", filePath, io.TeeReader(body, hash), file.Size)
+	if err != nil {
+		log.Printf("failed to upload mod to server %s: %v", target.server.ID, err)
+		c.Error(apierror.Internal("failed to install mod"))
+		return false
+	}
+	defer resp.Body.Close()
+
+	if !filesWriteResponseOK(c, resp, http.StatusCreated) {
+		return false
+	}
+
+	if want := file.Hashes["sha512"]; want != "" && hex.EncodeToString(hash.Sum(nil)) != want {
+		log.Printf("mod file %s for server %s failed its hash check", file.Filename, target.server.ID)
+		h.deleteModFile(ctx, target, filePath)
+		c.Error(apierror.Internal("failed to install mod"))
+		return false
+	}
+	return true
+}
+
+// deleteModFile removes a mod file from the server, logging failures: a stale file only
+// needs deleting by hand
+func (h *ServerHandler) deleteModFile(ctx context.Context, target *filesTarget, filePath string) {
+	resp, err := supervisorFilesWrite(ctx, target, http.MethodDelete, "/files", filePath, nil, 0)
+	if err != nil {
+		log.Printf("failed to delete mod file %s on server %s: %v", filePath, target.server.ID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		log.Printf("failed to delete mod file %s on server %s: status %d", filePath, target.server.ID, resp.StatusCode)
+	}
+}
+
+// missingModDependencies returns the projects a mod version requires that the server
+// doesn't have installed
+func (h *ServerHandler) missingModDependencies(ctx context.Context, server *models.Server, version *modrinth.Version) ([]string, error) {
+	installed, err := h.db.ListServerMods(ctx, server.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mods: %w", err)
+	}
+	have := make(map[string]bool, len(installed))
+	for _, mod := range installed {
+		have[mod.ProjectID] = true
+	}
+
+	missing := []string{}
+	for _, dep := range version.Dependencies {
+		if dep.DependencyType == "required" && dep.ProjectID != "" && !have[dep.ProjectID] {
+			missing = append(missing, dep.ProjectID)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/nodesync"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// defaultNodeTokenTTL is how long node registration tokens can be used by default
+const defaultNodeTokenTTL = time.Hour
+
+// CreateNodeRegistrationToken issues a one-time token for the node agent to join a machine
+// as a game server node in the given location. The token is only returned here.
+func (h *AdminHandler) CreateNodeRegistrationToken(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.CreateNodeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	// Location values become label values, so they must be valid ones
+	for _, value := range []string{req.Region, req.Zone, req.Provider, req.Datacenter} {
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			c.Error(apierror.BadRequest("invalid location " + value + ": " + strings.Join(errs, "; ")))
+			return
+		}
+	}
+
+	ttl := defaultNodeTokenTTL
+	if req.ExpiresInMinutes > 0 {
+		ttl = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("failed to generate node registration token: %v", err)
+		c.Error(apierror.Internal("failed to create node registration token"))
+		return
+	}
+	secret := hex.EncodeToString(b)
+
+	token, err := h.db.CreateNodeRegistrationToken(c.Request.Context(), hashNodeToken(secret), req, userID, time.Now().Add(ttl))
+	if err != nil {
+		log.Printf("failed to create node registration token: %v", err)
+		c.Error(apierror.Internal("failed to create node registration token"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"token": secret, "registration": token})
+}
+
+// maxPortRangeSize caps the ports per node and protocol, each of which is a row per node
+const maxPortRangeSize = 10000
+
+// GetPortRange returns the host ports game servers are allocated on each node
+func (h *AdminHandler) GetPortRange(c *gin.Context) {
+	portMin, portMax, err := h.nodeSync.PortRange(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to get port range: %v", err)
+		c.Error(apierror.Internal("failed to get port range"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"port_range_min": portMin, "port_range_max": portMax})
+}
+
+// SetPortRange widens or shrinks the host port range of all nodes at once, replacing
+// PORT_RANGE_MIN and PORT_RANGE_MAX. Shrinking is refused while servers hold ports outside
+// the new range. Nodes' firewalls must be opened for added ports separately.
+func (h *AdminHandler) SetPortRange(c *gin.Context) {
+	var req models.PortRangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+	if req.PortRangeMax-req.PortRangeMin+1 > maxPortRangeSize {
+		c.Error(apierror.BadRequest(fmt.Sprintf("port range can hold at most %d ports", maxPortRangeSize)))
+		return
+	}
+
+	inUse, err := h.nodeSync.SetPortRange(c.Request.Context(), req.PortRangeMin, req.PortRangeMax)
+	if err != nil {
+		log.Printf("failed to set port range to %d-%d: %v", req.PortRangeMin, req.PortRangeMax, err)
+		c.Error(apierror.Internal("failed to set port range"))
+		return
+	}
+	if inUse > 0 {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict,
+			fmt.Sprintf("%d allocated ports lie outside the new range", inUse)).
+			WithDetails(gin.H{"ports_in_use": inUse}))
+		return
+	}
+
+	log.Printf("port range set to %d-%d by admin %s", req.PortRangeMin, req.PortRangeMax, middleware.GetUserID(c))
+	c.JSON(http.StatusOK, gin.H{"port_range_min": req.PortRangeMin, "port_range_max": req.PortRangeMax})
+}
+
+// hashNodeToken returns the hex SHA-256 node registration tokens are stored by
+func hashNodeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NodeAgentHandler serves the node agent, which joins a machine to the platform with a
+// node registration token: it has the API label its node, opens the game port range and
+// reports back once kubelet is healthy, so node sync picks the node up right away.
+type NodeAgentHandler struct {
+	db       *database.DB
+	nodeSync *nodesync.Service
+}
+
+// NewNodeAgentHandler creates a new node agent handler
+func NewNodeAgentHandler(db *database.DB, nodeSync *nodesync.Service) *NodeAgentHandler {
+	return &NodeAgentHandler{
+		db:       db,
+		nodeSync: nodeSync,
+	}
+}
+
+// RegisterRoutes registers the routes the node agent calls. They're public, as new machines
+// may not reach the internal API, and authorized by the registration token.
+func (h *NodeAgentHandler) RegisterRoutes(r *gin.Engine) {
+	agent := r.Group("/nodes/agent")
+	agent.Use(h.tokenMiddleware())
+	{
+		agent.POST("/register", h.RegisterNode)
+		agent.POST("/ready", h.NodeReady)
+	}
+}
+
+// tokenMiddleware validates the node registration token
+func (h *NodeAgentHandler) tokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || secret == "" {
+			c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeInvalidToken, "invalid token"))
+			c.Abort()
+			return
+		}
+
+		token, err := h.db.GetUsableNodeRegistrationToken(c.Request.Context(), hashNodeToken(secret))
+		if err != nil {
+			log.Printf("failed to get node registration token: %v", err)
+			c.Error(apierror.Internal("failed to check token"))
+			c.Abort()
+			return
+		}
+		if token == nil {
+			c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeInvalidToken, "invalid token"))
+			c.Abort()
+			return
+		}
+
+		c.Set("node_registration_token", token)
+		c.Next()
+	}
+}
+
+// RegisterNode labels the agent's node, which must have joined the cluster, and binds the
+// token to it. Returns the port range to open.
+func (h *NodeAgentHandler) RegisterNode(c *gin.Context) {
+	token := c.MustGet("node_registration_token").(*models.NodeRegistrationToken)
+
+	var req models.RegisterNodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	bound, err := h.db.BindNodeRegistrationToken(c.Request.Context(), token.ID, req.NodeName)
+	if err != nil {
+		log.Printf("failed to bind node registration token %s: %v", token.ID, err)
+		c.Error(apierror.Internal("failed to register node"))
+		return
+	}
+	if !bound {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict, "token was used to register another node"))
+		return
+	}
+
+	if err := h.nodeSync.RegisterNode(c.Request.Context(), req.NodeName, req.PublicIP, token); err != nil {
+		if errors.Is(err, nodesync.ErrNodeNotJoined) {
+			c.Error(apierror.New(http.StatusConflict, apierror.CodeNodeNotJoined, "node hasn't joined the cluster yet"))
+			return
+		}
+		if errors.Is(err, nodesync.ErrNodeNotNew) {
+			c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict, "only nodes that joined after the token was issued can be registered"))
+			return
+		}
+		log.Printf("failed to register node %s: %v", req.NodeName, err)
+		c.Error(apierror.Internal("failed to register node"))
+		return
+	}
+	log.Printf("node %s registered with token %s", req.NodeName, token.ID)
+
+	portMin, portMax, err := h.nodeSync.PortRange(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to get port range: %v", err)
+		c.Error(apierror.Internal("failed to register node"))
+		return
+	}
+	c.JSON(http.StatusOK, models.NodeRegistration{
+		NodeName:     req.NodeName,
+		PortRangeMin: portMin,
+		PortRangeMax: portMax,
+	})
+}
+
+// NodeReady is the agent's report that the port range is open and kubelet is healthy. The
+// node is synced right away and the token used up.
+func (h *NodeAgentHandler) NodeReady(c *gin.Context) {
+	token := c.MustGet("node_registration_token").(*models.NodeRegistrationToken)
+	if token.NodeName == nil {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict, "node isn't registered yet"))
+		return
+	}
+
+	node, err := h.nodeSync.SyncNode(c.Request.Context(), *token.NodeName)
+	if err != nil {
+		log.Printf("failed to sync node %s: %v", *token.NodeName, err)
+		c.Error(apierror.Internal("failed to sync node"))
+		return
+	}
+	if node == nil {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict, "node isn't labeled as a game server node"))
+		return
+	}
+
+	if err := h.db.CompleteNodeRegistrationToken(c.Request.Context(), token.ID); err != nil {
+		log.Printf("failed to complete node registration token %s: %v", token.ID, err)
+		c.Error(apierror.Internal("failed to sync node"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"node": gin.H{
+		"name":      node.Name,
+		"public_ip": node.PublicIP,
+		"is_active": node.IsActive,
+	}})
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// operationHistoryLimit is how many recent operations GET /servers/:id/operations returns
+const operationHistoryLimit = 20
+
+// User-facing operation failures. Underlying errors are logged, not stored.
+var (
+	errServerLookupFailed = errors.New("failed to look up server")
+	errStatusUpdateFailed = errors.New("failed to update server status")
+)
+
+// startOperation records a running operation for a server.
+// Tracking is best-effort: on failure it logs and returns nil, and the action proceeds untracked.
+func (h *ServerHandler) startOperation(ctx context.Context, serverID string, opType models.OperationType) *models.Operation {
+	op, err := h.db.CreateOperation(ctx, serverID, opType)
+	if err != nil {
+		log.Printf("failed to record %s operation for server %s: %v", opType, serverID, err)
+		return nil
+	}
+	return op
+}
+
+// finishOperation records the outcome of an operation. A non-nil err marks it failed
+// with err's message, which must be safe to show to users.
+func (h *ServerHandler) finishOperation(op *models.Operation, state models.OperationState, err error) {
+	if op == nil {
+		return
+	}
+
+	errorMessage := ""
+	if err != nil {
+		state = models.OperationStateFailed
+		errorMessage = err.Error()
+	}
+
+	if err := h.db.CompleteOperation(context.Background(), op.ID, state, errorMessage); err != nil {
+		log.Printf("failed to complete operation %s: %v", op.ID, err)
+	}
+}
+
+// ListOperations returns the most recent start/stop/restart/upgrade operations for a server
+func (h *ServerHandler) ListOperations(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	operations, err := h.db.ListServerOperations(c.Request.Context(), serverID, operationHistoryLimit)
+	if err != nil {
+		log.Printf("failed to list operations for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to list operations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"operations": operations})
+}
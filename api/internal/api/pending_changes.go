@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// GetPendingChanges compares the server's live deployment with the one a restart would create
+// from its current env overrides and the game catalog
+func (h *ServerHandler) GetPendingChanges(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	deployment, err := h.k8sClient.GetGameDeployment(c.Request.Context(), server.K8sNamespace(h.config.K8sNamespace), "server-"+serverID)
+	if err != nil {
+		log.Printf("failed to get deployment for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to get pending changes"))
+		return
+	}
+	if deployment == nil || len(deployment.Spec.Template.Spec.Containers) == 0 {
+		c.JSON(http.StatusOK, gin.H{"changes": models.PendingChanges{Spec: []models.SpecChange{}}})
+		return
+	}
+
+	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err != nil {
+		log.Printf("failed to load game catalog: %v", err)
+		c.Error(apierror.Internal("failed to load game catalog"))
+		return
+	}
+	gameConfig, err := h.serverGameConfig(c.Request.Context(), server, catalog)
+	if err != nil {
+		log.Printf("failed to get game config for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to load game catalog"))
+		return
+	}
+	planConfig, err := gameConfig.GetPlanConfig(string(server.Plan))
+	if err != nil {
+		log.Printf("failed to get plan config for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to load game catalog"))
+		return
+	}
+
+	if issues := gameConfig.PlanIssues(string(server.Game), string(server.Plan)); len(issues) > 0 {
+		log.Printf("invalid catalog entry for server %s: %s", serverID, issues[0])
+		c.Error(apierror.Internal("failed to load game catalog"))
+		return
+	}
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	desiredParams := desiredDeploymentParams(server, gameConfig, planConfig)
+
+	// Env the reconciler would set, minus the per-start vars
+	desiredEnv := desiredParams.Env
+	liveEnv := make(map[string]string, len(container.Env))
+	for _, env := range container.Env {
+		liveEnv[env.Name] = env.Value
+	}
+	for key := range k8s.PerStartEnvVars {
+		delete(desiredEnv, key)
+		delete(liveEnv, key)
+	}
+
+	changes := models.PendingChanges{
+		Deployed: true,
+		Env:      models.DiffEnv(liveEnv, desiredEnv),
+		Spec:     []models.SpecChange{},
+	}
+
+	if image := desiredParams.Image; image != container.Image {
+		changes.Spec = append(changes.Spec, models.SpecChange{Field: "image", From: container.Image, To: image})
+	}
+
+	desired := k8s.GameContainerResources(desiredParams)
+	changes.Spec = append(changes.Spec, diffResourceList("requests", container.Resources.Requests, desired.Requests)...)
+	changes.Spec = append(changes.Spec, diffResourceList("limits", container.Resources.Limits, desired.Limits)...)
+
+	if live := deployment.Spec.Template.Annotations[k8s.EgressBandwidthAnnotation]; live != planConfig.EgressBandwidth {
+		changes.Spec = append(changes.Spec, models.SpecChange{Field: "egress_bandwidth", From: live, To: planConfig.EgressBandwidth})
+	}
+
+	changes.RestartRequired = !changes.Env.Empty() || len(changes.Spec) > 0
+
+	c.JSON(http.StatusOK, gin.H{"changes": changes})
+}
+
+// diffResourceList returns the resources whose quantity differs between live and desired
+func diffResourceList(prefix string, live, desired corev1.ResourceList) []models.SpecChange {
+	var changes []models.SpecChange
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, k8s.GPUResourceName} {
+		liveQty, liveOK := live[name]
+		desiredQty, desiredOK := desired[name]
+		if liveOK == desiredOK && (!liveOK || liveQty.Cmp(desiredQty) == 0) {
+			continue
+		}
+
+		change := models.SpecChange{Field: prefix + "." + string(name)}
+		if liveOK {
+			change.From = liveQty.String()
+		}
+		if desiredOK {
+			change.To = desiredQty.String()
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// desiredDeploymentParams returns the parts of the deployment a restart would create that
+// k8s.TemplateHash covers, from the server's current env overrides and the game catalog
+func desiredDeploymentParams(server *models.Server, gameConfig *k8s.GameConfig, planConfig *k8s.PlanConfig) k8s.DeploymentParams {
+	env := k8s.MergeEnvVars(gameConfig.Env, planConfig.Env, server.EnvOverrides)
+	for key, value := range gameConfig.SupervisorEnv() {
+		env[key] = value
+	}
+
+	cpuMillicores, memBytes := gameConfig.ServerResources(planConfig)
+	return k8s.DeploymentParams{
+		Image:           gameConfig.ContainerImage(),
+		Env:             env,
+		EgressBandwidth: planConfig.EgressBandwidth,
+		CPURequest:      fmt.Sprintf("%dm", cpuMillicores),
+		MemRequest:      fmt.Sprintf("%d", memBytes),
+		GPUs:            planConfig.GPU,
+		Guaranteed:      planConfig.Performance,
+		PinCPUs:         planConfig.Performance && planConfig.PinCPUs,
+	}
+}
+
+// restartRequired reports whether the server's deployment was created from a different image,
+// env or resources than a restart would use now, by comparing template hashes. Servers without
+// a deployment, or with one created before hashes were recorded, never require a restart.
+func (h *ServerHandler) restartRequired(ctx context.Context, server *models.Server) (bool, error) {
+	deployment, err := h.k8sClient.GetGameDeployment(ctx, server.K8sNamespace(h.config.K8sNamespace), "server-"+server.ID.String())
+	if err != nil {
+		return false, err
+	}
+	if deployment == nil || k8s.DeploymentTemplateHash(deployment) == "" {
+		return false, nil
+	}
+
+	catalog, err := h.k8sClient.LoadGameCatalog(ctx, h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err != nil {
+		return false, err
+	}
+	gameConfig, err := h.serverGameConfig(ctx, server, catalog)
+	if err != nil {
+		return false, err
+	}
+	planConfig, err := gameConfig.GetPlanConfig(string(server.Plan))
+	if err != nil {
+		return false, err
+	}
+	if issues := gameConfig.PlanIssues(string(server.Game), string(server.Plan)); len(issues) > 0 {
+		return false, fmt.Errorf("invalid catalog entry: %s", issues[0])
+	}
+
+	desired := k8s.TemplateHash(desiredDeploymentParams(server, gameConfig, planConfig))
+	return k8s.DeploymentTemplateHash(deployment) != desired, nil
+}
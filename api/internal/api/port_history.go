@@ -0,0 +1,30 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// ListPortHistory returns the ports servers were allocated and released, with why, newest
+// first. Narrowed by ?server_id, ?node and ?port, e.g. to explain a server's port changing
+// or who held a port before.
+func (h *AdminHandler) ListPortHistory(c *gin.Context) {
+	var filter models.PortHistoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	events, err := h.db.ListPortAllocationHistory(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("failed to list port allocation history: %v", err)
+		c.Error(apierror.Internal("failed to list port history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": events})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+)
+
+// CheckPortAvailability reports whether a preferred port number is free for the game port of
+// a new server on the given game and plan
+func (h *ServerHandler) CheckPortAvailability(c *gin.Context) {
+	var query models.PortAvailabilityQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.K8sGameCatalogName)
+	if err != nil {
+		log.Printf("failed to load game catalog: %v", err)
+		c.Error(apierror.Internal("failed to load game configuration"))
+		return
+	}
+	gameConfig, err := catalog.GetGameConfig(query.Game)
+	if err != nil {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidGameOrPlan, err.Error()))
+		return
+	}
+	planConfig, err := gameConfig.GetPlanConfig(query.Plan)
+	if err != nil {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidGameOrPlan, err.Error()))
+		return
+	}
+
+	portReqs, resourceReq := portalloc.Requirements(gameConfig, planConfig, query.Plan, h.config.ServerNamespaces(query.Plan))
+	if query.Game == string(models.GameCustom) {
+		if query.Protocol == "" {
+			c.Error(apierror.BadRequest("protocol is required for custom games"))
+			return
+		}
+		portReqs = []portalloc.PortRequirement{{Name: "game", Protocol: query.Protocol}}
+	}
+
+	available, err := h.preferredPortAvailable(c.Request.Context(), planConfig, query.Port, portReqs, resourceReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"port": query.Port, "available": available})
+}
+
+// checkPreferredPort sets the preferred port number of a new server's game port, after
+// checking its plan has vanity ports and the number is free
+func (h *ServerHandler) checkPreferredPort(ctx context.Context, plan *k8s.PlanConfig, port int, portReqs []portalloc.PortRequirement, resourceReq *portalloc.ResourceRequirement) error {
+	available, err := h.preferredPortAvailable(ctx, plan, port, portReqs, resourceReq)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return apierror.New(http.StatusConflict, apierror.CodePortUnavailable, "preferred port is not available")
+	}
+	portReqs[0].Preferred = port
+	return nil
+}
+
+// preferredPortAvailable reports whether port is free for the first of portReqs, the game
+// port, on a node the server could be placed on
+func (h *ServerHandler) preferredPortAvailable(ctx context.Context, plan *k8s.PlanConfig, port int, portReqs []portalloc.PortRequirement, resourceReq *portalloc.ResourceRequirement) (bool, error) {
+	if !plan.VanityPorts {
+		return false, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "plan does not include a preferred port")
+	}
+	if len(portReqs) == 0 {
+		return false, apierror.BadRequest("game has no ports")
+	}
+
+	reqs := append([]portalloc.PortRequirement(nil), portReqs...)
+	reqs[0].Preferred = port
+	available, err := h.portAllocService.PreferredPortAvailable(ctx, reqs, resourceReq)
+	if err != nil {
+		log.Printf("failed to check preferred port %d: %v", port, err)
+		return false, apierror.Internal("failed to check port availability")
+	}
+	return available, nil
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/services/querycache"
+)
+
+// queryCacheControl lets Cloudflare and browsers absorb repeated queries too
+const queryCacheControl = "public, max-age=15"
+
+// QueryHandler serves public server queries (online, player count) from the query cache
+type QueryHandler struct {
+	cache *querycache.Cache
+}
+
+func NewQueryHandler(cache *querycache.Cache) *QueryHandler {
+	return &QueryHandler{cache: cache}
+}
+
+// GetServerQuery returns whether the server with a subdomain is online and how many players
+// are connected
+func (h *QueryHandler) GetServerQuery(c *gin.Context) {
+	query, err := h.cache.Get(c.Request.Context(), c.Param("subdomain"))
+	if err != nil {
+		log.Printf("failed to query server: %v", err)
+		c.Error(apierror.Internal("failed to query server"))
+		return
+	}
+	if query == nil {
+		c.Header("Cache-Control", queryCacheControl)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	c.Header("Cache-Control", queryCacheControl)
+	c.JSON(http.StatusOK, query)
+}
+
+// GetServerQueryBadge returns the server's status in the shields.io endpoint badge format
+func (h *QueryHandler) GetServerQueryBadge(c *gin.Context) {
+	query, err := h.cache.Get(c.Request.Context(), c.Param("subdomain"))
+	if err != nil {
+		log.Printf("failed to query server: %v", err)
+		c.Error(apierror.Internal("failed to query server"))
+		return
+	}
+
+	message, color := "unknown", "lightgrey"
+	switch {
+	case query == nil:
+	case !query.Online:
+		message, color = "offline", "red"
+	case query.Players != nil:
+		message, color = fmt.Sprintf("%d online", *query.Players), "brightgreen"
+	default:
+		message, color = "online", "brightgreen"
+	}
+
+	label := "server"
+	if query != nil {
+		label = query.Name
+	}
+
+	c.Header("Cache-Control", queryCacheControl)
+	c.JSON(http.StatusOK, gin.H{
+		"schemaVersion": 1,
+		"label":         label,
+		"message":       message,
+		"color":         color,
+	})
+}
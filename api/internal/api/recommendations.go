@@ -0,0 +1,55 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// GetRecommendations returns the plan changes recommended from the server's CPU and memory
+// usage over the last week. The list is empty if its plan fits.
+func (h *ServerHandler) GetRecommendations(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil || server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	rec, err := h.db.GetPlanRecommendation(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get plan recommendation for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to get recommendations"))
+		return
+	}
+
+	recommendations := []models.PlanRecommendation{}
+	// A recommendation for a plan the server already left is outdated
+	if rec != nil && rec.CurrentPlan == server.Plan {
+		recommendations = append(recommendations, *rec)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recommendations": recommendations})
+}
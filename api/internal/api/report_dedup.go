@@ -0,0 +1,70 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// reportDedup remembers the last report of each kind (status, heartbeat) a server's
+// supervisor sent, so identical reports repeated within the window are dropped before
+// they reach the database
+type reportDedup struct {
+	window time.Duration // 0 disables deduplication
+
+	mu      sync.Mutex
+	last    map[string]dedupEntry
+	sweptAt time.Time
+}
+
+type dedupEntry struct {
+	hash [sha256.Size]byte
+	at   time.Time
+}
+
+func newReportDedup(window time.Duration) *reportDedup {
+	return &reportDedup{window: window, last: make(map[string]dedupEntry)}
+}
+
+// reportHash hashes a bound request body, so equal reports hash alike however they were
+// encoded
+func reportHash(report any) [sha256.Size]byte {
+	body, _ := json.Marshal(report)
+	return sha256.Sum256(body)
+}
+
+// seen reports whether the server's last remembered report of this kind was identical and
+// within the window
+func (d *reportDedup) seen(serverID, kind string, hash [sha256.Size]byte) bool {
+	if d.window <= 0 {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, ok := d.last[serverID+"/"+kind]
+	return ok && entry.hash == hash && time.Since(entry.at) < d.window
+}
+
+// remember records a report once it was handled; failed reports aren't remembered, so
+// their retries go through
+func (d *reportDedup) remember(serverID, kind string, hash [sha256.Size]byte) {
+	if d.window <= 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(d.sweptAt) > time.Minute {
+		for key, entry := range d.last {
+			if now.Sub(entry.at) >= d.window {
+				delete(d.last, key)
+			}
+		}
+		d.sweptAt = now
+	}
+	d.last[serverID+"/"+kind] = dedupEntry{hash: hash, at: now}
+}
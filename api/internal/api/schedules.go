@@ -0,0 +1,166 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/scheduler"
+)
+
+// maxSchedulesPerServer caps the scheduled tasks a server can have
+const maxSchedulesPerServer = 10
+
+// ListSchedules returns the server's scheduled tasks with their next and last runs
+func (h *ServerHandler) ListSchedules(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	schedules, err := h.db.ListSchedules(c.Request.Context(), server.ID.String())
+	if err != nil {
+		log.Printf("failed to list schedules of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to list schedules"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules})
+}
+
+// CreateSchedule adds a task that restarts the server, sends a console command or takes a
+// backup at the times of a cron expression. The scheduler service runs it while the
+// server is running.
+func (h *ServerHandler) CreateSchedule(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	schedule := bindSchedule(c)
+	if schedule == nil {
+		return
+	}
+	schedule.ServerID = server.ID
+
+	ctx := c.Request.Context()
+	existing, err := h.db.ListSchedules(ctx, server.ID.String())
+	if err != nil {
+		log.Printf("failed to list schedules of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to create schedule"))
+		return
+	}
+	if len(existing) >= maxSchedulesPerServer {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeScheduleLimit,
+			"server already has the maximum number of schedules").
+			WithDetails(gin.H{"limit": maxSchedulesPerServer}))
+		return
+	}
+
+	created, err := h.db.CreateSchedule(ctx, schedule)
+	if err != nil {
+		log.Printf("failed to create schedule for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to create schedule"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"schedule": created})
+}
+
+// UpdateSchedule replaces one of the server's scheduled tasks, planning its next run anew
+func (h *ServerHandler) UpdateSchedule(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	scheduleID, err := uuid.Parse(c.Param("scheduleId"))
+	if err != nil {
+		c.Error(apierror.ErrScheduleNotFound)
+		return
+	}
+
+	schedule := bindSchedule(c)
+	if schedule == nil {
+		return
+	}
+	schedule.ID = scheduleID
+	schedule.ServerID = server.ID
+
+	updated, err := h.db.UpdateSchedule(c.Request.Context(), schedule)
+	if err != nil {
+		log.Printf("failed to update schedule %s: %v", scheduleID, err)
+		c.Error(apierror.Internal("failed to update schedule"))
+		return
+	}
+	if updated == nil {
+		c.Error(apierror.ErrScheduleNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedule": updated})
+}
+
+// DeleteSchedule removes one of the server's scheduled tasks
+func (h *ServerHandler) DeleteSchedule(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	scheduleID, err := uuid.Parse(c.Param("scheduleId"))
+	if err != nil {
+		c.Error(apierror.ErrScheduleNotFound)
+		return
+	}
+
+	deleted, err := h.db.DeleteSchedule(c.Request.Context(), server.ID.String(), scheduleID.String())
+	if err != nil {
+		log.Printf("failed to delete schedule %s: %v", scheduleID, err)
+		c.Error(apierror.Internal("failed to delete schedule"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.ErrScheduleNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted"})
+}
+
+// bindSchedule parses a schedule request and plans the task's first run. Otherwise it sets
+// the error and returns nil.
+func bindSchedule(c *gin.Context) *models.ServerSchedule {
+	var req models.ServerScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return nil
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	nextRunAt, err := scheduler.Plan(req.Cron, req.Timezone, time.Now())
+	if err != nil {
+		c.Error(apierror.BadRequest(err.Error()))
+		return nil
+	}
+
+	schedule := &models.ServerSchedule{
+		Name:      req.Name,
+		Cron:      req.Cron,
+		Timezone:  req.Timezone,
+		Action:    models.ScheduleAction(req.Action),
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		NextRunAt: nextRunAt,
+	}
+	// Only console commands carry a payload
+	if schedule.Action == models.ScheduleCommand {
+		schedule.Payload = &req.Payload
+	}
+	return schedule
+}
@@ -2,24 +2,36 @@ package api
 
 import (
 	"bufio"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"k8s.io/apimachinery/pkg/api/resource"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/mooncorn/gshub/api/config"
 	"github.com/mooncorn/gshub/api/internal/api/middleware"
 	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/i18n"
 	"github.com/mooncorn/gshub/api/internal/models"
 	"github.com/mooncorn/gshub/api/internal/services/broadcast"
 	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/modrinth"
 	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"github.com/mooncorn/gshub/api/internal/services/steam"
 	stripeservice "github.com/mooncorn/gshub/api/internal/services/stripe"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -31,25 +43,33 @@ type ServerHandler struct {
 	config           *config.Config
 	stripeService    *stripeservice.Service
 	portAllocService *portalloc.Service
+	machine          *serverstate.Machine
 	hub              *broadcast.Hub
+	mods             *modrinth.Client
+	steam            *steam.WebAPI // nil without a Steam Web API key
 }
 
-func NewServerHandler(db *database.DB, k8sClient *k8s.Client, cfg *config.Config, stripeSvc *stripeservice.Service, portAllocSvc *portalloc.Service, hub *broadcast.Hub) *ServerHandler {
+func NewServerHandler(db *database.DB, k8sClient *k8s.Client, cfg *config.Config, stripeSvc *stripeservice.Service, portAllocSvc *portalloc.Service, machine *serverstate.Machine, hub *broadcast.Hub) *ServerHandler {
 	return &ServerHandler{
 		db:               db,
 		k8sClient:        k8sClient,
 		config:           cfg,
 		stripeService:    stripeSvc,
 		portAllocService: portAllocSvc,
+		machine:          machine,
 		hub:              hub,
+		mods:             modrinth.NewClient(cfg.ModrinthAPIURL),
+		steam:            steam.NewWebAPI(cfg.SteamWebAPIKey),
 	}
 }
 
-// CheckoutResponse is the response for creating a checkout session
+// CheckoutResponse is the response for creating a checkout session. When paid with a saved
+// card the server is created immediately, so ServerID is set instead of the session.
 type CheckoutResponse struct {
-	SessionID        string `json:"session_id"`
-	CheckoutURL      string `json:"checkout_url"`
+	SessionID        string `json:"session_id,omitempty"`
+	CheckoutURL      string `json:"checkout_url,omitempty"`
 	PendingRequestID string `json:"pending_request_id"`
+	ServerID         string `json:"server_id,omitempty"`
 }
 
 // CheckoutSuccessResponse is the response for confirming checkout
@@ -62,33 +82,41 @@ type CheckoutSuccessResponse struct {
 func (h *ServerHandler) CreateCheckoutSession(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	var req models.CreateServerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
+	h.startCheckout(c, userID, req, nil, nil, nil)
+}
+
+// startCheckout validates a server request, records it as pending and starts paying for it,
+// either with the saved card or through a Checkout session. envOverrides, if any, are applied
+// to the server once it's created, cloneSource's data, if set, is copied into it, and
+// template's mods and scheduled tasks, if set, are added to it.
+func (h *ServerHandler) startCheckout(c *gin.Context, userID uuid.UUID, req models.CreateServerRequest, envOverrides map[string]string, cloneSource *uuid.UUID, template *models.ServerTemplate) {
 	// Check if subdomain already exists
 	// TODO: Consider reserving subdomains for pending requests as well
 	exists, err := h.db.SubdomainExists(c.Request.Context(), req.Subdomain)
 	if err != nil {
 		log.Printf("failed to check subdomain: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check subdomain"})
+		c.Error(apierror.Internal("failed to check subdomain"))
 		return
 	}
 	if exists {
 		log.Printf("subdomain already taken: %s", req.Subdomain)
-		c.JSON(http.StatusConflict, gin.H{"error": "subdomain already taken"})
+		c.Error(apierror.ErrSubdomainTaken)
 		return
 	}
 
@@ -96,7 +124,17 @@ func (h *ServerHandler) CreateCheckoutSession(c *gin.Context) {
 	priceID, err := h.config.GetPriceID(string(req.Game), string(req.Plan))
 	if err != nil {
 		log.Printf("invalid game or plan: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidGameOrPlan, err.Error()))
+		return
+	}
+
+	if err := checkAccountFlagged(c.Request.Context(), h.db, userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := checkSpendLimit(c.Request.Context(), h.db, h.stripeService, userID, priceID); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -104,50 +142,69 @@ func (h *ServerHandler) CreateCheckoutSession(c *gin.Context) {
 	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.K8sGameCatalogName)
 	if err != nil {
 		log.Printf("failed to load game catalog: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load game configuration"})
+		c.Error(apierror.Internal("failed to load game configuration"))
 		return
 	}
 
 	gameConfig, err := catalog.GetGameConfig(req.Game)
 	if err != nil {
 		log.Printf("game not found in catalog: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidGameOrPlan, err.Error()))
 		return
 	}
 
 	planConfig, err := gameConfig.GetPlanConfig(req.Plan)
 	if err != nil {
 		log.Printf("plan not found in catalog: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidGameOrPlan, err.Error()))
 		return
 	}
 
-	// Build port requirements from game config
-	portReqs := make([]portalloc.PortRequirement, len(gameConfig.Ports))
-	for i, p := range gameConfig.Ports {
-		portReqs[i] = portalloc.PortRequirement{Name: p.Name, Protocol: p.Protocol}
+	// Custom games supply their own image and ports
+	if req.Game == string(models.GameCustom) {
+		gameConfig, err = h.applyCustomGame(gameConfig, req.CustomGame, req.Plan)
+		if err != nil {
+			c.Error(err)
+			return
+		}
 	}
 
-	// Build resource requirements (with sidecar overhead)
-	cpuMillicores := parseCPUToMillicores(planConfig.CPU) + 100       // +100m for sidecar
-	memBytes := parseMemoryToBytes(planConfig.Memory) + 128*1024*1024 // +128Mi for sidecar
-	resourceReq := &portalloc.ResourceRequirement{
-		CPUMillicores: cpuMillicores,
-		MemoryBytes:   memBytes,
+	// Build port and resource requirements (with sidecar overhead) from game config
+	portReqs, resourceReq := portalloc.Requirements(gameConfig, planConfig, req.Plan, h.config.ServerNamespaces(req.Plan))
+
+	// Plans with vanity ports let the game port request its number
+	if req.PreferredPort != 0 {
+		if err := h.checkPreferredPort(c.Request.Context(), planConfig, req.PreferredPort, portReqs, resourceReq); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	// Capacity freed for the waitlist is left to whoever holds the offer
+	offerHeld, err := h.checkWaitlistOffer(c.Request.Context(), userID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if offerHeld {
+		c.Error(apierror.ErrCapacityUnavailable.WithDetails(&models.CapacityShortage{
+			Resource: models.CapacityWaitlist,
+			Waitlist: true,
+		}))
+		return
 	}
 
 	// Check capacity before proceeding to checkout
 	hasCapacity, err := h.portAllocService.HasCapacity(c.Request.Context(), portReqs, resourceReq)
 	if err != nil {
 		log.Printf("failed to check capacity: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check server availability"})
+		c.Error(apierror.Internal("failed to check server availability"))
 		return
 	}
 	if !hasCapacity {
 		log.Printf("no capacity available for game=%s plan=%s", req.Game, req.Plan)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "No server capacity available at this time. Please try again later.",
-		})
+		c.Error(h.capacityUnavailable(c.Request.Context(), req.Game, portReqs, resourceReq))
 		return
 	}
 
@@ -171,29 +228,91 @@ func (h *ServerHandler) CreateCheckoutSession(c *gin.Context) {
 	)
 	if err != nil {
 		log.Printf("failed to create pending request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create pending request"})
+		c.Error(apierror.Internal("failed to create pending request"))
 		return
 	}
 
+	// The user got their server, whether or not they waited for an offer
+	if err := h.db.FulfillCapacityWaitlist(c.Request.Context(), userID, req.Game, req.Plan); err != nil {
+		log.Printf("failed to fulfill capacity waitlist for user %s: %v", userID, err)
+	}
+
+	if req.Game == string(models.GameCustom) {
+		if err := h.db.SetPendingServerRequestCustomGame(c.Request.Context(), *pendingRequestID, req.CustomGame); err != nil {
+			log.Printf("failed to set pending request custom game: %v", err)
+			c.Error(apierror.Internal("failed to create pending request"))
+			return
+		}
+	}
+
+	if req.PreferredPort != 0 {
+		if err := h.db.SetPendingServerRequestPreferredPort(c.Request.Context(), *pendingRequestID, req.PreferredPort); err != nil {
+			log.Printf("failed to set pending request preferred port: %v", err)
+			c.Error(apierror.Internal("failed to create pending request"))
+			return
+		}
+	}
+
+	if len(envOverrides) > 0 {
+		if err := h.db.SetPendingServerRequestEnv(c.Request.Context(), *pendingRequestID, envOverrides); err != nil {
+			log.Printf("failed to set pending request env: %v", err)
+			c.Error(apierror.Internal("failed to create pending request"))
+			return
+		}
+	}
+
+	if cloneSource != nil {
+		if err := h.db.SetPendingServerRequestCloneSource(c.Request.Context(), *pendingRequestID, *cloneSource); err != nil {
+			log.Printf("failed to set pending request clone source: %v", err)
+			c.Error(apierror.Internal("failed to create pending request"))
+			return
+		}
+	}
+
+	if template != nil && (len(template.Mods) > 0 || len(template.Schedules) > 0) {
+		if err := h.db.SetPendingServerRequestTemplate(c.Request.Context(), *pendingRequestID, template.Mods, template.Schedules); err != nil {
+			log.Printf("failed to set pending request template: %v", err)
+			c.Error(apierror.Internal("failed to create pending request"))
+			return
+		}
+	}
+
 	// Get user email for Stripe
 	user, err := h.db.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		log.Printf("failed to get user email: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user email"})
+		c.Error(apierror.Internal("failed to get user email"))
 		return
 	}
 
+	// Returning customers can pay with their saved card and skip the Checkout redirect
+	if req.UseSavedCard {
+		server, err := h.stripeService.SubscribeWithSavedCard(c.Request.Context(), user, *pendingRequestID, priceID)
+		if err == nil {
+			c.JSON(http.StatusOK, CheckoutResponse{
+				PendingRequestID: pendingRequestID.String(),
+				ServerID:         server.ID.String(),
+			})
+			return
+		}
+		if !errors.Is(err, stripeservice.ErrSavedCardUnavailable) {
+			log.Printf("failed to subscribe with saved card: %v", err)
+			c.Error(apierror.Internal("failed to charge saved card"))
+			return
+		}
+		// Fall back to Checkout so the user can enter or confirm a card
+	}
+
 	// Create Stripe checkout session
 	sessionID, checkoutURL, err := h.stripeService.CreateCheckoutSession(
 		c.Request.Context(),
-		userID,
+		user,
 		*pendingRequestID,
 		priceID,
-		user.Email,
 	)
 	if err != nil {
 		log.Printf("failed to create checkout session: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create checkout session"})
+		c.Error(apierror.Internal("failed to create checkout session"))
 		return
 	}
 
@@ -201,7 +320,7 @@ func (h *ServerHandler) CreateCheckoutSession(c *gin.Context) {
 	err = h.db.UpdatePendingServerRequestWithSession(c.Request.Context(), *pendingRequestID, sessionID)
 	if err != nil {
 		log.Printf("failed to update pending request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update pending request"})
+		c.Error(apierror.Internal("failed to update pending request"))
 		return
 	}
 
@@ -216,20 +335,28 @@ func (h *ServerHandler) CreateCheckoutSession(c *gin.Context) {
 func (h *ServerHandler) ListServers(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	order := models.ServerSort(c.Query("sort"))
+	switch order {
+	case models.ServerSortDefault, models.ServerSortRecent, models.ServerSortFavorites:
+	default:
+		c.Error(apierror.BadRequest("sort must be recent or favorites"))
 		return
 	}
 
 	servers, err := h.db.ListServersByUser(c.Request.Context(), userID)
 	if err != nil {
 		log.Printf("failed to list servers: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list servers"})
+		c.Error(apierror.Internal("failed to list servers"))
 		return
 	}
 
@@ -237,8 +364,36 @@ func (h *ServerHandler) ListServers(c *gin.Context) {
 		servers = []models.Server{}
 	}
 
+	userServers, err := h.db.GetUserServers(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get favorites and recent servers: %v", err)
+		c.Error(apierror.Internal("failed to list servers"))
+		return
+	}
+	for i := range servers {
+		if us, ok := userServers[servers[i].ID]; ok {
+			servers[i].Favorite = us.Favorite
+			servers[i].LastAccessedAt = us.LastAccessedAt
+		}
+	}
+	sortServers(servers, order)
+
+	groups, err := h.db.ListServerGroups(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to list server groups: %v", err)
+		c.Error(apierror.Internal("failed to list servers"))
+		return
+	}
+	summarizeGroups(groups, servers)
+
+	lang := middleware.GetLanguage(c)
+	for i := range servers {
+		servers[i].StatusMessage = i18n.TPtr(lang, servers[i].StatusMessage)
+	}
+
 	c.JSON(http.StatusOK, models.ServerListResponse{
 		Servers: servers,
+		Groups:  groups,
 		Total:   len(servers),
 	})
 }
@@ -247,19 +402,19 @@ func (h *ServerHandler) ListServers(c *gin.Context) {
 func (h *ServerHandler) GetServer(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	serverID := c.Param("id")
 	if serverID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
+		c.Error(apierror.ErrServerIDRequired)
 		return
 	}
 
@@ -267,21 +422,23 @@ func (h *ServerHandler) GetServer(c *gin.Context) {
 	server, err := h.db.GetServerByIDWithDetails(c.Request.Context(), serverID)
 	if err != nil {
 		log.Printf("failed to get server: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	// Verify server belongs to user
 	if server.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	// Load game catalog to get default env
 	var gameConfigInfo *models.GameConfigInfo
-	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.K8sGameCatalogName)
+	var oomRecommendation *models.PlanUpgradeRecommendation
+	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
 	if err == nil {
 		if gameConfig, err := catalog.GetGameConfig(string(server.Game)); err == nil {
+			oomRecommendation = h.oomUpgradeRecommendation(c.Request.Context(), server, gameConfig)
 			if planConfig, err := gameConfig.GetPlanConfig(string(server.Plan)); err == nil {
 				// Merge game + plan defaults for display
 				defaultEnv := k8s.MergeEnvVars(gameConfig.Env, planConfig.Env, nil)
@@ -289,14 +446,62 @@ func (h *ServerHandler) GetServer(c *gin.Context) {
 				gameConfigInfo = &models.GameConfigInfo{
 					DefaultEnv:   defaultEnv,
 					EffectiveEnv: effectiveEnv,
+					LiveReload:   gameConfig.SupportsLiveReload(),
+				}
+				if gameConfig.Steam != nil && h.steam != nil {
+					gameConfigInfo.SteamTokenEnv = gameConfig.Steam.TokenEnv
 				}
 			}
 		}
 	}
 
+	if server.Game == models.GameCustom {
+		server.CustomGame, err = h.db.GetServerCustomGame(c.Request.Context(), serverID)
+		if err != nil {
+			log.Printf("failed to get custom game for server %s: %v", serverID, err)
+		}
+	}
+
+	if h.config.EdgeProxyEnabled() {
+		server.EdgePorts, err = h.db.GetServerEdgePorts(c.Request.Context(), serverID)
+		if err != nil {
+			log.Printf("failed to get edge ports for server %s: %v", serverID, err)
+		}
+		for i := range server.EdgePorts {
+			server.EdgePorts[i].Address = h.config.EdgeAddress
+		}
+	}
+
+	if server.Status == models.ServerStatusStarting {
+		phase, err := h.db.GetServerStartupPhase(c.Request.Context(), serverID)
+		if err != nil {
+			log.Printf("failed to get startup phase for server %s: %v", serverID, err)
+		} else if phase != "" {
+			server.StartupPhase = &phase
+		}
+	}
+
+	if server.Status == models.ServerStatusRunning || server.Status == models.ServerStatusStarting {
+		server.RestartRequired, err = h.restartRequired(c.Request.Context(), server)
+		if err != nil {
+			log.Printf("failed to check pending changes for server %s: %v", serverID, err)
+		}
+	}
+
+	// Opening a server's details counts as accessing it, for sorting by recent
+	if us, err := h.db.RecordServerAccess(c.Request.Context(), userID, server.ID); err != nil {
+		log.Printf("failed to record access to server %s: %v", serverID, err)
+	} else {
+		server.Favorite = us.Favorite
+		server.LastAccessedAt = us.LastAccessedAt
+	}
+
+	server.StatusMessage = i18n.TPtr(middleware.GetLanguage(c), server.StatusMessage)
+
 	c.JSON(http.StatusOK, gin.H{
-		"server":      server,
-		"game_config": gameConfigInfo,
+		"server":             server,
+		"game_config":        gameConfigInfo,
+		"oom_recommendation": oomRecommendation,
 	})
 }
 
@@ -304,62 +509,123 @@ func (h *ServerHandler) GetServer(c *gin.Context) {
 func (h *ServerHandler) UpdateServerEnv(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	serverID := c.Param("id")
 	if serverID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
+		c.Error(apierror.ErrServerIDRequired)
 		return
 	}
 
 	var req models.UpdateServerEnvRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.FromBindError(err))
 		return
 	}
 
 	// Get server and verify ownership
 	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	if server.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if !serverstate.CanEditEnv(server.Status) {
+		c.Error(apierror.InvalidServerState("server is being deleted"))
 		return
 	}
 
 	// Validate env keys
 	for key, value := range req.EnvOverrides {
 		if key == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "empty environment variable key"})
+			c.Error(apierror.BadRequest("empty environment variable key"))
 			return
 		}
 		if len(key) > 256 || len(value) > 4096 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "environment variable too long"})
+			c.Error(apierror.BadRequest("environment variable too long"))
 			return
 		}
 	}
 
+	// Live reload needs a running supervisor and a game that can re-read its config;
+	// check before saving so a rejected request changes nothing
+	var reloadEnv map[string]string
+	if req.Reload {
+		if server.Status != models.ServerStatusRunning {
+			c.Error(apierror.InvalidServerState("server must be running to receive commands"))
+			return
+		}
+
+		catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+		if err != nil {
+			log.Printf("failed to load game catalog: %v", err)
+			c.Error(apierror.Internal("failed to load game catalog"))
+			return
+		}
+		gameConfig, err := catalog.GetGameConfig(string(server.Game))
+		if err != nil || !gameConfig.SupportsLiveReload() {
+			c.Error(apierror.ErrLiveReloadUnsupported)
+			return
+		}
+		planConfig, err := gameConfig.GetPlanConfig(string(server.Plan))
+		if err != nil {
+			log.Printf("failed to get plan config: %v", err)
+			c.Error(apierror.Internal("failed to load game catalog"))
+			return
+		}
+		reloadEnv = k8s.MergeEnvVars(gameConfig.Env, planConfig.Env, req.EnvOverrides)
+	}
+
 	// Update env overrides in database
-	if err := h.db.UpdateServerEnvOverrides(c.Request.Context(), serverID, req.EnvOverrides); err != nil {
+	if _, err := h.db.UpdateServerEnvWithRevision(c.Request.Context(), serverID, req.EnvOverrides, userID, nil); err != nil {
 		log.Printf("failed to update env overrides: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update environment variables"})
+		c.Error(apierror.Internal("failed to update environment variables"))
 		return
 	}
 
+	if req.Reload {
+		// The supervisor re-renders config templates with the new env and runs the
+		// game's reload command. The Deployment keeps the old env until the next restart.
+		payload, _ := json.Marshal(reloadEnv)
+		payloadStr := string(payload)
+		cmd, err := h.db.CreateServerCommand(c.Request.Context(), serverID, models.CommandReloadConfig, &payloadStr)
+		if err != nil {
+			log.Printf("failed to queue config reload for server %s: %v", serverID, err)
+			c.Error(apierror.Internal("failed to queue command"))
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":  "reloading",
+			"message": "Environment variables updated. Applying changes to the running server...",
+			"command": cmd,
+		})
+		return
+	}
+
+	server.EnvOverrides = req.EnvOverrides
+	restartRequired, err := h.restartRequired(c.Request.Context(), server)
+	if err != nil {
+		log.Printf("failed to check pending changes for server %s: %v", serverID, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "updated",
-		"message": "Environment variables updated. Restart server for changes to take effect.",
+		"status":           "updated",
+		"message":          "Environment variables updated.",
+		"restart_required": restartRequired,
 	})
 }
 
@@ -367,19 +633,19 @@ func (h *ServerHandler) UpdateServerEnv(c *gin.Context) {
 func (h *ServerHandler) StopServer(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	serverID := c.Param("id")
 	if serverID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
+		c.Error(apierror.ErrServerIDRequired)
 		return
 	}
 
@@ -387,27 +653,26 @@ func (h *ServerHandler) StopServer(c *gin.Context) {
 	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
 	if err != nil {
 		log.Printf("failed to get server: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	// Verify server belongs to user
 	if server.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	// STEP 1: Atomically transition to "stopping"
 	// This prevents race conditions with concurrent stops or start-after-stop
-	transitioned, err := h.db.TransitionServerStatusFrom(
-		c.Request.Context(), serverID,
-		[]models.ServerStatus{models.ServerStatusRunning, models.ServerStatusPending, models.ServerStatusStarting},
-		models.ServerStatusStopping,
-		"Stopping server...",
-	)
+	transitioned, err := h.machine.Transition(c.Request.Context(), server, serverstate.Request{
+		From:    serverstate.StopFrom,
+		To:      models.ServerStatusStopping,
+		Message: "Stopping server...",
+	})
 	if err != nil {
 		log.Printf("failed to transition to stopping: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		c.Error(apierror.Internal("database error"))
 		return
 	}
 	if !transitioned {
@@ -416,34 +681,35 @@ func (h *ServerHandler) StopServer(c *gin.Context) {
 			c.JSON(http.StatusAccepted, gin.H{"status": "stopping", "message": "stop already in progress"})
 			return
 		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server cannot be stopped from current state"})
+		c.Error(apierror.InvalidServerState("server cannot be stopped from current state"))
 		return
 	}
 
-	// STEP 2: Fire-and-forget: trigger K8s deletion immediately
+	// STEP 2: Trigger K8s deletion in the background, tracked as an operation
 	// Reconciler will confirm completion and transition to stopped
-	go h.triggerServerStop(serverID)
+	op := h.startOperation(c.Request.Context(), serverID, models.OperationStop)
+	go h.triggerServerStop(serverID, op)
 
-	c.JSON(http.StatusAccepted, gin.H{"status": "stopping", "message": "server is stopping"})
+	c.JSON(http.StatusAccepted, gin.H{"status": "stopping", "message": "server is stopping", "operation": op})
 }
 
 // StartServer starts a stopped game server by setting status to pending
 func (h *ServerHandler) StartServer(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	serverID := c.Param("id")
 	if serverID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
+		c.Error(apierror.ErrServerIDRequired)
 		return
 	}
 
@@ -451,59 +717,85 @@ func (h *ServerHandler) StartServer(c *gin.Context) {
 	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
 	if err != nil {
 		log.Printf("failed to get server: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	// Verify server belongs to user
 	if server.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
-	// Atomically transition to pending (only from stopped/failed)
-	transitioned, err := h.db.TransitionServerStatusFrom(
-		c.Request.Context(), serverID,
-		[]models.ServerStatus{models.ServerStatusStopped, models.ServerStatusFailed},
-		models.ServerStatusPending,
-		"Starting server...",
-	)
+	if server.Status == models.ServerStatusSuspended {
+		c.Error(apierror.ErrServerSuspended)
+		return
+	}
+
+	// Coalesce with a start that's already in progress instead of failing
+	if server.Status == models.ServerStatusPending || server.Status == models.ServerStatusStarting {
+		c.JSON(http.StatusAccepted, gin.H{"status": "starting", "message": "start already in progress"})
+		return
+	}
+
+	// Hold the server lock, so concurrent starts can't both pass the restart budget check
+	var exhausted, transitioned bool
+	err = h.db.WithServerLock(c.Request.Context(), serverID, func() error {
+		if exhausted = h.restartBudgetExhausted(c, serverID); exhausted {
+			return nil
+		}
+
+		// Atomically transition to pending (only from stopped/failed)
+		transitioned, err = h.machine.Transition(c.Request.Context(), server, serverstate.Request{
+			From:    serverstate.StartFrom,
+			To:      models.ServerStatusPending,
+			Message: "Starting server...",
+		})
+		if err == nil && transitioned {
+			h.chargeRestartBudget(c.Request.Context(), serverID)
+		}
+		return err
+	})
+	if exhausted {
+		return
+	}
 	if err != nil {
 		log.Printf("failed to transition to pending: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		c.Error(apierror.Internal("database error"))
 		return
 	}
 	if !transitioned {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server cannot be started from current state"})
+		c.Error(apierror.InvalidServerState("server cannot be started from current state"))
 		return
 	}
 
-	// Fire-and-forget: trigger K8s resource creation immediately
+	// Trigger K8s resource creation in the background, tracked as an operation
 	// Reconciler will handle status transitions and retries if this fails
-	go h.triggerServerStart(server)
+	op := h.startOperation(c.Request.Context(), serverID, models.OperationStart)
+	go h.triggerServerStart(server, op)
 
-	c.JSON(http.StatusAccepted, gin.H{"status": "starting", "message": "server is starting"})
+	c.JSON(http.StatusAccepted, gin.H{"status": "starting", "message": "server is starting", "operation": op})
 }
 
 // RestartServer restarts a server with updated environment variables.
-// This deletes the deployment and transitions to pending so the reconciler
-// creates a new deployment with the latest env vars from the database.
+// This transitions to pending so the reconciler updates the deployment with the
+// latest env vars from the database, which rolls its pod.
 func (h *ServerHandler) RestartServer(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	serverID := c.Param("id")
 	if serverID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
+		c.Error(apierror.ErrServerIDRequired)
 		return
 	}
 
@@ -511,126 +803,243 @@ func (h *ServerHandler) RestartServer(c *gin.Context) {
 	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
 	if err != nil {
 		log.Printf("failed to get server: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	// Verify server belongs to user
 	if server.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
-	// Only restart from running or stopped states
-	if server.Status != models.ServerStatusRunning && server.Status != models.ServerStatusStopped {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server must be running or stopped to restart"})
+	if server.Status == models.ServerStatusSuspended {
+		c.Error(apierror.ErrServerSuspended)
 		return
 	}
 
-	// Delete deployment (keeps PVC with data intact)
-	deployName := "server-" + serverID
-	if err := h.k8sClient.DeleteGameDeployment(c.Request.Context(), h.config.K8sNamespace, deployName); err != nil {
-		log.Printf("RestartServer: failed to delete deployment for server %s: %v", serverID, err)
-		// Continue anyway - deployment might not exist
+	// Coalesce with a restart/start that's already in progress instead of failing
+	if server.Status == models.ServerStatusPending || server.Status == models.ServerStatusStarting {
+		c.JSON(http.StatusAccepted, gin.H{"status": "restarting", "message": "restart already in progress"})
+		return
 	}
 
-	// Release port allocation (will be reallocated on next reconcile)
-	if err := h.portAllocService.ReleasePorts(c.Request.Context(), server.ID); err != nil {
-		log.Printf("RestartServer: failed to release ports for server %s: %v", serverID, err)
-		// Continue anyway
+	// Only restart from running or stopped states
+	if !serverstate.In(server.Status, serverstate.RestartFrom) {
+		c.Error(apierror.InvalidServerState("server must be running or stopped to restart"))
+		return
 	}
 
-	// Transition to pending - reconciler creates new deployment with updated env
-	transitioned, err := h.db.TransitionServerStatusFrom(
-		c.Request.Context(), serverID,
-		[]models.ServerStatus{models.ServerStatusRunning, models.ServerStatusStopped},
-		models.ServerStatusPending,
-		"Restarting server with updated configuration...",
-	)
+	if h.restartBudgetExhausted(c, serverID) {
+		return
+	}
+
+	op := h.startOperation(c.Request.Context(), serverID, models.OperationRestart)
+
+	// Hold the server lock, so a concurrent stop or restart can't interleave with the transition
+	var exhausted, transitioned bool
+	err = h.db.WithServerLock(c.Request.Context(), serverID, func() error {
+		// Re-check under the lock - another request may have changed the status or used
+		// up the budget
+		current, err := h.db.GetServerByID(c.Request.Context(), serverID)
+		if err != nil {
+			return err
+		}
+		if !serverstate.In(current.Status, serverstate.RestartFrom) {
+			return nil
+		}
+		if exhausted = h.restartBudgetExhausted(c, serverID); exhausted {
+			return nil
+		}
+
+		// Transition to pending - the reconciler updates the existing deployment with the
+		// current env, and the deployment controller replaces its pod. The server keeps its
+		// ports, and its data stays in the PVC.
+		transitioned, err = h.machine.Transition(c.Request.Context(), current, serverstate.Request{
+			From:    serverstate.RestartFrom,
+			To:      models.ServerStatusPending,
+			Message: "Restarting server with updated configuration...",
+		})
+		if err == nil && transitioned {
+			h.chargeRestartBudget(c.Request.Context(), serverID)
+		}
+		return err
+	})
+	if exhausted {
+		h.finishOperation(op, models.OperationStateSuperseded, nil)
+		return
+	}
 	if err != nil {
-		log.Printf("failed to transition to pending: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		log.Printf("failed to restart server %s: %v", serverID, err)
+		h.finishOperation(op, models.OperationStateFailed, errStatusUpdateFailed)
+		c.Error(apierror.Internal("database error"))
 		return
 	}
 	if !transitioned {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server cannot be restarted from current state"})
+		h.finishOperation(op, models.OperationStateSuperseded, nil)
+		c.Error(apierror.InvalidServerState("server cannot be restarted from current state"))
 		return
 	}
+	h.finishOperation(op, models.OperationStateSucceeded, nil)
 
-	// Broadcast status update
-	h.hub.Publish(server.UserID, broadcast.StatusEvent{
-		ServerID:  serverID,
-		Status:    string(models.ServerStatusPending),
-		Timestamp: time.Now().UTC(),
-	})
+	c.JSON(http.StatusAccepted, gin.H{"status": "restarting", "message": "server is restarting", "operation": op})
+}
+
+// restartBudgetExhausted checks a start/restart against the server's restart budget. If the
+// budget is exhausted it responds with a cool-down error and returns true. The budget is
+// only charged, through chargeRestartBudget, once the start/restart has been made.
+func (h *ServerHandler) restartBudgetExhausted(c *gin.Context, serverID string) bool {
+	if h.config.RestartBudget <= 0 {
+		return false
+	}
+
+	retryAfter, err := h.db.StartBudgetRetryAfter(c.Request.Context(), serverID,
+		h.config.RestartBudget, h.config.RestartBudgetWindow)
+	if err != nil {
+		// Fail open - the budget is a safety net, not a reason to block users
+		log.Printf("failed to check restart budget for server %s: %v", serverID, err)
+		return false
+	}
+	if retryAfter <= 0 {
+		return false
+	}
+
+	retryAfterSeconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfterSeconds))
+	c.Error(apierror.RestartCooldown(retryAfterSeconds))
+	return true
+}
+
+// chargeRestartBudget records a start/restart that was made against the server's restart budget
+func (h *ServerHandler) chargeRestartBudget(ctx context.Context, serverID string) {
+	if h.config.RestartBudget <= 0 {
+		return
+	}
+	if err := h.db.ChargeStartBudget(ctx, serverID, h.config.RestartBudgetWindow); err != nil {
+		log.Printf("failed to charge restart budget for server %s: %v", serverID, err)
+	}
+}
+
+// triggerServerStart attempts to start a server and records the outcome on op.
+// It runs under the server lock and only acts if the server is still pending,
+// so a stop requested in the meantime wins instead of racing this start.
+func (h *ServerHandler) triggerServerStart(server *models.Server, op *models.Operation) {
+	ctx := context.Background()
+	serverID := server.ID.String()
 
-	c.JSON(http.StatusAccepted, gin.H{"status": "restarting", "message": "server is restarting"})
+	state := models.OperationStateSucceeded
+	err := h.db.WithServerLock(ctx, serverID, func() error {
+		current, err := h.db.GetServerByID(ctx, serverID)
+		if err != nil {
+			log.Printf("triggerServerStart: failed to get server %s: %v", serverID, err)
+			return errServerLookupFailed
+		}
+		if current.Status != models.ServerStatusPending {
+			log.Printf("triggerServerStart: server %s is %s, skipping superseded start", serverID, current.Status)
+			state = models.OperationStateSuperseded
+			return nil
+		}
+		return h.startServerLocked(ctx, server)
+	})
+	h.finishOperation(op, state, err)
 }
 
-// triggerServerStart attempts to start a server.
+// startServerLocked starts a pending server. Callers must hold the server lock.
 // If a deployment already exists, it scales it to 1 (fast restart).
 // Otherwise, it leaves the server in "pending" for the reconciler to create the deployment.
-func (h *ServerHandler) triggerServerStart(server *models.Server) {
-	ctx := context.Background()
+// Returned errors are safe to show to users; details are logged.
+func (h *ServerHandler) startServerLocked(ctx context.Context, server *models.Server) error {
 	serverID := server.ID.String()
 	deployName := "server-" + serverID
+	namespace := server.K8sNamespace(h.config.K8sNamespace)
 
 	// Check if deployment already exists (fast restart case)
-	exists, err := h.k8sClient.DeploymentExists(ctx, h.config.K8sNamespace, deployName)
+	exists, err := h.k8sClient.DeploymentExists(ctx, namespace, deployName)
 	if err != nil {
 		log.Printf("triggerServerStart: failed to check deployment existence for server %s: %v", serverID, err)
-		return // Reconciler will retry
+		return errors.New("failed to check server deployment") // Reconciler will retry
 	}
 
-	if exists {
+	// The reconciler waits for volume backups and restores before scaling up
+	busy, err := h.db.ServerVolumeBusy(ctx, serverID)
+	if err != nil {
+		log.Printf("triggerServerStart: failed to check volume backups for server %s: %v", serverID, err)
+		return errors.New("failed to check server volume") // Reconciler will retry
+	}
+
+	if exists && !busy {
 		// Fast path: Just scale up existing deployment
-		if err := h.k8sClient.ScaleGameDeployment(ctx, h.config.K8sNamespace, deployName, 1); err != nil {
+		if err := h.k8sClient.ScaleGameDeployment(ctx, namespace, deployName, 1); err != nil {
 			log.Printf("triggerServerStart: failed to scale deployment for server %s: %v", serverID, err)
-			return
+			return errors.New("failed to scale up server deployment")
 		}
 
 		// Transition to starting - supervisor will report running via internal API
-		transitioned, err := h.db.TransitionServerStatus(ctx, serverID,
-			models.ServerStatusPending, models.ServerStatusStarting,
-			"Starting game server...")
+		transitioned, err := h.machine.Transition(ctx, server, serverstate.Request{
+			From:    []models.ServerStatus{models.ServerStatusPending},
+			To:      models.ServerStatusStarting,
+			Message: "Starting game server...",
+		})
 		if err != nil {
 			log.Printf("triggerServerStart: failed to transition to starting for server %s: %v", serverID, err)
-			return
+			return errStatusUpdateFailed
 		}
 		if transitioned {
 			log.Printf("triggerServerStart: scaled deployment to 1 for server %s (fast restart)", serverID)
-
-			// Broadcast status update
-			h.hub.Publish(server.UserID, broadcast.StatusEvent{
-				ServerID:  serverID,
-				Status:    string(models.ServerStatusStarting),
-				Timestamp: time.Now().UTC(),
-			})
 		}
-		return
+		return nil
 	}
 
-	// Slow path: No deployment exists, reconciler will create one
+	// Slow path: No deployment exists or the volume is busy, reconciler will create or update it
 	// Just leave server in "pending" state for reconciler
+	if busy {
+		log.Printf("triggerServerStart: volume of server %s is being backed up or restored, reconciler will start it after", serverID)
+		return nil
+	}
 	log.Printf("triggerServerStart: no deployment exists for server %s, reconciler will create", serverID)
+	return nil
 }
 
-// triggerServerStop scales the deployment to 0 to stop the server.
+// triggerServerStop scales the deployment to 0 to stop the server and records the outcome on op.
+// It runs under the server lock and only acts if the server is still stopping,
+// so overlapping start/stop requests settle on the latest requested state.
+func (h *ServerHandler) triggerServerStop(serverID string, op *models.Operation) {
+	ctx := context.Background()
+
+	state := models.OperationStateSucceeded
+	err := h.db.WithServerLock(ctx, serverID, func() error {
+		current, err := h.db.GetServerByID(ctx, serverID)
+		if err != nil {
+			log.Printf("triggerServerStop: failed to get server %s: %v", serverID, err)
+			return errServerLookupFailed
+		}
+		if current.Status != models.ServerStatusStopping {
+			log.Printf("triggerServerStop: server %s is %s, skipping superseded stop", serverID, current.Status)
+			state = models.OperationStateSuperseded
+			return nil
+		}
+		return h.stopServerLocked(ctx, current)
+	})
+	h.finishOperation(op, state, err)
+}
+
+// stopServerLocked scales a stopping server's deployment to 0. Callers must hold the server lock.
 // The supervisor will receive SIGTERM and report "stopped" via internal API.
 // A fallback goroutine ensures the server is marked stopped if supervisor fails.
-func (h *ServerHandler) triggerServerStop(serverID string) {
-	ctx := context.Background()
+func (h *ServerHandler) stopServerLocked(ctx context.Context, server *models.Server) error {
+	serverID := server.ID.String()
 	deployName := "server-" + serverID
 
 	// Scale to 0 - supervisor receives SIGTERM and reports status via internal API
-	if err := h.k8sClient.ScaleGameDeployment(ctx, h.config.K8sNamespace, deployName, 0); err != nil {
+	if err := h.k8sClient.ScaleGameDeployment(ctx, server.K8sNamespace(h.config.K8sNamespace), deployName, 0); err != nil {
 		log.Printf("triggerServerStop: failed to scale deployment for server %s: %v", serverID, err)
-		return
+		return errors.New("failed to scale down server deployment")
 	}
 	log.Printf("triggerServerStop: scaled deployment to 0 for server %s", serverID)
 
 	// Start background fallback: mark as stopped if still "stopping" after timeout
 	go h.ensureStoppedState(serverID)
+	return nil
 }
 
 // ensureStoppedState is a fallback that marks server as stopped if supervisor
@@ -648,21 +1057,15 @@ func (h *ServerHandler) ensureStoppedState(serverID string) {
 	if server.Status == models.ServerStatusStopping {
 		// Verify deployment is actually scaled to 0
 		deployName := "server-" + serverID
-		deploy, err := h.k8sClient.GetGameDeployment(ctx, h.config.K8sNamespace, deployName)
+		deploy, err := h.k8sClient.GetGameDeployment(ctx, server.K8sNamespace(h.config.K8sNamespace), deployName)
 		if err != nil || deploy == nil || (deploy.Spec.Replicas != nil && *deploy.Spec.Replicas == 0) {
-			transitioned, _ := h.db.TransitionServerStatus(ctx, serverID,
-				models.ServerStatusStopping, models.ServerStatusStopped,
-				"Server stopped (fallback)")
+			transitioned, _ := h.machine.Transition(ctx, server, serverstate.Request{
+				From:    []models.ServerStatus{models.ServerStatusStopping},
+				To:      models.ServerStatusStopped,
+				Message: "Server stopped (fallback)",
+			})
 			if transitioned {
-				h.db.MarkServerStopped(ctx, serverID)
 				log.Printf("ensureStoppedState: fallback marked server %s as stopped", serverID)
-
-				// Broadcast status update
-				h.hub.Publish(server.UserID, broadcast.StatusEvent{
-					ServerID:  serverID,
-					Status:    string(models.ServerStatusStopped),
-					Timestamp: time.Now().UTC(),
-				})
 			}
 		}
 	}
@@ -674,7 +1077,7 @@ func (h *ServerHandler) HandleStripeWebhook(c *gin.Context) {
 	body, err := c.GetRawData()
 	if err != nil {
 		log.Printf("webhook_error=read_body error=%v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		c.Error(apierror.BadRequest("failed to read request body"))
 		return
 	}
 
@@ -682,14 +1085,14 @@ func (h *ServerHandler) HandleStripeWebhook(c *gin.Context) {
 	signature := c.GetHeader("Stripe-Signature")
 	if signature == "" {
 		log.Printf("webhook_error=missing_signature")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing signature header"})
+		c.Error(apierror.Unauthorized("missing signature header"))
 		return
 	}
 
 	event, err := h.stripeService.VerifyWebhookSignature(body, signature)
 	if err != nil {
 		log.Printf("webhook_error=invalid_signature error=%v", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		c.Error(apierror.Unauthorized("invalid signature"))
 		return
 	}
 
@@ -725,7 +1128,7 @@ func (h *ServerHandler) HandleStripeWebhook(c *gin.Context) {
 		}
 
 		log.Printf("webhook_error=processing_failed event_id=%s event_type=%s error=%v", event.ID, event.Type, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process webhook"})
+		c.Error(apierror.Internal("failed to process webhook"))
 		return
 	}
 
@@ -750,42 +1153,50 @@ func (h *ServerHandler) HandleStripeWebhook(c *gin.Context) {
 func (h *ServerHandler) StreamLogs(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
 		return
 	}
 
 	serverID := c.Param("id")
 	if serverID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "server ID required"})
+		c.Error(apierror.ErrServerIDRequired)
 		return
 	}
 
 	// Verify server ownership
 	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
 	if server.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		c.Error(apierror.ErrServerNotFound)
 		return
 	}
 
-	// Check server is in a state where logs are available
+	// Check server is in a state where logs are available. A failed server may still have
+	// a crash-looping pod whose previous container holds the crash output.
 	if server.Status != models.ServerStatusRunning &&
 		server.Status != models.ServerStatusStarting &&
-		server.Status != models.ServerStatusStopping {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":  "logs not available",
-			"reason": fmt.Sprintf("server is %s", server.Status),
-		})
+		server.Status != models.ServerStatusStopping &&
+		server.Status != models.ServerStatusFailed {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeLogsUnavailable, "logs not available").
+			WithDetails(gin.H{"reason": fmt.Sprintf("server is %s", server.Status)}))
+		return
+	}
+
+	// ?previous=true reads only the previous container instance, e.g. after a crash;
+	// grep, level and since filter the lines
+	query, apiErr := parseLogQuery(c)
+	if apiErr != nil {
+		c.Error(apiErr)
 		return
 	}
 
@@ -799,10 +1210,14 @@ func (h *ServerHandler) StreamLogs(c *gin.Context) {
 	ctx, cancel := context.WithCancel(c.Request.Context())
 	defer cancel()
 
-	// Start log streaming from K8s
-	// Find the pod by label since Deployment pods have generated suffixes
+	// Stream from every pod of the server: during a rolling restart the old pod is still
+	// terminating while the new one starts, and both have output worth seeing
+	namespace := server.K8sNamespace(h.config.K8sNamespace)
 	labelSelector := "server=" + serverID
-	pod, err := h.k8sClient.GetPodByLabel(ctx, h.config.K8sNamespace, labelSelector)
+	pods, err := h.k8sClient.ListPodsByLabel(ctx, namespace, labelSelector)
+	if err == nil && len(pods) == 0 {
+		err = fmt.Errorf("no pods found with label: %s", labelSelector)
+	}
 	if err != nil {
 		log.Printf("failed to find pod for server %s: %v", serverID, err)
 		c.SSEvent("error", gin.H{
@@ -813,69 +1228,97 @@ func (h *ServerHandler) StreamLogs(c *gin.Context) {
 		return
 	}
 
-	const tailLines int64 = 50
-	const containerName = "supervisor"
+	lines := make(chan podLogLine, 64)
+	done := make(chan podLogResult)
+	streaming := make(map[string]bool)
+	active := 0
 
-	logStream, err := h.k8sClient.StreamPodLogs(ctx, h.config.K8sNamespace, pod.Name, containerName, tailLines)
-	if err != nil {
-		log.Printf("failed to stream logs for server %s: %v", serverID, err)
-		c.SSEvent("error", gin.H{
-			"message": "Failed to connect to server logs",
-			"details": err.Error(),
-		})
-		c.Writer.Flush()
-		return
+	// start streams pods not seen yet and returns how many are still waiting for their
+	// container to start; those are picked up by a later scan
+	start := func(pods []corev1.Pod) int {
+		waiting := 0
+		for i := range pods {
+			pod := &pods[i]
+			if streaming[pod.Name] {
+				continue
+			}
+			if !query.previousOnly && !k8s.ContainerStarted(pod, logContainerName) {
+				waiting++
+				continue
+			}
+			streaming[pod.Name] = true
+			active++
+			go func() {
+				err := h.readPodLogs(ctx, namespace, pod, query, lines)
+				select {
+				case done <- podLogResult{pod: pod.Name, err: err}:
+				case <-ctx.Done():
+				}
+			}()
+		}
+		return waiting
+	}
+	waiting := start(pods)
+
+	podNames := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		podNames = append(podNames, pod.Name)
 	}
-	defer logStream.Close()
 
 	// Send initial connection success event
 	c.SSEvent("connected", gin.H{
 		"server_id": serverID,
 		"status":    "streaming",
+		"pods":      podNames,
 	})
 	c.Writer.Flush()
 
-	// Start heartbeat goroutine to prevent proxy timeouts
-	heartbeatDone := make(chan struct{})
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				close(heartbeatDone)
-				return
-			case <-ticker.C:
-				c.SSEvent("heartbeat", gin.H{"timestamp": time.Now().UTC().Format(time.RFC3339)})
-				c.Writer.Flush()
-			}
-		}
-	}()
+	// Heartbeats prevent proxy timeouts
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+	rescan := time.NewTicker(podRescanInterval)
+	defer rescan.Stop()
 
-	// Stream logs line by line
-	scanner := bufio.NewScanner(logStream)
-	for scanner.Scan() {
+	// Merge all pods' lines into the one SSE stream until every pod's stream has ended
+	for active > 0 || waiting > 0 {
 		select {
 		case <-ctx.Done():
 			log.Printf("log streaming ended for server %s: client disconnected", serverID)
 			return
-		default:
-			line := scanner.Text()
-			c.SSEvent("log", gin.H{
-				"line":      line,
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-			})
+
+		case line := <-lines:
+			sendLogLine(c, line)
+
+		case result := <-done:
+			active--
+			if result.err != nil {
+				log.Printf("log streaming error for server %s pod %s: %v", serverID, result.pod, result.err)
+				c.SSEvent("error", gin.H{
+					"message": "Log stream interrupted",
+					"details": result.err.Error(),
+					"pod":     result.pod,
+				})
+				c.Writer.Flush()
+			}
+			// A replacement pod may have been created since the last scan
+			if active == 0 && !query.previousOnly {
+				waiting = h.rescanLogPods(ctx, namespace, labelSelector, start, waiting)
+			}
+
+		case <-rescan.C:
+			if !query.previousOnly {
+				waiting = h.rescanLogPods(ctx, namespace, labelSelector, start, waiting)
+			}
+
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"timestamp": time.Now().UTC().Format(time.RFC3339)})
 			c.Writer.Flush()
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Printf("log streaming error for server %s: %v", serverID, err)
-		c.SSEvent("error", gin.H{
-			"message": "Log stream interrupted",
-			"details": err.Error(),
-		})
-		c.Writer.Flush()
+	// Readers send their lines before finishing, so some may still be buffered
+	for len(lines) > 0 {
+		sendLogLine(c, <-lines)
 	}
 
 	// Send end event when stream closes
@@ -885,19 +1328,268 @@ func (h *ServerHandler) StreamLogs(c *gin.Context) {
 	c.Writer.Flush()
 }
 
-// StreamStatus streams real-time status updates for all user's servers via SSE
+const (
+	// logContainerName is the container whose logs StreamLogs follows
+	logContainerName = "supervisor"
+
+	// logTailLines is how many past lines StreamLogs sends per container instance
+	logTailLines int64 = 50
+
+	// logFilteredTailLines is how many past lines are read per container instance when
+	// filtering, before the filters drop lines
+	logFilteredTailLines int64 = 1000
+
+	// recentRestartWindow is how recently a container must have restarted for StreamLogs to
+	// replay its previous instance's output before the current one
+	recentRestartWindow = 10 * time.Minute
+
+	// podRescanInterval is how often StreamLogs looks for new pods of the server
+	podRescanInterval = 5 * time.Second
+)
+
+// podLogLine is a log line read from one of a server's pods
+type podLogLine struct {
+	pod      string
+	line     string
+	severity string // Empty for lines the supervisor didn't tag
+	previous bool
+}
+
+// podLogResult reports that a pod's log stream ended
+type podLogResult struct {
+	pod string
+	err error
+}
+
+// rescanLogPods starts streaming pods created since the last scan. Keeps the previous
+// waiting count if the pods can't be listed.
+func (h *ServerHandler) rescanLogPods(ctx context.Context, namespace, labelSelector string, start func([]corev1.Pod) int, waiting int) int {
+	pods, err := h.k8sClient.ListPodsByLabel(ctx, namespace, labelSelector)
+	if err != nil {
+		log.Printf("failed to rescan pods for %s: %v", labelSelector, err)
+		return waiting
+	}
+	return start(pods)
+}
+
+// readPodLogs sends a pod's log lines until its stream ends. After a recent crash the
+// current container has little or nothing to show, so the previous one's output is sent
+// first; a waiting container (CrashLoopBackOff) has no current logs at all. With
+// previousOnly, only the previous container is read.
+func (h *ServerHandler) readPodLogs(ctx context.Context, namespace string, pod *corev1.Pod, query logQuery, lines chan<- podLogLine) error {
+	includePrevious := query.previousOnly || k8s.ContainerRestartedWithin(pod, logContainerName, recentRestartWindow)
+	followCurrent := !query.previousOnly && !(includePrevious && k8s.ContainerWaiting(pod, logContainerName))
+
+	tailLines := logTailLines
+	if query.filtered() {
+		tailLines = logFilteredTailLines
+	}
+
+	if includePrevious {
+		stream, err := h.k8sClient.PreviousPodLogs(ctx, namespace, pod.Name, logContainerName, tailLines, query.since)
+		if err != nil {
+			// An explicit request has nothing else to show; the fallback just skips ahead
+			if !followCurrent {
+				return err
+			}
+		} else {
+			err = scanLogLines(ctx, stream, pod.Name, true, query, lines)
+			stream.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if !followCurrent {
+		return nil
+	}
+
+	stream, err := h.k8sClient.StreamPodLogs(ctx, namespace, pod.Name, logContainerName, tailLines, query.since)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	return scanLogLines(ctx, stream, pod.Name, false, query, lines)
+}
+
+// scanLogLines sends each line of a log stream that passes the query's filters, until the
+// stream ends or the client disconnects
+func scanLogLines(ctx context.Context, stream io.Reader, pod string, previous bool, query logQuery, lines chan<- podLogLine) error {
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		severity, text := parseSeverityTag(scanner.Text())
+		if !query.match(severity, text) {
+			continue
+		}
+		select {
+		case lines <- podLogLine{pod: pod, line: text, severity: severity, previous: previous}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// sendLogLine sends a log line as an SSE log event. Lines from a previous container
+// instance are flagged.
+func sendLogLine(c *gin.Context, line podLogLine) {
+	event := gin.H{
+		"line":      line.line,
+		"pod":       line.pod,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	if line.severity != "" {
+		event["level"] = line.severity
+	}
+	if line.previous {
+		event["previous"] = true
+	}
+	c.SSEvent("log", event)
+	c.Writer.Flush()
+}
+
+// logDownloadLimit caps each container instance's logs in a download
+const logDownloadLimit int64 = 50 << 20
+
+// DownloadLogs sends the complete retained logs of every pod of a server as a gzipped
+// text file: the previous container instance after a restart, then the current one
+func (h *ServerHandler) DownloadLogs(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	// Verify server ownership
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	ctx := c.Request.Context()
+	namespace := server.K8sNamespace(h.config.K8sNamespace)
+	pods, err := h.k8sClient.ListPodsByLabel(ctx, namespace, "server="+serverID)
+	if err != nil {
+		log.Printf("failed to list pods for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to get server logs"))
+		return
+	}
+	if len(pods) == 0 {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeLogsUnavailable, "logs not available").
+			WithDetails(gin.H{"reason": fmt.Sprintf("server is %s", server.Status)}))
+		return
+	}
+
+	// Oldest pod first, so a rolling restart reads in order
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+	})
+
+	filename := fmt.Sprintf("%s-logs-%s.log.gz", server.Subdomain, time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	gz := gzip.NewWriter(c.Writer)
+	defer gz.Close()
+
+	for i := range pods {
+		pod := &pods[i]
+		if k8s.ContainerRestarted(pod, logContainerName) {
+			h.writePodLogs(ctx, gz, namespace, pod.Name, true)
+		}
+		if k8s.ContainerStarted(pod, logContainerName) && !k8s.ContainerWaiting(pod, logContainerName) {
+			h.writePodLogs(ctx, gz, namespace, pod.Name, false)
+		}
+	}
+}
+
+// writePodLogs writes one container instance's logs under a header naming it, with the
+// supervisor's severity tags stripped. Errors are written into the file, since the
+// response has already started.
+func (h *ServerHandler) writePodLogs(ctx context.Context, w io.Writer, namespace, podName string, previous bool) {
+	instance := "current"
+	if previous {
+		instance = "previous"
+	}
+	fmt.Fprintf(w, "==> %s (%s container) <==\n", podName, instance)
+
+	stream, err := h.k8sClient.PodLogs(ctx, namespace, podName, logContainerName, previous, logDownloadLimit)
+	if err != nil {
+		log.Printf("failed to download logs for pod %s: %v", podName, err)
+		fmt.Fprintf(w, "(logs unavailable: %v)\n\n", err)
+		return
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		_, text := parseSeverityTag(scanner.Text())
+		io.WriteString(w, text+"\n")
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(w, "(log download interrupted: %v)\n", err)
+	}
+	io.WriteString(w, "\n")
+}
+
+// StreamStatus streams real-time status updates and node incidents for all user's servers via SSE
 func (h *ServerHandler) StreamStatus(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
 	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.ErrUnauthorized)
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	// A new tab replaces the oldest stream at the limit, unless the client asks not to
+	streamStatus(c, h.db, h.hub, userID, broadcast.SubscribeOptions{Replace: c.Query("replace") != "false"})
+}
+
+// statusStreamRetryAfter is how long clients refused by a full status stream hub are told
+// to wait, in seconds
+const statusStreamRetryAfter = 30
+
+// streamStatus streams the status events of a user's servers via SSE until the client
+// disconnects, starting with the current state of each
+func streamStatus(c *gin.Context, db *database.DB, hub *broadcast.Hub, userID uuid.UUID, opts broadcast.SubscribeOptions) {
+	// Subscribe to hub for this user's events
+	sub, err := hub.Subscribe(userID, opts)
+	if errors.Is(err, broadcast.ErrHubFull) {
+		c.Header("Retry-After", strconv.Itoa(statusStreamRetryAfter))
+		c.Error(apierror.ErrTooManyStreams.WithDetails(map[string]int{"retry_after_seconds": statusStreamRetryAfter}))
+		return
+	}
+	if errors.Is(err, broadcast.ErrUserLimit) {
+		c.Error(apierror.ErrTooManyUserStreams.WithDetails(map[string]int{"limit": hub.Limits().PerUser}))
 		return
 	}
+	defer hub.Unsubscribe(userID, sub)
 
 	// Set SSE headers
 	c.Header("Content-Type", "text/event-stream")
@@ -909,12 +1601,8 @@ func (h *ServerHandler) StreamStatus(c *gin.Context) {
 	ctx, cancel := context.WithCancel(c.Request.Context())
 	defer cancel()
 
-	// Subscribe to hub for this user's events
-	eventCh := h.hub.Subscribe(userID)
-	defer h.hub.Unsubscribe(userID, eventCh)
-
 	// Get all user's servers and send initial state
-	servers, err := h.db.ListServersByUser(ctx, userID)
+	servers, err := db.ListServersByUser(ctx, userID)
 	if err != nil {
 		log.Printf("failed to list servers for user %s: %v", userID, err)
 		c.SSEvent("error", gin.H{
@@ -925,13 +1613,16 @@ func (h *ServerHandler) StreamStatus(c *gin.Context) {
 		return
 	}
 
+	lang := middleware.GetLanguage(c)
+
 	// Build initial state for all servers
 	initialServers := make([]gin.H, len(servers))
 	for i, server := range servers {
 		initialServers[i] = gin.H{
 			"server_id":      server.ID.String(),
 			"status":         server.Status,
-			"status_message": server.StatusMessage,
+			"status_message": i18n.TPtr(lang, server.StatusMessage),
+			"status_reason":  server.StatusReason,
 		}
 	}
 
@@ -955,17 +1646,32 @@ func (h *ServerHandler) StreamStatus(c *gin.Context) {
 			log.Printf("status streaming ended for user %s: client disconnected", userID)
 			return
 
-		case event, ok := <-eventCh:
+		case event, ok := <-sub.Events:
 			if !ok {
-				// Channel closed
+				// Closed by the hub. Replaced clients shouldn't reconnect, or tabs would take
+				// turns replacing each other.
+				if sub.CloseReason() == broadcast.CloseReplaced {
+					c.SSEvent("closed", gin.H{
+						"reason":  broadcast.CloseReplaced,
+						"message": i18n.T(lang, "Status updates moved to a newer tab or window"),
+					})
+					c.Writer.Flush()
+				}
 				return
 			}
-			c.SSEvent("status", gin.H{
-				"server_id":      event.ServerID,
-				"status":         event.Status,
-				"status_message": event.StatusMessage,
-				"timestamp":      event.Timestamp.Format(time.RFC3339),
-			})
+			switch event := event.(type) {
+			case broadcast.StatusEvent:
+				c.SSEvent(event.EventName(), gin.H{
+					"server_id":      event.ServerID,
+					"status":         event.Status,
+					"status_message": i18n.TPtr(lang, event.StatusMessage),
+					"status_reason":  event.StatusReason,
+					"startup_phase":  event.StartupPhase,
+					"timestamp":      event.Timestamp.Format(time.RFC3339),
+				})
+			default:
+				c.SSEvent(event.EventName(), event)
+			}
 			c.Writer.Flush()
 
 		case <-heartbeatTicker.C:
@@ -976,15 +1682,3 @@ func (h *ServerHandler) StreamStatus(c *gin.Context) {
 		}
 	}
 }
-
-// parseCPUToMillicores converts a CPU string (e.g., "1", "500m") to millicores
-func parseCPUToMillicores(cpu string) int {
-	q := resource.MustParse(cpu)
-	return int(q.MilliValue())
-}
-
-// parseMemoryToBytes converts a memory string (e.g., "2Gi", "512Mi") to bytes
-func parseMemoryToBytes(memory string) int64 {
-	q := resource.MustParse(memory)
-	return q.Value()
-}
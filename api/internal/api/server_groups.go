@@ -0,0 +1,340 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// maxServerGroups caps how many server groups a user can have
+const maxServerGroups = 50
+
+// ListServerGroups returns the user's server groups in order, with their aggregate status
+func (h *ServerHandler) ListServerGroups(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	groups, err := h.db.ListServerGroups(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to list server groups: %v", err)
+		c.Error(apierror.Internal("failed to list server groups"))
+		return
+	}
+	servers, err := h.db.ListServersByUser(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to list servers: %v", err)
+		c.Error(apierror.Internal("failed to list server groups"))
+		return
+	}
+	summarizeGroups(groups, servers)
+
+	c.JSON(http.StatusOK, gin.H{"groups": groups})
+}
+
+// CreateServerGroup creates an empty server group after the user's existing ones
+func (h *ServerHandler) CreateServerGroup(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.CreateServerGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	existing, err := h.db.ListServerGroups(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to list server groups: %v", err)
+		c.Error(apierror.Internal("failed to create server group"))
+		return
+	}
+	if len(existing) >= maxServerGroups {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeServerGroupLimit,
+			"you already have the maximum number of server groups").
+			WithDetails(gin.H{"limit": maxServerGroups}))
+		return
+	}
+
+	group, err := h.db.CreateServerGroup(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		log.Printf("failed to create server group: %v", err)
+		c.Error(apierror.Internal("failed to create server group"))
+		return
+	}
+	group.Status = models.GroupStatusEmpty
+
+	c.JSON(http.StatusCreated, gin.H{"group": group})
+}
+
+// UpdateServerGroup renames a server group
+func (h *ServerHandler) UpdateServerGroup(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.UpdateServerGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.ErrServerGroupNotFound)
+		return
+	}
+
+	group, err := h.db.RenameServerGroup(c.Request.Context(), userID, groupID, req.Name)
+	if err != nil {
+		log.Printf("failed to rename server group %s: %v", groupID, err)
+		c.Error(apierror.Internal("failed to update server group"))
+		return
+	}
+	if group == nil {
+		c.Error(apierror.ErrServerGroupNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group": group})
+}
+
+// DeleteServerGroup deletes a server group. Its servers are ungrouped, not deleted.
+func (h *ServerHandler) DeleteServerGroup(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.ErrServerGroupNotFound)
+		return
+	}
+
+	deleted, err := h.db.DeleteServerGroup(c.Request.Context(), userID, groupID)
+	if err != nil {
+		log.Printf("failed to delete server group %s: %v", groupID, err)
+		c.Error(apierror.Internal("failed to delete server group"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.ErrServerGroupNotFound)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ReorderServerGroups puts the user's server groups in the given order, which must list each
+// of them once
+func (h *ServerHandler) ReorderServerGroups(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.ReorderServerGroupsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	groups, err := h.db.ListServerGroups(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to list server groups: %v", err)
+		c.Error(apierror.Internal("failed to reorder server groups"))
+		return
+	}
+
+	remaining := make(map[uuid.UUID]bool, len(groups))
+	for _, group := range groups {
+		remaining[group.ID] = true
+	}
+	ids := make([]uuid.UUID, 0, len(req.GroupIDs))
+	for _, idStr := range req.GroupIDs {
+		id := uuid.MustParse(idStr) // Validated by binding
+		if !remaining[id] {
+			c.Error(apierror.BadRequest("group_ids must list each of your server groups once"))
+			return
+		}
+		delete(remaining, id)
+		ids = append(ids, id)
+	}
+	if len(remaining) > 0 {
+		c.Error(apierror.BadRequest("group_ids must list each of your server groups once"))
+		return
+	}
+
+	if err := h.db.ReorderServerGroups(c.Request.Context(), userID, ids); err != nil {
+		log.Printf("failed to reorder server groups: %v", err)
+		c.Error(apierror.Internal("failed to reorder server groups"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetGroupServers sets which of the user's servers a group holds and their order. Servers
+// moved in leave their previous group; the group's servers left out are ungrouped.
+func (h *ServerHandler) SetGroupServers(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.SetGroupServersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.ErrServerGroupNotFound)
+		return
+	}
+
+	groups, err := h.db.ListServerGroups(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to list server groups: %v", err)
+		c.Error(apierror.Internal("failed to update server group"))
+		return
+	}
+	found := false
+	for _, group := range groups {
+		found = found || group.ID == groupID
+	}
+	if !found {
+		c.Error(apierror.ErrServerGroupNotFound)
+		return
+	}
+
+	servers, err := h.db.ListServersByUser(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to list servers: %v", err)
+		c.Error(apierror.Internal("failed to update server group"))
+		return
+	}
+	owned := make(map[uuid.UUID]bool, len(servers))
+	for _, server := range servers {
+		owned[server.ID] = true
+	}
+	ids := make([]uuid.UUID, 0, len(req.ServerIDs))
+	seen := make(map[uuid.UUID]bool, len(req.ServerIDs))
+	for _, idStr := range req.ServerIDs {
+		id := uuid.MustParse(idStr) // Validated by binding
+		if !owned[id] {
+			c.Error(apierror.ErrServerNotFound)
+			return
+		}
+		if seen[id] {
+			c.Error(apierror.BadRequest("server_ids must not list a server twice"))
+			return
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if err := h.db.SetGroupServers(c.Request.Context(), userID, groupID, ids); err != nil {
+		log.Printf("failed to set servers of group %s: %v", groupID, err)
+		c.Error(apierror.Internal("failed to update server group"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// summarizeGroups sets the server counts and aggregate status of groups from the user's
+// servers. Servers being deleted aren't counted.
+func summarizeGroups(groups []models.ServerGroup, servers []models.Server) {
+	index := make(map[uuid.UUID]*models.ServerGroup, len(groups))
+	for i := range groups {
+		groups[i].StatusCounts = map[models.ServerStatus]int{}
+		index[groups[i].ID] = &groups[i]
+	}
+
+	for _, server := range servers {
+		if server.GroupID == nil {
+			continue
+		}
+		group := index[*server.GroupID]
+		if group == nil || server.Status == models.ServerStatusDeleting || server.Status == models.ServerStatusDeleted {
+			continue
+		}
+		group.ServerCount++
+		group.StatusCounts[server.Status]++
+	}
+
+	for i := range groups {
+		groups[i].Status = groupStatus(&groups[i])
+	}
+}
+
+// groupStatus summarizes a group's status counts
+func groupStatus(group *models.ServerGroup) models.GroupStatus {
+	counts := group.StatusCounts
+	switch {
+	case group.ServerCount == 0:
+		return models.GroupStatusEmpty
+	case counts[models.ServerStatusFailed] > 0 || counts[models.ServerStatusExpired] > 0 || counts[models.ServerStatusSuspended] > 0:
+		return models.GroupStatusAttention
+	case counts[models.ServerStatusRunning] == group.ServerCount:
+		return models.GroupStatusRunning
+	case counts[models.ServerStatusRunning] > 0 || counts[models.ServerStatusStarting] > 0 || counts[models.ServerStatusPending] > 0:
+		return models.GroupStatusPartial
+	default:
+		return models.GroupStatusStopped
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/services/serversession"
+)
+
+// sessionRanges are the ranges GetServerSessions covers
+var sessionRanges = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// maxSessions bounds the sessions GetServerSessions lists; the summary covers them all
+const maxSessions = 500
+
+// GetServerSessions returns the periods the server was online over ?range (24h, 7d, 30d or
+// 90d, default 7d), newest first, with its uptime and the hours billed for them
+func (h *ServerHandler) GetServerSessions(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	rangeName := c.DefaultQuery("range", "7d")
+	window, ok := sessionRanges[rangeName]
+	if !ok {
+		c.Error(apierror.BadRequest("range must be 24h, 7d, 30d or 90d"))
+		return
+	}
+	since := time.Now().Add(-window)
+	ctx := c.Request.Context()
+
+	sessions, err := h.db.ListServerSessions(ctx, server.ID.String(), since, serversession.Gap, maxSessions)
+	if err != nil {
+		log.Printf("failed to list sessions of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to get server sessions"))
+		return
+	}
+	summary, err := h.db.GetServerSessionSummary(ctx, server.ID.String(), since, serversession.Gap)
+	if err != nil {
+		log.Printf("failed to get session summary of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to get server sessions"))
+		return
+	}
+	summary.Range = rangeName
+	summary.UptimePercent = float64(summary.OnlineSeconds) / window.Seconds() * 100
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "summary": summary})
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	stripeservice "github.com/mooncorn/gshub/api/internal/services/stripe"
+)
+
+// GetSpendLimit returns the user's monthly spending limit and projected spend
+func (h *BillingHandler) GetSpendLimit(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	limit, err := h.db.GetSpendLimit(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get spend limit: %v", err)
+		c.Error(apierror.Internal("failed to get spending limit"))
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// UpdateSpendLimit sets or clears the user's monthly spending limit
+func (h *BillingHandler) UpdateSpendLimit(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.UpdateSpendLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	if err := h.db.UpdateSpendLimit(c.Request.Context(), userID, req.LimitCents, req.BlockCheckout); err != nil {
+		log.Printf("failed to update spend limit: %v", err)
+		c.Error(apierror.Internal("failed to update spending limit"))
+		return
+	}
+
+	limit, err := h.db.GetSpendLimit(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to get spend limit: %v", err)
+		c.Error(apierror.Internal("failed to get spending limit"))
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// checkSpendLimit rejects a checkout for priceID when the user chose to block checkouts
+// that would push their projected monthly spend over their limit
+func checkSpendLimit(ctx context.Context, db *database.DB, stripeService *stripeservice.Service, userID uuid.UUID, priceID string) error {
+	limit, err := db.GetSpendLimit(ctx, userID)
+	if err != nil {
+		log.Printf("failed to get spend limit: %v", err)
+		return apierror.Internal("failed to check spending limit")
+	}
+	if !limit.BlockCheckout || limit.LimitCents == nil {
+		return nil
+	}
+
+	price, err := stripeService.GetPrice(ctx, priceID)
+	if err != nil {
+		log.Printf("failed to get price: %v", err)
+		return apierror.Internal("failed to check spending limit")
+	}
+
+	if limit.Exceeded(price.UnitAmount) {
+		return apierror.ErrSpendLimitExceeded
+	}
+	return nil
+}
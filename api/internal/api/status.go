@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/stripe"
+)
+
+const (
+	// incidentHistory is how far back resolved incidents are listed
+	incidentHistory = 7 * 24 * time.Hour
+
+	// platformStatusTTL is how long a platform status snapshot is served before the
+	// components are checked again, so public traffic can't hammer dependencies
+	platformStatusTTL = 30 * time.Second
+
+	// componentCheckTimeout bounds each component health check
+	componentCheckTimeout = 3 * time.Second
+)
+
+// ComponentStatus is the health of a platform component, in order of severity
+type ComponentStatus string
+
+const (
+	ComponentOperational ComponentStatus = "operational"
+	ComponentDegraded    ComponentStatus = "degraded"
+	ComponentOutage      ComponentStatus = "outage"
+)
+
+var componentSeverity = map[ComponentStatus]int{
+	ComponentOperational: 0,
+	ComponentDegraded:    1,
+	ComponentOutage:      2,
+}
+
+// Component is one entry on the platform status page
+type Component struct {
+	Name   string          `json:"name"`
+	Status ComponentStatus `json:"status"`
+}
+
+// RegionStatus is the health of the game server nodes in a region
+type RegionStatus struct {
+	Region      string          `json:"region"`
+	Status      ComponentStatus `json:"status"`
+	Nodes       int             `json:"nodes"`
+	ActiveNodes int             `json:"active_nodes"`
+}
+
+// PlatformStatus is the public platform status snapshot
+type PlatformStatus struct {
+	Status     ComponentStatus   `json:"status"`
+	Components []Component       `json:"components"`
+	Regions    []RegionStatus    `json:"regions"`
+	Incidents  []models.Incident `json:"incidents"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
+
+// StatusHandler serves the public /status endpoints
+type StatusHandler struct {
+	db            *database.DB
+	k8sClient     *k8s.Client
+	stripeService *stripe.Service
+
+	mu       sync.Mutex
+	snapshot *PlatformStatus
+}
+
+func NewStatusHandler(db *database.DB, k8sClient *k8s.Client, stripeService *stripe.Service) *StatusHandler {
+	return &StatusHandler{
+		db:            db,
+		k8sClient:     k8sClient,
+		stripeService: stripeService,
+	}
+}
+
+// ListIncidents returns open node incidents and those from the past week, newest first
+func (h *StatusHandler) ListIncidents(c *gin.Context) {
+	incidents, err := h.db.ListIncidents(c.Request.Context(), time.Now().Add(-incidentHistory))
+	if err != nil {
+		log.Printf("failed to list incidents: %v", err)
+		c.Error(apierror.Internal("failed to list incidents"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"incidents": incidents})
+}
+
+// GetPlatformStatus returns the health of each platform component and region, with open
+// incidents and those from the past week
+func (h *StatusHandler) GetPlatformStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.platformStatus(c.Request.Context()))
+}
+
+// GetPlatformBadge returns the overall status in the shields.io endpoint badge format
+func (h *StatusHandler) GetPlatformBadge(c *gin.Context) {
+	status := h.platformStatus(c.Request.Context())
+
+	color := "brightgreen"
+	switch status.Status {
+	case ComponentDegraded:
+		color = "yellow"
+	case ComponentOutage:
+		color = "red"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schemaVersion": 1,
+		"label":         "gshub",
+		"message":       string(status.Status),
+		"color":         color,
+	})
+}
+
+// platformStatus returns the cached snapshot, checking the components again once it expires
+func (h *StatusHandler) platformStatus(ctx context.Context) *PlatformStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.snapshot != nil && time.Since(h.snapshot.CheckedAt) < platformStatusTTL {
+		return h.snapshot
+	}
+	h.snapshot = h.checkPlatform(ctx)
+	return h.snapshot
+}
+
+func (h *StatusHandler) checkPlatform(ctx context.Context) *PlatformStatus {
+	status := &PlatformStatus{
+		Components: []Component{{Name: "api", Status: ComponentOperational}},
+		Regions:    []RegionStatus{},
+		Incidents:  []models.Incident{},
+		CheckedAt:  time.Now(),
+	}
+
+	checks := []struct {
+		name  string
+		check func(context.Context) error
+	}{
+		{"database", h.db.Health},
+		{"kubernetes", h.k8sClient.Health},
+		{"payments", func(context.Context) error { return h.stripeService.Health() }},
+	}
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, componentCheckTimeout)
+		err := check.check(checkCtx)
+		cancel()
+
+		component := Component{Name: check.name, Status: ComponentOperational}
+		if err != nil {
+			log.Printf("platform status: %s check failed: %v", check.name, err)
+			component.Status = ComponentOutage
+		}
+		status.Components = append(status.Components, component)
+	}
+
+	// Regions and incidents live in the database, so skip them if it's down
+	if status.Components[1].Status == ComponentOperational {
+		incidents, err := h.db.ListIncidents(ctx, time.Now().Add(-incidentHistory))
+		if err != nil {
+			log.Printf("platform status: failed to list incidents: %v", err)
+		} else {
+			status.Incidents = incidents
+		}
+
+		nodes, err := h.db.GetRegionNodeStats(ctx)
+		if err != nil {
+			log.Printf("platform status: failed to get region node stats: %v", err)
+		} else {
+			status.Regions = regionStatuses(nodes, status.Incidents)
+		}
+	}
+
+	status.Status = ComponentOperational
+	for _, component := range status.Components {
+		status.Status = worstStatus(status.Status, component.Status)
+	}
+	for _, region := range status.Regions {
+		// A region outage only degrades the platform; the other regions still serve
+		if region.Status != ComponentOperational {
+			status.Status = worstStatus(status.Status, ComponentDegraded)
+		}
+	}
+
+	return status
+}
+
+// regionStatuses rates each region by its active nodes. A region is degraded if some of
+// its nodes are inactive or have an open incident, and out if none are active.
+func regionStatuses(nodes []database.RegionNodeStats, incidents []models.Incident) []RegionStatus {
+	openIncidents := make(map[string]bool)
+	for _, incident := range incidents {
+		if incident.ResolvedAt == nil {
+			openIncidents[incident.Region] = true
+		}
+	}
+
+	regions := make([]RegionStatus, 0, len(nodes))
+	for _, n := range nodes {
+		region := RegionStatus{
+			Region:      n.Region,
+			Status:      ComponentOperational,
+			Nodes:       n.Total,
+			ActiveNodes: n.Active,
+		}
+		switch {
+		case n.Active == 0:
+			region.Status = ComponentOutage
+		case n.Active < n.Total || openIncidents[n.Region]:
+			region.Status = ComponentDegraded
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+func worstStatus(a, b ComponentStatus) ComponentStatus {
+	if componentSeverity[b] > componentSeverity[a] {
+		return b
+	}
+	return a
+}
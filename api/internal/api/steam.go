@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"github.com/mooncorn/gshub/api/internal/services/steam"
+)
+
+// CreateSteamToken creates a Steam game server login token for a server whose game needs
+// one and saves it to the env var the game reads it from. The owner's linked Steam account
+// must own the game. An existing token is kept, clear the env var to get a new one.
+func (h *ServerHandler) CreateSteamToken(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+	if h.steam == nil {
+		c.Error(apierror.ErrSteamDisabled)
+		return
+	}
+	if !serverstate.CanEditEnv(server.Status) {
+		c.Error(apierror.InvalidServerState("server is being deleted"))
+		return
+	}
+	ctx := c.Request.Context()
+
+	catalog, err := h.k8sClient.LoadGameCatalog(ctx, h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err != nil {
+		log.Printf("failed to load game catalog: %v", err)
+		c.Error(apierror.Internal("failed to load game configuration"))
+		return
+	}
+	gameConfig, err := h.serverGameConfig(ctx, server, catalog)
+	if err != nil {
+		log.Printf("failed to get game config for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to load game configuration"))
+		return
+	}
+	if gameConfig.Steam == nil || gameConfig.Steam.TokenEnv == "" {
+		c.Error(apierror.BadRequest("this game doesn't use a Steam server token"))
+		return
+	}
+	tokenEnv := gameConfig.Steam.TokenEnv
+	if server.EnvOverrides[tokenEnv] != "" {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict,
+			fmt.Sprintf("%s is already set, clear it to create a new token", tokenEnv)))
+		return
+	}
+
+	account := h.checkSteamOwnership(c, server, gameConfig.Steam.AppID)
+	if account == nil {
+		return
+	}
+
+	// The memo lets support trace a token on the publisher account back to its server
+	memo := fmt.Sprintf("gshub %s (steam %s)", server.ID, account.ExternalID)
+	token, err := h.steam.CreateGameServerToken(ctx, gameConfig.Steam.AppID, memo)
+	if err != nil {
+		log.Printf("failed to create steam token for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to create Steam server token"))
+		return
+	}
+
+	envOverrides := maps.Clone(server.EnvOverrides)
+	if envOverrides == nil {
+		envOverrides = map[string]string{}
+	}
+	envOverrides[tokenEnv] = token
+	if _, err := h.db.UpdateServerEnvWithRevision(ctx, server.ID.String(), envOverrides, server.UserID, nil); err != nil {
+		log.Printf("failed to save steam token for server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to update environment variables"))
+		return
+	}
+
+	server.EnvOverrides = envOverrides
+	restartRequired, err := h.restartRequired(ctx, server)
+	if err != nil {
+		log.Printf("failed to check pending changes for server %s: %v", server.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"env_key":          tokenEnv,
+		"message":          "Steam server token created.",
+		"restart_required": restartRequired,
+	})
+}
+
+// checkSteamOwnership returns the server owner's linked Steam account if it owns the app.
+// Otherwise it sets the error and returns nil.
+func (h *ServerHandler) checkSteamOwnership(c *gin.Context, server *models.Server, appID int) *models.LinkedAccount {
+	if h.steam == nil {
+		c.Error(apierror.ErrSteamDisabled)
+		return nil
+	}
+	ctx := c.Request.Context()
+
+	account, err := h.db.GetLinkedAccount(ctx, server.UserID, models.LinkedAccountSteam)
+	if err != nil {
+		log.Printf("failed to get steam account of user %s: %v", server.UserID, err)
+		c.Error(apierror.Internal("failed to check Steam account"))
+		return nil
+	}
+	if account == nil {
+		c.Error(apierror.ErrSteamNotLinked)
+		return nil
+	}
+
+	owned, err := h.steam.OwnsApp(ctx, account.ExternalID, appID)
+	if errors.Is(err, steam.ErrPrivateProfile) {
+		c.Error(apierror.ErrSteamPrivateProfile)
+		return nil
+	}
+	if err != nil {
+		log.Printf("failed to check steam app %d ownership of user %s: %v", appID, server.UserID, err)
+		c.Error(apierror.Internal("failed to check Steam account"))
+		return nil
+	}
+	if !owned {
+		c.Error(apierror.ErrSteamNotOwned)
+		return nil
+	}
+	return account
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// publicTemplateListLimit caps how many shared templates are listed
+const publicTemplateListLimit = 100
+
+// CreateTemplate saves a server's game, plan, env overrides, mods and scheduled tasks as a template
+func (h *ServerHandler) CreateTemplate(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), req.ServerID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	// Templates only carry env overrides; a custom game's definition isn't part of them
+	if server.Game == models.GameCustom {
+		c.Error(apierror.BadRequest("templates can't be created from custom game servers"))
+		return
+	}
+
+	mods, schedules, err := h.templateSnapshot(c.Request.Context(), server.ID.String())
+	if err != nil {
+		log.Printf("failed to snapshot server %s for template: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to create template"))
+		return
+	}
+
+	template, err := h.db.CreateTemplate(c.Request.Context(), &models.ServerTemplate{
+		UserID:       userID,
+		Name:         req.Name,
+		Description:  req.Description,
+		Game:         server.Game,
+		Plan:         server.Plan,
+		EnvOverrides: server.EnvOverrides,
+		Mods:         mods,
+		Schedules:    schedules,
+		IsPublic:     req.IsPublic,
+	})
+	if err != nil {
+		log.Printf("failed to create template from server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to create template"))
+		return
+	}
+	template.IsOwner = true
+
+	c.JSON(http.StatusCreated, gin.H{"template": template})
+}
+
+// ListTemplates returns the user's templates, or shared templates with ?public=true
+func (h *ServerHandler) ListTemplates(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var templates []models.ServerTemplate
+	if c.Query("public") == "true" {
+		templates, err = h.db.ListPublicTemplates(c.Request.Context(), publicTemplateListLimit)
+	} else {
+		templates, err = h.db.ListTemplatesByUser(c.Request.Context(), userID)
+	}
+	if err != nil {
+		log.Printf("failed to list templates: %v", err)
+		c.Error(apierror.Internal("failed to list templates"))
+		return
+	}
+
+	for i := range templates {
+		templates[i].IsOwner = templates[i].UserID == userID
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// GetTemplate returns one of the user's templates or a shared template
+func (h *ServerHandler) GetTemplate(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	template := h.getVisibleTemplate(c, userID)
+	if template == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"template": template})
+}
+
+// UpdateTemplate renames a template or changes whether it's shared
+func (h *ServerHandler) UpdateTemplate(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.UpdateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	template := h.getVisibleTemplate(c, userID)
+	if template == nil {
+		return
+	}
+	if !template.IsOwner {
+		c.Error(apierror.ErrTemplateNotFound)
+		return
+	}
+
+	if req.Name != nil {
+		template.Name = *req.Name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	if req.IsPublic != nil {
+		template.IsPublic = *req.IsPublic
+	}
+
+	updated, err := h.db.UpdateTemplate(c.Request.Context(), template)
+	if err != nil {
+		log.Printf("failed to update template %s: %v", template.ID, err)
+		c.Error(apierror.Internal("failed to update template"))
+		return
+	}
+	updated.IsOwner = true
+
+	c.JSON(http.StatusOK, gin.H{"template": updated})
+}
+
+// DeleteTemplate deletes one of the user's templates. Servers created from it are unaffected.
+func (h *ServerHandler) DeleteTemplate(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.ErrTemplateNotFound)
+		return
+	}
+
+	deleted, err := h.db.DeleteTemplate(c.Request.Context(), userID, templateID)
+	if err != nil {
+		log.Printf("failed to delete template %s: %v", templateID, err)
+		c.Error(apierror.Internal("failed to delete template"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.ErrTemplateNotFound)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateServerFromTemplate starts checkout for a new server with a template's game, plan, env
+// overrides, mods and scheduled tasks. Works like CreateCheckoutSession otherwise.
+func (h *ServerHandler) CreateServerFromTemplate(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.CreateServerFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	template := h.getVisibleTemplate(c, userID)
+	if template == nil {
+		return
+	}
+
+	h.startCheckout(c, userID, models.CreateServerRequest{
+		DisplayName:  req.DisplayName,
+		Subdomain:    req.Subdomain,
+		Game:         string(template.Game),
+		Plan:         string(template.Plan),
+		UseSavedCard: req.UseSavedCard,
+	}, template.EnvOverrides, nil, template)
+}
+
+// templateSnapshot returns a server's installed mods, pinned to their versions, and its
+// scheduled tasks as a template saves them
+func (h *ServerHandler) templateSnapshot(ctx context.Context, serverID string) ([]models.TemplateMod, []models.TemplateSchedule, error) {
+	installed, err := h.db.ListServerMods(ctx, serverID)
+	if err != nil {
+		return nil, nil, err
+	}
+	mods := make([]models.TemplateMod, 0, len(installed))
+	for _, mod := range installed {
+		mods = append(mods, models.TemplateMod{ProjectID: mod.ProjectID, VersionID: mod.VersionID, Name: mod.Name})
+	}
+
+	serverSchedules, err := h.db.ListSchedules(ctx, serverID)
+	if err != nil {
+		return nil, nil, err
+	}
+	schedules := make([]models.TemplateSchedule, 0, len(serverSchedules))
+	for _, s := range serverSchedules {
+		schedules = append(schedules, models.TemplateSchedule{
+			Name:     s.Name,
+			Cron:     s.Cron,
+			Timezone: s.Timezone,
+			Action:   s.Action,
+			Payload:  s.Payload,
+			Enabled:  s.Enabled,
+		})
+	}
+	return mods, schedules, nil
+}
+
+// getVisibleTemplate loads the template in the :id param if the user owns it or it's shared.
+// Otherwise it attaches an error and returns nil.
+func (h *ServerHandler) getVisibleTemplate(c *gin.Context, userID uuid.UUID) *models.ServerTemplate {
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.ErrTemplateNotFound)
+		return nil
+	}
+
+	template, err := h.db.GetTemplate(c.Request.Context(), templateID)
+	if err != nil {
+		log.Printf("failed to get template %s: %v", templateID, err)
+		c.Error(apierror.Internal("failed to get template"))
+		return nil
+	}
+	if template == nil || (template.UserID != userID && !template.IsPublic) {
+		c.Error(apierror.ErrTemplateNotFound)
+		return nil
+	}
+
+	template.IsOwner = template.UserID == userID
+	return template
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+)
+
+// oomUpgradeRecommendation returns the next plan up for a server that was OOM killed,
+// or nil if the server wasn't OOM killed or no larger plan is available for its game.
+// The price delta is omitted if prices can't be retrieved from Stripe.
+func (h *ServerHandler) oomUpgradeRecommendation(ctx context.Context, server *models.Server, gameConfig *k8s.GameConfig) *models.PlanUpgradeRecommendation {
+	if server.StatusReason == nil || *server.StatusReason != models.StatusReasonOOMKilled {
+		return nil
+	}
+
+	currentPlan, err := gameConfig.GetPlanConfig(string(server.Plan))
+	if err != nil {
+		return nil
+	}
+
+	for _, plan := range server.Plan.LargerPlans() {
+		nextPlan, err := gameConfig.GetPlanConfig(string(plan))
+		if err != nil {
+			continue // Plan not offered for this game
+		}
+		nextPriceID, err := h.config.GetPriceID(string(server.Game), string(plan))
+		if err != nil {
+			continue // Plan not purchasable
+		}
+
+		rec := &models.PlanUpgradeRecommendation{
+			CurrentPlan:       server.Plan,
+			RecommendedPlan:   plan,
+			CurrentMemory:     currentPlan.Memory,
+			RecommendedMemory: nextPlan.Memory,
+		}
+
+		currentPriceID, err := h.config.GetPriceID(string(server.Game), string(server.Plan))
+		if err != nil {
+			return rec
+		}
+		currentPrice, err := h.stripeService.GetPrice(ctx, currentPriceID)
+		if err != nil {
+			log.Printf("failed to get current plan price for server %s: %v", server.ID, err)
+			return rec
+		}
+		nextPrice, err := h.stripeService.GetPrice(ctx, nextPriceID)
+		if err != nil {
+			log.Printf("failed to get recommended plan price for server %s: %v", server.ID, err)
+			return rec
+		}
+
+		delta := nextPrice.UnitAmount - currentPrice.UnitAmount
+		rec.PriceDelta = &delta
+		rec.Currency = string(nextPrice.Currency)
+		return rec
+	}
+
+	return nil
+}
+
+// UpgradeFromOOM moves an OOM-killed server to the recommended larger plan and restarts it.
+// The subscription is switched to the new price with proration.
+func (h *ServerHandler) UpgradeFromOOM(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.Status != models.ServerStatusFailed || server.StatusReason == nil || *server.StatusReason != models.StatusReasonOOMKilled {
+		c.Error(apierror.InvalidServerState("server has not run out of memory"))
+		return
+	}
+
+	if server.StripeSubscriptionID == nil || *server.StripeSubscriptionID == "" {
+		c.Error(apierror.ErrNoSubscription)
+		return
+	}
+
+	catalog, err := h.k8sClient.LoadGameCatalog(c.Request.Context(), h.config.K8sNamespace, h.config.GameCatalogName(server.CatalogChannel))
+	if err != nil {
+		log.Printf("failed to load game catalog: %v", err)
+		c.Error(apierror.Internal("failed to load game configuration"))
+		return
+	}
+
+	gameConfig, err := catalog.GetGameConfig(string(server.Game))
+	if err != nil {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidGameOrPlan, err.Error()))
+		return
+	}
+
+	rec := h.oomUpgradeRecommendation(c.Request.Context(), server, gameConfig)
+	if rec == nil {
+		c.Error(apierror.ErrNoUpgradeAvailable)
+		return
+	}
+
+	priceID, err := h.config.GetPriceID(string(server.Game), string(rec.RecommendedPlan))
+	if err != nil {
+		c.Error(apierror.ErrNoUpgradeAvailable)
+		return
+	}
+	currentPriceID, err := h.config.GetPriceID(string(server.Game), string(server.Plan))
+	if err != nil {
+		c.Error(apierror.ErrNoUpgradeAvailable)
+		return
+	}
+
+	// The server lock serializes concurrent upgrades, so the subscription is only changed once,
+	// and keeps a concurrent start from interleaving with the teardown
+	op := h.startOperation(c.Request.Context(), serverID, models.OperationUpgrade)
+	var superseded bool
+	var apiErr error
+	err = h.db.WithServerLock(c.Request.Context(), serverID, func() error {
+		// Re-check under the lock - another upgrade may have changed the plan already
+		current, err := h.db.GetServerByID(c.Request.Context(), serverID)
+		if err != nil {
+			return err
+		}
+		if current.Plan != server.Plan || current.Status != models.ServerStatusFailed ||
+			current.StatusReason == nil || *current.StatusReason != models.StatusReasonOOMKilled {
+			superseded = true
+			return nil
+		}
+
+		// Bill first so the user never gets resources they aren't paying for
+		if _, err := h.stripeService.ChangeSubscriptionPrice(c.Request.Context(), *server.StripeSubscriptionID, priceID); err != nil {
+			log.Printf("failed to change subscription price for server %s: %v", serverID, err)
+			apiErr = apierror.Internal("failed to update subscription")
+			return nil
+		}
+
+		if err := h.db.UpdateServerPlan(c.Request.Context(), serverID, rec.RecommendedPlan); err != nil {
+			log.Printf("failed to update plan for server %s after subscription change: %v", serverID, err)
+			// Put the subscription back, so the user isn't billed for a plan they don't have
+			if _, err := h.stripeService.ChangeSubscriptionPrice(c.Request.Context(), *server.StripeSubscriptionID, currentPriceID); err != nil {
+				log.Printf("failed to revert subscription price for server %s: %v", serverID, err)
+			}
+			apiErr = apierror.Internal("failed to update server plan")
+			return nil
+		}
+
+		// Delete deployment and release reservations so the reconciler recreates
+		// the server with the new plan's resources (PVC with data is kept)
+		deployName := "server-" + serverID
+		if err := h.k8sClient.DeleteGameDeployment(c.Request.Context(), current.K8sNamespace(h.config.K8sNamespace), deployName); err != nil {
+			log.Printf("UpgradeFromOOM: failed to delete deployment for server %s: %v", serverID, err)
+		}
+
+		_, err = h.machine.Transition(c.Request.Context(), current, serverstate.Request{
+			From:         []models.ServerStatus{models.ServerStatusFailed},
+			To:           models.ServerStatusPending,
+			Message:      "Upgrading server plan...",
+			ReleasePorts: true,
+			ReleaseCause: models.PortCauseUpgrade,
+		})
+		return err
+	})
+	if err != nil {
+		log.Printf("failed to transition to pending: %v", err)
+		h.finishOperation(op, models.OperationStateFailed, errStatusUpdateFailed)
+		c.Error(apierror.Internal("database error"))
+		return
+	}
+	if superseded {
+		h.finishOperation(op, models.OperationStateSuperseded, nil)
+		c.Error(apierror.InvalidServerState("server has not run out of memory"))
+		return
+	}
+	if apiErr != nil {
+		h.finishOperation(op, models.OperationStateFailed, apiErr)
+		c.Error(apiErr)
+		return
+	}
+	h.finishOperation(op, models.OperationStateSucceeded, nil)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":    "upgrading",
+		"message":   "server is restarting on the new plan",
+		"plan":      rec.RecommendedPlan,
+		"operation": op,
+	})
+}
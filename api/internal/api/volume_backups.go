@@ -0,0 +1,177 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/volumebackup"
+)
+
+// maxVolumeBackups caps the volume backups a server keeps; older ones must be deleted first
+const maxVolumeBackups = 5
+
+// ListVolumeBackups returns the server's volume backups, newest first
+func (h *ServerHandler) ListVolumeBackups(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	backups, err := h.db.ListVolumeBackups(c.Request.Context(), server.ID.String())
+	if err != nil {
+		log.Printf("failed to list volume backups of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to list volume backups"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"backups": backups})
+}
+
+// CreateVolumeBackup queues a snapshot of the stopped server's whole data volume, taken by
+// the volume backup service. The server can't start until it's done.
+func (h *ServerHandler) CreateVolumeBackup(c *gin.Context) {
+	server := h.getVolumeBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	serverID := server.ID.String()
+	backups, err := h.db.ListVolumeBackups(ctx, serverID)
+	if err != nil {
+		log.Printf("failed to list volume backups of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to create volume backup"))
+		return
+	}
+	if len(backups) >= maxVolumeBackups {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeBackupLimit,
+			fmt.Sprintf("servers keep at most %d volume backups, delete one first", maxVolumeBackups)))
+		return
+	}
+
+	id := uuid.New()
+	backup, err := h.db.CreateVolumeBackup(ctx, id, serverID, volumebackup.ObjectKey(serverID, id))
+	if err != nil {
+		log.Printf("failed to create volume backup of server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to create volume backup"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"backup": backup})
+}
+
+// DeleteVolumeBackup removes a finished volume backup from the bucket
+func (h *ServerHandler) DeleteVolumeBackup(c *gin.Context) {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return
+	}
+	backup := h.getVolumeBackup(c, server)
+	if backup == nil {
+		return
+	}
+
+	deleting, err := h.db.MarkVolumeBackupDeleting(c.Request.Context(), backup.ID)
+	if err != nil {
+		log.Printf("failed to delete volume backup %s: %v", backup.ID, err)
+		c.Error(apierror.Internal("failed to delete volume backup"))
+		return
+	}
+	if !deleting {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict, "volume backup is in progress or waiting to be restored"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Volume backup deleted"})
+}
+
+// RestoreVolumeBackup selects a completed volume backup to replace the stopped server's
+// data on its next start, replacing any earlier selection
+func (h *ServerHandler) RestoreVolumeBackup(c *gin.Context) {
+	server := h.getVolumeBackupServer(c)
+	if server == nil {
+		return
+	}
+	backup := h.getVolumeBackup(c, server)
+	if backup == nil {
+		return
+	}
+
+	ok, err := h.db.RequestVolumeRestore(c.Request.Context(), server.ID.String(), backup.ID)
+	if err != nil {
+		log.Printf("failed to request restore of volume backup %s: %v", backup.ID, err)
+		c.Error(apierror.Internal("failed to restore volume backup"))
+		return
+	}
+	if !ok {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeConflict, "only completed volume backups can be restored"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Volume backup will be restored on the next start"})
+}
+
+// CancelVolumeRestore drops the stopped server's pending restore
+func (h *ServerHandler) CancelVolumeRestore(c *gin.Context) {
+	server := h.getVolumeBackupServer(c)
+	if server == nil {
+		return
+	}
+
+	cancelled, err := h.db.CancelVolumeRestore(c.Request.Context(), server.ID.String())
+	if err != nil {
+		log.Printf("failed to cancel volume restore of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to cancel volume restore"))
+		return
+	}
+	if !cancelled {
+		c.Error(apierror.NotFound("no volume restore is pending"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Volume restore cancelled"})
+}
+
+// getVolumeBackupServer returns the server in the path if the user owns it, it's stopped
+// and volume backups are configured. Otherwise it sets the error and returns nil.
+func (h *ServerHandler) getVolumeBackupServer(c *gin.Context) *models.Server {
+	server := h.getBackupServer(c)
+	if server == nil {
+		return nil
+	}
+	if !h.config.BackupReplicationEnabled() {
+		c.Error(apierror.BadRequest("volume backups are not available"))
+		return nil
+	}
+	if server.Status != models.ServerStatusStopped {
+		c.Error(apierror.InvalidServerState("server must be stopped to back up or restore its volume"))
+		return nil
+	}
+	return server
+}
+
+// getVolumeBackup returns the server's volume backup in the path. Otherwise it sets the
+// error and returns nil.
+func (h *ServerHandler) getVolumeBackup(c *gin.Context, server *models.Server) *models.VolumeBackup {
+	if _, err := uuid.Parse(c.Param("backupId")); err != nil {
+		c.Error(apierror.NotFound("volume backup not found"))
+		return nil
+	}
+
+	backup, err := h.db.GetVolumeBackup(c.Request.Context(), server.ID.String(), c.Param("backupId"))
+	if err != nil {
+		log.Printf("failed to get volume backup of server %s: %v", server.ID, err)
+		c.Error(apierror.Internal("failed to get volume backup"))
+		return nil
+	}
+	if backup == nil {
+		c.Error(apierror.NotFound("volume backup not found"))
+		return nil
+	}
+	return backup
+}
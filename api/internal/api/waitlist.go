@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+)
+
+// JoinCapacityWaitlist signs the user up to be offered capacity for the game and plan, in any
+// region or the one given, after checkout found none
+func (h *ServerHandler) JoinCapacityWaitlist(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	var req models.JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	if _, err := h.config.GetPriceID(req.Game, req.Plan); err != nil {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeInvalidGameOrPlan, err.Error()))
+		return
+	}
+
+	if req.Region != "" {
+		known, err := h.knownRegion(c.Request.Context(), req.Region)
+		if err != nil {
+			log.Printf("failed to get regions: %v", err)
+			c.Error(apierror.Internal("failed to join waitlist"))
+			return
+		}
+		if !known {
+			c.Error(apierror.BadRequest("unknown region"))
+			return
+		}
+	}
+
+	entry, err := h.db.JoinCapacityWaitlist(c.Request.Context(), userID, req.Game, req.Plan, req.Region)
+	if err != nil {
+		log.Printf("failed to join capacity waitlist for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to join waitlist"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"waitlist": entry})
+}
+
+// ListCapacityWaitlist returns the user's waitlist signups that are waiting or hold an
+// unexpired offer
+func (h *ServerHandler) ListCapacityWaitlist(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	entries, err := h.db.ListCapacityWaitlist(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to list capacity waitlist for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to list waitlist"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"waitlist": entries})
+}
+
+// LeaveCapacityWaitlist removes one of the user's waitlist signups, giving up its place in
+// line or its offer
+func (h *ServerHandler) LeaveCapacityWaitlist(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.Error(apierror.NotFound("waitlist entry not found"))
+		return
+	}
+
+	left, err := h.db.LeaveCapacityWaitlist(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		log.Printf("failed to leave capacity waitlist for user %s: %v", userID, err)
+		c.Error(apierror.Internal("failed to leave waitlist"))
+		return
+	}
+	if !left {
+		c.Error(apierror.NotFound("waitlist entry not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left the waitlist"})
+}
+
+// checkWaitlistOffer validates the waitlist offer a checkout came from, if any, and reports
+// whether another user holds an offer for the game and plan instead
+func (h *ServerHandler) checkWaitlistOffer(ctx context.Context, userID uuid.UUID, req models.CreateServerRequest) (bool, error) {
+	if req.WaitlistID != "" {
+		valid, err := h.db.WaitlistOfferValid(ctx, userID, req.WaitlistID, req.Game, req.Plan)
+		if err != nil {
+			log.Printf("failed to check waitlist offer %s: %v", req.WaitlistID, err)
+			return false, apierror.Internal("failed to check server availability")
+		}
+		if !valid {
+			return false, apierror.ErrWaitlistOfferInvalid
+		}
+		return false, nil
+	}
+
+	held, err := h.db.WaitlistOfferHeld(ctx, userID, req.Game, req.Plan)
+	if err != nil {
+		log.Printf("failed to check waitlist offers: %v", err)
+		return false, apierror.Internal("failed to check server availability")
+	}
+	return held, nil
+}
+
+// knownRegion reports whether any node is labeled with the region
+func (h *ServerHandler) knownRegion(ctx context.Context, region string) (bool, error) {
+	regions, err := h.db.GetRegionNodeStats(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range regions {
+		if r.Region == region {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// capacityUnavailable returns the CAPACITY_UNAVAILABLE error for a checkout, detailing what
+// ran out and when it's expected back. Custom games can't wait, as the waitlist only knows
+// catalog games.
+func (h *ServerHandler) capacityUnavailable(ctx context.Context, game string, portReqs []portalloc.PortRequirement, resourceReq *portalloc.ResourceRequirement) *apierror.Error {
+	shortage, err := h.portAllocService.Shortage(ctx, portReqs, resourceReq)
+	if err != nil {
+		log.Printf("failed to explain capacity shortage: %v", err)
+		return apierror.ErrCapacityUnavailable
+	}
+	shortage.Waitlist = game != string(models.GameCustom)
+	return apierror.ErrCapacityUnavailable.WithDetails(shortage)
+}
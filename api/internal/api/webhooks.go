@@ -0,0 +1,263 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/api/apierror"
+	"github.com/mooncorn/gshub/api/internal/api/middleware"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/webhook"
+)
+
+const (
+	// maxWebhooksPerServer caps how many webhooks a server can have
+	maxWebhooksPerServer = 5
+
+	// webhookDeliveryHistoryLimit is how many recent deliveries the delivery log returns
+	webhookDeliveryHistoryLimit = 50
+)
+
+// ListWebhooks returns the webhooks registered for a server. Secrets are not included.
+func (h *ServerHandler) ListWebhooks(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	webhooks, err := h.db.ListWebhooks(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to list webhooks for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to list webhooks"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+// CreateWebhook registers a webhook for a server. The signing secret is returned only in
+// this response; one is generated if the request doesn't provide it.
+func (h *ServerHandler) CreateWebhook(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.FromBindError(err))
+		return
+	}
+
+	if err := webhook.ValidateURL(req.URL); err != nil {
+		c.Error(apierror.New(http.StatusBadRequest, apierror.CodeValidation, "request validation failed").
+			WithDetails(map[string]string{"url": "must be a public http or https URL"}))
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	existing, err := h.db.ListWebhooks(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to list webhooks for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to create webhook"))
+		return
+	}
+	if len(existing) >= maxWebhooksPerServer {
+		c.Error(apierror.New(http.StatusConflict, apierror.CodeWebhookLimit,
+			"server already has the maximum number of webhooks").
+			WithDetails(gin.H{"limit": maxWebhooksPerServer}))
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			log.Printf("failed to generate webhook secret: %v", err)
+			c.Error(apierror.Internal("failed to create webhook"))
+			return
+		}
+	}
+
+	created, err := h.db.CreateWebhook(c.Request.Context(), serverID, req.URL, secret, req.Events)
+	if err != nil {
+		log.Printf("failed to create webhook for server %s: %v", serverID, err)
+		c.Error(apierror.Internal("failed to create webhook"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"webhook": created, "secret": secret})
+}
+
+// DeleteWebhook removes a server's webhook along with its delivery log
+func (h *ServerHandler) DeleteWebhook(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	webhookID, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		c.Error(apierror.ErrWebhookNotFound)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	deleted, err := h.db.DeleteWebhook(c.Request.Context(), serverID, webhookID.String())
+	if err != nil {
+		log.Printf("failed to delete webhook %s: %v", webhookID, err)
+		c.Error(apierror.Internal("failed to delete webhook"))
+		return
+	}
+	if !deleted {
+		c.Error(apierror.ErrWebhookNotFound)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries returns a webhook's most recent deliveries, newest first, with
+// each one's state, attempts and last response
+func (h *ServerHandler) ListWebhookDeliveries(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	if userIDStr == "" {
+		c.Error(apierror.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.Error(apierror.ErrInvalidUserID)
+		return
+	}
+
+	serverID := c.Param("id")
+	if serverID == "" {
+		c.Error(apierror.ErrServerIDRequired)
+		return
+	}
+
+	webhookID, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		c.Error(apierror.ErrWebhookNotFound)
+		return
+	}
+
+	server, err := h.db.GetServerByID(c.Request.Context(), serverID)
+	if err != nil {
+		log.Printf("failed to get server: %v", err)
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	if server.UserID != userID {
+		c.Error(apierror.ErrServerNotFound)
+		return
+	}
+
+	wh, err := h.db.GetWebhook(c.Request.Context(), serverID, webhookID.String())
+	if err != nil {
+		log.Printf("failed to get webhook %s: %v", webhookID, err)
+		c.Error(apierror.Internal("failed to list webhook deliveries"))
+		return
+	}
+	if wh == nil {
+		c.Error(apierror.ErrWebhookNotFound)
+		return
+	}
+
+	deliveries, err := h.db.ListWebhookDeliveries(c.Request.Context(), webhookID.String(), webhookDeliveryHistoryLimit)
+	if err != nil {
+		log.Printf("failed to list deliveries for webhook %s: %v", webhookID, err)
+		c.Error(apierror.Internal("failed to list webhook deliveries"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// generateWebhookSecret returns a random 32-byte hex secret
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
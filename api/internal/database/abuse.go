@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// AbuseSample is the anomaly tracking state kept between heartbeats
+type AbuseSample struct {
+	NetTxBytes      *int64
+	NetSampledAt    *time.Time
+	CPUAnomalySince *time.Time
+	NetAnomalySince *time.Time
+	Exempt          bool
+}
+
+// GetAbuseSample returns a server's anomaly tracking state
+func (db *DB) GetAbuseSample(ctx context.Context, serverID string) (*AbuseSample, error) {
+	query := `
+		SELECT net_tx_bytes, net_sampled_at, cpu_anomaly_since, net_anomaly_since, abuse_exempt
+		FROM servers
+		WHERE id = $1
+	`
+
+	var sample AbuseSample
+	err := db.Pool.QueryRow(ctx, query, serverID).Scan(
+		&sample.NetTxBytes, &sample.NetSampledAt, &sample.CPUAnomalySince, &sample.NetAnomalySince, &sample.Exempt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get abuse sample: %w", err)
+	}
+	return &sample, nil
+}
+
+// UpdateAbuseSample stores the latest heartbeat sample and anomaly start times
+func (db *DB) UpdateAbuseSample(ctx context.Context, serverID string, netTxBytes int64, sampledAt time.Time, cpuAnomalySince, netAnomalySince *time.Time) error {
+	query := `
+		UPDATE servers
+		SET net_tx_bytes = $2,
+		    net_sampled_at = $3,
+		    cpu_anomaly_since = $4,
+		    net_anomaly_since = $5
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID, netTxBytes, sampledAt, cpuAnomalySince, netAnomalySince); err != nil {
+		return fmt.Errorf("failed to update abuse sample: %w", err)
+	}
+	return nil
+}
+
+// SetServerAbuseExempt exempts a server from (or subjects it to) the anomaly rules
+func (db *DB) SetServerAbuseExempt(ctx context.Context, serverID string, exempt bool) error {
+	query := `
+		UPDATE servers
+		SET abuse_exempt = $2,
+		    cpu_anomaly_since = NULL,
+		    net_anomaly_since = NULL,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := db.Pool.Exec(ctx, query, serverID, exempt)
+	if err != nil {
+		return fmt.Errorf("failed to set abuse exemption: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("server not found: %s", serverID)
+	}
+	return nil
+}
+
+// ListBannedHashes returns every banned binary hash, newest first
+func (db *DB) ListBannedHashes(ctx context.Context) ([]models.BannedHash, error) {
+	query := `SELECT sha256, name, created_at FROM banned_hashes ORDER BY created_at DESC`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list banned hashes: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := []models.BannedHash{}
+	for rows.Next() {
+		var h models.BannedHash
+		if err := rows.Scan(&h.SHA256, &h.Name, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan banned hash: %w", err)
+		}
+		hashes = append(hashes, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list banned hashes: %w", err)
+	}
+
+	return hashes, nil
+}
+
+// AddBannedHash bans a binary hash, renaming it if it was already banned
+func (db *DB) AddBannedHash(ctx context.Context, sha256, name string) (*models.BannedHash, error) {
+	query := `
+		INSERT INTO banned_hashes (sha256, name)
+		VALUES ($1, $2)
+		ON CONFLICT (sha256) DO UPDATE SET name = EXCLUDED.name
+		RETURNING sha256, name, created_at
+	`
+
+	var h models.BannedHash
+	if err := db.Pool.QueryRow(ctx, query, sha256, name).Scan(&h.SHA256, &h.Name, &h.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to add banned hash: %w", err)
+	}
+	return &h, nil
+}
+
+// DeleteBannedHash unbans a binary hash. Returns false if it wasn't banned.
+func (db *DB) DeleteBannedHash(ctx context.Context, sha256 string) (bool, error) {
+	result, err := db.Pool.Exec(ctx, `DELETE FROM banned_hashes WHERE sha256 = $1`, sha256)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete banned hash: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// GetBannedHashName returns the name of a banned hash, or ("", false) if it isn't banned
+func (db *DB) GetBannedHashName(ctx context.Context, sha256 string) (string, bool, error) {
+	var name string
+	err := db.Pool.QueryRow(ctx, `SELECT name FROM banned_hashes WHERE sha256 = $1`, sha256).Scan(&name)
+	if err == pgx.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get banned hash: %w", err)
+	}
+	return name, true, nil
+}
+
+// CreateAbuseReport records why a server was suspended for abuse
+func (db *DB) CreateAbuseReport(ctx context.Context, serverID, rule, detail string) error {
+	query := `INSERT INTO abuse_reports (server_id, rule, detail) VALUES ($1, $2, $3)`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID, rule, detail); err != nil {
+		return fmt.Errorf("failed to create abuse report: %w", err)
+	}
+	return nil
+}
+
+// ListUnresolvedAbuseReports returns abuse reports whose suspension hasn't been lifted, oldest first
+func (db *DB) ListUnresolvedAbuseReports(ctx context.Context) ([]models.AbuseReport, error) {
+	query := `
+		SELECT a.id, a.server_id, u.email, a.rule, a.detail, a.created_at, a.resolved_at
+		FROM abuse_reports a
+		JOIN servers s ON s.id = a.server_id
+		JOIN users u ON u.id = s.user_id
+		WHERE a.resolved_at IS NULL
+		ORDER BY a.created_at ASC
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list abuse reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []models.AbuseReport{}
+	for rows.Next() {
+		var r models.AbuseReport
+		if err := rows.Scan(&r.ID, &r.ServerID, &r.UserEmail, &r.Rule, &r.Detail, &r.CreatedAt, &r.ResolvedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan abuse report: %w", err)
+		}
+		reports = append(reports, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list abuse reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// ResolveServerAbuseReports marks a server's open abuse reports as resolved
+func (db *DB) ResolveServerAbuseReports(ctx context.Context, serverID string) error {
+	query := `UPDATE abuse_reports SET resolved_at = NOW() WHERE server_id = $1 AND resolved_at IS NULL`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to resolve abuse reports: %w", err)
+	}
+	return nil
+}
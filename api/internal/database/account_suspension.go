@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// SuspendUser makes an account read-only. Returns (false, nil) if it was already suspended.
+func (db *DB) SuspendUser(ctx context.Context, userID uuid.UUID, reason string) (bool, error) {
+	query := `
+		UPDATE users
+		SET suspended_at = NOW(),
+		    suspension_reason = $2,
+		    reinstatement_requested_at = NULL,
+		    reinstatement_message = NULL,
+		    updated_at = NOW()
+		WHERE id = $1 AND suspended_at IS NULL
+	`
+
+	result, err := db.Pool.Exec(ctx, query, userID, reason)
+	if err != nil {
+		return false, fmt.Errorf("failed to suspend user: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// ReinstateUser lifts an account suspension. Returns (false, nil) if it wasn't suspended.
+func (db *DB) ReinstateUser(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE users
+		SET suspended_at = NULL,
+		    suspension_reason = NULL,
+		    reinstatement_requested_at = NULL,
+		    reinstatement_message = NULL,
+		    updated_at = NOW()
+		WHERE id = $1 AND suspended_at IS NOT NULL
+	`
+
+	result, err := db.Pool.Exec(ctx, query, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to reinstate user: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// IsUserSuspended reports whether an account is suspended
+func (db *DB) IsUserSuspended(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `SELECT suspended_at IS NOT NULL FROM users WHERE id = $1`
+
+	var suspended bool
+	err := db.Pool.QueryRow(ctx, query, userID).Scan(&suspended)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check user suspension: %w", err)
+	}
+	return suspended, nil
+}
+
+// RequestReinstatement records a suspended user's request to be reinstated, replacing
+// any earlier one. Returns (false, nil) if the account isn't suspended.
+func (db *DB) RequestReinstatement(ctx context.Context, userID uuid.UUID, message string) (bool, error) {
+	query := `
+		UPDATE users
+		SET reinstatement_requested_at = NOW(),
+		    reinstatement_message = $2,
+		    updated_at = NOW()
+		WHERE id = $1 AND suspended_at IS NOT NULL
+	`
+
+	result, err := db.Pool.Exec(ctx, query, userID, message)
+	if err != nil {
+		return false, fmt.Errorf("failed to request reinstatement: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// ListSuspendedUsers returns suspended accounts, those asking to be reinstated first
+func (db *DB) ListSuspendedUsers(ctx context.Context) ([]models.SuspendedUser, error) {
+	query := `
+		SELECT id, email, suspended_at, COALESCE(suspension_reason, ''), reinstatement_requested_at, reinstatement_message
+		FROM users
+		WHERE suspended_at IS NOT NULL
+		ORDER BY reinstatement_requested_at ASC NULLS LAST, suspended_at ASC
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suspended users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.SuspendedUser{}
+	for rows.Next() {
+		var u models.SuspendedUser
+		err := rows.Scan(&u.ID, &u.Email, &u.SuspendedAt, &u.SuspensionReason, &u.ReinstatementRequestedAt, &u.ReinstatementMessage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan suspended user: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list suspended users: %w", err)
+	}
+
+	return users, nil
+}
+
+// CountUserDisputes returns how many payment disputes a user has had
+func (db *DB) CountUserDisputes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM stripe_disputes WHERE user_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count disputes: %w", err)
+	}
+	return count, nil
+}
+
+// CountUserAbuseReports returns how many times a user's servers were suspended for abuse
+func (db *DB) CountUserAbuseReports(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM abuse_reports a
+		JOIN servers s ON s.id = a.server_id
+		WHERE s.user_id = $1
+	`
+
+	var count int
+	if err := db.Pool.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count abuse reports: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// ListAllServers returns servers across all users matching filter, most recently updated
+// first. Deleted servers are left out.
+func (db *DB) ListAllServers(ctx context.Context, filter models.AdminServerFilter) ([]models.AdminServer, error) {
+	conditions := []string{"s.status != 'deleted'"}
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "s.status = "+arg(filter.Status))
+	}
+	if filter.UserID != "" {
+		conditions = append(conditions, "s.user_id = "+arg(filter.UserID))
+	}
+	if filter.Search != "" {
+		pattern := arg("%" + filter.Search + "%")
+		conditions = append(conditions, fmt.Sprintf("(s.subdomain ILIKE %[1]s OR s.display_name ILIKE %[1]s OR u.email ILIKE %[1]s)", pattern))
+	}
+	if filter.Stuck {
+		conditions = append(conditions, "s.status IN ('pending', 'starting', 'stopping')",
+			"s.updated_at < NOW() - "+arg(int(models.StuckAfter.Seconds()))+" * interval '1 second'")
+	}
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = 50
+	}
+	query := `
+		SELECT s.id, s.user_id, u.email, s.display_name, s.subdomain, s.game, s.plan, s.status, s.status_message,
+		       s.status_reason, s.node_name, s.last_reconciled, s.last_heartbeat, s.created_at, s.updated_at
+		FROM servers s
+		JOIN users u ON u.id = s.user_id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY s.updated_at DESC
+		LIMIT ` + arg(limit) + ` OFFSET ` + arg(filter.Offset)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers: %w", err)
+	}
+	defer rows.Close()
+
+	servers := []models.AdminServer{}
+	for rows.Next() {
+		var s models.AdminServer
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserEmail, &s.DisplayName, &s.Subdomain, &s.Game, &s.Plan, &s.Status,
+			&s.StatusMessage, &s.StatusReason, &s.NodeName, &s.LastReconciled, &s.LastHeartbeat, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan server: %w", err)
+		}
+		servers = append(servers, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list servers: %w", err)
+	}
+	return servers, nil
+}
+
+// ListNodeCapacity returns each node's port pool from port_allocations, with how many
+// ports and how much CPU, memory and GPU the servers placed on it hold, ordered by name
+func (db *DB) ListNodeCapacity(ctx context.Context) ([]models.NodeCapacity, error) {
+	query := `
+		WITH ports AS (
+			SELECT node_id,
+			       COUNT(*) FILTER (WHERE protocol = 'TCP') AS tcp,
+			       COUNT(*) FILTER (WHERE protocol = 'TCP' AND server_id IS NOT NULL) AS tcp_used,
+			       COUNT(*) FILTER (WHERE protocol = 'UDP') AS udp,
+			       COUNT(*) FILTER (WHERE protocol = 'UDP' AND server_id IS NOT NULL) AS udp_used
+			FROM port_allocations
+			GROUP BY node_id
+		), placed AS (
+			SELECT DISTINCT pa.node_id, s.id, s.status, s.reserved_cpu_millicores, s.reserved_memory_bytes, s.reserved_gpus
+			FROM port_allocations pa
+			JOIN servers s ON s.id = pa.server_id
+		), reserved AS (
+			SELECT node_id,
+			       COUNT(*) AS servers,
+			       COALESCE(SUM(reserved_cpu_millicores) FILTER (WHERE status NOT IN ('deleted', 'expired', 'failed')), 0) AS cpu,
+			       COALESCE(SUM(reserved_memory_bytes) FILTER (WHERE status NOT IN ('deleted', 'expired', 'failed')), 0)::BIGINT AS memory,
+			       COALESCE(SUM(reserved_gpus) FILTER (WHERE status NOT IN ('deleted', 'expired', 'failed')), 0) AS gpus
+			FROM placed
+			GROUP BY node_id
+		)
+		SELECT n.name, n.region, n.zone, n.is_active, n.dedicated, n.spot, n.os,
+		       COALESCE(r.servers, 0), COALESCE(p.tcp, 0), COALESCE(p.tcp_used, 0), COALESCE(p.udp, 0), COALESCE(p.udp_used, 0),
+		       n.allocatable_cpu_millicores, COALESCE(r.cpu, 0), n.allocatable_memory_bytes, COALESCE(r.memory, 0),
+		       n.allocatable_gpus, COALESCE(r.gpus, 0), n.updated_at
+		FROM nodes n
+		LEFT JOIN ports p ON p.node_id = n.id
+		LEFT JOIN reserved r ON r.node_id = n.id
+		ORDER BY n.name
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node capacity: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := []models.NodeCapacity{}
+	for rows.Next() {
+		var n models.NodeCapacity
+		if err := rows.Scan(&n.Name, &n.Region, &n.Zone, &n.IsActive, &n.Dedicated, &n.Spot, &n.OS,
+			&n.Servers, &n.TCPPorts, &n.TCPPortsUsed, &n.UDPPorts, &n.UDPPortsUsed,
+			&n.CPUMillicores, &n.ReservedCPUMillicores, &n.MemoryBytes, &n.ReservedMemoryBytes,
+			&n.GPUs, &n.ReservedGPUs, &n.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan node capacity: %w", err)
+		}
+		nodes = append(nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list node capacity: %w", err)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// GetBackupPolicy returns a server's backup policy. Returns (nil, nil) if it has none.
+func (db *DB) GetBackupPolicy(ctx context.Context, serverID string) (*models.BackupPolicy, error) {
+	query := `SELECT keep_daily, keep_weekly, replicate, updated_at FROM server_backup_policies WHERE server_id = $1`
+
+	var policy models.BackupPolicy
+	err := db.Pool.QueryRow(ctx, query, serverID).Scan(&policy.KeepDaily, &policy.KeepWeekly, &policy.Replicate, &policy.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// SetBackupPolicy creates or replaces a server's backup policy
+func (db *DB) SetBackupPolicy(ctx context.Context, serverID string, policy models.BackupPolicy) (*models.BackupPolicy, error) {
+	query := `
+		INSERT INTO server_backup_policies (server_id, keep_daily, keep_weekly, replicate)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (server_id) DO UPDATE
+		SET keep_daily = EXCLUDED.keep_daily,
+		    keep_weekly = EXCLUDED.keep_weekly,
+		    replicate = EXCLUDED.replicate,
+		    updated_at = NOW()
+		RETURNING keep_daily, keep_weekly, replicate, updated_at
+	`
+
+	var saved models.BackupPolicy
+	err := db.Pool.QueryRow(ctx, query, serverID, policy.KeepDaily, policy.KeepWeekly, policy.Replicate).
+		Scan(&saved.KeepDaily, &saved.KeepWeekly, &saved.Replicate, &saved.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set backup policy: %w", err)
+	}
+	return &saved, nil
+}
+
+const backupReplicaColumns = `id, server_id, command_id, path, object_key, size, state, attempts, last_error, created_at, replicated_at`
+
+func scanBackupReplica(row pgx.Row) (*models.BackupReplica, error) {
+	var replica models.BackupReplica
+	if err := row.Scan(&replica.ID, &replica.ServerID, &replica.CommandID, &replica.Path, &replica.ObjectKey,
+		&replica.Size, &replica.State, &replica.Attempts, &replica.LastError, &replica.CreatedAt, &replica.ReplicatedAt); err != nil {
+		return nil, err
+	}
+	replica.Name = path.Base(replica.Path)
+	return &replica, nil
+}
+
+func (db *DB) queryBackupReplicas(ctx context.Context, query string, args ...any) ([]models.BackupReplica, error) {
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	replicas := []models.BackupReplica{}
+	for rows.Next() {
+		replica, err := scanBackupReplica(rows)
+		if err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, *replica)
+	}
+	return replicas, rows.Err()
+}
+
+// CreateBackupReplica queues a backup archive to be copied off-site. Backup tools that
+// overwrite the same archive queue it again, replacing the previous copy.
+func (db *DB) CreateBackupReplica(ctx context.Context, serverID string, commandID uuid.UUID, archivePath, objectKey string, size int64) error {
+	query := `
+		INSERT INTO backup_replicas (server_id, command_id, path, object_key, size)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (server_id, path) DO UPDATE
+		SET command_id = EXCLUDED.command_id,
+		    size = EXCLUDED.size,
+		    state = 'pending',
+		    attempts = 0,
+		    last_error = NULL,
+		    created_at = NOW(),
+		    replicated_at = NULL
+	`
+	if _, err := db.Pool.Exec(ctx, query, serverID, commandID, archivePath, objectKey, size); err != nil {
+		return fmt.Errorf("failed to create backup replica: %w", err)
+	}
+	return nil
+}
+
+// ListBackupReplicas returns a server's off-site backup copies, newest first
+func (db *DB) ListBackupReplicas(ctx context.Context, serverID string) ([]models.BackupReplica, error) {
+	query := `SELECT ` + backupReplicaColumns + ` FROM backup_replicas WHERE server_id = $1 ORDER BY created_at DESC`
+
+	replicas, err := db.queryBackupReplicas(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup replicas: %w", err)
+	}
+	return replicas, nil
+}
+
+// ListBackupReplicaWork returns up to limit replicas waiting to be removed off-site, or to
+// be copied from a running server, oldest first
+func (db *DB) ListBackupReplicaWork(ctx context.Context, limit int) ([]models.BackupReplica, error) {
+	query := `
+		SELECT ` + backupReplicaColumns + ` FROM backup_replicas
+		WHERE state = 'deleting'
+		   OR (state = 'pending' AND server_id IN (SELECT id FROM servers WHERE status = 'running'))
+		ORDER BY created_at
+		LIMIT $1
+	`
+
+	replicas, err := db.queryBackupReplicas(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup replica work: %w", err)
+	}
+	return replicas, nil
+}
+
+// MarkBackupReplicaReplicated records that a replica was copied off-site
+func (db *DB) MarkBackupReplicaReplicated(ctx context.Context, id uuid.UUID, size int64) error {
+	query := `
+		UPDATE backup_replicas
+		SET state = 'replicated', size = $2, last_error = NULL, replicated_at = NOW()
+		WHERE id = $1 AND state = 'pending'
+	`
+	if _, err := db.Pool.Exec(ctx, query, id, size); err != nil {
+		return fmt.Errorf("failed to mark backup replica replicated: %w", err)
+	}
+	return nil
+}
+
+// RecordBackupReplicaError records a failed attempt to copy or remove a replica. Copies
+// that failed maxAttempts times are given up on.
+func (db *DB) RecordBackupReplicaError(ctx context.Context, id uuid.UUID, message string, maxAttempts int) error {
+	query := `
+		UPDATE backup_replicas
+		SET attempts = attempts + 1,
+		    last_error = $2,
+		    state = CASE WHEN state = 'pending' AND attempts + 1 >= $3 THEN 'failed' ELSE state END
+		WHERE id = $1
+	`
+	if _, err := db.Pool.Exec(ctx, query, id, message, maxAttempts); err != nil {
+		return fmt.Errorf("failed to record backup replica error: %w", err)
+	}
+	return nil
+}
+
+// MarkBackupReplicasDeleting queues replicas to be removed off-site
+func (db *DB) MarkBackupReplicasDeleting(ctx context.Context, ids []uuid.UUID) error {
+	query := `UPDATE backup_replicas SET state = 'deleting', attempts = 0, last_error = NULL WHERE id = ANY($1)`
+	if _, err := db.Pool.Exec(ctx, query, ids); err != nil {
+		return fmt.Errorf("failed to mark backup replicas deleting: %w", err)
+	}
+	return nil
+}
+
+// MarkServerBackupReplicasDeleting queues all of a server's replicas to be removed
+// off-site, e.g. when the server is deleted. Copies that never made it off-site are
+// dropped right away.
+func (db *DB) MarkServerBackupReplicasDeleting(ctx context.Context, serverID string) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM backup_replicas WHERE server_id = $1 AND state IN ('pending', 'failed')`, serverID); err != nil {
+		return fmt.Errorf("failed to delete pending backup replicas: %w", err)
+	}
+	query := `UPDATE backup_replicas SET state = 'deleting', attempts = 0, last_error = NULL WHERE server_id = $1`
+	if _, err := db.Pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to mark backup replicas deleting: %w", err)
+	}
+	return nil
+}
+
+// DeleteBackupReplica forgets a replica once it's removed off-site
+func (db *DB) DeleteBackupReplica(ctx context.Context, id uuid.UUID) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM backup_replicas WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete backup replica: %w", err)
+	}
+	return nil
+}
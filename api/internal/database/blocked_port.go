@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// blockedPortColumns selects a blocked_ports row b with how many of its ports servers hold
+const blockedPortColumns = `b.id, b.min_port, b.max_port, b.protocol, b.reason, b.created_at,
+	(SELECT COUNT(*) FROM port_allocations pa
+	 WHERE pa.server_id IS NOT NULL
+	 AND pa.port BETWEEN b.min_port AND b.max_port
+	 AND (b.protocol IS NULL OR b.protocol = pa.protocol))`
+
+func scanBlockedPort(row pgx.Row) (*models.BlockedPort, error) {
+	var blocked models.BlockedPort
+	if err := row.Scan(&blocked.ID, &blocked.MinPort, &blocked.MaxPort, &blocked.Protocol,
+		&blocked.Reason, &blocked.CreatedAt, &blocked.Allocated); err != nil {
+		return nil, err
+	}
+	return &blocked, nil
+}
+
+// ListBlockedPorts returns the blocked port ranges, lowest first
+func (db *DB) ListBlockedPorts(ctx context.Context) ([]models.BlockedPort, error) {
+	query := `SELECT ` + blockedPortColumns + ` FROM blocked_ports b ORDER BY b.min_port, b.max_port`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked ports: %w", err)
+	}
+	defer rows.Close()
+
+	blocked := []models.BlockedPort{}
+	for rows.Next() {
+		port, err := scanBlockedPort(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blocked port: %w", err)
+		}
+		blocked = append(blocked, *port)
+	}
+	return blocked, rows.Err()
+}
+
+// BlockPorts blocks a range of ports (protocol "" for both) and removes its free ports from
+// every node. Ports servers hold stay theirs until released.
+func (db *DB) BlockPorts(ctx context.Context, minPort, maxPort int, protocol, reason string) (*models.BlockedPort, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	insertQuery := `
+		INSERT INTO blocked_ports (min_port, max_port, protocol, reason)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, insertQuery, minPort, maxPort, protocol, reason).Scan(&id); err != nil {
+		return nil, fmt.Errorf("failed to block ports: %w", err)
+	}
+
+	removeQuery := `
+		DELETE FROM port_allocations
+		WHERE server_id IS NULL
+		AND port BETWEEN $1 AND $2
+		AND ($3 = '' OR protocol = $3)
+	`
+	if _, err := tx.Exec(ctx, removeQuery, minPort, maxPort, protocol); err != nil {
+		return nil, fmt.Errorf("failed to remove blocked ports: %w", err)
+	}
+
+	blocked, err := scanBlockedPort(tx.QueryRow(ctx, `SELECT `+blockedPortColumns+` FROM blocked_ports b WHERE b.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked port: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return blocked, nil
+}
+
+// UnblockPorts removes a blocked range and gives every node back its ports within
+// [minPort, maxPort] that no other range blocks. Returns false if it doesn't exist.
+func (db *DB) UnblockPorts(ctx context.Context, id string, minPort, maxPort int) (bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM blocked_ports WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to unblock ports: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := backfillNodePorts(ctx, tx, minPort, maxPort); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// ListServersOnBlockedPorts returns the IDs of servers holding blocked ports
+func (db *DB) ListServersOnBlockedPorts(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT DISTINCT pa.server_id::text FROM port_allocations pa
+		JOIN blocked_ports b ON pa.port BETWEEN b.min_port AND b.max_port
+			AND (b.protocol IS NULL OR b.protocol = pa.protocol)
+		WHERE pa.server_id IS NOT NULL
+	`
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers on blocked ports: %w", err)
+	}
+	defer rows.Close()
+
+	serverIDs := []string{}
+	for rows.Next() {
+		var serverID string
+		if err := rows.Scan(&serverID); err != nil {
+			return nil, fmt.Errorf("failed to scan server id: %w", err)
+		}
+		serverIDs = append(serverIDs, serverID)
+	}
+	return serverIDs, rows.Err()
+}
+
+// HasBlockedPorts reports whether a server holds any blocked port
+func (db *DB) HasBlockedPorts(ctx context.Context, serverID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM port_allocations pa
+			JOIN blocked_ports b ON pa.port BETWEEN b.min_port AND b.max_port
+				AND (b.protocol IS NULL OR b.protocol = pa.protocol)
+			WHERE pa.server_id = $1
+		)
+	`
+	var blocked bool
+	if err := db.Pool.QueryRow(ctx, query, serverID).Scan(&blocked); err != nil {
+		return false, fmt.Errorf("failed to check blocked ports: %w", err)
+	}
+	return blocked, nil
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NodeHeadroom is what an active node has left for new servers
+type NodeHeadroom struct {
+	NodeID            uuid.UUID
+	FreeTCPPorts      int
+	FreeUDPPorts      int
+	FreeCPUMillicores int64
+	FreeMemoryBytes   int64
+	FreeGPUs          int
+	PlanServers       int        // Active servers on the plan asked about
+	DedicatedServerID *uuid.UUID // Set while a dedicated node is taken
+}
+
+// ScheduledRelease is a server whose subscription is set to end, and what that frees on
+// its node
+type ScheduledRelease struct {
+	NodeID        uuid.UUID
+	ServerID      uuid.UUID
+	CancelAt      time.Time
+	TCPPorts      int
+	UDPPorts      int
+	CPUMillicores int64
+	MemoryBytes   int64
+	GPUs          int
+	Plan          string
+}
+
+// ListNodeHeadroom returns the headroom of every active node of a kind (dedicated, spot and
+// OS, "" = linux) in region ("" = any), counting the active servers on plan for its
+// co-tenancy cap. Like CheckResourceCapacity it counts reservations of servers that aren't
+// deleted, expired or failed.
+func (db *DB) ListNodeHeadroom(ctx context.Context, dedicated, spot bool, os, plan, region string) ([]NodeHeadroom, error) {
+	query := `
+		WITH active AS (
+			SELECT DISTINCT pa.node_id, s.id, s.plan, s.reserved_cpu_millicores, s.reserved_memory_bytes, s.reserved_gpus
+			FROM servers s
+			JOIN port_allocations pa ON pa.server_id = s.id
+			WHERE s.status NOT IN ('deleted', 'expired', 'failed')
+		)
+		SELECT n.id,
+		       (SELECT COUNT(*) FROM port_allocations pa WHERE pa.node_id = n.id AND pa.server_id IS NULL AND pa.protocol = 'TCP'),
+		       (SELECT COUNT(*) FROM port_allocations pa WHERE pa.node_id = n.id AND pa.server_id IS NULL AND pa.protocol = 'UDP'),
+		       n.allocatable_cpu_millicores - COALESCE((SELECT SUM(a.reserved_cpu_millicores) FROM active a WHERE a.node_id = n.id), 0)::bigint,
+		       n.allocatable_memory_bytes - COALESCE((SELECT SUM(a.reserved_memory_bytes) FROM active a WHERE a.node_id = n.id), 0)::bigint,
+		       n.allocatable_gpus - COALESCE((SELECT SUM(a.reserved_gpus) FROM active a WHERE a.node_id = n.id), 0),
+		       (SELECT COUNT(*) FROM active a WHERE a.node_id = n.id AND a.plan = $4),
+		       n.dedicated_server_id
+		FROM nodes n
+		WHERE n.is_active = TRUE
+		  AND n.allocatable_cpu_millicores IS NOT NULL
+		  AND n.allocatable_memory_bytes IS NOT NULL
+		  AND n.dedicated = $1
+		  AND n.spot = $2
+		  AND n.os = $3
+		  AND ($5 = '' OR n.region = $5)
+	`
+
+	rows, err := db.Pool.Query(ctx, query, dedicated, spot, nodeOS(os), plan, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node headroom: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []NodeHeadroom
+	for rows.Next() {
+		var n NodeHeadroom
+		if err := rows.Scan(&n.NodeID, &n.FreeTCPPorts, &n.FreeUDPPorts, &n.FreeCPUMillicores, &n.FreeMemoryBytes,
+			&n.FreeGPUs, &n.PlanServers, &n.DedicatedServerID); err != nil {
+			return nil, fmt.Errorf("failed to scan node headroom: %w", err)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+// ListScheduledReleases returns the active servers on nodes of a kind in region ("" = any)
+// whose subscriptions are set to end, soonest first
+func (db *DB) ListScheduledReleases(ctx context.Context, dedicated, spot bool, os, region string) ([]ScheduledRelease, error) {
+	query := `
+		SELECT n.id, s.id, s.cancel_at,
+		       COUNT(*) FILTER (WHERE pa.protocol = 'TCP'),
+		       COUNT(*) FILTER (WHERE pa.protocol = 'UDP'),
+		       COALESCE(s.reserved_cpu_millicores, 0), COALESCE(s.reserved_memory_bytes, 0), s.reserved_gpus, s.plan
+		FROM servers s
+		JOIN port_allocations pa ON pa.server_id = s.id
+		JOIN nodes n ON n.id = pa.node_id
+		WHERE s.cancel_at > NOW()
+		  AND s.status NOT IN ('deleted', 'expired', 'failed')
+		  AND n.is_active = TRUE
+		  AND n.dedicated = $1
+		  AND n.spot = $2
+		  AND n.os = $3
+		  AND ($4 = '' OR n.region = $4)
+		GROUP BY n.id, s.id
+		ORDER BY s.cancel_at
+	`
+
+	rows, err := db.Pool.Query(ctx, query, dedicated, spot, nodeOS(os), region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled releases: %w", err)
+	}
+	defer rows.Close()
+
+	var releases []ScheduledRelease
+	for rows.Next() {
+		var r ScheduledRelease
+		if err := rows.Scan(&r.NodeID, &r.ServerID, &r.CancelAt, &r.TCPPorts, &r.UDPPorts,
+			&r.CPUMillicores, &r.MemoryBytes, &r.GPUs, &r.Plan); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled release: %w", err)
+		}
+		releases = append(releases, r)
+	}
+	return releases, rows.Err()
+}
+
+// SetServerCancelAt records when a server's subscription is set to end (nil if it renews)
+func (db *DB) SetServerCancelAt(ctx context.Context, serverID string, cancelAt *time.Time) error {
+	query := `UPDATE servers SET cancel_at = $2 WHERE id = $1`
+	if _, err := db.Pool.Exec(ctx, query, serverID, cancelAt); err != nil {
+		return fmt.Errorf("failed to set server cancel time: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// CardExpiryTarget is a Stripe customer whose saved card is checked against their renewals
+type CardExpiryTarget struct {
+	UserID           uuid.UUID
+	Email            string
+	StripeCustomerID string
+	SubscriptionIDs  []string
+}
+
+// GetCardExpiryTargets returns users with a Stripe customer and active subscriptions
+func (db *DB) GetCardExpiryTargets(ctx context.Context) ([]CardExpiryTarget, error) {
+	query := `
+		SELECT u.id, u.email, u.stripe_customer_id, array_agg(s.stripe_subscription_id)
+		FROM users u
+		JOIN servers s ON s.user_id = u.id
+		WHERE u.stripe_customer_id IS NOT NULL AND u.stripe_customer_id <> ''
+		  AND s.stripe_subscription_id IS NOT NULL AND s.stripe_subscription_id <> ''
+		  AND s.status NOT IN ('expired', 'deleting', 'deleted')
+		GROUP BY u.id
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get card expiry targets: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []CardExpiryTarget
+	for rows.Next() {
+		var t CardExpiryTarget
+		if err := rows.Scan(&t.UserID, &t.Email, &t.StripeCustomerID, &t.SubscriptionIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan card expiry target: %w", err)
+		}
+		targets = append(targets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get card expiry targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+// CardExpiryWarned reports whether a user was already warned about a card's expiry
+func (db *DB) CardExpiryWarned(ctx context.Context, userID uuid.UUID, paymentMethodID string, expMonth, expYear int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM card_expiry_warnings
+			WHERE user_id = $1 AND payment_method_id = $2 AND exp_month = $3 AND exp_year = $4
+		)
+	`
+
+	var warned bool
+	if err := db.Pool.QueryRow(ctx, query, userID, paymentMethodID, expMonth, expYear).Scan(&warned); err != nil {
+		return false, fmt.Errorf("failed to check card expiry warning: %w", err)
+	}
+	return warned, nil
+}
+
+// MarkCardExpiryWarned records that a user was warned about a card's expiry
+func (db *DB) MarkCardExpiryWarned(ctx context.Context, userID uuid.UUID, paymentMethodID string, expMonth, expYear int64) error {
+	query := `
+		INSERT INTO card_expiry_warnings (user_id, payment_method_id, exp_month, exp_year)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, userID, paymentMethodID, expMonth, expYear); err != nil {
+		return fmt.Errorf("failed to mark card expiry warned: %w", err)
+	}
+	return nil
+}
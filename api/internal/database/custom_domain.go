@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const customDomainColumns = `id, server_id, domain, verification_token, status, last_error, checked_at, verified_at, created_at`
+
+func scanCustomDomain(row pgx.Row) (*models.CustomDomain, error) {
+	var domain models.CustomDomain
+	if err := row.Scan(&domain.ID, &domain.ServerID, &domain.Domain, &domain.VerificationToken, &domain.Status,
+		&domain.LastError, &domain.CheckedAt, &domain.VerifiedAt, &domain.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// CreateCustomDomain adds a pending custom domain to a server
+func (db *DB) CreateCustomDomain(ctx context.Context, serverID, domain, token string) (*models.CustomDomain, error) {
+	query := `
+		INSERT INTO custom_domains (server_id, domain, verification_token)
+		VALUES ($1, $2, $3)
+		RETURNING ` + customDomainColumns
+
+	created, err := scanCustomDomain(db.Pool.QueryRow(ctx, query, serverID, domain, token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create custom domain: %w", err)
+	}
+	return created, nil
+}
+
+// ListCustomDomains returns a server's custom domains, oldest first
+func (db *DB) ListCustomDomains(ctx context.Context, serverID string) ([]models.CustomDomain, error) {
+	query := `SELECT ` + customDomainColumns + ` FROM custom_domains WHERE server_id = $1 ORDER BY created_at`
+
+	rows, err := db.Pool.Query(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom domains: %w", err)
+	}
+	defer rows.Close()
+
+	domains := []models.CustomDomain{}
+	for rows.Next() {
+		domain, err := scanCustomDomain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom domain: %w", err)
+		}
+		domains = append(domains, *domain)
+	}
+	return domains, nil
+}
+
+// GetCustomDomain retrieves a server's custom domain by ID. Returns (nil, nil) if it doesn't exist.
+func (db *DB) GetCustomDomain(ctx context.Context, serverID, domainID string) (*models.CustomDomain, error) {
+	query := `SELECT ` + customDomainColumns + ` FROM custom_domains WHERE id = $1 AND server_id = $2`
+
+	domain, err := scanCustomDomain(db.Pool.QueryRow(ctx, query, domainID, serverID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom domain: %w", err)
+	}
+	return domain, nil
+}
+
+// CustomDomainVerifiedElsewhere reports whether another server has the domain verified
+func (db *DB) CustomDomainVerifiedElsewhere(ctx context.Context, serverID, domain string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM custom_domains
+			WHERE domain = $1 AND server_id != $2 AND status = 'verified'
+		)
+	`
+
+	var exists bool
+	if err := db.Pool.QueryRow(ctx, query, domain, serverID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check custom domain: %w", err)
+	}
+	return exists, nil
+}
+
+// MarkCustomDomainVerified marks a custom domain verified. Returns false if another server
+// has the same domain verified.
+func (db *DB) MarkCustomDomainVerified(ctx context.Context, domainID string) (bool, error) {
+	query := `
+		UPDATE custom_domains d
+		SET status = 'verified', last_error = NULL, checked_at = NOW(),
+		    verified_at = COALESCE(d.verified_at, NOW())
+		WHERE d.id = $1
+		AND NOT EXISTS (
+			SELECT 1 FROM custom_domains o
+			WHERE o.domain = d.domain AND o.id != d.id AND o.status = 'verified'
+		)
+	`
+
+	tag, err := db.Pool.Exec(ctx, query, domainID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark custom domain verified: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// MarkCustomDomainFailed records a failed verification. A verified domain loses its
+// verification, so the domain can be claimed by whoever controls its DNS now.
+func (db *DB) MarkCustomDomainFailed(ctx context.Context, domainID, reason string) error {
+	query := `
+		UPDATE custom_domains
+		SET status = 'failed', last_error = $2, checked_at = NOW(), verified_at = NULL
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, domainID, reason); err != nil {
+		return fmt.Errorf("failed to mark custom domain failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteCustomDomain removes a server's custom domain. Returns false if it doesn't exist.
+func (db *DB) DeleteCustomDomain(ctx context.Context, serverID, domainID string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM custom_domains WHERE id = $1 AND server_id = $2`, domainID, serverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete custom domain: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
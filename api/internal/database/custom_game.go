@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// SetServerCustomGame saves a custom game server's definition
+func (db *DB) SetServerCustomGame(ctx context.Context, serverID string, def *models.CustomGame) error {
+	definition, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("failed to marshal custom game: %w", err)
+	}
+
+	query := `
+		INSERT INTO server_custom_games (server_id, definition)
+		VALUES ($1, $2)
+		ON CONFLICT (server_id) DO UPDATE SET definition = EXCLUDED.definition, updated_at = NOW()
+	`
+	if _, err := db.Pool.Exec(ctx, query, serverID, definition); err != nil {
+		return fmt.Errorf("failed to set custom game: %w", err)
+	}
+	return nil
+}
+
+// GetServerCustomGame retrieves a custom game server's definition. Returns (nil, nil) if the
+// server has none.
+func (db *DB) GetServerCustomGame(ctx context.Context, serverID string) (*models.CustomGame, error) {
+	var definition []byte
+	err := db.Pool.QueryRow(ctx, `SELECT definition FROM server_custom_games WHERE server_id = $1`, serverID).Scan(&definition)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom game: %w", err)
+	}
+
+	var def models.CustomGame
+	if err := json.Unmarshal(definition, &def); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal custom game: %w", err)
+	}
+	return &def, nil
+}
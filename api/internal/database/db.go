@@ -52,6 +52,12 @@ func Connect(databaseURL string) (*DB, error) {
 	return &DB{Pool: pool}, nil
 }
 
+// Health checks that the database answers queries
+func (db *DB) Health(ctx context.Context) error {
+	var one int
+	return db.Pool.QueryRow(ctx, "SELECT 1").Scan(&one)
+}
+
 // Close closes the database connection pool
 func (db *DB) Close() {
 	if pool, ok := db.Pool.(*pgxpool.Pool); ok {
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// RecordServerCrash counts a crash in the server's usage for the current hour
+func (db *DB) RecordServerCrash(ctx context.Context, serverID string) error {
+	query := `
+		INSERT INTO server_resource_usage (server_id, hour, crashes)
+		VALUES ($1, date_trunc('hour', NOW()), 1)
+		ON CONFLICT (server_id, hour) DO UPDATE
+		SET crashes = server_resource_usage.crashes + 1
+	`
+	if _, err := db.Pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to record server crash: %w", err)
+	}
+	return nil
+}
+
+// DigestServer is a server's activity over the last week for the weekly digest, with the
+// average usage of the week before to show the trend. Averages are nil if the server didn't
+// run in that week.
+type DigestServer struct {
+	UserID               uuid.UUID
+	Email                string
+	ServerID             uuid.UUID
+	DisplayName          string
+	Game                 models.GameType
+	Plan                 models.ServerPlan
+	Status               models.ServerStatus
+	StripeSubscriptionID *string
+	UptimeHours          int  // Hours with at least one heartbeat from a running game
+	PeakPlayers          *int // nil if the game doesn't report players
+	Crashes              int
+	AvgCPUPercent        *float64
+	AvgMemoryMB          *float64
+	PrevAvgCPUPercent    *float64
+	PrevAvgMemoryMB      *float64
+}
+
+// ListDigestServers returns the servers of users who get the weekly digest and weren't sent
+// it within interval, grouped by user, with their activity since weekStart and the week
+// before it
+func (db *DB) ListDigestServers(ctx context.Context, interval time.Duration, weekStart time.Time) ([]DigestServer, error) {
+	query := `
+		SELECT u.id, u.email, s.id, s.display_name, s.game, s.plan, s.status, s.stripe_subscription_id,
+			COUNT(*) FILTER (WHERE r.hour >= $2 AND r.samples > 0),
+			MAX(r.players_max) FILTER (WHERE r.hour >= $2),
+			COALESCE(SUM(r.crashes) FILTER (WHERE r.hour >= $2), 0),
+			(SUM(r.cpu_percent_sum) FILTER (WHERE r.hour >= $2)
+				/ NULLIF(SUM(r.samples) FILTER (WHERE r.hour >= $2), 0))::float8,
+			(SUM(r.memory_mb_sum) FILTER (WHERE r.hour >= $2)
+				/ NULLIF(SUM(r.samples) FILTER (WHERE r.hour >= $2), 0))::float8,
+			(SUM(r.cpu_percent_sum) FILTER (WHERE r.hour < $2)
+				/ NULLIF(SUM(r.samples) FILTER (WHERE r.hour < $2), 0))::float8,
+			(SUM(r.memory_mb_sum) FILTER (WHERE r.hour < $2)
+				/ NULLIF(SUM(r.samples) FILTER (WHERE r.hour < $2), 0))::float8
+		FROM users u
+		JOIN servers s ON s.user_id = u.id
+		LEFT JOIN server_resource_usage r ON r.server_id = s.id AND r.hour >= $2::timestamptz - interval '7 days'
+		WHERE u.weekly_digest AND s.status != 'deleted'
+		AND (u.digest_emailed_at IS NULL OR u.digest_emailed_at < NOW() - $1 * interval '1 second')
+		GROUP BY u.id, s.id
+		ORDER BY u.id, s.display_name
+	`
+
+	rows, err := db.Pool.Query(ctx, query, interval.Seconds(), weekStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digest servers: %w", err)
+	}
+	defer rows.Close()
+
+	var servers []DigestServer
+	for rows.Next() {
+		var s DigestServer
+		if err := rows.Scan(&s.UserID, &s.Email, &s.ServerID, &s.DisplayName, &s.Game, &s.Plan, &s.Status,
+			&s.StripeSubscriptionID, &s.UptimeHours, &s.PeakPlayers, &s.Crashes,
+			&s.AvgCPUPercent, &s.AvgMemoryMB, &s.PrevAvgCPUPercent, &s.PrevAvgMemoryMB); err != nil {
+			return nil, fmt.Errorf("failed to scan digest server: %w", err)
+		}
+		servers = append(servers, s)
+	}
+	return servers, rows.Err()
+}
+
+// MarkDigestEmailed records that a user was sent the weekly digest
+func (db *DB) MarkDigestEmailed(ctx context.Context, userID uuid.UUID) error {
+	if _, err := db.Pool.Exec(ctx, `UPDATE users SET digest_emailed_at = NOW() WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to mark digest emailed: %w", err)
+	}
+	return nil
+}
+
+// GetEmailPreferences returns which optional emails a user gets
+func (db *DB) GetEmailPreferences(ctx context.Context, userID uuid.UUID) (*models.EmailPreferences, error) {
+	var prefs models.EmailPreferences
+	if err := db.Pool.QueryRow(ctx, `SELECT weekly_digest FROM users WHERE id = $1`, userID).Scan(&prefs.WeeklyDigest); err != nil {
+		return nil, fmt.Errorf("failed to get email preferences: %w", err)
+	}
+	return &prefs, nil
+}
+
+// UpdateEmailPreferences sets which optional emails a user gets
+func (db *DB) UpdateEmailPreferences(ctx context.Context, userID uuid.UUID, prefs models.EmailPreferences) error {
+	query := `UPDATE users SET weekly_digest = $2, updated_at = NOW() WHERE id = $1`
+	if _, err := db.Pool.Exec(ctx, query, userID, prefs.WeeklyDigest); err != nil {
+		return fmt.Errorf("failed to update email preferences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const discordLinkColumns = `user_id, discord_user_id, discord_username, linked_at`
+
+func scanDiscordLink(row pgx.Row) (*models.DiscordLink, error) {
+	var link models.DiscordLink
+	if err := row.Scan(&link.UserID, &link.DiscordUserID, &link.DiscordUsername, &link.LinkedAt); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// CreateDiscordLinkCode stores a one-time link code for a user, replacing any earlier one
+func (db *DB) CreateDiscordLinkCode(ctx context.Context, userID uuid.UUID, code string, expiresAt time.Time) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM discord_link_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete old discord link codes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO discord_link_codes (code, user_id, expires_at) VALUES ($1, $2, $3)`,
+		code, userID, expiresAt); err != nil {
+		return fmt.Errorf("failed to create discord link code: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// LinkDiscordAccount consumes a link code and links the Discord account to the code's
+// user, replacing a previous link of either. Returns (nil, nil) if the code is unknown or
+// expired.
+func (db *DB) LinkDiscordAccount(ctx context.Context, code, discordUserID, discordUsername string) (*models.DiscordLink, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var userID uuid.UUID
+	err = tx.QueryRow(ctx, `DELETE FROM discord_link_codes WHERE code = $1 AND expires_at > NOW() RETURNING user_id`, code).
+		Scan(&userID)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume discord link code: %w", err)
+	}
+
+	// A Discord account links to one user at a time
+	if _, err := tx.Exec(ctx, `DELETE FROM discord_links WHERE discord_user_id = $1 AND user_id <> $2`, discordUserID, userID); err != nil {
+		return nil, fmt.Errorf("failed to unlink previous discord link: %w", err)
+	}
+
+	query := `
+		INSERT INTO discord_links (user_id, discord_user_id, discord_username)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET discord_user_id = EXCLUDED.discord_user_id,
+		    discord_username = EXCLUDED.discord_username,
+		    linked_at = NOW()
+		RETURNING ` + discordLinkColumns
+	link, err := scanDiscordLink(tx.QueryRow(ctx, query, userID, discordUserID, discordUsername))
+	if err != nil {
+		return nil, fmt.Errorf("failed to link discord account: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return link, nil
+}
+
+// GetDiscordLinkByUser returns a user's linked Discord account. Returns (nil, nil) if none is linked.
+func (db *DB) GetDiscordLinkByUser(ctx context.Context, userID uuid.UUID) (*models.DiscordLink, error) {
+	link, err := scanDiscordLink(db.Pool.QueryRow(ctx,
+		`SELECT `+discordLinkColumns+` FROM discord_links WHERE user_id = $1`, userID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discord link: %w", err)
+	}
+	return link, nil
+}
+
+// GetDiscordLinkByDiscordUser returns the link of a Discord account. Returns (nil, nil) if it isn't linked.
+func (db *DB) GetDiscordLinkByDiscordUser(ctx context.Context, discordUserID string) (*models.DiscordLink, error) {
+	link, err := scanDiscordLink(db.Pool.QueryRow(ctx,
+		`SELECT `+discordLinkColumns+` FROM discord_links WHERE discord_user_id = $1`, discordUserID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discord link: %w", err)
+	}
+	return link, nil
+}
+
+// DeleteDiscordLink unlinks a user's Discord account. Returns false if none was linked.
+func (db *DB) DeleteDiscordLink(ctx context.Context, userID uuid.UUID) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM discord_links WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete discord link: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
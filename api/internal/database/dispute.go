@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// CreateDispute records a dispute. Returns (false, nil) if it was already recorded.
+func (db *DB) CreateDispute(ctx context.Context, d *models.Dispute) (bool, error) {
+	query := `
+		INSERT INTO stripe_disputes (stripe_dispute_id, payment_intent_id, server_id, user_id, amount, currency, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (stripe_dispute_id) DO NOTHING
+	`
+
+	result, err := db.Pool.Exec(ctx, query, d.StripeDisputeID, d.PaymentIntentID, d.ServerID, d.UserID,
+		d.Amount, d.Currency, d.Reason)
+	if err != nil {
+		return false, fmt.Errorf("failed to create dispute: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// ListUnresolvedDisputes returns disputes whose suspension hasn't been lifted, oldest first
+func (db *DB) ListUnresolvedDisputes(ctx context.Context) ([]models.Dispute, error) {
+	query := `
+		SELECT d.id, d.stripe_dispute_id, d.payment_intent_id, d.server_id, d.user_id, u.email,
+		       d.amount, d.currency, d.reason, d.created_at, d.resolved_at
+		FROM stripe_disputes d
+		LEFT JOIN users u ON u.id = d.user_id
+		WHERE d.resolved_at IS NULL
+		ORDER BY d.created_at ASC
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+	defer rows.Close()
+
+	disputes := []models.Dispute{}
+	for rows.Next() {
+		var d models.Dispute
+		err := rows.Scan(&d.ID, &d.StripeDisputeID, &d.PaymentIntentID, &d.ServerID, &d.UserID, &d.UserEmail,
+			&d.Amount, &d.Currency, &d.Reason, &d.CreatedAt, &d.ResolvedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dispute: %w", err)
+		}
+		disputes = append(disputes, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+
+	return disputes, nil
+}
+
+// ResolveServerDisputes marks a server's open disputes as resolved
+func (db *DB) ResolveServerDisputes(ctx context.Context, serverID string) error {
+	query := `UPDATE stripe_disputes SET resolved_at = NOW() WHERE server_id = $1 AND resolved_at IS NULL`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to resolve disputes: %w", err)
+	}
+	return nil
+}
+
+// FlagUser marks a user for review, blocking their checkouts. An existing flag is kept.
+func (db *DB) FlagUser(ctx context.Context, userID uuid.UUID, reason string) error {
+	query := `
+		UPDATE users
+		SET flagged_at = COALESCE(flagged_at, NOW()),
+		    flag_reason = COALESCE(flag_reason, $2),
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, userID, reason); err != nil {
+		return fmt.Errorf("failed to flag user: %w", err)
+	}
+	return nil
+}
+
+// ClearUserFlag removes a user's review flag
+func (db *DB) ClearUserFlag(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE users SET flagged_at = NULL, flag_reason = NULL, updated_at = NOW() WHERE id = $1`
+
+	if _, err := db.Pool.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to clear user flag: %w", err)
+	}
+	return nil
+}
+
+// IsUserFlagged reports whether a user is flagged for review
+func (db *DB) IsUserFlagged(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `SELECT flagged_at IS NOT NULL FROM users WHERE id = $1`
+
+	var flagged bool
+	err := db.Pool.QueryRow(ctx, query, userID).Scan(&flagged)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check user flag: %w", err)
+	}
+	return flagged, nil
+}
+
+// SuspendServer moves a server to suspended from any state except expired or being deleted.
+// Returns (false, nil) if the server was already suspended or can't be.
+func (db *DB) SuspendServer(ctx context.Context, id string, reason models.StatusReason, message string) (bool, error) {
+	query := `
+		UPDATE servers
+		SET status = 'suspended',
+		    status_message = $3,
+		    status_reason = $2,
+		    suspended_at = NOW(),
+		    reserved_cpu_millicores = NULL,
+		    reserved_memory_bytes = NULL,
+		    updated_at = NOW()
+		WHERE id = $1 AND status NOT IN ('suspended', 'expired', 'deleting', 'deleted')
+	`
+
+	result, err := db.Pool.Exec(ctx, query, id, string(reason), message)
+	if err != nil {
+		return false, fmt.Errorf("failed to suspend server: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// LiftServerSuspension returns a suspended server to stopped so its owner can start it.
+// Returns (false, nil) if the server isn't suspended.
+func (db *DB) LiftServerSuspension(ctx context.Context, id string) (bool, error) {
+	query := `
+		UPDATE servers
+		SET status = 'stopped',
+		    status_message = 'Suspension lifted',
+		    status_reason = NULL,
+		    suspended_at = NULL,
+		    cpu_anomaly_since = NULL,
+		    net_anomaly_since = NULL,
+		    stopped_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'suspended'
+	`
+
+	result, err := db.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to lift suspension: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// edgePortLockID serializes edge port assignment across API replicas
+const edgePortLockID = 4816 // arbitrary unique number
+
+// AssignEdgePorts gives a server a port in [minPort, maxPort] on the edge proxy for each
+// requirement it doesn't have one for yet, picking the lowest free port per protocol.
+// Ports of deleted servers are reclaimed first.
+func (db *DB) AssignEdgePorts(ctx context.Context, serverID uuid.UUID, requirements []PortRequirement, minPort, maxPort int) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", edgePortLockID); err != nil {
+		return fmt.Errorf("failed to acquire edge port lock: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM edge_ports e
+		USING servers s
+		WHERE s.id = e.server_id AND s.status = 'deleted'
+	`); err != nil {
+		return fmt.Errorf("failed to reclaim edge ports: %w", err)
+	}
+
+	for _, req := range requirements {
+		query := `
+			INSERT INTO edge_ports (port, protocol, server_id, port_name)
+			SELECT p, $2, $1, $3
+			FROM generate_series($4::int, $5::int) p
+			WHERE NOT EXISTS (SELECT 1 FROM edge_ports e WHERE e.port = p AND e.protocol = $2)
+			AND NOT EXISTS (SELECT 1 FROM edge_ports e WHERE e.server_id = $1 AND e.port_name = $3)
+			ORDER BY p
+			LIMIT 1
+		`
+
+		tag, err := tx.Exec(ctx, query, serverID, req.Protocol, req.Name, minPort, maxPort)
+		if err != nil {
+			return fmt.Errorf("failed to assign edge port %s: %w", req.Name, err)
+		}
+		if tag.RowsAffected() == 0 {
+			var exists bool
+			if err := tx.QueryRow(ctx,
+				`SELECT EXISTS (SELECT 1 FROM edge_ports WHERE server_id = $1 AND port_name = $2)`,
+				serverID, req.Name).Scan(&exists); err != nil {
+				return fmt.Errorf("failed to check edge port %s: %w", req.Name, err)
+			}
+			if !exists {
+				return fmt.Errorf("no free %s edge port for %s", req.Protocol, req.Name)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetServerEdgePorts returns a server's edge ports. Address is left for the caller to set.
+func (db *DB) GetServerEdgePorts(ctx context.Context, serverID string) ([]models.EdgePort, error) {
+	query := `
+		SELECT port_name, port, protocol
+		FROM edge_ports
+		WHERE server_id = $1
+		ORDER BY port_name
+	`
+
+	rows, err := db.Pool.Query(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server edge ports: %w", err)
+	}
+	defer rows.Close()
+
+	var ports []models.EdgePort
+	for rows.Next() {
+		var port models.EdgePort
+		if err := rows.Scan(&port.Name, &port.Port, &port.Protocol); err != nil {
+			return nil, fmt.Errorf("failed to scan edge port: %w", err)
+		}
+		ports = append(ports, port)
+	}
+	return ports, rows.Err()
+}
+
+// ListEdgeRoutes returns every edge port with the node port it currently forwards to. A
+// route is healthy when its server is running and sent a heartbeat within
+// heartbeatFreshness.
+func (db *DB) ListEdgeRoutes(ctx context.Context, heartbeatFreshness time.Duration) ([]models.EdgeRoute, error) {
+	query := `
+		SELECT e.port, e.protocol, e.server_id, n.public_ip, pa.port,
+			s.status = 'running' AND s.last_heartbeat > NOW() - $1 * interval '1 second'
+		FROM edge_ports e
+		JOIN servers s ON s.id = e.server_id
+		LEFT JOIN port_allocations pa
+			ON pa.server_id = e.server_id AND pa.port_name = e.port_name AND pa.protocol = e.protocol
+		LEFT JOIN nodes n ON n.id = pa.node_id
+		WHERE s.status != 'deleted'
+		ORDER BY e.port, e.protocol
+	`
+
+	rows, err := db.Pool.Query(ctx, query, heartbeatFreshness.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edge routes: %w", err)
+	}
+	defer rows.Close()
+
+	var routes []models.EdgeRoute
+	for rows.Next() {
+		var route models.EdgeRoute
+		var serverID uuid.UUID
+		var nodeIP *string
+		var hostPort *int
+		var healthy *bool
+		if err := rows.Scan(&route.Port, &route.Protocol, &serverID, &nodeIP, &hostPort, &healthy); err != nil {
+			return nil, fmt.Errorf("failed to scan edge route: %w", err)
+		}
+		route.ServerID = serverID.String()
+		if nodeIP != nil && hostPort != nil {
+			route.Backend = fmt.Sprintf("%s:%d", *nodeIP, *hostPort)
+			route.Healthy = healthy != nil && *healthy
+		}
+		routes = append(routes, route)
+	}
+	return routes, rows.Err()
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// RecordEgressUsage adds the traffic since the last heartbeat to the server's usage for the
+// current month. counter is the supervisor's cumulative byte counter; it resets when the game
+// restarts, in which case the whole counter is new traffic.
+func (db *DB) RecordEgressUsage(ctx context.Context, serverID string, counter int64) error {
+	query := `
+		INSERT INTO server_egress_usage (server_id, period, last_counter)
+		VALUES ($1, date_trunc('month', NOW() AT TIME ZONE 'UTC')::date, $2)
+		ON CONFLICT (server_id, period) DO UPDATE
+		SET tx_bytes = server_egress_usage.tx_bytes + CASE
+		        WHEN EXCLUDED.last_counter >= server_egress_usage.last_counter
+		        THEN EXCLUDED.last_counter - server_egress_usage.last_counter
+		        ELSE EXCLUDED.last_counter
+		    END,
+		    last_counter = EXCLUDED.last_counter,
+		    updated_at = NOW()
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID, counter); err != nil {
+		return fmt.Errorf("failed to record egress usage: %w", err)
+	}
+	return nil
+}
+
+// GetEgressUsage returns a server's usage for the current month. Returns (nil, nil) if
+// nothing was recorded yet.
+func (db *DB) GetEgressUsage(ctx context.Context, serverID string) (*models.EgressUsage, error) {
+	query := `
+		SELECT period, tx_bytes, alerted_at
+		FROM server_egress_usage
+		WHERE server_id = $1 AND period = date_trunc('month', NOW() AT TIME ZONE 'UTC')::date
+	`
+
+	var usage models.EgressUsage
+	err := db.Pool.QueryRow(ctx, query, serverID).Scan(&usage.Period, &usage.TxBytes, &usage.QuotaExceededAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get egress usage: %w", err)
+	}
+	return &usage, nil
+}
+
+// EgressUsageAlertCandidate is a server's current month usage that hasn't been alerted yet
+type EgressUsageAlertCandidate struct {
+	ServerID       uuid.UUID
+	DisplayName    string
+	Game           models.GameType
+	Plan           models.ServerPlan
+	CatalogChannel string
+	Email          string
+	Period         time.Time
+	TxBytes        int64
+}
+
+// ListEgressUsageNotAlerted returns this month's usage of servers whose owners haven't been
+// alerted yet, for comparing against plan quotas
+func (db *DB) ListEgressUsageNotAlerted(ctx context.Context) ([]EgressUsageAlertCandidate, error) {
+	query := `
+		SELECT s.id, s.display_name, s.game, s.plan, s.catalog_channel, u.email, e.period, e.tx_bytes
+		FROM server_egress_usage e
+		JOIN servers s ON s.id = e.server_id
+		JOIN users u ON u.id = s.user_id
+		WHERE e.period = date_trunc('month', NOW() AT TIME ZONE 'UTC')::date
+		  AND e.alerted_at IS NULL
+		  AND e.tx_bytes > 0
+		  AND s.status != 'deleted'
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list egress usage: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []EgressUsageAlertCandidate
+	for rows.Next() {
+		var c EgressUsageAlertCandidate
+		if err := rows.Scan(&c.ServerID, &c.DisplayName, &c.Game, &c.Plan, &c.CatalogChannel, &c.Email, &c.Period, &c.TxBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan egress usage: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list egress usage: %w", err)
+	}
+
+	return candidates, nil
+}
+
+// MarkEgressAlerted records that a server's owner was alerted about its usage for period
+func (db *DB) MarkEgressAlerted(ctx context.Context, serverID string, period time.Time) error {
+	query := `UPDATE server_egress_usage SET alerted_at = NOW() WHERE server_id = $1 AND period = $2`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID, period); err != nil {
+		return fmt.Errorf("failed to mark egress alerted: %w", err)
+	}
+	return nil
+}
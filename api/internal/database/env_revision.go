@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// UpdateServerEnvWithRevision replaces a server's env overrides and records the change as a
+// new revision. revertedTo is set when the change restores an earlier revision. Returns the
+// revision, or nil if the overrides didn't change.
+func (db *DB) UpdateServerEnvWithRevision(ctx context.Context, serverID string, envOverrides map[string]string, changedBy uuid.UUID, revertedTo *int) (*models.EnvRevision, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the server so concurrent changes get consecutive revisions
+	var previousJSON []byte
+	err = tx.QueryRow(ctx, `SELECT env_overrides FROM servers WHERE id = $1 FOR UPDATE`, serverID).Scan(&previousJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server env overrides: %w", err)
+	}
+	previous := map[string]string{}
+	if previousJSON != nil {
+		if err := json.Unmarshal(previousJSON, &previous); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal env_overrides: %w", err)
+		}
+	}
+	if envOverrides == nil {
+		envOverrides = map[string]string{}
+	}
+
+	diff := models.DiffEnv(previous, envOverrides)
+	if diff.Empty() {
+		return nil, nil
+	}
+
+	txDB := &DB{Pool: tx}
+	if err := txDB.UpdateServerEnvOverrides(ctx, serverID, envOverrides); err != nil {
+		return nil, err
+	}
+
+	envJSON, err := json.Marshal(envOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal env overrides: %w", err)
+	}
+	previousJSON, err = json.Marshal(previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal env overrides: %w", err)
+	}
+
+	revision := &models.EnvRevision{
+		EnvOverrides: envOverrides,
+		PreviousEnv:  previous,
+		Diff:         diff,
+		ChangedBy:    &changedBy,
+		RevertedTo:   revertedTo,
+	}
+	query := `
+		INSERT INTO server_env_revisions (server_id, revision, env_overrides, previous_env, changed_by, reverted_to)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5
+		FROM server_env_revisions WHERE server_id = $1
+		RETURNING revision, created_at
+	`
+	err = tx.QueryRow(ctx, query, serverID, envJSON, previousJSON, changedBy, revertedTo).
+		Scan(&revision.Revision, &revision.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record env revision: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return revision, nil
+}
+
+const envRevisionColumns = `r.revision, r.env_overrides, r.previous_env, r.changed_by, u.email, r.reverted_to, r.created_at`
+
+func scanEnvRevision(row pgx.Row) (*models.EnvRevision, error) {
+	var revision models.EnvRevision
+	var envJSON, previousJSON []byte
+	err := row.Scan(&revision.Revision, &envJSON, &previousJSON, &revision.ChangedBy, &revision.ChangedByEmail,
+		&revision.RevertedTo, &revision.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(envJSON, &revision.EnvOverrides); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal env_overrides: %w", err)
+	}
+	if err := json.Unmarshal(previousJSON, &revision.PreviousEnv); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal previous_env: %w", err)
+	}
+	revision.Diff = models.DiffEnv(revision.PreviousEnv, revision.EnvOverrides)
+	return &revision, nil
+}
+
+// ListEnvRevisions returns a server's env revisions, newest first
+func (db *DB) ListEnvRevisions(ctx context.Context, serverID string, limit int) ([]models.EnvRevision, error) {
+	query := `
+		SELECT ` + envRevisionColumns + `
+		FROM server_env_revisions r
+		LEFT JOIN users u ON u.id = r.changed_by
+		WHERE r.server_id = $1
+		ORDER BY r.revision DESC
+		LIMIT $2
+	`
+	rows, err := db.Pool.Query(ctx, query, serverID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list env revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.EnvRevision{}
+	for rows.Next() {
+		revision, err := scanEnvRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan env revision: %w", err)
+		}
+		revisions = append(revisions, *revision)
+	}
+	return revisions, nil
+}
+
+// GetEnvRevision retrieves one of a server's env revisions. Returns (nil, nil) if it doesn't exist.
+func (db *DB) GetEnvRevision(ctx context.Context, serverID string, revision int) (*models.EnvRevision, error) {
+	query := `
+		SELECT ` + envRevisionColumns + `
+		FROM server_env_revisions r
+		LEFT JOIN users u ON u.id = r.changed_by
+		WHERE r.server_id = $1 AND r.revision = $2
+	`
+	rev, err := scanEnvRevision(db.Pool.QueryRow(ctx, query, serverID, revision))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get env revision: %w", err)
+	}
+	return rev, nil
+}
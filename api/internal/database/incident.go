@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const incidentColumns = `id, kind, node_name, region, zone, affected_servers, started_at, resolved_at`
+
+func scanIncident(row pgx.Row) (*models.Incident, error) {
+	var incident models.Incident
+	err := row.Scan(
+		&incident.ID, &incident.Kind, &incident.NodeName, &incident.Region, &incident.Zone,
+		&incident.AffectedServers, &incident.StartedAt, &incident.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// OpenNodeIncident opens an incident for a node. Returns (nil, nil) if the node already
+// has an open incident.
+func (db *DB) OpenNodeIncident(ctx context.Context, node *Node, kind models.IncidentKind, affectedServers int) (*models.Incident, error) {
+	query := `
+		INSERT INTO node_incidents (node_name, kind, region, zone, affected_servers)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (node_name) WHERE resolved_at IS NULL DO NOTHING
+		RETURNING ` + incidentColumns
+	incident, err := scanIncident(db.Pool.QueryRow(ctx, query, node.Name, string(kind), node.Region, node.Zone, affectedServers))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open node incident: %w", err)
+	}
+	return incident, nil
+}
+
+// ResolveNodeIncident resolves a node's open incident. Returns (nil, nil) if it has none.
+func (db *DB) ResolveNodeIncident(ctx context.Context, nodeName string) (*models.Incident, error) {
+	query := `
+		UPDATE node_incidents
+		SET resolved_at = NOW()
+		WHERE node_name = $1 AND resolved_at IS NULL
+		RETURNING ` + incidentColumns
+	incident, err := scanIncident(db.Pool.QueryRow(ctx, query, nodeName))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve node incident: %w", err)
+	}
+	return incident, nil
+}
+
+// ListIncidents returns open incidents and those that started since the given time,
+// newest first
+func (db *DB) ListIncidents(ctx context.Context, since time.Time) ([]models.Incident, error) {
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM node_incidents
+		WHERE resolved_at IS NULL OR started_at >= $1
+		ORDER BY started_at DESC
+	`
+	rows, err := db.Pool.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incidents: %w", err)
+	}
+	defer rows.Close()
+
+	incidents := []models.Incident{}
+	for rows.Next() {
+		incident, err := scanIncident(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, *incident)
+	}
+	return incidents, nil
+}
+
+// GetActiveServersOnNode returns the IDs of servers placed on a node that are pending,
+// starting, running or stopping, grouped by owner
+func (db *DB) GetActiveServersOnNode(ctx context.Context, nodeName string) (map[uuid.UUID][]string, error) {
+	query := `
+		SELECT DISTINCT s.id, s.user_id
+		FROM servers s
+		JOIN port_allocations pa ON pa.server_id = s.id
+		JOIN nodes n ON n.id = pa.node_id
+		WHERE n.name = $1 AND s.status IN ('pending', 'starting', 'running', 'stopping')
+	`
+	rows, err := db.Pool.Query(ctx, query, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get servers on node: %w", err)
+	}
+	defer rows.Close()
+
+	servers := make(map[uuid.UUID][]string)
+	for rows.Next() {
+		var serverID, userID uuid.UUID
+		if err := rows.Scan(&serverID, &userID); err != nil {
+			return nil, fmt.Errorf("failed to scan server: %w", err)
+		}
+		servers[userID] = append(servers[userID], serverID.String())
+	}
+	return servers, nil
+}
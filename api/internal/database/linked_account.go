@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const linkedAccountColumns = `user_id, provider, external_id, display_name, linked_at`
+
+func scanLinkedAccount(row pgx.Row) (*models.LinkedAccount, error) {
+	var account models.LinkedAccount
+	if err := row.Scan(&account.UserID, &account.Provider, &account.ExternalID, &account.DisplayName, &account.LinkedAt); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// UpsertLinkedAccount links a provider account to a user, replacing the user's previous
+// account from that provider
+func (db *DB) UpsertLinkedAccount(ctx context.Context, userID uuid.UUID, provider models.LinkedAccountProvider, externalID, displayName string) (*models.LinkedAccount, error) {
+	query := `
+		INSERT INTO linked_accounts (user_id, provider, external_id, display_name)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, provider) DO UPDATE
+		SET external_id = EXCLUDED.external_id,
+		    display_name = EXCLUDED.display_name,
+		    linked_at = NOW()
+		RETURNING ` + linkedAccountColumns
+
+	account, err := scanLinkedAccount(db.Pool.QueryRow(ctx, query, userID, string(provider), externalID, displayName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to link account: %w", err)
+	}
+	return account, nil
+}
+
+// GetLinkedAccount returns the user's account from a provider. Returns (nil, nil) if none is
+// linked.
+func (db *DB) GetLinkedAccount(ctx context.Context, userID uuid.UUID, provider models.LinkedAccountProvider) (*models.LinkedAccount, error) {
+	query := `SELECT ` + linkedAccountColumns + ` FROM linked_accounts WHERE user_id = $1 AND provider = $2`
+
+	account, err := scanLinkedAccount(db.Pool.QueryRow(ctx, query, userID, string(provider)))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked account: %w", err)
+	}
+	return account, nil
+}
+
+// GetLinkedAccountByExternalID returns the link of a provider account. Returns (nil, nil) if
+// it isn't linked.
+func (db *DB) GetLinkedAccountByExternalID(ctx context.Context, provider models.LinkedAccountProvider, externalID string) (*models.LinkedAccount, error) {
+	query := `SELECT ` + linkedAccountColumns + ` FROM linked_accounts WHERE provider = $1 AND external_id = $2`
+
+	account, err := scanLinkedAccount(db.Pool.QueryRow(ctx, query, string(provider), externalID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked account: %w", err)
+	}
+	return account, nil
+}
+
+// ListLinkedAccounts returns a user's linked accounts
+func (db *DB) ListLinkedAccounts(ctx context.Context, userID uuid.UUID) ([]models.LinkedAccount, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+linkedAccountColumns+` FROM linked_accounts WHERE user_id = $1 ORDER BY provider`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list linked accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []models.LinkedAccount{}
+	for rows.Next() {
+		account, err := scanLinkedAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan linked account: %w", err)
+		}
+		accounts = append(accounts, *account)
+	}
+	return accounts, nil
+}
+
+// DeleteLinkedAccount unlinks a user's account from a provider. Returns false if none was linked.
+func (db *DB) DeleteLinkedAccount(ctx context.Context, userID uuid.UUID, provider models.LinkedAccountProvider) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM linked_accounts WHERE user_id = $1 AND provider = $2`, userID, string(provider))
+	if err != nil {
+		return false, fmt.Errorf("failed to unlink account: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
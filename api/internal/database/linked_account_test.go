@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetLinkedAccount(t *testing.T) {
+	db, cleanup := setupTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, RandomEmail(), "password_hash")
+	require.NoError(t, err, "CreateUser should not return an error")
+	otherUser, err := db.CreateUser(ctx, RandomEmail(), "password_hash")
+	require.NoError(t, err, "CreateUser should not return an error")
+
+	account, err := db.GetLinkedAccount(ctx, user.ID, models.LinkedAccountSteam)
+	require.NoError(t, err, "GetLinkedAccount should not return an error")
+	assert.Nil(t, account, "nothing linked yet")
+
+	_, err = db.UpsertLinkedAccount(ctx, user.ID, models.LinkedAccountSteam, "76561197960287930", "76561197960287930")
+	require.NoError(t, err, "UpsertLinkedAccount should not return an error")
+
+	account, err = db.GetLinkedAccount(ctx, user.ID, models.LinkedAccountSteam)
+	require.NoError(t, err)
+	require.NotNil(t, account)
+	assert.Equal(t, user.ID, account.UserID)
+	assert.Equal(t, "76561197960287930", account.ExternalID)
+
+	account, err = db.GetLinkedAccount(ctx, otherUser.ID, models.LinkedAccountSteam)
+	require.NoError(t, err)
+	assert.Nil(t, account, "another user's link isn't returned")
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// SetNodeMonthlyCost sets (or with nil clears) a node's monthly cost. Returns false if
+// there's no such node.
+func (db *DB) SetNodeMonthlyCost(ctx context.Context, name string, cost *int64) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `UPDATE nodes SET monthly_cost = $2, updated_at = NOW() WHERE name = $1`, name, cost)
+	if err != nil {
+		return false, fmt.Errorf("failed to set node monthly cost: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// NodeCost is a node with its monthly cost
+type NodeCost struct {
+	Name                     string
+	Provider                 string
+	Region                   string
+	IsActive                 bool
+	MonthlyCost              *int64
+	AllocatableCPUMillicores *int
+}
+
+// ListNodeCosts returns every node with its monthly cost
+func (db *DB) ListNodeCosts(ctx context.Context) ([]NodeCost, error) {
+	query := `
+		SELECT name, COALESCE(provider, ''), COALESCE(region, ''), is_active, monthly_cost, allocatable_cpu_millicores
+		FROM nodes
+		ORDER BY name
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node costs: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []NodeCost
+	for rows.Next() {
+		var node NodeCost
+		if err := rows.Scan(&node.Name, &node.Provider, &node.Region, &node.IsActive,
+			&node.MonthlyCost, &node.AllocatableCPUMillicores); err != nil {
+			return nil, fmt.Errorf("failed to scan node cost: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, rows.Err()
+}
+
+// BilledServer is a server with an active subscription and the node it's scheduled on
+type BilledServer struct {
+	ServerID              uuid.UUID
+	Game                  models.GameType
+	Plan                  models.ServerPlan
+	NodeName              *string // Unset when the server holds no allocation (e.g. stopped)
+	ReservedCPUMillicores int
+}
+
+// ListBilledServers returns every server that's being billed, with where it's scheduled
+func (db *DB) ListBilledServers(ctx context.Context) ([]BilledServer, error) {
+	query := `
+		SELECT s.id, s.game, s.plan,
+			(SELECT n.name FROM port_allocations pa JOIN nodes n ON n.id = pa.node_id
+			 WHERE pa.server_id = s.id LIMIT 1),
+			COALESCE(s.reserved_cpu_millicores, 0)
+		FROM servers s
+		WHERE s.stripe_subscription_id IS NOT NULL
+		AND s.status NOT IN ('expired', 'deleting', 'deleted')
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billed servers: %w", err)
+	}
+	defer rows.Close()
+
+	var servers []BilledServer
+	for rows.Next() {
+		var server BilledServer
+		if err := rows.Scan(&server.ServerID, &server.Game, &server.Plan, &server.NodeName,
+			&server.ReservedCPUMillicores); err != nil {
+			return nil, fmt.Errorf("failed to scan billed server: %w", err)
+		}
+		servers = append(servers, server)
+	}
+	return servers, rows.Err()
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// RecordServerMetrics adds CPU, memory and player samples to the server's metrics for the
+// minutes they were taken in
+func (db *DB) RecordServerMetrics(ctx context.Context, serverID string, samples []ResourceSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	sampledAt := make([]time.Time, len(samples))
+	cpuPercent := make([]float64, len(samples))
+	memoryMB := make([]int64, len(samples))
+	players := make([]*int, len(samples))
+	for i, sample := range samples {
+		sampledAt[i], cpuPercent[i], memoryMB[i] = sample.SampledAt, sample.CPUPercent, sample.MemoryMB
+		players[i] = sample.PlayersOnline
+	}
+
+	query := `
+		INSERT INTO server_metrics
+			(server_id, minute, samples, cpu_percent_sum, cpu_percent_max, memory_mb_sum, memory_mb_max, players_max)
+		SELECT $1, date_trunc('minute', s.sampled_at), COUNT(*),
+		       SUM(s.cpu_percent), MAX(s.cpu_percent), SUM(s.memory_mb), MAX(s.memory_mb), MAX(s.players)
+		FROM unnest($2::timestamptz[], $3::float8[], $4::bigint[], $5::int[]) AS s(sampled_at, cpu_percent, memory_mb, players)
+		GROUP BY date_trunc('minute', s.sampled_at)
+		ON CONFLICT (server_id, minute) DO UPDATE
+		SET samples = server_metrics.samples + EXCLUDED.samples,
+		    cpu_percent_sum = server_metrics.cpu_percent_sum + EXCLUDED.cpu_percent_sum,
+		    cpu_percent_max = GREATEST(server_metrics.cpu_percent_max, EXCLUDED.cpu_percent_max),
+		    memory_mb_sum = server_metrics.memory_mb_sum + EXCLUDED.memory_mb_sum,
+		    memory_mb_max = GREATEST(server_metrics.memory_mb_max, EXCLUDED.memory_mb_max),
+		    players_max = GREATEST(server_metrics.players_max, EXCLUDED.players_max)
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID, sampledAt, cpuPercent, memoryMB, players); err != nil {
+		return fmt.Errorf("failed to record server metrics: %w", err)
+	}
+	return nil
+}
+
+// ListServerMetrics returns a server's usage since a time in steps of step, oldest first
+func (db *DB) ListServerMetrics(ctx context.Context, serverID string, since time.Time, step time.Duration) ([]models.MetricsPoint, error) {
+	query := `
+		SELECT to_timestamp(floor(extract(epoch FROM minute) / $3::int) * $3::int) AS step,
+		       SUM(cpu_percent_sum) / SUM(samples), MAX(cpu_percent_max),
+		       (SUM(memory_mb_sum) / SUM(samples))::BIGINT, MAX(memory_mb_max),
+		       MAX(players_max)
+		FROM server_metrics
+		WHERE server_id = $1 AND minute >= $2
+		GROUP BY step
+		ORDER BY step
+	`
+
+	rows, err := db.Pool.Query(ctx, query, serverID, since, int(step.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list server metrics: %w", err)
+	}
+	defer rows.Close()
+
+	points := []models.MetricsPoint{}
+	for rows.Next() {
+		var point models.MetricsPoint
+		if err := rows.Scan(&point.Time, &point.CPUPercent, &point.CPUPercentMax,
+			&point.MemoryMB, &point.MemoryMBMax, &point.PlayersMax); err != nil {
+			return nil, fmt.Errorf("failed to scan server metrics: %w", err)
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// DeleteServerMetricsBefore removes per-minute metrics older than cutoff
+func (db *DB) DeleteServerMetricsBefore(ctx context.Context, cutoff time.Time) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM server_metrics WHERE minute < $1`, cutoff); err != nil {
+		return fmt.Errorf("failed to delete server metrics: %w", err)
+	}
+	return nil
+}
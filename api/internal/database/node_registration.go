@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const nodeRegistrationTokenColumns = `id, region, zone, provider, datacenter, node_name, expires_at, registered_at, completed_at, created_at`
+
+func scanNodeRegistrationToken(row pgx.Row) (*models.NodeRegistrationToken, error) {
+	var token models.NodeRegistrationToken
+	if err := row.Scan(&token.ID, &token.Region, &token.Zone, &token.Provider, &token.Datacenter,
+		&token.NodeName, &token.ExpiresAt, &token.RegisteredAt, &token.CompletedAt, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// CreateNodeRegistrationToken stores a node registration token by its hash
+func (db *DB) CreateNodeRegistrationToken(ctx context.Context, tokenHash string, req models.CreateNodeTokenRequest, createdBy uuid.UUID, expiresAt time.Time) (*models.NodeRegistrationToken, error) {
+	query := `
+		INSERT INTO node_registration_tokens (token_hash, region, zone, provider, datacenter, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + nodeRegistrationTokenColumns
+
+	token, err := scanNodeRegistrationToken(db.Pool.QueryRow(ctx, query, tokenHash,
+		req.Region, req.Zone, req.Provider, req.Datacenter, createdBy, expiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node registration token: %w", err)
+	}
+	return token, nil
+}
+
+// GetUsableNodeRegistrationToken returns the unexpired, unused node registration token with
+// the hash. Returns (nil, nil) if there is none.
+func (db *DB) GetUsableNodeRegistrationToken(ctx context.Context, tokenHash string) (*models.NodeRegistrationToken, error) {
+	query := `SELECT ` + nodeRegistrationTokenColumns + ` FROM node_registration_tokens
+		WHERE token_hash = $1 AND expires_at > NOW() AND completed_at IS NULL`
+
+	token, err := scanNodeRegistrationToken(db.Pool.QueryRow(ctx, query, tokenHash))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node registration token: %w", err)
+	}
+	return token, nil
+}
+
+// BindNodeRegistrationToken ties a token to the node registering with it. Registering the
+// same node again is allowed, so agents can retry; returns false if the token is bound to
+// another node.
+func (db *DB) BindNodeRegistrationToken(ctx context.Context, id uuid.UUID, nodeName string) (bool, error) {
+	query := `
+		UPDATE node_registration_tokens
+		SET node_name = $2, registered_at = COALESCE(registered_at, NOW())
+		WHERE id = $1 AND (node_name IS NULL OR node_name = $2)
+	`
+	tag, err := db.Pool.Exec(ctx, query, id, nodeName)
+	if err != nil {
+		return false, fmt.Errorf("failed to bind node registration token: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CompleteNodeRegistrationToken uses up a token once its node was reported healthy
+func (db *DB) CompleteNodeRegistrationToken(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE node_registration_tokens SET completed_at = NOW() WHERE id = $1 AND completed_at IS NULL`
+	if _, err := db.Pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to complete node registration token: %w", err)
+	}
+	return nil
+}
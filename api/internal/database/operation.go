@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// CreateOperation records a new running operation for a server
+func (db *DB) CreateOperation(ctx context.Context, serverID string, opType models.OperationType) (*models.Operation, error) {
+	query := `
+		INSERT INTO server_operations (server_id, type, state)
+		VALUES ($1, $2, $3)
+		RETURNING id, server_id, type, state, error_message, created_at, updated_at, completed_at
+	`
+
+	op := &models.Operation{}
+	err := db.Pool.QueryRow(ctx, query, serverID, string(opType), string(models.OperationStateRunning)).Scan(
+		&op.ID, &op.ServerID, &op.Type, &op.State,
+		&op.ErrorMessage, &op.CreatedAt, &op.UpdatedAt, &op.CompletedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create operation: %w", err)
+	}
+
+	return op, nil
+}
+
+// CompleteOperation moves a running operation to its final state.
+// errorMessage is only stored for failed operations.
+func (db *DB) CompleteOperation(ctx context.Context, id uuid.UUID, state models.OperationState, errorMessage string) error {
+	query := `
+		UPDATE server_operations
+		SET state = $2,
+		    error_message = NULLIF($3, ''),
+		    completed_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $1 AND state = $4
+	`
+	_, err := db.Pool.Exec(ctx, query, id, string(state), errorMessage, string(models.OperationStateRunning))
+	if err != nil {
+		return fmt.Errorf("failed to complete operation: %w", err)
+	}
+	return nil
+}
+
+// ListServerOperations returns a server's most recent operations, newest first
+func (db *DB) ListServerOperations(ctx context.Context, serverID string, limit int) ([]models.Operation, error) {
+	query := `
+		SELECT id, server_id, type, state, error_message, created_at, updated_at, completed_at
+		FROM server_operations
+		WHERE server_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := db.Pool.Query(ctx, query, serverID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operations: %w", err)
+	}
+	defer rows.Close()
+
+	operations := []models.Operation{}
+	for rows.Next() {
+		var op models.Operation
+		err := rows.Scan(
+			&op.ID, &op.ServerID, &op.Type, &op.State,
+			&op.ErrorMessage, &op.CreatedAt, &op.UpdatedAt, &op.CompletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan operation: %w", err)
+		}
+		operations = append(operations, op)
+	}
+
+	return operations, nil
+}
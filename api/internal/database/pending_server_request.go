@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -39,7 +40,8 @@ func (db *DB) GetPendingServerRequest(ctx context.Context, id uuid.UUID) (*model
 	query := `
 		SELECT
 			id, user_id, display_name, subdomain, game, plan,
-			stripe_session_id, status, server_id, created_at, updated_at, expires_at
+			stripe_session_id, status, server_id, created_at, updated_at, expires_at, env_overrides, custom_game,
+			COALESCE(preferred_port, 0), clone_source_id, template_mods, template_schedules
 		FROM pending_server_requests
 		WHERE id = $1
 	`
@@ -47,10 +49,25 @@ func (db *DB) GetPendingServerRequest(ctx context.Context, id uuid.UUID) (*model
 	row := db.Pool.QueryRow(ctx, query, id)
 	psr := &models.PendingServerRequest{}
 
+	var envOverridesJSON, customGameJSON, templateModsJSON, templateSchedulesJSON []byte
 	err := row.Scan(
 		&psr.ID, &psr.UserID, &psr.DisplayName, &psr.Subdomain, &psr.Game, &psr.Plan,
 		&psr.StripeSessionID, &psr.Status, &psr.ServerID, &psr.CreatedAt, &psr.UpdatedAt, &psr.ExpiresAt,
+		&envOverridesJSON, &customGameJSON, &psr.PreferredPort, &psr.CloneSourceID,
+		&templateModsJSON, &templateSchedulesJSON,
 	)
+	if err == nil && envOverridesJSON != nil {
+		err = json.Unmarshal(envOverridesJSON, &psr.EnvOverrides)
+	}
+	if err == nil && customGameJSON != nil {
+		err = json.Unmarshal(customGameJSON, &psr.CustomGame)
+	}
+	if err == nil && templateModsJSON != nil {
+		err = json.Unmarshal(templateModsJSON, &psr.TemplateMods)
+	}
+	if err == nil && templateSchedulesJSON != nil {
+		err = json.Unmarshal(templateSchedulesJSON, &psr.TemplateSchedules)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pending server request: %w", err)
 	}
@@ -63,7 +80,8 @@ func (db *DB) GetPendingServerRequestByStripeSession(ctx context.Context, sessio
 	query := `
 		SELECT
 			id, user_id, display_name, subdomain, game, plan,
-			stripe_session_id, status, server_id, created_at, updated_at, expires_at
+			stripe_session_id, status, server_id, created_at, updated_at, expires_at, env_overrides, custom_game,
+			COALESCE(preferred_port, 0)
 		FROM pending_server_requests
 		WHERE stripe_session_id = $1
 	`
@@ -71,10 +89,18 @@ func (db *DB) GetPendingServerRequestByStripeSession(ctx context.Context, sessio
 	row := db.Pool.QueryRow(ctx, query, sessionID)
 	psr := &models.PendingServerRequest{}
 
+	var envOverridesJSON, customGameJSON []byte
 	err := row.Scan(
 		&psr.ID, &psr.UserID, &psr.DisplayName, &psr.Subdomain, &psr.Game, &psr.Plan,
 		&psr.StripeSessionID, &psr.Status, &psr.ServerID, &psr.CreatedAt, &psr.UpdatedAt, &psr.ExpiresAt,
+		&envOverridesJSON, &customGameJSON, &psr.PreferredPort,
 	)
+	if err == nil && envOverridesJSON != nil {
+		err = json.Unmarshal(envOverridesJSON, &psr.EnvOverrides)
+	}
+	if err == nil && customGameJSON != nil {
+		err = json.Unmarshal(customGameJSON, &psr.CustomGame)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pending server request by stripe session: %w", err)
 	}
@@ -82,6 +108,93 @@ func (db *DB) GetPendingServerRequestByStripeSession(ctx context.Context, sessio
 	return psr, nil
 }
 
+// SetPendingServerRequestEnv sets the env overrides the requested server starts with
+func (db *DB) SetPendingServerRequestEnv(ctx context.Context, id uuid.UUID, envOverrides map[string]string) error {
+	jsonData, err := json.Marshal(envOverrides)
+	if err != nil {
+		return fmt.Errorf("failed to marshal env overrides: %w", err)
+	}
+
+	query := `
+		UPDATE pending_server_requests
+		SET env_overrides = $1, updated_at = NOW()
+		WHERE id = $2
+	`
+	if _, err := db.Pool.Exec(ctx, query, jsonData, id); err != nil {
+		return fmt.Errorf("failed to set pending server request env: %w", err)
+	}
+	return nil
+}
+
+// SetPendingServerRequestCustomGame sets the definition of a requested custom game server
+func (db *DB) SetPendingServerRequestCustomGame(ctx context.Context, id uuid.UUID, def *models.CustomGame) error {
+	jsonData, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("failed to marshal custom game: %w", err)
+	}
+
+	query := `
+		UPDATE pending_server_requests
+		SET custom_game = $1, updated_at = NOW()
+		WHERE id = $2
+	`
+	if _, err := db.Pool.Exec(ctx, query, jsonData, id); err != nil {
+		return fmt.Errorf("failed to set pending server request custom game: %w", err)
+	}
+	return nil
+}
+
+// SetPendingServerRequestPreferredPort sets the port number the requested server's game port
+// is allocated first
+func (db *DB) SetPendingServerRequestPreferredPort(ctx context.Context, id uuid.UUID, port int) error {
+	query := `
+		UPDATE pending_server_requests
+		SET preferred_port = $1, updated_at = NOW()
+		WHERE id = $2
+	`
+	if _, err := db.Pool.Exec(ctx, query, port, id); err != nil {
+		return fmt.Errorf("failed to set pending server request preferred port: %w", err)
+	}
+	return nil
+}
+
+// SetPendingServerRequestCloneSource sets the server whose data the requested server is
+// created with
+func (db *DB) SetPendingServerRequestCloneSource(ctx context.Context, id, sourceID uuid.UUID) error {
+	query := `
+		UPDATE pending_server_requests
+		SET clone_source_id = $1, updated_at = NOW()
+		WHERE id = $2
+	`
+	if _, err := db.Pool.Exec(ctx, query, sourceID, id); err != nil {
+		return fmt.Errorf("failed to set pending server request clone source: %w", err)
+	}
+	return nil
+}
+
+// SetPendingServerRequestTemplate sets the mods and scheduled tasks the requested server
+// starts with, from the template it's created from
+func (db *DB) SetPendingServerRequestTemplate(ctx context.Context, id uuid.UUID, mods []models.TemplateMod, schedules []models.TemplateSchedule) error {
+	modsJSON, err := json.Marshal(mods)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template mods: %w", err)
+	}
+	schedulesJSON, err := json.Marshal(schedules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template schedules: %w", err)
+	}
+
+	query := `
+		UPDATE pending_server_requests
+		SET template_mods = $1, template_schedules = $2, updated_at = NOW()
+		WHERE id = $3
+	`
+	if _, err := db.Pool.Exec(ctx, query, modsJSON, schedulesJSON, id); err != nil {
+		return fmt.Errorf("failed to set pending server request template: %w", err)
+	}
+	return nil
+}
+
 // UpdatePendingServerRequestWithSession updates the Stripe session ID
 func (db *DB) UpdatePendingServerRequestWithSession(ctx context.Context, id uuid.UUID, sessionID string) error {
 	query := `
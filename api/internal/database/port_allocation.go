@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
 )
 
 // Node represents a Kubernetes node available for game server scheduling
@@ -17,6 +18,14 @@ type Node struct {
 	IsActive                 bool
 	AllocatableCPUMillicores *int   // K8s allocatable CPU in millicores (1000 = 1 core)
 	AllocatableMemoryBytes   *int64 // K8s allocatable memory in bytes
+	AllocatableGPUs          int    // K8s allocatable nvidia.com/gpu
+	Dedicated                bool   // Tainted for dedicated plans, one server per node
+	Spot                     bool   // Spot capacity the provider may reclaim, for spot plans only
+	OS                       string // Operating system (linux or windows); games only run on their own
+	Zone                     string // Location labels, empty when unlabeled
+	Region                   string
+	Provider                 string
+	Datacenter               string
 	CreatedAt                time.Time
 	UpdatedAt                time.Time
 }
@@ -44,31 +53,53 @@ type AllocatedPort struct {
 
 // PortRequirement specifies a port needed for a game server
 type PortRequirement struct {
-	Name     string // "game", "query", "rcon"
-	Protocol string // "TCP" or "UDP"
+	Name      string // "game", "query", "rcon"
+	Protocol  string // "TCP" or "UDP"
+	SamePort  string // Name of a requirement of the other protocol that gets the same port number
+	Preferred int    // Port number to take if free, on any node (0 = any)
 }
 
 // ResourceRequirement specifies CPU/memory needed for a game server
 type ResourceRequirement struct {
-	CPUMillicores int   // CPU in millicores (1000 = 1 core)
-	MemoryBytes   int64 // Memory in bytes
+	CPUMillicores int    // CPU in millicores (1000 = 1 core)
+	MemoryBytes   int64  // Memory in bytes
+	GPUs          int    // nvidia.com/gpu count
+	Dedicated     bool   // Needs a free dedicated node to itself instead of a shared node
+	Spot          bool   // Needs a spot node instead of a regular one
+	OS            string // Node operating system the game needs ("" = linux)
+	Region        string // Only nodes in this region are considered ("" = any)
+
+	// Plan and MaxPerNode cap co-tenancy: nodes already running MaxPerNode active servers
+	// on Plan are skipped (0 = no cap)
+	Plan       string
+	MaxPerNode int
 }
 
 // UpsertNode creates or updates a node record
 func (db *DB) UpsertNode(ctx context.Context, node *Node) error {
 	query := `
-		INSERT INTO nodes (name, public_ip, is_active, allocatable_cpu_millicores, allocatable_memory_bytes)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO nodes (name, public_ip, is_active, allocatable_cpu_millicores, allocatable_memory_bytes, allocatable_gpus, dedicated,
+		                   spot, os, zone, region, provider, datacenter)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		ON CONFLICT (name) DO UPDATE SET
 			public_ip = EXCLUDED.public_ip,
 			is_active = EXCLUDED.is_active,
 			allocatable_cpu_millicores = EXCLUDED.allocatable_cpu_millicores,
 			allocatable_memory_bytes = EXCLUDED.allocatable_memory_bytes,
+			allocatable_gpus = EXCLUDED.allocatable_gpus,
+			dedicated = EXCLUDED.dedicated,
+			spot = EXCLUDED.spot,
+			os = EXCLUDED.os,
+			zone = EXCLUDED.zone,
+			region = EXCLUDED.region,
+			provider = EXCLUDED.provider,
+			datacenter = EXCLUDED.datacenter,
 			updated_at = NOW()
 		RETURNING id, created_at, updated_at
 	`
 	err := db.Pool.QueryRow(ctx, query, node.Name, node.PublicIP, node.IsActive,
-		node.AllocatableCPUMillicores, node.AllocatableMemoryBytes).
+		node.AllocatableCPUMillicores, node.AllocatableMemoryBytes, node.AllocatableGPUs, node.Dedicated,
+		node.Spot, nodeOS(node.OS), node.Zone, node.Region, node.Provider, node.Datacenter).
 		Scan(&node.ID, &node.CreatedAt, &node.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to upsert node: %w", err)
@@ -79,14 +110,16 @@ func (db *DB) UpsertNode(ctx context.Context, node *Node) error {
 // GetNodeByName retrieves a node by its Kubernetes name
 func (db *DB) GetNodeByName(ctx context.Context, name string) (*Node, error) {
 	query := `
-		SELECT id, name, public_ip, is_active, allocatable_cpu_millicores, allocatable_memory_bytes, created_at, updated_at
+		SELECT id, name, public_ip, is_active, allocatable_cpu_millicores, allocatable_memory_bytes, allocatable_gpus, dedicated,
+		       spot, os, zone, region, provider, datacenter, created_at, updated_at
 		FROM nodes
 		WHERE name = $1
 	`
 	var node Node
 	err := db.Pool.QueryRow(ctx, query, name).Scan(
 		&node.ID, &node.Name, &node.PublicIP, &node.IsActive,
-		&node.AllocatableCPUMillicores, &node.AllocatableMemoryBytes,
+		&node.AllocatableCPUMillicores, &node.AllocatableMemoryBytes, &node.AllocatableGPUs, &node.Dedicated,
+		&node.Spot, &node.OS, &node.Zone, &node.Region, &node.Provider, &node.Datacenter,
 		&node.CreatedAt, &node.UpdatedAt,
 	)
 	if err != nil {
@@ -98,7 +131,8 @@ func (db *DB) GetNodeByName(ctx context.Context, name string) (*Node, error) {
 // GetAllNodes retrieves all nodes
 func (db *DB) GetAllNodes(ctx context.Context) ([]Node, error) {
 	query := `
-		SELECT id, name, public_ip, is_active, allocatable_cpu_millicores, allocatable_memory_bytes, created_at, updated_at
+		SELECT id, name, public_ip, is_active, allocatable_cpu_millicores, allocatable_memory_bytes, allocatable_gpus, dedicated,
+		       spot, os, zone, region, provider, datacenter, created_at, updated_at
 		FROM nodes
 		ORDER BY name
 	`
@@ -113,7 +147,8 @@ func (db *DB) GetAllNodes(ctx context.Context) ([]Node, error) {
 		var node Node
 		if err := rows.Scan(
 			&node.ID, &node.Name, &node.PublicIP, &node.IsActive,
-			&node.AllocatableCPUMillicores, &node.AllocatableMemoryBytes,
+			&node.AllocatableCPUMillicores, &node.AllocatableMemoryBytes, &node.AllocatableGPUs, &node.Dedicated,
+			&node.Spot, &node.OS, &node.Zone, &node.Region, &node.Provider, &node.Datacenter,
 			&node.CreatedAt, &node.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan node: %w", err)
@@ -123,6 +158,38 @@ func (db *DB) GetAllNodes(ctx context.Context) ([]Node, error) {
 	return nodes, nil
 }
 
+// RegionNodeStats counts a region's game server nodes; Region is empty for unlabeled nodes
+type RegionNodeStats struct {
+	Region string
+	Total  int
+	Active int
+}
+
+// GetRegionNodeStats counts nodes and active nodes per region, ordered by region
+func (db *DB) GetRegionNodeStats(ctx context.Context) ([]RegionNodeStats, error) {
+	query := `
+		SELECT region, COUNT(*), COUNT(*) FILTER (WHERE is_active)
+		FROM nodes
+		GROUP BY region
+		ORDER BY region
+	`
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get region node stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []RegionNodeStats
+	for rows.Next() {
+		var s RegionNodeStats
+		if err := rows.Scan(&s.Region, &s.Total, &s.Active); err != nil {
+			return nil, fmt.Errorf("failed to scan region node stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
 // SetNodeActive updates the is_active status of a node
 func (db *DB) SetNodeActive(ctx context.Context, nodeName string, isActive bool) error {
 	query := `UPDATE nodes SET is_active = $2, updated_at = NOW() WHERE name = $1`
@@ -134,7 +201,7 @@ func (db *DB) SetNodeActive(ctx context.Context, nodeName string, isActive bool)
 }
 
 // InitializeNodePorts creates port allocation slots for a node
-// Only creates ports that don't already exist
+// Only creates ports that don't already exist and aren't blocked
 func (db *DB) InitializeNodePorts(ctx context.Context, nodeID uuid.UUID, minPort, maxPort int) error {
 	// Insert ports for both TCP and UDP using CROSS JOIN
 	query := `
@@ -142,6 +209,11 @@ func (db *DB) InitializeNodePorts(ctx context.Context, nodeID uuid.UUID, minPort
 		SELECT $1::uuid, ports.port, protocols.protocol
 		FROM generate_series($2::int, $3::int) AS ports(port)
 		CROSS JOIN (VALUES ('TCP'), ('UDP')) AS protocols(protocol)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM blocked_ports b
+			WHERE ports.port BETWEEN b.min_port AND b.max_port
+			AND (b.protocol IS NULL OR b.protocol = protocols.protocol)
+		)
 		ON CONFLICT (node_id, port, protocol) DO NOTHING
 	`
 	_, err := db.Pool.Exec(ctx, query, nodeID, minPort, maxPort)
@@ -151,19 +223,175 @@ func (db *DB) InitializeNodePorts(ctx context.Context, nodeID uuid.UUID, minPort
 	return nil
 }
 
+// GetPortRange returns the port range an admin set. Returns (0, 0, nil) if none was set.
+func (db *DB) GetPortRange(ctx context.Context) (minPort, maxPort int, err error) {
+	err = db.Pool.QueryRow(ctx, `SELECT min_port, max_port FROM port_range`).Scan(&minPort, &maxPort)
+	if err == pgx.ErrNoRows {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get port range: %w", err)
+	}
+	return minPort, maxPort, nil
+}
+
+// SetPortRange changes the port range of every node in one transaction: ports outside the
+// new range are removed and missing ones created. Returns how many allocated ports lie
+// outside the new range, in which case nothing is changed.
+func (db *DB) SetPortRange(ctx context.Context, minPort, maxPort int) (int, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the ports being removed so they can't be allocated meanwhile
+	rows, err := tx.Query(ctx, `
+		SELECT server_id IS NOT NULL FROM port_allocations
+		WHERE port < $1 OR port > $2
+		FOR UPDATE
+	`, minPort, maxPort)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock ports: %w", err)
+	}
+	inUse := 0
+	for rows.Next() {
+		var allocated bool
+		if err := rows.Scan(&allocated); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan port: %w", err)
+		}
+		if allocated {
+			inUse++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to lock ports: %w", err)
+	}
+	if inUse > 0 {
+		return inUse, nil
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM port_allocations WHERE port < $1 OR port > $2`, minPort, maxPort); err != nil {
+		return 0, fmt.Errorf("failed to remove ports: %w", err)
+	}
+
+	if err := backfillNodePorts(ctx, tx, minPort, maxPort); err != nil {
+		return 0, err
+	}
+
+	rangeQuery := `
+		INSERT INTO port_range (id, min_port, max_port) VALUES (TRUE, $1, $2)
+		ON CONFLICT (id) DO UPDATE SET min_port = $1, max_port = $2, updated_at = NOW()
+	`
+	if _, err := tx.Exec(ctx, rangeQuery, minPort, maxPort); err != nil {
+		return 0, fmt.Errorf("failed to set port range: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return 0, nil
+}
+
+// backfillNodePorts creates the missing ports in [minPort, maxPort] of every node, leaving
+// out blocked ones
+func backfillNodePorts(ctx context.Context, tx pgx.Tx, minPort, maxPort int) error {
+	query := `
+		INSERT INTO port_allocations (node_id, port, protocol)
+		SELECT nodes.id, ports.port, protocols.protocol
+		FROM nodes
+		CROSS JOIN generate_series($1::int, $2::int) AS ports(port)
+		CROSS JOIN (VALUES ('TCP'), ('UDP')) AS protocols(protocol)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM blocked_ports b
+			WHERE ports.port BETWEEN b.min_port AND b.max_port
+			AND (b.protocol IS NULL OR b.protocol = protocols.protocol)
+		)
+		ON CONFLICT (node_id, port, protocol) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, query, minPort, maxPort); err != nil {
+		return fmt.Errorf("failed to initialize node ports: %w", err)
+	}
+	return nil
+}
+
+// samePortNumbers counts the port numbers free on both protocols of node n
+const samePortNumbers = `(
+	SELECT COUNT(*) FROM port_allocations tcp
+	JOIN port_allocations udp ON udp.node_id = tcp.node_id AND udp.port = tcp.port
+		AND udp.protocol = 'UDP' AND udp.server_id IS NULL
+	WHERE tcp.node_id = n.id AND tcp.protocol = 'TCP' AND tcp.server_id IS NULL
+)`
+
+// pairedRequirement returns the requirement that must get the same port number as req,
+// or nil
+func pairedRequirement(requirements []PortRequirement, req PortRequirement) *PortRequirement {
+	for i := range requirements {
+		other := &requirements[i]
+		if other.Protocol != req.Protocol && (other.Name == req.SamePort || other.SamePort == req.Name) {
+			return other
+		}
+	}
+	return nil
+}
+
+// portCooling reports whether the free port alias is still cooling down after a server other
+// than serverParam released it
+func portCooling(alias, serverParam string) string {
+	return `((` + alias + `.cooldown_until > NOW() AND ` + alias + `.released_server_id IS DISTINCT FROM ` + serverParam + `) IS TRUE)`
+}
+
+// preferredPortFree reports whether port number portParam is free, and not cooling down for
+// server serverParam, on node n for every protocol in protocolsParam (true for all nodes
+// when none is preferred)
+func preferredPortFree(portParam, protocolsParam, serverParam string) string {
+	return `(
+		SELECT COUNT(DISTINCT pa.protocol) FROM port_allocations pa
+		WHERE pa.node_id = n.id AND pa.port = ` + portParam + ` AND pa.server_id IS NULL
+		AND NOT ` + portCooling("pa", serverParam) + `
+		AND pa.protocol = ANY(` + protocolsParam + `::text[])
+	) = cardinality(` + protocolsParam + `::text[])`
+}
+
+// preferredPort returns the port number a requirement prefers and the protocols it must be
+// free on: both for a pair, whichever side of it prefers the number
+func preferredPort(requirements []PortRequirement) (int, []string) {
+	for _, req := range requirements {
+		if req.Preferred == 0 {
+			continue
+		}
+		if pair := pairedRequirement(requirements, req); pair != nil {
+			return req.Preferred, []string{"TCP", "UDP"}
+		}
+		return req.Preferred, []string{req.Protocol}
+	}
+	return 0, []string{}
+}
+
 // AllocatePortsForServer allocates ports and reserves resources for a server on an available node
 // Uses SELECT FOR UPDATE to prevent race conditions
 // Returns the node and allocated ports
 // If resourceReq is nil, resource checking is skipped (for backward compatibility)
-func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, requirements []PortRequirement, resourceReq *ResourceRequirement) (*Node, []AllocatedPort, error) {
+// A dedicated requirement only matches a free dedicated node, which is then marked exclusive
+// to the server; every other allocation skips dedicated nodes. Likewise spot requirements
+// only match spot nodes, and others skip them. Nodes must run the game's operating system.
+// Requirements paired through SamePort get one port number free on both TCP and UDP.
+// A requirement's Preferred port number is taken if free: nodes where it is come first, and
+// other nodes give the requirement a free port as usual.
+// Free ports are handed out longest-free first. Ports another server released are cooling
+// down until their cooldown_until and only taken when the node has no other free port.
+// The ports are recorded in the allocation history with cause and detail.
+func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, requirements []PortRequirement, resourceReq *ResourceRequirement, cause models.PortAllocationCause, detail string) (*Node, []AllocatedPort, error) {
 	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	// Count required ports per protocol
-	tcpCount, udpCount := 0, 0
+	// Count required ports per protocol, and the numbers needed free on both
+	tcpCount, udpCount, pairCount := 0, 0, 0
 	for _, req := range requirements {
 		switch req.Protocol {
 		case "TCP":
@@ -171,7 +399,11 @@ func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, re
 		case "UDP":
 			udpCount++
 		}
+		if req.SamePort != "" {
+			pairCount++
+		}
 	}
+	preferred, preferredProtocols := preferredPort(requirements)
 
 	// Find a node with enough available ports and resources
 	// Lock the node row to prevent concurrent allocations
@@ -187,6 +419,14 @@ func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, re
 			WHERE n.is_active = TRUE
 			AND n.allocatable_cpu_millicores IS NOT NULL
 			AND n.allocatable_memory_bytes IS NOT NULL
+			-- Dedicated plans need a free dedicated node, shared plans a shared node
+			AND n.dedicated = $5
+			AND n.dedicated_server_id IS NULL
+			-- Spot plans need a spot node, other plans a regular node
+			AND n.spot = $9
+			-- Windows games need a Windows node, Linux games a Linux node
+			AND n.os = $10
+			AND ($11 = '' OR n.region = $11)
 			-- Port availability
 			AND (
 				SELECT COUNT(*) FROM port_allocations pa
@@ -196,6 +436,8 @@ func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, re
 				SELECT COUNT(*) FROM port_allocations pa
 				WHERE pa.node_id = n.id AND pa.server_id IS NULL AND pa.protocol = 'UDP'
 			) >= $2
+			-- Port numbers free on both protocols, for ports that need the same number
+			AND ($12 = 0 OR ` + samePortNumbers + ` >= $12)
 			-- Resource availability (capacity - sum of active reservations)
 			-- Derive node via port_allocations instead of node_name
 			AND (
@@ -214,8 +456,26 @@ func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, re
 					   AND s.reserved_memory_bytes IS NOT NULL), 0
 				)
 			) >= $4
-			-- Bin-packing: prefer nodes with LEAST remaining capacity after allocation (tightest fit)
-			ORDER BY LEAST(
+			-- GPU availability
+			AND (
+				n.allocatable_gpus - COALESCE(
+					(SELECT SUM(s.reserved_gpus) FROM servers s
+					 WHERE EXISTS (SELECT 1 FROM port_allocations pa WHERE pa.server_id = s.id AND pa.node_id = n.id)
+					   AND s.status NOT IN ('deleted', 'expired', 'failed')), 0
+				)
+			) >= $6
+			-- Co-tenancy cap for the plan
+			AND (
+				$7 = 0 OR (
+					SELECT COUNT(*) FROM servers s
+					WHERE EXISTS (SELECT 1 FROM port_allocations pa WHERE pa.server_id = s.id AND pa.node_id = n.id)
+					  AND s.plan = $8
+					  AND s.status NOT IN ('deleted', 'expired', 'failed')
+				) < $7
+			)
+			-- Nodes with the preferred port free first, then bin-packing: prefer nodes with
+			-- LEAST remaining capacity after allocation (tightest fit)
+			ORDER BY ` + preferredPortFree("$13", "$14", "$15") + ` DESC, LEAST(
 				n.allocatable_cpu_millicores - COALESCE(
 					(SELECT SUM(s.reserved_cpu_millicores) FROM servers s
 					 WHERE EXISTS (SELECT 1 FROM port_allocations pa WHERE pa.server_id = s.id AND pa.node_id = n.id)
@@ -232,7 +492,8 @@ func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, re
 			LIMIT 1
 			FOR UPDATE OF n
 		`
-		err = tx.QueryRow(ctx, nodeQuery, tcpCount, udpCount, resourceReq.CPUMillicores, resourceReq.MemoryBytes).
+		err = tx.QueryRow(ctx, nodeQuery, tcpCount, udpCount, resourceReq.CPUMillicores, resourceReq.MemoryBytes, resourceReq.Dedicated, resourceReq.GPUs, resourceReq.MaxPerNode, resourceReq.Plan, resourceReq.Spot, nodeOS(resourceReq.OS), resourceReq.Region, pairCount,
+			preferred, preferredProtocols, serverID).
 			Scan(&node.ID, &node.Name, &node.PublicIP)
 	} else {
 		// Query without resource checking (backward compatibility)
@@ -240,6 +501,9 @@ func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, re
 			SELECT n.id, n.name, n.public_ip
 			FROM nodes n
 			WHERE n.is_active = TRUE
+			AND n.dedicated = FALSE
+			AND n.spot = FALSE
+			AND n.os = 'linux'
 			AND (
 				SELECT COUNT(*) FROM port_allocations pa
 				WHERE pa.node_id = n.id AND pa.server_id IS NULL AND pa.protocol = 'TCP'
@@ -248,14 +512,15 @@ func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, re
 				SELECT COUNT(*) FROM port_allocations pa
 				WHERE pa.node_id = n.id AND pa.server_id IS NULL AND pa.protocol = 'UDP'
 			) >= $2
-			ORDER BY (
+			AND ($3 = 0 OR ` + samePortNumbers + ` >= $3)
+			ORDER BY ` + preferredPortFree("$4", "$5", "$6") + ` DESC, (
 				SELECT COUNT(*) FROM port_allocations pa
 				WHERE pa.node_id = n.id AND pa.server_id IS NULL
 			) DESC
 			LIMIT 1
 			FOR UPDATE OF n
 		`
-		err = tx.QueryRow(ctx, nodeQuery, tcpCount, udpCount).Scan(&node.ID, &node.Name, &node.PublicIP)
+		err = tx.QueryRow(ctx, nodeQuery, tcpCount, udpCount, pairCount, preferred, preferredProtocols, serverID).Scan(&node.ID, &node.Name, &node.PublicIP)
 	}
 
 	if err != nil {
@@ -267,35 +532,16 @@ func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, re
 
 	// Allocate ports for each requirement
 	var allocatedPorts []AllocatedPort
-	for _, req := range requirements {
-		// Get an available port for this protocol and lock it
-		portQuery := `
-			SELECT id, port
-			FROM port_allocations
-			WHERE node_id = $1 AND protocol = $2 AND server_id IS NULL
-			ORDER BY port ASC
-			LIMIT 1
-			FOR UPDATE
-		`
-
-		var portID uuid.UUID
-		var port int
-		err = tx.QueryRow(ctx, portQuery, node.ID, req.Protocol).Scan(&portID, &port)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to get available %s port: %w", req.Protocol, err)
-		}
-
-		// Assign the port to the server
+	assign := func(portID uuid.UUID, port int, req PortRequirement) error {
 		updateQuery := `
 			UPDATE port_allocations
-			SET server_id = $1, port_name = $2, allocated_at = NOW()
+			SET server_id = $1, port_name = $2, allocated_at = NOW(),
+				released_at = NULL, released_server_id = NULL, cooldown_until = NULL
 			WHERE id = $3
 		`
-		_, err = tx.Exec(ctx, updateQuery, serverID, req.Name, portID)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to allocate port: %w", err)
+		if _, err := tx.Exec(ctx, updateQuery, serverID, req.Name, portID); err != nil {
+			return fmt.Errorf("failed to allocate port: %w", err)
 		}
-
 		allocatedPorts = append(allocatedPorts, AllocatedPort{
 			NodeName: node.Name,
 			NodeIP:   node.PublicIP,
@@ -303,19 +549,99 @@ func (db *DB) AllocatePortsForServer(ctx context.Context, serverID uuid.UUID, re
 			Protocol: req.Protocol,
 			PortName: req.Name,
 		})
+		return nil
+	}
+
+	assigned := make(map[string]bool)
+	for _, req := range requirements {
+		if assigned[req.Name] {
+			continue
+		}
+
+		pair := pairedRequirement(requirements, req)
+		if pair == nil {
+			// Get an available port for this protocol and lock it
+			portQuery := `
+				SELECT pa.id, pa.port
+				FROM port_allocations pa
+				WHERE pa.node_id = $1 AND pa.protocol = $2 AND pa.server_id IS NULL
+				ORDER BY (pa.port = $3 AND NOT ` + portCooling("pa", "$4") + `) DESC,
+					` + portCooling("pa", "$4") + ` ASC, pa.released_at ASC NULLS FIRST, pa.port ASC
+				LIMIT 1
+				FOR UPDATE
+			`
+
+			var portID uuid.UUID
+			var port int
+			err = tx.QueryRow(ctx, portQuery, node.ID, req.Protocol, req.Preferred, serverID).Scan(&portID, &port)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get available %s port: %w", req.Protocol, err)
+			}
+			if err := assign(portID, port, req); err != nil {
+				return nil, nil, err
+			}
+			assigned[req.Name] = true
+			continue
+		}
+
+		// Get a number free on both protocols and lock both ports. Numbers locked elsewhere
+		// (e.g. being blocked) are skipped for the next one.
+		pairCooling := "(" + portCooling("tcp", "$3") + " OR " + portCooling("udp", "$3") + ")"
+		pairQuery := `
+			SELECT tcp.id, udp.id, tcp.port
+			FROM port_allocations tcp
+			JOIN port_allocations udp ON udp.node_id = tcp.node_id AND udp.port = tcp.port
+				AND udp.protocol = 'UDP' AND udp.server_id IS NULL
+			WHERE tcp.node_id = $1 AND tcp.protocol = 'TCP' AND tcp.server_id IS NULL
+			ORDER BY (tcp.port = $2 AND NOT ` + pairCooling + `) DESC,
+				` + pairCooling + ` ASC, GREATEST(tcp.released_at, udp.released_at) ASC NULLS FIRST, tcp.port ASC
+			LIMIT 1
+			FOR UPDATE OF tcp, udp SKIP LOCKED
+		`
+
+		var tcpID, udpID uuid.UUID
+		var port int
+		err = tx.QueryRow(ctx, pairQuery, node.ID, max(req.Preferred, pair.Preferred), serverID).Scan(&tcpID, &udpID, &port)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get available port number for %s and %s: %w", req.Name, pair.Name, err)
+		}
+
+		tcpReq, udpReq := req, *pair
+		if req.Protocol == "UDP" {
+			tcpReq, udpReq = *pair, req
+		}
+		if err := assign(tcpID, port, tcpReq); err != nil {
+			return nil, nil, err
+		}
+		if err := assign(udpID, port, udpReq); err != nil {
+			return nil, nil, err
+		}
+		assigned[req.Name] = true
+		assigned[pair.Name] = true
+	}
+
+	if err := recordAllocatedPorts(ctx, tx, serverID, allocatedPorts, cause, detail); err != nil {
+		return nil, nil, err
 	}
 
 	// Update server's resource reservations (node is derived from port_allocations)
 	if resourceReq != nil {
 		serverUpdateQuery := `
 			UPDATE servers
-			SET reserved_cpu_millicores = $1, reserved_memory_bytes = $2
-			WHERE id = $3
+			SET reserved_cpu_millicores = $1, reserved_memory_bytes = $2, reserved_gpus = $3
+			WHERE id = $4
 		`
-		_, err = tx.Exec(ctx, serverUpdateQuery, resourceReq.CPUMillicores, resourceReq.MemoryBytes, serverID)
+		_, err = tx.Exec(ctx, serverUpdateQuery, resourceReq.CPUMillicores, resourceReq.MemoryBytes, resourceReq.GPUs, serverID)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to update server: %w", err)
 		}
+
+		if resourceReq.Dedicated {
+			_, err = tx.Exec(ctx, `UPDATE nodes SET dedicated_server_id = $1, updated_at = NOW() WHERE id = $2`, serverID, node.ID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to reserve dedicated node: %w", err)
+			}
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -357,14 +683,39 @@ func (db *DB) GetServerPortAllocations(ctx context.Context, serverID uuid.UUID)
 	return ports, nil
 }
 
-// ReleaseServerPorts releases all ports allocated to a server
-func (db *DB) ReleaseServerPorts(ctx context.Context, serverID uuid.UUID) error {
+// ReleaseServerPorts releases all ports allocated to a server, and its dedicated node if it had one
+// Ports blocked since they were allocated are removed instead
+// Released ports cool down for cooldown before other servers get them (see AllocatePortsForServer)
+// The ports are recorded in the allocation history with cause and detail.
+func (db *DB) ReleaseServerPorts(ctx context.Context, serverID uuid.UUID, cooldown time.Duration, cause models.PortAllocationCause, detail string) error {
 	query := `
-		UPDATE port_allocations
-		SET server_id = NULL, port_name = NULL, allocated_at = NULL
-		WHERE server_id = $1
+		WITH history AS (
+			INSERT INTO allocations_history (server_id, node_name, port, protocol, port_name, action, cause, detail)
+			SELECT pa.server_id, n.name, pa.port, pa.protocol, pa.port_name, $2, $3, NULLIF($4, '')
+			FROM port_allocations pa
+			JOIN nodes n ON n.id = pa.node_id
+			WHERE pa.server_id = $1
+		), released_node AS (
+			UPDATE nodes SET dedicated_server_id = NULL, updated_at = NOW()
+			WHERE dedicated_server_id = $1
+		), removed AS (
+			DELETE FROM port_allocations pa
+			WHERE pa.server_id = $1 AND EXISTS (
+				SELECT 1 FROM blocked_ports b
+				WHERE pa.port BETWEEN b.min_port AND b.max_port
+				AND (b.protocol IS NULL OR b.protocol = pa.protocol)
+			)
+		)
+		UPDATE port_allocations pa
+		SET server_id = NULL, port_name = NULL, allocated_at = NULL,
+			released_at = NOW(), released_server_id = $1, cooldown_until = NOW() + make_interval(secs => $5)
+		WHERE pa.server_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM blocked_ports b
+			WHERE pa.port BETWEEN b.min_port AND b.max_port
+			AND (b.protocol IS NULL OR b.protocol = pa.protocol)
+		)
 	`
-	_, err := db.Pool.Exec(ctx, query, serverID)
+	_, err := db.Pool.Exec(ctx, query, serverID, models.PortReleased, cause, detail, cooldown.Seconds())
 	if err != nil {
 		return fmt.Errorf("failed to release server ports: %w", err)
 	}
@@ -374,7 +725,13 @@ func (db *DB) ReleaseServerPorts(ctx context.Context, serverID uuid.UUID) error
 // CheckResourceCapacity verifies if any node can accommodate the requested resources
 // This is a read-only check that does not allocate any resources
 // Returns true if capacity exists, false otherwise
-func (db *DB) CheckResourceCapacity(ctx context.Context, tcpPorts, udpPorts int, cpuMillicores int, memoryBytes int64) (bool, error) {
+// Dedicated checks look for a free dedicated node; shared checks skip dedicated nodes
+// Spot checks look for a spot node; other checks skip spot nodes
+// Only nodes running os are considered ("" = linux)
+// Nodes already running maxPerNode active servers on plan are skipped (0 = no cap)
+// Only nodes in region are considered ("" = any)
+// samePorts port numbers must be free on both TCP and UDP
+func (db *DB) CheckResourceCapacity(ctx context.Context, tcpPorts, udpPorts, samePorts int, cpuMillicores int, memoryBytes int64, gpus int, dedicated, spot bool, os, plan string, maxPerNode int, region string) (bool, error) {
 	query := `
 		SELECT EXISTS (
 			SELECT 1
@@ -382,6 +739,11 @@ func (db *DB) CheckResourceCapacity(ctx context.Context, tcpPorts, udpPorts int,
 			WHERE n.is_active = TRUE
 			AND n.allocatable_cpu_millicores IS NOT NULL
 			AND n.allocatable_memory_bytes IS NOT NULL
+			AND n.dedicated = $5
+			AND n.dedicated_server_id IS NULL
+			AND n.spot = $9
+			AND n.os = $10
+			AND ($11 = '' OR n.region = $11)
 			-- Port availability
 			AND (
 				SELECT COUNT(*) FROM port_allocations pa
@@ -391,6 +753,7 @@ func (db *DB) CheckResourceCapacity(ctx context.Context, tcpPorts, udpPorts int,
 				SELECT COUNT(*) FROM port_allocations pa
 				WHERE pa.node_id = n.id AND pa.server_id IS NULL AND pa.protocol = 'UDP'
 			) >= $2
+			AND ($12 = 0 OR ` + samePortNumbers + ` >= $12)
 			-- Resource availability (capacity - sum of active reservations)
 			AND (
 				n.allocatable_cpu_millicores - COALESCE(
@@ -408,18 +771,43 @@ func (db *DB) CheckResourceCapacity(ctx context.Context, tcpPorts, udpPorts int,
 					   AND s.reserved_memory_bytes IS NOT NULL), 0
 				)
 			) >= $4
+			-- GPU availability
+			AND (
+				n.allocatable_gpus - COALESCE(
+					(SELECT SUM(s.reserved_gpus) FROM servers s
+					 WHERE EXISTS (SELECT 1 FROM port_allocations pa WHERE pa.server_id = s.id AND pa.node_id = n.id)
+					   AND s.status NOT IN ('deleted', 'expired', 'failed')), 0
+				)
+			) >= $6
+			-- Co-tenancy cap for the plan
+			AND (
+				$7 = 0 OR (
+					SELECT COUNT(*) FROM servers s
+					WHERE EXISTS (SELECT 1 FROM port_allocations pa WHERE pa.server_id = s.id AND pa.node_id = n.id)
+					  AND s.plan = $8
+					  AND s.status NOT IN ('deleted', 'expired', 'failed')
+				) < $7
+			)
 			LIMIT 1
 		)
 	`
 
 	var exists bool
-	err := db.Pool.QueryRow(ctx, query, tcpPorts, udpPorts, cpuMillicores, memoryBytes).Scan(&exists)
+	err := db.Pool.QueryRow(ctx, query, tcpPorts, udpPorts, cpuMillicores, memoryBytes, dedicated, gpus, maxPerNode, plan, spot, nodeOS(os), region, samePorts).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check resource capacity: %w", err)
 	}
 	return exists, nil
 }
 
+// nodeOS returns the node operating system a requirement needs, linux unless set
+func nodeOS(os string) string {
+	if os == "" {
+		return "linux"
+	}
+	return os
+}
+
 // GetNodePortStats returns port usage statistics for a node
 func (db *DB) GetNodePortStats(ctx context.Context, nodeName string) (total, used int, err error) {
 	query := `
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PreferredPort(t *testing.T) {
+	tests := []struct {
+		name          string
+		requirements  []PortRequirement
+		wantPort      int
+		wantProtocols []string
+	}{
+		{
+			name:          "none preferred",
+			requirements:  []PortRequirement{{Name: "game", Protocol: "TCP"}},
+			wantPort:      0,
+			wantProtocols: []string{},
+		},
+		{
+			name:          "single protocol",
+			requirements:  []PortRequirement{{Name: "game", Protocol: "UDP", Preferred: 27015}},
+			wantPort:      27015,
+			wantProtocols: []string{"UDP"},
+		},
+		{
+			name: "pair preferred on the side naming the other",
+			requirements: []PortRequirement{
+				{Name: "game", Protocol: "TCP", SamePort: "voice", Preferred: 25565},
+				{Name: "voice", Protocol: "UDP"},
+			},
+			wantPort:      25565,
+			wantProtocols: []string{"TCP", "UDP"},
+		},
+		{
+			name: "pair preferred on the named side",
+			requirements: []PortRequirement{
+				{Name: "game", Protocol: "TCP", SamePort: "voice"},
+				{Name: "voice", Protocol: "UDP", Preferred: 25565},
+			},
+			wantPort:      25565,
+			wantProtocols: []string{"TCP", "UDP"},
+		},
+		{
+			name: "unpaired requirement of the other protocol",
+			requirements: []PortRequirement{
+				{Name: "game", Protocol: "TCP", Preferred: 25565},
+				{Name: "query", Protocol: "UDP"},
+			},
+			wantPort:      25565,
+			wantProtocols: []string{"TCP"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port, protocols := preferredPort(tt.requirements)
+			assert.Equal(t, tt.wantPort, port)
+			assert.Equal(t, tt.wantProtocols, protocols)
+		})
+	}
+}
+
+func Test_PairedRequirement(t *testing.T) {
+	requirements := []PortRequirement{
+		{Name: "game", Protocol: "TCP", SamePort: "voice"},
+		{Name: "voice", Protocol: "UDP"},
+		{Name: "query", Protocol: "UDP"},
+		{Name: "rcon", Protocol: "TCP"},
+	}
+
+	tests := []struct {
+		name string
+		req  PortRequirement
+		want string // Name of the paired requirement, "" for none
+	}{
+		{"names its pair", requirements[0], "voice"},
+		{"named by its pair", requirements[1], "game"},
+		{"unpaired UDP", requirements[2], ""},
+		{"unpaired TCP", requirements[3], ""},
+		{"same protocol never pairs", PortRequirement{Name: "web", Protocol: "TCP", SamePort: "rcon"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair := pairedRequirement(requirements, tt.req)
+			if tt.want == "" {
+				assert.Nil(t, pair)
+				return
+			}
+			if assert.NotNil(t, pair) {
+				assert.Equal(t, tt.want, pair.Name)
+			}
+		})
+	}
+}
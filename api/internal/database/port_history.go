@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// recordAllocatedPorts adds ports just allocated to a server to the allocation history
+func recordAllocatedPorts(ctx context.Context, tx pgx.Tx, serverID uuid.UUID, ports []AllocatedPort, cause models.PortAllocationCause, detail string) error {
+	query := `
+		INSERT INTO allocations_history (server_id, node_name, port, protocol, port_name, action, cause, detail)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+	`
+	for _, port := range ports {
+		if _, err := tx.Exec(ctx, query, serverID, port.NodeName, port.Port, port.Protocol, port.PortName,
+			models.PortAllocated, cause, detail); err != nil {
+			return fmt.Errorf("failed to record port allocation: %w", err)
+		}
+	}
+	return nil
+}
+
+// HasPortAllocationHistory reports whether a server was ever allocated ports
+func (db *DB) HasPortAllocationHistory(ctx context.Context, serverID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM allocations_history WHERE server_id = $1)`
+	if err := db.Pool.QueryRow(ctx, query, serverID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check port allocation history: %w", err)
+	}
+	return exists, nil
+}
+
+// ListPortAllocationHistory returns the ports allocated and released matching the filter,
+// newest first
+func (db *DB) ListPortAllocationHistory(ctx context.Context, filter models.PortHistoryFilter) ([]models.PortAllocationEvent, error) {
+	limit := filter.Limit
+	if limit == 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, server_id, node_name, port, protocol, port_name, action, cause, detail, created_at
+		FROM allocations_history
+		WHERE ($1 = '' OR server_id::text = $1)
+		AND ($2 = '' OR node_name = $2)
+		AND ($3 = 0 OR port = $3)
+		ORDER BY created_at DESC
+		LIMIT $4
+	`
+	rows, err := db.Pool.Query(ctx, query, filter.ServerID, filter.NodeName, filter.Port, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list port allocation history: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.PortAllocationEvent{}
+	for rows.Next() {
+		var event models.PortAllocationEvent
+		if err := rows.Scan(&event.ID, &event.ServerID, &event.NodeName, &event.Port, &event.Protocol,
+			&event.PortName, &event.Action, &event.Cause, &event.Detail, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan port allocation history: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// DeletePortAllocationHistoryBefore removes allocation history older than cutoff
+func (db *DB) DeletePortAllocationHistoryBefore(ctx context.Context, cutoff time.Time) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM allocations_history WHERE created_at < $1`, cutoff); err != nil {
+		return fmt.Errorf("failed to delete port allocation history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetServerPreferredPort returns the port number a server's game port is allocated first, or
+// 0 if it has none
+func (db *DB) GetServerPreferredPort(ctx context.Context, serverID string) (int, error) {
+	var port int
+	query := `SELECT COALESCE(preferred_port, 0) FROM servers WHERE id = $1`
+	if err := db.Pool.QueryRow(ctx, query, serverID).Scan(&port); err != nil {
+		return 0, fmt.Errorf("failed to get preferred port: %w", err)
+	}
+	return port, nil
+}
+
+// PreferredPortAvailable reports whether the Preferred port number of the requirements is
+// free, and not cooling down after a release, on an active node the server could be placed
+// on. Resources aren't checked: allocation falls back to another port when the nodes with
+// the number free are full.
+func (db *DB) PreferredPortAvailable(ctx context.Context, requirements []PortRequirement, resourceReq *ResourceRequirement) (bool, error) {
+	port, protocols := preferredPort(requirements)
+	if port == 0 {
+		return true, nil
+	}
+
+	dedicated, spot, os := false, false, "linux"
+	if resourceReq != nil {
+		dedicated, spot, os = resourceReq.Dedicated, resourceReq.Spot, nodeOS(resourceReq.OS)
+	}
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM nodes n
+			WHERE n.is_active = TRUE
+			AND n.dedicated = $3
+			AND n.dedicated_server_id IS NULL
+			AND n.spot = $4
+			AND n.os = $5
+			AND ` + preferredPortFree("$1", "$2", "NULL") + `
+		)
+	`
+	var available bool
+	if err := db.Pool.QueryRow(ctx, query, port, protocols, dedicated, spot, os).Scan(&available); err != nil {
+		return false, fmt.Errorf("failed to check preferred port: %w", err)
+	}
+	return available, nil
+}
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// ResourceSample is a game's CPU (percent of one core), memory usage and players online at
+// one point in time
+type ResourceSample struct {
+	SampledAt     time.Time
+	CPUPercent    float64
+	MemoryMB      int64
+	PlayersOnline *int // nil if the game doesn't report players
+}
+
+// RecordResourceUsage adds CPU, memory and player samples to the server's usage for the
+// hours they were taken in
+func (db *DB) RecordResourceUsage(ctx context.Context, serverID string, samples []ResourceSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	sampledAt := make([]time.Time, len(samples))
+	cpuPercent := make([]float64, len(samples))
+	memoryMB := make([]int64, len(samples))
+	players := make([]*int, len(samples))
+	for i, sample := range samples {
+		sampledAt[i], cpuPercent[i], memoryMB[i] = sample.SampledAt, sample.CPUPercent, sample.MemoryMB
+		players[i] = sample.PlayersOnline
+	}
+
+	// GREATEST and MAX ignore NULLs, so players_max stays NULL only if no sample had players
+	query := `
+		INSERT INTO server_resource_usage
+			(server_id, hour, samples, cpu_percent_sum, cpu_percent_max, memory_mb_sum, memory_mb_max, players_max)
+		SELECT $1, date_trunc('hour', s.sampled_at), COUNT(*),
+		       SUM(s.cpu_percent), MAX(s.cpu_percent), SUM(s.memory_mb), MAX(s.memory_mb), MAX(s.players)
+		FROM unnest($2::timestamptz[], $3::float8[], $4::bigint[], $5::int[]) AS s(sampled_at, cpu_percent, memory_mb, players)
+		GROUP BY date_trunc('hour', s.sampled_at)
+		ON CONFLICT (server_id, hour) DO UPDATE
+		SET samples = server_resource_usage.samples + EXCLUDED.samples,
+		    cpu_percent_sum = server_resource_usage.cpu_percent_sum + EXCLUDED.cpu_percent_sum,
+		    cpu_percent_max = GREATEST(server_resource_usage.cpu_percent_max, EXCLUDED.cpu_percent_max),
+		    memory_mb_sum = server_resource_usage.memory_mb_sum + EXCLUDED.memory_mb_sum,
+		    memory_mb_max = GREATEST(server_resource_usage.memory_mb_max, EXCLUDED.memory_mb_max),
+		    players_max = GREATEST(server_resource_usage.players_max, EXCLUDED.players_max)
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID, sampledAt, cpuPercent, memoryMB, players); err != nil {
+		return fmt.Errorf("failed to record resource usage: %w", err)
+	}
+	return nil
+}
+
+// DeleteResourceUsageBefore removes hourly usage older than cutoff
+func (db *DB) DeleteResourceUsageBefore(ctx context.Context, cutoff time.Time) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM server_resource_usage WHERE hour < $1`, cutoff); err != nil {
+		return fmt.Errorf("failed to delete resource usage: %w", err)
+	}
+	return nil
+}
+
+// ServerUsageStats is a server's CPU and memory usage since some time
+type ServerUsageStats struct {
+	ServerID       uuid.UUID
+	Game           models.GameType
+	Plan           models.ServerPlan
+	CatalogChannel string
+	Hours          int // Hours with at least one heartbeat
+	AvgCPUPercent  float64
+	P95CPUPercent  float64 // 95th percentile of hourly averages
+	AvgMemoryMB    int64
+	PeakMemoryMB   int64
+}
+
+// ListServerUsageStats returns the usage since a time of every server that ran for at least
+// minHours of it
+func (db *DB) ListServerUsageStats(ctx context.Context, since time.Time, minHours int) ([]ServerUsageStats, error) {
+	query := `
+		SELECT s.id, s.game, s.plan, s.catalog_channel, COUNT(*),
+			SUM(u.cpu_percent_sum) / SUM(u.samples),
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY u.cpu_percent_sum / u.samples),
+			SUM(u.memory_mb_sum) / SUM(u.samples),
+			MAX(u.memory_mb_max)
+		FROM server_resource_usage u
+		JOIN servers s ON s.id = u.server_id
+		WHERE u.hour >= $1 AND u.samples > 0 AND s.status != 'deleted'
+		GROUP BY s.id
+		HAVING COUNT(*) >= $2
+	`
+
+	rows, err := db.Pool.Query(ctx, query, since, minHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list server usage stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []ServerUsageStats
+	for rows.Next() {
+		var s ServerUsageStats
+		if err := rows.Scan(&s.ServerID, &s.Game, &s.Plan, &s.CatalogChannel, &s.Hours,
+			&s.AvgCPUPercent, &s.P95CPUPercent, &s.AvgMemoryMB, &s.PeakMemoryMB); err != nil {
+			return nil, fmt.Errorf("failed to scan server usage stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// UpsertPlanRecommendation stores a server's latest plan recommendation
+func (db *DB) UpsertPlanRecommendation(ctx context.Context, serverID string, rec *models.PlanRecommendation) error {
+	query := `
+		INSERT INTO server_plan_recommendations
+			(server_id, direction, current_plan, recommended_plan, reason, avg_cpu_percent,
+			 p95_cpu_percent, avg_memory_mb, peak_memory_mb, price_delta, currency, generated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), NOW())
+		ON CONFLICT (server_id) DO UPDATE
+		SET direction = EXCLUDED.direction,
+		    current_plan = EXCLUDED.current_plan,
+		    recommended_plan = EXCLUDED.recommended_plan,
+		    reason = EXCLUDED.reason,
+		    avg_cpu_percent = EXCLUDED.avg_cpu_percent,
+		    p95_cpu_percent = EXCLUDED.p95_cpu_percent,
+		    avg_memory_mb = EXCLUDED.avg_memory_mb,
+		    peak_memory_mb = EXCLUDED.peak_memory_mb,
+		    price_delta = EXCLUDED.price_delta,
+		    currency = EXCLUDED.currency,
+		    generated_at = EXCLUDED.generated_at
+	`
+
+	_, err := db.Pool.Exec(ctx, query, serverID, rec.Direction, rec.CurrentPlan, rec.RecommendedPlan, rec.Reason,
+		rec.AvgCPUPercent, rec.P95CPUPercent, rec.AvgMemoryMB, rec.PeakMemoryMB, rec.PriceDelta, rec.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to upsert plan recommendation: %w", err)
+	}
+	return nil
+}
+
+// DeletePlanRecommendationsBefore removes recommendations that weren't regenerated since
+// cutoff, e.g. because the server's usage now fits its plan
+func (db *DB) DeletePlanRecommendationsBefore(ctx context.Context, cutoff time.Time) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM server_plan_recommendations WHERE generated_at < $1`, cutoff); err != nil {
+		return fmt.Errorf("failed to delete plan recommendations: %w", err)
+	}
+	return nil
+}
+
+const planRecommendationColumns = `direction, current_plan, recommended_plan, reason, avg_cpu_percent,
+	p95_cpu_percent, avg_memory_mb, peak_memory_mb, price_delta, COALESCE(currency, ''), generated_at`
+
+func scanPlanRecommendation(row pgx.Row, extra ...any) (*models.PlanRecommendation, error) {
+	var rec models.PlanRecommendation
+	dest := append(extra, &rec.Direction, &rec.CurrentPlan, &rec.RecommendedPlan, &rec.Reason, &rec.AvgCPUPercent,
+		&rec.P95CPUPercent, &rec.AvgMemoryMB, &rec.PeakMemoryMB, &rec.PriceDelta, &rec.Currency, &rec.GeneratedAt)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// GetPlanRecommendation returns a server's latest plan recommendation. Returns (nil, nil)
+// if there is none.
+func (db *DB) GetPlanRecommendation(ctx context.Context, serverID string) (*models.PlanRecommendation, error) {
+	query := `SELECT ` + planRecommendationColumns + ` FROM server_plan_recommendations WHERE server_id = $1`
+
+	rec, err := scanPlanRecommendation(db.Pool.QueryRow(ctx, query, serverID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan recommendation: %w", err)
+	}
+	return rec, nil
+}
+
+// RecommendationDigestItem is a recommendation for the monthly summary email
+type RecommendationDigestItem struct {
+	UserID      uuid.UUID
+	Email       string
+	ServerID    uuid.UUID
+	DisplayName string
+	Game        models.GameType
+	models.PlanRecommendation
+}
+
+// ListRecommendationDigestItems returns the current recommendations of users who weren't
+// sent a summary within interval, grouped by user. Recommendations for a plan the server is
+// no longer on are left out.
+func (db *DB) ListRecommendationDigestItems(ctx context.Context, interval time.Duration) ([]RecommendationDigestItem, error) {
+	query := `
+		SELECT u.id, u.email, s.id, s.display_name, s.game, ` + planRecommendationColumns + `
+		FROM server_plan_recommendations r
+		JOIN servers s ON s.id = r.server_id
+		JOIN users u ON u.id = s.user_id
+		WHERE s.status != 'deleted' AND s.plan = r.current_plan
+		AND (u.recommendations_emailed_at IS NULL
+		     OR u.recommendations_emailed_at < NOW() - $1 * interval '1 second')
+		ORDER BY u.id, s.display_name
+	`
+
+	rows, err := db.Pool.Query(ctx, query, interval.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recommendation digest items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []RecommendationDigestItem
+	for rows.Next() {
+		var item RecommendationDigestItem
+		rec, err := scanPlanRecommendation(rows, &item.UserID, &item.Email, &item.ServerID, &item.DisplayName, &item.Game)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recommendation digest item: %w", err)
+		}
+		item.PlanRecommendation = *rec
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// MarkRecommendationsEmailed records that a user was sent the recommendations summary
+func (db *DB) MarkRecommendationsEmailed(ctx context.Context, userID uuid.UUID) error {
+	if _, err := db.Pool.Exec(ctx, `UPDATE users SET recommendations_emailed_at = NOW() WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to mark recommendations emailed: %w", err)
+	}
+	return nil
+}
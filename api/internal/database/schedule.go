@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const scheduleColumns = `id, server_id, name, cron, timezone, action, payload, enabled, next_run_at,
+	last_run_at, last_result, created_at, updated_at`
+
+func scanSchedule(row pgx.Row) (*models.ServerSchedule, error) {
+	var s models.ServerSchedule
+	if err := row.Scan(&s.ID, &s.ServerID, &s.Name, &s.Cron, &s.Timezone, &s.Action, &s.Payload, &s.Enabled,
+		&s.NextRunAt, &s.LastRunAt, &s.LastResult, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (db *DB) querySchedules(ctx context.Context, query string, args ...any) ([]models.ServerSchedule, error) {
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.ServerSchedule{}
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *s)
+	}
+	return schedules, rows.Err()
+}
+
+// CreateSchedule adds a scheduled task to a server
+func (db *DB) CreateSchedule(ctx context.Context, schedule *models.ServerSchedule) (*models.ServerSchedule, error) {
+	query := `
+		INSERT INTO server_schedules (server_id, name, cron, timezone, action, payload, enabled, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + scheduleColumns
+
+	created, err := scanSchedule(db.Pool.QueryRow(ctx, query, schedule.ServerID, schedule.Name, schedule.Cron,
+		schedule.Timezone, schedule.Action, schedule.Payload, schedule.Enabled, schedule.NextRunAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+	return created, nil
+}
+
+// ListSchedules returns a server's scheduled tasks, oldest first
+func (db *DB) ListSchedules(ctx context.Context, serverID string) ([]models.ServerSchedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM server_schedules WHERE server_id = $1 ORDER BY created_at`
+
+	schedules, err := db.querySchedules(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// UpdateSchedule replaces one of a server's scheduled tasks. Returns (nil, nil) if it
+// doesn't exist.
+func (db *DB) UpdateSchedule(ctx context.Context, schedule *models.ServerSchedule) (*models.ServerSchedule, error) {
+	query := `
+		UPDATE server_schedules
+		SET name = $3, cron = $4, timezone = $5, action = $6, payload = $7, enabled = $8, next_run_at = $9,
+		    updated_at = NOW()
+		WHERE id = $1 AND server_id = $2
+		RETURNING ` + scheduleColumns
+
+	updated, err := scanSchedule(db.Pool.QueryRow(ctx, query, schedule.ID, schedule.ServerID, schedule.Name,
+		schedule.Cron, schedule.Timezone, schedule.Action, schedule.Payload, schedule.Enabled, schedule.NextRunAt))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteSchedule removes one of a server's scheduled tasks. Returns false if it doesn't exist.
+func (db *DB) DeleteSchedule(ctx context.Context, serverID, id string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM server_schedules WHERE id = $1 AND server_id = $2`, id, serverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListDueSchedules returns the enabled scheduled tasks whose next run is due, oldest first
+func (db *DB) ListDueSchedules(ctx context.Context, now time.Time) ([]models.ServerSchedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM server_schedules
+		WHERE enabled AND next_run_at <= $1
+		ORDER BY next_run_at`
+
+	schedules, err := db.querySchedules(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// ClaimScheduleRun moves a due task's next run from runAt to nextRunAt. Returns false if
+// the task was claimed, changed or deleted meanwhile, so each run happens once.
+func (db *DB) ClaimScheduleRun(ctx context.Context, id uuid.UUID, runAt, nextRunAt time.Time) (bool, error) {
+	query := `UPDATE server_schedules SET next_run_at = $3 WHERE id = $1 AND next_run_at = $2 AND enabled`
+	tag, err := db.Pool.Exec(ctx, query, id, runAt, nextRunAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim schedule run: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RecordScheduleRun records what a task's run did
+func (db *DB) RecordScheduleRun(ctx context.Context, id uuid.UUID, result string) error {
+	query := `UPDATE server_schedules SET last_run_at = NOW(), last_result = $2 WHERE id = $1`
+	if _, err := db.Pool.Exec(ctx, query, id, result); err != nil {
+		return fmt.Errorf("failed to record schedule run: %w", err)
+	}
+	return nil
+}
+
+// DisableSchedule turns off a task that can't run anymore, recording why
+func (db *DB) DisableSchedule(ctx context.Context, id uuid.UUID, result string) error {
+	query := `UPDATE server_schedules SET enabled = FALSE, last_result = $2, updated_at = NOW() WHERE id = $1`
+	if _, err := db.Pool.Exec(ctx, query, id, result); err != nil {
+		return fmt.Errorf("failed to disable schedule: %w", err)
+	}
+	return nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/mooncorn/gshub/api/internal/models"
 )
 
@@ -16,15 +18,17 @@ type CreateServerParams struct {
 	Game                 models.GameType
 	Plan                 models.ServerPlan
 	StripeSubscriptionID *string
+	Namespace            string // K8s namespace the server's resources are created in
+	PreferredPort        int    // Port number the game port is allocated first (0 = any)
 }
 
 // CreateServer inserts a new server with pending status and populates the server model
 func (db *DB) CreateServer(ctx context.Context, serverParams *CreateServerParams) (*models.Server, error) {
 	query := `
 		INSERT INTO servers (
-			user_id, display_name, subdomain, game, plan, stripe_subscription_id
-		) VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, user_id, display_name, subdomain, game, plan, status, status_message,
+			user_id, display_name, subdomain, game, plan, stripe_subscription_id, namespace, preferred_port
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, 0))
+		RETURNING id, user_id, display_name, subdomain, game, plan, status, status_message, status_reason, namespace, catalog_channel,
 		          creation_error, last_reconciled, stripe_subscription_id,
 		          created_at, updated_at, stopped_at, expired_at, delete_after
 	`
@@ -37,6 +41,8 @@ func (db *DB) CreateServer(ctx context.Context, serverParams *CreateServerParams
 		serverParams.Game,
 		serverParams.Plan,
 		serverParams.StripeSubscriptionID,
+		serverParams.Namespace,
+		serverParams.PreferredPort,
 	).Scan(
 		&server.ID,
 		&server.UserID,
@@ -46,6 +52,9 @@ func (db *DB) CreateServer(ctx context.Context, serverParams *CreateServerParams
 		&server.Plan,
 		&server.Status,
 		&server.StatusMessage,
+		&server.StatusReason,
+		&server.Namespace,
+		&server.CatalogChannel,
 		&server.CreationError,
 		&server.LastReconciled,
 		&server.StripeSubscriptionID,
@@ -66,7 +75,7 @@ func (db *DB) CreateServer(ctx context.Context, serverParams *CreateServerParams
 // GetServerByID retrieves a single server by ID
 func (db *DB) GetServerByID(ctx context.Context, id string) (*models.Server, error) {
 	query := `
-		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message,
+		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message, status_reason, namespace, catalog_channel,
 		       creation_error, last_reconciled, stripe_subscription_id,
 		       created_at, updated_at, stopped_at, expired_at, delete_after, env_overrides
 		FROM servers
@@ -84,6 +93,9 @@ func (db *DB) GetServerByID(ctx context.Context, id string) (*models.Server, err
 		&server.Plan,
 		&server.Status,
 		&server.StatusMessage,
+		&server.StatusReason,
+		&server.Namespace,
+		&server.CatalogChannel,
 		&server.CreationError,
 		&server.LastReconciled,
 		&server.StripeSubscriptionID,
@@ -112,7 +124,7 @@ func (db *DB) GetServerByID(ctx context.Context, id string) (*models.Server, err
 func (db *DB) GetServerByIDWithDetails(ctx context.Context, id string) (*models.Server, error) {
 	query := `
 		SELECT
-			s.id, s.user_id, s.display_name, s.subdomain, s.game, s.plan, s.status, s.status_message,
+			s.id, s.user_id, s.display_name, s.subdomain, s.game, s.plan, s.status, s.status_message, s.status_reason, s.namespace, s.catalog_channel,
 			s.creation_error, s.last_reconciled, s.stripe_subscription_id,
 			s.created_at, s.updated_at, s.stopped_at, s.expired_at, s.delete_after, s.env_overrides,
 			COALESCE(
@@ -143,13 +155,23 @@ func (db *DB) GetServerByIDWithDetails(ctx context.Context, id string) (*models.
 				FROM server_volumes v
 				WHERE v.server_id = s.id),
 				'[]'::json
-			) as volumes
+			) as volumes,
+			(SELECT json_build_object(
+				'public_ip', n.public_ip,
+				'zone', n.zone,
+				'region', n.region,
+				'provider', n.provider,
+				'datacenter', n.datacenter
+			)
+			FROM nodes n
+			WHERE EXISTS (SELECT 1 FROM port_allocations pa WHERE pa.node_id = n.id AND pa.server_id = s.id)
+			LIMIT 1) as location
 		FROM servers s
 		WHERE s.id = $1
 	`
 
 	var server models.Server
-	var portsJSON, volumesJSON, envOverridesJSON []byte
+	var portsJSON, volumesJSON, envOverridesJSON, locationJSON []byte
 
 	err := db.Pool.QueryRow(ctx, query, id).Scan(
 		&server.ID,
@@ -160,6 +182,9 @@ func (db *DB) GetServerByIDWithDetails(ctx context.Context, id string) (*models.
 		&server.Plan,
 		&server.Status,
 		&server.StatusMessage,
+		&server.StatusReason,
+		&server.Namespace,
+		&server.CatalogChannel,
 		&server.CreationError,
 		&server.LastReconciled,
 		&server.StripeSubscriptionID,
@@ -171,6 +196,7 @@ func (db *DB) GetServerByIDWithDetails(ctx context.Context, id string) (*models.
 		&envOverridesJSON,
 		&portsJSON,
 		&volumesJSON,
+		&locationJSON,
 	)
 
 	if err != nil {
@@ -192,18 +218,24 @@ func (db *DB) GetServerByIDWithDetails(ctx context.Context, id string) (*models.
 		}
 	}
 
+	if locationJSON != nil {
+		if err := json.Unmarshal(locationJSON, &server.Location); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+		}
+	}
+
 	return &server, nil
 }
 
 // ListServersByUser returns all servers for a user
 func (db *DB) ListServersByUser(ctx context.Context, userID uuid.UUID) ([]models.Server, error) {
 	query := `
-		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message,
+		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message, status_reason, namespace, catalog_channel,
 		       creation_error, last_reconciled, stripe_subscription_id,
-		       created_at, updated_at, stopped_at, expired_at, delete_after, env_overrides
+		       created_at, updated_at, stopped_at, expired_at, delete_after, env_overrides, group_id
 		FROM servers
 		WHERE user_id = $1
-		ORDER BY created_at DESC
+		ORDER BY group_position, created_at DESC
 	`
 
 	rows, err := db.Pool.Query(ctx, query, userID)
@@ -225,6 +257,9 @@ func (db *DB) ListServersByUser(ctx context.Context, userID uuid.UUID) ([]models
 			&server.Plan,
 			&server.Status,
 			&server.StatusMessage,
+			&server.StatusReason,
+			&server.Namespace,
+			&server.CatalogChannel,
 			&server.CreationError,
 			&server.LastReconciled,
 			&server.StripeSubscriptionID,
@@ -234,6 +269,7 @@ func (db *DB) ListServersByUser(ctx context.Context, userID uuid.UUID) ([]models
 			&server.ExpiredAt,
 			&server.DeleteAfter,
 			&envOverridesJSON,
+			&server.GroupID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan server: %w", err)
@@ -253,7 +289,7 @@ func (db *DB) ListServersByUser(ctx context.Context, userID uuid.UUID) ([]models
 // Excludes hard-deleted servers (status != 'deleted' OR delete_after in future)
 func (db *DB) GetAllServers(ctx context.Context) ([]models.Server, error) {
 	query := `
-		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message,
+		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message, status_reason, namespace, catalog_channel,
 		       creation_error, last_reconciled, stripe_subscription_id,
 		       created_at, updated_at, stopped_at, expired_at, delete_after, env_overrides
 		FROM servers
@@ -280,6 +316,9 @@ func (db *DB) GetAllServers(ctx context.Context) ([]models.Server, error) {
 			&server.Plan,
 			&server.Status,
 			&server.StatusMessage,
+			&server.StatusReason,
+			&server.Namespace,
+			&server.CatalogChannel,
 			&server.CreationError,
 			&server.LastReconciled,
 			&server.StripeSubscriptionID,
@@ -322,38 +361,67 @@ func (db *DB) UpdateServerStatus(ctx context.Context, id, status, message string
 	return nil
 }
 
-// TransitionServerStatus atomically transitions status only if current matches expected.
-// Returns (true, nil) if transitioned, (false, nil) if status didn't match, (false, error) on DB error.
-func (db *DB) TransitionServerStatus(ctx context.Context, id string, fromStatus, toStatus models.ServerStatus, message string) (bool, error) {
+// TransitionServerStatus atomically moves a server to toStatus if it's currently in one of
+// fromStatuses, recording the message, reason code and startup phase (empty clears them)
+// and stamping stopped_at on entering stopped. Callers should go through serverstate.Machine,
+// which validates the transition and runs its side effects.
+// Returns the previous status and true if transitioned, ("", false, nil) if the status didn't match.
+func (db *DB) TransitionServerStatus(ctx context.Context, id string, fromStatuses []models.ServerStatus, toStatus models.ServerStatus, message string, reason models.StatusReason, phase models.StartupPhase) (models.ServerStatus, bool, error) {
+	statusStrings := make([]string, len(fromStatuses))
+	for i, s := range fromStatuses {
+		statusStrings[i] = string(s)
+	}
 	query := `
-		UPDATE servers
-		SET status = $2, status_message = $3, updated_at = NOW()
-		WHERE id = $1 AND status = $4
+		UPDATE servers s
+		SET status = $2,
+		    status_message = $3,
+		    status_reason = NULLIF($4, ''),
+		    startup_phase = NULLIF($6, ''),
+		    stopped_at = CASE WHEN $2 = 'stopped' AND old.status <> 'stopped' THEN NOW() ELSE s.stopped_at END,
+		    updated_at = NOW()
+		FROM (SELECT id, status FROM servers WHERE id = $1 FOR UPDATE) old
+		WHERE s.id = old.id AND old.status = ANY($5)
+		RETURNING old.status
 	`
-	result, err := db.Pool.Exec(ctx, query, id, string(toStatus), message, string(fromStatus))
+	var previous string
+	err := db.Pool.QueryRow(ctx, query, id, string(toStatus), message, string(reason), statusStrings, string(phase)).Scan(&previous)
+	if err == pgx.ErrNoRows {
+		return "", false, nil
+	}
 	if err != nil {
-		return false, fmt.Errorf("failed to transition status: %w", err)
+		return "", false, fmt.Errorf("failed to transition status: %w", err)
 	}
-	return result.RowsAffected() == 1, nil
+	return models.ServerStatus(previous), true, nil
 }
 
-// TransitionServerStatusFrom transitions from any of the given statuses.
-// Returns (true, nil) if transitioned, (false, nil) if status didn't match, (false, error) on DB error.
-func (db *DB) TransitionServerStatusFrom(ctx context.Context, id string, fromStatuses []models.ServerStatus, toStatus models.ServerStatus, message string) (bool, error) {
-	statusStrings := make([]string, len(fromStatuses))
-	for i, s := range fromStatuses {
-		statusStrings[i] = string(s)
-	}
+// UpdateServerStartupPhase records the startup phase and message of a starting server.
+// Returns false if the server isn't starting.
+func (db *DB) UpdateServerStartupPhase(ctx context.Context, id string, phase models.StartupPhase, message string) (bool, error) {
 	query := `
 		UPDATE servers
-		SET status = $2, status_message = $3, updated_at = NOW()
-		WHERE id = $1 AND status = ANY($4)
+		SET startup_phase = $2,
+		    status_message = $3,
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'starting'
 	`
-	result, err := db.Pool.Exec(ctx, query, id, string(toStatus), message, statusStrings)
+	result, err := db.Pool.Exec(ctx, query, id, string(phase), message)
 	if err != nil {
-		return false, fmt.Errorf("failed to transition status: %w", err)
+		return false, fmt.Errorf("failed to update startup phase: %w", err)
 	}
-	return result.RowsAffected() == 1, nil
+	return result.RowsAffected() > 0, nil
+}
+
+// GetServerStartupPhase returns the startup phase of a server, or "" if none was reported
+func (db *DB) GetServerStartupPhase(ctx context.Context, id string) (models.StartupPhase, error) {
+	var phase *string
+	err := db.Pool.QueryRow(ctx, `SELECT startup_phase FROM servers WHERE id = $1`, id).Scan(&phase)
+	if err != nil {
+		return "", fmt.Errorf("failed to get startup phase: %w", err)
+	}
+	if phase == nil {
+		return "", nil
+	}
+	return models.StartupPhase(*phase), nil
 }
 
 // UpdateServerToRunning transitions server to running state
@@ -362,6 +430,7 @@ func (db *DB) UpdateServerToRunning(ctx context.Context, id string) error {
         UPDATE servers
         SET status = 'running',
             status_message = NULL,
+            status_reason = NULL,
             updated_at = NOW()
         WHERE id = $1
     `
@@ -369,23 +438,39 @@ func (db *DB) UpdateServerToRunning(ctx context.Context, id string) error {
 	return err
 }
 
-// MarkServerFailed marks a server as failed with an error message
-func (db *DB) MarkServerFailed(ctx context.Context, id, errorMsg string) error {
+// MarkServerFailed marks a server as failed with an error message and reason code
+func (db *DB) MarkServerFailed(ctx context.Context, id, errorMsg string, reason models.StatusReason) error {
 	query := `
 		UPDATE servers
 		SET status = 'failed',
 		    creation_error = $2,
+		    status_reason = NULLIF($3, ''),
 		    last_reconciled = NOW(),
 		    updated_at = NOW()
 		WHERE id = $1
 	`
-	_, err := db.Pool.Exec(ctx, query, id, errorMsg)
+	_, err := db.Pool.Exec(ctx, query, id, errorMsg, string(reason))
 	if err != nil {
 		return fmt.Errorf("failed to mark server as failed: %w", err)
 	}
 	return nil
 }
 
+// UpdateServerPlan changes a server's plan. Resources are re-reserved on the next reconcile.
+func (db *DB) UpdateServerPlan(ctx context.Context, id string, plan models.ServerPlan) error {
+	query := `
+		UPDATE servers
+		SET plan = $2,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := db.Pool.Exec(ctx, query, id, string(plan))
+	if err != nil {
+		return fmt.Errorf("failed to update server plan: %w", err)
+	}
+	return nil
+}
+
 // UpdateServerLastReconciled updates the last_reconciled timestamp
 func (db *DB) UpdateServerLastReconciled(ctx context.Context, id string) error {
 	query := `
@@ -499,7 +584,7 @@ func (db *DB) GetServerVolumes(ctx context.Context, serverID string) ([]models.S
 // GetServerByStripeSubscriptionID retrieves a server by its Stripe subscription ID
 func (db *DB) GetServerByStripeSubscriptionID(ctx context.Context, subscriptionID string) (*models.Server, error) {
 	query := `
-		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message,
+		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message, namespace, catalog_channel,
 		       stripe_subscription_id,
 		       created_at, updated_at, stopped_at, expired_at, delete_after
 		FROM servers
@@ -516,6 +601,8 @@ func (db *DB) GetServerByStripeSubscriptionID(ctx context.Context, subscriptionI
 		&server.Plan,
 		&server.Status,
 		&server.StatusMessage,
+		&server.Namespace,
+		&server.CatalogChannel,
 		&server.StripeSubscriptionID,
 		&server.CreatedAt,
 		&server.UpdatedAt,
@@ -540,8 +627,10 @@ func (db *DB) MarkServerExpired(ctx context.Context, id string) error {
 		SET status = 'expired',
 		    expired_at = NOW(),
 		    delete_after = NOW() + interval '7 days',
+		    deletion_warning_sent_at = NULL,
 		    reserved_cpu_millicores = NULL,
 		    reserved_memory_bytes = NULL,
+		    cancel_at = NULL,
 		    updated_at = NOW()
 		WHERE id = $1
 	`
@@ -554,10 +643,24 @@ func (db *DB) MarkServerExpired(ctx context.Context, id string) error {
 	return nil
 }
 
+// ExpediteServerDeletion makes an expired server due for cleanup now, skipping the rest of
+// its grace period and the deletion warning email
+func (db *DB) ExpediteServerDeletion(ctx context.Context, id string) error {
+	query := `
+		UPDATE servers
+		SET delete_after = NOW(), deletion_warning_sent_at = COALESCE(deletion_warning_sent_at, NOW()), updated_at = NOW()
+		WHERE id = $1 AND status = 'expired'
+	`
+	if _, err := db.Pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to expedite server deletion: %w", err)
+	}
+	return nil
+}
+
 // GetExpiredServersForCleanup retrieves servers that are expired and past their delete_after time
 func (db *DB) GetExpiredServersForCleanup(ctx context.Context) ([]models.Server, error) {
 	query := `
-		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message,
+		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message, namespace, catalog_channel,
 		       creation_error, last_reconciled, stripe_subscription_id,
 		       created_at, updated_at, stopped_at, expired_at, delete_after, env_overrides
 		FROM servers
@@ -584,6 +687,8 @@ func (db *DB) GetExpiredServersForCleanup(ctx context.Context) ([]models.Server,
 			&server.Plan,
 			&server.Status,
 			&server.StatusMessage,
+			&server.Namespace,
+			&server.CatalogChannel,
 			&server.CreationError,
 			&server.LastReconciled,
 			&server.StripeSubscriptionID,
@@ -611,7 +716,7 @@ func (db *DB) GetExpiredServersForCleanup(ctx context.Context) ([]models.Server,
 // GetServersByStatus retrieves all servers with a given status (used by reconciler)
 func (db *DB) GetServersByStatus(ctx context.Context, status string) ([]models.Server, error) {
 	query := `
-		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message,
+		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message, namespace, catalog_channel,
 		       creation_error, last_reconciled, stripe_subscription_id,
 		       created_at, updated_at, stopped_at, expired_at, delete_after, env_overrides
 		FROM servers
@@ -638,6 +743,8 @@ func (db *DB) GetServersByStatus(ctx context.Context, status string) ([]models.S
 			&server.Plan,
 			&server.Status,
 			&server.StatusMessage,
+			&server.Namespace,
+			&server.CatalogChannel,
 			&server.CreationError,
 			&server.LastReconciled,
 			&server.StripeSubscriptionID,
@@ -662,6 +769,46 @@ func (db *DB) GetServersByStatus(ctx context.Context, status string) ([]models.S
 	return servers, nil
 }
 
+// SetServerCatalogChannel moves a server to a game catalog channel, optionally switching its
+// game to one defined in that channel. Takes effect when the server's deployment is recreated.
+func (db *DB) SetServerCatalogChannel(ctx context.Context, serverID, channel string, game models.GameType) error {
+	query := `
+		UPDATE servers
+		SET catalog_channel = $2,
+		    game = $3,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := db.Pool.Exec(ctx, query, serverID, channel, game)
+	if err != nil {
+		return fmt.Errorf("failed to set catalog channel: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("server not found: %s", serverID)
+	}
+	return nil
+}
+
+// SetServerNamespace moves a server that has no K8s resources yet to another namespace
+func (db *DB) SetServerNamespace(ctx context.Context, serverID, namespace string) error {
+	query := `
+		UPDATE servers
+		SET namespace = $2,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := db.Pool.Exec(ctx, query, serverID, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to set namespace: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("server not found: %s", serverID)
+	}
+	return nil
+}
+
 // UpdateServerEnvOverrides updates the env_overrides for a server
 func (db *DB) UpdateServerEnvOverrides(ctx context.Context, id string, envOverrides map[string]string) error {
 	query := `
@@ -698,6 +845,7 @@ func (db *DB) ReactivateServer(ctx context.Context, id string, subscriptionID st
 		    stripe_subscription_id = $2,
 		    expired_at = NULL,
 		    delete_after = NULL,
+		    deletion_warning_sent_at = NULL,
 		    status_message = 'Reactivating server...',
 		    updated_at = NOW()
 		WHERE id = $1 AND status = 'expired'
@@ -730,6 +878,20 @@ func (db *DB) SetServerAuthToken(ctx context.Context, serverID, token string) er
 	return nil
 }
 
+// GetServerAuthToken returns a server's supervisor auth token, which the supervisor also
+// requires of the API when serving files
+func (db *DB) GetServerAuthToken(ctx context.Context, serverID string) (string, error) {
+	var token *string
+	err := db.Pool.QueryRow(ctx, `SELECT auth_token FROM servers WHERE id = $1`, serverID).Scan(&token)
+	if err != nil {
+		return "", fmt.Errorf("failed to get server auth token: %w", err)
+	}
+	if token == nil {
+		return "", fmt.Errorf("server has no auth token")
+	}
+	return *token, nil
+}
+
 // ValidateServerAuthToken validates the auth token for a server
 func (db *DB) ValidateServerAuthToken(ctx context.Context, serverID, token string) (bool, error) {
 	query := `
@@ -744,41 +906,27 @@ func (db *DB) ValidateServerAuthToken(ctx context.Context, serverID, token strin
 	return count == 1, nil
 }
 
-// UpdateServerHeartbeat updates the last_heartbeat timestamp
-func (db *DB) UpdateServerHeartbeat(ctx context.Context, serverID string) error {
+// UpdateServerHeartbeat updates the last_heartbeat timestamp and the players online (nil if
+// the game doesn't report players)
+func (db *DB) UpdateServerHeartbeat(ctx context.Context, serverID string, playersOnline *int) error {
 	query := `
 		UPDATE servers
 		SET last_heartbeat = NOW(),
+		    players_online = $2,
 		    updated_at = NOW()
 		WHERE id = $1
 	`
-	_, err := db.Pool.Exec(ctx, query, serverID)
+	_, err := db.Pool.Exec(ctx, query, serverID, playersOnline)
 	if err != nil {
 		return fmt.Errorf("failed to update heartbeat: %w", err)
 	}
 	return nil
 }
 
-// UpdateServerStatusAny updates server status from any current status
-func (db *DB) UpdateServerStatusAny(ctx context.Context, id string, toStatus models.ServerStatus, message string) error {
-	query := `
-		UPDATE servers
-		SET status = $2,
-		    status_message = $3,
-		    updated_at = NOW()
-		WHERE id = $1
-	`
-	_, err := db.Pool.Exec(ctx, query, id, string(toStatus), message)
-	if err != nil {
-		return fmt.Errorf("failed to update status: %w", err)
-	}
-	return nil
-}
-
 // GetServersWithoutRecentHeartbeat finds servers with stale heartbeats
 func (db *DB) GetServersWithoutRecentHeartbeat(ctx context.Context, status models.ServerStatus, threshold int) ([]models.Server, error) {
 	query := `
-		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message,
+		SELECT id, user_id, display_name, subdomain, game, plan, status, status_message, namespace, catalog_channel,
 		       creation_error, last_reconciled, stripe_subscription_id,
 		       created_at, updated_at, stopped_at, expired_at, delete_after, env_overrides,
 		       last_heartbeat
@@ -807,6 +955,8 @@ func (db *DB) GetServersWithoutRecentHeartbeat(ctx context.Context, status model
 			&server.Plan,
 			&server.Status,
 			&server.StatusMessage,
+			&server.Namespace,
+			&server.CatalogChannel,
 			&server.CreationError,
 			&server.LastReconciled,
 			&server.StripeSubscriptionID,
@@ -862,3 +1012,46 @@ func (db *DB) RecordOOMEvent(ctx context.Context, serverID string) error {
 	}
 	return nil
 }
+
+// StartBudgetRetryAfter checks a user-initiated start/restart against the server's budget of
+// limit starts per fixed window. Returns 0 if the budget allows it, or the time remaining
+// until the window resets if it's exhausted.
+func (db *DB) StartBudgetRetryAfter(ctx context.Context, serverID string, limit int, window time.Duration) (time.Duration, error) {
+	query := `
+		SELECT CASE
+		        WHEN start_window_start IS NULL
+		             OR start_window_start <= NOW() - $3 * interval '1 second'
+		             OR start_count < $2 THEN 0
+		        ELSE GREATEST(EXTRACT(EPOCH FROM start_window_start + $3 * interval '1 second' - NOW()), 0)
+		    END
+		FROM servers
+		WHERE id = $1
+	`
+
+	var remainingSeconds float64
+	if err := db.Pool.QueryRow(ctx, query, serverID, limit, window.Seconds()).Scan(&remainingSeconds); err != nil {
+		return 0, fmt.Errorf("failed to get start budget: %w", err)
+	}
+	return time.Duration(remainingSeconds * float64(time.Second)), nil
+}
+
+// ChargeStartBudget records a start/restart against the server's budget, opening a new
+// window if the last one has passed
+func (db *DB) ChargeStartBudget(ctx context.Context, serverID string, window time.Duration) error {
+	query := `
+		UPDATE servers
+		SET start_count = CASE
+		        WHEN start_window_start IS NULL OR start_window_start <= NOW() - $2 * interval '1 second' THEN 1
+		        ELSE start_count + 1
+		    END,
+		    start_window_start = CASE
+		        WHEN start_window_start IS NULL OR start_window_start <= NOW() - $2 * interval '1 second' THEN NOW()
+		        ELSE start_window_start
+		    END
+		WHERE id = $1
+	`
+	if _, err := db.Pool.Exec(ctx, query, serverID, window.Seconds()); err != nil {
+		return fmt.Errorf("failed to charge start budget: %w", err)
+	}
+	return nil
+}
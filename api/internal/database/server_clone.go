@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const serverCloneColumns = `server_id, source_server_id, state, error, created_at, completed_at`
+
+func scanServerClone(row pgx.Row) (*models.ServerClone, error) {
+	var clone models.ServerClone
+	if err := row.Scan(&clone.ServerID, &clone.SourceServerID, &clone.State, &clone.Error,
+		&clone.CreatedAt, &clone.CompletedAt); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// CreateServerClone records that a new server starts with a copy of the source's data
+func (db *DB) CreateServerClone(ctx context.Context, serverID, sourceID uuid.UUID) error {
+	query := `INSERT INTO server_clones (server_id, source_server_id) VALUES ($1, $2)`
+	if _, err := db.Pool.Exec(ctx, query, serverID, sourceID); err != nil {
+		return fmt.Errorf("failed to create server clone: %w", err)
+	}
+	return nil
+}
+
+// GetServerClone returns the clone a server was created as. Returns (nil, nil) if the server
+// isn't a clone.
+func (db *DB) GetServerClone(ctx context.Context, serverID string) (*models.ServerClone, error) {
+	query := `SELECT ` + serverCloneColumns + ` FROM server_clones WHERE server_id = $1`
+
+	clone, err := scanServerClone(db.Pool.QueryRow(ctx, query, serverID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server clone: %w", err)
+	}
+	return clone, nil
+}
+
+// ListActiveServerClones returns the clones waiting for or copying their data, oldest first
+func (db *DB) ListActiveServerClones(ctx context.Context) ([]models.ServerClone, error) {
+	query := `SELECT ` + serverCloneColumns + ` FROM server_clones
+		WHERE state IN ('pending', 'copying')
+		ORDER BY created_at`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list server clones: %w", err)
+	}
+	defer rows.Close()
+
+	clones := []models.ServerClone{}
+	for rows.Next() {
+		clone, err := scanServerClone(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan server clone: %w", err)
+		}
+		clones = append(clones, *clone)
+	}
+	return clones, rows.Err()
+}
+
+// MarkServerCloneCopying records that a clone's copy pod was created
+func (db *DB) MarkServerCloneCopying(ctx context.Context, serverID uuid.UUID) error {
+	query := `UPDATE server_clones SET state = 'copying' WHERE server_id = $1 AND state = 'pending'`
+	if _, err := db.Pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to mark server clone copying: %w", err)
+	}
+	return nil
+}
+
+// CompleteServerClone records that a clone's data was copied, and gives it the source's
+// installed mods, whose files came along
+func (db *DB) CompleteServerClone(ctx context.Context, serverID uuid.UUID) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var sourceID *uuid.UUID
+	query := `
+		UPDATE server_clones
+		SET state = 'completed', error = NULL, completed_at = NOW()
+		WHERE server_id = $1 AND state IN ('pending', 'copying')
+		RETURNING source_server_id
+	`
+	err = tx.QueryRow(ctx, query, serverID).Scan(&sourceID)
+	if err == pgx.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to complete server clone: %w", err)
+	}
+
+	if sourceID != nil {
+		modsQuery := `
+			INSERT INTO server_mods (server_id, project_id, version_id, name, version_number, loader, path, size)
+			SELECT $1, project_id, version_id, name, version_number, loader, path, size
+			FROM server_mods WHERE server_id = $2
+			ON CONFLICT (server_id, project_id) DO NOTHING
+		`
+		if _, err := tx.Exec(ctx, modsQuery, serverID, *sourceID); err != nil {
+			return fmt.Errorf("failed to copy server mods: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FailServerClone records that copying a clone's data failed
+func (db *DB) FailServerClone(ctx context.Context, serverID uuid.UUID, message string) error {
+	query := `
+		UPDATE server_clones
+		SET state = 'failed', error = $2, completed_at = NOW()
+		WHERE server_id = $1 AND state IN ('pending', 'copying')
+	`
+	if _, err := db.Pool.Exec(ctx, query, serverID, message); err != nil {
+		return fmt.Errorf("failed to fail server clone: %w", err)
+	}
+	return nil
+}
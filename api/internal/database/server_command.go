@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// serverCommandsChannel is the Postgres notification channel a server's ID is sent on when
+// a command is queued for it
+const serverCommandsChannel = "server_commands"
+
+const serverCommandColumns = `id, server_id, type, payload, state, result, created_at, delivered_at, completed_at,
+	artifact_path, artifact_size, progress_done, progress_total`
+
+func scanServerCommand(row interface{ Scan(...any) error }) (*models.ServerCommand, error) {
+	var cmd models.ServerCommand
+	var artifactPath *string
+	var artifactSize, progressDone, progressTotal *int64
+	err := row.Scan(
+		&cmd.ID, &cmd.ServerID, &cmd.Type, &cmd.Payload, &cmd.State,
+		&cmd.Result, &cmd.CreatedAt, &cmd.DeliveredAt, &cmd.CompletedAt,
+		&artifactPath, &artifactSize, &progressDone, &progressTotal,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if artifactPath != nil {
+		cmd.Artifact = &models.CommandArtifact{Path: *artifactPath, Name: path.Base(*artifactPath)}
+		if artifactSize != nil {
+			cmd.Artifact.Size = *artifactSize
+		}
+	}
+	if progressDone != nil && progressTotal != nil {
+		cmd.Progress = &models.CommandProgress{DoneBytes: *progressDone, TotalBytes: *progressTotal}
+	}
+	return &cmd, nil
+}
+
+// CreateServerCommand queues a command for a server's supervisor
+func (db *DB) CreateServerCommand(ctx context.Context, serverID string, cmdType models.CommandType, payload *string) (*models.ServerCommand, error) {
+	// Listeners are notified once the command is committed
+	query := `
+		WITH cmd AS (
+			INSERT INTO server_commands (server_id, type, payload)
+			VALUES ($1, $2, $3)
+			RETURNING ` + serverCommandColumns + `
+		)
+		SELECT ` + serverCommandColumns + `
+		FROM cmd, pg_notify('` + serverCommandsChannel + `', cmd.server_id::text)`
+
+	cmd, err := scanServerCommand(db.Pool.QueryRow(ctx, query, serverID, string(cmdType), payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create server command: %w", err)
+	}
+	return cmd, nil
+}
+
+// ListenServerCommands calls fn with the server ID of every command queued from now on, by
+// any API replica, until ctx is cancelled or the connection fails. It holds a connection of
+// its own for as long as it listens.
+func (db *DB) ListenServerCommands(ctx context.Context, fn func(serverID string)) error {
+	pool, ok := db.Pool.(*pgxpool.Pool)
+	if !ok {
+		return fmt.Errorf("failed to listen for server commands: not a connection pool")
+	}
+	pooled, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// Taken out of the pool, so the LISTEN doesn't outlive this call on a reused connection
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+serverCommandsChannel); err != nil {
+		return fmt.Errorf("failed to listen for server commands: %w", err)
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for server commands: %w", err)
+		}
+		fn(notification.Payload)
+	}
+}
+
+// GetServerCommand retrieves a server's command by ID
+func (db *DB) GetServerCommand(ctx context.Context, serverID, commandID string) (*models.ServerCommand, error) {
+	query := `SELECT ` + serverCommandColumns + ` FROM server_commands WHERE id = $1 AND server_id = $2`
+
+	cmd, err := scanServerCommand(db.Pool.QueryRow(ctx, query, commandID, serverID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server command: %w", err)
+	}
+	return cmd, nil
+}
+
+// GetServerCommandByID retrieves a command by ID alone, for signed download links
+func (db *DB) GetServerCommandByID(ctx context.Context, commandID string) (*models.ServerCommand, error) {
+	query := `SELECT ` + serverCommandColumns + ` FROM server_commands WHERE id = $1`
+
+	cmd, err := scanServerCommand(db.Pool.QueryRow(ctx, query, commandID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server command: %w", err)
+	}
+	return cmd, nil
+}
+
+// ClaimServerCommands marks a server's pending commands as delivered and returns them oldest first.
+// Commands queued longer than maxAge ago (importMaxAge for imports, which may be queued while the
+// server is still being created) are expired instead of delivered.
+func (db *DB) ClaimServerCommands(ctx context.Context, serverID string, maxAge, importMaxAge time.Duration) ([]models.ServerCommand, error) {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE server_commands
+		SET state = 'expired', completed_at = NOW()
+		WHERE server_id = $1 AND state = 'pending'
+		  AND created_at < NOW() - (CASE WHEN type = 'import' THEN $3 ELSE $2 END) * interval '1 second'
+	`, serverID, maxAge.Seconds(), importMaxAge.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire server commands: %w", err)
+	}
+
+	query := `
+		UPDATE server_commands
+		SET state = 'delivered', delivered_at = NOW()
+		WHERE id IN (
+			SELECT id FROM server_commands
+			WHERE server_id = $1 AND state = 'pending'
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + serverCommandColumns
+
+	rows, err := db.Pool.Query(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim server commands: %w", err)
+	}
+	defer rows.Close()
+
+	commands := []models.ServerCommand{}
+	for rows.Next() {
+		cmd, err := scanServerCommand(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan server command: %w", err)
+		}
+		commands = append(commands, *cmd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim server commands: %w", err)
+	}
+
+	// RETURNING doesn't preserve order; deliver in the order they were queued
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].CreatedAt.Before(commands[j].CreatedAt)
+	})
+
+	return commands, nil
+}
+
+// CompleteServerCommand records the supervisor's result for a delivered command, and the
+// file it produced, if any
+func (db *DB) CompleteServerCommand(ctx context.Context, serverID string, commandID uuid.UUID, state models.CommandState, result string, artifact *models.CommandArtifact) error {
+	var artifactPath *string
+	var artifactSize *int64
+	if artifact != nil {
+		artifactPath, artifactSize = &artifact.Path, &artifact.Size
+	}
+
+	query := `
+		UPDATE server_commands
+		SET state = $3,
+		    result = NULLIF($4, ''),
+		    artifact_path = $5,
+		    artifact_size = $6,
+		    completed_at = NOW()
+		WHERE id = $1 AND server_id = $2 AND state = 'delivered'
+	`
+	tag, err := db.Pool.Exec(ctx, query, commandID, serverID, string(state), result, artifactPath, artifactSize)
+	if err != nil {
+		return fmt.Errorf("failed to complete server command: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("command not found or not delivered")
+	}
+	return nil
+}
+
+// UpdateServerCommandProgress records how far a delivered command has got
+func (db *DB) UpdateServerCommandProgress(ctx context.Context, serverID string, commandID uuid.UUID, done, total int64) error {
+	query := `
+		UPDATE server_commands
+		SET progress_done = $3, progress_total = $4
+		WHERE id = $1 AND server_id = $2 AND state = 'delivered'
+	`
+	tag, err := db.Pool.Exec(ctx, query, commandID, serverID, done, total)
+	if err != nil {
+		return fmt.Errorf("failed to update server command progress: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("command not found or not delivered")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeletionWarning is an expired server whose owner should be warned before its data is deleted
+type DeletionWarning struct {
+	ServerID    uuid.UUID
+	DisplayName string
+	Subdomain   string
+	Email       string
+	DeleteAfter time.Time
+}
+
+// GetServersDueDeletionWarning returns expired servers that will be permanently deleted
+// within lead and whose owner hasn't been warned yet
+func (db *DB) GetServersDueDeletionWarning(ctx context.Context, lead time.Duration) ([]DeletionWarning, error) {
+	query := `
+		SELECT s.id, s.display_name, s.subdomain, u.email, s.delete_after
+		FROM servers s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.status = 'expired'
+		  AND s.deletion_warning_sent_at IS NULL
+		  AND s.delete_after > NOW()
+		  AND s.delete_after <= NOW() + $1 * interval '1 second'
+		ORDER BY s.delete_after ASC
+	`
+
+	rows, err := db.Pool.Query(ctx, query, lead.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get servers due deletion warning: %w", err)
+	}
+	defer rows.Close()
+
+	var warnings []DeletionWarning
+	for rows.Next() {
+		var w DeletionWarning
+		if err := rows.Scan(&w.ServerID, &w.DisplayName, &w.Subdomain, &w.Email, &w.DeleteAfter); err != nil {
+			return nil, fmt.Errorf("failed to scan deletion warning: %w", err)
+		}
+		warnings = append(warnings, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get servers due deletion warning: %w", err)
+	}
+
+	return warnings, nil
+}
+
+// MarkDeletionWarningSent records that the owner of an expired server was warned
+func (db *DB) MarkDeletionWarningSent(ctx context.Context, serverID string) error {
+	query := `
+		UPDATE servers
+		SET deletion_warning_sent_at = NOW()
+		WHERE id = $1 AND status = 'expired'
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to mark deletion warning sent: %w", err)
+	}
+	return nil
+}
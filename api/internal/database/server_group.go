@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const serverGroupColumns = `id, user_id, name, position, created_at, updated_at`
+
+func scanServerGroup(row pgx.Row) (*models.ServerGroup, error) {
+	var group models.ServerGroup
+	err := row.Scan(&group.ID, &group.UserID, &group.Name, &group.Position, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ListServerGroups returns a user's server groups in order
+func (db *DB) ListServerGroups(ctx context.Context, userID uuid.UUID) ([]models.ServerGroup, error) {
+	rows, err := db.Pool.Query(ctx,
+		`SELECT `+serverGroupColumns+` FROM server_groups WHERE user_id = $1 ORDER BY position, created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list server groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []models.ServerGroup{}
+	for rows.Next() {
+		group, err := scanServerGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan server group: %w", err)
+		}
+		groups = append(groups, *group)
+	}
+	return groups, rows.Err()
+}
+
+// CreateServerGroup creates a server group after the user's existing ones
+func (db *DB) CreateServerGroup(ctx context.Context, userID uuid.UUID, name string) (*models.ServerGroup, error) {
+	query := `
+		INSERT INTO server_groups (user_id, name, position)
+		SELECT $1, $2, COALESCE(MAX(position) + 1, 0) FROM server_groups WHERE user_id = $1
+		RETURNING ` + serverGroupColumns
+
+	group, err := scanServerGroup(db.Pool.QueryRow(ctx, query, userID, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create server group: %w", err)
+	}
+	return group, nil
+}
+
+// RenameServerGroup renames a user's server group. Returns (nil, nil) if it doesn't exist.
+func (db *DB) RenameServerGroup(ctx context.Context, userID, id uuid.UUID, name string) (*models.ServerGroup, error) {
+	query := `
+		UPDATE server_groups SET name = $3, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + serverGroupColumns
+
+	group, err := scanServerGroup(db.Pool.QueryRow(ctx, query, id, userID, name))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename server group: %w", err)
+	}
+	return group, nil
+}
+
+// DeleteServerGroup deletes a user's server group, ungrouping its servers. Returns false if it
+// doesn't exist.
+func (db *DB) DeleteServerGroup(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM server_groups WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete server group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	// The foreign key clears group_id; the position only means something within a group
+	if _, err := tx.Exec(ctx, `UPDATE servers SET group_position = 0 WHERE user_id = $1 AND group_id IS NULL AND group_position <> 0`, userID); err != nil {
+		return false, fmt.Errorf("failed to ungroup servers: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// ReorderServerGroups sets the positions of a user's groups to their order in ids
+func (db *DB) ReorderServerGroups(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	query := `
+		UPDATE server_groups g
+		SET position = o.ord - 1, updated_at = NOW()
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, ord)
+		WHERE g.id = o.id AND g.user_id = $1
+	`
+	if _, err := db.Pool.Exec(ctx, query, userID, ids); err != nil {
+		return fmt.Errorf("failed to reorder server groups: %w", err)
+	}
+	return nil
+}
+
+// SetGroupServers makes serverIDs, in that order, the servers of a user's group. They leave
+// any other group, and the group's servers not in serverIDs are ungrouped.
+func (db *DB) SetGroupServers(ctx context.Context, userID, groupID uuid.UUID, serverIDs []uuid.UUID) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE servers SET group_id = NULL, group_position = 0
+		WHERE group_id = $1 AND user_id = $2 AND id <> ALL($3::uuid[])
+	`, groupID, userID, serverIDs); err != nil {
+		return fmt.Errorf("failed to ungroup servers: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE servers s
+		SET group_id = $1, group_position = o.ord - 1
+		FROM unnest($3::uuid[]) WITH ORDINALITY AS o(id, ord)
+		WHERE s.id = o.id AND s.user_id = $2
+	`, groupID, userID, serverIDs); err != nil {
+		return fmt.Errorf("failed to group servers: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
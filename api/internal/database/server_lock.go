@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// serverLockClass namespaces per-server advisory locks so they can't collide with
+// other advisory locks (e.g. migrationLockID)
+const serverLockClass = 4815
+
+// WithServerLock runs fn while holding a per-server advisory lock, serializing
+// start/stop/restart operations on the same server across all API replicas.
+// The lock is transaction-scoped and released when fn returns.
+func (db *DB) WithServerLock(ctx context.Context, serverID string, fn func() error) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin server lock transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, hashtext($2))", serverLockClass, serverID); err != nil {
+		return fmt.Errorf("failed to acquire server lock: %w", err)
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const serverModColumns = `id, server_id, project_id, version_id, name, version_number, loader, path, size, installed_at`
+
+func scanServerMod(row pgx.Row) (*models.ServerMod, error) {
+	var mod models.ServerMod
+	if err := row.Scan(&mod.ID, &mod.ServerID, &mod.ProjectID, &mod.VersionID, &mod.Name,
+		&mod.VersionNumber, &mod.Loader, &mod.Path, &mod.Size, &mod.InstalledAt); err != nil {
+		return nil, err
+	}
+	return &mod, nil
+}
+
+// ListServerMods returns the mods installed on a server, by name
+func (db *DB) ListServerMods(ctx context.Context, serverID string) ([]models.ServerMod, error) {
+	query := `SELECT ` + serverModColumns + ` FROM server_mods WHERE server_id = $1 ORDER BY lower(name)`
+
+	rows, err := db.Pool.Query(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list server mods: %w", err)
+	}
+	defer rows.Close()
+
+	mods := []models.ServerMod{}
+	for rows.Next() {
+		mod, err := scanServerMod(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan server mod: %w", err)
+		}
+		mods = append(mods, *mod)
+	}
+	return mods, rows.Err()
+}
+
+// GetServerMod returns one of a server's mods. Returns (nil, nil) if it doesn't exist.
+func (db *DB) GetServerMod(ctx context.Context, serverID, id string) (*models.ServerMod, error) {
+	query := `SELECT ` + serverModColumns + ` FROM server_mods WHERE id = $1 AND server_id = $2`
+
+	mod, err := scanServerMod(db.Pool.QueryRow(ctx, query, id, serverID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server mod: %w", err)
+	}
+	return mod, nil
+}
+
+// GetServerModByProject returns the server's installed version of a Modrinth project.
+// Returns (nil, nil) if it isn't installed.
+func (db *DB) GetServerModByProject(ctx context.Context, serverID, projectID string) (*models.ServerMod, error) {
+	query := `SELECT ` + serverModColumns + ` FROM server_mods WHERE server_id = $1 AND project_id = $2`
+
+	mod, err := scanServerMod(db.Pool.QueryRow(ctx, query, serverID, projectID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server mod: %w", err)
+	}
+	return mod, nil
+}
+
+// SaveServerMod records a mod installed on a server, replacing the project's earlier version
+func (db *DB) SaveServerMod(ctx context.Context, mod *models.ServerMod) (*models.ServerMod, error) {
+	query := `
+		INSERT INTO server_mods (server_id, project_id, version_id, name, version_number, loader, path, size)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (server_id, project_id) DO UPDATE SET
+			version_id = EXCLUDED.version_id,
+			name = EXCLUDED.name,
+			version_number = EXCLUDED.version_number,
+			loader = EXCLUDED.loader,
+			path = EXCLUDED.path,
+			size = EXCLUDED.size,
+			installed_at = NOW()
+		RETURNING ` + serverModColumns
+
+	saved, err := scanServerMod(db.Pool.QueryRow(ctx, query, mod.ServerID, mod.ProjectID, mod.VersionID,
+		mod.Name, mod.VersionNumber, mod.Loader, mod.Path, mod.Size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save server mod: %w", err)
+	}
+	return saved, nil
+}
+
+// DeleteServerMod forgets a mod removed from a server
+func (db *DB) DeleteServerMod(ctx context.Context, id string) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM server_mods WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete server mod: %w", err)
+	}
+	return nil
+}
+
+// AddServerPendingMods records mods a server created from a template still has to install
+func (db *DB) AddServerPendingMods(ctx context.Context, serverID uuid.UUID, mods []models.TemplateMod) error {
+	query := `
+		INSERT INTO server_pending_mods (server_id, project_id, version_id, name)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (server_id, project_id) DO NOTHING
+	`
+	for _, mod := range mods {
+		if _, err := db.Pool.Exec(ctx, query, serverID, mod.ProjectID, mod.VersionID, mod.Name); err != nil {
+			return fmt.Errorf("failed to add server pending mod: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListServerPendingMods returns the mods a server still has to install from its template, by name
+func (db *DB) ListServerPendingMods(ctx context.Context, serverID string) ([]models.TemplateMod, error) {
+	query := `
+		SELECT project_id, version_id, name FROM server_pending_mods
+		WHERE server_id = $1
+		ORDER BY lower(name)
+	`
+
+	rows, err := db.Pool.Query(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list server pending mods: %w", err)
+	}
+	defer rows.Close()
+
+	mods := []models.TemplateMod{}
+	for rows.Next() {
+		var mod models.TemplateMod
+		if err := rows.Scan(&mod.ProjectID, &mod.VersionID, &mod.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan server pending mod: %w", err)
+		}
+		mods = append(mods, mod)
+	}
+	return mods, rows.Err()
+}
+
+// DeleteServerPendingMod forgets a template mod once it's installed or dismissed. Returns
+// false if it wasn't pending.
+func (db *DB) DeleteServerPendingMod(ctx context.Context, serverID, projectID string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM server_pending_mods WHERE server_id = $1 AND project_id = $2`, serverID, projectID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete server pending mod: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
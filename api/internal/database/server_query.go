@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// ServerQueryInfo is what public server queries are answered from
+type ServerQueryInfo struct {
+	DisplayName   string
+	Game          models.GameType
+	Status        models.ServerStatus
+	PlayersOnline *int
+	LastHeartbeat *time.Time
+}
+
+// GetServerQueryInfo returns the public query data of the server with a subdomain.
+// Returns (nil, nil) if there's no such server.
+func (db *DB) GetServerQueryInfo(ctx context.Context, subdomain string) (*ServerQueryInfo, error) {
+	query := `
+		SELECT display_name, game, status, players_online, last_heartbeat
+		FROM servers
+		WHERE subdomain = $1 AND status != 'deleted'
+	`
+
+	var info ServerQueryInfo
+	err := db.Pool.QueryRow(ctx, query, subdomain).Scan(
+		&info.DisplayName, &info.Game, &info.Status, &info.PlayersOnline, &info.LastHeartbeat)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server query info: %w", err)
+	}
+	return &info, nil
+}
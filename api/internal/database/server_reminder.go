@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// StoppedServerReminder is a server stopped long enough that its owner should be reminded it's still billed
+type StoppedServerReminder struct {
+	ServerID             uuid.UUID
+	DisplayName          string
+	Subdomain            string
+	Game                 models.GameType
+	Plan                 models.ServerPlan
+	StripeSubscriptionID string
+	Email                string
+	StoppedAt            time.Time
+	AutoCancel           bool
+}
+
+// GetServersDueStoppedReminder returns subscribed servers stopped continuously for at least
+// stoppedFor whose owner hasn't been reminded since they were stopped
+func (db *DB) GetServersDueStoppedReminder(ctx context.Context, stoppedFor time.Duration) ([]StoppedServerReminder, error) {
+	query := `
+		SELECT s.id, s.display_name, s.subdomain, s.game, s.plan, s.stripe_subscription_id,
+		       u.email, s.stopped_at, s.auto_cancel_when_stopped
+		FROM servers s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.status = 'stopped'
+		  AND s.stripe_subscription_id IS NOT NULL AND s.stripe_subscription_id <> ''
+		  AND s.stopped_at <= NOW() - $1 * interval '1 second'
+		  AND (s.stopped_reminder_sent_at IS NULL OR s.stopped_reminder_sent_at < s.stopped_at)
+		ORDER BY s.stopped_at ASC
+	`
+
+	rows, err := db.Pool.Query(ctx, query, stoppedFor.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get servers due stopped reminder: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []StoppedServerReminder
+	for rows.Next() {
+		var r StoppedServerReminder
+		err := rows.Scan(&r.ServerID, &r.DisplayName, &r.Subdomain, &r.Game, &r.Plan,
+			&r.StripeSubscriptionID, &r.Email, &r.StoppedAt, &r.AutoCancel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stopped server reminder: %w", err)
+		}
+		reminders = append(reminders, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get servers due stopped reminder: %w", err)
+	}
+
+	return reminders, nil
+}
+
+// MarkStoppedReminderSent records that the owner of a stopped server was reminded
+func (db *DB) MarkStoppedReminderSent(ctx context.Context, serverID string) error {
+	query := `UPDATE servers SET stopped_reminder_sent_at = NOW() WHERE id = $1`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to mark stopped reminder sent: %w", err)
+	}
+	return nil
+}
+
+// SetServerAutoCancel sets whether a server's subscription is cancelled automatically
+// once it has been stopped long enough to be due a reminder
+func (db *DB) SetServerAutoCancel(ctx context.Context, serverID string, enabled bool) error {
+	query := `UPDATE servers SET auto_cancel_when_stopped = $2, updated_at = NOW() WHERE id = $1`
+
+	if _, err := db.Pool.Exec(ctx, query, serverID, enabled); err != nil {
+		return fmt.Errorf("failed to set server auto-cancel: %w", err)
+	}
+	return nil
+}
+
+// GetAutoCancelServerIDs returns the IDs of a user's servers with auto-cancel enabled
+func (db *DB) GetAutoCancelServerIDs(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]bool, error) {
+	query := `SELECT id FROM servers WHERE user_id = $1 AND auto_cancel_when_stopped`
+
+	rows, err := db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auto-cancel servers: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan auto-cancel server: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
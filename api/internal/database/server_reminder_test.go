@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createStoppedServer creates a subscribed server for the user that has been stopped for the given duration
+func createStoppedServer(t *testing.T, db *DB, userID uuid.UUID, subscriptionID string, stoppedFor time.Duration) *models.Server {
+	t.Helper()
+	ctx := context.Background()
+
+	server, err := db.CreateServer(ctx, &CreateServerParams{
+		UserID:               userID,
+		DisplayName:          "Test Server",
+		Subdomain:            RandomSubdomain(),
+		Game:                 models.GameMinecraft,
+		Plan:                 models.PlanSmall,
+		StripeSubscriptionID: &subscriptionID,
+	})
+	require.NoError(t, err, "CreateServer should not return an error")
+
+	_, err = db.Pool.Exec(ctx,
+		`UPDATE servers SET status = 'stopped', stopped_at = NOW() - $2 * interval '1 second' WHERE id = $1`,
+		server.ID, stoppedFor.Seconds())
+	require.NoError(t, err, "stopping the server should not return an error")
+
+	return server
+}
+
+func Test_GetServersDueStoppedReminder(t *testing.T) {
+	db, cleanup := setupTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	week := 7 * 24 * time.Hour
+
+	email := RandomEmail()
+	user, err := db.CreateUser(ctx, email, "password_hash")
+	require.NoError(t, err, "CreateUser should not return an error")
+
+	due := createStoppedServer(t, db, user.ID, "sub_due", 8*24*time.Hour)
+	older := createStoppedServer(t, db, user.ID, "sub_older", 10*24*time.Hour)
+	createStoppedServer(t, db, user.ID, "sub_recent", 2*24*time.Hour)
+	createStoppedServer(t, db, user.ID, "", 8*24*time.Hour) // Not subscribed
+
+	running := createStoppedServer(t, db, user.ID, "sub_running", 8*24*time.Hour)
+	require.NoError(t, db.UpdateServerToRunning(ctx, running.ID.String()))
+
+	reminders, err := db.GetServersDueStoppedReminder(ctx, week)
+	require.NoError(t, err, "GetServersDueStoppedReminder should not return an error")
+	require.Len(t, reminders, 2, "only long-stopped, subscribed servers are due")
+
+	// Longest stopped first
+	assert.Equal(t, older.ID, reminders[0].ServerID)
+	assert.Equal(t, due.ID, reminders[1].ServerID)
+	assert.Equal(t, "sub_due", reminders[1].StripeSubscriptionID)
+	assert.Equal(t, email, reminders[1].Email)
+	assert.Equal(t, models.GameMinecraft, reminders[1].Game)
+	assert.Equal(t, models.PlanSmall, reminders[1].Plan)
+	assert.False(t, reminders[1].AutoCancel)
+}
+
+func Test_MarkStoppedReminderSent(t *testing.T) {
+	db, cleanup := setupTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	week := 7 * 24 * time.Hour
+
+	user, err := db.CreateUser(ctx, RandomEmail(), "password_hash")
+	require.NoError(t, err, "CreateUser should not return an error")
+
+	server := createStoppedServer(t, db, user.ID, "sub_1", 8*24*time.Hour)
+
+	err = db.MarkStoppedReminderSent(ctx, server.ID.String())
+	require.NoError(t, err, "MarkStoppedReminderSent should not return an error")
+
+	reminders, err := db.GetServersDueStoppedReminder(ctx, week)
+	require.NoError(t, err)
+	assert.Empty(t, reminders, "a reminded server isn't due again for the same stop")
+
+	// Starting and stopping again makes it due again once it's been stopped long enough
+	_, err = db.Pool.Exec(ctx,
+		`UPDATE servers SET stopped_reminder_sent_at = NOW() - interval '30 days', stopped_at = NOW() - interval '8 days' WHERE id = $1`,
+		server.ID)
+	require.NoError(t, err)
+
+	reminders, err = db.GetServersDueStoppedReminder(ctx, week)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1, "a new stop after the last reminder is due")
+	assert.Equal(t, server.ID, reminders[0].ServerID)
+}
+
+func Test_SetServerAutoCancel(t *testing.T) {
+	db, cleanup := setupTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, RandomEmail(), "password_hash")
+	require.NoError(t, err, "CreateUser should not return an error")
+	otherUser, err := db.CreateUser(ctx, RandomEmail(), "password_hash")
+	require.NoError(t, err, "CreateUser should not return an error")
+
+	enabled := createStoppedServer(t, db, user.ID, "sub_1", 8*24*time.Hour)
+	disabled := createStoppedServer(t, db, user.ID, "sub_2", 8*24*time.Hour)
+	other := createStoppedServer(t, db, otherUser.ID, "sub_3", 8*24*time.Hour)
+
+	require.NoError(t, db.SetServerAutoCancel(ctx, enabled.ID.String(), true))
+	require.NoError(t, db.SetServerAutoCancel(ctx, other.ID.String(), true))
+
+	ids, err := db.GetAutoCancelServerIDs(ctx, user.ID)
+	require.NoError(t, err, "GetAutoCancelServerIDs should not return an error")
+	assert.Equal(t, map[uuid.UUID]bool{enabled.ID: true}, ids, "only the user's own enabled servers")
+	assert.False(t, ids[disabled.ID])
+
+	reminders, err := db.GetServersDueStoppedReminder(ctx, 7*24*time.Hour)
+	require.NoError(t, err)
+	autoCancel := make(map[uuid.UUID]bool)
+	for _, r := range reminders {
+		autoCancel[r.ServerID] = r.AutoCancel
+	}
+	assert.True(t, autoCancel[enabled.ID])
+	assert.False(t, autoCancel[disabled.ID])
+
+	// Turning it off again
+	require.NoError(t, db.SetServerAutoCancel(ctx, enabled.ID.String(), false))
+	ids, err = db.GetAutoCancelServerIDs(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// sessionEnd is when a session ended: its end, or for the current session its last
+// heartbeat if they stopped more than $gap seconds ago, otherwise now
+func sessionEnd(gapParam string) string {
+	return `COALESCE(ended_at, CASE WHEN last_seen_at < NOW() - make_interval(secs => ` + gapParam + `) THEN last_seen_at ELSE NOW() END)`
+}
+
+// StartServerSession opens a session for a server that started running, unless one is
+// already open
+func (db *DB) StartServerSession(ctx context.Context, serverID uuid.UUID) error {
+	query := `
+		INSERT INTO server_sessions (server_id)
+		VALUES ($1)
+		ON CONFLICT (server_id) WHERE ended_at IS NULL DO NOTHING
+	`
+	if _, err := db.Pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to start server session: %w", err)
+	}
+	return nil
+}
+
+// TouchServerSession records a heartbeat of a running server in its current session. A
+// session whose last heartbeat is more than gap ago ended then, since the server was gone
+// in between, and a new one starts, as it does for a running server without one.
+func (db *DB) TouchServerSession(ctx context.Context, serverID string, gap time.Duration) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE server_sessions
+		SET ended_at = last_seen_at
+		WHERE server_id = $1 AND ended_at IS NULL AND last_seen_at < NOW() - make_interval(secs => $2)
+	`
+	if _, err := tx.Exec(ctx, query, serverID, gap.Seconds()); err != nil {
+		return fmt.Errorf("failed to end stale server session: %w", err)
+	}
+
+	query = `
+		WITH touched AS (
+			UPDATE server_sessions SET last_seen_at = NOW()
+			WHERE server_id = $1 AND ended_at IS NULL
+			RETURNING id
+		)
+		INSERT INTO server_sessions (server_id)
+		SELECT id FROM servers
+		WHERE id = $1 AND status = 'running' AND NOT EXISTS (SELECT 1 FROM touched)
+		ON CONFLICT (server_id) WHERE ended_at IS NULL DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to touch server session: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// EndServerSession closes a server's current session as it leaves running for status. If
+// its heartbeats stopped more than gap ago, it ended at the last one.
+func (db *DB) EndServerSession(ctx context.Context, serverID uuid.UUID, status models.ServerStatus, gap time.Duration) error {
+	query := `
+		UPDATE server_sessions
+		SET ended_at = ` + sessionEnd("$3") + `,
+		    end_status = $2
+		WHERE server_id = $1 AND ended_at IS NULL
+	`
+	if _, err := db.Pool.Exec(ctx, query, serverID, string(status), gap.Seconds()); err != nil {
+		return fmt.Errorf("failed to end server session: %w", err)
+	}
+	return nil
+}
+
+// ListServerSessions returns a server's sessions overlapping the time since since, newest
+// first, at most limit
+func (db *DB) ListServerSessions(ctx context.Context, serverID string, since time.Time, gap time.Duration, limit int) ([]models.ServerSession, error) {
+	query := `
+		SELECT id, started_at, last_seen_at,
+		       CASE WHEN ended_at IS NULL AND last_seen_at < NOW() - make_interval(secs => $3) THEN last_seen_at ELSE ended_at END,
+		       end_status,
+		       EXTRACT(EPOCH FROM ` + sessionEnd("$3") + ` - started_at)::BIGINT
+		FROM server_sessions
+		WHERE server_id = $1 AND ` + sessionEnd("$3") + ` > $2
+		ORDER BY started_at DESC
+		LIMIT $4
+	`
+	rows, err := db.Pool.Query(ctx, query, serverID, since, gap.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list server sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.ServerSession{}
+	for rows.Next() {
+		var session models.ServerSession
+		if err := rows.Scan(&session.ID, &session.StartedAt, &session.LastSeenAt, &session.EndedAt,
+			&session.EndStatus, &session.DurationSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan server session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// GetServerSessionSummary totals a server's online time since since, counting only the part
+// of each session after it. Billable hours round each session's part up to whole hours.
+func (db *DB) GetServerSessionSummary(ctx context.Context, serverID string, since time.Time, gap time.Duration) (*models.ServerSessionSummary, error) {
+	query := `
+		WITH online AS (
+			SELECT GREATEST(EXTRACT(EPOCH FROM ` + sessionEnd("$3") + ` - GREATEST(started_at, $2)), 0) AS seconds
+			FROM server_sessions
+			WHERE server_id = $1 AND ` + sessionEnd("$3") + ` > $2
+		)
+		SELECT COALESCE(SUM(seconds), 0)::BIGINT,
+		       COALESCE(SUM(CEIL(seconds / 3600)), 0)::BIGINT,
+		       (SELECT MAX(last_seen_at) FROM server_sessions WHERE server_id = $1)
+		FROM online
+	`
+	summary := models.ServerSessionSummary{Since: since}
+	err := db.Pool.QueryRow(ctx, query, serverID, since, gap.Seconds()).
+		Scan(&summary.OnlineSeconds, &summary.BillableHours, &summary.LastSeenAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server session summary: %w", err)
+	}
+	return &summary, nil
+}
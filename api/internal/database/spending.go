@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// SpendProjectionTarget is a user whose projected spend needs refreshing
+type SpendProjectionTarget struct {
+	UserID          uuid.UUID
+	Email           string
+	LimitCents      *int64
+	AlertSentAt     *time.Time
+	SubscriptionIDs []string
+}
+
+// GetSpendLimit returns a user's spending cap and cached projected spend
+func (db *DB) GetSpendLimit(ctx context.Context, userID uuid.UUID) (*models.SpendLimit, error) {
+	query := `
+		SELECT spend_limit_cents, spend_limit_block_checkout, projected_spend_cents,
+		       COALESCE(projected_spend_currency, ''), projected_spend_updated_at
+		FROM users
+		WHERE id = $1
+	`
+
+	var limit models.SpendLimit
+	err := db.Pool.QueryRow(ctx, query, userID).Scan(
+		&limit.LimitCents,
+		&limit.BlockCheckout,
+		&limit.ProjectedCents,
+		&limit.Currency,
+		&limit.ProjectedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spend limit: %w", err)
+	}
+
+	return &limit, nil
+}
+
+// UpdateSpendLimit sets a user's spending cap. The alert is re-armed so a new limit
+// that is already exceeded is reported on the next projection.
+func (db *DB) UpdateSpendLimit(ctx context.Context, userID uuid.UUID, limitCents *int64, blockCheckout bool) error {
+	query := `
+		UPDATE users
+		SET spend_limit_cents = $2,
+		    spend_limit_block_checkout = $3,
+		    spend_alert_sent_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, userID, limitCents, blockCheckout); err != nil {
+		return fmt.Errorf("failed to update spend limit: %w", err)
+	}
+	return nil
+}
+
+// GetSpendProjectionTargets returns users with active subscriptions, plus users with a
+// previous projection so it's cleared once their last subscription ends
+func (db *DB) GetSpendProjectionTargets(ctx context.Context) ([]SpendProjectionTarget, error) {
+	query := `
+		SELECT u.id, u.email, u.spend_limit_cents, u.spend_alert_sent_at,
+		       COALESCE(array_agg(s.stripe_subscription_id) FILTER (WHERE s.id IS NOT NULL), '{}')
+		FROM users u
+		LEFT JOIN servers s ON s.user_id = u.id
+		  AND s.stripe_subscription_id IS NOT NULL AND s.stripe_subscription_id <> ''
+		  AND s.status NOT IN ('expired', 'deleting', 'deleted')
+		WHERE s.id IS NOT NULL OR u.projected_spend_cents IS NOT NULL
+		GROUP BY u.id
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spend projection targets: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []SpendProjectionTarget
+	for rows.Next() {
+		var t SpendProjectionTarget
+		if err := rows.Scan(&t.UserID, &t.Email, &t.LimitCents, &t.AlertSentAt, &t.SubscriptionIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan spend projection target: %w", err)
+		}
+		targets = append(targets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get spend projection targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+// UpdateProjectedSpend caches a user's projected charges for the coming month
+func (db *DB) UpdateProjectedSpend(ctx context.Context, userID uuid.UUID, cents int64, currency string) error {
+	query := `
+		UPDATE users
+		SET projected_spend_cents = $2,
+		    projected_spend_currency = NULLIF($3, ''),
+		    projected_spend_updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, userID, cents, currency); err != nil {
+		return fmt.Errorf("failed to update projected spend: %w", err)
+	}
+	return nil
+}
+
+// MarkSpendAlertSent records that a user was alerted about exceeding their spending cap
+func (db *DB) MarkSpendAlertSent(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE users SET spend_alert_sent_at = NOW() WHERE id = $1`
+
+	if _, err := db.Pool.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to mark spend alert sent: %w", err)
+	}
+	return nil
+}
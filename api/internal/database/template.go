@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const templateColumns = `id, user_id, name, description, game, plan, env_overrides, mods, schedules, is_public,
+	created_at, updated_at`
+
+func scanTemplate(row pgx.Row) (*models.ServerTemplate, error) {
+	var template models.ServerTemplate
+	var envOverridesJSON, modsJSON, schedulesJSON []byte
+	err := row.Scan(&template.ID, &template.UserID, &template.Name, &template.Description, &template.Game,
+		&template.Plan, &envOverridesJSON, &modsJSON, &schedulesJSON, &template.IsPublic, &template.CreatedAt,
+		&template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if envOverridesJSON != nil {
+		if err := json.Unmarshal(envOverridesJSON, &template.EnvOverrides); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal env_overrides: %w", err)
+		}
+	}
+	if modsJSON != nil {
+		if err := json.Unmarshal(modsJSON, &template.Mods); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal mods: %w", err)
+		}
+	}
+	if schedulesJSON != nil {
+		if err := json.Unmarshal(schedulesJSON, &template.Schedules); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal schedules: %w", err)
+		}
+	}
+	if template.Mods == nil {
+		template.Mods = []models.TemplateMod{}
+	}
+	if template.Schedules == nil {
+		template.Schedules = []models.TemplateSchedule{}
+	}
+	return &template, nil
+}
+
+func scanTemplates(rows pgx.Rows) ([]models.ServerTemplate, error) {
+	defer rows.Close()
+
+	templates := []models.ServerTemplate{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, *template)
+	}
+	return templates, rows.Err()
+}
+
+// CreateTemplate saves a server configuration as a template
+func (db *DB) CreateTemplate(ctx context.Context, template *models.ServerTemplate) (*models.ServerTemplate, error) {
+	envJSON, err := json.Marshal(template.EnvOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal env overrides: %w", err)
+	}
+	modsJSON, err := json.Marshal(template.Mods)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal mods: %w", err)
+	}
+	schedulesJSON, err := json.Marshal(template.Schedules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schedules: %w", err)
+	}
+
+	query := `
+		INSERT INTO server_templates (user_id, name, description, game, plan, env_overrides, mods, schedules, is_public)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + templateColumns
+
+	created, err := scanTemplate(db.Pool.QueryRow(ctx, query, template.UserID, template.Name, template.Description,
+		template.Game, template.Plan, envJSON, modsJSON, schedulesJSON, template.IsPublic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	return created, nil
+}
+
+// GetTemplate retrieves a template by ID. Returns (nil, nil) if it doesn't exist.
+func (db *DB) GetTemplate(ctx context.Context, id uuid.UUID) (*models.ServerTemplate, error) {
+	template, err := scanTemplate(db.Pool.QueryRow(ctx, `SELECT `+templateColumns+` FROM server_templates WHERE id = $1`, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	return template, nil
+}
+
+// ListTemplatesByUser returns a user's templates, newest first
+func (db *DB) ListTemplatesByUser(ctx context.Context, userID uuid.UUID) ([]models.ServerTemplate, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+templateColumns+` FROM server_templates WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return scanTemplates(rows)
+}
+
+// ListPublicTemplates returns shared templates, newest first
+func (db *DB) ListPublicTemplates(ctx context.Context, limit int) ([]models.ServerTemplate, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+templateColumns+` FROM server_templates WHERE is_public ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list public templates: %w", err)
+	}
+	return scanTemplates(rows)
+}
+
+// UpdateTemplate updates a template's name, description and sharing
+func (db *DB) UpdateTemplate(ctx context.Context, template *models.ServerTemplate) (*models.ServerTemplate, error) {
+	query := `
+		UPDATE server_templates
+		SET name = $2, description = $3, is_public = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + templateColumns
+
+	updated, err := scanTemplate(db.Pool.QueryRow(ctx, query, template.ID, template.Name, template.Description, template.IsPublic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteTemplate deletes a user's template. Returns false if it doesn't exist.
+func (db *DB) DeleteTemplate(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM server_templates WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete template: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
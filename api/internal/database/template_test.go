@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateTemplate_ModsAndSchedules(t *testing.T) {
+	db, cleanup := setupTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, RandomEmail(), "password_hash")
+	require.NoError(t, err, "CreateUser should not return an error")
+
+	payload := "say hello"
+	mods := []models.TemplateMod{{ProjectID: "P7dR8mSH", VersionID: "v1", Name: "Fabric API"}}
+	schedules := []models.TemplateSchedule{
+		{Name: "Nightly restart", Cron: "0 4 * * *", Timezone: "Europe/Berlin", Action: models.ScheduleRestart, Enabled: true},
+		{Name: "Greeting", Cron: "0 * * * *", Timezone: "UTC", Action: models.ScheduleCommand, Payload: &payload},
+	}
+
+	created, err := db.CreateTemplate(ctx, &models.ServerTemplate{
+		UserID:    user.ID,
+		Name:      "Modded",
+		Game:      models.GameMinecraft,
+		Plan:      models.PlanSmall,
+		Mods:      mods,
+		Schedules: schedules,
+	})
+	require.NoError(t, err, "CreateTemplate should not return an error")
+	assert.Equal(t, mods, created.Mods)
+	assert.Equal(t, schedules, created.Schedules)
+
+	got, err := db.GetTemplate(ctx, created.ID)
+	require.NoError(t, err, "GetTemplate should not return an error")
+	assert.Equal(t, mods, got.Mods)
+	assert.Equal(t, schedules, got.Schedules)
+
+	// Templates without mods or schedules list them as empty
+	plain, err := db.CreateTemplate(ctx, &models.ServerTemplate{
+		UserID: user.ID,
+		Name:   "Plain",
+		Game:   models.GameMinecraft,
+		Plan:   models.PlanSmall,
+	})
+	require.NoError(t, err, "CreateTemplate should not return an error")
+	assert.NotNil(t, plain.Mods)
+	assert.Empty(t, plain.Mods)
+	assert.NotNil(t, plain.Schedules)
+	assert.Empty(t, plain.Schedules)
+}
+
+func Test_SetPendingServerRequestTemplate(t *testing.T) {
+	db, cleanup := setupTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, RandomEmail(), "password_hash")
+	require.NoError(t, err, "CreateUser should not return an error")
+
+	name := "From template"
+	id, err := db.CreatePendingServerRequest(ctx, user.ID, &name, RandomSubdomain(), string(models.GameMinecraft), string(models.PlanSmall))
+	require.NoError(t, err, "CreatePendingServerRequest should not return an error")
+
+	mods := []models.TemplateMod{{ProjectID: "P7dR8mSH", VersionID: "v1", Name: "Fabric API"}}
+	schedules := []models.TemplateSchedule{{Name: "Backup", Cron: "0 3 * * *", Timezone: "UTC", Action: models.ScheduleBackup, Enabled: true}}
+
+	err = db.SetPendingServerRequestTemplate(ctx, *id, mods, schedules)
+	require.NoError(t, err, "SetPendingServerRequestTemplate should not return an error")
+
+	req, err := db.GetPendingServerRequest(ctx, *id)
+	require.NoError(t, err, "GetPendingServerRequest should not return an error")
+	assert.Equal(t, mods, req.TemplateMods)
+	assert.Equal(t, schedules, req.TemplateSchedules)
+}
+
+func Test_ServerPendingMods(t *testing.T) {
+	db, cleanup := setupTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, RandomEmail(), "password_hash")
+	require.NoError(t, err, "CreateUser should not return an error")
+
+	server, err := db.CreateServer(ctx, &CreateServerParams{
+		UserID:      user.ID,
+		DisplayName: "Test Server",
+		Subdomain:   RandomSubdomain(),
+		Game:        models.GameMinecraft,
+		Plan:        models.PlanSmall,
+	})
+	require.NoError(t, err, "CreateServer should not return an error")
+	serverID := server.ID.String()
+
+	mods := []models.TemplateMod{
+		{ProjectID: "AANobbMI", VersionID: "v2", Name: "sodium"},
+		{ProjectID: "P7dR8mSH", VersionID: "v1", Name: "Fabric API"},
+	}
+	require.NoError(t, db.AddServerPendingMods(ctx, server.ID, mods))
+	// Adding the same projects again is a no-op
+	require.NoError(t, db.AddServerPendingMods(ctx, server.ID, mods[:1]))
+
+	pending, err := db.ListServerPendingMods(ctx, serverID)
+	require.NoError(t, err, "ListServerPendingMods should not return an error")
+	require.Len(t, pending, 2)
+	assert.Equal(t, "Fabric API", pending[0].Name, "sorted by name, ignoring case")
+	assert.Equal(t, "sodium", pending[1].Name)
+
+	deleted, err := db.DeleteServerPendingMod(ctx, serverID, "P7dR8mSH")
+	require.NoError(t, err, "DeleteServerPendingMod should not return an error")
+	assert.True(t, deleted)
+
+	deleted, err = db.DeleteServerPendingMod(ctx, serverID, "P7dR8mSH")
+	require.NoError(t, err)
+	assert.False(t, deleted, "already gone")
+
+	pending, err = db.ListServerPendingMods(ctx, serverID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.TemplateMod{mods[0]}, pending)
+}
@@ -14,7 +14,7 @@ func (db *DB) CreateUser(ctx context.Context, email, passwordHash string) (*mode
 	query := `
 		INSERT INTO users (email, password_hash)
 		VALUES ($1, $2)
-		RETURNING id, email, password_hash, email_verified, stripe_customer_id, created_at, updated_at
+		RETURNING id, email, password_hash, email_verified, stripe_customer_id, role, suspended_at, suspension_reason, created_at, updated_at
 	`
 
 	var user models.User
@@ -24,6 +24,9 @@ func (db *DB) CreateUser(ctx context.Context, email, passwordHash string) (*mode
 		&user.PasswordHash,
 		&user.EmailVerified,
 		&user.StripeCustomerID,
+		&user.Role,
+		&user.SuspendedAt,
+		&user.SuspensionReason,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -38,7 +41,7 @@ func (db *DB) CreateUser(ctx context.Context, email, passwordHash string) (*mode
 // GetUserByEmail retrieves a user by email address
 func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, email_verified, stripe_customer_id, created_at, updated_at
+		SELECT id, email, password_hash, email_verified, stripe_customer_id, role, suspended_at, suspension_reason, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -50,6 +53,9 @@ func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, e
 		&user.PasswordHash,
 		&user.EmailVerified,
 		&user.StripeCustomerID,
+		&user.Role,
+		&user.SuspendedAt,
+		&user.SuspensionReason,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -64,7 +70,7 @@ func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, e
 // GetUserByID retrieves a user by ID
 func (db *DB) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, email_verified, stripe_customer_id, created_at, updated_at
+		SELECT id, email, password_hash, email_verified, stripe_customer_id, role, suspended_at, suspension_reason, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -76,6 +82,9 @@ func (db *DB) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User,
 		&user.PasswordHash,
 		&user.EmailVerified,
 		&user.StripeCustomerID,
+		&user.Role,
+		&user.SuspendedAt,
+		&user.SuspensionReason,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -104,6 +113,94 @@ func (db *DB) MarkEmailVerified(ctx context.Context, userID uuid.UUID) error {
 	return nil
 }
 
+// SetUserStripeCustomerID records the Stripe customer a user is billed through and returns
+// the one actually stored. The first customer wins, so concurrent checkouts converge on it.
+func (db *DB) SetUserStripeCustomerID(ctx context.Context, userID uuid.UUID, customerID string) (string, error) {
+	query := `
+		UPDATE users
+		SET stripe_customer_id = COALESCE(stripe_customer_id, $2),
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING stripe_customer_id
+	`
+
+	var stored string
+	if err := db.Pool.QueryRow(ctx, query, userID, customerID).Scan(&stored); err != nil {
+		return "", fmt.Errorf("failed to set stripe customer ID: %w", err)
+	}
+
+	return stored, nil
+}
+
+// ClearUserStripeCustomerID forgets a user's Stripe customer if it is still customerID,
+// e.g. after the customer was deleted in Stripe
+func (db *DB) ClearUserStripeCustomerID(ctx context.Context, userID uuid.UUID, customerID string) error {
+	query := `
+		UPDATE users
+		SET stripe_customer_id = NULL,
+		    updated_at = NOW()
+		WHERE id = $1 AND stripe_customer_id = $2
+	`
+
+	_, err := db.Pool.Exec(ctx, query, userID, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to clear stripe customer ID: %w", err)
+	}
+
+	return nil
+}
+
+// ListUsersWithoutStripeCustomer returns users with no Stripe customer recorded
+func (db *DB) ListUsersWithoutStripeCustomer(ctx context.Context) ([]models.User, error) {
+	query := `
+		SELECT id, email, password_hash, email_verified, stripe_customer_id, role, suspended_at, suspension_reason, created_at, updated_at
+		FROM users
+		WHERE stripe_customer_id IS NULL
+		ORDER BY created_at ASC
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users without stripe customer: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.PasswordHash,
+			&user.EmailVerified,
+			&user.StripeCustomerID,
+			&user.Role,
+			&user.SuspendedAt,
+			&user.SuspensionReason,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list users without stripe customer: %w", err)
+	}
+
+	return users, nil
+}
+
+// SetUserRole changes a user's role. Returns false if the user doesn't exist.
+func (db *DB) SetUserRole(ctx context.Context, userID uuid.UUID, role models.UserRole) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1`, userID, role)
+	if err != nil {
+		return false, fmt.Errorf("failed to set user role: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 // UpdateUserPassword updates a user's password hash
 func (db *DB) UpdateUserPassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
 	query := `
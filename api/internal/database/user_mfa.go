@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// GetUserMFA returns a user's second factor, enabled or still being enrolled. Returns
+// (nil, nil) if the user has none.
+func (db *DB) GetUserMFA(ctx context.Context, userID uuid.UUID) (*models.UserMFA, error) {
+	query := `
+		SELECT user_id, secret, backup_code_hashes, last_used_step, enabled_at, created_at
+		FROM user_mfa
+		WHERE user_id = $1
+	`
+	var mfa models.UserMFA
+	err := db.Pool.QueryRow(ctx, query, userID).Scan(
+		&mfa.UserID, &mfa.Secret, &mfa.BackupCodeHashes, &mfa.LastUsedStep, &mfa.EnabledAt, &mfa.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user mfa: %w", err)
+	}
+	return &mfa, nil
+}
+
+// StartUserMFAEnrollment stores a new secret for the user to confirm with a code, replacing
+// an unconfirmed one. Returns false if the user already has a second factor enabled.
+func (db *DB) StartUserMFAEnrollment(ctx context.Context, userID uuid.UUID, secret string) (bool, error) {
+	query := `
+		INSERT INTO user_mfa (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret,
+		    backup_code_hashes = '{}',
+		    last_used_step = 0,
+		    created_at = NOW()
+		WHERE user_mfa.enabled_at IS NULL
+	`
+	tag, err := db.Pool.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return false, fmt.Errorf("failed to start mfa enrollment: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// EnableUserMFA confirms an enrollment with the time step of the code the user entered and
+// stores their backup codes. Returns false if there's no enrollment to confirm or the step
+// was already used.
+func (db *DB) EnableUserMFA(ctx context.Context, userID uuid.UUID, step int64, backupCodeHashes []string) (bool, error) {
+	query := `
+		UPDATE user_mfa
+		SET enabled_at = NOW(), last_used_step = $2, backup_code_hashes = $3
+		WHERE user_id = $1 AND enabled_at IS NULL AND last_used_step < $2
+	`
+	tag, err := db.Pool.Exec(ctx, query, userID, step, backupCodeHashes)
+	if err != nil {
+		return false, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseUserMFAStep records that a code of the given time step was used. Returns false if a
+// code of that step or a later one was used before, so a code can't be replayed.
+func (db *DB) UseUserMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query := `UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+	tag, err := db.Pool.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to use mfa code: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseUserMFABackupCode removes a backup code from an enabled second factor. Returns false if
+// the user has no such code.
+func (db *DB) UseUserMFABackupCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE user_mfa
+		SET backup_code_hashes = array_remove(backup_code_hashes, $2)
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND $2 = ANY(backup_code_hashes)
+	`
+	tag, err := db.Pool.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use mfa backup code: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReplaceUserMFABackupCodes replaces all of an enabled second factor's backup codes
+func (db *DB) ReplaceUserMFABackupCodes(ctx context.Context, userID uuid.UUID, backupCodeHashes []string) error {
+	query := `UPDATE user_mfa SET backup_code_hashes = $2 WHERE user_id = $1 AND enabled_at IS NOT NULL`
+	if _, err := db.Pool.Exec(ctx, query, userID, backupCodeHashes); err != nil {
+		return fmt.Errorf("failed to replace mfa backup codes: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserMFA removes a user's second factor
+func (db *DB) DeleteUserMFA(ctx context.Context, userID uuid.UUID) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete user mfa: %w", err)
+	}
+	return nil
+}
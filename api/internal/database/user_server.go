@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserServer is a user's favorite flag and last access of a server
+type UserServer struct {
+	Favorite       bool
+	LastAccessedAt *time.Time
+}
+
+// GetUserServers returns the favorite flags and last accesses of a user's servers, by server
+// ID. Servers the user never opened or favorited are missing.
+func (db *DB) GetUserServers(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]UserServer, error) {
+	rows, err := db.Pool.Query(ctx,
+		`SELECT server_id, favorite, last_accessed_at FROM user_servers WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user servers: %w", err)
+	}
+	defer rows.Close()
+
+	userServers := make(map[uuid.UUID]UserServer)
+	for rows.Next() {
+		var serverID uuid.UUID
+		var us UserServer
+		if err := rows.Scan(&serverID, &us.Favorite, &us.LastAccessedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user server: %w", err)
+		}
+		userServers[serverID] = us
+	}
+	return userServers, rows.Err()
+}
+
+// RecordServerAccess notes that a user opened a server now, and returns the user's favorite
+// flag and last access of it
+func (db *DB) RecordServerAccess(ctx context.Context, userID, serverID uuid.UUID) (*UserServer, error) {
+	query := `
+		INSERT INTO user_servers (user_id, server_id, last_accessed_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id, server_id) DO UPDATE SET last_accessed_at = NOW()
+		RETURNING favorite, last_accessed_at
+	`
+
+	var us UserServer
+	if err := db.Pool.QueryRow(ctx, query, userID, serverID).Scan(&us.Favorite, &us.LastAccessedAt); err != nil {
+		return nil, fmt.Errorf("failed to record server access: %w", err)
+	}
+	return &us, nil
+}
+
+// SetServerFavorite marks or unmarks a server as one of a user's favorites
+func (db *DB) SetServerFavorite(ctx context.Context, userID, serverID uuid.UUID, favorite bool) error {
+	query := `
+		INSERT INTO user_servers (user_id, server_id, favorite)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, server_id) DO UPDATE SET favorite = EXCLUDED.favorite
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, userID, serverID, favorite); err != nil {
+		return fmt.Errorf("failed to set server favorite: %w", err)
+	}
+	return nil
+}
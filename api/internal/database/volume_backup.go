@@ -0,0 +1,235 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const volumeBackupColumns = `id, server_id, object_key, size, state, error, restore_requested_at, created_at, completed_at`
+
+func scanVolumeBackup(row pgx.Row) (*models.VolumeBackup, error) {
+	var backup models.VolumeBackup
+	if err := row.Scan(&backup.ID, &backup.ServerID, &backup.ObjectKey, &backup.Size, &backup.State,
+		&backup.Error, &backup.RestoreRequestedAt, &backup.CreatedAt, &backup.CompletedAt); err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+func (db *DB) queryVolumeBackups(ctx context.Context, query string, args ...any) ([]models.VolumeBackup, error) {
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	backups := []models.VolumeBackup{}
+	for rows.Next() {
+		backup, err := scanVolumeBackup(rows)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, *backup)
+	}
+	return backups, rows.Err()
+}
+
+// CreateVolumeBackup queues a backup of a server's data volume
+func (db *DB) CreateVolumeBackup(ctx context.Context, id uuid.UUID, serverID, objectKey string) (*models.VolumeBackup, error) {
+	query := `
+		INSERT INTO server_backups (id, server_id, object_key)
+		VALUES ($1, $2, $3)
+		RETURNING ` + volumeBackupColumns
+
+	backup, err := scanVolumeBackup(db.Pool.QueryRow(ctx, query, id, serverID, objectKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create volume backup: %w", err)
+	}
+	return backup, nil
+}
+
+// ListVolumeBackups returns a server's volume backups, newest first. Backups being
+// removed are left out.
+func (db *DB) ListVolumeBackups(ctx context.Context, serverID string) ([]models.VolumeBackup, error) {
+	query := `SELECT ` + volumeBackupColumns + ` FROM server_backups
+		WHERE server_id = $1 AND state != 'deleting'
+		ORDER BY created_at DESC`
+
+	backups, err := db.queryVolumeBackups(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volume backups: %w", err)
+	}
+	return backups, nil
+}
+
+// GetVolumeBackup returns one of a server's volume backups. Returns (nil, nil) if it
+// doesn't exist or is being removed.
+func (db *DB) GetVolumeBackup(ctx context.Context, serverID, id string) (*models.VolumeBackup, error) {
+	query := `SELECT ` + volumeBackupColumns + ` FROM server_backups
+		WHERE id = $1 AND server_id = $2 AND state != 'deleting'`
+
+	backup, err := scanVolumeBackup(db.Pool.QueryRow(ctx, query, id, serverID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume backup: %w", err)
+	}
+	return backup, nil
+}
+
+// ListVolumeBackupWork returns the volume backups waiting to be taken, running, waiting to
+// be restored or removed, oldest first
+func (db *DB) ListVolumeBackupWork(ctx context.Context) ([]models.VolumeBackup, error) {
+	query := `SELECT ` + volumeBackupColumns + ` FROM server_backups
+		WHERE state IN ('pending', 'running', 'deleting') OR restore_requested_at IS NOT NULL
+		ORDER BY created_at`
+
+	backups, err := db.queryVolumeBackups(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volume backup work: %w", err)
+	}
+	return backups, nil
+}
+
+// MarkVolumeBackupRunning records that a backup's pod was created
+func (db *DB) MarkVolumeBackupRunning(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE server_backups SET state = 'running' WHERE id = $1 AND state = 'pending'`
+	if _, err := db.Pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark volume backup running: %w", err)
+	}
+	return nil
+}
+
+// CompleteVolumeBackup records that a backup was stored in the bucket
+func (db *DB) CompleteVolumeBackup(ctx context.Context, id uuid.UUID, size int64) error {
+	query := `
+		UPDATE server_backups
+		SET state = 'completed', size = $2, error = NULL, completed_at = NOW()
+		WHERE id = $1 AND state IN ('pending', 'running')
+	`
+	if _, err := db.Pool.Exec(ctx, query, id, size); err != nil {
+		return fmt.Errorf("failed to complete volume backup: %w", err)
+	}
+	return nil
+}
+
+// FailVolumeBackup records that taking a backup failed
+func (db *DB) FailVolumeBackup(ctx context.Context, id uuid.UUID, message string) error {
+	query := `
+		UPDATE server_backups
+		SET state = 'failed', error = $2, completed_at = NOW()
+		WHERE id = $1 AND state IN ('pending', 'running')
+	`
+	if _, err := db.Pool.Exec(ctx, query, id, message); err != nil {
+		return fmt.Errorf("failed to fail volume backup: %w", err)
+	}
+	return nil
+}
+
+// MarkVolumeBackupDeleting queues a finished backup to be removed from the bucket. Returns
+// false if it's still being taken or waits to be restored.
+func (db *DB) MarkVolumeBackupDeleting(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE server_backups SET state = 'deleting'
+		WHERE id = $1 AND state IN ('completed', 'failed') AND restore_requested_at IS NULL
+	`
+	tag, err := db.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark volume backup deleting: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// MarkServerVolumeBackupsDeleting queues all of a server's volume backups to be removed,
+// e.g. when the server is deleted
+func (db *DB) MarkServerVolumeBackupsDeleting(ctx context.Context, serverID string) error {
+	query := `UPDATE server_backups SET state = 'deleting', restore_requested_at = NULL WHERE server_id = $1`
+	if _, err := db.Pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to mark volume backups deleting: %w", err)
+	}
+	return nil
+}
+
+// DeleteVolumeBackup forgets a backup once it's removed from the bucket
+func (db *DB) DeleteVolumeBackup(ctx context.Context, id uuid.UUID) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM server_backups WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete volume backup: %w", err)
+	}
+	return nil
+}
+
+// RequestVolumeRestore selects a completed backup to be restored on the server's next
+// start, replacing any other selection. Returns false if the backup isn't completed.
+func (db *DB) RequestVolumeRestore(ctx context.Context, serverID string, id uuid.UUID) (bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	clearQuery := `UPDATE server_backups SET restore_requested_at = NULL WHERE server_id = $1 AND restore_requested_at IS NOT NULL`
+	if _, err := tx.Exec(ctx, clearQuery, serverID); err != nil {
+		return false, fmt.Errorf("failed to clear volume restore: %w", err)
+	}
+
+	query := `
+		UPDATE server_backups SET restore_requested_at = NOW()
+		WHERE id = $1 AND server_id = $2 AND state = 'completed'
+	`
+	tag, err := tx.Exec(ctx, query, id, serverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to request volume restore: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// CancelVolumeRestore clears a server's pending restore. Returns false if it had none.
+func (db *DB) CancelVolumeRestore(ctx context.Context, serverID string) (bool, error) {
+	query := `UPDATE server_backups SET restore_requested_at = NULL WHERE server_id = $1 AND restore_requested_at IS NOT NULL`
+	tag, err := db.Pool.Exec(ctx, query, serverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel volume restore: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// FinishVolumeRestore records that a backup's restore finished, or was given up on
+func (db *DB) FinishVolumeRestore(ctx context.Context, id uuid.UUID) error {
+	if _, err := db.Pool.Exec(ctx, `UPDATE server_backups SET restore_requested_at = NULL WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to finish volume restore: %w", err)
+	}
+	return nil
+}
+
+// ServerVolumeBusy reports whether a server's data volume is being backed up, waits to be
+// restored or filled with a clone's source, or is being copied into a clone, so the game
+// must not start yet
+func (db *DB) ServerVolumeBusy(ctx context.Context, serverID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM server_backups
+			WHERE server_id = $1 AND (state IN ('pending', 'running') OR restore_requested_at IS NOT NULL)
+		) OR EXISTS (
+			SELECT 1 FROM server_clones
+			WHERE (server_id = $1 AND state IN ('pending', 'copying'))
+			OR (source_server_id = $1 AND state = 'copying')
+		)
+	`
+	var busy bool
+	if err := db.Pool.QueryRow(ctx, query, serverID).Scan(&busy); err != nil {
+		return false, fmt.Errorf("failed to check volume backups: %w", err)
+	}
+	return busy, nil
+}
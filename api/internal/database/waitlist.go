@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const waitlistColumns = `id, game, plan, region, created_at, offered_at, offer_expires_at`
+
+func scanWaitlistEntry(row pgx.Row) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	if err := row.Scan(&entry.ID, &entry.Game, &entry.Plan, &entry.Region, &entry.CreatedAt,
+		&entry.OfferedAt, &entry.OfferExpiresAt); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// JoinCapacityWaitlist signs a user up to be offered capacity for a game and plan in region
+// ("" = any). Signing up again while waiting keeps the original place in line.
+func (db *DB) JoinCapacityWaitlist(ctx context.Context, userID uuid.UUID, game, plan, region string) (*models.WaitlistEntry, error) {
+	query := `
+		WITH inserted AS (
+			INSERT INTO capacity_waitlist (user_id, game, plan, region)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, game, plan, region) WHERE offered_at IS NULL DO NOTHING
+			RETURNING ` + waitlistColumns + `
+		)
+		SELECT ` + waitlistColumns + ` FROM inserted
+		UNION ALL
+		SELECT ` + waitlistColumns + ` FROM capacity_waitlist
+		WHERE user_id = $1 AND game = $2 AND plan = $3 AND region = $4 AND offered_at IS NULL
+		LIMIT 1
+	`
+
+	entry, err := scanWaitlistEntry(db.Pool.QueryRow(ctx, query, userID, game, plan, region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to join capacity waitlist: %w", err)
+	}
+	return entry, nil
+}
+
+// ListCapacityWaitlist returns a user's signups that are waiting or hold an unexpired offer,
+// oldest first
+func (db *DB) ListCapacityWaitlist(ctx context.Context, userID uuid.UUID) ([]models.WaitlistEntry, error) {
+	query := `SELECT ` + waitlistColumns + ` FROM capacity_waitlist
+		WHERE user_id = $1 AND fulfilled_at IS NULL AND (offer_expires_at IS NULL OR offer_expires_at > NOW())
+		ORDER BY created_at`
+
+	rows, err := db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list capacity waitlist: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.WaitlistEntry{}
+	for rows.Next() {
+		entry, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan waitlist entry: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, rows.Err()
+}
+
+// LeaveCapacityWaitlist removes one of a user's signups. Returns false if it doesn't exist.
+func (db *DB) LeaveCapacityWaitlist(ctx context.Context, userID uuid.UUID, id string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM capacity_waitlist WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to leave capacity waitlist: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// WaitlistSignup is a user waiting for capacity
+type WaitlistSignup struct {
+	ID     uuid.UUID
+	Email  string
+	Game   string
+	Plan   string
+	Region string
+}
+
+// ListNextWaitlistSignups returns, for each game, plan and region whose queue holds no
+// unexpired offer, the signup that has waited longest
+func (db *DB) ListNextWaitlistSignups(ctx context.Context) ([]WaitlistSignup, error) {
+	query := `
+		SELECT DISTINCT ON (w.game, w.plan, w.region) w.id, u.email, w.game, w.plan, w.region
+		FROM capacity_waitlist w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.offered_at IS NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM capacity_waitlist o
+			WHERE o.game = w.game AND o.plan = w.plan AND o.region = w.region
+			  AND o.offered_at IS NOT NULL AND o.fulfilled_at IS NULL AND o.offer_expires_at > NOW()
+		  )
+		ORDER BY w.game, w.plan, w.region, w.created_at
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list waitlist signups: %w", err)
+	}
+	defer rows.Close()
+
+	var signups []WaitlistSignup
+	for rows.Next() {
+		var w WaitlistSignup
+		if err := rows.Scan(&w.ID, &w.Email, &w.Game, &w.Plan, &w.Region); err != nil {
+			return nil, fmt.Errorf("failed to scan waitlist signup: %w", err)
+		}
+		signups = append(signups, w)
+	}
+	return signups, rows.Err()
+}
+
+// OfferWaitlistSignup records that a waiting signup was offered capacity until expiresAt.
+// Returns false if it was offered already or left the waitlist.
+func (db *DB) OfferWaitlistSignup(ctx context.Context, id uuid.UUID, expiresAt time.Time) (bool, error) {
+	query := `
+		UPDATE capacity_waitlist SET offered_at = NOW(), offer_expires_at = $2
+		WHERE id = $1 AND offered_at IS NULL
+	`
+	tag, err := db.Pool.Exec(ctx, query, id, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to offer waitlist signup: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// WaitlistOfferValid reports whether a user holds an unexpired, unused offer for a game and
+// plan under the signup id
+func (db *DB) WaitlistOfferValid(ctx context.Context, userID uuid.UUID, id, game, plan string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM capacity_waitlist
+			WHERE id = $1 AND user_id = $2 AND game = $3 AND plan = $4
+			  AND offered_at IS NOT NULL AND fulfilled_at IS NULL AND offer_expires_at > NOW()
+		)
+	`
+	var valid bool
+	if err := db.Pool.QueryRow(ctx, query, id, userID, game, plan).Scan(&valid); err != nil {
+		return false, fmt.Errorf("failed to check waitlist offer: %w", err)
+	}
+	return valid, nil
+}
+
+// FulfillCapacityWaitlist takes a user's signups for a game and plan, in any region, off the
+// waitlist once they check it out, whether or not they were offered capacity
+func (db *DB) FulfillCapacityWaitlist(ctx context.Context, userID uuid.UUID, game, plan string) error {
+	query := `
+		UPDATE capacity_waitlist SET fulfilled_at = NOW(), offered_at = COALESCE(offered_at, NOW())
+		WHERE user_id = $1 AND game = $2 AND plan = $3 AND fulfilled_at IS NULL
+	`
+	if _, err := db.Pool.Exec(ctx, query, userID, game, plan); err != nil {
+		return fmt.Errorf("failed to fulfill capacity waitlist: %w", err)
+	}
+	return nil
+}
+
+// WaitlistOfferHeld reports whether another user holds an unexpired, unused offer for a game
+// and plan, so checkouts without it leave the freed capacity to them
+func (db *DB) WaitlistOfferHeld(ctx context.Context, userID uuid.UUID, game, plan string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM capacity_waitlist
+			WHERE user_id != $1 AND game = $2 AND plan = $3
+			  AND offered_at IS NOT NULL AND fulfilled_at IS NULL AND offer_expires_at > NOW()
+		)
+	`
+	var held bool
+	if err := db.Pool.QueryRow(ctx, query, userID, game, plan).Scan(&held); err != nil {
+		return false, fmt.Errorf("failed to check waitlist offers: %w", err)
+	}
+	return held, nil
+}
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+const webhookColumns = `id, server_id, url, secret, events, created_at`
+
+func scanWebhook(row pgx.Row) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var events []string
+	if err := row.Scan(&webhook.ID, &webhook.ServerID, &webhook.URL, &webhook.Secret, &events, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		webhook.Events = append(webhook.Events, models.WebhookEvent(event))
+	}
+	return &webhook, nil
+}
+
+// CreateWebhook registers a webhook for a server
+func (db *DB) CreateWebhook(ctx context.Context, serverID, url, secret string, events []string) (*models.Webhook, error) {
+	query := `
+		INSERT INTO server_webhooks (server_id, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + webhookColumns
+
+	webhook, err := scanWebhook(db.Pool.QueryRow(ctx, query, serverID, url, secret, events))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks returns a server's webhooks, oldest first
+func (db *DB) ListWebhooks(ctx context.Context, serverID string) ([]models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM server_webhooks WHERE server_id = $1 ORDER BY created_at`
+
+	rows, err := db.Pool.Query(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks, nil
+}
+
+// GetWebhook retrieves a server's webhook by ID. Returns (nil, nil) if it doesn't exist.
+func (db *DB) GetWebhook(ctx context.Context, serverID, webhookID string) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM server_webhooks WHERE id = $1 AND server_id = $2`
+
+	webhook, err := scanWebhook(db.Pool.QueryRow(ctx, query, webhookID, serverID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook removes a server's webhook and its deliveries. Returns false if it doesn't exist.
+func (db *DB) DeleteWebhook(ctx context.Context, serverID, webhookID string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM server_webhooks WHERE id = $1 AND server_id = $2`, webhookID, serverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// EnqueueWebhookDeliveries queues a payload for every webhook of a server subscribed to
+// the event. Returns the number of deliveries queued.
+func (db *DB) EnqueueWebhookDeliveries(ctx context.Context, serverID string, event models.WebhookEvent, payload []byte) (int, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $2::text, $3
+		FROM server_webhooks
+		WHERE server_id = $1 AND $2::text = ANY(events)
+	`
+	tag, err := db.Pool.Exec(ctx, query, serverID, string(event), payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// DueWebhookDelivery is a pending delivery with the webhook it goes to
+type DueWebhookDelivery struct {
+	models.WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// ClaimDueWebhookDeliveries returns up to limit pending deliveries whose next attempt is
+// due, oldest first, and pushes their next attempt back by lease so a concurrent or
+// crashed worker doesn't send them twice in the meantime
+func (db *DB) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DueWebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $2 * interval '1 second'
+		FROM server_webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE state = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, d.created_at, w.url, w.secret
+	`
+	rows, err := db.Pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []DueWebhookDelivery
+	for rows.Next() {
+		var d DueWebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Attempts, &d.CreatedAt, &d.URL, &d.Secret); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookAttempt records the outcome of a delivery attempt. A delivery that wasn't
+// delivered is retried at nextAttempt, or marked failed when nextAttempt is nil.
+func (db *DB) RecordWebhookAttempt(ctx context.Context, deliveryID uuid.UUID, delivered bool, responseStatus int, attemptErr string, nextAttempt *time.Time) error {
+	state := models.WebhookDeliveryDelivered
+	if !delivered {
+		state = models.WebhookDeliveryPending
+		if nextAttempt == nil {
+			state = models.WebhookDeliveryFailed
+		}
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET state = $2,
+		    attempts = attempts + 1,
+		    response_status = NULLIF($3, 0),
+		    last_error = NULLIF($4, ''),
+		    next_attempt_at = COALESCE($5, next_attempt_at),
+		    delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END
+		WHERE id = $1
+	`
+	if _, err := db.Pool.Exec(ctx, query, deliveryID, string(state), responseStatus, attemptErr, nextAttempt); err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns a webhook's most recent deliveries, newest first
+func (db *DB) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_id, event, payload, state, attempts, response_status, last_error,
+		       next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+	rows, err := db.Pool.Query(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.State, &d.Attempts, &d.ResponseStatus,
+			&d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+// DeleteOldWebhookDeliveries removes finished deliveries created before the cutoff
+func (db *DB) DeleteOldWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM webhook_deliveries WHERE state <> 'pending' AND created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old webhook deliveries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
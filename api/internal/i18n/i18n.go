@@ -0,0 +1,134 @@
+// Package i18n translates user-facing API strings (error messages and server
+// status messages) into the language requested via Accept-Language.
+//
+// Messages are keyed by their English source text, so existing call sites keep
+// producing English strings and translation happens only when a response is
+// rendered. Messages without a translation are returned unchanged.
+package i18n
+
+import (
+	"regexp"
+
+	"golang.org/x/text/language"
+)
+
+// Supported languages. The first entry is the fallback.
+var supported = []language.Tag{
+	language.English,
+	language.Spanish,
+	language.German,
+}
+
+var matcher = language.NewMatcher(supported)
+
+// DefaultLanguage is used when Accept-Language is missing or unsupported
+const DefaultLanguage = "en"
+
+// MatchLanguage returns the best supported base language ("en", "es", "de")
+// for an Accept-Language header value
+func MatchLanguage(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return DefaultLanguage
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLanguage
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLanguage
+	}
+
+	base, _ := supported[index].Base()
+	return base.String()
+}
+
+// T translates an English source message into lang.
+// Untranslated messages and the default language return msg unchanged.
+func T(lang, msg string) string {
+	if lang == DefaultLanguage || msg == "" {
+		return msg
+	}
+
+	if translated, ok := messages[lang][msg]; ok {
+		return translated
+	}
+
+	for _, tpl := range templates {
+		if replacement, ok := tpl.translations[lang]; ok && tpl.pattern.MatchString(msg) {
+			return tpl.pattern.ReplaceAllString(msg, replacement)
+		}
+	}
+
+	return msg
+}
+
+// TPtr translates an optional message, preserving nil
+func TPtr(lang string, msg *string) *string {
+	if msg == nil {
+		return nil
+	}
+	translated := T(lang, *msg)
+	return &translated
+}
+
+// template translates messages with dynamic parts (counts, exit codes, reasons).
+// Capture groups from pattern are available as $1, $2... in translations.
+type template struct {
+	pattern      *regexp.Regexp
+	translations map[string]string
+}
+
+var templates = []template{
+	{
+		pattern: regexp.MustCompile(`^Server crash loop detected \((\d+) restarts\)\. Check server logs for errors\.$`),
+		translations: map[string]string{
+			"es": "Se detectó un bucle de fallos del servidor ($1 reinicios). Revisa los registros del servidor.",
+			"de": "Absturzschleife des Servers erkannt ($1 Neustarts). Prüfe die Serverprotokolle.",
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^Process crashed with exit code (-?\d+)$`),
+		translations: map[string]string{
+			"es": "El proceso falló con el código de salida $1",
+			"de": "Prozess mit Exit-Code $1 abgestürzt",
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^Process exited during startup with exit code (-?\d+)$`),
+		translations: map[string]string{
+			"es": "El proceso terminó durante el arranque con el código de salida $1",
+			"de": "Prozess wurde beim Start mit Exit-Code $1 beendet",
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^server is (\w+)$`),
+		translations: map[string]string{
+			"es": "el servidor está en estado $1",
+			"de": "Server ist im Status $1",
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^must be at least (\d+) characters$`),
+		translations: map[string]string{
+			"es": "debe tener al menos $1 caracteres",
+			"de": "muss mindestens $1 Zeichen lang sein",
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^must be at most (\d+) characters$`),
+		translations: map[string]string{
+			"es": "debe tener como máximo $1 caracteres",
+			"de": "darf höchstens $1 Zeichen lang sein",
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^must be one of: (.+)$`),
+		translations: map[string]string{
+			"es": "debe ser uno de: $1",
+			"de": "muss einer der folgenden Werte sein: $1",
+		},
+	},
+}
@@ -0,0 +1,429 @@
+package i18n
+
+// messages maps language -> English source text -> translation
+var messages = map[string]map[string]string{
+	"es": {
+		// Server status messages
+		"Creating game server...":                            "Creando el servidor de juego...",
+		"Starting server...":                                 "Iniciando el servidor...",
+		"Starting game server...":                            "Iniciando el servidor de juego...",
+		"Starting game process":                              "Iniciando el proceso del juego",
+		"Game server is running":                             "El servidor de juego está en ejecución",
+		"Installing game files":                              "Instalando los archivos del juego",
+		"Generating world":                                   "Generando el mundo",
+		"Starting game":                                      "Iniciando el juego",
+		"Stopping server...":                                 "Deteniendo el servidor...",
+		"Stopping game process":                              "Deteniendo el proceso del juego",
+		"Game process stopped":                               "Proceso del juego detenido",
+		"Server stopped (fallback)":                          "Servidor detenido",
+		"Restarting server with updated configuration...":    "Reiniciando el servidor con la configuración actualizada...",
+		"Cleaning up resources...":                           "Liberando recursos...",
+		"Subscription cancelled":                             "Suscripción cancelada",
+		"Timeout waiting for pod to be ready":                "Se agotó el tiempo de espera para que el servidor esté listo",
+		"Server stopped unexpectedly (deployment not found)": "El servidor se detuvo inesperadamente",
+		"Server unresponsive (heartbeat timeout). Click Start to restart.":                                                                           "El servidor no responde. Pulsa Iniciar para reiniciarlo.",
+		"Server ran out of memory (OOM killed). Consider upgrading to a larger plan.":                                                                "El servidor se quedó sin memoria. Considera cambiar a un plan mayor.",
+		"Game process health check failed":                                                                                                           "Falló la comprobación de estado del proceso del juego",
+		"The server was moved off its node by the cluster and is restarting. Players may have been briefly disconnected.":                            "El clúster trasladó el servidor fuera de su nodo y se está reiniciando. Es posible que los jugadores se hayan desconectado brevemente.",
+		"The server's host is being reclaimed by its provider, so the server is moving to another host. Players may have been briefly disconnected.": "El proveedor está recuperando el host del servidor, así que el servidor se está trasladando a otro host. Es posible que los jugadores se hayan desconectado brevemente.",
+		"Upgrading server plan...":                          "Actualizando el plan del servidor...",
+		"Suspended: a payment for this server was disputed": "Suspendido: se disputó un pago de este servidor",
+		"Suspended: abuse detected, pending review":         "Suspendido: se detectó un abuso, pendiente de revisión",
+
+		// Error messages
+		"unauthorized":                                  "no autorizado",
+		"invalid user ID":                               "ID de usuario no válido",
+		"server not found":                              "servidor no encontrado",
+		"server ID required":                            "se requiere el ID del servidor",
+		"subdomain already taken":                       "el subdominio ya está en uso",
+		"invalid credentials":                           "credenciales no válidas",
+		"user already exists":                           "el usuario ya existe",
+		"user not found":                                "usuario no encontrado",
+		"email already verified":                        "el correo electrónico ya está verificado",
+		"invalid or expired token":                      "token no válido o caducado",
+		"missing authorization":                         "falta la autorización",
+		"invalid request body":                          "cuerpo de la solicitud no válido",
+		"request validation failed":                     "la validación de la solicitud falló",
+		"server has no active subscription":             "el servidor no tiene una suscripción activa",
+		"server is not expired":                         "el servidor no ha caducado",
+		"server cannot be started from current state":   "el servidor no se puede iniciar en su estado actual",
+		"server cannot be stopped from current state":   "el servidor no se puede detener en su estado actual",
+		"server cannot be restarted from current state": "el servidor no se puede reiniciar en su estado actual",
+		"server must be running or stopped to restart":  "el servidor debe estar en ejecución o detenido para reiniciarlo",
+		"logs not available":                            "registros no disponibles",
+		"server has not run out of memory":              "el servidor no se ha quedado sin memoria",
+		"no larger plan is available for this server":   "no hay un plan mayor disponible para este servidor",
+		"server was restarted too many times, please wait before trying again": "el servidor se reinició demasiadas veces, espera antes de volver a intentarlo",
+		"too many requests, please slow down":                                  "demasiadas solicitudes, reduce la frecuencia",
+		"server must be running to receive commands":                           "el servidor debe estar en ejecución para recibir comandos",
+		"command not found":                                                              "comando no encontrado",
+		"webhook not found":                                                              "webhook no encontrado",
+		"template not found":                                                             "plantilla no encontrada",
+		"environment revision not found":                                                 "revisión de entorno no encontrada",
+		"discord integration is not enabled":                                             "la integración con Discord no está activada",
+		"this account is already linked to another user":                                 "esta cuenta ya está vinculada a otro usuario",
+		"could not verify the Steam sign-in, please try again":                           "no se pudo verificar el inicio de sesión de Steam, inténtalo de nuevo",
+		"linked account not found":                                                       "cuenta vinculada no encontrada",
+		"token is not allowed to access this endpoint":                                   "el token no tiene permiso para acceder a este endpoint",
+		"server already has the maximum number of webhooks":                              "el servidor ya tiene el número máximo de webhooks",
+		"must be a public http or https URL":                                             "debe ser una URL http o https pública",
+		"this game does not support applying changes without a restart":                  "este juego no permite aplicar cambios sin reiniciar",
+		"Environment variables updated. Applying changes to the running server...":       "Variables de entorno actualizadas. Aplicando los cambios al servidor en ejecución...",
+		"Environment variables updated.":                                                 "Variables de entorno actualizadas.",
+		"confirmation does not match the server's subdomain":                             "la confirmación no coincide con el subdominio del servidor",
+		"this purchase would exceed your monthly spending limit":                         "esta compra superaría tu límite de gasto mensual",
+		"server is already scheduled for deletion":                                       "el servidor ya está programado para eliminarse",
+		"server is suspended pending review":                                             "el servidor está suspendido pendiente de revisión",
+		"your account is under review, please contact support":                           "tu cuenta está en revisión, contacta con soporte",
+		"admin access required":                                                          "se requiere acceso de administrador",
+		"catalog channel is not configured":                                              "el canal del catálogo no está configurado",
+		"catalog entry is invalid":                                                       "la entrada del catálogo no es válida",
+		"custom games are not available":                                                 "los juegos personalizados no están disponibles",
+		"images must come from an allowed registry":                                      "las imágenes deben provenir de un registro permitido",
+		"custom games need a game definition":                                            "los juegos personalizados necesitan una definición de juego",
+		"server is not a custom game":                                                    "el servidor no es un juego personalizado",
+		"custom game definition is invalid":                                              "la definición del juego personalizado no es válida",
+		"ports can't be added, removed or renamed after the server is created":           "los puertos no se pueden añadir, eliminar ni renombrar después de crear el servidor",
+		"failed to update custom game":                                                   "no se pudo actualizar el juego personalizado",
+		"failed to get egress usage":                                                     "no se pudo obtener el uso de tráfico saliente",
+		"failed to query server":                                                         "no se pudo consultar el servidor",
+		"failed to get recommendations":                                                  "no se pudieron obtener las recomendaciones",
+		"custom domain not found":                                                        "dominio personalizado no encontrado",
+		"domain is already added to this server":                                         "el dominio ya está añadido a este servidor",
+		"domain is already verified for another server":                                  "el dominio ya está verificado para otro servidor",
+		"server already has the maximum number of custom domains":                        "el servidor ya tiene el número máximo de dominios personalizados",
+		"failed to list custom domains":                                                  "no se pudieron listar los dominios personalizados",
+		"failed to add custom domain":                                                    "no se pudo añadir el dominio personalizado",
+		"failed to verify custom domain":                                                 "no se pudo verificar el dominio personalizado",
+		"failed to delete custom domain":                                                 "no se pudo eliminar el dominio personalizado",
+		"range must be 1h, 6h, 24h or 7d":                                                "el rango debe ser 1h, 6h, 24h o 7d",
+		"failed to get server metrics":                                                   "no se pudieron obtener las métricas del servidor",
+		"failed to get server sessions":                                                  "no se pudieron obtener las sesiones del servidor",
+		"range must be 24h, 7d, 30d or 90d":                                              "el rango debe ser 24h, 7d, 30d o 90d",
+		"too many live status connections, please try again shortly":                     "demasiadas conexiones de estado en vivo, vuelve a intentarlo en breve",
+		"too many status streams are open for this account, close another tab or window": "hay demasiadas conexiones de estado abiertas para esta cuenta, cierra otra pestaña o ventana",
+		"Status updates moved to a newer tab or window":                                  "Las actualizaciones de estado se trasladaron a una pestaña o ventana más reciente",
+		"invalid verification code":                                                      "código de verificación no válido",
+		"incorrect password":                                                             "contraseña incorrecta",
+		"failed to log in":                                                               "no se pudo iniciar sesión",
+		"failed to get two-factor authentication":                                        "no se pudo obtener la autenticación en dos pasos",
+		"two-factor authentication is already enabled":                                   "la autenticación en dos pasos ya está activada",
+		"failed to set up two-factor authentication":                                     "no se pudo configurar la autenticación en dos pasos",
+		"two-factor authentication setup was not started":                                "no se inició la configuración de la autenticación en dos pasos",
+		"failed to enable two-factor authentication":                                     "no se pudo activar la autenticación en dos pasos",
+		"failed to disable two-factor authentication":                                    "no se pudo desactivar la autenticación en dos pasos",
+		"failed to generate backup codes":                                                "no se pudieron generar los códigos de respaldo",
+		"failed to verify code":                                                          "no se pudo verificar el código",
+		"server cloning is not available":                                                "la clonación de servidores no está disponible",
+		"expired servers can't be cloned":                                                "los servidores vencidos no se pueden clonar",
+		"this server's data can't be copied to a new server":                             "los datos de este servidor no se pueden copiar a un servidor nuevo",
+		"failed to clone server":                                                         "no se pudo clonar el servidor",
+		"failed to get server clone":                                                     "no se pudo obtener la clonación del servidor",
+		"server is not a clone":                                                          "el servidor no es un clon",
+		"failed to list mods":                                                            "no se pudieron listar los mods",
+		"failed to search mods":                                                          "no se pudieron buscar mods",
+		"no version of the mod supports this server":                                     "ninguna versión del mod es compatible con este servidor",
+		"mod version has no files":                                                       "la versión del mod no tiene archivos",
+		"failed to install mod":                                                          "no se pudo instalar el mod",
+		"failed to download mod":                                                         "no se pudo descargar el mod",
+		"mod not found":                                                                  "mod no encontrado",
+		"failed to remove mod":                                                           "no se pudo eliminar el mod",
+		"mods aren't available for this server":                                          "los mods no están disponibles para este servidor",
+		"Mod installed, restart the server to load it":                                   "Mod instalado, reinicia el servidor para cargarlo",
+		"Mod removed, restart the server to unload it":                                   "Mod eliminado, reinicia el servidor para descargarlo",
+		"preferred port is not available":                                                "el puerto preferido no está disponible",
+		"plan does not include a preferred port":                                         "el plan no incluye un puerto preferido",
+		"protocol is required for custom games":                                          "el protocolo es obligatorio para juegos personalizados",
+		"game has no ports":                                                              "el juego no tiene puertos",
+		"failed to check port availability":                                              "no se pudo comprobar la disponibilidad del puerto",
+		"The server's port was reserved by the platform, so the server is moving to a new port. Players may have been briefly disconnected.": "El puerto del servidor fue reservado por la plataforma, así que el servidor se está trasladando a un puerto nuevo. Es posible que los jugadores se hayan desconectado brevemente.",
+		"schedule not found": "programación no encontrada",
+		"server already has the maximum number of schedules":                 "el servidor ya tiene el número máximo de programaciones",
+		"failed to list schedules":                                           "no se pudieron listar las programaciones",
+		"failed to create schedule":                                          "no se pudo crear la programación",
+		"failed to update schedule":                                          "no se pudo actualizar la programación",
+		"failed to delete schedule":                                          "no se pudo eliminar la programación",
+		"Schedule deleted":                                                   "Programación eliminada",
+		"cron expression never runs":                                         "la expresión cron nunca se ejecuta",
+		"tasks can run at most every 15 minutes":                             "las tareas pueden ejecutarse como máximo cada 15 minutos",
+		"failed to list waitlist":                                            "no se pudo obtener la lista de espera",
+		"failed to leave waitlist":                                           "no se pudo salir de la lista de espera",
+		"waitlist entry not found":                                           "entrada de la lista de espera no encontrada",
+		"unknown region":                                                     "región desconocida",
+		"this waitlist offer has expired or was already used":                "esta oferta de la lista de espera ha caducado o ya se usó",
+		"Left the waitlist":                                                  "Has salido de la lista de espera",
+		"server must be running to open its console":                         "el servidor debe estar en ejecución para abrir su consola",
+		"failed to open console":                                             "no se pudo abrir la consola",
+		"failed to join waitlist":                                            "no se pudo unir a la lista de espera",
+		"files of expired servers are read-only":                             "los archivos de los servidores vencidos son de solo lectura",
+		"server must be running to manage its files":                         "el servidor debe estar en ejecución para gestionar sus archivos",
+		"failed to access files":                                             "no se pudo acceder a los archivos",
+		"failed to upload file":                                              "no se pudo subir el archivo",
+		"failed to create directory":                                         "no se pudo crear el directorio",
+		"failed to delete file":                                              "no se pudo eliminar el archivo",
+		"failed to change files":                                             "no se pudieron modificar los archivos",
+		"path not found":                                                     "ruta no encontrada",
+		"restart the server to enable file management":                       "reinicia el servidor para habilitar la gestión de archivos",
+		"path can't be changed":                                              "la ruta no se puede modificar",
+		"path is a directory":                                                "la ruta es un directorio",
+		"not enough free space on the server for the file":                   "no hay suficiente espacio libre en el servidor para el archivo",
+		"path can't be written":                                              "no se puede escribir en la ruta",
+		"failed to check account status":                                     "no se pudo comprobar el estado de la cuenta",
+		"server is being deleted":                                            "el servidor se está eliminando",
+		"failed to get email preferences":                                    "no se pudieron obtener las preferencias de correo",
+		"failed to update email preferences":                                 "no se pudieron actualizar las preferencias de correo",
+		"failed to list volume backups":                                      "no se pudieron listar las copias de seguridad del volumen",
+		"failed to create volume backup":                                     "no se pudo crear la copia de seguridad del volumen",
+		"failed to get volume backup":                                        "no se pudo obtener la copia de seguridad del volumen",
+		"failed to delete volume backup":                                     "no se pudo eliminar la copia de seguridad del volumen",
+		"failed to restore volume backup":                                    "no se pudo restaurar la copia de seguridad del volumen",
+		"failed to cancel volume restore":                                    "no se pudo cancelar la restauración del volumen",
+		"volume backup not found":                                            "copia de seguridad del volumen no encontrada",
+		"volume backups are not available":                                   "las copias de seguridad del volumen no están disponibles",
+		"volume backup is in progress or waiting to be restored":             "la copia de seguridad del volumen está en curso o pendiente de restaurarse",
+		"only completed volume backups can be restored":                      "solo se pueden restaurar copias de seguridad del volumen completadas",
+		"no volume restore is pending":                                       "no hay ninguna restauración del volumen pendiente",
+		"server must be stopped to back up or restore its volume":            "el servidor debe estar detenido para respaldar o restaurar su volumen",
+		"failed to run command":                                              "no se pudo ejecutar el comando",
+		"command must be a single line":                                      "el comando debe ocupar una sola línea",
+		"restart the server to enable console commands":                      "reinicia el servidor para habilitar los comandos de consola",
+		"the game could not run the command":                                 "el juego no pudo ejecutar el comando",
+		"server group not found":                                             "grupo de servidores no encontrado",
+		"you already have the maximum number of server groups":               "ya tienes el número máximo de grupos de servidores",
+		"group_ids must list each of your server groups once":                "group_ids debe incluir cada uno de tus grupos de servidores una sola vez",
+		"server_ids must not list a server twice":                            "server_ids no debe incluir un servidor dos veces",
+		"failed to list server groups":                                       "no se pudieron listar los grupos de servidores",
+		"failed to create server group":                                      "no se pudo crear el grupo de servidores",
+		"failed to update server group":                                      "no se pudo actualizar el grupo de servidores",
+		"failed to delete server group":                                      "no se pudo eliminar el grupo de servidores",
+		"failed to reorder server groups":                                    "no se pudieron reordenar los grupos de servidores",
+		"failed to update favorite":                                          "no se pudo actualizar el favorito",
+		"sort must be recent or favorites":                                   "sort debe ser recent o favorites",
+		"templates can't be created from custom game servers":                "no se pueden crear plantillas a partir de servidores de juegos personalizados",
+		"server is not suspended":                                            "el servidor no está suspendido",
+		"your account is suspended and read-only until reinstated":           "tu cuenta está suspendida y en modo de solo lectura hasta que se restablezca",
+		"account is not suspended":                                           "la cuenta no está suspendida",
+		"account is already suspended":                                       "la cuenta ya está suspendida",
+		"internal error":                                                     "error interno",
+		"No server capacity available at this time. Please try again later.": "No hay capacidad disponible en este momento. Inténtalo de nuevo más tarde.",
+
+		// Validation messages
+		"is required":                   "es obligatorio",
+		"must be a valid email address": "debe ser una dirección de correo válida",
+		"must be a valid DNS label":     "debe ser una etiqueta DNS válida",
+		"must be a valid domain name":   "debe ser un nombre de dominio válido",
+		"is invalid":                    "no es válido",
+	},
+	"de": {
+		// Server status messages
+		"Creating game server...":                            "Gameserver wird erstellt...",
+		"Starting server...":                                 "Server wird gestartet...",
+		"Starting game server...":                            "Gameserver wird gestartet...",
+		"Starting game process":                              "Spielprozess wird gestartet",
+		"Game server is running":                             "Gameserver läuft",
+		"Installing game files":                              "Spieldateien werden installiert",
+		"Generating world":                                   "Welt wird generiert",
+		"Starting game":                                      "Spiel wird gestartet",
+		"Stopping server...":                                 "Server wird gestoppt...",
+		"Stopping game process":                              "Spielprozess wird gestoppt",
+		"Game process stopped":                               "Spielprozess gestoppt",
+		"Server stopped (fallback)":                          "Server gestoppt",
+		"Restarting server with updated configuration...":    "Server wird mit neuer Konfiguration neu gestartet...",
+		"Cleaning up resources...":                           "Ressourcen werden freigegeben...",
+		"Subscription cancelled":                             "Abonnement gekündigt",
+		"Timeout waiting for pod to be ready":                "Zeitüberschreitung beim Warten auf den Server",
+		"Server stopped unexpectedly (deployment not found)": "Server wurde unerwartet gestoppt",
+		"Server unresponsive (heartbeat timeout). Click Start to restart.":                                                                           "Server reagiert nicht. Klicke auf Starten, um ihn neu zu starten.",
+		"Server ran out of memory (OOM killed). Consider upgrading to a larger plan.":                                                                "Dem Server ist der Arbeitsspeicher ausgegangen. Erwäge ein Upgrade auf einen größeren Tarif.",
+		"Game process health check failed":                                                                                                           "Zustandsprüfung des Spielprozesses fehlgeschlagen",
+		"The server was moved off its node by the cluster and is restarting. Players may have been briefly disconnected.":                            "Der Cluster hat den Server von seinem Knoten verschoben und startet ihn neu. Spieler wurden möglicherweise kurz getrennt.",
+		"The server's host is being reclaimed by its provider, so the server is moving to another host. Players may have been briefly disconnected.": "Der Host des Servers wird von seinem Anbieter zurückgefordert, daher wird der Server auf einen anderen Host verschoben. Spieler wurden möglicherweise kurz getrennt.",
+		"Upgrading server plan...":                          "Servertarif wird aktualisiert...",
+		"Suspended: a payment for this server was disputed": "Gesperrt: eine Zahlung für diesen Server wurde angefochten",
+		"Suspended: abuse detected, pending review":         "Gesperrt: Missbrauch erkannt, Prüfung ausstehend",
+
+		// Error messages
+		"unauthorized":                                  "nicht autorisiert",
+		"invalid user ID":                               "ungültige Benutzer-ID",
+		"server not found":                              "Server nicht gefunden",
+		"server ID required":                            "Server-ID erforderlich",
+		"subdomain already taken":                       "Subdomain ist bereits vergeben",
+		"invalid credentials":                           "ungültige Anmeldedaten",
+		"user already exists":                           "Benutzer existiert bereits",
+		"user not found":                                "Benutzer nicht gefunden",
+		"email already verified":                        "E-Mail-Adresse ist bereits bestätigt",
+		"invalid or expired token":                      "ungültiges oder abgelaufenes Token",
+		"missing authorization":                         "Autorisierung fehlt",
+		"invalid request body":                          "ungültiger Anfrageinhalt",
+		"request validation failed":                     "Validierung der Anfrage fehlgeschlagen",
+		"server has no active subscription":             "Server hat kein aktives Abonnement",
+		"server is not expired":                         "Server ist nicht abgelaufen",
+		"server cannot be started from current state":   "Server kann im aktuellen Status nicht gestartet werden",
+		"server cannot be stopped from current state":   "Server kann im aktuellen Status nicht gestoppt werden",
+		"server cannot be restarted from current state": "Server kann im aktuellen Status nicht neu gestartet werden",
+		"server must be running or stopped to restart":  "Server muss laufen oder gestoppt sein, um neu zu starten",
+		"logs not available":                            "Protokolle nicht verfügbar",
+		"server has not run out of memory":              "Dem Server ist nicht der Arbeitsspeicher ausgegangen",
+		"no larger plan is available for this server":   "Für diesen Server ist kein größerer Tarif verfügbar",
+		"server was restarted too many times, please wait before trying again": "Der Server wurde zu oft neu gestartet, bitte warte, bevor du es erneut versuchst",
+		"too many requests, please slow down":                                  "Zu viele Anfragen, bitte langsamer",
+		"server must be running to receive commands":                           "Server muss laufen, um Befehle zu empfangen",
+		"command not found":                                                              "Befehl nicht gefunden",
+		"webhook not found":                                                              "Webhook nicht gefunden",
+		"template not found":                                                             "Vorlage nicht gefunden",
+		"environment revision not found":                                                 "Umgebungsrevision nicht gefunden",
+		"discord integration is not enabled":                                             "Die Discord-Integration ist nicht aktiviert",
+		"this account is already linked to another user":                                 "Dieses Konto ist bereits mit einem anderen Benutzer verknüpft",
+		"could not verify the Steam sign-in, please try again":                           "Die Steam-Anmeldung konnte nicht überprüft werden, bitte versuche es erneut",
+		"linked account not found":                                                       "Verknüpftes Konto nicht gefunden",
+		"token is not allowed to access this endpoint":                                   "Das Token darf nicht auf diesen Endpunkt zugreifen",
+		"server already has the maximum number of webhooks":                              "Der Server hat bereits die maximale Anzahl an Webhooks",
+		"must be a public http or https URL":                                             "muss eine öffentliche http- oder https-URL sein",
+		"this game does not support applying changes without a restart":                  "Dieses Spiel unterstützt keine Änderungen ohne Neustart",
+		"Environment variables updated. Applying changes to the running server...":       "Umgebungsvariablen aktualisiert. Änderungen werden auf den laufenden Server angewendet...",
+		"Environment variables updated.":                                                 "Umgebungsvariablen aktualisiert.",
+		"confirmation does not match the server's subdomain":                             "Bestätigung stimmt nicht mit der Subdomain des Servers überein",
+		"this purchase would exceed your monthly spending limit":                         "dieser Kauf würde dein monatliches Ausgabenlimit überschreiten",
+		"server is already scheduled for deletion":                                       "Server ist bereits zur Löschung vorgesehen",
+		"server is suspended pending review":                                             "Server ist bis zur Prüfung gesperrt",
+		"your account is under review, please contact support":                           "Dein Konto wird geprüft, bitte wende dich an den Support",
+		"admin access required":                                                          "Administratorzugriff erforderlich",
+		"catalog channel is not configured":                                              "Katalogkanal ist nicht konfiguriert",
+		"catalog entry is invalid":                                                       "Katalogeintrag ist ungültig",
+		"custom games are not available":                                                 "Benutzerdefinierte Spiele sind nicht verfügbar",
+		"images must come from an allowed registry":                                      "Images müssen aus einer erlaubten Registry stammen",
+		"custom games need a game definition":                                            "Benutzerdefinierte Spiele benötigen eine Spieldefinition",
+		"server is not a custom game":                                                    "Server ist kein benutzerdefiniertes Spiel",
+		"custom game definition is invalid":                                              "Definition des benutzerdefinierten Spiels ist ungültig",
+		"ports can't be added, removed or renamed after the server is created":           "Ports können nach dem Erstellen des Servers nicht hinzugefügt, entfernt oder umbenannt werden",
+		"failed to update custom game":                                                   "Benutzerdefiniertes Spiel konnte nicht aktualisiert werden",
+		"failed to get egress usage":                                                     "Ausgehender Datenverkehr konnte nicht abgerufen werden",
+		"failed to query server":                                                         "Server konnte nicht abgefragt werden",
+		"failed to get recommendations":                                                  "Empfehlungen konnten nicht abgerufen werden",
+		"custom domain not found":                                                        "Eigene Domain nicht gefunden",
+		"domain is already added to this server":                                         "Die Domain ist diesem Server bereits hinzugefügt",
+		"domain is already verified for another server":                                  "Die Domain ist bereits für einen anderen Server verifiziert",
+		"server already has the maximum number of custom domains":                        "Der Server hat bereits die maximale Anzahl an eigenen Domains",
+		"failed to list custom domains":                                                  "Eigene Domains konnten nicht aufgelistet werden",
+		"failed to add custom domain":                                                    "Eigene Domain konnte nicht hinzugefügt werden",
+		"failed to verify custom domain":                                                 "Eigene Domain konnte nicht verifiziert werden",
+		"failed to delete custom domain":                                                 "Eigene Domain konnte nicht gelöscht werden",
+		"range must be 1h, 6h, 24h or 7d":                                                "Zeitraum muss 1h, 6h, 24h oder 7d sein",
+		"failed to get server metrics":                                                   "Servermetriken konnten nicht abgerufen werden",
+		"failed to get server sessions":                                                  "Serversitzungen konnten nicht abgerufen werden",
+		"range must be 24h, 7d, 30d or 90d":                                              "Der Zeitraum muss 24h, 7d, 30d oder 90d sein",
+		"too many live status connections, please try again shortly":                     "zu viele Live-Statusverbindungen, bitte versuche es gleich noch einmal",
+		"too many status streams are open for this account, close another tab or window": "für dieses Konto sind zu viele Statusverbindungen geöffnet, schließe einen anderen Tab oder ein anderes Fenster",
+		"Status updates moved to a newer tab or window":                                  "Statusaktualisierungen wurden in einen neueren Tab oder ein neueres Fenster verschoben",
+		"invalid verification code":                                                      "ungültiger Bestätigungscode",
+		"incorrect password":                                                             "falsches Passwort",
+		"failed to log in":                                                               "Anmeldung fehlgeschlagen",
+		"failed to get two-factor authentication":                                        "Zwei-Faktor-Authentifizierung konnte nicht abgerufen werden",
+		"two-factor authentication is already enabled":                                   "Zwei-Faktor-Authentifizierung ist bereits aktiviert",
+		"failed to set up two-factor authentication":                                     "Zwei-Faktor-Authentifizierung konnte nicht eingerichtet werden",
+		"two-factor authentication setup was not started":                                "die Einrichtung der Zwei-Faktor-Authentifizierung wurde nicht gestartet",
+		"failed to enable two-factor authentication":                                     "Zwei-Faktor-Authentifizierung konnte nicht aktiviert werden",
+		"failed to disable two-factor authentication":                                    "Zwei-Faktor-Authentifizierung konnte nicht deaktiviert werden",
+		"failed to generate backup codes":                                                "Backup-Codes konnten nicht erstellt werden",
+		"failed to verify code":                                                          "Code konnte nicht überprüft werden",
+		"server cloning is not available":                                                "Das Klonen von Servern ist nicht verfügbar",
+		"expired servers can't be cloned":                                                "Abgelaufene Server können nicht geklont werden",
+		"this server's data can't be copied to a new server":                             "Die Daten dieses Servers können nicht auf einen neuen Server kopiert werden",
+		"failed to clone server":                                                         "Server konnte nicht geklont werden",
+		"failed to get server clone":                                                     "Klon des Servers konnte nicht abgerufen werden",
+		"server is not a clone":                                                          "Der Server ist kein Klon",
+		"failed to list mods":                                                            "Mods konnten nicht aufgelistet werden",
+		"failed to search mods":                                                          "Mods konnten nicht gesucht werden",
+		"no version of the mod supports this server":                                     "Keine Version des Mods unterstützt diesen Server",
+		"mod version has no files":                                                       "Die Mod-Version hat keine Dateien",
+		"failed to install mod":                                                          "Mod konnte nicht installiert werden",
+		"failed to download mod":                                                         "Mod konnte nicht heruntergeladen werden",
+		"mod not found":                                                                  "Mod nicht gefunden",
+		"failed to remove mod":                                                           "Mod konnte nicht entfernt werden",
+		"mods aren't available for this server":                                          "Mods sind für diesen Server nicht verfügbar",
+		"Mod installed, restart the server to load it":                                   "Mod installiert, starte den Server neu, um ihn zu laden",
+		"Mod removed, restart the server to unload it":                                   "Mod entfernt, starte den Server neu, um ihn zu entladen",
+		"preferred port is not available":                                                "Der bevorzugte Port ist nicht verfügbar",
+		"plan does not include a preferred port":                                         "Der Tarif enthält keinen bevorzugten Port",
+		"protocol is required for custom games":                                          "Für eigene Spiele ist ein Protokoll erforderlich",
+		"game has no ports":                                                              "Das Spiel hat keine Ports",
+		"failed to check port availability":                                              "Portverfügbarkeit konnte nicht geprüft werden",
+		"The server's port was reserved by the platform, so the server is moving to a new port. Players may have been briefly disconnected.": "Der Port des Servers wurde von der Plattform reserviert, daher wird der Server auf einen neuen Port verschoben. Spieler wurden möglicherweise kurz getrennt.",
+		"schedule not found": "Zeitplan nicht gefunden",
+		"server already has the maximum number of schedules":                 "Der Server hat bereits die maximale Anzahl an Zeitplänen",
+		"failed to list schedules":                                           "Zeitpläne konnten nicht aufgelistet werden",
+		"failed to create schedule":                                          "Zeitplan konnte nicht erstellt werden",
+		"failed to update schedule":                                          "Zeitplan konnte nicht aktualisiert werden",
+		"failed to delete schedule":                                          "Zeitplan konnte nicht gelöscht werden",
+		"Schedule deleted":                                                   "Zeitplan gelöscht",
+		"cron expression never runs":                                         "Der Cron-Ausdruck wird nie ausgeführt",
+		"tasks can run at most every 15 minutes":                             "Aufgaben können höchstens alle 15 Minuten ausgeführt werden",
+		"failed to list waitlist":                                            "Warteliste konnte nicht geladen werden",
+		"failed to leave waitlist":                                           "Verlassen der Warteliste fehlgeschlagen",
+		"waitlist entry not found":                                           "Wartelisteneintrag nicht gefunden",
+		"unknown region":                                                     "Unbekannte Region",
+		"this waitlist offer has expired or was already used":                "Dieses Wartelistenangebot ist abgelaufen oder wurde bereits genutzt",
+		"Left the waitlist":                                                  "Warteliste verlassen",
+		"server must be running to open its console":                         "Der Server muss laufen, um seine Konsole zu öffnen",
+		"failed to open console":                                             "Konsole konnte nicht geöffnet werden",
+		"failed to join waitlist":                                            "Beitritt zur Warteliste fehlgeschlagen",
+		"files of expired servers are read-only":                             "Dateien abgelaufener Server sind schreibgeschützt",
+		"server must be running to manage its files":                         "Der Server muss laufen, um seine Dateien zu verwalten",
+		"failed to access files":                                             "Auf Dateien konnte nicht zugegriffen werden",
+		"failed to upload file":                                              "Datei konnte nicht hochgeladen werden",
+		"failed to create directory":                                         "Verzeichnis konnte nicht erstellt werden",
+		"failed to delete file":                                              "Datei konnte nicht gelöscht werden",
+		"failed to change files":                                             "Dateien konnten nicht geändert werden",
+		"path not found":                                                     "Pfad nicht gefunden",
+		"restart the server to enable file management":                       "Starte den Server neu, um die Dateiverwaltung zu aktivieren",
+		"path can't be changed":                                              "Der Pfad kann nicht geändert werden",
+		"path is a directory":                                                "Der Pfad ist ein Verzeichnis",
+		"not enough free space on the server for the file":                   "Nicht genügend freier Speicher auf dem Server für die Datei",
+		"path can't be written":                                              "In den Pfad kann nicht geschrieben werden",
+		"failed to check account status":                                     "Kontostatus konnte nicht geprüft werden",
+		"server is being deleted":                                            "Server wird gelöscht",
+		"failed to get email preferences":                                    "E-Mail-Einstellungen konnten nicht abgerufen werden",
+		"failed to update email preferences":                                 "E-Mail-Einstellungen konnten nicht aktualisiert werden",
+		"failed to list volume backups":                                      "Volume-Backups konnten nicht aufgelistet werden",
+		"failed to create volume backup":                                     "Volume-Backup konnte nicht erstellt werden",
+		"failed to get volume backup":                                        "Volume-Backup konnte nicht abgerufen werden",
+		"failed to delete volume backup":                                     "Volume-Backup konnte nicht gelöscht werden",
+		"failed to restore volume backup":                                    "Volume-Backup konnte nicht wiederhergestellt werden",
+		"failed to cancel volume restore":                                    "Wiederherstellung des Volumes konnte nicht abgebrochen werden",
+		"volume backup not found":                                            "Volume-Backup nicht gefunden",
+		"volume backups are not available":                                   "Volume-Backups sind nicht verfügbar",
+		"volume backup is in progress or waiting to be restored":             "Das Volume-Backup läuft noch oder wartet auf die Wiederherstellung",
+		"only completed volume backups can be restored":                      "Nur abgeschlossene Volume-Backups können wiederhergestellt werden",
+		"no volume restore is pending":                                       "Es steht keine Wiederherstellung des Volumes aus",
+		"server must be stopped to back up or restore its volume":            "Der Server muss gestoppt sein, um sein Volume zu sichern oder wiederherzustellen",
+		"failed to run command":                                              "Befehl konnte nicht ausgeführt werden",
+		"command must be a single line":                                      "Der Befehl muss aus einer einzigen Zeile bestehen",
+		"restart the server to enable console commands":                      "Starte den Server neu, um Konsolenbefehle zu aktivieren",
+		"the game could not run the command":                                 "Das Spiel konnte den Befehl nicht ausführen",
+		"server group not found":                                             "Servergruppe nicht gefunden",
+		"you already have the maximum number of server groups":               "Du hast bereits die maximale Anzahl an Servergruppen",
+		"group_ids must list each of your server groups once":                "group_ids muss jede deiner Servergruppen genau einmal enthalten",
+		"server_ids must not list a server twice":                            "server_ids darf keinen Server doppelt enthalten",
+		"failed to list server groups":                                       "Servergruppen konnten nicht aufgelistet werden",
+		"failed to create server group":                                      "Servergruppe konnte nicht erstellt werden",
+		"failed to update server group":                                      "Servergruppe konnte nicht aktualisiert werden",
+		"failed to delete server group":                                      "Servergruppe konnte nicht gelöscht werden",
+		"failed to reorder server groups":                                    "Servergruppen konnten nicht neu angeordnet werden",
+		"failed to update favorite":                                          "Favorit konnte nicht aktualisiert werden",
+		"sort must be recent or favorites":                                   "sort muss recent oder favorites sein",
+		"templates can't be created from custom game servers":                "Aus Servern mit benutzerdefinierten Spielen können keine Vorlagen erstellt werden",
+		"server is not suspended":                                            "Server ist nicht gesperrt",
+		"your account is suspended and read-only until reinstated":           "Dein Konto ist gesperrt und bis zur Wiederherstellung schreibgeschützt",
+		"account is not suspended":                                           "Konto ist nicht gesperrt",
+		"account is already suspended":                                       "Konto ist bereits gesperrt",
+		"internal error":                                                     "interner Fehler",
+		"No server capacity available at this time. Please try again later.": "Derzeit ist keine Serverkapazität verfügbar. Bitte versuche es später erneut.",
+
+		// Validation messages
+		"is required":                   "ist erforderlich",
+		"must be a valid email address": "muss eine gültige E-Mail-Adresse sein",
+		"must be a valid DNS label":     "muss ein gültiges DNS-Label sein",
+		"must be a valid domain name":   "muss ein gültiger Domainname sein",
+		"is invalid":                    "ist ungültig",
+	},
+}
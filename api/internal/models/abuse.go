@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Abuse rules that suspend a server
+const (
+	AbuseRuleCPU          = "cpu"           // CPU usage stayed above the threshold
+	AbuseRuleNetwork      = "network"       // Outbound traffic stayed above the threshold
+	AbuseRuleBannedBinary = "banned_binary" // Supervisor found a banned binary before starting the game
+)
+
+// BannedHash is the SHA-256 of a game binary supervisors refuse to run
+type BannedHash struct {
+	SHA256    string    `json:"sha256"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AbuseReport records why a server was suspended for abuse
+type AbuseReport struct {
+	ID         uuid.UUID  `json:"id"`
+	ServerID   uuid.UUID  `json:"server_id"`
+	UserEmail  string     `json:"user_email"`
+	Rule       string     `json:"rule"`
+	Detail     string     `json:"detail"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// AddBannedHashRequest is the payload for banning a binary
+type AddBannedHashRequest struct {
+	SHA256 string `json:"sha256" binding:"required,len=64,hexadecimal"`
+	Name   string `json:"name" binding:"required,max=255"`
+}
+
+// SetAbuseExemptRequest is the payload for exempting a server from the anomaly rules
+type SetAbuseExemptRequest struct {
+	Exempt *bool `json:"exempt" binding:"required"`
+}
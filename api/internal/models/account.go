@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SuspendedUser is a suspended account as listed for admins
+type SuspendedUser struct {
+	ID                       uuid.UUID  `json:"id"`
+	Email                    string     `json:"email"`
+	SuspendedAt              time.Time  `json:"suspended_at"`
+	SuspensionReason         string     `json:"suspension_reason"`
+	ReinstatementRequestedAt *time.Time `json:"reinstatement_requested_at,omitempty"`
+	ReinstatementMessage     *string    `json:"reinstatement_message,omitempty"`
+}
+
+// SuspendUserRequest is the payload for an admin suspending an account
+type SuspendUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ReinstatementRequest is the payload for a suspended user asking to be reinstated
+type ReinstatementRequest struct {
+	Message string `json:"message" binding:"required,max=2000"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminServer is a server as listed to operators, across all users
+type AdminServer struct {
+	ID             uuid.UUID     `json:"id"`
+	UserID         uuid.UUID     `json:"user_id"`
+	UserEmail      string        `json:"user_email"`
+	DisplayName    string        `json:"display_name"`
+	Subdomain      string        `json:"subdomain"`
+	Game           GameType      `json:"game"`
+	Plan           ServerPlan    `json:"plan"`
+	Status         ServerStatus  `json:"status"`
+	StatusMessage  *string       `json:"status_message,omitempty"`
+	StatusReason   *StatusReason `json:"status_reason,omitempty"`
+	NodeName       *string       `json:"node_name,omitempty"`
+	LastReconciled *time.Time    `json:"last_reconciled,omitempty"`
+	LastHeartbeat  *time.Time    `json:"last_heartbeat,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// AdminServerFilter narrows the servers listed to operators
+type AdminServerFilter struct {
+	Status ServerStatus `form:"status"`
+	UserID string       `form:"user_id" binding:"omitempty,uuid"`
+	Search string       `form:"q" binding:"max=255"` // Matches the subdomain, display name or owner's email
+	// Stuck lists servers that have sat in pending, starting or stopping for StuckAfter
+	Stuck  bool `form:"stuck"`
+	Limit  int  `form:"limit" binding:"omitempty,min=1,max=200"` // Default: 50
+	Offset int  `form:"offset" binding:"omitempty,min=0"`
+}
+
+// StuckAfter is how long a server may sit in a transitional status before operators see
+// it as stuck
+const StuckAfter = 10 * time.Minute
+
+// NodeCapacity is a node's port pool and resources, and how much of them servers hold
+type NodeCapacity struct {
+	Name                  string    `json:"name"`
+	Region                string    `json:"region,omitempty"`
+	Zone                  string    `json:"zone,omitempty"`
+	IsActive              bool      `json:"is_active"`
+	Dedicated             bool      `json:"dedicated"`
+	Spot                  bool      `json:"spot"`
+	OS                    string    `json:"os"`
+	Servers               int       `json:"servers"` // Servers holding ports on the node
+	TCPPorts              int       `json:"tcp_ports"`
+	TCPPortsUsed          int       `json:"tcp_ports_used"`
+	UDPPorts              int       `json:"udp_ports"`
+	UDPPortsUsed          int       `json:"udp_ports_used"`
+	CPUMillicores         *int      `json:"cpu_millicores,omitempty"` // Allocatable
+	ReservedCPUMillicores int       `json:"reserved_cpu_millicores"`
+	MemoryBytes           *int64    `json:"memory_bytes,omitempty"` // Allocatable
+	ReservedMemoryBytes   int64     `json:"reserved_memory_bytes"`
+	GPUs                  int       `json:"gpus"`
+	ReservedGPUs          int       `json:"reserved_gpus"`
+	UpdatedAt             time.Time `json:"updated_at"` // When a sync last recorded it
+
+	LastSync *NodeSyncResult `json:"last_sync,omitempty"` // What the last node sync did with it
+}
+
+// SetUserRoleRequest is the payload for changing a user's role
+type SetUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BackupPolicy is how many backups a server keeps and whether they're copied off-site.
+// Retention keeps the newest backup of each of the last KeepDaily days and KeepWeekly weeks
+// that have one, both in the data volume and off-site.
+type BackupPolicy struct {
+	KeepDaily  int        `json:"keep_daily"`
+	KeepWeekly int        `json:"keep_weekly"`
+	Replicate  bool       `json:"replicate"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"` // Unset for the plan's default policy
+}
+
+// BackupLimits is the backup retention and replication a server's plan allows
+type BackupLimits struct {
+	MaxDaily    int  `json:"max_daily"`
+	MaxWeekly   int  `json:"max_weekly"`
+	Replication bool `json:"replication"` // Also false when off-site replication isn't configured
+}
+
+// DefaultBackupPolicy keeps as many backups as limits allow, without replicating them
+func DefaultBackupPolicy(limits BackupLimits) BackupPolicy {
+	return BackupPolicy{KeepDaily: limits.MaxDaily, KeepWeekly: limits.MaxWeekly}
+}
+
+// Within returns the policy reduced to limits, e.g. after a downgrade to a smaller plan
+func (p BackupPolicy) Within(limits BackupLimits) BackupPolicy {
+	p.KeepDaily = min(p.KeepDaily, limits.MaxDaily)
+	p.KeepWeekly = min(p.KeepWeekly, limits.MaxWeekly)
+	p.Replicate = p.Replicate && limits.Replication
+	return p
+}
+
+// BackupRetention is how many backups to keep: the newest of each of the last KeepDaily
+// days and KeepWeekly weeks that have one. The newest backup is always kept.
+type BackupRetention struct {
+	KeepDaily  int `json:"keep_daily"`
+	KeepWeekly int `json:"keep_weekly"`
+}
+
+// Retention returns the retention the policy applies
+func (p BackupPolicy) Retention() BackupRetention {
+	return BackupRetention{KeepDaily: p.KeepDaily, KeepWeekly: p.KeepWeekly}
+}
+
+// Keep reports which of the backups taken at times (newest first) the retention keeps.
+// The supervisor prunes archives in the data volume the same way.
+func (r BackupRetention) Keep(times []time.Time) []bool {
+	keep := make([]bool, len(times))
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	for i, t := range times {
+		day := t.UTC().Format(time.DateOnly)
+		year, week := t.UTC().ISOWeek()
+		weekKey := fmt.Sprintf("%d-W%02d", year, week)
+
+		keep[i] = i == 0
+		if !days[day] && len(days) < r.KeepDaily {
+			days[day] = true
+			keep[i] = true
+		}
+		if !weeks[weekKey] && len(weeks) < r.KeepWeekly {
+			weeks[weekKey] = true
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+// BackupCommandPayload is the payload of a backup command
+type BackupCommandPayload struct {
+	Retention *BackupRetention `json:"retention,omitempty"` // Prune old archives; nil keeps them all
+	Replicate bool             `json:"replicate,omitempty"` // Copy the archive off-site; handled by the API
+}
+
+// BackupReplicaState is the state of an off-site backup copy
+type BackupReplicaState string
+
+const (
+	BackupReplicaPending    BackupReplicaState = "pending"    // Waiting to be copied
+	BackupReplicaReplicated BackupReplicaState = "replicated" // Stored off-site
+	BackupReplicaFailed     BackupReplicaState = "failed"     // Copying failed too many times
+	BackupReplicaDeleting   BackupReplicaState = "deleting"   // Pruned, waiting to be removed off-site
+)
+
+// BackupReplica is an off-site copy of a backup archive
+type BackupReplica struct {
+	ID           uuid.UUID          `json:"id"`
+	ServerID     uuid.UUID          `json:"server_id"`
+	CommandID    *uuid.UUID         `json:"command_id,omitempty"`
+	Path         string             `json:"-"` // Relative to the data volume
+	Name         string             `json:"name"`
+	ObjectKey    string             `json:"-"`
+	Size         int64              `json:"size"`
+	State        BackupReplicaState `json:"state"`
+	Attempts     int                `json:"-"`
+	LastError    *string            `json:"last_error,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+	ReplicatedAt *time.Time         `json:"replicated_at,omitempty"`
+}
+
+// UpdateBackupPolicyRequest is the request body for changing a server's backup policy
+type UpdateBackupPolicyRequest struct {
+	KeepDaily  int  `json:"keep_daily" binding:"min=1"`
+	KeepWeekly int  `json:"keep_weekly" binding:"min=0"`
+	Replicate  bool `json:"replicate"`
+}
+
+// VolumeBackupState is the state of a volume backup
+type VolumeBackupState string
+
+const (
+	VolumeBackupPending   VolumeBackupState = "pending"   // Waiting for its pod
+	VolumeBackupRunning   VolumeBackupState = "running"   // Its pod is archiving the volume
+	VolumeBackupCompleted VolumeBackupState = "completed" // Stored in the bucket
+	VolumeBackupFailed    VolumeBackupState = "failed"
+	VolumeBackupDeleting  VolumeBackupState = "deleting" // Waiting to be removed from the bucket
+)
+
+// VolumeBackup is a snapshot of a server's whole data volume, taken while it's stopped
+type VolumeBackup struct {
+	ID                 uuid.UUID         `json:"id"`
+	ServerID           uuid.UUID         `json:"server_id"`
+	ObjectKey          string            `json:"-"`
+	Size               int64             `json:"size"`
+	State              VolumeBackupState `json:"state"`
+	Error              *string           `json:"error,omitempty"`
+	RestoreRequestedAt *time.Time        `json:"restore_requested_at,omitempty"` // Set while it waits to be restored on the next start
+	CreatedAt          time.Time         `json:"created_at"`
+	CompletedAt        *time.Time        `json:"completed_at,omitempty"`
+}
+
+// Active reports whether the backup's pod is, or is about to be, using the volume
+func (b *VolumeBackup) Active() bool {
+	return b.State == VolumeBackupPending || b.State == VolumeBackupRunning
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CapacityResource is what a new server can't get enough of
+type CapacityResource string
+
+const (
+	CapacityQuota     CapacityResource = "quota"      // The server namespace's resource quota
+	CapacityNodes     CapacityResource = "nodes"      // No free node of the kind the plan needs (dedicated, spot, OS)
+	CapacityPorts     CapacityResource = "ports"      // Game ports
+	CapacityCPU       CapacityResource = "cpu"        // CPU
+	CapacityMemory    CapacityResource = "memory"     // Memory
+	CapacityGPU       CapacityResource = "gpu"        // GPUs
+	CapacityPlanLimit CapacityResource = "plan_limit" // Every node runs as many servers on the plan as it allows
+	CapacityWaitlist  CapacityResource = "waitlist"   // Freed capacity is offered to users on the waitlist first
+)
+
+// CapacityShortage explains why a server can't be created right now, returned as the details
+// of CAPACITY_UNAVAILABLE errors
+type CapacityShortage struct {
+	Resource CapacityResource `json:"resource"`
+	// AvailableAt is when subscriptions already set to end are expected to free enough room,
+	// if they will
+	AvailableAt *time.Time `json:"available_at,omitempty"`
+	// Waitlist is whether the user can sign up to be offered room once there is some
+	Waitlist bool `json:"waitlist"`
+}
+
+// WaitlistEntry is a user's signup to be offered capacity for a game and plan. Signups are
+// offered capacity one at a time, in order, with a checkout link that works until
+// OfferExpiresAt.
+type WaitlistEntry struct {
+	ID             uuid.UUID  `json:"id"`
+	Game           string     `json:"game"`
+	Plan           string     `json:"plan"`
+	Region         string     `json:"region,omitempty"` // Empty for any region
+	CreatedAt      time.Time  `json:"created_at"`
+	OfferedAt      *time.Time `json:"offered_at,omitempty"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty"`
+}
+
+// JoinWaitlistRequest is the payload for signing up to the capacity waitlist
+type JoinWaitlistRequest struct {
+	Game   string `json:"game" binding:"required,oneof=minecraft valheim"`
+	Plan   string `json:"plan" binding:"required,oneof=small medium large dedicated budget"`
+	Region string `json:"region" binding:"omitempty,max=255"` // Only count capacity in this region
+}
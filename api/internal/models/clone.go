@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServerCloneState is how far copying a clone's data has got
+type ServerCloneState string
+
+const (
+	ServerClonePending   ServerCloneState = "pending"   // Waiting for the source to be stopped
+	ServerCloneCopying   ServerCloneState = "copying"   // Its pod is copying the source's volume
+	ServerCloneCompleted ServerCloneState = "completed" // The server starts with the copy
+	ServerCloneFailed    ServerCloneState = "failed"
+)
+
+// ServerClone is a server created as a copy of another, whose data volume is filled with
+// the source's before its first start
+type ServerClone struct {
+	ServerID       uuid.UUID        `json:"server_id"`
+	SourceServerID *uuid.UUID       `json:"source_server_id,omitempty"` // Unset once the source is deleted
+	State          ServerCloneState `json:"state"`
+	Error          *string          `json:"error,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+}
+
+// Active reports whether the clone still waits for, or is copying, its data
+func (c *ServerClone) Active() bool {
+	return c.State == ServerClonePending || c.State == ServerCloneCopying
+}
+
+// CloneServerRequest is the payload for cloning a server. Game, plan, env and data come
+// from the source.
+type CloneServerRequest struct {
+	DisplayName string `json:"display_name" binding:"omitempty,min=3,max=50"`
+	Subdomain   string `json:"subdomain" binding:"required,min=3,max=50,dns"`
+
+	UseSavedCard bool `json:"use_saved_card"`
+}
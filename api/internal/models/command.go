@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CommandType is an action the API asks a server's supervisor to perform
+type CommandType string
+
+const (
+	CommandStop           CommandType = "stop"            // Gracefully stop the game process
+	CommandReloadConfig   CommandType = "reload_config"   // Send SIGHUP so the game reloads its config
+	CommandBackup         CommandType = "backup"          // Run the game's backup command
+	CommandExec           CommandType = "exec"            // Write payload to the game console (stdin)
+	CommandRestartProcess CommandType = "restart_process" // Stop and re-exec the game process inside the same pod
+	CommandExport         CommandType = "export"          // Archive the data volume as a zip for download
+	CommandImport         CommandType = "import"          // Replace the world with an uploaded archive
+)
+
+// CommandState is the delivery state of a command
+type CommandState string
+
+const (
+	CommandStatePending   CommandState = "pending"   // Queued, not yet picked up by the supervisor
+	CommandStateDelivered CommandState = "delivered" // Handed to the supervisor, awaiting result
+	CommandStateSucceeded CommandState = "succeeded"
+	CommandStateFailed    CommandState = "failed"
+	CommandStateExpired   CommandState = "expired" // Not picked up in time (e.g. server stopped)
+)
+
+// ServerCommand is a command queued for a server's supervisor
+type ServerCommand struct {
+	ID          uuid.UUID    `json:"id"`
+	ServerID    uuid.UUID    `json:"server_id"`
+	Type        CommandType  `json:"type"`
+	Payload     *string      `json:"payload,omitempty"`
+	State       CommandState `json:"state"`
+	Result      *string      `json:"result,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	DeliveredAt *time.Time   `json:"delivered_at,omitempty"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+
+	Artifact *CommandArtifact `json:"artifact,omitempty"` // File produced for download
+	Progress *CommandProgress `json:"progress,omitempty"` // Reported by long-running commands
+}
+
+// CommandArtifact is a file a command produced in the server's data volume, downloadable
+// while the server runs
+type CommandArtifact struct {
+	Path string `json:"-"` // Relative to the data volume
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// CommandProgress is how far a long-running command, such as an export, has got
+type CommandProgress struct {
+	DoneBytes  int64 `json:"done_bytes"`
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// CommandDownloadURL is a signed link to download a command's artifact without logging in
+type CommandDownloadURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateServerCommandRequest is the payload for queuing a command on a running server
+type CreateServerCommandRequest struct {
+	Type    string `json:"type" binding:"required,oneof=reload_config backup exec export"`
+	Payload string `json:"payload" binding:"required_if=Type exec,max=1000"`
+}
+
+// ConsoleCommandRequest is the payload for running a console command on a running server
+type ConsoleCommandRequest struct {
+	Command string `json:"command" binding:"required,max=1000"`
+}
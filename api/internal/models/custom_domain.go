@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomDomainStatus is the verification state of a custom domain
+type CustomDomainStatus string
+
+const (
+	CustomDomainPending  CustomDomainStatus = "pending"  // TXT record not checked yet
+	CustomDomainVerified CustomDomainStatus = "verified" // TXT record found
+	CustomDomainFailed   CustomDomainStatus = "failed"   // TXT record missing or wrong at the last check
+)
+
+// CustomDomain is a domain a user points at one of their servers
+type CustomDomain struct {
+	ID                uuid.UUID          `json:"id"`
+	ServerID          uuid.UUID          `json:"server_id"`
+	Domain            string             `json:"domain"`
+	VerificationToken string             `json:"-"`
+	Status            CustomDomainStatus `json:"status"`
+	LastError         *string            `json:"last_error,omitempty"`
+	CheckedAt         *time.Time         `json:"checked_at,omitempty"`
+	VerifiedAt        *time.Time         `json:"verified_at,omitempty"`
+	CreatedAt         time.Time          `json:"created_at"`
+
+	// Set by the API from the server's current ports
+	Verification DNSRecord   `json:"verification"`      // TXT record that proves ownership
+	Records      []DNSRecord `json:"records,omitempty"` // Records that point the domain at the server
+	Connect      string      `json:"connect,omitempty"` // What players enter to join, e.g. "play.example.com"
+}
+
+// DNSRecord is a record the user adds at their DNS provider
+type DNSRecord struct {
+	Type  string `json:"type"` // "TXT", "A", "AAAA" or "SRV"
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CreateCustomDomainRequest is the request body for adding a custom domain
+type CreateCustomDomainRequest struct {
+	Domain string `json:"domain" binding:"required,max=253"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// CustomGame is a user-supplied game definition for servers of the "custom" game type. The
+// supervisor is injected into the image, so any Linux image that can run the start command
+// works. Resources and price come from the plan.
+type CustomGame struct {
+	Image        string                 `json:"image" binding:"required,max=255"`
+	StartCommand []string               `json:"start_command" binding:"required,min=1,max=32,dive,max=1024"`
+	WorkDir      string                 `json:"work_dir,omitempty" binding:"max=255"`
+	DataPath     string                 `json:"data_path,omitempty" binding:"max=255"` // Where the server's volume is mounted (default /data)
+	Ports        []CustomGamePort       `json:"ports" binding:"required,min=1,max=8,dive"`
+	HealthCheck  *CustomGameHealthCheck `json:"health_check,omitempty"`
+}
+
+// CustomGamePort is a port a custom game listens on
+type CustomGamePort struct {
+	Name     string `json:"name" binding:"required,max=15"`
+	Port     int32  `json:"port" binding:"required,min=1,max=65535"`
+	Protocol string `json:"protocol" binding:"required,oneof=TCP UDP"`
+	SamePort string `json:"same_port,omitempty" binding:"max=15"` // Port of the other protocol that gets the same host port number
+}
+
+// CustomGameHealthCheck tells the supervisor when a custom game is ready
+type CustomGameHealthCheck struct {
+	Type     string `json:"type" binding:"required,oneof=none port log-pattern"`
+	Port     int32  `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Pattern  string `json:"pattern,omitempty" binding:"max=512"`
+}
+
+// UpdateCustomGameRequest is the payload for changing a custom game's definition
+type UpdateCustomGameRequest struct {
+	CustomGame CustomGame `json:"custom_game" binding:"required"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DiscordLink is a Discord account linked to a user for the Discord bot
+type DiscordLink struct {
+	UserID          uuid.UUID `json:"-"`
+	DiscordUserID   string    `json:"discord_user_id"`
+	DiscordUsername string    `json:"discord_username"`
+	LinkedAt        time.Time `json:"linked_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dispute is a chargeback on a payment, recorded from Stripe's charge.dispute.created webhook
+type Dispute struct {
+	ID              uuid.UUID  `json:"id"`
+	StripeDisputeID string     `json:"stripe_dispute_id"`
+	PaymentIntentID *string    `json:"payment_intent_id,omitempty"`
+	ServerID        *uuid.UUID `json:"server_id,omitempty"`
+	UserID          *uuid.UUID `json:"user_id,omitempty"`
+	UserEmail       *string    `json:"user_email,omitempty"`
+	Amount          int64      `json:"amount"`
+	Currency        string     `json:"currency"`
+	Reason          string     `json:"reason"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+}
+
+// LiftSuspensionRequest is the optional payload for lifting a server's suspension
+type LiftSuspensionRequest struct {
+	ClearUserFlag bool `json:"clear_user_flag"` // Also let the owner check out again
+}
@@ -0,0 +1,19 @@
+package models
+
+// EdgePort is a server's stable port on the edge proxy. It's kept while the server is
+// stopped and when it moves between nodes.
+type EdgePort struct {
+	Name     string `json:"name"` // "game", "query", "rcon"
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"` // "TCP" or "UDP"
+	Address  string `json:"address"`  // The edge proxy's anycast address
+}
+
+// EdgeRoute tells the edge proxy where to forward an edge port's traffic
+type EdgeRoute struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	ServerID string `json:"server_id"`
+	Backend  string `json:"backend,omitempty"` // node_ip:host_port, empty while the server has no allocation
+	Healthy  bool   `json:"healthy"`           // Running with a recent heartbeat
+}
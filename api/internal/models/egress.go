@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// EgressUsage is a server's outbound traffic for the current month against its plan's limits
+type EgressUsage struct {
+	Period          time.Time  `json:"period"` // First day of the month (UTC)
+	TxBytes         int64      `json:"tx_bytes"`
+	QuotaBytes      int64      `json:"quota_bytes,omitempty"`       // 0 means unlimited
+	BandwidthLimit  string     `json:"bandwidth_limit,omitempty"`   // Bits per second, e.g. "100M"; empty means unlimited
+	QuotaExceededAt *time.Time `json:"quota_exceeded_at,omitempty"` // When the owner was alerted
+}
@@ -0,0 +1,11 @@
+package models
+
+// EmailPreferences are the optional emails a user gets
+type EmailPreferences struct {
+	WeeklyDigest bool `json:"weekly_digest"` // Weekly summary of each server's activity
+}
+
+// UpdateEmailPreferencesRequest is the payload for changing a user's email preferences
+type UpdateEmailPreferencesRequest struct {
+	WeeklyDigest *bool `json:"weekly_digest" binding:"required"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EnvRevision is one change to a server's env overrides
+type EnvRevision struct {
+	Revision       int               `json:"revision"`
+	EnvOverrides   map[string]string `json:"env_overrides"` // Overrides after the change
+	PreviousEnv    map[string]string `json:"-"`
+	Diff           EnvDiff           `json:"diff"`
+	ChangedBy      *uuid.UUID        `json:"changed_by,omitempty"`
+	ChangedByEmail *string           `json:"changed_by_email,omitempty"`
+	RevertedTo     *int              `json:"reverted_to,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// EnvDiff describes what an env override change added, removed and changed
+type EnvDiff struct {
+	Added   map[string]string    `json:"added,omitempty"`
+	Removed map[string]string    `json:"removed,omitempty"` // Values before removal
+	Changed map[string]EnvChange `json:"changed,omitempty"`
+}
+
+// EnvChange is a changed env override value
+type EnvChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DiffEnv returns the difference between two sets of env overrides
+func DiffEnv(from, to map[string]string) EnvDiff {
+	var diff EnvDiff
+	for key, value := range to {
+		previous, ok := from[key]
+		switch {
+		case !ok:
+			if diff.Added == nil {
+				diff.Added = map[string]string{}
+			}
+			diff.Added[key] = value
+		case previous != value:
+			if diff.Changed == nil {
+				diff.Changed = map[string]EnvChange{}
+			}
+			diff.Changed[key] = EnvChange{From: previous, To: value}
+		}
+	}
+	for key, value := range from {
+		if _, ok := to[key]; !ok {
+			if diff.Removed == nil {
+				diff.Removed = map[string]string{}
+			}
+			diff.Removed[key] = value
+		}
+	}
+	return diff
+}
+
+// Empty reports whether the diff has no changes
+func (d EnvDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// FileAccessStatus is the state of an expired server's temporary file access
+type FileAccessStatus string
+
+const (
+	FileAccessStopped  FileAccessStatus = "stopped"  // Not started, stopped, or past its lifetime
+	FileAccessStarting FileAccessStatus = "starting" // File access pod is being scheduled or started
+	FileAccessReady    FileAccessStatus = "ready"    // Files can be listed and downloaded
+)
+
+// FileAccess describes an expired server's temporary read-only access to its data
+type FileAccess struct {
+	Status      FileAccessStatus `json:"status"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`   // When the access stops; start it again to extend
+	DeleteAfter *time.Time       `json:"delete_after,omitempty"` // When the server's data is removed for good
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IncidentKind is what went wrong with a node
+type IncidentKind string
+
+const (
+	IncidentNodeNotReady  IncidentKind = "node_not_ready" // Node stopped reporting Ready
+	IncidentNodeDrained   IncidentKind = "node_drained"   // Node cordoned for maintenance
+	IncidentNodeReclaimed IncidentKind = "node_reclaimed" // Spot node being reclaimed by its provider
+)
+
+// Incident is a node-level outage affecting the servers on that node
+type Incident struct {
+	ID              uuid.UUID    `json:"id"`
+	Kind            IncidentKind `json:"kind"`
+	NodeName        string       `json:"-"` // Internal, not shown to users
+	Region          string       `json:"region,omitempty"`
+	Zone            string       `json:"zone,omitempty"`
+	AffectedServers int          `json:"affected_servers"`
+	StartedAt       time.Time    `json:"started_at"`
+	ResolvedAt      *time.Time   `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LinkedAccountProvider is a third-party service users can link an account from
+type LinkedAccountProvider string
+
+const (
+	LinkedAccountSteam LinkedAccountProvider = "steam"
+)
+
+// LinkedAccount is a third-party account linked to a user
+type LinkedAccount struct {
+	UserID      uuid.UUID             `json:"-"`
+	Provider    LinkedAccountProvider `json:"provider"`
+	ExternalID  string                `json:"external_id"`
+	DisplayName string                `json:"display_name"`
+	LinkedAt    time.Time             `json:"linked_at"`
+}
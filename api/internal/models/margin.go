@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// MarginReport compares what nodes cost with the revenue of the servers scheduled on them.
+// Amounts are monthly, in the smallest unit of Currency.
+type MarginReport struct {
+	Currency string       `json:"currency,omitempty"`
+	Nodes    []NodeMargin `json:"nodes"`
+	Games    []GameMargin `json:"games"`
+
+	// IdleCost is the cost of nodes no billed server is scheduled on
+	IdleCost int64 `json:"idle_cost"`
+	// Billed servers that aren't scheduled on a node (e.g. stopped) earn revenue at no cost
+	UnscheduledServers int   `json:"unscheduled_servers"`
+	UnscheduledRevenue int64 `json:"unscheduled_revenue"`
+
+	TotalCost    int64 `json:"total_cost"`
+	TotalRevenue int64 `json:"total_revenue"`
+	TotalMargin  int64 `json:"total_margin"`
+
+	// Servers whose plan price couldn't be retrieved are left out of revenue
+	UnpricedServers int       `json:"unpriced_servers"`
+	GeneratedAt     time.Time `json:"generated_at"`
+}
+
+// NodeMargin is a node's cost against the revenue of the servers scheduled on it
+type NodeMargin struct {
+	Node          string   `json:"node"`
+	Provider      string   `json:"provider,omitempty"`
+	Region        string   `json:"region,omitempty"`
+	Active        bool     `json:"active"`
+	MonthlyCost   *int64   `json:"monthly_cost"` // Unset when not configured
+	Servers       int      `json:"servers"`
+	Revenue       int64    `json:"revenue"`
+	Margin        *int64   `json:"margin"`         // Unset without a cost
+	MarginPercent *float64 `json:"margin_percent"` // Of revenue; unset without a cost or revenue
+
+	// Reserved CPU shows how full the node is, i.e. how much more revenue it could take
+	AllocatableCPUMillicores *int `json:"allocatable_cpu_millicores,omitempty"`
+	ReservedCPUMillicores    int  `json:"reserved_cpu_millicores"`
+}
+
+// GameMargin is a game's revenue against its share of node costs, split by reserved CPU
+type GameMargin struct {
+	Game          GameType `json:"game"`
+	Servers       int      `json:"servers"`
+	Revenue       int64    `json:"revenue"`
+	Cost          int64    `json:"cost"`
+	Margin        int64    `json:"margin"`
+	MarginPercent *float64 `json:"margin_percent"` // Of revenue; unset without revenue
+}
+
+// SetNodeCostRequest sets a node's monthly cost; null clears it
+type SetNodeCostRequest struct {
+	MonthlyCost *int64 `json:"monthly_cost" binding:"omitempty,min=0"`
+}
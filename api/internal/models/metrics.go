@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// MetricsPoint is a server's average and peak usage over one step of a metrics range
+type MetricsPoint struct {
+	Time          time.Time `json:"time"` // Start of the step
+	CPUPercent    float64   `json:"cpu_percent"`
+	CPUPercentMax float64   `json:"cpu_percent_max"`
+	MemoryMB      int64     `json:"memory_mb"`
+	MemoryMBMax   int64     `json:"memory_mb_max"`
+	PlayersMax    *int      `json:"players_max"`
+}
+
+// ServerMetrics is a server's usage over a range, for charts. Steps without heartbeats
+// (e.g. while the server was stopped) have no point.
+type ServerMetrics struct {
+	Range           string         `json:"range"`
+	StepSeconds     int            `json:"step_seconds"`
+	CPULimitPercent *float64       `json:"cpu_limit_percent,omitempty"` // The plan's CPU, in percent of one core
+	MemoryLimitMB   *int64         `json:"memory_limit_mb,omitempty"`
+	Points          []MetricsPoint `json:"points"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserMFA is a user's TOTP second factor. It guards logins once EnabledAt is set.
+type UserMFA struct {
+	UserID           uuid.UUID
+	Secret           string
+	BackupCodeHashes []string
+	LastUsedStep     int64
+	EnabledAt        *time.Time
+	CreatedAt        time.Time
+}
+
+// Enabled reports whether logins need a code
+func (m *UserMFA) Enabled() bool {
+	return m != nil && m.EnabledAt != nil
+}
+
+// MFAStatus describes a user's second factor without its secrets
+type MFAStatus struct {
+	Enabled              bool       `json:"enabled"`
+	EnabledAt            *time.Time `json:"enabled_at,omitempty"`
+	BackupCodesRemaining int        `json:"backup_codes_remaining"`
+}
+
+// Status returns the user-facing description of the second factor
+func (m *UserMFA) Status() MFAStatus {
+	if !m.Enabled() {
+		return MFAStatus{}
+	}
+	return MFAStatus{
+		Enabled:              true,
+		EnabledAt:            m.EnabledAt,
+		BackupCodesRemaining: len(m.BackupCodeHashes),
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServerMod is a mod or plugin installed on a server from Modrinth
+type ServerMod struct {
+	ID            uuid.UUID `json:"id"`
+	ServerID      uuid.UUID `json:"server_id"`
+	ProjectID     string    `json:"project_id"`
+	VersionID     string    `json:"version_id"`
+	Name          string    `json:"name"`
+	VersionNumber string    `json:"version_number"`
+	Loader        string    `json:"loader"`
+	Path          string    `json:"path"` // File in the data volume
+	Size          int64     `json:"size"`
+	InstalledAt   time.Time `json:"installed_at"`
+}
+
+// InstallModRequest is the payload for installing a mod, or updating an installed one
+type InstallModRequest struct {
+	ProjectID string `json:"project_id" binding:"required,max=64"`  // Modrinth project ID or slug
+	VersionID string `json:"version_id" binding:"omitempty,max=64"` // Default: the newest for the server's loader and game version
+}
+
+// ModSearchQuery is the query for searching mods a server can install
+type ModSearchQuery struct {
+	Query string `form:"q" binding:"max=100"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=50"` // Default: 20
+}
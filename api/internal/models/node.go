@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NodeRegistrationToken lets the node agent join one machine as a game server node. The
+// location it carries becomes the node's labels.
+type NodeRegistrationToken struct {
+	ID           uuid.UUID  `json:"id"`
+	Region       string     `json:"region,omitempty"`
+	Zone         string     `json:"zone,omitempty"`
+	Provider     string     `json:"provider,omitempty"`
+	Datacenter   string     `json:"datacenter,omitempty"`
+	NodeName     *string    `json:"node_name,omitempty"` // Set once an agent registers with it
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RegisteredAt *time.Time `json:"registered_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CreateNodeTokenRequest is the payload for issuing a node registration token
+type CreateNodeTokenRequest struct {
+	Region     string `json:"region" binding:"omitempty,max=63"`
+	Zone       string `json:"zone" binding:"omitempty,max=63"`
+	Provider   string `json:"provider" binding:"omitempty,max=63"`
+	Datacenter string `json:"datacenter" binding:"omitempty,max=63"`
+	// ExpiresInMinutes is how long the token can be used to register (default: 60)
+	ExpiresInMinutes int `json:"expires_in_minutes" binding:"omitempty,min=1,max=1440"`
+}
+
+// RegisterNodeRequest is the node agent's payload for labeling its node
+type RegisterNodeRequest struct {
+	NodeName string `json:"node_name" binding:"required,max=253"`
+	PublicIP string `json:"public_ip" binding:"required,ip"`
+}
+
+// NodeRegistration tells the node agent what to open in the machine's firewall
+type NodeRegistration struct {
+	NodeName     string `json:"node_name"`
+	PortRangeMin int    `json:"port_range_min"`
+	PortRangeMax int    `json:"port_range_max"`
+}
+
+// PortRangeRequest is the payload for changing the host port range of all nodes
+type PortRangeRequest struct {
+	PortRangeMin int `json:"port_range_min" binding:"required,min=1024,max=65535"`
+	PortRangeMax int `json:"port_range_max" binding:"required,min=1024,max=65535,gtefield=PortRangeMin"`
+}
+
+// BlockedPort is a range of host ports game servers don't get on any node
+type BlockedPort struct {
+	ID        uuid.UUID `json:"id"`
+	MinPort   int       `json:"min_port"`
+	MaxPort   int       `json:"max_port"`
+	Protocol  *string   `json:"protocol"` // TCP or UDP, nil for both
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	// Allocated is how many of the range's ports servers still hold
+	Allocated int `json:"allocated"`
+}
+
+// BlockPortsRequest is the payload for blocking host ports
+type BlockPortsRequest struct {
+	MinPort  int    `json:"min_port" binding:"required,min=1,max=65535"`
+	MaxPort  int    `json:"max_port" binding:"omitempty,min=1,max=65535"` // Defaults to MinPort
+	Protocol string `json:"protocol" binding:"omitempty,oneof=TCP UDP"`   // Defaults to both
+	Reason   string `json:"reason" binding:"max=200"`
+}
+
+// NodeSyncState is what the last node sync did with a node
+type NodeSyncState string
+
+const (
+	NodeSynced    NodeSyncState = "synced"    // Recorded and active
+	NodeNotReady  NodeSyncState = "not_ready" // Recorded, but inactive until Kubernetes reports it ready
+	NodeReclaimed NodeSyncState = "reclaimed" // Spot node being reclaimed, inactive and its servers moved off
+	NodeSkipped   NodeSyncState = "skipped"   // Not recorded, e.g. for lack of a public IP label
+	NodeFailed    NodeSyncState = "failed"    // Recording it failed
+	NodeMissing   NodeSyncState = "missing"   // Recorded before, but gone from the cluster
+)
+
+// NodeSyncResult is what the last node sync did with one node
+type NodeSyncResult struct {
+	Name    string        `json:"name"`
+	State   NodeSyncState `json:"state"`
+	Message string        `json:"message,omitempty"`
+}
+
+// NodeSyncStatus describes the last node sync an API replica ran
+type NodeSyncStatus struct {
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Error      string           `json:"error,omitempty"` // Set if the sync failed as a whole
+	Nodes      []NodeSyncResult `json:"nodes"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OperationType is the kind of action an operation performs on a server
+type OperationType string
+
+const (
+	OperationStart   OperationType = "start"
+	OperationStop    OperationType = "stop"
+	OperationRestart OperationType = "restart"
+	OperationUpgrade OperationType = "upgrade"
+)
+
+// OperationState is the lifecycle state of an operation
+type OperationState string
+
+const (
+	OperationStateRunning    OperationState = "running"    // Background work in progress
+	OperationStateSucceeded  OperationState = "succeeded"  // Work completed
+	OperationStateFailed     OperationState = "failed"     // Work failed, see error_message
+	OperationStateSuperseded OperationState = "superseded" // Skipped because a later request changed the server's state
+)
+
+// Operation records a user-initiated action on a server and its outcome
+type Operation struct {
+	ID           uuid.UUID      `json:"id"`
+	ServerID     uuid.UUID      `json:"server_id"`
+	Type         OperationType  `json:"type"`
+	State        OperationState `json:"state"`
+	ErrorMessage *string        `json:"error_message,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	CompletedAt  *time.Time     `json:"completed_at,omitempty"`
+}
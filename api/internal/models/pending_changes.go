@@ -0,0 +1,18 @@
+package models
+
+// PendingChanges is what restarting a server would change about its running deployment
+type PendingChanges struct {
+	// Deployed is false when the server has no deployment; its next start uses the
+	// current configuration, so nothing is pending
+	Deployed        bool         `json:"deployed"`
+	RestartRequired bool         `json:"restart_required"`
+	Env             EnvDiff      `json:"env"`
+	Spec            []SpecChange `json:"spec"` // Image and resource changes
+}
+
+// SpecChange is a changed deployment field, e.g. "image" or "requests.memory"
+type SpecChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
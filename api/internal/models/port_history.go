@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PortAllocationCause is why a server's ports were allocated or released
+type PortAllocationCause string
+
+const (
+	PortCauseCreate     PortAllocationCause = "create"     // First placement of a new server
+	PortCauseRestart    PortAllocationCause = "restart"    // Placed again on a start after its ports were released
+	PortCauseReallocate PortAllocationCause = "reallocate" // Moved by the reconciler, e.g. off an inactive node
+	PortCauseRequeue    PortAllocationCause = "requeue"    // Requeued to be placed again, e.g. after its pod was evicted
+	PortCauseUpgrade    PortAllocationCause = "upgrade"    // Moved to a larger plan
+	PortCauseExpiry     PortAllocationCause = "expiry"     // Subscription ended
+	PortCauseSuspension PortAllocationCause = "suspension" // Suspended by an admin or a dispute
+	PortCauseAdmin      PortAllocationCause = "admin"      // Moved off blocked ports by an admin
+)
+
+// PortAllocationAction is whether a port was taken or given back
+type PortAllocationAction string
+
+const (
+	PortAllocated PortAllocationAction = "allocated"
+	PortReleased  PortAllocationAction = "released"
+)
+
+// PortAllocationEvent is one port a server was allocated or released
+type PortAllocationEvent struct {
+	ID        uuid.UUID            `json:"id"`
+	ServerID  uuid.UUID            `json:"server_id"`
+	NodeName  string               `json:"node_name"`
+	Port      int                  `json:"port"`
+	Protocol  string               `json:"protocol"`
+	PortName  *string              `json:"port_name,omitempty"`
+	Action    PortAllocationAction `json:"action"`
+	Cause     PortAllocationCause  `json:"cause"`
+	Detail    *string              `json:"detail,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// PortHistoryFilter narrows the port allocation history listed to operators
+type PortHistoryFilter struct {
+	ServerID string `form:"server_id" binding:"omitempty,uuid"`
+	NodeName string `form:"node" binding:"max=253"`
+	Port     int    `form:"port" binding:"omitempty,min=1,max=65535"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=500"` // Default: 100
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// PlanRecommendationDirection is whether a recommendation moves a server up or down a plan
+type PlanRecommendationDirection string
+
+const (
+	RecommendUpgrade   PlanRecommendationDirection = "upgrade"
+	RecommendDowngrade PlanRecommendationDirection = "downgrade"
+)
+
+// PlanRecommendationReason is why a plan change is recommended
+type PlanRecommendationReason string
+
+const (
+	ReasonMemoryPressure PlanRecommendationReason = "memory_pressure" // Peak memory close to the plan's limit
+	ReasonCPUPressure    PlanRecommendationReason = "cpu_pressure"    // Sustained CPU close to the plan's limit
+	ReasonUnderused      PlanRecommendationReason = "underused"       // Usage fits comfortably in the next plan down
+)
+
+// PlanRecommendation suggests a different plan based on a server's CPU and memory usage
+// over the last week
+type PlanRecommendation struct {
+	Direction       PlanRecommendationDirection `json:"direction"`
+	CurrentPlan     ServerPlan                  `json:"current_plan"`
+	RecommendedPlan ServerPlan                  `json:"recommended_plan"`
+	Reason          PlanRecommendationReason    `json:"reason"`
+	AvgCPUPercent   float64                     `json:"avg_cpu_percent"` // Percent of one core
+	P95CPUPercent   float64                     `json:"p95_cpu_percent"` // 95th percentile of hourly averages
+	AvgMemoryMB     int64                       `json:"avg_memory_mb"`
+	PeakMemoryMB    int64                       `json:"peak_memory_mb"`
+	PriceDelta      *int64                      `json:"price_delta,omitempty"` // Monthly difference in the smallest currency unit
+	Currency        string                      `json:"currency,omitempty"`
+	GeneratedAt     time.Time                   `json:"generated_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleAction is what a scheduled task does when it runs
+type ScheduleAction string
+
+const (
+	ScheduleRestart ScheduleAction = "restart" // Restart the server, as POST /servers/:id/restart does
+	ScheduleCommand ScheduleAction = "command" // Write the payload to the game console
+	ScheduleBackup  ScheduleAction = "backup"  // Back up the world, pruned by the backup policy
+)
+
+// ServerSchedule is a task that runs on a server at the times of a cron expression. Tasks
+// only run while the server is running; other runs are skipped.
+type ServerSchedule struct {
+	ID         uuid.UUID      `json:"id"`
+	ServerID   uuid.UUID      `json:"server_id"`
+	Name       string         `json:"name"`
+	Cron       string         `json:"cron"`
+	Timezone   string         `json:"timezone"`
+	Action     ScheduleAction `json:"action"`
+	Payload    *string        `json:"payload,omitempty"`
+	Enabled    bool           `json:"enabled"`
+	NextRunAt  time.Time      `json:"next_run_at"`
+	LastRunAt  *time.Time     `json:"last_run_at,omitempty"`
+	LastResult *string        `json:"last_result,omitempty"` // What the last run did, or why it was skipped
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// ServerScheduleRequest is the payload for creating or replacing a scheduled task
+type ServerScheduleRequest struct {
+	Name     string `json:"name" binding:"max=100"`
+	Cron     string `json:"cron" binding:"required,max=100"`
+	Timezone string `json:"timezone" binding:"omitempty,max=64"` // Default: UTC
+	Action   string `json:"action" binding:"required,oneof=restart command backup"`
+	Payload  string `json:"payload" binding:"required_if=Action command,max=1000"`
+	Enabled  *bool  `json:"enabled"` // Default: true
+}
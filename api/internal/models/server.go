@@ -16,6 +16,8 @@ type Server struct {
 	Plan                 ServerPlan        `json:"plan"`
 	Status               ServerStatus      `json:"status"`
 	StatusMessage        *string           `json:"status_message,omitempty"`
+	StatusReason         *StatusReason     `json:"status_reason,omitempty"`
+	StartupPhase         *StartupPhase     `json:"startup_phase,omitempty"` // Set in server details while starting
 	CreationError        *string           `json:"creation_error,omitempty"`
 	LastReconciled       *time.Time        `json:"last_reconciled,omitempty"`
 	Volumes              []ServerVolume    `json:"volumes,omitempty"`
@@ -27,7 +29,34 @@ type Server struct {
 	ExpiredAt            *time.Time        `json:"expired_at,omitempty"`
 	DeleteAfter          *time.Time        `json:"delete_after,omitempty"`
 	EnvOverrides         map[string]string `json:"env_overrides,omitempty"`
+	GroupID              *uuid.UUID        `json:"group_id,omitempty"`         // Set in server lists for grouped servers
+	Favorite             bool              `json:"favorite"`                   // Whether the requesting user marked the server as a favorite
+	LastAccessedAt       *time.Time        `json:"last_accessed_at,omitempty"` // When the requesting user last opened the server's details
 	LastHeartbeat        *time.Time        `json:"last_heartbeat,omitempty"`
+	Location             *ServerLocation   `json:"location,omitempty"`    // Set in server details once placed on a node
+	CustomGame           *CustomGame       `json:"custom_game,omitempty"` // Set in server details for custom games
+	EdgePorts            []EdgePort        `json:"edge_ports,omitempty"`  // Set in server details when the edge proxy is enabled
+	RestartRequired      bool              `json:"restart_required"`      // Set in server details: the deployment predates its current configuration
+	Namespace            string            `json:"-"`                     // K8s namespace, empty for the default namespace
+	CatalogChannel       string            `json:"-"`                     // Game catalog channel: production or staging
+}
+
+// K8sNamespace returns the namespace the server's K8s resources live in,
+// falling back to defaultNamespace for servers created without one
+func (s *Server) K8sNamespace(defaultNamespace string) string {
+	if s.Namespace != "" {
+		return s.Namespace
+	}
+	return defaultNamespace
+}
+
+// ServerLocation describes the node a server runs on, from the node's labels
+type ServerLocation struct {
+	PublicIP   string `json:"public_ip"`
+	Zone       string `json:"zone,omitempty"`
+	Region     string `json:"region,omitempty"`
+	Provider   string `json:"provider,omitempty"`
+	Datacenter string `json:"datacenter,omitempty"`
 }
 
 // ServerPort represents a single port configuration
@@ -52,6 +81,61 @@ type ServerVolume struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// StatusReason is a machine-readable cause for the current status,
+// letting clients render tailored help alongside status_message
+type StatusReason string
+
+const (
+	StatusReasonOOMKilled         StatusReason = "OOM_KILLED"         // Game process exceeded its memory limit
+	StatusReasonCrashLoop         StatusReason = "CRASH_LOOP"         // Container keeps restarting
+	StatusReasonHeartbeatTimeout  StatusReason = "HEARTBEAT_TIMEOUT"  // Supervisor stopped sending heartbeats
+	StatusReasonNoCapacity        StatusReason = "NO_CAPACITY"        // No node has room for the server
+	StatusReasonImagePullError    StatusReason = "IMAGE_PULL_ERROR"   // Game image could not be pulled
+	StatusReasonStartupTimeout    StatusReason = "STARTUP_TIMEOUT"    // Pod did not become ready in time
+	StatusReasonDeploymentMissing StatusReason = "DEPLOYMENT_MISSING" // Deployment disappeared while running
+	StatusReasonPodFailed         StatusReason = "POD_FAILED"         // Pod entered the Failed phase
+	StatusReasonInvalidConfig     StatusReason = "INVALID_CONFIG"     // Game or plan missing from the catalog or invalid, or invalid supervisor settings
+	StatusReasonDispute           StatusReason = "DISPUTE"            // Suspended: a payment for the server was disputed
+	StatusReasonAbuse             StatusReason = "ABUSE"              // Suspended: an abuse rule tripped (CPU/network anomaly or banned binary)
+	StatusReasonGameUnresponsive  StatusReason = "GAME_UNRESPONSIVE"  // Game is running but failing its health checks
+	StatusReasonPodEvicted        StatusReason = "POD_EVICTED"        // Pending: the pod was evicted or preempted, so the server is placed again
+	StatusReasonNodeReclaimed     StatusReason = "NODE_RECLAIMED"     // Pending: its spot node is being reclaimed, so the server is moved off it
+	StatusReasonRestoreFailed     StatusReason = "RESTORE_FAILED"     // Restoring a volume backup before the start failed
+	StatusReasonCloneFailed       StatusReason = "CLONE_FAILED"       // Copying a cloned server's data before the first start failed
+	StatusReasonPortBlocked       StatusReason = "PORT_BLOCKED"       // Pending: an admin blocked one of its ports, so the server gets new ones
+)
+
+// IsValid reports whether r is a known reason code
+func (r StatusReason) IsValid() bool {
+	switch r {
+	case StatusReasonOOMKilled, StatusReasonCrashLoop, StatusReasonHeartbeatTimeout,
+		StatusReasonNoCapacity, StatusReasonImagePullError, StatusReasonStartupTimeout,
+		StatusReasonDeploymentMissing, StatusReasonPodFailed, StatusReasonInvalidConfig,
+		StatusReasonGameUnresponsive:
+		return true
+	}
+	return false
+}
+
+// StartupPhase is how far a starting server has got, as inferred by its supervisor from
+// installer progress and the game's output
+type StartupPhase string
+
+const (
+	StartupPhaseInstalling      StartupPhase = "installing"       // Downloading or updating game files
+	StartupPhaseGeneratingWorld StartupPhase = "generating_world" // Loading or generating the world
+	StartupPhaseStarting        StartupPhase = "starting"         // Game process booting
+)
+
+// IsValid reports whether p is a known startup phase
+func (p StartupPhase) IsValid() bool {
+	switch p {
+	case StartupPhaseInstalling, StartupPhaseGeneratingWorld, StartupPhaseStarting:
+		return true
+	}
+	return false
+}
+
 // Server lifecycle status constants
 type ServerStatus string
 
@@ -65,6 +149,9 @@ const (
 	ServerStatusFailed   ServerStatus = "failed"   // Something went wrong during creation/runtime
 	ServerStatusDeleting ServerStatus = "deleting" // Hard delete in progress, PVC being deleted
 	ServerStatusDeleted  ServerStatus = "deleted"  // All resources cleaned up, ready for DB deletion
+
+	// Suspended pending admin review (pod deleted, PVC preserved); can't be started until lifted
+	ServerStatusSuspended ServerStatus = "suspended"
 )
 
 // Game type constants
@@ -75,6 +162,9 @@ const (
 	GameValheim   GameType = "valheim"
 	GameRust      GameType = "rust"
 	GameARK       GameType = "ark"
+
+	// GameCustom servers run a user-supplied image; see CustomGame
+	GameCustom GameType = "custom"
 )
 
 // Server plan constants (for future billing tiers)
@@ -84,14 +174,78 @@ const (
 	PlanSmall  ServerPlan = "small"
 	PlanMedium ServerPlan = "medium"
 	PlanLarge  ServerPlan = "large"
+
+	// PlanDedicated runs the server alone on a dedicated node
+	PlanDedicated ServerPlan = "dedicated"
+
+	// PlanBudget runs the server on spot nodes, which may be reclaimed at short notice
+	PlanBudget ServerPlan = "budget"
 )
 
+// planOrder lists plans from smallest to largest
+var planOrder = []ServerPlan{PlanSmall, PlanMedium, PlanLarge}
+
+// LargerPlans returns the plans above p, smallest first
+func (p ServerPlan) LargerPlans() []ServerPlan {
+	for i, plan := range planOrder {
+		if plan == p {
+			return planOrder[i+1:]
+		}
+	}
+	return nil
+}
+
+// SmallerPlans returns the plans below p, largest first
+func (p ServerPlan) SmallerPlans() []ServerPlan {
+	for i, plan := range planOrder {
+		if plan == p {
+			smaller := make([]ServerPlan, 0, i)
+			for j := i - 1; j >= 0; j-- {
+				smaller = append(smaller, planOrder[j])
+			}
+			return smaller
+		}
+	}
+	return nil
+}
+
+// PlanUpgradeRecommendation suggests the next plan up after a server was OOM killed
+type PlanUpgradeRecommendation struct {
+	CurrentPlan       ServerPlan `json:"current_plan"`
+	RecommendedPlan   ServerPlan `json:"recommended_plan"`
+	CurrentMemory     string     `json:"current_memory"`
+	RecommendedMemory string     `json:"recommended_memory"`
+	PriceDelta        *int64     `json:"price_delta,omitempty"` // Monthly difference in the smallest currency unit
+	Currency          string     `json:"currency,omitempty"`
+}
+
 // CreateServerRequest is the payload for creating a new server
 type CreateServerRequest struct {
 	DisplayName string `json:"display_name" binding:"omitempty,min=3,max=50"` // Optional
 	Subdomain   string `json:"subdomain" binding:"required,min=3,max=50,dns"`
-	Game        string `json:"game" binding:"required,oneof=minecraft valheim"`
-	Plan        string `json:"plan" binding:"required,oneof=small medium large"`
+	Game        string `json:"game" binding:"required,oneof=minecraft valheim custom"`
+	Plan        string `json:"plan" binding:"required,oneof=small medium large dedicated budget"`
+
+	// CustomGame is required when Game is "custom"
+	CustomGame *CustomGame `json:"custom_game" binding:"omitempty"`
+
+	// PreferredPort is the port number the game port gets if it's free on some node, on plans
+	// with vanity ports (0 = any)
+	PreferredPort int `json:"preferred_port" binding:"omitempty,min=1024,max=65535"`
+
+	UseSavedCard bool `json:"use_saved_card"` // Charge the saved card instead of redirecting to Checkout
+
+	// WaitlistID is the capacity waitlist signup whose offer link led to this checkout
+	WaitlistID string `json:"waitlist_id" binding:"omitempty,uuid"`
+}
+
+// PortAvailabilityQuery asks whether a preferred port number is free for a new server.
+// Custom games name the protocol of their game port, which the catalog doesn't know.
+type PortAvailabilityQuery struct {
+	Game     string `form:"game" binding:"required,oneof=minecraft valheim custom"`
+	Plan     string `form:"plan" binding:"required,oneof=small medium large dedicated budget"`
+	Port     int    `form:"port" binding:"required,min=1024,max=65535"`
+	Protocol string `form:"protocol" binding:"omitempty,oneof=TCP UDP"`
 }
 
 // UpdateServerRequest is the payload for updating server details
@@ -101,17 +255,60 @@ type UpdateServerRequest struct {
 
 // ServerListResponse is the response for listing servers
 type ServerListResponse struct {
-	Servers []Server `json:"servers"`
-	Total   int      `json:"total"`
+	Servers []Server      `json:"servers"` // Grouped servers in their order within the group
+	Groups  []ServerGroup `json:"groups"`
+	Total   int           `json:"total"`
+}
+
+// ServerSort is the order of a server list
+type ServerSort string
+
+const (
+	ServerSortDefault   ServerSort = ""          // Group order, then newest first
+	ServerSortRecent    ServerSort = "recent"    // Most recently opened first, never opened last
+	ServerSortFavorites ServerSort = "favorites" // Favorites first, each part most recently opened first
+)
+
+// SetServerFavoriteRequest is the payload for marking or unmarking a favorite server
+type SetServerFavoriteRequest struct {
+	Favorite *bool `json:"favorite" binding:"required"`
 }
 
 // UpdateServerEnvRequest is the payload for updating server environment variables
 type UpdateServerEnvRequest struct {
 	EnvOverrides map[string]string `json:"env_overrides" binding:"required"`
+	// Reload applies the change to the running server without a redeploy, for games
+	// that support live reload (see GameConfigInfo.LiveReload)
+	Reload bool `json:"reload"`
+}
+
+// DeleteServerRequest is the payload for deleting a server
+type DeleteServerRequest struct {
+	Confirmation string `json:"confirmation" binding:"required"` // Must match the server's subdomain
 }
 
 // GameConfigInfo contains game configuration details for the API response
 type GameConfigInfo struct {
 	DefaultEnv   map[string]string `json:"default_env"`
 	EffectiveEnv map[string]string `json:"effective_env"`
+	LiveReload   bool              `json:"live_reload"` // Env changes can be applied without a restart
+
+	// SteamTokenEnv is the env var the server reads its Steam game server login token from,
+	// which can be created with the owner's linked Steam account (empty when not needed)
+	SteamTokenEnv string `json:"steam_token_env,omitempty"`
+}
+
+// SetCatalogChannelRequest is the payload for moving a server to a game catalog channel
+type SetCatalogChannelRequest struct {
+	Channel string `json:"channel" binding:"required"`
+	Game    string `json:"game"` // Optional: switch to a game defined in the channel's catalog
+}
+
+// ServerCapabilities are the actions the owner can currently take on a server
+type ServerCapabilities struct {
+	CanStart       bool `json:"can_start"`
+	CanStop        bool `json:"can_stop"`
+	CanRestart     bool `json:"can_restart"`
+	CanEditEnv     bool `json:"can_edit_env"`
+	CanResubscribe bool `json:"can_resubscribe"`
 }
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServerGroup is a user-defined folder for organizing servers in the dashboard
+type ServerGroup struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"-"`
+	Name      string    `json:"name"`
+	Position  int       `json:"position"` // Groups are listed by position, ascending
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Set when listing servers, from the group's servers that aren't being deleted
+	ServerCount  int                  `json:"server_count"`
+	StatusCounts map[ServerStatus]int `json:"status_counts,omitempty"`
+	Status       GroupStatus          `json:"status,omitempty"`
+}
+
+// GroupStatus summarizes the statuses of a group's servers
+type GroupStatus string
+
+const (
+	GroupStatusEmpty     GroupStatus = "empty"     // The group has no servers
+	GroupStatusRunning   GroupStatus = "running"   // Every server is running
+	GroupStatusPartial   GroupStatus = "partial"   // Some servers are running or starting, others aren't
+	GroupStatusStopped   GroupStatus = "stopped"   // No server is running or starting
+	GroupStatusAttention GroupStatus = "attention" // A server failed, expired or is suspended
+)
+
+// CreateServerGroupRequest is the payload for creating a server group
+type CreateServerGroupRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=50"`
+}
+
+// UpdateServerGroupRequest is the payload for renaming a server group
+type UpdateServerGroupRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=50"`
+}
+
+// ReorderServerGroupsRequest lists all of a user's groups in their new order
+type ReorderServerGroupsRequest struct {
+	GroupIDs []string `json:"group_ids" binding:"max=50,dive,uuid"`
+}
+
+// SetGroupServersRequest lists the servers a group should hold, in order. Servers moved in
+// leave their previous group; servers left out are ungrouped.
+type SetGroupServersRequest struct {
+	ServerIDs []string `json:"server_ids" binding:"max=500,dive,uuid"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ServerQuery is the public "is this server online" answer served from cached supervisor
+// data instead of the game's query port
+type ServerQuery struct {
+	Subdomain string    `json:"subdomain"`
+	Name      string    `json:"name"`
+	Game      GameType  `json:"game"`
+	Online    bool      `json:"online"`
+	Players   *int      `json:"players,omitempty"` // Only set when online and the game reports players
+	CheckedAt time.Time `json:"checked_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServerSession is a period the server's game was online: from entering running until it
+// left running, or until its last heartbeat if they stopped first
+type ServerSession struct {
+	ID              uuid.UUID     `json:"id"`
+	StartedAt       time.Time     `json:"started_at"`
+	LastSeenAt      time.Time     `json:"last_seen_at"`         // Last heartbeat
+	EndedAt         *time.Time    `json:"ended_at,omitempty"`   // Unset while online
+	EndStatus       *ServerStatus `json:"end_status,omitempty"` // Unset if its heartbeats stopped
+	DurationSeconds int64         `json:"duration_seconds"`     // So far, while online
+}
+
+// ServerSessionSummary totals a server's online time over a range
+type ServerSessionSummary struct {
+	Range         string     `json:"range"`
+	Since         time.Time  `json:"since"`
+	OnlineSeconds int64      `json:"online_seconds"`
+	UptimePercent float64    `json:"uptime_percent"`
+	BillableHours int64      `json:"billable_hours"` // Online time per session rounded up to whole hours
+	LastSeenAt    *time.Time `json:"last_seen_at,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// SpendLimit is a user's monthly spending cap together with their cached projected spend
+type SpendLimit struct {
+	LimitCents     *int64     `json:"limit_cents"`
+	BlockCheckout  bool       `json:"block_checkout"`
+	ProjectedCents *int64     `json:"projected_cents"`
+	Currency       string     `json:"currency,omitempty"`
+	ProjectedAt    *time.Time `json:"projected_at,omitempty"`
+}
+
+// Exceeded reports whether adding additionalCents to the projected spend would go over the limit
+func (l *SpendLimit) Exceeded(additionalCents int64) bool {
+	if l.LimitCents == nil {
+		return false
+	}
+	var projected int64
+	if l.ProjectedCents != nil {
+		projected = *l.ProjectedCents
+	}
+	return projected+additionalCents > *l.LimitCents
+}
+
+// UpdateSpendLimitRequest is the payload for setting a user's spending cap.
+// A null limit_cents removes the limit.
+type UpdateSpendLimitRequest struct {
+	LimitCents    *int64 `json:"limit_cents" binding:"omitempty,min=0"`
+	BlockCheckout bool   `json:"block_checkout"`
+}
@@ -8,18 +8,24 @@ import (
 
 // PendingServerRequest represents a server creation request waiting for payment
 type PendingServerRequest struct {
-	ID              uuid.UUID     `json:"id"`
-	UserID          uuid.UUID     `json:"user_id"`
-	DisplayName     *string       `json:"display_name,omitempty"`
-	Subdomain       string        `json:"subdomain"`
-	Game            string        `json:"game"`
-	Plan            string        `json:"plan"`
-	StripeSessionID *string       `json:"stripe_session_id,omitempty"`
-	Status          PaymentStatus `json:"status"` // awaiting_payment, completed, failed, expired
-	ServerID        *uuid.UUID    `json:"server_id,omitempty"`
-	CreatedAt       time.Time     `json:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at"`
-	ExpiresAt       time.Time     `json:"expires_at"`
+	ID                uuid.UUID          `json:"id"`
+	UserID            uuid.UUID          `json:"user_id"`
+	DisplayName       *string            `json:"display_name,omitempty"`
+	Subdomain         string             `json:"subdomain"`
+	Game              string             `json:"game"`
+	Plan              string             `json:"plan"`
+	StripeSessionID   *string            `json:"stripe_session_id,omitempty"`
+	Status            PaymentStatus      `json:"status"` // awaiting_payment, completed, failed, expired
+	ServerID          *uuid.UUID         `json:"server_id,omitempty"`
+	EnvOverrides      map[string]string  `json:"env_overrides,omitempty"`      // Applied to the server once created, e.g. from a template
+	CustomGame        *CustomGame        `json:"custom_game,omitempty"`        // Definition for servers of the custom game type
+	PreferredPort     int                `json:"preferred_port,omitempty"`     // Port number the game port is allocated first
+	CloneSourceID     *uuid.UUID         `json:"clone_source_id,omitempty"`    // Server whose data the new server starts with
+	TemplateMods      []TemplateMod      `json:"template_mods,omitempty"`      // Installed once the server runs, from a template
+	TemplateSchedules []TemplateSchedule `json:"template_schedules,omitempty"` // Scheduled tasks the server starts with, from a template
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	ExpiresAt         time.Time          `json:"expires_at"`
 }
 
 type PaymentStatus string
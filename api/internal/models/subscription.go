@@ -22,9 +22,71 @@ type ServerSubscription struct {
 	Subscription *SubscriptionInfo `json:"subscription,omitempty"`
 	ExpiredAt    *time.Time        `json:"expired_at,omitempty"`
 	DeleteAfter  *time.Time        `json:"delete_after,omitempty"`
+
+	// AutoCancelWhenStopped cancels the subscription once the server has been stopped long enough
+	AutoCancelWhenStopped bool `json:"auto_cancel_when_stopped"`
+}
+
+// SetAutoCancelRequest is the payload for toggling auto-cancel of a stopped server's subscription
+type SetAutoCancelRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// ReminderCancelRequest is the payload for cancelling a subscription from the signed link
+// in a stopped-server reminder email
+type ReminderCancelRequest struct {
+	Expires   int64  `json:"expires" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// ResubscribeRequest is the optional payload for resubscribing an expired server
+type ResubscribeRequest struct {
+	UseSavedCard bool `json:"use_saved_card"` // Charge the saved card instead of redirecting to Checkout
+}
+
+// SavedCard describes a card saved on the user's Stripe customer
+type SavedCard struct {
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int64  `json:"exp_month"`
+	ExpYear  int64  `json:"exp_year"`
+}
+
+// PaymentMethodResponse is the response for the user's saved payment method.
+// Card is null when the user has to pay through Checkout.
+type PaymentMethodResponse struct {
+	Card *SavedCard `json:"card"`
 }
 
 // BillingResponse is the response for the billing page
 type BillingResponse struct {
 	Subscriptions []ServerSubscription `json:"subscriptions"`
 }
+
+// Invoice is a Stripe invoice of the user's subscriptions
+type Invoice struct {
+	ID         string        `json:"id"`
+	Number     string        `json:"number,omitempty"`
+	Status     string        `json:"status"` // draft, open, paid, uncollectible, void
+	Currency   string        `json:"currency"`
+	Total      int64         `json:"total"` // In the smallest currency unit
+	AmountPaid int64         `json:"amount_paid"`
+	CreatedAt  time.Time     `json:"created_at"`
+	URL        string        `json:"url,omitempty"` // Stripe-hosted invoice page
+	Lines      []InvoiceLine `json:"lines"`
+}
+
+// InvoiceLine is a charge on an invoice, with the server it's for when known
+type InvoiceLine struct {
+	Description string     `json:"description"`
+	Amount      int64      `json:"amount"`
+	PeriodStart *time.Time `json:"period_start,omitempty"`
+	PeriodEnd   *time.Time `json:"period_end,omitempty"`
+	ServerID    string     `json:"server_id,omitempty"`
+	Subdomain   string     `json:"subdomain,omitempty"`
+}
+
+// InvoicesResponse is the response for the user's invoice history
+type InvoicesResponse struct {
+	Invoices []Invoice `json:"invoices"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServerTemplate is a saved server configuration new servers can be created from
+type ServerTemplate struct {
+	ID           uuid.UUID          `json:"id"`
+	UserID       uuid.UUID          `json:"-"`
+	Name         string             `json:"name"`
+	Description  string             `json:"description"`
+	Game         GameType           `json:"game"`
+	Plan         ServerPlan         `json:"plan"`
+	EnvOverrides map[string]string  `json:"env_overrides,omitempty"`
+	Mods         []TemplateMod      `json:"mods"`
+	Schedules    []TemplateSchedule `json:"schedules"`
+	IsPublic     bool               `json:"is_public"`
+	IsOwner      bool               `json:"is_owner"` // Whether the requesting user owns the template
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// TemplateMod is a mod servers created from a template install, pinned to the version the
+// template's server had
+type TemplateMod struct {
+	ProjectID string `json:"project_id"` // Modrinth project
+	VersionID string `json:"version_id"`
+	Name      string `json:"name"`
+}
+
+// TemplateSchedule is a scheduled task servers created from a template start with
+type TemplateSchedule struct {
+	Name     string         `json:"name"`
+	Cron     string         `json:"cron"`
+	Timezone string         `json:"timezone"`
+	Action   ScheduleAction `json:"action"`
+	Payload  *string        `json:"payload,omitempty"`
+	Enabled  bool           `json:"enabled"`
+}
+
+// CreateTemplateRequest is the payload for saving a server's configuration as a template
+type CreateTemplateRequest struct {
+	ServerID    string `json:"server_id" binding:"required,uuid"`
+	Name        string `json:"name" binding:"required,min=3,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+// UpdateTemplateRequest is the payload for updating a template's details
+type UpdateTemplateRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=3,max=100"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=1000"`
+	IsPublic    *bool   `json:"is_public,omitempty"`
+}
+
+// CreateServerFromTemplateRequest is the payload for creating a server from a template.
+// Game, plan, env, mods and scheduled tasks come from the template.
+type CreateServerFromTemplateRequest struct {
+	DisplayName string `json:"display_name" binding:"omitempty,min=3,max=50"`
+	Subdomain   string `json:"subdomain" binding:"required,min=3,max=50,dns"`
+
+	UseSavedCard bool `json:"use_saved_card"`
+}
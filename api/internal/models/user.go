@@ -6,28 +6,48 @@ import (
 	"github.com/google/uuid"
 )
 
+// UserRole is what a user may do beyond managing their own servers
+type UserRole string
+
+const (
+	UserRoleUser  UserRole = "user"
+	UserRoleAdmin UserRole = "admin" // Can use the /admin API
+)
+
 type User struct {
 	ID               uuid.UUID `json:"id"`
 	Email            string    `json:"email"`
 	PasswordHash     string    `json:"-"`
 	EmailVerified    bool      `json:"email_verified"`
 	StripeCustomerID *string   `json:"stripe_customer_id,omitempty"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	Role             UserRole  `json:"role"`
+
+	// Suspended accounts are read-only: they can't start servers or check out until reinstated
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspensionReason *string    `json:"suspension_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type UserResponse struct {
-	ID            string    `json:"id"`
-	Email         string    `json:"email"`
-	EmailVerified bool      `json:"email_verified"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID               string     `json:"id"`
+	Email            string     `json:"email"`
+	EmailVerified    bool       `json:"email_verified"`
+	Role             UserRole   `json:"role"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspensionReason *string    `json:"suspension_reason,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
 }
 
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		ID:            u.ID.String(),
-		Email:         u.Email,
-		EmailVerified: u.EmailVerified,
-		CreatedAt:     u.CreatedAt,
+		ID:               u.ID.String(),
+		Email:            u.Email,
+		EmailVerified:    u.EmailVerified,
+		Role:             u.Role,
+		SuspendedAt:      u.SuspendedAt,
+		SuspensionReason: u.SuspensionReason,
+		CreatedAt:        u.CreatedAt,
 	}
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEvent is a type of event a user webhook can subscribe to
+type WebhookEvent string
+
+const (
+	WebhookServerStatusChanged WebhookEvent = "server.status_changed" // Any status transition
+	WebhookServerCrashed       WebhookEvent = "server.crashed"        // Transition to failed
+	WebhookBackupCompleted     WebhookEvent = "backup.completed"
+	WebhookBackupFailed        WebhookEvent = "backup.failed"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to
+var WebhookEvents = []WebhookEvent{
+	WebhookServerStatusChanged,
+	WebhookServerCrashed,
+	WebhookBackupCompleted,
+	WebhookBackupFailed,
+}
+
+// Webhook is an outgoing webhook a user registered for a server
+type Webhook struct {
+	ID        uuid.UUID      `json:"id"`
+	ServerID  uuid.UUID      `json:"server_id"`
+	URL       string         `json:"url"`
+	Secret    string         `json:"-"` // Only returned once, when the webhook is created
+	Events    []WebhookEvent `json:"events"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// WebhookDeliveryState is the delivery state of a webhook payload
+type WebhookDeliveryState string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryState = "pending" // Queued or waiting for a retry
+	WebhookDeliveryDelivered WebhookDeliveryState = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryState = "failed" // Retries exhausted
+)
+
+// WebhookDelivery is one payload sent, or to be sent, to a webhook
+type WebhookDelivery struct {
+	ID             uuid.UUID            `json:"id"`
+	WebhookID      uuid.UUID            `json:"webhook_id"`
+	Event          WebhookEvent         `json:"event"`
+	Payload        json.RawMessage      `json:"payload"`
+	State          WebhookDeliveryState `json:"state"`
+	Attempts       int                  `json:"attempts"`
+	ResponseStatus *int                 `json:"response_status,omitempty"`
+	LastError      *string              `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time            `json:"next_attempt_at"`
+	CreatedAt      time.Time            `json:"created_at"`
+	DeliveredAt    *time.Time           `json:"delivered_at,omitempty"`
+}
+
+// CreateWebhookRequest is the payload for registering a server webhook. The secret is
+// generated when omitted.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2000"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=server.status_changed server.crashed backup.completed backup.failed"`
+}
@@ -0,0 +1,177 @@
+package abuse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/account"
+	"github.com/mooncorn/gshub/api/internal/services/broadcast"
+	"github.com/mooncorn/gshub/api/internal/services/email"
+	"github.com/mooncorn/gshub/api/internal/services/suspension"
+	"go.uber.org/zap"
+)
+
+// Config holds the anomaly rule thresholds
+type Config struct {
+	CPUPercent   int           // Game process CPU, percent of one core (0 disables)
+	NetTxMbps    int           // Outbound traffic in Mbit/s (0 disables)
+	SustainedFor time.Duration // How long a threshold must be exceeded before suspending
+}
+
+// Service evaluates supervisor heartbeats and reports against the abuse rules and
+// suspends offending servers until an admin reviews them
+type Service struct {
+	db          *database.DB
+	suspension  *suspension.Service
+	account     *account.Service
+	email       *email.Service
+	hub         *broadcast.Hub
+	adminEmails []string
+	config      Config
+	logger      *zap.Logger
+}
+
+// NewService creates a new abuse detection service
+func NewService(db *database.DB, suspensionService *suspension.Service, accountService *account.Service, emailService *email.Service, hub *broadcast.Hub, appConfig *config.Config, logger *zap.Logger) *Service {
+	return &Service{
+		db:          db,
+		suspension:  suspensionService,
+		account:     accountService,
+		email:       emailService,
+		hub:         hub,
+		adminEmails: appConfig.AdminEmails,
+		config: Config{
+			CPUPercent:   appConfig.AbuseCPUPercent,
+			NetTxMbps:    appConfig.AbuseNetTxMbps,
+			SustainedFor: appConfig.AbuseSustainedFor,
+		},
+		logger: logger,
+	}
+}
+
+// CheckHeartbeat applies the CPU and network rules to a heartbeat. netTxBytes is the
+// supervisor's cumulative outbound byte counter; a rate is computed against the previous
+// heartbeat. A server is suspended once a rule has been exceeded for SustainedFor.
+func (s *Service) CheckHeartbeat(ctx context.Context, serverID string, cpuPercent float64, netTxBytes int64) error {
+	if s.config.CPUPercent <= 0 && s.config.NetTxMbps <= 0 {
+		return nil
+	}
+
+	sample, err := s.db.GetAbuseSample(ctx, serverID)
+	if err != nil {
+		return err
+	}
+	if sample.Exempt {
+		return nil
+	}
+
+	now := time.Now()
+
+	var cpuSince *time.Time
+	if s.config.CPUPercent > 0 && cpuPercent >= float64(s.config.CPUPercent) {
+		cpuSince = sinceOrNow(sample.CPUAnomalySince, now)
+	}
+
+	// Counters reset when the game restarts; skip the rate for that sample
+	var netSince *time.Time
+	var txMbps float64
+	if sample.NetTxBytes != nil && sample.NetSampledAt != nil && netTxBytes >= *sample.NetTxBytes {
+		if elapsed := now.Sub(*sample.NetSampledAt).Seconds(); elapsed > 0 {
+			txMbps = float64(netTxBytes-*sample.NetTxBytes) * 8 / 1e6 / elapsed
+		}
+		if s.config.NetTxMbps > 0 && txMbps >= float64(s.config.NetTxMbps) {
+			netSince = sinceOrNow(sample.NetAnomalySince, now)
+		}
+	}
+
+	if err := s.db.UpdateAbuseSample(ctx, serverID, netTxBytes, now, cpuSince, netSince); err != nil {
+		return err
+	}
+
+	switch {
+	case cpuSince != nil && now.Sub(*cpuSince) >= s.config.SustainedFor:
+		return s.suspend(ctx, serverID, models.AbuseRuleCPU,
+			fmt.Sprintf("CPU at %.0f%% for %s (limit %d%%)", cpuPercent, now.Sub(*cpuSince).Round(time.Minute), s.config.CPUPercent))
+	case netSince != nil && now.Sub(*netSince) >= s.config.SustainedFor:
+		return s.suspend(ctx, serverID, models.AbuseRuleNetwork,
+			fmt.Sprintf("sending %.0f Mbit/s for %s (limit %d Mbit/s)", txMbps, now.Sub(*netSince).Round(time.Minute), s.config.NetTxMbps))
+	}
+	return nil
+}
+
+// ReportBannedBinary handles a supervisor finding a banned binary before starting the game.
+// The hash is checked against the list, so supervisors can't suspend servers with made-up
+// reports. Returns false if the hash isn't banned.
+func (s *Service) ReportBannedBinary(ctx context.Context, serverID, path, sha256 string) (bool, error) {
+	name, banned, err := s.db.GetBannedHashName(ctx, strings.ToLower(sha256))
+	if err != nil || !banned {
+		return false, err
+	}
+
+	return true, s.suspend(ctx, serverID, models.AbuseRuleBannedBinary,
+		fmt.Sprintf("%s is banned binary %q (sha256 %s)", path, name, sha256))
+}
+
+// suspend suspends a server for abuse, records why and alerts admins
+func (s *Service) suspend(ctx context.Context, serverID, rule, detail string) error {
+	server, err := s.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+
+	message := "Suspended: abuse detected, pending review"
+	suspended, err := s.suspension.Suspend(ctx, server, models.StatusReasonAbuse, message)
+	if err != nil {
+		return fmt.Errorf("failed to suspend server: %w", err)
+	}
+	if !suspended {
+		return nil
+	}
+
+	if err := s.db.CreateAbuseReport(ctx, serverID, rule, detail); err != nil {
+		s.logger.Error("failed to record abuse report", zap.Error(err), zap.String("server_id", serverID))
+	} else if err := s.account.CheckRepeatOffender(ctx, server.UserID); err != nil {
+		s.logger.Error("failed to check repeat abuse", zap.Error(err), zap.String("user_id", server.UserID.String()))
+	}
+
+	s.logger.Warn("server suspended for abuse",
+		zap.String("server_id", serverID),
+		zap.String("rule", rule),
+		zap.String("detail", detail))
+
+	s.hub.Publish(server.UserID, broadcast.StatusEvent{
+		ServerID:      serverID,
+		Status:        string(models.ServerStatusSuspended),
+		StatusMessage: &message,
+		StatusReason:  string(models.StatusReasonAbuse),
+		Timestamp:     time.Now().UTC(),
+	})
+
+	userEmail := "unknown"
+	if user, err := s.db.GetUserByID(ctx, server.UserID); err == nil {
+		userEmail = user.Email
+	}
+	for _, admin := range s.adminEmails {
+		if admin = strings.TrimSpace(admin); admin == "" {
+			continue
+		}
+		if err := s.email.SendAbuseAlertEmail(admin, serverID, userEmail, rule, detail); err != nil {
+			s.logger.Warn("failed to send abuse alert", zap.Error(err), zap.String("admin", admin))
+		}
+	}
+
+	return nil
+}
+
+// sinceOrNow keeps an anomaly's existing start time, or starts it now
+func sinceOrNow(since *time.Time, now time.Time) *time.Time {
+	if since != nil {
+		return since
+	}
+	return &now
+}
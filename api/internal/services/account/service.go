@@ -0,0 +1,138 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/email"
+)
+
+// Reasons recorded when an account is suspended automatically
+const (
+	ReasonRepeatedDisputes = "Repeated payment disputes"
+	ReasonRepeatedAbuse    = "Repeated abuse"
+)
+
+// Service suspends and reinstates accounts. A suspended account is read-only: its owner can
+// still sign in, view servers and billing, stop servers and cancel subscriptions, but can't
+// start servers or buy anything until an admin reinstates it.
+type Service struct {
+	db                *database.DB
+	email             *email.Service
+	adminEmails       []string
+	disputesThreshold int
+	abuseThreshold    int
+}
+
+// NewService creates a new account service
+func NewService(db *database.DB, emailService *email.Service, cfg *config.Config) *Service {
+	return &Service{
+		db:                db,
+		email:             emailService,
+		adminEmails:       cfg.AdminEmails,
+		disputesThreshold: cfg.AccountSuspendDisputes,
+		abuseThreshold:    cfg.AccountSuspendAbuse,
+	}
+}
+
+// IsSuspended reports whether an account is suspended
+func (s *Service) IsSuspended(ctx context.Context, userID string) (bool, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return false, fmt.Errorf("invalid user ID: %w", err)
+	}
+	return s.db.IsUserSuspended(ctx, id)
+}
+
+// Suspend makes an account read-only and tells its owner why. Returns false if the account
+// was already suspended.
+func (s *Service) Suspend(ctx context.Context, userID uuid.UUID, reason string) (bool, error) {
+	suspended, err := s.db.SuspendUser(ctx, userID, reason)
+	if err != nil || !suspended {
+		return false, err
+	}
+
+	log.Printf("Account suspended: user_id=%s reason=%q", userID, reason)
+
+	if user, err := s.db.GetUserByID(ctx, userID); err == nil {
+		if err := s.email.SendAccountSuspendedEmail(user.Email, reason); err != nil {
+			log.Printf("Failed to send account suspended email: user_id=%s error=%v", userID, err)
+		}
+	}
+	return true, nil
+}
+
+// Reinstate lifts an account suspension and tells its owner. Returns false if the account
+// wasn't suspended.
+func (s *Service) Reinstate(ctx context.Context, userID uuid.UUID) (bool, error) {
+	reinstated, err := s.db.ReinstateUser(ctx, userID)
+	if err != nil || !reinstated {
+		return false, err
+	}
+
+	log.Printf("Account reinstated: user_id=%s", userID)
+
+	if user, err := s.db.GetUserByID(ctx, userID); err == nil {
+		if err := s.email.SendAccountReinstatedEmail(user.Email); err != nil {
+			log.Printf("Failed to send account reinstated email: user_id=%s error=%v", userID, err)
+		}
+	}
+	return true, nil
+}
+
+// RequestReinstatement records a suspended user's appeal and forwards it to the admins.
+// Returns false if the account isn't suspended.
+func (s *Service) RequestReinstatement(ctx context.Context, user *models.User, message string) (bool, error) {
+	requested, err := s.db.RequestReinstatement(ctx, user.ID, message)
+	if err != nil || !requested {
+		return false, err
+	}
+
+	reason := ""
+	if user.SuspensionReason != nil {
+		reason = *user.SuspensionReason
+	}
+	for _, admin := range s.adminEmails {
+		if admin = strings.TrimSpace(admin); admin == "" {
+			continue
+		}
+		if err := s.email.SendReinstatementRequestEmail(admin, user.Email, user.ID.String(), reason, message); err != nil {
+			log.Printf("Failed to send reinstatement request: user_id=%s admin=%s error=%v", user.ID, admin, err)
+		}
+	}
+	return true, nil
+}
+
+// CheckRepeatOffender suspends an account once it reaches the configured number of payment
+// disputes or abuse suspensions. Called after each new dispute or abuse report.
+func (s *Service) CheckRepeatOffender(ctx context.Context, userID uuid.UUID) error {
+	if s.disputesThreshold > 0 {
+		disputes, err := s.db.CountUserDisputes(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if disputes >= s.disputesThreshold {
+			_, err := s.Suspend(ctx, userID, ReasonRepeatedDisputes)
+			return err
+		}
+	}
+
+	if s.abuseThreshold > 0 {
+		reports, err := s.db.CountUserAbuseReports(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if reports >= s.abuseThreshold {
+			_, err := s.Suspend(ctx, userID, ReasonRepeatedAbuse)
+			return err
+		}
+	}
+
+	return nil
+}
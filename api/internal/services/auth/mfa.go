@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// mfaTokenTTL is how long a login that passed the password check has to enter its code
+const mfaTokenTTL = 5 * time.Minute
+
+var (
+	// ErrMFAInvalidCode is returned for codes that don't match, or were already used
+	ErrMFAInvalidCode = errors.New("invalid verification code")
+	// ErrMFAAlreadyEnabled is returned when enrolling a user whose second factor is enabled
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrMFANotEnrolled is returned when confirming without a pending enrollment
+	ErrMFANotEnrolled = errors.New("two-factor authentication setup was not started")
+)
+
+// GetMFA returns the user's second factor, or nil if they have none
+func (s *Service) GetMFA(ctx context.Context, userID string) (*models.UserMFA, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+	return s.db.GetUserMFA(ctx, parsedUserID)
+}
+
+// StartMFAEnrollment generates a TOTP secret for the user to add to their authenticator app,
+// and returns it with its otpauth:// URI. It guards logins once confirmed with EnableMFA.
+func (s *Service) StartMFAEnrollment(ctx context.Context, user *models.User) (string, string, error) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	started, err := s.db.StartUserMFAEnrollment(ctx, user.ID, secret)
+	if err != nil {
+		return "", "", err
+	}
+	if !started {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+	return secret, totpURL(s.config.MFAIssuer, user.Email, secret), nil
+}
+
+// EnableMFA confirms the user's enrollment with a code from their app and returns their
+// backup codes, which are only shown this once
+func (s *Service) EnableMFA(ctx context.Context, userID string, code string) ([]string, error) {
+	mfa, err := s.GetMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.Enabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if mfa == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := matchTOTP(mfa.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrMFAInvalidCode
+	}
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate backup codes: %w", err)
+	}
+	enabled, err := s.db.EnableUserMFA(ctx, mfa.UserID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrMFAInvalidCode
+	}
+	return codes, nil
+}
+
+// VerifyMFA checks a code from the user's app, or one of their backup codes, and uses it up.
+// Returns ErrMFAInvalidCode if it doesn't match.
+func (s *Service) VerifyMFA(ctx context.Context, userID string, code string) error {
+	mfa, err := s.GetMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !mfa.Enabled() {
+		return ErrMFAInvalidCode
+	}
+
+	if step, ok := matchTOTP(mfa.Secret, code, time.Now()); ok {
+		used, err := s.db.UseUserMFAStep(ctx, mfa.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrMFAInvalidCode
+		}
+		return nil
+	}
+
+	used, err := s.db.UseUserMFABackupCode(ctx, mfa.UserID, hashBackupCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrMFAInvalidCode
+	}
+	return nil
+}
+
+// RegenerateBackupCodes replaces the user's backup codes and returns the new ones
+func (s *Service) RegenerateBackupCodes(ctx context.Context, userID string) ([]string, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate backup codes: %w", err)
+	}
+	if err := s.db.ReplaceUserMFABackupCodes(ctx, parsedUserID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA removes the user's second factor
+func (s *Service) DisableMFA(ctx context.Context, userID string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+	return s.db.DeleteUserMFA(ctx, parsedUserID)
+}
+
+// GenerateMFAToken generates the short-lived token a login that passed the password check
+// continues with once the user enters their code. It's scoped to scope, which must allow
+// no routes.
+func (s *Service) GenerateMFAToken(user *models.User, scope string) (string, time.Time, error) {
+	return s.GenerateScopedToken(user, scope, mfaTokenTTL)
+}
+
+// ParseScopedToken validates a token generated by GenerateScopedToken for scope and returns
+// its claims
+func (s *Service) ParseScopedToken(tokenString, scope string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.config.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid or expired token")
+	}
+	claims, ok := token.Claims.(*Claims)
+	if !ok || claims.Scope != scope {
+		return nil, fmt.Errorf("invalid or expired token")
+	}
+	return claims, nil
+}
@@ -30,6 +30,7 @@ func NewService(db *database.DB, cfg *config.Config) *Service {
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	Scope  string `json:"scope,omitempty"` // Set on tokens restricted to some routes, e.g. for the Discord bot
 	jwt.RegisteredClaims
 }
 
@@ -63,6 +64,26 @@ func (s *Service) GenerateAccessToken(user *models.User) (string, error) {
 	return token.SignedString([]byte(s.config.JWTSecret))
 }
 
+// GenerateScopedToken generates a short-lived JWT access token restricted to the routes
+// middleware.RestrictTokenScope allows for scope
+func (s *Service) GenerateScopedToken(user *models.User, scope string, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	claims := &Claims{
+		UserID: user.ID.String(),
+		Email:  user.Email,
+		Scope:  scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(s.config.JWTSecret))
+	return signed, expiresAt, err
+}
+
 // GenerateRefreshToken generates a random refresh token
 func (s *Service) GenerateRefreshToken() (string, error) {
 	b := make([]byte, 32)
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238), the defaults every authenticator app supports
+const (
+	totpPeriod = 30 // Seconds per time step
+	totpDigits = 6
+	totpSkew   = 1 // Steps accepted either side of the current one, for clock drift
+
+	backupCodeCount = 10
+
+	// backupCodeAlphabet leaves out characters that are easy to mistype (0/O, 1/I)
+	backupCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	backupCodeLength   = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret returns a random 160-bit secret, base32 encoded without padding
+func generateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// totpURL returns the otpauth:// URI authenticator apps enroll a secret with, usually shown
+// as a QR code
+func totpURL(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + params.Encode()
+}
+
+// totpCode returns the code of a time step
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
+
+// matchTOTP returns the time step a code is valid for at now
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// generateBackupCodes returns new one-time backup codes, formatted XXXXX-XXXXX, and the
+// hashes they're stored by
+func generateBackupCodes() ([]string, []string, error) {
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	b := make([]byte, backupCodeLength)
+	for i := range codes {
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		var code strings.Builder
+		for j, c := range b {
+			if j == backupCodeLength/2 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(backupCodeAlphabet[int(c)%len(backupCodeAlphabet)])
+		}
+		codes[i] = code.String()
+		hashes[i] = hashBackupCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashBackupCode returns the hex SHA-256 backup codes are stored by, ignoring case, spaces
+// and dashes
+func hashBackupCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
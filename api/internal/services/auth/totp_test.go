@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 seed from RFC 6238 appendix B, "12345678901234567890", base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfcSecret)
+	require.NoError(t, err)
+
+	// The RFC lists 8-digit codes; 6-digit codes are their last 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, totpCode(key, tt.unix/totpPeriod), "time %d", tt.unix)
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0) // Step 37037037, code 050471
+	key, err := totpEncoding.DecodeString(rfcSecret)
+	require.NoError(t, err)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfcSecret, "050471", step, true},
+		{"previous step", rfcSecret, totpCode(key, step-1), step - 1, true},
+		{"next step", rfcSecret, totpCode(key, step+1), step + 1, true},
+		{"two steps old", rfcSecret, totpCode(key, step-2), 0, false},
+		{"two steps ahead", rfcSecret, totpCode(key, step+2), 0, false},
+		{"wrong code", rfcSecret, "123456", 0, false},
+		{"too short", rfcSecret, "05047", 0, false},
+		{"too long", rfcSecret, "0504710", 0, false},
+		{"empty", rfcSecret, "", 0, false},
+		{"invalid secret", "not base32!", "050471", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := matchTOTP(tt.secret, tt.code, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantStep, gotStep)
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+
+	key, err := totpEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, 20)
+
+	other, err := generateTOTPSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
+
+func TestTOTPURL(t *testing.T) {
+	raw := totpURL("GSHUB.PRO", "user@example.com", rfcSecret)
+
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/GSHUB.PRO:user@example.com", u.Path)
+
+	q := u.Query()
+	assert.Equal(t, rfcSecret, q.Get("secret"))
+	assert.Equal(t, "GSHUB.PRO", q.Get("issuer"))
+	assert.Equal(t, "SHA1", q.Get("algorithm"))
+	assert.Equal(t, "6", q.Get("digits"))
+	assert.Equal(t, "30", q.Get("period"))
+}
+
+func TestGenerateBackupCodes(t *testing.T) {
+	codes, hashes, err := generateBackupCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, backupCodeCount)
+	require.Len(t, hashes, backupCodeCount)
+
+	format := regexp.MustCompile(`^[` + backupCodeAlphabet + `]{5}-[` + backupCodeAlphabet + `]{5}$`)
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		assert.Regexp(t, format, code)
+		assert.Equal(t, hashBackupCode(code), hashes[i])
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+}
+
+func TestHashBackupCode(t *testing.T) {
+	// SHA-256 of "ABCDE23456"
+	const want = "82a15347a056ccbf451fbd1c865eed21ec190069eedd32b0c9dc6452887811cc"
+
+	tests := []struct {
+		name  string
+		code  string
+		match bool
+	}{
+		{"as issued", "ABCDE-23456", true},
+		{"without dash", "ABCDE23456", true},
+		{"lowercase", "abcde-23456", true},
+		{"spaces", " ABCDE 23456 ", true},
+		{"different code", "ABCDE-23457", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hashBackupCode(tt.code)
+			assert.Equal(t, tt.match, got == want, "hash %s", got)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package backupreplica
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/objectstore"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for the backup replication service
+type Config struct {
+	// Interval is how often queued replicas are copied or removed (default: 1 minute)
+	Interval time.Duration
+	// BatchSize is how many replicas are handled per run (default: 10)
+	BatchSize int
+	// TransferTimeout bounds copying one archive off-site (default: 1 hour)
+	TransferTimeout time.Duration
+	// MaxAttempts is how often copying an archive is tried before giving up (default: 5)
+	MaxAttempts int
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:        time.Minute,
+		BatchSize:       10,
+		TransferTimeout: time.Hour,
+		MaxAttempts:     5,
+	}
+}
+
+// Service copies backup archives from servers' data volumes to the off-site bucket, and
+// removes the copies their server's retention no longer keeps. Archives are read from the
+// running supervisor, so servers that are stopped are replicated once they start again.
+type Service struct {
+	db        *database.DB
+	k8sClient *k8s.Client
+	store     *objectstore.Client
+	cfg       *config.Config
+	config    Config
+	logger    *zap.Logger
+	runner    *periodic.Runner
+}
+
+// NewService creates a new backup replication service
+func NewService(db *database.DB, k8sClient *k8s.Client, store *objectstore.Client, cfg *config.Config, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:        db,
+		k8sClient: k8sClient,
+		store:     store,
+		cfg:       cfg,
+		config:    config,
+		logger:    logger,
+	}
+	s.runner = periodic.New("backup replication", config.Interval, s.runReplication, logger)
+	return s
+}
+
+// ObjectKey returns where a server's backup archive is stored in the bucket
+func ObjectKey(serverID, name string) string {
+	return fmt.Sprintf("servers/%s/%s", serverID, name)
+}
+
+// Start begins the backup replication service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx)
+}
+
+// Stop stops the backup replication service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// runReplication handles a batch of replicas waiting to be copied or removed
+func (s *Service) runReplication(ctx context.Context) {
+	replicas, err := s.db.ListBackupReplicaWork(ctx, s.config.BatchSize)
+	if err != nil {
+		s.logger.Error("failed to list backup replica work", zap.Error(err))
+		return
+	}
+
+	for _, replica := range replicas {
+		var err error
+		if replica.State == models.BackupReplicaDeleting {
+			err = s.remove(ctx, replica)
+		} else {
+			err = s.replicate(ctx, replica)
+		}
+		if err == nil {
+			continue
+		}
+
+		s.logger.Warn("failed to handle backup replica",
+			zap.String("server_id", replica.ServerID.String()),
+			zap.String("replica_id", replica.ID.String()),
+			zap.String("state", string(replica.State)),
+			zap.Error(err),
+		)
+		if err := s.db.RecordBackupReplicaError(ctx, replica.ID, err.Error(), s.config.MaxAttempts); err != nil {
+			s.logger.Error("failed to record backup replica error", zap.Error(err))
+		}
+	}
+}
+
+// remove deletes a pruned replica from the bucket and forgets it
+func (s *Service) remove(ctx context.Context, replica models.BackupReplica) error {
+	if err := s.store.Delete(ctx, replica.ObjectKey); err != nil {
+		return err
+	}
+	return s.db.DeleteBackupReplica(ctx, replica.ID)
+}
+
+// replicate copies an archive from its server's supervisor to the bucket, then applies the
+// server's retention to its replicas. Replicas of servers that aren't running are left for
+// a later run.
+func (s *Service) replicate(ctx context.Context, replica models.BackupReplica) error {
+	serverID := replica.ServerID.String()
+	server, err := s.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server.Status != models.ServerStatusRunning {
+		return nil
+	}
+
+	pods, err := s.k8sClient.ListPodsByLabel(ctx, server.K8sNamespace(s.cfg.K8sNamespace), "server="+serverID)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	podIP := ""
+	for i := range pods {
+		if pods[i].DeletionTimestamp == nil && k8s.PodReady(&pods[i]) {
+			podIP = pods[i].Status.PodIP
+			break
+		}
+	}
+	if podIP == "" {
+		return nil // Starting or restarting
+	}
+
+	token, err := s.db.GetServerAuthToken(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get auth token: %w", err)
+	}
+
+	transferCtx, cancel := context.WithTimeout(ctx, s.config.TransferTimeout)
+	defer cancel()
+
+	target := fmt.Sprintf("http://%s:%d/files/download?path=%s", podIP, k8s.SupervisorHTTPPort, url.QueryEscape(replica.Path))
+	req, err := http.NewRequestWithContext(transferCtx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Pruned by the supervisor before it could be copied
+		s.logger.Info("backup archive gone before replication",
+			zap.String("server_id", serverID),
+			zap.String("path", replica.Path),
+		)
+		return s.db.DeleteBackupReplica(ctx, replica.ID)
+	}
+	if resp.StatusCode != http.StatusOK || resp.ContentLength < 0 {
+		return fmt.Errorf("supervisor returned status %d", resp.StatusCode)
+	}
+
+	if err := s.store.Put(transferCtx, replica.ObjectKey, resp.Body, resp.ContentLength); err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+	if err := s.db.MarkBackupReplicaReplicated(ctx, replica.ID, resp.ContentLength); err != nil {
+		return err
+	}
+
+	s.logger.Info("replicated backup",
+		zap.String("server_id", serverID),
+		zap.String("object_key", replica.ObjectKey),
+		zap.Int64("bytes", resp.ContentLength),
+	)
+
+	if err := s.prune(ctx, replica); err != nil {
+		s.logger.Warn("failed to prune backup replicas",
+			zap.String("server_id", serverID),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+// prune queues the server's replicas for removal that the retention of the backup which
+// produced replica doesn't keep: the same retention the supervisor pruned its archives by
+func (s *Service) prune(ctx context.Context, replica models.BackupReplica) error {
+	if replica.CommandID == nil {
+		return nil
+	}
+	cmd, err := s.db.GetServerCommandByID(ctx, replica.CommandID.String())
+	if err != nil {
+		return err
+	}
+	if cmd.Payload == nil {
+		return nil
+	}
+	var payload models.BackupCommandPayload
+	if err := json.Unmarshal([]byte(*cmd.Payload), &payload); err != nil {
+		return fmt.Errorf("invalid backup payload: %w", err)
+	}
+	if payload.Retention == nil {
+		return nil
+	}
+
+	replicas, err := s.db.ListBackupReplicas(ctx, replica.ServerID.String())
+	if err != nil {
+		return err
+	}
+	var replicated []models.BackupReplica
+	var times []time.Time
+	for _, r := range replicas {
+		if r.State == models.BackupReplicaReplicated {
+			replicated = append(replicated, r)
+			times = append(times, r.CreatedAt)
+		}
+	}
+
+	var pruned []uuid.UUID
+	for i, keep := range payload.Retention.Keep(times) {
+		if !keep {
+			pruned = append(pruned, replicated[i].ID)
+		}
+	}
+	if len(pruned) == 0 {
+		return nil
+	}
+	return s.db.MarkBackupReplicasDeleting(ctx, pruned)
+}
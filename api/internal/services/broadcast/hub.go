@@ -1,102 +1,356 @@
 package broadcast
 
 import (
+	"errors"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// Event is published to a user's SSE stream under its EventName
+type Event interface {
+	EventName() string
+}
+
 // StatusEvent represents a server status change event
 type StatusEvent struct {
 	ServerID      string    `json:"server_id"`
 	Status        string    `json:"status"`
 	StatusMessage *string   `json:"status_message,omitempty"`
+	StatusReason  string    `json:"status_reason,omitempty"`
+	StartupPhase  string    `json:"startup_phase,omitempty"` // While starting, once the supervisor reports one
 	Timestamp     time.Time `json:"timestamp"`
 }
 
-// Hub manages SSE client subscriptions and broadcasts status events
+func (StatusEvent) EventName() string { return "status" }
+
+// ProgressEvent reports how far a starting server has got with a startup milestone from its
+// game catalog, e.g. preparing the spawn area
+type ProgressEvent struct {
+	ServerID  string    `json:"server_id"`
+	Label     string    `json:"label"`
+	Percent   int       `json:"percent"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (ProgressEvent) EventName() string { return "progress" }
+
+// IncidentEvent tells a user that a node hosting some of their servers has an incident
+// (State "open") or has recovered (State "resolved")
+type IncidentEvent struct {
+	IncidentID string     `json:"incident_id"`
+	Kind       string     `json:"kind"`
+	State      string     `json:"state"`
+	ServerIDs  []string   `json:"server_ids"` // The user's servers on the node
+	StartedAt  time.Time  `json:"started_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Timestamp  time.Time  `json:"timestamp"`
+}
+
+func (IncidentEvent) EventName() string { return "incident" }
+
+// CommandEvent reports the progress or completion of a command on one of the user's
+// servers, e.g. a world export
+type CommandEvent struct {
+	ServerID   string    `json:"server_id"`
+	CommandID  string    `json:"command_id"`
+	Type       string    `json:"type"`
+	State      string    `json:"state"`
+	DoneBytes  int64     `json:"done_bytes,omitempty"`
+	TotalBytes int64     `json:"total_bytes,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+func (CommandEvent) EventName() string { return "command" }
+
+// Reasons the hub ends a subscription, given by Subscription.CloseReason
+const (
+	CloseSlow     = "slow"     // Its buffer stayed full too long; reconnecting gets the current state
+	CloseReplaced = "replaced" // The user opened more streams than allowed; don't reconnect
+)
+
+var (
+	// ErrHubFull is returned by Subscribe when the hub has as many subscribers as allowed
+	ErrHubFull = errors.New("too many status streams")
+	// ErrUserLimit is returned by Subscribe when the user has as many subscribers as allowed
+	// and the oldest may not be replaced
+	ErrUserLimit = errors.New("too many status streams for this user")
+)
+
+// Limits caps concurrent subscribers. Zero values don't limit.
+type Limits struct {
+	PerUser int `json:"per_user"` // Per user, not counting watchers
+	Total   int `json:"total"`    // In this hub, i.e. per API replica
+}
+
+// SubscribeOptions control how a subscription counts toward the hub's limits
+type SubscribeOptions struct {
+	// Replace closes the user's oldest subscription when they're at the per-user limit,
+	// instead of failing with ErrUserLimit
+	Replace bool
+	// Watcher subscriptions (e.g. an admin following a user's stream) don't count toward
+	// the user's limit and are never replaced
+	Watcher bool
+}
+
+// Hub manages SSE client subscriptions and broadcasts events
 type Hub struct {
 	mu          sync.RWMutex
-	subscribers map[uuid.UUID]map[chan StatusEvent]struct{} // userID -> set of channels
+	subscribers map[uuid.UUID]map[*Subscription]struct{} // userID -> subscriptions
+	total       int
 	logger      *zap.Logger
 	bufferSize  int
+	slowTimeout time.Duration // Subscribers whose buffer stays full this long are dropped (0 = never)
+	limits      Limits
+
+	published    atomic.Uint64
+	dropped      atomic.Uint64
+	disconnected atomic.Uint64
+	replaced     atomic.Uint64
+	rejected     atomic.Uint64
+}
+
+// Subscription is one SSE client's event channel. Publishers update it under the read lock.
+type Subscription struct {
+	// Events receives the user's events. The hub closes it when it ends the subscription.
+	Events chan Event
+
+	since       time.Time
+	watcher     bool
+	closeReason string       // Set before Events is closed by the hub
+	fullFrom    atomic.Int64 // Unix nanos the buffer was first found full, 0 while it isn't
+	dropped     atomic.Uint64
+}
+
+// Limits returns the hub's subscriber limits
+func (h *Hub) Limits() Limits {
+	return h.limits
+}
+
+// CloseReason returns why the hub ended the subscription, once Events is closed
+func (s *Subscription) CloseReason() string {
+	return s.closeReason
 }
 
-// NewHub creates a new broadcast hub
-func NewHub(logger *zap.Logger) *Hub {
+// NewHub creates a new broadcast hub. Subscribers that don't drain their buffer for
+// slowTimeout are disconnected (0 only drops their events).
+func NewHub(logger *zap.Logger, slowTimeout time.Duration, limits Limits) *Hub {
 	return &Hub{
-		subscribers: make(map[uuid.UUID]map[chan StatusEvent]struct{}),
+		subscribers: make(map[uuid.UUID]map[*Subscription]struct{}),
 		logger:      logger,
 		bufferSize:  10, // Buffer to handle burst events
+		slowTimeout: slowTimeout,
+		limits:      limits,
 	}
 }
 
-// Subscribe creates a new subscription for a user and returns a channel to receive events
-func (h *Hub) Subscribe(userID uuid.UUID) chan StatusEvent {
+// Subscribe creates a new subscription for a user to receive events on. The hub closes its
+// channel if the subscriber falls too far behind, or is replaced by a newer one. Fails with
+// ErrHubFull or ErrUserLimit once the hub's limits are reached.
+func (h *Hub) Subscribe(userID uuid.UUID, opts SubscribeOptions) (*Subscription, error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	ch := make(chan StatusEvent, h.bufferSize)
+	var oldest *Subscription
+	if h.limits.PerUser > 0 && !opts.Watcher {
+		count := 0
+		for sub := range h.subscribers[userID] {
+			if sub.watcher {
+				continue
+			}
+			count++
+			if oldest == nil || sub.since.Before(oldest.since) {
+				oldest = sub
+			}
+		}
+		if count < h.limits.PerUser {
+			oldest = nil
+		} else if !opts.Replace {
+			h.rejected.Add(1)
+			return nil, ErrUserLimit
+		}
+	}
+	// A replacement frees its own slot
+	if oldest == nil && h.limits.Total > 0 && h.total >= h.limits.Total {
+		h.rejected.Add(1)
+		return nil, ErrHubFull
+	}
+
+	if oldest != nil {
+		h.remove(userID, oldest, CloseReplaced)
+		h.replaced.Add(1)
+		h.logger.Debug("replacing oldest client subscription",
+			zap.String("user_id", userID.String()),
+			zap.Int("limit", h.limits.PerUser),
+		)
+	}
 
+	sub := &Subscription{
+		Events:  make(chan Event, h.bufferSize),
+		since:   time.Now(),
+		watcher: opts.Watcher,
+	}
 	if h.subscribers[userID] == nil {
-		h.subscribers[userID] = make(map[chan StatusEvent]struct{})
+		h.subscribers[userID] = make(map[*Subscription]struct{})
 	}
-	h.subscribers[userID][ch] = struct{}{}
+	h.subscribers[userID][sub] = struct{}{}
+	h.total++
 
 	h.logger.Debug("client subscribed",
 		zap.String("user_id", userID.String()),
 		zap.Int("total_subscribers", len(h.subscribers[userID])),
 	)
 
-	return ch
+	return sub, nil
 }
 
 // Unsubscribe removes a subscription for a user
-func (h *Hub) Unsubscribe(userID uuid.UUID, ch chan StatusEvent) {
+func (h *Hub) Unsubscribe(userID uuid.UUID, sub *Subscription) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if subs, ok := h.subscribers[userID]; ok {
-		if _, exists := subs[ch]; exists {
-			delete(subs, ch)
-			close(ch)
-
-			// Clean up empty user entry
-			if len(subs) == 0 {
-				delete(h.subscribers, userID)
-			}
-
-			h.logger.Debug("client unsubscribed",
-				zap.String("user_id", userID.String()),
-			)
-		}
+	if h.remove(userID, sub, "") {
+		h.logger.Debug("client unsubscribed",
+			zap.String("user_id", userID.String()),
+		)
 	}
 }
 
-// Publish sends an event to all subscribers for a specific user
-// Non-blocking: drops events if client buffer is full
-func (h *Hub) Publish(userID uuid.UUID, event StatusEvent) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
+// remove closes and forgets a subscription, reporting whether it existed. The caller holds
+// the write lock.
+func (h *Hub) remove(userID uuid.UUID, sub *Subscription, reason string) bool {
 	subs, ok := h.subscribers[userID]
 	if !ok {
-		return // No subscribers for this user
+		return false
+	}
+	if _, exists := subs[sub]; !exists {
+		return false
+	}
+	delete(subs, sub)
+	h.total--
+	sub.closeReason = reason
+	close(sub.Events)
+
+	// Clean up empty user entry
+	if len(subs) == 0 {
+		delete(h.subscribers, userID)
 	}
+	return true
+}
+
+// Publish sends an event to all subscribers for a specific user
+// Non-blocking: drops events if client buffer is full, and disconnects clients whose buffer
+// has been full for longer than the slow-subscriber timeout
+func (h *Hub) Publish(userID uuid.UUID, event Event) {
+	h.published.Add(1)
 
-	for ch := range subs {
+	var slow []*Subscription
+	h.mu.RLock()
+	for sub := range h.subscribers[userID] {
 		select {
-		case ch <- event:
-			// Event sent successfully
+		case sub.Events <- event:
+			sub.fullFrom.Store(0)
 		default:
 			// Buffer full, drop event (client is slow)
+			sub.dropped.Add(1)
+			h.dropped.Add(1)
+
+			now := time.Now().UnixNano()
+			sub.fullFrom.CompareAndSwap(0, now)
+			if h.slowTimeout > 0 && time.Duration(now-sub.fullFrom.Load()) >= h.slowTimeout {
+				slow = append(slow, sub)
+				continue
+			}
 			h.logger.Warn("dropping event, client buffer full",
 				zap.String("user_id", userID.String()),
-				zap.String("server_id", event.ServerID),
-				zap.String("status", event.Status),
+				zap.String("event", event.EventName()),
+			)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(slow) == 0 {
+		return
+	}
+
+	// Closing the channel ends the client's stream; it reconnects and gets the current state
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, sub := range slow {
+		if h.remove(userID, sub, CloseSlow) {
+			h.disconnected.Add(1)
+			h.logger.Warn("disconnecting slow client, buffer full too long",
+				zap.String("user_id", userID.String()),
+				zap.Duration("timeout", h.slowTimeout),
 			)
 		}
 	}
 }
+
+// Stats is a snapshot of the hub's subscribers and event counters since startup
+type Stats struct {
+	Users        int         `json:"users"`
+	Subscribers  int         `json:"subscribers"`
+	Published    uint64      `json:"published"`    // Events published, to any number of subscribers
+	Dropped      uint64      `json:"dropped"`      // Deliveries dropped because a buffer was full
+	Disconnected uint64      `json:"disconnected"` // Subscribers disconnected for staying full
+	Replaced     uint64      `json:"replaced"`     // Subscribers closed for their user's newer ones
+	Rejected     uint64      `json:"rejected"`     // Subscriptions refused at a limit
+	BufferSize   int         `json:"buffer_size"`
+	Limits       Limits      `json:"limits"`
+	ByUser       []UserStats `json:"by_user"` // Most queued events first
+}
+
+// UserStats are one user's subscribers
+type UserStats struct {
+	UserID      uuid.UUID         `json:"user_id"`
+	Subscribers []SubscriberStats `json:"subscribers"`
+	Queued      int               `json:"queued"` // Events waiting in all their buffers
+}
+
+// SubscriberStats describe one SSE client
+type SubscriberStats struct {
+	ConnectedAt time.Time  `json:"connected_at"`
+	Watcher     bool       `json:"watcher,omitempty"`
+	Queued      int        `json:"queued"`
+	Dropped     uint64     `json:"dropped"`
+	FullSince   *time.Time `json:"full_since,omitempty"`
+}
+
+// Stats returns the hub's current subscribers and counters
+func (h *Hub) Stats() Stats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := Stats{
+		Users:        len(h.subscribers),
+		Published:    h.published.Load(),
+		Dropped:      h.dropped.Load(),
+		Disconnected: h.disconnected.Load(),
+		Replaced:     h.replaced.Load(),
+		Rejected:     h.rejected.Load(),
+		BufferSize:   h.bufferSize,
+		Limits:       h.limits,
+		ByUser:       make([]UserStats, 0, len(h.subscribers)),
+	}
+	for userID, subs := range h.subscribers {
+		user := UserStats{UserID: userID, Subscribers: make([]SubscriberStats, 0, len(subs))}
+		for sub := range subs {
+			ss := SubscriberStats{ConnectedAt: sub.since, Watcher: sub.watcher, Queued: len(sub.Events), Dropped: sub.dropped.Load()}
+			if from := sub.fullFrom.Load(); from != 0 {
+				t := time.Unix(0, from)
+				ss.FullSince = &t
+			}
+			user.Subscribers = append(user.Subscribers, ss)
+			user.Queued += ss.Queued
+		}
+		stats.Subscribers += len(subs)
+		stats.ByUser = append(stats.ByUser, user)
+	}
+	sort.Slice(stats.ByUser, func(i, j int) bool { return stats.ByUser[i].Queued > stats.ByUser[j].Queued })
+	return stats
+}
@@ -0,0 +1,146 @@
+package cardexpiry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/email"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"github.com/mooncorn/gshub/api/internal/services/stripe"
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for the card expiry service
+type Config struct {
+	// Interval is how often saved cards are checked against renewals (default: 1 day).
+	// Warnings are tracked in the database, so checking often doesn't send duplicates.
+	Interval time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval: 24 * time.Hour,
+	}
+}
+
+// Service warns users whose saved card expires before one of their subscriptions renews,
+// so they update it before the renewal fails and their servers expire. Each card expiry is
+// warned about once: the warnings recorded in the database decide what's sent, not the
+// interval, so a restart or a short interval never repeats a warning or skips a card.
+type Service struct {
+	db            *database.DB
+	stripeService *stripe.Service
+	email         *email.Service
+	config        Config
+	logger        *zap.Logger
+	runner        *periodic.Runner
+}
+
+// NewService creates a new card expiry service
+func NewService(db *database.DB, stripeService *stripe.Service, emailService *email.Service, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:            db,
+		stripeService: stripeService,
+		email:         emailService,
+		config:        config,
+		logger:        logger,
+	}
+	s.runner = periodic.New("card expiry", config.Interval, s.runChecks, logger).RunFirst()
+	return s
+}
+
+// Start begins the card expiry service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx)
+}
+
+// Stop stops the card expiry service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// runChecks checks the saved card of every customer with active subscriptions
+func (s *Service) runChecks(ctx context.Context) {
+	targets, err := s.db.GetCardExpiryTargets(ctx)
+	if err != nil {
+		s.logger.Error("failed to get card expiry targets", zap.Error(err))
+		return
+	}
+
+	for _, target := range targets {
+		if err := s.check(ctx, target); err != nil {
+			s.logger.Error("failed to check card expiry",
+				zap.String("user_id", target.UserID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// check warns the user if the card their renewals are charged to expires before the next
+// one, unless they were already warned about that card
+func (s *Service) check(ctx context.Context, target database.CardExpiryTarget) error {
+	user := &models.User{ID: target.UserID, Email: target.Email, StripeCustomerID: &target.StripeCustomerID}
+	card, err := s.stripeService.SavedCard(ctx, user)
+	if err != nil {
+		return err
+	}
+	if card == nil || card.Card == nil {
+		return nil
+	}
+
+	// Cards are valid through the last day of their expiry month
+	expiresAt := time.Date(int(card.Card.ExpYear), time.Month(card.Card.ExpMonth)+1, 1, 0, 0, 0, 0, time.UTC)
+
+	renewsAt, err := s.nextRenewalAfter(ctx, target.SubscriptionIDs, expiresAt)
+	if err != nil {
+		return err
+	}
+	if renewsAt.IsZero() {
+		return nil
+	}
+
+	warned, err := s.db.CardExpiryWarned(ctx, target.UserID, card.ID, card.Card.ExpMonth, card.Card.ExpYear)
+	if err != nil || warned {
+		return err
+	}
+
+	description := fmt.Sprintf("%s card ending in %s", card.Card.Brand, card.Card.Last4)
+	expires := fmt.Sprintf("%02d/%d", card.Card.ExpMonth, card.Card.ExpYear)
+	if err := s.email.SendCardExpiringEmail(target.Email, description, expires, renewsAt); err != nil {
+		return err
+	}
+
+	s.logger.Info("sent card expiry warning",
+		zap.String("user_id", target.UserID.String()),
+		zap.String("payment_method_id", card.ID),
+		zap.Time("renews_at", renewsAt),
+	)
+
+	return s.db.MarkCardExpiryWarned(ctx, target.UserID, card.ID, card.Card.ExpMonth, card.Card.ExpYear)
+}
+
+// nextRenewalAfter returns the earliest renewal of the subscriptions at or after t, or the
+// zero time if none renews by then. Subscriptions set to cancel don't renew.
+func (s *Service) nextRenewalAfter(ctx context.Context, subscriptionIDs []string, t time.Time) (time.Time, error) {
+	var next time.Time
+	for _, subscriptionID := range subscriptionIDs {
+		sub, err := s.stripeService.GetSubscription(ctx, subscriptionID)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if sub.Status == "canceled" || sub.CancelAtPeriodEnd || sub.Items == nil || len(sub.Items.Data) == 0 {
+			continue
+		}
+
+		renewsAt := time.Unix(sub.Items.Data[0].CurrentPeriodEnd, 0).UTC()
+		if !renewsAt.Before(t) && (next.IsZero() || renewsAt.Before(next)) {
+			next = renewsAt
+		}
+	}
+	return next, nil
+}
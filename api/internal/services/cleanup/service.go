@@ -7,7 +7,9 @@ import (
 
 	"github.com/mooncorn/gshub/api/internal/database"
 	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/email"
 	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
 	"go.uber.org/zap"
 )
 
@@ -15,14 +17,23 @@ import (
 type Config struct {
 	// Interval is how often to run cleanup (default: 1 hour)
 	Interval time.Duration
-	// Namespace is the K8s namespace to clean up resources in
+	// Namespace is the default K8s namespace, used for servers that don't record their own
 	Namespace string
+	// DeletionWarningLead is how long before permanent deletion owners are emailed (default: 24 hours)
+	DeletionWarningLead time.Duration
+	// MetricsRetention is how long per-minute server metrics are kept (default: 7 days)
+	MetricsRetention time.Duration
+	// PortHistoryRetention is how long the port allocation history is kept (default: 90 days)
+	PortHistoryRetention time.Duration
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() Config {
 	return Config{
-		Interval: 1 * time.Hour,
+		Interval:             1 * time.Hour,
+		DeletionWarningLead:  24 * time.Hour,
+		MetricsRetention:     7 * 24 * time.Hour,
+		PortHistoryRetention: 90 * 24 * time.Hour,
 	}
 }
 
@@ -30,16 +41,20 @@ func DefaultConfig() Config {
 type Service struct {
 	db        *database.DB
 	k8sClient *k8s.Client
+	machine   *serverstate.Machine
+	email     *email.Service
 	config    Config
 	logger    *zap.Logger
 	stopCh    chan struct{}
 }
 
 // NewService creates a new cleanup service
-func NewService(db *database.DB, k8sClient *k8s.Client, config Config, logger *zap.Logger) *Service {
+func NewService(db *database.DB, k8sClient *k8s.Client, machine *serverstate.Machine, emailService *email.Service, config Config, logger *zap.Logger) *Service {
 	return &Service{
 		db:        db,
 		k8sClient: k8sClient,
+		machine:   machine,
+		email:     emailService,
 		config:    config,
 		logger:    logger,
 		stopCh:    make(chan struct{}),
@@ -79,8 +94,18 @@ func (s *Service) Stop() {
 	close(s.stopCh)
 }
 
-// runCleanup finds and cleans up expired servers past their grace period
+// runCleanup drops old server metrics, warns owners of expired servers nearing deletion,
+// then cleans up expired servers past their grace period
 func (s *Service) runCleanup(ctx context.Context) {
+	if err := s.db.DeleteServerMetricsBefore(ctx, time.Now().Add(-s.config.MetricsRetention)); err != nil {
+		s.logger.Error("failed to delete old server metrics", zap.Error(err))
+	}
+	if err := s.db.DeletePortAllocationHistoryBefore(ctx, time.Now().Add(-s.config.PortHistoryRetention)); err != nil {
+		s.logger.Error("failed to delete old port allocation history", zap.Error(err))
+	}
+
+	s.sendDeletionWarnings(ctx)
+
 	servers, err := s.db.GetExpiredServersForCleanup(ctx)
 	if err != nil {
 		s.logger.Error("failed to get expired servers for cleanup", zap.Error(err))
@@ -102,8 +127,11 @@ func (s *Service) runCleanup(ctx context.Context) {
 
 		// Step 1: Atomically transition expired -> deleting
 		// This prevents concurrent cleanup attempts
-		transitioned, err := s.db.TransitionServerStatus(ctx, serverID,
-			models.ServerStatusExpired, models.ServerStatusDeleting, "Cleaning up resources...")
+		transitioned, err := s.machine.Transition(ctx, &server, serverstate.Request{
+			From:    []models.ServerStatus{models.ServerStatusExpired},
+			To:      models.ServerStatusDeleting,
+			Message: "Cleaning up resources...",
+		})
 		if err != nil {
 			s.logger.Error("failed to transition to deleting",
 				zap.String("server_id", serverID),
@@ -120,16 +148,24 @@ func (s *Service) runCleanup(ctx context.Context) {
 			continue
 		}
 
-		// Step 2: Delete PVC from K8s
-		if err := s.k8sClient.DeletePVC(ctx, s.config.Namespace, pvcName); err != nil {
+		// Step 2: Delete the file access pod, if the owner left one open, and the PVC from K8s
+		if err := s.k8sClient.DeleteFileAccessPods(ctx, server.K8sNamespace(s.config.Namespace), serverID); err != nil {
+			s.logger.Warn("failed to delete file access pod",
+				zap.String("server_id", serverID),
+				zap.Error(err),
+			)
+		}
+		if err := s.k8sClient.DeletePVC(ctx, server.K8sNamespace(s.config.Namespace), pvcName); err != nil {
 			s.logger.Error("failed to delete PVC, reverting to expired",
 				zap.String("server_id", serverID),
 				zap.String("pvc_name", pvcName),
 				zap.Error(err),
 			)
 			// Revert to expired so we can retry next cycle
-			s.db.TransitionServerStatus(ctx, serverID,
-				models.ServerStatusDeleting, models.ServerStatusExpired, "")
+			s.machine.Transition(ctx, &server, serverstate.Request{
+				From: []models.ServerStatus{models.ServerStatusDeleting},
+				To:   models.ServerStatusExpired,
+			})
 			failureCount++
 			continue
 		}
@@ -139,9 +175,25 @@ func (s *Service) runCleanup(ctx context.Context) {
 			zap.String("pvc_name", pvcName),
 		)
 
+		// Off-site backup copies and volume backups go with the data; their services remove them
+		if err := s.db.MarkServerBackupReplicasDeleting(ctx, serverID); err != nil {
+			s.logger.Warn("failed to remove backup replicas",
+				zap.String("server_id", serverID),
+				zap.Error(err),
+			)
+		}
+		if err := s.db.MarkServerVolumeBackupsDeleting(ctx, serverID); err != nil {
+			s.logger.Warn("failed to remove volume backups",
+				zap.String("server_id", serverID),
+				zap.Error(err),
+			)
+		}
+
 		// Step 3: Transition to deleted
-		s.db.TransitionServerStatus(ctx, serverID,
-			models.ServerStatusDeleting, models.ServerStatusDeleted, "")
+		s.machine.Transition(ctx, &server, serverstate.Request{
+			From: []models.ServerStatus{models.ServerStatusDeleting},
+			To:   models.ServerStatusDeleted,
+		})
 
 		// Step 4: Hard delete server record from database
 		if err := s.db.HardDeleteServer(ctx, serverID); err != nil {
@@ -166,3 +218,43 @@ func (s *Service) runCleanup(ctx context.Context) {
 		zap.Int("failed", failureCount),
 	)
 }
+
+// sendDeletionWarnings emails owners of expired servers (whether their subscription
+// ended or they deleted the server) once, shortly before the data is deleted
+func (s *Service) sendDeletionWarnings(ctx context.Context) {
+	warnings, err := s.db.GetServersDueDeletionWarning(ctx, s.config.DeletionWarningLead)
+	if err != nil {
+		s.logger.Error("failed to get servers due deletion warning", zap.Error(err))
+		return
+	}
+
+	for _, w := range warnings {
+		serverID := w.ServerID.String()
+		name := w.DisplayName
+		if name == "" {
+			name = w.Subdomain
+		}
+
+		if err := s.email.SendServerDeletionWarningEmail(w.Email, name, serverID, w.DeleteAfter); err != nil {
+			// Not marked as sent, so the next cycle retries
+			s.logger.Error("failed to send deletion warning email",
+				zap.String("server_id", serverID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if err := s.db.MarkDeletionWarningSent(ctx, serverID); err != nil {
+			s.logger.Error("failed to mark deletion warning sent",
+				zap.String("server_id", serverID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		s.logger.Info("sent deletion warning",
+			zap.String("server_id", serverID),
+			zap.Time("delete_after", w.DeleteAfter),
+		)
+	}
+}
@@ -0,0 +1,107 @@
+// Package commandwake wakes the polls supervisors hold open for their server's commands as
+// soon as a command is queued, by whichever API replica queued it, through Postgres
+// notifications
+package commandwake
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"go.uber.org/zap"
+)
+
+// listenRetryDelay is how long to wait before listening again after the connection failed
+const listenRetryDelay = 5 * time.Second
+
+// Service listens for queued commands and wakes the polls waiting for them. Polls also
+// re-check the queue on their own now and then, so a notification missed while the
+// service reconnects only delays a command.
+type Service struct {
+	db     *database.DB
+	logger *zap.Logger
+	stopCh chan struct{}
+
+	mu      sync.Mutex
+	waiters map[string]map[chan struct{}]struct{} // By server ID
+}
+
+// NewService creates a new command wakeup service
+func NewService(db *database.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:      db,
+		logger:  logger,
+		stopCh:  make(chan struct{}),
+		waiters: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// Start begins listening for queued commands
+func (s *Service) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-s.stopCh
+		cancel()
+	}()
+
+	go func() {
+		for {
+			err := s.db.ListenServerCommands(ctx, s.wake)
+			if ctx.Err() != nil {
+				s.logger.Info("command wakeup service stopped")
+				return
+			}
+			s.logger.Warn("stopped listening for server commands, retrying", zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+				s.logger.Info("command wakeup service stopped")
+				return
+			case <-time.After(listenRetryDelay):
+			}
+		}
+	}()
+
+	s.logger.Info("command wakeup service started")
+}
+
+// Stop stops the command wakeup service
+func (s *Service) Stop() {
+	close(s.stopCh)
+}
+
+// Wait returns a channel that's closed when a command is queued for the server, and a
+// function that stops waiting. Waiting should start before the queue is checked, so a
+// command queued in between isn't missed.
+func (s *Service) Wait(serverID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{})
+
+	s.mu.Lock()
+	if s.waiters[serverID] == nil {
+		s.waiters[serverID] = make(map[chan struct{}]struct{})
+	}
+	s.waiters[serverID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.waiters[serverID][ch]; ok {
+			delete(s.waiters[serverID], ch)
+			if len(s.waiters[serverID]) == 0 {
+				delete(s.waiters, serverID)
+			}
+		}
+	}
+}
+
+// wake wakes everything waiting for the server's commands
+func (s *Service) wake(serverID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.waiters[serverID] {
+		close(ch)
+	}
+	delete(s.waiters, serverID)
+}
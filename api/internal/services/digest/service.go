@@ -0,0 +1,176 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/email"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"github.com/mooncorn/gshub/api/internal/services/stripe"
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for the digest service
+type Config struct {
+	// Interval is how often users due a digest are looked for (default: 1 hour)
+	Interval time.Duration
+	// EmailInterval is how often each user is sent the digest (default: 7 days)
+	EmailInterval time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:      time.Hour,
+		EmailInterval: 7 * 24 * time.Hour,
+	}
+}
+
+// Service emails users a weekly summary of each of their servers' uptime, peak players,
+// crashes, resource usage trend and renewal date. Activity comes from the hourly usage
+// supervisors report in heartbeats; crashes are counted by the CrashCounter hook. Users can
+// opt out in their email preferences.
+type Service struct {
+	db            *database.DB
+	stripeService *stripe.Service
+	email         *email.Service
+	config        Config
+	logger        *zap.Logger
+	runner        *periodic.Runner
+}
+
+// NewService creates a new digest service
+func NewService(db *database.DB, stripeService *stripe.Service, emailService *email.Service, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:            db,
+		stripeService: stripeService,
+		email:         emailService,
+		config:        config,
+		logger:        logger,
+	}
+	s.runner = periodic.New("digest", config.Interval, s.sendDigests, logger)
+	return s
+}
+
+// Start begins the digest service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx)
+}
+
+// Stop stops the digest service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// CrashCounter returns a state machine hook that counts crashes for the digest: a starting
+// or running server that fails
+func CrashCounter(db *database.DB, logger *zap.Logger) serverstate.Hook {
+	return func(ctx context.Context, change serverstate.Change) {
+		if change.To != models.ServerStatusFailed {
+			return
+		}
+		if change.From != models.ServerStatusStarting && change.From != models.ServerStatusRunning {
+			return
+		}
+		if err := db.RecordServerCrash(ctx, change.Server.ID.String()); err != nil {
+			logger.Error("failed to record server crash", zap.String("server_id", change.Server.ID.String()), zap.Error(err))
+		}
+	}
+}
+
+// sendDigests emails each user due a digest the last week of their servers
+func (s *Service) sendDigests(ctx context.Context) {
+	weekStart := time.Now().Add(-7 * 24 * time.Hour)
+	servers, err := s.db.ListDigestServers(ctx, s.config.EmailInterval, weekStart)
+	if err != nil {
+		s.logger.Error("failed to list digest servers", zap.Error(err))
+		return
+	}
+
+	// Servers are ordered by user
+	for start := 0; start < len(servers); {
+		end := start
+		var digests []email.ServerDigest
+		for ; end < len(servers) && servers[end].UserID == servers[start].UserID; end++ {
+			digests = append(digests, s.serverDigest(ctx, servers[end]))
+		}
+		user := servers[start]
+		start = end
+
+		if err := s.email.SendWeeklyDigestEmail(user.Email, digests); err != nil {
+			// Not marked as emailed, so it's retried on the next run
+			s.logger.Error("failed to send weekly digest", zap.String("user_id", user.UserID.String()), zap.Error(err))
+			continue
+		}
+		if err := s.db.MarkDigestEmailed(ctx, user.UserID); err != nil {
+			s.logger.Error("failed to mark digest emailed", zap.String("user_id", user.UserID.String()), zap.Error(err))
+		}
+	}
+}
+
+// serverDigest describes a server's week for the digest email
+func (s *Service) serverDigest(ctx context.Context, server database.DigestServer) email.ServerDigest {
+	stats := []string{fmt.Sprintf("Uptime: %d of %d hours", server.UptimeHours, 7*24)}
+	if server.PeakPlayers != nil {
+		stats = append(stats, fmt.Sprintf("Peak players: %d", *server.PeakPlayers))
+	}
+	stats = append(stats, fmt.Sprintf("Crashes: %d", server.Crashes))
+	if server.AvgCPUPercent != nil && server.AvgMemoryMB != nil {
+		stats = append(stats, fmt.Sprintf("Average CPU: %.0f%% of a core%s", *server.AvgCPUPercent,
+			trend(*server.AvgCPUPercent, server.PrevAvgCPUPercent)))
+		stats = append(stats, fmt.Sprintf("Average memory: %.0f MB%s", *server.AvgMemoryMB,
+			trend(*server.AvgMemoryMB, server.PrevAvgMemoryMB)))
+	}
+	if renewal := s.renewal(ctx, server); renewal != "" {
+		stats = append(stats, renewal)
+	}
+
+	return email.ServerDigest{
+		ServerName: server.DisplayName,
+		ServerID:   server.ServerID.String(),
+		Stats:      stats,
+	}
+}
+
+// renewal describes when the server's subscription renews or ends, or returns "" if it has
+// none (expired servers' subscriptions ended) or Stripe can't be reached
+func (s *Service) renewal(ctx context.Context, server database.DigestServer) string {
+	if server.Status == models.ServerStatusExpired || server.StripeSubscriptionID == nil || *server.StripeSubscriptionID == "" {
+		return ""
+	}
+	sub, err := s.stripeService.GetSubscription(ctx, *server.StripeSubscriptionID)
+	if err != nil {
+		s.logger.Warn("failed to get subscription for digest", zap.String("server_id", server.ServerID.String()), zap.Error(err))
+		return ""
+	}
+	if sub.Items == nil || len(sub.Items.Data) == 0 {
+		return ""
+	}
+
+	date := time.Unix(sub.Items.Data[0].CurrentPeriodEnd, 0).UTC().Format("January 2, 2006")
+	if sub.CancelAtPeriodEnd {
+		return "Subscription ends: " + date
+	}
+	return "Renews: " + date
+}
+
+// trend describes the change from the previous week's average, or returns "" if the server
+// didn't run then
+func trend(current float64, previous *float64) string {
+	if previous == nil || *previous <= 0 {
+		return ""
+	}
+	change := (current - *previous) / *previous * 100
+	if math.Abs(change) < 5 {
+		return " (about the same as the week before)"
+	}
+	if change > 0 {
+		return fmt.Sprintf(" (up %.0f%% from the week before)", change)
+	}
+	return fmt.Sprintf(" (down %.0f%% from the week before)", -change)
+}
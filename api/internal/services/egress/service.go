@@ -0,0 +1,139 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/services/email"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for the egress service
+type Config struct {
+	// Interval is how often monthly usage is compared against plan quotas (default: 15 minutes)
+	Interval time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval: 15 * time.Minute,
+	}
+}
+
+// Service alerts owners of servers whose outbound traffic this month exceeds their plan's
+// egress quota. Usage is recorded from supervisor heartbeats; each server is alerted at most
+// once per month.
+type Service struct {
+	db        *database.DB
+	k8sClient *k8s.Client
+	appConfig *config.Config
+	email     *email.Service
+	config    Config
+	logger    *zap.Logger
+	runner    *periodic.Runner
+}
+
+// NewService creates a new egress service
+func NewService(db *database.DB, k8sClient *k8s.Client, appConfig *config.Config, emailService *email.Service, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:        db,
+		k8sClient: k8sClient,
+		appConfig: appConfig,
+		email:     emailService,
+		config:    config,
+		logger:    logger,
+	}
+	s.runner = periodic.New("egress", config.Interval, s.runAlerts, logger).RunFirst()
+	return s
+}
+
+// Start begins the egress service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx)
+}
+
+// Stop stops the egress service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// runAlerts alerts the owner of every server over its plan's quota this month
+func (s *Service) runAlerts(ctx context.Context) {
+	usages, err := s.db.ListEgressUsageNotAlerted(ctx)
+	if err != nil {
+		s.logger.Error("failed to list egress usage", zap.Error(err))
+		return
+	}
+
+	// Catalogs are loaded once per run and channel
+	catalogs := map[string]*k8s.GameCatalog{}
+	for _, usage := range usages {
+		serverID := usage.ServerID.String()
+
+		catalog, ok := catalogs[usage.CatalogChannel]
+		if !ok {
+			catalog, err = s.k8sClient.LoadGameCatalog(ctx, s.appConfig.K8sNamespace, s.appConfig.GameCatalogName(usage.CatalogChannel))
+			if err != nil {
+				s.logger.Error("failed to load game catalog", zap.String("channel", usage.CatalogChannel), zap.Error(err))
+				return
+			}
+			catalogs[usage.CatalogChannel] = catalog
+		}
+
+		quota := quotaBytes(catalog, string(usage.Game), string(usage.Plan))
+		if quota <= 0 || usage.TxBytes < quota {
+			continue
+		}
+
+		if err := s.email.SendEgressOverageEmail(usage.Email, usage.DisplayName, serverID, formatBytes(usage.TxBytes), formatBytes(quota)); err != nil {
+			// Not marked as alerted, so it's retried on the next run
+			s.logger.Error("failed to send egress overage alert", zap.String("server_id", serverID), zap.Error(err))
+			continue
+		}
+
+		if err := s.db.MarkEgressAlerted(ctx, serverID, usage.Period); err != nil {
+			s.logger.Error("failed to mark egress alerted", zap.String("server_id", serverID), zap.Error(err))
+			continue
+		}
+
+		s.logger.Info("server exceeded egress quota",
+			zap.String("server_id", serverID),
+			zap.Int64("tx_bytes", usage.TxBytes),
+			zap.Int64("quota_bytes", quota),
+		)
+	}
+}
+
+// quotaBytes returns the monthly egress quota of a game's plan, or 0 if it's unlimited or
+// not in the catalog
+func quotaBytes(catalog *k8s.GameCatalog, game, plan string) int64 {
+	gameConfig, err := catalog.GetGameConfig(game)
+	if err != nil {
+		return 0
+	}
+	planConfig, err := gameConfig.GetPlanConfig(plan)
+	if err != nil {
+		return 0
+	}
+	return planConfig.EgressQuotaBytes()
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 TiB"
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTP"[exp])
+}
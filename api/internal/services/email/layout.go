@@ -0,0 +1,47 @@
+package email
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// layoutTemplate is the page every HTML email is rendered into
+var layoutTemplate = template.Must(template.New("layout").Parse(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<meta charset="utf-8">
+		</head>
+		<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+			<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+				<h1 style="color: #4F46E5;">{{.Heading}}</h1>
+				{{.Body}}
+			</div>
+		</body>
+		</html>
+	`))
+
+// layout renders an email's heading and body into the shared page. The body is HTML the
+// caller built, with any user text in it already escaped.
+func layout(heading, body string) string {
+	var b strings.Builder
+	data := struct {
+		Heading string
+		Body    template.HTML
+	}{heading, template.HTML(body)}
+	if err := layoutTemplate.Execute(&b, data); err != nil {
+		// Only fails when writing to b fails, which a strings.Builder doesn't
+		panic(err)
+	}
+	return b.String()
+}
+
+// button renders a call-to-action link to url
+func button(url, label string) string {
+	return fmt.Sprintf(`<p style="margin: 30px 0;">
+					<a href="%s" style="background-color: #4F46E5; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">
+						%s
+					</a>
+				</p>`, template.HTMLEscapeString(url), template.HTMLEscapeString(label))
+}
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"html"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/mooncorn/gshub/api/config"
 )
@@ -24,31 +28,16 @@ func (s *Service) SendVerificationEmail(to, token string) error {
 	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", s.config.FrontendURL, token)
 
 	subject := "Verify your email - GSHUB.PRO"
-	htmlContent := fmt.Sprintf(`
-		<!DOCTYPE html>
-		<html>
-		<head>
-			<meta charset="utf-8">
-		</head>
-		<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
-			<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
-				<h1 style="color: #4F46E5;">Welcome to GSHUB.PRO!</h1>
-				<p>Thank you for creating an account. Please verify your email address by clicking the link below:</p>
-				<p style="margin: 30px 0;">
-					<a href="%s" style="background-color: #4F46E5; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">
-						Verify Email Address
-					</a>
-				</p>
-				<p style="color: #666; font-size: 14px;">
-					If you didn't create this account, you can safely ignore this email.
-				</p>
-				<p style="color: #666; font-size: 14px;">
-					This link will expire in 24 hours.
-				</p>
-			</div>
-		</body>
-		</html>
-	`, verifyURL)
+	htmlContent := layout("Welcome to GSHUB.PRO!", fmt.Sprintf(`
+		<p>Thank you for creating an account. Please verify your email address by clicking the link below:</p>
+		%s
+		<p style="color: #666; font-size: 14px;">
+			If you didn't create this account, you can safely ignore this email.
+		</p>
+		<p style="color: #666; font-size: 14px;">
+			This link will expire in 24 hours.
+		</p>
+	`, button(verifyURL, "Verify Email Address")))
 
 	plainContent := fmt.Sprintf(`
 Welcome to GSHUB.PRO!
@@ -70,31 +59,16 @@ func (s *Service) SendPasswordResetEmail(to, token string) error {
 	resetURL := fmt.Sprintf("%s/reset-password?token=%s", s.config.FrontendURL, token)
 
 	subject := "Reset your password - GSHUB.PRO"
-	htmlContent := fmt.Sprintf(`
-		<!DOCTYPE html>
-		<html>
-		<head>
-			<meta charset="utf-8">
-		</head>
-		<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
-			<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
-				<h1 style="color: #4F46E5;">Password Reset Request</h1>
-				<p>We received a request to reset your password. Click the link below to create a new password:</p>
-				<p style="margin: 30px 0;">
-					<a href="%s" style="background-color: #4F46E5; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">
-						Reset Password
-					</a>
-				</p>
-				<p style="color: #666; font-size: 14px;">
-					If you didn't request a password reset, you can safely ignore this email. Your password will not be changed.
-				</p>
-				<p style="color: #666; font-size: 14px;">
-					This link will expire in 1 hour.
-				</p>
-			</div>
-		</body>
-		</html>
-	`, resetURL)
+	htmlContent := layout("Password Reset Request", fmt.Sprintf(`
+		<p>We received a request to reset your password. Click the link below to create a new password:</p>
+		%s
+		<p style="color: #666; font-size: 14px;">
+			If you didn't request a password reset, you can safely ignore this email. Your password will not be changed.
+		</p>
+		<p style="color: #666; font-size: 14px;">
+			This link will expire in 1 hour.
+		</p>
+	`, button(resetURL, "Reset Password")))
 
 	plainContent := fmt.Sprintf(`
 Password Reset Request
@@ -111,6 +85,438 @@ This link will expire in 1 hour.
 	return s.sendEmail(to, subject, plainContent, htmlContent)
 }
 
+// SendServerDeletionWarningEmail warns that an expired server's data is about to be
+// permanently deleted, with a link to restore it by resubscribing
+func (s *Service) SendServerDeletionWarningEmail(to, serverName, serverID string, deleteAfter time.Time) error {
+	serverURL := fmt.Sprintf("%s/servers/%s", s.config.FrontendURL, serverID)
+	deleteDate := deleteAfter.UTC().Format("January 2, 2006 at 15:04 UTC")
+
+	subject := fmt.Sprintf("%s will be permanently deleted - GSHUB.PRO", serverName)
+	htmlContent := layout("Your server will be deleted soon", fmt.Sprintf(`
+		<p>Your server <strong>%s</strong> has no active subscription. Its world and files will be permanently deleted on <strong>%s</strong>.</p>
+		<p>To keep your data, restore the server by resubscribing before then:</p>
+		%s
+		<p style="color: #666; font-size: 14px;">
+			If you no longer need this server, you don't have to do anything.
+		</p>
+	`, html.EscapeString(serverName), deleteDate, button(serverURL, "Restore Server")))
+
+	plainContent := fmt.Sprintf(`
+Your server will be deleted soon
+
+Your server %s has no active subscription. Its world and files will be permanently deleted on %s.
+
+To keep your data, restore the server by resubscribing before then:
+
+%s
+
+If you no longer need this server, you don't have to do anything.
+	`, serverName, deleteDate, serverURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendStoppedServerReminderEmail reminds an owner that a long-stopped server is still billed,
+// with a signed one-click link to cancel its subscription and a link to change to a cheaper plan
+func (s *Service) SendStoppedServerReminderEmail(to, serverName, serverID, cancelURL string, stoppedFor time.Duration, monthlyCost string) error {
+	serverURL := fmt.Sprintf("%s/servers/%s", s.config.FrontendURL, serverID)
+	days := int(stoppedFor.Hours() / 24)
+
+	subject := fmt.Sprintf("%s is stopped but still billed - GSHUB.PRO", serverName)
+	htmlContent := layout("Your server is still being billed", fmt.Sprintf(`
+		<p>Your server <strong>%s</strong> has been stopped for %d days. Its subscription is still active and costs <strong>%s per month</strong>.</p>
+		<p>If you no longer play on it, you can cancel the subscription with one click, no sign-in needed. The server keeps running until the end of the billing period:</p>
+		%s
+		<p>Or keep the server and <a href="%s">switch to a smaller plan</a>.</p>
+		<p style="color: #666; font-size: 14px;">
+			You can turn on automatic cancellation for stopped servers in your billing settings. We won't remind you again until this server is started and stopped again.
+		</p>
+	`, html.EscapeString(serverName), days, monthlyCost, button(cancelURL, "Cancel Subscription"), serverURL))
+
+	plainContent := fmt.Sprintf(`
+Your server is still being billed
+
+Your server %s has been stopped for %d days. Its subscription is still active and costs %s per month.
+
+If you no longer play on it, you can cancel the subscription with one click, no sign-in needed. The server keeps running until the end of the billing period:
+
+%s
+
+Or keep the server and switch to a smaller plan:
+
+%s
+
+You can turn on automatic cancellation for stopped servers in your billing settings. We won't remind you again until this server is started and stopped again.
+	`, serverName, days, monthlyCost, cancelURL, serverURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendStoppedServerCancelledEmail tells an owner that a long-stopped server's subscription
+// was cancelled because they enabled auto-cancel
+func (s *Service) SendStoppedServerCancelledEmail(to, serverName string, stoppedFor time.Duration) error {
+	billingURL := fmt.Sprintf("%s/settings/billing", s.config.FrontendURL)
+	days := int(stoppedFor.Hours() / 24)
+
+	subject := fmt.Sprintf("Subscription for %s cancelled - GSHUB.PRO", serverName)
+	htmlContent := layout("Subscription cancelled", fmt.Sprintf(`
+		<p>Your server <strong>%s</strong> has been stopped for %d days, so its subscription was cancelled as you requested. It will end at the close of the current billing period.</p>
+		<p>Changed your mind? You can resume the subscription before then:</p>
+		%s
+	`, html.EscapeString(serverName), days, button(billingURL, "Manage Billing")))
+
+	plainContent := fmt.Sprintf(`
+Subscription cancelled
+
+Your server %s has been stopped for %d days, so its subscription was cancelled as you requested. It will end at the close of the current billing period.
+
+Changed your mind? You can resume the subscription before then:
+
+%s
+	`, serverName, days, billingURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendSpendLimitAlertEmail warns that a user's projected charges for the coming month
+// exceed the spending limit they set
+func (s *Service) SendSpendLimitAlertEmail(to, projected, limit string) error {
+	billingURL := fmt.Sprintf("%s/settings/billing", s.config.FrontendURL)
+
+	subject := "You're over your monthly spending limit - GSHUB.PRO"
+	htmlContent := layout("Spending limit exceeded", fmt.Sprintf(`
+		<p>Your upcoming charges are projected at <strong>%s</strong>, which is over the monthly limit of <strong>%s</strong> you set.</p>
+		<p>Review your subscriptions to cancel or downgrade servers you don't need, or raise your limit:</p>
+		%s
+		<p style="color: #666; font-size: 14px;">
+			We'll alert you at most once a month.
+		</p>
+	`, projected, limit, button(billingURL, "Manage Billing")))
+
+	plainContent := fmt.Sprintf(`
+Spending limit exceeded
+
+Your upcoming charges are projected at %s, which is over the monthly limit of %s you set.
+
+Review your subscriptions to cancel or downgrade servers you don't need, or raise your limit:
+
+%s
+
+We'll alert you at most once a month.
+	`, projected, limit, billingURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendCardExpiringEmail warns that the card a user's subscriptions are charged with expires
+// before their next renewal, so the renewal would fail
+func (s *Service) SendCardExpiringEmail(to, card, expires string, renewsAt time.Time) error {
+	billingURL := fmt.Sprintf("%s/settings/billing", s.config.FrontendURL)
+	renewal := renewsAt.Format("January 2, 2006")
+
+	subject := "Your card expires before your next renewal - GSHUB.PRO"
+	htmlContent := layout("Your card is about to expire", fmt.Sprintf(`
+		<p>Your <strong>%s</strong> expires at the end of <strong>%s</strong>, before your subscription renews on <strong>%s</strong>.</p>
+		<p>If the renewal can't be charged, your servers are stopped and their data is deleted after the grace period. Update your payment method from the billing page to avoid this:</p>
+		%s
+		<p style="color: #666; font-size: 14px;">
+			We'll only remind you once about this card.
+		</p>
+	`, html.EscapeString(card), expires, renewal, button(billingURL, "Update Payment Method")))
+
+	plainContent := fmt.Sprintf(`
+Your card is about to expire
+
+Your %s expires at the end of %s, before your subscription renews on %s.
+
+If the renewal can't be charged, your servers are stopped and their data is deleted after the grace period. Update your payment method from the billing page to avoid this:
+
+%s
+
+We'll only remind you once about this card.
+	`, card, expires, renewal, billingURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendDisputeAlertEmail notifies an admin that a payment was disputed and the
+// affected server was suspended
+func (s *Service) SendDisputeAlertEmail(to, disputeID, reason, amount, userEmail, serverID string) error {
+	subject := fmt.Sprintf("Payment dispute %s - GSHUB.PRO", disputeID)
+	htmlContent := layout("Payment disputed", fmt.Sprintf(`
+		<p>Stripe dispute <strong>%s</strong> was opened for <strong>%s</strong> (reason: %s).</p>
+		<p>User: %s<br>Server: %s</p>
+		<p>The server was suspended and the user flagged. Lift the suspension with POST /admin/servers/{id}/lift-suspension once the dispute is resolved.</p>
+	`, disputeID, amount, reason, userEmail, serverID))
+
+	plainContent := fmt.Sprintf(`
+Payment disputed
+
+Stripe dispute %s was opened for %s (reason: %s).
+
+User: %s
+Server: %s
+
+The server was suspended and the user flagged. Lift the suspension with POST /admin/servers/{id}/lift-suspension once the dispute is resolved.
+	`, disputeID, amount, reason, userEmail, serverID)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendAbuseAlertEmail notifies an admin that a server was suspended by an abuse rule
+func (s *Service) SendAbuseAlertEmail(to, serverID, userEmail, rule, detail string) error {
+	subject := fmt.Sprintf("Server suspended for abuse (%s) - GSHUB.PRO", rule)
+	htmlContent := layout("Server suspended", fmt.Sprintf(`
+		<p>The <strong>%s</strong> abuse rule suspended a server: %s</p>
+		<p>User: %s<br>Server: %s</p>
+		<p>Review it and lift the suspension with POST /admin/servers/{id}/lift-suspension if it was a false positive.</p>
+	`, rule, detail, userEmail, serverID))
+
+	plainContent := fmt.Sprintf(`
+Server suspended
+
+The %s abuse rule suspended a server: %s
+
+User: %s
+Server: %s
+
+Review it and lift the suspension with POST /admin/servers/{id}/lift-suspension if it was a false positive.
+	`, rule, detail, userEmail, serverID)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendEgressOverageEmail tells an owner that a server sent more traffic this month than
+// its plan includes
+func (s *Service) SendEgressOverageEmail(to, serverName, serverID, used, quota string) error {
+	serverURL := fmt.Sprintf("%s/servers/%s", s.config.FrontendURL, serverID)
+
+	subject := fmt.Sprintf("%s exceeded its monthly traffic - GSHUB.PRO", serverName)
+	htmlContent := layout("Monthly traffic exceeded", fmt.Sprintf(`
+		<p>Your server <strong>%s</strong> has sent <strong>%s</strong> this month, more than the <strong>%s</strong> included in its plan.</p>
+		<p>If this traffic is unexpected, check who is connected to your server. A larger plan includes more traffic:</p>
+		%s
+		<p style="color: #666; font-size: 14px;">
+			We'll only send this once per month.
+		</p>
+	`, html.EscapeString(serverName), used, quota, button(serverURL, "View Server")))
+
+	plainContent := fmt.Sprintf(`
+Monthly traffic exceeded
+
+Your server %s has sent %s this month, more than the %s included in its plan.
+
+If this traffic is unexpected, check who is connected to your server. A larger plan includes more traffic:
+
+%s
+
+We'll only send this once per month.
+	`, serverName, used, quota, serverURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// PlanRecommendation is one line of the monthly plan recommendations summary
+type PlanRecommendation struct {
+	ServerName string
+	ServerID   string
+	Summary    string // e.g. "Upgrade from small to medium: peak memory is close to the plan's limit"
+	PriceDelta string // e.g. "+5.00 USD per month", empty if unknown
+}
+
+// SendPlanRecommendationsEmail sends the monthly summary of plan changes recommended from
+// a user's servers' CPU and memory usage
+func (s *Service) SendPlanRecommendationsEmail(to string, recommendations []PlanRecommendation) error {
+	dashboardURL := fmt.Sprintf("%s/dashboard", s.config.FrontendURL)
+
+	var htmlItems, plainItems strings.Builder
+	for _, rec := range recommendations {
+		serverURL := fmt.Sprintf("%s/servers/%s", s.config.FrontendURL, rec.ServerID)
+		cost := ""
+		if rec.PriceDelta != "" {
+			cost = " (" + rec.PriceDelta + ")"
+		}
+		fmt.Fprintf(&htmlItems, `<li><a href="%s"><strong>%s</strong></a>: %s%s</li>`,
+			serverURL, html.EscapeString(rec.ServerName), html.EscapeString(rec.Summary), html.EscapeString(cost))
+		fmt.Fprintf(&plainItems, "- %s: %s%s\n  %s\n", rec.ServerName, rec.Summary, cost, serverURL)
+	}
+
+	subject := "Plan recommendations for your servers - GSHUB.PRO"
+	htmlContent := layout("Plan recommendations", fmt.Sprintf(`
+		<p>Based on how much CPU and memory your servers used over the last week, these plan changes could save money or keep them running smoothly:</p>
+		<ul>%s</ul>
+		%s
+		<p style="color: #666; font-size: 14px;">
+			We'll send this summary at most once a month.
+		</p>
+	`, htmlItems.String(), button(dashboardURL, "View Servers")))
+
+	plainContent := fmt.Sprintf(`
+Plan recommendations
+
+Based on how much CPU and memory your servers used over the last week, these plan changes could save money or keep them running smoothly:
+
+%s
+%s
+
+We'll send this summary at most once a month.
+	`, plainItems.String(), dashboardURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// ServerDigest is one server's section of the weekly digest
+type ServerDigest struct {
+	ServerName string
+	ServerID   string
+	Stats      []string // e.g. "Uptime: 42 hours", "Peak players: 12"
+}
+
+// SendWeeklyDigestEmail sends the weekly summary of each of a user's servers' activity
+func (s *Service) SendWeeklyDigestEmail(to string, servers []ServerDigest) error {
+	dashboardURL := fmt.Sprintf("%s/dashboard", s.config.FrontendURL)
+
+	var htmlItems, plainItems strings.Builder
+	for _, server := range servers {
+		serverURL := fmt.Sprintf("%s/servers/%s", s.config.FrontendURL, server.ServerID)
+		fmt.Fprintf(&htmlItems, `<h2 style="font-size: 18px; margin-bottom: 4px;"><a href="%s">%s</a></h2><ul style="margin-top: 0;">`,
+			serverURL, html.EscapeString(server.ServerName))
+		fmt.Fprintf(&plainItems, "%s\n%s\n", server.ServerName, serverURL)
+		for _, stat := range server.Stats {
+			fmt.Fprintf(&htmlItems, "<li>%s</li>", html.EscapeString(stat))
+			fmt.Fprintf(&plainItems, "- %s\n", stat)
+		}
+		htmlItems.WriteString("</ul>")
+		plainItems.WriteString("\n")
+	}
+
+	subject := "Your weekly server summary - GSHUB.PRO"
+	htmlContent := layout("Your week in review", fmt.Sprintf(`
+		<p>Here's how your servers did over the last 7 days:</p>
+		%s
+		%s
+		<p style="color: #666; font-size: 14px;">
+			You can turn off this weekly summary in your email preferences.
+		</p>
+	`, htmlItems.String(), button(dashboardURL, "View Servers")))
+
+	plainContent := fmt.Sprintf(`
+Your week in review
+
+Here's how your servers did over the last 7 days:
+
+%s%s
+
+You can turn off this weekly summary in your email preferences.
+	`, plainItems.String(), dashboardURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendAccountSuspendedEmail tells a user their account was suspended and how to ask
+// for reinstatement
+func (s *Service) SendAccountSuspendedEmail(to, reason string) error {
+	billingURL := fmt.Sprintf("%s/settings/billing", s.config.FrontendURL)
+
+	subject := "Your account has been suspended - GSHUB.PRO"
+	htmlContent := layout("Account suspended", fmt.Sprintf(`
+		<p>Your account has been suspended: %s</p>
+		<p>You can still sign in and view your servers and billing, but you can't start servers or make purchases. Running servers are not affected.</p>
+		<p>If you believe this is a mistake, you can request reinstatement from your billing settings:</p>
+		%s
+	`, html.EscapeString(reason), button(billingURL, "Request Reinstatement")))
+
+	plainContent := fmt.Sprintf(`
+Account suspended
+
+Your account has been suspended: %s
+
+You can still sign in and view your servers and billing, but you can't start servers or make purchases. Running servers are not affected.
+
+If you believe this is a mistake, you can request reinstatement from your billing settings:
+
+%s
+	`, reason, billingURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendCapacityAvailableEmail offers a user on the waitlist the capacity for a server of a
+// game and plan, through a checkout link that works until expiresAt
+func (s *Service) SendCapacityAvailableEmail(to, waitlistID, game, plan string, expiresAt time.Time) error {
+	createURL := fmt.Sprintf("%s/servers/new?game=%s&plan=%s&waitlist=%s",
+		s.config.FrontendURL, url.QueryEscape(game), url.QueryEscape(plan), url.QueryEscape(waitlistID))
+	expires := expiresAt.UTC().Format("January 2, 2006 at 15:04 UTC")
+
+	subject := "Server capacity is available - GSHUB.PRO"
+	htmlContent := layout("Capacity is available", fmt.Sprintf(`
+		<p>It's your turn on the waitlist: there's room for a <strong>%s</strong> server on the <strong>%s</strong> plan, held for you until <strong>%s</strong>.</p>
+		%s
+		<p style="color: #666; font-size: 14px;">After that, the next person in line is offered it.</p>
+	`, game, plan, expires, button(createURL, "Create Server")))
+
+	plainContent := fmt.Sprintf(`
+Capacity is available
+
+It's your turn on the waitlist: there's room for a %s server on the %s plan, held for you until %s.
+
+%s
+
+After that, the next person in line is offered it.
+	`, game, plan, expires, createURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendAccountReinstatedEmail tells a user their account suspension was lifted
+func (s *Service) SendAccountReinstatedEmail(to string) error {
+	dashboardURL := fmt.Sprintf("%s/dashboard", s.config.FrontendURL)
+
+	subject := "Your account has been reinstated - GSHUB.PRO"
+	htmlContent := layout("Account reinstated", fmt.Sprintf(`
+		<p>Your account suspension has been lifted. You can start servers and make purchases again.</p>
+		%s
+	`, button(dashboardURL, "Go to Dashboard")))
+
+	plainContent := fmt.Sprintf(`
+Account reinstated
+
+Your account suspension has been lifted. You can start servers and make purchases again.
+
+%s
+	`, dashboardURL)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
+// SendReinstatementRequestEmail notifies an admin that a suspended user asked to be reinstated
+func (s *Service) SendReinstatementRequestEmail(to, userEmail, userID, reason, message string) error {
+	subject := fmt.Sprintf("Reinstatement request from %s - GSHUB.PRO", userEmail)
+	htmlContent := layout("Reinstatement requested", fmt.Sprintf(`
+		<p><strong>%s</strong> (%s) asked to have their account reinstated.</p>
+		<p>Suspended for: %s</p>
+		<p>Their message:</p>
+		<p style="white-space: pre-wrap;">%s</p>
+		<p>Reinstate with POST /admin/users/{id}/reinstate.</p>
+	`, html.EscapeString(userEmail), userID, html.EscapeString(reason), html.EscapeString(message)))
+
+	plainContent := fmt.Sprintf(`
+Reinstatement requested
+
+%s (%s) asked to have their account reinstated.
+
+Suspended for: %s
+
+Their message:
+
+%s
+
+Reinstate with POST /admin/users/{id}/reinstate.
+	`, userEmail, userID, reason, message)
+
+	return s.sendEmail(to, subject, plainContent, htmlContent)
+}
+
 // MailerSendRequest represents the MailerSend API request structure
 type MailerSendRequest struct {
 	From    EmailAddress   `json:"from"`
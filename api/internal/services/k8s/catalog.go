@@ -2,9 +2,15 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mooncorn/gshub/api/internal/models"
 
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -15,16 +21,94 @@ type GameCatalog struct {
 
 // GameConfig holds configuration for a specific game
 type GameConfig struct {
-	Name              string                `yaml:"name"`
-	Image             string                `yaml:"image"`             // Legacy: game server image (used with Agones)
-	SupervisorImage   string                `yaml:"supervisorImage"`   // Supervisor image (includes game server)
-	Ports             []GamePort            `yaml:"ports"`
-	Volumes           []GameVolume          `yaml:"volumes"`
-	Env               map[string]string     `yaml:"env"`
-	HealthCheck       *HealthCheckConfig    `yaml:"healthCheck"`
-	Process           *ProcessConfig        `yaml:"process"`           // Supervisor process configuration
-	SupervisorOverhead *ResourceOverhead    `yaml:"supervisorOverhead"` // Additional resources for supervisor
-	Plans             map[string]PlanConfig `yaml:"plans"`
+	Name               string                `yaml:"name"`
+	Image              string                `yaml:"image"`           // Legacy: game server image (used with Agones)
+	SupervisorImage    string                `yaml:"supervisorImage"` // Supervisor image (includes game server)
+	Ports              []GamePort            `yaml:"ports"`
+	Volumes            []GameVolume          `yaml:"volumes"`
+	Env                map[string]string     `yaml:"env"`
+	HealthCheck        *HealthCheckConfig    `yaml:"healthCheck"`
+	Probes             *ProbesConfig         `yaml:"probes"`             // Pod probe timing; defaults when unset
+	Process            *ProcessConfig        `yaml:"process"`            // Supervisor process configuration
+	SupervisorOverhead *ResourceOverhead     `yaml:"supervisorOverhead"` // Additional resources for supervisor
+	Plans              map[string]PlanConfig `yaml:"plans"`
+
+	// OS is the operating system the game's image runs on, "linux" (the default) or
+	// "windows". Windows games only run on Windows nodes, and their image must include the
+	// Windows build of the supervisor.
+	OS string `yaml:"os"`
+
+	// SRVService is the service name game clients look up SRV records for, e.g. "minecraft"
+	// for _minecraft._tcp.<domain> (empty when the game client doesn't use SRV records)
+	SRVService string `yaml:"srvService"`
+
+	// Custom games take their image, ports, start command and health check from each server's
+	// models.CustomGame (see WithCustomGame). supervisorImage is then the plain supervisor
+	// image, whose binary is copied into the user's image when the pod starts.
+	Custom bool `yaml:"custom"`
+
+	// Mods lets owners install mods or plugins from Modrinth into the data volume, for the
+	// mod loader the server's env selects
+	Mods *ModsConfig `yaml:"mods"`
+
+	// Steam is set for dedicated servers that need a Steam game server login token
+	Steam *SteamConfig `yaml:"steam"`
+}
+
+// SteamConfig describes the Steam game server login token a game's dedicated server reads
+// from its env. Owners whose linked Steam account owns AppID can have one created for them.
+type SteamConfig struct {
+	AppID    int    `yaml:"appId"`    // Steam app the token is created for
+	TokenEnv string `yaml:"tokenEnv"` // Env var the server reads the token from, e.g. RUST_SERVER_TOKEN
+}
+
+// ModsConfig describes the mod loaders a game's servers can run. The server's env picks one:
+// the value of LoaderEnv (e.g. TYPE=FABRIC) matches a loader's EnvValue, and VersionEnv holds
+// the game version mods must support (e.g. VERSION=1.21.1; unset or "LATEST" matches any).
+type ModsConfig struct {
+	LoaderEnv  string      `yaml:"loaderEnv"`
+	VersionEnv string      `yaml:"versionEnv"`
+	Loaders    []ModLoader `yaml:"loaders"`
+}
+
+// ModLoader is a mod loader or plugin platform mods can be installed for
+type ModLoader struct {
+	Name        string `yaml:"name"`        // Modrinth loader, e.g. "fabric" or "paper"
+	EnvValue    string `yaml:"envValue"`    // Value of the game's loaderEnv that selects it
+	ProjectType string `yaml:"projectType"` // Modrinth project type searched: "mod" (default) or "plugin"
+	Dir         string `yaml:"dir"`         // Directory in the data volume files go to, e.g. "mods"
+
+	// SteamAppID, if set, is a Steam app the owner's linked Steam account must own to install
+	// mods, e.g. a paid game or DLC whose Workshop content the mods come from
+	SteamAppID int `yaml:"steamAppId"`
+}
+
+// ServerModLoader returns the mod loader env selects and the game version mods must support (""
+// for any), or nil if the game has no mods or env selects no loader
+func (game *GameConfig) ServerModLoader(env map[string]string) (*ModLoader, string) {
+	if game.Mods == nil {
+		return nil, ""
+	}
+	value := env[game.Mods.LoaderEnv]
+	for i := range game.Mods.Loaders {
+		loader := &game.Mods.Loaders[i]
+		if strings.EqualFold(loader.EnvValue, value) {
+			version := env[game.Mods.VersionEnv]
+			if strings.EqualFold(version, "latest") {
+				version = ""
+			}
+			return loader, version
+		}
+	}
+	return nil, ""
+}
+
+// SearchProjectType returns the Modrinth project type searched for the loader's mods
+func (loader *ModLoader) SearchProjectType() string {
+	if loader.ProjectType == "" {
+		return "mod"
+	}
+	return loader.ProjectType
 }
 
 // ProcessConfig holds configuration for the supervisor process management
@@ -33,6 +117,45 @@ type ProcessConfig struct {
 	WorkDir      string   `yaml:"workDir"`      // Working directory for the game process
 	GracePeriod  int      `yaml:"gracePeriod"`  // Seconds to wait for graceful shutdown
 	StopCommand  []string `yaml:"stopCommand"`  // Optional command to stop gracefully (e.g., RCON)
+	LogFormat    string   `yaml:"logFormat"`    // Supervisor parser that tags output lines with severity
+
+	// Live reload: config files rendered from env by the supervisor, and a command that makes
+	// the running game pick them up. With a reloadCommand, env changes apply without a redeploy.
+	ConfigTemplates []ConfigTemplate `yaml:"configTemplates"`
+	ReloadCommand   []string         `yaml:"reloadCommand"`
+
+	// Backups: a command run on request, and the directory (inside the data volume) it writes
+	// archives to. The newest archive can be downloaded once the backup completes.
+	BackupCommand []string `yaml:"backupCommand"`
+	BackupDir     string   `yaml:"backupDir"`
+
+	// World imports: the directory (inside the data volume) an uploaded archive replaces, and
+	// files the archive must contain for the game to load it, e.g. level.dat. Imports are off
+	// without an importDir.
+	ImportDir           string   `yaml:"importDir"`
+	ImportRequiredFiles []string `yaml:"importRequiredFiles"`
+
+	// Console commands: run over RCON on localhost when rconPort is set, with the password in
+	// the game's rconPasswordEnv env var (generated by the supervisor when unset), and written
+	// to the game's stdin otherwise
+	RCONPort        int    `yaml:"rconPort"`
+	RCONPasswordEnv string `yaml:"rconPasswordEnv"`
+
+	// Startup progress: milestones in the game's output the supervisor reports as a
+	// percentage while the server starts, e.g. Minecraft's "Preparing spawn area: (\d+)%"
+	ProgressPatterns []ProgressPattern `yaml:"progressPatterns"`
+}
+
+// ProgressPattern is a startup milestone in the game's output
+type ProgressPattern struct {
+	Pattern string `yaml:"pattern" json:"pattern"` // Regex whose first group captures a percentage
+	Label   string `yaml:"label" json:"label"`     // What the game is busy with, e.g. "Preparing spawn area"
+}
+
+// ConfigTemplate is a game config file the supervisor renders from env (${VAR} syntax)
+type ConfigTemplate struct {
+	Path     string `yaml:"path" json:"path"`         // Absolute path of the rendered file
+	Template string `yaml:"template" json:"template"` // File contents with ${VAR} placeholders
 }
 
 // ResourceOverhead holds additional resource requirements for the supervisor
@@ -52,10 +175,53 @@ type HealthCheckConfig struct {
 	Interval     string `yaml:"interval"`     // Check interval (e.g., "10" for seconds)
 }
 
+// ProbesConfig tunes the supervisor container's probes. Readiness follows the game's health
+// checks, so games that take long to start want a longer initial delay.
+type ProbesConfig struct {
+	Liveness  *ProbeConfig `yaml:"liveness"`
+	Readiness *ProbeConfig `yaml:"readiness"`
+}
+
+// ProbeConfig is the timing of a K8s probe; zero fields keep the default
+type ProbeConfig struct {
+	InitialDelaySeconds int32 `yaml:"initialDelaySeconds"`
+	PeriodSeconds       int32 `yaml:"periodSeconds"`
+	FailureThreshold    int32 `yaml:"failureThreshold"`
+}
+
+// LivenessProbe returns the game's liveness probe timing, or nil for the defaults
+func (game *GameConfig) LivenessProbe() *ProbeConfig {
+	if game.Probes == nil {
+		return nil
+	}
+	return game.Probes.Liveness
+}
+
+// ReadinessProbe returns the game's readiness probe timing, or nil for the defaults
+func (game *GameConfig) ReadinessProbe() *ProbeConfig {
+	if game.Probes == nil {
+		return nil
+	}
+	return game.Probes.Readiness
+}
+
+// Port returns the game's port named name, or nil
+func (game *GameConfig) Port(name string) *GamePort {
+	for i := range game.Ports {
+		if game.Ports[i].Name == name {
+			return &game.Ports[i]
+		}
+	}
+	return nil
+}
+
 type GamePort struct {
 	Name     string `yaml:"name"`
 	Port     int32  `yaml:"port"`
 	Protocol string `yaml:"protocol"`
+	// SamePort names a port of the other protocol that must get the same host port number,
+	// for games that listen on one number over both TCP and UDP
+	SamePort string `yaml:"samePort"`
 }
 
 type GameVolume struct {
@@ -71,11 +237,79 @@ type PlanConfig struct {
 	Memory  string            `yaml:"memory"`
 	Storage string            `yaml:"storage"`
 	Env     map[string]string `yaml:"env"` // Plan-level environment variables
+	GPU     int               `yaml:"gpu"` // nvidia.com/gpu count (0 = none)
+
+	// Dedicated plans get a whole node tainted with DedicatedNodeTaintKey to themselves
+	Dedicated bool `yaml:"dedicated"`
+
+	// Spot (budget) plans run only on spot nodes, which are cheaper but may be reclaimed by
+	// their provider at short notice; their servers are then moved to another spot node
+	Spot bool `yaml:"spot"`
+
+	// Performance plans run with limits equal to requests (Guaranteed QoS) so they aren't
+	// throttled or evicted in favor of other pods. With PinCPUs the CPU request is rounded up
+	// to whole cores, which the kubelet's static CPU manager pins to exclusive cores.
+	Performance bool `yaml:"performance"`
+	PinCPUs     bool `yaml:"pinCPUs"`
+
+	// MaxPerNode caps how many servers on plans with this name share a node, on top of the
+	// resource math, to limit noisy neighbors (0 = no cap)
+	MaxPerNode int `yaml:"maxPerNode"`
+
+	// EgressBandwidth caps the server's outbound traffic in bits per second (e.g. "100M"),
+	// enforced by the CNI bandwidth plugin. EgressQuota is the monthly outbound traffic in
+	// bytes (e.g. "1Ti") after which the owner is alerted. Empty means unlimited.
+	EgressBandwidth string `yaml:"egressBandwidth"`
+	EgressQuota     string `yaml:"egressQuota"`
+
+	// Backup limits: how many daily and weekly backups servers on the plan can keep (0 means
+	// the defaults below), and whether they can replicate backups off-site
+	BackupMaxDaily    int  `yaml:"backupMaxDaily"`
+	BackupMaxWeekly   int  `yaml:"backupMaxWeekly"`
+	BackupReplication bool `yaml:"backupReplication"`
+
+	// VanityPorts lets servers on the plan request the port number of their game port
+	VanityPorts bool `yaml:"vanityPorts"`
+}
+
+const (
+	// defaultBackupMaxDaily and defaultBackupMaxWeekly are the backup limits of plans that
+	// don't set their own
+	defaultBackupMaxDaily  = 7
+	defaultBackupMaxWeekly = 4
+)
+
+// BackupLimits returns the backup retention and replication the plan allows
+func (plan *PlanConfig) BackupLimits() models.BackupLimits {
+	limits := models.BackupLimits{
+		MaxDaily:    plan.BackupMaxDaily,
+		MaxWeekly:   plan.BackupMaxWeekly,
+		Replication: plan.BackupReplication,
+	}
+	if limits.MaxDaily == 0 {
+		limits.MaxDaily = defaultBackupMaxDaily
+	}
+	if limits.MaxWeekly == 0 {
+		limits.MaxWeekly = defaultBackupMaxWeekly
+	}
+	return limits
+}
+
+// EgressQuotaBytes returns the plan's monthly egress quota in bytes, or 0 if unlimited
+func (plan *PlanConfig) EgressQuotaBytes() int64 {
+	if plan.EgressQuota == "" {
+		return 0
+	}
+	q, err := resource.ParseQuantity(plan.EgressQuota)
+	if err != nil {
+		return 0
+	}
+	return q.Value()
 }
 
 // LoadGameCatalog reads the game-catalog ConfigMap from Kubernetes
 func (c *Client) LoadGameCatalog(ctx context.Context, namespace, configMapName string) (*GameCatalog, error) {
-	cm, err := c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, configMapName, metav1.GetOptions{})
+	cm, err := c.forNamespace(namespace).CoreV1().ConfigMaps(namespace).Get(ctx, configMapName, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ConfigMap: %w", err)
 	}
@@ -85,8 +319,13 @@ func (c *Client) LoadGameCatalog(ctx context.Context, namespace, configMapName s
 		return nil, fmt.Errorf("games.yaml not found in ConfigMap")
 	}
 
+	return ParseGameCatalog([]byte(catalogYAML))
+}
+
+// ParseGameCatalog parses the contents of games.yaml
+func ParseGameCatalog(data []byte) (*GameCatalog, error) {
 	var catalog GameCatalog
-	if err := yaml.Unmarshal([]byte(catalogYAML), &catalog); err != nil {
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
 		return nil, fmt.Errorf("failed to parse games.yaml: %w", err)
 	}
 
@@ -102,6 +341,17 @@ func (catalog *GameCatalog) GetGameConfig(game string) (*GameConfig, error) {
 	return &config, nil
 }
 
+// SupportsLiveReload reports whether env changes can be applied to a running server
+// by re-rendering its config templates and running its reload command
+func (game *GameConfig) SupportsLiveReload() bool {
+	return game.Process != nil && len(game.Process.ReloadCommand) > 0
+}
+
+// SupportsImport reports whether the game's worlds can be imported from an archive
+func (game *GameConfig) SupportsImport() bool {
+	return game.Process != nil && game.Process.ImportDir != ""
+}
+
 // GetPlanConfig retrieves configuration for a specific plan
 func (game *GameConfig) GetPlanConfig(plan string) (*PlanConfig, error) {
 	config, ok := game.Plans[plan]
@@ -111,6 +361,206 @@ func (game *GameConfig) GetPlanConfig(plan string) (*PlanConfig, error) {
 	return &config, nil
 }
 
+// RoundCPU rounds a server's CPU (plan plus supervisor overhead, in millicores) up to
+// whole cores when the plan pins CPUs, so reservations match what the pod requests
+func (plan *PlanConfig) RoundCPU(millicores int) int {
+	if plan.Performance && plan.PinCPUs {
+		return (millicores + 999) / 1000 * 1000
+	}
+	return millicores
+}
+
+// Supervisor resources added to every plan unless the game sets supervisorOverhead
+const (
+	defaultSupervisorCPU    = 50               // 50m
+	defaultSupervisorMemory = 64 * 1024 * 1024 // 64Mi
+)
+
+// ServerResources returns the CPU (millicores) and memory (bytes) a server on plan needs:
+// the plan plus supervisor overhead, with CPU rounded for pinned plans
+func (game *GameConfig) ServerResources(plan *PlanConfig) (int, int64) {
+	supervisorCPU := defaultSupervisorCPU
+	supervisorMem := int64(defaultSupervisorMemory)
+	if game.SupervisorOverhead != nil {
+		if game.SupervisorOverhead.CPU != "" {
+			supervisorCPU = parseCPUToMillicores(game.SupervisorOverhead.CPU)
+		}
+		if game.SupervisorOverhead.Memory != "" {
+			supervisorMem = parseMemoryToBytes(game.SupervisorOverhead.Memory)
+		}
+	}
+
+	cpuMillicores := plan.RoundCPU(parseCPUToMillicores(plan.CPU) + supervisorCPU)
+	memBytes := parseMemoryToBytes(plan.Memory) + supervisorMem
+	return cpuMillicores, memBytes
+}
+
+// Operating systems game images run on, as in the kubernetes.io/os node label
+const (
+	OSLinux   = "linux"
+	OSWindows = "windows"
+)
+
+// NodeOS returns the operating system of the nodes the game runs on
+func (game *GameConfig) NodeOS() string {
+	if game.OS == "" {
+		return OSLinux
+	}
+	return game.OS
+}
+
+// ContainerImage returns the image game servers run (prefers supervisorImage, falls back to
+// the legacy image). Custom games run the user's image.
+func (game *GameConfig) ContainerImage() string {
+	if game.Custom {
+		return game.Image
+	}
+	if game.SupervisorImage != "" {
+		return game.SupervisorImage
+	}
+	return game.Image
+}
+
+// InjectedSupervisorImage returns the image the supervisor binary is copied from for games
+// whose image doesn't include it, or "" if the game's image does
+func (game *GameConfig) InjectedSupervisorImage() string {
+	if game.Custom {
+		return game.SupervisorImage
+	}
+	return ""
+}
+
+// defaultCustomGameDataPath is where custom games' volume is mounted unless they choose
+const defaultCustomGameDataPath = "/data"
+
+// WithCustomGame returns the custom game entry with a server's definition applied: the
+// catalog supplies plans and supervisor settings, the definition everything else
+func (game *GameConfig) WithCustomGame(def *models.CustomGame) *GameConfig {
+	resolved := *game
+	resolved.Image = def.Image
+
+	resolved.Ports = make([]GamePort, len(def.Ports))
+	for i, port := range def.Ports {
+		resolved.Ports[i] = GamePort{Name: port.Name, Port: port.Port, Protocol: port.Protocol, SamePort: port.SamePort}
+	}
+
+	dataPath := def.DataPath
+	if dataPath == "" {
+		dataPath = defaultCustomGameDataPath
+	}
+	resolved.Volumes = []GameVolume{{Name: "data", MountPath: dataPath, SubPath: "data"}}
+
+	process := ProcessConfig{}
+	if game.Process != nil {
+		process.GracePeriod = game.Process.GracePeriod
+	}
+	process.StartCommand = def.StartCommand
+	process.WorkDir = def.WorkDir
+	if process.WorkDir == "" {
+		process.WorkDir = dataPath
+	}
+	resolved.Process = &process
+
+	resolved.HealthCheck = nil
+	if hc := def.HealthCheck; hc != nil {
+		resolved.HealthCheck = &HealthCheckConfig{Type: hc.Type, Protocol: hc.Protocol, Pattern: hc.Pattern}
+		if hc.Port != 0 {
+			resolved.HealthCheck.Port = strconv.Itoa(int(hc.Port))
+		}
+	}
+
+	return &resolved
+}
+
+// SupervisorEnv returns the env vars that configure the supervisor's process management and
+// health checks for the game
+func (game *GameConfig) SupervisorEnv() map[string]string {
+	env := map[string]string{}
+
+	if game.Process != nil {
+		if len(game.Process.StartCommand) > 0 {
+			cmdJSON, _ := json.Marshal(game.Process.StartCommand)
+			env["GSHUB_START_COMMAND"] = string(cmdJSON)
+		}
+		if game.Process.WorkDir != "" {
+			env["GSHUB_WORK_DIR"] = game.Process.WorkDir
+		}
+		if game.Process.GracePeriod > 0 {
+			env["GSHUB_GRACE_PERIOD"] = fmt.Sprintf("%d", game.Process.GracePeriod)
+		}
+		if len(game.Process.ConfigTemplates) > 0 {
+			templatesJSON, _ := json.Marshal(game.Process.ConfigTemplates)
+			env["GSHUB_CONFIG_TEMPLATES"] = string(templatesJSON)
+		}
+		if len(game.Process.ReloadCommand) > 0 {
+			cmdJSON, _ := json.Marshal(game.Process.ReloadCommand)
+			env["GSHUB_RELOAD_COMMAND"] = string(cmdJSON)
+		}
+		if game.Process.LogFormat != "" {
+			env["GSHUB_LOG_FORMAT"] = game.Process.LogFormat
+		}
+		if len(game.Process.BackupCommand) > 0 {
+			cmdJSON, _ := json.Marshal(game.Process.BackupCommand)
+			env["GSHUB_BACKUP_COMMAND"] = string(cmdJSON)
+		}
+		if game.Process.BackupDir != "" {
+			env["GSHUB_BACKUP_DIR"] = game.Process.BackupDir
+		}
+		if game.Process.ImportDir != "" {
+			env["GSHUB_IMPORT_DIR"] = game.Process.ImportDir
+		}
+		if len(game.Process.ImportRequiredFiles) > 0 {
+			filesJSON, _ := json.Marshal(game.Process.ImportRequiredFiles)
+			env["GSHUB_IMPORT_REQUIRED_FILES"] = string(filesJSON)
+		}
+		if len(game.Process.ProgressPatterns) > 0 {
+			patternsJSON, _ := json.Marshal(game.Process.ProgressPatterns)
+			env["GSHUB_PROGRESS_PATTERNS"] = string(patternsJSON)
+		}
+		if game.Process.RCONPort != 0 {
+			env["GSHUB_RCON_PORT"] = fmt.Sprintf("%d", game.Process.RCONPort)
+			env["GSHUB_RCON_PASSWORD_ENV"] = game.Process.RCONPasswordEnv
+		}
+	}
+
+	// World exports archive the data volume
+	if len(game.Volumes) > 0 {
+		env["GSHUB_DATA_DIR"] = game.Volumes[0].MountPath
+	}
+
+	if game.HealthCheck != nil {
+		env["GSHUB_HEALTH_TYPE"] = game.HealthCheck.Type
+		env["GSHUB_HEALTH_PORT"] = game.HealthCheck.Port
+		env["GSHUB_HEALTH_PROTOCOL"] = game.HealthCheck.Protocol
+		if game.HealthCheck.InitialDelay != "" {
+			env["GSHUB_HEALTH_INITIAL_DELAY"] = game.HealthCheck.InitialDelay
+		}
+		if game.HealthCheck.Timeout != "" {
+			env["GSHUB_HEALTH_TIMEOUT"] = game.HealthCheck.Timeout
+		}
+		if game.HealthCheck.Interval != "" {
+			env["GSHUB_HEALTH_INTERVAL"] = game.HealthCheck.Interval
+		}
+		if game.HealthCheck.Pattern != "" {
+			env["GSHUB_HEALTH_PATTERN"] = game.HealthCheck.Pattern
+		}
+	}
+
+	return env
+}
+
+// parseCPUToMillicores converts a CPU string (e.g., "1", "500m", "2") to millicores
+func parseCPUToMillicores(cpu string) int {
+	q := resource.MustParse(cpu)
+	return int(q.MilliValue())
+}
+
+// parseMemoryToBytes converts a memory string (e.g., "2Gi", "512Mi") to bytes
+func parseMemoryToBytes(memory string) int64 {
+	q := resource.MustParse(memory)
+	return q.Value()
+}
+
 // MergeEnvVars performs a three-layer merge of environment variables.
 // Priority (highest wins): userOverrides > planEnv > gameEnv
 func MergeEnvVars(gameEnv, planEnv, userOverrides map[string]string) map[string]string {
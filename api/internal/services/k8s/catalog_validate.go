@@ -0,0 +1,368 @@
+package k8s
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// CatalogIssue is a problem in the game catalog that would break servers using it
+type CatalogIssue struct {
+	Game    string `json:"game"`
+	Plan    string `json:"plan,omitempty"` // Empty for game-level issues
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (i CatalogIssue) String() string {
+	location := i.Game
+	if i.Plan != "" {
+		location += "/" + i.Plan
+	}
+	return fmt.Sprintf("%s: %s: %s", location, i.Field, i.Message)
+}
+
+// Health check types the supervisor understands
+var validHealthCheckTypes = map[string]bool{"none": true, "port": true, "log-pattern": true}
+
+// portNamePattern matches names Kubernetes accepts for container ports
+var portNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// imagePattern loosely matches image references: [registry/]path[:tag][@digest]
+var imagePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._\-/:]*(:[\w][\w.\-]{0,127})?(@sha256:[a-f0-9]{64})?$`)
+
+// supervisorProbePort is the supervisor's liveness/readiness server (GSHUB_HEALTH_SERVER_PORT)
+const supervisorProbePort = "8080/TCP"
+
+// Validate checks the catalog for problems the reconciler can't work around: missing images,
+// invalid resource quantities, conflicting ports and unknown health check types. Issues are
+// sorted by game and plan.
+func (catalog *GameCatalog) Validate() []CatalogIssue {
+	issues := []CatalogIssue{}
+	if len(catalog.Games) == 0 {
+		return append(issues, CatalogIssue{Field: "games", Message: "catalog has no games"})
+	}
+
+	for name, game := range catalog.Games {
+		issues = append(issues, game.validate(name)...)
+	}
+
+	sort.SliceStable(issues, func(a, b int) bool {
+		if issues[a].Game != issues[b].Game {
+			return issues[a].Game < issues[b].Game
+		}
+		return issues[a].Plan < issues[b].Plan
+	})
+	return issues
+}
+
+// PlanIssues returns the catalog issues affecting servers of game on plan
+func (catalog *GameCatalog) PlanIssues(game, plan string) []CatalogIssue {
+	gameConfig, ok := catalog.Games[game]
+	if !ok {
+		return nil
+	}
+	return gameConfig.PlanIssues(game, plan)
+}
+
+// PlanIssues returns the issues affecting servers of the game (named name) on plan. Use it
+// on custom games after WithCustomGame to check the server's definition too.
+func (game *GameConfig) PlanIssues(name, plan string) []CatalogIssue {
+	var issues []CatalogIssue
+	for _, issue := range game.validate(name) {
+		if issue.Plan == "" || issue.Plan == plan {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// ImageAllowed reports whether image comes from one of the allowed registries. Entries are a
+// registry ("ghcr.io") or a registry path ("ghcr.io/myorg"); images without a registry are
+// on docker.io.
+func ImageAllowed(image string, allowedRegistries []string) bool {
+	ref := image
+	if first, _, ok := strings.Cut(image, "/"); !ok || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		ref = "docker.io/" + image
+	}
+	for _, allowed := range allowedRegistries {
+		allowed = strings.TrimSuffix(strings.TrimSpace(allowed), "/")
+		if allowed != "" && strings.HasPrefix(ref, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (game *GameConfig) validate(name string) []CatalogIssue {
+	var issues []CatalogIssue
+	add := func(plan, field, format string, args ...any) {
+		issues = append(issues, CatalogIssue{Game: name, Plan: plan, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch game.OS {
+	case "", OSLinux:
+	case OSWindows:
+		// The supervisor is injected with a Linux init container
+		if game.Custom {
+			add("", "os", "custom games can't run on windows")
+		}
+	default:
+		add("", "os", "unknown os %q (must be linux or windows)", game.OS)
+	}
+
+	switch {
+	case game.Custom && game.SupervisorImage == "":
+		add("", "supervisorImage", "custom game has no supervisor image to inject")
+	case game.Custom && game.Image == "":
+		// Each server's definition supplies the image (see WithCustomGame)
+	case game.ContainerImage() == "":
+		add("", "supervisorImage", "game has no image")
+	case !imagePattern.MatchString(game.ContainerImage()):
+		add("", "image", "invalid image reference %q", game.ContainerImage())
+	}
+
+	// Ports share the pod's network namespace, so the same port and protocol can't be
+	// declared twice, or taken by the supervisor's probe server
+	portNames := map[string]bool{}
+	portsInUse := map[string]string{supervisorProbePort: "supervisor"}
+	for i, port := range game.Ports {
+		field := fmt.Sprintf("ports[%d]", i)
+		if port.Name == "" {
+			add("", field+".name", "port has no name")
+		} else if !portNamePattern.MatchString(port.Name) || len(port.Name) > 15 {
+			add("", field+".name", "port name must be at most 15 lowercase letters, digits and dashes, got %q", port.Name)
+		} else if portNames[port.Name] {
+			add("", field+".name", "duplicate port name %q", port.Name)
+		}
+		portNames[port.Name] = true
+
+		if port.Port < 1 || port.Port > 65535 {
+			add("", field+".port", "port must be between 1 and 65535, got %d", port.Port)
+		}
+		if port.Protocol != "TCP" && port.Protocol != "UDP" {
+			add("", field+".protocol", "protocol must be TCP or UDP, got %q", port.Protocol)
+			continue
+		}
+
+		key := fmt.Sprintf("%d/%s", port.Port, port.Protocol)
+		if other, ok := portsInUse[key]; ok {
+			add("", field+".port", "port %s conflicts with port %q", key, other)
+		}
+		portsInUse[key] = port.Name
+	}
+
+	// Paired ports get one host port number, so each pair is a TCP and a UDP port, declared on
+	// one of them
+	paired := map[string]bool{}
+	for i, port := range game.Ports {
+		if port.SamePort == "" {
+			continue
+		}
+		field := fmt.Sprintf("ports[%d].samePort", i)
+		other := game.Port(port.SamePort)
+		switch {
+		case other == nil:
+			add("", field, "unknown port %q", port.SamePort)
+		case other.Protocol == port.Protocol:
+			add("", field, "port %q must use the other protocol", port.SamePort)
+		case paired[port.Name] || paired[other.Name]:
+			add("", field, "port %q or %q is already paired", port.Name, other.Name)
+		default:
+			paired[port.Name] = true
+			paired[other.Name] = true
+		}
+	}
+
+	for i, volume := range game.Volumes {
+		if !path.IsAbs(volume.MountPath) {
+			add("", fmt.Sprintf("volumes[%d].mount_path", i), "mount path must be absolute, got %q", volume.MountPath)
+		}
+	}
+
+	// The supervisor serves backups from the data volume, so they must be written inside it
+	if game.Process != nil && game.Process.BackupDir != "" {
+		if len(game.Volumes) == 0 || !strings.HasPrefix(path.Clean(game.Process.BackupDir)+"/", path.Clean(game.Volumes[0].MountPath)+"/") {
+			add("", "process.backupDir", "backup directory must be inside the data volume, got %q", game.Process.BackupDir)
+		}
+	}
+
+	// Imports are uploaded into the data volume and extracted within it
+	if game.Process != nil && game.Process.ImportDir != "" {
+		if len(game.Volumes) == 0 || !strings.HasPrefix(path.Clean(game.Process.ImportDir)+"/", path.Clean(game.Volumes[0].MountPath)+"/") {
+			add("", "process.importDir", "import directory must be inside the data volume, got %q", game.Process.ImportDir)
+		}
+		for _, file := range game.Process.ImportRequiredFiles {
+			if file == "" || path.IsAbs(file) || strings.HasPrefix(path.Clean(file), "..") {
+				add("", "process.importRequiredFiles", "required files must be relative paths inside the archive, got %q", file)
+			}
+		}
+	}
+
+	// Matches the supervisor's checks of GSHUB_PROGRESS_PATTERNS
+	if game.Process != nil {
+		for i, p := range game.Process.ProgressPatterns {
+			field := fmt.Sprintf("process.progressPatterns[%d]", i)
+			if re, err := regexp.Compile(p.Pattern); err != nil {
+				add("", field+".pattern", "pattern is not a valid regex: %v", err)
+			} else if re.NumSubexp() < 1 {
+				add("", field+".pattern", "pattern must capture the percentage in a group, got %q", p.Pattern)
+			}
+			if p.Label == "" {
+				add("", field+".label", "progress pattern needs a label")
+			}
+		}
+	}
+
+	// Matches the supervisor's checks of GSHUB_RCON_PORT and GSHUB_RCON_PASSWORD_ENV
+	if game.Process != nil && game.Process.RCONPort != 0 {
+		if game.Process.RCONPort < 1 || game.Process.RCONPort > 65535 {
+			add("", "process.rconPort", "RCON port must be between 1 and 65535, got %d", game.Process.RCONPort)
+		}
+		if game.Process.RCONPasswordEnv == "" {
+			add("", "process.rconPasswordEnv", "RCON password env var is required with an RCON port")
+		}
+	}
+
+	// Mods are uploaded through the supervisor's file server, rooted at the data volume
+	if mods := game.Mods; mods != nil {
+		if mods.LoaderEnv == "" {
+			add("", "mods.loaderEnv", "mods need the env var that selects the loader")
+		}
+		if len(mods.Loaders) == 0 {
+			add("", "mods.loaders", "mods need at least one loader")
+		}
+		envValues := map[string]bool{}
+		for i, loader := range mods.Loaders {
+			field := fmt.Sprintf("mods.loaders[%d]", i)
+			if loader.Name == "" {
+				add("", field+".name", "loader has no name")
+			}
+			if loader.EnvValue == "" {
+				add("", field+".envValue", "loader has no env value")
+			} else if envValues[strings.ToLower(loader.EnvValue)] {
+				add("", field+".envValue", "duplicate env value %q", loader.EnvValue)
+			}
+			envValues[strings.ToLower(loader.EnvValue)] = true
+			if loader.ProjectType != "" && loader.ProjectType != "mod" && loader.ProjectType != "plugin" {
+				add("", field+".projectType", "project type must be mod or plugin, got %q", loader.ProjectType)
+			}
+			if loader.Dir == "" || path.IsAbs(loader.Dir) || strings.HasPrefix(path.Clean(loader.Dir), "..") {
+				add("", field+".dir", "directory must be a relative path inside the data volume, got %q", loader.Dir)
+			}
+		}
+	}
+
+	if game.Probes != nil {
+		probes := []struct {
+			name  string
+			probe *ProbeConfig
+		}{
+			{"liveness", game.Probes.Liveness},
+			{"readiness", game.Probes.Readiness},
+		}
+		for _, p := range probes {
+			if p.probe != nil && (p.probe.InitialDelaySeconds < 0 || p.probe.PeriodSeconds < 0 || p.probe.FailureThreshold < 0) {
+				add("", "probes."+p.name, "probe timing can't be negative")
+			}
+		}
+	}
+
+	if hc := game.HealthCheck; hc != nil {
+		switch {
+		case !validHealthCheckTypes[hc.Type]:
+			add("", "healthCheck.type", "unknown health check type %q (must be port, log-pattern or none)", hc.Type)
+		case hc.Type == "port":
+			port, err := strconv.Atoi(hc.Port)
+			if err != nil || port < 1 || port > 65535 {
+				add("", "healthCheck.port", "port health check needs a port between 1 and 65535, got %q", hc.Port)
+			}
+			if hc.Protocol != "TCP" && hc.Protocol != "UDP" {
+				add("", "healthCheck.protocol", "protocol must be TCP or UDP, got %q", hc.Protocol)
+			}
+		case hc.Type == "log-pattern":
+			if hc.Pattern == "" {
+				add("", "healthCheck.pattern", "log-pattern health check needs a pattern")
+			} else if _, err := regexp.Compile(hc.Pattern); err != nil {
+				add("", "healthCheck.pattern", "pattern is not a valid regex: %v", err)
+			}
+		}
+		// Matches the supervisor's parsing of GSHUB_HEALTH_* durations
+		seconds := []struct {
+			field, value string
+			min          int
+		}{
+			{"initialDelay", hc.InitialDelay, 0},
+			{"timeout", hc.Timeout, 1},
+			{"interval", hc.Interval, 1},
+		}
+		for _, s := range seconds {
+			if n, err := strconv.Atoi(s.value); s.value != "" && (err != nil || n < s.min) {
+				add("", "healthCheck."+s.field, "must be a whole number of seconds >= %d, got %q", s.min, s.value)
+			}
+		}
+	}
+
+	if overhead := game.SupervisorOverhead; overhead != nil {
+		validateQuantity(overhead.CPU, false, func(msg string) { add("", "supervisorOverhead.cpu", "%s", msg) })
+		validateQuantity(overhead.Memory, false, func(msg string) { add("", "supervisorOverhead.memory", "%s", msg) })
+	}
+
+	if len(game.Plans) == 0 {
+		add("", "plans", "game has no plans")
+	}
+	for planName, plan := range game.Plans {
+		validateQuantity(plan.CPU, true, func(msg string) { add(planName, "cpu", "%s", msg) })
+		validateQuantity(plan.Memory, true, func(msg string) { add(planName, "memory", "%s", msg) })
+		validateQuantity(plan.Storage, true, func(msg string) { add(planName, "storage", "%s", msg) })
+		if plan.GPU < 0 {
+			add(planName, "gpu", "must not be negative, got %d", plan.GPU)
+		}
+		if plan.MaxPerNode < 0 {
+			add(planName, "maxPerNode", "must not be negative, got %d", plan.MaxPerNode)
+		}
+		if plan.BackupMaxDaily < 0 {
+			add(planName, "backupMaxDaily", "must not be negative, got %d", plan.BackupMaxDaily)
+		}
+		if plan.BackupMaxWeekly < 0 {
+			add(planName, "backupMaxWeekly", "must not be negative, got %d", plan.BackupMaxWeekly)
+		}
+		validateQuantity(plan.EgressBandwidth, false, func(msg string) { add(planName, "egressBandwidth", "%s", msg) })
+		validateQuantity(plan.EgressQuota, false, func(msg string) { add(planName, "egressQuota", "%s", msg) })
+		if plan.PinCPUs && !plan.Performance {
+			add(planName, "pinCPUs", "pinCPUs only applies to performance plans")
+		}
+		if plan.PinCPUs && game.NodeOS() == OSWindows {
+			add(planName, "pinCPUs", "CPUs can't be pinned on windows")
+		}
+		if plan.Spot && (plan.Dedicated || plan.Performance) {
+			add(planName, "spot", "spot plans can't be dedicated or performance plans")
+		}
+	}
+
+	return issues
+}
+
+// validateQuantity reports a problem with a resource quantity. Empty values are only
+// reported when required.
+func validateQuantity(value string, required bool, report func(string)) {
+	if value == "" {
+		if required {
+			report("is required")
+		}
+		return
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		report(fmt.Sprintf("invalid quantity %q", value))
+		return
+	}
+	if q.Sign() <= 0 {
+		report(fmt.Sprintf("must be greater than zero, got %q", value))
+	}
+}
@@ -2,24 +2,48 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"sync"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
 )
 
 // ResourceOverheadFactor is the multiplier applied to resource requests
 // to reserve capacity for system overhead (kubelet, containerd, OS)
 const ResourceOverheadFactor = 0.90 // 10% reserved for system
 
+// DedicatedNodeTaintKey is the taint that reserves a node for dedicated plans
+// (e.g. platform.io/dedicated=true:NoSchedule). Only dedicated plan pods tolerate it.
+const DedicatedNodeTaintKey = "platform.io/dedicated"
+
+// SpotNodeTaintKey is the taint that keeps other plans off spot nodes
+// (e.g. platform.io/spot=true:NoSchedule). Only spot plan pods tolerate it.
+const SpotNodeTaintKey = "platform.io/spot"
+
+// WindowsNodeTaintKey is the taint that keeps Linux pods off Windows nodes
+// (node.kubernetes.io/os=windows:NoSchedule). Windows game pods tolerate it.
+const WindowsNodeTaintKey = "node.kubernetes.io/os"
+
+// GPUResourceName is the extended resource NVIDIA's device plugin advertises GPUs as.
+// GPU nodes are commonly tainted with the same key, so GPU pods tolerate it.
+const GPUResourceName corev1.ResourceName = "nvidia.com/gpu"
+
 // StaticPortConfig defines a port with a pre-allocated host port
 type StaticPortConfig struct {
 	Name          string
@@ -39,6 +63,12 @@ type VolumeConfig struct {
 type Client struct {
 	clientset *kubernetes.Clientset // Standard K8s resources (Pods, PVCs, Nodes, Deployments)
 	config    *rest.Config
+
+	// Requests in server namespaces impersonate serverAccount there, see ScopeServerNamespaces
+	controlNamespace string
+	serverAccount    string
+	scopedMu         sync.Mutex
+	scoped           map[string]*kubernetes.Clientset // By namespace
 }
 
 // NewClient initializes a new Kubernetes client with in-cluster config or kubeconfig fallback
@@ -68,6 +98,37 @@ func NewClient() (*Client, error) {
 	}, nil
 }
 
+// ScopeServerNamespaces makes requests in namespaces other than controlNamespace impersonate
+// the ServiceAccount serviceAccount of that namespace, so what the API can do in a server
+// namespace is what that namespace's RoleBinding grants, and the API's own ServiceAccount
+// only needs to be allowed to impersonate. Must be called before the client is used.
+func (c *Client) ScopeServerNamespaces(controlNamespace, serviceAccount string) {
+	c.controlNamespace = controlNamespace
+	c.serverAccount = serviceAccount
+	c.scoped = make(map[string]*kubernetes.Clientset)
+}
+
+// forNamespace returns the clientset for requests in namespace
+func (c *Client) forNamespace(namespace string) *kubernetes.Clientset {
+	if c.serverAccount == "" || namespace == c.controlNamespace {
+		return c.clientset
+	}
+
+	c.scopedMu.Lock()
+	defer c.scopedMu.Unlock()
+	if clientset, ok := c.scoped[namespace]; ok {
+		return clientset
+	}
+	config := rest.CopyConfig(c.config)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, c.serverAccount),
+	}
+	// Only the impersonated user differs from the config the client was created with
+	clientset := kubernetes.NewForConfigOrDie(config)
+	c.scoped[namespace] = clientset
+	return clientset
+}
+
 // Health checks connectivity to the Kubernetes API server
 func (c *Client) Health(ctx context.Context) error {
 	_, err := c.clientset.Discovery().ServerVersion()
@@ -94,7 +155,7 @@ func (c *Client) CreatePVC(ctx context.Context, namespace, name, storageSize str
 		},
 	}
 
-	_, err := c.clientset.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	_, err := c.forNamespace(namespace).CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create PVC: %w", err)
 	}
@@ -104,7 +165,7 @@ func (c *Client) CreatePVC(ctx context.Context, namespace, name, storageSize str
 
 // DeletePVC deletes a PersistentVolumeClaim
 func (c *Client) DeletePVC(ctx context.Context, namespace, name string) error {
-	err := c.clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err := c.forNamespace(namespace).CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	if err != nil && !errors.IsNotFound(err) {
 		return fmt.Errorf("failed to delete PVC: %w", err)
 	}
@@ -129,9 +190,21 @@ func (c *Client) ListNodes(ctx context.Context) ([]corev1.Node, error) {
 	return list.Items, nil
 }
 
+// LabelNode sets labels on a node, leaving its other labels as they are
+func (c *Client) LabelNode(ctx context.Context, name string, labels map[string]string) error {
+	patch, err := json.Marshal(map[string]any{"metadata": map[string]any{"labels": labels}})
+	if err != nil {
+		return err
+	}
+	if _, err := c.clientset.CoreV1().Nodes().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to label node: %w", err)
+	}
+	return nil
+}
+
 // GetPodByLabel finds a pod by label selector, returns the first running pod found
 func (c *Client) GetPodByLabel(ctx context.Context, namespace, labelSelector string) (*corev1.Pod, error) {
-	pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+	pods, err := c.forNamespace(namespace).CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: labelSelector,
 	})
 	if err != nil {
@@ -153,17 +226,31 @@ func (c *Client) GetPodByLabel(ctx context.Context, namespace, labelSelector str
 	return nil, fmt.Errorf("no pods found with label: %s", labelSelector)
 }
 
+// ListPodsByLabel lists the pods matching a label selector, including terminating ones
+func (c *Client) ListPodsByLabel(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+	pods, err := c.forNamespace(namespace).CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
 // StreamPodLogs returns a streaming io.ReadCloser for real-time log following.
-// The stream includes the last `tailLines` of historical logs followed by new logs.
-// The caller is responsible for closing the returned stream.
-func (c *Client) StreamPodLogs(ctx context.Context, namespace, podName, containerName string, tailLines int64) (io.ReadCloser, error) {
+// The stream includes the last `tailLines` of historical logs (only those after `since`,
+// if set) followed by new logs. The caller is responsible for closing the returned stream.
+func (c *Client) StreamPodLogs(ctx context.Context, namespace, podName, containerName string, tailLines int64, since time.Time) (io.ReadCloser, error) {
 	opts := &corev1.PodLogOptions{
 		Container: containerName,
 		Follow:    true,
 		TailLines: &tailLines,
 	}
+	if !since.IsZero() {
+		opts.SinceTime = &metav1.Time{Time: since}
+	}
 
-	req := c.clientset.CoreV1().Pods(namespace).GetLogs(podName, opts)
+	req := c.forNamespace(namespace).CoreV1().Pods(namespace).GetLogs(podName, opts)
 	stream, err := req.Stream(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stream pod logs: %w", err)
@@ -172,6 +259,90 @@ func (c *Client) StreamPodLogs(ctx context.Context, namespace, podName, containe
 	return stream, nil
 }
 
+// PreviousPodLogs returns the last `tailLines` of logs (only those after `since`, if set)
+// from a container's previous instance, i.e. the one that exited before the last restart.
+// The stream doesn't follow. The caller is responsible for closing the returned stream.
+func (c *Client) PreviousPodLogs(ctx context.Context, namespace, podName, containerName string, tailLines int64, since time.Time) (io.ReadCloser, error) {
+	opts := &corev1.PodLogOptions{
+		Container: containerName,
+		Previous:  true,
+		TailLines: &tailLines,
+	}
+	if !since.IsZero() {
+		opts.SinceTime = &metav1.Time{Time: since}
+	}
+
+	req := c.forNamespace(namespace).CoreV1().Pods(namespace).GetLogs(podName, opts)
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream previous pod logs: %w", err)
+	}
+
+	return stream, nil
+}
+
+// PodLogs returns a container's complete retained logs, or its previous instance's, up to
+// limitBytes. The stream doesn't follow. The caller is responsible for closing the returned stream.
+func (c *Client) PodLogs(ctx context.Context, namespace, podName, containerName string, previous bool, limitBytes int64) (io.ReadCloser, error) {
+	opts := &corev1.PodLogOptions{
+		Container:  containerName,
+		Previous:   previous,
+		LimitBytes: &limitBytes,
+	}
+
+	req := c.forNamespace(namespace).CoreV1().Pods(namespace).GetLogs(podName, opts)
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod logs: %w", err)
+	}
+
+	return stream, nil
+}
+
+// ContainerRestarted reports whether a container has a previous instance
+func ContainerRestarted(pod *corev1.Pod, containerName string) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == containerName {
+			return cs.RestartCount > 0
+		}
+	}
+	return false
+}
+
+// ContainerRestartedWithin reports whether a container's previous instance exited within
+// the given window
+func ContainerRestartedWithin(pod *corev1.Pod, containerName string, window time.Duration) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != containerName {
+			continue
+		}
+		terminated := cs.LastTerminationState.Terminated
+		return cs.RestartCount > 0 && terminated != nil && time.Since(terminated.FinishedAt.Time) < window
+	}
+	return false
+}
+
+// ContainerStarted reports whether a container has started at least once, so it has logs
+func ContainerStarted(pod *corev1.Pod, containerName string) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == containerName {
+			return cs.RestartCount > 0 || cs.State.Waiting == nil
+		}
+	}
+	return false
+}
+
+// ContainerWaiting reports whether a container is waiting to (re)start, e.g. in CrashLoopBackOff,
+// in which case it has no current logs to follow
+func ContainerWaiting(pod *corev1.Pod, containerName string) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == containerName {
+			return cs.State.Waiting != nil
+		}
+	}
+	return false
+}
+
 // DeploymentParams holds parameters for creating a game server Deployment
 type DeploymentParams struct {
 	Namespace   string
@@ -186,16 +357,126 @@ type DeploymentParams struct {
 	PVCName     string
 	Labels      map[string]string
 	GracePeriod int32
+	Dedicated   bool   // Tolerate the dedicated node taint
+	Spot        bool   // Tolerate the spot node taint
+	OS          string // Node operating system; OSWindows runs a Windows pod, anything else Linux
+	GPUs        int    // nvidia.com/gpu to request (0 = none)
+	Guaranteed  bool   // Set limits equal to requests (Guaranteed QoS)
+	PinCPUs     bool   // Request CPURequest as-is (whole cores) for the static CPU manager
+
+	// SupervisorImage, if set, is copied into Image by an init container and run as its
+	// command, for images that don't include the supervisor (custom games)
+	SupervisorImage string
+
+	// AntiAffinityLabels keeps the pod off nodes already running a pod with these labels
+	AntiAffinityLabels map[string]string
+
+	// EgressBandwidth caps the pod's outbound traffic (bits per second, e.g. "100M"); empty
+	// means unlimited
+	EgressBandwidth string
+
+	// Probe timing from the game catalog; nil or zero fields keep the defaults
+	LivenessProbe  *ProbeConfig
+	ReadinessProbe *ProbeConfig
 }
 
+// Default probe timing of the supervisor container
+var (
+	defaultLivenessProbe  = ProbeConfig{InitialDelaySeconds: 10, PeriodSeconds: 10, FailureThreshold: 3}
+	defaultReadinessProbe = ProbeConfig{InitialDelaySeconds: 30, PeriodSeconds: 15, FailureThreshold: 2}
+)
+
+// supervisorProbe returns an HTTP probe of the supervisor's path, timed by cfg with
+// defaults for unset fields
+func supervisorProbe(path string, cfg *ProbeConfig, defaults ProbeConfig) *corev1.Probe {
+	timing := defaults
+	if cfg != nil {
+		if cfg.InitialDelaySeconds > 0 {
+			timing.InitialDelaySeconds = cfg.InitialDelaySeconds
+		}
+		if cfg.PeriodSeconds > 0 {
+			timing.PeriodSeconds = cfg.PeriodSeconds
+		}
+		if cfg.FailureThreshold > 0 {
+			timing.FailureThreshold = cfg.FailureThreshold
+		}
+	}
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: intstr.FromInt(SupervisorHTTPPort),
+			},
+		},
+		InitialDelaySeconds: timing.InitialDelaySeconds,
+		PeriodSeconds:       timing.PeriodSeconds,
+		FailureThreshold:    timing.FailureThreshold,
+	}
+}
+
+// EgressBandwidthAnnotation is read by the CNI bandwidth plugin to shape a pod's egress
+const EgressBandwidthAnnotation = "kubernetes.io/egress-bandwidth"
+
+// supervisorBinDir is where the supervisor binary is installed into images without it
+const supervisorBinDir = "/gshub"
+
+// ErrDeploymentSelectorChanged is returned by UpdateGameDeployment when the deployment's
+// labels changed (e.g. a new plan); selectors are immutable, so it must be recreated
+var ErrDeploymentSelectorChanged = stderrors.New("deployment selector changed")
+
 // CreateGameDeployment creates a Kubernetes Deployment for a game server with supervisor
 func (c *Client) CreateGameDeployment(ctx context.Context, params DeploymentParams) error {
-	// Build environment variables
-	var envVars []corev1.EnvVar
-	for key, value := range params.Env {
+	_, err := c.forNamespace(params.Namespace).AppsV1().Deployments(params.Namespace).Create(ctx, gameDeployment(params), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create Deployment: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateGameDeployment replaces the pod template of an existing game server Deployment and
+// scales it to one replica. The Deployment controller then replaces the pod, keeping the
+// Deployment (and its pod's place on the node) rather than deleting and recreating it.
+// Reports whether the image, env or resources changed, per the Deployment's template hash.
+func (c *Client) UpdateGameDeployment(ctx context.Context, params DeploymentParams) (bool, error) {
+	desired := gameDeployment(params)
+	deployments := c.forNamespace(params.Namespace).AppsV1().Deployments(params.Namespace)
+
+	var changed bool
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := deployments.Get(ctx, params.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get Deployment: %w", err)
+		}
+		if existing.Spec.Selector == nil || !maps.Equal(existing.Spec.Selector.MatchLabels, desired.Spec.Selector.MatchLabels) {
+			return ErrDeploymentSelectorChanged
+		}
+
+		changed = DeploymentTemplateHash(existing) != DeploymentTemplateHash(desired)
+		if existing.Annotations == nil {
+			existing.Annotations = map[string]string{}
+		}
+		existing.Annotations[TemplateHashAnnotation] = DeploymentTemplateHash(desired)
+		existing.Labels = desired.Labels
+		existing.Spec.Replicas = desired.Spec.Replicas
+		existing.Spec.Strategy = desired.Spec.Strategy
+		existing.Spec.Template = desired.Spec.Template
+		if _, err := deployments.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update Deployment: %w", err)
+		}
+		return nil
+	})
+	return changed, err
+}
+
+// gameDeployment builds the Deployment of a game server with supervisor
+func gameDeployment(params DeploymentParams) *appsv1.Deployment {
+	// Build environment variables, sorted so unchanged env renders the same template
+	envVars := make([]corev1.EnvVar, 0, len(params.Env))
+	for _, key := range slices.Sorted(maps.Keys(params.Env)) {
 		envVars = append(envVars, corev1.EnvVar{
 			Name:  key,
-			Value: value,
+			Value: params.Env[key],
 		})
 	}
 
@@ -232,11 +513,82 @@ func (c *Client) CreateGameDeployment(ctx context.Context, params DeploymentPara
 		},
 	}
 
-	// Apply overhead factor to resource requests
-	cpuQty := resource.MustParse(params.CPURequest)
-	memQty := resource.MustParse(params.MemRequest)
-	adjustedCPU := resource.NewMilliQuantity(int64(float64(cpuQty.MilliValue())*ResourceOverheadFactor), resource.DecimalSI)
-	adjustedMemory := resource.NewQuantity(int64(float64(memQty.Value())*ResourceOverheadFactor), resource.BinarySI)
+	// Images without the supervisor get its (static) binary from an init container
+	var initContainers []corev1.Container
+	var command []string
+	if params.SupervisorImage != "" {
+		binMount := corev1.VolumeMount{Name: "supervisor-bin", MountPath: supervisorBinDir}
+		podVolumes = append(podVolumes, corev1.Volume{
+			Name:         "supervisor-bin",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		initContainers = append(initContainers, corev1.Container{
+			Name:         "install-supervisor",
+			Image:        params.SupervisorImage,
+			Command:      []string{"cp", "/usr/local/bin/supervisor", supervisorBinDir + "/supervisor"},
+			VolumeMounts: []corev1.VolumeMount{binMount},
+		})
+		volumeMounts = append(volumeMounts, binMount)
+		command = []string{supervisorBinDir + "/supervisor"}
+	}
+
+	resources := GameContainerResources(params)
+
+	var podAnnotations map[string]string
+	if params.EgressBandwidth != "" {
+		podAnnotations = map[string]string{EgressBandwidthAnnotation: params.EgressBandwidth}
+	}
+
+	// Dedicated plans run on tainted nodes reserved for them
+	var tolerations []corev1.Toleration
+	if params.Dedicated {
+		tolerations = append(tolerations, corev1.Toleration{
+			Key:      DedicatedNodeTaintKey,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+	// Spot plans run on tainted spot nodes
+	if params.Spot {
+		tolerations = append(tolerations, corev1.Toleration{
+			Key:      SpotNodeTaintKey,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+	// Windows games run Windows pods, on Windows nodes
+	var podOS *corev1.PodOS
+	if params.OS == OSWindows {
+		podOS = &corev1.PodOS{Name: corev1.Windows}
+		tolerations = append(tolerations, corev1.Toleration{
+			Key:      WindowsNodeTaintKey,
+			Operator: corev1.TolerationOpEqual,
+			Value:    OSWindows,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+	if params.GPUs > 0 {
+		tolerations = append(tolerations, corev1.Toleration{
+			Key:      string(GPUResourceName),
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+
+	// Co-tenancy cap of one per node: refuse nodes already running a matching pod
+	var podAntiAffinity *corev1.PodAntiAffinity
+	if len(params.AntiAffinityLabels) > 0 {
+		podAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: params.AntiAffinityLabels},
+					// Servers live in per-plan namespaces, so match pods in every namespace
+					NamespaceSelector: &metav1.LabelSelector{},
+					TopologyKey:       "kubernetes.io/hostname",
+				},
+			},
+		}
+	}
 
 	replicas := int32(1)
 	gracePeriod := int64(params.GracePeriod)
@@ -244,22 +596,28 @@ func (c *Client) CreateGameDeployment(ctx context.Context, params DeploymentPara
 		gracePeriod = 30
 	}
 
-	deployment := &appsv1.Deployment{
+	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      params.Name,
-			Namespace: params.Namespace,
-			Labels:    params.Labels,
+			Name:        params.Name,
+			Namespace:   params.Namespace,
+			Labels:      params.Labels,
+			Annotations: map[string]string{TemplateHashAnnotation: TemplateHash(params)},
 		},
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: params.Labels,
 			},
+			// The pod is pinned to its node's host ports and volume, so the old pod must be
+			// gone before its replacement can start
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: params.Labels,
+					Labels:      params.Labels,
+					Annotations: podAnnotations,
 				},
 				Spec: corev1.PodSpec{
+					OS:                            podOS,
 					ServiceAccountName:            "gshub-supervisor",
 					TerminationGracePeriodSeconds: &gracePeriod,
 					DNSConfig: &corev1.PodDNSConfig{
@@ -272,6 +630,7 @@ func (c *Client) CreateGameDeployment(ctx context.Context, params DeploymentPara
 					},
 					// Hard node affinity: Pin to the specific node where port is allocated
 					Affinity: &corev1.Affinity{
+						PodAntiAffinity: podAntiAffinity,
 						NodeAffinity: &corev1.NodeAffinity{
 							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
 								NodeSelectorTerms: []corev1.NodeSelectorTerm{
@@ -288,41 +647,19 @@ func (c *Client) CreateGameDeployment(ctx context.Context, params DeploymentPara
 							},
 						},
 					},
+					Tolerations:    tolerations,
+					InitContainers: initContainers,
 					Containers: []corev1.Container{
 						{
-							Name:         "supervisor",
-							Image:        params.Image,
-							Env:          envVars,
-							Ports:        containerPorts,
-							VolumeMounts: volumeMounts,
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{
-									corev1.ResourceCPU:    *adjustedCPU,
-									corev1.ResourceMemory: *adjustedMemory,
-								},
-							},
-							LivenessProbe: &corev1.Probe{
-								ProbeHandler: corev1.ProbeHandler{
-									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/healthz",
-										Port: intstr.FromInt(8080),
-									},
-								},
-								InitialDelaySeconds: 10,
-								PeriodSeconds:       10,
-								FailureThreshold:    3,
-							},
-							ReadinessProbe: &corev1.Probe{
-								ProbeHandler: corev1.ProbeHandler{
-									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/readyz",
-										Port: intstr.FromInt(8080),
-									},
-								},
-								InitialDelaySeconds: 30,
-								PeriodSeconds:       15,
-								FailureThreshold:    2,
-							},
+							Name:           "supervisor",
+							Image:          params.Image,
+							Command:        command,
+							Env:            envVars,
+							Ports:          containerPorts,
+							VolumeMounts:   volumeMounts,
+							Resources:      resources,
+							LivenessProbe:  supervisorProbe("/healthz", params.LivenessProbe, defaultLivenessProbe),
+							ReadinessProbe: supervisorProbe("/readyz", params.ReadinessProbe, defaultReadinessProbe),
 						},
 					},
 					Volumes: podVolumes,
@@ -330,18 +667,53 @@ func (c *Client) CreateGameDeployment(ctx context.Context, params DeploymentPara
 			},
 		},
 	}
+}
 
-	_, err := c.clientset.AppsV1().Deployments(params.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to create Deployment: %w", err)
+// GameContainerResources returns the resource requests and limits of a game server's
+// container: CPURequest and MemRequest with the overhead factor applied, plus any GPUs
+func GameContainerResources(params DeploymentParams) corev1.ResourceRequirements {
+	cpuQty := resource.MustParse(params.CPURequest)
+	memQty := resource.MustParse(params.MemRequest)
+	adjustedCPU := resource.NewMilliQuantity(int64(float64(cpuQty.MilliValue())*ResourceOverheadFactor), resource.DecimalSI)
+	if params.PinCPUs {
+		// The static CPU manager only grants exclusive cores to integer CPU requests
+		adjustedCPU = resource.NewQuantity(cpuQty.Value(), resource.DecimalSI)
 	}
+	adjustedMemory := resource.NewQuantity(int64(float64(memQty.Value())*ResourceOverheadFactor), resource.BinarySI)
 
-	return nil
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    *adjustedCPU,
+			corev1.ResourceMemory: *adjustedMemory,
+		},
+	}
+	// Guaranteed QoS: limits equal to requests, so the pod is never throttled below its
+	// request or evicted ahead of burstable pods
+	if params.Guaranteed {
+		resources.Limits = corev1.ResourceList{
+			corev1.ResourceCPU:    *adjustedCPU,
+			corev1.ResourceMemory: *adjustedMemory,
+		}
+	}
+	// Extended resources can't be overcommitted, so GPUs are requested without the
+	// overhead factor and limits must equal requests
+	if params.GPUs > 0 {
+		gpuQty := *resource.NewQuantity(int64(params.GPUs), resource.DecimalSI)
+		resources.Requests[GPUResourceName] = gpuQty
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		resources.Limits[GPUResourceName] = gpuQty
+	}
+	return resources
 }
 
-// GetGameDeployment retrieves a game server Deployment
+// GetGameDeployment retrieves a game server Deployment. Returns (nil, nil) if it doesn't exist.
 func (c *Client) GetGameDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
-	deployment, err := c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	deployment, err := c.forNamespace(namespace).AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Deployment: %w", err)
 	}
@@ -350,7 +722,7 @@ func (c *Client) GetGameDeployment(ctx context.Context, namespace, name string)
 
 // DeleteGameDeployment deletes a game server Deployment
 func (c *Client) DeleteGameDeployment(ctx context.Context, namespace, name string) error {
-	err := c.clientset.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err := c.forNamespace(namespace).AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	if err != nil && !errors.IsNotFound(err) {
 		return fmt.Errorf("failed to delete Deployment: %w", err)
 	}
@@ -359,13 +731,13 @@ func (c *Client) DeleteGameDeployment(ctx context.Context, namespace, name strin
 
 // ScaleGameDeployment scales a Deployment to the specified number of replicas
 func (c *Client) ScaleGameDeployment(ctx context.Context, namespace, name string, replicas int32) error {
-	scale, err := c.clientset.AppsV1().Deployments(namespace).GetScale(ctx, name, metav1.GetOptions{})
+	scale, err := c.forNamespace(namespace).AppsV1().Deployments(namespace).GetScale(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to get Deployment scale: %w", err)
 	}
 
 	scale.Spec.Replicas = replicas
-	_, err = c.clientset.AppsV1().Deployments(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+	_, err = c.forNamespace(namespace).AppsV1().Deployments(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to scale Deployment: %w", err)
 	}
@@ -375,7 +747,7 @@ func (c *Client) ScaleGameDeployment(ctx context.Context, namespace, name string
 
 // DeploymentExists checks if a Deployment exists
 func (c *Client) DeploymentExists(ctx context.Context, namespace, name string) (bool, error) {
-	_, err := c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	_, err := c.forNamespace(namespace).AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return false, nil
@@ -384,3 +756,205 @@ func (c *Client) DeploymentExists(ctx context.Context, namespace, name string) (
 	}
 	return true, nil
 }
+
+// SupervisorHTTPPort is the port the supervisor serves probes and files on, both in game
+// server pods and in file access pods
+const SupervisorHTTPPort = 8080
+
+// fileAccessMountPath is where file access pods mount the data volume
+const fileAccessMountPath = "/data"
+
+// FileAccessParams holds parameters for creating a file access pod
+type FileAccessParams struct {
+	Namespace string
+	ServerID  string
+	Image     string // Supervisor image, run in file access mode
+	PVCName   string
+	Token     string        // Bearer token the pod requires
+	Lifetime  time.Duration // The pod is stopped after this long
+}
+
+// fileAccessSelector selects a server's file access pods. They aren't labeled server=<id>,
+// which would make them look like the game server's pods.
+func fileAccessSelector(serverID string) string {
+	return "app=file-access,file-access-server=" + serverID
+}
+
+// CreateFileAccessPod creates a pod that serves a server's data volume read-only over HTTP,
+// for the owner of an expired server to rescue their files. It runs no game, tolerates the
+// node taints the volume's node may have, and stops itself after Lifetime.
+func (c *Client) CreateFileAccessPod(ctx context.Context, params FileAccessParams) (*corev1.Pod, error) {
+	deadline := int64(params.Lifetime.Seconds())
+	gracePeriod := int64(5)
+	automountToken := false
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			// Generated, so a new pod doesn't wait for a stopped one to finish deleting
+			GenerateName: "files-" + params.ServerID + "-",
+			Namespace:    params.Namespace,
+			Labels: map[string]string{
+				"app":                "file-access",
+				"file-access-server": params.ServerID,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds:         &deadline,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			AutomountServiceAccountToken:  &automountToken,
+			Tolerations: []corev1.Toleration{
+				{Key: DedicatedNodeTaintKey, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				{Key: SpotNodeTaintKey, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				{Key: string(GPUResourceName), Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "files",
+					Image: params.Image,
+					Env: []corev1.EnvVar{
+						{Name: "GSHUB_FILE_ACCESS_DIR", Value: fileAccessMountPath},
+						{Name: "GSHUB_FILE_ACCESS_TOKEN", Value: params.Token},
+					},
+					Ports: []corev1.ContainerPort{
+						{Name: "files", ContainerPort: SupervisorHTTPPort, Protocol: corev1.ProtocolTCP},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "server-data", MountPath: fileAccessMountPath, ReadOnly: true},
+					},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("50m"),
+							corev1.ResourceMemory: resource.MustParse("64Mi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("256Mi"),
+						},
+					},
+					ReadinessProbe: &corev1.Probe{
+						ProbeHandler: corev1.ProbeHandler{
+							HTTPGet: &corev1.HTTPGetAction{
+								Path: "/healthz",
+								Port: intstr.FromInt(SupervisorHTTPPort),
+							},
+						},
+						PeriodSeconds: 2,
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "server-data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: params.PVCName,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	created, err := c.forNamespace(params.Namespace).CoreV1().Pods(params.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file access pod: %w", err)
+	}
+	return created, nil
+}
+
+// GetFileAccessPod returns a server's file access pod that is starting or running. Returns
+// (nil, nil) if there is none, e.g. it reached its lifetime or was stopped.
+func (c *Client) GetFileAccessPod(ctx context.Context, namespace, serverID string) (*corev1.Pod, error) {
+	pods, err := c.ListPodsByLabel(ctx, namespace, fileAccessSelector(serverID))
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp == nil && (pod.Status.Phase == corev1.PodPending || pod.Status.Phase == corev1.PodRunning) {
+			return pod, nil
+		}
+	}
+	return nil, nil
+}
+
+// DeleteFileAccessPods deletes all of a server's file access pods, including finished ones
+func (c *Client) DeleteFileAccessPods(ctx context.Context, namespace, serverID string) error {
+	pods, err := c.ListPodsByLabel(ctx, namespace, fileAccessSelector(serverID))
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		err := c.forNamespace(namespace).CoreV1().Pods(namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete file access pod: %w", err)
+		}
+	}
+	return nil
+}
+
+// KillServerPods deletes a server's game pods without a grace period, so the game isn't
+// given a chance to shut down cleanly. Used to force stop servers whose pods hang.
+func (c *Client) KillServerPods(ctx context.Context, namespace, serverID string) error {
+	pods, err := c.ListPodsByLabel(ctx, namespace, "server="+serverID)
+	if err != nil {
+		return err
+	}
+	gracePeriod := int64(0)
+	for _, pod := range pods {
+		err := c.forNamespace(namespace).CoreV1().Pods(namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete pod: %w", err)
+		}
+	}
+	return nil
+}
+
+// FileAccessToken returns the bearer token a file access pod was created with
+func FileAccessToken(pod *corev1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		for _, env := range container.Env {
+			if env.Name == "GSHUB_FILE_ACCESS_TOKEN" {
+				return env.Value
+			}
+		}
+	}
+	return ""
+}
+
+// PodEvicted reports whether a pod was evicted (node pressure, drain) or preempted, and why.
+// Such pods are disrupted from outside rather than failing on their own.
+func PodEvicted(pod *corev1.Pod) (string, bool) {
+	if pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == "Evicted" {
+		return pod.Status.Reason, true
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.DisruptionTarget && cond.Status == corev1.ConditionTrue {
+			return cond.Reason, true
+		}
+	}
+	return "", false
+}
+
+// DeletePod deletes a pod. Missing pods are not an error.
+func (c *Client) DeletePod(ctx context.Context, namespace, name string) error {
+	err := c.forNamespace(namespace).CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete pod: %w", err)
+	}
+	return nil
+}
+
+// PodReady reports whether a pod is running and passing its readiness probe
+func PodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxInspectionEvents caps the events returned by InspectServer, newest first
+const maxInspectionEvents = 50
+
+// ServerInspection is the live Kubernetes state of a game server, for debugging without
+// kubectl access. Secrets are redacted.
+type ServerInspection struct {
+	Deployment *appsv1.Deployment            `json:"deployment"` // Nil if it doesn't exist
+	Pods       []corev1.Pod                  `json:"pods"`
+	PVC        *corev1.PersistentVolumeClaim `json:"pvc"` // Nil if it doesn't exist
+	Events     []corev1.Event                `json:"events"`
+}
+
+// redactedValue replaces secret env values in inspections
+const redactedValue = "[redacted]"
+
+// secretEnvMarkers mark env vars whose values are redacted in inspections: the supervisor's
+// and file access pods' tokens, and passwords or keys users set through env overrides
+var secretEnvMarkers = []string{"TOKEN", "PASSWORD", "SECRET", "KEY"}
+
+// InspectServer returns the Deployment, pods, PVC and recent events of a game server. Its
+// resources are all named server-<id>, so events are matched by that prefix.
+func (c *Client) InspectServer(ctx context.Context, namespace, serverID string) (*ServerInspection, error) {
+	name := "server-" + serverID
+	inspection := &ServerInspection{Pods: []corev1.Pod{}, Events: []corev1.Event{}}
+
+	deployment, err := c.GetGameDeployment(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	inspection.Deployment = deployment
+
+	pods, err := c.ListPodsByLabel(ctx, namespace, "server="+serverID)
+	if err != nil {
+		return nil, err
+	}
+	inspection.Pods = append(inspection.Pods, pods...)
+
+	pvc, err := c.forNamespace(namespace).CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		inspection.PVC = pvc
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get PVC: %w", err)
+	}
+
+	events, err := c.forNamespace(namespace).CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	for _, event := range events.Items {
+		if strings.HasPrefix(event.InvolvedObject.Name, name) {
+			inspection.Events = append(inspection.Events, event)
+		}
+	}
+	slices.SortFunc(inspection.Events, func(a, b corev1.Event) int {
+		return eventTime(b).Compare(eventTime(a))
+	})
+	if len(inspection.Events) > maxInspectionEvents {
+		inspection.Events = inspection.Events[:maxInspectionEvents]
+	}
+
+	inspection.sanitize()
+	return inspection, nil
+}
+
+// eventTime returns when an event last happened
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// sanitize redacts secrets and drops bookkeeping fields that only add noise
+func (s *ServerInspection) sanitize() {
+	if s.Deployment != nil {
+		s.Deployment.ManagedFields = nil
+		redactPodSpec(&s.Deployment.Spec.Template.Spec)
+	}
+	for i := range s.Pods {
+		s.Pods[i].ManagedFields = nil
+		redactPodSpec(&s.Pods[i].Spec)
+	}
+	if s.PVC != nil {
+		s.PVC.ManagedFields = nil
+	}
+	for i := range s.Events {
+		s.Events[i].ManagedFields = nil
+	}
+}
+
+// redactPodSpec redacts secret env values of a pod's containers
+func redactPodSpec(spec *corev1.PodSpec) {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			for j := range containers[i].Env {
+				env := &containers[i].Env[j]
+				if env.Value != "" && isSecretEnv(env.Name) {
+					env.Value = redactedValue
+				}
+			}
+		}
+	}
+}
+
+// isSecretEnv reports whether an env var's name marks its value as secret
+func isSecretEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range secretEnvMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// QuotaHeadroom is what a namespace's ResourceQuotas still let new pods request. Nil fields
+// aren't limited by any quota.
+type QuotaHeadroom struct {
+	CPUMillicores *int64
+	MemoryBytes   *int64
+	GPUs          *int64
+	Pods          *int64
+}
+
+// Fits reports whether one more pod requesting the given resources fits the headroom
+func (h *QuotaHeadroom) Fits(cpuMillicores, memoryBytes, gpus int64) bool {
+	fits := func(limit *int64, want int64) bool { return limit == nil || *limit >= want }
+	return fits(h.CPUMillicores, cpuMillicores) &&
+		fits(h.MemoryBytes, memoryBytes) &&
+		fits(h.GPUs, gpus) &&
+		fits(h.Pods, 1)
+}
+
+// NamespaceQuotaHeadroom returns the tightest headroom the namespace's ResourceQuotas leave,
+// or nil if it has none
+func (c *Client) NamespaceQuotaHeadroom(ctx context.Context, namespace string) (*QuotaHeadroom, error) {
+	quotas, err := c.forNamespace(namespace).CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource quotas: %w", err)
+	}
+	if len(quotas.Items) == 0 {
+		return nil, nil
+	}
+
+	headroom := &QuotaHeadroom{}
+	for _, quota := range quotas.Items {
+		for name, hard := range quota.Status.Hard {
+			used := quota.Status.Used[name]
+			// Limits count too: Guaranteed plans set limits equal to requests
+			switch name {
+			case corev1.ResourceCPU, corev1.ResourceRequestsCPU, corev1.ResourceLimitsCPU:
+				tighten(&headroom.CPUMillicores, hard.MilliValue()-used.MilliValue())
+			case corev1.ResourceMemory, corev1.ResourceRequestsMemory, corev1.ResourceLimitsMemory:
+				tighten(&headroom.MemoryBytes, hard.Value()-used.Value())
+			case corev1.DefaultResourceRequestsPrefix + GPUResourceName:
+				tighten(&headroom.GPUs, hard.Value()-used.Value())
+			case corev1.ResourcePods:
+				tighten(&headroom.Pods, hard.Value()-used.Value())
+			}
+		}
+	}
+	return headroom, nil
+}
+
+// tighten lowers a headroom limit to remaining, if that's tighter
+func tighten(limit **int64, remaining int64) {
+	if *limit == nil || remaining < **limit {
+		*limit = &remaining
+	}
+}
@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TemplateHashAnnotation records on a game server Deployment the hash of the image, env and
+// resources it was created from, so changes waiting for a restart can be detected
+const TemplateHashAnnotation = "gshub.io/template-hash"
+
+// PerStartEnvVars are set by the reconciler on every deployment (the auth token is regenerated
+// each time), so they never count as changes
+var PerStartEnvVars = map[string]bool{
+	"GSHUB_SERVER_ID":         true,
+	"GSHUB_API_ENDPOINT":      true,
+	"GSHUB_API_GRPC_ENDPOINT": true,
+	"GSHUB_AUTH_TOKEN":        true,
+}
+
+// TemplateHash hashes the parts of a game server's Deployment a user or the catalog can
+// change: its image, env (minus PerStartEnvVars), resources and egress bandwidth. Only
+// Image, Env, EgressBandwidth and the fields GameContainerResources reads are used.
+func TemplateHash(params DeploymentParams) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "image=%s\n", params.Image)
+	for _, key := range slices.Sorted(maps.Keys(params.Env)) {
+		if PerStartEnvVars[key] {
+			continue
+		}
+		fmt.Fprintf(h, "env.%s=%q\n", key, params.Env[key])
+	}
+
+	resources := GameContainerResources(params)
+	for _, list := range []struct {
+		prefix    string
+		resources corev1.ResourceList
+	}{{"requests", resources.Requests}, {"limits", resources.Limits}} {
+		for _, name := range slices.Sorted(maps.Keys(list.resources)) {
+			qty := list.resources[name]
+			fmt.Fprintf(h, "%s.%s=%s\n", list.prefix, name, qty.String())
+		}
+	}
+	fmt.Fprintf(h, "egress=%s\n", params.EgressBandwidth)
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// DeploymentTemplateHash returns the template hash a Deployment was created with, or "" for
+// Deployments created before it was recorded
+func DeploymentTemplateHash(deployment *appsv1.Deployment) string {
+	return deployment.Annotations[TemplateHashAnnotation]
+}
@@ -0,0 +1,168 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeJob is what a volume job pod does with a server's data volume
+type VolumeJob string
+
+const (
+	VolumeJobBackup  VolumeJob = "backup"  // Archive the volume and upload it
+	VolumeJobRestore VolumeJob = "restore" // Download an archive and replace the volume's contents with it
+	VolumeJobCopy    VolumeJob = "copy"    // Replace the volume's contents with another server's volume
+)
+
+// volumeJobMountPath is where volume job pods mount the data volume
+const volumeJobMountPath = "/data"
+
+// volumeJobSourcePath is where copy jobs mount the volume copied from, read-only
+const volumeJobSourcePath = "/source"
+
+// VolumeJobParams holds parameters for creating a volume job pod
+type VolumeJobParams struct {
+	Namespace string
+	Name      string
+	ServerID  string
+	Image     string // Supervisor image, run in volume job mode
+	PVCName   string
+	Job       VolumeJob
+	URL       string        // Presigned object storage URL to upload to or download from
+	SourcePVC string        // Volume copied from, in the same namespace (copy jobs)
+	Timeout   time.Duration // The pod is stopped after this long
+}
+
+// CreateVolumeJobPod creates a pod that backs up or restores a stopped server's data volume
+// through object storage, or copies another stopped server's volume into it, then exits.
+// Like file access pods it runs no game and tolerates the taints the volume's node may have.
+// Its result, the archive size or the error, is the container's termination message.
+func (c *Client) CreateVolumeJobPod(ctx context.Context, params VolumeJobParams) (*corev1.Pod, error) {
+	deadline := int64(params.Timeout.Seconds())
+	gracePeriod := int64(5)
+	automountToken := false
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      params.Name,
+			Namespace: params.Namespace,
+			Labels: map[string]string{
+				"app":               "volume-job",
+				"volume-job":        string(params.Job),
+				"volume-job-server": params.ServerID,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds:         &deadline,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			AutomountServiceAccountToken:  &automountToken,
+			Tolerations: []corev1.Toleration{
+				{Key: DedicatedNodeTaintKey, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				{Key: SpotNodeTaintKey, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				{Key: string(GPUResourceName), Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "volume",
+					Image: params.Image,
+					Env: []corev1.EnvVar{
+						{Name: "GSHUB_VOLUME_JOB", Value: string(params.Job)},
+						{Name: "GSHUB_VOLUME_DIR", Value: volumeJobMountPath},
+						{Name: "GSHUB_VOLUME_URL", Value: params.URL},
+					},
+					TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "server-data", MountPath: volumeJobMountPath},
+					},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("100m"),
+							corev1.ResourceMemory: resource.MustParse("64Mi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("256Mi"),
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "server-data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: params.PVCName,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if params.Job == VolumeJobCopy {
+		container := &pod.Spec.Containers[0]
+		container.Env = append(container.Env, corev1.EnvVar{Name: "GSHUB_VOLUME_SOURCE", Value: volumeJobSourcePath})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name: "source-data", MountPath: volumeJobSourcePath, ReadOnly: true,
+		})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: "source-data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: params.SourcePVC,
+					ReadOnly:  true,
+				},
+			},
+		})
+	}
+
+	created, err := c.forNamespace(params.Namespace).CoreV1().Pods(params.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create volume job pod: %w", err)
+	}
+	return created, nil
+}
+
+// GetPod retrieves a pod by name. Returns (nil, nil) if it doesn't exist.
+func (c *Client) GetPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	pod, err := c.forNamespace(namespace).CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod: %w", err)
+	}
+	return pod, nil
+}
+
+// TerminationMessage returns the termination message of a pod's first container once it
+// exited, or "" if it hasn't
+func TerminationMessage(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil {
+			return status.State.Terminated.Message
+		}
+	}
+	return ""
+}
+
+// VolumeJobError describes why a volume job pod failed
+func VolumeJobError(pod *corev1.Pod) string {
+	if message := strings.TrimSpace(TerminationMessage(pod)); message != "" {
+		return message
+	}
+	if pod.Status.Reason == "DeadlineExceeded" {
+		return "timed out"
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+	return "pod failed"
+}
@@ -0,0 +1,199 @@
+// Package modrinth searches Modrinth's catalog of mods and plugins and resolves the files
+// to install
+package modrinth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultBaseURL is Modrinth's public API
+const DefaultBaseURL = "https://api.modrinth.com/v2"
+
+// userAgent identifies the platform, as Modrinth asks API clients to
+const userAgent = "gshub (https://github.com/mooncorn/gshub)"
+
+// ErrNotFound is returned for projects and versions Modrinth doesn't know
+var ErrNotFound = errors.New("not found on modrinth")
+
+// Client calls the Modrinth API
+type Client struct {
+	baseURL   string
+	client    *http.Client
+	downloads *http.Client // Files can be large, so downloads get longer
+}
+
+// NewClient creates a Modrinth client for the API at baseURL (DefaultBaseURL if empty)
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL:   baseURL,
+		client:    &http.Client{Timeout: 15 * time.Second},
+		downloads: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Project is a mod or plugin found by a search
+type Project struct {
+	ProjectID   string `json:"project_id"`
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Downloads   int    `json:"downloads"`
+	IconURL     string `json:"icon_url,omitempty"`
+}
+
+// Version is a release of a project
+type Version struct {
+	ID            string       `json:"id"`
+	ProjectID     string       `json:"project_id"`
+	Name          string       `json:"name"`
+	VersionNumber string       `json:"version_number"`
+	GameVersions  []string     `json:"game_versions"`
+	Loaders       []string     `json:"loaders"`
+	Files         []File       `json:"files"`
+	Dependencies  []Dependency `json:"dependencies"`
+}
+
+// File is a downloadable file of a version
+type File struct {
+	URL      string            `json:"url"`
+	Filename string            `json:"filename"`
+	Primary  bool              `json:"primary"`
+	Size     int64             `json:"size"`
+	Hashes   map[string]string `json:"hashes"` // sha1 and sha512, hex
+}
+
+// Dependency is another project a version needs or works with
+type Dependency struct {
+	ProjectID      string `json:"project_id"`
+	VersionID      string `json:"version_id"`
+	DependencyType string `json:"dependency_type"` // required, optional, incompatible or embedded
+}
+
+// PrimaryFile returns the file to install: the one marked primary, or the first
+func (v *Version) PrimaryFile() *File {
+	for i := range v.Files {
+		if v.Files[i].Primary {
+			return &v.Files[i]
+		}
+	}
+	if len(v.Files) > 0 {
+		return &v.Files[0]
+	}
+	return nil
+}
+
+// Search returns up to limit projects of projectType matching query that support loader
+// and, if set, gameVersion, most relevant first
+func (c *Client) Search(ctx context.Context, query, projectType, loader, gameVersion string, limit int) ([]Project, error) {
+	facets := [][]string{{"project_type:" + projectType}, {"categories:" + loader}}
+	if gameVersion != "" {
+		facets = append(facets, []string{"versions:" + gameVersion})
+	}
+	facetsJSON, err := json.Marshal(facets)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"query":  {query},
+		"facets": {string(facetsJSON)},
+		"limit":  {fmt.Sprint(limit)},
+	}
+	var result struct {
+		Hits []Project `json:"hits"`
+	}
+	if err := c.get(ctx, "/search?"+params.Encode(), &result); err != nil {
+		return nil, fmt.Errorf("failed to search modrinth: %w", err)
+	}
+	return result.Hits, nil
+}
+
+// LatestVersion returns the newest version of a project (ID or slug) for loader and, if
+// set, gameVersion
+func (c *Client) LatestVersion(ctx context.Context, project, loader, gameVersion string) (*Version, error) {
+	params := url.Values{"loaders": {fmt.Sprintf("[%q]", loader)}}
+	if gameVersion != "" {
+		params.Set("game_versions", fmt.Sprintf("[%q]", gameVersion))
+	}
+	var versions []Version
+	if err := c.get(ctx, "/project/"+url.PathEscape(project)+"/version?"+params.Encode(), &versions); err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	return &versions[0], nil // Newest first
+}
+
+// GetVersion returns a version by ID
+func (c *Client) GetVersion(ctx context.Context, id string) (*Version, error) {
+	var version Version
+	if err := c.get(ctx, "/version/"+url.PathEscape(id), &version); err != nil {
+		return nil, fmt.Errorf("failed to get version: %w", err)
+	}
+	return &version, nil
+}
+
+// GetProjectTitle returns a project's display name
+func (c *Client) GetProjectTitle(ctx context.Context, project string) (string, error) {
+	var result struct {
+		Title string `json:"title"`
+	}
+	if err := c.get(ctx, "/project/"+url.PathEscape(project), &result); err != nil {
+		return "", fmt.Errorf("failed to get project: %w", err)
+	}
+	return result.Title, nil
+}
+
+// Download opens a version file. The caller closes the body.
+func (c *Client) Download(ctx context.Context, file *File) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, c.downloads, file.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", file.Filename, err)
+	}
+	return resp.Body, nil
+}
+
+func (c *Client) get(ctx context.Context, endpoint string, out any) error {
+	resp, err := c.do(ctx, c.client, c.baseURL+endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do sends a GET request, failing for anything but 200
+func (c *Client) do(ctx context.Context, client *http.Client, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach modrinth: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("modrinth returned status %d", resp.StatusCode)
+	}
+}
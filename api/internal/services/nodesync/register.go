@@ -0,0 +1,117 @@
+package nodesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Node registration errors
+var (
+	// ErrNodeNotJoined is returned when registering a node that isn't in the cluster (yet)
+	ErrNodeNotJoined = errors.New("node hasn't joined the cluster")
+	// ErrNodeNotNew is returned when registering a node that was in the cluster before the
+	// token was issued, or that's already a game server node
+	ErrNodeNotNew = errors.New("node was not added with this token")
+)
+
+// PortRange returns the host ports game servers are allocated on each node: the range an
+// admin set, or else the configured one
+func (s *Service) PortRange(ctx context.Context) (int, int, error) {
+	portMin, portMax, err := s.db.GetPortRange(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	if portMax == 0 {
+		return s.config.PortRangeMin, s.config.PortRangeMax, nil
+	}
+	return portMin, portMax, nil
+}
+
+// SetPortRange changes the host port range of all nodes, creating and removing their ports.
+// Returns how many allocated ports lie outside the new range, in which case it's unchanged.
+// Nodes' firewalls must let the new ports through.
+func (s *Service) SetPortRange(ctx context.Context, portMin, portMax int) (int, error) {
+	// Syncs create the nodes' ports, so one running meanwhile mustn't use the old range
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	inUse, err := s.db.SetPortRange(ctx, portMin, portMax)
+	if err != nil || inUse > 0 {
+		return inUse, err
+	}
+	s.logger.Info("changed port range", zap.Int("min", portMin), zap.Int("max", portMax))
+	return 0, nil
+}
+
+// RegisterNode labels a node that joined the cluster as a game server node reachable at
+// publicIP, with the location of the token it registered with. Location labels that aren't
+// set are left alone.
+//
+// Only nodes that joined after the token was issued can be registered, and only once, so a
+// token can't relabel existing nodes, e.g. to send a game node's players to another IP. An
+// agent retrying with the token its node was registered with is let through.
+func (s *Service) RegisterNode(ctx context.Context, name, publicIP string, token *models.NodeRegistrationToken) error {
+	node, err := s.k8sClient.GetNode(ctx, name)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ErrNodeNotJoined
+		}
+		return err
+	}
+
+	// Node timestamps have second precision
+	if node.CreationTimestamp.Time.Before(token.CreatedAt.Truncate(time.Second)) {
+		return ErrNodeNotNew
+	}
+	retry := token.NodeName != nil && *token.NodeName == name
+	if !retry {
+		if _, ok := node.Labels[s.config.NodeRoleLabel]; ok {
+			return ErrNodeNotNew
+		}
+		if _, ok := node.Labels[s.config.PublicIPLabel]; ok {
+			return ErrNodeNotNew
+		}
+	}
+
+	labels := map[string]string{
+		s.config.NodeRoleLabel: "true",
+		s.config.PublicIPLabel: publicIP,
+	}
+	for key, value := range map[string]string{
+		s.config.RegionLabel:     token.Region,
+		s.config.ZoneLabel:       token.Zone,
+		s.config.ProviderLabel:   token.Provider,
+		s.config.DatacenterLabel: token.Datacenter,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return s.k8sClient.LabelNode(ctx, name, labels)
+}
+
+// SyncNode syncs all nodes right away, rather than on the next interval, and returns the
+// named node as recorded. Returns (nil, nil) if it wasn't synced, e.g. while it's missing
+// its labels.
+func (s *Service) SyncNode(ctx context.Context, name string) (*database.Node, error) {
+	if err := s.SyncNodes(ctx); err != nil {
+		return nil, err
+	}
+	nodes, err := s.db.GetAllNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database nodes: %w", err)
+	}
+	for i := range nodes {
+		if nodes[i].Name == name {
+			return &nodes[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -3,19 +3,24 @@ package nodesync
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/broadcast"
 	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/statusingest"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 )
 
 // Config holds configuration for the node sync service
 type Config struct {
-	// PortRangeMin is the minimum port number for game servers
+	// PortRangeMin is the minimum port number for game servers, until an admin sets a range
 	PortRangeMin int
-	// PortRangeMax is the maximum port number for game servers
+	// PortRangeMax is the maximum port number for game servers, until an admin sets a range
 	PortRangeMax int
 	// SyncInterval is how often to sync nodes (0 = no periodic sync)
 	SyncInterval time.Duration
@@ -23,33 +28,83 @@ type Config struct {
 	NodeRoleLabel string
 	// PublicIPLabel is the label key containing the node's public IP
 	PublicIPLabel string
+	// DedicatedTaintKey is the taint key marking nodes reserved for dedicated plans
+	DedicatedTaintKey string
+	// SpotLabels mark spot (preemptible) nodes, which only run spot plans: a node carrying
+	// any of these labels with the given value ("" matches any value) is a spot node
+	SpotLabels map[string]string
+	// ReclaimTaintKeys are the taints termination handlers put on a spot node its provider
+	// is about to reclaim
+	ReclaimTaintKeys []string
+	// ReclaimCheckInterval is how often spot nodes are checked for reclaim notices between
+	// syncs, as notices come only minutes before the node goes (0 = only when syncing)
+	ReclaimCheckInterval time.Duration
+	// ZoneLabel, RegionLabel, ProviderLabel and DatacenterLabel are the label keys
+	// describing where a node runs, shown to users in server details
+	ZoneLabel       string
+	RegionLabel     string
+	ProviderLabel   string
+	DatacenterLabel string
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() Config {
 	return Config{
-		PortRangeMin:  25501,
-		PortRangeMax:  25999,
-		SyncInterval:  5 * time.Minute,
-		NodeRoleLabel: "node-role.kubernetes.io/gameserver",
-		PublicIPLabel: "platform.io/public-ip",
+		PortRangeMin:      25501,
+		PortRangeMax:      25999,
+		SyncInterval:      5 * time.Minute,
+		NodeRoleLabel:     "node-role.kubernetes.io/gameserver",
+		PublicIPLabel:     "platform.io/public-ip",
+		DedicatedTaintKey: k8s.DedicatedNodeTaintKey,
+		SpotLabels: map[string]string{
+			k8s.SpotNodeTaintKey:                    "true",
+			"node.kubernetes.io/lifecycle":          "spot",
+			"cloud.google.com/gke-spot":             "true",
+			"cloud.google.com/gke-preemptible":      "true",
+			"eks.amazonaws.com/capacityType":        "SPOT",
+			"karpenter.sh/capacity-type":            "spot",
+			"kubernetes.azure.com/scalesetpriority": "spot",
+		},
+		ReclaimTaintKeys: []string{
+			"cloud.google.com/impending-node-termination", // GKE graceful node shutdown
+			"aws-node-termination-handler/spot-itn",       // AWS spot interruption notice
+			"karpenter.sh/disrupted",                      // Karpenter about to remove the node
+		},
+		ReclaimCheckInterval: 30 * time.Second,
+		ZoneLabel:            "topology.kubernetes.io/zone",
+		RegionLabel:          "topology.kubernetes.io/region",
+		ProviderLabel:        "platform.io/provider",
+		DatacenterLabel:      "platform.io/datacenter",
 	}
 }
 
-// Service synchronizes Kubernetes nodes with the database
+// Service synchronizes Kubernetes nodes with the database and tracks node incidents,
+// notifying the owners of servers on an affected node. Servers on a spot node that's being
+// reclaimed are moved to another node.
 type Service struct {
 	db        *database.DB
 	k8sClient *k8s.Client
+	hub       *broadcast.Hub
+	ingestor  *statusingest.Ingestor
 	config    Config
 	logger    *zap.Logger
 	stopCh    chan struct{}
+
+	// syncMu serializes syncs, which also run when the node agent registers a node or an
+	// admin asks for one
+	syncMu sync.Mutex
+
+	statusMu sync.Mutex
+	lastSync *models.NodeSyncStatus
 }
 
 // NewService creates a new node sync service
-func NewService(db *database.DB, k8sClient *k8s.Client, config Config, logger *zap.Logger) *Service {
+func NewService(db *database.DB, k8sClient *k8s.Client, hub *broadcast.Hub, ingestor *statusingest.Ingestor, config Config, logger *zap.Logger) *Service {
 	return &Service{
 		db:        db,
 		k8sClient: k8sClient,
+		hub:       hub,
+		ingestor:  ingestor,
 		config:    config,
 		logger:    logger,
 		stopCh:    make(chan struct{}),
@@ -72,12 +127,23 @@ func (s *Service) Start(ctx context.Context) {
 		ticker := time.NewTicker(s.config.SyncInterval)
 		defer ticker.Stop()
 
+		var reclaimChecks <-chan time.Time
+		if s.config.ReclaimCheckInterval > 0 {
+			reclaimTicker := time.NewTicker(s.config.ReclaimCheckInterval)
+			defer reclaimTicker.Stop()
+			reclaimChecks = reclaimTicker.C
+		}
+
 		for {
 			select {
 			case <-ticker.C:
 				if err := s.SyncNodes(ctx); err != nil {
 					s.logger.Error("periodic node sync failed", zap.Error(err))
 				}
+			case <-reclaimChecks:
+				if err := s.CheckReclaims(ctx); err != nil {
+					s.logger.Error("spot reclaim check failed", zap.Error(err))
+				}
 			case <-s.stopCh:
 				s.logger.Info("node sync stopped")
 				return
@@ -90,6 +156,7 @@ func (s *Service) Start(ctx context.Context) {
 
 	s.logger.Info("node sync started",
 		zap.Duration("interval", s.config.SyncInterval),
+		zap.Duration("reclaim_check_interval", s.config.ReclaimCheckInterval),
 	)
 }
 
@@ -100,11 +167,62 @@ func (s *Service) Stop() {
 
 // SyncNodes fetches nodes from Kubernetes and updates the database
 func (s *Service) SyncNodes(ctx context.Context) error {
+	_, err := s.Sync(ctx)
+	return err
+}
+
+// Sync runs SyncNodes and returns what it did with each node, also kept for LastSync
+func (s *Service) Sync(ctx context.Context) (*models.NodeSyncStatus, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	status := &models.NodeSyncStatus{StartedAt: time.Now(), Nodes: []models.NodeSyncResult{}}
+	err := s.syncNodes(ctx, status)
+	status.FinishedAt = time.Now()
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	s.statusMu.Lock()
+	s.lastSync = status
+	s.statusMu.Unlock()
+	return status, err
+}
+
+// LastSync returns what this replica's last node sync did, or nil before the first one
+func (s *Service) LastSync() *models.NodeSyncStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	return s.lastSync
+}
+
+// syncNodes syncs the nodes, recording the outcome for each in status
+func (s *Service) syncNodes(ctx context.Context, status *models.NodeSyncStatus) error {
+	recorded := make(map[string]bool)
+	record := func(name string, state models.NodeSyncState, message string) {
+		recorded[name] = true
+		status.Nodes = append(status.Nodes, models.NodeSyncResult{Name: name, State: state, Message: message})
+	}
+
 	nodes, err := s.k8sClient.ListNodes(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to list nodes: %w", err)
 	}
 
+	portMin, portMax, err := s.PortRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Pods other than game servers (DaemonSets, system and platform pods) take part of each
+	// node's allocatable resources that game server reservations don't account for. Without
+	// cluster-wide pod access, the full allocatable is recorded.
+	otherPods, err := s.k8sClient.ListPodsByLabel(ctx, "", "app!=game-server")
+	if err != nil {
+		s.logger.Warn("failed to list pods, not accounting for other pods' requests", zap.Error(err))
+	}
+	otherRequests := podRequestsByNode(otherPods)
+
 	// Track which nodes we see from K8s
 	seenNodes := make(map[string]bool)
 
@@ -121,6 +239,7 @@ func (s *Service) SyncNodes(ctx context.Context) error {
 				zap.String("node", node.Name),
 				zap.String("label", s.config.PublicIPLabel),
 			)
+			record(node.Name, models.NodeSkipped, "missing the "+s.config.PublicIPLabel+" label")
 			continue
 		}
 
@@ -129,25 +248,41 @@ func (s *Service) SyncNodes(ctx context.Context) error {
 		// Check if node is ready
 		isReady := isNodeReady(&node)
 
-		// Extract allocatable resources from K8s node
+		// A spot node being reclaimed takes no new servers
+		spot := s.isSpot(&node)
+		reclaimed := spot && s.reclaimNotice(&node)
+
+		// Extract allocatable resources from K8s node, less what other pods request
 		var cpuMillicores *int
 		var memoryBytes *int64
 		if cpuQuantity, ok := node.Status.Allocatable[corev1.ResourceCPU]; ok {
-			val := int(cpuQuantity.MilliValue())
+			val := max(int(cpuQuantity.MilliValue()-otherRequests[node.Name].cpuMillicores), 0)
 			cpuMillicores = &val
 		}
 		if memQuantity, ok := node.Status.Allocatable[corev1.ResourceMemory]; ok {
-			val := memQuantity.Value()
+			val := max(memQuantity.Value()-otherRequests[node.Name].memoryBytes, 0)
 			memoryBytes = &val
 		}
+		gpus := 0
+		if gpuQuantity, ok := node.Status.Allocatable[k8s.GPUResourceName]; ok {
+			gpus = int(gpuQuantity.Value())
+		}
 
 		// Upsert node in database
 		dbNode := &database.Node{
 			Name:                     node.Name,
 			PublicIP:                 publicIP,
-			IsActive:                 isReady,
+			IsActive:                 isReady && !reclaimed,
 			AllocatableCPUMillicores: cpuMillicores,
 			AllocatableMemoryBytes:   memoryBytes,
+			AllocatableGPUs:          gpus,
+			Dedicated:                hasTaint(&node, s.config.DedicatedTaintKey),
+			Spot:                     spot,
+			OS:                       nodeOS(&node),
+			Zone:                     node.Labels[s.config.ZoneLabel],
+			Region:                   node.Labels[s.config.RegionLabel],
+			Provider:                 node.Labels[s.config.ProviderLabel],
+			Datacenter:               node.Labels[s.config.DatacenterLabel],
 		}
 
 		if err := s.db.UpsertNode(ctx, dbNode); err != nil {
@@ -155,25 +290,45 @@ func (s *Service) SyncNodes(ctx context.Context) error {
 				zap.String("node", node.Name),
 				zap.Error(err),
 			)
+			record(node.Name, models.NodeFailed, "failed to record the node")
 			continue
 		}
 
 		// Initialize port allocations for this node
-		if err := s.db.InitializeNodePorts(ctx, dbNode.ID, s.config.PortRangeMin, s.config.PortRangeMax); err != nil {
+		if err := s.db.InitializeNodePorts(ctx, dbNode.ID, portMin, portMax); err != nil {
 			s.logger.Error("failed to initialize ports for node",
 				zap.String("node", node.Name),
 				zap.Error(err),
 			)
+			record(node.Name, models.NodeFailed, "failed to initialize its ports")
 			continue
 		}
 
 		s.logger.Debug("synced node",
 			zap.String("node", node.Name),
 			zap.String("public_ip", publicIP),
-			zap.Bool("is_active", isReady),
+			zap.Bool("is_active", dbNode.IsActive),
 			zap.Intp("cpu_millicores", cpuMillicores),
 			zap.Int64p("memory_bytes", memoryBytes),
+			zap.Int("gpus", gpus),
+			zap.Bool("dedicated", dbNode.Dedicated),
+			zap.Bool("spot", spot),
+			zap.String("os", dbNode.OS),
 		)
+
+		switch {
+		case reclaimed:
+			record(node.Name, models.NodeReclaimed, "")
+		case !isReady:
+			record(node.Name, models.NodeNotReady, "")
+		default:
+			record(node.Name, models.NodeSynced, "")
+		}
+
+		s.syncIncident(ctx, dbNode, incidentKind(&node, reclaimed))
+		if reclaimed {
+			s.migrateServers(ctx, dbNode)
+		}
 	}
 
 	// Mark nodes that are no longer in K8s as inactive
@@ -183,6 +338,13 @@ func (s *Service) SyncNodes(ctx context.Context) error {
 	}
 
 	for _, dbNode := range dbNodes {
+		if !seenNodes[dbNode.Name] {
+			// A node removed from the cluster no longer has an incident to track
+			s.syncIncident(ctx, &dbNode, "")
+			if !recorded[dbNode.Name] {
+				record(dbNode.Name, models.NodeMissing, "")
+			}
+		}
 		if !seenNodes[dbNode.Name] && dbNode.IsActive {
 			s.logger.Info("marking missing node as inactive",
 				zap.String("node", dbNode.Name),
@@ -203,6 +365,208 @@ func (s *Service) SyncNodes(ctx context.Context) error {
 	return nil
 }
 
+// CheckReclaims looks for reclaim notices on spot nodes, which come too shortly before the
+// node goes to wait for the next sync. A reclaimed node is marked inactive and its servers
+// are moved to other nodes.
+func (s *Service) CheckReclaims(ctx context.Context) error {
+	nodes, err := s.k8sClient.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	for i := range nodes {
+		node := &nodes[i]
+		if _, hasRole := node.Labels[s.config.NodeRoleLabel]; !hasRole {
+			continue
+		}
+		if !s.isSpot(node) || !s.reclaimNotice(node) {
+			continue
+		}
+
+		dbNode, err := s.db.GetNodeByName(ctx, node.Name)
+		if err != nil {
+			// Not synced yet, so nothing was placed on it
+			s.logger.Debug("reclaimed node not synced", zap.String("node", node.Name), zap.Error(err))
+			continue
+		}
+		if dbNode.IsActive {
+			if err := s.db.SetNodeActive(ctx, node.Name, false); err != nil {
+				s.logger.Error("failed to mark reclaimed node inactive", zap.String("node", node.Name), zap.Error(err))
+			}
+			dbNode.IsActive = false
+		}
+
+		s.syncIncident(ctx, dbNode, incidentKind(node, true))
+		s.migrateServers(ctx, dbNode)
+	}
+	return nil
+}
+
+// migrateServers requeues the starting and running servers on a reclaimed spot node: they
+// go back to pending with their ports released, so the reconciler places them on another
+// node and rolls their Deployment, stopping the game gracefully while the node is still up
+func (s *Service) migrateServers(ctx context.Context, node *database.Node) {
+	servers, err := s.db.GetActiveServersOnNode(ctx, node.Name)
+	if err != nil {
+		s.logger.Error("failed to get servers on node", zap.String("node", node.Name), zap.Error(err))
+		return
+	}
+
+	for _, serverIDs := range servers {
+		for _, serverID := range serverIDs {
+			applied, err := s.ingestor.Ingest(ctx, statusingest.Report{
+				ServerID: serverID,
+				Source:   statusingest.SourceNodeSync,
+				Status:   models.ServerStatusPending,
+				Message:  "The server's host is being reclaimed by its provider, so the server is moving to another host. Players may have been briefly disconnected.",
+				Reason:   models.StatusReasonNodeReclaimed,
+			})
+			if err != nil {
+				s.logger.Error("failed to requeue server on reclaimed node",
+					zap.String("node", node.Name),
+					zap.String("server_id", serverID),
+					zap.Error(err))
+				continue
+			}
+			if applied {
+				s.logger.Warn("spot node reclaimed, moving server",
+					zap.String("node", node.Name),
+					zap.String("server_id", serverID))
+			}
+		}
+	}
+}
+
+// syncIncident opens or resolves a node's incident to match its current state (empty kind
+// means healthy) and notifies the owners of servers on the node
+func (s *Service) syncIncident(ctx context.Context, node *database.Node, kind models.IncidentKind) {
+	if kind == "" {
+		incident, err := s.db.ResolveNodeIncident(ctx, node.Name)
+		if err != nil {
+			s.logger.Error("failed to resolve node incident", zap.String("node", node.Name), zap.Error(err))
+			return
+		}
+		if incident == nil {
+			return
+		}
+		s.logger.Info("node incident resolved",
+			zap.String("node", node.Name),
+			zap.Duration("duration", incident.ResolvedAt.Sub(incident.StartedAt)))
+		s.notifyIncident(ctx, incident, "resolved")
+		return
+	}
+
+	servers, err := s.db.GetActiveServersOnNode(ctx, node.Name)
+	if err != nil {
+		s.logger.Error("failed to get servers on node", zap.String("node", node.Name), zap.Error(err))
+		return
+	}
+	affected := 0
+	for _, ids := range servers {
+		affected += len(ids)
+	}
+
+	incident, err := s.db.OpenNodeIncident(ctx, node, kind, affected)
+	if err != nil {
+		s.logger.Error("failed to open node incident", zap.String("node", node.Name), zap.Error(err))
+		return
+	}
+	if incident == nil {
+		return // Already open
+	}
+	s.logger.Warn("node incident opened",
+		zap.String("node", node.Name),
+		zap.String("kind", string(kind)),
+		zap.Int("affected_servers", affected))
+	s.publishIncident(incident, "open", servers)
+}
+
+// notifyIncident publishes an incident update to the owners of servers currently on its node
+func (s *Service) notifyIncident(ctx context.Context, incident *models.Incident, state string) {
+	servers, err := s.db.GetActiveServersOnNode(ctx, incident.NodeName)
+	if err != nil {
+		s.logger.Error("failed to get servers on node", zap.String("node", incident.NodeName), zap.Error(err))
+		return
+	}
+	s.publishIncident(incident, state, servers)
+}
+
+// publishIncident publishes an incident event to each owner of the given servers
+func (s *Service) publishIncident(incident *models.Incident, state string, servers map[uuid.UUID][]string) {
+	for userID, serverIDs := range servers {
+		s.hub.Publish(userID, broadcast.IncidentEvent{
+			IncidentID: incident.ID.String(),
+			Kind:       string(incident.Kind),
+			State:      state,
+			ServerIDs:  serverIDs,
+			StartedAt:  incident.StartedAt,
+			ResolvedAt: incident.ResolvedAt,
+			Timestamp:  time.Now().UTC(),
+		})
+	}
+}
+
+// incidentKind returns the incident a node is in, or empty if it's healthy. A node that
+// isn't Ready takes precedence over one being reclaimed, which takes precedence over one
+// that's only drained (termination handlers usually cordon reclaimed nodes too).
+func incidentKind(node *corev1.Node, reclaimed bool) models.IncidentKind {
+	if !isNodeReady(node) {
+		return models.IncidentNodeNotReady
+	}
+	if reclaimed {
+		return models.IncidentNodeReclaimed
+	}
+	if node.Spec.Unschedulable {
+		return models.IncidentNodeDrained
+	}
+	return ""
+}
+
+// podRequests is the CPU and memory pods request on a node
+type podRequests struct {
+	cpuMillicores int64
+	memoryBytes   int64
+}
+
+// podRequestsByNode sums the requests of scheduled, unfinished pods per node. Init containers
+// run before the others, so a pod requests the larger of its biggest init container and the
+// sum of its containers.
+func podRequestsByNode(pods []corev1.Pod) map[string]podRequests {
+	byNode := make(map[string]podRequests)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		var requests, initRequests podRequests
+		for _, container := range pod.Spec.Containers {
+			requests.cpuMillicores += container.Resources.Requests.Cpu().MilliValue()
+			requests.memoryBytes += container.Resources.Requests.Memory().Value()
+		}
+		for _, container := range pod.Spec.InitContainers {
+			initRequests.cpuMillicores = max(initRequests.cpuMillicores, container.Resources.Requests.Cpu().MilliValue())
+			initRequests.memoryBytes = max(initRequests.memoryBytes, container.Resources.Requests.Memory().Value())
+		}
+
+		total := byNode[pod.Spec.NodeName]
+		total.cpuMillicores += max(requests.cpuMillicores, initRequests.cpuMillicores)
+		total.memoryBytes += max(requests.memoryBytes, initRequests.memoryBytes)
+		byNode[pod.Spec.NodeName] = total
+	}
+	return byNode
+}
+
+// nodeOS returns the operating system a node runs, as its kubelet reports it
+func nodeOS(node *corev1.Node) string {
+	if os := node.Status.NodeInfo.OperatingSystem; os != "" {
+		return os
+	}
+	if os := node.Labels[corev1.LabelOSStable]; os != "" {
+		return os
+	}
+	return k8s.OSLinux
+}
+
 // isNodeReady checks if a Kubernetes node is in Ready condition
 func isNodeReady(node *corev1.Node) bool {
 	for _, condition := range node.Status.Conditions {
@@ -212,3 +576,33 @@ func isNodeReady(node *corev1.Node) bool {
 	}
 	return false
 }
+
+// hasTaint checks if a node carries a taint with the given key
+func hasTaint(node *corev1.Node, key string) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// isSpot checks if a node carries one of the spot labels
+func (s *Service) isSpot(node *corev1.Node) bool {
+	for key, want := range s.config.SpotLabels {
+		if value, ok := node.Labels[key]; ok && (want == "" || value == want) {
+			return true
+		}
+	}
+	return false
+}
+
+// reclaimNotice checks if a node carries one of the reclaim taints
+func (s *Service) reclaimNotice(node *corev1.Node) bool {
+	for _, key := range s.config.ReclaimTaintKeys {
+		if hasTaint(node, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package objectstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config holds the location and credentials of an S3-compatible bucket
+type Config struct {
+	Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Client stores and removes objects in an S3-compatible bucket, addressed path-style
+// (endpoint/bucket/key) so it works with AWS and self-hosted stores alike. Only what
+// backups need is implemented.
+type Client struct {
+	config     Config
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the bucket. Uploads can be large, so requests are bounded
+// by their context rather than a client timeout.
+func NewClient(config Config) *Client {
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	return &Client{config: config, httpClient: &http.Client{}}
+}
+
+// Put uploads size bytes from body as the object at key, replacing any existing object
+func (c *Client) Put(ctx context.Context, key string, body io.Reader, size int64) error {
+	req, err := c.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return c.do(req, http.StatusOK)
+}
+
+// Delete removes the object at key. Removing an object that doesn't exist succeeds.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	path := "/" + escapePath(c.config.Bucket) + "/" + escapePath(key)
+	req, err := http.NewRequestWithContext(ctx, method, c.config.Endpoint+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.URL.RawPath = path
+	c.sign(req, time.Now().UTC())
+	return req, nil
+}
+
+func (c *Client) do(req *http.Request, okStatuses ...int) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	for _, status := range okStatuses {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s %s returned status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(message)))
+}
+
+// sign adds an AWS Signature Version 4 to the request. The payload isn't hashed, so bodies
+// can be streamed; use an https endpoint so it can't be tampered with.
+func (c *Client) sign(req *http.Request, now time.Time) {
+	const payloadHash = "UNSIGNED-PAYLOAD"
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"", // No query string
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(c.signingKey(date), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// PresignURL returns a URL that allows one method (GET or PUT) on the object at key for
+// expires (at most 7 days) without credentials, for pods that move data to and from the
+// bucket themselves
+func (c *Client) PresignURL(method, key string, expires time.Duration) (string, error) {
+	return c.presign(method, key, expires, time.Now().UTC())
+}
+
+func (c *Client) presign(method, key string, expires time.Duration, now time.Time) (string, error) {
+	u, err := url.Parse(c.config.Endpoint + "/" + escapePath(c.config.Bucket) + "/" + escapePath(key))
+	if err != nil {
+		return "", fmt.Errorf("invalid object URL: %w", err)
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + c.config.Region + "/s3/aws4_request"
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", c.config.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", fmt.Sprint(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	// Encode sorts by key, as the canonical query string must be
+	canonicalQuery := strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery,
+		"host:" + u.Host,
+		"",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(c.signingKey(date), stringToSign))
+
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// signingKey derives the SigV4 key for a date (YYYYMMDD)
+func (c *Client) signingKey(date string) []byte {
+	key := hmacSHA256([]byte("AWS4"+c.config.SecretAccessKey), date)
+	key = hmacSHA256(key, c.config.Region)
+	key = hmacSHA256(key, "s3")
+	return hmacSHA256(key, "aws4_request")
+}
+
+// escapePath percent-encodes each segment of an object path as S3 expects: everything but
+// unreserved characters, keeping the slashes
+func escapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		ch := p[i]
+		if ch == '/' || ch == '-' || ch == '_' || ch == '.' || ch == '~' ||
+			'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,84 @@
+// Package periodic runs a background service's work on an interval
+package periodic
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Runner calls a function on an interval in the background until it's stopped or its
+// context is cancelled. Runs never overlap.
+type Runner struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context)
+	logger   *zap.Logger
+	runFirst bool
+	wakeCh   chan struct{}
+	stopCh   chan struct{}
+}
+
+// New creates a runner for the named service that calls run every interval
+func New(name string, interval time.Duration, run func(ctx context.Context), logger *zap.Logger) *Runner {
+	return &Runner{
+		name:     name,
+		interval: interval,
+		run:      run,
+		logger:   logger,
+		wakeCh:   make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// RunFirst makes the runner call run as soon as it starts rather than after the first
+// interval. The first run happens in the background, so a slow one doesn't hold up startup.
+func (r *Runner) RunFirst() *Runner {
+	r.runFirst = true
+	return r
+}
+
+// Start begins calling run in the background. The fields are logged along with the interval.
+func (r *Runner) Start(ctx context.Context, fields ...zap.Field) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		if r.runFirst {
+			r.run(ctx)
+		}
+
+		for {
+			select {
+			case <-ticker.C:
+				r.run(ctx)
+			case <-r.wakeCh:
+				r.run(ctx)
+			case <-r.stopCh:
+				r.logger.Info(r.name + " service stopped")
+				return
+			case <-ctx.Done():
+				r.logger.Info(r.name + " service context cancelled")
+				return
+			}
+		}
+	}()
+
+	r.logger.Info(r.name+" service started",
+		append([]zap.Field{zap.Duration("interval", r.interval)}, fields...)...,
+	)
+}
+
+// Stop stops the runner. It doesn't wait for a run in progress to finish.
+func (r *Runner) Stop() {
+	close(r.stopCh)
+}
+
+// Wake calls run without waiting for the next tick
+func (r *Runner) Wake() {
+	select {
+	case r.wakeCh <- struct{}{}:
+	default: // A run is already due
+	}
+}
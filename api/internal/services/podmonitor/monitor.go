@@ -6,41 +6,61 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/mooncorn/gshub/api/internal/database"
 	"github.com/mooncorn/gshub/api/internal/models"
-	"github.com/mooncorn/gshub/api/internal/services/broadcast"
 	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/statusingest"
 	"go.uber.org/zap"
 )
 
 const (
 	// CrashLoopThreshold is the restart count that indicates a crash loop
 	CrashLoopThreshold = 5
+
+	// UnreadyThreshold is how long a running server's pod may stay unready before the
+	// game counts as unresponsive. The supervisor normally reports a hung game itself; this
+	// covers reports that never arrive.
+	UnreadyThreshold = 2 * time.Minute
+
+	// evictionMemory is how long an evicted pod that requeued its server is remembered, so a
+	// preempted pod that takes a while to terminate doesn't requeue the server again
+	evictionMemory = time.Hour
 )
 
 // PodMonitor watches K8s pods for container-level issues
 type PodMonitor struct {
 	db        *database.DB
 	k8sClient *k8s.Client
-	hub       *broadcast.Hub
+	ingestor  *statusingest.Ingestor
 	logger    *zap.Logger
-	namespace string
+	namespace string // Default namespace for servers that don't record their own
 	ticker    *time.Ticker
 	done      chan struct{}
 	interval  time.Duration
+
+	// unreadySince is when each running server's pod was first seen unready, reset once
+	// it's ready again. Only the monitoring loop touches it.
+	unreadySince map[string]time.Time
+	// requeuedPods is when each evicted pod (by UID) requeued its server. Only the monitoring
+	// loop touches it.
+	requeuedPods map[types.UID]time.Time
 }
 
 // NewPodMonitor creates a new pod monitor
-func NewPodMonitor(db *database.DB, k8sClient *k8s.Client, hub *broadcast.Hub, logger *zap.Logger, namespace string) *PodMonitor {
+func NewPodMonitor(db *database.DB, k8sClient *k8s.Client, ingestor *statusingest.Ingestor, logger *zap.Logger, namespace string) *PodMonitor {
 	return &PodMonitor{
 		db:        db,
 		k8sClient: k8sClient,
-		hub:       hub,
+		ingestor:  ingestor,
 		logger:    logger,
 		namespace: namespace,
 		done:      make(chan struct{}),
 		interval:  30 * time.Second,
+
+		unreadySince: make(map[string]time.Time),
+		requeuedPods: make(map[types.UID]time.Time),
 	}
 }
 
@@ -89,12 +109,40 @@ func (m *PodMonitor) checkPods(ctx context.Context) {
 
 	servers := append(runningServers, startingServers...)
 
+	// Forget servers that are no longer running, so a later run starts counting afresh
+	running := make(map[string]bool, len(runningServers))
+	for _, server := range runningServers {
+		running[server.ID.String()] = true
+	}
+	for serverID := range m.unreadySince {
+		if !running[serverID] {
+			delete(m.unreadySince, serverID)
+		}
+	}
+	for uid, requeuedAt := range m.requeuedPods {
+		if time.Since(requeuedAt) > evictionMemory {
+			delete(m.requeuedPods, uid)
+		}
+	}
+
 	for _, server := range servers {
 		serverID := server.ID.String()
-		labelSelector := "server=" + serverID
+		namespace := server.K8sNamespace(m.namespace)
 
-		pod, err := m.k8sClient.GetPodByLabel(ctx, m.namespace, labelSelector)
+		pods, err := m.k8sClient.ListPodsByLabel(ctx, namespace, "server="+serverID)
 		if err != nil {
+			m.logger.Error("failed to list pods", zap.String("server_id", serverID), zap.Error(err))
+			continue
+		}
+
+		// An evicted or preempted pod didn't fail on its own; place the server again
+		if evicted, reason := m.evictedPod(pods); evicted != nil {
+			m.handleEviction(ctx, &server, evicted, reason)
+			continue
+		}
+
+		pod := currentPod(pods)
+		if pod == nil {
 			// Pod not found - could be scaling, stopping, or deleted
 			continue
 		}
@@ -140,7 +188,100 @@ func (m *PodMonitor) checkPods(ctx context.Context) {
 		if pod.Status.Phase == corev1.PodFailed {
 			m.handlePodFailed(ctx, &server, pod.Status.Reason, pod.Status.Message)
 		}
+
+		// Readiness reflects the game's health checks, so a running server whose pod stays
+		// unready has a hung game
+		if running[serverID] && pod.Status.Phase == corev1.PodRunning {
+			m.checkReadiness(ctx, &server, k8s.PodReady(pod))
+		}
+	}
+}
+
+// evictedPod returns a pod of the server that was evicted or preempted, and why. Pods that
+// already requeued the server are skipped.
+func (m *PodMonitor) evictedPod(pods []corev1.Pod) (*corev1.Pod, string) {
+	for i := range pods {
+		if _, seen := m.requeuedPods[pods[i].UID]; seen {
+			continue
+		}
+		if reason, ok := k8s.PodEvicted(&pods[i]); ok {
+			return &pods[i], reason
+		}
+	}
+	return nil, ""
+}
+
+// currentPod returns the server's running pod, or any pod if none is running (it might be
+// starting)
+func currentPod(pods []corev1.Pod) *corev1.Pod {
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodRunning {
+			return &pods[i]
+		}
+	}
+	if len(pods) > 0 {
+		return &pods[0]
+	}
+	return nil
+}
+
+// handleEviction requeues a server whose pod was evicted (node pressure or drain) or
+// preempted: it goes back to pending with its ports released, so the reconciler places it
+// again, on another node if its own has no room. Each pod requeues its server once; evicted
+// pods linger in the Failed phase, so they're also deleted.
+func (m *PodMonitor) handleEviction(ctx context.Context, server *models.Server, pod *corev1.Pod, reason string) {
+	serverID := server.ID.String()
+
+	m.logger.Warn("pod evicted, requeueing server",
+		zap.String("server_id", serverID),
+		zap.String("pod", pod.Name),
+		zap.String("reason", reason))
+
+	_, err := m.ingestor.Ingest(ctx, statusingest.Report{
+		ServerID: serverID,
+		Source:   statusingest.SourcePodMonitor,
+		Status:   models.ServerStatusPending,
+		Message:  "The server was moved off its node by the cluster and is restarting. Players may have been briefly disconnected.",
+		Reason:   models.StatusReasonPodEvicted,
+	})
+	if err != nil {
+		m.logger.Error("failed to requeue evicted server", zap.Error(err), zap.String("server_id", serverID))
+		return
+	}
+
+	m.requeuedPods[pod.UID] = time.Now()
+
+	if pod.Status.Phase == corev1.PodFailed {
+		if err := m.k8sClient.DeletePod(ctx, pod.Namespace, pod.Name); err != nil {
+			m.logger.Error("failed to delete evicted pod", zap.Error(err), zap.String("server_id", serverID))
+		}
+	}
+}
+
+// checkReadiness reports a running server unresponsive once its pod has been unready for
+// UnreadyThreshold. The supervisor reports it running again when the game recovers.
+func (m *PodMonitor) checkReadiness(ctx context.Context, server *models.Server, ready bool) {
+	serverID := server.ID.String()
+	if ready {
+		delete(m.unreadySince, serverID)
+		return
+	}
+
+	since, ok := m.unreadySince[serverID]
+	if !ok {
+		m.unreadySince[serverID] = time.Now()
+		return
 	}
+	if time.Since(since) < UnreadyThreshold {
+		return
+	}
+
+	m.logger.Warn("running server's pod is unready",
+		zap.String("server_id", serverID),
+		zap.Duration("unready_for", time.Since(since)))
+
+	delete(m.unreadySince, serverID)
+	m.reportFailure(ctx, serverID, "Game process health check failed", models.StatusReasonGameUnresponsive)
 }
 
 // handleCrashLoop handles servers in a crash loop
@@ -157,19 +298,7 @@ func (m *PodMonitor) handleCrashLoop(ctx context.Context, server *models.Server,
 		m.logger.Error("failed to update restart count", zap.Error(err), zap.String("server_id", serverID))
 	}
 
-	// Only transition to failed if still running (avoid race with other handlers)
-	transitioned, _ := m.db.TransitionServerStatus(ctx, serverID,
-		models.ServerStatusRunning, models.ServerStatusFailed, message)
-
-	if transitioned {
-		// Broadcast to user
-		m.hub.Publish(server.UserID, broadcast.StatusEvent{
-			ServerID:      serverID,
-			Status:        string(models.ServerStatusFailed),
-			StatusMessage: &message,
-			Timestamp:     time.Now().UTC(),
-		})
-	}
+	m.reportFailure(ctx, serverID, message, models.StatusReasonCrashLoop)
 }
 
 // handleOOMKill handles servers that were killed due to out of memory
@@ -184,18 +313,7 @@ func (m *PodMonitor) handleOOMKill(ctx context.Context, server *models.Server) {
 		m.logger.Error("failed to record OOM event", zap.Error(err), zap.String("server_id", serverID))
 	}
 
-	// Transition to failed
-	transitioned, _ := m.db.TransitionServerStatus(ctx, serverID,
-		models.ServerStatusRunning, models.ServerStatusFailed, message)
-
-	if transitioned {
-		m.hub.Publish(server.UserID, broadcast.StatusEvent{
-			ServerID:      serverID,
-			Status:        string(models.ServerStatusFailed),
-			StatusMessage: &message,
-			Timestamp:     time.Now().UTC(),
-		})
-	}
+	m.reportFailure(ctx, serverID, message, models.StatusReasonOOMKilled)
 }
 
 // handleWaitingState handles pods stuck in waiting states
@@ -203,22 +321,16 @@ func (m *PodMonitor) handleWaitingState(ctx context.Context, server *models.Serv
 	serverID := server.ID.String()
 	message := fmt.Sprintf("%s: %s", reason, waitMessage)
 
+	statusReason := models.StatusReasonCrashLoop
+	if reason == "ImagePullBackOff" || reason == "ErrImagePull" {
+		statusReason = models.StatusReasonImagePullError
+	}
+
 	m.logger.Warn("pod in waiting state",
 		zap.String("server_id", serverID),
 		zap.String("reason", reason))
 
-	// Try to transition from starting to failed
-	transitioned, _ := m.db.TransitionServerStatus(ctx, serverID,
-		models.ServerStatusStarting, models.ServerStatusFailed, message)
-
-	if transitioned {
-		m.hub.Publish(server.UserID, broadcast.StatusEvent{
-			ServerID:      serverID,
-			Status:        string(models.ServerStatusFailed),
-			StatusMessage: &message,
-			Timestamp:     time.Now().UTC(),
-		})
-	}
+	m.reportFailure(ctx, serverID, message, statusReason)
 }
 
 // handlePodFailed handles pods that have failed
@@ -230,21 +342,20 @@ func (m *PodMonitor) handlePodFailed(ctx context.Context, server *models.Server,
 		zap.String("server_id", serverID),
 		zap.String("reason", reason))
 
-	// Try to transition from either running or starting to failed
-	transitioned, _ := m.db.TransitionServerStatus(ctx, serverID,
-		models.ServerStatusRunning, models.ServerStatusFailed, message)
-
-	if !transitioned {
-		transitioned, _ = m.db.TransitionServerStatus(ctx, serverID,
-			models.ServerStatusStarting, models.ServerStatusFailed, message)
-	}
+	m.reportFailure(ctx, serverID, message, models.StatusReasonPodFailed)
+}
 
-	if transitioned {
-		m.hub.Publish(server.UserID, broadcast.StatusEvent{
-			ServerID:      serverID,
-			Status:        string(models.ServerStatusFailed),
-			StatusMessage: &message,
-			Timestamp:     time.Now().UTC(),
-		})
+// reportFailure submits a failure to the status ingestor, which only applies it to servers
+// that are still starting or running
+func (m *PodMonitor) reportFailure(ctx context.Context, serverID, message string, reason models.StatusReason) {
+	_, err := m.ingestor.Ingest(ctx, statusingest.Report{
+		ServerID: serverID,
+		Source:   statusingest.SourcePodMonitor,
+		Status:   models.ServerStatusFailed,
+		Message:  message,
+		Reason:   reason,
+	})
+	if err != nil {
+		m.logger.Error("failed to report server failure", zap.Error(err), zap.String("server_id", serverID))
 	}
 }
@@ -3,37 +3,69 @@ package portalloc
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
 	"github.com/mooncorn/gshub/api/internal/services/k8s"
 	"go.uber.org/zap"
 )
 
 // Service manages port allocations for game servers
 type Service struct {
-	db     *database.DB
-	logger *zap.Logger
+	db        *database.DB
+	k8sClient *k8s.Client
+	edgePorts EdgePortRange
+	cooldown  time.Duration // Released ports are kept from other servers this long
+	logger    *zap.Logger
 }
 
 // NewService creates a new port allocation service
-func NewService(db *database.DB, logger *zap.Logger) *Service {
+func NewService(db *database.DB, k8sClient *k8s.Client, edgePorts EdgePortRange, cooldown time.Duration, logger *zap.Logger) *Service {
 	return &Service{
-		db:     db,
-		logger: logger,
+		db:        db,
+		k8sClient: k8sClient,
+		edgePorts: edgePorts,
+		cooldown:  cooldown,
+		logger:    logger,
 	}
 }
 
+// EdgePortRange is the range of ports servers get on the edge proxy
+type EdgePortRange struct {
+	Min int
+	Max int
+}
+
+// Enabled reports whether servers get edge ports
+func (r EdgePortRange) Enabled() bool {
+	return r.Min > 0 && r.Max >= r.Min
+}
+
 // PortRequirement specifies a port needed for a game server
 type PortRequirement struct {
-	Name     string // "game", "query", "rcon"
-	Protocol string // "TCP" or "UDP"
+	Name      string // "game", "query", "rcon"
+	Protocol  string // "TCP" or "UDP"
+	SamePort  string // Name of a requirement of the other protocol that gets the same port number
+	Preferred int    // Port number to take if free, on any node (0 = any)
 }
 
 // ResourceRequirement specifies CPU/memory needed for a game server
 type ResourceRequirement struct {
-	CPUMillicores int   // CPU in millicores (1000 = 1 core)
-	MemoryBytes   int64 // Memory in bytes
+	CPUMillicores int    // CPU in millicores (1000 = 1 core)
+	MemoryBytes   int64  // Memory in bytes
+	GPUs          int    // nvidia.com/gpu count, not subject to the overhead factor
+	Dedicated     bool   // Needs a dedicated node to itself (dedicated plans)
+	Spot          bool   // Needs a spot node (spot plans)
+	OS            string // Node operating system the game runs on ("" = linux)
+
+	Plan       string // Plan name, counted for MaxPerNode
+	MaxPerNode int    // Most active servers on Plan per node (0 = no cap)
+
+	Namespace          string   // Namespace the server's pod runs in, checked against its ResourceQuotas ("" to skip)
+	FallbackNamespaces []string // Namespaces a new server may be placed in instead, when Namespace's quota is used up
+	Region             string   // Only nodes in this topology.kubernetes.io/region are considered ("" = any)
 }
 
 // AllocatedPort contains node info with the allocated port
@@ -48,15 +80,10 @@ type AllocatedPort struct {
 // AllocatePorts allocates ports and resources for a server on an available node
 // Returns allocated ports or error if no capacity
 // If resourceReq is nil, resource checking is skipped (for backward compatibility)
-func (s *Service) AllocatePorts(ctx context.Context, serverID uuid.UUID, requirements []PortRequirement, resourceReq *ResourceRequirement) ([]AllocatedPort, error) {
+// cause and detail (optional) are recorded in the allocation history
+func (s *Service) AllocatePorts(ctx context.Context, serverID uuid.UUID, requirements []PortRequirement, resourceReq *ResourceRequirement, cause models.PortAllocationCause, detail string) ([]AllocatedPort, error) {
 	// Convert to database requirements
-	dbReqs := make([]database.PortRequirement, len(requirements))
-	for i, req := range requirements {
-		dbReqs[i] = database.PortRequirement{
-			Name:     req.Name,
-			Protocol: req.Protocol,
-		}
-	}
+	dbReqs := toDBRequirements(requirements)
 
 	// Convert resource requirement if provided, applying overhead factor
 	// to reserve capacity for system processes (kubelet, containerd, OS)
@@ -65,10 +92,29 @@ func (s *Service) AllocatePorts(ctx context.Context, serverID uuid.UUID, require
 		dbResourceReq = &database.ResourceRequirement{
 			CPUMillicores: int(float64(resourceReq.CPUMillicores) * k8s.ResourceOverheadFactor),
 			MemoryBytes:   int64(float64(resourceReq.MemoryBytes) * k8s.ResourceOverheadFactor),
+			GPUs:          resourceReq.GPUs,
+			Dedicated:     resourceReq.Dedicated,
+			Spot:          resourceReq.Spot,
+			OS:            resourceReq.OS,
+			Region:        resourceReq.Region,
+			Plan:          resourceReq.Plan,
+			MaxPerNode:    resourceReq.MaxPerNode,
+		}
+	}
+
+	// Nodes may have room the namespace's quota doesn't, and the Deployment would then fail
+	// to create its pod
+	if dbResourceReq != nil {
+		fits, err := s.fitsQuota(ctx, resourceReq.Namespace, dbResourceReq.CPUMillicores, dbResourceReq.MemoryBytes, dbResourceReq.GPUs)
+		if err != nil {
+			return nil, err
+		}
+		if !fits {
+			return nil, fmt.Errorf("resource quota of namespace %s exhausted", resourceReq.Namespace)
 		}
 	}
 
-	node, dbPorts, err := s.db.AllocatePortsForServer(ctx, serverID, dbReqs, dbResourceReq)
+	node, dbPorts, err := s.db.AllocatePortsForServer(ctx, serverID, dbReqs, dbResourceReq, cause, detail)
 	if err != nil {
 		s.logger.Error("failed to allocate ports",
 			zap.String("server_id", serverID.String()),
@@ -77,6 +123,17 @@ func (s *Service) AllocatePorts(ctx context.Context, serverID uuid.UUID, require
 		return nil, fmt.Errorf("failed to allocate ports: %w", err)
 	}
 
+	// Edge ports are kept across allocations; this only assigns missing ones
+	if s.edgePorts.Enabled() {
+		if err := s.db.AssignEdgePorts(ctx, serverID, dbReqs, s.edgePorts.Min, s.edgePorts.Max); err != nil {
+			// The server is still reachable on its node port
+			s.logger.Warn("failed to assign edge ports",
+				zap.String("server_id", serverID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+
 	// Convert to service-level types
 	ports := make([]AllocatedPort, len(dbPorts))
 	for i, p := range dbPorts {
@@ -119,9 +176,10 @@ func (s *Service) GetServerPorts(ctx context.Context, serverID uuid.UUID) ([]All
 	return ports, nil
 }
 
-// ReleasePorts releases all ports allocated to a server
-func (s *Service) ReleasePorts(ctx context.Context, serverID uuid.UUID) error {
-	if err := s.db.ReleaseServerPorts(ctx, serverID); err != nil {
+// ReleasePorts releases all ports allocated to a server, recording cause and detail
+// (optional) in the allocation history
+func (s *Service) ReleasePorts(ctx context.Context, serverID uuid.UUID, cause models.PortAllocationCause, detail string) error {
+	if err := s.db.ReleaseServerPorts(ctx, serverID, s.cooldown, cause, detail); err != nil {
 		s.logger.Error("failed to release ports",
 			zap.String("server_id", serverID.String()),
 			zap.Error(err),
@@ -131,6 +189,7 @@ func (s *Service) ReleasePorts(ctx context.Context, serverID uuid.UUID) error {
 
 	s.logger.Info("released ports for server",
 		zap.String("server_id", serverID.String()),
+		zap.String("cause", string(cause)),
 	)
 
 	return nil
@@ -145,45 +204,168 @@ func (s *Service) HasAllocatedPorts(ctx context.Context, serverID uuid.UUID) (bo
 	return len(ports) > 0, nil
 }
 
+// PreferredPortAvailable reports whether the preferred port number of the requirements, if
+// any, is free on a node the server could be placed on
+func (s *Service) PreferredPortAvailable(ctx context.Context, requirements []PortRequirement, resourceReq *ResourceRequirement) (bool, error) {
+	var dbResourceReq *database.ResourceRequirement
+	if resourceReq != nil {
+		dbResourceReq = &database.ResourceRequirement{
+			Dedicated: resourceReq.Dedicated,
+			Spot:      resourceReq.Spot,
+			OS:        resourceReq.OS,
+		}
+	}
+	return s.db.PreferredPortAvailable(ctx, toDBRequirements(requirements), dbResourceReq)
+}
+
+func toDBRequirements(requirements []PortRequirement) []database.PortRequirement {
+	dbReqs := make([]database.PortRequirement, len(requirements))
+	for i, req := range requirements {
+		dbReqs[i] = database.PortRequirement{
+			Name:      req.Name,
+			Protocol:  req.Protocol,
+			SamePort:  req.SamePort,
+			Preferred: req.Preferred,
+		}
+	}
+	return dbReqs
+}
+
 // HasCapacity checks if there's available capacity for a server with given requirements
 // This is a read-only check that does not allocate any resources
 // Used for optimistic validation before checkout
 func (s *Service) HasCapacity(ctx context.Context, requirements []PortRequirement, resourceReq *ResourceRequirement) (bool, error) {
+	check := newCapacityCheck(requirements, resourceReq)
+
+	_, fits, err := s.fitsAnyQuota(ctx, check)
+	if err != nil {
+		return false, err
+	}
+	if !fits {
+		s.logger.Debug("capacity check result: namespace quotas exhausted", zap.Strings("namespaces", check.namespaces))
+		return false, nil
+	}
+
+	hasCapacity, err := s.db.CheckResourceCapacity(ctx, check.tcpPorts, check.udpPorts, check.samePorts, check.cpuMillicores, check.memoryBytes,
+		check.gpus, check.dedicated, check.spot, check.os, check.plan, check.maxPerNode, check.region)
+	if err != nil {
+		s.logger.Error("failed to check resource capacity",
+			zap.Error(err),
+		)
+		return false, fmt.Errorf("failed to check resource capacity: %w", err)
+	}
+
+	s.logger.Debug("capacity check result",
+		zap.Bool("has_capacity", hasCapacity),
+		zap.Int("tcp_ports", check.tcpPorts),
+		zap.Int("udp_ports", check.udpPorts),
+		zap.Int("same_ports", check.samePorts),
+		zap.Int("cpu_millicores", check.cpuMillicores),
+		zap.Int64("memory_bytes", check.memoryBytes),
+		zap.Int("gpus", check.gpus),
+		zap.Bool("dedicated", check.dedicated),
+		zap.Bool("spot", check.spot),
+		zap.String("os", check.os),
+		zap.Int("max_per_node", check.maxPerNode),
+	)
+
+	return hasCapacity, nil
+}
+
+// capacityCheck is what a server needs from a node, after the overhead factor
+type capacityCheck struct {
+	tcpPorts      int
+	udpPorts      int
+	samePorts     int // Port numbers needed free on both protocols
+	cpuMillicores int
+	memoryBytes   int64
+	gpus          int
+	dedicated     bool
+	spot          bool
+	os            string
+	plan          string
+	maxPerNode    int
+	namespaces    []string // Namespace, then FallbackNamespaces
+	region        string
+}
+
+func newCapacityCheck(requirements []PortRequirement, resourceReq *ResourceRequirement) capacityCheck {
+	var check capacityCheck
+
 	// Count required ports by protocol
-	tcpCount := 0
-	udpCount := 0
 	for _, req := range requirements {
 		switch req.Protocol {
 		case "TCP":
-			tcpCount++
+			check.tcpPorts++
 		case "UDP":
-			udpCount++
+			check.udpPorts++
+		}
+		if req.SamePort != "" {
+			check.samePorts++
 		}
 	}
 
 	// Apply overhead factor to resource requirements
-	cpuMillicores := 0
-	var memoryBytes int64 = 0
 	if resourceReq != nil {
-		cpuMillicores = int(float64(resourceReq.CPUMillicores) * k8s.ResourceOverheadFactor)
-		memoryBytes = int64(float64(resourceReq.MemoryBytes) * k8s.ResourceOverheadFactor)
+		check.cpuMillicores = int(float64(resourceReq.CPUMillicores) * k8s.ResourceOverheadFactor)
+		check.memoryBytes = int64(float64(resourceReq.MemoryBytes) * k8s.ResourceOverheadFactor)
+		check.gpus = resourceReq.GPUs
+		check.dedicated = resourceReq.Dedicated
+		check.spot = resourceReq.Spot
+		check.os = resourceReq.OS
+		check.plan = resourceReq.Plan
+		check.maxPerNode = resourceReq.MaxPerNode
+		if resourceReq.Namespace != "" {
+			check.namespaces = append([]string{resourceReq.Namespace}, resourceReq.FallbackNamespaces...)
+		}
+		check.region = resourceReq.Region
+	}
+	return check
+}
+
+// SelectNamespace returns the first of the requirement's Namespace and FallbackNamespaces
+// whose ResourceQuotas a new server fits, or Namespace if none does, so placing it fails on
+// the quota of the namespace it would normally go to
+func (s *Service) SelectNamespace(ctx context.Context, resourceReq *ResourceRequirement) (string, error) {
+	namespace, fits, err := s.fitsAnyQuota(ctx, newCapacityCheck(nil, resourceReq))
+	if err != nil || !fits {
+		return resourceReq.Namespace, err
+	}
+	return namespace, nil
+}
+
+// fitsAnyQuota returns the first of the check's namespaces whose ResourceQuotas it fits,
+// and false if it fits none. Checks without namespaces always fit.
+func (s *Service) fitsAnyQuota(ctx context.Context, check capacityCheck) (string, bool, error) {
+	if len(check.namespaces) == 0 {
+		return "", true, nil
+	}
+	for _, namespace := range check.namespaces {
+		fits, err := s.fitsQuota(ctx, namespace, check.cpuMillicores, check.memoryBytes, check.gpus)
+		if err != nil || fits {
+			return namespace, fits, err
+		}
+	}
+	return "", false, nil
+}
+
+// fitsQuota reports whether a pod requesting the given resources (after the overhead factor)
+// fits the ResourceQuotas of its namespace. Namespaces without quotas always fit.
+func (s *Service) fitsQuota(ctx context.Context, namespace string, cpuMillicores int, memoryBytes int64, gpus int) (bool, error) {
+	if namespace == "" || s.k8sClient == nil {
+		return true, nil
 	}
 
-	hasCapacity, err := s.db.CheckResourceCapacity(ctx, tcpCount, udpCount, cpuMillicores, memoryBytes)
+	headroom, err := s.k8sClient.NamespaceQuotaHeadroom(ctx, namespace)
 	if err != nil {
-		s.logger.Error("failed to check resource capacity",
+		s.logger.Error("failed to check namespace quota",
+			zap.String("namespace", namespace),
 			zap.Error(err),
 		)
-		return false, fmt.Errorf("failed to check resource capacity: %w", err)
+		return false, fmt.Errorf("failed to check namespace quota: %w", err)
 	}
-
-	s.logger.Debug("capacity check result",
-		zap.Bool("has_capacity", hasCapacity),
-		zap.Int("tcp_ports", tcpCount),
-		zap.Int("udp_ports", udpCount),
-		zap.Int("cpu_millicores", cpuMillicores),
-		zap.Int64("memory_bytes", memoryBytes),
-	)
-
-	return hasCapacity, nil
+	if headroom == nil {
+		return true, nil
+	}
+	return headroom.Fits(int64(cpuMillicores), memoryBytes, int64(gpus)), nil
 }
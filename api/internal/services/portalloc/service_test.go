@@ -0,0 +1,219 @@
+package portalloc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mi = 1024 * 1024
+
+func TestEdgePortRange_Enabled(t *testing.T) {
+	tests := []struct {
+		name string
+		r    EdgePortRange
+		want bool
+	}{
+		{"unset", EdgePortRange{}, false},
+		{"range", EdgePortRange{Min: 20000, Max: 20100}, true},
+		{"single port", EdgePortRange{Min: 20000, Max: 20000}, true},
+		{"inverted", EdgePortRange{Min: 20100, Max: 20000}, false},
+		{"no minimum", EdgePortRange{Max: 20000}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.r.Enabled())
+		})
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	game := &k8s.GameConfig{
+		Ports: []k8s.GamePort{
+			{Name: "game", Protocol: "TCP", SamePort: "voice"},
+			{Name: "voice", Protocol: "UDP"},
+			{Name: "rcon", Protocol: "TCP"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		plan          k8s.PlanConfig
+		namespaces    []string
+		wantCPU       int
+		wantMemory    int64
+		wantNamespace string
+		wantFallbacks []string
+	}{
+		{
+			name:       "plan plus sidecar",
+			plan:       k8s.PlanConfig{CPU: "1", Memory: "2Gi"},
+			wantCPU:    1000 + sidecarCPUMillicores,
+			wantMemory: 2048*mi + sidecarMemoryBytes,
+		},
+		{
+			name:       "pinned CPUs round up to whole cores",
+			plan:       k8s.PlanConfig{CPU: "1500m", Memory: "1Gi", Performance: true, PinCPUs: true},
+			wantCPU:    2000,
+			wantMemory: 1024*mi + sidecarMemoryBytes,
+		},
+		{
+			name:          "one namespace",
+			plan:          k8s.PlanConfig{CPU: "500m", Memory: "512Mi"},
+			namespaces:    []string{"games-small"},
+			wantCPU:       500 + sidecarCPUMillicores,
+			wantMemory:    512*mi + sidecarMemoryBytes,
+			wantNamespace: "games-small",
+			wantFallbacks: []string{},
+		},
+		{
+			name:          "fallback namespaces",
+			plan:          k8s.PlanConfig{CPU: "500m", Memory: "512Mi"},
+			namespaces:    []string{"games-small", "games-small-2", "games-small-3"},
+			wantCPU:       500 + sidecarCPUMillicores,
+			wantMemory:    512*mi + sidecarMemoryBytes,
+			wantNamespace: "games-small",
+			wantFallbacks: []string{"games-small-2", "games-small-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ports, req := Requirements(game, &tt.plan, "small", tt.namespaces)
+
+			assert.Equal(t, []PortRequirement{
+				{Name: "game", Protocol: "TCP", SamePort: "voice"},
+				{Name: "voice", Protocol: "UDP"},
+				{Name: "rcon", Protocol: "TCP"},
+			}, ports)
+			require.NotNil(t, req)
+			assert.Equal(t, tt.wantCPU, req.CPUMillicores)
+			assert.Equal(t, tt.wantMemory, req.MemoryBytes)
+			assert.Equal(t, k8s.OSLinux, req.OS)
+			assert.Equal(t, "small", req.Plan)
+			assert.Equal(t, tt.wantNamespace, req.Namespace)
+			assert.Equal(t, tt.wantFallbacks, req.FallbackNamespaces)
+		})
+	}
+}
+
+func TestNewCapacityCheck(t *testing.T) {
+	ports := []PortRequirement{
+		{Name: "game", Protocol: "TCP", SamePort: "voice"},
+		{Name: "voice", Protocol: "UDP"},
+		{Name: "query", Protocol: "UDP"},
+	}
+
+	tests := []struct {
+		name           string
+		req            *ResourceRequirement
+		wantCPU        int
+		wantMemory     int64
+		wantNamespaces []string
+	}{
+		{"ports only", nil, 0, 0, nil},
+		{"overhead applied", &ResourceRequirement{CPUMillicores: 1000, MemoryBytes: 1000}, 900, 900, nil},
+		{"namespace", &ResourceRequirement{Namespace: "games"}, 0, 0, []string{"games"}},
+		{"namespace then fallbacks", &ResourceRequirement{Namespace: "games", FallbackNamespaces: []string{"games-2"}}, 0, 0, []string{"games", "games-2"}},
+		{"no namespace skips the quota", &ResourceRequirement{FallbackNamespaces: []string{"games-2"}}, 0, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := newCapacityCheck(ports, tt.req)
+			assert.Equal(t, 1, check.tcpPorts)
+			assert.Equal(t, 2, check.udpPorts)
+			assert.Equal(t, 1, check.samePorts)
+			assert.Equal(t, tt.wantCPU, check.cpuMillicores)
+			assert.Equal(t, tt.wantMemory, check.memoryBytes)
+			assert.Equal(t, tt.wantNamespaces, check.namespaces)
+		})
+	}
+}
+
+func TestCapacityCheck_Missing(t *testing.T) {
+	check := capacityCheck{tcpPorts: 1, udpPorts: 1, cpuMillicores: 1000, memoryBytes: 1024, gpus: 1, plan: "small", maxPerNode: 2}
+	roomy := database.NodeHeadroom{FreeTCPPorts: 5, FreeUDPPorts: 5, FreeCPUMillicores: 4000, FreeMemoryBytes: 4096, FreeGPUs: 1, PlanServers: 1}
+	taken := uuid.New()
+
+	tests := []struct {
+		name   string
+		modify func(*database.NodeHeadroom)
+		want   []models.CapacityResource
+	}{
+		{"fits", func(*database.NodeHeadroom) {}, nil},
+		{"dedicated node taken", func(n *database.NodeHeadroom) { n.DedicatedServerID = &taken }, []models.CapacityResource{models.CapacityNodes}},
+		{"no TCP ports", func(n *database.NodeHeadroom) { n.FreeTCPPorts = 0 }, []models.CapacityResource{models.CapacityPorts}},
+		{"no UDP ports", func(n *database.NodeHeadroom) { n.FreeUDPPorts = 0 }, []models.CapacityResource{models.CapacityPorts}},
+		{"CPU", func(n *database.NodeHeadroom) { n.FreeCPUMillicores = 999 }, []models.CapacityResource{models.CapacityCPU}},
+		{"memory", func(n *database.NodeHeadroom) { n.FreeMemoryBytes = 1023 }, []models.CapacityResource{models.CapacityMemory}},
+		{"GPU", func(n *database.NodeHeadroom) { n.FreeGPUs = 0 }, []models.CapacityResource{models.CapacityGPU}},
+		{"plan limit", func(n *database.NodeHeadroom) { n.PlanServers = 2 }, []models.CapacityResource{models.CapacityPlanLimit}},
+		{
+			"most fundamental first",
+			func(n *database.NodeHeadroom) { n.FreeMemoryBytes = 0; n.FreeTCPPorts = 0; n.FreeCPUMillicores = 0 },
+			[]models.CapacityResource{models.CapacityPorts, models.CapacityCPU, models.CapacityMemory},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := roomy
+			tt.modify(&node)
+			assert.Equal(t, tt.want, check.missing(node))
+		})
+	}
+}
+
+func TestCapacityCheck_Release(t *testing.T) {
+	check := capacityCheck{tcpPorts: 1, cpuMillicores: 1000, memoryBytes: 1024, plan: "small", maxPerNode: 1}
+	releasing := uuid.New()
+
+	tests := []struct {
+		name          string
+		dedicatedTo   *uuid.UUID
+		release       database.ScheduledRelease
+		wantMissing   []models.CapacityResource
+		wantDedicated bool
+	}{
+		{
+			name:        "frees resources and the plan slot",
+			release:     database.ScheduledRelease{ServerID: releasing, TCPPorts: 1, CPUMillicores: 1000, MemoryBytes: 1024, Plan: "small", CancelAt: time.Now()},
+			wantMissing: nil,
+		},
+		{
+			name:        "other plans keep the slot",
+			release:     database.ScheduledRelease{ServerID: releasing, TCPPorts: 1, CPUMillicores: 1000, MemoryBytes: 1024, Plan: "large", CancelAt: time.Now()},
+			wantMissing: []models.CapacityResource{models.CapacityPlanLimit},
+		},
+		{
+			name:        "frees the dedicated node it holds",
+			dedicatedTo: &releasing,
+			release:     database.ScheduledRelease{ServerID: releasing, TCPPorts: 1, CPUMillicores: 1000, MemoryBytes: 1024, Plan: "small", CancelAt: time.Now()},
+			wantMissing: nil,
+		},
+		{
+			name:          "another server keeps the dedicated node",
+			dedicatedTo:   func() *uuid.UUID { id := uuid.New(); return &id }(),
+			release:       database.ScheduledRelease{ServerID: releasing, TCPPorts: 1, CPUMillicores: 1000, MemoryBytes: 1024, Plan: "small", CancelAt: time.Now()},
+			wantMissing:   []models.CapacityResource{models.CapacityNodes},
+			wantDedicated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := database.NodeHeadroom{PlanServers: 1, DedicatedServerID: tt.dedicatedTo}
+			check.release(&node, tt.release)
+			assert.Equal(t, tt.wantMissing, check.missing(node))
+			assert.Equal(t, tt.wantDedicated, node.DedicatedServerID != nil)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package portalloc
+
+import (
+	"context"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Sidecar resources reserved on top of the plan at checkout
+const (
+	sidecarCPUMillicores = 100               // 100m
+	sidecarMemoryBytes   = 128 * 1024 * 1024 // 128Mi
+)
+
+// Requirements returns the ports and resources a new server of game on plan (named
+// planName) needs, including the supervisor sidecar. namespaces are where its pod may run,
+// in order of preference, for the quota check.
+func Requirements(game *k8s.GameConfig, plan *k8s.PlanConfig, planName string, namespaces []string) ([]PortRequirement, *ResourceRequirement) {
+	ports := make([]PortRequirement, len(game.Ports))
+	for i, p := range game.Ports {
+		ports[i] = PortRequirement{Name: p.Name, Protocol: p.Protocol, SamePort: p.SamePort}
+	}
+
+	cpu := resource.MustParse(plan.CPU)
+	memory := resource.MustParse(plan.Memory)
+	req := &ResourceRequirement{
+		CPUMillicores: plan.RoundCPU(int(cpu.MilliValue()) + sidecarCPUMillicores),
+		MemoryBytes:   memory.Value() + sidecarMemoryBytes,
+		GPUs:          plan.GPU,
+		Dedicated:     plan.Dedicated,
+		Spot:          plan.Spot,
+		OS:            game.NodeOS(),
+		Plan:          planName,
+		MaxPerNode:    plan.MaxPerNode,
+	}
+	if len(namespaces) > 0 {
+		req.Namespace, req.FallbackNamespaces = namespaces[0], namespaces[1:]
+	}
+	return ports, req
+}
+
+// Shortage explains why HasCapacity found no room: the resource the closest node lacks, and
+// when subscriptions already set to end are expected to free enough of it, if they will.
+// Waitlist is left for the caller to set.
+func (s *Service) Shortage(ctx context.Context, requirements []PortRequirement, resourceReq *ResourceRequirement) (*models.CapacityShortage, error) {
+	check := newCapacityCheck(requirements, resourceReq)
+
+	_, fits, err := s.fitsAnyQuota(ctx, check)
+	if err != nil {
+		return nil, err
+	}
+	if !fits {
+		return &models.CapacityShortage{Resource: models.CapacityQuota}, nil
+	}
+
+	nodes, err := s.db.ListNodeHeadroom(ctx, check.dedicated, check.spot, check.os, check.plan, check.region)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := s.db.ListScheduledReleases(ctx, check.dedicated, check.spot, check.os, check.region)
+	if err != nil {
+		return nil, err
+	}
+
+	shortage := &models.CapacityShortage{Resource: models.CapacityNodes}
+	fewest := -1
+	for i := range nodes {
+		missing := check.missing(nodes[i])
+		if len(missing) > 0 && (fewest < 0 || len(missing) < fewest) {
+			fewest = len(missing)
+			shortage.Resource = missing[0]
+		}
+
+		// Releases are soonest first, so the first one that makes room is when it frees up
+		for _, release := range releases {
+			if release.NodeID != nodes[i].NodeID {
+				continue
+			}
+			check.release(&nodes[i], release)
+			if len(check.missing(nodes[i])) == 0 {
+				if shortage.AvailableAt == nil || release.CancelAt.Before(*shortage.AvailableAt) {
+					at := release.CancelAt
+					shortage.AvailableAt = &at
+				}
+				break
+			}
+		}
+	}
+	return shortage, nil
+}
+
+// missing returns the resources node lacks for the check, most fundamental first, matching
+// the conditions of CheckResourceCapacity
+func (check capacityCheck) missing(node database.NodeHeadroom) []models.CapacityResource {
+	var missing []models.CapacityResource
+	if node.DedicatedServerID != nil {
+		missing = append(missing, models.CapacityNodes)
+	}
+	if node.FreeTCPPorts < check.tcpPorts || node.FreeUDPPorts < check.udpPorts {
+		missing = append(missing, models.CapacityPorts)
+	}
+	if node.FreeCPUMillicores < int64(check.cpuMillicores) {
+		missing = append(missing, models.CapacityCPU)
+	}
+	if node.FreeMemoryBytes < check.memoryBytes {
+		missing = append(missing, models.CapacityMemory)
+	}
+	if node.FreeGPUs < check.gpus {
+		missing = append(missing, models.CapacityGPU)
+	}
+	if check.maxPerNode > 0 && node.PlanServers >= check.maxPerNode {
+		missing = append(missing, models.CapacityPlanLimit)
+	}
+	return missing
+}
+
+// release adds what a server frees on its node when its subscription ends
+func (check capacityCheck) release(node *database.NodeHeadroom, release database.ScheduledRelease) {
+	node.FreeTCPPorts += release.TCPPorts
+	node.FreeUDPPorts += release.UDPPorts
+	node.FreeCPUMillicores += release.CPUMillicores
+	node.FreeMemoryBytes += release.MemoryBytes
+	node.FreeGPUs += release.GPUs
+	if release.Plan == check.plan {
+		node.PlanServers--
+	}
+	if node.DedicatedServerID != nil && *node.DedicatedServerID == release.ServerID {
+		node.DedicatedServerID = nil
+	}
+}
@@ -0,0 +1,133 @@
+package querycache
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"golang.org/x/sync/singleflight"
+)
+
+// Config holds configuration for the query cache
+type Config struct {
+	// TTL is how long an answer is served before the database is read again (default: 15 seconds)
+	TTL time.Duration
+	// MaxEntries bounds memory use under floods of made-up subdomains (default: 10000)
+	MaxEntries int
+	// HeartbeatFreshness is how recent a heartbeat must be for a running server to count as
+	// online (default: 2 minutes, four missed heartbeats)
+	HeartbeatFreshness time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		TTL:                15 * time.Second,
+		MaxEntries:         10000,
+		HeartbeatFreshness: 2 * time.Minute,
+	}
+}
+
+// subdomainPattern matches valid subdomains (see validateDNS); anything else is rejected
+// without a lookup
+var subdomainPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+type entry struct {
+	query     *models.ServerQuery // nil for unknown subdomains, so they're cached too
+	expiresAt time.Time
+}
+
+// Cache answers public server queries from the status and player counts supervisors
+// report, so the internet never reaches game query ports. Answers (including "no such
+// server") are cached for TTL and concurrent misses share one database read, so query
+// floods cost at most one read per subdomain per TTL.
+type Cache struct {
+	db     *database.DB
+	config Config
+
+	mu      sync.Mutex
+	entries map[string]entry
+	group   singleflight.Group
+}
+
+// New creates a query cache
+func New(db *database.DB, config Config) *Cache {
+	return &Cache{
+		db:      db,
+		config:  config,
+		entries: make(map[string]entry),
+	}
+}
+
+// Get returns the query answer for a subdomain, or nil if there's no such server
+func (c *Cache) Get(ctx context.Context, subdomain string) (*models.ServerQuery, error) {
+	if !subdomainPattern.MatchString(subdomain) {
+		return nil, nil
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.entries[subdomain]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.query, nil
+	}
+
+	result, err, _ := c.group.Do(subdomain, func() (any, error) {
+		query, err := c.load(ctx, subdomain)
+		if err != nil {
+			return nil, err
+		}
+		c.store(subdomain, query)
+		return query, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.ServerQuery), nil
+}
+
+// load reads a subdomain's query answer from the database
+func (c *Cache) load(ctx context.Context, subdomain string) (*models.ServerQuery, error) {
+	info, err := c.db.GetServerQueryInfo(ctx, subdomain)
+	if err != nil || info == nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	query := &models.ServerQuery{
+		Subdomain: subdomain,
+		Name:      info.DisplayName,
+		Game:      info.Game,
+		Online: info.Status == models.ServerStatusRunning &&
+			info.LastHeartbeat != nil && now.Sub(*info.LastHeartbeat) < c.config.HeartbeatFreshness,
+		CheckedAt: now,
+	}
+	if query.Online {
+		query.Players = info.PlayersOnline
+	}
+	return query, nil
+}
+
+// store caches an answer, making room by dropping expired entries (or all of them) once
+// the cache is full
+func (c *Cache) store(subdomain string, query *models.ServerQuery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.config.MaxEntries {
+		for key, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= c.config.MaxEntries {
+			c.entries = make(map[string]entry)
+		}
+	}
+	c.entries[subdomain] = entry{query: query, expiresAt: now.Add(c.config.TTL)}
+}
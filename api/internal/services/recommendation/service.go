@@ -0,0 +1,326 @@
+package recommendation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/email"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"github.com/mooncorn/gshub/api/internal/services/stripe"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Config holds configuration for the recommendation service
+type Config struct {
+	// Interval is how often recommendations are regenerated (default: 7 days)
+	Interval time.Duration
+	// Window is how much usage history recommendations are based on (default: 7 days)
+	Window time.Duration
+	// MinHours is how many hours a server must have run in Window to get a recommendation
+	// (default: 24)
+	MinHours int
+	// Retention is how long hourly usage is kept (default: 30 days)
+	Retention time.Duration
+	// EmailInterval is how often users are sent a summary of their recommendations
+	// (default: 30 days)
+	EmailInterval time.Duration
+
+	// Upgrades are recommended when peak memory reaches UpgradeMemoryRatio of the plan's
+	// memory or p95 CPU reaches UpgradeCPURatio of its CPU (defaults: 0.9 and 0.8).
+	// Downgrades when both fit within DowngradeRatio of the next plan down (default: 0.6).
+	UpgradeMemoryRatio float64
+	UpgradeCPURatio    float64
+	DowngradeRatio     float64
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:           7 * 24 * time.Hour,
+		Window:             7 * 24 * time.Hour,
+		MinHours:           24,
+		Retention:          30 * 24 * time.Hour,
+		EmailInterval:      30 * 24 * time.Hour,
+		UpgradeMemoryRatio: 0.9,
+		UpgradeCPURatio:    0.8,
+		DowngradeRatio:     0.6,
+	}
+}
+
+// Service recommends plan upgrades and downgrades from the CPU and memory usage supervisors
+// report in heartbeats, and emails users a monthly summary of them
+type Service struct {
+	db            *database.DB
+	k8sClient     *k8s.Client
+	appConfig     *config.Config
+	stripeService *stripe.Service
+	email         *email.Service
+	config        Config
+	logger        *zap.Logger
+	runner        *periodic.Runner
+}
+
+// NewService creates a new recommendation service
+func NewService(db *database.DB, k8sClient *k8s.Client, appConfig *config.Config, stripeService *stripe.Service, emailService *email.Service, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:            db,
+		k8sClient:     k8sClient,
+		appConfig:     appConfig,
+		stripeService: stripeService,
+		email:         emailService,
+		config:        config,
+		logger:        logger,
+	}
+	s.runner = periodic.New("recommendation", config.Interval, s.run, logger).RunFirst()
+	return s
+}
+
+// Start begins the recommendation service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx)
+}
+
+// Stop stops the recommendation service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// run regenerates recommendations, sends due summaries and drops old usage
+func (s *Service) run(ctx context.Context) {
+	s.generate(ctx)
+	s.sendSummaries(ctx)
+
+	if err := s.db.DeleteResourceUsageBefore(ctx, time.Now().Add(-s.config.Retention)); err != nil {
+		s.logger.Error("failed to delete old resource usage", zap.Error(err))
+	}
+}
+
+// generate replaces every server's recommendation with one from its usage over Window
+func (s *Service) generate(ctx context.Context) {
+	startedAt := time.Now()
+
+	stats, err := s.db.ListServerUsageStats(ctx, startedAt.Add(-s.config.Window), s.config.MinHours)
+	if err != nil {
+		s.logger.Error("failed to list server usage stats", zap.Error(err))
+		return
+	}
+
+	// Catalogs are loaded once per run and channel
+	catalogs := map[string]*k8s.GameCatalog{}
+	generated := 0
+	for _, stat := range stats {
+		serverID := stat.ServerID.String()
+
+		catalog, ok := catalogs[stat.CatalogChannel]
+		if !ok {
+			catalog, err = s.k8sClient.LoadGameCatalog(ctx, s.appConfig.K8sNamespace, s.appConfig.GameCatalogName(stat.CatalogChannel))
+			if err != nil {
+				// Keep existing recommendations rather than dropping them below
+				s.logger.Error("failed to load game catalog", zap.String("channel", stat.CatalogChannel), zap.Error(err))
+				return
+			}
+			catalogs[stat.CatalogChannel] = catalog
+		}
+
+		gameConfig, err := catalog.GetGameConfig(string(stat.Game))
+		if err != nil {
+			continue
+		}
+		rec := s.recommend(stat, gameConfig)
+		if rec == nil {
+			continue
+		}
+		s.addPriceDelta(ctx, string(stat.Game), rec)
+
+		if err := s.db.UpsertPlanRecommendation(ctx, serverID, rec); err != nil {
+			s.logger.Error("failed to store plan recommendation", zap.String("server_id", serverID), zap.Error(err))
+			continue
+		}
+		generated++
+	}
+
+	// Servers that no longer need a change (or didn't run enough) lose their recommendation
+	if err := s.db.DeletePlanRecommendationsBefore(ctx, startedAt); err != nil {
+		s.logger.Error("failed to delete stale plan recommendations", zap.Error(err))
+	}
+
+	s.logger.Info("generated plan recommendations",
+		zap.Int("servers", len(stats)),
+		zap.Int("recommendations", generated),
+	)
+}
+
+// recommend returns the plan change a server's usage calls for, or nil if its plan fits.
+// Only plans offered for the game and purchasable are recommended.
+func (s *Service) recommend(stat database.ServerUsageStats, gameConfig *k8s.GameConfig) *models.PlanRecommendation {
+	planConfig, err := gameConfig.GetPlanConfig(string(stat.Plan))
+	if err != nil {
+		return nil
+	}
+	cpuMillicores, memoryMB, ok := planCapacity(planConfig)
+	if !ok {
+		return nil
+	}
+
+	// CPU is reported in percent of one core
+	usedMillicores := stat.P95CPUPercent * 10
+	rec := &models.PlanRecommendation{
+		CurrentPlan:   stat.Plan,
+		AvgCPUPercent: stat.AvgCPUPercent,
+		P95CPUPercent: stat.P95CPUPercent,
+		AvgMemoryMB:   stat.AvgMemoryMB,
+		PeakMemoryMB:  stat.PeakMemoryMB,
+	}
+
+	var reason models.PlanRecommendationReason
+	switch {
+	case float64(stat.PeakMemoryMB) >= float64(memoryMB)*s.config.UpgradeMemoryRatio:
+		reason = models.ReasonMemoryPressure
+	case usedMillicores >= float64(cpuMillicores)*s.config.UpgradeCPURatio:
+		reason = models.ReasonCPUPressure
+	}
+	if reason != "" {
+		for _, plan := range stat.Plan.LargerPlans() {
+			if s.offered(gameConfig, string(stat.Game), plan) {
+				rec.Direction = models.RecommendUpgrade
+				rec.RecommendedPlan = plan
+				rec.Reason = reason
+				return rec
+			}
+		}
+		return nil
+	}
+
+	for _, plan := range stat.Plan.SmallerPlans() {
+		if !s.offered(gameConfig, string(stat.Game), plan) {
+			continue
+		}
+		smaller, _ := gameConfig.GetPlanConfig(string(plan))
+		smallerCPU, smallerMemory, ok := planCapacity(smaller)
+		if ok && float64(stat.PeakMemoryMB) <= float64(smallerMemory)*s.config.DowngradeRatio &&
+			usedMillicores <= float64(smallerCPU)*s.config.DowngradeRatio {
+			rec.Direction = models.RecommendDowngrade
+			rec.RecommendedPlan = plan
+			rec.Reason = models.ReasonUnderused
+			return rec
+		}
+		// Only the next plan down is considered
+		break
+	}
+	return nil
+}
+
+// offered reports whether a plan is in the game's catalog entry and has a price
+func (s *Service) offered(gameConfig *k8s.GameConfig, game string, plan models.ServerPlan) bool {
+	if _, err := gameConfig.GetPlanConfig(string(plan)); err != nil {
+		return false
+	}
+	_, err := s.appConfig.GetPriceID(game, string(plan))
+	return err == nil
+}
+
+// addPriceDelta sets the monthly price difference of a recommendation. It's left unset if
+// prices can't be retrieved from Stripe.
+func (s *Service) addPriceDelta(ctx context.Context, game string, rec *models.PlanRecommendation) {
+	currentPriceID, err := s.appConfig.GetPriceID(game, string(rec.CurrentPlan))
+	if err != nil {
+		return
+	}
+	nextPriceID, err := s.appConfig.GetPriceID(game, string(rec.RecommendedPlan))
+	if err != nil {
+		return
+	}
+	currentPrice, err := s.stripeService.GetPrice(ctx, currentPriceID)
+	if err != nil {
+		s.logger.Warn("failed to get current plan price", zap.String("price_id", currentPriceID), zap.Error(err))
+		return
+	}
+	nextPrice, err := s.stripeService.GetPrice(ctx, nextPriceID)
+	if err != nil {
+		s.logger.Warn("failed to get recommended plan price", zap.String("price_id", nextPriceID), zap.Error(err))
+		return
+	}
+
+	delta := nextPrice.UnitAmount - currentPrice.UnitAmount
+	rec.PriceDelta = &delta
+	rec.Currency = string(nextPrice.Currency)
+}
+
+// sendSummaries emails each user due a summary their current recommendations
+func (s *Service) sendSummaries(ctx context.Context) {
+	items, err := s.db.ListRecommendationDigestItems(ctx, s.config.EmailInterval)
+	if err != nil {
+		s.logger.Error("failed to list recommendation digest items", zap.Error(err))
+		return
+	}
+
+	// Items are ordered by user
+	for start := 0; start < len(items); {
+		end := start
+		var recs []email.PlanRecommendation
+		for ; end < len(items) && items[end].UserID == items[start].UserID; end++ {
+			recs = append(recs, emailRecommendation(items[end]))
+		}
+		user := items[start]
+		start = end
+
+		if err := s.email.SendPlanRecommendationsEmail(user.Email, recs); err != nil {
+			// Not marked as emailed, so it's retried on the next run
+			s.logger.Error("failed to send plan recommendations", zap.String("user_id", user.UserID.String()), zap.Error(err))
+			continue
+		}
+		if err := s.db.MarkRecommendationsEmailed(ctx, user.UserID); err != nil {
+			s.logger.Error("failed to mark recommendations emailed", zap.String("user_id", user.UserID.String()), zap.Error(err))
+		}
+	}
+}
+
+// emailRecommendation describes a recommendation for the summary email
+func emailRecommendation(item database.RecommendationDigestItem) email.PlanRecommendation {
+	var why string
+	switch item.Reason {
+	case models.ReasonMemoryPressure:
+		why = "peak memory is close to the plan's limit"
+	case models.ReasonCPUPressure:
+		why = "CPU use is close to the plan's limit"
+	default:
+		why = "it uses well under the smaller plan's resources"
+	}
+
+	var action string
+	if item.Direction == models.RecommendUpgrade {
+		action = "Upgrade"
+	} else {
+		action = "Downgrade"
+	}
+
+	rec := email.PlanRecommendation{
+		ServerName: item.DisplayName,
+		ServerID:   item.ServerID.String(),
+		Summary:    fmt.Sprintf("%s from %s to %s: %s", action, item.CurrentPlan, item.RecommendedPlan, why),
+	}
+	if item.PriceDelta != nil {
+		rec.PriceDelta = fmt.Sprintf("%+.2f %s per month", float64(*item.PriceDelta)/100, strings.ToUpper(item.Currency))
+	}
+	return rec
+}
+
+// planCapacity returns a plan's CPU in millicores and memory in MB
+func planCapacity(plan *k8s.PlanConfig) (int64, int64, bool) {
+	cpu, err := resource.ParseQuantity(plan.CPU)
+	if err != nil {
+		return 0, 0, false
+	}
+	memory, err := resource.ParseQuantity(plan.Memory)
+	if err != nil {
+		return 0, 0, false
+	}
+	return cpu.MilliValue(), memory.Value() / (1024 * 1024), true
+}
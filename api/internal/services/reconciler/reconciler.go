@@ -4,45 +4,56 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"slices"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 
+	"github.com/google/uuid"
 	"github.com/mooncorn/gshub/api/internal/database"
 	"github.com/mooncorn/gshub/api/internal/models"
 	"github.com/mooncorn/gshub/api/internal/services/k8s"
 	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"github.com/mooncorn/gshub/api/internal/services/statusingest"
 	"go.uber.org/zap"
 )
 
 // ServerReconciler reconciles pending servers by creating K8s resources
 type ServerReconciler struct {
-	db                 *database.DB
-	k8sClient          *k8s.Client
-	portAllocService   *portalloc.Service
-	logger             *zap.Logger
-	done               chan struct{}
-	ticker             *time.Ticker
-	reconcileTicket    time.Duration
-	k8sNamespace       string
-	k8sGameCatalogName string
+	db               *database.DB
+	k8sClient        *k8s.Client
+	portAllocService *portalloc.Service
+	machine          *serverstate.Machine
+	ingestor         *statusingest.Ingestor
+	logger           *zap.Logger
+	done             chan struct{}
+	ticker           *time.Ticker
+	reconcileTicket  time.Duration
+	k8sNamespace     string                      // Control-plane namespace, default for servers that don't record their own
+	serverNamespaces func(plan string) []string  // Namespaces new servers on a plan may be placed in, in order
+	catalogName      func(channel string) string // Game catalog ConfigMap for a server's catalog channel
+	supervisorGRPC   bool                        // Give supervisors the gRPC endpoint
 }
 
 // NewServerReconciler creates a new reconciler
-func NewServerReconciler(db *database.DB, k8sClient *k8s.Client, portAllocService *portalloc.Service, logger *zap.Logger, k8sNamespace, k8sGameCatalogName string) *ServerReconciler {
+func NewServerReconciler(db *database.DB, k8sClient *k8s.Client, portAllocService *portalloc.Service, machine *serverstate.Machine, ingestor *statusingest.Ingestor, logger *zap.Logger, k8sNamespace string, serverNamespaces func(plan string) []string, catalogName func(channel string) string, supervisorGRPC bool) *ServerReconciler {
 	return &ServerReconciler{
-		db:                 db,
-		k8sClient:          k8sClient,
-		portAllocService:   portAllocService,
-		logger:             logger,
-		done:               make(chan struct{}),
-		reconcileTicket:    15 * time.Second, // Run every 15 seconds
-		k8sNamespace:       k8sNamespace,
-		k8sGameCatalogName: k8sGameCatalogName,
+		db:               db,
+		k8sClient:        k8sClient,
+		portAllocService: portAllocService,
+		machine:          machine,
+		ingestor:         ingestor,
+		logger:           logger,
+		done:             make(chan struct{}),
+		reconcileTicket:  15 * time.Second, // Run every 15 seconds
+		k8sNamespace:     k8sNamespace,
+		serverNamespaces: serverNamespaces,
+		catalogName:      catalogName,
+		supervisorGRPC:   supervisorGRPC,
 	}
 }
 
@@ -109,10 +120,19 @@ func (r *ServerReconciler) reconcileStartupTimeouts(ctx context.Context) {
 
 		// Check timeout (5 minutes)
 		if time.Since(server.UpdatedAt) > 5*time.Minute {
-			r.db.TransitionServerStatus(ctx, serverID,
-				models.ServerStatusStarting, models.ServerStatusFailed,
-				"Timeout waiting for pod to be ready")
-			r.logger.Warn("server startup timed out", zap.String("server_id", serverID))
+			transitioned, err := r.machine.Transition(ctx, &server, serverstate.Request{
+				From:    []models.ServerStatus{models.ServerStatusStarting},
+				To:      models.ServerStatusFailed,
+				Message: "Timeout waiting for pod to be ready",
+				Reason:  models.StatusReasonStartupTimeout,
+			})
+			if err != nil {
+				r.logger.Error("failed to mark startup timeout", zap.String("server_id", serverID), zap.Error(err))
+				continue
+			}
+			if transitioned {
+				r.logger.Warn("server startup timed out", zap.String("server_id", serverID))
+			}
 		}
 	}
 }
@@ -131,18 +151,27 @@ func (r *ServerReconciler) reconcilePendingServers(ctx context.Context) {
 
 	r.logger.Debug("reconciling pending servers", zap.Int("count", len(pendingServers)))
 
-	// Load game catalog once
-	catalog, err := r.k8sClient.LoadGameCatalog(ctx, r.k8sNamespace, r.k8sGameCatalogName)
-	if err != nil {
-		r.logger.Error("failed to load game catalog", zap.Error(err))
-		return
-	}
+	// Load each catalog channel's ConfigMap once
+	catalogs := map[string]*k8s.GameCatalog{}
 
 	// Reconcile each pending server
 	successCount := 0
 	failureCount := 0
 
 	for _, server := range pendingServers {
+		catalogName := r.catalogName(server.CatalogChannel)
+		catalog, ok := catalogs[catalogName]
+		if !ok {
+			var err error
+			catalog, err = r.k8sClient.LoadGameCatalog(ctx, r.k8sNamespace, catalogName)
+			if err != nil {
+				r.logger.Error("failed to load game catalog", zap.String("catalog", catalogName), zap.Error(err))
+				failureCount++
+				continue
+			}
+			catalogs[catalogName] = catalog
+		}
+
 		if err := r.reconcileServer(ctx, &server, catalog); err != nil {
 			r.logger.Error("failed to reconcile server",
 				zap.String("server_id", server.ID.String()),
@@ -188,7 +217,7 @@ func (r *ServerReconciler) reconcileHeartbeatTimeouts(ctx context.Context) {
 
 		// Check if deployment still exists
 		deployName := fmt.Sprintf("server-%s", serverID)
-		exists, err := r.k8sClient.DeploymentExists(ctx, r.k8sNamespace, deployName)
+		exists, err := r.k8sClient.DeploymentExists(ctx, server.K8sNamespace(r.k8sNamespace), deployName)
 		if err != nil {
 			r.logger.Error("failed to check deployment existence",
 				zap.Error(err),
@@ -196,22 +225,26 @@ func (r *ServerReconciler) reconcileHeartbeatTimeouts(ctx context.Context) {
 			continue
 		}
 
+		report := statusingest.Report{
+			ServerID: serverID,
+			Source:   statusingest.SourceHeartbeat,
+			Status:   models.ServerStatusFailed,
+			Message:  "Server unresponsive (heartbeat timeout). Click Start to restart.",
+			Reason:   models.StatusReasonHeartbeatTimeout,
+		}
 		if !exists {
-			// Deployment gone but DB says running - update status
-			r.db.TransitionServerStatus(ctx, serverID,
-				models.ServerStatusRunning, models.ServerStatusFailed,
-				"Server stopped unexpectedly (deployment not found)")
-			r.logger.Warn("server deployment not found, marking failed", zap.String("server_id", serverID))
-			continue
+			// Deployment gone but DB says running
+			report.Message = "Server stopped unexpectedly (deployment not found)"
+			report.Reason = models.StatusReasonDeploymentMissing
 		}
 
-		// Deployment exists but supervisor not responding - mark as failed
-		transitioned, _ := r.db.TransitionServerStatus(ctx, serverID,
-			models.ServerStatusRunning, models.ServerStatusFailed,
-			"Server unresponsive (heartbeat timeout). Click Start to restart.")
-
+		transitioned, err := r.ingestor.Ingest(ctx, report)
+		if err != nil {
+			r.logger.Error("failed to report heartbeat timeout", zap.Error(err), zap.String("server_id", serverID))
+			continue
+		}
 		if transitioned {
-			r.logger.Warn("server marked failed due to heartbeat timeout", zap.String("server_id", serverID))
+			r.logger.Warn("server marked failed", zap.String("server_id", serverID), zap.String("reason", string(report.Reason)))
 		}
 	}
 }
@@ -219,13 +252,14 @@ func (r *ServerReconciler) reconcileHeartbeatTimeouts(ctx context.Context) {
 // reconcileServer processes a single pending server
 func (r *ServerReconciler) reconcileServer(ctx context.Context, server *models.Server, catalog *k8s.GameCatalog) error {
 	serverID := server.ID.String()
+	namespace := server.K8sNamespace(r.k8sNamespace)
 
 	// Get game configuration
 	gameConfig, err := catalog.GetGameConfig(string(server.Game))
 	if err != nil {
 		errMsg := fmt.Sprintf("invalid game config: %v", err)
 		r.logger.Warn("marking server as failed", zap.String("server_id", serverID), zap.String("reason", errMsg))
-		return r.db.MarkServerFailed(ctx, serverID, errMsg)
+		return r.db.MarkServerFailed(ctx, serverID, errMsg, models.StatusReasonInvalidConfig)
 	}
 
 	// Get plan configuration
@@ -233,21 +267,35 @@ func (r *ServerReconciler) reconcileServer(ctx context.Context, server *models.S
 	if err != nil {
 		errMsg := fmt.Sprintf("invalid plan config: %v", err)
 		r.logger.Warn("marking server as failed", zap.String("server_id", serverID), zap.String("reason", errMsg))
-		return r.db.MarkServerFailed(ctx, serverID, errMsg)
+		return r.db.MarkServerFailed(ctx, serverID, errMsg, models.StatusReasonInvalidConfig)
 	}
 
-	// Calculate supervisor overhead
-	supervisorCPU := 50   // 50m default
-	supervisorMem := int64(64 * 1024 * 1024) // 64Mi default
-	if gameConfig.SupervisorOverhead != nil {
-		if gameConfig.SupervisorOverhead.CPU != "" {
-			supervisorCPU = parseCPUToMillicores(gameConfig.SupervisorOverhead.CPU)
+	// Custom games bring their own image, ports, start command and health check
+	if server.Game == models.GameCustom {
+		def, err := r.db.GetServerCustomGame(ctx, serverID)
+		if err != nil {
+			r.logger.Error("failed to get custom game", zap.String("server_id", serverID), zap.Error(err))
+			return r.db.UpdateServerLastReconciled(ctx, serverID)
 		}
-		if gameConfig.SupervisorOverhead.Memory != "" {
-			supervisorMem = parseMemoryToBytes(gameConfig.SupervisorOverhead.Memory)
+		if def == nil {
+			errMsg := "custom game has no definition"
+			r.logger.Warn("marking server as failed", zap.String("server_id", serverID), zap.String("reason", errMsg))
+			return r.db.MarkServerFailed(ctx, serverID, errMsg, models.StatusReasonInvalidConfig)
 		}
+		gameConfig = gameConfig.WithCustomGame(def)
 	}
 
+	// Refuse catalog entries that would produce a broken deployment (or panic parsing
+	// resource quantities) instead of creating one
+	if issues := gameConfig.PlanIssues(string(server.Game), string(server.Plan)); len(issues) > 0 {
+		errMsg := fmt.Sprintf("invalid catalog entry: %s", issues[0])
+		r.logger.Warn("marking server as failed", zap.String("server_id", serverID), zap.String("reason", errMsg))
+		return r.db.MarkServerFailed(ctx, serverID, errMsg, models.StatusReasonInvalidConfig)
+	}
+
+	// Plan resources plus supervisor overhead
+	cpuMillicores, memBytes := gameConfig.ServerResources(planConfig)
+
 	// STEP 1: Allocate ports (if not already allocated)
 	allocations, err := r.portAllocService.GetServerPorts(ctx, server.ID)
 	if err != nil {
@@ -255,30 +303,86 @@ func (r *ServerReconciler) reconcileServer(ctx context.Context, server *models.S
 		return r.db.UpdateServerLastReconciled(ctx, serverID)
 	}
 
+	// Restarts keep the server's ports; they no longer fit if the game's ports changed or an
+	// admin blocked one, and the server moves if its node went inactive (e.g. a spot node
+	// being reclaimed)
+	reallocateReason := ""
+	switch {
+	case len(allocations) == 0:
+	case !allocationsMatchPorts(allocations, gameConfig.Ports):
+		reallocateReason = "game ports changed"
+	case !r.nodeActive(ctx, allocations[0].NodeName):
+		reallocateReason = "node inactive"
+	case r.portsBlocked(ctx, serverID):
+		reallocateReason = "ports blocked"
+	}
+	if reallocateReason != "" {
+		r.logger.Info("reallocating ports", zap.String("server_id", serverID), zap.String("reason", reallocateReason))
+		if err := r.portAllocService.ReleasePorts(ctx, server.ID, models.PortCauseReallocate, reallocateReason); err != nil {
+			r.logger.Error("failed to release ports", zap.String("server_id", serverID), zap.Error(err))
+			return r.db.UpdateServerLastReconciled(ctx, serverID)
+		}
+		allocations = nil
+	}
+
 	if len(allocations) == 0 {
+		// Servers allocated before were released since, e.g. to move or to be requeued
+		cause := models.PortCauseReallocate
+		if reallocateReason == "" {
+			cause, err = r.placementCause(ctx, server.ID)
+			if err != nil {
+				r.logger.Error("failed to check port allocation history", zap.String("server_id", serverID), zap.Error(err))
+				return r.db.UpdateServerLastReconciled(ctx, serverID)
+			}
+		}
+
 		// Need to allocate ports - build requirements from game config
 		portReqs := make([]portalloc.PortRequirement, len(gameConfig.Ports))
 		for i, p := range gameConfig.Ports {
 			portReqs[i] = portalloc.PortRequirement{
 				Name:     p.Name,
 				Protocol: p.Protocol,
+				SamePort: p.SamePort,
 			}
 		}
 
-		// Build resource requirements from plan config + supervisor overhead
-		cpuMillicores := parseCPUToMillicores(planConfig.CPU) + supervisorCPU
-		memBytes := parseMemoryToBytes(planConfig.Memory) + supervisorMem
+		// The game port (the first) takes the server's preferred number if it's free
+		preferredPort, err := r.db.GetServerPreferredPort(ctx, serverID)
+		if err != nil {
+			r.logger.Error("failed to get preferred port", zap.String("server_id", serverID), zap.Error(err))
+			return r.db.UpdateServerLastReconciled(ctx, serverID)
+		}
+		if preferredPort != 0 && len(portReqs) > 0 {
+			portReqs[0].Preferred = preferredPort
+		}
 
 		resourceReq := &portalloc.ResourceRequirement{
 			CPUMillicores: cpuMillicores,
 			MemoryBytes:   memBytes,
+			GPUs:          planConfig.GPU,
+			Dedicated:     planConfig.Dedicated,
+			Spot:          planConfig.Spot,
+			OS:            gameConfig.NodeOS(),
+			Plan:          string(server.Plan),
+			MaxPerNode:    planConfig.MaxPerNode,
+			Namespace:     namespace,
 		}
 
-		allocations, err = r.portAllocService.AllocatePorts(ctx, server.ID, portReqs, resourceReq)
+		if cause == models.PortCauseCreate {
+			namespace, err = r.selectNamespace(ctx, server, resourceReq)
+			if err != nil {
+				r.logger.Error("failed to select namespace", zap.String("server_id", serverID), zap.Error(err))
+				return r.db.UpdateServerLastReconciled(ctx, serverID)
+			}
+			resourceReq.Namespace = namespace
+			server.Namespace = namespace
+		}
+
+		allocations, err = r.portAllocService.AllocatePorts(ctx, server.ID, portReqs, resourceReq, cause, reallocateReason)
 		if err != nil {
 			errMsg := fmt.Sprintf("no capacity available: %v", err)
 			r.logger.Warn("marking server as failed - no capacity", zap.String("server_id", serverID))
-			return r.db.MarkServerFailed(ctx, serverID, errMsg)
+			return r.db.MarkServerFailed(ctx, serverID, errMsg, models.StatusReasonNoCapacity)
 		}
 
 		r.logger.Info("allocated ports and resources for server",
@@ -286,23 +390,38 @@ func (r *ServerReconciler) reconcileServer(ctx context.Context, server *models.S
 			zap.String("node", allocations[0].NodeName),
 			zap.Int("port_count", len(allocations)),
 			zap.Int("cpu_millicores", cpuMillicores),
-			zap.Int64("memory_bytes", memBytes))
+			zap.Int64("memory_bytes", memBytes),
+			zap.Int("gpus", planConfig.GPU))
 	}
 
 	// STEP 2: Create PVC if it doesn't exist
 	pvcName := fmt.Sprintf("server-%s", serverID)
 	labels := map[string]string{
-		"server":           serverID,
-		"game":             string(server.Game),
-		"app":              "game-server",
+		"server": serverID,
+		"game":   string(server.Game),
+		"app":    "game-server",
+		"plan":   string(server.Plan),
 	}
 
-	err = r.k8sClient.CreatePVC(ctx, r.k8sNamespace, pvcName, planConfig.Storage, labels)
+	err = r.k8sClient.CreatePVC(ctx, namespace, pvcName, planConfig.Storage, labels)
 	if err != nil && !isAlreadyExistsError(err) {
 		r.logger.Error("failed to create PVC", zap.String("server_id", serverID), zap.Error(err))
 		return r.db.UpdateServerLastReconciled(ctx, serverID)
 	}
 
+	// Volume backups, restores and clone copies need the volume to themselves; the volume
+	// backup service restores a selected backup, and the clone service copies a clone's
+	// source, before the deployment is created
+	busy, err := r.db.ServerVolumeBusy(ctx, serverID)
+	if err != nil {
+		r.logger.Error("failed to check volume backups", zap.String("server_id", serverID), zap.Error(err))
+		return r.db.UpdateServerLastReconciled(ctx, serverID)
+	}
+	if busy {
+		r.logger.Debug("waiting for volume backup or restore", zap.String("server_id", serverID))
+		return r.db.UpdateServerLastReconciled(ctx, serverID)
+	}
+
 	// STEP 3: Generate auth token for supervisor
 	authToken, err := generateAuthToken()
 	if err != nil {
@@ -314,7 +433,7 @@ func (r *ServerReconciler) reconcileServer(ctx context.Context, server *models.S
 		return r.db.UpdateServerLastReconciled(ctx, serverID)
 	}
 
-	// STEP 4: Create Deployment with supervisor
+	// STEP 4: Create or update Deployment with supervisor
 	deployName := fmt.Sprintf("server-%s", serverID)
 	nodeName := allocations[0].NodeName
 
@@ -352,81 +471,67 @@ func (r *ServerReconciler) reconcileServer(ctx context.Context, server *models.S
 
 	// Add supervisor environment variables
 	effectiveEnv["GSHUB_SERVER_ID"] = serverID
+	// The API always runs in the control-plane namespace, even for servers in other namespaces
 	effectiveEnv["GSHUB_API_ENDPOINT"] = fmt.Sprintf("http://api.%s.svc:8081", r.k8sNamespace)
-	effectiveEnv["GSHUB_AUTH_TOKEN"] = authToken
-
-	// Add process configuration for supervisor
-	if gameConfig.Process != nil {
-		if len(gameConfig.Process.StartCommand) > 0 {
-			cmdJSON, _ := json.Marshal(gameConfig.Process.StartCommand)
-			effectiveEnv["GSHUB_START_COMMAND"] = string(cmdJSON)
-		}
-		if gameConfig.Process.WorkDir != "" {
-			effectiveEnv["GSHUB_WORK_DIR"] = gameConfig.Process.WorkDir
-		}
-		if gameConfig.Process.GracePeriod > 0 {
-			effectiveEnv["GSHUB_GRACE_PERIOD"] = fmt.Sprintf("%d", gameConfig.Process.GracePeriod)
-		}
-	}
-
-	// Add health check configuration for supervisor
-	if gameConfig.HealthCheck != nil {
-		effectiveEnv["GSHUB_HEALTH_TYPE"] = gameConfig.HealthCheck.Type
-		effectiveEnv["GSHUB_HEALTH_PORT"] = gameConfig.HealthCheck.Port
-		effectiveEnv["GSHUB_HEALTH_PROTOCOL"] = gameConfig.HealthCheck.Protocol
-		if gameConfig.HealthCheck.InitialDelay != "" {
-			effectiveEnv["GSHUB_HEALTH_INITIAL_DELAY"] = gameConfig.HealthCheck.InitialDelay
-		}
-		if gameConfig.HealthCheck.Timeout != "" {
-			effectiveEnv["GSHUB_HEALTH_TIMEOUT"] = gameConfig.HealthCheck.Timeout
-		}
-		if gameConfig.HealthCheck.Interval != "" {
-			effectiveEnv["GSHUB_HEALTH_INTERVAL"] = gameConfig.HealthCheck.Interval
-		}
-		if gameConfig.HealthCheck.Pattern != "" {
-			effectiveEnv["GSHUB_HEALTH_PATTERN"] = gameConfig.HealthCheck.Pattern
-		}
+	if r.supervisorGRPC {
+		effectiveEnv["GSHUB_API_GRPC_ENDPOINT"] = fmt.Sprintf("api.%s.svc:8082", r.k8sNamespace)
 	}
+	effectiveEnv["GSHUB_AUTH_TOKEN"] = authToken
 
-	// Determine image to use (prefer supervisorImage, fallback to legacy image)
-	image := gameConfig.SupervisorImage
-	if image == "" {
-		image = gameConfig.Image
+	// Add process and health check configuration for supervisor
+	for key, value := range gameConfig.SupervisorEnv() {
+		effectiveEnv[key] = value
 	}
 
-	// Calculate total resources (plan + supervisor overhead)
-	totalCPU := fmt.Sprintf("%dm", parseCPUToMillicores(planConfig.CPU)+supervisorCPU)
-	totalMemBytes := parseMemoryToBytes(planConfig.Memory) + supervisorMem
-	totalMem := fmt.Sprintf("%d", totalMemBytes)
-
 	// Get grace period
 	gracePeriod := int32(30)
 	if gameConfig.Process != nil && gameConfig.Process.GracePeriod > 0 {
 		gracePeriod = int32(gameConfig.Process.GracePeriod)
 	}
 
-	err = r.k8sClient.CreateGameDeployment(ctx, k8s.DeploymentParams{
-		Namespace:   r.k8sNamespace,
-		Name:        deployName,
-		Image:       image,
-		NodeName:    nodeName,
-		Ports:       staticPorts,
-		Volumes:     volumes,
-		Env:         effectiveEnv,
-		CPURequest:  totalCPU,
-		MemRequest:  totalMem,
-		PVCName:     pvcName,
-		Labels:      labels,
-		GracePeriod: gracePeriod,
-	})
-	if err != nil && !isAlreadyExistsError(err) {
-		r.logger.Error("failed to create Deployment", zap.String("server_id", serverID), zap.Error(err))
+	// Allocation enforces the co-tenancy cap; a cap of one is also enforced by the scheduler
+	var antiAffinityLabels map[string]string
+	if planConfig.MaxPerNode == 1 {
+		antiAffinityLabels = map[string]string{"app": "game-server", "plan": string(server.Plan)}
+	}
+
+	params := k8s.DeploymentParams{
+		Namespace:       namespace,
+		Name:            deployName,
+		Image:           gameConfig.ContainerImage(),
+		SupervisorImage: gameConfig.InjectedSupervisorImage(),
+		EgressBandwidth: planConfig.EgressBandwidth,
+		NodeName:        nodeName,
+		Ports:           staticPorts,
+		Volumes:         volumes,
+		Env:             effectiveEnv,
+		CPURequest:      fmt.Sprintf("%dm", cpuMillicores),
+		MemRequest:      fmt.Sprintf("%d", memBytes),
+		PVCName:         pvcName,
+		Labels:          labels,
+		GracePeriod:     gracePeriod,
+		Dedicated:       planConfig.Dedicated,
+		Spot:            planConfig.Spot,
+		OS:              gameConfig.NodeOS(),
+		GPUs:            planConfig.GPU,
+		Guaranteed:      planConfig.Performance,
+		PinCPUs:         planConfig.Performance && planConfig.PinCPUs,
+
+		AntiAffinityLabels: antiAffinityLabels,
+		LivenessProbe:      gameConfig.LivenessProbe(),
+		ReadinessProbe:     gameConfig.ReadinessProbe(),
+	}
+	if err := r.applyDeployment(ctx, params); err != nil {
+		r.logger.Error("failed to apply Deployment", zap.String("server_id", serverID), zap.Error(err))
 		return r.db.UpdateServerLastReconciled(ctx, serverID)
 	}
 
 	// STEP 5: Transition to "starting" - supervisor will report status via internal API
-	transitioned, err := r.db.TransitionServerStatus(ctx, serverID,
-		models.ServerStatusPending, models.ServerStatusStarting, "Creating game server...")
+	transitioned, err := r.machine.Transition(ctx, server, serverstate.Request{
+		From:    []models.ServerStatus{models.ServerStatusPending},
+		To:      models.ServerStatusStarting,
+		Message: "Creating game server...",
+	})
 	if err != nil {
 		r.logger.Error("failed to transition to starting", zap.String("server_id", serverID), zap.Error(err))
 		return err
@@ -455,19 +560,128 @@ func generateAuthToken() (string, error) {
 }
 
 // isAlreadyExistsError checks if an error is due to a resource already existing
-func isAlreadyExistsError(err error) bool {
-	return errors.IsAlreadyExists(err)
+// applyDeployment creates the server's Deployment, or updates the one a restart kept so the
+// Deployment controller replaces its pod. Deployments whose labels changed are recreated.
+func (r *ServerReconciler) applyDeployment(ctx context.Context, params k8s.DeploymentParams) error {
+	err := r.k8sClient.CreateGameDeployment(ctx, params)
+	if !isAlreadyExistsError(err) {
+		return err
+	}
+
+	changed, err := r.k8sClient.UpdateGameDeployment(ctx, params)
+	if err == nil {
+		r.logger.Info("updated existing Deployment",
+			zap.String("deployment", params.Name),
+			zap.Bool("template_changed", changed))
+		return nil
+	}
+	if !stderrors.Is(err, k8s.ErrDeploymentSelectorChanged) {
+		return err
+	}
+	if err := r.k8sClient.DeleteGameDeployment(ctx, params.Namespace, params.Name); err != nil {
+		return err
+	}
+	return r.k8sClient.CreateGameDeployment(ctx, params)
 }
 
-// parseCPUToMillicores converts a CPU string (e.g., "1", "500m", "2") to millicores
-func parseCPUToMillicores(cpu string) int {
-	q := resource.MustParse(cpu)
-	return int(q.MilliValue())
+// allocationsMatchPorts reports whether a server's allocated ports are exactly the game's
+// ports, with paired ones sharing a number
+func allocationsMatchPorts(allocations []portalloc.AllocatedPort, ports []k8s.GamePort) bool {
+	if len(allocations) != len(ports) {
+		return false
+	}
+	for _, port := range ports {
+		alloc := findAllocation(allocations, port.Name, port.Protocol)
+		if alloc == nil {
+			return false
+		}
+		// Paired ports must share their number, which they didn't have to before pairing
+		if port.SamePort != "" {
+			other := findAllocation(allocations, port.SamePort, "")
+			if other == nil || other.Port != alloc.Port {
+				return false
+			}
+		}
+	}
+	return true
 }
 
-// parseMemoryToBytes converts a memory string (e.g., "2Gi", "512Mi") to bytes
-func parseMemoryToBytes(memory string) int64 {
-	q := resource.MustParse(memory)
-	return q.Value()
+// findAllocation returns the allocation of the named port with protocol ("" = any), or nil
+func findAllocation(allocations []portalloc.AllocatedPort, name, protocol string) *portalloc.AllocatedPort {
+	for i := range allocations {
+		if allocations[i].PortName == name && (protocol == "" || allocations[i].Protocol == protocol) {
+			return &allocations[i]
+		}
+	}
+	return nil
 }
 
+// nodeActive reports whether a node can take servers. Lookup failures count as active, so
+// they don't move servers.
+func (r *ServerReconciler) nodeActive(ctx context.Context, nodeName string) bool {
+	node, err := r.db.GetNodeByName(ctx, nodeName)
+	if err != nil {
+		r.logger.Warn("failed to get node", zap.String("node", nodeName), zap.Error(err))
+		return true
+	}
+	return node.IsActive
+}
+
+// portsBlocked reports whether the server holds ports an admin blocked since. Errors count
+// as not blocked, so the server keeps its ports.
+func (r *ServerReconciler) portsBlocked(ctx context.Context, serverID string) bool {
+	blocked, err := r.db.HasBlockedPorts(ctx, serverID)
+	if err != nil {
+		r.logger.Warn("failed to check blocked ports", zap.String("server_id", serverID), zap.Error(err))
+		return false
+	}
+	return blocked
+}
+
+// placementCause tells a new server's first placement from a server being placed again
+// after its ports were released
+func (r *ServerReconciler) placementCause(ctx context.Context, serverID uuid.UUID) (models.PortAllocationCause, error) {
+	placed, err := r.db.HasPortAllocationHistory(ctx, serverID)
+	if err != nil {
+		return "", err
+	}
+	if placed {
+		return models.PortCauseRestart, nil
+	}
+	return models.PortCauseCreate, nil
+}
+
+// selectNamespace returns the namespace a new server is first placed in: the first of its
+// plan's namespaces with quota left, starting from the one it was created in. Servers whose
+// volume a clone or restore waits on stay where they are.
+func (r *ServerReconciler) selectNamespace(ctx context.Context, server *models.Server, resourceReq *portalloc.ResourceRequirement) (string, error) {
+	serverID := server.ID.String()
+	candidates := r.serverNamespaces(string(server.Plan))
+	i := slices.Index(candidates, resourceReq.Namespace)
+	if i < 0 || i == len(candidates)-1 {
+		return resourceReq.Namespace, nil
+	}
+	busy, err := r.db.ServerVolumeBusy(ctx, serverID)
+	if err != nil || busy {
+		return resourceReq.Namespace, err
+	}
+
+	req := *resourceReq
+	req.FallbackNamespaces = candidates[i+1:]
+	namespace, err := r.portAllocService.SelectNamespace(ctx, &req)
+	if err != nil || namespace == resourceReq.Namespace {
+		return resourceReq.Namespace, err
+	}
+	if err := r.db.SetServerNamespace(ctx, serverID, namespace); err != nil {
+		return "", err
+	}
+	r.logger.Info("placing server in another namespace, the quota of its own is used up",
+		zap.String("server_id", serverID),
+		zap.String("from", resourceReq.Namespace),
+		zap.String("to", namespace))
+	return namespace, nil
+}
+
+func isAlreadyExistsError(err error) bool {
+	return errors.IsAlreadyExists(err)
+}
@@ -0,0 +1,40 @@
+package reminder
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// CancelLinkLifetime is how long the one-click cancel link in a reminder email works
+const CancelLinkLifetime = 14 * 24 * time.Hour
+
+// CancelURL returns a signed link that cancels a server's subscription without a login,
+// valid until expires
+func CancelURL(frontendURL, secret, serverID string, expires time.Time) string {
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires.Unix(), 10)},
+		"signature": {signCancel(secret, serverID, expires.Unix())},
+	}
+	return fmt.Sprintf("%s/servers/%s/reminder-action?%s", frontendURL, serverID, query.Encode())
+}
+
+// VerifyCancel reports whether a cancel link's signature is valid for the server and
+// the link hasn't expired
+func VerifyCancel(secret, serverID string, expires int64, signature string, now time.Time) bool {
+	if now.Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(signCancel(secret, serverID, expires)))
+}
+
+// signCancel signs a cancel link for a server, valid until expires
+func signCancel(secret, serverID string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "reminder-cancel:%s:%d", serverID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,63 @@
+package reminder
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+func TestCancelURL(t *testing.T) {
+	expires := time.Unix(1700000000, 0)
+	raw := CancelURL("https://gshub.pro", testSecret, "srv-1", expires)
+
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "gshub.pro", u.Host)
+	assert.Equal(t, "/servers/srv-1/reminder-action", u.Path)
+	assert.Equal(t, "1700000000", u.Query().Get("expires"))
+	assert.Equal(t, signCancel(testSecret, "srv-1", expires.Unix()), u.Query().Get("signature"))
+}
+
+func TestVerifyCancel(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	expires := now.Add(time.Hour).Unix()
+	signature := signCancel(testSecret, "srv-1", expires)
+
+	tests := []struct {
+		name      string
+		secret    string
+		serverID  string
+		expires   int64
+		signature string
+		want      bool
+	}{
+		{"valid", testSecret, "srv-1", expires, signature, true},
+		{"expired", testSecret, "srv-1", now.Add(-time.Second).Unix(), signCancel(testSecret, "srv-1", now.Add(-time.Second).Unix()), false},
+		{"other server", testSecret, "srv-2", expires, signature, false},
+		{"extended expiry", testSecret, "srv-1", expires + 3600, signature, false},
+		{"other secret", "other-secret", "srv-1", expires, signature, false},
+		{"empty signature", testSecret, "srv-1", expires, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, VerifyCancel(tt.secret, tt.serverID, tt.expires, tt.signature, now))
+		})
+	}
+}
+
+func TestCancelURL_Verifies(t *testing.T) {
+	now := time.Now()
+	u, err := url.Parse(CancelURL("https://gshub.pro", testSecret, "srv-1", now.Add(CancelLinkLifetime)))
+	require.NoError(t, err)
+
+	expires, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, VerifyCancel(testSecret, "srv-1", expires, u.Query().Get("signature"), now))
+}
@@ -0,0 +1,157 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/services/email"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	stripeservice "github.com/mooncorn/gshub/api/internal/services/stripe"
+	"github.com/stripe/stripe-go/v84"
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for the stopped-server reminder service
+type Config struct {
+	// Interval is how often to look for long-stopped servers (default: 1 hour)
+	Interval time.Duration
+	// StoppedFor is how long a server must be stopped before its owner is reminded (default: 7 days)
+	StoppedFor time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:   1 * time.Hour,
+		StoppedFor: 7 * 24 * time.Hour,
+	}
+}
+
+// store is the subset of the database used by Service
+type store interface {
+	GetServersDueStoppedReminder(ctx context.Context, stoppedFor time.Duration) ([]database.StoppedServerReminder, error)
+	MarkStoppedReminderSent(ctx context.Context, serverID string) error
+}
+
+// billing is the subset of the Stripe service used by Service
+type billing interface {
+	GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+	CancelSubscriptionAtPeriodEnd(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+	GetPrice(ctx context.Context, priceID string) (*stripe.Price, error)
+}
+
+// mailer is the subset of the email service used by Service
+type mailer interface {
+	SendStoppedServerReminderEmail(to, serverName, serverID, cancelURL string, stoppedFor time.Duration, monthlyCost string) error
+	SendStoppedServerCancelledEmail(to, serverName string, stoppedFor time.Duration) error
+}
+
+// Service reminds owners of servers that have been stopped for a long time that they're
+// still paying for them, or cancels the subscription if the owner opted in to auto-cancel.
+// Each stop is reminded at most once.
+type Service struct {
+	db            store
+	appConfig     *config.Config
+	stripeService billing
+	email         mailer
+	config        Config
+	logger        *zap.Logger
+	runner        *periodic.Runner
+}
+
+// NewService creates a new reminder service
+func NewService(db *database.DB, appConfig *config.Config, stripeService *stripeservice.Service, emailService *email.Service, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:            db,
+		appConfig:     appConfig,
+		stripeService: stripeService,
+		email:         emailService,
+		config:        config,
+		logger:        logger,
+	}
+	s.runner = periodic.New("reminder", config.Interval, s.runReminders, logger).RunFirst()
+	return s
+}
+
+// Start begins the reminder service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx, zap.Duration("stopped_for", s.config.StoppedFor))
+}
+
+// Stop stops the reminder service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// runReminders handles every server that has been stopped long enough and hasn't been
+// reminded since it was stopped
+func (s *Service) runReminders(ctx context.Context) {
+	servers, err := s.db.GetServersDueStoppedReminder(ctx, s.config.StoppedFor)
+	if err != nil {
+		s.logger.Error("failed to get servers due stopped reminder", zap.Error(err))
+		return
+	}
+
+	for _, server := range servers {
+		serverID := server.ServerID.String()
+		if err := s.remind(ctx, server); err != nil {
+			// Not marked as sent, so it's retried on the next run
+			s.logger.Error("failed to handle stopped server",
+				zap.String("server_id", serverID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if err := s.db.MarkStoppedReminderSent(ctx, serverID); err != nil {
+			s.logger.Error("failed to mark stopped reminder sent",
+				zap.String("server_id", serverID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// remind cancels the server's subscription if auto-cancel is enabled, otherwise emails
+// the owner its monthly cost. Subscriptions already set to cancel are left alone.
+func (s *Service) remind(ctx context.Context, server database.StoppedServerReminder) error {
+	sub, err := s.stripeService.GetSubscription(ctx, server.StripeSubscriptionID)
+	if err != nil {
+		return err
+	}
+	if sub.CancelAtPeriodEnd {
+		return nil
+	}
+
+	stoppedFor := time.Since(server.StoppedAt)
+
+	if server.AutoCancel {
+		if _, err := s.stripeService.CancelSubscriptionAtPeriodEnd(ctx, server.StripeSubscriptionID); err != nil {
+			return err
+		}
+		s.logger.Info("auto-cancelled subscription of stopped server",
+			zap.String("server_id", server.ServerID.String()),
+			zap.Duration("stopped_for", stoppedFor),
+		)
+		return s.email.SendStoppedServerCancelledEmail(server.Email, server.DisplayName, stoppedFor)
+	}
+
+	priceID, err := s.appConfig.GetPriceID(string(server.Game), string(server.Plan))
+	if err != nil {
+		return err
+	}
+	price, err := s.stripeService.GetPrice(ctx, priceID)
+	if err != nil {
+		return err
+	}
+	monthlyCost := fmt.Sprintf("%.2f %s", float64(price.UnitAmount)/100, strings.ToUpper(string(price.Currency)))
+
+	serverID := server.ServerID.String()
+	cancelURL := CancelURL(s.appConfig.FrontendURL, s.appConfig.JWTSecret, serverID, time.Now().Add(CancelLinkLifetime))
+
+	return s.email.SendStoppedServerReminderEmail(server.Email, server.DisplayName, serverID, cancelURL, stoppedFor, monthlyCost)
+}
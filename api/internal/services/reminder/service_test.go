@@ -0,0 +1,186 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v84"
+	"go.uber.org/zap"
+)
+
+type fakeStore struct {
+	due        []database.StoppedServerReminder
+	dueErr     error
+	stoppedFor time.Duration
+	marked     []string
+}
+
+func (f *fakeStore) GetServersDueStoppedReminder(ctx context.Context, stoppedFor time.Duration) ([]database.StoppedServerReminder, error) {
+	f.stoppedFor = stoppedFor
+	return f.due, f.dueErr
+}
+
+func (f *fakeStore) MarkStoppedReminderSent(ctx context.Context, serverID string) error {
+	f.marked = append(f.marked, serverID)
+	return nil
+}
+
+type fakeBilling struct {
+	subscriptions map[string]*stripe.Subscription
+	cancelled     []string
+}
+
+func (f *fakeBilling) GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	sub, ok := f.subscriptions[subscriptionID]
+	if !ok {
+		return nil, errors.New("no such subscription")
+	}
+	return sub, nil
+}
+
+func (f *fakeBilling) CancelSubscriptionAtPeriodEnd(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	f.cancelled = append(f.cancelled, subscriptionID)
+	return &stripe.Subscription{ID: subscriptionID, CancelAtPeriodEnd: true}, nil
+}
+
+func (f *fakeBilling) GetPrice(ctx context.Context, priceID string) (*stripe.Price, error) {
+	return &stripe.Price{ID: priceID, UnitAmount: 599, Currency: stripe.CurrencyUSD}, nil
+}
+
+type sentEmail struct {
+	kind        string
+	to          string
+	serverName  string
+	cancelURL   string
+	monthlyCost string
+}
+
+type fakeMailer struct {
+	sent []sentEmail
+}
+
+func (f *fakeMailer) SendStoppedServerReminderEmail(to, serverName, serverID, cancelURL string, stoppedFor time.Duration, monthlyCost string) error {
+	f.sent = append(f.sent, sentEmail{kind: "reminder", to: to, serverName: serverName, cancelURL: cancelURL, monthlyCost: monthlyCost})
+	return nil
+}
+
+func (f *fakeMailer) SendStoppedServerCancelledEmail(to, serverName string, stoppedFor time.Duration) error {
+	f.sent = append(f.sent, sentEmail{kind: "cancelled", to: to, serverName: serverName})
+	return nil
+}
+
+func newTestService(db *fakeStore, billing *fakeBilling, mail *fakeMailer) *Service {
+	return &Service{
+		db: db,
+		appConfig: &config.Config{
+			FrontendURL:  "https://gshub.pro",
+			JWTSecret:    testSecret,
+			StripePrices: map[string]map[string]string{"minecraft": {"small": "price_small"}},
+		},
+		stripeService: billing,
+		email:         mail,
+		config:        DefaultConfig(),
+		logger:        zap.NewNop(),
+	}
+}
+
+func stoppedServer(subscriptionID string, autoCancel bool) database.StoppedServerReminder {
+	return database.StoppedServerReminder{
+		ServerID:             uuid.New(),
+		DisplayName:          "Survival",
+		Game:                 models.GameMinecraft,
+		Plan:                 models.PlanSmall,
+		StripeSubscriptionID: subscriptionID,
+		Email:                "owner@example.com",
+		StoppedAt:            time.Now().Add(-8 * 24 * time.Hour),
+		AutoCancel:           autoCancel,
+	}
+}
+
+func TestRunReminders_SendsReminder(t *testing.T) {
+	server := stoppedServer("sub_1", false)
+	db := &fakeStore{due: []database.StoppedServerReminder{server}}
+	billing := &fakeBilling{subscriptions: map[string]*stripe.Subscription{"sub_1": {ID: "sub_1"}}}
+	mail := &fakeMailer{}
+
+	newTestService(db, billing, mail).runReminders(context.Background())
+
+	assert.Equal(t, 7*24*time.Hour, db.stoppedFor)
+	assert.Empty(t, billing.cancelled)
+	require.Len(t, mail.sent, 1)
+	assert.Equal(t, "reminder", mail.sent[0].kind)
+	assert.Equal(t, "owner@example.com", mail.sent[0].to)
+	assert.Equal(t, "5.99 USD", mail.sent[0].monthlyCost)
+	assert.Equal(t, []string{server.ServerID.String()}, db.marked)
+
+	// The email's cancel link works for this server only
+	u, err := url.Parse(mail.sent[0].cancelURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/servers/"+server.ServerID.String()+"/reminder-action", u.Path)
+	expires, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, VerifyCancel(testSecret, server.ServerID.String(), expires, u.Query().Get("signature"), time.Now()))
+	assert.False(t, VerifyCancel(testSecret, uuid.NewString(), expires, u.Query().Get("signature"), time.Now()))
+}
+
+func TestRunReminders_AutoCancel(t *testing.T) {
+	server := stoppedServer("sub_1", true)
+	db := &fakeStore{due: []database.StoppedServerReminder{server}}
+	billing := &fakeBilling{subscriptions: map[string]*stripe.Subscription{"sub_1": {ID: "sub_1"}}}
+	mail := &fakeMailer{}
+
+	newTestService(db, billing, mail).runReminders(context.Background())
+
+	assert.Equal(t, []string{"sub_1"}, billing.cancelled)
+	require.Len(t, mail.sent, 1)
+	assert.Equal(t, "cancelled", mail.sent[0].kind)
+	assert.Equal(t, []string{server.ServerID.String()}, db.marked)
+}
+
+func TestRunReminders_AlreadyCancelling(t *testing.T) {
+	server := stoppedServer("sub_1", true)
+	db := &fakeStore{due: []database.StoppedServerReminder{server}}
+	billing := &fakeBilling{subscriptions: map[string]*stripe.Subscription{
+		"sub_1": {ID: "sub_1", CancelAtPeriodEnd: true},
+	}}
+	mail := &fakeMailer{}
+
+	newTestService(db, billing, mail).runReminders(context.Background())
+
+	assert.Empty(t, billing.cancelled)
+	assert.Empty(t, mail.sent)
+	assert.Equal(t, []string{server.ServerID.String()}, db.marked, "marked so it isn't checked again")
+}
+
+func TestRunReminders_FailureIsRetried(t *testing.T) {
+	failing := stoppedServer("sub_missing", false)
+	ok := stoppedServer("sub_1", false)
+	db := &fakeStore{due: []database.StoppedServerReminder{failing, ok}}
+	billing := &fakeBilling{subscriptions: map[string]*stripe.Subscription{"sub_1": {ID: "sub_1"}}}
+	mail := &fakeMailer{}
+
+	newTestService(db, billing, mail).runReminders(context.Background())
+
+	require.Len(t, mail.sent, 1)
+	assert.Equal(t, []string{ok.ServerID.String()}, db.marked, "the failed server is left for the next run")
+}
+
+func TestRunReminders_QueryError(t *testing.T) {
+	db := &fakeStore{dueErr: errors.New("connection refused")}
+	mail := &fakeMailer{}
+
+	newTestService(db, &fakeBilling{}, mail).runReminders(context.Background())
+
+	assert.Empty(t, mail.sent)
+	assert.Empty(t, db.marked)
+}
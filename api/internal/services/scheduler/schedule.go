@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata" // The api image ships without a zone database
+)
+
+// MinInterval is the shortest time allowed between two runs of a scheduled task
+const MinInterval = 15 * time.Minute
+
+// intervalChecks is how many upcoming runs are checked against MinInterval
+const intervalChecks = 10
+
+// Plan parses a task's cron expression in its time zone ("" means UTC) and returns its
+// first run after t. Expressions that run more often than MinInterval, or never, are
+// rejected.
+func Plan(spec, timezone string, t time.Time) (time.Time, error) {
+	cron, loc, err := parse(spec, timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	first := cron.Next(t.In(loc))
+	if first.IsZero() {
+		return time.Time{}, errors.New("cron expression never runs")
+	}
+	prev := first
+	for i := 0; i < intervalChecks; i++ {
+		next := cron.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if next.Sub(prev) < MinInterval {
+			return time.Time{}, fmt.Errorf("tasks can run at most every %d minutes", int(MinInterval.Minutes()))
+		}
+		prev = next
+	}
+	return first, nil
+}
+
+// nextRun returns a valid task's first run after t, or the zero time if it can't be
+// planned anymore
+func nextRun(spec, timezone string, t time.Time) time.Time {
+	cron, loc, err := parse(spec, timezone)
+	if err != nil {
+		return time.Time{}
+	}
+	return cron.Next(t.In(loc))
+}
+
+func parse(spec, timezone string) (*Cron, *time.Location, error) {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unknown time zone %q", timezone)
+	}
+	return cron, loc, nil
+}
@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for the scheduler service
+type Config struct {
+	// Interval is how often due tasks are run (default: 30 seconds)
+	Interval time.Duration
+	// MaxDelay is how late a task may still run, e.g. after the API was down; later runs
+	// are skipped (default: 10 minutes)
+	MaxDelay time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval: 30 * time.Second,
+		MaxDelay: 10 * time.Minute,
+	}
+}
+
+// BackupPayloadFunc returns the payload of a backup command for a server, which carries
+// the retention of its backup policy
+type BackupPayloadFunc func(ctx context.Context, server *models.Server) (string, error)
+
+// Service runs the tasks users schedule on their servers. Tasks only act on running
+// servers, and restarts go through the state machine like user restarts, so the
+// reconciler rolls the pod as usual. Runs that find the server in any other state are
+// skipped and recorded as such.
+type Service struct {
+	db            *database.DB
+	machine       *serverstate.Machine
+	backupPayload BackupPayloadFunc
+	config        Config
+	logger        *zap.Logger
+	runner        *periodic.Runner
+}
+
+// NewService creates a new scheduler service
+func NewService(db *database.DB, machine *serverstate.Machine, backupPayload BackupPayloadFunc, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:            db,
+		machine:       machine,
+		backupPayload: backupPayload,
+		config:        config,
+		logger:        logger,
+	}
+	s.runner = periodic.New("scheduler", config.Interval, s.runDue, logger)
+	return s
+}
+
+// Start begins the scheduler service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx, zap.Duration("max_delay", s.config.MaxDelay))
+}
+
+// Stop stops the scheduler service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// runDue runs every task whose time has come and plans its next run. Each run is claimed
+// by moving the task's next run first, so several API replicas run it once.
+func (s *Service) runDue(ctx context.Context) {
+	now := time.Now()
+	schedules, err := s.db.ListDueSchedules(ctx, now)
+	if err != nil {
+		s.logger.Error("failed to list due schedules", zap.Error(err))
+		return
+	}
+
+	for _, schedule := range schedules {
+		next := nextRun(schedule.Cron, schedule.Timezone, now)
+		if next.IsZero() {
+			if err := s.db.DisableSchedule(ctx, schedule.ID, "disabled: the cron expression doesn't run anymore"); err != nil {
+				s.logger.Warn("failed to disable schedule", zap.String("schedule_id", schedule.ID.String()), zap.Error(err))
+			}
+			continue
+		}
+
+		claimed, err := s.db.ClaimScheduleRun(ctx, schedule.ID, schedule.NextRunAt, next)
+		if err != nil {
+			s.logger.Warn("failed to claim schedule run", zap.String("schedule_id", schedule.ID.String()), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		var result string
+		if late := now.Sub(schedule.NextRunAt); late > s.config.MaxDelay {
+			result = fmt.Sprintf("skipped: missed by %s", late.Round(time.Minute))
+		} else {
+			result = s.run(ctx, schedule)
+		}
+
+		s.logger.Info("ran scheduled task",
+			zap.String("server_id", schedule.ServerID.String()),
+			zap.String("schedule_id", schedule.ID.String()),
+			zap.String("action", string(schedule.Action)),
+			zap.String("result", result),
+		)
+		if err := s.db.RecordScheduleRun(ctx, schedule.ID, result); err != nil {
+			s.logger.Warn("failed to record schedule run", zap.String("schedule_id", schedule.ID.String()), zap.Error(err))
+		}
+	}
+}
+
+// run performs a task on its server and returns what it did, which is shown to the user
+func (s *Service) run(ctx context.Context, schedule models.ServerSchedule) string {
+	serverID := schedule.ServerID.String()
+	server, err := s.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		s.logger.Warn("failed to get scheduled server", zap.String("server_id", serverID), zap.Error(err))
+		return "failed: server not found"
+	}
+	if server.Status != models.ServerStatusRunning {
+		return fmt.Sprintf("skipped: server is %s", server.Status)
+	}
+
+	switch schedule.Action {
+	case models.ScheduleRestart:
+		return s.restart(ctx, serverID)
+	case models.ScheduleCommand:
+		if _, err := s.db.CreateServerCommand(ctx, serverID, models.CommandExec, schedule.Payload); err != nil {
+			s.logger.Warn("failed to queue scheduled command", zap.String("server_id", serverID), zap.Error(err))
+			return "failed: couldn't queue the command"
+		}
+		return "command sent"
+	case models.ScheduleBackup:
+		payload, err := s.backupPayload(ctx, server)
+		if err == nil {
+			_, err = s.db.CreateServerCommand(ctx, serverID, models.CommandBackup, &payload)
+		}
+		if err != nil {
+			s.logger.Warn("failed to queue scheduled backup", zap.String("server_id", serverID), zap.Error(err))
+			return "failed: couldn't queue the backup"
+		}
+		return "backup started"
+	default:
+		return fmt.Sprintf("failed: unknown action %q", schedule.Action)
+	}
+}
+
+// restart restarts a running server, recording the operation like a user restart
+func (s *Service) restart(ctx context.Context, serverID string) string {
+	op, err := s.db.CreateOperation(ctx, serverID, models.OperationRestart)
+	if err != nil {
+		s.logger.Warn("failed to record scheduled restart", zap.String("server_id", serverID), zap.Error(err))
+	}
+
+	// Hold the server lock, so a concurrent stop or restart can't interleave with the transition
+	var status models.ServerStatus
+	var transitioned bool
+	err = s.db.WithServerLock(ctx, serverID, func() error {
+		current, err := s.db.GetServerByID(ctx, serverID)
+		if err != nil {
+			return err
+		}
+		status = current.Status
+		transitioned, err = s.machine.Transition(ctx, current, serverstate.Request{
+			From:    []models.ServerStatus{models.ServerStatusRunning},
+			To:      models.ServerStatusPending,
+			Message: "Restarting server on schedule...",
+		})
+		return err
+	})
+
+	state, message, result := models.OperationStateSucceeded, "", "restarted"
+	switch {
+	case err != nil:
+		s.logger.Warn("failed to restart server on schedule", zap.String("server_id", serverID), zap.Error(err))
+		state, message, result = models.OperationStateFailed, "failed to update server status", "failed: couldn't restart the server"
+	case !transitioned:
+		state, result = models.OperationStateSuperseded, fmt.Sprintf("skipped: server is %s", status)
+	}
+	if op != nil {
+		if err := s.db.CompleteOperation(ctx, op.ID, state, message); err != nil {
+			s.logger.Warn("failed to complete operation", zap.String("operation_id", op.ID.String()), zap.Error(err))
+		}
+	}
+	return result
+}
@@ -0,0 +1,198 @@
+// Package serverclone fills the data volumes of cloned servers with their source's data
+package serverclone
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Config holds configuration for the clone service
+type Config struct {
+	// Interval is how often copies are started and checked (default: 15 seconds)
+	Interval time.Duration
+	// JobTimeout bounds one copy pod (default: 2 hours)
+	JobTimeout time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:   15 * time.Second,
+		JobTimeout: 2 * time.Hour,
+	}
+}
+
+// Service copies a clone's source volume into the clone's before its first start. The copy
+// runs in a pod of its own (the supervisor image in volume job mode) mounting both volumes,
+// once the source is stopped; the reconciler creates the clone's deployment after that.
+type Service struct {
+	db        *database.DB
+	k8sClient *k8s.Client
+	machine   *serverstate.Machine
+	cfg       *config.Config
+	config    Config
+	logger    *zap.Logger
+	runner    *periodic.Runner
+}
+
+// NewService creates a new clone service
+func NewService(db *database.DB, k8sClient *k8s.Client, machine *serverstate.Machine, cfg *config.Config, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:        db,
+		k8sClient: k8sClient,
+		machine:   machine,
+		cfg:       cfg,
+		config:    config,
+		logger:    logger,
+	}
+	s.runner = periodic.New("clone", config.Interval, s.runCopies, logger)
+	return s
+}
+
+// Start begins the clone service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx)
+}
+
+// Stop stops the clone service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// runCopies advances every clone waiting for or copying its data
+func (s *Service) runCopies(ctx context.Context) {
+	clones, err := s.db.ListActiveServerClones(ctx)
+	if err != nil {
+		s.logger.Error("failed to list server clones", zap.Error(err))
+		return
+	}
+
+	for _, clone := range clones {
+		if err := s.copy(ctx, clone); err != nil {
+			s.logger.Warn("failed to handle server clone",
+				zap.String("server_id", clone.ServerID.String()),
+				zap.String("state", string(clone.State)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// copy starts a clone's copy pod once the source is stopped and its game pods are gone,
+// and records the result once it exits. A failed copy fails the clone's first start.
+func (s *Service) copy(ctx context.Context, clone models.ServerClone) error {
+	serverID := clone.ServerID.String()
+	server, err := s.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	namespace := server.K8sNamespace(s.cfg.K8sNamespace)
+	podName := "volume-copy-" + serverID
+
+	pod, err := s.k8sClient.GetPod(ctx, namespace, podName)
+	if err != nil {
+		return err
+	}
+
+	if pod == nil {
+		if clone.State == models.ServerCloneCopying {
+			return s.fail(ctx, server, "copy pod disappeared")
+		}
+		if server.Status != models.ServerStatusPending {
+			return s.db.FailServerClone(ctx, clone.ServerID, fmt.Sprintf("server is %s", server.Status))
+		}
+		if clone.SourceServerID == nil {
+			return s.fail(ctx, server, "the cloned server was deleted")
+		}
+
+		source, err := s.db.GetServerByID(ctx, clone.SourceServerID.String())
+		if err != nil {
+			return fmt.Errorf("failed to get source server: %w", err)
+		}
+		switch source.Status {
+		case models.ServerStatusStopped, models.ServerStatusExpired:
+		case models.ServerStatusDeleted:
+			return s.fail(ctx, server, "the cloned server was deleted")
+		default:
+			return nil // Copied once the source is stopped
+		}
+		// Both volumes are mounted by one pod
+		sourceNamespace := source.K8sNamespace(s.cfg.K8sNamespace)
+		if sourceNamespace != namespace {
+			return s.fail(ctx, server, "the cloned server's data is in another namespace")
+		}
+		if running, err := s.gameRunning(ctx, source); err != nil || running {
+			return err // The source's pod is still shutting down
+		}
+
+		_, err = s.k8sClient.CreateVolumeJobPod(ctx, k8s.VolumeJobParams{
+			Namespace: namespace,
+			Name:      podName,
+			ServerID:  serverID,
+			Image:     s.cfg.FileAccessImage,
+			PVCName:   "server-" + serverID,
+			Job:       k8s.VolumeJobCopy,
+			SourcePVC: "server-" + source.ID.String(),
+			Timeout:   s.config.JobTimeout,
+		})
+		if err != nil {
+			return err
+		}
+		s.logger.Info("copying volume", zap.String("server_id", serverID), zap.String("source_id", source.ID.String()))
+		return s.db.MarkServerCloneCopying(ctx, clone.ServerID)
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		if err := s.db.CompleteServerClone(ctx, clone.ServerID); err != nil {
+			return err
+		}
+		s.logger.Info("copied volume", zap.String("server_id", serverID))
+		return s.k8sClient.DeletePod(ctx, namespace, podName)
+	case corev1.PodFailed:
+		if err := s.fail(ctx, server, k8s.VolumeJobError(pod)); err != nil {
+			return err
+		}
+		return s.k8sClient.DeletePod(ctx, namespace, podName)
+	default:
+		return nil
+	}
+}
+
+// fail records that the clone's data couldn't be copied and fails its first start
+func (s *Service) fail(ctx context.Context, server *models.Server, message string) error {
+	if err := s.db.FailServerClone(ctx, server.ID, message); err != nil {
+		return err
+	}
+	s.logger.Warn("failed to copy volume",
+		zap.String("server_id", server.ID.String()),
+		zap.String("error", message),
+	)
+	_, err := s.machine.Transition(ctx, server, serverstate.Request{
+		From:    []models.ServerStatus{models.ServerStatusPending},
+		To:      models.ServerStatusFailed,
+		Message: "Copying the cloned server's data failed: " + message,
+		Reason:  models.StatusReasonCloneFailed,
+	})
+	return err
+}
+
+// gameRunning reports whether any of the server's game pods still exist, e.g. while one
+// is terminating after a stop
+func (s *Service) gameRunning(ctx context.Context, server *models.Server) (bool, error) {
+	pods, err := s.k8sClient.ListPodsByLabel(ctx, server.K8sNamespace(s.cfg.K8sNamespace), "server="+server.ID.String())
+	if err != nil {
+		return false, err
+	}
+	return len(pods) > 0, nil
+}
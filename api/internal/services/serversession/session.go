@@ -0,0 +1,36 @@
+// Package serversession records the periods servers' games are online, from status
+// changes and heartbeats
+package serversession
+
+import (
+	"context"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"go.uber.org/zap"
+)
+
+// Gap is how long a running server may go without a heartbeat before its session ended at
+// the last one, matching the reconciler's heartbeat timeout
+const Gap = 2 * time.Minute
+
+// Hook opens a session when a server starts running and ends it when the server leaves
+// running
+func Hook(db *database.DB, logger *zap.Logger) serverstate.Hook {
+	return func(ctx context.Context, change serverstate.Change) {
+		var err error
+		switch {
+		case change.To == models.ServerStatusRunning:
+			err = db.StartServerSession(ctx, change.Server.ID)
+		case change.From == models.ServerStatusRunning:
+			err = db.EndServerSession(ctx, change.Server.ID, change.To, Gap)
+		default:
+			return
+		}
+		if err != nil {
+			logger.Error("failed to record server session", zap.String("server_id", change.Server.ID.String()), zap.Error(err))
+		}
+	}
+}
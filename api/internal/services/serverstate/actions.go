@@ -0,0 +1,27 @@
+package serverstate
+
+import (
+	"slices"
+
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// Statuses users can act on a server from. The handlers pass these as Request.From and the
+// capabilities endpoint checks them, so the UI doesn't need its own copy of the rules.
+var (
+	StartFrom       = []models.ServerStatus{models.ServerStatusStopped, models.ServerStatusFailed}
+	StopFrom        = []models.ServerStatus{models.ServerStatusRunning, models.ServerStatusPending, models.ServerStatusStarting}
+	RestartFrom     = []models.ServerStatus{models.ServerStatusRunning, models.ServerStatusStopped}
+	ResubscribeFrom = []models.ServerStatus{models.ServerStatusExpired}
+)
+
+// CanEditEnv reports whether a server's environment variables may be changed. Only servers
+// whose data is being or was deleted can't be edited.
+func CanEditEnv(status models.ServerStatus) bool {
+	return status != models.ServerStatusDeleting && status != models.ServerStatusDeleted
+}
+
+// In reports whether a status is one of statuses
+func In(status models.ServerStatus, statuses []models.ServerStatus) bool {
+	return slices.Contains(statuses, status)
+}
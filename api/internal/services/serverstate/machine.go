@@ -0,0 +1,160 @@
+package serverstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/broadcast"
+	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+)
+
+// ErrInvalidTransition is returned for a transition missing from the transition table
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// Request describes a status change
+type Request struct {
+	From    []models.ServerStatus // Statuses the server may be in; nothing changes if it's in none
+	To      models.ServerStatus
+	Message string
+	Reason  models.StatusReason
+	Phase   models.StartupPhase // Startup phase of a server moving to starting, if known
+
+	// ReleasePorts frees the server's ports and resource reservations as part of the
+	// transition. Only set it once the server's deployment is gone and its status has been
+	// checked under the server lock, since ports are released even if the status then
+	// doesn't match. ReleaseCause is recorded in the port allocation history, along with
+	// the message.
+	ReleasePorts bool
+	ReleaseCause models.PortAllocationCause
+}
+
+// Change is a status transition that was applied
+type Change struct {
+	Server  *models.Server // The server as it was before the transition
+	From    models.ServerStatus
+	To      models.ServerStatus
+	Message string
+	Reason  models.StatusReason
+}
+
+// Hook runs after a transition is applied
+type Hook func(ctx context.Context, change Change)
+
+// Machine applies server status transitions. Every transition is checked against the
+// transition table, applied atomically only if the server is still in one of the expected
+// statuses, with its side effects: ports are released if requested, stopped_at is stamped
+// on entering stopped, and the change is broadcast to the owner and passed to hooks.
+type Machine struct {
+	db               *database.DB
+	hub              *broadcast.Hub
+	portAllocService *portalloc.Service
+	hooks            []Hook
+}
+
+// NewMachine creates a new state machine. hub and portAllocService may be nil for tools
+// that don't broadcast or release ports.
+func NewMachine(db *database.DB, hub *broadcast.Hub, portAllocService *portalloc.Service) *Machine {
+	return &Machine{
+		db:               db,
+		hub:              hub,
+		portAllocService: portAllocService,
+	}
+}
+
+// OnTransition registers a hook to run after every applied transition. Hooks must be
+// registered before the machine is used.
+func (m *Machine) OnTransition(hook Hook) {
+	m.hooks = append(m.hooks, hook)
+}
+
+// Validate checks every transition in a request against the transition table
+func Validate(req Request) error {
+	if len(req.From) == 0 {
+		return fmt.Errorf("%w: no source status for %s", ErrInvalidTransition, req.To)
+	}
+	for _, from := range req.From {
+		if !Allowed(from, req.To) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, req.To)
+		}
+	}
+	return nil
+}
+
+// Transition moves a server to req.To if it's still in one of req.From and runs the side
+// effects. Returns false if the server's status didn't match.
+func (m *Machine) Transition(ctx context.Context, server *models.Server, req Request) (bool, error) {
+	if err := Validate(req); err != nil {
+		return false, err
+	}
+
+	serverID := server.ID.String()
+
+	// Release before the status changes, so the reconciler never picks up a pending server
+	// that still holds its old ports
+	if req.ReleasePorts && m.portAllocService != nil {
+		if err := m.portAllocService.ReleasePorts(ctx, server.ID, req.ReleaseCause, req.Message); err != nil {
+			// Continue anyway - releasing is idempotent and the next teardown retries it
+			log.Printf("Failed to release ports: server_id=%s error=%v", serverID, err)
+		}
+	}
+
+	previous, transitioned, err := m.db.TransitionServerStatus(ctx, serverID, req.From, req.To, req.Message, req.Reason, req.Phase)
+	if err != nil || !transitioned {
+		return false, err
+	}
+
+	if m.hub != nil {
+		event := broadcast.StatusEvent{
+			ServerID:     serverID,
+			Status:       string(req.To),
+			StatusReason: string(req.Reason),
+			StartupPhase: string(req.Phase),
+			Timestamp:    time.Now().UTC(),
+		}
+		if req.Message != "" {
+			event.StatusMessage = &req.Message
+		}
+		m.hub.Publish(server.UserID, event)
+	}
+
+	change := Change{
+		Server:  server,
+		From:    previous,
+		To:      req.To,
+		Message: req.Message,
+		Reason:  req.Reason,
+	}
+	for _, hook := range m.hooks {
+		hook(ctx, change)
+	}
+	return true, nil
+}
+
+// SetPhase records how far a starting server has got and broadcasts it. It isn't a
+// transition, so no hooks run. Returns false if the server isn't starting.
+func (m *Machine) SetPhase(ctx context.Context, server *models.Server, phase models.StartupPhase, message string) (bool, error) {
+	serverID := server.ID.String()
+	updated, err := m.db.UpdateServerStartupPhase(ctx, serverID, phase, message)
+	if err != nil || !updated {
+		return false, err
+	}
+
+	if m.hub != nil {
+		event := broadcast.StatusEvent{
+			ServerID:     serverID,
+			Status:       string(models.ServerStatusStarting),
+			StartupPhase: string(phase),
+			Timestamp:    time.Now().UTC(),
+		}
+		if message != "" {
+			event.StatusMessage = &message
+		}
+		m.hub.Publish(server.UserID, event)
+	}
+	return true, nil
+}
@@ -0,0 +1,125 @@
+package serverstate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mooncorn/gshub/api/internal/models"
+)
+
+// States lists every server status in lifecycle order
+var States = []models.ServerStatus{
+	models.ServerStatusPending,
+	models.ServerStatusStarting,
+	models.ServerStatusRunning,
+	models.ServerStatusStopping,
+	models.ServerStatusStopped,
+	models.ServerStatusFailed,
+	models.ServerStatusSuspended,
+	models.ServerStatusExpired,
+	models.ServerStatusDeleting,
+	models.ServerStatusDeleted,
+}
+
+// Edge is a valid status transition and what drives it
+type Edge struct {
+	From    models.ServerStatus `json:"from"`
+	To      models.ServerStatus `json:"to"`
+	Trigger string              `json:"trigger"`
+}
+
+// active are the statuses a server can be suspended or expired from
+var active = []models.ServerStatus{
+	models.ServerStatusPending,
+	models.ServerStatusStarting,
+	models.ServerStatusRunning,
+	models.ServerStatusStopping,
+	models.ServerStatusStopped,
+	models.ServerStatusFailed,
+}
+
+// edges is the transition table. Suspension, lifting a suspension and reactivation are
+// applied by dedicated queries that also reset billing and abuse fields (SuspendServer,
+// LiftServerSuspension, ReactivateServer); they're listed so the diagram is complete.
+var edges = buildEdges()
+
+func buildEdges() []Edge {
+	e := []Edge{
+		{models.ServerStatusPending, models.ServerStatusStarting, "deployment created or scaled up"},
+		{models.ServerStatusPending, models.ServerStatusRunning, "supervisor"}, // Supervisor can report before the reconciler records starting
+		{models.ServerStatusPending, models.ServerStatusStopping, "user stop"},
+		{models.ServerStatusPending, models.ServerStatusFailed, "invalid config, no capacity, failed restore or supervisor"},
+
+		{models.ServerStatusStarting, models.ServerStatusPending, "pod evicted or preempted, spot node reclaimed"},
+		{models.ServerStatusStarting, models.ServerStatusRunning, "supervisor"},
+		{models.ServerStatusStarting, models.ServerStatusStopping, "user stop or supervisor"},
+		{models.ServerStatusStarting, models.ServerStatusStopped, "supervisor"},
+		{models.ServerStatusStarting, models.ServerStatusFailed, "startup timeout, pod monitor or supervisor"},
+
+		{models.ServerStatusRunning, models.ServerStatusPending, "user restart, pod evicted or preempted, spot node reclaimed"},
+		{models.ServerStatusRunning, models.ServerStatusStarting, "supervisor restarting the game"},
+		{models.ServerStatusRunning, models.ServerStatusStopping, "user stop or supervisor"},
+		{models.ServerStatusRunning, models.ServerStatusStopped, "supervisor"},
+		{models.ServerStatusRunning, models.ServerStatusFailed, "pod monitor, heartbeat timeout or supervisor"},
+
+		{models.ServerStatusStopping, models.ServerStatusStopped, "supervisor or stop fallback"},
+		{models.ServerStatusStopping, models.ServerStatusFailed, "supervisor"},
+
+		{models.ServerStatusStopped, models.ServerStatusPending, "user start or restart"},
+		{models.ServerStatusStopped, models.ServerStatusStarting, "supervisor restarting the game"},
+
+		{models.ServerStatusFailed, models.ServerStatusPending, "user start or plan upgrade"},
+		{models.ServerStatusFailed, models.ServerStatusStarting, "supervisor restarting the game"},
+		{models.ServerStatusFailed, models.ServerStatusRunning, "supervisor: unresponsive game healthy again"},
+	}
+	for _, from := range active {
+		e = append(e, Edge{from, models.ServerStatusSuspended, "dispute or abuse"})
+	}
+	for _, from := range active {
+		e = append(e, Edge{from, models.ServerStatusExpired, "subscription ended or server deleted"})
+	}
+	return append(e,
+		Edge{models.ServerStatusSuspended, models.ServerStatusStopped, "admin lifts suspension"},
+		Edge{models.ServerStatusSuspended, models.ServerStatusExpired, "subscription ended"},
+		Edge{models.ServerStatusExpired, models.ServerStatusPending, "resubscribed"},
+		Edge{models.ServerStatusExpired, models.ServerStatusDeleting, "grace period over"},
+		Edge{models.ServerStatusDeleting, models.ServerStatusExpired, "cleanup failed, retried later"},
+		Edge{models.ServerStatusDeleting, models.ServerStatusDeleted, "data deleted"},
+	)
+}
+
+// allowed indexes edges by from and to status
+var allowed = func() map[models.ServerStatus]map[models.ServerStatus]bool {
+	m := make(map[models.ServerStatus]map[models.ServerStatus]bool)
+	for _, e := range edges {
+		if m[e.From] == nil {
+			m[e.From] = make(map[models.ServerStatus]bool)
+		}
+		m[e.From][e.To] = true
+	}
+	return m
+}()
+
+// Allowed reports whether a server may move from one status to another. Staying in the
+// same status (e.g. the supervisor updating the status message) is always allowed.
+func Allowed(from, to models.ServerStatus) bool {
+	return from == to || allowed[from][to]
+}
+
+// Edges returns the transition table
+func Edges() []Edge {
+	return append([]Edge(nil), edges...)
+}
+
+// Mermaid renders the transition table as a Mermaid state diagram, for docs and the
+// admin API
+func Mermaid() string {
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	fmt.Fprintf(&b, "    [*] --> %s\n", models.ServerStatusPending)
+	for _, e := range edges {
+		fmt.Fprintf(&b, "    %s --> %s: %s\n", e.From, e.To, e.Trigger)
+	}
+	fmt.Fprintf(&b, "    %s --> [*]\n", models.ServerStatusDeleted)
+	return b.String()
+}
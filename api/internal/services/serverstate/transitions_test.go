@@ -0,0 +1,168 @@
+package serverstate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pending   = models.ServerStatusPending
+	starting  = models.ServerStatusStarting
+	running   = models.ServerStatusRunning
+	stopping  = models.ServerStatusStopping
+	stopped   = models.ServerStatusStopped
+	failed    = models.ServerStatusFailed
+	suspended = models.ServerStatusSuspended
+	expired   = models.ServerStatusExpired
+	deleting  = models.ServerStatusDeleting
+	deleted   = models.ServerStatusDeleted
+)
+
+// expectedTransitions is the full transition table, written out independently of edges
+var expectedTransitions = map[models.ServerStatus][]models.ServerStatus{
+	pending:   {starting, running, stopping, failed, suspended, expired},
+	starting:  {pending, running, stopping, stopped, failed, suspended, expired},
+	running:   {pending, starting, stopping, stopped, failed, suspended, expired},
+	stopping:  {stopped, failed, suspended, expired},
+	stopped:   {pending, starting, suspended, expired},
+	failed:    {pending, starting, running, suspended, expired},
+	suspended: {stopped, expired},
+	expired:   {pending, deleting},
+	deleting:  {expired, deleted},
+	deleted:   {},
+}
+
+func TestAllowed_EveryPair(t *testing.T) {
+	require.Len(t, expectedTransitions, len(States), "every status needs an entry")
+
+	for _, from := range States {
+		want := make(map[models.ServerStatus]bool)
+		for _, to := range expectedTransitions[from] {
+			want[to] = true
+		}
+		for _, to := range States {
+			expected := from == to || want[to]
+			assert.Equal(t, expected, Allowed(from, to), "%s -> %s", from, to)
+		}
+	}
+}
+
+func TestAllowed_UnknownStatus(t *testing.T) {
+	assert.False(t, Allowed("bogus", running))
+	assert.False(t, Allowed(running, "bogus"))
+	assert.True(t, Allowed("bogus", "bogus"), "same status is always allowed")
+}
+
+func TestEdges_Valid(t *testing.T) {
+	known := make(map[models.ServerStatus]bool)
+	for _, s := range States {
+		known[s] = true
+	}
+
+	seen := make(map[Edge]bool)
+	for _, e := range Edges() {
+		assert.True(t, known[e.From], "unknown from status %q", e.From)
+		assert.True(t, known[e.To], "unknown to status %q", e.To)
+		assert.NotEqual(t, e.From, e.To, "self transitions are implicit")
+		assert.NotEmpty(t, e.Trigger, "%s -> %s has no trigger", e.From, e.To)
+
+		key := Edge{From: e.From, To: e.To}
+		assert.False(t, seen[key], "duplicate edge %s -> %s", e.From, e.To)
+		seen[key] = true
+	}
+}
+
+func TestEdges_ReturnsCopy(t *testing.T) {
+	e := Edges()
+	e[0].To = deleted
+	assert.NotEqual(t, deleted, Edges()[0].To)
+}
+
+func TestEdges_EveryStatusReachableFromPending(t *testing.T) {
+	reached := map[models.ServerStatus]bool{pending: true}
+	queue := []models.ServerStatus{pending}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, e := range Edges() {
+			if e.From == from && !reached[e.To] {
+				reached[e.To] = true
+				queue = append(queue, e.To)
+			}
+		}
+	}
+
+	for _, s := range States {
+		assert.True(t, reached[s], "%s is unreachable", s)
+	}
+}
+
+func TestEdges_DeletedIsTerminal(t *testing.T) {
+	for _, e := range Edges() {
+		assert.NotEqual(t, deleted, e.From, "deleted -> %s", e.To)
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	diagram := Mermaid()
+	lines := strings.Split(strings.TrimSuffix(diagram, "\n"), "\n")
+
+	require.Equal(t, "stateDiagram-v2", lines[0])
+	assert.Equal(t, "    [*] --> pending", lines[1])
+	assert.Equal(t, "    deleted --> [*]", lines[len(lines)-1])
+	require.Len(t, lines, len(Edges())+3)
+
+	for i, e := range Edges() {
+		assert.Equal(t, "    "+string(e.From)+" --> "+string(e.To)+": "+e.Trigger, lines[i+2])
+	}
+	assert.Equal(t, diagram, Mermaid(), "diagram must be deterministic")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     Request
+		wantErr bool
+	}{
+		{"single source", Request{From: []models.ServerStatus{stopping}, To: stopped}, false},
+		{"several sources", Request{From: []models.ServerStatus{running, pending, starting}, To: stopping}, false},
+		{"same status", Request{From: []models.ServerStatus{running}, To: running}, false},
+		{"no source", Request{To: stopped}, true},
+		{"invalid source", Request{From: []models.ServerStatus{deleted}, To: pending}, true},
+		{"one invalid source among valid ones", Request{From: []models.ServerStatus{stopped, expired}, To: starting}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.req)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidTransition)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMachine_TransitionRejectsInvalidBeforeWriting(t *testing.T) {
+	// No database: an invalid transition must fail before any query runs
+	m := NewMachine(nil, nil, nil)
+	called := false
+	m.OnTransition(func(context.Context, Change) { called = true })
+
+	server := &models.Server{ID: uuid.New(), Status: deleted}
+	ok, err := m.Transition(context.Background(), server, Request{
+		From: []models.ServerStatus{deleted},
+		To:   running,
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.False(t, ok)
+	assert.False(t, called, "hooks only run for applied transitions")
+}
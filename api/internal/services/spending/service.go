@@ -0,0 +1,134 @@
+package spending
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/services/email"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"github.com/mooncorn/gshub/api/internal/services/stripe"
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for the spending service
+type Config struct {
+	// Interval is how often projected spend is refreshed from Stripe (default: 24 hours)
+	Interval time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval: 24 * time.Hour,
+	}
+}
+
+// Service caches each user's projected monthly spend from Stripe upcoming invoices and
+// alerts users whose projection exceeds their spending limit
+type Service struct {
+	db            *database.DB
+	stripeService *stripe.Service
+	email         *email.Service
+	config        Config
+	logger        *zap.Logger
+	runner        *periodic.Runner
+}
+
+// NewService creates a new spending service
+func NewService(db *database.DB, stripeService *stripe.Service, emailService *email.Service, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:            db,
+		stripeService: stripeService,
+		email:         emailService,
+		config:        config,
+		logger:        logger,
+	}
+	// The first projection asks Stripe about every subscription, so it runs in the background
+	// rather than holding up the API's startup
+	s.runner = periodic.New("spending", config.Interval, s.runProjections, logger).RunFirst()
+	return s
+}
+
+// Start begins the spending service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx)
+}
+
+// Stop stops the spending service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// runProjections refreshes the projected spend of every user with subscriptions
+func (s *Service) runProjections(ctx context.Context) {
+	targets, err := s.db.GetSpendProjectionTargets(ctx)
+	if err != nil {
+		s.logger.Error("failed to get spend projection targets", zap.Error(err))
+		return
+	}
+
+	for _, target := range targets {
+		if err := s.project(ctx, target); err != nil {
+			s.logger.Error("failed to project spend",
+				zap.String("user_id", target.UserID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// project sums the upcoming charges of a user's subscriptions, caches the total and
+// alerts the user once a month if it exceeds their limit. A projection is only stored
+// when every subscription could be previewed.
+func (s *Service) project(ctx context.Context, target database.SpendProjectionTarget) error {
+	var total int64
+	var currency string
+	for _, subscriptionID := range target.SubscriptionIDs {
+		amount, cur, err := s.stripeService.UpcomingCharge(ctx, subscriptionID)
+		if err != nil {
+			return err
+		}
+		total += amount
+		if currency == "" {
+			currency = cur
+		}
+	}
+
+	if err := s.db.UpdateProjectedSpend(ctx, target.UserID, total, currency); err != nil {
+		return err
+	}
+
+	if target.LimitCents == nil || total <= *target.LimitCents {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if target.AlertSentAt != nil && !target.AlertSentAt.Before(monthStart) {
+		return nil
+	}
+
+	err := s.email.SendSpendLimitAlertEmail(target.Email, formatAmount(total, currency), formatAmount(*target.LimitCents, currency))
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("sent spend limit alert",
+		zap.String("user_id", target.UserID.String()),
+		zap.Int64("projected_cents", total),
+		zap.Int64("limit_cents", *target.LimitCents),
+	)
+
+	return s.db.MarkSpendAlertSent(ctx, target.UserID)
+}
+
+// formatAmount renders an amount in the smallest currency unit, e.g. "12.50 USD"
+func formatAmount(cents int64, currency string) string {
+	if currency == "" {
+		currency = "usd"
+	}
+	return fmt.Sprintf("%.2f %s", float64(cents)/100, strings.ToUpper(currency))
+}
@@ -0,0 +1,185 @@
+package statusingest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"go.uber.org/zap"
+)
+
+// Source identifies who observed a server's status
+type Source string
+
+const (
+	SourceSupervisor Source = "supervisor"  // Status reports from the in-pod supervisor
+	SourcePodMonitor Source = "pod_monitor" // Container and pod states from the K8s API
+	SourceHeartbeat  Source = "heartbeat"   // Missing supervisor heartbeats, checked by the reconciler
+	SourceNodeSync   Source = "node_sync"   // Reclaim notices of spot nodes, checked by node sync
+)
+
+// Report is a status observed by a source
+type Report struct {
+	ServerID string
+	Source   Source
+	Status   models.ServerStatus
+	Message  string
+	Reason   models.StatusReason
+	Phase    models.StartupPhase // Startup phase of a starting report, if known
+}
+
+// Ingestor is the single entry point for observed server status. Every source submits
+// reports here, and Arbitrate decides whether a report may replace the current status,
+// so sources can't overwrite each other's conclusions or the API's lifecycle decisions.
+type Ingestor struct {
+	db      *database.DB
+	machine *serverstate.Machine
+	logger  *zap.Logger
+}
+
+// NewIngestor creates a new status ingestor
+func NewIngestor(db *database.DB, machine *serverstate.Machine, logger *zap.Logger) *Ingestor {
+	return &Ingestor{
+		db:      db,
+		machine: machine,
+		logger:  logger,
+	}
+}
+
+// Ingest applies a report through the state machine if it wins arbitration against the
+// server's current status. Returns false if the report was rejected.
+func (i *Ingestor) Ingest(ctx context.Context, report Report) (bool, error) {
+	// The status may change between reading and writing it; re-arbitrate once if it does
+	for attempt := 0; attempt < 2; attempt++ {
+		server, err := i.db.GetServerByID(ctx, report.ServerID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get server: %w", err)
+		}
+
+		// The supervisor reporting the progress of a starting server isn't a transition;
+		// only the phase changes
+		if report.Source == SourceSupervisor && report.Phase != "" &&
+			server.Status == models.ServerStatusStarting && report.Status == models.ServerStatusStarting {
+			applied, err := i.machine.SetPhase(ctx, server, report.Phase, report.Message)
+			if err != nil || applied {
+				return applied, err
+			}
+			continue
+		}
+
+		if ok, rule := Arbitrate(server.Status, server.StatusReason, report); !ok {
+			i.logger.Debug("status report rejected",
+				zap.String("server_id", report.ServerID),
+				zap.String("source", string(report.Source)),
+				zap.String("current", string(server.Status)),
+				zap.String("reported", string(report.Status)),
+				zap.String("rule", rule))
+			return false, nil
+		}
+
+		applied, err := i.machine.Transition(ctx, server, serverstate.Request{
+			From:    []models.ServerStatus{server.Status},
+			To:      report.Status,
+			Message: report.Message,
+			Reason:  report.Reason,
+			Phase:   report.Phase,
+			// A requeued server is placed again, possibly on another node
+			ReleasePorts: report.Status == models.ServerStatusPending,
+			ReleaseCause: models.PortCauseRequeue,
+		})
+		if err != nil {
+			return false, err
+		}
+		if applied {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// lifecycleStatuses are decided by the API (billing, deletion, admin review) rather than
+// observed, so no source report replaces them
+var lifecycleStatuses = map[models.ServerStatus]bool{
+	models.ServerStatusExpired:   true,
+	models.ServerStatusDeleting:  true,
+	models.ServerStatusDeleted:   true,
+	models.ServerStatusSuspended: true,
+}
+
+// infrastructureReasons are failures diagnosed from outside the pod. They stick until the
+// user starts the server again, so a supervisor coming back up in a restarted container
+// doesn't hide why the server failed.
+var infrastructureReasons = map[models.StatusReason]bool{
+	models.StatusReasonOOMKilled:         true,
+	models.StatusReasonCrashLoop:         true,
+	models.StatusReasonImagePullError:    true,
+	models.StatusReasonPodFailed:         true,
+	models.StatusReasonDeploymentMissing: true,
+	models.StatusReasonHeartbeatTimeout:  true,
+}
+
+// requeueReasons are the reasons each observer may requeue a server for
+var requeueReasons = map[Source]models.StatusReason{
+	SourcePodMonitor: models.StatusReasonPodEvicted,
+	SourceNodeSync:   models.StatusReasonNodeReclaimed,
+}
+
+// Arbitrate decides whether a report may replace a server's current status. Besides the
+// state machine's transition table, the rules, in order of precedence:
+//
+//  1. Lifecycle statuses (expired, deleting, deleted, suspended) are never replaced.
+//  2. Pod monitor and heartbeat sources only report failures, and only for servers that
+//     are starting or running. The pod monitor also requeues (reports pending) servers
+//     whose pod was evicted or preempted, and node sync servers whose spot node is being
+//     reclaimed; node sync reports nothing else.
+//  3. A stopping server only accepts the supervisor's stopped or failed report, so a late
+//     report can't undo a stop.
+//  4. A failure diagnosed from outside the pod is only replaced by a user action, not by
+//     the supervisor reporting starting or running.
+//  5. A failed server only goes back to running if it failed by being unresponsive, i.e.
+//     the game was still running and has recovered.
+//
+// Returns the rule that rejected the report, if any.
+func Arbitrate(current models.ServerStatus, currentReason *models.StatusReason, report Report) (bool, string) {
+	if lifecycleStatuses[current] {
+		return false, "lifecycle status"
+	}
+	if !serverstate.Allowed(current, report.Status) {
+		return false, "invalid transition"
+	}
+
+	switch report.Source {
+	case SourcePodMonitor, SourceHeartbeat, SourceNodeSync:
+		requeue := report.Status == models.ServerStatusPending && report.Reason != "" &&
+			report.Reason == requeueReasons[report.Source]
+		if report.Source == SourceNodeSync && !requeue {
+			return false, "node sync reports requeues only"
+		}
+		if report.Status != models.ServerStatusFailed && !requeue {
+			return false, "observer reports failures only"
+		}
+		if current != models.ServerStatusStarting && current != models.ServerStatusRunning {
+			return false, "server not starting or running"
+		}
+		return true, ""
+
+	case SourceSupervisor:
+		if current == models.ServerStatusStopping &&
+			report.Status != models.ServerStatusStopped && report.Status != models.ServerStatusFailed {
+			return false, "server stopping"
+		}
+		if current == models.ServerStatusFailed && currentReason != nil && infrastructureReasons[*currentReason] &&
+			(report.Status == models.ServerStatusStarting || report.Status == models.ServerStatusRunning) {
+			return false, "infrastructure failure"
+		}
+		if current == models.ServerStatusFailed && report.Status == models.ServerStatusRunning &&
+			(currentReason == nil || *currentReason != models.StatusReasonGameUnresponsive) {
+			return false, "game not running"
+		}
+		return true, ""
+	}
+
+	return false, "unknown source"
+}
@@ -0,0 +1,108 @@
+// Package steam links Steam accounts through Steam's OpenID 2.0 sign-in, and checks what
+// linked accounts own and creates game server tokens through the Steam Web API
+package steam
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const openIDEndpoint = "https://steamcommunity.com/openid/login"
+
+// signedFields must be covered by an assertion's signature, or they could be swapped for
+// another user's after Steam signed it
+var signedFields = []string{"claimed_id", "identity", "return_to", "op_endpoint"}
+
+// claimedIDPattern extracts the SteamID64 from a verified claimed ID
+var claimedIDPattern = regexp.MustCompile(`^https://steamcommunity\.com/openid/id/(\d{17})$`)
+
+// OpenID signs users in with Steam to learn their SteamID64
+type OpenID struct {
+	client   *http.Client
+	endpoint string
+}
+
+// NewOpenID creates a Steam OpenID client
+func NewOpenID() *OpenID {
+	return &OpenID{client: &http.Client{Timeout: 10 * time.Second}, endpoint: openIDEndpoint}
+}
+
+// AuthURL returns the Steam sign-in URL that redirects back to returnTo with the assertion.
+// realm is the origin Steam shows the user they're signing in to.
+func (o *OpenID) AuthURL(returnTo, realm string) string {
+	params := url.Values{
+		"openid.ns":         {"http://specs.openid.net/auth/2.0"},
+		"openid.mode":       {"checkid_setup"},
+		"openid.return_to":  {returnTo},
+		"openid.realm":      {realm},
+		"openid.identity":   {"http://specs.openid.net/auth/2.0/identifier_select"},
+		"openid.claimed_id": {"http://specs.openid.net/auth/2.0/identifier_select"},
+	}
+	return o.endpoint + "?" + params.Encode()
+}
+
+// Verify checks a positive assertion Steam redirected back with, by asking Steam to
+// validate its signature, and returns the signed-in user's SteamID64. The assertion must
+// have been made by Steam for a return_to under returnToPrefix, and its signature must cover
+// the user's identity and the return_to.
+func (o *OpenID) Verify(ctx context.Context, assertion url.Values, returnToPrefix string) (string, error) {
+	if assertion.Get("openid.mode") != "id_res" {
+		return "", fmt.Errorf("not a positive assertion")
+	}
+	if assertion.Get("openid.op_endpoint") != openIDEndpoint {
+		return "", fmt.Errorf("assertion was not made by steam")
+	}
+	signed := strings.Split(assertion.Get("openid.signed"), ",")
+	for _, field := range signedFields {
+		if !slices.Contains(signed, field) {
+			return "", fmt.Errorf("assertion doesn't sign %s", field)
+		}
+	}
+	if assertion.Get("openid.identity") != assertion.Get("openid.claimed_id") {
+		return "", fmt.Errorf("identity doesn't match the claimed ID")
+	}
+	if !strings.HasPrefix(assertion.Get("openid.return_to"), returnToPrefix) {
+		return "", fmt.Errorf("assertion was made for another return URL")
+	}
+	match := claimedIDPattern.FindStringSubmatch(assertion.Get("openid.claimed_id"))
+	if match == nil {
+		return "", fmt.Errorf("invalid claimed ID")
+	}
+
+	check := url.Values{}
+	for key, values := range assertion {
+		if strings.HasPrefix(key, "openid.") {
+			check[key] = values
+		}
+	}
+	check.Set("openid.mode", "check_authentication")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint, strings.NewReader(check.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach steam: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read steam response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || !slices.Contains(strings.Split(string(body), "\n"), "is_valid:true") {
+		return "", fmt.Errorf("steam rejected the assertion")
+	}
+
+	return match[1], nil
+}
@@ -0,0 +1,208 @@
+package steam
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testReturnTo = "https://api.gshub.pro/auth/steam/callback?state=abc"
+	testClaimed  = "https://steamcommunity.com/openid/id/76561197960287930"
+)
+
+// validAssertion returns a positive assertion as Steam redirects back with it
+func validAssertion() url.Values {
+	return url.Values{
+		"openid.ns":             {"http://specs.openid.net/auth/2.0"},
+		"openid.mode":           {"id_res"},
+		"openid.op_endpoint":    {openIDEndpoint},
+		"openid.claimed_id":     {testClaimed},
+		"openid.identity":       {testClaimed},
+		"openid.return_to":      {testReturnTo},
+		"openid.response_nonce": {"2026-10-16T00:00:00Zabc"},
+		"openid.assoc_handle":   {"1234567890"},
+		"openid.signed":         {"signed,op_endpoint,claimed_id,identity,return_to,response_nonce,assoc_handle"},
+		"openid.sig":            {"c2lnbmF0dXJl"},
+	}
+}
+
+// fakeSteam answers check_authentication requests with body, and records the last request
+func fakeSteam(t *testing.T, status int, body string) (*OpenID, *url.Values) {
+	t.Helper()
+
+	var received url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received, err = url.ParseQuery(string(raw))
+		require.NoError(t, err)
+
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	o := NewOpenID()
+	o.endpoint = srv.URL
+	return o, &received
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(url.Values)
+		status  int
+		body    string
+		wantID  string
+		wantErr string
+	}{
+		{
+			name:   "valid assertion",
+			status: http.StatusOK,
+			body:   "ns:http://specs.openid.net/auth/2.0\nis_valid:true\n",
+			wantID: "76561197960287930",
+		},
+		{
+			name:    "rejected by steam",
+			status:  http.StatusOK,
+			body:    "ns:http://specs.openid.net/auth/2.0\nis_valid:false\n",
+			wantErr: "steam rejected the assertion",
+		},
+		{
+			name:    "steam error status",
+			status:  http.StatusInternalServerError,
+			body:    "is_valid:true\n",
+			wantErr: "steam rejected the assertion",
+		},
+		{
+			name:    "is_valid only as a substring",
+			status:  http.StatusOK,
+			body:    "error:is_valid:true\n",
+			wantErr: "steam rejected the assertion",
+		},
+		{
+			name:    "not a positive assertion",
+			modify:  func(v url.Values) { v.Set("openid.mode", "cancel") },
+			wantErr: "not a positive assertion",
+		},
+		{
+			name:    "made by another provider",
+			modify:  func(v url.Values) { v.Set("openid.op_endpoint", "https://evil.example/openid/login") },
+			wantErr: "assertion was not made by steam",
+		},
+		{
+			name:    "claimed ID not signed",
+			modify:  func(v url.Values) { v.Set("openid.signed", "signed,op_endpoint,identity,return_to") },
+			wantErr: "assertion doesn't sign claimed_id",
+		},
+		{
+			name:    "identity not signed",
+			modify:  func(v url.Values) { v.Set("openid.signed", "signed,op_endpoint,claimed_id,return_to") },
+			wantErr: "assertion doesn't sign identity",
+		},
+		{
+			name:    "return URL not signed",
+			modify:  func(v url.Values) { v.Set("openid.signed", "signed,op_endpoint,claimed_id,identity") },
+			wantErr: "assertion doesn't sign return_to",
+		},
+		{
+			name:    "endpoint not signed",
+			modify:  func(v url.Values) { v.Set("openid.signed", "signed,claimed_id,identity,return_to") },
+			wantErr: "assertion doesn't sign op_endpoint",
+		},
+		{
+			name:    "identity differs from claimed ID",
+			modify:  func(v url.Values) { v.Set("openid.identity", "https://steamcommunity.com/openid/id/76561197960287931") },
+			wantErr: "identity doesn't match the claimed ID",
+		},
+		{
+			name:    "made for another return URL",
+			modify:  func(v url.Values) { v.Set("openid.return_to", "https://evil.example/callback") },
+			wantErr: "assertion was made for another return URL",
+		},
+		{
+			name: "claimed ID on another host",
+			modify: func(v url.Values) {
+				v.Set("openid.claimed_id", "https://evil.example/openid/id/76561197960287930")
+				v.Set("openid.identity", "https://evil.example/openid/id/76561197960287930")
+			},
+			wantErr: "invalid claimed ID",
+		},
+		{
+			name: "claimed ID is not a SteamID64",
+			modify: func(v url.Values) {
+				v.Set("openid.claimed_id", "https://steamcommunity.com/openid/id/123")
+				v.Set("openid.identity", "https://steamcommunity.com/openid/id/123")
+			},
+			wantErr: "invalid claimed ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, _ := fakeSteam(t, tt.status, tt.body)
+			assertion := validAssertion()
+			if tt.modify != nil {
+				tt.modify(assertion)
+			}
+
+			id, err := o.Verify(context.Background(), assertion, "https://api.gshub.pro/auth/steam/callback")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Empty(t, id)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantID, id)
+		})
+	}
+}
+
+func TestVerify_AsksSteamToCheckTheAssertion(t *testing.T) {
+	o, received := fakeSteam(t, http.StatusOK, "is_valid:true\n")
+	assertion := validAssertion()
+	assertion.Set("unrelated", "dropped")
+
+	_, err := o.Verify(context.Background(), assertion, "https://api.gshub.pro/")
+	require.NoError(t, err)
+
+	assert.Equal(t, "check_authentication", received.Get("openid.mode"))
+	assert.Equal(t, assertion.Get("openid.sig"), received.Get("openid.sig"))
+	assert.Equal(t, assertion.Get("openid.signed"), received.Get("openid.signed"))
+	assert.Equal(t, testClaimed, received.Get("openid.claimed_id"))
+	assert.Empty(t, received.Get("unrelated"), "only openid.* fields are forwarded")
+}
+
+func TestVerify_SteamUnreachable(t *testing.T) {
+	o := NewOpenID()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	o.endpoint = srv.URL
+	srv.Close()
+
+	_, err := o.Verify(context.Background(), validAssertion(), "https://api.gshub.pro/")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to reach steam")
+}
+
+func TestAuthURL(t *testing.T) {
+	raw := NewOpenID().AuthURL(testReturnTo, "https://api.gshub.pro")
+
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, openIDEndpoint, u.Scheme+"://"+u.Host+u.Path)
+
+	q := u.Query()
+	assert.Equal(t, "checkid_setup", q.Get("openid.mode"))
+	assert.Equal(t, testReturnTo, q.Get("openid.return_to"))
+	assert.Equal(t, "https://api.gshub.pro", q.Get("openid.realm"))
+	assert.Equal(t, "http://specs.openid.net/auth/2.0/identifier_select", q.Get("openid.identity"))
+	assert.Equal(t, "http://specs.openid.net/auth/2.0/identifier_select", q.Get("openid.claimed_id"))
+}
@@ -0,0 +1,118 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const webAPIEndpoint = "https://api.steampowered.com"
+
+// ErrPrivateProfile is returned when a user's Steam game details are private, so what they
+// own can't be checked
+var ErrPrivateProfile = errors.New("steam game details are private")
+
+// WebAPI calls the Steam Web API with the platform's publisher key
+type WebAPI struct {
+	client   *http.Client
+	endpoint string
+	key      string
+}
+
+// NewWebAPI creates a Steam Web API client. Returns nil without a key.
+func NewWebAPI(key string) *WebAPI {
+	if key == "" {
+		return nil
+	}
+	return &WebAPI{client: &http.Client{Timeout: 10 * time.Second}, endpoint: webAPIEndpoint, key: key}
+}
+
+// OwnsApp reports whether the Steam user owns the app, counting free games they've played.
+// Returns ErrPrivateProfile if their game details aren't public.
+func (w *WebAPI) OwnsApp(ctx context.Context, steamID string, appID int) (bool, error) {
+	params := url.Values{
+		"key":                       {w.key},
+		"steamid":                   {steamID},
+		"appids_filter[0]":          {strconv.Itoa(appID)},
+		"include_played_free_games": {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.endpoint+"/IPlayerService/GetOwnedGames/v1/?"+params.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	var result struct {
+		Response struct {
+			// Missing when the profile's game details are private
+			GameCount *int `json:"game_count"`
+			Games     []struct {
+				AppID int `json:"appid"`
+			} `json:"games"`
+		} `json:"response"`
+	}
+	if err := w.do(req, &result); err != nil {
+		return false, err
+	}
+
+	if result.Response.GameCount == nil {
+		return false, ErrPrivateProfile
+	}
+	for _, game := range result.Response.Games {
+		if game.AppID == appID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateGameServerToken creates a game server login token for the app on the publisher
+// account. memo is shown next to the token on Steam's token management page.
+func (w *WebAPI) CreateGameServerToken(ctx context.Context, appID int, memo string) (string, error) {
+	form := url.Values{
+		"key":   {w.key},
+		"appid": {strconv.Itoa(appID)},
+		"memo":  {memo},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint+"/IGameServersService/CreateAccount/v1/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var result struct {
+		Response struct {
+			LoginToken string `json:"login_token"`
+		} `json:"response"`
+	}
+	if err := w.do(req, &result); err != nil {
+		return "", err
+	}
+	if result.Response.LoginToken == "" {
+		return "", fmt.Errorf("steam returned no login token")
+	}
+	return result.Response.LoginToken, nil
+}
+
+// do sends the request and decodes the JSON response into out
+func (w *WebAPI) do(req *http.Request, out any) error {
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach steam: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("steam returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode steam response: %w", err)
+	}
+	return nil
+}
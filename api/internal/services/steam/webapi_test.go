@@ -0,0 +1,103 @@
+package steam
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebAPI answers Web API requests with body, and records the last request
+func fakeWebAPI(t *testing.T, status int, body string) (*WebAPI, *http.Request, *url.Values) {
+	t.Helper()
+
+	var received http.Request
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		form, err = url.ParseQuery(string(raw))
+		require.NoError(t, err)
+		received = *r
+
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	w := NewWebAPI("test-key")
+	w.endpoint = srv.URL
+	return w, &received, &form
+}
+
+func TestNewWebAPI_NoKey(t *testing.T) {
+	assert.Nil(t, NewWebAPI(""))
+}
+
+func TestOwnsApp(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    bool
+		wantErr error
+	}{
+		{"owned", http.StatusOK, `{"response":{"game_count":1,"games":[{"appid":346110,"playtime_forever":12}]}}`, true, nil},
+		{"not owned", http.StatusOK, `{"response":{"game_count":0}}`, false, nil},
+		{"private profile", http.StatusOK, `{"response":{}}`, false, ErrPrivateProfile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, received, _ := fakeWebAPI(t, tt.status, tt.body)
+
+			owned, err := w.OwnsApp(context.Background(), "76561197960287930", 346110)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, owned)
+
+			assert.Equal(t, "/IPlayerService/GetOwnedGames/v1/", received.URL.Path)
+			query := received.URL.Query()
+			assert.Equal(t, "test-key", query.Get("key"))
+			assert.Equal(t, "76561197960287930", query.Get("steamid"))
+			assert.Equal(t, "346110", query.Get("appids_filter[0]"))
+		})
+	}
+}
+
+func TestOwnsApp_SteamError(t *testing.T) {
+	w, _, _ := fakeWebAPI(t, http.StatusForbidden, `<html>Forbidden</html>`)
+
+	_, err := w.OwnsApp(context.Background(), "76561197960287930", 346110)
+	assert.Error(t, err)
+}
+
+func TestCreateGameServerToken(t *testing.T) {
+	w, received, form := fakeWebAPI(t, http.StatusOK, `{"response":{"steamid":"85568392920040000","login_token":"ABCDEF123456"}}`)
+
+	token, err := w.CreateGameServerToken(context.Background(), 252490, "gshub server 1")
+	require.NoError(t, err)
+	assert.Equal(t, "ABCDEF123456", token)
+
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "/IGameServersService/CreateAccount/v1/", received.URL.Path)
+	assert.Equal(t, "test-key", form.Get("key"))
+	assert.Equal(t, "252490", form.Get("appid"))
+	assert.Equal(t, "gshub server 1", form.Get("memo"))
+}
+
+func TestCreateGameServerToken_NoToken(t *testing.T) {
+	// Steam answers an empty response when the publisher account can't create more tokens
+	w, _, _ := fakeWebAPI(t, http.StatusOK, `{"response":{}}`)
+
+	_, err := w.CreateGameServerToken(context.Background(), 252490, "gshub server 1")
+	assert.Error(t, err)
+}
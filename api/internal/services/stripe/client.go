@@ -0,0 +1,156 @@
+package stripe
+
+import (
+	"github.com/stripe/stripe-go/v84"
+	"github.com/stripe/stripe-go/v84/balance"
+	portalsession "github.com/stripe/stripe-go/v84/billingportal/session"
+	"github.com/stripe/stripe-go/v84/checkout/session"
+	"github.com/stripe/stripe-go/v84/customer"
+	"github.com/stripe/stripe-go/v84/invoice"
+	"github.com/stripe/stripe-go/v84/invoicepayment"
+	"github.com/stripe/stripe-go/v84/price"
+	"github.com/stripe/stripe-go/v84/subscription"
+)
+
+// client is the subset of the Stripe API used by Service.
+// It is satisfied by liveClient (real Stripe) and mockClient (local simulation).
+type client interface {
+	NewCheckoutSession(params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error)
+	GetCheckoutSession(id string) (*stripe.CheckoutSession, error)
+	GetSubscription(id string) (*stripe.Subscription, error)
+	UpdateSubscription(id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error)
+	CancelSubscription(id string) (*stripe.Subscription, error)
+	GetPrice(id string) (*stripe.Price, error)
+	PreviewInvoice(params *stripe.InvoiceCreatePreviewParams) (*stripe.Invoice, error)
+	ListInvoices(customerID string, limit int64) ([]*stripe.Invoice, error)
+	NewSubscription(params *stripe.SubscriptionParams) (*stripe.Subscription, error)
+	GetCustomer(id string) (*stripe.Customer, error)
+	NewCustomer(params *stripe.CustomerParams) (*stripe.Customer, error)
+	UpdateCustomer(id string, params *stripe.CustomerParams) (*stripe.Customer, error)
+	ListCustomersByEmail(email string) ([]*stripe.Customer, error)
+	ListCards(customerID string) ([]*stripe.PaymentMethod, error)
+	NewPortalSession(params *stripe.BillingPortalSessionParams) (*stripe.BillingPortalSession, error)
+	SubscriptionIDForPaymentIntent(paymentIntentID string) (string, error)
+	Ping() error
+}
+
+// liveClient calls the real Stripe API using the package-level stripe.Key
+type liveClient struct{}
+
+func (liveClient) NewCheckoutSession(params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error) {
+	return session.New(params)
+}
+
+func (liveClient) GetCheckoutSession(id string) (*stripe.CheckoutSession, error) {
+	return session.Get(id, &stripe.CheckoutSessionParams{})
+}
+
+func (liveClient) GetSubscription(id string) (*stripe.Subscription, error) {
+	return subscription.Get(id, nil)
+}
+
+func (liveClient) UpdateSubscription(id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+	return subscription.Update(id, params)
+}
+
+func (liveClient) CancelSubscription(id string) (*stripe.Subscription, error) {
+	return subscription.Cancel(id, nil)
+}
+
+func (liveClient) GetPrice(id string) (*stripe.Price, error) {
+	return price.Get(id, nil)
+}
+
+func (liveClient) PreviewInvoice(params *stripe.InvoiceCreatePreviewParams) (*stripe.Invoice, error) {
+	return invoice.CreatePreview(params)
+}
+
+func (liveClient) ListInvoices(customerID string, limit int64) ([]*stripe.Invoice, error) {
+	params := &stripe.InvoiceListParams{Customer: stripe.String(customerID)}
+	params.Limit = stripe.Int64(limit)
+
+	var invoices []*stripe.Invoice
+	iter := invoice.List(params)
+	for iter.Next() && int64(len(invoices)) < limit {
+		invoices = append(invoices, iter.Invoice())
+	}
+	return invoices, iter.Err()
+}
+
+func (liveClient) NewSubscription(params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+	return subscription.New(params)
+}
+
+func (liveClient) GetCustomer(id string) (*stripe.Customer, error) {
+	params := &stripe.CustomerParams{}
+	params.AddExpand("invoice_settings.default_payment_method")
+	return customer.Get(id, params)
+}
+
+func (liveClient) NewCustomer(params *stripe.CustomerParams) (*stripe.Customer, error) {
+	return customer.New(params)
+}
+
+func (liveClient) UpdateCustomer(id string, params *stripe.CustomerParams) (*stripe.Customer, error) {
+	return customer.Update(id, params)
+}
+
+func (liveClient) ListCustomersByEmail(email string) ([]*stripe.Customer, error) {
+	params := &stripe.CustomerListParams{Email: stripe.String(email)}
+	params.Limit = stripe.Int64(100)
+
+	var customers []*stripe.Customer
+	iter := customer.List(params)
+	for iter.Next() {
+		customers = append(customers, iter.Customer())
+	}
+	return customers, iter.Err()
+}
+
+func (liveClient) ListCards(customerID string) ([]*stripe.PaymentMethod, error) {
+	params := &stripe.CustomerListPaymentMethodsParams{
+		Customer: stripe.String(customerID),
+		Type:     stripe.String(string(stripe.PaymentMethodTypeCard)),
+	}
+	params.Limit = stripe.Int64(10)
+
+	var cards []*stripe.PaymentMethod
+	iter := customer.ListPaymentMethods(params)
+	for iter.Next() && len(cards) < 10 {
+		cards = append(cards, iter.PaymentMethod())
+	}
+	return cards, iter.Err()
+}
+
+func (liveClient) NewPortalSession(params *stripe.BillingPortalSessionParams) (*stripe.BillingPortalSession, error) {
+	return portalsession.New(params)
+}
+
+// SubscriptionIDForPaymentIntent returns the subscription whose invoice the payment intent
+// paid, or "" if it didn't pay a subscription invoice
+func (liveClient) SubscriptionIDForPaymentIntent(paymentIntentID string) (string, error) {
+	params := &stripe.InvoicePaymentListParams{
+		Payment: &stripe.InvoicePaymentListPaymentParams{
+			Type:          stripe.String("payment_intent"),
+			PaymentIntent: stripe.String(paymentIntentID),
+		},
+	}
+	params.AddExpand("data.invoice")
+	params.Limit = stripe.Int64(1)
+
+	iter := invoicepayment.List(params)
+	for iter.Next() {
+		inv := iter.InvoicePayment().Invoice
+		if inv != nil && inv.Parent != nil && inv.Parent.SubscriptionDetails != nil &&
+			inv.Parent.SubscriptionDetails.Subscription != nil {
+			return inv.Parent.SubscriptionDetails.Subscription.ID, nil
+		}
+	}
+	return "", iter.Err()
+}
+
+// Ping checks that the Stripe API is reachable and the key is valid
+func (liveClient) Ping() error {
+	_, err := balance.Get(nil)
+	return err
+}
@@ -0,0 +1,98 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stripe/stripe-go/v84"
+)
+
+// EnsureCustomer returns the Stripe customer a user is billed through, so every checkout
+// and subscription of the user shares one customer and its saved cards. The stored
+// customer's email is kept in sync with the user's. Users without one are matched to an
+// existing customer with their email, or a new customer is created.
+func (s *Service) EnsureCustomer(ctx context.Context, user *models.User) (string, error) {
+	if user.StripeCustomerID != nil && *user.StripeCustomerID != "" {
+		customerID := *user.StripeCustomerID
+		cus, err := s.client.GetCustomer(customerID)
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve customer: %w", err)
+		}
+
+		if !cus.Deleted {
+			if cus.Email != user.Email {
+				params := &stripe.CustomerParams{Email: stripe.String(user.Email)}
+				if _, err := s.client.UpdateCustomer(customerID, params); err != nil {
+					return "", fmt.Errorf("failed to sync customer email: %w", err)
+				}
+				log.Printf("Synced Stripe customer email: user_id=%s customer_id=%s", user.ID, customerID)
+			}
+			return customerID, nil
+		}
+
+		// Deleted in Stripe: forget it and find or create a replacement below
+		log.Printf("Stripe customer was deleted: user_id=%s customer_id=%s", user.ID, customerID)
+		if err := s.db.ClearUserStripeCustomerID(ctx, user.ID, customerID); err != nil {
+			return "", err
+		}
+	}
+
+	customerID, err := s.FindCustomer(ctx, user)
+	if err != nil {
+		return "", err
+	}
+
+	if customerID == "" {
+		params := &stripe.CustomerParams{
+			Email:    stripe.String(user.Email),
+			Metadata: map[string]string{"user_id": user.ID.String()},
+		}
+		// Concurrent first checkouts of the same user create a single customer
+		params.IdempotencyKey = stripe.String("customer-" + user.ID.String())
+
+		cus, err := s.client.NewCustomer(params)
+		if err != nil {
+			return "", fmt.Errorf("failed to create customer: %w", err)
+		}
+		customerID = cus.ID
+		log.Printf("Created Stripe customer: user_id=%s customer_id=%s", user.ID, customerID)
+	}
+
+	stored, err := s.db.SetUserStripeCustomerID(ctx, user.ID, customerID)
+	if err != nil {
+		return "", err
+	}
+	user.StripeCustomerID = &stored
+	return stored, nil
+}
+
+// FindCustomer returns the existing Stripe customer to adopt for a user that has none
+// recorded: checkouts used to create a new customer per session, so several may share the
+// user's email. The oldest one is kept; it's the one earlier subscriptions were billed to.
+// Returns "" if there is none.
+func (s *Service) FindCustomer(ctx context.Context, user *models.User) (string, error) {
+	customers, err := s.client.ListCustomersByEmail(user.Email)
+	if err != nil {
+		return "", fmt.Errorf("failed to list customers: %w", err)
+	}
+
+	var oldest *stripe.Customer
+	for _, cus := range customers {
+		if cus.Deleted {
+			continue
+		}
+		if oldest == nil || cus.Created < oldest.Created {
+			oldest = cus
+		}
+	}
+	if oldest == nil {
+		return "", nil
+	}
+
+	if len(customers) > 1 {
+		log.Printf("Found duplicate Stripe customers: user_id=%s count=%d kept=%s", user.ID, len(customers), oldest.ID)
+	}
+	return oldest.ID, nil
+}
@@ -0,0 +1,108 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stripe/stripe-go/v84"
+)
+
+// disputeSuspensionMessage is the status message shown on servers suspended for a dispute
+const disputeSuspensionMessage = "Suspended: a payment for this server was disputed"
+
+// handleDisputeCreated is the internal handler for charge.dispute.created events
+func (s *Service) handleDisputeCreated(ctx context.Context, event *stripe.Event) error {
+	var dispute stripe.Dispute
+	if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+		return fmt.Errorf("failed to unmarshal dispute from webhook event: %w", err)
+	}
+
+	log.Printf("Processing dispute: event_id=%s dispute_id=%s reason=%s", event.ID, dispute.ID, dispute.Reason)
+
+	var subscriptionID string
+	if dispute.PaymentIntent != nil && dispute.PaymentIntent.ID != "" {
+		var err error
+		subscriptionID, err = s.client.SubscriptionIDForPaymentIntent(dispute.PaymentIntent.ID)
+		if err != nil {
+			return fmt.Errorf("failed to find subscription for disputed payment: %w", err)
+		}
+	}
+
+	return s.processDispute(ctx, event.ID, &dispute, subscriptionID)
+}
+
+// processDispute records a dispute, flags the paying user, suspends the server the disputed
+// subscription pays for and alerts admins. Disputes already recorded are ignored, so
+// redelivered events don't alert twice.
+func (s *Service) processDispute(ctx context.Context, eventID string, dispute *stripe.Dispute, subscriptionID string) error {
+	record := &models.Dispute{
+		StripeDisputeID: dispute.ID,
+		Amount:          dispute.Amount,
+		Currency:        string(dispute.Currency),
+		Reason:          string(dispute.Reason),
+	}
+	if dispute.PaymentIntent != nil && dispute.PaymentIntent.ID != "" {
+		record.PaymentIntentID = &dispute.PaymentIntent.ID
+	}
+
+	var server *models.Server
+	if subscriptionID != "" {
+		var err error
+		server, err = s.db.GetServerByStripeSubscriptionID(ctx, subscriptionID)
+		if err != nil {
+			// Still record the dispute and alert admins so it can be handled manually
+			log.Printf("Failed to find server for disputed subscription: event_id=%s subscription_id=%s error=%v", eventID, subscriptionID, err)
+		}
+	}
+	if server != nil {
+		record.ServerID = &server.ID
+		record.UserID = &server.UserID
+	}
+
+	created, err := s.db.CreateDispute(ctx, record)
+	if err != nil {
+		return err
+	}
+	if !created {
+		log.Printf("Dispute already processed: event_id=%s dispute_id=%s", eventID, dispute.ID)
+		return nil
+	}
+
+	userEmail := "unknown"
+	serverID := "unknown"
+	if server != nil {
+		serverID = server.ID.String()
+
+		if err := s.db.FlagUser(ctx, server.UserID, "Chargeback dispute "+dispute.ID); err != nil {
+			return err
+		}
+		if user, err := s.db.GetUserByID(ctx, server.UserID); err == nil {
+			userEmail = user.Email
+		}
+
+		if _, err := s.suspension.Suspend(ctx, server, models.StatusReasonDispute, disputeSuspensionMessage); err != nil {
+			return fmt.Errorf("failed to suspend disputed server: event_id=%s server_id=%s error=%w", eventID, serverID, err)
+		}
+
+		if err := s.account.CheckRepeatOffender(ctx, server.UserID); err != nil {
+			log.Printf("Failed to check repeat disputes: event_id=%s user_id=%s error=%v", eventID, server.UserID, err)
+		}
+	}
+
+	amount := fmt.Sprintf("%.2f %s", float64(dispute.Amount)/100, strings.ToUpper(string(dispute.Currency)))
+	for _, admin := range s.config.AdminEmails {
+		if admin = strings.TrimSpace(admin); admin == "" {
+			continue
+		}
+		if err := s.email.SendDisputeAlertEmail(admin, dispute.ID, string(dispute.Reason), amount, userEmail, serverID); err != nil {
+			log.Printf("Failed to send dispute alert: event_id=%s dispute_id=%s admin=%s error=%v", eventID, dispute.ID, admin, err)
+		}
+	}
+
+	log.Printf("Dispute processed: event_id=%s dispute_id=%s server_id=%s", eventID, dispute.ID, serverID)
+	return nil
+}
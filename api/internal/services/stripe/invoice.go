@@ -0,0 +1,129 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stripe/stripe-go/v84"
+)
+
+// Metadata keys identifying the server a subscription pays for. They are set on the
+// subscription, whose metadata Stripe copies to its invoice lines, and on its item.
+const (
+	metadataServerID  = "server_id"
+	metadataSubdomain = "subdomain"
+)
+
+// maxInvoices caps how many of a customer's most recent invoices are listed
+const maxInvoices = 24
+
+// tagSubscription labels a subscription with the server it pays for, so invoices state
+// which server each charge is for. Failing to label it is logged, not fatal.
+func (s *Service) tagSubscription(ctx context.Context, eventID string, server *models.Server, subscriptionID string) {
+	sub, err := s.client.GetSubscription(subscriptionID)
+	if err != nil {
+		log.Printf("Failed to retrieve subscription to tag: event_id=%s subscription_id=%s error=%v", eventID, subscriptionID, err)
+		return
+	}
+
+	metadata := map[string]string{
+		metadataServerID:  server.ID.String(),
+		metadataSubdomain: server.Subdomain,
+	}
+	params := &stripe.SubscriptionParams{
+		Description: stripe.String(fmt.Sprintf("Server %s (%s)", server.DisplayName, server.Subdomain)),
+		Metadata:    metadata,
+	}
+	if sub.Items != nil && len(sub.Items.Data) > 0 {
+		params.Items = []*stripe.SubscriptionItemsParams{
+			{ID: stripe.String(sub.Items.Data[0].ID), Metadata: metadata},
+		}
+	}
+
+	if _, err := s.client.UpdateSubscription(subscriptionID, params); err != nil {
+		log.Printf("Failed to tag subscription: event_id=%s server_id=%s subscription_id=%s error=%v", eventID, server.ID, subscriptionID, err)
+	}
+}
+
+// tagReactivatedSubscription labels the new subscription of a reactivated server
+func (s *Service) tagReactivatedSubscription(ctx context.Context, eventID string, serverID string, subscriptionID string) {
+	server, err := s.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		log.Printf("Failed to get server to tag subscription: event_id=%s server_id=%s error=%v", eventID, serverID, err)
+		return
+	}
+	s.tagSubscription(ctx, eventID, server, subscriptionID)
+}
+
+// ListInvoices returns the user's most recent invoices, newest first, with the server each
+// line charges for. Users who never paid have none.
+func (s *Service) ListInvoices(ctx context.Context, user *models.User) ([]models.Invoice, error) {
+	invoices := []models.Invoice{}
+	if user.StripeCustomerID == nil || *user.StripeCustomerID == "" {
+		return invoices, nil
+	}
+
+	stripeInvoices, err := s.client.ListInvoices(*user.StripeCustomerID, maxInvoices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices: %w", err)
+	}
+
+	// Subscriptions created before servers were tagged are matched by subscription ID instead
+	servers, err := s.db.ListServersByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers: %w", err)
+	}
+	bySubscription := make(map[string]*models.Server, len(servers))
+	for i := range servers {
+		if servers[i].StripeSubscriptionID != nil {
+			bySubscription[*servers[i].StripeSubscriptionID] = &servers[i]
+		}
+	}
+
+	for _, inv := range stripeInvoices {
+		invoice := models.Invoice{
+			ID:         inv.ID,
+			Number:     inv.Number,
+			Status:     string(inv.Status),
+			Currency:   string(inv.Currency),
+			Total:      inv.Total,
+			AmountPaid: inv.AmountPaid,
+			CreatedAt:  time.Unix(inv.Created, 0).UTC(),
+			URL:        inv.HostedInvoiceURL,
+			Lines:      []models.InvoiceLine{},
+		}
+		if inv.Lines != nil {
+			for _, line := range inv.Lines.Data {
+				invoice.Lines = append(invoice.Lines, invoiceLine(line, bySubscription))
+			}
+		}
+		invoices = append(invoices, invoice)
+	}
+	return invoices, nil
+}
+
+// invoiceLine converts a Stripe invoice line, identifying its server from the metadata set
+// by tagSubscription, or else from the user's servers by subscription ID
+func invoiceLine(line *stripe.InvoiceLineItem, bySubscription map[string]*models.Server) models.InvoiceLine {
+	l := models.InvoiceLine{
+		Description: line.Description,
+		Amount:      line.Amount,
+		ServerID:    line.Metadata[metadataServerID],
+		Subdomain:   line.Metadata[metadataSubdomain],
+	}
+	if line.Period != nil {
+		start := time.Unix(line.Period.Start, 0).UTC()
+		end := time.Unix(line.Period.End, 0).UTC()
+		l.PeriodStart, l.PeriodEnd = &start, &end
+	}
+
+	if l.ServerID == "" && line.Parent != nil && line.Parent.SubscriptionItemDetails != nil {
+		if server, ok := bySubscription[line.Parent.SubscriptionItemDetails.Subscription]; ok {
+			l.ServerID, l.Subdomain = server.ID.String(), server.Subdomain
+		}
+	}
+	return l
+}
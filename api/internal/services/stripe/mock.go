@@ -0,0 +1,554 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v84"
+)
+
+// Mock mode errors
+var (
+	ErrMockModeDisabled    = errors.New("stripe mock mode is disabled")
+	ErrMockSessionNotFound = errors.New("mock checkout session not found")
+)
+
+const mockBillingPeriod = 30 * 24 * time.Hour
+
+// mockPlanAmounts are the monthly prices (in cents) reported for synthesized
+// price_mock_<game>_<plan> price IDs
+var mockPlanAmounts = map[string]int64{
+	"small":     500,
+	"medium":    1000,
+	"large":     2000,
+	"dedicated": 6000,
+	"budget":    300,
+}
+
+// mockClient simulates the Stripe API in memory for local development and E2E tests.
+// Checkout sessions are completed explicitly via Service.CompleteMockCheckout
+// instead of by a Stripe-hosted payment page and webhook.
+type mockClient struct {
+	frontendURL string
+
+	mu            sync.Mutex
+	sessions      map[string]*stripe.CheckoutSession
+	subscriptions map[string]*stripe.Subscription
+	customers     map[string]*stripe.Customer
+	idempotent    map[string]string // Idempotency key -> subscription ID
+}
+
+func newMockClient(frontendURL string) *mockClient {
+	return &mockClient{
+		frontendURL:   frontendURL,
+		sessions:      make(map[string]*stripe.CheckoutSession),
+		subscriptions: make(map[string]*stripe.Subscription),
+		customers:     make(map[string]*stripe.Customer),
+		idempotent:    make(map[string]string),
+	}
+}
+
+func mockID(prefix string) string {
+	return prefix + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+func (m *mockClient) NewCheckoutSession(params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error) {
+	id := mockID("cs_mock_")
+
+	metadata := make(map[string]string, len(params.Metadata))
+	for k, v := range params.Metadata {
+		metadata[k] = v
+	}
+
+	sess := &stripe.CheckoutSession{
+		ID:            id,
+		Mode:          stripe.CheckoutSessionModeSubscription,
+		Status:        stripe.CheckoutSessionStatusOpen,
+		PaymentStatus: stripe.CheckoutSessionPaymentStatusUnpaid,
+		Metadata:      metadata,
+		URL:           m.frontendURL + "/dev/checkout/" + id,
+	}
+	if params.SuccessURL != nil {
+		sess.SuccessURL = *params.SuccessURL
+	}
+	if params.CancelURL != nil {
+		sess.CancelURL = *params.CancelURL
+	}
+	if params.CustomerEmail != nil {
+		sess.CustomerEmail = *params.CustomerEmail
+	}
+	if params.Customer != nil {
+		sess.Customer = &stripe.Customer{ID: *params.Customer}
+	}
+	if len(params.LineItems) > 0 && params.LineItems[0].Price != nil {
+		sess.LineItems = &stripe.LineItemList{
+			Data: []*stripe.LineItem{{Price: &stripe.Price{ID: *params.LineItems[0].Price}}},
+		}
+	}
+
+	m.mu.Lock()
+	m.sessions[id] = sess
+	m.mu.Unlock()
+
+	return sess, nil
+}
+
+func (m *mockClient) GetCheckoutSession(id string) (*stripe.CheckoutSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sess, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrMockSessionNotFound
+	}
+	copied := *sess
+	return &copied, nil
+}
+
+// GetSubscription returns a mock subscription. Unknown IDs are treated as active
+// subscriptions so servers created before an API restart keep working.
+func (m *mockClient) GetSubscription(id string) (*stripe.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub := m.getOrCreateSubscriptionLocked(id)
+	copied := *sub
+	return &copied, nil
+}
+
+func (m *mockClient) UpdateSubscription(id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub := m.getOrCreateSubscriptionLocked(id)
+	if sub.Status == stripe.SubscriptionStatusCanceled {
+		return nil, fmt.Errorf("subscription %s is canceled", id)
+	}
+
+	if len(params.Items) > 0 && params.Items[0].Price != nil {
+		sub.Items.Data[0].Price = &stripe.Price{ID: *params.Items[0].Price}
+	}
+	if len(params.Items) > 0 && params.Items[0].Metadata != nil {
+		sub.Items.Data[0].Metadata = mergeMetadata(sub.Items.Data[0].Metadata, params.Items[0].Metadata)
+	}
+	if params.Metadata != nil {
+		sub.Metadata = mergeMetadata(sub.Metadata, params.Metadata)
+	}
+	if params.Description != nil {
+		sub.Description = *params.Description
+	}
+
+	if params.CancelAtPeriodEnd != nil {
+		sub.CancelAtPeriodEnd = *params.CancelAtPeriodEnd
+		if sub.CancelAtPeriodEnd {
+			sub.CanceledAt = time.Now().Unix()
+			sub.CancelAt = sub.Items.Data[0].CurrentPeriodEnd
+		} else {
+			sub.CanceledAt = 0
+			sub.CancelAt = 0
+		}
+	}
+
+	copied := *sub
+	return &copied, nil
+}
+
+func (m *mockClient) CancelSubscription(id string) (*stripe.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub := m.getOrCreateSubscriptionLocked(id)
+	if sub.Status == stripe.SubscriptionStatusCanceled {
+		return nil, fmt.Errorf("subscription %s is canceled", id)
+	}
+	sub.Status = stripe.SubscriptionStatusCanceled
+	sub.CanceledAt = time.Now().Unix()
+	sub.EndedAt = sub.CanceledAt
+
+	copied := *sub
+	return &copied, nil
+}
+
+func (m *mockClient) GetPrice(id string) (*stripe.Price, error) {
+	plan := id[strings.LastIndex(id, "_")+1:]
+	amount, ok := mockPlanAmounts[plan]
+	if !strings.HasPrefix(id, "price_mock_") || !ok {
+		return nil, fmt.Errorf("unknown mock price %s", id)
+	}
+	return &stripe.Price{
+		ID:         id,
+		Currency:   stripe.CurrencyUSD,
+		UnitAmount: amount,
+	}, nil
+}
+
+// PreviewInvoice bills the subscription's price for the next period. Subscriptions whose
+// price isn't known (created before an API restart) are previewed as free.
+func (m *mockClient) PreviewInvoice(params *stripe.InvoiceCreatePreviewParams) (*stripe.Invoice, error) {
+	if params.Subscription == nil {
+		return nil, fmt.Errorf("mock invoice preview requires a subscription")
+	}
+
+	m.mu.Lock()
+	sub := m.getOrCreateSubscriptionLocked(*params.Subscription)
+	item := *sub.Items.Data[0]
+	m.mu.Unlock()
+
+	inv := &stripe.Invoice{Currency: stripe.CurrencyUSD}
+	if item.Price != nil {
+		p, err := m.GetPrice(item.Price.ID)
+		if err != nil {
+			return nil, err
+		}
+		inv.AmountDue = p.UnitAmount
+		inv.Total = p.UnitAmount
+	}
+	return inv, nil
+}
+
+// ListInvoices returns one invoice per subscription of the customer, for its current period.
+// Lines carry the subscription's metadata like Stripe's do.
+func (m *mockClient) ListInvoices(customerID string, limit int64) ([]*stripe.Invoice, error) {
+	m.mu.Lock()
+	var subs []stripe.Subscription
+	for _, sub := range m.subscriptions {
+		if sub.Customer != nil && sub.Customer.ID == customerID {
+			subs = append(subs, *sub)
+		}
+	}
+	m.mu.Unlock()
+
+	var invoices []*stripe.Invoice
+	for _, sub := range subs {
+		if int64(len(invoices)) >= limit {
+			break
+		}
+		item := sub.Items.Data[0]
+		var amount int64
+		if item.Price != nil {
+			if p, err := m.GetPrice(item.Price.ID); err == nil {
+				amount = p.UnitAmount
+			}
+		}
+		invoices = append(invoices, &stripe.Invoice{
+			ID:         mockID("in_mock_"),
+			Status:     stripe.InvoiceStatusPaid,
+			Currency:   stripe.CurrencyUSD,
+			Total:      amount,
+			AmountPaid: amount,
+			Created:    item.CurrentPeriodStart,
+			Lines: &stripe.InvoiceLineItemList{
+				Data: []*stripe.InvoiceLineItem{{
+					ID:          mockID("il_mock_"),
+					Amount:      amount,
+					Currency:    stripe.CurrencyUSD,
+					Description: sub.Description,
+					Metadata:    mergeMetadata(nil, sub.Metadata),
+					Period:      &stripe.Period{Start: item.CurrentPeriodStart, End: item.CurrentPeriodEnd},
+					Parent: &stripe.InvoiceLineItemParent{
+						Type: stripe.InvoiceLineItemParentTypeSubscriptionItemDetails,
+						SubscriptionItemDetails: &stripe.InvoiceLineItemParentSubscriptionItemDetails{
+							Subscription:     sub.ID,
+							SubscriptionItem: item.ID,
+						},
+					},
+				}},
+			},
+		})
+	}
+	return invoices, nil
+}
+
+// NewSubscription charges the customer's default card immediately. Mock cards never decline.
+// Like Stripe, a repeated idempotency key returns the subscription created first.
+func (m *mockClient) NewSubscription(params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+	if params.Customer == nil || params.DefaultPaymentMethod == nil {
+		return nil, fmt.Errorf("mock subscription requires a customer and payment method")
+	}
+	if len(params.Items) == 0 || params.Items[0].Price == nil {
+		return nil, fmt.Errorf("mock subscription requires a price")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.getOrCreateCustomerLocked(*params.Customer)
+
+	if params.IdempotencyKey != nil {
+		if id, ok := m.idempotent[*params.IdempotencyKey]; ok {
+			copied := *m.subscriptions[id]
+			return &copied, nil
+		}
+	}
+
+	sub := m.getOrCreateSubscriptionLocked(mockID("sub_mock_"))
+	if params.IdempotencyKey != nil {
+		m.idempotent[*params.IdempotencyKey] = sub.ID
+	}
+	sub.Customer = &stripe.Customer{ID: *params.Customer}
+	sub.Items.Data[0].Price = &stripe.Price{ID: *params.Items[0].Price}
+	sub.Metadata = params.Metadata
+
+	copied := *sub
+	return &copied, nil
+}
+
+// GetCustomer returns a mock customer. Unknown IDs are treated as customers with a saved
+// card so users who paid before an API restart can still pay with it.
+func (m *mockClient) GetCustomer(id string) (*stripe.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cus := m.getOrCreateCustomerLocked(id)
+	copied := *cus
+	return &copied, nil
+}
+
+func (m *mockClient) NewCustomer(params *stripe.CustomerParams) (*stripe.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cus := m.getOrCreateCustomerLocked(mockID("cus_mock_"))
+	cus.Created = time.Now().Unix()
+	if params.Email != nil {
+		cus.Email = *params.Email
+	}
+	cus.Metadata = params.Metadata
+
+	copied := *cus
+	return &copied, nil
+}
+
+func (m *mockClient) UpdateCustomer(id string, params *stripe.CustomerParams) (*stripe.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cus := m.getOrCreateCustomerLocked(id)
+	if params.Email != nil {
+		cus.Email = *params.Email
+	}
+
+	copied := *cus
+	return &copied, nil
+}
+
+func (m *mockClient) ListCustomersByEmail(email string) ([]*stripe.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var customers []*stripe.Customer
+	for _, cus := range m.customers {
+		if cus.Email == email {
+			copied := *cus
+			customers = append(customers, &copied)
+		}
+	}
+	return customers, nil
+}
+
+func (m *mockClient) ListCards(customerID string) ([]*stripe.PaymentMethod, error) {
+	cus, err := m.GetCustomer(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return []*stripe.PaymentMethod{cus.InvoiceSettings.DefaultPaymentMethod}, nil
+}
+
+// NewPortalSession returns straight to the return URL; there's no mock customer portal
+func (m *mockClient) NewPortalSession(params *stripe.BillingPortalSessionParams) (*stripe.BillingPortalSession, error) {
+	sess := &stripe.BillingPortalSession{ID: mockID("bps_mock_"), URL: m.frontendURL}
+	if params.ReturnURL != nil {
+		sess.URL = *params.ReturnURL
+	}
+	return sess, nil
+}
+
+// SubscriptionIDForPaymentIntent always returns "": mock subscriptions aren't paid through
+// payment intents, so mock disputes name the subscription directly (DisputeMockSubscription)
+func (m *mockClient) SubscriptionIDForPaymentIntent(paymentIntentID string) (string, error) {
+	return "", nil
+}
+
+func (m *mockClient) Ping() error {
+	return nil
+}
+
+// completeSession marks a session as paid and attaches a new active subscription
+func (m *mockClient) completeSession(id string) (*stripe.CheckoutSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sess, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrMockSessionNotFound
+	}
+
+	if sess.Customer == nil {
+		// Paying through checkout saves the card on a new customer
+		cus := m.getOrCreateCustomerLocked(mockID("cus_mock_"))
+		sess.Customer = &stripe.Customer{ID: cus.ID}
+	}
+	if sess.Subscription == nil {
+		sub := m.getOrCreateSubscriptionLocked(mockID("sub_mock_"))
+		sub.Customer = &stripe.Customer{ID: sess.Customer.ID}
+		if sess.LineItems != nil && len(sess.LineItems.Data) > 0 {
+			sub.Items.Data[0].Price = sess.LineItems.Data[0].Price
+		}
+		sess.Subscription = &stripe.Subscription{ID: sub.ID}
+	}
+	sess.Status = stripe.CheckoutSessionStatusComplete
+	sess.PaymentStatus = stripe.CheckoutSessionPaymentStatusPaid
+
+	copied := *sess
+	return &copied, nil
+}
+
+// endSubscription marks a subscription as canceled, as Stripe does when the period ends
+func (m *mockClient) endSubscription(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub := m.getOrCreateSubscriptionLocked(id)
+	sub.Status = stripe.SubscriptionStatusCanceled
+	sub.EndedAt = time.Now().Unix()
+}
+
+// mergeMetadata returns a copy of metadata with updates applied. Like Stripe, keys updated
+// to "" are removed.
+func mergeMetadata(metadata, updates map[string]string) map[string]string {
+	merged := make(map[string]string, len(metadata)+len(updates))
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	for k, v := range updates {
+		if v == "" {
+			delete(merged, k)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func (m *mockClient) getOrCreateSubscriptionLocked(id string) *stripe.Subscription {
+	if sub, ok := m.subscriptions[id]; ok {
+		return sub
+	}
+
+	now := time.Now()
+	sub := &stripe.Subscription{
+		ID:     id,
+		Status: stripe.SubscriptionStatusActive,
+		Items: &stripe.SubscriptionItemList{
+			Data: []*stripe.SubscriptionItem{
+				{
+					ID:                 mockID("si_mock_"),
+					CurrentPeriodStart: now.Unix(),
+					CurrentPeriodEnd:   now.Add(mockBillingPeriod).Unix(),
+				},
+			},
+		},
+	}
+	m.subscriptions[id] = sub
+	return sub
+}
+
+func (m *mockClient) getOrCreateCustomerLocked(id string) *stripe.Customer {
+	if cus, ok := m.customers[id]; ok {
+		return cus
+	}
+
+	cus := &stripe.Customer{
+		ID: id,
+		InvoiceSettings: &stripe.CustomerInvoiceSettings{
+			DefaultPaymentMethod: &stripe.PaymentMethod{
+				ID:   mockID("pm_mock_"),
+				Type: stripe.PaymentMethodTypeCard,
+				Card: &stripe.PaymentMethodCard{
+					Brand:    stripe.PaymentMethodCardBrandVisa,
+					Last4:    "4242",
+					ExpMonth: 12,
+					ExpYear:  int64(time.Now().Year() + 3),
+				},
+			},
+		},
+	}
+	m.customers[id] = cus
+	return cus
+}
+
+// IsMockMode reports whether the service simulates Stripe locally
+func (s *Service) IsMockMode() bool {
+	return s.mock != nil
+}
+
+// Health checks that the Stripe API is reachable. Always healthy in mock mode.
+func (s *Service) Health() error {
+	return s.client.Ping()
+}
+
+// CompleteMockCheckout simulates a successful payment for a mock checkout session
+// and runs the same processing as a checkout.session.completed webhook.
+func (s *Service) CompleteMockCheckout(ctx context.Context, sessionID string) (*stripe.CheckoutSession, error) {
+	if s.mock == nil {
+		return nil, ErrMockModeDisabled
+	}
+
+	sess, err := s.mock.completeSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventID := mockID("evt_mock_")
+	log.Printf("Completing mock checkout session: event_id=%s session_id=%s", eventID, sess.ID)
+
+	if err := s.processCheckoutSession(ctx, eventID, sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// EndMockSubscription simulates the end of a subscription's billing period and
+// runs the same processing as a customer.subscription.deleted webhook.
+func (s *Service) EndMockSubscription(ctx context.Context, subscriptionID string) error {
+	if s.mock == nil {
+		return ErrMockModeDisabled
+	}
+
+	s.mock.endSubscription(subscriptionID)
+
+	eventID := mockID("evt_mock_")
+	log.Printf("Ending mock subscription: event_id=%s subscription_id=%s", eventID, subscriptionID)
+
+	return s.expireSubscription(ctx, eventID, subscriptionID)
+}
+
+// DisputeMockSubscription simulates a chargeback on a subscription's payment and runs
+// the same processing as a charge.dispute.created webhook.
+func (s *Service) DisputeMockSubscription(ctx context.Context, subscriptionID string) error {
+	if s.mock == nil {
+		return ErrMockModeDisabled
+	}
+
+	var amount int64
+	if sub, err := s.mock.GetSubscription(subscriptionID); err == nil && sub.Items.Data[0].Price != nil {
+		amount = sub.Items.Data[0].Price.UnitAmount
+	}
+	dispute := &stripe.Dispute{
+		ID:       mockID("dp_mock_"),
+		Amount:   amount,
+		Currency: stripe.CurrencyUSD,
+		Reason:   stripe.DisputeReasonFraudulent,
+	}
+
+	eventID := mockID("evt_mock_")
+	log.Printf("Disputing mock subscription: event_id=%s subscription_id=%s", eventID, subscriptionID)
+
+	return s.processDispute(ctx, eventID, dispute, subscriptionID)
+}
@@ -0,0 +1,180 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stripe/stripe-go/v84"
+)
+
+// ErrSavedCardUnavailable means the user has no saved card, or charging it failed or needs
+// their interaction (e.g. 3D Secure). Callers fall back to a Checkout session.
+var ErrSavedCardUnavailable = errors.New("saved card unavailable")
+
+// SavedCard returns the card a user would be charged with when paying without Checkout:
+// their customer's default payment method, or else their most recently saved card.
+// Returns nil if the user has none.
+func (s *Service) SavedCard(ctx context.Context, user *models.User) (*stripe.PaymentMethod, error) {
+	if user.StripeCustomerID == nil || *user.StripeCustomerID == "" {
+		return nil, nil
+	}
+
+	cus, err := s.client.GetCustomer(*user.StripeCustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve customer: %w", err)
+	}
+	if cus.InvoiceSettings != nil && cus.InvoiceSettings.DefaultPaymentMethod != nil &&
+		cus.InvoiceSettings.DefaultPaymentMethod.Card != nil {
+		return cus.InvoiceSettings.DefaultPaymentMethod, nil
+	}
+
+	cards, err := s.client.ListCards(*user.StripeCustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved cards: %w", err)
+	}
+	if len(cards) == 0 {
+		return nil, nil
+	}
+	return cards[0], nil
+}
+
+// CreatePortalSession returns a short-lived link to the Stripe customer portal, where the
+// user can update their saved card. Users who never paid have no customer and get
+// ErrSavedCardUnavailable.
+func (s *Service) CreatePortalSession(ctx context.Context, user *models.User) (string, error) {
+	if user.StripeCustomerID == nil || *user.StripeCustomerID == "" {
+		return "", ErrSavedCardUnavailable
+	}
+
+	sess, err := s.client.NewPortalSession(&stripe.BillingPortalSessionParams{
+		Customer:  user.StripeCustomerID,
+		ReturnURL: stripe.String(s.config.FrontendURL + "/settings/billing"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create portal session: %w", err)
+	}
+	return sess.URL, nil
+}
+
+// SubscribeWithSavedCard pays for a pending server request with the user's saved card and
+// creates the server right away, without redirecting to Checkout. Paying twice for the same
+// request creates one subscription and returns its server.
+func (s *Service) SubscribeWithSavedCard(ctx context.Context, user *models.User, pendingRequestID uuid.UUID, priceID string) (*models.Server, error) {
+	sub, err := s.subscribeWithSavedCard(ctx, user, priceID, "subscribe-"+pendingRequestID.String(), map[string]string{
+		"pending_request_id": pendingRequestID.String(),
+		"user_id":            user.ID.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	eventID := "direct_" + sub.ID
+	server, err := s.createServerFromPendingRequest(ctx, eventID, pendingRequestID, sub.ID)
+	if err != nil {
+		s.cancelUnusedSubscription(sub.ID)
+		return nil, err
+	}
+	if server == nil {
+		// Already completed: by this subscription on a double submit, or else by another payment
+		server, err = s.db.GetServerByStripeSubscriptionID(ctx, sub.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.cancelUnusedSubscription(sub.ID)
+			return nil, fmt.Errorf("pending request %s was already completed", pendingRequestID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return server, nil
+}
+
+// ResubscribeWithSavedCard pays for an expired server with the user's saved card and
+// reactivates it right away, without redirecting to Checkout. Paying twice for the same
+// expiry creates one subscription.
+func (s *Service) ResubscribeWithSavedCard(ctx context.Context, user *models.User, server *models.Server, priceID string) error {
+	// A server can expire more than once, and each expiry is paid for separately
+	key := "resubscribe-" + server.ID.String()
+	if server.ExpiredAt != nil {
+		key += "-" + strconv.FormatInt(server.ExpiredAt.Unix(), 10)
+	}
+	sub, err := s.subscribeWithSavedCard(ctx, user, priceID, key, map[string]string{
+		"resubscribe_server_id": server.ID.String(),
+		"user_id":               user.ID.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.ReactivateServer(ctx, server.ID.String(), sub.ID); err != nil {
+		// A double submit finds the server already reactivated with this subscription
+		if current, getErr := s.db.GetServerByID(ctx, server.ID.String()); getErr == nil &&
+			current.StripeSubscriptionID != nil && *current.StripeSubscriptionID == sub.ID {
+			return nil
+		}
+		s.cancelUnusedSubscription(sub.ID)
+		return fmt.Errorf("failed to reactivate server: %w", err)
+	}
+
+	log.Printf("Server reactivated with saved card: server_id=%s subscription_id=%s", server.ID, sub.ID)
+
+	s.stopFileAccess(ctx, "direct_"+sub.ID, server.ID.String())
+	s.tagReactivatedSubscription(ctx, "direct_"+sub.ID, server.ID.String(), sub.ID)
+	return nil
+}
+
+// cancelUnusedSubscription cancels a subscription paid for a server that couldn't be created
+// or reactivated, so the user isn't billed for nothing. Failures are logged for manual refunds.
+func (s *Service) cancelUnusedSubscription(subscriptionID string) {
+	if _, err := s.client.CancelSubscription(subscriptionID); err != nil {
+		log.Printf("failed to cancel unused subscription %s: %v", subscriptionID, err)
+		return
+	}
+	log.Printf("Canceled unused subscription: subscription_id=%s", subscriptionID)
+}
+
+// subscribeWithSavedCard creates a subscription charged to the user's saved card. The first
+// invoice must be paid immediately; if it can't be, no subscription is left behind and
+// ErrSavedCardUnavailable is returned. Retries with the same idempotency key return the
+// subscription created first instead of charging again.
+func (s *Service) subscribeWithSavedCard(ctx context.Context, user *models.User, priceID, idempotencyKey string, metadata map[string]string) (*stripe.Subscription, error) {
+	card, err := s.SavedCard(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return nil, ErrSavedCardUnavailable
+	}
+
+	params := &stripe.SubscriptionParams{
+		Customer:             user.StripeCustomerID,
+		DefaultPaymentMethod: stripe.String(card.ID),
+		Items: []*stripe.SubscriptionItemsParams{
+			{
+				Price:    stripe.String(priceID),
+				Quantity: stripe.Int64(1),
+			},
+		},
+		PaymentBehavior: stripe.String("error_if_incomplete"),
+		Metadata:        metadata,
+	}
+	params.IdempotencyKey = stripe.String(idempotencyKey)
+
+	sub, err := s.client.NewSubscription(params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Type == stripe.ErrorTypeCard {
+			log.Printf("Saved card declined: user_id=%s code=%s", user.ID, stripeErr.Code)
+			return nil, ErrSavedCardUnavailable
+		}
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	log.Printf("Subscription created with saved card: user_id=%s subscription_id=%s", user.ID, sub.ID)
+	return sub, nil
+}
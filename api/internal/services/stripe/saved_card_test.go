@@ -0,0 +1,170 @@
+package stripe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v84"
+)
+
+// newMockService returns a service backed by the in-memory Stripe mock, without a database
+func newMockService() *Service {
+	mock := newMockClient("http://localhost:3000")
+	return &Service{
+		config: &config.Config{FrontendURL: "http://localhost:3000"},
+		client: mock,
+		mock:   mock,
+	}
+}
+
+func TestSubscribeWithSavedCard(t *testing.T) {
+	tests := []struct {
+		name       string
+		customerID *string
+		wantErr    error
+	}{
+		{"no customer", nil, ErrSavedCardUnavailable},
+		{"empty customer", stripe.String(""), ErrSavedCardUnavailable},
+		{"customer with a card", stripe.String("cus_test"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newMockService()
+			user := &models.User{ID: uuid.New(), StripeCustomerID: tt.customerID}
+
+			sub, err := s.subscribeWithSavedCard(context.Background(), user, "price_mock_minecraft_small", "key-1", map[string]string{"user_id": user.ID.String()})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, sub)
+				assert.Empty(t, s.mock.subscriptions, "no subscription is created")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, *tt.customerID, sub.Customer.ID)
+			assert.Equal(t, "price_mock_minecraft_small", sub.Items.Data[0].Price.ID)
+			assert.Equal(t, user.ID.String(), sub.Metadata["user_id"])
+		})
+	}
+}
+
+func TestSubscribeWithSavedCard_Idempotent(t *testing.T) {
+	tests := []struct {
+		name     string
+		firstKey string
+		retryKey string
+		wantSame bool
+	}{
+		{"double submit of the same request", "subscribe-a", "subscribe-a", true},
+		{"different requests", "subscribe-a", "subscribe-b", false},
+		{"resubscribe after each expiry", "resubscribe-srv-100", "resubscribe-srv-200", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newMockService()
+			user := &models.User{ID: uuid.New(), StripeCustomerID: stripe.String("cus_test")}
+
+			first, err := s.subscribeWithSavedCard(context.Background(), user, "price_mock_minecraft_small", tt.firstKey, nil)
+			require.NoError(t, err)
+			retry, err := s.subscribeWithSavedCard(context.Background(), user, "price_mock_minecraft_small", tt.retryKey, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantSame, first.ID == retry.ID)
+			wantSubs := 2
+			if tt.wantSame {
+				wantSubs = 1
+			}
+			assert.Len(t, s.mock.subscriptions, wantSubs)
+		})
+	}
+}
+
+func TestCancelUnusedSubscription(t *testing.T) {
+	s := newMockService()
+	user := &models.User{ID: uuid.New(), StripeCustomerID: stripe.String("cus_test")}
+
+	sub, err := s.subscribeWithSavedCard(context.Background(), user, "price_mock_minecraft_small", "subscribe-a", nil)
+	require.NoError(t, err)
+
+	s.cancelUnusedSubscription(sub.ID)
+
+	canceled, err := s.client.GetSubscription(sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stripe.SubscriptionStatusCanceled, canceled.Status)
+
+	// Canceling twice only logs
+	s.cancelUnusedSubscription(sub.ID)
+}
+
+func TestChangeSubscriptionPrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []string // Prices to change to, in order
+		cancel  bool     // Cancel the subscription first
+		want    string
+		wantErr bool
+	}{
+		{"upgrade", []string{"price_mock_minecraft_medium"}, false, "price_mock_minecraft_medium", false},
+		{"upgrade reverted", []string{"price_mock_minecraft_medium", "price_mock_minecraft_small"}, false, "price_mock_minecraft_small", false},
+		{"canceled subscription", []string{"price_mock_minecraft_medium"}, true, "price_mock_minecraft_small", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newMockService()
+			user := &models.User{ID: uuid.New(), StripeCustomerID: stripe.String("cus_test")}
+			sub, err := s.subscribeWithSavedCard(context.Background(), user, "price_mock_minecraft_small", "subscribe-a", nil)
+			require.NoError(t, err)
+			if tt.cancel {
+				_, err := s.client.CancelSubscription(sub.ID)
+				require.NoError(t, err)
+			}
+
+			for _, price := range tt.steps {
+				_, err = s.ChangeSubscriptionPrice(context.Background(), sub.ID, price)
+				if tt.wantErr {
+					assert.Error(t, err)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+
+			current, err := s.client.GetSubscription(sub.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, current.Items.Data[0].Price.ID)
+			assert.Equal(t, sub.Items.Data[0].ID, current.Items.Data[0].ID, "the existing item is updated")
+		})
+	}
+}
+
+func TestSavedCard(t *testing.T) {
+	tests := []struct {
+		name       string
+		customerID *string
+		wantCard   bool
+	}{
+		{"no customer", nil, false},
+		{"empty customer", stripe.String(""), false},
+		{"customer with a default card", stripe.String("cus_test"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newMockService()
+			card, err := s.SavedCard(context.Background(), &models.User{ID: uuid.New(), StripeCustomerID: tt.customerID})
+			require.NoError(t, err)
+			if !tt.wantCard {
+				assert.Nil(t, card)
+				return
+			}
+			require.NotNil(t, card)
+			assert.Equal(t, "4242", card.Card.Last4)
+		})
+	}
+}
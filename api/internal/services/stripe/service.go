@@ -6,16 +6,20 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mooncorn/gshub/api/config"
 	"github.com/mooncorn/gshub/api/internal/database"
 	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/account"
+	"github.com/mooncorn/gshub/api/internal/services/email"
 	"github.com/mooncorn/gshub/api/internal/services/k8s"
 	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+	"github.com/mooncorn/gshub/api/internal/services/scheduler"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"github.com/mooncorn/gshub/api/internal/services/suspension"
 	"github.com/stripe/stripe-go/v84"
-	"github.com/stripe/stripe-go/v84/checkout/session"
-	"github.com/stripe/stripe-go/v84/subscription"
 	"github.com/stripe/stripe-go/v84/webhook"
 )
 
@@ -24,7 +28,13 @@ type Service struct {
 	config           *config.Config
 	k8sClient        *k8s.Client
 	portAllocService *portalloc.Service
+	machine          *serverstate.Machine
 	k8sNamespace     string
+	suspension       *suspension.Service
+	account          *account.Service
+	email            *email.Service
+	client           client
+	mock             *mockClient // non-nil when STRIPE_MOCK_MODE is enabled
 }
 
 // WebhookError represents an error that occurred during webhook processing
@@ -59,25 +69,45 @@ var (
 	ErrMissingEventData  = NewWebhookError(http.StatusBadRequest, "missing or invalid event data", nil)
 )
 
-func NewService(db *database.DB, cfg *config.Config, k8sClient *k8s.Client, portAllocService *portalloc.Service, k8sNamespace string) *Service {
-	stripe.Key = cfg.StripeSecretKey
-	return &Service{
+// NewService creates the Stripe service. accountService flags repeat dispute offenders.
+func NewService(db *database.DB, cfg *config.Config, k8sClient *k8s.Client, portAllocService *portalloc.Service, machine *serverstate.Machine, accountService *account.Service, k8sNamespace string) *Service {
+	svc := &Service{
 		db:               db,
 		config:           cfg,
 		k8sClient:        k8sClient,
 		portAllocService: portAllocService,
+		machine:          machine,
 		k8sNamespace:     k8sNamespace,
+		suspension:       suspension.NewService(db, k8sClient, portAllocService, k8sNamespace),
+		account:          accountService,
+		email:            email.NewService(cfg),
 	}
+
+	if cfg.StripeMockMode {
+		log.Printf("Stripe mock mode enabled: checkout and subscriptions are simulated locally")
+		svc.mock = newMockClient(cfg.FrontendURL)
+		svc.client = svc.mock
+	} else {
+		stripe.Key = cfg.StripeSecretKey
+		svc.client = liveClient{}
+	}
+
+	return svc
 }
 
 // CreateCheckoutSession creates a Stripe Checkout Session with pending request metadata
-func (s *Service) CreateCheckoutSession(ctx context.Context, userID uuid.UUID, pendingRequestID uuid.UUID, priceID string, email string) (string, string, error) {
+func (s *Service) CreateCheckoutSession(ctx context.Context, user *models.User, pendingRequestID uuid.UUID, priceID string) (string, string, error) {
+	customerID, err := s.EnsureCustomer(ctx, user)
+	if err != nil {
+		return "", "", err
+	}
+
 	// Create checkout session parameters
 	params := &stripe.CheckoutSessionParams{
-		Mode:          stripe.String(string(stripe.CheckoutSessionModeSubscription)),
-		SuccessURL:    stripe.String(s.config.FrontendURL + "/"),
-		CancelURL:     stripe.String(s.config.FrontendURL + "/servers/new"),
-		CustomerEmail: stripe.String(email),
+		Customer:   stripe.String(customerID),
+		Mode:       stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		SuccessURL: stripe.String(s.config.FrontendURL + "/"),
+		CancelURL:  stripe.String(s.config.FrontendURL + "/servers/new"),
 		LineItems: []*stripe.CheckoutSessionLineItemParams{
 			{
 				Price:    stripe.String(priceID),
@@ -86,11 +116,11 @@ func (s *Service) CreateCheckoutSession(ctx context.Context, userID uuid.UUID, p
 		},
 		Metadata: map[string]string{
 			"pending_request_id": pendingRequestID.String(),
-			"user_id":            userID.String(),
+			"user_id":            user.ID.String(),
 		},
 	}
 
-	sess, err := session.New(params)
+	sess, err := s.client.NewCheckoutSession(params)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create checkout session: %w", err)
 	}
@@ -100,7 +130,7 @@ func (s *Service) CreateCheckoutSession(ctx context.Context, userID uuid.UUID, p
 
 // RetrieveCheckoutSession retrieves a Stripe checkout session by ID
 func (s *Service) RetrieveCheckoutSession(ctx context.Context, sessionID string) (*stripe.CheckoutSession, error) {
-	sess, err := session.Get(sessionID, &stripe.CheckoutSessionParams{})
+	sess, err := s.client.GetCheckoutSession(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve checkout session: %w", err)
 	}
@@ -135,6 +165,8 @@ func (s *Service) HandleStripeEvent(ctx context.Context, event *stripe.Event) er
 		return s.handleSubscriptionUpdated(ctx, event)
 	case "customer.subscription.deleted":
 		return s.handleSubscriptionDeleted(ctx, event)
+	case "charge.dispute.created":
+		return s.handleDisputeCreated(ctx, event)
 	default:
 		// Log unknown event type but don't fail
 		log.Printf("Received unhandled Stripe event type: event_id=%s event_type=%s", event.ID, event.Type)
@@ -152,14 +184,36 @@ func (s *Service) handleCheckoutSessionCompleted(ctx context.Context, event *str
 
 	log.Printf("Processing checkout session: event_id=%s session_id=%s", event.ID, sess.ID)
 
+	return s.processCheckoutSession(ctx, event.ID, &sess)
+}
+
+// processCheckoutSession routes a paid checkout session to resubscription or new server creation
+func (s *Service) processCheckoutSession(ctx context.Context, eventID string, sess *stripe.CheckoutSession) error {
+	s.recordCustomer(ctx, eventID, sess)
+
 	// Check if this is a resubscription
 	if resubscribeServerID, ok := sess.Metadata["resubscribe_server_id"]; ok {
-		log.Printf("Processing resubscription: event_id=%s server_id=%s", event.ID, resubscribeServerID)
-		return s.handleResubscribeCheckout(ctx, event.ID, &sess, resubscribeServerID)
+		log.Printf("Processing resubscription: event_id=%s server_id=%s", eventID, resubscribeServerID)
+		return s.handleResubscribeCheckout(ctx, eventID, sess, resubscribeServerID)
 	}
 
 	// Handle new server creation
-	return s.CompleteCheckoutSession(ctx, event.ID, &sess)
+	return s.CompleteCheckoutSession(ctx, eventID, sess)
+}
+
+// recordCustomer remembers the customer a checkout was paid through, for sessions created
+// before customers were assigned up front. Failing to record it is logged, not fatal.
+func (s *Service) recordCustomer(ctx context.Context, eventID string, sess *stripe.CheckoutSession) {
+	if sess.Customer == nil || sess.Customer.ID == "" {
+		return
+	}
+	userID, err := uuid.Parse(sess.Metadata["user_id"])
+	if err != nil {
+		return
+	}
+	if _, err := s.db.SetUserStripeCustomerID(ctx, userID, sess.Customer.ID); err != nil {
+		log.Printf("Failed to record Stripe customer: event_id=%s user_id=%s error=%v", eventID, userID, err)
+	}
 }
 
 // handleSubscriptionUpdated is the internal handler for customer.subscription.updated events
@@ -181,9 +235,30 @@ func (s *Service) handleSubscriptionUpdated(ctx context.Context, event *stripe.E
 	// Log status change but don't act on subscription.updated alone
 	// The actual action happens when subscription.deleted is received
 	log.Printf("Subscription status change: event_id=%s server_id=%s subscription_id=%s status=%s", event.ID, server.ID, sub.ID, sub.Status)
+
+	// Capacity checks estimate when resources free up from subscriptions set to end
+	if err := s.db.SetServerCancelAt(ctx, server.ID.String(), subscriptionCancelAt(&sub)); err != nil {
+		return err
+	}
 	return nil
 }
 
+// subscriptionCancelAt returns when a subscription is set to end, or nil if it renews
+func subscriptionCancelAt(sub *stripe.Subscription) *time.Time {
+	var at int64
+	switch {
+	case sub.CancelAt > 0:
+		at = sub.CancelAt
+	case sub.CancelAtPeriodEnd && sub.Items != nil && len(sub.Items.Data) > 0:
+		at = sub.Items.Data[0].CurrentPeriodEnd
+	}
+	if at == 0 {
+		return nil
+	}
+	t := time.Unix(at, 0)
+	return &t
+}
+
 // handleSubscriptionDeleted is the internal handler for customer.subscription.deleted events
 func (s *Service) handleSubscriptionDeleted(ctx context.Context, event *stripe.Event) error {
 	var sub stripe.Subscription
@@ -193,61 +268,161 @@ func (s *Service) handleSubscriptionDeleted(ctx context.Context, event *stripe.E
 
 	log.Printf("Processing subscription deletion: event_id=%s subscription_id=%s", event.ID, sub.ID)
 
+	return s.expireSubscription(ctx, event.ID, sub.ID)
+}
+
+// expireSubscription expires the server tied to an ended subscription and frees its resources
+func (s *Service) expireSubscription(ctx context.Context, eventID string, subscriptionID string) error {
 	// Find server by subscription ID
-	server, err := s.db.GetServerByStripeSubscriptionID(ctx, sub.ID)
+	server, err := s.db.GetServerByStripeSubscriptionID(ctx, subscriptionID)
 	if err != nil {
-		log.Printf("Failed to find server for subscription deletion: event_id=%s subscription_id=%s error=%v", event.ID, sub.ID, err)
+		log.Printf("Failed to find server for subscription deletion: event_id=%s subscription_id=%s error=%v", eventID, subscriptionID, err)
 		return nil // Don't fail webhook if server not found; it may have been created before we stored subscription IDs
 	}
 
-	serverID := server.ID.String()
+	expired, err := s.expireServer(ctx, eventID, server, []models.ServerStatus{
+		models.ServerStatusPending,
+		models.ServerStatusStarting,
+		models.ServerStatusRunning,
+		models.ServerStatusStopping,
+		models.ServerStatusStopped,
+		models.ServerStatusSuspended,
+	}, "Subscription cancelled")
+	if err != nil {
+		return err
+	}
+	if !expired {
+		// Server was already expired/failed/deleted - that's fine
+		log.Printf("Server already in terminal state: event_id=%s server_id=%s status=%s", eventID, server.ID, server.Status)
+		return nil
+	}
 
-	// 1. Atomically transition to expired from any active state
-	// This prevents race conditions with concurrent stop/start operations
-	transitioned, err := s.db.TransitionServerStatusFrom(ctx, serverID,
-		[]models.ServerStatus{
+	log.Printf("Server marked as expired: event_id=%s server_id=%s subscription_id=%s delete_after=+7days", eventID, server.ID, subscriptionID)
+	return nil
+}
+
+// DeleteServer handles a user deleting their server: the server expires like an ended
+// subscription, keeping its data for the 7-day grace period during which it can be
+// restored by resubscribing, and only then is the subscription cancelled immediately.
+// Returns false if the server was already expired or being deleted. Deleting an expired
+// server again retries cancelling its subscription, in case that failed the first time.
+func (s *Service) DeleteServer(ctx context.Context, server *models.Server) (bool, error) {
+	if server.Status == models.ServerStatusExpired {
+		return false, s.cancelDeletedServerSubscription(server)
+	}
+
+	expired, err := s.expireServer(ctx, "user_delete", server, []models.ServerStatus{
+		models.ServerStatusPending,
+		models.ServerStatusStarting,
+		models.ServerStatusRunning,
+		models.ServerStatusStopping,
+		models.ServerStatusStopped,
+		models.ServerStatusFailed,
+	}, "Server deleted")
+	if err != nil || !expired {
+		return false, err
+	}
+	if err := s.cancelDeletedServerSubscription(server); err != nil {
+		return false, err
+	}
+
+	log.Printf("Server deleted by user: server_id=%s delete_after=+7days", server.ID)
+	return true, nil
+}
+
+// ForceDeleteServer deletes a server at an operator's request, skipping the grace period:
+// the server expires from any status but deleting and deleted, suspended included, its
+// subscription is cancelled, and the cleanup service removes its data on its next run.
+// Returns false if the server is already being deleted. An expired server only has its
+// subscription cancelled and its deletion brought forward, so failures can be retried.
+func (s *Service) ForceDeleteServer(ctx context.Context, server *models.Server) (bool, error) {
+	if server.Status != models.ServerStatusExpired {
+		expired, err := s.expireServer(ctx, "admin_delete", server, []models.ServerStatus{
 			models.ServerStatusPending,
 			models.ServerStatusStarting,
 			models.ServerStatusRunning,
 			models.ServerStatusStopping,
 			models.ServerStatusStopped,
-		},
-		models.ServerStatusExpired,
-		"Subscription cancelled",
-	)
+			models.ServerStatusFailed,
+			models.ServerStatusSuspended,
+		}, "Server deleted by an administrator")
+		if err != nil || !expired {
+			return false, err
+		}
+	}
+
+	if err := s.cancelDeletedServerSubscription(server); err != nil {
+		return false, err
+	}
+	if err := s.db.ExpediteServerDeletion(ctx, server.ID.String()); err != nil {
+		return false, err
+	}
+	log.Printf("Server force deleted by admin: server_id=%s", server.ID)
+	return true, nil
+}
+
+// cancelDeletedServerSubscription cancels a deleted server's subscription immediately, if
+// it has one that isn't cancelled yet
+func (s *Service) cancelDeletedServerSubscription(server *models.Server) error {
+	if server.StripeSubscriptionID == nil || *server.StripeSubscriptionID == "" {
+		return nil
+	}
+	sub, err := s.client.GetSubscription(*server.StripeSubscriptionID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve subscription: %w", err)
+	}
+	if sub.Status != stripe.SubscriptionStatusCanceled {
+		if _, err := s.client.CancelSubscription(*server.StripeSubscriptionID); err != nil {
+			return fmt.Errorf("failed to cancel subscription: %w", err)
+		}
+		log.Printf("Cancelled subscription for deleted server: server_id=%s subscription_id=%s", server.ID, *server.StripeSubscriptionID)
+	}
+	return nil
+}
+
+// expireServer moves a server from one of fromStatuses to expired, starting the 7-day
+// grace period, and frees its Deployment and ports. Returns false if the server was
+// in none of fromStatuses. eventID identifies the triggering event in logs.
+func (s *Service) expireServer(ctx context.Context, eventID string, server *models.Server, fromStatuses []models.ServerStatus, message string) (bool, error) {
+	serverID := server.ID.String()
+
+	// 1. Atomically transition to expired from any active state
+	// This prevents race conditions with concurrent stop/start operations
+	transitioned, err := s.machine.Transition(ctx, server, serverstate.Request{
+		From:    fromStatuses,
+		To:      models.ServerStatusExpired,
+		Message: message,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to transition server to expired: event_id=%s server_id=%s error=%w", event.ID, serverID, err)
+		return false, fmt.Errorf("failed to transition server to expired: event_id=%s server_id=%s error=%w", eventID, serverID, err)
 	}
 
 	if !transitioned {
-		// Server was already expired/failed/deleted - that's fine
-		log.Printf("Server already in terminal state: event_id=%s server_id=%s status=%s", event.ID, serverID, server.Status)
-		return nil
+		return false, nil
 	}
 
 	// 2. Set expiration metadata (timestamps, clear resource reservations)
 	if err := s.db.MarkServerExpired(ctx, serverID); err != nil {
-		log.Printf("Failed to set expiration metadata: event_id=%s server_id=%s error=%v", event.ID, serverID, err)
+		log.Printf("Failed to set expiration metadata: event_id=%s server_id=%s error=%v", eventID, serverID, err)
 		// Continue - status is already expired, timestamps are secondary
 	}
 
 	// 3. Delete Deployment from K8s (idempotent - may not exist if stopped)
 	deployName := "server-" + serverID
-	if err := s.k8sClient.DeleteGameDeployment(ctx, s.k8sNamespace, deployName); err != nil {
-		log.Printf("Failed to delete Deployment (may not exist): event_id=%s server_id=%s error=%v", event.ID, serverID, err)
+	if err := s.k8sClient.DeleteGameDeployment(ctx, server.K8sNamespace(s.k8sNamespace), deployName); err != nil {
+		log.Printf("Failed to delete Deployment (may not exist): event_id=%s server_id=%s error=%v", eventID, serverID, err)
 	} else {
-		log.Printf("Deleted Deployment: event_id=%s server_id=%s", event.ID, serverID)
+		log.Printf("Deleted Deployment: event_id=%s server_id=%s", eventID, serverID)
 	}
 
 	// 4. Release port allocations (idempotent - may not be allocated)
-	if err := s.portAllocService.ReleasePorts(ctx, server.ID); err != nil {
-		log.Printf("Failed to release ports: event_id=%s server_id=%s error=%v", event.ID, serverID, err)
+	if err := s.portAllocService.ReleasePorts(ctx, server.ID, models.PortCauseExpiry, ""); err != nil {
+		log.Printf("Failed to release ports: event_id=%s server_id=%s error=%v", eventID, serverID, err)
 	} else {
-		log.Printf("Released ports: event_id=%s server_id=%s", event.ID, serverID)
+		log.Printf("Released ports: event_id=%s server_id=%s", eventID, serverID)
 	}
 
-	log.Printf("Server marked as expired: event_id=%s server_id=%s subscription_id=%s delete_after=+7days", event.ID, server.ID, sub.ID)
-	return nil
+	return true, nil
 }
 
 // CompleteCheckoutSession completes a checkout session and creates the associated server
@@ -279,10 +454,17 @@ func (s *Service) CompleteCheckoutSession(ctx context.Context, eventID string, s
 	subscriptionID := sess.Subscription.ID
 	log.Printf("Checkout session subscription: event_id=%s session_id=%s subscription_id=%s", eventID, sess.ID, subscriptionID)
 
+	_, err = s.createServerFromPendingRequest(ctx, eventID, pendingRequestID, subscriptionID)
+	return err
+}
+
+// createServerFromPendingRequest creates the server a pending request paid for and marks the
+// request completed. Returns nil if the request was already processed.
+func (s *Service) createServerFromPendingRequest(ctx context.Context, eventID string, pendingRequestID uuid.UUID, subscriptionID string) (*models.Server, error) {
 	// Start transaction to ensure atomicity of all operations
 	tx, err := s.db.Pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
@@ -292,13 +474,13 @@ func (s *Service) CompleteCheckoutSession(ctx context.Context, eventID string, s
 	// Retrieve pending server request within transaction
 	pendingReq, err := txDB.GetPendingServerRequest(ctx, pendingRequestID)
 	if err != nil {
-		return fmt.Errorf("failed to get pending server request: %w", err)
+		return nil, fmt.Errorf("failed to get pending server request: %w", err)
 	}
 
 	// Check if already processed
 	if pendingReq.Status != models.PendingStatusAwaitingPayment {
 		log.Printf("Pending request already processed: event_id=%s pending_request_id=%s status=%s", eventID, pendingRequestID, pendingReq.Status)
-		return nil // Idempotent: return success if already processed
+		return nil, nil // Idempotent: return success if already processed
 	}
 
 	// Create the server from pending request
@@ -309,31 +491,96 @@ func (s *Service) CompleteCheckoutSession(ctx context.Context, eventID string, s
 		Game:                 models.GameType(pendingReq.Game),
 		Plan:                 models.ServerPlan(pendingReq.Plan),
 		StripeSubscriptionID: &subscriptionID,
+		Namespace:            s.config.ServerNamespace(pendingReq.Plan),
+		PreferredPort:        pendingReq.PreferredPort,
 	}
 
 	createdServer, err := txDB.CreateServer(ctx, serverParams)
 	if err != nil {
-		return fmt.Errorf("failed to create server: %w", err)
+		return nil, fmt.Errorf("failed to create server: %w", err)
+	}
+
+	// Servers created from a template start with its env overrides
+	if len(pendingReq.EnvOverrides) > 0 {
+		if err := txDB.UpdateServerEnvOverrides(ctx, createdServer.ID.String(), pendingReq.EnvOverrides); err != nil {
+			return nil, fmt.Errorf("failed to set server env overrides: %w", err)
+		}
+		createdServer.EnvOverrides = pendingReq.EnvOverrides
+	}
+
+	if pendingReq.CustomGame != nil {
+		if err := txDB.SetServerCustomGame(ctx, createdServer.ID.String(), pendingReq.CustomGame); err != nil {
+			return nil, fmt.Errorf("failed to set server custom game: %w", err)
+		}
+	}
+
+	// Clones start with a copy of their source's data
+	if pendingReq.CloneSourceID != nil {
+		if err := txDB.CreateServerClone(ctx, createdServer.ID, *pendingReq.CloneSourceID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Servers created from a template start with its scheduled tasks; its mods are installed
+	// from the server once it runs
+	if err := createTemplateSchedules(ctx, txDB, createdServer.ID, pendingReq.TemplateSchedules); err != nil {
+		return nil, err
+	}
+	if len(pendingReq.TemplateMods) > 0 {
+		if err := txDB.AddServerPendingMods(ctx, createdServer.ID, pendingReq.TemplateMods); err != nil {
+			return nil, err
+		}
 	}
 
 	// Mark pending request as completed with server ID
 	err = txDB.MarkPendingServerRequestCompleted(ctx, pendingRequestID, createdServer.ID)
 	if err != nil {
-		return fmt.Errorf("failed to mark pending request as completed: %w", err)
+		return nil, fmt.Errorf("failed to mark pending request as completed: %w", err)
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	log.Printf("Server created successfully: event_id=%s server_id=%s pending_request_id=%s", eventID, createdServer.ID, pendingRequestID)
+
+	s.tagSubscription(ctx, eventID, createdServer, subscriptionID)
+	return createdServer, nil
+}
+
+// createTemplateSchedules adds a template's scheduled tasks to a server created from it.
+// Tasks that can no longer be planned, e.g. because the minimum interval was raised since the
+// template was saved, are skipped.
+func createTemplateSchedules(ctx context.Context, txDB *database.DB, serverID uuid.UUID, schedules []models.TemplateSchedule) error {
+	now := time.Now()
+	for _, t := range schedules {
+		nextRunAt, err := scheduler.Plan(t.Cron, t.Timezone, now)
+		if err != nil {
+			log.Printf("skipping template schedule %q for server %s: %v", t.Name, serverID, err)
+			continue
+		}
+
+		_, err = txDB.CreateSchedule(ctx, &models.ServerSchedule{
+			ServerID:  serverID,
+			Name:      t.Name,
+			Cron:      t.Cron,
+			Timezone:  t.Timezone,
+			Action:    t.Action,
+			Payload:   t.Payload,
+			Enabled:   t.Enabled,
+			NextRunAt: nextRunAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // GetSubscription retrieves subscription details from Stripe
 func (s *Service) GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
-	sub, err := subscription.Get(subscriptionID, nil)
+	sub, err := s.client.GetSubscription(subscriptionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve subscription: %w", err)
 	}
@@ -345,7 +592,7 @@ func (s *Service) CancelSubscriptionAtPeriodEnd(ctx context.Context, subscriptio
 	params := &stripe.SubscriptionParams{
 		CancelAtPeriodEnd: stripe.Bool(true),
 	}
-	sub, err := subscription.Update(subscriptionID, params)
+	sub, err := s.client.UpdateSubscription(subscriptionID, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to cancel subscription: %w", err)
 	}
@@ -357,20 +604,80 @@ func (s *Service) ResumeSubscription(ctx context.Context, subscriptionID string)
 	params := &stripe.SubscriptionParams{
 		CancelAtPeriodEnd: stripe.Bool(false),
 	}
-	sub, err := subscription.Update(subscriptionID, params)
+	sub, err := s.client.UpdateSubscription(subscriptionID, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resume subscription: %w", err)
 	}
 	return sub, nil
 }
 
+// GetPrice retrieves a Stripe price by ID
+func (s *Service) GetPrice(ctx context.Context, priceID string) (*stripe.Price, error) {
+	p, err := s.client.GetPrice(priceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve price: %w", err)
+	}
+	return p, nil
+}
+
+// UpcomingCharge returns what a subscription will be charged at its next renewal, including
+// metered usage, in the smallest currency unit. Subscriptions that won't renew cost nothing.
+func (s *Service) UpcomingCharge(ctx context.Context, subscriptionID string) (int64, string, error) {
+	sub, err := s.client.GetSubscription(subscriptionID)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to retrieve subscription: %w", err)
+	}
+	if sub.Status == stripe.SubscriptionStatusCanceled || sub.CancelAtPeriodEnd {
+		return 0, "", nil
+	}
+
+	inv, err := s.client.PreviewInvoice(&stripe.InvoiceCreatePreviewParams{
+		Subscription: stripe.String(subscriptionID),
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to preview upcoming invoice: %w", err)
+	}
+	return inv.AmountDue, string(inv.Currency), nil
+}
+
+// ChangeSubscriptionPrice moves a subscription to a different price, prorating the difference
+func (s *Service) ChangeSubscriptionPrice(ctx context.Context, subscriptionID string, priceID string) (*stripe.Subscription, error) {
+	sub, err := s.client.GetSubscription(subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve subscription: %w", err)
+	}
+	if sub.Items == nil || len(sub.Items.Data) == 0 {
+		return nil, fmt.Errorf("subscription %s has no items", subscriptionID)
+	}
+
+	params := &stripe.SubscriptionParams{
+		Items: []*stripe.SubscriptionItemsParams{
+			{
+				ID:    stripe.String(sub.Items.Data[0].ID),
+				Price: stripe.String(priceID),
+			},
+		},
+		ProrationBehavior: stripe.String("create_prorations"),
+	}
+	updated, err := s.client.UpdateSubscription(subscriptionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to change subscription price: %w", err)
+	}
+	return updated, nil
+}
+
 // CreateResubscribeCheckoutSession creates a new checkout session for resubscribing an expired server
-func (s *Service) CreateResubscribeCheckoutSession(ctx context.Context, serverID uuid.UUID, userID uuid.UUID, priceID string, email string) (string, string, error) {
+func (s *Service) CreateResubscribeCheckoutSession(ctx context.Context, serverID uuid.UUID, user *models.User, priceID string) (string, string, error) {
+	customerID, err := s.EnsureCustomer(ctx, user)
+	if err != nil {
+		return "", "", err
+	}
+
 	params := &stripe.CheckoutSessionParams{
-		Mode:          stripe.String(string(stripe.CheckoutSessionModeSubscription)),
-		SuccessURL:    stripe.String(s.config.FrontendURL + "/settings/billing?resubscribed=true"),
-		CancelURL:     stripe.String(s.config.FrontendURL + "/settings/billing"),
-		CustomerEmail: stripe.String(email),
+		Customer:   stripe.String(customerID),
+		Mode:       stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		SuccessURL: stripe.String(s.config.FrontendURL + "/settings/billing?resubscribed=true"),
+		CancelURL:  stripe.String(s.config.FrontendURL + "/settings/billing"),
 		LineItems: []*stripe.CheckoutSessionLineItemParams{
 			{
 				Price:    stripe.String(priceID),
@@ -379,11 +686,11 @@ func (s *Service) CreateResubscribeCheckoutSession(ctx context.Context, serverID
 		},
 		Metadata: map[string]string{
 			"resubscribe_server_id": serverID.String(),
-			"user_id":               userID.String(),
+			"user_id":               user.ID.String(),
 		},
 	}
 
-	sess, err := session.New(params)
+	sess, err := s.client.NewCheckoutSession(params)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create resubscribe checkout session: %w", err)
 	}
@@ -414,5 +721,21 @@ func (s *Service) handleResubscribeCheckout(ctx context.Context, eventID string,
 	}
 
 	log.Printf("Server reactivated: event_id=%s server_id=%s subscription_id=%s", eventID, serverID, subscriptionID)
+
+	s.stopFileAccess(ctx, eventID, serverID.String())
+	s.tagReactivatedSubscription(ctx, eventID, serverID.String(), subscriptionID)
 	return nil
 }
+
+// stopFileAccess deletes the file access pod of a reactivated server, if the owner had one
+// open, so it doesn't hold the data volume the game server needs
+func (s *Service) stopFileAccess(ctx context.Context, eventID string, serverID string) {
+	server, err := s.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		log.Printf("Failed to get server to stop file access: event_id=%s server_id=%s error=%v", eventID, serverID, err)
+		return
+	}
+	if err := s.k8sClient.DeleteFileAccessPods(ctx, server.K8sNamespace(s.k8sNamespace), serverID); err != nil {
+		log.Printf("Failed to delete file access pod: event_id=%s server_id=%s error=%v", eventID, serverID, err)
+	}
+}
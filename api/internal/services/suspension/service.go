@@ -0,0 +1,75 @@
+package suspension
+
+import (
+	"context"
+	"log"
+
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+)
+
+// Service suspends servers pending admin review and lifts suspensions. A suspended server's
+// pod is deleted and its ports released like an expired server's, but its data is kept and
+// it can't be started again until an admin lifts the suspension.
+type Service struct {
+	db               *database.DB
+	k8sClient        *k8s.Client
+	portAllocService *portalloc.Service
+	k8sNamespace     string
+}
+
+// NewService creates a new suspension service
+func NewService(db *database.DB, k8sClient *k8s.Client, portAllocService *portalloc.Service, k8sNamespace string) *Service {
+	return &Service{
+		db:               db,
+		k8sClient:        k8sClient,
+		portAllocService: portAllocService,
+		k8sNamespace:     k8sNamespace,
+	}
+}
+
+// Suspend moves a server to suspended and tears down its pod. Returns false if the server
+// was already suspended, expired or being deleted.
+func (s *Service) Suspend(ctx context.Context, server *models.Server, reason models.StatusReason, message string) (bool, error) {
+	serverID := server.ID.String()
+
+	suspended, err := s.db.SuspendServer(ctx, serverID, reason, message)
+	if err != nil || !suspended {
+		return false, err
+	}
+
+	// Delete Deployment from K8s (idempotent - may not exist if stopped)
+	deployName := "server-" + serverID
+	if err := s.k8sClient.DeleteGameDeployment(ctx, server.K8sNamespace(s.k8sNamespace), deployName); err != nil {
+		log.Printf("Failed to delete Deployment of suspended server (may not exist): server_id=%s error=%v", serverID, err)
+	}
+
+	// Release port allocations (idempotent - may not be allocated)
+	if err := s.portAllocService.ReleasePorts(ctx, server.ID, models.PortCauseSuspension, string(reason)); err != nil {
+		log.Printf("Failed to release ports of suspended server: server_id=%s error=%v", serverID, err)
+	}
+
+	log.Printf("Server suspended: server_id=%s reason=%s", serverID, reason)
+	return true, nil
+}
+
+// Lift returns a suspended server to stopped so its owner can start it again, and marks
+// its disputes and abuse reports resolved. Returns false if the server isn't suspended.
+func (s *Service) Lift(ctx context.Context, serverID string) (bool, error) {
+	lifted, err := s.db.LiftServerSuspension(ctx, serverID)
+	if err != nil || !lifted {
+		return false, err
+	}
+
+	if err := s.db.ResolveServerDisputes(ctx, serverID); err != nil {
+		return true, err
+	}
+	if err := s.db.ResolveServerAbuseReports(ctx, serverID); err != nil {
+		return true, err
+	}
+
+	log.Printf("Server suspension lifted: server_id=%s", serverID)
+	return true, nil
+}
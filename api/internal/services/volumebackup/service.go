@@ -0,0 +1,295 @@
+package volumebackup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/objectstore"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Config holds configuration for the volume backup service
+type Config struct {
+	// Interval is how often backups and restores are started and checked (default: 15 seconds)
+	Interval time.Duration
+	// JobTimeout bounds one backup or restore pod (default: 2 hours)
+	JobTimeout time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:   15 * time.Second,
+		JobTimeout: 2 * time.Hour,
+	}
+}
+
+// Service takes and restores snapshots of servers' whole data volumes. Each runs in a pod
+// of its own (the supervisor image in volume job mode) that moves the archive to or from
+// the backup bucket through a presigned URL. Backups run while the server is stopped;
+// restores run when it's next started, before the reconciler creates its deployment.
+type Service struct {
+	db        *database.DB
+	k8sClient *k8s.Client
+	store     *objectstore.Client
+	machine   *serverstate.Machine
+	cfg       *config.Config
+	config    Config
+	logger    *zap.Logger
+	runner    *periodic.Runner
+}
+
+// NewService creates a new volume backup service
+func NewService(db *database.DB, k8sClient *k8s.Client, store *objectstore.Client, machine *serverstate.Machine, cfg *config.Config, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:        db,
+		k8sClient: k8sClient,
+		store:     store,
+		machine:   machine,
+		cfg:       cfg,
+		config:    config,
+		logger:    logger,
+	}
+	s.runner = periodic.New("volume backup", config.Interval, s.runJobs, logger)
+	return s
+}
+
+// ObjectKey returns where a server's volume backup is stored in the bucket
+func ObjectKey(serverID string, backupID uuid.UUID) string {
+	return fmt.Sprintf("servers/%s/volume/%s.tar.gz", serverID, backupID)
+}
+
+// Start begins the volume backup service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx)
+}
+
+// Stop stops the volume backup service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// runJobs advances every volume backup that is being taken, restored or removed
+func (s *Service) runJobs(ctx context.Context) {
+	backups, err := s.db.ListVolumeBackupWork(ctx)
+	if err != nil {
+		s.logger.Error("failed to list volume backup work", zap.Error(err))
+		return
+	}
+
+	// Restores wait for the server's backups, which need the volume as it was
+	backingUp := map[uuid.UUID]bool{}
+	for _, backup := range backups {
+		if backup.Active() {
+			backingUp[backup.ServerID] = true
+		}
+	}
+
+	for _, backup := range backups {
+		var err error
+		switch {
+		case backup.State == models.VolumeBackupDeleting:
+			err = s.remove(ctx, backup)
+		case backup.Active():
+			err = s.backUp(ctx, backup)
+		case backup.RestoreRequestedAt != nil && !backingUp[backup.ServerID]:
+			err = s.restore(ctx, backup)
+		}
+		if err != nil {
+			s.logger.Warn("failed to handle volume backup",
+				zap.String("server_id", backup.ServerID.String()),
+				zap.String("backup_id", backup.ID.String()),
+				zap.String("state", string(backup.State)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// backUp starts a backup's pod once no game pod uses the volume, and records its result
+// once it exits
+func (s *Service) backUp(ctx context.Context, backup models.VolumeBackup) error {
+	serverID := backup.ServerID.String()
+	server, err := s.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	namespace := server.K8sNamespace(s.cfg.K8sNamespace)
+	podName := "volume-backup-" + backup.ID.String()
+
+	pod, err := s.k8sClient.GetPod(ctx, namespace, podName)
+	if err != nil {
+		return err
+	}
+
+	if pod == nil {
+		if backup.State == models.VolumeBackupRunning {
+			return s.db.FailVolumeBackup(ctx, backup.ID, "backup pod disappeared")
+		}
+		// Starts wait for the backup, so a server that isn't stopped was stopped no longer
+		// (e.g. expired and deleted) by the time the backup got its turn
+		if server.Status != models.ServerStatusStopped && server.Status != models.ServerStatusPending {
+			return s.db.FailVolumeBackup(ctx, backup.ID, fmt.Sprintf("server is %s", server.Status))
+		}
+		if running, err := s.gameRunning(ctx, server); err != nil || running {
+			return err // The game's pod is still shutting down
+		}
+
+		url, err := s.store.PresignURL(http.MethodPut, backup.ObjectKey, s.config.JobTimeout)
+		if err != nil {
+			return err
+		}
+		if _, err := s.k8sClient.CreateVolumeJobPod(ctx, s.jobParams(server, podName, k8s.VolumeJobBackup, url)); err != nil {
+			return err
+		}
+		s.logger.Info("backing up volume", zap.String("server_id", serverID), zap.String("backup_id", backup.ID.String()))
+		return s.db.MarkVolumeBackupRunning(ctx, backup.ID)
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		size, err := strconv.ParseInt(strings.TrimSpace(k8s.TerminationMessage(pod)), 10, 64)
+		if err != nil {
+			err = s.db.FailVolumeBackup(ctx, backup.ID, "backup pod reported no archive size")
+		} else {
+			err = s.db.CompleteVolumeBackup(ctx, backup.ID, size)
+			s.logger.Info("backed up volume",
+				zap.String("server_id", serverID),
+				zap.String("backup_id", backup.ID.String()),
+				zap.Int64("bytes", size),
+			)
+		}
+		if err != nil {
+			return err
+		}
+		return s.k8sClient.DeletePod(ctx, namespace, podName)
+	case corev1.PodFailed:
+		if err := s.db.FailVolumeBackup(ctx, backup.ID, k8s.VolumeJobError(pod)); err != nil {
+			return err
+		}
+		return s.k8sClient.DeletePod(ctx, namespace, podName)
+	default:
+		if backup.State == models.VolumeBackupPending {
+			return s.db.MarkVolumeBackupRunning(ctx, backup.ID)
+		}
+		return nil
+	}
+}
+
+// restore starts a restore's pod once the server is started again, and records its result
+// once it exits. The reconciler creates the server's deployment after that; a failed
+// restore fails the start.
+func (s *Service) restore(ctx context.Context, backup models.VolumeBackup) error {
+	serverID := backup.ServerID.String()
+	server, err := s.db.GetServerByID(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	namespace := server.K8sNamespace(s.cfg.K8sNamespace)
+	podName := "volume-restore-" + backup.ID.String()
+
+	pod, err := s.k8sClient.GetPod(ctx, namespace, podName)
+	if err != nil {
+		return err
+	}
+
+	if pod == nil {
+		if server.Status != models.ServerStatusPending {
+			return nil // Restored on the next start
+		}
+		if running, err := s.gameRunning(ctx, server); err != nil || running {
+			return err
+		}
+
+		url, err := s.store.PresignURL(http.MethodGet, backup.ObjectKey, s.config.JobTimeout)
+		if err != nil {
+			return err
+		}
+		if _, err := s.k8sClient.CreateVolumeJobPod(ctx, s.jobParams(server, podName, k8s.VolumeJobRestore, url)); err != nil {
+			return err
+		}
+		s.logger.Info("restoring volume", zap.String("server_id", serverID), zap.String("backup_id", backup.ID.String()))
+		return nil
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		if err := s.db.FinishVolumeRestore(ctx, backup.ID); err != nil {
+			return err
+		}
+		s.logger.Info("restored volume", zap.String("server_id", serverID), zap.String("backup_id", backup.ID.String()))
+		return s.k8sClient.DeletePod(ctx, namespace, podName)
+	case corev1.PodFailed:
+		if err := s.db.FinishVolumeRestore(ctx, backup.ID); err != nil {
+			return err
+		}
+		message := k8s.VolumeJobError(pod)
+		s.logger.Warn("failed to restore volume",
+			zap.String("server_id", serverID),
+			zap.String("backup_id", backup.ID.String()),
+			zap.String("error", message),
+		)
+		if _, err := s.machine.Transition(ctx, server, serverstate.Request{
+			From:    []models.ServerStatus{models.ServerStatusPending},
+			To:      models.ServerStatusFailed,
+			Message: "Restoring the backup failed: " + message,
+			Reason:  models.StatusReasonRestoreFailed,
+		}); err != nil {
+			return err
+		}
+		return s.k8sClient.DeletePod(ctx, namespace, podName)
+	default:
+		return nil
+	}
+}
+
+// remove deletes a backup from the bucket and forgets it. Its pod is stopped first, in
+// case the server was deleted while it ran.
+func (s *Service) remove(ctx context.Context, backup models.VolumeBackup) error {
+	if server, err := s.db.GetServerByID(ctx, backup.ServerID.String()); err == nil {
+		namespace := server.K8sNamespace(s.cfg.K8sNamespace)
+		if err := s.k8sClient.DeletePod(ctx, namespace, "volume-backup-"+backup.ID.String()); err != nil {
+			return err
+		}
+	}
+	if err := s.store.Delete(ctx, backup.ObjectKey); err != nil {
+		return err
+	}
+	return s.db.DeleteVolumeBackup(ctx, backup.ID)
+}
+
+// gameRunning reports whether any of the server's game pods still exist, e.g. while one
+// is terminating after a stop
+func (s *Service) gameRunning(ctx context.Context, server *models.Server) (bool, error) {
+	pods, err := s.k8sClient.ListPodsByLabel(ctx, server.K8sNamespace(s.cfg.K8sNamespace), "server="+server.ID.String())
+	if err != nil {
+		return false, err
+	}
+	return len(pods) > 0, nil
+}
+
+func (s *Service) jobParams(server *models.Server, podName string, job k8s.VolumeJob, url string) k8s.VolumeJobParams {
+	serverID := server.ID.String()
+	return k8s.VolumeJobParams{
+		Namespace: server.K8sNamespace(s.cfg.K8sNamespace),
+		Name:      podName,
+		ServerID:  serverID,
+		Image:     s.cfg.FileAccessImage,
+		PVCName:   "server-" + serverID,
+		Job:       job,
+		URL:       url,
+		Timeout:   s.config.JobTimeout,
+	}
+}
@@ -0,0 +1,159 @@
+package waitlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/mooncorn/gshub/api/config"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/email"
+	"github.com/mooncorn/gshub/api/internal/services/k8s"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"github.com/mooncorn/gshub/api/internal/services/portalloc"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for the waitlist service
+type Config struct {
+	// Interval is how often capacity is checked for waiting users, e.g. after nodes are
+	// added (default: 5 minutes)
+	Interval time.Duration
+	// OfferTTL is how long an offered user has to check out before the next user in line is
+	// offered the capacity instead (default: 2 hours)
+	OfferTTL time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval: 5 * time.Minute,
+		OfferTTL: 2 * time.Hour,
+	}
+}
+
+// Service offers capacity to users on the capacity waitlist once their game and plan pass
+// checkout's capacity check again (in their region, if they chose one). Each game, plan and
+// region's users are offered it one at a time, in the order they signed up: the offered user
+// gets a checkout link that works until the offer expires, checkouts by anyone else for the
+// game and plan are turned away meanwhile, and the next user is offered it once they check
+// out or the offer expires.
+type Service struct {
+	db               *database.DB
+	k8sClient        *k8s.Client
+	portAllocService *portalloc.Service
+	email            *email.Service
+	cfg              *config.Config
+	config           Config
+	logger           *zap.Logger
+	runner           *periodic.Runner
+}
+
+// NewService creates a new waitlist service
+func NewService(db *database.DB, k8sClient *k8s.Client, portAllocService *portalloc.Service, emailService *email.Service, cfg *config.Config, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:               db,
+		k8sClient:        k8sClient,
+		portAllocService: portAllocService,
+		email:            emailService,
+		cfg:              cfg,
+		config:           config,
+		logger:           logger,
+	}
+	s.runner = periodic.New("waitlist", config.Interval, s.offer, logger)
+	return s
+}
+
+// Start begins the waitlist service
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx, zap.Duration("offer_ttl", s.config.OfferTTL))
+}
+
+// Stop stops the waitlist service
+func (s *Service) Stop() {
+	s.runner.Stop()
+}
+
+// CapacityFreed is a state machine hook that checks the waitlist without waiting for the
+// next tick once a server expires and releases its ports and resources
+func (s *Service) CapacityFreed(_ context.Context, change serverstate.Change) {
+	if change.To != models.ServerStatusExpired {
+		return
+	}
+	s.runner.Wake()
+}
+
+// offer offers capacity to the next user in line for each game, plan and region that has
+// some and no outstanding offer
+func (s *Service) offer(ctx context.Context) {
+	signups, err := s.db.ListNextWaitlistSignups(ctx)
+	if err != nil {
+		s.logger.Error("failed to list waitlist signups", zap.Error(err))
+		return
+	}
+	if len(signups) == 0 {
+		return
+	}
+
+	catalog, err := s.k8sClient.LoadGameCatalog(ctx, s.cfg.K8sNamespace, s.cfg.K8sGameCatalogName)
+	if err != nil {
+		s.logger.Error("failed to load game catalog", zap.Error(err))
+		return
+	}
+
+	// Capacity offered this tick isn't taken yet, so each game and plan gets one offer per
+	// tick even if several regions are waiting for it
+	offered := map[string]bool{}
+	for _, signup := range signups {
+		key := signup.Game + "/" + signup.Plan
+		if offered[key] || !s.hasCapacity(ctx, catalog, signup.Game, signup.Plan, signup.Region) {
+			continue
+		}
+
+		expiresAt := time.Now().Add(s.config.OfferTTL)
+		ok, err := s.db.OfferWaitlistSignup(ctx, signup.ID, expiresAt)
+		if err != nil {
+			s.logger.Error("failed to offer waitlist signup", zap.String("signup_id", signup.ID.String()), zap.Error(err))
+			continue
+		}
+		if !ok {
+			continue // Left the waitlist meanwhile
+		}
+		offered[key] = true
+
+		if err := s.email.SendCapacityAvailableEmail(signup.Email, signup.ID.String(), signup.Game, signup.Plan, expiresAt); err != nil {
+			s.logger.Error("failed to send capacity available email", zap.String("signup_id", signup.ID.String()), zap.Error(err))
+		}
+		s.logger.Info("offered capacity to waitlist signup",
+			zap.String("signup_id", signup.ID.String()),
+			zap.String("game", signup.Game),
+			zap.String("plan", signup.Plan),
+			zap.String("region", signup.Region),
+		)
+	}
+}
+
+// hasCapacity runs checkout's capacity check for a game and plan, counting only nodes in
+// region if it's set
+func (s *Service) hasCapacity(ctx context.Context, catalog *k8s.GameCatalog, game, plan, region string) bool {
+	gameConfig, err := catalog.GetGameConfig(game)
+	if err != nil {
+		s.logger.Warn("waitlist game not in catalog", zap.String("game", game), zap.Error(err))
+		return false
+	}
+	planConfig, err := gameConfig.GetPlanConfig(plan)
+	if err != nil {
+		s.logger.Warn("waitlist plan not in catalog", zap.String("game", game), zap.String("plan", plan), zap.Error(err))
+		return false
+	}
+
+	portReqs, resourceReq := portalloc.Requirements(gameConfig, planConfig, plan, s.cfg.ServerNamespaces(plan))
+	resourceReq.Region = region
+	ok, err := s.portAllocService.HasCapacity(ctx, portReqs, resourceReq)
+	if err != nil {
+		s.logger.Error("failed to check capacity", zap.String("game", game), zap.String("plan", plan), zap.Error(err))
+		return false
+	}
+	return ok
+}
@@ -0,0 +1,333 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mooncorn/gshub/api/internal/database"
+	"github.com/mooncorn/gshub/api/internal/models"
+	"github.com/mooncorn/gshub/api/internal/services/periodic"
+	"github.com/mooncorn/gshub/api/internal/services/serverstate"
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for the webhook delivery service
+type Config struct {
+	// Interval is how often due deliveries are sent (default: 5 seconds)
+	Interval time.Duration
+	// BatchSize is how many deliveries are claimed per run (default: 50)
+	BatchSize int
+	// Concurrency is how many deliveries are sent at once (default: 8)
+	Concurrency int
+	// Timeout bounds each delivery request (default: 10 seconds)
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is tried before it fails (default: 6)
+	MaxAttempts int
+	// Retention is how long finished deliveries are kept for the delivery log (default: 30 days)
+	Retention time.Duration
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:    5 * time.Second,
+		BatchSize:   50,
+		Concurrency: 8,
+		Timeout:     10 * time.Second,
+		MaxAttempts: 6,
+		Retention:   30 * 24 * time.Hour,
+	}
+}
+
+// retryBackoff is the wait after each failed attempt; the last entry repeats
+var retryBackoff = []time.Duration{
+	1 * time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+// Payload is the JSON body POSTed to user webhooks
+type Payload struct {
+	ID        string              `json:"id"` // Unique per event; a retried delivery keeps it
+	Event     models.WebhookEvent `json:"event"`
+	ServerID  string              `json:"server_id"`
+	Timestamp time.Time           `json:"timestamp"`
+	Data      any                 `json:"data"`
+}
+
+// Service queues payloads for the webhooks users registered on their servers and delivers
+// them, signed with each webhook's secret, retrying failures with backoff
+type Service struct {
+	db     *database.DB
+	config Config
+	client *http.Client
+	logger *zap.Logger
+	runner *periodic.Runner
+	pruner *periodic.Runner
+}
+
+// NewService creates a new webhook delivery service
+func NewService(db *database.DB, config Config, logger *zap.Logger) *Service {
+	s := &Service{
+		db:     db,
+		config: config,
+		client: newClient(config.Timeout),
+		logger: logger,
+	}
+	s.runner = periodic.New("webhook", config.Interval, s.deliverDue, logger)
+	s.pruner = periodic.New("webhook prune", time.Hour, s.prune, logger)
+	return s
+}
+
+// Start begins delivering queued payloads
+func (s *Service) Start(ctx context.Context) {
+	s.runner.Start(ctx, zap.Int("max_attempts", s.config.MaxAttempts))
+	s.pruner.Start(ctx)
+}
+
+// Stop stops the webhook service
+func (s *Service) Stop() {
+	s.runner.Stop()
+	s.pruner.Stop()
+}
+
+// Enqueue queues an event for every webhook of the server subscribed to it
+func (s *Service) Enqueue(ctx context.Context, serverID string, event models.WebhookEvent, data any) {
+	body, err := json.Marshal(Payload{
+		ID:        uuid.NewString(),
+		Event:     event,
+		ServerID:  serverID,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		s.logger.Error("failed to marshal webhook payload", zap.String("event", string(event)), zap.Error(err))
+		return
+	}
+
+	if _, err := s.db.EnqueueWebhookDeliveries(ctx, serverID, event, body); err != nil {
+		s.logger.Error("failed to enqueue webhook deliveries",
+			zap.String("server_id", serverID),
+			zap.String("event", string(event)),
+			zap.Error(err))
+	}
+}
+
+// OnTransition is a state machine hook that queues status change and crash events
+func (s *Service) OnTransition(ctx context.Context, change serverstate.Change) {
+	serverID := change.Server.ID.String()
+	data := map[string]any{
+		"from":    change.From,
+		"to":      change.To,
+		"message": change.Message,
+	}
+	if change.Reason != "" {
+		data["reason"] = change.Reason
+	}
+
+	s.Enqueue(ctx, serverID, models.WebhookServerStatusChanged, data)
+	if change.To == models.ServerStatusFailed {
+		s.Enqueue(ctx, serverID, models.WebhookServerCrashed, data)
+	}
+}
+
+// BackupFinished queues a backup completed or failed event
+func (s *Service) BackupFinished(ctx context.Context, serverID string, commandID uuid.UUID, success bool, result string) {
+	event := models.WebhookBackupCompleted
+	if !success {
+		event = models.WebhookBackupFailed
+	}
+	s.Enqueue(ctx, serverID, event, map[string]any{
+		"command_id": commandID,
+		"result":     result,
+	})
+}
+
+// deliverDue sends every delivery whose next attempt is due
+func (s *Service) deliverDue(ctx context.Context) {
+	// The lease covers the slowest batch, so claimed deliveries aren't claimed again mid-send
+	lease := s.config.Timeout * time.Duration(s.config.BatchSize/s.config.Concurrency+1)
+	deliveries, err := s.db.ClaimDueWebhookDeliveries(ctx, s.config.BatchSize, lease)
+	if err != nil {
+		s.logger.Error("failed to claim webhook deliveries", zap.Error(err))
+		return
+	}
+
+	sem := make(chan struct{}, s.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, d := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.deliver(ctx, d)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver sends one delivery and records the outcome, scheduling a retry on failure
+func (s *Service) deliver(ctx context.Context, d database.DueWebhookDelivery) {
+	status, err := s.send(ctx, d)
+	delivered := err == nil
+
+	var nextAttempt *time.Time
+	errMsg := ""
+	if !delivered {
+		errMsg = err.Error()
+		attempts := d.Attempts + 1
+		if attempts < s.config.MaxAttempts {
+			next := time.Now().Add(retryBackoff[min(attempts, len(retryBackoff))-1])
+			nextAttempt = &next
+		}
+		s.logger.Info("webhook delivery failed",
+			zap.String("delivery_id", d.ID.String()),
+			zap.String("webhook_id", d.WebhookID.String()),
+			zap.Int("attempt", attempts),
+			zap.Bool("retrying", nextAttempt != nil),
+			zap.Error(err))
+	}
+
+	if err := s.db.RecordWebhookAttempt(ctx, d.ID, delivered, status, errMsg, nextAttempt); err != nil {
+		s.logger.Error("failed to record webhook attempt", zap.String("delivery_id", d.ID.String()), zap.Error(err))
+	}
+}
+
+// send POSTs a delivery's payload. Returns the response status, if any, and an error
+// unless the webhook answered 2xx.
+func (s *Service) send(ctx context.Context, d database.DueWebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gshub-webhooks/1")
+	req.Header.Set("X-GSHub-Event", string(d.Event))
+	req.Header.Set("X-GSHub-Delivery", d.ID.String())
+	req.Header.Set("X-GSHub-Signature", "t="+timestamp+",v1="+Sign(d.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// prune removes finished deliveries older than the retention period
+func (s *Service) prune(ctx context.Context) {
+	deleted, err := s.db.DeleteOldWebhookDeliveries(ctx, time.Now().Add(-s.config.Retention))
+	if err != nil {
+		s.logger.Error("failed to prune webhook deliveries", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("pruned webhook deliveries", zap.Int64("deleted", deleted))
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+// Receivers recompute it from the X-GSHub-Signature timestamp and the raw body, and
+// should reject old timestamps to prevent replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrBlockedAddress is returned for webhook URLs that resolve to non-public addresses
+var ErrBlockedAddress = errors.New("webhook address is not public")
+
+// ValidateURL checks that a webhook URL is http(s) and doesn't point at a non-public
+// address literal or localhost. Hostnames are checked again when connecting, since
+// they may resolve differently by then.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook URL must be http or https")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("webhook URL has no host")
+	}
+	if host == "localhost" {
+		return ErrBlockedAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// newClient returns an HTTP client that only connects to public addresses, so webhooks
+// can't reach the cluster network or cloud metadata endpoints. The check runs on the
+// resolved address, which also covers DNS names pointing at private ranges. Redirects
+// aren't followed.
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        20,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), used by some cluster networks
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsMulticast() &&
+		!sharedAddressSpace.Contains(ip)
+}
@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"server.started"}`)
+
+	// Expected signatures computed independently with HMAC-SHA256 over "<timestamp>.<body>"
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		want      string
+	}{
+		{"signs timestamp and body", "whsec_test", "1700000000", "623a10aecbf4ddb87806b4e29e413a27a199fbba1e17f3296afa72924498b08f"},
+		{"timestamp is covered", "whsec_test", "1700000001", "cec3148c515cfec77e61949b06a037cf5f951b16c6b9e3a5ca3c4322a0bcda34"},
+		{"keyed with the secret", "whsec_other", "1700000000", "2b547d4a465369b65e714b1627c49ef2a904a7ee59dbcc51420fe685444aaf0b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Sign(tt.secret, tt.timestamp, body))
+		})
+	}
+}
+
+func TestSign_BodyIsCovered(t *testing.T) {
+	a := Sign("whsec_test", "1700000000", []byte(`{"event":"server.started"}`))
+	b := Sign("whsec_test", "1700000000", []byte(`{"event":"server.stopped"}`))
+	assert.NotEqual(t, a, b)
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error // nil for valid URLs; ErrBlockedAddress for non-public hosts
+		invalid bool  // Any other error
+	}{
+		{"https hostname", "https://example.com/hook", nil, false},
+		{"http with port", "http://example.com:8080/hook", nil, false},
+		{"public IPv4", "https://8.8.8.8/hook", nil, false},
+		{"public IPv6", "https://[2001:4860:4860::8888]/hook", nil, false},
+		{"ftp scheme", "ftp://example.com/hook", nil, true},
+		{"no scheme", "example.com/hook", nil, true},
+		{"no host", "https:///hook", nil, true},
+		{"localhost", "http://localhost:8080/hook", ErrBlockedAddress, false},
+		{"loopback", "http://127.0.0.1/hook", ErrBlockedAddress, false},
+		{"IPv6 loopback", "http://[::1]/hook", ErrBlockedAddress, false},
+		{"private 10/8", "http://10.0.0.5/hook", ErrBlockedAddress, false},
+		{"private 192.168/16", "http://192.168.1.1/hook", ErrBlockedAddress, false},
+		{"private 172.16/12", "http://172.16.0.1/hook", ErrBlockedAddress, false},
+		{"cloud metadata", "http://169.254.169.254/latest/meta-data", ErrBlockedAddress, false},
+		{"shared address space", "http://100.64.0.1/hook", ErrBlockedAddress, false},
+		{"unspecified", "http://0.0.0.0/hook", ErrBlockedAddress, false},
+		{"IPv6 unique local", "http://[fd00::1]/hook", ErrBlockedAddress, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateURL(tt.url)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.invalid:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrBlockedAddress)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"1.1.1.1", true},
+		{"100.63.255.255", true},
+		{"100.128.0.0", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.31.255.255", false},
+		{"192.168.0.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.0", false},
+		{"100.127.255.255", false},
+		{"224.0.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd12:3456::1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			require.NotNil(t, ip)
+			assert.Equal(t, tt.public, isPublicIP(ip))
+		})
+	}
+}
+
+func TestNewClient_RefusesNonPublicAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	_, err := newClient(time.Second).Get(srv.URL)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBlockedAddress), "got %v", err)
+}
+
+func TestNewClient_DoesNotFollowRedirects(t *testing.T) {
+	client := newClient(time.Second)
+	require.NotNil(t, client.CheckRedirect)
+	assert.Equal(t, http.ErrUseLastResponse, client.CheckRedirect(nil, nil))
+}
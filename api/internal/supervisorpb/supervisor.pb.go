@@ -0,0 +1,1181 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: supervisor/v1/supervisor.proto
+
+package supervisorpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ReportStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // starting, running, stopping, stopped or failed
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"` // Optional machine-readable reason, e.g. INVALID_CONFIG
+	Phase         string                 `protobuf:"bytes,4,opt,name=phase,proto3" json:"phase,omitempty"`   // Optional startup phase of a starting server, e.g. installing
+	ProcessPid    int32                  `protobuf:"varint,5,opt,name=process_pid,json=processPid,proto3" json:"process_pid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportStatusRequest) Reset() {
+	*x = ReportStatusRequest{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportStatusRequest) ProtoMessage() {}
+
+func (x *ReportStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportStatusRequest.ProtoReflect.Descriptor instead.
+func (*ReportStatusRequest) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ReportStatusRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ReportStatusRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ReportStatusRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ReportStatusRequest) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *ReportStatusRequest) GetProcessPid() int32 {
+	if x != nil {
+		return x.ProcessPid
+	}
+	return 0
+}
+
+type ReportStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Result        string                 `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"` // updated, ignored or duplicate
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportStatusResponse) Reset() {
+	*x = ReportStatusResponse{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportStatusResponse) ProtoMessage() {}
+
+func (x *ReportStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportStatusResponse.ProtoReflect.Descriptor instead.
+func (*ReportStatusResponse) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ReportStatusResponse) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+type ReportProgressRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	Percent       int32                  `protobuf:"varint,2,opt,name=percent,proto3" json:"percent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportProgressRequest) Reset() {
+	*x = ReportProgressRequest{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportProgressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportProgressRequest) ProtoMessage() {}
+
+func (x *ReportProgressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportProgressRequest.ProtoReflect.Descriptor instead.
+func (*ReportProgressRequest) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReportProgressRequest) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *ReportProgressRequest) GetPercent() int32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+type ReportProgressResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Result        string                 `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"` // recorded or ignored
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportProgressResponse) Reset() {
+	*x = ReportProgressResponse{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportProgressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportProgressResponse) ProtoMessage() {}
+
+func (x *ReportProgressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportProgressResponse.ProtoReflect.Descriptor instead.
+func (*ReportProgressResponse) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReportProgressResponse) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+type HeartbeatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProcessPid    int32                  `protobuf:"varint,1,opt,name=process_pid,json=processPid,proto3" json:"process_pid,omitempty"`
+	NetTxBytes    int64                  `protobuf:"varint,2,opt,name=net_tx_bytes,json=netTxBytes,proto3" json:"net_tx_bytes,omitempty"`              // Cumulative bytes sent by the game's network namespace
+	PlayersOnline *int32                 `protobuf:"varint,3,opt,name=players_online,json=playersOnline,proto3,oneof" json:"players_online,omitempty"` // Unset for games whose output doesn't report players
+	Samples       []*MetricSample        `protobuf:"bytes,4,rep,name=samples,proto3" json:"samples,omitempty"`                                         // Oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *HeartbeatRequest) GetProcessPid() int32 {
+	if x != nil {
+		return x.ProcessPid
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetNetTxBytes() int64 {
+	if x != nil {
+		return x.NetTxBytes
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetPlayersOnline() int32 {
+	if x != nil && x.PlayersOnline != nil {
+		return *x.PlayersOnline
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetSamples() []*MetricSample {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+// MetricSample is the game process's resource usage at one point in time
+type MetricSample struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SampledAt     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=sampled_at,json=sampledAt,proto3" json:"sampled_at,omitempty"`
+	MemoryMb      int64                  `protobuf:"varint,2,opt,name=memory_mb,json=memoryMb,proto3" json:"memory_mb,omitempty"`
+	CpuPercent    float64                `protobuf:"fixed64,3,opt,name=cpu_percent,json=cpuPercent,proto3" json:"cpu_percent,omitempty"` // Percent of one core
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricSample) Reset() {
+	*x = MetricSample{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricSample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricSample) ProtoMessage() {}
+
+func (x *MetricSample) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricSample.ProtoReflect.Descriptor instead.
+func (*MetricSample) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MetricSample) GetSampledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SampledAt
+	}
+	return nil
+}
+
+func (x *MetricSample) GetMemoryMb() int64 {
+	if x != nil {
+		return x.MemoryMb
+	}
+	return 0
+}
+
+func (x *MetricSample) GetCpuPercent() float64 {
+	if x != nil {
+		return x.CpuPercent
+	}
+	return 0
+}
+
+type HeartbeatResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Result        string                 `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"` // ok or duplicate
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatResponse) Reset() {
+	*x = HeartbeatResponse{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatResponse) ProtoMessage() {}
+
+func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *HeartbeatResponse) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+type ClaimCommandsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClaimCommandsRequest) Reset() {
+	*x = ClaimCommandsRequest{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimCommandsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimCommandsRequest) ProtoMessage() {}
+
+func (x *ClaimCommandsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimCommandsRequest.ProtoReflect.Descriptor instead.
+func (*ClaimCommandsRequest) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{7}
+}
+
+type ClaimCommandsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commands      []*Command             `protobuf:"bytes,1,rep,name=commands,proto3" json:"commands,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClaimCommandsResponse) Reset() {
+	*x = ClaimCommandsResponse{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimCommandsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimCommandsResponse) ProtoMessage() {}
+
+func (x *ClaimCommandsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimCommandsResponse.ProtoReflect.Descriptor instead.
+func (*ClaimCommandsResponse) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ClaimCommandsResponse) GetCommands() []*Command {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+type StreamCommandsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamCommandsRequest) Reset() {
+	*x = StreamCommandsRequest{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamCommandsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamCommandsRequest) ProtoMessage() {}
+
+func (x *StreamCommandsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamCommandsRequest.ProtoReflect.Descriptor instead.
+func (*StreamCommandsRequest) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{9}
+}
+
+// Command is an action queued by the API for the server
+type Command struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`       // e.g. stop, backup, exec
+	Payload       string                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"` // JSON, depending on the type
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Command) Reset() {
+	*x = Command{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Command) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Command) ProtoMessage() {}
+
+func (x *Command) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Command.ProtoReflect.Descriptor instead.
+func (*Command) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Command) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Command) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Command) GetPayload() string {
+	if x != nil {
+		return x.Payload
+	}
+	return ""
+}
+
+type ReportCommandResultRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CommandId     string                 `protobuf:"bytes,1,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Result        string                 `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	Artifact      *Artifact              `protobuf:"bytes,4,opt,name=artifact,proto3" json:"artifact,omitempty"` // A file the command produced, if any
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportCommandResultRequest) Reset() {
+	*x = ReportCommandResultRequest{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportCommandResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportCommandResultRequest) ProtoMessage() {}
+
+func (x *ReportCommandResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportCommandResultRequest.ProtoReflect.Descriptor instead.
+func (*ReportCommandResultRequest) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ReportCommandResultRequest) GetCommandId() string {
+	if x != nil {
+		return x.CommandId
+	}
+	return ""
+}
+
+func (x *ReportCommandResultRequest) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ReportCommandResultRequest) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *ReportCommandResultRequest) GetArtifact() *Artifact {
+	if x != nil {
+		return x.Artifact
+	}
+	return nil
+}
+
+// Artifact is a file a command produced for the user to download, e.g. a backup archive
+type Artifact struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // Relative to the data directory
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Artifact) Reset() {
+	*x = Artifact{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Artifact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Artifact) ProtoMessage() {}
+
+func (x *Artifact) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Artifact.ProtoReflect.Descriptor instead.
+func (*Artifact) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Artifact) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Artifact) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type ReportCommandResultResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportCommandResultResponse) Reset() {
+	*x = ReportCommandResultResponse{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportCommandResultResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportCommandResultResponse) ProtoMessage() {}
+
+func (x *ReportCommandResultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportCommandResultResponse.ProtoReflect.Descriptor instead.
+func (*ReportCommandResultResponse) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{13}
+}
+
+type ReportCommandProgressRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CommandId     string                 `protobuf:"bytes,1,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	DoneBytes     int64                  `protobuf:"varint,2,opt,name=done_bytes,json=doneBytes,proto3" json:"done_bytes,omitempty"`
+	TotalBytes    int64                  `protobuf:"varint,3,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportCommandProgressRequest) Reset() {
+	*x = ReportCommandProgressRequest{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportCommandProgressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportCommandProgressRequest) ProtoMessage() {}
+
+func (x *ReportCommandProgressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportCommandProgressRequest.ProtoReflect.Descriptor instead.
+func (*ReportCommandProgressRequest) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ReportCommandProgressRequest) GetCommandId() string {
+	if x != nil {
+		return x.CommandId
+	}
+	return ""
+}
+
+func (x *ReportCommandProgressRequest) GetDoneBytes() int64 {
+	if x != nil {
+		return x.DoneBytes
+	}
+	return 0
+}
+
+func (x *ReportCommandProgressRequest) GetTotalBytes() int64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+type ReportCommandProgressResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportCommandProgressResponse) Reset() {
+	*x = ReportCommandProgressResponse{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportCommandProgressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportCommandProgressResponse) ProtoMessage() {}
+
+func (x *ReportCommandProgressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportCommandProgressResponse.ProtoReflect.Descriptor instead.
+func (*ReportCommandProgressResponse) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{15}
+}
+
+type GetBannedHashesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBannedHashesRequest) Reset() {
+	*x = GetBannedHashesRequest{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBannedHashesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBannedHashesRequest) ProtoMessage() {}
+
+func (x *GetBannedHashesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBannedHashesRequest.ProtoReflect.Descriptor instead.
+func (*GetBannedHashesRequest) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{16}
+}
+
+type GetBannedHashesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hashes        []string               `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBannedHashesResponse) Reset() {
+	*x = GetBannedHashesResponse{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBannedHashesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBannedHashesResponse) ProtoMessage() {}
+
+func (x *GetBannedHashesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBannedHashesResponse.ProtoReflect.Descriptor instead.
+func (*GetBannedHashesResponse) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetBannedHashesResponse) GetHashes() []string {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+type ReportBannedBinaryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Sha256        string                 `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportBannedBinaryRequest) Reset() {
+	*x = ReportBannedBinaryRequest{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportBannedBinaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportBannedBinaryRequest) ProtoMessage() {}
+
+func (x *ReportBannedBinaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportBannedBinaryRequest.ProtoReflect.Descriptor instead.
+func (*ReportBannedBinaryRequest) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ReportBannedBinaryRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ReportBannedBinaryRequest) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type ReportBannedBinaryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportBannedBinaryResponse) Reset() {
+	*x = ReportBannedBinaryResponse{}
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportBannedBinaryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportBannedBinaryResponse) ProtoMessage() {}
+
+func (x *ReportBannedBinaryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_supervisor_v1_supervisor_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportBannedBinaryResponse.ProtoReflect.Descriptor instead.
+func (*ReportBannedBinaryResponse) Descriptor() ([]byte, []int) {
+	return file_supervisor_v1_supervisor_proto_rawDescGZIP(), []int{19}
+}
+
+var File_supervisor_v1_supervisor_proto protoreflect.FileDescriptor
+
+const file_supervisor_v1_supervisor_proto_rawDesc = "" +
+	"\n" +
+	"\x1esupervisor/v1/supervisor.proto\x12\x13gshub.supervisor.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x96\x01\n" +
+	"\x13ReportStatusRequest\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05phase\x18\x04 \x01(\tR\x05phase\x12\x1f\n" +
+	"\vprocess_pid\x18\x05 \x01(\x05R\n" +
+	"processPid\".\n" +
+	"\x14ReportStatusResponse\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\"G\n" +
+	"\x15ReportProgressRequest\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x18\n" +
+	"\apercent\x18\x02 \x01(\x05R\apercent\"0\n" +
+	"\x16ReportProgressResponse\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\"\xd1\x01\n" +
+	"\x10HeartbeatRequest\x12\x1f\n" +
+	"\vprocess_pid\x18\x01 \x01(\x05R\n" +
+	"processPid\x12 \n" +
+	"\fnet_tx_bytes\x18\x02 \x01(\x03R\n" +
+	"netTxBytes\x12*\n" +
+	"\x0eplayers_online\x18\x03 \x01(\x05H\x00R\rplayersOnline\x88\x01\x01\x12;\n" +
+	"\asamples\x18\x04 \x03(\v2!.gshub.supervisor.v1.MetricSampleR\asamplesB\x11\n" +
+	"\x0f_players_online\"\x87\x01\n" +
+	"\fMetricSample\x129\n" +
+	"\n" +
+	"sampled_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tsampledAt\x12\x1b\n" +
+	"\tmemory_mb\x18\x02 \x01(\x03R\bmemoryMb\x12\x1f\n" +
+	"\vcpu_percent\x18\x03 \x01(\x01R\n" +
+	"cpuPercent\"+\n" +
+	"\x11HeartbeatResponse\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\"\x16\n" +
+	"\x14ClaimCommandsRequest\"Q\n" +
+	"\x15ClaimCommandsResponse\x128\n" +
+	"\bcommands\x18\x01 \x03(\v2\x1c.gshub.supervisor.v1.CommandR\bcommands\"\x17\n" +
+	"\x15StreamCommandsRequest\"G\n" +
+	"\aCommand\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\tR\apayload\"\xa8\x01\n" +
+	"\x1aReportCommandResultRequest\x12\x1d\n" +
+	"\n" +
+	"command_id\x18\x01 \x01(\tR\tcommandId\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x16\n" +
+	"\x06result\x18\x03 \x01(\tR\x06result\x129\n" +
+	"\bartifact\x18\x04 \x01(\v2\x1d.gshub.supervisor.v1.ArtifactR\bartifact\"2\n" +
+	"\bArtifact\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\"\x1d\n" +
+	"\x1bReportCommandResultResponse\"}\n" +
+	"\x1cReportCommandProgressRequest\x12\x1d\n" +
+	"\n" +
+	"command_id\x18\x01 \x01(\tR\tcommandId\x12\x1d\n" +
+	"\n" +
+	"done_bytes\x18\x02 \x01(\x03R\tdoneBytes\x12\x1f\n" +
+	"\vtotal_bytes\x18\x03 \x01(\x03R\n" +
+	"totalBytes\"\x1f\n" +
+	"\x1dReportCommandProgressResponse\"\x18\n" +
+	"\x16GetBannedHashesRequest\"1\n" +
+	"\x17GetBannedHashesResponse\x12\x16\n" +
+	"\x06hashes\x18\x01 \x03(\tR\x06hashes\"G\n" +
+	"\x19ReportBannedBinaryRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06sha256\x18\x02 \x01(\tR\x06sha256\"\x1c\n" +
+	"\x1aReportBannedBinaryResponse2\xdd\a\n" +
+	"\n" +
+	"Supervisor\x12c\n" +
+	"\fReportStatus\x12(.gshub.supervisor.v1.ReportStatusRequest\x1a).gshub.supervisor.v1.ReportStatusResponse\x12i\n" +
+	"\x0eReportProgress\x12*.gshub.supervisor.v1.ReportProgressRequest\x1a+.gshub.supervisor.v1.ReportProgressResponse\x12Z\n" +
+	"\tHeartbeat\x12%.gshub.supervisor.v1.HeartbeatRequest\x1a&.gshub.supervisor.v1.HeartbeatResponse\x12f\n" +
+	"\rClaimCommands\x12).gshub.supervisor.v1.ClaimCommandsRequest\x1a*.gshub.supervisor.v1.ClaimCommandsResponse\x12\\\n" +
+	"\x0eStreamCommands\x12*.gshub.supervisor.v1.StreamCommandsRequest\x1a\x1c.gshub.supervisor.v1.Command0\x01\x12x\n" +
+	"\x13ReportCommandResult\x12/.gshub.supervisor.v1.ReportCommandResultRequest\x1a0.gshub.supervisor.v1.ReportCommandResultResponse\x12~\n" +
+	"\x15ReportCommandProgress\x121.gshub.supervisor.v1.ReportCommandProgressRequest\x1a2.gshub.supervisor.v1.ReportCommandProgressResponse\x12l\n" +
+	"\x0fGetBannedHashes\x12+.gshub.supervisor.v1.GetBannedHashesRequest\x1a,.gshub.supervisor.v1.GetBannedHashesResponse\x12u\n" +
+	"\x12ReportBannedBinary\x12..gshub.supervisor.v1.ReportBannedBinaryRequest\x1a/.gshub.supervisor.v1.ReportBannedBinaryResponseb\x06proto3"
+
+var (
+	file_supervisor_v1_supervisor_proto_rawDescOnce sync.Once
+	file_supervisor_v1_supervisor_proto_rawDescData []byte
+)
+
+func file_supervisor_v1_supervisor_proto_rawDescGZIP() []byte {
+	file_supervisor_v1_supervisor_proto_rawDescOnce.Do(func() {
+		file_supervisor_v1_supervisor_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_supervisor_v1_supervisor_proto_rawDesc), len(file_supervisor_v1_supervisor_proto_rawDesc)))
+	})
+	return file_supervisor_v1_supervisor_proto_rawDescData
+}
+
+var file_supervisor_v1_supervisor_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_supervisor_v1_supervisor_proto_goTypes = []any{
+	(*ReportStatusRequest)(nil),           // 0: gshub.supervisor.v1.ReportStatusRequest
+	(*ReportStatusResponse)(nil),          // 1: gshub.supervisor.v1.ReportStatusResponse
+	(*ReportProgressRequest)(nil),         // 2: gshub.supervisor.v1.ReportProgressRequest
+	(*ReportProgressResponse)(nil),        // 3: gshub.supervisor.v1.ReportProgressResponse
+	(*HeartbeatRequest)(nil),              // 4: gshub.supervisor.v1.HeartbeatRequest
+	(*MetricSample)(nil),                  // 5: gshub.supervisor.v1.MetricSample
+	(*HeartbeatResponse)(nil),             // 6: gshub.supervisor.v1.HeartbeatResponse
+	(*ClaimCommandsRequest)(nil),          // 7: gshub.supervisor.v1.ClaimCommandsRequest
+	(*ClaimCommandsResponse)(nil),         // 8: gshub.supervisor.v1.ClaimCommandsResponse
+	(*StreamCommandsRequest)(nil),         // 9: gshub.supervisor.v1.StreamCommandsRequest
+	(*Command)(nil),                       // 10: gshub.supervisor.v1.Command
+	(*ReportCommandResultRequest)(nil),    // 11: gshub.supervisor.v1.ReportCommandResultRequest
+	(*Artifact)(nil),                      // 12: gshub.supervisor.v1.Artifact
+	(*ReportCommandResultResponse)(nil),   // 13: gshub.supervisor.v1.ReportCommandResultResponse
+	(*ReportCommandProgressRequest)(nil),  // 14: gshub.supervisor.v1.ReportCommandProgressRequest
+	(*ReportCommandProgressResponse)(nil), // 15: gshub.supervisor.v1.ReportCommandProgressResponse
+	(*GetBannedHashesRequest)(nil),        // 16: gshub.supervisor.v1.GetBannedHashesRequest
+	(*GetBannedHashesResponse)(nil),       // 17: gshub.supervisor.v1.GetBannedHashesResponse
+	(*ReportBannedBinaryRequest)(nil),     // 18: gshub.supervisor.v1.ReportBannedBinaryRequest
+	(*ReportBannedBinaryResponse)(nil),    // 19: gshub.supervisor.v1.ReportBannedBinaryResponse
+	(*timestamppb.Timestamp)(nil),         // 20: google.protobuf.Timestamp
+}
+var file_supervisor_v1_supervisor_proto_depIdxs = []int32{
+	5,  // 0: gshub.supervisor.v1.HeartbeatRequest.samples:type_name -> gshub.supervisor.v1.MetricSample
+	20, // 1: gshub.supervisor.v1.MetricSample.sampled_at:type_name -> google.protobuf.Timestamp
+	10, // 2: gshub.supervisor.v1.ClaimCommandsResponse.commands:type_name -> gshub.supervisor.v1.Command
+	12, // 3: gshub.supervisor.v1.ReportCommandResultRequest.artifact:type_name -> gshub.supervisor.v1.Artifact
+	0,  // 4: gshub.supervisor.v1.Supervisor.ReportStatus:input_type -> gshub.supervisor.v1.ReportStatusRequest
+	2,  // 5: gshub.supervisor.v1.Supervisor.ReportProgress:input_type -> gshub.supervisor.v1.ReportProgressRequest
+	4,  // 6: gshub.supervisor.v1.Supervisor.Heartbeat:input_type -> gshub.supervisor.v1.HeartbeatRequest
+	7,  // 7: gshub.supervisor.v1.Supervisor.ClaimCommands:input_type -> gshub.supervisor.v1.ClaimCommandsRequest
+	9,  // 8: gshub.supervisor.v1.Supervisor.StreamCommands:input_type -> gshub.supervisor.v1.StreamCommandsRequest
+	11, // 9: gshub.supervisor.v1.Supervisor.ReportCommandResult:input_type -> gshub.supervisor.v1.ReportCommandResultRequest
+	14, // 10: gshub.supervisor.v1.Supervisor.ReportCommandProgress:input_type -> gshub.supervisor.v1.ReportCommandProgressRequest
+	16, // 11: gshub.supervisor.v1.Supervisor.GetBannedHashes:input_type -> gshub.supervisor.v1.GetBannedHashesRequest
+	18, // 12: gshub.supervisor.v1.Supervisor.ReportBannedBinary:input_type -> gshub.supervisor.v1.ReportBannedBinaryRequest
+	1,  // 13: gshub.supervisor.v1.Supervisor.ReportStatus:output_type -> gshub.supervisor.v1.ReportStatusResponse
+	3,  // 14: gshub.supervisor.v1.Supervisor.ReportProgress:output_type -> gshub.supervisor.v1.ReportProgressResponse
+	6,  // 15: gshub.supervisor.v1.Supervisor.Heartbeat:output_type -> gshub.supervisor.v1.HeartbeatResponse
+	8,  // 16: gshub.supervisor.v1.Supervisor.ClaimCommands:output_type -> gshub.supervisor.v1.ClaimCommandsResponse
+	10, // 17: gshub.supervisor.v1.Supervisor.StreamCommands:output_type -> gshub.supervisor.v1.Command
+	13, // 18: gshub.supervisor.v1.Supervisor.ReportCommandResult:output_type -> gshub.supervisor.v1.ReportCommandResultResponse
+	15, // 19: gshub.supervisor.v1.Supervisor.ReportCommandProgress:output_type -> gshub.supervisor.v1.ReportCommandProgressResponse
+	17, // 20: gshub.supervisor.v1.Supervisor.GetBannedHashes:output_type -> gshub.supervisor.v1.GetBannedHashesResponse
+	19, // 21: gshub.supervisor.v1.Supervisor.ReportBannedBinary:output_type -> gshub.supervisor.v1.ReportBannedBinaryResponse
+	13, // [13:22] is the sub-list for method output_type
+	4,  // [4:13] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_supervisor_v1_supervisor_proto_init() }
+func file_supervisor_v1_supervisor_proto_init() {
+	if File_supervisor_v1_supervisor_proto != nil {
+		return
+	}
+	file_supervisor_v1_supervisor_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_supervisor_v1_supervisor_proto_rawDesc), len(file_supervisor_v1_supervisor_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_supervisor_v1_supervisor_proto_goTypes,
+		DependencyIndexes: file_supervisor_v1_supervisor_proto_depIdxs,
+		MessageInfos:      file_supervisor_v1_supervisor_proto_msgTypes,
+	}.Build()
+	File_supervisor_v1_supervisor_proto = out.File
+	file_supervisor_v1_supervisor_proto_goTypes = nil
+	file_supervisor_v1_supervisor_proto_depIdxs = nil
+}
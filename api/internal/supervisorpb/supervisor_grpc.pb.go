@@ -0,0 +1,457 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: supervisor/v1/supervisor.proto
+
+package supervisorpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Supervisor_ReportStatus_FullMethodName          = "/gshub.supervisor.v1.Supervisor/ReportStatus"
+	Supervisor_ReportProgress_FullMethodName        = "/gshub.supervisor.v1.Supervisor/ReportProgress"
+	Supervisor_Heartbeat_FullMethodName             = "/gshub.supervisor.v1.Supervisor/Heartbeat"
+	Supervisor_ClaimCommands_FullMethodName         = "/gshub.supervisor.v1.Supervisor/ClaimCommands"
+	Supervisor_StreamCommands_FullMethodName        = "/gshub.supervisor.v1.Supervisor/StreamCommands"
+	Supervisor_ReportCommandResult_FullMethodName   = "/gshub.supervisor.v1.Supervisor/ReportCommandResult"
+	Supervisor_ReportCommandProgress_FullMethodName = "/gshub.supervisor.v1.Supervisor/ReportCommandProgress"
+	Supervisor_GetBannedHashes_FullMethodName       = "/gshub.supervisor.v1.Supervisor/GetBannedHashes"
+	Supervisor_ReportBannedBinary_FullMethodName    = "/gshub.supervisor.v1.Supervisor/ReportBannedBinary"
+)
+
+// SupervisorClient is the client API for Supervisor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Supervisor is the internal protocol between game server supervisors and the API, served on
+// the API's port 8082 beside the JSON endpoints on 8081. Every call identifies the server
+// with "x-server-id" metadata and authenticates with "authorization: Bearer <token>".
+type SupervisorClient interface {
+	// ReportStatus reports a process status, or the startup phase of a starting game
+	ReportStatus(ctx context.Context, in *ReportStatusRequest, opts ...grpc.CallOption) (*ReportStatusResponse, error)
+	// ReportProgress reports how far a starting game has got with a milestone
+	ReportProgress(ctx context.Context, in *ReportProgressRequest, opts ...grpc.CallOption) (*ReportProgressResponse, error)
+	// Heartbeat keeps the server's heartbeat fresh and carries the usage samples taken since
+	// the last one
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	// ClaimCommands returns the commands queued right now, without waiting
+	ClaimCommands(ctx context.Context, in *ClaimCommandsRequest, opts ...grpc.CallOption) (*ClaimCommandsResponse, error)
+	// StreamCommands sends queued commands as they arrive, until the supervisor disconnects
+	StreamCommands(ctx context.Context, in *StreamCommandsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Command], error)
+	// ReportCommandResult reports the outcome of a command
+	ReportCommandResult(ctx context.Context, in *ReportCommandResultRequest, opts ...grpc.CallOption) (*ReportCommandResultResponse, error)
+	// ReportCommandProgress reports how far a long-running command has got
+	ReportCommandProgress(ctx context.Context, in *ReportCommandProgressRequest, opts ...grpc.CallOption) (*ReportCommandProgressResponse, error)
+	// GetBannedHashes returns the SHA-256 hashes of binaries the supervisor must refuse to run
+	GetBannedHashes(ctx context.Context, in *GetBannedHashesRequest, opts ...grpc.CallOption) (*GetBannedHashesResponse, error)
+	// ReportBannedBinary reports a banned binary; the API suspends the server
+	ReportBannedBinary(ctx context.Context, in *ReportBannedBinaryRequest, opts ...grpc.CallOption) (*ReportBannedBinaryResponse, error)
+}
+
+type supervisorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSupervisorClient(cc grpc.ClientConnInterface) SupervisorClient {
+	return &supervisorClient{cc}
+}
+
+func (c *supervisorClient) ReportStatus(ctx context.Context, in *ReportStatusRequest, opts ...grpc.CallOption) (*ReportStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportStatusResponse)
+	err := c.cc.Invoke(ctx, Supervisor_ReportStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *supervisorClient) ReportProgress(ctx context.Context, in *ReportProgressRequest, opts ...grpc.CallOption) (*ReportProgressResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportProgressResponse)
+	err := c.cc.Invoke(ctx, Supervisor_ReportProgress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *supervisorClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, Supervisor_Heartbeat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *supervisorClient) ClaimCommands(ctx context.Context, in *ClaimCommandsRequest, opts ...grpc.CallOption) (*ClaimCommandsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClaimCommandsResponse)
+	err := c.cc.Invoke(ctx, Supervisor_ClaimCommands_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *supervisorClient) StreamCommands(ctx context.Context, in *StreamCommandsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Command], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Supervisor_ServiceDesc.Streams[0], Supervisor_StreamCommands_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamCommandsRequest, Command]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Supervisor_StreamCommandsClient = grpc.ServerStreamingClient[Command]
+
+func (c *supervisorClient) ReportCommandResult(ctx context.Context, in *ReportCommandResultRequest, opts ...grpc.CallOption) (*ReportCommandResultResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportCommandResultResponse)
+	err := c.cc.Invoke(ctx, Supervisor_ReportCommandResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *supervisorClient) ReportCommandProgress(ctx context.Context, in *ReportCommandProgressRequest, opts ...grpc.CallOption) (*ReportCommandProgressResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportCommandProgressResponse)
+	err := c.cc.Invoke(ctx, Supervisor_ReportCommandProgress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *supervisorClient) GetBannedHashes(ctx context.Context, in *GetBannedHashesRequest, opts ...grpc.CallOption) (*GetBannedHashesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBannedHashesResponse)
+	err := c.cc.Invoke(ctx, Supervisor_GetBannedHashes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *supervisorClient) ReportBannedBinary(ctx context.Context, in *ReportBannedBinaryRequest, opts ...grpc.CallOption) (*ReportBannedBinaryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportBannedBinaryResponse)
+	err := c.cc.Invoke(ctx, Supervisor_ReportBannedBinary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SupervisorServer is the server API for Supervisor service.
+// All implementations must embed UnimplementedSupervisorServer
+// for forward compatibility.
+//
+// Supervisor is the internal protocol between game server supervisors and the API, served on
+// the API's port 8082 beside the JSON endpoints on 8081. Every call identifies the server
+// with "x-server-id" metadata and authenticates with "authorization: Bearer <token>".
+type SupervisorServer interface {
+	// ReportStatus reports a process status, or the startup phase of a starting game
+	ReportStatus(context.Context, *ReportStatusRequest) (*ReportStatusResponse, error)
+	// ReportProgress reports how far a starting game has got with a milestone
+	ReportProgress(context.Context, *ReportProgressRequest) (*ReportProgressResponse, error)
+	// Heartbeat keeps the server's heartbeat fresh and carries the usage samples taken since
+	// the last one
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	// ClaimCommands returns the commands queued right now, without waiting
+	ClaimCommands(context.Context, *ClaimCommandsRequest) (*ClaimCommandsResponse, error)
+	// StreamCommands sends queued commands as they arrive, until the supervisor disconnects
+	StreamCommands(*StreamCommandsRequest, grpc.ServerStreamingServer[Command]) error
+	// ReportCommandResult reports the outcome of a command
+	ReportCommandResult(context.Context, *ReportCommandResultRequest) (*ReportCommandResultResponse, error)
+	// ReportCommandProgress reports how far a long-running command has got
+	ReportCommandProgress(context.Context, *ReportCommandProgressRequest) (*ReportCommandProgressResponse, error)
+	// GetBannedHashes returns the SHA-256 hashes of binaries the supervisor must refuse to run
+	GetBannedHashes(context.Context, *GetBannedHashesRequest) (*GetBannedHashesResponse, error)
+	// ReportBannedBinary reports a banned binary; the API suspends the server
+	ReportBannedBinary(context.Context, *ReportBannedBinaryRequest) (*ReportBannedBinaryResponse, error)
+	mustEmbedUnimplementedSupervisorServer()
+}
+
+// UnimplementedSupervisorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSupervisorServer struct{}
+
+func (UnimplementedSupervisorServer) ReportStatus(context.Context, *ReportStatusRequest) (*ReportStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportStatus not implemented")
+}
+func (UnimplementedSupervisorServer) ReportProgress(context.Context, *ReportProgressRequest) (*ReportProgressResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportProgress not implemented")
+}
+func (UnimplementedSupervisorServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedSupervisorServer) ClaimCommands(context.Context, *ClaimCommandsRequest) (*ClaimCommandsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ClaimCommands not implemented")
+}
+func (UnimplementedSupervisorServer) StreamCommands(*StreamCommandsRequest, grpc.ServerStreamingServer[Command]) error {
+	return status.Error(codes.Unimplemented, "method StreamCommands not implemented")
+}
+func (UnimplementedSupervisorServer) ReportCommandResult(context.Context, *ReportCommandResultRequest) (*ReportCommandResultResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportCommandResult not implemented")
+}
+func (UnimplementedSupervisorServer) ReportCommandProgress(context.Context, *ReportCommandProgressRequest) (*ReportCommandProgressResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportCommandProgress not implemented")
+}
+func (UnimplementedSupervisorServer) GetBannedHashes(context.Context, *GetBannedHashesRequest) (*GetBannedHashesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetBannedHashes not implemented")
+}
+func (UnimplementedSupervisorServer) ReportBannedBinary(context.Context, *ReportBannedBinaryRequest) (*ReportBannedBinaryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportBannedBinary not implemented")
+}
+func (UnimplementedSupervisorServer) mustEmbedUnimplementedSupervisorServer() {}
+func (UnimplementedSupervisorServer) testEmbeddedByValue()                    {}
+
+// UnsafeSupervisorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SupervisorServer will
+// result in compilation errors.
+type UnsafeSupervisorServer interface {
+	mustEmbedUnimplementedSupervisorServer()
+}
+
+func RegisterSupervisorServer(s grpc.ServiceRegistrar, srv SupervisorServer) {
+	// If the following call panics, it indicates UnimplementedSupervisorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Supervisor_ServiceDesc, srv)
+}
+
+func _Supervisor_ReportStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SupervisorServer).ReportStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Supervisor_ReportStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SupervisorServer).ReportStatus(ctx, req.(*ReportStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Supervisor_ReportProgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportProgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SupervisorServer).ReportProgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Supervisor_ReportProgress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SupervisorServer).ReportProgress(ctx, req.(*ReportProgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Supervisor_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SupervisorServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Supervisor_Heartbeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SupervisorServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Supervisor_ClaimCommands_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClaimCommandsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SupervisorServer).ClaimCommands(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Supervisor_ClaimCommands_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SupervisorServer).ClaimCommands(ctx, req.(*ClaimCommandsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Supervisor_StreamCommands_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamCommandsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SupervisorServer).StreamCommands(m, &grpc.GenericServerStream[StreamCommandsRequest, Command]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Supervisor_StreamCommandsServer = grpc.ServerStreamingServer[Command]
+
+func _Supervisor_ReportCommandResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportCommandResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SupervisorServer).ReportCommandResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Supervisor_ReportCommandResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SupervisorServer).ReportCommandResult(ctx, req.(*ReportCommandResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Supervisor_ReportCommandProgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportCommandProgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SupervisorServer).ReportCommandProgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Supervisor_ReportCommandProgress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SupervisorServer).ReportCommandProgress(ctx, req.(*ReportCommandProgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Supervisor_GetBannedHashes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBannedHashesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SupervisorServer).GetBannedHashes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Supervisor_GetBannedHashes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SupervisorServer).GetBannedHashes(ctx, req.(*GetBannedHashesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Supervisor_ReportBannedBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportBannedBinaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SupervisorServer).ReportBannedBinary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Supervisor_ReportBannedBinary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SupervisorServer).ReportBannedBinary(ctx, req.(*ReportBannedBinaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Supervisor_ServiceDesc is the grpc.ServiceDesc for Supervisor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Supervisor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gshub.supervisor.v1.Supervisor",
+	HandlerType: (*SupervisorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReportStatus",
+			Handler:    _Supervisor_ReportStatus_Handler,
+		},
+		{
+			MethodName: "ReportProgress",
+			Handler:    _Supervisor_ReportProgress_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _Supervisor_Heartbeat_Handler,
+		},
+		{
+			MethodName: "ClaimCommands",
+			Handler:    _Supervisor_ClaimCommands_Handler,
+		},
+		{
+			MethodName: "ReportCommandResult",
+			Handler:    _Supervisor_ReportCommandResult_Handler,
+		},
+		{
+			MethodName: "ReportCommandProgress",
+			Handler:    _Supervisor_ReportCommandProgress_Handler,
+		},
+		{
+			MethodName: "GetBannedHashes",
+			Handler:    _Supervisor_GetBannedHashes_Handler,
+		},
+		{
+			MethodName: "ReportBannedBinary",
+			Handler:    _Supervisor_ReportBannedBinary_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamCommands",
+			Handler:       _Supervisor_StreamCommands_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "supervisor/v1/supervisor.proto",
+}
@@ -0,0 +1,3 @@
+-- Machine-readable reason code accompanying status_message (e.g. OOM_KILLED, CRASH_LOOP)
+-- Cleared on every status transition that doesn't set a new reason
+ALTER TABLE servers ADD COLUMN status_reason VARCHAR(50);
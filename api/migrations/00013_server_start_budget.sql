@@ -0,0 +1,4 @@
+-- Per-server budget of user-initiated starts/restarts to stop crash-restart loops thrashing nodes
+-- start_window_start marks the beginning of the current fixed window, start_count the starts within it
+ALTER TABLE servers ADD COLUMN start_window_start TIMESTAMP WITH TIME ZONE;
+ALTER TABLE servers ADD COLUMN start_count INT NOT NULL DEFAULT 0;
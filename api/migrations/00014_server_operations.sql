@@ -0,0 +1,14 @@
+-- Tracks in-flight and recent server actions (start/stop/restart/upgrade) so failures in
+-- background work are visible to users instead of only in API logs
+CREATE TABLE IF NOT EXISTS server_operations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  server_id UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+  type VARCHAR(20) NOT NULL,                    -- start, stop, restart, upgrade
+  state VARCHAR(20) NOT NULL DEFAULT 'running', -- running, succeeded, failed, superseded
+  error_message TEXT,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_operations_server_created ON server_operations(server_id, created_at DESC);
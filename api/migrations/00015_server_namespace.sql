@@ -0,0 +1,3 @@
+-- Namespace the server's K8s resources (deployment, PVC) live in, chosen from its plan at creation
+-- Empty means the API's default namespace (K8S_NAMESPACE), which all pre-existing servers use
+ALTER TABLE servers ADD COLUMN namespace VARCHAR(63) NOT NULL DEFAULT '';
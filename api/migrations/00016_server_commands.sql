@@ -0,0 +1,16 @@
+-- Commands the API queues for a server's supervisor, delivered by long-polling
+-- GET /internal/servers/:id/commands (graceful stop, config reload, backup, console input)
+CREATE TABLE IF NOT EXISTS server_commands (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  server_id UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+  type VARCHAR(30) NOT NULL,                    -- stop, reload_config, backup, exec
+  payload TEXT,                                 -- e.g. the console line for exec
+  state VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, delivered, succeeded, failed, expired
+  result TEXT,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  delivered_at TIMESTAMP WITH TIME ZONE,
+  completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_commands_pending ON server_commands(server_id, created_at)
+    WHERE state = 'pending';
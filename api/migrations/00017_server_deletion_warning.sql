@@ -0,0 +1,3 @@
+-- When the "server will be permanently deleted" email went out for an expired server
+-- NULL means not sent yet; reset whenever the server expires again or is reactivated
+ALTER TABLE servers ADD COLUMN deletion_warning_sent_at TIMESTAMP WITH TIME ZONE;
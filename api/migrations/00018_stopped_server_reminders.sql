@@ -0,0 +1,5 @@
+-- Reminders for servers left stopped while still billed
+-- stopped_reminder_sent_at: when the owner was last reminded; a reminder is due again only after a new stop
+-- auto_cancel_when_stopped: owner opted in to cancel the subscription instead of being reminded
+ALTER TABLE servers ADD COLUMN stopped_reminder_sent_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE servers ADD COLUMN auto_cancel_when_stopped BOOLEAN NOT NULL DEFAULT FALSE;
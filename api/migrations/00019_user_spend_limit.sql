@@ -0,0 +1,11 @@
+-- Per-user monthly spending cap
+-- spend_limit_cents: monthly limit in the smallest currency unit (NULL = no limit)
+-- spend_limit_block_checkout: reject new checkouts that would push projected spend over the limit
+-- projected_spend_*: next month's charges from Stripe upcoming invoices, refreshed daily
+-- spend_alert_sent_at: when the owner was last alerted about exceeding the limit (at most once a month)
+ALTER TABLE users ADD COLUMN spend_limit_cents BIGINT;
+ALTER TABLE users ADD COLUMN spend_limit_block_checkout BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN projected_spend_cents BIGINT;
+ALTER TABLE users ADD COLUMN projected_spend_currency VARCHAR(3);
+ALTER TABLE users ADD COLUMN projected_spend_updated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN spend_alert_sent_at TIMESTAMP WITH TIME ZONE;
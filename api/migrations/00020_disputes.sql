@@ -0,0 +1,24 @@
+-- Chargeback disputes and server suspension
+-- Servers with a disputed payment are suspended (status 'suspended') until an admin lifts it
+ALTER TABLE servers ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE;
+
+-- Users with a disputed payment are flagged and can't check out until an admin clears the flag
+ALTER TABLE users ADD COLUMN flagged_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN flag_reason TEXT;
+
+-- Disputes received through the charge.dispute.created webhook
+CREATE TABLE IF NOT EXISTS stripe_disputes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  stripe_dispute_id VARCHAR(255) NOT NULL UNIQUE,
+  payment_intent_id VARCHAR(255),
+  server_id UUID REFERENCES servers(id) ON DELETE SET NULL, -- NULL if the payment couldn't be matched to a server
+  user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  amount BIGINT NOT NULL,                                  -- smallest currency unit
+  currency VARCHAR(3) NOT NULL,
+  reason VARCHAR(50) NOT NULL,                             -- Stripe dispute reason, e.g. fraudulent
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  resolved_at TIMESTAMP WITH TIME ZONE                     -- set when an admin lifts the suspension
+);
+
+CREATE INDEX IF NOT EXISTS idx_stripe_disputes_unresolved ON stripe_disputes(created_at)
+    WHERE resolved_at IS NULL;
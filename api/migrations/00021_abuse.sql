@@ -0,0 +1,31 @@
+-- Abuse detection: CPU/network anomaly tracking, banned binaries and abuse reports
+-- Servers tripping a rule are suspended (status 'suspended', reason ABUSE) until an admin lifts it
+
+-- Last heartbeat sample, used to turn the supervisor's cumulative counters into rates
+ALTER TABLE servers ADD COLUMN net_tx_bytes BIGINT;
+ALTER TABLE servers ADD COLUMN net_sampled_at TIMESTAMP WITH TIME ZONE;
+-- When each anomaly started; cleared as soon as a sample is back under the threshold
+ALTER TABLE servers ADD COLUMN cpu_anomaly_since TIMESTAMP WITH TIME ZONE;
+ALTER TABLE servers ADD COLUMN net_anomaly_since TIMESTAMP WITH TIME ZONE;
+-- Reviewed servers an admin exempted from the anomaly rules
+ALTER TABLE servers ADD COLUMN abuse_exempt BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- SHA-256 hashes of game binaries supervisors refuse to run
+CREATE TABLE IF NOT EXISTS banned_hashes (
+  sha256 CHAR(64) PRIMARY KEY,
+  name VARCHAR(255) NOT NULL, -- What the binary is, shown to admins
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Why servers were suspended for abuse
+CREATE TABLE IF NOT EXISTS abuse_reports (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  server_id UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+  rule VARCHAR(50) NOT NULL, -- cpu, network or banned_binary
+  detail TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  resolved_at TIMESTAMP WITH TIME ZONE -- set when an admin lifts the suspension
+);
+
+CREATE INDEX IF NOT EXISTS idx_abuse_reports_unresolved ON abuse_reports(created_at)
+    WHERE resolved_at IS NULL;
@@ -0,0 +1,11 @@
+-- Soft account suspension: suspended users keep read-only access but can't start servers or check out
+-- Set automatically on repeated chargebacks or abuse, or manually by admins
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN suspension_reason TEXT;
+
+-- A suspended user's request to be reinstated, cleared when an admin decides
+ALTER TABLE users ADD COLUMN reinstatement_requested_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN reinstatement_message TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_users_suspended ON users(suspended_at)
+    WHERE suspended_at IS NOT NULL;
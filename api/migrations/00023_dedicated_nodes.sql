@@ -0,0 +1,9 @@
+-- Dedicated nodes: nodes tainted platform.io/dedicated are reserved for dedicated plans,
+-- each running a single server that has the whole node to itself
+ALTER TABLE nodes ADD COLUMN dedicated BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The server a dedicated node is exclusive to, NULL while the node is free
+ALTER TABLE nodes ADD COLUMN dedicated_server_id UUID REFERENCES servers(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_nodes_dedicated_server ON nodes(dedicated_server_id)
+    WHERE dedicated_server_id IS NOT NULL;
@@ -0,0 +1,6 @@
+-- GPU scheduling: nvidia.com/gpu allocatable per node and reserved per server
+ALTER TABLE nodes ADD COLUMN allocatable_gpus INT NOT NULL DEFAULT 0;
+ALTER TABLE servers ADD COLUMN reserved_gpus INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN nodes.allocatable_gpus IS 'K8s allocatable nvidia.com/gpu';
+COMMENT ON COLUMN servers.reserved_gpus IS 'Reserved nvidia.com/gpu for this server';
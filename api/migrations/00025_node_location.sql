@@ -0,0 +1,5 @@
+-- Where each node physically runs, synced from its labels and shown in server details
+ALTER TABLE nodes ADD COLUMN zone VARCHAR(255) NOT NULL DEFAULT '';       -- topology.kubernetes.io/zone
+ALTER TABLE nodes ADD COLUMN region VARCHAR(255) NOT NULL DEFAULT '';     -- topology.kubernetes.io/region
+ALTER TABLE nodes ADD COLUMN provider VARCHAR(255) NOT NULL DEFAULT '';   -- platform.io/provider
+ALTER TABLE nodes ADD COLUMN datacenter VARCHAR(255) NOT NULL DEFAULT ''; -- platform.io/datacenter
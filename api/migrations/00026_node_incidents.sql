@@ -0,0 +1,17 @@
+-- Node-level incidents (a node NotReady or drained), opened and resolved by node sync
+CREATE TABLE IF NOT EXISTS node_incidents (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    node_name        VARCHAR(255) NOT NULL,
+    kind             VARCHAR(20) NOT NULL,                 -- node_not_ready or node_drained
+    region           VARCHAR(255) NOT NULL DEFAULT '',     -- Copied from the node so history survives node removal
+    zone             VARCHAR(255) NOT NULL DEFAULT '',
+    affected_servers INT NOT NULL DEFAULT 0,               -- Active servers on the node when the incident opened
+    started_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at      TIMESTAMP WITH TIME ZONE
+);
+
+-- At most one open incident per node
+CREATE UNIQUE INDEX IF NOT EXISTS idx_node_incidents_open ON node_incidents(node_name)
+    WHERE resolved_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_node_incidents_started_at ON node_incidents(started_at);
@@ -0,0 +1,29 @@
+-- Outgoing webhooks users register per server, and the queue of signed deliveries to them
+CREATE TABLE IF NOT EXISTS server_webhooks (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id  UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    url        TEXT NOT NULL,
+    secret     VARCHAR(255) NOT NULL,              -- HMAC-SHA256 key for the X-GSHub-Signature header
+    events     TEXT[] NOT NULL,                    -- Subscribed event types, e.g. server.crashed
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_webhooks_server_id ON server_webhooks(server_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id      UUID NOT NULL REFERENCES server_webhooks(id) ON DELETE CASCADE,
+    event           VARCHAR(50) NOT NULL,
+    payload         JSONB NOT NULL,
+    state           VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, delivered or failed
+    attempts        INT NOT NULL DEFAULT 0,
+    response_status INT,                                    -- HTTP status of the last attempt
+    last_error      TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at)
+    WHERE state = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at);
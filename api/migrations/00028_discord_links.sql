@@ -0,0 +1,16 @@
+-- Discord accounts linked to users, so the Discord bot can act on their servers
+CREATE TABLE IF NOT EXISTS discord_links (
+    user_id          UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    discord_user_id  VARCHAR(32) NOT NULL UNIQUE,        -- Discord snowflake
+    discord_username VARCHAR(255) NOT NULL,
+    linked_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- One-time codes a user enters with /link in Discord to link their account
+CREATE TABLE IF NOT EXISTS discord_link_codes (
+    code       VARCHAR(16) PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_discord_link_codes_user_id ON discord_link_codes(user_id);
@@ -0,0 +1,10 @@
+-- Third-party accounts (e.g. Steam) linked to users
+CREATE TABLE IF NOT EXISTS linked_accounts (
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider     VARCHAR(32) NOT NULL,               -- e.g. steam
+    external_id  VARCHAR(255) NOT NULL,              -- Account ID at the provider, e.g. SteamID64
+    display_name VARCHAR(255) NOT NULL,
+    linked_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, provider),
+    UNIQUE (provider, external_id)
+);
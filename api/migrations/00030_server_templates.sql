@@ -0,0 +1,19 @@
+-- Reusable server configurations users save from a server and create new servers from
+CREATE TABLE IF NOT EXISTS server_templates (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name          VARCHAR(100) NOT NULL,
+    description   TEXT NOT NULL DEFAULT '',
+    game          VARCHAR(50) NOT NULL,
+    plan          VARCHAR(50) NOT NULL,
+    env_overrides JSONB,
+    is_public     BOOLEAN NOT NULL DEFAULT FALSE,     -- Listed for, and usable by, every user
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_templates_user_id ON server_templates(user_id);
+CREATE INDEX IF NOT EXISTS idx_server_templates_public ON server_templates(created_at DESC) WHERE is_public;
+
+-- Env overrides a server created from a template starts with, applied once it's paid for
+ALTER TABLE pending_server_requests ADD COLUMN IF NOT EXISTS env_overrides JSONB;
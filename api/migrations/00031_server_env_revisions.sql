@@ -0,0 +1,11 @@
+-- Every change to a server's env overrides, so a bad change can be reverted
+CREATE TABLE IF NOT EXISTS server_env_revisions (
+    server_id     UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    revision      INTEGER NOT NULL,                   -- 1, 2, ... per server
+    env_overrides JSONB NOT NULL,                     -- Overrides after the change
+    previous_env  JSONB NOT NULL,                     -- Overrides before the change
+    changed_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+    reverted_to   INTEGER,                            -- Set when the change reverted to an earlier revision
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (server_id, revision)
+);
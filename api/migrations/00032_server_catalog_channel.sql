@@ -0,0 +1,3 @@
+-- Game catalog channel per server, so admins can test game definitions from the
+-- staging catalog on internal servers
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS catalog_channel VARCHAR(20) NOT NULL DEFAULT 'production';
@@ -0,0 +1,9 @@
+-- User-supplied game definitions for servers of the "custom" game type
+CREATE TABLE IF NOT EXISTS server_custom_games (
+    server_id  UUID PRIMARY KEY REFERENCES servers(id) ON DELETE CASCADE,
+    definition JSONB NOT NULL,                          -- models.CustomGame
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Carried from checkout to the created server
+ALTER TABLE pending_server_requests ADD COLUMN IF NOT EXISTS custom_game JSONB;
@@ -0,0 +1,10 @@
+-- Monthly outbound traffic per server, accumulated from supervisor heartbeats
+CREATE TABLE IF NOT EXISTS server_egress_usage (
+    server_id    UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    period       DATE NOT NULL,                      -- First day of the month (UTC)
+    tx_bytes     BIGINT NOT NULL DEFAULT 0,
+    last_counter BIGINT NOT NULL,                    -- Supervisor's cumulative counter at the last heartbeat
+    alerted_at   TIMESTAMP WITH TIME ZONE,           -- Owner was alerted about exceeding the plan's quota
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (server_id, period)
+);
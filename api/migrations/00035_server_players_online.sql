@@ -0,0 +1,2 @@
+-- Players online as last reported by the supervisor (NULL if the game doesn't report players)
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS players_online INTEGER;
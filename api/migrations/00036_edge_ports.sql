@@ -0,0 +1,11 @@
+-- Stable ports on the edge proxy's anycast address. Unlike port_allocations they're kept
+-- while a server is stopped, so its address doesn't change when it moves between nodes.
+CREATE TABLE IF NOT EXISTS edge_ports (
+    port       INTEGER NOT NULL,
+    protocol   VARCHAR(10) NOT NULL,                 -- TCP or UDP
+    server_id  UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    port_name  VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (port, protocol),
+    UNIQUE (server_id, port_name)
+);
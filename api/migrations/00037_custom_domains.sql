@@ -0,0 +1,17 @@
+-- Domains users point at their servers. A domain is claimed by adding a TXT record with
+-- verification_token; only one server can have it verified at a time.
+CREATE TABLE IF NOT EXISTS custom_domains (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id          UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    domain             VARCHAR(253) NOT NULL,
+    verification_token VARCHAR(64) NOT NULL,
+    status             VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, verified or failed
+    last_error         TEXT,                                   -- Why the last verification failed
+    checked_at         TIMESTAMP WITH TIME ZONE,
+    verified_at        TIMESTAMP WITH TIME ZONE,
+    created_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (server_id, domain)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_domains_verified
+    ON custom_domains (domain) WHERE status = 'verified';
@@ -0,0 +1,30 @@
+-- Hourly CPU and memory usage per server, aggregated from supervisor heartbeats
+CREATE TABLE IF NOT EXISTS server_resource_usage (
+    server_id       UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    hour            TIMESTAMP WITH TIME ZONE NOT NULL,
+    samples         INTEGER NOT NULL DEFAULT 0,
+    cpu_percent_sum DOUBLE PRECISION NOT NULL DEFAULT 0, -- Percent of one core
+    cpu_percent_max DOUBLE PRECISION NOT NULL DEFAULT 0,
+    memory_mb_sum   BIGINT NOT NULL DEFAULT 0,
+    memory_mb_max   BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (server_id, hour)
+);
+
+-- Latest plan recommendation per server, regenerated weekly from server_resource_usage
+CREATE TABLE IF NOT EXISTS server_plan_recommendations (
+    server_id        UUID PRIMARY KEY REFERENCES servers(id) ON DELETE CASCADE,
+    direction        VARCHAR(20) NOT NULL, -- upgrade or downgrade
+    current_plan     VARCHAR(20) NOT NULL,
+    recommended_plan VARCHAR(20) NOT NULL,
+    reason           VARCHAR(50) NOT NULL,
+    avg_cpu_percent  DOUBLE PRECISION NOT NULL,
+    p95_cpu_percent  DOUBLE PRECISION NOT NULL,
+    avg_memory_mb    BIGINT NOT NULL,
+    peak_memory_mb   BIGINT NOT NULL,
+    price_delta      BIGINT,              -- Monthly difference in the smallest currency unit
+    currency         VARCHAR(3),
+    generated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- When the user was last sent the monthly recommendations summary
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recommendations_emailed_at TIMESTAMP WITH TIME ZONE;
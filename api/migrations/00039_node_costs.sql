@@ -0,0 +1,3 @@
+-- What each node costs per month at its provider, in the smallest unit of the Stripe prices'
+-- currency (NULL when not configured). Set by admins for margin reporting.
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS monthly_cost BIGINT;
@@ -0,0 +1,10 @@
+-- Cards whose owner was warned they expire before a subscription renews, so each card
+-- expiry is only warned about once
+CREATE TABLE IF NOT EXISTS card_expiry_warnings (
+    user_id           UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_method_id VARCHAR(255) NOT NULL,
+    exp_month         INTEGER NOT NULL,
+    exp_year          INTEGER NOT NULL,
+    sent_at           TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, payment_method_id, exp_year, exp_month)
+);
@@ -0,0 +1,7 @@
+-- Files commands produce for download (backup archives, world exports), stored in the
+-- server's data volume, and the progress of long-running commands
+ALTER TABLE server_commands
+    ADD COLUMN IF NOT EXISTS artifact_path TEXT,   -- Relative to the data volume
+    ADD COLUMN IF NOT EXISTS artifact_size BIGINT,
+    ADD COLUMN IF NOT EXISTS progress_done BIGINT,
+    ADD COLUMN IF NOT EXISTS progress_total BIGINT;
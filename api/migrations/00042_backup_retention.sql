@@ -0,0 +1,29 @@
+-- How many backups each server keeps and whether they're replicated off-site. Servers
+-- without a policy keep as many as their plan allows and don't replicate.
+CREATE TABLE IF NOT EXISTS server_backup_policies (
+    server_id   UUID PRIMARY KEY REFERENCES servers(id) ON DELETE CASCADE,
+    keep_daily  INTEGER NOT NULL,
+    keep_weekly INTEGER NOT NULL,
+    replicate   BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Off-site copies of backup archives. server_id has no foreign key, so copies of deleted
+-- servers stay tracked until they're removed from the bucket.
+CREATE TABLE IF NOT EXISTS backup_replicas (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id     UUID NOT NULL,
+    command_id    UUID,                                   -- Backup command that produced the archive
+    path          TEXT NOT NULL,                          -- Archive in the data volume
+    object_key    TEXT NOT NULL,
+    size          BIGINT NOT NULL DEFAULT 0,
+    state         VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, replicated, failed or deleting
+    attempts      INTEGER NOT NULL DEFAULT 0,
+    last_error    TEXT,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    replicated_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (server_id, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_backup_replicas_work
+    ON backup_replicas (created_at) WHERE state IN ('pending', 'deleting');
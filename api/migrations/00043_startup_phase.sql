@@ -0,0 +1,3 @@
+-- How far a starting server has got (installing, generating_world or starting), as reported
+-- by its supervisor. Cleared by every status transition that doesn't set it.
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS startup_phase VARCHAR(20);
@@ -0,0 +1,3 @@
+-- Spot nodes: nodes node sync finds carrying a provider's spot label run only spot (budget)
+-- plans, and their servers are moved elsewhere when the provider reclaims the node
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS spot BOOLEAN NOT NULL DEFAULT FALSE;
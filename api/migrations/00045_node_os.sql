@@ -0,0 +1,3 @@
+-- Node operating system, from the kubelet. Windows games are only placed on Windows nodes
+-- and Linux games only on Linux nodes.
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS os VARCHAR(20) NOT NULL DEFAULT 'linux';
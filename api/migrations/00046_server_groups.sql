@@ -0,0 +1,16 @@
+-- User-defined folders organizing servers in the dashboard
+CREATE TABLE IF NOT EXISTS server_groups (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name       VARCHAR(50) NOT NULL,
+    position   INTEGER NOT NULL DEFAULT 0,     -- Groups are listed by position, ascending
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_groups_user_id ON server_groups(user_id);
+
+-- The group a server is filed under, if any, and its place within the group
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS group_id UUID REFERENCES server_groups(id) ON DELETE SET NULL;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS group_position INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_servers_group_id ON servers(group_id) WHERE group_id IS NOT NULL;
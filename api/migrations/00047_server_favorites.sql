@@ -0,0 +1,9 @@
+-- Per-user server bookkeeping for dashboard navigation: favorites and when each server was
+-- last opened
+CREATE TABLE IF NOT EXISTS user_servers (
+    user_id          UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    server_id        UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    favorite         BOOLEAN NOT NULL DEFAULT FALSE,
+    last_accessed_at TIMESTAMP WITH TIME ZONE,       -- Last time the user opened the server's details
+    PRIMARY KEY (user_id, server_id)
+);
@@ -0,0 +1,23 @@
+-- Snapshots of servers' whole data volumes, taken on request while the server is stopped
+-- and stored in the backup bucket. server_id has no foreign key, so snapshots of deleted
+-- servers stay tracked until they're removed from the bucket.
+CREATE TABLE IF NOT EXISTS server_backups (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id            UUID NOT NULL,
+    object_key           TEXT NOT NULL,
+    size                 BIGINT NOT NULL DEFAULT 0,
+    state                VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed or deleting
+    error                TEXT,
+    restore_requested_at TIMESTAMP WITH TIME ZONE,               -- Set while the backup waits to be restored on the next start
+    created_at           TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at         TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_backups_server ON server_backups (server_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_server_backups_work
+    ON server_backups (created_at) WHERE state IN ('pending', 'running', 'deleting') OR restore_requested_at IS NOT NULL;
+
+-- A server restores at most one backup at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_server_backups_restore
+    ON server_backups (server_id) WHERE restore_requested_at IS NOT NULL;
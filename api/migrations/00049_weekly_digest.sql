@@ -0,0 +1,9 @@
+-- Peak players and crashes per hour, for the weekly digest (players_max is NULL if the
+-- game doesn't report players)
+ALTER TABLE server_resource_usage ADD COLUMN IF NOT EXISTS players_max INTEGER;
+ALTER TABLE server_resource_usage ADD COLUMN IF NOT EXISTS crashes INTEGER NOT NULL DEFAULT 0;
+
+-- weekly_digest: whether the user gets the weekly server activity email (opt-out)
+-- digest_emailed_at: when the user was last sent it
+ALTER TABLE users ADD COLUMN IF NOT EXISTS weekly_digest BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_emailed_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,19 @@
+-- When a server's subscription is set to end (cancelled at period end), from Stripe's
+-- subscription updates. Capacity checks use it to estimate when resources free up.
+ALTER TABLE servers ADD COLUMN cancel_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_servers_cancel_at ON servers (cancel_at) WHERE cancel_at IS NOT NULL;
+
+-- Users waiting for capacity for a game and plan, emailed once it frees up
+CREATE TABLE IF NOT EXISTS capacity_waitlist (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    game        VARCHAR(50) NOT NULL,
+    plan        VARCHAR(50) NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    notified_at TIMESTAMP WITH TIME ZONE
+);
+
+-- A user waits at most once per game and plan
+CREATE UNIQUE INDEX IF NOT EXISTS idx_capacity_waitlist_waiting
+    ON capacity_waitlist (user_id, game, plan) WHERE notified_at IS NULL;
@@ -0,0 +1,19 @@
+-- Waitlist signups are offered capacity one at a time per game, plan and region, in signup
+-- order, with a checkout link that works until offer_expires_at. A signup is fulfilled once
+-- its user checks out the game and plan.
+ALTER TABLE capacity_waitlist RENAME COLUMN notified_at TO offered_at;
+ALTER TABLE capacity_waitlist ADD COLUMN region VARCHAR(255) NOT NULL DEFAULT ''; -- '' = any region
+ALTER TABLE capacity_waitlist ADD COLUMN offer_expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE capacity_waitlist ADD COLUMN fulfilled_at TIMESTAMP WITH TIME ZONE;
+
+-- Signups already notified count as expired offers
+UPDATE capacity_waitlist SET offer_expires_at = offered_at WHERE offered_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_capacity_waitlist_waiting;
+
+-- A user waits at most once per game, plan and region
+CREATE UNIQUE INDEX IF NOT EXISTS idx_capacity_waitlist_waiting
+    ON capacity_waitlist (user_id, game, plan, region) WHERE offered_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_capacity_waitlist_queue
+    ON capacity_waitlist (game, plan, region, created_at) WHERE fulfilled_at IS NULL;
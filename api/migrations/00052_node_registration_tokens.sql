@@ -0,0 +1,17 @@
+-- One-time tokens admins hand to the node agent to join a machine as a game server node.
+-- Only the SHA-256 of the token is stored. The token is bound to the first node that
+-- registers with it and used up once the agent reports the node healthy.
+CREATE TABLE IF NOT EXISTS node_registration_tokens (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    token_hash    VARCHAR(64) NOT NULL UNIQUE,
+    region        VARCHAR(255) NOT NULL DEFAULT '', -- Location labels applied to the node ('' = not set)
+    zone          VARCHAR(255) NOT NULL DEFAULT '',
+    provider      VARCHAR(255) NOT NULL DEFAULT '',
+    datacenter    VARCHAR(255) NOT NULL DEFAULT '',
+    created_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+    node_name     VARCHAR(255),                     -- Set once an agent registers with the token
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    registered_at TIMESTAMP WITH TIME ZONE,
+    completed_at  TIMESTAMP WITH TIME ZONE,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,21 @@
+-- Cron-style tasks users schedule on their servers: restarts, console commands and backups.
+-- The scheduler runs due tasks on running servers and records what happened in last_result.
+CREATE TABLE IF NOT EXISTS server_schedules (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id   UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    name        VARCHAR(100) NOT NULL DEFAULT '',
+    cron        VARCHAR(100) NOT NULL,
+    timezone    VARCHAR(64) NOT NULL DEFAULT 'UTC', -- IANA time zone the cron expression is in
+    action      VARCHAR(20) NOT NULL,               -- restart, command or backup
+    payload     TEXT,                               -- Console command of command tasks
+    enabled     BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_result TEXT,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_schedules_server ON server_schedules (server_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_server_schedules_due ON server_schedules (next_run_at) WHERE enabled;
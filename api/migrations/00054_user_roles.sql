@@ -0,0 +1,3 @@
+-- Roles grant access to the /admin API. ADMIN_EMAILS stay admins regardless, so the first
+-- operator can grant the role to others.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user'; -- user or admin
@@ -0,0 +1,9 @@
+-- The host port range game servers are allocated on each node, once an admin changed it.
+-- Until then PORT_RANGE_MIN and PORT_RANGE_MAX apply. Holds at most one row.
+CREATE TABLE IF NOT EXISTS port_range (
+    id         BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    min_port   INT NOT NULL,
+    max_port   INT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (min_port <= max_port)
+);
@@ -0,0 +1,12 @@
+-- Host ports game servers must not get, e.g. node-exporter, alternate SSH ports or ranges
+-- the provider reserves. Their slots are left out of port_allocations; ports servers held
+-- when they were blocked are dropped once released.
+CREATE TABLE IF NOT EXISTS blocked_ports (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    min_port   INT NOT NULL,
+    max_port   INT NOT NULL,
+    protocol   VARCHAR(10),               -- TCP or UDP, NULL blocks both
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (min_port <= max_port)
+);
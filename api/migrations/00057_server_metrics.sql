@@ -0,0 +1,15 @@
+-- Per-minute CPU, memory and player usage per server from supervisor heartbeats, charted
+-- for the owner. Kept for a week by the cleanup service.
+CREATE TABLE IF NOT EXISTS server_metrics (
+    server_id       UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    minute          TIMESTAMP WITH TIME ZONE NOT NULL,
+    samples         INTEGER NOT NULL DEFAULT 0,
+    cpu_percent_sum DOUBLE PRECISION NOT NULL DEFAULT 0, -- Percent of one core
+    cpu_percent_max DOUBLE PRECISION NOT NULL DEFAULT 0,
+    memory_mb_sum   BIGINT NOT NULL DEFAULT 0,
+    memory_mb_max   BIGINT NOT NULL DEFAULT 0,
+    players_max     INTEGER,                             -- NULL if the game doesn't report players
+    PRIMARY KEY (server_id, minute)
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_metrics_minute ON server_metrics (minute);
@@ -0,0 +1,3 @@
+-- Port number a server's game port is allocated first, if free on some node (premium plans)
+ALTER TABLE pending_server_requests ADD COLUMN IF NOT EXISTS preferred_port INTEGER;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS preferred_port INTEGER;
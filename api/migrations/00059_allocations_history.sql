@@ -0,0 +1,19 @@
+-- Every port a server was allocated or released, with why, so operators can explain a
+-- server's port changing. server_id has no foreign key, so the history of deleted servers
+-- stays until the cleanup service trims it.
+CREATE TABLE IF NOT EXISTS allocations_history (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id   UUID NOT NULL,
+    node_name   VARCHAR(255) NOT NULL,
+    port        INT NOT NULL,
+    protocol    VARCHAR(10) NOT NULL,
+    port_name   VARCHAR(50),
+    action      VARCHAR(20) NOT NULL, -- allocated or released
+    cause       VARCHAR(20) NOT NULL, -- create, restart, reallocate, requeue, upgrade, expiry, suspension or admin
+    detail      TEXT,                 -- e.g. why the reconciler reallocated
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_allocations_history_server ON allocations_history (server_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_allocations_history_port ON allocations_history (node_name, port, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_allocations_history_created ON allocations_history (created_at);
@@ -0,0 +1,15 @@
+-- Mods and plugins owners installed from Modrinth, one version per project and server.
+-- path is the file in the data volume, relative to its root.
+CREATE TABLE IF NOT EXISTS server_mods (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id       UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    project_id      VARCHAR(64) NOT NULL,
+    version_id      VARCHAR(64) NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    version_number  VARCHAR(255) NOT NULL,
+    loader          VARCHAR(50) NOT NULL,
+    path            TEXT NOT NULL,
+    size            BIGINT NOT NULL DEFAULT 0,
+    installed_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (server_id, project_id)
+);
@@ -0,0 +1,6 @@
+-- Released ports cool down before other servers get them, so stale DNS and client caches
+-- don't reach someone else's server. Free ports are handed out longest-free first; the
+-- server that released a port may take it back during its cool-down.
+ALTER TABLE port_allocations ADD COLUMN IF NOT EXISTS released_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE port_allocations ADD COLUMN IF NOT EXISTS released_server_id UUID;
+ALTER TABLE port_allocations ADD COLUMN IF NOT EXISTS cooldown_until TIMESTAMP WITH TIME ZONE;
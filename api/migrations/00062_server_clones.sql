@@ -0,0 +1,15 @@
+-- Servers created as a copy of another server of the same owner. The new server's data
+-- volume is filled with the source's before its first start; the source must stay stopped
+-- while it's copied.
+ALTER TABLE pending_server_requests ADD COLUMN IF NOT EXISTS clone_source_id UUID REFERENCES servers(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS server_clones (
+    server_id        UUID PRIMARY KEY REFERENCES servers(id) ON DELETE CASCADE,
+    source_server_id UUID REFERENCES servers(id) ON DELETE SET NULL,
+    state            VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, copying, completed or failed
+    error            TEXT,
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at     TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_clones_active ON server_clones(state) WHERE state IN ('pending', 'copying');
@@ -0,0 +1,11 @@
+-- Two-factor authentication with TOTP authenticator apps. A row without enabled_at is an
+-- enrollment waiting for its first code. Backup codes are stored as SHA-256 hashes and
+-- removed once used.
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id            UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret             TEXT NOT NULL, -- Base32 TOTP secret
+    backup_code_hashes TEXT[] NOT NULL DEFAULT '{}',
+    last_used_step     BIGINT NOT NULL DEFAULT 0, -- Codes of this time step or earlier are rejected, so each works once
+    enabled_at         TIMESTAMP WITH TIME ZONE,
+    created_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,14 @@
+-- Periods a server's game was online, from entering running until leaving it or its last
+-- heartbeat before they stopped. Heartbeats move last_seen_at; a session with ended_at unset
+-- is the current one.
+CREATE TABLE IF NOT EXISTS server_sessions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id    UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    started_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ended_at     TIMESTAMP WITH TIME ZONE,
+    end_status   VARCHAR(20) -- Status the server left running for; unset if its heartbeats stopped
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_server_sessions_open ON server_sessions(server_id) WHERE ended_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_server_sessions_server ON server_sessions(server_id, started_at DESC);
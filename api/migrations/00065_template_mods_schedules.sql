@@ -0,0 +1,17 @@
+-- Mods and scheduled tasks saved with a template, and carried to servers created from it
+ALTER TABLE server_templates ADD COLUMN IF NOT EXISTS mods JSONB;
+ALTER TABLE server_templates ADD COLUMN IF NOT EXISTS schedules JSONB;
+
+ALTER TABLE pending_server_requests ADD COLUMN IF NOT EXISTS template_mods JSONB;
+ALTER TABLE pending_server_requests ADD COLUMN IF NOT EXISTS template_schedules JSONB;
+
+-- Mods a server created from a template still has to install. Mod files can only be written
+-- to a running server, so the owner installs them from there.
+CREATE TABLE IF NOT EXISTS server_pending_mods (
+    server_id   UUID NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    project_id  VARCHAR(64) NOT NULL,
+    version_id  VARCHAR(64) NOT NULL,
+    name        VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (server_id, project_id)
+);
@@ -0,0 +1,61 @@
+// Command genvalues renders the environment expected by config.Load, so game
+// server manifests and the API reconciler can be checked against the Go code.
+//
+// Usage:
+//
+//	go run ./cmd/genvalues -format values # Helm-style values.yaml
+//	go run ./cmd/genvalues -format env    # Deployment container env list
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/mooncorn/gshub/supervisor/internal/config"
+)
+
+func main() {
+	format := flag.String("format", "values", "output format: values or env")
+	flag.Parse()
+
+	fmt.Println("# Code generated by cmd/genvalues from config.EnvVars. DO NOT EDIT.")
+	switch *format {
+	case "values":
+		printValues()
+	case "env":
+		printEnv()
+	default:
+		log.Fatalf("unknown format %q (want values or env)", *format)
+	}
+}
+
+func printValues() {
+	fmt.Println("supervisor:")
+	fmt.Println("  env:")
+	for _, v := range config.EnvVars {
+		fmt.Printf("    # %s%s\n", v.Description, requiredSuffix(v))
+		fmt.Printf("    %s: %s\n", v.Name, strconv.Quote(v.Default))
+	}
+}
+
+func printEnv() {
+	for _, v := range config.EnvVars {
+		fmt.Printf("# %s%s\n", v.Description, requiredSuffix(v))
+		fmt.Printf("- name: %s\n", v.Name)
+		fmt.Printf("  value: %s\n", strconv.Quote(v.Default))
+	}
+}
+
+func requiredSuffix(v config.EnvVar) string {
+	switch {
+	case v.Required && v.Secret:
+		return " (required, secret)"
+	case v.Required:
+		return " (required)"
+	case v.Secret:
+		return " (secret)"
+	}
+	return ""
+}
@@ -2,7 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/mooncorn/gshub/supervisor/internal/api"
@@ -10,10 +16,21 @@ import (
 	supervisorhttp "github.com/mooncorn/gshub/supervisor/internal/http"
 	"github.com/mooncorn/gshub/supervisor/internal/metrics"
 	"github.com/mooncorn/gshub/supervisor/internal/process"
+	"github.com/mooncorn/gshub/supervisor/internal/volume"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	// commandPollWait is how long each command poll is held open by the API
+	commandPollWait = 25 * time.Second
+	// commandPollRetryDelay is the pause after a failed command poll
+	commandPollRetryDelay = 5 * time.Second
+	// maxHeartbeatSamples caps the samples sent with one heartbeat, matching the API's limit;
+	// the oldest are dropped first
+	maxHeartbeatSamples = 60
+)
+
 func main() {
 	// Initialize logger
 	logConfig := zap.NewProductionConfig()
@@ -26,17 +43,31 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Started by the API to give the owner of an expired server read-only access to its data
+	if dir := os.Getenv("GSHUB_FILE_ACCESS_DIR"); dir != "" {
+		runFileAccess(dir, logger)
+		return
+	}
+
+	// Started by the API to back up or restore a stopped server's data volume
+	if job := os.Getenv("GSHUB_VOLUME_JOB"); job != "" {
+		runVolumeJob(job, logger)
+		return
+	}
+
 	logger.Info("supervisor starting")
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
+		reportConfigError(cfg, err, logger)
 		logger.Fatal("failed to load config", zap.Error(err))
 	}
 
 	logger.Info("configuration loaded",
 		zap.String("server_id", cfg.ServerID),
 		zap.String("api_endpoint", cfg.APIEndpoint),
+		zap.String("api_grpc_endpoint", cfg.APIGRPCEndpoint),
 		zap.Strings("start_command", cfg.StartCommand),
 		zap.String("work_dir", cfg.WorkDir),
 		zap.Duration("grace_period", cfg.GracePeriod),
@@ -48,15 +79,39 @@ func main() {
 
 	// Initialize API client
 	apiClient := api.NewClient(cfg.APIEndpoint, cfg.ServerID, cfg.AuthToken, logger)
+	if cfg.APIGRPCEndpoint != "" {
+		if err := apiClient.UseGRPC(cfg.APIGRPCEndpoint); err != nil {
+			logger.Warn("failed to set up gRPC, using the JSON endpoints", zap.Error(err))
+		}
+	}
+	defer apiClient.Close()
+
+	// Reap orphans of the game's launcher scripts, as PID 1 of the container
+	reaper := process.NewReaper(logger)
+	reaper.Start(ctx)
 
 	// Initialize process manager
-	manager, err := process.NewManager(cfg, apiClient, logger)
+	manager, err := process.NewManager(cfg, apiClient, reaper, logger)
 	if err != nil {
 		logger.Fatal("failed to create process manager", zap.Error(err))
 	}
 
 	// Start HTTP health server for K8s probes
 	healthServer := supervisorhttp.NewServer(cfg.HealthServerPort, manager, logger)
+	if cfg.DataDir != "" {
+		files, err := supervisorhttp.NewFileServer(cfg.HealthServerPort, cfg.DataDir, cfg.AuthToken, logger)
+		if err != nil {
+			logger.Warn("files, backups and exports can't be accessed", zap.Error(err))
+		} else {
+			// The owner manages the running server's files through the API
+			files.AllowWrites()
+			healthServer.ServeFiles(files)
+		}
+	}
+	if cfg.ImportDir != "" {
+		healthServer.ServeImports(supervisorhttp.NewImportReceiver(cfg.DataDir, cfg.AuthToken, logger))
+	}
+	healthServer.ServeConsole(supervisorhttp.NewConsoleHandler(manager, cfg.AuthToken, logger))
 	go func() {
 		if err := healthServer.Start(ctx); err != nil {
 			logger.Error("health server error", zap.Error(err))
@@ -67,6 +122,10 @@ func main() {
 	signalHandler := process.NewSignalHandler(manager, logger)
 	signalHandler.Start(ctx)
 
+	// Imports queued while the server was being created run before the first start, so the
+	// game never generates a world of its own
+	claimed := runEarlyImports(ctx, cfg, apiClient, manager, logger)
+
 	// Start the game process
 	if err := manager.Start(ctx); err != nil {
 		logger.Error("failed to start game process", zap.Error(err))
@@ -74,13 +133,14 @@ func main() {
 	}
 
 	// Start continuous health monitoring after startup
-	go manager.StartContinuousHealthCheck(ctx, func(status, message string) {
-		apiClient.ReportStatusWithRetry(ctx, api.Status(status), message, manager.PID(), 3)
-	})
+	go manager.StartContinuousHealthCheck(ctx)
 
 	// Start heartbeat loop
 	go runHeartbeat(ctx, cfg, apiClient, manager, logger)
 
+	// Start polling the API for commands (graceful stop, config reload, backup, console input, export, import)
+	go runCommandLoop(ctx, apiClient, manager, logger, claimed)
+
 	// Wait for the process to exit (either from signal or crash)
 	manager.Wait()
 
@@ -99,34 +159,238 @@ func main() {
 	}
 }
 
-// runHeartbeat sends periodic heartbeats to the API
+// fileAccessPort is where the file server listens in file access mode
+const fileAccessPort = 8080
+
+// runFileAccess serves dir read-only until the pod is terminated, instead of running a game
+func runFileAccess(dir string, logger *zap.Logger) {
+	token := os.Getenv("GSHUB_FILE_ACCESS_TOKEN")
+	if token == "" {
+		logger.Fatal("GSHUB_FILE_ACCESS_TOKEN is required in file access mode")
+	}
+
+	server, err := supervisorhttp.NewFileServer(fileAccessPort, dir, token, logger)
+	if err != nil {
+		logger.Fatal("failed to create file server", zap.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	if err := server.Start(ctx); err != nil {
+		logger.Fatal("file server failed", zap.Error(err))
+	}
+}
+
+// terminationLogPath is where Kubernetes reads a container's termination message from
+const terminationLogPath = "/dev/termination-log"
+
+// runVolumeJob backs up (job "backup") or restores (job "restore") the volume at
+// GSHUB_VOLUME_DIR through the presigned object storage URL in GSHUB_VOLUME_URL, or fills it
+// from the volume mounted at GSHUB_VOLUME_SOURCE (job "copy"), then exits. The result, the
+// archive size or the error, is left as the termination message for the API.
+func runVolumeJob(job string, logger *zap.Logger) {
+	dir := os.Getenv("GSHUB_VOLUME_DIR")
+	url := os.Getenv("GSHUB_VOLUME_URL")
+	source := os.Getenv("GSHUB_VOLUME_SOURCE")
+	if dir == "" {
+		logger.Fatal("GSHUB_VOLUME_DIR is required for volume jobs")
+	}
+	if job == "copy" && source == "" {
+		logger.Fatal("GSHUB_VOLUME_SOURCE is required for copy jobs")
+	}
+	if job != "copy" && url == "" {
+		logger.Fatal("GSHUB_VOLUME_URL is required for backup and restore jobs")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	var result string
+	var err error
+	switch job {
+	case "backup":
+		var size int64
+		size, err = volume.Backup(ctx, dir, url, logger)
+		result = strconv.FormatInt(size, 10)
+	case "restore":
+		err = volume.Restore(ctx, dir, url, logger)
+	case "copy":
+		err = volume.Copy(ctx, source, dir, logger)
+	default:
+		err = errors.New("unknown volume job " + job)
+	}
+
+	if err != nil {
+		os.WriteFile(terminationLogPath, []byte(err.Error()), 0o644)
+		logger.Fatal("volume job failed", zap.String("job", job), zap.Error(err))
+	}
+	os.WriteFile(terminationLogPath, []byte(result), 0o644)
+}
+
+// runHeartbeat samples the game's resource usage every MetricsSampleInterval and sends the
+// samples in batches with each heartbeat
 func runHeartbeat(ctx context.Context, cfg *config.Config, apiClient *api.Client, manager *process.Manager, logger *zap.Logger) {
 	ticker := time.NewTicker(cfg.HeartbeatInterval)
 	defer ticker.Stop()
+	sampleTicker := time.NewTicker(min(cfg.MetricsSampleInterval, cfg.HeartbeatInterval))
+	defer sampleTicker.Stop()
+
+	// CPU usage is computed between consecutive samples
+	var sampler metrics.Sampler
+	// Kept until a heartbeat succeeds, so samples survive a brief API outage
+	var samples []api.MetricSample
+	var netTxBytes int64
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-sampleTicker.C:
+			if !manager.IsRunning() {
+				continue
+			}
+			// Collect actual memory, CPU and network metrics from procfs
+			processMetrics, err := sampler.Sample(manager.PID())
+			if err != nil {
+				continue
+			}
+			samples = append(samples, api.MetricSample{
+				SampledAt:  time.Now().UTC(),
+				MemoryMB:   processMetrics.MemoryMB,
+				CPUPercent: processMetrics.CPUPercent,
+			})
+			if len(samples) > maxHeartbeatSamples {
+				samples = samples[len(samples)-maxHeartbeatSamples:]
+			}
+			netTxBytes = processMetrics.NetTxBytes
 		case <-ticker.C:
 			if manager.IsRunning() {
 				pid := manager.PID()
-
-				// Collect actual memory metrics from procfs
-				memoryMB := int64(0)
-				cpuPercent := float64(0)
-
-				if processMetrics, err := metrics.CollectProcessMetrics(pid); err == nil {
-					memoryMB = processMetrics.MemoryMB
-					cpuPercent = processMetrics.CPUPercent
-				}
-
-				if err := apiClient.SendHeartbeat(ctx, pid, memoryMB, cpuPercent); err != nil {
+				if err := apiClient.SendHeartbeat(ctx, pid, samples, netTxBytes, manager.PlayersOnline()); err != nil {
 					logger.Warn("failed to send heartbeat", zap.Error(err))
 				} else {
-					logger.Debug("heartbeat sent", zap.Int("pid", pid), zap.Int64("memory_mb", memoryMB))
+					logger.Debug("heartbeat sent", zap.Int("pid", pid), zap.Int("samples", len(samples)))
+					samples = nil
 				}
 			}
 		}
 	}
 }
+
+// runEarlyImports handles import commands that were queued before the supervisor started,
+// and returns the other commands claimed along with them
+func runEarlyImports(ctx context.Context, cfg *config.Config, apiClient *api.Client, manager *process.Manager, logger *zap.Logger) []api.Command {
+	if cfg.ImportDir == "" {
+		return nil
+	}
+
+	commands, err := apiClient.PollCommands(ctx, 0)
+	if err != nil {
+		logger.Warn("failed to poll commands before start", zap.Error(err))
+		return nil
+	}
+
+	var rest []api.Command
+	for _, cmd := range commands {
+		if cmd.Type != api.CommandImport {
+			rest = append(rest, cmd)
+			continue
+		}
+		handleCommand(ctx, apiClient, manager, logger, cmd)
+	}
+	return rest
+}
+
+// runCommandLoop long-polls the API for queued commands and reports each result, starting
+// with commands that were already claimed. Backups and exports can take many minutes, so
+// they run one at a time beside the loop, which keeps handling stops and other commands.
+func runCommandLoop(ctx context.Context, apiClient *api.Client, manager *process.Manager, logger *zap.Logger, claimed []api.Command) {
+	var backgroundMu sync.Mutex
+	dispatch := func(cmd api.Command) {
+		if cmd.Type != api.CommandBackup && cmd.Type != api.CommandExport {
+			handleCommand(ctx, apiClient, manager, logger, cmd)
+			return
+		}
+		go func() {
+			backgroundMu.Lock()
+			defer backgroundMu.Unlock()
+			handleCommand(ctx, apiClient, manager, logger, cmd)
+		}()
+	}
+
+	for _, cmd := range claimed {
+		dispatch(cmd)
+	}
+
+	for {
+		commands, err := apiClient.PollCommands(ctx, commandPollWait)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warn("failed to poll commands", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(commandPollRetryDelay):
+			}
+			continue
+		}
+
+		for _, cmd := range commands {
+			dispatch(cmd)
+		}
+
+		if !manager.IsRunning() {
+			return
+		}
+	}
+}
+
+// handleCommand performs a command and reports its result to the API
+func handleCommand(ctx context.Context, apiClient *api.Client, manager *process.Manager, logger *zap.Logger, cmd api.Command) {
+	logger.Info("received command", zap.String("command_id", cmd.ID), zap.String("type", string(cmd.Type)))
+
+	result, artifact, err := manager.HandleCommand(ctx, cmd)
+	success := err == nil
+	if err != nil {
+		logger.Warn("command failed", zap.String("command_id", cmd.ID), zap.Error(err))
+		result = err.Error()
+	}
+
+	// Report even if ctx was cancelled by a stop command
+	reportCtx, reportCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer reportCancel()
+	if err := apiClient.ReportCommandResult(reportCtx, cmd.ID, success, result, artifact); err != nil {
+		logger.Warn("failed to report command result", zap.String("command_id", cmd.ID), zap.Error(err))
+	}
+}
+
+// reportConfigError logs every configuration problem and, if the API is reachable
+// with the loaded settings, reports the server as failed so operators see the
+// misconfiguration (e.g. a bad catalog entry) without digging through pod logs.
+func reportConfigError(cfg *config.Config, err error, logger *zap.Logger) {
+	var validationErr *config.ValidationError
+	if !errors.As(err, &validationErr) {
+		return
+	}
+
+	for _, problem := range validationErr.Problems {
+		logger.Error("invalid configuration", zap.String("problem", problem))
+	}
+
+	if cfg == nil || !cfg.CanReport() {
+		logger.Warn("cannot report configuration error to API: connection settings are missing or invalid")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	apiClient := api.NewClient(cfg.APIEndpoint, cfg.ServerID, cfg.AuthToken, logger)
+	message := "Invalid server configuration: " + strings.Join(validationErr.Problems, "; ")
+	if err := apiClient.ReportStatusWithReason(ctx, api.StatusFailed, message, api.ReasonInvalidConfig, 0); err != nil {
+		logger.Warn("failed to report configuration error to API", zap.Error(err))
+	}
+}
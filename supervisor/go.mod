@@ -2,6 +2,16 @@ module github.com/mooncorn/gshub/supervisor
 
 go 1.25.0
 
-require go.uber.org/zap v1.27.0
+require (
+	go.uber.org/zap v1.27.0
+	google.golang.org/grpc v1.75.0
+	google.golang.org/protobuf v1.36.9
+)
 
-require go.uber.org/multierr v1.10.0 // indirect
+require (
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7 // indirect
+)
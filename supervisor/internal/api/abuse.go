@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// BannedBinaryRequest reports a banned binary found before starting the game
+type BannedBinaryRequest struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// GetBannedHashes fetches the SHA-256 hashes of binaries the supervisor must refuse to run
+func (c *Client) GetBannedHashes(ctx context.Context) ([]string, error) {
+	if hashes, handled, err := c.bannedHashesGRPC(ctx); handled {
+		return hashes, err
+	}
+
+	url := fmt.Sprintf("%s/internal/servers/%s/banned-hashes", c.baseURL, c.serverID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.authToken)
+
+	resp, err := c.do(c.httpClient, req)
+	if errors.Is(err, ErrCircuitOpen) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &StatusCodeError{Code: resp.StatusCode}
+	}
+
+	var body struct {
+		Hashes []string `json:"hashes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode banned hashes: %w", err)
+	}
+
+	return body.Hashes, nil
+}
+
+// ReportBannedBinary reports a banned binary; the API suspends the server
+func (c *Client) ReportBannedBinary(ctx context.Context, path, sha256 string) error {
+	req := BannedBinaryRequest{
+		Path:   path,
+		SHA256: sha256,
+	}
+	if handled, err := c.reportBannedBinaryGRPC(ctx, req); handled {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/internal/servers/%s/banned-binary", c.baseURL, c.serverID)
+	return c.post(ctx, url, req)
+}
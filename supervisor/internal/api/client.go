@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -22,18 +24,58 @@ const (
 	StatusFailed   Status = "failed"
 )
 
+// Reason is a machine-readable cause accompanying a status, matching the API's reason codes
+type Reason string
+
+const (
+	ReasonInvalidConfig    Reason = "INVALID_CONFIG"
+	ReasonGameUnresponsive Reason = "GAME_UNRESPONSIVE"
+)
+
+// Phase is what a starting game is busy with, matching the API's startup phases
+type Phase string
+
+const (
+	PhaseInstalling      Phase = "installing"       // Downloading or updating game files
+	PhaseGeneratingWorld Phase = "generating_world" // Creating or loading the world
+	PhaseStarting        Phase = "starting"         // Launching the game
+)
+
 // StatusUpdateRequest is sent to report status changes
 type StatusUpdateRequest struct {
 	Status     Status `json:"status"`
 	Message    string `json:"message,omitempty"`
+	Reason     Reason `json:"reason,omitempty"`
+	Phase      Phase  `json:"phase,omitempty"` // Only for starting
 	ProcessPID int    `json:"process_pid,omitempty"`
 }
 
+// ProgressRequest is sent as a starting game passes a progress milestone
+type ProgressRequest struct {
+	Label   string `json:"label"`
+	Percent int    `json:"percent"`
+}
+
 // HeartbeatRequest is sent periodically while running
 type HeartbeatRequest struct {
 	ProcessPID int     `json:"process_pid"`
 	MemoryMB   int64   `json:"memory_mb,omitempty"`
 	CPUPercent float64 `json:"cpu_percent,omitempty"`
+	NetTxBytes int64   `json:"net_tx_bytes,omitempty"`
+
+	// PlayersOnline is nil for games whose output doesn't report players
+	PlayersOnline *int `json:"players_online,omitempty"`
+
+	// Samples are the resource usage samples taken since the last successful heartbeat,
+	// oldest first. MemoryMB and CPUPercent repeat the latest one.
+	Samples []MetricSample `json:"samples,omitempty"`
+}
+
+// MetricSample is the game process's resource usage at one point in time
+type MetricSample struct {
+	SampledAt  time.Time `json:"sampled_at"`
+	MemoryMB   int64     `json:"memory_mb"`
+	CPUPercent float64   `json:"cpu_percent"`
 }
 
 // Client communicates with the gshub API internal endpoint
@@ -43,6 +85,14 @@ type Client struct {
 	serverID    string
 	authToken   string
 	logger      *zap.Logger
+
+	breaker circuitBreaker
+	grpc    *grpcTransport // Nil if only the JSON endpoints are used
+
+	// statusMu serializes status deliveries; pendingStatus is the latest status not yet
+	// delivered, sent when the API recovers
+	statusMu      sync.Mutex
+	pendingStatus *StatusUpdateRequest
 }
 
 // NewClient creates a new API client
@@ -60,22 +110,78 @@ func NewClient(baseURL, serverID, authToken string, logger *zap.Logger) *Client
 
 // ReportStatus sends a status update to the API
 func (c *Client) ReportStatus(ctx context.Context, status Status, message string, pid int) error {
+	return c.ReportStatusWithReason(ctx, status, message, "", pid)
+}
+
+// ReportStatusWithReason sends a status update with a reason code to the API
+func (c *Client) ReportStatusWithReason(ctx context.Context, status Status, message string, reason Reason, pid int) error {
 	req := StatusUpdateRequest{
 		Status:     status,
 		Message:    message,
+		Reason:     reason,
+		ProcessPID: pid,
+	}
+	return c.sendStatus(ctx, req)
+}
+
+// ReportPhase tells the API what the starting game is busy with
+func (c *Client) ReportPhase(ctx context.Context, phase Phase, message string, pid int) error {
+	req := StatusUpdateRequest{
+		Status:     StatusStarting,
+		Message:    message,
+		Phase:      phase,
 		ProcessPID: pid,
 	}
+	return c.sendStatus(ctx, req)
+}
+
+// sendStatus sends a status update once. It supersedes any queued status, which would
+// otherwise be delivered after it.
+func (c *Client) sendStatus(ctx context.Context, req StatusUpdateRequest) error {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.pendingStatus = nil
+	return c.postStatus(ctx, &req)
+}
 
+// postStatus delivers a status update, over gRPC if available
+func (c *Client) postStatus(ctx context.Context, req *StatusUpdateRequest) error {
+	if handled, err := c.reportStatusGRPC(ctx, req); handled {
+		return err
+	}
 	url := fmt.Sprintf("%s/internal/servers/%s/status", c.baseURL, c.serverID)
 	return c.post(ctx, url, req)
 }
 
-// SendHeartbeat sends a heartbeat to the API
-func (c *Client) SendHeartbeat(ctx context.Context, pid int, memoryMB int64, cpuPercent float64) error {
+// ReportProgress tells the API how far the starting game has got with a milestone
+func (c *Client) ReportProgress(ctx context.Context, label string, percent int) error {
+	req := ProgressRequest{
+		Label:   label,
+		Percent: percent,
+	}
+	if handled, err := c.reportProgressGRPC(ctx, req); handled {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/internal/servers/%s/progress", c.baseURL, c.serverID)
+	return c.post(ctx, url, req)
+}
+
+// SendHeartbeat sends a heartbeat to the API with the samples taken since the last one
+func (c *Client) SendHeartbeat(ctx context.Context, pid int, samples []MetricSample, netTxBytes int64, playersOnline *int) error {
 	req := HeartbeatRequest{
-		ProcessPID: pid,
-		MemoryMB:   memoryMB,
-		CPUPercent: cpuPercent,
+		ProcessPID:    pid,
+		NetTxBytes:    netTxBytes,
+		PlayersOnline: playersOnline,
+		Samples:       samples,
+	}
+	if len(samples) > 0 {
+		latest := samples[len(samples)-1]
+		req.MemoryMB = latest.MemoryMB
+		req.CPUPercent = latest.CPUPercent
+	}
+	if handled, err := c.heartbeatGRPC(ctx, req); handled {
+		return err
 	}
 
 	url := fmt.Sprintf("%s/internal/servers/%s/heartbeat", c.baseURL, c.serverID)
@@ -97,47 +203,18 @@ func (c *Client) post(ctx context.Context, url string, body interface{}) error {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.authToken)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(c.httpClient, req)
+	if errors.Is(err, ErrCircuitOpen) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return &StatusCodeError{Code: resp.StatusCode}
 	}
 
 	return nil
 }
-
-// ReportStatusWithRetry sends a status update with retries
-func (c *Client) ReportStatusWithRetry(ctx context.Context, status Status, message string, pid int, maxRetries int) {
-	for i := 0; i <= maxRetries; i++ {
-		err := c.ReportStatus(ctx, status, message, pid)
-		if err == nil {
-			c.logger.Info("reported status",
-				zap.String("status", string(status)),
-				zap.String("message", message),
-				zap.Int("pid", pid))
-			return
-		}
-
-		c.logger.Warn("failed to report status, retrying",
-			zap.Error(err),
-			zap.Int("attempt", i+1),
-			zap.Int("max_retries", maxRetries))
-
-		if i < maxRetries {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(time.Duration(i+1) * time.Second):
-				// Exponential backoff
-			}
-		}
-	}
-
-	c.logger.Error("failed to report status after retries",
-		zap.String("status", string(status)),
-		zap.Int("max_retries", maxRetries))
-}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// CommandType is an action requested by the API, matching the API's command types
+type CommandType string
+
+const (
+	CommandStop           CommandType = "stop"
+	CommandReloadConfig   CommandType = "reload_config"
+	CommandBackup         CommandType = "backup"
+	CommandExec           CommandType = "exec"
+	CommandRestartProcess CommandType = "restart_process"
+	CommandExport         CommandType = "export"
+	CommandImport         CommandType = "import"
+)
+
+// Command is an action queued by the API for this server
+type Command struct {
+	ID      string      `json:"id"`
+	Type    CommandType `json:"type"`
+	Payload string      `json:"payload,omitempty"`
+}
+
+// Artifact is a file a command produced for the user to download, e.g. a backup archive
+type Artifact struct {
+	Path string `json:"path"` // Relative to the data directory
+	Size int64  `json:"size"`
+}
+
+// CommandResultRequest reports the outcome of a command
+type CommandResultRequest struct {
+	Success  bool      `json:"success"`
+	Result   string    `json:"result,omitempty"`
+	Artifact *Artifact `json:"artifact,omitempty"`
+}
+
+// CommandProgressRequest reports how far a long-running command has got
+type CommandProgressRequest struct {
+	DoneBytes  int64 `json:"done_bytes"`
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// PollCommands long-polls the API for queued commands, waiting up to wait for one to arrive
+func (c *Client) PollCommands(ctx context.Context, wait time.Duration) ([]Command, error) {
+	// Over gRPC the API pushes commands on a stream kept open between polls
+	if wait > 0 {
+		if commands, handled, err := c.pollCommandStream(ctx, wait); handled {
+			return commands, err
+		}
+	} else if commands, handled, err := c.claimCommandsGRPC(ctx); handled {
+		return commands, err
+	}
+
+	url := fmt.Sprintf("%s/internal/servers/%s/commands?wait=%d", c.baseURL, c.serverID, int(wait.Seconds()))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.authToken)
+
+	// The API holds the request open for up to wait, so the default timeout is too short
+	httpClient := &http.Client{Timeout: wait + c.httpClient.Timeout}
+	resp, err := c.do(httpClient, req)
+	if errors.Is(err, ErrCircuitOpen) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &StatusCodeError{Code: resp.StatusCode}
+	}
+
+	var body struct {
+		Commands []Command `json:"commands"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode commands: %w", err)
+	}
+
+	return body.Commands, nil
+}
+
+// ReportCommandResult sends the outcome of a command, and the file it produced if any, to the API
+func (c *Client) ReportCommandResult(ctx context.Context, commandID string, success bool, result string, artifact *Artifact) error {
+	req := CommandResultRequest{
+		Success:  success,
+		Result:   result,
+		Artifact: artifact,
+	}
+	if handled, err := c.reportCommandResultGRPC(ctx, commandID, req); handled {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/internal/servers/%s/commands/%s/result", c.baseURL, c.serverID, commandID)
+	return c.post(ctx, url, req)
+}
+
+// ReportCommandProgress sends the progress of a long-running command to the API
+func (c *Client) ReportCommandProgress(ctx context.Context, commandID string, doneBytes, totalBytes int64) error {
+	req := CommandProgressRequest{
+		DoneBytes:  doneBytes,
+		TotalBytes: totalBytes,
+	}
+	if handled, err := c.reportCommandProgressGRPC(ctx, commandID, req); handled {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/internal/servers/%s/commands/%s/progress", c.baseURL, c.serverID, commandID)
+	return c.post(ctx, url, req)
+}
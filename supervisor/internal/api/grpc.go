@@ -0,0 +1,328 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mooncorn/gshub/supervisor/internal/supervisorpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcTransport is the gRPC protocol to the API (proto/supervisor/v1). Calls fall back to
+// the JSON endpoints for good if the API doesn't serve the protocol, and for that call only
+// if its port is unreachable.
+type grpcTransport struct {
+	conn   *grpc.ClientConn
+	client supervisorpb.SupervisorClient
+
+	unsupported atomic.Bool // The API answered Unimplemented
+
+	// ctx lives as long as the client and bounds the command stream
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	stream *commandStream
+}
+
+// commandStream receives commands from StreamCommands in the background; commands is
+// closed when the stream ends, after err is set
+type commandStream struct {
+	commands chan Command
+	err      error
+}
+
+// tokenCredentials identifies and authenticates every call as the server's supervisor
+type tokenCredentials struct {
+	serverID  string
+	authToken string
+}
+
+func (t tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"x-server-id": t.serverID, "authorization": "Bearer " + t.authToken}, nil
+}
+
+func (tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// UseGRPC makes the client talk to the API's gRPC endpoint (host:port), keeping the JSON
+// endpoints as a fallback. The connection is made lazily.
+func (c *Client) UseGRPC(endpoint string) error {
+	conn, err := grpc.NewClient(endpoint,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(tokenCredentials{serverID: c.serverID, authToken: c.authToken}))
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.grpc = &grpcTransport{
+		conn:   conn,
+		client: supervisorpb.NewSupervisorClient(conn),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	return nil
+}
+
+// Close closes the gRPC connection, if any
+func (c *Client) Close() error {
+	if c.grpc == nil {
+		return nil
+	}
+	c.grpc.cancel()
+	return c.grpc.conn.Close()
+}
+
+func (c *Client) grpcEnabled() bool {
+	return c.grpc != nil && !c.grpc.unsupported.Load()
+}
+
+// callGRPC makes a gRPC call through the circuit breaker. It returns false if the call
+// should be made over JSON instead.
+func (c *Client) callGRPC(ctx context.Context, call func(context.Context, supervisorpb.SupervisorClient) error) (bool, error) {
+	if !c.grpcEnabled() {
+		return false, nil
+	}
+	if !c.breaker.allow() {
+		return true, ErrCircuitOpen
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.httpClient.Timeout)
+	defer cancel()
+	err := call(ctx, c.grpc.client)
+	if c.grpcOutcome(err) {
+		return false, nil
+	}
+	return true, err
+}
+
+// grpcOutcome records a gRPC call's outcome with the circuit breaker, and reports whether
+// the call should be made over JSON instead
+func (c *Client) grpcOutcome(err error) bool {
+	switch status.Code(err) {
+	case codes.Unimplemented:
+		if c.grpc.unsupported.CompareAndSwap(false, true) {
+			c.logger.Warn("API doesn't serve the gRPC protocol, using the JSON endpoints")
+		}
+		c.recordOutcome(true)
+		return true
+	case codes.Unavailable:
+		return true // The JSON request records the outcome
+	case codes.Canceled:
+		return false
+	case codes.Internal, codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted:
+		c.recordOutcome(false)
+		return false
+	default:
+		c.recordOutcome(true)
+		return false
+	}
+}
+
+func (c *Client) reportStatusGRPC(ctx context.Context, req *StatusUpdateRequest) (bool, error) {
+	return c.callGRPC(ctx, func(ctx context.Context, client supervisorpb.SupervisorClient) error {
+		_, err := client.ReportStatus(ctx, &supervisorpb.ReportStatusRequest{
+			Status:     string(req.Status),
+			Message:    req.Message,
+			Reason:     string(req.Reason),
+			Phase:      string(req.Phase),
+			ProcessPid: int32(req.ProcessPID),
+		})
+		return err
+	})
+}
+
+func (c *Client) reportProgressGRPC(ctx context.Context, req ProgressRequest) (bool, error) {
+	return c.callGRPC(ctx, func(ctx context.Context, client supervisorpb.SupervisorClient) error {
+		_, err := client.ReportProgress(ctx, &supervisorpb.ReportProgressRequest{
+			Label:   req.Label,
+			Percent: int32(req.Percent),
+		})
+		return err
+	})
+}
+
+func (c *Client) heartbeatGRPC(ctx context.Context, req HeartbeatRequest) (bool, error) {
+	msg := &supervisorpb.HeartbeatRequest{
+		ProcessPid: int32(req.ProcessPID),
+		NetTxBytes: req.NetTxBytes,
+		Samples:    make([]*supervisorpb.MetricSample, len(req.Samples)),
+	}
+	if req.PlayersOnline != nil {
+		players := int32(*req.PlayersOnline)
+		msg.PlayersOnline = &players
+	}
+	for i, sample := range req.Samples {
+		msg.Samples[i] = &supervisorpb.MetricSample{
+			SampledAt:  timestamppb.New(sample.SampledAt),
+			MemoryMb:   sample.MemoryMB,
+			CpuPercent: sample.CPUPercent,
+		}
+	}
+
+	return c.callGRPC(ctx, func(ctx context.Context, client supervisorpb.SupervisorClient) error {
+		_, err := client.Heartbeat(ctx, msg)
+		return err
+	})
+}
+
+func (c *Client) claimCommandsGRPC(ctx context.Context) ([]Command, bool, error) {
+	var commands []Command
+	handled, err := c.callGRPC(ctx, func(ctx context.Context, client supervisorpb.SupervisorClient) error {
+		resp, err := client.ClaimCommands(ctx, &supervisorpb.ClaimCommandsRequest{})
+		for _, msg := range resp.GetCommands() {
+			commands = append(commands, commandFromMessage(msg))
+		}
+		return err
+	})
+	return commands, handled, err
+}
+
+// pollCommandStream waits up to wait for commands from the command stream, opening it if
+// needed. It returns false if the poll should be made over JSON instead.
+func (c *Client) pollCommandStream(ctx context.Context, wait time.Duration) ([]Command, bool, error) {
+	if !c.grpcEnabled() {
+		return nil, false, nil
+	}
+	stream, err := c.commandStream()
+	if err != nil {
+		return nil, true, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil, true, ctx.Err()
+	case <-timer.C:
+		return nil, true, nil
+	case cmd, ok := <-stream.commands:
+		if !ok {
+			c.grpc.mu.Lock()
+			if c.grpc.stream == stream {
+				c.grpc.stream = nil
+			}
+			c.grpc.mu.Unlock()
+			if c.grpcOutcome(stream.err) {
+				return nil, false, nil
+			}
+			return nil, true, fmt.Errorf("command stream ended: %w", stream.err)
+		}
+
+		// Take whatever else already arrived
+		commands := []Command{cmd}
+		for {
+			select {
+			case cmd, ok := <-stream.commands:
+				if !ok {
+					return commands, true, nil
+				}
+				commands = append(commands, cmd)
+			default:
+				return commands, true, nil
+			}
+		}
+	}
+}
+
+// commandStream returns the open command stream, opening one if there's none
+func (c *Client) commandStream() (*commandStream, error) {
+	c.grpc.mu.Lock()
+	defer c.grpc.mu.Unlock()
+
+	if c.grpc.stream != nil {
+		return c.grpc.stream, nil
+	}
+	if !c.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	ctx := c.grpc.ctx
+	recv, err := c.grpc.client.StreamCommands(ctx, &supervisorpb.StreamCommandsRequest{})
+	if err != nil {
+		c.grpcOutcome(err)
+		return nil, fmt.Errorf("failed to open command stream: %w", err)
+	}
+
+	stream := &commandStream{commands: make(chan Command)}
+	go func() {
+		defer close(stream.commands)
+		for {
+			msg, err := recv.Recv()
+			if err != nil {
+				stream.err = err
+				return
+			}
+			c.recordOutcome(true)
+			select {
+			case stream.commands <- commandFromMessage(msg):
+			case <-ctx.Done():
+				stream.err = ctx.Err()
+				return
+			}
+		}
+	}()
+	c.grpc.stream = stream
+	return stream, nil
+}
+
+func commandFromMessage(msg *supervisorpb.Command) Command {
+	return Command{ID: msg.GetId(), Type: CommandType(msg.GetType()), Payload: msg.GetPayload()}
+}
+
+func (c *Client) reportCommandResultGRPC(ctx context.Context, commandID string, req CommandResultRequest) (bool, error) {
+	msg := &supervisorpb.ReportCommandResultRequest{
+		CommandId: commandID,
+		Success:   req.Success,
+		Result:    req.Result,
+	}
+	if req.Artifact != nil {
+		msg.Artifact = &supervisorpb.Artifact{Path: req.Artifact.Path, Size: req.Artifact.Size}
+	}
+
+	return c.callGRPC(ctx, func(ctx context.Context, client supervisorpb.SupervisorClient) error {
+		_, err := client.ReportCommandResult(ctx, msg)
+		return err
+	})
+}
+
+func (c *Client) reportCommandProgressGRPC(ctx context.Context, commandID string, req CommandProgressRequest) (bool, error) {
+	return c.callGRPC(ctx, func(ctx context.Context, client supervisorpb.SupervisorClient) error {
+		_, err := client.ReportCommandProgress(ctx, &supervisorpb.ReportCommandProgressRequest{
+			CommandId:  commandID,
+			DoneBytes:  req.DoneBytes,
+			TotalBytes: req.TotalBytes,
+		})
+		return err
+	})
+}
+
+func (c *Client) bannedHashesGRPC(ctx context.Context) ([]string, bool, error) {
+	var hashes []string
+	handled, err := c.callGRPC(ctx, func(ctx context.Context, client supervisorpb.SupervisorClient) error {
+		resp, err := client.GetBannedHashes(ctx, &supervisorpb.GetBannedHashesRequest{})
+		hashes = resp.GetHashes()
+		return err
+	})
+	return hashes, handled, err
+}
+
+func (c *Client) reportBannedBinaryGRPC(ctx context.Context, req BannedBinaryRequest) (bool, error) {
+	return c.callGRPC(ctx, func(ctx context.Context, client supervisorpb.SupervisorClient) error {
+		_, err := client.ReportBannedBinary(ctx, &supervisorpb.ReportBannedBinaryRequest{
+			Path:   req.Path,
+			Sha256: req.SHA256,
+		})
+		return err
+	})
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// breakerThreshold is how many consecutive failed requests open the circuit breaker
+	breakerThreshold = 5
+	// breakerCooldown is how long an open breaker fails requests before letting one through
+	// to test whether the API recovered
+	breakerCooldown = 30 * time.Second
+
+	// statusMaxAttempts bounds how often ReportStatusWithRetry tries before leaving the
+	// status queued for delivery on recovery
+	statusMaxAttempts = 5
+	// statusRetryBaseDelay is the backoff after the first failed attempt, doubled after each
+	// further one up to statusRetryMaxDelay
+	statusRetryBaseDelay = 500 * time.Millisecond
+	statusRetryMaxDelay  = 8 * time.Second
+	// pendingStatusTimeout bounds delivering a queued status once the API recovers
+	pendingStatusTimeout = 15 * time.Second
+)
+
+// ErrCircuitOpen is returned without making a request while the API looks unavailable
+var ErrCircuitOpen = errors.New("API unavailable, circuit breaker open")
+
+// StatusCodeError is a non-2xx response from the API
+type StatusCodeError struct {
+	Code int
+}
+
+func (e *StatusCodeError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.Code)
+}
+
+// retryable reports whether a failed request may succeed if tried again: network errors,
+// server errors and rate limiting are, other rejections (e.g. a rotated auth token) aren't
+func retryable(err error) bool {
+	var statusErr *StatusCodeError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= 500 || statusErr.Code == http.StatusTooManyRequests
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied, codes.NotFound, codes.FailedPrecondition:
+		return false
+	}
+	return true
+}
+
+// circuitBreaker stops requests to the API after breakerThreshold consecutive failures, so
+// an outage isn't made worse by every retry loop hammering it. Once breakerCooldown has
+// passed, one request is let through; its success closes the breaker.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// allow reports whether a request may be made now. When the cooldown has passed it lets
+// one request through and keeps the breaker open for the others until that one finishes.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < breakerThreshold {
+		return true
+	}
+	now := time.Now()
+	if now.Before(b.openUntil) {
+		return false
+	}
+	b.openUntil = now.Add(breakerCooldown)
+	return true
+}
+
+// record notes a request's outcome, and reports whether it opened or closed the breaker
+func (b *circuitBreaker) record(success bool) (opened, closed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		closed = b.failures >= breakerThreshold
+		b.failures = 0
+		return false, closed
+	}
+	b.failures++
+	if b.failures == breakerThreshold {
+		b.openUntil = time.Now().Add(breakerCooldown)
+		return true, false
+	}
+	return false, false
+}
+
+// do sends a request through the circuit breaker. Responses other than server errors and
+// rate limiting count as the API being available.
+func (c *Client) do(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	if !c.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := httpClient.Do(req)
+	failed := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	c.recordOutcome(!failed)
+	return resp, err
+}
+
+// recordOutcome notes a request's outcome with the circuit breaker, delivering the queued
+// status once the API is reachable again
+func (c *Client) recordOutcome(success bool) {
+	opened, closed := c.breaker.record(success)
+	if opened {
+		c.logger.Warn("API unavailable, pausing requests", zap.Duration("cooldown", breakerCooldown))
+	}
+	if closed {
+		c.logger.Info("API reachable again")
+		go c.deliverPendingStatus()
+	}
+}
+
+// backoff returns the delay before the given retry (1 for the first), doubling from
+// statusRetryBaseDelay up to statusRetryMaxDelay, with jitter so supervisors that lost the
+// API together don't retry in lockstep
+func backoff(retry int) time.Duration {
+	delay := statusRetryBaseDelay << min(retry-1, 10)
+	delay = min(delay, statusRetryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// ReportStatusWithRetry sends a status update, retrying with backoff. A status that can't
+// be delivered stays queued and is sent when the API recovers, unless a newer status
+// replaces it first.
+func (c *Client) ReportStatusWithRetry(ctx context.Context, status Status, message string, pid int) {
+	req := &StatusUpdateRequest{
+		Status:     status,
+		Message:    message,
+		ProcessPID: pid,
+	}
+
+	c.statusMu.Lock()
+	c.pendingStatus = req
+	c.statusMu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		delivered, err := c.sendPendingStatus(ctx, req)
+		if delivered {
+			c.logger.Info("reported status",
+				zap.String("status", string(status)),
+				zap.String("message", message),
+				zap.Int("pid", pid))
+			return
+		}
+		if err == nil {
+			return // Replaced by a newer status
+		}
+
+		if !retryable(err) {
+			c.logger.Error("status rejected by the API",
+				zap.Error(err),
+				zap.String("status", string(status)))
+			c.dropPendingStatus(req)
+			return
+		}
+		if errors.Is(err, ErrCircuitOpen) || attempt >= statusMaxAttempts {
+			c.logger.Warn("failed to report status, queued until the API recovers",
+				zap.Error(err),
+				zap.String("status", string(status)),
+				zap.Int("attempts", attempt))
+			return
+		}
+
+		c.logger.Warn("failed to report status, retrying",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", statusMaxAttempts))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+// sendPendingStatus sends req if it's still the queued status, and reports whether it was
+// delivered. It returns false and no error if a newer status replaced it.
+func (c *Client) sendPendingStatus(ctx context.Context, req *StatusUpdateRequest) (bool, error) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+
+	if c.pendingStatus != req {
+		return false, nil
+	}
+	if err := c.postStatus(ctx, req); err != nil {
+		return false, err
+	}
+	c.pendingStatus = nil
+	return true, nil
+}
+
+// dropPendingStatus forgets req unless a newer status replaced it
+func (c *Client) dropPendingStatus(req *StatusUpdateRequest) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	if c.pendingStatus == req {
+		c.pendingStatus = nil
+	}
+}
+
+// deliverPendingStatus sends the queued status, if any, once the API is reachable again
+func (c *Client) deliverPendingStatus() {
+	c.statusMu.Lock()
+	req := c.pendingStatus
+	c.statusMu.Unlock()
+	if req == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pendingStatusTimeout)
+	defer cancel()
+	delivered, err := c.sendPendingStatus(ctx, req)
+	switch {
+	case delivered:
+		c.logger.Info("reported queued status", zap.String("status", string(req.Status)))
+	case err != nil && !retryable(err):
+		c.logger.Error("queued status rejected by the API", zap.Error(err), zap.String("status", string(req.Status)))
+		c.dropPendingStatus(req)
+	case err != nil:
+		c.logger.Warn("failed to report queued status", zap.Error(err), zap.String("status", string(req.Status)))
+	}
+}
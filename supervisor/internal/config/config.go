@@ -3,8 +3,11 @@ package config
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"net/url"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,12 +18,37 @@ type Config struct {
 	AuthToken string
 
 	// API connection
-	APIEndpoint string
+	APIEndpoint     string
+	APIGRPCEndpoint string // host:port of the API's gRPC protocol; empty uses the JSON endpoints
 
 	// Process configuration
-	StartCommand []string
-	WorkDir      string
-	GracePeriod  time.Duration
+	StartCommand  []string
+	WorkDir       string
+	GracePeriod   time.Duration
+	BackupCommand []string // Run for API backup commands; empty means backups aren't supported
+	BackupDir     string   // Where the backup command writes archives; the newest is offered for download
+
+	// DataDir is the server's data volume, which world exports archive
+	DataDir string
+
+	// World imports: uploaded archives replace ImportDir; empty means imports aren't supported
+	ImportDir           string
+	ImportRequiredFiles []string // Files an archive must contain for the game to load it
+
+	// Live reload configuration
+	ConfigTemplates []ConfigTemplate // Config files rendered from env before start and on reload
+	ReloadCommand   []string         // Makes the running game apply re-rendered config; SIGHUP when empty
+
+	// Log format used to tag game output lines with their severity
+	LogFormat string
+
+	// Console commands go to the game's RCON server on localhost if RCONPort is set, and to
+	// its stdin otherwise. RCONPasswordEnv names the game's env var holding the RCON password.
+	RCONPort        int
+	RCONPasswordEnv string
+
+	// Startup progress milestones matched in the output of a starting game
+	ProgressPatterns []ProgressPattern
 
 	// Health check configuration
 	HealthType     string // "port", "log-pattern", "none"
@@ -32,127 +60,247 @@ type Config struct {
 	HealthInterval time.Duration
 
 	// Heartbeat configuration
-	HeartbeatInterval time.Duration
+	HeartbeatInterval     time.Duration
+	MetricsSampleInterval time.Duration // Capped at HeartbeatInterval
 
 	// Health server configuration (for K8s probes)
 	HealthServerPort int
 }
 
-// Load reads configuration from environment variables
+// ConfigTemplate is a game config file rendered from env (${VAR} syntax)
+type ConfigTemplate struct {
+	Path     string `json:"path"`
+	Template string `json:"template"`
+}
+
+// ProgressPattern is a startup milestone in the game's output, e.g. "Preparing spawn area: (\d+)%"
+type ProgressPattern struct {
+	Pattern string `json:"pattern"` // Regex whose first group captures a percentage
+	Label   string `json:"label"`   // What the game is busy with, shown beside the percentage
+}
+
+// ValidationError lists every misconfiguration found by Load
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d configuration problem(s): %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// CanReport reports whether enough configuration was loaded to reach the API,
+// so a failed status can be reported even when other settings are invalid
+func (c *Config) CanReport() bool {
+	return c.ServerID != "" && c.AuthToken != "" && c.APIEndpoint != ""
+}
+
+// Load reads configuration from environment variables and validates it.
+// Instead of stopping at the first problem it checks everything and returns a
+// *ValidationError listing all of them, together with the partially loaded config.
 func Load() (*Config, error) {
-	cfg := &Config{
-		// Defaults
-		GracePeriod:       30 * time.Second,
-		HealthType:        "none",
-		HealthProtocol:    "TCP",
-		InitialDelay:      15 * time.Second,
-		HealthTimeout:     120 * time.Second,
-		HealthInterval:    10 * time.Second,
-		HeartbeatInterval: 30 * time.Second,
-		HealthServerPort:  8080,
+	cfg := &Config{}
+	var problems []string
+	addProblem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
 	}
 
 	// Required fields
-	cfg.ServerID = os.Getenv("GSHUB_SERVER_ID")
+	cfg.ServerID = getEnv("GSHUB_SERVER_ID")
 	if cfg.ServerID == "" {
-		return nil, fmt.Errorf("GSHUB_SERVER_ID is required")
+		addProblem("GSHUB_SERVER_ID is required")
 	}
 
-	cfg.AuthToken = os.Getenv("GSHUB_AUTH_TOKEN")
+	cfg.AuthToken = getEnv("GSHUB_AUTH_TOKEN")
 	if cfg.AuthToken == "" {
-		return nil, fmt.Errorf("GSHUB_AUTH_TOKEN is required")
+		addProblem("GSHUB_AUTH_TOKEN is required")
 	}
 
-	cfg.APIEndpoint = os.Getenv("GSHUB_API_ENDPOINT")
+	cfg.APIEndpoint = getEnv("GSHUB_API_ENDPOINT")
 	if cfg.APIEndpoint == "" {
-		return nil, fmt.Errorf("GSHUB_API_ENDPOINT is required")
+		addProblem("GSHUB_API_ENDPOINT is required")
+	} else if u, err := url.Parse(cfg.APIEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		addProblem("GSHUB_API_ENDPOINT must be an http(s) URL, got %q", cfg.APIEndpoint)
+		cfg.APIEndpoint = ""
 	}
+	cfg.APIGRPCEndpoint = getEnv("GSHUB_API_GRPC_ENDPOINT")
 
 	// Start command (JSON array)
-	startCmdJSON := os.Getenv("GSHUB_START_COMMAND")
+	startCmdJSON := getEnv("GSHUB_START_COMMAND")
 	if startCmdJSON == "" {
-		return nil, fmt.Errorf("GSHUB_START_COMMAND is required")
-	}
-	if err := json.Unmarshal([]byte(startCmdJSON), &cfg.StartCommand); err != nil {
-		return nil, fmt.Errorf("invalid GSHUB_START_COMMAND JSON: %w", err)
-	}
-	if len(cfg.StartCommand) == 0 {
-		return nil, fmt.Errorf("GSHUB_START_COMMAND must have at least one element")
+		addProblem("GSHUB_START_COMMAND is required")
+	} else if err := json.Unmarshal([]byte(startCmdJSON), &cfg.StartCommand); err != nil {
+		addProblem("GSHUB_START_COMMAND must be a JSON array of strings: %v", err)
+	} else if len(cfg.StartCommand) == 0 || cfg.StartCommand[0] == "" {
+		addProblem("GSHUB_START_COMMAND must start with a non-empty executable")
 	}
 
 	// Optional fields
-	if workDir := os.Getenv("GSHUB_WORK_DIR"); workDir != "" {
-		cfg.WorkDir = workDir
-	}
+	cfg.WorkDir = getEnv("GSHUB_WORK_DIR")
 
-	if gracePeriod := os.Getenv("GSHUB_GRACE_PERIOD"); gracePeriod != "" {
-		seconds, err := strconv.Atoi(gracePeriod)
-		if err != nil {
-			return nil, fmt.Errorf("invalid GSHUB_GRACE_PERIOD: %w", err)
+	if backupCmdJSON := getEnv("GSHUB_BACKUP_COMMAND"); backupCmdJSON != "" {
+		if err := json.Unmarshal([]byte(backupCmdJSON), &cfg.BackupCommand); err != nil {
+			addProblem("GSHUB_BACKUP_COMMAND must be a JSON array of strings: %v", err)
+		} else if len(cfg.BackupCommand) == 0 || cfg.BackupCommand[0] == "" {
+			addProblem("GSHUB_BACKUP_COMMAND must start with a non-empty executable")
 		}
-		cfg.GracePeriod = time.Duration(seconds) * time.Second
 	}
 
-	// Health check configuration
-	if healthType := os.Getenv("GSHUB_HEALTH_TYPE"); healthType != "" {
-		cfg.HealthType = healthType
+	cfg.DataDir = getEnv("GSHUB_DATA_DIR")
+	if cfg.DataDir != "" && !filepath.IsAbs(cfg.DataDir) {
+		addProblem("GSHUB_DATA_DIR must be absolute, got %q", cfg.DataDir)
 	}
 
-	if healthPort := os.Getenv("GSHUB_HEALTH_PORT"); healthPort != "" {
-		port, err := strconv.Atoi(healthPort)
-		if err != nil {
-			return nil, fmt.Errorf("invalid GSHUB_HEALTH_PORT: %w", err)
+	// Backups are downloaded from the data volume, so they must be written inside it
+	cfg.BackupDir = getEnv("GSHUB_BACKUP_DIR")
+	if cfg.BackupDir != "" {
+		if rel, err := filepath.Rel(cfg.DataDir, cfg.BackupDir); cfg.DataDir == "" || err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			addProblem("GSHUB_BACKUP_DIR must be inside GSHUB_DATA_DIR, got %q", cfg.BackupDir)
 		}
-		cfg.HealthPort = port
 	}
 
-	if healthProtocol := os.Getenv("GSHUB_HEALTH_PROTOCOL"); healthProtocol != "" {
-		cfg.HealthProtocol = healthProtocol
+	// Imports are uploaded into the data volume and extracted within it
+	cfg.ImportDir = getEnv("GSHUB_IMPORT_DIR")
+	if cfg.ImportDir != "" {
+		if rel, err := filepath.Rel(cfg.DataDir, cfg.ImportDir); cfg.DataDir == "" || err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			addProblem("GSHUB_IMPORT_DIR must be inside GSHUB_DATA_DIR, got %q", cfg.ImportDir)
+		}
+	}
+	if requiredJSON := getEnv("GSHUB_IMPORT_REQUIRED_FILES"); requiredJSON != "" {
+		if err := json.Unmarshal([]byte(requiredJSON), &cfg.ImportRequiredFiles); err != nil {
+			addProblem("GSHUB_IMPORT_REQUIRED_FILES must be a JSON array of strings: %v", err)
+		}
+		for _, file := range cfg.ImportRequiredFiles {
+			if file == "" || filepath.IsAbs(file) || strings.HasPrefix(filepath.Clean(file), "..") {
+				addProblem("GSHUB_IMPORT_REQUIRED_FILES must be relative paths inside the archive, got %q", file)
+			}
+		}
 	}
 
-	if healthPattern := os.Getenv("GSHUB_HEALTH_PATTERN"); healthPattern != "" {
-		cfg.HealthPattern = healthPattern
+	var err error
+	if cfg.GracePeriod, err = getEnvSeconds("GSHUB_GRACE_PERIOD", 0); err != nil {
+		addProblem("%v", err)
 	}
 
-	if initialDelay := os.Getenv("GSHUB_HEALTH_INITIAL_DELAY"); initialDelay != "" {
-		seconds, err := strconv.Atoi(initialDelay)
-		if err != nil {
-			return nil, fmt.Errorf("invalid GSHUB_HEALTH_INITIAL_DELAY: %w", err)
+	if templatesJSON := getEnv("GSHUB_CONFIG_TEMPLATES"); templatesJSON != "" {
+		if err := json.Unmarshal([]byte(templatesJSON), &cfg.ConfigTemplates); err != nil {
+			addProblem("GSHUB_CONFIG_TEMPLATES must be a JSON array of {path, template} objects: %v", err)
+		}
+		for _, tpl := range cfg.ConfigTemplates {
+			if !filepath.IsAbs(tpl.Path) {
+				addProblem("GSHUB_CONFIG_TEMPLATES paths must be absolute, got %q", tpl.Path)
+			}
 		}
-		cfg.InitialDelay = time.Duration(seconds) * time.Second
 	}
 
-	if healthTimeout := os.Getenv("GSHUB_HEALTH_TIMEOUT"); healthTimeout != "" {
-		seconds, err := strconv.Atoi(healthTimeout)
-		if err != nil {
-			return nil, fmt.Errorf("invalid GSHUB_HEALTH_TIMEOUT: %w", err)
+	if reloadCmdJSON := getEnv("GSHUB_RELOAD_COMMAND"); reloadCmdJSON != "" {
+		if err := json.Unmarshal([]byte(reloadCmdJSON), &cfg.ReloadCommand); err != nil {
+			addProblem("GSHUB_RELOAD_COMMAND must be a JSON array of strings: %v", err)
+		} else if len(cfg.ReloadCommand) == 0 || cfg.ReloadCommand[0] == "" {
+			addProblem("GSHUB_RELOAD_COMMAND must start with a non-empty executable")
 		}
-		cfg.HealthTimeout = time.Duration(seconds) * time.Second
 	}
 
-	if healthInterval := os.Getenv("GSHUB_HEALTH_INTERVAL"); healthInterval != "" {
-		seconds, err := strconv.Atoi(healthInterval)
-		if err != nil {
-			return nil, fmt.Errorf("invalid GSHUB_HEALTH_INTERVAL: %w", err)
-		}
-		cfg.HealthInterval = time.Duration(seconds) * time.Second
+	cfg.LogFormat = getEnv("GSHUB_LOG_FORMAT")
+
+	cfg.RCONPasswordEnv = getEnv("GSHUB_RCON_PASSWORD_ENV")
+	if cfg.RCONPort, err = getEnvPort("GSHUB_RCON_PORT"); err != nil {
+		addProblem("%v", err)
+	}
+	if cfg.RCONPort != 0 && cfg.RCONPasswordEnv == "" {
+		addProblem("GSHUB_RCON_PASSWORD_ENV is required when GSHUB_RCON_PORT is set")
 	}
 
-	if heartbeatInterval := os.Getenv("GSHUB_HEARTBEAT_INTERVAL"); heartbeatInterval != "" {
-		seconds, err := strconv.Atoi(heartbeatInterval)
-		if err != nil {
-			return nil, fmt.Errorf("invalid GSHUB_HEARTBEAT_INTERVAL: %w", err)
+	if patternsJSON := getEnv("GSHUB_PROGRESS_PATTERNS"); patternsJSON != "" {
+		if err := json.Unmarshal([]byte(patternsJSON), &cfg.ProgressPatterns); err != nil {
+			addProblem("GSHUB_PROGRESS_PATTERNS must be a JSON array of {pattern, label} objects: %v", err)
 		}
-		cfg.HeartbeatInterval = time.Duration(seconds) * time.Second
+		for _, p := range cfg.ProgressPatterns {
+			if re, err := regexp.Compile(p.Pattern); err != nil {
+				addProblem("GSHUB_PROGRESS_PATTERNS pattern %q is not a valid regex: %v", p.Pattern, err)
+			} else if re.NumSubexp() < 1 {
+				addProblem("GSHUB_PROGRESS_PATTERNS pattern %q must capture the percentage in a group", p.Pattern)
+			}
+			if p.Label == "" {
+				addProblem("GSHUB_PROGRESS_PATTERNS pattern %q needs a label", p.Pattern)
+			}
+		}
+	}
+
+	// Health check configuration
+	cfg.HealthType = getEnv("GSHUB_HEALTH_TYPE")
+	cfg.HealthProtocol = getEnv("GSHUB_HEALTH_PROTOCOL")
+	cfg.HealthPattern = getEnv("GSHUB_HEALTH_PATTERN")
+
+	if cfg.HealthPort, err = getEnvPort("GSHUB_HEALTH_PORT"); err != nil {
+		addProblem("%v", err)
+	}
+	if cfg.InitialDelay, err = getEnvSeconds("GSHUB_HEALTH_INITIAL_DELAY", 0); err != nil {
+		addProblem("%v", err)
+	}
+	if cfg.HealthTimeout, err = getEnvSeconds("GSHUB_HEALTH_TIMEOUT", 1); err != nil {
+		addProblem("%v", err)
+	}
+	if cfg.HealthInterval, err = getEnvSeconds("GSHUB_HEALTH_INTERVAL", 1); err != nil {
+		addProblem("%v", err)
+	}
+	if cfg.HeartbeatInterval, err = getEnvSeconds("GSHUB_HEARTBEAT_INTERVAL", 1); err != nil {
+		addProblem("%v", err)
+	}
+	if cfg.MetricsSampleInterval, err = getEnvSeconds("GSHUB_METRICS_SAMPLE_INTERVAL", 1); err != nil {
+		addProblem("%v", err)
+	}
+	if cfg.HealthServerPort, err = getEnvPort("GSHUB_HEALTH_SERVER_PORT"); err != nil {
+		addProblem("%v", err)
 	}
 
-	if healthServerPort := os.Getenv("GSHUB_HEALTH_SERVER_PORT"); healthServerPort != "" {
-		port, err := strconv.Atoi(healthServerPort)
-		if err != nil {
-			return nil, fmt.Errorf("invalid GSHUB_HEALTH_SERVER_PORT: %w", err)
+	switch cfg.HealthType {
+	case "none":
+	case "port":
+		if getEnv("GSHUB_HEALTH_PORT") == "" {
+			addProblem("GSHUB_HEALTH_PORT is required when GSHUB_HEALTH_TYPE is port")
 		}
-		cfg.HealthServerPort = port
+		if cfg.HealthProtocol != "TCP" && cfg.HealthProtocol != "UDP" {
+			addProblem("GSHUB_HEALTH_PROTOCOL must be TCP or UDP, got %q", cfg.HealthProtocol)
+		}
+	case "log-pattern":
+		if cfg.HealthPattern == "" {
+			addProblem("GSHUB_HEALTH_PATTERN is required when GSHUB_HEALTH_TYPE is log-pattern")
+		} else if _, err := regexp.Compile(cfg.HealthPattern); err != nil {
+			addProblem("GSHUB_HEALTH_PATTERN is not a valid regex: %v", err)
+		}
+	default:
+		addProblem("GSHUB_HEALTH_TYPE must be port, log-pattern or none, got %q", cfg.HealthType)
+	}
+
+	if cfg.HealthType != "none" && cfg.HealthInterval > cfg.HealthTimeout {
+		addProblem("GSHUB_HEALTH_INTERVAL (%v) must not exceed GSHUB_HEALTH_TIMEOUT (%v)", cfg.HealthInterval, cfg.HealthTimeout)
 	}
 
+	if len(problems) > 0 {
+		return cfg, &ValidationError{Problems: problems}
+	}
 	return cfg, nil
 }
+
+// getEnvPort parses key as a port number; unset variables without a default are 0
+func getEnvPort(key string) (int, error) {
+	value := getEnv(key)
+	if value == "" {
+		return 0, nil
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%s must be a port number between 1 and 65535, got %q", key, value)
+	}
+	return port, nil
+}
+
+// getEnvSeconds parses key as a whole number of seconds no smaller than minSeconds
+func getEnvSeconds(key string, minSeconds int) (time.Duration, error) {
+	value := getEnv(key)
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < minSeconds {
+		return 0, fmt.Errorf("%s must be a whole number of seconds >= %d, got %q", key, minSeconds, value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
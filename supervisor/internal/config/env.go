@@ -0,0 +1,62 @@
+package config
+
+import "os"
+
+// EnvVar describes an environment variable read by Load
+type EnvVar struct {
+	Name        string
+	Default     string
+	Secret      bool // Sensitive value, not to be logged or committed
+	Required    bool
+	Description string
+}
+
+// EnvVars lists every environment variable read by Load, with its default.
+// The API's reconciler sets these on game server Deployments; cmd/genvalues
+// renders them for manifests, and Load panics on names missing from this list.
+var EnvVars = []EnvVar{
+	{Name: "GSHUB_SERVER_ID", Required: true, Description: "Server UUID"},
+	{Name: "GSHUB_AUTH_TOKEN", Secret: true, Required: true, Description: "Token for the API internal endpoints"},
+	{Name: "GSHUB_API_ENDPOINT", Required: true, Description: "API internal endpoint URL"},
+	{Name: "GSHUB_API_GRPC_ENDPOINT", Description: "API gRPC endpoint as host:port; empty uses the JSON endpoints"},
+
+	{Name: "GSHUB_START_COMMAND", Required: true, Description: "Game start command as a JSON array"},
+	{Name: "GSHUB_WORK_DIR", Description: "Working directory for the game process"},
+	{Name: "GSHUB_GRACE_PERIOD", Default: "30", Description: "Seconds to wait for graceful shutdown"},
+	{Name: "GSHUB_BACKUP_COMMAND", Description: "Backup command as a JSON array, run on API backup requests"},
+	{Name: "GSHUB_BACKUP_DIR", Description: "Directory the backup command writes archives to, inside GSHUB_DATA_DIR"},
+	{Name: "GSHUB_DATA_DIR", Description: "Mount path of the server's data volume, archived by world exports"},
+	{Name: "GSHUB_IMPORT_DIR", Description: "Directory uploaded world archives replace, inside GSHUB_DATA_DIR (empty disables imports)"},
+	{Name: "GSHUB_IMPORT_REQUIRED_FILES", Description: "Files a world archive must contain, as a JSON array of relative paths"},
+	{Name: "GSHUB_CONFIG_TEMPLATES", Description: "Config files rendered from env, as a JSON array of {path, template}"},
+	{Name: "GSHUB_RELOAD_COMMAND", Description: "Command as a JSON array that applies re-rendered config to the running game"},
+	{Name: "GSHUB_PROGRESS_PATTERNS", Description: "Startup progress milestones, as a JSON array of {pattern, label}; the pattern's first group captures a percentage"},
+	{Name: "GSHUB_RCON_PORT", Description: "Port of the game's RCON server on localhost; console commands go to stdin when empty"},
+	{Name: "GSHUB_RCON_PASSWORD_ENV", Description: "Game env var holding the RCON password, generated when unset"},
+	{Name: "GSHUB_LOG_FORMAT", Default: "generic", Description: "Game log format for severity tagging: generic, minecraft, valheim or enshrouded"},
+
+	{Name: "GSHUB_HEALTH_TYPE", Default: "none", Description: "Health check type: port, log-pattern or none"},
+	{Name: "GSHUB_HEALTH_PORT", Description: "Port checked by the port health check"},
+	{Name: "GSHUB_HEALTH_PROTOCOL", Default: "TCP", Description: "Protocol for the port health check: TCP or UDP"},
+	{Name: "GSHUB_HEALTH_PATTERN", Description: "Regex matched by the log-pattern health check"},
+	{Name: "GSHUB_HEALTH_INITIAL_DELAY", Default: "15", Description: "Seconds before the first health check"},
+	{Name: "GSHUB_HEALTH_TIMEOUT", Default: "120", Description: "Seconds to wait for the game to become healthy"},
+	{Name: "GSHUB_HEALTH_INTERVAL", Default: "10", Description: "Seconds between health checks"},
+
+	{Name: "GSHUB_HEARTBEAT_INTERVAL", Default: "30", Description: "Seconds between heartbeats to the API"},
+	{Name: "GSHUB_METRICS_SAMPLE_INTERVAL", Default: "5", Description: "Seconds between resource usage samples, sent in batches with each heartbeat"},
+	{Name: "GSHUB_HEALTH_SERVER_PORT", Default: "8080", Description: "Port for the K8s probe HTTP server"},
+}
+
+// getEnv returns the value of key, or its registered default when unset
+func getEnv(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	for _, v := range EnvVars {
+		if v.Name == key {
+			return v.Default
+		}
+	}
+	panic("config: environment variable " + key + " is not registered in EnvVars")
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mooncorn/gshub/supervisor/internal/process"
+	"go.uber.org/zap"
+)
+
+// maxConsoleRequestBytes bounds the body of a console request
+const maxConsoleRequestBytes = 4 << 10
+
+// ConsoleRunner runs console commands in the game, and gives access to its console
+type ConsoleRunner interface {
+	RunConsoleCommand(ctx context.Context, command string) (string, error)
+	SubscribeConsole() (backlog []string, lines <-chan string, cancel func())
+	SendInput(line string) error
+}
+
+// ConsoleHandler runs console commands the API relays from the server's owner, e.g. to op
+// players or save the world, and returns their output. It also backs the interactive
+// console: a stream of the game's output and raw lines written to its stdin.
+type ConsoleHandler struct {
+	runner ConsoleRunner
+	token  string
+	logger *zap.Logger
+}
+
+// consoleRequest is a console command to run
+type consoleRequest struct {
+	Command string `json:"command"`
+}
+
+// consoleResponse is the output of a console command
+type consoleResponse struct {
+	Output string `json:"output"`
+}
+
+// consoleInput is a line typed into the interactive console
+type consoleInput struct {
+	Line string `json:"line"`
+}
+
+// NewConsoleHandler creates a console handler running commands through runner
+func NewConsoleHandler(runner ConsoleRunner, token string, logger *zap.Logger) *ConsoleHandler {
+	return &ConsoleHandler{runner: runner, token: token, logger: logger}
+}
+
+// Register adds the console endpoint to mux
+func (h *ConsoleHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /console", bearerAuth(h.token, h.handleCommand))
+	mux.HandleFunc("GET /console", bearerAuth(h.token, h.handleStream))
+	mux.HandleFunc("POST /console/input", bearerAuth(h.token, h.handleInput))
+}
+
+// handleCommand runs one console command. Commands are single lines, so one request can't
+// smuggle several into the game's stdin.
+func (h *ConsoleHandler) handleCommand(w http.ResponseWriter, r *http.Request) {
+	var req consoleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConsoleRequestBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	command := strings.TrimSpace(req.Command)
+	if command == "" || strings.ContainsAny(command, "\r\n") {
+		http.Error(w, "command must be a single non-empty line", http.StatusBadRequest)
+		return
+	}
+
+	output, err := h.runner.RunConsoleCommand(r.Context(), command)
+	if errors.Is(err, process.ErrNotRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.logger.Warn("console command failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(consoleResponse{Output: output})
+}
+
+// handleStream streams the game's recent output and then each new line as plain text, one
+// line each, until the client disconnects. It carries on across in-place restarts.
+func (h *ConsoleHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	backlog, lines, cancel := h.runner.SubscribeConsole()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for _, line := range backlog {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case line := <-lines:
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleInput writes a line typed into the interactive console to the game's stdin, without
+// waiting for output; it shows up in the stream. Like commands, input is a single line.
+func (h *ConsoleHandler) handleInput(w http.ResponseWriter, r *http.Request) {
+	var req consoleInput
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConsoleRequestBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.ContainsAny(req.Line, "\r\n") {
+		http.Error(w, "input must be a single line", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.runner.SendInput(req.Line); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
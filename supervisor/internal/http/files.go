@@ -0,0 +1,322 @@
+package http
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// FileEntry is a file or directory in a listing
+type FileEntry struct {
+	Name       string    `json:"name"`
+	Dir        bool      `json:"dir"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// FileServer serves a directory: read-only for the data of an expired server its owner is
+// rescuing, and writable for a running one, whose owner manages its files and downloads its
+// backups and exports. Requests must carry the bearer token the API knows the supervisor by.
+type FileServer struct {
+	port     int
+	root     *os.Root
+	token    string
+	writable bool
+	logger   *zap.Logger
+}
+
+// NewFileServer creates a file server for dir. Paths can't escape dir, even through symlinks.
+func NewFileServer(port int, dir, token string, logger *zap.Logger) (*FileServer, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", dir, err)
+	}
+	return &FileServer{port: port, root: root, token: token, logger: logger}, nil
+}
+
+// AllowWrites also lets files be uploaded, directories created and entries deleted. Must be
+// called before Register.
+func (s *FileServer) AllowWrites() {
+	s.writable = true
+}
+
+// Register adds the file endpoints to mux
+func (s *FileServer) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/files", bearerAuth(s.token, s.handleList))
+	mux.HandleFunc("/files/download", bearerAuth(s.token, s.handleDownload))
+	if s.writable {
+		mux.HandleFunc("PUT /files/upload", bearerAuth(s.token, s.handleUpload))
+		mux.HandleFunc("POST /files/mkdir", bearerAuth(s.token, s.handleMkdir))
+		mux.HandleFunc("DELETE /files", bearerAuth(s.token, s.handleDelete))
+	}
+}
+
+// Start serves until ctx is cancelled
+func (s *FileServer) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	s.Register(mux)
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.port),
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.Info("starting file server", zap.Int("port", s.port), zap.String("dir", s.root.Name()))
+	err := httpServer.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("file server error: %w", err)
+	}
+	return nil
+}
+
+// bearerAuth only passes requests carrying the bearer token on to next
+func bearerAuth(want string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// cleanPath turns the ?path query into a path relative to the root ("." for the root)
+func cleanPath(r *http.Request) string {
+	p := strings.TrimPrefix(path.Clean("/"+r.URL.Query().Get("path")), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// handleList returns the entries of a directory, directories first
+func (s *FileServer) handleList(w http.ResponseWriter, r *http.Request) {
+	entries, err := fs.ReadDir(s.root.FS(), cleanPath(r))
+	if err != nil {
+		writeFSError(w, err)
+		return
+	}
+
+	files := make([]FileEntry, 0, len(entries))
+	for _, dirs := range []bool{true, false} {
+		for _, entry := range entries {
+			if entry.IsDir() != dirs {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue // Removed since listing
+			}
+			files = append(files, FileEntry{
+				Name:       entry.Name(),
+				Dir:        entry.IsDir(),
+				Size:       info.Size(),
+				ModifiedAt: info.ModTime().UTC(),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"files": files})
+}
+
+// handleDownload streams a file, or a directory as a zip archive
+func (s *FileServer) handleDownload(w http.ResponseWriter, r *http.Request) {
+	p := cleanPath(r)
+	info, err := s.root.Stat(p)
+	if err != nil {
+		writeFSError(w, err)
+		return
+	}
+
+	name := path.Base(p)
+	if p == "." {
+		name = "data"
+	}
+
+	if !info.IsDir() {
+		f, err := s.root.Open(p)
+		if err != nil {
+			writeFSError(w, err)
+			return
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		http.ServeContent(w, r, name, info.ModTime(), f)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".zip"))
+	if err := s.writeZip(w, p); err != nil {
+		// Headers are already sent; the truncated archive fails to open
+		s.logger.Warn("failed to write zip", zap.String("path", p), zap.Error(err))
+	}
+}
+
+// writeZip writes the directory at dir (relative to the root) as a zip archive
+func (s *FileServer) writeZip(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+	fsys := s.root.FS()
+
+	err := fs.WalkDir(fsys, dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil // Directories are implied by file paths; skip symlinks and devices
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = p
+		if dir != "." {
+			header.Name = strings.TrimPrefix(p, dir+"/")
+		}
+		header.Method = zip.Deflate
+
+		dst, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		src, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(dst, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writablePath returns the ?path query if the owner may change it. The root itself and the
+// top-level .gshub-* entries the supervisor manages (exports, imports, volume backups) are
+// off limits.
+func writablePath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	p := cleanPath(r)
+	if p == "." || strings.HasPrefix(strings.SplitN(p, "/", 2)[0], ".gshub-") {
+		http.Error(w, "path can't be changed", http.StatusForbidden)
+		return "", false
+	}
+	return p, true
+}
+
+// handleUpload stores the request body as the file at ?path, creating missing parent
+// directories and replacing an existing file. The file only appears once complete, so a
+// failed upload leaves the old one in place.
+func (s *FileServer) handleUpload(w http.ResponseWriter, r *http.Request) {
+	p, ok := writablePath(w, r)
+	if !ok {
+		return
+	}
+	if info, err := s.root.Stat(p); err == nil && info.IsDir() {
+		http.Error(w, "path is a directory", http.StatusConflict)
+		return
+	}
+	if err := s.root.MkdirAll(path.Dir(p), 0o755); err != nil {
+		writeFSError(w, err)
+		return
+	}
+
+	partial := p + ".partial"
+	f, err := s.root.Create(partial)
+	if err != nil {
+		writeFSError(w, err)
+		return
+	}
+	written, err := io.Copy(f, r.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = s.root.Rename(partial, p)
+	}
+	if err != nil {
+		s.root.Remove(partial)
+		s.logger.Warn("failed to receive file", zap.String("path", p), zap.Error(err))
+		if errors.Is(err, syscall.ENOSPC) {
+			http.Error(w, "not enough free space for the file", http.StatusInsufficientStorage)
+		} else {
+			http.Error(w, "upload failed", http.StatusBadRequest)
+		}
+		return
+	}
+
+	s.logger.Info("received file", zap.String("path", p), zap.Int64("bytes", written))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleMkdir creates the directory at ?path, and any missing parents
+func (s *FileServer) handleMkdir(w http.ResponseWriter, r *http.Request) {
+	p, ok := writablePath(w, r)
+	if !ok {
+		return
+	}
+	if err := s.root.MkdirAll(p, 0o755); err != nil {
+		writeFSError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleDelete removes the file or directory (with its contents) at ?path
+func (s *FileServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	p, ok := writablePath(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.root.Lstat(p); err != nil {
+		writeFSError(w, err)
+		return
+	}
+	if err := s.root.RemoveAll(p); err != nil {
+		writeFSError(w, err)
+		return
+	}
+	s.logger.Info("deleted file", zap.String("path", p))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeFSError(w http.ResponseWriter, err error) {
+	switch {
+	case os.IsNotExist(err):
+		http.Error(w, "not found", http.StatusNotFound)
+	case os.IsPermission(err):
+		http.Error(w, "permission denied", http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
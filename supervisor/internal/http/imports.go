@@ -0,0 +1,94 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"syscall"
+
+	"github.com/mooncorn/gshub/supervisor/internal/process"
+	"go.uber.org/zap"
+)
+
+// commandIDPattern matches the UUIDs the API gives commands
+var commandIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// ImportReceiver accepts world archives the API uploads for import commands, and stores
+// them in the data directory until the import command extracts them
+type ImportReceiver struct {
+	dataDir string
+	token   string
+	logger  *zap.Logger
+}
+
+// NewImportReceiver creates an import receiver storing archives under dataDir
+func NewImportReceiver(dataDir, token string, logger *zap.Logger) *ImportReceiver {
+	return &ImportReceiver{dataDir: dataDir, token: token, logger: logger}
+}
+
+// Register adds the upload endpoint to mux
+func (r *ImportReceiver) Register(mux *http.ServeMux) {
+	mux.HandleFunc("PUT /imports/{commandID}", bearerAuth(r.token, r.handleUpload))
+}
+
+// handleUpload stores the request body as the archive of an import command. The archive
+// only appears under its final name once complete, so a partial upload is never imported.
+func (r *ImportReceiver) handleUpload(w http.ResponseWriter, req *http.Request) {
+	commandID := req.PathValue("commandID")
+	if !commandIDPattern.MatchString(commandID) {
+		http.Error(w, "invalid command ID", http.StatusBadRequest)
+		return
+	}
+
+	dest := process.ImportArchivePath(r.dataDir, commandID)
+	if _, err := os.Stat(dest); err == nil {
+		http.Error(w, "archive already uploaded", http.StatusConflict)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		http.Error(w, "failed to store archive", http.StatusInternalServerError)
+		return
+	}
+
+	partial := dest + ".partial"
+	written, err := writeFile(partial, req.Body)
+	if err != nil {
+		os.Remove(partial)
+		r.logger.Warn("failed to receive import archive", zap.String("command_id", commandID), zap.Error(err))
+		if errors.Is(err, syscall.ENOSPC) {
+			http.Error(w, "not enough free space for the archive", http.StatusInsufficientStorage)
+		} else {
+			http.Error(w, "upload failed", http.StatusBadRequest)
+		}
+		return
+	}
+	if err := os.Rename(partial, dest); err != nil {
+		os.Remove(partial)
+		http.Error(w, "failed to store archive", http.StatusInternalServerError)
+		return
+	}
+
+	r.logger.Info("received import archive", zap.String("command_id", commandID), zap.Int64("bytes", written))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// writeFile copies r into a new file at path and returns how many bytes it wrote
+func writeFile(path string, r io.Reader) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return written, err
+	}
+	if err := f.Close(); err != nil {
+		return written, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return written, nil
+}
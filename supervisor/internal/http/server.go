@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/mooncorn/gshub/supervisor/internal/metrics"
 	"github.com/mooncorn/gshub/supervisor/internal/process"
 	"go.uber.org/zap"
 )
@@ -36,6 +37,9 @@ type Server struct {
 	logger     *zap.Logger
 	httpServer *http.Server
 	startTime  time.Time
+	files      *FileServer
+	imports    *ImportReceiver
+	console    *ConsoleHandler
 }
 
 // NewServer creates a new HTTP health server
@@ -48,16 +52,43 @@ func NewServer(port int, manager ManagerInterface, logger *zap.Logger) *Server {
 	}
 }
 
+// ServeFiles also serves the data directory through files, so the owner can manage the
+// server's files and the API can download backups and exports. Must be called before Start.
+func (s *Server) ServeFiles(files *FileServer) {
+	s.files = files
+}
+
+// ServeImports also accepts world archives for import commands through imports. Must be
+// called before Start.
+func (s *Server) ServeImports(imports *ImportReceiver) {
+	s.imports = imports
+}
+
+// ServeConsole also runs console commands the API relays through console, and serves the
+// interactive console's output stream and input. Must be called before Start.
+func (s *Server) ServeConsole(console *ConsoleHandler) {
+	s.console = console
+}
+
 // Start begins serving HTTP requests
 func (s *Server) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", s.handleLiveness)
 	mux.HandleFunc("/readyz", s.handleReadiness)
 	mux.HandleFunc("/status", s.handleStatus)
+	if s.files != nil {
+		s.files.Register(mux)
+	}
+	if s.imports != nil {
+		s.imports.Register(mux)
+	}
+	if s.console != nil {
+		s.console.Register(mux)
+	}
 
 	s.httpServer = &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.port),
-		Handler: mux,
+		Handler: countSupervisorTx(mux),
 	}
 
 	// Graceful shutdown when context is cancelled
@@ -76,6 +107,37 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
+// countSupervisorTx counts response bodies as the supervisor's traffic, so serving backups
+// and downloads from the game's pod isn't counted against the game's network usage
+func countSupervisorTx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&txCountingWriter{ResponseWriter: w}, r)
+	})
+}
+
+// txCountingWriter reports the bytes written through it to metrics.AddSupervisorTx
+type txCountingWriter struct {
+	http.ResponseWriter
+}
+
+func (w *txCountingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	metrics.AddSupervisorTx(int64(n))
+	return n, err
+}
+
+// Flush keeps streamed responses like the console working through the wrapper
+func (w *txCountingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *txCountingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // handleLiveness responds to K8s liveness probes
 // Returns 200 if supervisor process is alive
 func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
@@ -84,9 +146,10 @@ func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleReadiness responds to K8s readiness probes
-// Returns 200 only if game process is healthy
+// Returns 200 only if the game is running and passing its health checks, so a hung game
+// is taken out of service
 func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
-	if s.manager.IsHealthy() && s.manager.IsRunning() {
+	if s.manager.IsHealthy() && s.manager.Status() == process.StatusRunning {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ready"))
 	} else {
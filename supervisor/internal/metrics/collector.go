@@ -5,16 +5,39 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// clockTicksPerSecond is USER_HZ, the unit of CPU times in /proc/[pid]/stat (100 on Linux)
+const clockTicksPerSecond = 100
+
 // ProcessMetrics holds collected process metrics
 type ProcessMetrics struct {
 	MemoryMB   int64
 	CPUPercent float64
+	NetTxBytes int64 // Cumulative bytes sent on the process's network interfaces, less the supervisor's
 }
 
-// CollectProcessMetrics gathers memory and CPU metrics for a given PID
-// Reads from /proc filesystem which is Linux-specific
+// supervisorTxBytes counts what the supervisor itself has sent, like backups the API copies
+// off-site and files the owner downloads, which shares the game's network interfaces
+var supervisorTxBytes atomic.Int64
+
+// gameTx holds the last game traffic reported, which is never allowed to go backwards
+var gameTx struct {
+	mu    sync.Mutex
+	bytes int64
+}
+
+// AddSupervisorTx records n bytes sent by the supervisor rather than the game
+func AddSupervisorTx(n int64) {
+	supervisorTxBytes.Add(n)
+}
+
+// CollectProcessMetrics gathers memory and network metrics for a given PID and the other
+// processes in its process group, so games started through launcher scripts are measured
+// by the game rather than the script. Reads from /proc filesystem which is Linux-specific
 func CollectProcessMetrics(pid int) (*ProcessMetrics, error) {
 	if pid <= 0 {
 		return nil, fmt.Errorf("invalid PID: %d", pid)
@@ -22,36 +45,186 @@ func CollectProcessMetrics(pid int) (*ProcessMetrics, error) {
 
 	metrics := &ProcessMetrics{}
 
-	// Read memory from /proc/[pid]/status
-	// Look for VmRSS (Resident Set Size)
-	statusPath := fmt.Sprintf("/proc/%d/status", pid)
-	data, err := os.ReadFile(statusPath)
+	// Memory is the sum of the group's resident set sizes
+	rssKB, err := readRSSKB(pid)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range groupMembers(pid) {
+		if member == pid {
+			continue
+		}
+		if kb, err := readRSSKB(member); err == nil {
+			rssKB += kb // Members may exit meanwhile
+		}
+	}
+	metrics.MemoryMB = rssKB / 1024
+
+	// CPU usage requires sampling over time; see Sampler
+	metrics.CPUPercent = 0.0
+
+	// Network counters are best-effort: unreadable counters are reported as 0
+	if txBytes, err := readNetTxBytes(pid); err == nil {
+		metrics.NetTxBytes = gameTxBytes(txBytes)
+	}
+
+	return metrics, nil
+}
+
+// Sampler collects process metrics and computes CPU usage from the CPU time
+// consumed since the previous sample
+type Sampler struct {
+	mu        sync.Mutex
+	lastPID   int
+	lastTicks int64
+	lastAt    time.Time
+}
+
+// Sample gathers metrics for a PID. CPUPercent is relative to one core and is 0 on the
+// first sample for a PID.
+func (s *Sampler) Sample(pid int) (*ProcessMetrics, error) {
+	metrics, err := CollectProcessMetrics(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	ticks, err := readCPUTicks(pid)
+	if err != nil {
+		return metrics, nil
+	}
+	for _, member := range groupMembers(pid) {
+		if member == pid {
+			continue
+		}
+		if memberTicks, err := readCPUTicks(member); err == nil {
+			ticks += memberTicks
+		}
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastPID == pid && ticks >= s.lastTicks {
+		if elapsed := now.Sub(s.lastAt).Seconds(); elapsed > 0 {
+			metrics.CPUPercent = float64(ticks-s.lastTicks) / clockTicksPerSecond / elapsed * 100
+		}
+	}
+	s.lastPID, s.lastTicks, s.lastAt = pid, ticks, now
+
+	return metrics, nil
+}
+
+// readRSSKB returns the resident set size of a PID in kB, from /proc/[pid]/status
+func readRSSKB(pid int) (int64, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read proc status: %w", err)
+		return 0, fmt.Errorf("failed to read proc status: %w", err)
 	}
 
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
+	for _, line := range strings.Split(string(data), "\n") {
 		if strings.HasPrefix(line, "VmRSS:") {
 			// Parse "VmRSS:    12345 kB"
 			fields := strings.Fields(line)
 			if len(fields) >= 2 {
-				kb, err := strconv.ParseInt(fields[1], 10, 64)
-				if err == nil {
-					metrics.MemoryMB = kb / 1024
+				if kb, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+					return kb, nil
 				}
 			}
 			break
 		}
 	}
+	return 0, nil // Kernel threads and zombies have no VmRSS
+}
 
-	// CPU usage requires sampling over time, which is complex
-	// For now, we'll return 0 and rely on K8s metrics for CPU
-	// A proper implementation would read /proc/[pid]/stat multiple times
-	// and calculate the delta
-	metrics.CPUPercent = 0.0
+// groupMembers returns the live processes in the process group pgid. Members starting or
+// exiting between samples skew (or, for exits, skip) one CPU sample.
+func groupMembers(pgid int) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
 
-	return metrics, nil
+	var members []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			continue
+		}
+		// State and pgrp are fields 3 and 5, i.e. 0 and 2 after the name
+		stat := string(data)
+		fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+		if len(fields) < 3 || fields[0] == "Z" || fields[2] != strconv.Itoa(pgid) {
+			continue
+		}
+		members = append(members, pid)
+	}
+	return members
+}
+
+// readCPUTicks returns the user+system CPU time of a PID in clock ticks
+func readCPUTicks(pid int) (int64, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read proc stat: %w", err)
+	}
+
+	// The command name (field 2) may contain spaces, so parse after its closing paren.
+	// utime and stime are fields 14 and 15, i.e. 12 and 13 after the name.
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("unexpected proc stat format")
+	}
+	utime, err := strconv.ParseInt(fields[11], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse utime: %w", err)
+	}
+	stime, err := strconv.ParseInt(fields[12], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse stime: %w", err)
+	}
+	return utime + stime, nil
+}
+
+// readNetTxBytes sums bytes sent on every interface except loopback in the PID's
+// network namespace (the whole pod, in Kubernetes)
+func readNetTxBytes(pid int) (int64, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/net/dev", pid))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read proc net/dev: %w", err)
+	}
+
+	// Lines look like "  eth0: rx_bytes rx_packets ... (8 rx fields) tx_bytes ..."
+	var total int64
+	for _, line := range strings.Split(string(data), "\n") {
+		iface, counters, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(iface) == "lo" {
+			continue
+		}
+		fields := strings.Fields(counters)
+		if len(fields) < 9 {
+			continue
+		}
+		if txBytes, err := strconv.ParseInt(fields[8], 10, 64); err == nil {
+			total += txBytes
+		}
+	}
+	return total, nil
+}
+
+// gameTxBytes takes the supervisor's traffic out of the interfaces' counter. Bytes the
+// supervisor has written may not have left the interface yet, so the result is kept from
+// dropping below what was reported before, which the API would take for a counter reset.
+func gameTxBytes(txBytes int64) int64 {
+	gameTx.mu.Lock()
+	defer gameTx.mu.Unlock()
+	gameTx.bytes = max(gameTx.bytes, txBytes-supervisorTxBytes.Load())
+	return gameTx.bytes
 }
 
 // GetMemoryUsageMB returns memory usage in MB for a PID
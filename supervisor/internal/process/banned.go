@@ -0,0 +1,118 @@
+package process
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mooncorn/gshub/supervisor/internal/api"
+	"go.uber.org/zap"
+)
+
+// maxHashedFileSize skips larger files so huge world data can't stall startup
+const maxHashedFileSize = 1 << 30
+
+// checkBannedBinaries hashes the files the game could run and refuses to start if any is on
+// the API's banned list. Candidates are the start command's executable, arguments naming
+// files (e.g. java -jar server.jar), and executables and jars at the top of the work dir.
+// The check fails open when the list can't be fetched, so an API hiccup doesn't keep
+// servers down.
+func (m *Manager) checkBannedBinaries(ctx context.Context, command []string) error {
+	hashes, err := m.apiClient.GetBannedHashes(ctx)
+	if err != nil {
+		m.logger.Warn("failed to fetch banned binary hashes, skipping check", zap.Error(err))
+		return nil
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	banned := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		banned[strings.ToLower(hash)] = true
+	}
+
+	for _, path := range m.binaryCandidates(command) {
+		hash, err := hashFile(path)
+		if err != nil {
+			m.logger.Debug("skipping unreadable file in banned binary check", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		if !banned[hash] {
+			continue
+		}
+
+		m.logger.Error("refusing to start banned binary", zap.String("path", path), zap.String("sha256", hash))
+		if err := m.apiClient.ReportBannedBinary(ctx, path, hash); err != nil {
+			m.logger.Warn("failed to report banned binary", zap.Error(err))
+			m.apiClient.ReportStatusWithRetry(ctx, api.StatusFailed, "Refusing to start a banned binary", 0)
+		}
+		return fmt.Errorf("banned binary %s (sha256 %s)", path, hash)
+	}
+
+	return nil
+}
+
+// binaryCandidates lists the existing regular files checked against the banned list
+func (m *Manager) binaryCandidates(command []string) []string {
+	seen := make(map[string]bool)
+	var candidates []string
+	add := func(path string) {
+		if !filepath.IsAbs(path) && m.config.WorkDir != "" {
+			path = filepath.Join(m.config.WorkDir, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxHashedFileSize || seen[path] {
+			return
+		}
+		seen[path] = true
+		candidates = append(candidates, path)
+	}
+
+	if executable, err := exec.LookPath(command[0]); err == nil {
+		add(executable)
+	} else {
+		add(command[0])
+	}
+	for _, arg := range command[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			add(arg)
+		}
+	}
+
+	if m.config.WorkDir != "" {
+		entries, _ := os.ReadDir(m.config.WorkDir)
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if strings.HasSuffix(entry.Name(), ".jar") || info.Mode().Perm()&0o111 != 0 {
+				add(entry.Name())
+			}
+		}
+	}
+
+	return candidates
+}
+
+// hashFile returns the hex SHA-256 of a file
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
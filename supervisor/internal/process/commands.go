@@ -0,0 +1,288 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mooncorn/gshub/supervisor/internal/api"
+	"go.uber.org/zap"
+)
+
+const (
+	// backupTimeout bounds how long a backup command may run
+	backupTimeout = 30 * time.Minute
+	// reloadTimeout bounds how long a reload command may run
+	reloadTimeout = 2 * time.Minute
+	// maxResultLength truncates command output reported back to the API
+	maxResultLength = 4000
+)
+
+// HandleCommand performs an action requested by the API and returns a short result
+// for the user, plus the file it produced for download, if any. Stop only ends the game
+// process; the API scales the pod down itself.
+func (m *Manager) HandleCommand(ctx context.Context, cmd api.Command) (string, *api.Artifact, error) {
+	switch cmd.Type {
+	case api.CommandStop:
+		if err := m.Stop(ctx, true); err != nil {
+			return "", nil, err
+		}
+		return "Game process stopped", nil, nil
+
+	case api.CommandRestartProcess:
+		if err := m.Restart(ctx); err != nil {
+			return "", nil, err
+		}
+		return "Game process restarted", nil, nil
+
+	case api.CommandReloadConfig:
+		result, err := m.reloadConfig(ctx, cmd.Payload)
+		return result, nil, err
+
+	case api.CommandExec:
+		if err := m.SendInput(cmd.Payload); err != nil {
+			return "", nil, err
+		}
+		return "Command sent to console", nil, nil
+
+	case api.CommandBackup:
+		return m.runBackup(ctx, cmd.Payload)
+
+	case api.CommandExport:
+		return m.exportWorld(ctx, cmd)
+
+	case api.CommandImport:
+		return m.importWorld(ctx, cmd)
+
+	default:
+		return "", nil, fmt.Errorf("unsupported command type %q", cmd.Type)
+	}
+}
+
+// reloadConfig applies env changes (payload is a JSON object of the server's full env)
+// by re-rendering config templates and running the reload command, or sending SIGHUP
+// when the game has none. The env also applies to later in-place restarts. It's only
+// passed to the game and its helper commands, never set in the supervisor itself.
+func (m *Manager) reloadConfig(ctx context.Context, payload string) (string, error) {
+	if m.Status() != StatusRunning {
+		return "", fmt.Errorf("cannot reload: process is in %s state", m.Status())
+	}
+
+	if payload != "" {
+		var env map[string]string
+		if err := json.Unmarshal([]byte(payload), &env); err != nil {
+			return "", fmt.Errorf("invalid reload payload: %w", err)
+		}
+		if skipped := m.setEnvOverrides(env); len(skipped) > 0 {
+			m.logger.Warn("ignored reserved env variables in reload", zap.Strings("keys", skipped))
+		}
+	}
+
+	if err := m.renderConfigTemplates(); err != nil {
+		return "", err
+	}
+
+	if len(m.config.ReloadCommand) == 0 {
+		if err := m.Signal(syscall.SIGHUP); err != nil {
+			return "", err
+		}
+		return "Reload signal sent", nil
+	}
+
+	output, err := m.runAuxCommand(ctx, "reload", m.config.ReloadCommand, reloadTimeout)
+	if err != nil {
+		return "", err
+	}
+	if output == "" {
+		output = "Configuration reloaded"
+	}
+	return output, nil
+}
+
+// backupPayload is the payload of a backup command
+type backupPayload struct {
+	Retention *backupRetention `json:"retention"` // Prune old archives; nil keeps them all
+}
+
+// backupRetention is how many archives to keep in the backup directory: the newest of each
+// of the last KeepDaily days and KeepWeekly weeks that have one. The newest is always kept.
+type backupRetention struct {
+	KeepDaily  int `json:"keep_daily"`
+	KeepWeekly int `json:"keep_weekly"`
+}
+
+// runBackup runs the configured backup command alongside the game process. With a backup
+// directory, the newest archive in it is offered for download and older ones are pruned
+// by the retention in the payload.
+func (m *Manager) runBackup(ctx context.Context, payload string) (string, *api.Artifact, error) {
+	if len(m.config.BackupCommand) == 0 {
+		return "", nil, fmt.Errorf("backups are not supported for this server")
+	}
+
+	var backup backupPayload
+	if payload != "" {
+		if err := json.Unmarshal([]byte(payload), &backup); err != nil {
+			return "", nil, fmt.Errorf("invalid backup payload: %w", err)
+		}
+	}
+
+	output, err := m.runAuxCommand(ctx, "backup", m.config.BackupCommand, backupTimeout)
+	if err != nil {
+		return "", nil, err
+	}
+	if output == "" {
+		output = "Backup completed"
+	}
+
+	if m.config.BackupDir == "" {
+		return output, nil, nil
+	}
+
+	// The backup itself succeeded even if pruning fails or the archive can't be found
+	if backup.Retention != nil {
+		pruned, err := m.pruneBackups(*backup.Retention)
+		if err != nil {
+			m.logger.Warn("failed to prune backups", zap.Error(err))
+		}
+		if len(pruned) > 0 {
+			m.logger.Info("pruned backups", zap.Strings("archives", pruned))
+			output += fmt.Sprintf(" (removed %d old backups)", len(pruned))
+		}
+	}
+
+	artifact, err := m.latestBackup()
+	if err != nil {
+		m.logger.Warn("failed to find backup archive", zap.Error(err))
+	}
+	return output, artifact, nil
+}
+
+// pruneBackups removes the archives in the backup directory that retention doesn't keep,
+// and returns their names
+func (m *Manager) pruneBackups(retention backupRetention) ([]string, error) {
+	entries, err := os.ReadDir(m.config.BackupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var archives []os.FileInfo
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			archives = append(archives, info)
+		}
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].ModTime().After(archives[j].ModTime())
+	})
+
+	// Newest first, so each day and week keeps its newest archive
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	var pruned []string
+	for i, archive := range archives {
+		day := archive.ModTime().UTC().Format(time.DateOnly)
+		year, week := archive.ModTime().UTC().ISOWeek()
+		weekKey := fmt.Sprintf("%d-W%02d", year, week)
+
+		keep := i == 0
+		if !days[day] && len(days) < retention.KeepDaily {
+			days[day] = true
+			keep = true
+		}
+		if !weeks[weekKey] && len(weeks) < retention.KeepWeekly {
+			weeks[weekKey] = true
+			keep = true
+		}
+		if keep {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(m.config.BackupDir, archive.Name())); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, archive.Name())
+	}
+	return pruned, nil
+}
+
+// latestBackup returns the most recently modified file in the backup directory, or nil
+// if there is none
+func (m *Manager) latestBackup() (*api.Artifact, error) {
+	entries, err := os.ReadDir(m.config.BackupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var newest os.FileInfo
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if newest == nil || info.ModTime().After(newest.ModTime()) {
+			newest = info
+		}
+	}
+	if newest == nil {
+		return nil, nil
+	}
+
+	path, err := filepath.Rel(m.config.DataDir, filepath.Join(m.config.BackupDir, newest.Name()))
+	if err != nil {
+		return nil, err
+	}
+	return &api.Artifact{Path: filepath.ToSlash(path), Size: newest.Size()}, nil
+}
+
+// runAuxCommand runs a catalog-defined helper command next to the game process and
+// returns its trimmed output, truncated to the last maxResultLength bytes
+func (m *Manager) runAuxCommand(ctx context.Context, name string, command []string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = m.expandEnv(arg)
+	}
+
+	aux := exec.CommandContext(ctx, args[0], args[1:]...)
+	aux.Dir = m.config.WorkDir
+	aux.Env = m.processEnv()
+
+	m.logger.Info("running "+name+" command", zap.Strings("command", args))
+
+	var output bytes.Buffer
+	aux.Stdout = &output
+	aux.Stderr = &output
+	err := m.reaper.StartCommand(aux)
+	if err == nil {
+		err = aux.Wait()
+		m.reaper.Forget(aux.Process.Pid)
+	}
+	result := strings.TrimSpace(output.String())
+	if len(result) > maxResultLength {
+		result = result[len(result)-maxResultLength:]
+	}
+	if err != nil {
+		if result != "" {
+			return "", fmt.Errorf("%s failed: %w: %s", name, err, result)
+		}
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package process
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mooncorn/gshub/supervisor/internal/rcon"
+)
+
+const (
+	// consoleTimeout bounds a console command
+	consoleTimeout = 10 * time.Second
+	// consoleQuietPeriod is how long the game's output must pause after a command written to
+	// stdin before its output counts as complete
+	consoleQuietPeriod = 500 * time.Millisecond
+	// consoleMaxWait bounds how long output is collected after a command written to stdin
+	consoleMaxWait = 3 * time.Second
+)
+
+// ErrNotRunning is returned for console commands while the game isn't running
+var ErrNotRunning = errors.New("game is not running")
+
+// RunConsoleCommand runs a console command in the game and returns its output, truncated to
+// the last maxResultLength bytes. Games serving RCON run it over RCON; otherwise it's written
+// to the game's stdin and the output is what the game prints until it goes quiet, which may
+// include unrelated lines such as player chat.
+func (m *Manager) RunConsoleCommand(ctx context.Context, command string) (string, error) {
+	if status := m.Status(); status != StatusRunning {
+		return "", fmt.Errorf("%w: process is in %s state", ErrNotRunning, status)
+	}
+
+	// One at a time, so output captured from stdin isn't shared between commands
+	m.consoleMu.Lock()
+	defer m.consoleMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, consoleTimeout)
+	defer cancel()
+
+	var output string
+	var err error
+	if m.config.RCONPort != 0 {
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(m.config.RCONPort))
+		output, err = rcon.Exec(ctx, addr, m.getenv(m.config.RCONPasswordEnv), command)
+	} else {
+		output, err = m.runStdinCommand(ctx, command)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	output = strings.TrimSpace(output)
+	if len(output) > maxResultLength {
+		output = output[len(output)-maxResultLength:]
+	}
+	return output, nil
+}
+
+// runStdinCommand writes command to the game's stdin and collects the lines it prints until
+// it's quiet for consoleQuietPeriod, or consoleMaxWait has passed
+func (m *Manager) runStdinCommand(ctx context.Context, command string) (string, error) {
+	lines := make(chan string, 100)
+	m.consoleOutput.Store(&lines)
+	defer m.consoleOutput.Store(nil)
+
+	if err := m.SendInput(command); err != nil {
+		return "", err
+	}
+
+	var output []string
+	deadline := time.NewTimer(consoleMaxWait)
+	defer deadline.Stop()
+	quiet := time.NewTimer(consoleQuietPeriod)
+	defer quiet.Stop()
+
+	for {
+		select {
+		case line := <-lines:
+			output = append(output, line)
+			quiet.Reset(consoleQuietPeriod)
+		case <-quiet.C:
+			return strings.Join(output, "\n"), nil
+		case <-deadline.C:
+			return strings.Join(output, "\n"), nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// captureConsoleOutput passes a line of game output to the console command waiting for it,
+// if any
+func (m *Manager) captureConsoleOutput(line string) {
+	if lines := m.consoleOutput.Load(); lines != nil {
+		select {
+		case *lines <- line:
+		default: // Drop lines beyond the buffer rather than stall the game's output
+		}
+	}
+}
+
+// ensureRCONPassword gives the game a random RCON password unless one is set. Only the
+// supervisor talks to the game's RCON, so nobody else needs to know it.
+func (m *Manager) ensureRCONPassword() error {
+	if m.config.RCONPort == 0 || m.getenv(m.config.RCONPasswordEnv) != "" {
+		return nil
+	}
+	password := make([]byte, 16)
+	if _, err := rand.Read(password); err != nil {
+		return fmt.Errorf("failed to generate RCON password: %w", err)
+	}
+	return os.Setenv(m.config.RCONPasswordEnv, hex.EncodeToString(password))
+}
@@ -0,0 +1,59 @@
+package process
+
+import "sync"
+
+const (
+	// consoleBacklog is how many recent lines of game output new console viewers get
+	consoleBacklog = 200
+	// consoleViewerBuffer is how many lines a console viewer can fall behind before it misses some
+	consoleViewerBuffer = 256
+)
+
+// ConsoleLog keeps the game's recent output and passes new lines to live console viewers
+type ConsoleLog struct {
+	mu      sync.Mutex
+	lines   []string // The last consoleBacklog lines, oldest at next once full
+	next    int
+	viewers map[chan string]struct{}
+}
+
+// Subscribe returns the recent lines and a channel receiving new ones until cancel is
+// called. Viewers that can't keep up miss lines rather than stall the game's output.
+func (l *ConsoleLog) Subscribe() (backlog []string, lines <-chan string, cancel func()) {
+	ch := make(chan string, consoleViewerBuffer)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.viewers == nil {
+		l.viewers = make(map[chan string]struct{})
+	}
+	l.viewers[ch] = struct{}{}
+	backlog = append(backlog, l.lines[l.next:]...)
+	backlog = append(backlog, l.lines[:l.next]...)
+
+	return backlog, ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.viewers, ch)
+	}
+}
+
+// publish records a line of game output and passes it to the viewers
+func (l *ConsoleLog) publish(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.lines) < consoleBacklog {
+		l.lines = append(l.lines, line)
+	} else {
+		l.lines[l.next] = line
+		l.next = (l.next + 1) % consoleBacklog
+	}
+
+	for ch := range l.viewers {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package process
+
+import (
+	"os"
+	"strings"
+)
+
+// reservedEnvPrefixes and reservedEnv are variables users can't set through a config reload:
+// supervisor settings, which come from the Deployment, and variables that change how
+// programs are loaded or found
+var (
+	reservedEnvPrefixes = []string{"GSHUB_", "LD_", "DYLD_"}
+	reservedEnv         = map[string]bool{
+		"PATH": true, "HOME": true, "SHELL": true, "IFS": true, "TMPDIR": true,
+		"GCONV_PATH": true, "LOCPATH": true, "NLSPATH": true, "HOSTALIASES": true,
+	}
+)
+
+// isReservedEnv reports whether a reload may not set key
+func isReservedEnv(key string) bool {
+	if reservedEnv[key] {
+		return true
+	}
+	for _, prefix := range reservedEnvPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setEnvOverrides replaces the env a reload applied on top of the supervisor's own, for the
+// game and its helper commands. Reserved keys are skipped and returned.
+func (m *Manager) setEnvOverrides(env map[string]string) []string {
+	overrides := make(map[string]string, len(env))
+	var skipped []string
+	for key, value := range env {
+		if isReservedEnv(key) {
+			skipped = append(skipped, key)
+			continue
+		}
+		overrides[key] = value
+	}
+
+	m.envMu.Lock()
+	m.envOverrides = overrides
+	m.envMu.Unlock()
+	return skipped
+}
+
+// getenv returns the game's value of key: a reload's, or else the supervisor's
+func (m *Manager) getenv(key string) string {
+	m.envMu.RLock()
+	value, ok := m.envOverrides[key]
+	m.envMu.RUnlock()
+	if ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// expandEnv replaces ${VAR} and $VAR in s with the game's env
+func (m *Manager) expandEnv(s string) string {
+	return os.Expand(s, m.getenv)
+}
+
+// processEnv returns the env the game and its helper commands run with: the supervisor's,
+// with a reload's on top
+func (m *Manager) processEnv() []string {
+	m.envMu.RLock()
+	defer m.envMu.RUnlock()
+
+	env := make([]string, 0, len(m.envOverrides))
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := m.envOverrides[key]; !ok {
+			env = append(env, kv)
+		}
+	}
+	for key, value := range m.envOverrides {
+		env = append(env, key+"="+value)
+	}
+	return env
+}
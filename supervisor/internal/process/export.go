@@ -0,0 +1,250 @@
+package process
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mooncorn/gshub/supervisor/internal/api"
+	"go.uber.org/zap"
+)
+
+const (
+	// exportTimeout bounds how long a world export may run
+	exportTimeout = 2 * time.Hour
+	// exportDirName is where exports are written, inside the data directory. Only the latest
+	// export is kept.
+	exportDirName = ".gshub-exports"
+	// progressInterval is how often export, import and startup progress is reported to the API
+	progressInterval = 2 * time.Second
+)
+
+// exportPayload is the payload of an export command
+type exportPayload struct {
+	MaxBytes int64 `json:"max_bytes"` // Refuse worlds larger than this (0 means no limit)
+}
+
+// worldFile is a file to include in an export
+type worldFile struct {
+	path string // Relative to the data directory
+	size int64
+}
+
+// exportWorld archives the data directory as a zip for the user to download, reporting
+// progress as it goes. The game keeps running, so files it writes meanwhile may be caught
+// mid-save; running a backup first gives a consistent copy of games that support it.
+func (m *Manager) exportWorld(ctx context.Context, cmd api.Command) (string, *api.Artifact, error) {
+	if m.config.DataDir == "" {
+		return "", nil, fmt.Errorf("world exports are not supported for this server")
+	}
+
+	var payload exportPayload
+	if cmd.Payload != "" {
+		if err := json.Unmarshal([]byte(cmd.Payload), &payload); err != nil {
+			return "", nil, fmt.Errorf("invalid export payload: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	exportDir := filepath.Join(m.config.DataDir, exportDirName)
+	files, total, err := listWorldFiles(m.config.DataDir, exportDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list world files: %w", err)
+	}
+	if payload.MaxBytes > 0 && total > payload.MaxBytes {
+		return "", nil, fmt.Errorf("world is %s, over the %s export limit", formatBytes(total), formatBytes(payload.MaxBytes))
+	}
+
+	// Replace the previous export, so exports never pile up on the volume
+	if err := os.RemoveAll(exportDir); err != nil {
+		return "", nil, fmt.Errorf("failed to remove previous export: %w", err)
+	}
+
+	// The archive is at most about the size of the world, and must fit beside it
+	if free, err := freeBytes(m.config.DataDir); err == nil && uint64(total) > free {
+		return "", nil, fmt.Errorf("not enough free space to export: world is %s, %s free", formatBytes(total), formatBytes(int64(free)))
+	}
+
+	if err := os.MkdirAll(exportDir, 0o755); err != nil {
+		return "", nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	name := fmt.Sprintf("world-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	progress := &commandProgress{manager: m, commandID: cmd.ID, total: total}
+	progress.report(true)
+
+	partial := filepath.Join(exportDir, name+".partial")
+	if err := m.writeWorldZip(ctx, partial, files, progress); err != nil {
+		os.Remove(partial)
+		return "", nil, err
+	}
+	if err := os.Rename(partial, filepath.Join(exportDir, name)); err != nil {
+		os.Remove(partial)
+		return "", nil, fmt.Errorf("failed to finish export: %w", err)
+	}
+	progress.report(true)
+
+	info, err := os.Stat(filepath.Join(exportDir, name))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to stat export: %w", err)
+	}
+
+	m.logger.Info("exported world",
+		zap.Int("files", len(files)),
+		zap.Int64("world_bytes", total),
+		zap.Int64("archive_bytes", info.Size()))
+
+	result := fmt.Sprintf("Exported %d files (%s)", len(files), formatBytes(info.Size()))
+	return result, &api.Artifact{Path: exportDirName + "/" + name, Size: info.Size()}, nil
+}
+
+// listWorldFiles returns the regular files under dataDir, skipping the export directory,
+// and their total size. Symlinks are skipped, so nothing outside the volume is exported.
+func listWorldFiles(dataDir, exportDir string) ([]worldFile, int64, error) {
+	var files []worldFile
+	var total int64
+
+	err := filepath.WalkDir(dataDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && path == exportDir {
+			return filepath.SkipDir
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // Removed by the game since listing
+		}
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, worldFile{path: filepath.ToSlash(rel), size: info.Size()})
+		total += info.Size()
+		return nil
+	})
+	return files, total, err
+}
+
+// writeWorldZip writes files (relative to the data directory) into a zip archive at dest
+func (m *Manager) writeWorldZip(ctx context.Context, dest string, files []worldFile, progress *commandProgress) error {
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create export: %w", err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("export cancelled: %w", err)
+		}
+		if err := m.addZipFile(zw, file, progress); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return out.Close()
+}
+
+// addZipFile copies one file into the archive. Files the game removed since listing are
+// skipped.
+func (m *Manager) addZipFile(zw *zip.Writer, file worldFile, progress *commandProgress) error {
+	src, err := os.Open(filepath.Join(m.config.DataDir, filepath.FromSlash(file.path)))
+	if errors.Is(err, fs.ErrNotExist) {
+		progress.add(file.size)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", file.path, err)
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", file.path, err)
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", file.path, err)
+	}
+	header.Name = file.path
+	header.Method = zip.Deflate
+
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", file.path, err)
+	}
+	if _, err := io.Copy(dst, io.TeeReader(src, progress)); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", file.path, err)
+	}
+	return nil
+}
+
+// commandProgress counts the bytes an export or import has processed so far and reports
+// them to the API, at most once per progressInterval
+type commandProgress struct {
+	manager    *Manager
+	commandID  string
+	total      int64
+	done       int64
+	reportedAt time.Time
+}
+
+// Write counts bytes copied
+func (p *commandProgress) Write(b []byte) (int, error) {
+	p.add(int64(len(b)))
+	return len(b), nil
+}
+
+func (p *commandProgress) add(n int64) {
+	p.done = min(p.done+n, p.total) // Files may have grown since listing
+	p.report(false)
+}
+
+// report sends the progress to the API; unless forced, only if the interval has passed.
+// Failures are logged and otherwise ignored.
+func (p *commandProgress) report(force bool) {
+	if !force && time.Since(p.reportedAt) < progressInterval {
+		return
+	}
+	p.reportedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.manager.apiClient.ReportCommandProgress(ctx, p.commandID, p.done, p.total); err != nil {
+		p.manager.logger.Debug("failed to report command progress", zap.Error(err))
+	}
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 GiB"
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTP"[exp])
+}
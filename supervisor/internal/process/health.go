@@ -206,8 +206,10 @@ func (hc *HealthChecker) StartLogScanner(ctx context.Context) {
 	}()
 }
 
-// RunContinuousChecks runs health checks continuously after initial healthy state
-func (hc *HealthChecker) RunContinuousChecks(ctx context.Context, onUnhealthy func()) {
+// RunContinuousChecks runs health checks continuously after initial healthy state.
+// onChange is called with false once the game fails maxFailures checks in a row, and with
+// true when it passes a check again afterwards.
+func (hc *HealthChecker) RunContinuousChecks(ctx context.Context, onChange func(healthy bool)) {
 	if hc.config.Type == "none" {
 		return
 	}
@@ -217,6 +219,7 @@ func (hc *HealthChecker) RunContinuousChecks(ctx context.Context, onUnhealthy fu
 
 	failCount := 0
 	maxFailures := 3 // Mark unhealthy after 3 consecutive failures
+	unhealthy := false
 
 	for {
 		select {
@@ -242,13 +245,18 @@ func (hc *HealthChecker) RunContinuousChecks(ctx context.Context, onUnhealthy fu
 
 				if failCount >= maxFailures {
 					hc.setHealthy(false)
-					if onUnhealthy != nil {
-						onUnhealthy()
+					if !unhealthy && onChange != nil {
+						onChange(false)
 					}
+					unhealthy = true
 				}
 			} else {
 				failCount = 0
 				hc.setHealthy(true)
+				if unhealthy && onChange != nil {
+					onChange(true)
+				}
+				unhealthy = false
 			}
 		}
 	}
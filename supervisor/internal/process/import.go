@@ -0,0 +1,367 @@
+package process
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mooncorn/gshub/supervisor/internal/api"
+	"go.uber.org/zap"
+)
+
+const (
+	// importUploadWait is how long an import waits for its archive to be uploaded
+	importUploadWait = time.Hour
+	// importTimeout bounds extracting an uploaded archive
+	importTimeout = 2 * time.Hour
+	// importDirName is where uploaded archives wait to be extracted, inside the data directory
+	importDirName = ".gshub-imports"
+)
+
+// importPayload is the payload of an import command
+type importPayload struct {
+	MaxBytes int64 `json:"max_bytes"` // Refuse archives that extract to more than this (0 means no limit)
+}
+
+// archiveFile is a regular file in an uploaded archive
+type archiveFile struct {
+	name string // Slash-separated, relative to the archive root
+	size int64
+}
+
+// ImportArchivePath returns where the archive for an import command is uploaded to
+func ImportArchivePath(dataDir, commandID string) string {
+	return filepath.Join(dataDir, importDirName, commandID)
+}
+
+// importWorld waits for the command's archive to be uploaded, checks it holds a world for
+// this game, and replaces the import directory with its contents. A running game is stopped
+// for the import and started again afterwards.
+func (m *Manager) importWorld(ctx context.Context, cmd api.Command) (string, *api.Artifact, error) {
+	if m.config.ImportDir == "" {
+		return "", nil, fmt.Errorf("world imports are not supported for this server")
+	}
+
+	var payload importPayload
+	if cmd.Payload != "" {
+		if err := json.Unmarshal([]byte(cmd.Payload), &payload); err != nil {
+			return "", nil, fmt.Errorf("invalid import payload: %w", err)
+		}
+	}
+
+	if m.Status() == StatusIdle {
+		m.apiClient.ReportStatusWithRetry(ctx, api.StatusStarting, "Waiting for world upload", 0)
+	}
+
+	archive := ImportArchivePath(m.config.DataDir, cmd.ID)
+	if err := waitForArchive(ctx, archive); err != nil {
+		return "", nil, err
+	}
+	defer os.Remove(archive)
+
+	ctx, cancel := context.WithTimeout(ctx, importTimeout)
+	defer cancel()
+
+	format, err := detectArchiveFormat(archive)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var files []archiveFile
+	var total int64
+	err = walkArchive(archive, format, false, func(name string, size int64, _ fs.FileMode, _ io.Reader) error {
+		files = append(files, archiveFile{name: name, size: size})
+		total += size
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if payload.MaxBytes > 0 && total > payload.MaxBytes {
+		return "", nil, fmt.Errorf("archive extracts to %s, over the %s import limit", formatBytes(total), formatBytes(payload.MaxBytes))
+	}
+	if free, err := freeBytes(m.config.DataDir); err == nil && uint64(total) > free {
+		return "", nil, fmt.Errorf("not enough free space to import: archive extracts to %s, %s free", formatBytes(total), formatBytes(int64(free)))
+	}
+
+	root, err := archiveRoot(files, m.config.ImportRequiredFiles)
+	if err != nil {
+		return "", nil, err
+	}
+
+	progress := &commandProgress{manager: m, commandID: cmd.ID, total: total}
+	progress.report(true)
+
+	var extracted int
+	extract := func() error {
+		if err := m.clearImportDir(); err != nil {
+			return err
+		}
+		n, err := m.extractArchive(ctx, archive, format, root, progress)
+		extracted = n
+		return err
+	}
+
+	if m.Status() == StatusRunning {
+		err = m.restartWith(ctx, "Importing world", extract)
+	} else {
+		m.apiClient.ReportStatusWithRetry(ctx, api.StatusStarting, "Importing world", 0)
+		err = extract()
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	progress.report(true)
+
+	m.logger.Info("imported world",
+		zap.String("format", format),
+		zap.String("root", root),
+		zap.Int("files", extracted),
+		zap.Int64("bytes", total))
+
+	return fmt.Sprintf("Imported %d files (%s)", extracted, formatBytes(total)), nil, nil
+}
+
+// waitForArchive waits until the archive at path has been uploaded
+func waitForArchive(ctx context.Context, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, importUploadWait)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no archive was uploaded within %s", importUploadWait)
+		case <-ticker.C:
+		}
+	}
+}
+
+// detectArchiveFormat returns "zip" or "tar.gz" from the archive's first bytes
+func detectArchiveFormat(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(f, magic)
+	switch {
+	case n >= 4 && string(magic) == "PK\x03\x04":
+		return "zip", nil
+	case n >= 2 && magic[0] == 0x1f && magic[1] == 0x8b:
+		return "tar.gz", nil
+	default:
+		return "", fmt.Errorf("unsupported archive format: upload a .zip or .tar.gz file")
+	}
+}
+
+// walkArchive calls fn for each regular file in the archive, with its cleaned name and, if
+// read is set, its contents. Directories, links and other special files are skipped, and
+// names that would escape the import directory are rejected.
+func walkArchive(archive, format string, read bool, fn func(name string, size int64, mode fs.FileMode, r io.Reader) error) error {
+	switch format {
+	case "zip":
+		zr, err := zip.OpenReader(archive)
+		if err != nil {
+			return fmt.Errorf("invalid zip archive: %w", err)
+		}
+		defer zr.Close()
+
+		for _, f := range zr.File {
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			name, err := cleanArchiveName(f.Name)
+			if err != nil {
+				return err
+			}
+			if !read {
+				if err := fn(name, int64(f.UncompressedSize64), f.Mode(), nil); err != nil {
+					return err
+				}
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			err = fn(name, int64(f.UncompressedSize64), f.Mode(), rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "tar.gz":
+		f, err := os.Open(archive)
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer f.Close()
+
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("invalid tar.gz archive: %w", err)
+		}
+		defer gz.Close()
+
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid tar.gz archive: %w", err)
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			name, err := cleanArchiveName(header.Name)
+			if err != nil {
+				return err
+			}
+			var r io.Reader
+			if read {
+				r = tr
+			}
+			if err := fn(name, header.Size, header.FileInfo().Mode(), r); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// cleanArchiveName normalizes a file name from an archive, rejecting absolute paths and
+// paths that leave the archive
+func cleanArchiveName(name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("archive contains unsafe path %q", name)
+	}
+	return cleaned, nil
+}
+
+// archiveRoot returns the directory in the archive that holds the world: the shallowest one
+// containing every required file, so worlds zipped inside a folder import as well. Without
+// required files the whole archive is imported.
+func archiveRoot(files []archiveFile, required []string) (string, error) {
+	if len(required) == 0 {
+		return "", nil
+	}
+
+	names := make(map[string]bool, len(files))
+	for _, file := range files {
+		names[file.name] = true
+	}
+
+	first := path.Clean(required[0])
+	root, found := "", false
+	for _, file := range files {
+		if file.name != first && !strings.HasSuffix(file.name, "/"+first) {
+			continue
+		}
+		candidate := strings.TrimSuffix(file.name, first)
+		if found && strings.Count(candidate, "/") >= strings.Count(root, "/") {
+			continue
+		}
+		complete := true
+		for _, req := range required[1:] {
+			if !names[candidate+path.Clean(req)] {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			root, found = candidate, true
+		}
+	}
+
+	if !found {
+		return "", fmt.Errorf("archive is not a world for this game: it must contain %s", strings.Join(required, ", "))
+	}
+	return root, nil
+}
+
+// clearImportDir removes the current world from the import directory, keeping the
+// supervisor's own directories and the backup directory
+func (m *Manager) clearImportDir() error {
+	entries, err := os.ReadDir(m.config.ImportDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.MkdirAll(m.config.ImportDir, 0o755)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read import directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".gshub-") {
+			continue
+		}
+		full := filepath.Join(m.config.ImportDir, entry.Name())
+		if m.config.BackupDir != "" && (full == m.config.BackupDir || strings.HasPrefix(m.config.BackupDir, full+string(filepath.Separator))) {
+			continue
+		}
+		if err := os.RemoveAll(full); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// extractArchive writes the files under root in the archive into the import directory and
+// returns how many it wrote
+func (m *Manager) extractArchive(ctx context.Context, archive, format, root string, progress *commandProgress) (int, error) {
+	count := 0
+	err := walkArchive(archive, format, true, func(name string, size int64, mode fs.FileMode, r io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("import cancelled: %w", err)
+		}
+		rel, ok := strings.CutPrefix(name, root)
+		if !ok || strings.HasPrefix(rel, ".gshub-") {
+			return nil // Outside the world (e.g. __MACOSX metadata), or the supervisor's own
+		}
+
+		dest := filepath.Join(m.config.ImportDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", name, err)
+		}
+		out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644|mode.Perm()&0o111)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", name, err)
+		}
+		if _, err := io.Copy(out, io.TeeReader(r, progress)); err != nil {
+			out.Close()
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		if err := out.Close(); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,99 @@
+package process
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Severity is the level of a game output line
+type Severity string
+
+const (
+	SeverityDebug Severity = "debug"
+	SeverityInfo  Severity = "info"
+	SeverityWarn  Severity = "warn"
+	SeverityError Severity = "error"
+)
+
+// SeverityTagPrefix starts the tag written before every game output line, e.g.
+// "[gshub:warn] ". The API strips it and uses it to filter and colorize logs.
+const SeverityTagPrefix = "[gshub:"
+
+// severityRule maps lines matching a pattern to a severity; the first match wins
+type severityRule struct {
+	pattern  *regexp.Regexp
+	severity Severity
+}
+
+// genericRules recognize common level keywords in any log format
+var genericRules = []severityRule{
+	{regexp.MustCompile(`(?i)\b(error|fatal|severe|exception|panic)\b`), SeverityError},
+	{regexp.MustCompile(`(?i)\bwarn(ing)?\b`), SeverityWarn},
+	{regexp.MustCompile(`(?i)\b(debug|trace)\b`), SeverityDebug},
+}
+
+// LogFormats are the per-game line parsers, selected with GSHUB_LOG_FORMAT. Lines no
+// rule matches are info.
+var LogFormats = map[string][]severityRule{
+	"generic": genericRules,
+
+	// [12:34:56] [Server thread/WARN]: Can't keep up!
+	"minecraft": {
+		{regexp.MustCompile(`^\[[^\]]*\] \[[^\]]*/(ERROR|FATAL)\]`), SeverityError},
+		{regexp.MustCompile(`^\[[^\]]*\] \[[^\]]*/WARN\]`), SeverityWarn},
+		{regexp.MustCompile(`^\[[^\]]*\] \[[^\]]*/(DEBUG|TRACE)\]`), SeverityDebug},
+		{regexp.MustCompile(`^\[[^\]]*\] \[[^\]]*/INFO\]`), SeverityInfo},
+		// Java stack traces continue the error above them
+		{regexp.MustCompile(`^\s+at |^Caused by: |^[\w.]+(Exception|Error)(: |$)`), SeverityError},
+	},
+
+	// 02/14/2024 12:34:56: Unity and game output, with Unity's own error markers
+	"valheim": append([]severityRule{
+		{regexp.MustCompile(`NullReferenceException|^Failed to |\(Filename: .*Line: \d+\)`), SeverityError},
+	}, genericRules...),
+
+	// [Session] 'HostOnline' (up)!  /  [error] Failed to bind socket
+	"enshrouded": append([]severityRule{
+		{regexp.MustCompile(`^\[(error|fatal)\]`), SeverityError},
+		{regexp.MustCompile(`^\[(warning|warn)\]`), SeverityWarn},
+		{regexp.MustCompile(`^wine: |^\d+:err:`), SeverityError},
+		{regexp.MustCompile(`^\d+:fixme:`), SeverityDebug},
+	}, genericRules...),
+}
+
+// LogParser tags game output lines with their severity
+type LogParser struct {
+	rules []severityRule
+}
+
+// NewLogParser returns the parser for a log format, falling back to generic for
+// unknown formats
+func NewLogParser(format string) *LogParser {
+	rules, ok := LogFormats[format]
+	if !ok {
+		rules = genericRules
+	}
+	return &LogParser{rules: rules}
+}
+
+// Severity returns the severity of a line
+func (p *LogParser) Severity(line string) Severity {
+	for _, rule := range p.rules {
+		if rule.pattern.MatchString(line) {
+			return rule.severity
+		}
+	}
+	return SeverityInfo
+}
+
+// Tag prefixes a line with its severity tag
+func (p *LogParser) Tag(line string) string {
+	var b strings.Builder
+	severity := p.Severity(line)
+	b.Grow(len(SeverityTagPrefix) + len(severity) + 2 + len(line))
+	b.WriteString(SeverityTagPrefix)
+	b.WriteString(string(severity))
+	b.WriteString("] ")
+	b.WriteString(line)
+	return b.String()
+}
@@ -1,12 +1,15 @@
 package process
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -32,24 +35,52 @@ type Manager struct {
 	config        *config.Config
 	apiClient     *api.Client
 	healthChecker *HealthChecker
+	logParser     *LogParser
+	players       *PlayerTracker
+	phases        *PhaseTracker
+	progress      *ProgressTracker
+	reaper        *Reaper
 	logger        *zap.Logger
 
 	cmd      *exec.Cmd
 	status   Status
 	statusMu sync.RWMutex
 
-	// Channels for coordination
-	stopCh   chan struct{}
-	doneCh   chan struct{}
-	exitCode int
+	// envOverrides is the env the last config reload set for the game
+	envOverrides map[string]string
+	envMu        sync.RWMutex
+
+	// Channels for coordination. doneCh closes when the current process exits;
+	// exitCh closes once the supervisor should exit, i.e. not for in-place restarts.
+	stopCh     chan struct{}
+	doneCh     chan struct{}
+	exitCh     chan struct{}
+	exitOnce   sync.Once
+	exitCode   int
+	restarting atomic.Bool
+
+	// unresponsive is set while a running game fails its health checks
+	unresponsive atomic.Bool
 
 	// For stdout/stderr capture
 	stdout io.ReadCloser
 	stderr io.ReadCloser
+
+	// Game console input for exec and console commands
+	stdin   io.WriteCloser
+	stdinMu sync.Mutex
+
+	// consoleMu serializes console commands; consoleOutput receives the game's output while
+	// one written to stdin waits for it
+	consoleMu     sync.Mutex
+	consoleOutput atomic.Pointer[chan string]
+
+	// consoleLog is the game's output for the interactive console
+	consoleLog ConsoleLog
 }
 
 // NewManager creates a new process manager
-func NewManager(cfg *config.Config, apiClient *api.Client, logger *zap.Logger) (*Manager, error) {
+func NewManager(cfg *config.Config, apiClient *api.Client, reaper *Reaper, logger *zap.Logger) (*Manager, error) {
 	healthConfig := HealthConfig{
 		Type:         cfg.HealthType,
 		Port:         cfg.HealthPort,
@@ -65,14 +96,24 @@ func NewManager(cfg *config.Config, apiClient *api.Client, logger *zap.Logger) (
 		return nil, fmt.Errorf("failed to create health checker: %w", err)
 	}
 
+	if _, ok := LogFormats[cfg.LogFormat]; !ok {
+		logger.Warn("unknown log format, using generic", zap.String("log_format", cfg.LogFormat))
+	}
+
 	return &Manager{
 		config:        cfg,
 		apiClient:     apiClient,
 		healthChecker: healthChecker,
+		logParser:     NewLogParser(cfg.LogFormat),
+		players:       NewPlayerTracker(cfg.LogFormat),
+		phases:        NewPhaseTracker(cfg.LogFormat),
+		progress:      NewProgressTracker(cfg.ProgressPatterns),
+		reaper:        reaper,
 		logger:        logger,
 		status:        StatusIdle,
 		stopCh:        make(chan struct{}),
 		doneCh:        make(chan struct{}),
+		exitCh:        make(chan struct{}),
 	}, nil
 }
 
@@ -108,20 +149,29 @@ func (m *Manager) Start(ctx context.Context) error {
 
 	m.setStatus(StatusStarting)
 	m.stopCh = make(chan struct{})
+	m.statusMu.Lock()
 	m.doneCh = make(chan struct{})
+	m.statusMu.Unlock()
+	m.healthChecker.setHealthy(false)
+	m.unresponsive.Store(false)
 
 	// Report starting status
-	m.apiClient.ReportStatusWithRetry(ctx, api.StatusStarting, "Starting game process", 0, 3)
+	m.apiClient.ReportStatusWithRetry(ctx, api.StatusStarting, "Starting game process", 0)
 
 	// Build command
 	if len(m.config.StartCommand) == 0 {
 		return fmt.Errorf("start command is empty")
 	}
 
+	if err := m.ensureRCONPassword(); err != nil {
+		m.setStatus(StatusFailed)
+		return err
+	}
+
 	// Expand environment variables in command arguments (e.g., ${MEMORY} -> 1536M)
 	expandedCmd := make([]string, len(m.config.StartCommand))
 	for i, arg := range m.config.StartCommand {
-		expandedCmd[i] = os.ExpandEnv(arg)
+		expandedCmd[i] = m.expandEnv(arg)
 	}
 
 	m.cmd = exec.CommandContext(ctx, expandedCmd[0], expandedCmd[1:]...)
@@ -131,11 +181,30 @@ func (m *Manager) Start(ctx context.Context) error {
 		m.cmd.Dir = m.config.WorkDir
 	}
 
-	// Inherit environment and add supervisor-specific vars
-	m.cmd.Env = os.Environ()
+	// Inherit environment, with the env of the last config reload on top
+	m.cmd.Env = m.processEnv()
+
+	if err := m.renderConfigTemplates(); err != nil {
+		m.setStatus(StatusFailed)
+		m.apiClient.ReportStatusWithRetry(ctx, api.StatusFailed, fmt.Sprintf("Failed to render config: %v", err), 0)
+		return fmt.Errorf("failed to render config templates: %w", err)
+	}
+
+	if err := m.checkBannedBinaries(ctx, expandedCmd); err != nil {
+		m.setStatus(StatusFailed)
+		return err
+	}
+
+	// Capture stdout and stderr, and keep stdin open for console commands
+	stdin, err := m.cmd.StdinPipe()
+	if err != nil {
+		m.setStatus(StatusFailed)
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	m.stdinMu.Lock()
+	m.stdin = stdin
+	m.stdinMu.Unlock()
 
-	// Capture stdout and stderr
-	var err error
 	m.stdout, err = m.cmd.StdoutPipe()
 	if err != nil {
 		m.setStatus(StatusFailed)
@@ -149,24 +218,25 @@ func (m *Manager) Start(ctx context.Context) error {
 	}
 
 	// Set up process group for clean shutdown
-	m.cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-	}
+	setProcessGroup(m.cmd)
 
 	// Start the process
 	m.logger.Info("starting game process",
 		zap.Strings("command", expandedCmd),
 		zap.String("work_dir", m.config.WorkDir))
 
-	if err := m.cmd.Start(); err != nil {
+	if err := m.reaper.StartCommand(m.cmd); err != nil {
 		m.setStatus(StatusFailed)
-		m.apiClient.ReportStatusWithRetry(ctx, api.StatusFailed, fmt.Sprintf("Failed to start: %v", err), 0, 3)
+		m.apiClient.ReportStatusWithRetry(ctx, api.StatusFailed, fmt.Sprintf("Failed to start: %v", err), 0)
 		return fmt.Errorf("failed to start process: %w", err)
 	}
 
 	m.logger.Info("game process started", zap.Int("pid", m.cmd.Process.Pid))
 
-	// Start log forwarding
+	// Start log forwarding; players from the previous run are gone
+	m.players.Reset()
+	m.phases.Reset()
+	m.progress.Reset()
 	go m.forwardLogs("stdout", m.stdout)
 	go m.forwardLogs("stderr", m.stderr)
 
@@ -188,14 +258,14 @@ func (m *Manager) Start(ctx context.Context) error {
 	if err := m.healthChecker.WaitForHealthy(healthCtx); err != nil {
 		m.logger.Error("health check failed", zap.Error(err))
 		m.setStatus(StatusFailed)
-		m.apiClient.ReportStatusWithRetry(ctx, api.StatusFailed, fmt.Sprintf("Health check failed: %v", err), m.PID(), 3)
+		m.apiClient.ReportStatusWithRetry(ctx, api.StatusFailed, fmt.Sprintf("Health check failed: %v", err), m.PID())
 		// Kill the process since it's not healthy
 		m.Stop(ctx, false)
 		return fmt.Errorf("health check failed: %w", err)
 	}
 
 	m.setStatus(StatusRunning)
-	m.apiClient.ReportStatusWithRetry(ctx, api.StatusRunning, "Game server is running", m.PID(), 3)
+	m.apiClient.ReportStatusWithRetry(ctx, api.StatusRunning, "Game server is running", m.PID())
 
 	m.logger.Info("game process is healthy and running", zap.Int("pid", m.PID()))
 
@@ -204,6 +274,59 @@ func (m *Manager) Start(ctx context.Context) error {
 
 // Stop gracefully stops the game process
 func (m *Manager) Stop(ctx context.Context, graceful bool) error {
+	if err := m.stop(ctx, graceful, "Stopping game process"); err != nil {
+		return err
+	}
+
+	// Use a dedicated context for the final status report to ensure it completes
+	// even if the parent context is cancelled during shutdown
+	reportCtx, reportCancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer reportCancel()
+	m.apiClient.ReportStatusWithRetry(reportCtx, api.StatusStopped, "Game process stopped", 0)
+
+	return nil
+}
+
+// Restart stops the game process and starts it again inside the same pod,
+// without the supervisor exiting. The API sees stopping -> starting -> running.
+func (m *Manager) Restart(ctx context.Context) error {
+	if m.Status() != StatusRunning {
+		return fmt.Errorf("cannot restart: process is in %s state", m.Status())
+	}
+	return m.restartWith(ctx, "Restarting game process", nil)
+}
+
+// restartWith restarts the game process like Restart, running between (if set) while it's
+// stopped. The process is started again even if between fails.
+func (m *Manager) restartWith(ctx context.Context, message string, between func() error) error {
+	m.restarting.Store(true)
+	if err := m.stop(ctx, true, message); err != nil {
+		m.restarting.Store(false)
+		return err
+	}
+
+	var betweenErr error
+	if between != nil {
+		betweenErr = between()
+	}
+
+	err := m.Start(ctx)
+	m.restarting.Store(false)
+	if err != nil {
+		// The supervisor can't recover on its own; exit so the pod is restarted
+		m.exitOnce.Do(func() { close(m.exitCh) })
+		return err
+	}
+
+	// The new process may have exited while restarting was still set
+	if m.ExitCode() != -1 {
+		m.exitOnce.Do(func() { close(m.exitCh) })
+	}
+	return betweenErr
+}
+
+// stop ends the game process, reporting stopping with message but not stopped
+func (m *Manager) stop(ctx context.Context, graceful bool, message string) error {
 	if m.Status() != StatusRunning && m.Status() != StatusStarting {
 		return fmt.Errorf("cannot stop: process is in %s state", m.Status())
 	}
@@ -211,7 +334,7 @@ func (m *Manager) Stop(ctx context.Context, graceful bool) error {
 	m.setStatus(StatusStopping)
 	close(m.stopCh)
 
-	m.apiClient.ReportStatusWithRetry(ctx, api.StatusStopping, "Stopping game process", m.PID(), 3)
+	m.apiClient.ReportStatusWithRetry(ctx, api.StatusStopping, message, m.PID())
 
 	if m.cmd == nil || m.cmd.Process == nil {
 		m.setStatus(StatusStopped)
@@ -224,7 +347,7 @@ func (m *Manager) Stop(ctx context.Context, graceful bool) error {
 		m.logger.Info("sending SIGTERM for graceful shutdown", zap.Int("pid", pid))
 
 		// Send SIGTERM to the process group
-		if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil {
+		if err := signalGroup(pid, syscall.SIGTERM); err != nil {
 			m.logger.Warn("failed to send SIGTERM", zap.Error(err))
 		}
 
@@ -235,43 +358,83 @@ func (m *Manager) Stop(ctx context.Context, graceful bool) error {
 		case <-time.After(m.config.GracePeriod):
 			m.logger.Warn("grace period exceeded, sending SIGKILL",
 				zap.Duration("grace_period", m.config.GracePeriod))
-			if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
+			if err := signalGroup(pid, syscall.SIGKILL); err != nil {
 				m.logger.Warn("failed to send SIGKILL", zap.Error(err))
 			}
 			<-m.doneCh
 		case <-ctx.Done():
 			m.logger.Warn("context cancelled, sending SIGKILL")
-			syscall.Kill(-pid, syscall.SIGKILL)
+			signalGroup(pid, syscall.SIGKILL)
 			<-m.doneCh
 		}
 	} else {
 		m.logger.Info("sending SIGKILL for immediate shutdown", zap.Int("pid", pid))
-		if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
+		if err := signalGroup(pid, syscall.SIGKILL); err != nil {
 			m.logger.Warn("failed to send SIGKILL", zap.Error(err))
 		}
 		<-m.doneCh
 	}
 
 	m.setStatus(StatusStopped)
+	return nil
+}
 
-	// Use a dedicated context for the final status report to ensure it completes
-	// even if the parent context is cancelled during shutdown
-	reportCtx, reportCancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer reportCancel()
-	m.apiClient.ReportStatusWithRetry(reportCtx, api.StatusStopped, "Game process stopped", 0, 3)
+// SendInput writes a line to the game process's console (stdin)
+func (m *Manager) SendInput(line string) error {
+	if m.Status() != StatusRunning {
+		return fmt.Errorf("cannot send input: process is in %s state", m.Status())
+	}
+
+	m.stdinMu.Lock()
+	defer m.stdinMu.Unlock()
+	if m.stdin == nil {
+		return fmt.Errorf("process has no stdin")
+	}
+	if _, err := io.WriteString(m.stdin, strings.TrimRight(line, "\r\n")+"\n"); err != nil {
+		return fmt.Errorf("failed to write to stdin: %w", err)
+	}
+	return nil
+}
+
+// SubscribeConsole returns the game's recent output and a channel receiving new lines until
+// cancel is called, for the interactive console
+func (m *Manager) SubscribeConsole() (backlog []string, lines <-chan string, cancel func()) {
+	return m.consoleLog.Subscribe()
+}
+
+// Signal sends sig to the game's process group, e.g. SIGHUP to reload configuration
+func (m *Manager) Signal(sig syscall.Signal) error {
+	if m.Status() != StatusRunning {
+		return fmt.Errorf("cannot signal: process is in %s state", m.Status())
+	}
 
+	pid := m.PID()
+	if pid == 0 {
+		return fmt.Errorf("process is not running")
+	}
+	if err := signalGroup(pid, sig); err != nil {
+		return fmt.Errorf("failed to send %s: %w", sig, err)
+	}
 	return nil
 }
 
 // waitForExit waits for the process to exit and updates status
 func (m *Manager) waitForExit() {
-	defer close(m.doneCh)
+	doneCh := m.doneCh
+	defer func() {
+		close(doneCh)
+		if !m.restarting.Load() {
+			m.exitOnce.Do(func() { close(m.exitCh) })
+		}
+	}()
 
 	if m.cmd == nil {
 		return
 	}
 
+	pid := m.cmd.Process.Pid
 	err := m.cmd.Wait()
+	m.reaper.Forget(pid)
 	m.exitCode = m.cmd.ProcessState.ExitCode()
 
 	m.logger.Info("game process exited",
@@ -290,30 +453,57 @@ func (m *Manager) waitForExit() {
 		if m.exitCode == 0 {
 			// Clean exit (e.g., game server shutdown command)
 			m.setStatus(StatusStopped)
-			m.apiClient.ReportStatusWithRetry(ctx, api.StatusStopped, "Game process stopped", 0, 3)
+			m.apiClient.ReportStatusWithRetry(ctx, api.StatusStopped, "Game process stopped", 0)
 		} else {
 			// Unexpected crash
 			m.setStatus(StatusFailed)
 			m.apiClient.ReportStatusWithRetry(ctx, api.StatusFailed,
-				fmt.Sprintf("Process crashed with exit code %d", m.exitCode), 0, 3)
+				fmt.Sprintf("Process crashed with exit code %d", m.exitCode), 0)
 		}
 	} else if currentStatus == StatusStarting {
 		// Process exited during startup - report failure
 		m.setStatus(StatusFailed)
 		m.apiClient.ReportStatusWithRetry(ctx, api.StatusFailed,
-			fmt.Sprintf("Process exited during startup with exit code %d", m.exitCode), 0, 3)
+			fmt.Sprintf("Process exited during startup with exit code %d", m.exitCode), 0)
+	}
+
+	// Launcher scripts may exit before the processes they started; end those before the
+	// process counts as exited, so a restart doesn't run beside them
+	m.endProcessGroup(pid)
+}
+
+// endProcessGroup ends the processes left in the game's process group after its first
+// process exited: they get the grace period to exit on SIGTERM before being killed. Their
+// zombies are reaped by the reaper. Processes that left the group (e.g. with setsid) are
+// not found.
+func (m *Manager) endProcessGroup(pgid int) {
+	if err := signalGroup(pgid, syscall.SIGTERM); err != nil {
+		return // Nothing left
+	}
+	m.logger.Info("stopping processes left in the game's process group", zap.Int("pgid", pgid))
+
+	deadline := time.Now().Add(m.config.GracePeriod)
+	for time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		if !groupAlive(pgid) {
+			return
+		}
 	}
+
+	m.logger.Warn("grace period exceeded, killing processes left in the game's process group",
+		zap.Int("pgid", pgid), zap.Duration("grace_period", m.config.GracePeriod))
+	signalGroup(pgid, syscall.SIGKILL)
 }
 
-// Wait blocks until the process exits
+// Wait blocks until the process exits, except for in-place restarts
 func (m *Manager) Wait() {
-	<-m.doneCh
+	<-m.exitCh
 }
 
 // WaitWithContext blocks until the process exits or context is cancelled
 func (m *Manager) WaitWithContext(ctx context.Context) error {
 	select {
-	case <-m.doneCh:
+	case <-m.exitCh:
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
@@ -322,29 +512,46 @@ func (m *Manager) WaitWithContext(ctx context.Context) error {
 
 // ExitCode returns the exit code of the process (-1 if not exited)
 func (m *Manager) ExitCode() int {
+	m.statusMu.RLock()
+	doneCh := m.doneCh
+	m.statusMu.RUnlock()
+
 	select {
-	case <-m.doneCh:
+	case <-doneCh:
 		return m.exitCode
 	default:
 		return -1
 	}
 }
 
-// forwardLogs reads from a reader and logs each line
+// forwardLogs writes each line from a reader to our stdout/stderr for container logs,
+// tagged with its severity
 func (m *Manager) forwardLogs(name string, reader io.Reader) {
-	buf := make([]byte, 4096)
+	out := os.Stdout
+	if name != "stdout" {
+		out = os.Stderr
+	}
+
+	// A Reader rather than a Scanner so overlong lines can't stop forwarding and block the game
+	br := bufio.NewReader(reader)
 	for {
-		n, err := reader.Read(buf)
-		if n > 0 {
-			// Log the output
+		line, err := br.ReadString('\n')
+		if line != "" {
+			line = strings.TrimRight(line, "\r\n")
 			m.logger.Debug("game output",
 				zap.String("stream", name),
-				zap.ByteString("data", buf[:n]))
-			// Also write to our stdout/stderr for docker logs
-			if name == "stdout" {
-				os.Stdout.Write(buf[:n])
-			} else {
-				os.Stderr.Write(buf[:n])
+				zap.String("data", line))
+			out.WriteString(m.logParser.Tag(line) + "\n")
+			m.players.Observe(line)
+			m.captureConsoleOutput(line)
+			m.consoleLog.publish(line)
+			if m.Status() == StatusStarting {
+				if phase := m.phases.Observe(line); phase != "" {
+					m.reportPhase(phase)
+				}
+				if label, percent, ok := m.progress.Observe(line); ok {
+					m.reportProgress(label, percent)
+				}
 			}
 		}
 		if err != nil {
@@ -356,6 +563,34 @@ func (m *Manager) forwardLogs(name string, reader io.Reader) {
 	}
 }
 
+// reportPhase tells the API the starting game moved to phase. Failures are logged and
+// otherwise ignored; the next phase or status report supersedes it.
+func (m *Manager) reportPhase(phase api.Phase) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.apiClient.ReportPhase(ctx, phase, phaseMessages[phase], m.PID()); err != nil {
+		m.logger.Debug("failed to report startup phase", zap.String("phase", string(phase)), zap.Error(err))
+		return
+	}
+	m.logger.Info("reported startup phase", zap.String("phase", string(phase)))
+}
+
+// reportProgress tells the API how far the starting game has got with a milestone. Failures
+// are logged and otherwise ignored; the next milestone supersedes it.
+func (m *Manager) reportProgress(label string, percent int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.apiClient.ReportProgress(ctx, label, percent); err != nil {
+		m.logger.Debug("failed to report startup progress", zap.String("label", label), zap.Error(err))
+	}
+}
+
+// PlayersOnline returns the number of players online, or nil if the game's output doesn't
+// report players
+func (m *Manager) PlayersOnline() *int {
+	return m.players.Online()
+}
+
 // IsRunning returns true if the process is currently running
 func (m *Manager) IsRunning() bool {
 	status := m.Status()
@@ -367,15 +602,30 @@ func (m *Manager) IsHealthy() bool {
 	return m.healthChecker.IsHealthy()
 }
 
-// StartContinuousHealthCheck starts continuous health monitoring after startup
-// The onStatusChange callback is invoked when the game process becomes unhealthy
-func (m *Manager) StartContinuousHealthCheck(ctx context.Context, onStatusChange func(status, message string)) {
-	m.healthChecker.RunContinuousChecks(ctx, func() {
-		// Game became unhealthy
-		m.logger.Warn("game process became unhealthy during continuous monitoring")
-		m.setStatus(StatusFailed)
-		if onStatusChange != nil {
-			onStatusChange("failed", "Game process health check failed")
+// StartContinuousHealthCheck monitors the game's health after startup. A running game that
+// fails its health checks is reported failed (GAME_UNRESPONSIVE) but keeps running, since
+// hung games often recover; it's reported running again once it passes a check. The process
+// status stays running throughout, so the game can still be stopped or restarted, while
+// /readyz takes the pod out of service.
+func (m *Manager) StartContinuousHealthCheck(ctx context.Context) {
+	m.healthChecker.RunContinuousChecks(ctx, func(healthy bool) {
+		// Failed checks are expected while the process is being restarted
+		if m.Status() != StatusRunning {
+			return
+		}
+
+		if !healthy {
+			m.logger.Warn("game process became unhealthy during continuous monitoring")
+			m.unresponsive.Store(true)
+			if err := m.apiClient.ReportStatusWithReason(ctx, api.StatusFailed, "Game process health check failed", api.ReasonGameUnresponsive, m.PID()); err != nil {
+				m.logger.Warn("failed to report unhealthy game", zap.Error(err))
+			}
+			return
+		}
+
+		if m.unresponsive.Swap(false) {
+			m.logger.Info("game process is healthy again")
+			m.apiClient.ReportStatusWithRetry(ctx, api.StatusRunning, "Game server is running", m.PID())
 		}
 	})
 }
@@ -0,0 +1,88 @@
+package process
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/mooncorn/gshub/supervisor/internal/api"
+)
+
+// phaseRule moves a starting game to a phase when a line of its output matches pattern
+type phaseRule struct {
+	pattern *regexp.Regexp
+	phase   api.Phase
+}
+
+// steamcmdPhaseRules recognize steamcmd, which launcher scripts of Steam games run to
+// install or update the game before starting it
+var steamcmdPhaseRules = []phaseRule{
+	// Update state (0x61) downloading, progress: 45.12 (1234567 / 2734567)
+	{regexp.MustCompile(`Update state \(0x[0-9a-fA-F]+\) (downloading|verifying|preallocating|committing)`), api.PhaseInstalling},
+	// Success! App '896660' fully installed.
+	{regexp.MustCompile(`Success! App '\d+' (fully installed|already up to date)`), api.PhaseStarting},
+}
+
+// PhaseFormats are the per-game startup phase rules, keyed like LogFormats. Every game
+// also gets steamcmdPhaseRules; until a rule matches, the game is just starting.
+var PhaseFormats = map[string][]phaseRule{
+	"minecraft": {
+		// [init] Downloading Paper 1.21.1-119 (itzg/minecraft-server)
+		{regexp.MustCompile(`^\[init\] (Downloading|Resolving|Installing) `), api.PhaseInstalling},
+		// [12:34:56] [Server thread/INFO]: Starting minecraft server version 1.21.1
+		{regexp.MustCompile(`\]: Starting minecraft server version `), api.PhaseStarting},
+		// [12:34:56] [Server thread/INFO]: Preparing level "world"
+		{regexp.MustCompile(`\]: Preparing (level "|start region|spawn area)`), api.PhaseGeneratingWorld},
+	},
+
+	// 02/14/2024 12:34:56: Load world: Dedicated (Dedicated)
+	"valheim": {
+		{regexp.MustCompile(`: (Load world: |Generating locations|World generator init)`), api.PhaseGeneratingWorld},
+	},
+}
+
+// PhaseTracker infers a starting game's phase from its output
+type PhaseTracker struct {
+	rules []phaseRule
+
+	mu    sync.Mutex
+	phase api.Phase
+}
+
+// NewPhaseTracker returns the tracker for a log format
+func NewPhaseTracker(format string) *PhaseTracker {
+	rules := append([]phaseRule{}, steamcmdPhaseRules...)
+	return &PhaseTracker{rules: append(rules, PhaseFormats[format]...)}
+}
+
+// Observe returns the phase a line of output moves the game to, or "" if it stays in the
+// same phase
+func (t *PhaseTracker) Observe(line string) api.Phase {
+	for _, rule := range t.rules {
+		if !rule.pattern.MatchString(line) {
+			continue
+		}
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if rule.phase == t.phase {
+			return ""
+		}
+		t.phase = rule.phase
+		return rule.phase
+	}
+	return ""
+}
+
+// Reset forgets the phase, e.g. when the game is started again
+func (t *PhaseTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phase = ""
+}
+
+// phaseMessages are the status messages reported with each phase
+var phaseMessages = map[api.Phase]string{
+	api.PhaseInstalling:      "Installing game files",
+	api.PhaseGeneratingWorld: "Generating world",
+	api.PhaseStarting:        "Starting game",
+}
@@ -0,0 +1,105 @@
+package process
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// playerRules recognize players joining and leaving in a game's output. Join and leave
+// capture the player's name; count, if set, captures the number online from lines that
+// report it directly.
+type playerRules struct {
+	join  *regexp.Regexp
+	leave *regexp.Regexp
+	count *regexp.Regexp
+}
+
+// PlayerFormats are the per-game player trackers, keyed like LogFormats. Games without
+// an entry don't report players.
+var PlayerFormats = map[string]playerRules{
+	// [12:34:56] [Server thread/INFO]: Steve joined the game
+	"minecraft": {
+		join:  regexp.MustCompile(`\]: (\w{1,16}) joined the game$`),
+		leave: regexp.MustCompile(`\]: (\w{1,16}) left the game$`),
+	},
+
+	// 02/14/2024 12:34:56: Connections 2 ZDOS:12345  sent:0 recv:0
+	"valheim": {
+		count: regexp.MustCompile(`Connections (\d+) ZDOS:`),
+	},
+
+	// [server] Player 'Name' logged in with Permissions:  /  [server] Remove Player 'Name'
+	"enshrouded": {
+		join:  regexp.MustCompile(`^\[server\] Player '([^']+)' logged in`),
+		leave: regexp.MustCompile(`^\[server\] Remove Player '([^']+)'`),
+	},
+}
+
+// PlayerTracker counts the players online from game output
+type PlayerTracker struct {
+	rules     playerRules
+	supported bool
+
+	mu      sync.Mutex
+	players map[string]bool
+	count   int
+}
+
+// NewPlayerTracker returns the tracker for a log format
+func NewPlayerTracker(format string) *PlayerTracker {
+	rules, ok := PlayerFormats[format]
+	return &PlayerTracker{rules: rules, supported: ok, players: map[string]bool{}}
+}
+
+// Observe updates the count from a line of game output
+func (t *PlayerTracker) Observe(line string) {
+	if !t.supported {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rules.count != nil {
+		if m := t.rules.count.FindStringSubmatch(line); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				t.count = n
+			}
+			return
+		}
+	}
+	if t.rules.join != nil {
+		if m := t.rules.join.FindStringSubmatch(line); m != nil {
+			t.players[m[1]] = true
+			t.count = len(t.players)
+			return
+		}
+	}
+	if t.rules.leave != nil {
+		if m := t.rules.leave.FindStringSubmatch(line); m != nil {
+			delete(t.players, m[1])
+			t.count = len(t.players)
+		}
+	}
+}
+
+// Reset forgets all players, e.g. when the game restarts
+func (t *PlayerTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.players = map[string]bool{}
+	t.count = 0
+}
+
+// Online returns the number of players online, or nil if the game's output doesn't
+// report players
+func (t *PlayerTracker) Online() *int {
+	if !t.supported {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	count := t.count
+	return &count
+}
@@ -0,0 +1,62 @@
+package process
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// ctrlBreakEvent is CTRL_BREAK_EVENT from wincon.h, the only console event that can be sent
+// to a process group
+const ctrlBreakEvent = 1
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procGenerateConsoleCtrlEvent = kernel32.NewProc("GenerateConsoleCtrlEvent")
+	procGetDiskFreeSpaceExW      = kernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+// setProcessGroup starts cmd in a console process group of its own, so console events
+// reach the game without stopping the supervisor
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
+
+// signalGroup delivers sig to the process group pgid the closest way Windows allows:
+// SIGTERM and SIGINT become a Ctrl+Break event, which games handle like Ctrl+C, and SIGKILL
+// terminates the process tree. Other signals aren't supported.
+func signalGroup(pgid int, sig syscall.Signal) error {
+	switch sig {
+	case syscall.SIGTERM, syscall.SIGINT:
+		if ok, _, err := procGenerateConsoleCtrlEvent.Call(ctrlBreakEvent, uintptr(pgid)); ok == 0 {
+			return err
+		}
+		return nil
+	case syscall.SIGKILL:
+		return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pgid)).Run()
+	}
+	return fmt.Errorf("signal %q is not supported on Windows", sig.String())
+}
+
+// groupAlive reports whether any process is left in the process group pgid. Windows can't
+// list a group's processes, so once Ctrl+Break was sent, the rest are left to exit on it.
+func groupAlive(pgid int) bool {
+	return false
+}
+
+// freeBytes returns the space available to the supervisor's user on dir's volume
+func freeBytes(dir string) (uint64, error) {
+	path, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if ok, _, err := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&free)), 0, 0); ok == 0 {
+		return 0, err
+	}
+	return free, nil
+}
@@ -0,0 +1,80 @@
+package process
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mooncorn/gshub/supervisor/internal/config"
+)
+
+// progressRule is a compiled config.ProgressPattern
+type progressRule struct {
+	pattern *regexp.Regexp
+	label   string
+}
+
+// ProgressTracker matches a starting game's output against its catalog's progress
+// milestones, e.g. "Preparing spawn area: 45%"
+type ProgressTracker struct {
+	rules []progressRule
+
+	mu         sync.Mutex
+	label      string
+	percent    int
+	reportedAt time.Time
+}
+
+// NewProgressTracker compiles the progress patterns; invalid ones, which config.Load
+// already reported, are skipped
+func NewProgressTracker(patterns []config.ProgressPattern) *ProgressTracker {
+	t := &ProgressTracker{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil || re.NumSubexp() < 1 {
+			continue
+		}
+		t.rules = append(t.rules, progressRule{pattern: re, label: p.Label})
+	}
+	return t
+}
+
+// Observe returns the milestone and percentage a line of output reports, and whether it's
+// worth reporting: the percentage changed, and the milestone is new, complete, or
+// progressInterval has passed since the last report
+func (t *ProgressTracker) Observe(line string) (string, int, bool) {
+	for _, rule := range t.rules {
+		match := rule.pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		percent, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			continue
+		}
+		return t.update(rule.label, min(max(int(percent), 0), 100))
+	}
+	return "", 0, false
+}
+
+func (t *ProgressTracker) update(label string, percent int) (string, int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if label == t.label && percent == t.percent {
+		return "", 0, false
+	}
+	if label == t.label && percent < 100 && time.Since(t.reportedAt) < progressInterval {
+		return "", 0, false
+	}
+	t.label, t.percent, t.reportedAt = label, percent, time.Now()
+	return label, percent, true
+}
+
+// Reset forgets the last milestone, e.g. when the game is started again
+func (t *ProgressTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.label, t.percent, t.reportedAt = "", 0, time.Time{}
+}
@@ -0,0 +1,54 @@
+package process
+
+import (
+	"os/exec"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// Reaper reaps orphaned processes. The supervisor runs as PID 1 in the container, so
+// processes whose parent exits (e.g. children of launcher scripts that fork and exit) are
+// reparented to it; unreaped, they stay zombies until the pod is deleted. Outside PID 1,
+// the supervisor becomes a child subreaper to get them instead of the host's init. Windows
+// has no zombies, so there the reaper only starts commands.
+type Reaper struct {
+	mu      sync.Mutex
+	tracked map[int]bool // Started with StartCommand; reaped by their exec.Cmd
+	reaped  atomic.Int64
+	logger  *zap.Logger
+}
+
+// NewReaper creates a new reaper
+func NewReaper(logger *zap.Logger) *Reaper {
+	return &Reaper{
+		tracked: make(map[int]bool),
+		logger:  logger,
+	}
+}
+
+// StartCommand starts cmd, leaving its process for cmd.Wait to reap. Call Forget with its
+// PID once Wait returns.
+func (r *Reaper) StartCommand(cmd *exec.Cmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	r.tracked[cmd.Process.Pid] = true
+	return nil
+}
+
+// Forget stops leaving the process with pid alone, once its exec.Cmd reaped it
+func (r *Reaper) Forget(pid int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tracked, pid)
+}
+
+// Reaped returns how many orphans have been reaped
+func (r *Reaper) Reaped() int64 {
+	return r.reaped.Load()
+}
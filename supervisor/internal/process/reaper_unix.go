@@ -0,0 +1,108 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// prSetChildSubreaper is PR_SET_CHILD_SUBREAPER from linux/prctl.h
+	prSetChildSubreaper = 36
+	// reapInterval is how often orphans are reaped without a SIGCHLD, as signals that
+	// arrive together are delivered once
+	reapInterval = 30 * time.Second
+)
+
+// Start begins reaping orphans until ctx is cancelled
+func (r *Reaper) Start(ctx context.Context) {
+	if os.Getpid() != 1 {
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+			r.logger.Warn("failed to become child subreaper, orphans are left to init", zap.Error(errno))
+		}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGCHLD)
+
+	go func() {
+		defer signal.Stop(sigCh)
+
+		ticker := time.NewTicker(reapInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sigCh:
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			r.reap()
+		}
+	}()
+}
+
+// reap waits for every zombie child of the supervisor that no exec.Cmd is waiting for.
+// Zombies are found in /proc rather than with wait(-1), which would steal the exit status
+// of processes started with StartCommand.
+func (r *Reaper) reap() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		r.logger.Debug("failed to list processes", zap.Error(err))
+		return
+	}
+
+	self := os.Getpid()
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || r.tracked[pid] {
+			continue
+		}
+		state, ppid, err := readProcState(pid)
+		if err != nil || ppid != self || state != "Z" {
+			continue
+		}
+
+		var status syscall.WaitStatus
+		if wpid, err := syscall.Wait4(pid, &status, syscall.WNOHANG, nil); err == nil && wpid == pid {
+			r.reaped.Add(1)
+			r.logger.Debug("reaped orphaned process", zap.Int("pid", pid), zap.Int("exit_code", status.ExitStatus()))
+		}
+	}
+}
+
+// readProcState returns the state and parent PID of a process from /proc/[pid]/stat
+func readProcState(pid int) (string, int, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", 0, err
+	}
+
+	// The command name in parentheses may contain spaces; state and ppid follow it
+	end := strings.LastIndexByte(string(data), ')')
+	if end < 0 {
+		return "", 0, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 2 {
+		return "", 0, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, err
+	}
+	return fields[0], ppid, nil
+}
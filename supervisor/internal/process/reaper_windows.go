@@ -0,0 +1,6 @@
+package process
+
+import "context"
+
+// Start does nothing: Windows has no zombies to reap
+func (r *Reaper) Start(ctx context.Context) {}
@@ -0,0 +1,31 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+// renderConfigTemplates writes the configured config files, expanding ${VAR} from the game's env
+func (m *Manager) renderConfigTemplates() error {
+	for _, tpl := range m.config.ConfigTemplates {
+		if err := os.MkdirAll(filepath.Dir(tpl.Path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", tpl.Path, err)
+		}
+
+		// Write to a temp file and rename so the game never reads a half-written config
+		tmpPath := tpl.Path + ".gshub-tmp"
+		if err := os.WriteFile(tmpPath, []byte(m.expandEnv(tpl.Template)), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", tpl.Path, err)
+		}
+		if err := os.Rename(tmpPath, tpl.Path); err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write %s: %w", tpl.Path, err)
+		}
+
+		m.logger.Debug("rendered config template", zap.String("path", tpl.Path))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Package rcon is a client for the Source RCON protocol, which Minecraft and many other
+// games serve for remote console commands.
+package rcon
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+const (
+	packetAuth         = 3
+	packetAuthResponse = 2
+	packetExec         = 2
+	packetResponse     = 0
+
+	// maxPacketSize bounds the packets read from the game; Source caps bodies at 4096 bytes
+	maxPacketSize = 64 << 10
+
+	authID       = 1
+	execID       = 2
+	terminatorID = 3
+)
+
+// ErrAuthFailed is returned when the game rejects the password
+var ErrAuthFailed = errors.New("RCON authentication failed")
+
+// Exec connects to the RCON server at addr, authenticates with password and runs command,
+// returning its response. ctx bounds the whole exchange.
+func Exec(ctx context.Context, addr, password, command string) (string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to RCON: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	r := bufio.NewReader(conn)
+
+	if err := writePacket(conn, authID, packetAuth, password); err != nil {
+		return "", fmt.Errorf("failed to authenticate: %w", err)
+	}
+	for {
+		id, typ, _, err := readPacket(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to authenticate: %w", err)
+		}
+		// Source sends an empty response before the auth response
+		if typ != packetAuthResponse {
+			continue
+		}
+		if id == -1 {
+			return "", ErrAuthFailed
+		}
+		break
+	}
+
+	// Games answer packets in order, so the answer to an empty packet sent after the command
+	// marks the end of a response split over several packets
+	if err := writePacket(conn, execID, packetExec, command); err != nil {
+		return "", fmt.Errorf("failed to send command: %w", err)
+	}
+	if err := writePacket(conn, terminatorID, packetResponse, ""); err != nil {
+		return "", fmt.Errorf("failed to send command: %w", err)
+	}
+
+	var output strings.Builder
+	for {
+		id, _, body, err := readPacket(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to read response: %w", err)
+		}
+		switch id {
+		case execID:
+			output.WriteString(body)
+		case terminatorID:
+			return output.String(), nil
+		}
+	}
+}
+
+// writePacket sends a packet: its length, ID, type and body, with two trailing null bytes
+func writePacket(w io.Writer, id, typ int32, body string) error {
+	packet := make([]byte, 0, 14+len(body))
+	packet = binary.LittleEndian.AppendUint32(packet, uint32(10+len(body)))
+	packet = binary.LittleEndian.AppendUint32(packet, uint32(id))
+	packet = binary.LittleEndian.AppendUint32(packet, uint32(typ))
+	packet = append(packet, body...)
+	packet = append(packet, 0, 0)
+	_, err := w.Write(packet)
+	return err
+}
+
+// readPacket reads one packet and returns its ID, type and body
+func readPacket(r io.Reader) (id, typ int32, body string, err error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", err
+	}
+	if size < 10 || size > maxPacketSize {
+		return 0, 0, "", fmt.Errorf("invalid packet size %d", size)
+	}
+
+	packet := make([]byte, size)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return 0, 0, "", err
+	}
+	id = int32(binary.LittleEndian.Uint32(packet[0:4]))
+	typ = int32(binary.LittleEndian.Uint32(packet[4:8]))
+	return id, typ, strings.TrimRight(string(packet[8:]), "\x00"), nil
+}
@@ -0,0 +1,469 @@
+// Package volume backs up a server's whole data volume to object storage, restores it and
+// copies it into another server's, run by the API in a pod of its own while the server is
+// stopped
+package volume
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// workDirName is where the archive is staged, inside the volume. The archive must fit
+// beside the data, like world exports.
+const workDirName = ".gshub-volume"
+
+// supervisorPrefix marks the supervisor's own top-level directories (exports, imports),
+// which backups skip
+const supervisorPrefix = ".gshub-"
+
+// Backup archives dir as a .tar.gz and uploads it with a PUT to uploadURL, a presigned
+// object storage URL. Returns the archive's size.
+func Backup(ctx context.Context, dir, uploadURL string, logger *zap.Logger) (int64, error) {
+	workDir := filepath.Join(dir, workDirName)
+	if err := os.RemoveAll(workDir); err != nil {
+		return 0, fmt.Errorf("failed to clear work directory: %w", err)
+	}
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	archive := filepath.Join(workDir, "backup.tar.gz")
+	files, err := writeArchive(ctx, dir, archive)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := upload(ctx, archive, uploadURL)
+	if err != nil {
+		return 0, err
+	}
+	logger.Info("backed up volume", zap.Int("files", files), zap.Int64("bytes", size))
+	return size, nil
+}
+
+// Restore downloads a .tar.gz written by Backup from downloadURL and replaces the contents
+// of dir with it. The archive is checked before anything is removed, so a corrupt or
+// truncated download leaves the volume as it was.
+func Restore(ctx context.Context, dir, downloadURL string, logger *zap.Logger) error {
+	workDir := filepath.Join(dir, workDirName)
+	if err := os.RemoveAll(workDir); err != nil {
+		return fmt.Errorf("failed to clear work directory: %w", err)
+	}
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	archive := filepath.Join(workDir, "restore.tar.gz")
+	if err := download(ctx, downloadURL, archive); err != nil {
+		return err
+	}
+	if err := walkArchive(archive, func(string, *tar.Header, io.Reader) error { return nil }); err != nil {
+		return fmt.Errorf("invalid backup archive: %w", err)
+	}
+
+	if err := clearDir(dir); err != nil {
+		return err
+	}
+
+	files := 0
+	var links []*tar.Header
+	err := walkArchive(archive, func(name string, header *tar.Header, r io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("restore cancelled: %w", err)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, header.FileInfo().Mode().Perm()|0o700); err != nil {
+				return fmt.Errorf("failed to create %s: %w", name, err)
+			}
+		case tar.TypeReg:
+			if err := extractFile(target, header, r); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", name, err)
+			}
+			files++
+		case tar.TypeSymlink:
+			// Created last, so no file is written through a link
+			header.Name = name
+			links = append(links, header)
+			return nil
+		default:
+			return nil
+		}
+		restoreMetadata(target, header)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, header := range links {
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", path.Dir(header.Name), err)
+		}
+		if err := os.Symlink(header.Linkname, target); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", header.Name, err)
+		}
+		restoreMetadata(target, header)
+	}
+
+	logger.Info("restored volume", zap.Int("files", files), zap.Int("links", len(links)))
+	return nil
+}
+
+// Copy replaces the contents of dir with those of source, another server's volume mounted
+// beside it, skipping the supervisor's own top-level directories like Backup does
+func Copy(ctx context.Context, source, dir string, logger *zap.Logger) error {
+	if _, err := os.Stat(source); err != nil {
+		return fmt.Errorf("failed to open source volume: %w", err)
+	}
+	if err := clearDir(dir); err != nil {
+		return err
+	}
+
+	files := 0
+	var links []*tar.Header
+	err := filepath.WalkDir(source, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("copy cancelled: %w", err)
+		}
+		if p == source {
+			return nil
+		}
+		rel, err := filepath.Rel(source, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.Contains(name, "/") && strings.HasPrefix(name, supervisorPrefix) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return fmt.Errorf("failed to read link %s: %w", name, err)
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %w", name, err)
+		}
+		target := filepath.Join(dir, rel)
+
+		switch {
+		case info.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()|0o700); err != nil {
+				return fmt.Errorf("failed to create %s: %w", name, err)
+			}
+		case info.Mode().IsRegular():
+			if err := copyFile(p, target, header); err != nil {
+				return fmt.Errorf("failed to copy %s: %w", name, err)
+			}
+			files++
+		case link != "":
+			// Created last, so no file is written through a link
+			header.Name = name
+			links = append(links, header)
+			return nil
+		default:
+			return nil // Sockets, devices and pipes aren't data
+		}
+		restoreMetadata(target, header)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, header := range links {
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if err := os.Symlink(header.Linkname, target); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", header.Name, err)
+		}
+		restoreMetadata(target, header)
+	}
+
+	logger.Info("copied volume", zap.Int("files", files), zap.Int("links", len(links)))
+	return nil
+}
+
+// copyFile copies a regular file of a source volume to target. Files that grew since they
+// were listed are cut at their listed size.
+func copyFile(src, target string, header *tar.Header) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return extractFile(target, header, io.LimitReader(in, header.Size))
+}
+
+// writeArchive writes the regular files, directories and symlinks under dir into a
+// .tar.gz at dest, skipping the supervisor's own top-level directories. Returns how many
+// files it archived.
+func writeArchive(ctx context.Context, dir, dest string) (int, error) {
+	out, err := os.Create(dest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	files := 0
+	err = filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("backup cancelled: %w", err)
+		}
+		if p == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.Contains(name, "/") && strings.HasPrefix(name, supervisorPrefix) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		switch {
+		case info.Mode().IsRegular(), info.IsDir():
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(p); err != nil {
+				return fmt.Errorf("failed to read link %s: %w", name, err)
+			}
+		default:
+			return nil // Sockets, devices and pipes aren't data
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", name, err)
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", name, err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer f.Close()
+		// Files that grew since they were listed are cut at their listed size
+		if _, err := io.Copy(tw, io.LimitReader(f, info.Size())); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", name, err)
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return files, out.Close()
+}
+
+// walkArchive calls fn with the cleaned name of each entry of a .tar.gz, and a reader of
+// its content. Entries whose names would leave the volume fail the walk.
+func walkArchive(archive string, fn func(name string, header *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == "." {
+			continue
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || strings.HasPrefix(name, workDirName) {
+			return fmt.Errorf("unsafe path in archive: %s", header.Name)
+		}
+		if err := fn(name, header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// extractFile writes a regular file from the archive
+func extractFile(target string, header *tar.Header, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, header.FileInfo().Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// restoreMetadata restores an entry's owner (when running as root) and modification time.
+// Failures are ignored; the data is what matters.
+func restoreMetadata(target string, header *tar.Header) {
+	if os.Geteuid() == 0 {
+		os.Lchown(target, header.Uid, header.Gid)
+	}
+	if header.Typeflag != tar.TypeSymlink {
+		os.Chtimes(target, time.Now(), header.ModTime)
+	}
+}
+
+// clearDir removes everything in dir except the work directory
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list volume: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == workDirName {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to clear volume: %w", err)
+		}
+	}
+	return nil
+}
+
+// upload PUTs the archive to a presigned URL
+func upload(ctx context.Context, archive, uploadURL string) (int64, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, f)
+	if err != nil {
+		return 0, fmt.Errorf("invalid upload URL: %w", err)
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload archive: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to upload archive: %s", statusError(resp))
+	}
+	return info.Size(), nil
+}
+
+// download GETs a presigned URL into dest
+func download(ctx context.Context, downloadURL, dest string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid download URL: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download archive: %s", statusError(resp))
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+	return out.Close()
+}
+
+// statusError describes an unexpected response from object storage
+func statusError(resp *http.Response) string {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Sprintf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+}
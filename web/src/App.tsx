@@ -14,11 +14,16 @@ import { ResetPasswordPage } from "@/pages/auth/ResetPasswordPage"
 import { VerifyEmailPage } from "@/pages/auth/VerifyEmailPage"
 import { DashboardPage } from "@/pages/dashboard/DashboardPage"
 import { CreateServerPage } from "@/pages/servers/CreateServerPage"
+import { ReminderActionPage } from "@/pages/servers/ReminderActionPage"
 import { BillingPage } from "@/pages/settings/BillingPage"
+import { IntegrationsPage } from "@/pages/settings/IntegrationsPage"
+import { SteamCallbackPage } from "@/pages/settings/SteamCallbackPage"
+import { TemplatesPage } from "@/pages/templates/TemplatesPage"
 import { ServerLayout } from "@/components/servers/ServerLayout"
 import { ServerDashboardTab } from "@/pages/servers/tabs/ServerDashboardTab"
 import { ServerConfigurationTab } from "@/pages/servers/tabs/ServerConfigurationTab"
 import { ServerFilesTab } from "@/pages/servers/tabs/ServerFilesTab"
+import { MockCheckoutPage } from "@/pages/dev/MockCheckoutPage"
 
 function App() {
   return (
@@ -44,11 +49,18 @@ function App() {
             <Route path="/servers/new" element={<CreateServerPage />} />
           </Route>
 
+          {/* One-click cancel from a reminder email, authorized by the signed link */}
+          <Route path="/servers/:id/reminder-action" element={<ReminderActionPage />} />
+
           {/* Protected routes */}
           <Route element={<ProtectedRoute />}>
             <Route element={<RootLayout />}>
               <Route path="/" element={<DashboardPage />} />
               <Route path="/settings/billing" element={<BillingPage />} />
+              <Route path="/settings/integrations" element={<IntegrationsPage />} />
+              <Route path="/settings/integrations/steam" element={<SteamCallbackPage />} />
+              <Route path="/templates" element={<TemplatesPage />} />
+              <Route path="/dev/checkout/:sessionId" element={<MockCheckoutPage />} />
               <Route path="/servers/:id" element={<ServerLayout />}>
                 <Route index element={<ServerDashboardTab />} />
                 <Route path="configuration" element={<ServerConfigurationTab />} />
@@ -4,7 +4,10 @@ export interface User {
   id: string
   email: string
   email_verified: boolean
+  role: "user" | "admin" // Admins can use the /admin API
   created_at: string
+  suspended_at?: string
+  suspension_reason?: string
 }
 
 export interface AuthResponse {
@@ -13,6 +16,19 @@ export interface AuthResponse {
   user: User
 }
 
+// Returned by login instead of tokens when the user has two-factor authentication
+export interface MFARequiredResponse {
+  mfa_required: true
+  mfa_token: string // For loginMFA with the user's code
+  expires_at: string
+}
+
+export interface MFAStatus {
+  enabled: boolean
+  enabled_at?: string
+  backup_codes_remaining: number
+}
+
 export const authApi = {
   register: (email: string, password: string) =>
     client.post<{ message: string; user: User }>("/auth/register", {
@@ -21,7 +37,17 @@ export const authApi = {
     }),
 
   login: (email: string, password: string) =>
-    client.post<AuthResponse>("/auth/login", { email, password }),
+    client.post<AuthResponse | MFARequiredResponse>("/auth/login", {
+      email,
+      password,
+    }),
+
+  // Code from the authenticator app, or a backup code
+  loginMFA: (mfaToken: string, code: string) =>
+    client.post<AuthResponse>("/auth/login/mfa", {
+      mfa_token: mfaToken,
+      code,
+    }),
 
   logout: (refreshToken: string) =>
     client.post("/auth/logout", { refresh_token: refreshToken }),
@@ -45,4 +71,28 @@ export const authApi = {
     client.post("/auth/reset-password", { token, password }),
 
   getProfile: () => client.get<User>("/me"),
+
+  getMFA: () => client.get<{ mfa: MFAStatus }>("/me/mfa"),
+
+  // The otpauth:// URL is shown as a QR code for authenticator apps
+  setupMFA: () =>
+    client.post<{ secret: string; otpauth_url: string }>("/me/mfa/setup"),
+
+  // Other sessions are logged out; the new tokens replace this one's
+  enableMFA: (code: string) =>
+    client.post<{
+      message: string
+      backup_codes: string[]
+      access_token: string
+      refresh_token: string
+    }>("/me/mfa/enable", { code }),
+
+  disableMFA: (password: string, code: string) =>
+    client.post<{ message: string }>("/me/mfa/disable", { password, code }),
+
+  regenerateMFABackupCodes: (code: string) =>
+    client.post<{ backup_codes: string[] }>("/me/mfa/backup-codes", { code }),
+
+  requestReinstatement: (message: string) =>
+    client.post<{ message: string }>("/me/reinstatement-request", { message }),
 }
@@ -19,6 +19,7 @@ export interface ServerSubscription {
   subscription?: SubscriptionInfo
   expired_at?: string
   delete_after?: string
+  auto_cancel_when_stopped: boolean
 }
 
 export interface BillingResponse {
@@ -32,9 +33,32 @@ export interface CancelResponse {
   current_period_end: string
 }
 
+export interface ReminderCancelResponse extends CancelResponse {
+  display_name: string
+}
+
+// Resubscribing with a saved card reactivates the server immediately (status) instead of
+// returning a checkout session to redirect to
 export interface ResubscribeResponse {
-  session_id: string
-  checkout_url: string
+  session_id?: string
+  checkout_url?: string
+  status?: string
+  message?: string
+}
+
+export interface SavedCard {
+  brand: string
+  last4: string
+  exp_month: number
+  exp_year: number
+}
+
+export interface PaymentMethodResponse {
+  card: SavedCard | null
+}
+
+export interface PortalSessionResponse {
+  url: string
 }
 
 export interface ResumeResponse {
@@ -42,15 +66,78 @@ export interface ResumeResponse {
   message: string
 }
 
+export interface AutoCancelResponse {
+  auto_cancel_when_stopped: boolean
+}
+
+export interface SpendLimit {
+  limit_cents: number | null
+  block_checkout: boolean
+  projected_cents: number | null
+  currency?: string
+  projected_at?: string
+}
+
+export interface UpdateSpendLimitRequest {
+  limit_cents: number | null
+  block_checkout: boolean
+}
+
+// Lines identify the server they charge for when it's known
+export interface InvoiceLine {
+  description: string
+  amount: number
+  period_start?: string
+  period_end?: string
+  server_id?: string
+  subdomain?: string
+}
+
+export interface Invoice {
+  id: string
+  number?: string
+  status: string
+  currency: string
+  total: number
+  amount_paid: number
+  created_at: string
+  url?: string
+  lines: InvoiceLine[]
+}
+
+export interface InvoicesResponse {
+  invoices: Invoice[]
+}
+
 export const billingApi = {
   getBilling: () => client.get<BillingResponse>("/billing"),
 
   cancelSubscription: (serverId: string) =>
     client.post<CancelResponse>(`/billing/servers/${serverId}/cancel`),
 
+  // Cancels from the signed link in a stopped-server reminder email, no login needed
+  cancelFromReminder: (serverId: string, expires: number, signature: string) =>
+    client.post<ReminderCancelResponse>(`/servers/${serverId}/reminder-action`, { expires, signature }),
+
   resumeSubscription: (serverId: string) =>
     client.post<ResumeResponse>(`/billing/servers/${serverId}/resume`),
 
-  resubscribe: (serverId: string) =>
-    client.post<ResubscribeResponse>(`/billing/servers/${serverId}/resubscribe`),
+  resubscribe: (serverId: string, useSavedCard = false) =>
+    client.post<ResubscribeResponse>(`/billing/servers/${serverId}/resubscribe`, {
+      use_saved_card: useSavedCard,
+    }),
+
+  getInvoices: () => client.get<InvoicesResponse>("/billing/invoices"),
+
+  getPaymentMethod: () => client.get<PaymentMethodResponse>("/billing/payment-method"),
+
+  createPortalSession: () => client.post<PortalSessionResponse>("/billing/portal"),
+
+  setAutoCancel: (serverId: string, enabled: boolean) =>
+    client.put<AutoCancelResponse>(`/billing/servers/${serverId}/auto-cancel`, { enabled }),
+
+  getSpendLimit: () => client.get<SpendLimit>("/billing/spend-limit"),
+
+  updateSpendLimit: (data: UpdateSpendLimitRequest) =>
+    client.put<SpendLimit>("/billing/spend-limit", data),
 }
@@ -2,6 +2,26 @@ import axios, { type AxiosError, type InternalAxiosRequestConfig } from "axios"
 
 const API_URL = import.meta.env.VITE_API_URL || "http://localhost:8080"
 
+// Standard API error envelope: { "error": { code, message, details, request_id } }
+export interface ApiErrorBody {
+  code: string
+  message: string
+  details?: unknown
+  request_id?: string
+}
+
+export interface ApiErrorResponse {
+  error: ApiErrorBody
+}
+
+// Extracts the structured API error from a failed request, if present
+export function getApiError(err: unknown): ApiErrorBody | undefined {
+  if (axios.isAxiosError<ApiErrorResponse>(err)) {
+    return err.response?.data?.error
+  }
+  return undefined
+}
+
 const client = axios.create({
   baseURL: API_URL,
   headers: {
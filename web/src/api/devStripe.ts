@@ -0,0 +1,28 @@
+import client from "./client"
+
+// Endpoints only available when the API runs with STRIPE_MOCK_MODE enabled
+
+export interface MockCheckoutSession {
+  session_id: string
+  status: string
+  payment_status: string
+  success_url: string
+  cancel_url: string
+}
+
+export interface CompleteMockCheckoutResponse {
+  session_id: string
+  subscription_id: string
+  success_url: string
+}
+
+export const devStripeApi = {
+  getCheckoutSession: (sessionId: string) =>
+    client.get<MockCheckoutSession>(`/dev/stripe/checkout/${sessionId}`),
+
+  completeCheckoutSession: (sessionId: string) =>
+    client.post<CompleteMockCheckoutResponse>(`/dev/stripe/checkout/${sessionId}/complete`),
+
+  endSubscription: (serverId: string) =>
+    client.post<{ status: string }>(`/dev/stripe/servers/${serverId}/end-subscription`),
+}
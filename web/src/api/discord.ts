@@ -0,0 +1,18 @@
+import client from "./client"
+
+export interface DiscordLink {
+  discord_user_id: string
+  discord_username: string
+  linked_at: string
+}
+
+export const discordApi = {
+  // 404s when the Discord integration isn't enabled on this deployment
+  getLink: () => client.get<{ link: DiscordLink | null }>("/me/discord"),
+
+  // One-time code the user enters with /link in Discord
+  createLinkCode: () =>
+    client.post<{ code: string; expires_at: string }>("/me/discord/link-code"),
+
+  unlink: () => client.delete("/me/discord"),
+}
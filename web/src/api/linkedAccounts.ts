@@ -0,0 +1,27 @@
+import client from "./client"
+
+export type LinkedAccountProvider = "steam"
+
+export interface LinkedAccount {
+  provider: LinkedAccountProvider
+  external_id: string
+  display_name: string
+  linked_at: string
+}
+
+export const linkedAccountsApi = {
+  list: () => client.get<{ accounts: LinkedAccount[] }>("/me/linked-accounts"),
+
+  // Returns the Steam sign-in URL; Steam redirects back to /settings/integrations/steam
+  startSteamLink: () =>
+    client.post<{ redirect_url: string }>("/me/linked-accounts/steam"),
+
+  // assertion is the query string Steam redirected back with
+  verifySteamLink: (assertion: string) =>
+    client.post<{ account: LinkedAccount }>("/me/linked-accounts/steam/verify", {
+      assertion,
+    }),
+
+  unlink: (provider: LinkedAccountProvider) =>
+    client.delete(`/me/linked-accounts/${provider}`),
+}
@@ -1,13 +1,22 @@
 const API_URL = import.meta.env.VITE_API_URL || "http://localhost:8080"
 
+export type LogLevel = "debug" | "info" | "warn" | "error"
+
 export interface LogEvent {
   line: string
   timestamp: string
+  // Pod the line came from; a server briefly has two during a rolling restart
+  pod: string
+  // Severity tagged by the supervisor; unset for untagged lines
+  level?: LogLevel
+  // Set for lines from the container instance that ran before the last restart
+  previous?: boolean
 }
 
 export interface ConnectedEvent {
   server_id: string
   status: string
+  pods: string[]
 }
 
 export interface ErrorEvent {
@@ -23,12 +32,32 @@ export interface LogStreamCallbacks {
   onHeartbeat?: () => void
 }
 
+// Filters applied server-side before lines are sent
+export interface LogStreamOptions {
+  grep?: string
+  level?: LogLevel // Minimum severity
+  since?: string // RFC 3339 timestamp
+  previous?: boolean // Only the previous container instance
+}
+
+// URL of the gzipped full log download; a plain link so the browser handles the file
+export function getLogDownloadUrl(serverId: string): string {
+  const token = localStorage.getItem("access_token")
+  return `${API_URL}/servers/${serverId}/logs/download?token=${encodeURIComponent(token || "")}`
+}
+
 export function createLogStream(
   serverId: string,
-  callbacks: LogStreamCallbacks
+  callbacks: LogStreamCallbacks,
+  options: LogStreamOptions = {}
 ): EventSource {
   const token = localStorage.getItem("access_token")
-  const url = `${API_URL}/servers/${serverId}/logs?token=${encodeURIComponent(token || "")}`
+  const params = new URLSearchParams({ token: token || "" })
+  if (options.grep) params.set("grep", options.grep)
+  if (options.level) params.set("level", options.level)
+  if (options.since) params.set("since", options.since)
+  if (options.previous) params.set("previous", "true")
+  const url = `${API_URL}/servers/${serverId}/logs?${params}`
 
   const eventSource = new EventSource(url)
 
@@ -1,5 +1,7 @@
 import client from "./client"
 
+const API_URL = import.meta.env.VITE_API_URL || "http://localhost:8080"
+
 export type ServerStatus =
   | "pending"
   | "starting"
@@ -10,9 +12,48 @@ export type ServerStatus =
   | "failed"
   | "deleting"
   | "deleted"
+  | "suspended"
+
+// How far a starting server has got, as inferred by its supervisor
+export type StartupPhase = "installing" | "generating_world" | "starting"
+
+// Machine-readable cause for the current status (see models.StatusReason)
+export type StatusReason =
+  | "OOM_KILLED"
+  | "CRASH_LOOP"
+  | "HEARTBEAT_TIMEOUT"
+  | "NO_CAPACITY"
+  | "IMAGE_PULL_ERROR"
+  | "STARTUP_TIMEOUT"
+  | "DEPLOYMENT_MISSING"
+  | "POD_FAILED"
+  | "INVALID_CONFIG"
+  | "DISPUTE"
+  | "ABUSE"
+  | "GAME_UNRESPONSIVE"
+  | "POD_EVICTED"
+  | "NODE_RECLAIMED"
+  | "RESTORE_FAILED"
+  | "CLONE_FAILED"
+  | "PORT_BLOCKED"
 
 export type GameType = "minecraft" | "valheim"
-export type ServerPlan = "small" | "medium" | "large"
+export type ServerPlan = "small" | "medium" | "large" | "dedicated" | "budget"
+
+// User-supplied definition for servers of the "custom" game type
+export interface CustomGame {
+  image: string
+  start_command: string[]
+  work_dir?: string
+  data_path?: string
+  ports: { name: string; port: number; protocol: "TCP" | "UDP"; same_port?: string }[]
+  health_check?: {
+    type: "none" | "port" | "log-pattern"
+    port?: number
+    protocol?: "TCP" | "UDP"
+    pattern?: string
+  }
+}
 
 export interface ServerPort {
   id: string
@@ -23,6 +64,14 @@ export interface ServerPort {
   protocol: string
 }
 
+// Stable port on the edge proxy's anycast address, kept while the server moves between nodes
+export interface EdgePort {
+  name: string
+  port: number
+  protocol: string
+  address: string
+}
+
 export interface Server {
   id: string
   user_id: string
@@ -32,12 +81,27 @@ export interface Server {
   plan: ServerPlan
   status: ServerStatus
   status_message?: string
+  status_reason?: StatusReason
+  startup_phase?: StartupPhase // Only set in server details while starting
   ports?: ServerPort[]
   env_overrides?: Record<string, string>
+  location?: ServerLocation
+  custom_game?: CustomGame // Only set in server details for custom games
+  edge_ports?: EdgePort[] // Only set in server details when the edge proxy is enabled
+  restart_required?: boolean // Set in server details: the deployment predates the current configuration
   created_at: string
   updated_at: string
 }
 
+// Where the server's node runs; only set in server details
+export interface ServerLocation {
+  public_ip: string
+  zone?: string
+  region?: string
+  provider?: string
+  datacenter?: string
+}
+
 export interface ServerListResponse {
   servers: Server[]
   total: number
@@ -46,18 +110,347 @@ export interface ServerListResponse {
 export interface GameConfigInfo {
   default_env: Record<string, string>
   effective_env: Record<string, string>
+  live_reload: boolean
+  // Env var the server reads its Steam game server login token from, if the game needs one
+  steam_token_env?: string
+}
+
+export interface PlanUpgradeRecommendation {
+  current_plan: ServerPlan
+  recommended_plan: ServerPlan
+  current_memory: string
+  recommended_memory: string
+  price_delta?: number // Monthly difference in the smallest currency unit
+  currency?: string
+}
+
+export type OperationType = "start" | "stop" | "restart" | "upgrade"
+
+export type OperationState = "running" | "succeeded" | "failed" | "superseded"
+
+export interface Operation {
+  id: string
+  server_id: string
+  type: OperationType
+  state: OperationState
+  error_message?: string
+  created_at: string
+  updated_at: string
+  completed_at?: string
+}
+
+export type ServerCommandType =
+  | "stop"
+  | "reload_config"
+  | "backup"
+  | "exec"
+  | "export"
+  | "import"
+  | "restart_process"
+
+export type ServerCommandState =
+  | "pending"
+  | "delivered"
+  | "succeeded"
+  | "failed"
+  | "expired"
+
+export interface ServerCommand {
+  id: string
+  server_id: string
+  type: ServerCommandType
+  payload?: string
+  state: ServerCommandState
+  result?: string
+  created_at: string
+  delivered_at?: string
+  completed_at?: string
+  // Archive a backup or export produced; download it with getCommandDownloadUrl
+  artifact?: CommandArtifact
+  // Reported by exports and imports while they run; also streamed as "command" events
+  progress?: CommandProgress
+}
+
+export interface CommandArtifact {
+  name: string
+  size: number
+}
+
+export interface CommandProgress {
+  done_bytes: number
+  total_bytes: number
+}
+
+export interface CommandDownloadUrl {
+  url: string
+  expires_at: string
+}
+
+// Backups kept: the newest of each of the last keep_daily days and keep_weekly
+// weeks. updated_at is unset while the plan's default applies.
+export interface BackupPolicy {
+  keep_daily: number
+  keep_weekly: number
+  replicate: boolean
+  updated_at?: string
+}
+
+export interface BackupLimits {
+  max_daily: number
+  max_weekly: number
+  replication: boolean
+}
+
+export interface BackupReplica {
+  id: string
+  server_id: string
+  command_id?: string
+  name: string
+  size: number
+  state: "pending" | "replicated" | "failed" | "deleting"
+  last_error?: string
+  created_at: string
+  replicated_at?: string
+}
+
+// A task run at the times of a five-field cron expression in timezone, while the server
+// is running. last_result says what the last run did or why it was skipped.
+export interface ServerSchedule {
+  id: string
+  server_id: string
+  name: string
+  cron: string
+  timezone: string
+  action: "restart" | "command" | "backup"
+  payload?: string
+  enabled: boolean
+  next_run_at: string
+  last_run_at?: string
+  last_result?: string
+  created_at: string
+  updated_at: string
+}
+
+export type ServerScheduleInput = Pick<
+  ServerSchedule,
+  "name" | "cron" | "timezone" | "action" | "payload" | "enabled"
+>
+
+// Actions the server's owner can currently take, computed by the API from its status rules
+export interface ServerCapabilities {
+  can_start: boolean
+  can_stop: boolean
+  can_restart: boolean
+  can_edit_env: boolean
+  can_resubscribe: boolean
 }
 
 export interface ServerDetailResponse {
   server: Server
   k8s_state?: string
   game_config?: GameConfigInfo
+  oom_recommendation?: PlanUpgradeRecommendation | null
+}
+
+// Paying with a saved card creates the server immediately (server_id) instead of
+// returning a checkout session to redirect to
+export interface EnvRevision {
+  revision: number
+  env_overrides: Record<string, string>
+  diff: {
+    added?: Record<string, string>
+    removed?: Record<string, string>
+    changed?: Record<string, { from: string; to: string }>
+  }
+  changed_by?: string
+  changed_by_email?: string
+  reverted_to?: number
+  created_at: string
+}
+
+// Outbound traffic this month against the plan's limits
+export interface EgressUsage {
+  period: string
+  tx_bytes: number
+  quota_bytes?: number // Unset means unlimited
+  bandwidth_limit?: string // Bits per second, e.g. "100M"
+  quota_exceeded_at?: string
+}
+
+export type MetricsRange = "1h" | "6h" | "24h" | "7d"
+
+export interface MetricsPoint {
+  time: string // Start of the step
+  cpu_percent: number // Percent of one core
+  cpu_percent_max: number
+  memory_mb: number
+  memory_mb_max: number
+  players_max: number | null
+}
+
+export interface ServerMetrics {
+  range: MetricsRange
+  step_seconds: number
+  cpu_limit_percent?: number
+  memory_limit_mb?: number
+  points: MetricsPoint[] // Steps without heartbeats have no point
+}
+
+export type SessionsRange = "24h" | "7d" | "30d" | "90d"
+
+// A period the server was online
+export interface ServerSession {
+  id: string
+  started_at: string
+  last_seen_at: string
+  ended_at?: string // Unset while online
+  end_status?: ServerStatus // Unset if its heartbeats stopped
+  duration_seconds: number
+}
+
+export interface ServerSessionSummary {
+  range: SessionsRange
+  since: string
+  online_seconds: number
+  uptime_percent: number
+  billable_hours: number // Each session rounded up to whole hours
+  last_seen_at?: string
+}
+
+export interface DNSRecord {
+  type: "TXT" | "A" | "AAAA" | "SRV"
+  name: string
+  value: string
+}
+
+// A domain the user points at a server, verified with a TXT record
+export interface CustomDomain {
+  id: string
+  server_id: string
+  domain: string
+  status: "pending" | "verified" | "failed"
+  last_error?: string
+  checked_at?: string
+  verified_at?: string
+  created_at: string
+  verification: DNSRecord
+  records?: DNSRecord[] // Unset until the server has a port to point at
+  connect?: string // What players enter, e.g. "play.example.com"
+}
+
+// Plan change suggested from the server's CPU and memory usage over the last week
+export interface PlanRecommendation {
+  direction: "upgrade" | "downgrade"
+  current_plan: ServerPlan
+  recommended_plan: ServerPlan
+  reason: "memory_pressure" | "cpu_pressure" | "underused"
+  avg_cpu_percent: number // Percent of one core
+  p95_cpu_percent: number
+  avg_memory_mb: number
+  peak_memory_mb: number
+  price_delta?: number // Monthly difference in the smallest currency unit
+  currency?: string
+  generated_at: string
+}
+
+export interface PendingChanges {
+  deployed: boolean
+  restart_required: boolean
+  env: EnvRevision["diff"]
+  spec: { field: string; from: string; to: string }[]
+}
+
+// Temporary read-only access to an expired server's data
+export interface FileAccess {
+  status: "stopped" | "starting" | "ready"
+  expires_at?: string // Start it again after this to keep browsing
+  delete_after?: string // When the data is removed for good
+}
+
+export interface FileEntry {
+  name: string
+  dir: boolean
+  size: number
+  modified_at: string
+}
+
+export interface ServerMod {
+  id: string
+  server_id: string
+  project_id: string // Modrinth project
+  version_id: string
+  name: string
+  version_number: string
+  loader: string
+  path: string
+  size: number
+  installed_at: string
+}
+
+// A mod from the server's template that isn't installed yet
+export interface PendingMod {
+  project_id: string
+  version_id: string
+  name: string
+}
+
+export interface ModProject {
+  project_id: string
+  slug: string
+  title: string
+  description: string
+  author: string
+  downloads: number
+  icon_url?: string
+}
+
+// Copying a cloned server's data, which happens once the source is stopped
+export interface ServerClone {
+  server_id: string
+  source_server_id?: string // Unset once the source is deleted
+  state: "pending" | "copying" | "completed" | "failed"
+  error?: string
+  created_at: string
+  completed_at?: string
 }
 
 export interface CheckoutResponse {
-  session_id: string
-  checkout_url: string
+  session_id?: string
+  checkout_url?: string
   pending_request_id: string
+  server_id?: string
+}
+
+// Messages from the interactive console socket
+export interface ConsoleEvent {
+  type: "output" | "error"
+  line?: string
+  message?: string
+}
+
+// Details of CAPACITY_UNAVAILABLE checkout errors
+export interface CapacityShortage {
+  resource:
+    | "quota"
+    | "nodes"
+    | "ports"
+    | "cpu"
+    | "memory"
+    | "gpu"
+    | "plan_limit"
+    | "waitlist" // Freed capacity is offered to waitlisted users first
+  available_at?: string // When subscriptions set to end should free enough room
+  waitlist: boolean
+}
+
+export interface WaitlistEntry {
+  id: string
+  game: GameType
+  plan: ServerPlan
+  region?: string // Unset for any region
+  created_at: string
+  offered_at?: string
+  offer_expires_at?: string // Checkout with waitlist_id works until then
 }
 
 export const serversApi = {
@@ -65,6 +458,17 @@ export const serversApi = {
 
   get: (id: string) => client.get<ServerDetailResponse>(`/servers/${id}`),
 
+  getCapabilities: (id: string) =>
+    client.get<{ capabilities: ServerCapabilities }>(
+      `/servers/${id}/capabilities`
+    ),
+
+  delete: (id: string, confirmation: string) =>
+    client.delete<{ status: string; message: string; delete_after?: string }>(
+      `/servers/${id}`,
+      { data: { confirmation } }
+    ),
+
   start: (id: string) =>
     client.post<{ status: string; message: string }>(`/servers/${id}/start`),
 
@@ -75,17 +479,318 @@ export const serversApi = {
     displayName: string,
     subdomain: string,
     game: GameType,
-    plan: ServerPlan
+    plan: ServerPlan,
+    useSavedCard = false,
+    waitlistId?: string, // From the waitlist offer email's link
+    preferredPort?: number // Plans with vanity ports only
   ) =>
     client.post<CheckoutResponse>("/servers/checkout", {
       display_name: displayName,
       subdomain,
       game,
       plan,
+      use_saved_card: useSavedCard,
+      waitlist_id: waitlistId,
+      preferred_port: preferredPort,
+    }),
+
+  // Whether a preferred game port number is free for a new server; fails with FORBIDDEN on
+  // plans without vanity ports. Custom games pass their game port's protocol.
+  checkPortAvailability: (
+    game: GameType,
+    plan: ServerPlan,
+    port: number,
+    protocol?: "TCP" | "UDP"
+  ) =>
+    client.get<{ port: number; available: boolean }>(
+      "/servers/port-availability",
+      { params: { game, plan, port, protocol } }
+    ),
+
+  // Offers the user capacity for the game and plan, in order, once it frees up
+  joinWaitlist: (game: GameType, plan: ServerPlan, region?: string) =>
+    client.post<{ waitlist: WaitlistEntry }>("/capacity-waitlist", {
+      game,
+      plan,
+      region,
+    }),
+
+  listWaitlist: () =>
+    client.get<{ waitlist: WaitlistEntry[] }>("/capacity-waitlist"),
+
+  leaveWaitlist: (id: string) =>
+    client.delete<{ message: string }>(`/capacity-waitlist/${id}`),
+
+  restartProcess: (id: string) =>
+    client.post<{ message: string; command: ServerCommand }>(
+      `/servers/${id}/process/restart`
+    ),
+
+  updateEnv: (
+    id: string,
+    envOverrides: Record<string, string>,
+    reload = false
+  ) =>
+    client.put<{
+      status: string
+      message: string
+      restart_required?: boolean
+      command?: ServerCommand
+    }>(
+      `/servers/${id}/env`,
+      { env_overrides: envOverrides, reload }
+    ),
+
+  getEnvHistory: (id: string) =>
+    client.get<{ revisions: EnvRevision[] }>(`/servers/${id}/env/history`),
+
+  // Restores the environment variables saved by a revision
+  revertEnv: (id: string, revision: number) =>
+    client.post<{
+      status: string
+      message: string
+      restart_required: boolean
+      revision: EnvRevision | null
+    }>(
+      `/servers/${id}/env/revert/${revision}`
+    ),
+
+  // What restarting the server would change about its running deployment
+  getPendingChanges: (id: string) =>
+    client.get<{ changes: PendingChanges }>(`/servers/${id}/pending-changes`),
+
+  getEgressUsage: (id: string) =>
+    client.get<{ usage: EgressUsage }>(`/servers/${id}/egress`),
+
+  getMetrics: (id: string, range: MetricsRange = "24h") =>
+    client.get<{ metrics: ServerMetrics }>(`/servers/${id}/metrics`, {
+      params: { range },
+    }),
+
+  getSessions: (id: string, range: SessionsRange = "7d") =>
+    client.get<{ sessions: ServerSession[]; summary: ServerSessionSummary }>(
+      `/servers/${id}/sessions`,
+      { params: { range } }
+    ),
+
+  // Replaces a custom game server's definition; applied on the next restart
+  updateCustomGame: (id: string, customGame: CustomGame) =>
+    client.put<{ custom_game: CustomGame }>(`/servers/${id}/custom-game`, {
+      custom_game: customGame,
+    }),
+
+  getRecommendations: (id: string) =>
+    client.get<{ recommendations: PlanRecommendation[] }>(
+      `/servers/${id}/recommendations`
+    ),
+
+  listDomains: (id: string) =>
+    client.get<{ domains: CustomDomain[] }>(`/servers/${id}/domains`),
+
+  addDomain: (id: string, domain: string) =>
+    client.post<{ domain: CustomDomain }>(`/servers/${id}/domains`, { domain }),
+
+  // Looks up the domain's verification TXT record
+  verifyDomain: (id: string, domainId: string) =>
+    client.post<{ domain: CustomDomain }>(
+      `/servers/${id}/domains/${domainId}/verify`
+    ),
+
+  deleteDomain: (id: string, domainId: string) =>
+    client.delete(`/servers/${id}/domains/${domainId}`),
+
+  listOperations: (id: string) =>
+    client.get<{ operations: Operation[] }>(`/servers/${id}/operations`),
+
+  sendCommand: (
+    id: string,
+    type: Exclude<ServerCommandType, "stop" | "restart_process">,
+    payload?: string
+  ) =>
+    client.post<{ command: ServerCommand }>(`/servers/${id}/commands`, {
+      type,
+      payload,
     }),
 
-  updateEnv: (id: string, envOverrides: Record<string, string>) =>
-    client.put<{ status: string; message: string }>(`/servers/${id}/env`, {
-      env_overrides: envOverrides,
+  getCommand: (id: string, commandId: string) =>
+    client.get<{ command: ServerCommand }>(
+      `/servers/${id}/commands/${commandId}`
+    ),
+
+  // Queues a world import, then upload its archive with uploadImport. Imports
+  // queued while a new server is being created run before its first start.
+  startImport: (id: string) =>
+    client.post<{ command: ServerCommand }>(`/servers/${id}/import`),
+
+  // Uploads a .zip or .tar.gz world archive for an import. Fails with IMPORT_NOT_READY
+  // until the server's pod is running; retry until it succeeds.
+  uploadImport: (
+    id: string,
+    commandId: string,
+    archive: File,
+    onProgress?: (loaded: number, total: number) => void
+  ) =>
+    client.put<{ command: ServerCommand }>(
+      `/servers/${id}/import/${commandId}`,
+      archive,
+      {
+        headers: { "Content-Type": "application/octet-stream" },
+        onUploadProgress: (event) =>
+          onProgress?.(event.loaded, event.total ?? archive.size),
+      }
+    ),
+
+  // Signed link to a backup or export archive, usable without logging in
+  getCommandDownloadUrl: (id: string, commandId: string) =>
+    client.post<{ download: CommandDownloadUrl }>(
+      `/servers/${id}/commands/${commandId}/download-url`
+    ),
+
+  getBackupPolicy: (id: string) =>
+    client.get<{ policy: BackupPolicy; limits: BackupLimits }>(
+      `/servers/${id}/backup-policy`
+    ),
+
+  // Applies from the next backup. Fails with BACKUP_LIMIT beyond the plan's limits.
+  updateBackupPolicy: (
+    id: string,
+    policy: Pick<BackupPolicy, "keep_daily" | "keep_weekly" | "replicate">
+  ) =>
+    client.put<{ policy: BackupPolicy; limits: BackupLimits }>(
+      `/servers/${id}/backup-policy`,
+      policy
+    ),
+
+  listBackupReplicas: (id: string) =>
+    client.get<{ replicas: BackupReplica[] }>(`/servers/${id}/backup-replicas`),
+
+  listSchedules: (id: string) =>
+    client.get<{ schedules: ServerSchedule[] }>(`/servers/${id}/schedules`),
+
+  // payload is the console command of command tasks. Tasks may run at most every 15
+  // minutes; fails with SCHEDULE_LIMIT beyond 10 per server.
+  createSchedule: (id: string, schedule: ServerScheduleInput) =>
+    client.post<{ schedule: ServerSchedule }>(
+      `/servers/${id}/schedules`,
+      schedule
+    ),
+
+  updateSchedule: (
+    id: string,
+    scheduleId: string,
+    schedule: ServerScheduleInput
+  ) =>
+    client.put<{ schedule: ServerSchedule }>(
+      `/servers/${id}/schedules/${scheduleId}`,
+      schedule
+    ),
+
+  deleteSchedule: (id: string, scheduleId: string) =>
+    client.delete(`/servers/${id}/schedules/${scheduleId}`),
+
+  getFileAccess: (id: string) =>
+    client.get<{ file_access: FileAccess }>(`/servers/${id}/file-access`),
+
+  // Starts a temporary pod serving an expired server's data; poll until ready
+  startFileAccess: (id: string) =>
+    client.post<{ file_access: FileAccess }>(`/servers/${id}/file-access`),
+
+  stopFileAccess: (id: string) =>
+    client.delete<{ file_access: FileAccess }>(`/servers/${id}/file-access`),
+
+  listFiles: (id: string, path: string) =>
+    client.get<{ files: FileEntry[] }>(`/servers/${id}/files`, {
+      params: { path },
     }),
+
+  // Directories download as a zip archive
+  getFileDownloadUrl: (id: string, path: string) => {
+    const token = localStorage.getItem("access_token")
+    const params = new URLSearchParams({ path, token: token || "" })
+    return `${API_URL}/servers/${id}/files/download?${params}`
+  },
+
+  // WebSocket of the interactive console: receives ConsoleEvent JSON, send each input line
+  // as a text message
+  getConsoleUrl: (id: string) => {
+    const token = localStorage.getItem("access_token")
+    const params = new URLSearchParams({ token: token || "" })
+    const base = new URL(API_URL, window.location.href)
+    base.protocol = base.protocol === "https:" ? "wss:" : "ws:"
+    return `${base.toString().replace(/\/$/, "")}/servers/${id}/console?${params}`
+  },
+
+  // Uploads, directories and deletes need the server running; expired servers are read-only
+  uploadFile: (id: string, path: string, file: Blob) =>
+    client.put<{ message: string }>(`/servers/${id}/files/upload`, file, {
+      params: { path },
+      headers: { "Content-Type": "application/octet-stream" },
+    }),
+
+  createDirectory: (id: string, path: string) =>
+    client.post<{ message: string }>(`/servers/${id}/files/mkdir`, null, {
+      params: { path },
+    }),
+
+  deleteFile: (id: string, path: string) =>
+    client.delete<{ message: string }>(`/servers/${id}/files`, {
+      params: { path },
+    }),
+
+  listMods: (id: string) =>
+    client.get<{
+      loader: string
+      game_version: string
+      dir: string
+      mods: ServerMod[]
+      pending: PendingMod[]
+    }>(`/servers/${id}/mods`),
+
+  searchMods: (id: string, q: string) =>
+    client.get<{ projects: ModProject[] }>(`/servers/${id}/mods/search`, {
+      params: { q },
+    }),
+
+  installMod: (id: string, projectId: string, versionId?: string) =>
+    client.post<{ mod: ServerMod; missing_dependencies: string[]; message: string }>(
+      `/servers/${id}/mods`,
+      { project_id: projectId, version_id: versionId }
+    ),
+
+  removeMod: (id: string, modId: string) =>
+    client.delete<{ message: string }>(`/servers/${id}/mods/${modId}`),
+
+  // Installs the mods from the server's template that aren't installed yet
+  installPendingMods: (id: string) =>
+    client.post<{ mods: ServerMod[]; message: string }>(`/servers/${id}/mods/pending`),
+
+  dismissPendingMod: (id: string, projectId: string) =>
+    client.delete(`/servers/${id}/mods/pending/${projectId}`),
+
+  // Create a Steam game server login token with the owner's linked Steam account
+  createSteamToken: (id: string) =>
+    client.post<{ env_key: string; message: string; restart_required: boolean }>(
+      `/servers/${id}/steam-token`
+    ),
+
+  // Checkout for a new server with this one's game, plan, env and data
+  clone: (
+    id: string,
+    subdomain: string,
+    displayName?: string,
+    useSavedCard = false
+  ) =>
+    client.post<CheckoutResponse>(`/servers/${id}/clone`, {
+      display_name: displayName,
+      subdomain,
+      use_saved_card: useSavedCard,
+    }),
+
+  getClone: (id: string) =>
+    client.get<{ clone: ServerClone }>(`/servers/${id}/clone`),
+
+  upgradeFromOOM: (id: string) =>
+    client.post<{ status: string; message: string; plan: ServerPlan }>(
+      `/servers/${id}/upgrade-from-oom`
+    ),
 }
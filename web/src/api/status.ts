@@ -1,4 +1,4 @@
-import type { ServerStatus } from "./servers"
+import type { ServerStatus, StartupPhase, StatusReason } from "./servers"
 
 const API_URL = import.meta.env.VITE_API_URL || "http://localhost:8080"
 
@@ -6,6 +6,8 @@ export interface StatusEvent {
   server_id: string
   status: ServerStatus
   status_message?: string
+  status_reason?: StatusReason | ""
+  startup_phase?: StartupPhase | ""
   timestamp: string
 }
 
@@ -13,6 +15,7 @@ export interface ServerInitialState {
   server_id: string
   status: ServerStatus
   status_message?: string
+  status_reason?: StatusReason | ""
 }
 
 export interface ConnectedEvent {
@@ -20,16 +23,46 @@ export interface ConnectedEvent {
   timestamp: string
 }
 
+// How far a starting server has got with a startup milestone from its game catalog
+export interface ProgressEvent {
+  server_id: string
+  label: string
+  percent: number
+  timestamp: string
+}
+
+export type IncidentKind = "node_not_ready" | "node_drained" | "node_reclaimed"
+
+export interface IncidentEvent {
+  incident_id: string
+  kind: IncidentKind
+  state: "open" | "resolved"
+  server_ids: string[]
+  started_at: string
+  resolved_at?: string
+  timestamp: string
+}
+
 export interface ErrorEvent {
   message: string
   details?: string
 }
 
+// Sent before the API ends a stream the user replaced by opening a newer one, e.g. in
+// another tab; the stream isn't reopened
+export interface ClosedEvent {
+  reason: "replaced"
+  message: string
+}
+
 export interface StatusStreamCallbacks {
   onStatus: (status: StatusEvent) => void
   onConnected: (data: ConnectedEvent) => void
   onError: (error: ErrorEvent) => void
+  onIncident?: (incident: IncidentEvent) => void
+  onProgress?: (progress: ProgressEvent) => void
   onHeartbeat?: () => void
+  onClosed?: (event: ClosedEvent) => void
 }
 
 export function createStatusStream(callbacks: StatusStreamCallbacks): EventSource {
@@ -65,10 +98,38 @@ export function createStatusStream(callbacks: StatusStreamCallbacks): EventSourc
     }
   })
 
+  eventSource.addEventListener("incident", (event) => {
+    try {
+      callbacks.onIncident?.(JSON.parse(event.data))
+    } catch (e) {
+      console.error("Failed to parse incident event:", e)
+    }
+  })
+
+  eventSource.addEventListener("progress", (event) => {
+    try {
+      callbacks.onProgress?.(JSON.parse(event.data))
+    } catch (e) {
+      console.error("Failed to parse progress event:", e)
+    }
+  })
+
   eventSource.addEventListener("heartbeat", () => {
     callbacks.onHeartbeat?.()
   })
 
+  eventSource.addEventListener("closed", (event) => {
+    // Reconnecting would replace the newer stream in turn
+    eventSource.close()
+    try {
+      const closed: ClosedEvent = JSON.parse(event.data)
+      callbacks.onClosed?.(closed)
+      callbacks.onError({ message: closed.message })
+    } catch (e) {
+      console.error("Failed to parse closed event:", e)
+    }
+  })
+
   // Handle connection errors (network issues, auth failures, etc.)
   eventSource.onerror = () => {
     // EventSource auto-reconnects by default on network errors
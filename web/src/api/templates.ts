@@ -0,0 +1,54 @@
+import client from "./client"
+import type {
+  CheckoutResponse,
+  GameType,
+  PendingMod,
+  ServerPlan,
+  ServerScheduleInput,
+} from "./servers"
+
+export interface ServerTemplate {
+  id: string
+  name: string
+  description: string
+  game: GameType
+  plan: ServerPlan
+  env_overrides?: Record<string, string>
+  mods: PendingMod[]
+  schedules: ServerScheduleInput[]
+  is_public: boolean
+  is_owner: boolean
+  created_at: string
+  updated_at: string
+}
+
+export const templatesApi = {
+  // Lists the user's templates, or every shared template when pub is set
+  list: (pub = false) =>
+    client.get<{ templates: ServerTemplate[] }>("/templates", {
+      params: pub ? { public: true } : undefined,
+    }),
+
+  get: (id: string) => client.get<{ template: ServerTemplate }>(`/templates/${id}`),
+
+  // Saves the server's game, plan, environment variables, mods and scheduled tasks
+  create: (serverId: string, name: string, description: string, isPublic: boolean) =>
+    client.post<{ template: ServerTemplate }>("/templates", {
+      server_id: serverId,
+      name,
+      description,
+      is_public: isPublic,
+    }),
+
+  update: (id: string, data: { name?: string; description?: string; is_public?: boolean }) =>
+    client.patch<{ template: ServerTemplate }>(`/templates/${id}`, data),
+
+  delete: (id: string) => client.delete(`/templates/${id}`),
+
+  // Same response as serversApi.checkout
+  createServer: (id: string, displayName: string, subdomain: string) =>
+    client.post<CheckoutResponse>(`/servers/from-template/${id}`, {
+      display_name: displayName,
+      subdomain,
+    }),
+}
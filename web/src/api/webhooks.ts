@@ -0,0 +1,59 @@
+import client from "./client"
+
+export type WebhookEvent =
+  | "server.status_changed"
+  | "server.crashed"
+  | "backup.completed"
+  | "backup.failed"
+
+export const WEBHOOK_EVENTS: { value: WebhookEvent; label: string }[] = [
+  { value: "server.status_changed", label: "Status changes" },
+  { value: "server.crashed", label: "Crashes" },
+  { value: "backup.completed", label: "Backup completed" },
+  { value: "backup.failed", label: "Backup failed" },
+]
+
+export interface Webhook {
+  id: string
+  server_id: string
+  url: string
+  events: WebhookEvent[]
+  created_at: string
+}
+
+export type WebhookDeliveryState = "pending" | "delivered" | "failed"
+
+export interface WebhookDelivery {
+  id: string
+  webhook_id: string
+  event: WebhookEvent
+  payload: unknown
+  state: WebhookDeliveryState
+  attempts: number
+  response_status?: number
+  last_error?: string
+  next_attempt_at: string
+  created_at: string
+  delivered_at?: string
+}
+
+export const webhooksApi = {
+  list: (serverId: string) =>
+    client.get<{ webhooks: Webhook[] }>(`/servers/${serverId}/webhooks`),
+
+  // The secret is only returned here; it signs every delivery (X-GSHub-Signature)
+  create: (serverId: string, url: string, events: WebhookEvent[], secret?: string) =>
+    client.post<{ webhook: Webhook; secret: string }>(`/servers/${serverId}/webhooks`, {
+      url,
+      events,
+      secret: secret || undefined,
+    }),
+
+  delete: (serverId: string, webhookId: string) =>
+    client.delete(`/servers/${serverId}/webhooks/${webhookId}`),
+
+  listDeliveries: (serverId: string, webhookId: string) =>
+    client.get<{ deliveries: WebhookDelivery[] }>(
+      `/servers/${serverId}/webhooks/${webhookId}/deliveries`
+    ),
+}
@@ -57,7 +57,7 @@ export function SubscriptionCard({ subscription }: SubscriptionCardProps) {
   }
 
   const handleResubscribe = () => {
-    resubscribeMutation.mutate(subscription.server_id)
+    resubscribeMutation.mutate({ serverId: subscription.server_id })
   }
 
   return (
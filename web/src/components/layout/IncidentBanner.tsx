@@ -0,0 +1,33 @@
+import { AlertTriangle } from "lucide-react"
+import { Alert, AlertDescription } from "@/components/ui/alert"
+import type { IncidentEvent } from "@/api/status"
+
+const INCIDENT_MESSAGES: Record<IncidentEvent["kind"], string> = {
+  node_not_ready: "A host running your servers is unreachable. Affected servers may be offline until it recovers.",
+  node_drained: "A host running your servers is under maintenance. Affected servers may restart.",
+  node_reclaimed: "A budget host running your servers is being reclaimed. Affected servers are moving to another host.",
+}
+
+interface IncidentBannerProps {
+  incidents: IncidentEvent[]
+}
+
+export function IncidentBanner({ incidents }: IncidentBannerProps) {
+  if (incidents.length === 0) {
+    return null
+  }
+
+  return (
+    <div className="space-y-2 mb-6">
+      {incidents.map((incident) => (
+        <Alert key={incident.incident_id} className="bg-yellow-500/10 border-yellow-500/20">
+          <AlertTriangle className="h-4 w-4 text-yellow-500" />
+          <AlertDescription className="text-sm">
+            {INCIDENT_MESSAGES[incident.kind]} ({incident.server_ids.length}{" "}
+            {incident.server_ids.length === 1 ? "server" : "servers"} affected)
+          </AlertDescription>
+        </Alert>
+      ))}
+    </div>
+  )
+}
@@ -1,5 +1,5 @@
 import { Link, useNavigate } from "react-router-dom"
-import { ChevronDown, CreditCard, LogOut, Gamepad2 } from "lucide-react"
+import { ChevronDown, CreditCard, LayoutTemplate, LogOut, Gamepad2, Plug } from "lucide-react"
 import { useAuth } from "@/hooks/useAuth"
 import { Button } from "@/components/ui/button"
 import {
@@ -47,6 +47,14 @@ export function Navbar() {
                 <CreditCard className="h-4 w-4" />
                 Billing
               </DropdownMenuItem>
+              <DropdownMenuItem onClick={() => navigate("/templates")}>
+                <LayoutTemplate className="h-4 w-4" />
+                Templates
+              </DropdownMenuItem>
+              <DropdownMenuItem onClick={() => navigate("/settings/integrations")}>
+                <Plug className="h-4 w-4" />
+                Integrations
+              </DropdownMenuItem>
               <DropdownMenuItem onClick={handleLogout}>
                 <LogOut className="h-4 w-4" />
                 Sign out
@@ -0,0 +1,61 @@
+import { useQuery } from "@tanstack/react-query"
+import { serversApi } from "@/api/servers"
+import { cn } from "@/lib/utils"
+
+interface EgressUsageBoxProps {
+  serverId: string
+}
+
+function formatBytes(bytes: number) {
+  const units = ["B", "KiB", "MiB", "GiB", "TiB"]
+  let value = bytes
+  let unit = 0
+  while (value >= 1024 && unit < units.length - 1) {
+    value /= 1024
+    unit++
+  }
+  return unit === 0 ? `${value} ${units[unit]}` : `${value.toFixed(1)} ${units[unit]}`
+}
+
+// Outbound traffic this month. Renders nothing for plans without egress limits.
+export function EgressUsageBox({ serverId }: EgressUsageBoxProps) {
+  const { data } = useQuery({
+    queryKey: ["server", serverId, "egress"],
+    queryFn: () => serversApi.getEgressUsage(serverId).then((r) => r.data.usage),
+    refetchInterval: 60_000,
+  })
+
+  if (!data || (!data.quota_bytes && !data.bandwidth_limit)) {
+    return null
+  }
+
+  const percent = data.quota_bytes
+    ? Math.min(100, (data.tx_bytes / data.quota_bytes) * 100)
+    : 0
+  const exceeded = !!data.quota_bytes && data.tx_bytes >= data.quota_bytes
+
+  return (
+    <div className="rounded-lg bg-card/50 border border-border/50 px-4 py-3 space-y-2">
+      <div className="flex items-center justify-between text-sm">
+        <span className="text-muted-foreground">Traffic this month</span>
+        <span className={cn("font-medium", exceeded && "text-amber-600")}>
+          {formatBytes(data.tx_bytes)}
+          {data.quota_bytes ? ` / ${formatBytes(data.quota_bytes)}` : ""}
+        </span>
+      </div>
+      {!!data.quota_bytes && (
+        <div className="h-1.5 rounded-full bg-muted overflow-hidden">
+          <div
+            className={cn("h-full", exceeded ? "bg-amber-500" : "bg-primary")}
+            style={{ width: `${percent}%` }}
+          />
+        </div>
+      )}
+      {data.bandwidth_limit && (
+        <p className="text-xs text-muted-foreground">
+          Outbound bandwidth limited to {data.bandwidth_limit}bit/s
+        </p>
+      )}
+    </div>
+  )
+}
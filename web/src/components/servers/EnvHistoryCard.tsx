@@ -0,0 +1,112 @@
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { Button } from "@/components/ui/button"
+import { Badge } from "@/components/ui/badge"
+import { Skeleton } from "@/components/ui/skeleton"
+import { serversApi, type EnvRevision } from "@/api/servers"
+
+interface EnvHistoryCardProps {
+  serverId: string
+}
+
+export function EnvDiffList({ diff }: { diff: EnvRevision["diff"] }) {
+  return (
+    <ul className="font-mono text-xs space-y-0.5 break-all">
+      {Object.entries(diff.added ?? {}).map(([key, value]) => (
+        <li key={`+${key}`} className="text-green-600">
+          + {key}={value}
+        </li>
+      ))}
+      {Object.entries(diff.changed ?? {}).map(([key, change]) => (
+        <li key={`~${key}`} className="text-amber-600">
+          ~ {key}: {change.from} → {change.to}
+        </li>
+      ))}
+      {Object.entries(diff.removed ?? {}).map(([key, value]) => (
+        <li key={`-${key}`} className="text-destructive">
+          - {key}={value}
+        </li>
+      ))}
+    </ul>
+  )
+}
+
+export function EnvHistoryCard({ serverId }: EnvHistoryCardProps) {
+  const queryClient = useQueryClient()
+
+  const { data, isLoading } = useQuery({
+    queryKey: ["server", serverId, "env-history"],
+    queryFn: () => serversApi.getEnvHistory(serverId).then((r) => r.data.revisions),
+  })
+
+  const revert = useMutation({
+    mutationFn: (revision: number) => serversApi.revertEnv(serverId, revision),
+    onSuccess: () => {
+      // Refreshes the server's env as well as the history
+      queryClient.invalidateQueries({ queryKey: ["server", serverId] })
+    },
+  })
+
+  const revisions = data ?? []
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="text-sm font-medium">Environment history</CardTitle>
+        <CardDescription>
+          Every change to this server's environment variables. Reverting saves
+          a revision's variables again; restart the server to apply them.
+        </CardDescription>
+      </CardHeader>
+      <CardContent>
+        {isLoading ? (
+          <Skeleton className="h-24 w-full" />
+        ) : revisions.length === 0 ? (
+          <p className="text-sm text-muted-foreground">No changes yet.</p>
+        ) : (
+          <div className="space-y-4">
+            {revert.isError && (
+              <p className="text-sm text-destructive">
+                Failed to revert environment variables.
+              </p>
+            )}
+            {revisions.map((revision, i) => (
+              <div key={revision.revision} className="space-y-2 border-b pb-3 last:border-0">
+                <div className="flex items-center justify-between gap-2">
+                  <div className="flex items-center gap-2 text-sm">
+                    <span className="font-medium">#{revision.revision}</span>
+                    {i === 0 && <Badge variant="secondary">Current</Badge>}
+                    {revision.reverted_to && (
+                      <Badge variant="outline">Reverted to #{revision.reverted_to}</Badge>
+                    )}
+                    <span className="text-muted-foreground">
+                      {new Date(revision.created_at).toLocaleString()}
+                      {revision.changed_by_email && ` by ${revision.changed_by_email}`}
+                    </span>
+                  </div>
+                  {i > 0 && (
+                    <Button
+                      variant="outline"
+                      size="sm"
+                      disabled={revert.isPending}
+                      onClick={() => revert.mutate(revision.revision)}
+                    >
+                      Revert to this
+                    </Button>
+                  )}
+                </div>
+                <EnvDiffList diff={revision.diff} />
+              </div>
+            ))}
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
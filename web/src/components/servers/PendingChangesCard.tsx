@@ -0,0 +1,51 @@
+import { useQuery } from "@tanstack/react-query"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { serversApi } from "@/api/servers"
+import { EnvDiffList } from "./EnvHistoryCard"
+
+interface PendingChangesCardProps {
+  serverId: string
+}
+
+// Shows what a restart would change. Renders nothing when the server is up to date.
+export function PendingChangesCard({ serverId }: PendingChangesCardProps) {
+  const { data } = useQuery({
+    queryKey: ["server", serverId, "pending-changes"],
+    queryFn: () =>
+      serversApi.getPendingChanges(serverId).then((r) => r.data.changes),
+  })
+
+  if (!data?.restart_required) {
+    return null
+  }
+
+  return (
+    <Card className="border-amber-500/50">
+      <CardHeader>
+        <CardTitle className="text-sm font-medium">Restart required</CardTitle>
+        <CardDescription>
+          The running server doesn't have these changes yet. Restart it to
+          apply them.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-2">
+        <EnvDiffList diff={data.env} />
+        {data.spec.length > 0 && (
+          <ul className="font-mono text-xs space-y-0.5 break-all">
+            {data.spec.map((change) => (
+              <li key={change.field} className="text-amber-600">
+                ~ {change.field}: {change.from || "none"} → {change.to || "none"}
+              </li>
+            ))}
+          </ul>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
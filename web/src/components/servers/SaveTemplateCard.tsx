@@ -0,0 +1,96 @@
+import { useState } from "react"
+import { Link } from "react-router-dom"
+import { useMutation, useQueryClient } from "@tanstack/react-query"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import { templatesApi } from "@/api/templates"
+
+interface SaveTemplateCardProps {
+  serverId: string
+}
+
+export function SaveTemplateCard({ serverId }: SaveTemplateCardProps) {
+  const queryClient = useQueryClient()
+  const [name, setName] = useState("")
+  const [description, setDescription] = useState("")
+  const [isPublic, setIsPublic] = useState(false)
+
+  const save = useMutation({
+    mutationFn: () => templatesApi.create(serverId, name.trim(), description.trim(), isPublic),
+    onSuccess: () => {
+      setName("")
+      setDescription("")
+      setIsPublic(false)
+      queryClient.invalidateQueries({ queryKey: ["templates"] })
+    },
+  })
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="text-sm font-medium">Save as template</CardTitle>
+        <CardDescription>
+          Save this server's game, plan, environment variables, mods and
+          scheduled tasks to create new servers from later.
+        </CardDescription>
+      </CardHeader>
+      <CardContent>
+        <form
+          className="space-y-3"
+          onSubmit={(e) => {
+            e.preventDefault()
+            save.mutate()
+          }}
+        >
+          <div className="space-y-1">
+            <Label htmlFor="template-name">Name</Label>
+            <Input
+              id="template-name"
+              value={name}
+              maxLength={100}
+              onChange={(e) => setName(e.target.value)}
+            />
+          </div>
+          <div className="space-y-1">
+            <Label htmlFor="template-description">Description</Label>
+            <Input
+              id="template-description"
+              value={description}
+              maxLength={1000}
+              onChange={(e) => setDescription(e.target.value)}
+            />
+          </div>
+          <label className="flex items-center gap-2 text-sm">
+            <input
+              type="checkbox"
+              checked={isPublic}
+              onChange={(e) => setIsPublic(e.target.checked)}
+            />
+            Share publicly (other users can see its environment variables)
+          </label>
+          {save.isError && (
+            <p className="text-sm text-destructive">
+              Could not save the template. Please try again.
+            </p>
+          )}
+          {save.isSuccess && (
+            <p className="text-sm text-muted-foreground">
+              Template saved. <Link to="/templates" className="underline">View templates</Link>
+            </p>
+          )}
+          <Button type="submit" size="sm" disabled={name.trim().length < 3 || save.isPending}>
+            Save template
+          </Button>
+        </form>
+      </CardContent>
+    </Card>
+  )
+}
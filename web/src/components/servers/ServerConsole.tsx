@@ -1,14 +1,22 @@
 import { useRef, useEffect, useState, useCallback } from "react"
-import { ArrowDown } from "lucide-react"
+import { ArrowDown, Download } from "lucide-react"
 import { Button } from "@/components/ui/button"
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card"
-import type { LogEvent } from "@/api/logs"
+import type { LogEvent, LogLevel } from "@/api/logs"
+
+const levelColors: Record<LogLevel, string> = {
+  debug: "text-zinc-500",
+  info: "text-zinc-300",
+  warn: "text-yellow-400",
+  error: "text-red-400",
+}
 
 interface ServerConsoleProps {
   logs: LogEvent[]
   isConnected: boolean
   error: string | null
   onClear: () => void
+  downloadUrl?: string
   className?: string
 }
 
@@ -17,6 +25,7 @@ export function ServerConsole({
   isConnected,
   error,
   onClear,
+  downloadUrl,
   className,
 }: ServerConsoleProps) {
   const containerRef = useRef<HTMLDivElement>(null)
@@ -59,6 +68,9 @@ export function ServerConsole({
     setIsUserScrolledUp(false)
   }, [scrollContainerToBottom])
 
+  // Tag lines with their pod only while output comes from more than one
+  const multiPod = new Set(logs.map((log) => log.pod)).size > 1
+
   return (
     <div className={className}>
       <div className="relative">
@@ -74,12 +86,30 @@ export function ServerConsole({
             )}
             {error && <p className="text-red-400">Error: {error}</p>}
             {logs.map((log, i) => (
-              <div key={i} className="whitespace-pre-wrap text-zinc-300">
+              <div
+                key={i}
+                className={`whitespace-pre-wrap ${log.previous ? "text-zinc-500" : levelColors[log.level ?? "info"]}`}
+              >
+                {multiPod && <span className="text-zinc-500">[{log.pod.slice(-5)}] </span>}
                 {log.line}
               </div>
             ))}
           </div>
 
+          {downloadUrl && (
+            <Button
+              asChild
+              size="sm"
+              variant="secondary"
+              className="absolute right-4 top-4 gap-1"
+            >
+              <a href={downloadUrl} download>
+                <Download className="h-3 w-3" />
+                Download
+              </a>
+            </Button>
+          )}
+
           {/* Scroll to bottom button */}
           {isUserScrolledUp && logs.length > 0 && (
             <Button
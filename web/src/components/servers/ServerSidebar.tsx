@@ -78,6 +78,11 @@ export function ServerSidebar() {
           className="w-full"
         />
       )}
+      {server?.restart_required && (
+        <p className="text-xs text-muted-foreground">
+          Restart required to apply changes
+        </p>
+      )}
     </div>
   )
 }
@@ -40,6 +40,10 @@ export const statusConfig: Record<ServerStatus, { label: string; className: stri
       label: "Deleted",
       className: "bg-gray-500/20 text-gray-400 border-gray-500/50",
     },
+    suspended: {
+      label: "Suspended",
+      className: "bg-red-500/20 text-red-400 border-red-500/50",
+    },
   }
 
 interface ServerStatusBadgeProps {
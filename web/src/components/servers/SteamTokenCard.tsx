@@ -0,0 +1,75 @@
+import { Link } from "react-router-dom"
+import { useMutation, useQueryClient } from "@tanstack/react-query"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { Button } from "@/components/ui/button"
+import { serversApi } from "@/api/servers"
+import { getApiError } from "@/api/client"
+
+interface SteamTokenCardProps {
+  serverId: string
+  envKey: string
+  isSet: boolean
+}
+
+export function SteamTokenCard({ serverId, envKey, isSet }: SteamTokenCardProps) {
+  const queryClient = useQueryClient()
+
+  const create = useMutation({
+    mutationFn: () => serversApi.createSteamToken(serverId),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ["server", serverId] })
+    },
+  })
+
+  const error = create.isError ? getApiError(create.error) : undefined
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="text-sm font-medium">Steam server token</CardTitle>
+        <CardDescription>
+          This game's dedicated server needs a Steam game server login token in{" "}
+          <code>{envKey}</code>. Create one with your linked Steam account, which must own the
+          game.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-3">
+        {isSet ? (
+          <p className="text-sm text-muted-foreground">
+            A token is set. Clear <code>{envKey}</code> above to create a new one.
+          </p>
+        ) : (
+          <Button size="sm" disabled={create.isPending} onClick={() => create.mutate()}>
+            {create.isPending ? "Creating..." : "Create token"}
+          </Button>
+        )}
+        {error?.code === "STEAM_NOT_LINKED" ? (
+          <p className="text-sm text-destructive">
+            <Link to="/settings/integrations" className="underline">
+              Link a Steam account
+            </Link>{" "}
+            first.
+          </p>
+        ) : (
+          create.isError && (
+            <p className="text-sm text-destructive">
+              {error?.message ?? "Could not create the token. Please try again."}
+            </p>
+          )
+        )}
+        {create.isSuccess && (
+          <p className="text-sm text-muted-foreground">
+            Token created.
+            {create.data.data.restart_required && " Restart the server to apply it."}
+          </p>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
@@ -0,0 +1,182 @@
+import { useState } from "react"
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query"
+import { Trash2 } from "lucide-react"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { Alert, AlertDescription } from "@/components/ui/alert"
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import { CopyableText } from "@/components/ui/copyable-text"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import { WEBHOOK_EVENTS, webhooksApi, type WebhookEvent } from "@/api/webhooks"
+
+interface WebhooksCardProps {
+  serverId: string
+}
+
+export function WebhooksCard({ serverId }: WebhooksCardProps) {
+  const queryClient = useQueryClient()
+  const [url, setUrl] = useState("")
+  const [events, setEvents] = useState<WebhookEvent[]>(["server.crashed"])
+  const [createdSecret, setCreatedSecret] = useState<string | null>(null)
+  const [expanded, setExpanded] = useState<string | null>(null)
+
+  const queryKey = ["servers", serverId, "webhooks"]
+
+  const { data: webhooks = [] } = useQuery({
+    queryKey,
+    queryFn: async () => (await webhooksApi.list(serverId)).data.webhooks,
+  })
+
+  const { data: deliveries = [] } = useQuery({
+    queryKey: [...queryKey, expanded, "deliveries"],
+    queryFn: async () =>
+      (await webhooksApi.listDeliveries(serverId, expanded!)).data.deliveries,
+    enabled: expanded !== null,
+  })
+
+  const create = useMutation({
+    mutationFn: () => webhooksApi.create(serverId, url, events),
+    onSuccess: (res) => {
+      setCreatedSecret(res.data.secret)
+      setUrl("")
+      queryClient.invalidateQueries({ queryKey })
+    },
+  })
+
+  const remove = useMutation({
+    mutationFn: (webhookId: string) => webhooksApi.delete(serverId, webhookId),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey }),
+  })
+
+  const toggleEvent = (event: WebhookEvent) => {
+    setEvents((prev) =>
+      prev.includes(event) ? prev.filter((e) => e !== event) : [...prev, event]
+    )
+  }
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="text-sm font-medium">Webhooks</CardTitle>
+        <CardDescription>
+          POST signed JSON to your own endpoints (e.g. a Discord bot) when
+          something happens on this server. Failed deliveries are retried.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        {webhooks.map((webhook) => (
+          <div key={webhook.id} className="space-y-2 rounded-md border p-3">
+            <div className="flex items-center justify-between gap-2">
+              <button
+                type="button"
+                className="truncate text-left text-sm font-mono hover:underline"
+                onClick={() =>
+                  setExpanded(expanded === webhook.id ? null : webhook.id)
+                }
+              >
+                {webhook.url}
+              </button>
+              <Button
+                size="sm"
+                variant="ghost"
+                onClick={() => remove.mutate(webhook.id)}
+                disabled={remove.isPending}
+              >
+                <Trash2 className="h-4 w-4" />
+              </Button>
+            </div>
+            <div className="flex flex-wrap gap-1">
+              {webhook.events.map((event) => (
+                <Badge key={event} variant="secondary">
+                  {event}
+                </Badge>
+              ))}
+            </div>
+            {expanded === webhook.id && (
+              <div className="space-y-1 pt-2 text-xs">
+                {deliveries.length === 0 && (
+                  <p className="text-muted-foreground">No deliveries yet</p>
+                )}
+                {deliveries.map((delivery) => (
+                  <div key={delivery.id} className="flex justify-between gap-2">
+                    <span className="font-mono">{delivery.event}</span>
+                    <span className="text-muted-foreground">
+                      {delivery.state}
+                      {delivery.response_status
+                        ? ` (${delivery.response_status})`
+                        : ""}
+                      {delivery.attempts > 1 ? `, ${delivery.attempts} attempts` : ""}
+                      {" · "}
+                      {new Date(delivery.created_at).toLocaleString()}
+                    </span>
+                  </div>
+                ))}
+              </div>
+            )}
+          </div>
+        ))}
+
+        {createdSecret && (
+          <Alert>
+            <AlertDescription className="space-y-2">
+              <p>
+                Signing secret for the new webhook. It won't be shown again.
+              </p>
+              <CopyableText value={createdSecret} />
+            </AlertDescription>
+          </Alert>
+        )}
+
+        <form
+          className="space-y-3 border-t pt-4"
+          onSubmit={(e) => {
+            e.preventDefault()
+            create.mutate()
+          }}
+        >
+          <div className="space-y-1">
+            <Label htmlFor="webhook-url">Endpoint URL</Label>
+            <Input
+              id="webhook-url"
+              type="url"
+              placeholder="https://example.com/gshub"
+              value={url}
+              onChange={(e) => setUrl(e.target.value)}
+            />
+          </div>
+          <div className="flex flex-wrap gap-3">
+            {WEBHOOK_EVENTS.map(({ value, label }) => (
+              <label key={value} className="flex items-center gap-1 text-sm">
+                <input
+                  type="checkbox"
+                  checked={events.includes(value)}
+                  onChange={() => toggleEvent(value)}
+                />
+                {label}
+              </label>
+            ))}
+          </div>
+          {create.isError && (
+            <p className="text-sm text-destructive">
+              Could not add the webhook. Check the URL and try again.
+            </p>
+          )}
+          <Button
+            type="submit"
+            size="sm"
+            disabled={!url || events.length === 0 || create.isPending}
+          >
+            Add webhook
+          </Button>
+        </form>
+      </CardContent>
+    </Card>
+  )
+}
@@ -0,0 +1,70 @@
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { Button } from "@/components/ui/button"
+import { Skeleton } from "@/components/ui/skeleton"
+import { linkedAccountsApi } from "@/api/linkedAccounts"
+
+export function SteamLinkCard() {
+  const queryClient = useQueryClient()
+
+  const { data: accounts = [], isLoading } = useQuery({
+    queryKey: ["me", "linked-accounts"],
+    queryFn: async () => (await linkedAccountsApi.list()).data.accounts,
+  })
+  const steam = accounts.find((a) => a.provider === "steam")
+
+  const start = useMutation({
+    mutationFn: linkedAccountsApi.startSteamLink,
+    onSuccess: (res) => {
+      window.location.href = res.data.redirect_url
+    },
+  })
+
+  const unlink = useMutation({
+    mutationFn: () => linkedAccountsApi.unlink("steam"),
+    onSuccess: () =>
+      queryClient.invalidateQueries({ queryKey: ["me", "linked-accounts"] }),
+  })
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="text-sm font-medium">Steam</CardTitle>
+        <CardDescription>
+          Link your Steam account by signing in with Steam. GSHUB only receives
+          your public Steam ID.
+        </CardDescription>
+      </CardHeader>
+      <CardContent>
+        {isLoading ? (
+          <Skeleton className="h-4 w-48" />
+        ) : steam ? (
+          <div className="flex items-center justify-between gap-2">
+            <p className="text-sm">
+              Linked to Steam ID{" "}
+              <span className="font-mono">{steam.external_id}</span>
+            </p>
+            <Button
+              size="sm"
+              variant="outline"
+              onClick={() => unlink.mutate()}
+              disabled={unlink.isPending}
+            >
+              Unlink
+            </Button>
+          </div>
+        ) : (
+          <Button size="sm" onClick={() => start.mutate()} disabled={start.isPending}>
+            Sign in with Steam
+          </Button>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
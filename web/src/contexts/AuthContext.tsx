@@ -6,13 +6,15 @@ import {
   useRef,
   type ReactNode,
 } from "react"
-import { authApi, type User } from "@/api/auth"
+import { authApi, type AuthResponse, type User } from "@/api/auth"
 
 interface AuthContextType {
   user: User | null
   isLoading: boolean
   isAuthenticated: boolean
-  login: (email: string, password: string) => Promise<void>
+  // Resolves to an MFA token when the user must still enter a two-factor code
+  login: (email: string, password: string) => Promise<string | null>
+  loginMFA: (mfaToken: string, code: string) => Promise<void>
   logout: () => Promise<void>
   register: (email: string, password: string) => Promise<void>
   refreshUser: () => Promise<void>
@@ -50,11 +52,24 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     }
   }, [refreshUser])
 
+  const storeSession = (data: AuthResponse) => {
+    localStorage.setItem("access_token", data.access_token)
+    localStorage.setItem("refresh_token", data.refresh_token)
+    setUser(data.user)
+  }
+
   const login = async (email: string, password: string) => {
     const res = await authApi.login(email, password)
-    localStorage.setItem("access_token", res.data.access_token)
-    localStorage.setItem("refresh_token", res.data.refresh_token)
-    setUser(res.data.user)
+    if ("mfa_required" in res.data) {
+      return res.data.mfa_token
+    }
+    storeSession(res.data)
+    return null
+  }
+
+  const loginMFA = async (mfaToken: string, code: string) => {
+    const res = await authApi.loginMFA(mfaToken, code)
+    storeSession(res.data)
   }
 
   const logout = async () => {
@@ -84,6 +99,7 @@ export function AuthProvider({ children }: { children: ReactNode }) {
         isLoading,
         isAuthenticated: !!user,
         login,
+        loginMFA,
         logout,
         register,
         refreshUser,
@@ -55,11 +55,13 @@ export function ServerDetailProvider({ children }: { children: ReactNode }) {
   const updateEnv = useMutation({
     mutationFn: (envOverrides: Record<string, string>) =>
       serversApi.updateEnv(id!, envOverrides),
-    onSuccess: () => {
+    onSuccess: (response) => {
       queryClient.invalidateQueries({ queryKey: ["server", id] })
       setEnvUpdateMessage({
         type: "success",
-        text: "Environment variables saved. Restart server for changes to take effect.",
+        text: response.data.restart_required
+          ? "Environment variables saved. Restart server for changes to take effect."
+          : "Environment variables saved.",
       })
       setTimeout(() => setEnvUpdateMessage(null), 5000)
     },
@@ -77,7 +79,8 @@ export function ServerDetailProvider({ children }: { children: ReactNode }) {
   const showLogs =
     server?.status === "running" ||
     server?.status === "starting" ||
-    server?.status === "stopping"
+    server?.status === "stopping" ||
+    server?.status === "failed"
 
   const { logs, isConnected, error: logError, clearLogs } = useServerLogs(id, showLogs)
 
@@ -1,5 +1,5 @@
 import { useQuery, useMutation, useQueryClient } from "@tanstack/react-query"
-import { billingApi } from "@/api/billing"
+import { billingApi, type UpdateSpendLimitRequest } from "@/api/billing"
 
 export function useBilling() {
   return useQuery({
@@ -36,11 +36,73 @@ export function useResumeSubscription() {
 }
 
 export function useResubscribe() {
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: ({ serverId, useSavedCard = false }: { serverId: string; useSavedCard?: boolean }) =>
+      billingApi.resubscribe(serverId, useSavedCard),
+    onSuccess: (response) => {
+      if (response.data.checkout_url) {
+        // Redirect to Stripe checkout
+        window.location.href = response.data.checkout_url
+        return
+      }
+      // Paid with the saved card, the server is already reactivated
+      queryClient.invalidateQueries({ queryKey: ["billing"] })
+      queryClient.invalidateQueries({ queryKey: ["servers"] })
+    },
+  })
+}
+
+export function usePaymentMethod() {
+  return useQuery({
+    queryKey: ["billing", "payment-method"],
+    queryFn: async () => {
+      const res = await billingApi.getPaymentMethod()
+      return res.data.card
+    },
+  })
+}
+
+export function useOpenBillingPortal() {
   return useMutation({
-    mutationFn: (serverId: string) => billingApi.resubscribe(serverId),
+    mutationFn: () => billingApi.createPortalSession(),
     onSuccess: (response) => {
-      // Redirect to Stripe checkout
-      window.location.href = response.data.checkout_url
+      // Redirect to the Stripe customer portal to update the saved card
+      window.location.href = response.data.url
+    },
+  })
+}
+
+export function useSetAutoCancel() {
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: ({ serverId, enabled }: { serverId: string; enabled: boolean }) =>
+      billingApi.setAutoCancel(serverId, enabled),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ["billing"] })
+    },
+  })
+}
+
+export function useSpendLimit() {
+  return useQuery({
+    queryKey: ["billing", "spend-limit"],
+    queryFn: async () => {
+      const res = await billingApi.getSpendLimit()
+      return res.data
+    },
+  })
+}
+
+export function useUpdateSpendLimit() {
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: (data: UpdateSpendLimitRequest) => billingApi.updateSpendLimit(data),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ["billing", "spend-limit"] })
     },
   })
 }
@@ -24,3 +24,15 @@ export function useStopServer() {
     },
   })
 }
+
+export function useUpgradeFromOOM() {
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: (id: string) => serversApi.upgradeFromOOM(id),
+    onSuccess: (_, id) => {
+      queryClient.invalidateQueries({ queryKey: ["servers"] })
+      queryClient.invalidateQueries({ queryKey: ["servers", id] })
+    },
+  })
+}
@@ -1,18 +1,25 @@
 import { useState, useEffect, useRef, useCallback } from "react"
-import { createLogStream, type LogEvent } from "@/api/logs"
+import { createLogStream, type LogEvent, type LogStreamOptions } from "@/api/logs"
 
 const MAX_LOG_LINES = 1000
 
-export function useServerLogs(serverId: string | undefined, enabled: boolean = true) {
+export function useServerLogs(
+  serverId: string | undefined,
+  enabled: boolean = true,
+  options: LogStreamOptions = {}
+) {
   const [logs, setLogs] = useState<LogEvent[]>([])
   const [isConnected, setIsConnected] = useState(false)
   const [error, setError] = useState<string | null>(null)
   const eventSourceRef = useRef<EventSource | null>(null)
+  const filterKeyRef = useRef<string | null>(null)
 
   const clearLogs = useCallback(() => {
     setLogs([])
   }, [])
 
+  const { grep, level, since, previous } = options
+
   useEffect(() => {
     if (!serverId || !enabled) {
       return
@@ -22,6 +29,13 @@ export function useServerLogs(serverId: string | undefined, enabled: boolean = t
     setError(null)
     setIsConnected(false)
 
+    // Lines from before a filter change may not match the new filters
+    const filterKey = JSON.stringify({ grep, level, since, previous })
+    if (filterKeyRef.current !== null && filterKeyRef.current !== filterKey) {
+      setLogs([])
+    }
+    filterKeyRef.current = filterKey
+
     const es = createLogStream(serverId, {
       onLog: (log) => {
         setLogs((prev) => {
@@ -44,7 +58,7 @@ export function useServerLogs(serverId: string | undefined, enabled: boolean = t
       onHeartbeat: () => {
         // Keep-alive, no action needed
       },
-    })
+    }, { grep, level, since, previous })
 
     eventSourceRef.current = es
 
@@ -52,7 +66,7 @@ export function useServerLogs(serverId: string | undefined, enabled: boolean = t
       es.close()
       eventSourceRef.current = null
     }
-  }, [serverId, enabled])
+  }, [serverId, enabled, grep, level, since, previous])
 
   return { logs, isConnected, error, clearLogs }
 }
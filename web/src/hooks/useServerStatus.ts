@@ -1,6 +1,12 @@
 import { useEffect, useRef, useState } from "react"
 import { useQueryClient } from "@tanstack/react-query"
-import { createStatusStream, type StatusEvent, type ConnectedEvent } from "@/api/status"
+import {
+  createStatusStream,
+  type StatusEvent,
+  type ConnectedEvent,
+  type IncidentEvent,
+  type ProgressEvent,
+} from "@/api/status"
 import type { ServerDetailResponse, ServerStatus } from "@/api/servers"
 
 interface UseServerStatusOptions {
@@ -11,6 +17,10 @@ export function useServerStatus(options: UseServerStatusOptions = {}) {
   const { enabled = true } = options
   const [isConnected, setIsConnected] = useState(false)
   const [error, setError] = useState<string | null>(null)
+  // Open node incidents affecting the user's servers, keyed by incident ID
+  const [incidents, setIncidents] = useState<Record<string, IncidentEvent>>({})
+  // Latest startup milestone of each starting server, keyed by server ID
+  const [progress, setProgress] = useState<Record<string, ProgressEvent>>({})
   const eventSourceRef = useRef<EventSource | null>(null)
   const queryClient = useQueryClient()
 
@@ -36,11 +46,23 @@ export function useServerStatus(options: UseServerStatusOptions = {}) {
                 ...old.server,
                 status: event.status as ServerStatus,
                 status_message: event.status_message,
+                status_reason: event.status_reason || undefined,
+                startup_phase: event.startup_phase || undefined,
               },
             }
           }
         )
 
+        // Milestones only describe the current start
+        if (event.status !== "starting") {
+          setProgress((prev) => {
+            if (!(event.server_id in prev)) return prev
+            const next = { ...prev }
+            delete next[event.server_id]
+            return next
+          })
+        }
+
         // Also invalidate the servers list query to refresh totals
         queryClient.invalidateQueries({ queryKey: ["servers"], exact: true })
       },
@@ -60,6 +82,7 @@ export function useServerStatus(options: UseServerStatusOptions = {}) {
                   ...old.server,
                   status: server.status as ServerStatus,
                   status_message: server.status_message,
+                  status_reason: server.status_reason || undefined,
                 },
               }
             }
@@ -70,6 +93,20 @@ export function useServerStatus(options: UseServerStatusOptions = {}) {
         setError(err.message)
         setIsConnected(false)
       },
+      onIncident: (incident: IncidentEvent) => {
+        setIncidents((prev) => {
+          const next = { ...prev }
+          if (incident.state === "open") {
+            next[incident.incident_id] = incident
+          } else {
+            delete next[incident.incident_id]
+          }
+          return next
+        })
+      },
+      onProgress: (event: ProgressEvent) => {
+        setProgress((prev) => ({ ...prev, [event.server_id]: event }))
+      },
       onHeartbeat: () => {
         // Keep-alive, no action needed
       },
@@ -83,5 +120,5 @@ export function useServerStatus(options: UseServerStatusOptions = {}) {
     }
   }, [enabled, queryClient])
 
-  return { isConnected, error }
+  return { isConnected, error, incidents: Object.values(incidents), progress }
 }
@@ -23,7 +23,7 @@ export const GAMES: Record<
     id: "minecraft",
     name: "Minecraft: Java Edition",
     description: "Build, explore, and survive in a blocky world",
-    plans: ["small", "medium", "large"],
+    plans: ["budget", "small", "medium", "large", "dedicated"],
   },
   valheim: {
     id: "valheim",
@@ -68,6 +68,22 @@ export const PLANS: Record<
     memory: "8 GB",
     price: "$20/mo",
   },
+  dedicated: {
+    id: "dedicated",
+    name: "Dedicated",
+    players: "50+",
+    cpu: "7 vCPU",
+    memory: "28 GB",
+    price: "$60/mo",
+  },
+  budget: {
+    id: "budget",
+    name: "Budget",
+    players: "2-5",
+    cpu: "1 vCPU",
+    memory: "2 GB",
+    price: "$3/mo",
+  },
 }
 
 export interface EnvVarDefinition {
@@ -9,10 +9,12 @@ import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card"
 export function LoginPage() {
   const [email, setEmail] = useState("")
   const [password, setPassword] = useState("")
+  const [mfaToken, setMFAToken] = useState<string | null>(null)
+  const [code, setCode] = useState("")
   const [error, setError] = useState("")
   const [isLoading, setIsLoading] = useState(false)
 
-  const { login } = useAuth()
+  const { login, loginMFA } = useAuth()
   const navigate = useNavigate()
   const location = useLocation()
 
@@ -24,8 +26,26 @@ export function LoginPage() {
     setError("")
     setIsLoading(true)
 
+    if (mfaToken) {
+      loginMFA(mfaToken, code)
+        .then(() => {
+          navigate(from, { replace: true })
+        })
+        .catch(() => {
+          setError("Invalid or expired code")
+          setIsLoading(false)
+        })
+      return
+    }
+
     login(email, password)
-      .then(() => {
+      .then((token) => {
+        if (token) {
+          // Two-factor authentication: ask for the code next
+          setMFAToken(token)
+          setIsLoading(false)
+          return
+        }
         navigate(from, { replace: true })
       })
       .catch(() => {
@@ -34,6 +54,57 @@ export function LoginPage() {
       })
   }
 
+  if (mfaToken) {
+    return (
+      <Card>
+        <CardHeader className="space-y-1">
+          <CardTitle className="text-xl">Two-factor authentication</CardTitle>
+        </CardHeader>
+        <CardContent>
+          <form onSubmit={handleSubmit} className="space-y-4">
+            {error && (
+              <div className="rounded-md border border-destructive/50 bg-destructive/10 px-4 py-3 text-sm text-destructive">
+                {error}
+              </div>
+            )}
+
+            <div className="space-y-2">
+              <Label htmlFor="code">
+                Code from your authenticator app, or a backup code
+              </Label>
+              <Input
+                id="code"
+                value={code}
+                onChange={(e) => setCode(e.target.value)}
+                required
+                autoFocus
+                autoComplete="one-time-code"
+              />
+            </div>
+
+            <Button type="submit" className="w-full" disabled={isLoading}>
+              {isLoading ? "Verifying..." : "Verify"}
+            </Button>
+
+            <div className="text-center text-sm text-muted-foreground">
+              <button
+                type="button"
+                onClick={() => {
+                  setMFAToken(null)
+                  setCode("")
+                  setError("")
+                }}
+                className="hover:text-foreground underline"
+              >
+                Back to sign in
+              </button>
+            </div>
+          </form>
+        </CardContent>
+      </Card>
+    )
+  }
+
   return (
     <Card>
       <CardHeader className="space-y-1">
@@ -0,0 +1,70 @@
+import { useEffect, useState } from "react"
+import { useParams } from "react-router-dom"
+import { devStripeApi, type MockCheckoutSession } from "@/api/devStripe"
+import { Button } from "@/components/ui/button"
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card"
+import { Skeleton } from "@/components/ui/skeleton"
+
+// Stand-in for the Stripe-hosted checkout page when the API runs in mock mode
+export function MockCheckoutPage() {
+  const { sessionId } = useParams<{ sessionId: string }>()
+  const [session, setSession] = useState<MockCheckoutSession | null>(null)
+  const [error, setError] = useState<string | null>(null)
+  const [completing, setCompleting] = useState(false)
+
+  useEffect(() => {
+    if (!sessionId) return
+    devStripeApi
+      .getCheckoutSession(sessionId)
+      .then((res) => setSession(res.data))
+      .catch(() => setError("Checkout session not found."))
+  }, [sessionId])
+
+  const handleComplete = async () => {
+    if (!sessionId) return
+    setCompleting(true)
+    try {
+      const res = await devStripeApi.completeCheckoutSession(sessionId)
+      window.location.href = res.data.success_url
+    } catch {
+      setError("Failed to complete payment.")
+      setCompleting(false)
+    }
+  }
+
+  return (
+    <div className="mx-auto max-w-md py-12">
+      <Card>
+        <CardHeader className="space-y-1">
+          <CardTitle className="text-xl">Test checkout</CardTitle>
+        </CardHeader>
+        <CardContent className="space-y-4">
+          <p className="text-sm text-muted-foreground">
+            Stripe is running in mock mode. No real payment will be made.
+          </p>
+          {error && <p className="text-sm text-destructive">{error}</p>}
+          {!session && !error && <Skeleton className="h-4 w-full" />}
+          {session && (
+            <>
+              <p className="font-mono text-xs text-muted-foreground">{session.session_id}</p>
+              <Button
+                className="w-full"
+                onClick={handleComplete}
+                disabled={completing || session.payment_status === "paid"}
+              >
+                {completing ? "Completing..." : "Complete payment"}
+              </Button>
+              <Button
+                variant="outline"
+                className="w-full"
+                onClick={() => (window.location.href = session.cancel_url)}
+              >
+                Cancel
+              </Button>
+            </>
+          )}
+        </CardContent>
+      </Card>
+    </div>
+  )
+}
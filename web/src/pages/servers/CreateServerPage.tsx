@@ -1,7 +1,10 @@
 import { useState, useMemo, useEffect } from "react"
 import { Link, useSearchParams, useNavigate, useLocation } from "react-router-dom"
 import { Search, ChevronDown, Cpu, MemoryStick, Users } from "lucide-react"
+import { useQuery } from "@tanstack/react-query"
 import { serversApi, type GameType, type ServerPlan } from "@/api/servers"
+import { templatesApi } from "@/api/templates"
+import { getApiError } from "@/api/client"
 import { useAuth } from "@/hooks/useAuth"
 import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
@@ -46,6 +49,16 @@ const PLAN_COLORS: Record<ServerPlan, { border: string; bg: string; badge: strin
     bg: "bg-gradient-to-br from-yellow-900/30 to-yellow-800/10",
     badge: "bg-yellow-600/30 text-yellow-200",
   },
+  dedicated: {
+    border: "border-cyan-500/50 hover:border-cyan-400",
+    bg: "bg-gradient-to-br from-cyan-900/30 to-cyan-800/10",
+    badge: "bg-cyan-600/30 text-cyan-200",
+  },
+  budget: {
+    border: "border-emerald-700/50 hover:border-emerald-600",
+    bg: "bg-gradient-to-br from-emerald-950/30 to-emerald-900/10",
+    badge: "bg-emerald-700/30 text-emerald-300",
+  },
 }
 
 export function CreateServerPage() {
@@ -61,6 +74,21 @@ export function CreateServerPage() {
   const [isDropdownOpen, setIsDropdownOpen] = useState(false)
   const [search, setSearch] = useState("")
 
+  // ?template=<id> creates the server from a saved template's game, plan, env, mods and schedules
+  const templateId = searchParams.get("template")
+  const { data: template } = useQuery({
+    queryKey: ["templates", templateId],
+    queryFn: async () => (await templatesApi.get(templateId!)).data.template,
+    enabled: !!templateId && isAuthenticated,
+  })
+
+  useEffect(() => {
+    if (template) {
+      setSelectedGame(template.game)
+      setSelectedPlan(template.plan)
+    }
+  }, [template])
+
   // Restore from session storage or URL params on mount
   useEffect(() => {
     const pending = sessionStorage.getItem(SESSION_KEY)
@@ -122,17 +150,23 @@ export function CreateServerPage() {
 
     try {
       const subdomain = generateSubdomain(finalDisplayName)
-      const response = await serversApi.checkout(
-        finalDisplayName,
-        subdomain,
-        selectedGame,
-        selectedPlan
-      )
-      window.location.href = response.data.checkout_url
+      const response = template
+        ? await templatesApi.createServer(template.id, finalDisplayName, subdomain)
+        : await serversApi.checkout(finalDisplayName, subdomain, selectedGame, selectedPlan)
+      if (response.data.server_id) {
+        navigate(`/servers/${response.data.server_id}`)
+      } else if (response.data.checkout_url) {
+        window.location.href = response.data.checkout_url
+      }
     } catch (err) {
-      if (err instanceof Error && "response" in err) {
-        const axiosError = err as { response?: { data?: { error?: string } } }
-        setError(axiosError.response?.data?.error || "Failed to create server")
+      const apiError = getApiError(err)
+      if (apiError?.code === "SUBDOMAIN_TAKEN") {
+        setError("That server name is already taken. Please choose another.")
+      } else if (apiError?.code === "VALIDATION_FAILED" && apiError.details) {
+        const [field, message] = Object.entries(apiError.details as Record<string, string>)[0]
+        setError(`${field.replace("_", " ")} ${message}`)
+      } else if (apiError) {
+        setError(apiError.message || "Failed to create server")
       } else {
         setError("Failed to create server. Please try again.")
       }
@@ -171,6 +205,17 @@ export function CreateServerPage() {
         </p>
       </div>
 
+      {template && (
+        <div className="rounded-md border px-4 py-3 text-sm">
+          Creating from template <span className="font-medium">{template.name}</span>.
+          Its game, plan, environment variables and scheduled tasks will be used, and
+          its mods can be installed once the server is running.{" "}
+          <Link to="/servers/new" className="underline">
+            Start from scratch
+          </Link>
+        </div>
+      )}
+
       {error && (
         <div className="rounded-md border border-destructive/50 bg-destructive/10 px-4 py-3 text-sm text-destructive">
           {error}
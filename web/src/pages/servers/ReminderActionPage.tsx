@@ -0,0 +1,118 @@
+import { useState } from "react"
+import { Link, useParams, useSearchParams } from "react-router-dom"
+import { Gamepad2 } from "lucide-react"
+import { billingApi, type ReminderCancelResponse } from "@/api/billing"
+import { getApiError } from "@/api/client"
+import { Button } from "@/components/ui/button"
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card"
+
+function formatDate(dateString: string): string {
+  return new Date(dateString).toLocaleDateString("en-US", {
+    year: "numeric",
+    month: "long",
+    day: "numeric",
+  })
+}
+
+// Cancels a stopped server's subscription from the signed link in a reminder email.
+// The cancel waits for a click so link scanners that open the page don't cancel it.
+export function ReminderActionPage() {
+  const { id } = useParams<{ id: string }>()
+  const [searchParams] = useSearchParams()
+  const expires = Number(searchParams.get("expires"))
+  const signature = searchParams.get("signature")
+
+  const [status, setStatus] = useState<"idle" | "loading" | "success" | "error">(
+    id && expires && signature ? "idle" : "error"
+  )
+  const [result, setResult] = useState<ReminderCancelResponse | null>(null)
+  const [error, setError] = useState("This cancel link is invalid or has expired.")
+
+  const handleCancel = () => {
+    if (!id || !signature) return
+    setStatus("loading")
+    billingApi
+      .cancelFromReminder(id, expires, signature)
+      .then((response) => {
+        setResult(response.data)
+        setStatus("success")
+      })
+      .catch((err) => {
+        const apiError = getApiError(err)
+        if (apiError && apiError.code !== "FORBIDDEN") {
+          setError(apiError.message)
+        }
+        setStatus("error")
+      })
+  }
+
+  return (
+    <div className="min-h-screen bg-background">
+      <header className="border-b border-border/30 bg-background/50 backdrop-blur-sm">
+        <div className="container mx-auto flex h-14 items-center px-4">
+          <Link to="/" className="flex items-center gap-2 text-lg font-semibold text-foreground">
+            <Gamepad2 className="h-6 w-6" />
+            GSHUB
+          </Link>
+        </div>
+      </header>
+      <div className="flex min-h-[calc(100vh-3.5rem)] items-center justify-center p-4">
+        <div className="w-full max-w-sm">
+          {status === "error" ? (
+            <Card>
+              <CardHeader className="space-y-1">
+                <CardTitle className="text-xl">Couldn't cancel subscription</CardTitle>
+              </CardHeader>
+              <CardContent className="space-y-4">
+                <p className="text-sm text-muted-foreground">{error}</p>
+                <Link to="/settings/billing">
+                  <Button variant="outline" className="w-full">
+                    Manage billing
+                  </Button>
+                </Link>
+              </CardContent>
+            </Card>
+          ) : status === "success" && result ? (
+            <Card>
+              <CardHeader className="space-y-1">
+                <CardTitle className="text-xl">Subscription cancelled</CardTitle>
+              </CardHeader>
+              <CardContent className="space-y-4">
+                <p className="text-sm text-muted-foreground">
+                  The subscription for <strong>{result.display_name}</strong> ends on{" "}
+                  {formatDate(result.current_period_end)}. You can resume it from your billing
+                  settings until then.
+                </p>
+                <Link to="/settings/billing">
+                  <Button variant="outline" className="w-full">
+                    Manage billing
+                  </Button>
+                </Link>
+              </CardContent>
+            </Card>
+          ) : (
+            <Card>
+              <CardHeader className="space-y-1">
+                <CardTitle className="text-xl">Cancel subscription?</CardTitle>
+              </CardHeader>
+              <CardContent className="space-y-4">
+                <p className="text-sm text-muted-foreground">
+                  Your server keeps running until the end of the current billing period, then it
+                  expires.
+                </p>
+                <Button
+                  variant="destructive"
+                  className="w-full"
+                  disabled={status === "loading"}
+                  onClick={handleCancel}
+                >
+                  {status === "loading" ? "Cancelling..." : "Cancel subscription"}
+                </Button>
+              </CardContent>
+            </Card>
+          )}
+        </div>
+      </div>
+    </div>
+  )
+}
@@ -1,5 +1,10 @@
 import { useServerDetail } from "@/contexts/ServerDetailContext"
 import { EnvEditor } from "@/components/servers/EnvEditor"
+import { PendingChangesCard } from "@/components/servers/PendingChangesCard"
+import { EnvHistoryCard } from "@/components/servers/EnvHistoryCard"
+import { WebhooksCard } from "@/components/servers/WebhooksCard"
+import { SaveTemplateCard } from "@/components/servers/SaveTemplateCard"
+import { SteamTokenCard } from "@/components/servers/SteamTokenCard"
 import { Alert, AlertDescription } from "@/components/ui/alert"
 import { Skeleton } from "@/components/ui/skeleton"
 
@@ -29,6 +34,8 @@ export function ServerConfigurationTab() {
         </Alert>
       )}
 
+      <PendingChangesCard serverId={server.id} />
+
       <EnvEditor
         game={server.game}
         gameConfig={gameConfig ?? undefined}
@@ -37,6 +44,20 @@ export function ServerConfigurationTab() {
         }}
         disabled={updateEnv.isPending}
       />
+
+      {gameConfig?.steam_token_env && (
+        <SteamTokenCard
+          serverId={server.id}
+          envKey={gameConfig.steam_token_env}
+          isSet={!!server.env_overrides?.[gameConfig.steam_token_env]}
+        />
+      )}
+
+      <EnvHistoryCard serverId={server.id} />
+
+      <WebhooksCard serverId={server.id} />
+
+      <SaveTemplateCard serverId={server.id} />
     </div>
   )
 }
@@ -1,7 +1,9 @@
 import { Link } from "react-router-dom"
-import { Settings } from "lucide-react"
+import { MapPin, Settings } from "lucide-react"
 import { useServerDetail } from "@/contexts/ServerDetailContext"
+import { getLogDownloadUrl } from "@/api/logs"
 import { ServerConsole } from "@/components/servers/ServerConsole"
+import { EgressUsageBox } from "@/components/servers/EgressUsageBox"
 import { CopyableText } from "@/components/ui/copyable-text"
 import { Skeleton } from "@/components/ui/skeleton"
 import {
@@ -54,6 +56,13 @@ export function ServerDashboardTab() {
 
   const plan = PLANS[server.plan]
 
+  const location = server.location
+  const locationText = location
+    ? [location.datacenter, location.region || location.zone, location.provider]
+        .filter(Boolean)
+        .join(" · ")
+    : ""
+
   return (
     <TooltipProvider>
       <div className="space-y-6">
@@ -82,7 +91,19 @@ export function ServerDashboardTab() {
 
           {/* IP Address Box */}
           <div className="flex items-center justify-between rounded-lg bg-card/50 border border-border/50 px-4 py-3">
-            <span className="text-sm text-muted-foreground">IP Address</span>
+            <div className="flex items-center gap-2">
+              <span className="text-sm text-muted-foreground">IP Address</span>
+              {locationText && (
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <MapPin className="h-4 w-4 text-muted-foreground" />
+                  </TooltipTrigger>
+                  <TooltipContent>
+                    <p>{locationText}</p>
+                  </TooltipContent>
+                </Tooltip>
+              )}
+            </div>
             {connectionAddress ? (
               <CopyableText value={connectionAddress} className="bg-transparent p-0" />
             ) : (
@@ -93,6 +114,8 @@ export function ServerDashboardTab() {
           </div>
         </div>
 
+        <EgressUsageBox serverId={server.id} />
+
         {/* Console */}
         {showLogs && (
           <ServerConsole
@@ -100,6 +123,7 @@ export function ServerDashboardTab() {
             isConnected={isConnected}
             error={logError}
             onClear={clearLogs}
+            downloadUrl={getLogDownloadUrl(server.id)}
           />
         )}
       </div>
@@ -1,5 +1,5 @@
 import { Link } from "react-router-dom"
-import { useBilling } from "@/hooks/useBilling"
+import { useBilling, useOpenBillingPortal } from "@/hooks/useBilling"
 import { SubscriptionCard } from "@/components/billing/SubscriptionCard"
 import { Card, CardContent } from "@/components/ui/card"
 import { Skeleton } from "@/components/ui/skeleton"
@@ -7,14 +7,27 @@ import { Button } from "@/components/ui/button"
 
 export function BillingPage() {
   const { data: subscriptions, isLoading, error } = useBilling()
+  const portalMutation = useOpenBillingPortal()
 
   return (
     <div className="space-y-6">
-      <div>
-        <h1 className="text-xl font-semibold">Billing & Subscriptions</h1>
-        <p className="text-sm text-muted-foreground mt-1">
-          Manage your server subscriptions and billing information
-        </p>
+      <div className="flex items-start justify-between gap-4">
+        <div>
+          <h1 className="text-xl font-semibold">Billing & Subscriptions</h1>
+          <p className="text-sm text-muted-foreground mt-1">
+            Manage your server subscriptions and billing information
+          </p>
+        </div>
+        {subscriptions && subscriptions.length > 0 && (
+          <Button
+            variant="outline"
+            size="sm"
+            onClick={() => portalMutation.mutate()}
+            disabled={portalMutation.isPending}
+          >
+            {portalMutation.isPending ? "Opening..." : "Update Payment Method"}
+          </Button>
+        )}
       </div>
 
       {isLoading && (
@@ -0,0 +1,117 @@
+import { useState } from "react"
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { Button } from "@/components/ui/button"
+import { CopyableText } from "@/components/ui/copyable-text"
+import { Skeleton } from "@/components/ui/skeleton"
+import { SteamLinkCard } from "@/components/settings/SteamLinkCard"
+import { discordApi } from "@/api/discord"
+
+export function IntegrationsPage() {
+  const queryClient = useQueryClient()
+  const [linkCode, setLinkCode] = useState<{ code: string; expires_at: string } | null>(null)
+
+  const { data: link, isLoading, isError } = useQuery({
+    queryKey: ["me", "discord"],
+    queryFn: async () => (await discordApi.getLink()).data.link,
+    retry: false,
+  })
+
+  const createCode = useMutation({
+    mutationFn: discordApi.createLinkCode,
+    onSuccess: (res) => setLinkCode(res.data),
+  })
+
+  const unlink = useMutation({
+    mutationFn: discordApi.unlink,
+    onSuccess: () => {
+      setLinkCode(null)
+      queryClient.invalidateQueries({ queryKey: ["me", "discord"] })
+    },
+  })
+
+  return (
+    <div className="space-y-6">
+      <div>
+        <h1 className="text-xl font-semibold">Integrations</h1>
+        <p className="text-sm text-muted-foreground mt-1">
+          Connect other apps to your GSHUB account
+        </p>
+      </div>
+
+      <Card>
+        <CardHeader>
+          <CardTitle className="text-sm font-medium">Discord</CardTitle>
+          <CardDescription>
+            Start, stop and check your servers from Discord with{" "}
+            <code>/server start</code>, <code>/server stop</code> and{" "}
+            <code>/server status</code>.
+          </CardDescription>
+        </CardHeader>
+        <CardContent className="space-y-4">
+          {isLoading && <Skeleton className="h-4 w-48" />}
+
+          {isError && (
+            <p className="text-sm text-muted-foreground">
+              The Discord integration isn't available right now.
+            </p>
+          )}
+
+          {!isLoading && !isError && link && (
+            <div className="flex items-center justify-between gap-2">
+              <p className="text-sm">
+                Linked to <span className="font-medium">{link.discord_username}</span>
+              </p>
+              <Button
+                size="sm"
+                variant="outline"
+                onClick={() => unlink.mutate()}
+                disabled={unlink.isPending}
+              >
+                Unlink
+              </Button>
+            </div>
+          )}
+
+          {!isLoading && !isError && !link && (
+            <div className="space-y-3">
+              {linkCode ? (
+                <>
+                  <p className="text-sm">
+                    Run this command in Discord within 10 minutes:
+                  </p>
+                  <CopyableText value={`/link code:${linkCode.code}`} />
+                  <Button
+                    size="sm"
+                    variant="ghost"
+                    onClick={() =>
+                      queryClient.invalidateQueries({ queryKey: ["me", "discord"] })
+                    }
+                  >
+                    I've linked my account
+                  </Button>
+                </>
+              ) : (
+                <Button
+                  size="sm"
+                  onClick={() => createCode.mutate()}
+                  disabled={createCode.isPending}
+                >
+                  Link Discord account
+                </Button>
+              )}
+            </div>
+          )}
+        </CardContent>
+      </Card>
+
+      <SteamLinkCard />
+    </div>
+  )
+}
@@ -0,0 +1,42 @@
+import { useEffect, useRef } from "react"
+import { Link, useNavigate } from "react-router-dom"
+import { useMutation } from "@tanstack/react-query"
+import { Card, CardContent } from "@/components/ui/card"
+import { Button } from "@/components/ui/button"
+import { linkedAccountsApi } from "@/api/linkedAccounts"
+
+// SteamCallbackPage is where Steam redirects after sign-in; it hands the assertion to the API
+export function SteamCallbackPage() {
+  const navigate = useNavigate()
+  const sent = useRef(false)
+
+  const verify = useMutation({
+    mutationFn: linkedAccountsApi.verifySteamLink,
+    onSuccess: () => navigate("/settings/integrations", { replace: true }),
+  })
+
+  useEffect(() => {
+    if (sent.current) return
+    sent.current = true
+    verify.mutate(window.location.search)
+  }, [verify])
+
+  return (
+    <Card>
+      <CardContent className="p-6 text-center space-y-3">
+        {verify.isError ? (
+          <>
+            <p className="text-sm text-muted-foreground">
+              Couldn't link your Steam account. Please try again.
+            </p>
+            <Button asChild size="sm" variant="outline">
+              <Link to="/settings/integrations">Back to integrations</Link>
+            </Button>
+          </>
+        ) : (
+          <p className="text-sm text-muted-foreground">Linking your Steam account...</p>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
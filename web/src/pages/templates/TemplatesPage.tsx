@@ -0,0 +1,129 @@
+import { Link } from "react-router-dom"
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query"
+import { Trash2 } from "lucide-react"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import { Skeleton } from "@/components/ui/skeleton"
+import { templatesApi, type ServerTemplate } from "@/api/templates"
+
+function TemplateRow({
+  template,
+  onDelete,
+  onToggleShare,
+}: {
+  template: ServerTemplate
+  onDelete?: () => void
+  onToggleShare?: () => void
+}) {
+  return (
+    <div className="flex items-center justify-between gap-3 rounded-md border p-3">
+      <div className="min-w-0 space-y-1">
+        <div className="flex items-center gap-2">
+          <p className="truncate text-sm font-medium">{template.name}</p>
+          <Badge variant="secondary">{template.game}</Badge>
+          <Badge variant="outline">{template.plan}</Badge>
+          {template.is_owner && template.is_public && <Badge>Shared</Badge>}
+        </div>
+        {(template.mods.length > 0 || template.schedules.length > 0) && (
+          <p className="text-xs text-muted-foreground">
+            {template.mods.length} mod{template.mods.length === 1 ? "" : "s"},{" "}
+            {template.schedules.length} scheduled task{template.schedules.length === 1 ? "" : "s"}
+          </p>
+        )}
+        {template.description && (
+          <p className="truncate text-xs text-muted-foreground">{template.description}</p>
+        )}
+      </div>
+      <div className="flex shrink-0 items-center gap-1">
+        {onToggleShare && (
+          <Button size="sm" variant="ghost" onClick={onToggleShare}>
+            {template.is_public ? "Unshare" : "Share"}
+          </Button>
+        )}
+        {onDelete && (
+          <Button size="sm" variant="ghost" onClick={onDelete}>
+            <Trash2 className="h-4 w-4" />
+          </Button>
+        )}
+        <Button asChild size="sm">
+          <Link to={`/servers/new?template=${template.id}`}>Use</Link>
+        </Button>
+      </div>
+    </div>
+  )
+}
+
+export function TemplatesPage() {
+  const queryClient = useQueryClient()
+
+  const mine = useQuery({
+    queryKey: ["templates", "mine"],
+    queryFn: async () => (await templatesApi.list()).data.templates,
+  })
+  const shared = useQuery({
+    queryKey: ["templates", "public"],
+    queryFn: async () => (await templatesApi.list(true)).data.templates,
+  })
+
+  const invalidate = () => queryClient.invalidateQueries({ queryKey: ["templates"] })
+  const remove = useMutation({ mutationFn: templatesApi.delete, onSuccess: invalidate })
+  const toggleShare = useMutation({
+    mutationFn: (t: ServerTemplate) => templatesApi.update(t.id, { is_public: !t.is_public }),
+    onSuccess: invalidate,
+  })
+
+  return (
+    <div className="space-y-6">
+      <div>
+        <h1 className="text-xl font-semibold">Templates</h1>
+        <p className="text-sm text-muted-foreground mt-1">
+          Create servers from saved configurations. Save a template from a
+          server's Configuration tab.
+        </p>
+      </div>
+
+      <Card>
+        <CardHeader>
+          <CardTitle className="text-sm font-medium">Your templates</CardTitle>
+        </CardHeader>
+        <CardContent className="space-y-2">
+          {mine.isLoading && <Skeleton className="h-12 w-full" />}
+          {mine.data?.length === 0 && (
+            <p className="text-sm text-muted-foreground">No templates yet.</p>
+          )}
+          {mine.data?.map((template) => (
+            <TemplateRow
+              key={template.id}
+              template={template}
+              onDelete={() => remove.mutate(template.id)}
+              onToggleShare={() => toggleShare.mutate(template)}
+            />
+          ))}
+        </CardContent>
+      </Card>
+
+      <Card>
+        <CardHeader>
+          <CardTitle className="text-sm font-medium">Shared templates</CardTitle>
+          <CardDescription>Templates other users made public</CardDescription>
+        </CardHeader>
+        <CardContent className="space-y-2">
+          {shared.isLoading && <Skeleton className="h-12 w-full" />}
+          {shared.data?.filter((t) => !t.is_owner).length === 0 && (
+            <p className="text-sm text-muted-foreground">No shared templates yet.</p>
+          )}
+          {shared.data
+            ?.filter((t) => !t.is_owner)
+            .map((template) => <TemplateRow key={template.id} template={template} />)}
+        </CardContent>
+      </Card>
+    </div>
+  )
+}